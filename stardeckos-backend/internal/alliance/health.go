@@ -0,0 +1,168 @@
+package alliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// healthClient makes the health check requests; each step has its own
+// timeout so one slow endpoint doesn't hide the others' latency
+var healthClient = &http.Client{Timeout: 10 * time.Second}
+
+// discoveryDocument is the part of an OpenID discovery document the health
+// check needs
+type discoveryDocument struct {
+	Issuer        string `json:"issuer"`
+	JWKSURI       string `json:"jwks_uri"`
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+// CheckOIDCHealth checks an OIDC provider the way a sign-in would use it: it
+// fetches the discovery document, the signing keys it points to, and probes
+// the token endpoint with an authorization code that can't be valid, so no
+// token is ever issued. A refusal of the client's credentials is reported as
+// an error, since sign-ins would fail the same way.
+func CheckOIDCHealth(ctx context.Context, config *models.OIDCConfig) models.ProviderHealthCheck {
+	check := models.ProviderHealthCheck{CheckedAt: time.Now()}
+	var problems []string
+
+	var doc discoveryDocument
+	issuer := strings.TrimSuffix(config.IssuerURL, "/")
+	check.DiscoveryMS, check.DiscoveryOK = timeStep(func() error {
+		res, err := healthGet(ctx, issuer+"/.well-known/openid-configuration")
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(res, &doc); err != nil {
+			return fmt.Errorf("invalid discovery document: %w", err)
+		}
+		// go-oidc refuses a provider whose document names another issuer
+		if strings.TrimSuffix(doc.Issuer, "/") != issuer {
+			return fmt.Errorf("discovery document names issuer %q", doc.Issuer)
+		}
+		return nil
+	}, "discovery", &problems)
+	if !check.DiscoveryOK {
+		check.Error = strings.Join(problems, "; ")
+		return check
+	}
+
+	check.JWKSMS, check.JWKSOK = timeStep(func() error {
+		if doc.JWKSURI == "" {
+			return fmt.Errorf("discovery document has no jwks_uri")
+		}
+		res, err := healthGet(ctx, doc.JWKSURI)
+		if err != nil {
+			return err
+		}
+		var keys struct {
+			Keys []json.RawMessage `json:"keys"`
+		}
+		if err := json.Unmarshal(res, &keys); err != nil {
+			return fmt.Errorf("invalid key set: %w", err)
+		}
+		check.JWKSKeys = len(keys.Keys)
+		if check.JWKSKeys == 0 {
+			return fmt.Errorf("key set is empty")
+		}
+		return nil
+	}, "jwks", &problems)
+
+	check.TokenMS, check.TokenOK = timeStep(func() error {
+		if doc.TokenEndpoint == "" {
+			return fmt.Errorf("discovery document has no token_endpoint")
+		}
+		return probeTokenEndpoint(ctx, doc.TokenEndpoint, config, &problems)
+	}, "token endpoint", &problems)
+
+	check.Healthy = len(problems) == 0
+	check.Error = strings.Join(problems, "; ")
+	return check
+}
+
+// timeStep runs one step of a health check, returning how long it took and
+// whether it worked; a failure is added to problems under the step's name
+func timeStep(step func() error, name string, problems *[]string) (int64, bool) {
+	start := time.Now()
+	err := step()
+	elapsed := time.Since(start).Milliseconds()
+	if err != nil {
+		*problems = append(*problems, name+": "+err.Error())
+		return elapsed, false
+	}
+	return elapsed, true
+}
+
+// healthGet fetches a URL and returns its body, failing on any status but 200
+func healthGet(ctx context.Context, target string) ([]byte, error) {
+	stepCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(stepCtx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := healthClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", target, res.Status)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+// probeTokenEndpoint posts a bogus authorization code to the token endpoint.
+// Any OAuth error is the answer expected; only a server error or no answer
+// means the endpoint is down. A rejected client is recorded in problems, but
+// the endpoint itself still counts as working.
+func probeTokenEndpoint(ctx context.Context, endpoint string, config *models.OIDCConfig, problems *[]string) error {
+	stepCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {"stardeck-health-check-" + generateState()},
+		"redirect_uri": {config.RedirectURI},
+	}
+	if config.ClientSecret == "" {
+		form.Set("client_id", config.ClientID)
+	}
+	req, err := http.NewRequestWithContext(stepCtx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	}
+
+	res, err := healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 500 {
+		return fmt.Errorf("returned %s", res.Status)
+	}
+
+	var oauthErr struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&oauthErr)
+	if oauthErr.Error == "invalid_client" || oauthErr.Error == "unauthorized_client" || res.StatusCode == http.StatusUnauthorized {
+		*problems = append(*problems, "token endpoint: the provider rejected the client credentials")
+	}
+	return nil
+}
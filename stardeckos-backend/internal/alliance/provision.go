@@ -0,0 +1,447 @@
+package alliance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// provisionClient makes the admin API requests to managed IdPs
+var provisionClient = &http.Client{Timeout: 30 * time.Second}
+
+// ClientSpec is a client to create in a managed IdP
+type ClientSpec struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURIs []string
+	Scopes       []string
+}
+
+// ProvisionClient creates a client in a managed IdP with the credentials
+// Stardeck generated, so the app can use them without anyone touching the
+// IdP's console
+func ProvisionClient(ctx context.Context, config *models.ManagedIdPConfig, spec ClientSpec) (*models.ProvisionedClient, error) {
+	if len(spec.Scopes) == 0 {
+		spec.Scopes = []string{"openid", "email", "profile"}
+	}
+	var (
+		remote *models.ProvisionedClient
+		err    error
+	)
+	switch config.Kind {
+	case models.ManagedIdPAuthentik:
+		remote, err = provisionAuthentik(ctx, config, spec)
+	case models.ManagedIdPKeycloak:
+		remote, err = provisionKeycloak(ctx, config, spec)
+	default:
+		return nil, fmt.Errorf("unsupported managed IdP kind %q", config.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	remote.Kind = config.Kind
+	remote.ProvisionedAt = time.Now()
+	return remote, nil
+}
+
+// DeprovisionClient removes a client ProvisionClient created. A client the
+// IdP no longer has counts as removed.
+func DeprovisionClient(ctx context.Context, config *models.ManagedIdPConfig, remote *models.ProvisionedClient) error {
+	switch remote.Kind {
+	case models.ManagedIdPAuthentik:
+		if remote.Slug != "" {
+			if err := adminRequest(ctx, config, http.MethodDelete, "/api/v3/core/applications/"+url.PathEscape(remote.Slug)+"/", "", nil, nil); err != nil && !isNotFound(err) {
+				return err
+			}
+		}
+		err := adminRequest(ctx, config, http.MethodDelete, "/api/v3/providers/oauth2/"+url.PathEscape(remote.RemoteID)+"/", "", nil, nil)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		return nil
+	case models.ManagedIdPKeycloak:
+		token, err := keycloakToken(ctx, config)
+		if err != nil {
+			return err
+		}
+		err = adminRequest(ctx, config, http.MethodDelete, keycloakClientsPath(config)+"/"+url.PathEscape(remote.RemoteID), token, nil, nil)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported managed IdP kind %q", remote.Kind)
+	}
+}
+
+// UpdateClientSecret sets the secret of a client ProvisionClient created
+func UpdateClientSecret(ctx context.Context, config *models.ManagedIdPConfig, remote *models.ProvisionedClient, secret string) error {
+	switch remote.Kind {
+	case models.ManagedIdPAuthentik:
+		body := map[string]string{"client_secret": secret}
+		return adminRequest(ctx, config, http.MethodPatch, "/api/v3/providers/oauth2/"+url.PathEscape(remote.RemoteID)+"/", "", body, nil)
+	case models.ManagedIdPKeycloak:
+		token, err := keycloakToken(ctx, config)
+		if err != nil {
+			return err
+		}
+		// Keycloak only changes the fields a client update carries
+		body := map[string]string{"secret": secret}
+		return adminRequest(ctx, config, http.MethodPut, keycloakClientsPath(config)+"/"+url.PathEscape(remote.RemoteID), token, body, nil)
+	default:
+		return fmt.Errorf("unsupported managed IdP kind %q", remote.Kind)
+	}
+}
+
+// Authentik
+
+// authentikDefaultFlows are the flows a new authentik install ships with
+var authentikDefaultFlows = map[string]string{
+	"authorization": "default-provider-authorization-implicit-consent",
+	"invalidation":  "default-provider-invalidation-flow",
+}
+
+type authentikList struct {
+	Results []json.RawMessage `json:"results"`
+}
+
+// provisionAuthentik creates an OAuth2 provider and an application for it.
+// Each authentik application has its own issuer, named after its slug.
+func provisionAuthentik(ctx context.Context, config *models.ManagedIdPConfig, spec ClientSpec) (*models.ProvisionedClient, error) {
+	authorization, err := authentikFlow(ctx, config, "authorization")
+	if err != nil {
+		return nil, err
+	}
+	invalidation, err := authentikFlow(ctx, config, "invalidation")
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := authentikScopeMappings(ctx, config, spec.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	signingKey, err := authentikSigningKey(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	redirects := make([]map[string]string, 0, len(spec.RedirectURIs))
+	for _, uri := range spec.RedirectURIs {
+		redirects = append(redirects, map[string]string{"matching_mode": "strict", "url": uri})
+	}
+	providerBody := map[string]interface{}{
+		"name":               "Stardeck: " + spec.Name,
+		"authorization_flow": authorization,
+		"invalidation_flow":  invalidation,
+		"client_type":        "confidential",
+		"client_id":          spec.ClientID,
+		"client_secret":      spec.ClientSecret,
+		"redirect_uris":      redirects,
+		"property_mappings":  mappings,
+	}
+	// Without a key authentik signs tokens with the client secret, which apps
+	// checking the JWKS can't verify
+	if signingKey != "" {
+		providerBody["signing_key"] = signingKey
+	}
+	var provider struct {
+		PK json.Number `json:"pk"`
+	}
+	if err := adminRequest(ctx, config, http.MethodPost, "/api/v3/providers/oauth2/", "", providerBody, &provider); err != nil {
+		return nil, fmt.Errorf("failed to create authentik provider: %w", err)
+	}
+
+	remote := &models.ProvisionedClient{RemoteID: provider.PK.String()}
+	remote.Slug = authentikSlug(spec.Name, spec.ClientID)
+	appBody := map[string]interface{}{
+		"name":     spec.Name,
+		"slug":     remote.Slug,
+		"provider": provider.PK,
+	}
+	if err := adminRequest(ctx, config, http.MethodPost, "/api/v3/core/applications/", "", appBody, nil); err != nil {
+		// Don't leave a provider no application uses behind
+		adminRequest(ctx, config, http.MethodDelete, "/api/v3/providers/oauth2/"+url.PathEscape(remote.RemoteID)+"/", "", nil, nil)
+		return nil, fmt.Errorf("failed to create authentik application: %w", err)
+	}
+	remote.IssuerURL = strings.TrimSuffix(config.BaseURL, "/") + "/application/o/" + remote.Slug + "/"
+	return remote, nil
+}
+
+// authentikFlow finds the pk of the default flow with a designation, or
+// failing that the first flow of that designation
+func authentikFlow(ctx context.Context, config *models.ManagedIdPConfig, designation string) (string, error) {
+	var list authentikList
+	query := "/api/v3/flows/instances/?designation=" + url.QueryEscape(designation)
+	if err := adminRequest(ctx, config, http.MethodGet, query, "", nil, &list); err != nil {
+		return "", fmt.Errorf("failed to list authentik %s flows: %w", designation, err)
+	}
+	first := ""
+	for _, raw := range list.Results {
+		var flow struct {
+			PK   string `json:"pk"`
+			Slug string `json:"slug"`
+		}
+		if json.Unmarshal(raw, &flow) != nil {
+			continue
+		}
+		if flow.Slug == authentikDefaultFlows[designation] {
+			return flow.PK, nil
+		}
+		if first == "" {
+			first = flow.PK
+		}
+	}
+	if first == "" {
+		return "", fmt.Errorf("authentik has no %s flow", designation)
+	}
+	return first, nil
+}
+
+// authentikScopeMappings finds the scope mappings that fill in the claims of
+// the requested scopes
+func authentikScopeMappings(ctx context.Context, config *models.ManagedIdPConfig, scopes []string) ([]string, error) {
+	var list authentikList
+	if err := adminRequest(ctx, config, http.MethodGet, "/api/v3/propertymappings/provider/scope/?page_size=100", "", nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list authentik scope mappings: %w", err)
+	}
+	wanted := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		wanted[scope] = true
+	}
+	mappings := []string{}
+	for _, raw := range list.Results {
+		var mapping struct {
+			PK        string `json:"pk"`
+			ScopeName string `json:"scope_name"`
+			Managed   string `json:"managed"`
+		}
+		if json.Unmarshal(raw, &mapping) != nil {
+			continue
+		}
+		// Only authentik's own mapping for each scope; admins may have added others
+		if wanted[mapping.ScopeName] && strings.HasPrefix(mapping.Managed, "goauthentik.io/") {
+			mappings = append(mappings, mapping.PK)
+		}
+	}
+	return mappings, nil
+}
+
+// authentikSigningKey returns the pk of the first certificate with a private
+// key, or "" when there is none
+func authentikSigningKey(ctx context.Context, config *models.ManagedIdPConfig) (string, error) {
+	var list authentikList
+	if err := adminRequest(ctx, config, http.MethodGet, "/api/v3/crypto/certificatekeypairs/?has_key=true", "", nil, &list); err != nil {
+		return "", fmt.Errorf("failed to list authentik certificates: %w", err)
+	}
+	for _, raw := range list.Results {
+		var keypair struct {
+			PK string `json:"pk"`
+		}
+		if json.Unmarshal(raw, &keypair) == nil && keypair.PK != "" {
+			return keypair.PK, nil
+		}
+	}
+	return "", nil
+}
+
+var slugInvalid = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// authentikSlug makes an application slug from the app name, with part of
+// the client ID so two apps of the same name don't collide
+func authentikSlug(name, clientID string) string {
+	slug := strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = "app"
+	}
+	suffix := clientID
+	if len(suffix) > 8 {
+		suffix = suffix[:8]
+	}
+	return "stardeck-" + slug + "-" + suffix
+}
+
+// Keycloak
+
+// keycloakRealm returns the realm clients go in
+func keycloakRealm(config *models.ManagedIdPConfig) string {
+	if config.Realm == "" {
+		return "master"
+	}
+	return config.Realm
+}
+
+func keycloakClientsPath(config *models.ManagedIdPConfig) string {
+	return "/admin/realms/" + url.PathEscape(keycloakRealm(config)) + "/clients"
+}
+
+// keycloakToken signs in to the master realm as the admin user
+func keycloakToken(ctx context.Context, config *models.ManagedIdPConfig) (string, error) {
+	form := url.Values{
+		"grant_type": {"password"},
+		"client_id":  {"admin-cli"},
+		"username":   {config.AdminUsername},
+		"password":   {config.AdminPassword},
+	}
+	endpoint := strings.TrimSuffix(config.BaseURL, "/") + "/realms/master/protocol/openid-connect/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := provisionClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign in to keycloak: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to sign in to keycloak: %w", apiError(res))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("keycloak returned no access token")
+	}
+	return token.AccessToken, nil
+}
+
+// provisionKeycloak creates a confidential OpenID Connect client in the realm
+func provisionKeycloak(ctx context.Context, config *models.ManagedIdPConfig, spec ClientSpec) (*models.ProvisionedClient, error) {
+	token, err := keycloakToken(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"clientId":                  spec.ClientID,
+		"name":                      spec.Name,
+		"description":               "Created by Stardeck",
+		"enabled":                   true,
+		"protocol":                  "openid-connect",
+		"publicClient":              false,
+		"clientAuthenticatorType":   "client-secret",
+		"secret":                    spec.ClientSecret,
+		"redirectUris":              spec.RedirectURIs,
+		"standardFlowEnabled":       true,
+		"directAccessGrantsEnabled": false,
+	}
+	var location string
+	if err := adminRequestLocation(ctx, config, http.MethodPost, keycloakClientsPath(config), token, body, &location); err != nil {
+		return nil, fmt.Errorf("failed to create keycloak client: %w", err)
+	}
+	// Keycloak answers with the new client's URL, ending in its UUID
+	id := path.Base(location)
+	if location == "" || id == "clients" {
+		return nil, fmt.Errorf("keycloak did not say which client it created")
+	}
+	return &models.ProvisionedClient{
+		RemoteID:  id,
+		IssuerURL: strings.TrimSuffix(config.BaseURL, "/") + "/realms/" + url.PathEscape(keycloakRealm(config)),
+	}, nil
+}
+
+// Admin API requests
+
+// statusError is an admin API response with an unexpected status
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	if e.message == "" {
+		return fmt.Sprintf("IdP returned %d %s", e.status, http.StatusText(e.status))
+	}
+	return fmt.Sprintf("IdP returned %d %s: %s", e.status, http.StatusText(e.status), e.message)
+}
+
+func isNotFound(err error) bool {
+	se, ok := err.(*statusError)
+	return ok && se.status == http.StatusNotFound
+}
+
+// apiError reads an error response, keeping a short excerpt of its body
+func apiError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	return &statusError{status: res.StatusCode, message: strings.TrimSpace(string(body))}
+}
+
+// adminRequest sends a JSON request to the IdP's admin API, authenticated
+// with token or, when that is empty, the configured API token. The response
+// is decoded into out when it is set.
+func adminRequest(ctx context.Context, config *models.ManagedIdPConfig, method, apiPath, token string, body, out interface{}) error {
+	res, err := sendAdminRequest(ctx, config, method, apiPath, token, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 4<<20)).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from IdP: %w", err)
+	}
+	return nil
+}
+
+// adminRequestLocation is adminRequest for creations answered with the new
+// object's URL in the Location header
+func adminRequestLocation(ctx context.Context, config *models.ManagedIdPConfig, method, apiPath, token string, body interface{}, location *string) error {
+	res, err := sendAdminRequest(ctx, config, method, apiPath, token, body)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	*location = res.Header.Get("Location")
+	return nil
+}
+
+func sendAdminRequest(ctx context.Context, config *models.ManagedIdPConfig, method, apiPath, token string, body interface{}) (*http.Response, error) {
+	if config.BaseURL == "" {
+		return nil, fmt.Errorf("managed IdP has no base URL")
+	}
+	if token == "" {
+		token = config.APIToken
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(config.BaseURL, "/")+apiPath, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := provisionClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		return nil, apiError(res)
+	}
+	return res, nil
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -19,14 +20,17 @@ import (
 
 var allianceRepo *database.AllianceRepo
 
-// InitAllianceRepo initializes the Alliance repository
+// InitAllianceRepo initializes the Alliance repository and starts the client
+// secret monitor
 func InitAllianceRepo() {
 	allianceRepo = database.NewAllianceRepo()
+	go runClientSecretMonitor()
 }
 
 // Alliance Status
 
-// getAllianceStatusHandler returns the current Alliance status
+// getAllianceStatusHandler returns the current Alliance status, with the
+// health of each provider from its recent checks
 func getAllianceStatusHandler(c echo.Context) error {
 	status, err := allianceRepo.GetStatus()
 	if err != nil {
@@ -34,6 +38,11 @@ func getAllianceStatusHandler(c echo.Context) error {
 			"error": "Failed to get Alliance status: " + err.Error(),
 		})
 	}
+	if status.Providers, err = allianceProvidersHealth(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get provider health: " + err.Error(),
+		})
+	}
 	return c.JSON(http.StatusOK, status)
 }
 
@@ -72,7 +81,7 @@ func createProviderHandler(c echo.Context) error {
 	var req models.CreateProviderRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -91,10 +100,16 @@ func createProviderHandler(c echo.Context) error {
 	}
 
 	provider := &models.AllianceProvider{
-		Name:    req.Name,
-		Type:    req.Type,
-		Enabled: true,
-		Config:  string(configJSON),
+		Name:      req.Name,
+		Type:      req.Type,
+		Enabled:   true,
+		IsManaged: req.IsManaged,
+		Config:    string(configJSON),
+	}
+	if _, err := managedIdP(provider); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
 	}
 
 	if err := allianceRepo.CreateProvider(provider); err != nil {
@@ -128,7 +143,7 @@ func updateProviderHandler(c echo.Context) error {
 	var req models.UpdateProviderRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -147,6 +162,14 @@ func updateProviderHandler(c echo.Context) error {
 		}
 		provider.Config = string(configJSON)
 	}
+	if req.IsManaged != nil {
+		provider.IsManaged = *req.IsManaged
+	}
+	if _, err := managedIdP(provider); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
 
 	if err := allianceRepo.UpdateProvider(provider); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -176,11 +199,18 @@ func deleteProviderHandler(c echo.Context) error {
 		})
 	}
 
-	// Delete associated users and groups first
-	allianceRepo.DeleteUsersByProvider(id)
-	allianceRepo.DeleteGroupsByProvider(id)
-
-	if err := allianceRepo.DeleteProvider(id); err != nil {
+	// Delete associated users and groups with the provider
+	err = database.WithTx(func(tx *sql.Tx) error {
+		repo := allianceRepo.InTx(tx)
+		if err := repo.DeleteUsersByProvider(id); err != nil {
+			return err
+		}
+		if err := repo.DeleteGroupsByProvider(id); err != nil {
+			return err
+		}
+		return repo.DeleteProvider(id)
+	})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to delete provider: " + err.Error(),
 		})
@@ -286,6 +316,10 @@ func listClientsHandler(c echo.Context) error {
 			"error": "Failed to list clients: " + err.Error(),
 		})
 	}
+	settings, now := loadClientSecretSettings(), time.Now()
+	for i := range clients {
+		withSecretAge(&clients[i], settings, now)
+	}
 	return c.JSON(http.StatusOK, clients)
 }
 
@@ -303,15 +337,18 @@ func getClientHandler(c echo.Context) error {
 			"error": "Client not found",
 		})
 	}
+	withSecretAge(client, loadClientSecretSettings(), time.Now())
 	return c.JSON(http.StatusOK, client)
 }
 
-// createClientHandler creates a new OIDC/SAML client
+// createClientHandler creates a new OIDC/SAML client. For a managed provider
+// the client is created in the IdP as well, so the app can sign in with the
+// returned credentials straight away.
 func createClientHandler(c echo.Context) error {
 	var req models.CreateClientRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -348,19 +385,58 @@ func createClientHandler(c echo.Context) error {
 		Config:       "{}",
 	}
 
+	// A managed provider gets the client too, with the same credentials
+	managed, err := managedIdP(provider)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	var remote *models.ProvisionedClient
+	if managed != nil {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+		defer cancel()
+		remote, err = alliance.ProvisionClient(ctx, managed, alliance.ClientSpec{
+			Name:         req.AppName,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURIs: req.RedirectURIs,
+			Scopes:       req.Scopes,
+		})
+		if err != nil {
+			return c.JSON(http.StatusBadGateway, map[string]string{
+				"error": "Failed to create the client in " + provider.Name + ": " + err.Error(),
+			})
+		}
+		setClientProvisioning(client, remote)
+	}
+
 	if err := allianceRepo.CreateClient(client); err != nil {
+		if remote != nil {
+			undoCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			alliance.DeprovisionClient(undoCtx, managed, remote)
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to create client: " + err.Error(),
 		})
 	}
 
 	user := c.Get("user").(*models.User)
-	logAudit(user, models.ActionAllianceClientCreate, req.AppName, nil)
+	var details map[string]interface{}
+	if remote != nil {
+		details = map[string]interface{}{
+			"provisioned": remote.Kind,
+			"remote_id":   remote.RemoteID,
+		}
+	}
+	logAudit(user, models.ActionAllianceClientCreate, req.AppName, details)
 
+	withSecretAge(client, loadClientSecretSettings(), time.Now())
 	return c.JSON(http.StatusCreated, client)
 }
 
-// deleteClientHandler deletes a client
+// deleteClientHandler deletes a client, and its copy in a managed IdP
 func deleteClientHandler(c echo.Context) error {
 	id := c.Param("id")
 
@@ -376,6 +452,19 @@ func deleteClientHandler(c echo.Context) error {
 		})
 	}
 
+	// Remove the IdP's copy first; ?force=true deletes the client here even
+	// when the IdP can't be reached
+	var details map[string]interface{}
+	if remote := clientProvisioning(client); remote != nil {
+		err := deprovisionAllianceClient(c.Request().Context(), client, remote)
+		if err != nil && c.QueryParam("force") != "true" {
+			return c.JSON(http.StatusBadGateway, map[string]string{
+				"error": "Failed to remove the client from the IdP: " + err.Error(),
+			})
+		}
+		details = map[string]interface{}{"idp_removed": err == nil}
+	}
+
 	if err := allianceRepo.DeleteClient(id); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to delete client: " + err.Error(),
@@ -383,7 +472,7 @@ func deleteClientHandler(c echo.Context) error {
 	}
 
 	user := c.Get("user").(*models.User)
-	logAudit(user, models.ActionAllianceClientDelete, client.AppName, nil)
+	logAudit(user, models.ActionAllianceClientDelete, client.AppName, details)
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"status": "deleted",
@@ -439,7 +528,7 @@ func syncUsersHandler(c echo.Context) error {
 	var req models.SyncUsersRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -480,7 +569,7 @@ func getBuiltInTemplateHandler(c echo.Context) error {
 	template := templates.GetBuiltInTemplate(id)
 	if template == nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Template not found",
+			"error": tr(c, "error.template_not_found"),
 		})
 	}
 	return c.JSON(http.StatusOK, template)
@@ -492,7 +581,7 @@ func deployBuiltInTemplateHandler(c echo.Context) error {
 	template := templates.GetBuiltInTemplate(id)
 	if template == nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Template not found",
+			"error": tr(c, "error.template_not_found"),
 		})
 	}
 
@@ -502,7 +591,7 @@ func deployBuiltInTemplateHandler(c echo.Context) error {
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -529,6 +618,9 @@ func deployBuiltInTemplateHandler(c echo.Context) error {
 		if env["AUTHENTIK_SECRET_KEY"] == "" {
 			env["AUTHENTIK_SECRET_KEY"] = generateSecret(64)
 		}
+		if env["API_TOKEN"] == "" {
+			env["API_TOKEN"] = generateSecret(64)
+		}
 	}
 
 	// Validate required env vars
@@ -594,7 +686,7 @@ func deployBuiltInTemplateHandler(c echo.Context) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
 
-	if err := podmanService.ComposeStart(ctx, stack.Path, stack.Name); err != nil {
+	if err := podmanService.ComposeStart(ctx, stack.Path, stack.Name, stack.Profiles); err != nil {
 		// Stack created but failed to start - return partial success
 		return c.JSON(http.StatusCreated, map[string]interface{}{
 			"stack":   stack,
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/alliance"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	allianceHealthInterval  = 5 * time.Minute
+	allianceHealthRetention = 7 * 24 * time.Hour
+	// allianceHealthRecent is how many checks the status shows and uptime is
+	// worked out from: two hours at the default interval
+	allianceHealthRecent = 24
+	// allianceHealthMaxHistory caps the checks returned per request
+	allianceHealthMaxHistory = 2016
+)
+
+var allianceHealthRepo *database.AllianceHealthRepo
+
+// InitAllianceHealth initializes the provider health repository and starts
+// the checker
+func InitAllianceHealth() {
+	allianceHealthRepo = database.NewAllianceHealthRepo()
+	go runAllianceHealthChecker()
+}
+
+// runAllianceHealthChecker checks every enabled provider on an interval and
+// drops checks older than the retention
+func runAllianceHealthChecker() {
+	for {
+		time.Sleep(allianceHealthInterval)
+		if maintenanceModeActive() {
+			continue
+		}
+		providers, err := allianceRepo.ListEnabledProviders()
+		if err != nil {
+			log.Printf("Warning: failed to list identity providers for health checks: %v", err)
+			continue
+		}
+		for i := range providers {
+			if _, err := checkProviderHealth(context.Background(), &providers[i]); err != nil {
+				log.Printf("Warning: health check of identity provider %s failed: %v", providers[i].Name, err)
+			}
+		}
+		if _, err := allianceHealthRepo.DeleteOlderThan(time.Now().Add(-allianceHealthRetention)); err != nil {
+			log.Printf("Warning: failed to prune identity provider health checks: %v", err)
+		}
+	}
+}
+
+// checkProviderHealth runs and records a health check. Only OIDC providers
+// can be checked; others return nil.
+func checkProviderHealth(ctx context.Context, provider *models.AllianceProvider) (*models.ProviderHealthCheck, error) {
+	if provider.Type != models.ProviderTypeOIDC {
+		return nil, nil
+	}
+	var check models.ProviderHealthCheck
+	if config, err := database.ParseOIDCConfig(provider.Config); err != nil {
+		check = models.ProviderHealthCheck{Error: "invalid OIDC configuration: " + err.Error()}
+	} else {
+		check = alliance.CheckOIDCHealth(ctx, config)
+	}
+	check.ProviderID = provider.ID
+	if err := allianceHealthRepo.Record(&check); err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+// providerHealth sums up a provider's latest checks
+func providerHealth(provider *models.AllianceProvider, limit int) (models.ProviderHealth, error) {
+	health := models.ProviderHealth{
+		ProviderID: provider.ID,
+		Name:       provider.Name,
+		Type:       provider.Type,
+		Enabled:    provider.Enabled,
+		Status:     models.ProviderHealthUnknown,
+	}
+	history, err := allianceHealthRepo.ListRecent(provider.ID, limit)
+	if err != nil {
+		return health, err
+	}
+	health.History = history
+	if len(history) == 0 {
+		return health, nil
+	}
+	health.Latest = &history[0]
+	// A disabled provider's last checks may be long out of date
+	if !provider.Enabled {
+		return health, nil
+	}
+
+	switch {
+	case health.Latest.Healthy:
+		health.Status = models.ProviderHealthHealthy
+	case health.Latest.DiscoveryOK:
+		health.Status = models.ProviderHealthDegraded
+	default:
+		health.Status = models.ProviderHealthDown
+	}
+	healthy := 0
+	for _, check := range history {
+		if check.Healthy {
+			healthy++
+		}
+	}
+	uptime := float64(healthy) * 100 / float64(len(history))
+	health.Uptime = &uptime
+	return health, nil
+}
+
+// allianceProvidersHealth returns the health of every configured provider
+func allianceProvidersHealth() ([]models.ProviderHealth, error) {
+	providers, err := allianceRepo.ListProviders()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.ProviderHealth, 0, len(providers))
+	for i := range providers {
+		health, err := providerHealth(&providers[i], allianceHealthRecent)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, health)
+	}
+	return result, nil
+}
+
+// getProviderHealthHandler handles GET /api/alliance/providers/:id/health,
+// with up to limit checks of history (default 288, a day at the default
+// interval)
+func getProviderHealthHandler(c echo.Context) error {
+	limit := 288
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > allianceHealthMaxHistory {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "limit must be between 1 and " + strconv.Itoa(allianceHealthMaxHistory),
+			})
+		}
+		limit = n
+	}
+
+	provider, err := allianceRepo.GetProvider(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get provider: " + err.Error(),
+		})
+	}
+	if provider == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Provider not found",
+		})
+	}
+
+	health, err := providerHealth(provider, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get provider health: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, health)
+}
+
+// checkProviderHealthHandler handles POST /api/alliance/providers/:id/health,
+// running a check now instead of waiting for the next one
+func checkProviderHealthHandler(c echo.Context) error {
+	provider, err := allianceRepo.GetProvider(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get provider: " + err.Error(),
+		})
+	}
+	if provider == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Provider not found",
+		})
+	}
+	if provider.Type != models.ProviderTypeOIDC {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Health checks are only available for OIDC providers",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+	if _, err := checkProviderHealth(ctx, provider); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to record health check: " + err.Error(),
+		})
+	}
+
+	health, err := providerHealth(provider, allianceHealthRecent)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get provider health: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, health)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"stardeckos-backend/internal/alliance"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// managedIdP returns the admin API of a managed provider, or nil when the
+// provider isn't managed
+func managedIdP(provider *models.AllianceProvider) (*models.ManagedIdPConfig, error) {
+	if !provider.IsManaged {
+		return nil, nil
+	}
+	if provider.Type != models.ProviderTypeOIDC {
+		return nil, fmt.Errorf("only OIDC providers can be managed")
+	}
+	config, err := database.ParseOIDCConfig(provider.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC configuration: %w", err)
+	}
+	managed := config.Managed
+	if managed == nil || managed.BaseURL == "" {
+		return nil, fmt.Errorf("managed provider has no admin API configured (config.managed.base_url)")
+	}
+	switch managed.Kind {
+	case models.ManagedIdPAuthentik:
+		if managed.APIToken == "" {
+			return nil, fmt.Errorf("authentik needs an API token (config.managed.api_token)")
+		}
+	case models.ManagedIdPKeycloak:
+		if managed.AdminUsername == "" || managed.AdminPassword == "" {
+			return nil, fmt.Errorf("keycloak needs an admin username and password (config.managed)")
+		}
+	default:
+		return nil, fmt.Errorf("managed IdP kind must be %s or %s", models.ManagedIdPAuthentik, models.ManagedIdPKeycloak)
+	}
+	return managed, nil
+}
+
+// clientProvisioning returns where a client was created in a managed IdP, or
+// nil when it only exists in Stardeck
+func clientProvisioning(client *models.AllianceClient) *models.ProvisionedClient {
+	var config models.AllianceClientConfig
+	if json.Unmarshal([]byte(client.Config), &config) != nil {
+		return nil
+	}
+	return config.Provisioned
+}
+
+// setClientProvisioning records the IdP copy of a client in its config,
+// keeping whatever else the config holds
+func setClientProvisioning(client *models.AllianceClient, remote *models.ProvisionedClient) error {
+	config := map[string]json.RawMessage{}
+	if client.Config != "" {
+		if err := json.Unmarshal([]byte(client.Config), &config); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(remote)
+	if err != nil {
+		return err
+	}
+	config["provisioned"] = data
+	merged, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	client.Config = string(merged)
+	return nil
+}
+
+// provisionedClientIdP finds the admin API of the managed provider a client
+// was created in
+func provisionedClientIdP(client *models.AllianceClient) (*models.ManagedIdPConfig, error) {
+	provider, err := allianceRepo.GetProvider(client.ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("provider not found")
+	}
+	managed, err := managedIdP(provider)
+	if err != nil {
+		return nil, err
+	}
+	if managed == nil {
+		return nil, fmt.Errorf("%s is no longer a managed provider", provider.Name)
+	}
+	return managed, nil
+}
+
+// deprovisionAllianceClient removes a client's copy from the managed IdP
+func deprovisionAllianceClient(ctx context.Context, client *models.AllianceClient, remote *models.ProvisionedClient) error {
+	managed, err := provisionedClientIdP(client)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return alliance.DeprovisionClient(ctx, managed, remote)
+}
+
+// pushClientSecret gives a client's copy in the managed IdP a new secret
+func pushClientSecret(ctx context.Context, client *models.AllianceClient, remote *models.ProvisionedClient, secret string) error {
+	managed, err := provisionedClientIdP(client)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return alliance.UpdateClientSecret(ctx, managed, remote, secret)
+}
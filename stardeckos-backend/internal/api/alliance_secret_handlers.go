@@ -0,0 +1,234 @@
+package api
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	clientSecretMonitorInterval = time.Hour
+	maxClientSecretGraceHours   = 30 * 24
+)
+
+// loadClientSecretSettings reads the client secret settings, applying
+// defaults for missing values
+func loadClientSecretSettings() models.ClientSecretSettings {
+	s := models.ClientSecretSettings{MaxAgeDays: 90, DefaultGraceHours: 24}
+	if v, err := settingsRepo.GetInt(database.SettingClientSecretMaxAge); err == nil && v >= 0 {
+		s.MaxAgeDays = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingClientSecretGrace); err == nil && v >= 0 {
+		s.DefaultGraceHours = v
+	}
+	return s
+}
+
+// withSecretAge fills in how old a client's secret is and whether it is past
+// the maximum age
+func withSecretAge(c *models.AllianceClient, settings models.ClientSecretSettings, now time.Time) {
+	c.SecretAgeDays = int(now.Sub(c.SecretCreatedAt).Hours() / 24)
+	c.SecretExpired = settings.MaxAgeDays > 0 && c.SecretAgeDays >= settings.MaxAgeDays
+}
+
+// runClientSecretMonitor revokes replaced secrets whose grace period is over
+// and alerts admins once about each secret that passes the maximum age
+func runClientSecretMonitor() {
+	for {
+		time.Sleep(clientSecretMonitorInterval)
+		now := time.Now()
+		if _, err := allianceRepo.RevokePreviousSecrets(now); err != nil {
+			log.Printf("Warning: failed to revoke replaced client secrets: %v", err)
+		}
+
+		settings := loadClientSecretSettings()
+		if settings.MaxAgeDays == 0 {
+			continue
+		}
+		clients, err := allianceRepo.ListClients()
+		if err != nil {
+			log.Printf("Warning: failed to list Alliance clients: %v", err)
+			continue
+		}
+		for i := range clients {
+			client := &clients[i]
+			withSecretAge(client, settings, now)
+			if !client.SecretExpired || client.SecretAgeNotifiedAt != nil {
+				continue
+			}
+			notifyRoles(localized(models.Notification{
+				Type:  models.NotificationClientSecretAge,
+				Level: models.NotificationWarning,
+				Data: map[string]interface{}{
+					"client_id":  client.ID,
+					"app_name":   client.AppName,
+					"created_at": client.SecretCreatedAt,
+					"age_days":   client.SecretAgeDays,
+				},
+			}, "notification.client_secret_age",
+				"app_name", client.AppName,
+				"age_days", strconv.Itoa(client.SecretAgeDays),
+				"max_age_days", strconv.Itoa(settings.MaxAgeDays),
+			), models.RoleAdmin)
+			if err := allianceRepo.SetSecretAgeNotified(client.ID, now); err != nil {
+				log.Printf("Warning: failed to record secret age alert for %s: %v", client.AppName, err)
+			}
+		}
+	}
+}
+
+// allianceClientFromParam loads the client named by the :id parameter. It
+// writes the error response itself, returning a nil client.
+func allianceClientFromParam(c echo.Context) (*models.AllianceClient, error) {
+	client, err := allianceRepo.GetClient(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get client: " + err.Error(),
+		})
+	}
+	if client == nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.client_not_found"),
+		})
+	}
+	return client, nil
+}
+
+// rotateClientSecretHandler handles POST /api/alliance/clients/:id/rotate-secret.
+// The response carries the new secret; the old one keeps working through the
+// grace period so the app and the IdP can be updated one after the other.
+// A client created in a managed IdP has its secret replaced there too.
+func rotateClientSecretHandler(c echo.Context) error {
+	settings := loadClientSecretSettings()
+	req := models.RotateClientSecretRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	grace := settings.DefaultGraceHours
+	if req.GraceHours != nil {
+		grace = *req.GraceHours
+	}
+	if grace < 0 || grace > maxClientSecretGraceHours {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.field_range", "field", "grace_hours", "min", "0", "max", strconv.Itoa(maxClientSecretGraceHours)),
+		})
+	}
+
+	client, err := allianceClientFromParam(c)
+	if client == nil {
+		return err
+	}
+
+	var previousUntil *time.Time
+	if grace > 0 {
+		until := time.Now().Add(time.Duration(grace) * time.Hour)
+		previousUntil = &until
+	}
+	secret := generateClientSecret()
+	// A managed IdP holds a single secret, so there the old one stops working
+	// as soon as the new one is pushed
+	remote := clientProvisioning(client)
+	if remote != nil {
+		if err := pushClientSecret(c.Request().Context(), client, remote, secret); err != nil {
+			return c.JSON(http.StatusBadGateway, map[string]string{
+				"error": "Failed to update the secret in the IdP: " + err.Error(),
+			})
+		}
+	}
+	if err := allianceRepo.RotateClientSecret(client.ID, secret, previousUntil); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to rotate client secret: " + err.Error(),
+		})
+	}
+
+	rotated, err := allianceRepo.GetClient(client.ID)
+	if err != nil || rotated == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Secret rotated but the client could not be reloaded",
+		})
+	}
+	withSecretAge(rotated, settings, time.Now())
+
+	Audit.LogFromContext(c, models.ActionAllianceClientRotate, client.AppName, map[string]interface{}{
+		"client_id":   client.ID,
+		"grace_hours": grace,
+		"idp_updated": remote != nil,
+	})
+	return c.JSON(http.StatusOK, rotated)
+}
+
+// verifyClientSecretHandler handles POST /api/alliance/clients/:id/verify-secret,
+// telling whether an app is configured with the current secret, the previous
+// one still in its grace period, or neither
+func verifyClientSecretHandler(c echo.Context) error {
+	var req models.VerifyClientSecretRequest
+	if err := c.Bind(&req); err != nil || req.Secret == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.secret_required"),
+		})
+	}
+
+	client, err := allianceClientFromParam(c)
+	if client == nil {
+		return err
+	}
+
+	resp := models.VerifyClientSecretResponse{}
+	switch {
+	case subtle.ConstantTimeCompare([]byte(req.Secret), []byte(client.ClientSecret)) == 1:
+		resp.Valid, resp.Matched = true, "current"
+	case client.PreviousSecret != "" && client.PreviousSecretExpiresAt != nil &&
+		time.Now().Before(*client.PreviousSecretExpiresAt) &&
+		subtle.ConstantTimeCompare([]byte(req.Secret), []byte(client.PreviousSecret)) == 1:
+		resp.Valid, resp.Matched, resp.ExpiresAt = true, "previous", client.PreviousSecretExpiresAt
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// getClientSecretSettingsHandler returns the client secret rotation settings
+func getClientSecretSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadClientSecretSettings())
+}
+
+// updateClientSecretSettingsHandler sets the maximum secret age and the
+// default grace period
+func updateClientSecretSettingsHandler(c echo.Context) error {
+	settings := loadClientSecretSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if settings.MaxAgeDays < 0 || settings.MaxAgeDays > 3650 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.field_range", "field", "max_age_days", "min", "0", "max", "3650"),
+		})
+	}
+	if settings.DefaultGraceHours < 0 || settings.DefaultGraceHours > maxClientSecretGraceHours {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.field_range", "field", "default_grace_hours", "min", "0", "max", strconv.Itoa(maxClientSecretGraceHours)),
+		})
+	}
+
+	values := map[string]string{
+		database.SettingClientSecretMaxAge: strconv.Itoa(settings.MaxAgeDays),
+		database.SettingClientSecretGrace:  strconv.Itoa(settings.DefaultGraceHours),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionAllianceSecretSettings, "alliance", values)
+	return c.JSON(http.StatusOK, loadClientSecretSettings())
+}
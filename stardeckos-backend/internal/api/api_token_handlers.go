@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// maxAPITokenDays caps how far ahead a token's expiry may be set
+const maxAPITokenDays = 365
+
+var apiTokenRepo *database.APITokenRepo
+
+// InitAPITokenRepo initializes the API token repository
+func InitAPITokenRepo() {
+	apiTokenRepo = database.NewAPITokenRepo()
+}
+
+// listAPITokensHandler handles GET /api/auth/tokens, listing the user's
+// tokens, or everyone's for an admin asking with all=true
+func listAPITokensHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	userID := user.ID
+	if c.QueryParam("all") == "true" && user.IsAdmin() {
+		userID = 0
+	}
+
+	tokens, err := apiTokenRepo.List(userID)
+	if err != nil {
+		c.Logger().Error("list API tokens error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to list API tokens",
+		})
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// createAPITokenHandler handles POST /api/auth/tokens. The token is returned
+// once; only its hash is stored.
+func createAPITokenHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	var req models.CreateAPITokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request_body"),
+		})
+	}
+
+	token := &models.APIToken{UserID: user.ID, Username: user.Username, Name: strings.TrimSpace(req.Name)}
+	if msg := validateAPIToken(c, user, token, req); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	secret, err := apiTokenRepo.Create(token)
+	if err != nil {
+		c.Logger().Error("create API token error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to create API token",
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionAPITokenCreate, token.Name, map[string]interface{}{
+		"token_id":   token.ID,
+		"prefix":     token.Prefix,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt,
+	})
+
+	return c.JSON(http.StatusCreated, models.CreatedAPIToken{APIToken: *token, Token: secret})
+}
+
+// revokeAPITokenHandler handles DELETE /api/auth/tokens/:id. Users revoke
+// their own tokens; admins anyone's.
+func revokeAPITokenHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_token_id"),
+		})
+	}
+
+	token, err := apiTokenRepo.GetByID(id)
+	if errors.Is(err, database.ErrAPITokenNotFound) || (err == nil && token.UserID != user.ID && !user.IsAdmin()) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.token_not_found"),
+		})
+	}
+	if err != nil {
+		c.Logger().Error("get API token error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to get API token",
+		})
+	}
+	if token.RevokedAt != nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": tr(c, "error.token_revoked"),
+		})
+	}
+
+	if err := apiTokenRepo.Revoke(token.ID); err != nil {
+		c.Logger().Error("revoke API token error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to revoke API token",
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionAPITokenRevoke, token.Name, map[string]interface{}{
+		"token_id": token.ID,
+		"prefix":   token.Prefix,
+		"owner":    token.Username,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// validateAPIToken checks a token request and fills in the token's scopes
+// and expiry. Scopes naming a single permission must be ones the user holds;
+// wildcards are narrowed to what the user holds whenever the token is used.
+func validateAPIToken(c echo.Context, user *models.User, token *models.APIToken, req models.CreateAPITokenRequest) string {
+	if token.Name == "" || len(token.Name) > 64 {
+		return tr(c, "error.name_length", "max", "64")
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxAPITokenDays {
+		return tr(c, "error.field_range", "field", "expires_in_days", "min", "0", "max", strconv.Itoa(maxAPITokenDays))
+	}
+	if req.ExpiresInDays > 0 {
+		expires := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expires
+	}
+
+	token.Scopes = []string{}
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !models.ValidPermission(scope) {
+			return tr(c, "error.unknown_scope", "scope", scope)
+		}
+		if !strings.Contains(scope, "*") && !user.HasPermission(scope) {
+			return tr(c, "error.scope_not_held", "scope", scope)
+		}
+		if !slices.Contains(token.Scopes, scope) {
+			token.Scopes = append(token.Scopes, scope)
+		}
+	}
+	if len(token.Scopes) == 0 {
+		return tr(c, "error.scope_required")
+	}
+	return ""
+}
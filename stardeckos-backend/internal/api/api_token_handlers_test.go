@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+func TestAPITokenAuditKeepsTokenID(t *testing.T) {
+	openTestDB(t)
+	InitAPITokenRepo()
+	admin := &models.User{Username: "admin", Role: models.RoleAdmin, AuthType: models.AuthTypeLocal}
+	if err := database.NewUserRepo().Create(admin); err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+
+	e := echo.New()
+	asAdmin := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", admin)
+			return next(c)
+		}
+	}
+	e.POST("/api/auth/tokens", createAPITokenHandler, asAdmin)
+	e.DELETE("/api/auth/tokens/:id", revokeAPITokenHandler, asAdmin)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/tokens", strings.NewReader(`{"name":"ci","scopes":["containers:read"]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create token: got %d %s", rec.Code, rec.Body.String())
+	}
+	var created models.CreatedAPIToken
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/auth/tokens/"+strconv.FormatInt(created.APIToken.ID, 10), nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke token: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, action := range []string{models.ActionAPITokenCreate, models.ActionAPITokenRevoke} {
+		var stored string
+		if err := database.DB.QueryRow("SELECT details FROM audit_logs WHERE action = ?", action).Scan(&stored); err != nil {
+			t.Fatalf("read %s details: %v", action, err)
+		}
+		var details map[string]interface{}
+		if err := json.Unmarshal([]byte(stored), &details); err != nil {
+			t.Fatalf("decode %s details: %v", action, err)
+		}
+		if got, ok := details["token_id"].(float64); !ok || int64(got) != created.APIToken.ID {
+			t.Errorf("%s token_id = %v, want %d", action, details["token_id"], created.APIToken.ID)
+		}
+		if strings.Contains(stored, created.Token) {
+			t.Errorf("%s was stored with the token itself: %s", action, stored)
+		}
+	}
+}
+
+func TestAPITokenErrorsLocalized(t *testing.T) {
+	openTestDB(t)
+	InitAPITokenRepo()
+	user := &models.User{ID: 1, Username: "ops", Role: models.RoleOperator}
+
+	e := echo.New()
+	e.POST("/api/auth/tokens", createAPITokenHandler, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", user)
+			return next(c)
+		}
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/tokens", strings.NewReader(`{"name":"ci","scopes":[]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Accept-Language", "fr")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if want := "au moins une portée est requise"; rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("token without scopes in French: got %d %s, want 400 with %q", rec.Code, rec.Body.String(), want)
+	}
+}
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var approvalRepo *database.ApprovalRepo
+
+// maxApprovalBody caps how much of a held request body is stored for review
+const maxApprovalBody = 64 * 1024
+
+// InitApprovalRepo initializes the approval request repository
+func InitApprovalRepo() {
+	approvalRepo = database.NewApprovalRepo()
+}
+
+// loadApprovalSettings reads four-eyes settings, applying defaults for missing values
+func loadApprovalSettings() models.ApprovalSettings {
+	s := models.ApprovalSettings{TTLMinutes: 30}
+	if v, err := settingsRepo.GetBool(database.SettingApprovalsEnabled); err == nil {
+		s.Enabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingApprovalTTL); err == nil && v > 0 {
+		s.TTLMinutes = v
+	}
+	return s
+}
+
+// approvalFingerprint identifies a request by method, path, query and body so an
+// approval cannot be reused for a different target
+func approvalFingerprint(method, path, query string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + "\n" + path + "\n" + query + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// requireApproval holds a destructive action for a second admin when four-eyes
+// mode is on. applies narrows which calls count as destructive; nil means all.
+//
+// A held call returns 202 with the approval request. Once another admin approves
+// it, the requester repeats the identical call with ?approval_id= (or the
+// X-Approval-ID header) before the deadline, and it runs exactly once.
+func requireApproval(action string, applies func(c echo.Context) bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			settings := loadApprovalSettings()
+			if !settings.Enabled || (applies != nil && !applies(c)) {
+				return next(c)
+			}
+
+			user := c.Get("user").(*models.User)
+			req := c.Request()
+
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxApprovalBody+1))
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Failed to read request: " + err.Error(),
+				})
+			}
+			if len(body) > maxApprovalBody {
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+					"error": "Request body too large for approval",
+				})
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			query := req.URL.Query()
+			approvalID := query.Get("approval_id")
+			if approvalID == "" {
+				approvalID = req.Header.Get("X-Approval-ID")
+			}
+			query.Del("approval_id")
+			canonicalQuery := query.Encode()
+			fingerprint := approvalFingerprint(req.Method, req.URL.Path, canonicalQuery, body)
+
+			approvalRepo.ExpireStale(time.Now())
+
+			if approvalID != "" {
+				approval, err := approvalRepo.GetByID(approvalID)
+				if err == sql.ErrNoRows {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": "Approval not found",
+					})
+				}
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": "Failed to get approval: " + err.Error(),
+					})
+				}
+				if approval.RequestedBy != user.ID || approval.Fingerprint != fingerprint {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": "Approval does not match this request",
+					})
+				}
+				if approval.Status != models.ApprovalApproved {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": "Approval is " + string(approval.Status),
+					})
+				}
+				ok, err := approvalRepo.MarkExecuted(approval.ID)
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": "Failed to consume approval: " + err.Error(),
+					})
+				}
+				if !ok {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": "Approval has already been used",
+					})
+				}
+
+				logAudit(user, models.ActionApprovalExecute, action, map[string]interface{}{
+					"approval_id": approval.ID,
+					"approved_by": approval.Decider,
+				})
+				return next(c)
+			}
+
+			approval := &models.ApprovalRequest{
+				Action:      action,
+				Method:      req.Method,
+				Path:        req.URL.Path,
+				Query:       canonicalQuery,
+				Body:        string(body),
+				Fingerprint: fingerprint,
+				Reason:      req.Header.Get("X-Approval-Reason"),
+				RequestedBy: user.ID,
+				Requester:   user.Username,
+				ExpiresAt:   time.Now().Add(time.Duration(settings.TTLMinutes) * time.Minute),
+			}
+			if err := approvalRepo.Create(approval); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to create approval request: " + err.Error(),
+				})
+			}
+
+			logAudit(user, models.ActionApprovalRequest, action, map[string]interface{}{
+				"approval_id": approval.ID,
+				"path":        approval.Path,
+			})
+			notifyRoles(localized(models.Notification{
+				Type:  models.NotificationApprovalRequested,
+				Level: models.NotificationWarning,
+				Data: map[string]interface{}{
+					"approval_id": approval.ID,
+					"action":      action,
+				},
+			}, "notification.approval_requested", "username", user.Username, "action", action, "path", approval.Path),
+				models.RoleAdmin)
+
+			return c.JSON(http.StatusAccepted, map[string]interface{}{
+				"approval_required": true,
+				"approval":          approval,
+			})
+		}
+	}
+}
+
+// removesContainerVolumes reports whether a container removal also deletes its volumes
+func removesContainerVolumes(c echo.Context) bool {
+	return c.QueryParam("volumes") == "true"
+}
+
+// listApprovalsHandler returns approval requests, optionally filtered by ?status=
+func listApprovalsHandler(c echo.Context) error {
+	approvalRepo.ExpireStale(time.Now())
+
+	approvals, err := approvalRepo.List(c.QueryParam("status"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list approvals: " + err.Error(),
+		})
+	}
+	if approvals == nil {
+		approvals = []models.ApprovalRequest{}
+	}
+	return c.JSON(http.StatusOK, approvals)
+}
+
+// getApprovalHandler returns a single approval request
+func getApprovalHandler(c echo.Context) error {
+	approvalRepo.ExpireStale(time.Now())
+
+	approval, err := approvalRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Approval not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get approval: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, approval)
+}
+
+// approveRequestHandler lets a second admin approve a held action
+func approveRequestHandler(c echo.Context) error {
+	return decideApproval(c, models.ApprovalApproved)
+}
+
+// rejectRequestHandler lets an admin reject a held action
+func rejectRequestHandler(c echo.Context) error {
+	return decideApproval(c, models.ApprovalRejected)
+}
+
+// decideApproval records a reviewer's decision on a pending approval request
+func decideApproval(c echo.Context, status models.ApprovalStatus) error {
+	approvalRepo.ExpireStale(time.Now())
+
+	approval, err := approvalRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Approval not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get approval: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	if status == models.ApprovalApproved && approval.RequestedBy == user.ID {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "A second admin must approve this request",
+		})
+	}
+
+	var req models.DecideApprovalRequest
+	c.Bind(&req)
+
+	// An approval opens a fresh window for the requester to run the action
+	expiresAt := approval.ExpiresAt
+	if status == models.ApprovalApproved {
+		expiresAt = time.Now().Add(time.Duration(loadApprovalSettings().TTLMinutes) * time.Minute)
+	}
+
+	ok, err := approvalRepo.Decide(approval.ID, status, user.ID, req.Comment, expiresAt)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save decision: " + err.Error(),
+		})
+	}
+	if !ok {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Approval is " + string(approval.Status),
+		})
+	}
+
+	auditAction := models.ActionApprovalApprove
+	level := models.NotificationSuccess
+	if status == models.ApprovalRejected {
+		auditAction = models.ActionApprovalReject
+		level = models.NotificationWarning
+	}
+	logAudit(user, auditAction, approval.Action, map[string]interface{}{
+		"approval_id":  approval.ID,
+		"requested_by": approval.Requester,
+		"comment":      req.Comment,
+	})
+	notifyUser(approval.RequestedBy, localized(models.Notification{
+		Type:  models.NotificationApprovalDecided,
+		Level: level,
+		Data: map[string]interface{}{
+			"approval_id": approval.ID,
+			"status":      status,
+		},
+	}, "notification.approval_"+string(status), "username", user.Username, "action", approval.Action, "path", approval.Path))
+
+	updated, err := approvalRepo.GetByID(approval.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get approval: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// getApprovalSettingsHandler returns the four-eyes configuration
+func getApprovalSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadApprovalSettings())
+}
+
+// updateApprovalSettingsHandler turns four-eyes mode on or off and sets the TTL
+func updateApprovalSettingsHandler(c echo.Context) error {
+	settings := loadApprovalSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if settings.TTLMinutes < 1 || settings.TTLMinutes > 7*24*60 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "ttl_minutes must be between 1 and 10080",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingApprovalsEnabled: strconv.FormatBool(settings.Enabled),
+		database.SettingApprovalTTL:      strconv.Itoa(settings.TTLMinutes),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionApprovalSettings, "approvals", values)
+
+	return c.JSON(http.StatusOK, loadApprovalSettings())
+}
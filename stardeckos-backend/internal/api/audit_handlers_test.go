@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// openTestDB opens a fresh database for the test
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := database.Open(database.Config{Path: filepath.Join(t.TempDir(), "stardeck.db")}); err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	InitAuditRepo()
+	Audit = NewAuditLogger()
+}
+
+func TestAuditDetailsRedactedWhenStored(t *testing.T) {
+	openTestDB(t)
+	InitStackRepo()
+	admin := &models.User{Username: "admin", Role: models.RoleAdmin, AuthType: models.AuthTypeLocal}
+	if err := database.NewUserRepo().Create(admin); err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	stack := &models.Stack{
+		Name:           "db",
+		ComposeContent: "services:\n  db:\n    image: postgres:16\n",
+		Status:         models.StackStatusStopped,
+		Path:           t.TempDir(),
+	}
+	if err := stackRepo.Create(stack); err != nil {
+		t.Fatalf("create stack: %v", err)
+	}
+
+	e := echo.New()
+	asAdmin := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", admin)
+			return next(c)
+		}
+	}
+	e.PUT("/api/stacks/:id", updateStackHandler, asAdmin)
+	e.GET("/api/audit", listAuditLogsHandler, asAdmin)
+	e.GET("/api/audit/:id", getAuditLogHandler, asAdmin)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := serve(http.MethodPut, "/api/stacks/"+stack.ID, `{"env_content":"POSTGRES_USER=app\nPOSTGRES_PASSWORD=hunter2\n"}`); rec.Code != http.StatusOK {
+		t.Fatalf("update stack: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Redaction happens when the entry is stored, so even an admin never
+	// sees the secret
+	rec := serve(http.MethodGet, "/api/audit", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list audit logs: got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, "hunter2") {
+		t.Errorf("audit list shows a secret to an admin: %s", body)
+	}
+	for _, want := range []string{"POSTGRES_PASSWORD", "POSTGRES_USER", database.AuditRedacted} {
+		if !strings.Contains(body, want) {
+			t.Errorf("audit list is missing %q: %s", want, body)
+		}
+	}
+
+	logs, _, err := database.NewAuditRepo().List(models.AuditFilter{Action: models.ActionStackUpdate, Limit: 10})
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("list stack update entries: %d, %v", len(logs), err)
+	}
+	var stored string
+	if err := database.DB.QueryRow("SELECT details FROM audit_logs WHERE id = ?", logs[0].ID).Scan(&stored); err != nil {
+		t.Fatalf("read stored details: %v", err)
+	}
+	if strings.Contains(stored, "hunter2") {
+		t.Errorf("audit entry was stored with a secret: %s", stored)
+	}
+	rec = serve(http.MethodGet, "/api/audit/"+strconv.FormatInt(logs[0].ID, 10), "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "hunter2") {
+		t.Errorf("audit entry: got %d %s", rec.Code, rec.Body.String())
+	}
+}
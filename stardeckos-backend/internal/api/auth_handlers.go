@@ -24,7 +24,7 @@ func loginHandler(c echo.Context) error {
 	var req auth.LoginRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -39,17 +39,35 @@ func loginHandler(c echo.Context) error {
 	userAgent := c.Request().UserAgent()
 
 	resp, err := authService.Login(req, ipAddress, userAgent)
+	if errors.Is(err, auth.ErrTwoFactorRequired) {
+		// The password was right; the client asks for the code and retries
+		return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+			"error":               "two-factor code required",
+			"two_factor_required": true,
+		})
+	}
 	if err != nil {
 		// Log failed login attempt
 		Audit.Log(0, req.Username, models.ActionLoginFailed, req.Username, map[string]string{
 			"reason": err.Error(),
 		}, ipAddress)
+		reportAuthFailure(c, req.Username)
+		notifyRoles(localized(models.Notification{
+			Type:  models.NotificationLoginFailed,
+			Level: models.NotificationWarning,
+			Data:  map[string]interface{}{"username": req.Username, "ip_address": ipAddress},
+		}, "notification.login_failed", "username", req.Username, "ip", ipAddress), models.RoleAdmin)
 
 		switch {
 		case errors.Is(err, auth.ErrInvalidCredentials):
 			return c.JSON(http.StatusUnauthorized, map[string]string{
 				"error": "invalid username or password",
 			})
+		case errors.Is(err, auth.ErrInvalidTwoFactorCode):
+			return c.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"error":               "invalid two-factor code",
+				"two_factor_required": true,
+			})
 		case errors.Is(err, auth.ErrUserDisabled):
 			return c.JSON(http.StatusForbidden, map[string]string{
 				"error": "user account is disabled",
@@ -66,8 +84,38 @@ func loginHandler(c echo.Context) error {
 		}
 	}
 
+	if resp.RecoveryCodeUsed {
+		Audit.Log(resp.User.ID, resp.User.Username, models.ActionTwoFactorRecoveryUsed, resp.User.Username, nil, ipAddress)
+		return finishLogin(c, resp, map[string]string{"second_factor": "recovery_code"})
+	}
+	return finishLogin(c, resp, nil)
+}
+
+// finishLogin audits a successful sign-in, sets the session cookie and returns
+// the session. details records how the user signed in, if not by password.
+func finishLogin(c echo.Context, resp *auth.LoginResponse, details interface{}) error {
+	ipAddress := c.RealIP()
+	userAgent := c.Request().UserAgent()
+
+	// Only admins may sign in while maintenance mode blocks logins
+	if maintenanceBlocksLogin(resp.User) {
+		authService.Logout(resp.Token)
+		Audit.Log(resp.User.ID, resp.User.Username, models.ActionLoginFailed, resp.User.Username, map[string]string{
+			"reason": "maintenance mode",
+		}, ipAddress)
+		return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
+			"error":       "Stardeck is in maintenance mode",
+			"maintenance": loadMaintenanceMode(),
+		})
+	}
+
 	// Log successful login
-	Audit.Log(resp.User.ID, resp.User.Username, models.ActionLogin, resp.User.Username, nil, ipAddress)
+	Audit.Log(resp.User.ID, resp.User.Username, models.ActionLogin, resp.User.Username, details, ipAddress)
+	notifyUser(resp.User.ID, localized(models.Notification{
+		Type:  models.NotificationLoginDetected,
+		Level: models.NotificationInfo,
+		Data:  map[string]interface{}{"ip_address": ipAddress, "user_agent": userAgent},
+	}, "notification.login_detected", "ip", ipAddress))
 
 	// Clear rate limit on successful login
 	auth.LoginRateLimiter.RecordSuccess(ipAddress)
@@ -81,7 +129,7 @@ func loginHandler(c echo.Context) error {
 		Value:    resp.Token,
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   c.Request().TLS != nil, // Secure if HTTPS
+		Secure:   c.Scheme() == "https", // Secure if HTTPS, including behind a trusted proxy
 		SameSite: http.SameSiteStrictMode,
 		MaxAge:   int(resp.ExpiresAt.Sub(resp.User.CreatedAt).Seconds()),
 	}
@@ -139,17 +187,14 @@ func logoutHandler(c echo.Context) error {
 func refreshTokenHandler(c echo.Context) error {
 	token := getTokenFromRequest(c)
 	if token == "" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "no session token",
-		})
+		return auth.SessionError(c, nil)
 	}
 
 	session, err := authService.RefreshToken(token)
 	if err != nil {
-		if errors.Is(err, database.ErrSessionNotFound) || errors.Is(err, database.ErrSessionExpired) {
-			return c.JSON(http.StatusUnauthorized, map[string]string{
-				"error": "session expired or invalid",
-			})
+		if errors.Is(err, database.ErrSessionNotFound) || errors.Is(err, database.ErrSessionExpired) ||
+			errors.Is(err, auth.ErrSessionLifetimeExceeded) || errors.Is(err, auth.ErrUserDisabled) {
+			return auth.SessionError(c, err)
 		}
 		c.Logger().Error("refresh token error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -166,21 +211,18 @@ func refreshTokenHandler(c echo.Context) error {
 func getCurrentUser(c echo.Context) error {
 	token := getTokenFromRequest(c)
 	if token == "" {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "not authenticated",
-		})
+		return auth.SessionError(c, nil)
 	}
 
 	user, session, err := authService.ValidateToken(token)
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "session expired or invalid",
-		})
+		return auth.SessionError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"user":       user,
-		"session":    session,
+		"user":           user,
+		"session":        session,
+		"session_policy": authService.SessionPolicy(user.Role),
 	})
 }
 
@@ -0,0 +1,464 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/i18n"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	// autoUpdateDefaultInterval is how often images are checked, in hours
+	autoUpdateDefaultInterval = 24
+	// autoUpdateDefaultWindow is how long a maintenance window stays open, in minutes
+	autoUpdateDefaultWindow = 60
+	// updateHistoryLimit caps the history entries returned per request
+	updateHistoryLimit = 100
+)
+
+var autoUpdateRepo *database.AutoUpdateRepo
+
+// InitAutoUpdateRepo initializes the auto-update repository and starts the scheduler
+func InitAutoUpdateRepo() {
+	autoUpdateRepo = database.NewAutoUpdateRepo()
+	go runAutoUpdater()
+}
+
+// autoUpdateWindowStart returns when the maintenance window holding minute t
+// opened, or zero when t is outside every window
+func autoUpdateWindowStart(a *models.ContainerAutoUpdate, t time.Time) time.Time {
+	loc, err := scheduleLocation(a.Timezone)
+	if err != nil {
+		return time.Time{}
+	}
+	cron, err := system.ParseCron(a.WindowSchedule)
+	if err != nil {
+		return time.Time{}
+	}
+	t = t.In(loc).Truncate(time.Minute)
+	for i := 0; i < a.WindowMinutes; i++ {
+		if start := t.Add(-time.Duration(i) * time.Minute); cron.Matches(start) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// withNextWindow fills in when the maintenance window next opens, for display
+func withNextWindow(a *models.ContainerAutoUpdate) {
+	if !a.Enabled || a.Policy != models.AutoUpdateAuto {
+		return
+	}
+	loc, err := scheduleLocation(a.Timezone)
+	if err != nil {
+		return
+	}
+	if cron, err := system.ParseCron(a.WindowSchedule); err == nil {
+		if next := cron.Next(time.Now().In(loc)); !next.IsZero() {
+			a.NextWindowAt = &next
+		}
+	}
+}
+
+// runAutoUpdater wakes at the top of every minute to check images that are
+// due and update containers whose maintenance window is open. Updates run one
+// at a time, so a slow pull delays the next pass rather than overlapping it.
+func runAutoUpdater() {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(next.Sub(now))
+
+		if maintenanceModeActive() {
+			continue
+		}
+
+		policies, err := autoUpdateRepo.ListEnabled()
+		if err != nil {
+			log.Printf("Warning: failed to load auto-update policies: %v", err)
+			continue
+		}
+		for i := range policies {
+			runAutoUpdatePolicy(&policies[i], next)
+		}
+	}
+}
+
+// runAutoUpdatePolicy does what a policy calls for at minute now: a fresh
+// check and the update once per open window, or a check when one is due
+func runAutoUpdatePolicy(a *models.ContainerAutoUpdate, now time.Time) {
+	if a.Policy == models.AutoUpdateAuto {
+		start := autoUpdateWindowStart(a, now)
+		if !start.IsZero() && (a.LastAttemptAt == nil || a.LastAttemptAt.Before(start)) {
+			if a.LastCheckedAt == nil || a.LastCheckedAt.Before(start) {
+				checkAutoUpdateImage(a)
+			}
+			if a.UpdateAvailable {
+				autoUpdateContainer(a, start)
+			}
+			return
+		}
+	}
+
+	interval := time.Duration(a.CheckIntervalHours) * time.Hour
+	if a.LastCheckedAt == nil || now.Sub(*a.LastCheckedAt) >= interval {
+		checkAutoUpdateImage(a)
+	}
+}
+
+// checkAutoUpdateImage compares a container's image with its registry tag,
+// records the result and tells admins about each new image once
+func checkAutoUpdateImage(a *models.ContainerAutoUpdate) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	a.LastCheckedAt = &now
+
+	check, err := autoUpdateCheck(ctx, a.ContainerID)
+	if err != nil {
+		a.LastError = err.Error()
+		log.Printf("Warning: image check for %s failed: %v", a.ContainerName, err)
+		if err := autoUpdateRepo.RecordCheck(a.ContainerID, false, "", "", a.LastError); err != nil {
+			log.Printf("Warning: failed to record image check for %s: %v", a.ContainerName, err)
+		}
+		return
+	}
+
+	a.UpdateAvailable, a.LocalDigest, a.RemoteDigest, a.LastError = check.HasUpdate, check.LocalDigest, check.RemoteDigest, ""
+	if err := autoUpdateRepo.RecordCheck(a.ContainerID, check.HasUpdate, check.LocalDigest, check.RemoteDigest, ""); err != nil {
+		log.Printf("Warning: failed to record image check for %s: %v", a.ContainerName, err)
+	}
+
+	if check.HasUpdate && check.RemoteDigest != a.NotifiedDigest {
+		notifyImageUpdate(a)
+		if err := autoUpdateRepo.SetNotified(a.ContainerID, check.RemoteDigest); err == nil {
+			a.NotifiedDigest = check.RemoteDigest
+		}
+	}
+}
+
+// autoUpdateCheck checks the image of a Stardeck container for a new digest
+func autoUpdateCheck(ctx context.Context, id string) (*system.ImageUpdateCheck, error) {
+	container, err := containerRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("container not found: %w", err)
+	}
+	image := container.Image
+	if inspect, err := podmanService.InspectContainer(ctx, container.ContainerID); err == nil {
+		image = inspect.Config.Image
+	}
+	return podmanService.CheckImageUpdate(ctx, image)
+}
+
+// notifyImageUpdate tells admins a container's image has a new version, and
+// when it will be installed
+func notifyImageUpdate(a *models.ContainerAutoUpdate) {
+	key, params := "notification.image_update", []string{"container", a.ContainerName}
+	if a.Policy == models.AutoUpdateAuto {
+		key = "notification.image_update_window"
+		if withNextWindow(a); a.NextWindowAt != nil {
+			key, params = "notification.image_update_at", append(params, "window", a.NextWindowAt.Format(time.RFC1123))
+		}
+	}
+	notifyRoles(localized(models.Notification{
+		Type:  models.NotificationImageUpdate,
+		Level: models.NotificationInfo,
+		Data: map[string]interface{}{
+			"container_id":  a.ContainerID,
+			"local_digest":  a.LocalDigest,
+			"remote_digest": a.RemoteDigest,
+			"policy":        a.Policy,
+		},
+	}, key, params...), models.RoleAdmin)
+}
+
+// autoUpdateContainer runs the update workflow for a container whose window
+// opened at start, recording the outcome in the policy and the history
+func autoUpdateContainer(a *models.ContainerAutoUpdate, start time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	container, err := containerRepo.GetByID(a.ContainerID)
+	if err != nil {
+		return
+	}
+
+	entry := newUpdateHistory(container.ID, models.UpdateTriggerScheduled, "system")
+	entry.OldDigest, entry.NewDigest = a.LocalDigest, a.RemoteDigest
+	req := &models.UpdateContainerImageRequest{
+		ContainerID:  container.ContainerID,
+		CreateBackup: a.CreateBackup,
+		RemoveOld:    a.RemoveOld,
+	}
+	noop := func(string, string, bool, int, map[string]interface{}) {}
+	result, err := runContainerUpdate(ctx, req, nil, i18n.DefaultLanguage, noop)
+	recordUpdateHistory(entry, result, err)
+
+	errMsg := ""
+	details := map[string]interface{}{"digest": a.RemoteDigest}
+	if err != nil {
+		errMsg = err.Error()
+		details["error"] = errMsg
+		log.Printf("Warning: automatic update of %s failed: %v", a.ContainerName, err)
+		notifyRoles(localized(models.Notification{
+			Type:  models.NotificationImageUpdate,
+			Level: models.NotificationError,
+			Data:  map[string]interface{}{"container_id": a.ContainerID},
+		}, "notification.auto_update_failed", "container", a.ContainerName, "error", errMsg), models.RoleAdmin)
+	}
+	a.LastAttemptAt = &start
+	if err := autoUpdateRepo.RecordAttempt(a.ContainerID, start, errMsg); err != nil {
+		log.Printf("Warning: failed to record automatic update of %s: %v", a.ContainerName, err)
+	}
+	Audit.Log(0, "system", models.ActionContainerAutoUpdateRun, a.ContainerName, details, "")
+}
+
+// newUpdateHistory starts a history entry for an update of the container ref
+// names, a Stardeck or podman ID
+func newUpdateHistory(ref, trigger, triggeredBy string) *models.ContainerUpdateHistory {
+	h := &models.ContainerUpdateHistory{
+		ContainerID:   ref,
+		ContainerName: ref,
+		Trigger:       trigger,
+		TriggeredBy:   triggeredBy,
+		StartedAt:     time.Now(),
+	}
+	if container, err := lookupManagedContainer(ref); err == nil {
+		h.ContainerID, h.ContainerName, h.OldImage = container.ID, container.Name, container.Image
+	}
+	return h
+}
+
+// recordUpdateHistory stores how an update ended
+func recordUpdateHistory(h *models.ContainerUpdateHistory, result *containerUpdateResult, err error) {
+	now := time.Now()
+	h.FinishedAt = &now
+	if err != nil {
+		h.Status, h.Error = models.UpdateStatusFailed, err.Error()
+	} else {
+		h.Status = models.UpdateStatusSuccess
+		h.ContainerName, h.OldImage, h.NewImage = result.Name, result.OldImage, result.NewImage
+		h.BackupContainer = result.BackupContainer
+		autoUpdateRepo.MarkUpdated(h.ContainerID)
+	}
+	if err := autoUpdateRepo.AddHistory(h); err != nil {
+		log.Printf("Warning: failed to record update history for %s: %v", h.ContainerName, err)
+	}
+}
+
+// getContainerAutoUpdate loads the auto-update policy of the container in the URL
+func getContainerAutoUpdate(c echo.Context) (*models.ContainerAutoUpdate, error) {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	a, err := autoUpdateRepo.Get(container.ID)
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Container has no auto-update policy",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get auto-update policy: " + err.Error(),
+		})
+	}
+	return a, nil
+}
+
+// listAutoUpdatesHandler lists the auto-update policies of all containers
+func listAutoUpdatesHandler(c echo.Context) error {
+	policies, err := autoUpdateRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list auto-update policies: " + err.Error(),
+		})
+	}
+	for i := range policies {
+		withNextWindow(&policies[i])
+	}
+	return c.JSON(http.StatusOK, policies)
+}
+
+// getAutoUpdateHandler returns a container's auto-update policy and the
+// result of its last check
+func getAutoUpdateHandler(c echo.Context) error {
+	a, err := getContainerAutoUpdate(c)
+	if a == nil {
+		return err
+	}
+	withNextWindow(a)
+	return c.JSON(http.StatusOK, a)
+}
+
+// updateAutoUpdateHandler creates or replaces a container's auto-update policy
+func updateAutoUpdateHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.UpdateContainerAutoUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	switch req.Policy {
+	case models.AutoUpdateNotify:
+		req.WindowSchedule = ""
+	case models.AutoUpdateAuto:
+		if req.WindowSchedule == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "window_schedule is required for the auto policy",
+			})
+		}
+		if _, err := system.ParseCron(req.WindowSchedule); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid window schedule: " + err.Error(),
+			})
+		}
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "policy must be notify or auto",
+		})
+	}
+	if req.CheckIntervalHours == 0 {
+		req.CheckIntervalHours = autoUpdateDefaultInterval
+	}
+	if req.CheckIntervalHours < 1 || req.CheckIntervalHours > 24*30 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "check_interval_hours must be between 1 and 720",
+		})
+	}
+	if req.WindowMinutes == 0 {
+		req.WindowMinutes = autoUpdateDefaultWindow
+	}
+	if req.WindowMinutes < 1 || req.WindowMinutes > 24*60 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "window_minutes must be between 1 and 1440",
+		})
+	}
+	if req.Timezone == "" {
+		req.Timezone = "Local"
+	}
+	if _, err := scheduleLocation(req.Timezone); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid timezone: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	a := &models.ContainerAutoUpdate{
+		ContainerID:        container.ID,
+		Enabled:            req.Enabled == nil || *req.Enabled,
+		Policy:             req.Policy,
+		CheckIntervalHours: req.CheckIntervalHours,
+		WindowSchedule:     req.WindowSchedule,
+		WindowMinutes:      req.WindowMinutes,
+		Timezone:           req.Timezone,
+		CreateBackup:       req.CreateBackup,
+		RemoveOld:          req.RemoveOld,
+		CreatedBy:          &user.ID,
+	}
+	if err := autoUpdateRepo.Upsert(a); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save auto-update policy: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionContainerAutoUpdateSet, container.Name, map[string]interface{}{
+		"enabled":              a.Enabled,
+		"policy":               a.Policy,
+		"check_interval_hours": a.CheckIntervalHours,
+		"window_schedule":      a.WindowSchedule,
+		"window_minutes":       a.WindowMinutes,
+		"timezone":             a.Timezone,
+	})
+
+	saved, err := autoUpdateRepo.Get(container.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get auto-update policy: " + err.Error(),
+		})
+	}
+	withNextWindow(saved)
+	return c.JSON(http.StatusOK, saved)
+}
+
+// deleteAutoUpdateHandler removes a container's auto-update policy; its
+// update history is kept
+func deleteAutoUpdateHandler(c echo.Context) error {
+	a, err := getContainerAutoUpdate(c)
+	if a == nil {
+		return err
+	}
+
+	if err := autoUpdateRepo.Delete(a.ContainerID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete auto-update policy: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionContainerAutoUpdateDelete, a.ContainerName, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Auto-update policy deleted",
+	})
+}
+
+// checkAutoUpdateHandler checks a container's image now instead of waiting
+// for the next scheduled check
+func checkAutoUpdateHandler(c echo.Context) error {
+	a, err := getContainerAutoUpdate(c)
+	if a == nil {
+		return err
+	}
+
+	checkAutoUpdateImage(a)
+	withNextWindow(a)
+	return c.JSON(http.StatusOK, a)
+}
+
+// listUpdateHistoryHandler lists recent container updates, of one container
+// with ?container_id= or of all of them
+func listUpdateHistoryHandler(c echo.Context) error {
+	limit := updateHistoryLimit
+	if n, err := strconv.Atoi(c.QueryParam("limit")); err == nil && n > 0 && n < limit {
+		limit = n
+	}
+
+	containerID := ""
+	if ref := c.QueryParam("container_id"); ref != "" {
+		containerID = ref
+		if container, err := lookupManagedContainer(ref); err == nil {
+			containerID = container.ID
+		}
+	}
+
+	history, err := autoUpdateRepo.ListHistory(containerID, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list update history: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, history)
+}
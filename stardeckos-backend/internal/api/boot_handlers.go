@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var bootReportRepo *database.BootReportRepo
+
+// bootReconcileMu prevents concurrent reconciliation runs
+var bootReconcileMu sync.Mutex
+
+const (
+	bootStartAttempts   = 3
+	bootStartRetryDelay = 10 * time.Second
+	bootPodmanWait      = 2 * time.Minute
+)
+
+// InitBootReconciler initializes boot report storage and reconciles auto-start containers after a host restart
+func InitBootReconciler() {
+	bootReportRepo = database.NewBootReportRepo()
+	go reconcileAfterBoot()
+}
+
+// currentBootID returns the kernel's unique ID for the current boot
+func currentBootID() string {
+	data, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// reconcileAfterBoot runs once per host boot, skipping service restarts within the same boot
+func reconcileAfterBoot() {
+	bootID := currentBootID()
+	if last, err := bootReportRepo.GetLatestBootID(); err == nil && bootID != "" && last == bootID {
+		return
+	}
+
+	// Podman may not be ready immediately after the host comes up
+	deadline := time.Now().Add(bootPodmanWait)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := podmanService.CheckPodman(ctx)
+		cancel()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			log.Printf("Warning: skipping boot reconciliation, podman unavailable: %v", err)
+			return
+		}
+		time.Sleep(5 * time.Second)
+	}
+
+	report, err := reconcileAutoStart("boot", bootID)
+	if err != nil {
+		log.Printf("Warning: boot reconciliation failed: %v", err)
+		return
+	}
+	log.Printf("Boot reconciliation: %d auto-start containers, %d started, %d already running, %d failed, %d missing",
+		report.Total, report.Started, report.AlreadyRunning, report.Failed, report.Missing)
+}
+
+// reconcileAutoStart starts every auto-start container that isn't running and records the outcome
+func reconcileAutoStart(trigger, bootID string) (*models.BootReport, error) {
+	bootReconcileMu.Lock()
+	defer bootReconcileMu.Unlock()
+
+	report := &models.BootReport{
+		BootID:    bootID,
+		Trigger:   trigger,
+		StartedAt: time.Now(),
+		Results:   []models.BootResult{},
+	}
+
+	containers, err := containerRepo.ListAutoStart()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	live, err := podmanService.ListContainers(ctx)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	liveStatus := make(map[string]models.ContainerStatus)
+	for _, lc := range live {
+		liveStatus[lc.ContainerID] = lc.Status
+	}
+
+	for _, dc := range containers {
+		result := models.BootResult{ID: dc.ID, ContainerID: dc.ContainerID, Name: dc.Name}
+
+		status, exists := liveStatus[dc.ContainerID]
+		switch {
+		case !exists:
+			result.Action = models.BootActionMissing
+			result.Error = "container not found in podman"
+			report.Missing++
+		case status == models.ContainerStatusRunning:
+			result.Action = models.BootActionAlreadyRunning
+			report.AlreadyRunning++
+		default:
+			var startErr error
+			for result.Attempts < bootStartAttempts {
+				result.Attempts++
+				ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+				startErr = podmanService.StartContainer(ctx, dc.ContainerID)
+				cancel()
+				if startErr == nil {
+					break
+				}
+				time.Sleep(bootStartRetryDelay)
+			}
+
+			if startErr != nil {
+				result.Action = models.BootActionFailed
+				result.Error = startErr.Error()
+				report.Failed++
+			} else {
+				result.Action = models.BootActionStarted
+				containerRepo.UpdateStatus(dc.ID, models.ContainerStatusRunning)
+				report.Started++
+			}
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	report.Total = len(containers)
+	report.FinishedAt = time.Now()
+
+	if err := bootReportRepo.Create(report); err != nil {
+		log.Printf("Warning: failed to save boot report: %v", err)
+	}
+
+	return report, nil
+}
+
+// getLatestBootReportHandler returns the most recent reconciliation report
+func getLatestBootReportHandler(c echo.Context) error {
+	report, err := bootReportRepo.GetLatest()
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "No boot report available",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get boot report: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"report":          report,
+		"current_boot_id": currentBootID(),
+	})
+}
+
+// listBootReportsHandler returns summaries of past reconciliation runs
+func listBootReportsHandler(c echo.Context) error {
+	reports, err := bootReportRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list boot reports: " + err.Error(),
+		})
+	}
+
+	if reports == nil {
+		reports = []models.BootReport{}
+	}
+
+	return c.JSON(http.StatusOK, reports)
+}
+
+// getBootReportHandler returns a single reconciliation report
+func getBootReportHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid report ID",
+		})
+	}
+
+	report, err := bootReportRepo.GetByID(id)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Boot report not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get boot report: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// reconcileAutoStartHandler runs reconciliation on demand
+func reconcileAutoStartHandler(c echo.Context) error {
+	report, err := reconcileAutoStart("manual", currentBootID())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to reconcile containers: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionBootReconcile, "containers", map[string]interface{}{
+		"started": report.Started,
+		"failed":  report.Failed,
+		"missing": report.Missing,
+	})
+
+	return c.JSON(http.StatusOK, report)
+}
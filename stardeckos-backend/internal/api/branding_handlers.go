@@ -0,0 +1,241 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	brandingBaseDir       = "/var/lib/stardeck/branding"
+	defaultInstanceName   = "Stardeck OS"
+	defaultAccentColor    = "#6366f1"
+	maxInstanceNameLength = 64
+	maxBannerLength       = 4000
+	maxLegalNoticeLength  = 20000
+)
+
+// accentColorPattern accepts #rgb and #rrggbb hex colors
+var accentColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// loadBrandingSettings reads the instance branding, applying defaults for missing values
+func loadBrandingSettings() models.BrandingSettings {
+	b := models.BrandingSettings{
+		InstanceName: defaultInstanceName,
+		AccentColor:  defaultAccentColor,
+	}
+	if v, err := settingsRepo.Get(database.SettingBrandingName); err == nil && v != "" {
+		b.InstanceName = v
+	}
+	if v, err := settingsRepo.Get(database.SettingBrandingAccent); err == nil && v != "" {
+		b.AccentColor = v
+	}
+	b.Banner, _ = settingsRepo.Get(database.SettingBrandingBanner)
+	b.LegalNotice, _ = settingsRepo.Get(database.SettingBrandingLegal)
+	if v, err := settingsRepo.GetBool(database.SettingBrandingRequireAck); err == nil {
+		b.RequireAck = v && b.LegalNotice != ""
+	}
+	if logo, err := settingsRepo.Get(database.SettingBrandingLogo); err == nil && logo != "" {
+		// The file name changes on every upload, so it doubles as a cache buster
+		b.LogoURL = "/api/branding/logo?v=" + strings.TrimSuffix(logo, filepath.Ext(logo))
+	}
+	b.UpdatedAt, _ = settingsRepo.Get(database.SettingBrandingUpdated)
+	return b
+}
+
+// getBrandingHandler returns the instance branding (public, used by the login page)
+func getBrandingHandler(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	return c.JSON(http.StatusOK, loadBrandingSettings())
+}
+
+// updateBrandingHandler updates the instance name, accent color, banner and legal notice
+func updateBrandingHandler(c echo.Context) error {
+	settings := loadBrandingSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	settings.InstanceName = strings.TrimSpace(settings.InstanceName)
+	settings.AccentColor = strings.TrimSpace(settings.AccentColor)
+	if settings.InstanceName == "" {
+		settings.InstanceName = defaultInstanceName
+	}
+	if settings.AccentColor == "" {
+		settings.AccentColor = defaultAccentColor
+	}
+
+	if len(settings.InstanceName) > maxInstanceNameLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("instance_name must be at most %d characters", maxInstanceNameLength),
+		})
+	}
+	if !accentColorPattern.MatchString(settings.AccentColor) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "accent_color must be a hex color such as #6366f1",
+		})
+	}
+	if len(settings.Banner) > maxBannerLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("banner must be at most %d characters", maxBannerLength),
+		})
+	}
+	if len(settings.LegalNotice) > maxLegalNoticeLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("legal_notice must be at most %d characters", maxLegalNoticeLength),
+		})
+	}
+	if settings.RequireAck && strings.TrimSpace(settings.LegalNotice) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "require_ack needs a legal_notice",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingBrandingName:       settings.InstanceName,
+		database.SettingBrandingAccent:     settings.AccentColor,
+		database.SettingBrandingBanner:     settings.Banner,
+		database.SettingBrandingLegal:      settings.LegalNotice,
+		database.SettingBrandingRequireAck: strconv.FormatBool(settings.RequireAck),
+		database.SettingBrandingUpdated:    time.Now().Format(time.RFC3339),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save branding: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionBrandingUpdate, "branding", map[string]interface{}{
+		"instance_name": settings.InstanceName,
+		"accent_color":  settings.AccentColor,
+		"require_ack":   settings.RequireAck,
+	})
+
+	return c.JSON(http.StatusOK, loadBrandingSettings())
+}
+
+// uploadBrandingLogoHandler replaces the login page logo with an uploaded PNG, JPEG or SVG
+func uploadBrandingLogoHandler(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "No file uploaded",
+		})
+	}
+
+	if file.Size > maxIconSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Logo too large (max %d MB)", maxIconSize/(1024*1024)),
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxIconSize+1))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read uploaded file",
+		})
+	}
+
+	ext, ok := allowedIconTypes[detectIconType(data)]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Unsupported logo type (use PNG, JPEG, or SVG)",
+		})
+	}
+
+	if err := os.MkdirAll(brandingBaseDir, 0755); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create branding directory: " + err.Error(),
+		})
+	}
+
+	name := "logo-" + uuid.New().String()[:8] + ext
+	if err := os.WriteFile(filepath.Join(brandingBaseDir, name), data, 0644); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save logo: " + err.Error(),
+		})
+	}
+
+	previous, _ := settingsRepo.Get(database.SettingBrandingLogo)
+	if err := settingsRepo.Set(database.SettingBrandingLogo, name); err != nil {
+		os.Remove(filepath.Join(brandingBaseDir, name))
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save branding: " + err.Error(),
+		})
+	}
+	if previous != "" {
+		os.Remove(filepath.Join(brandingBaseDir, filepath.Base(previous)))
+	}
+	settingsRepo.Set(database.SettingBrandingUpdated, time.Now().Format(time.RFC3339))
+
+	Audit.LogFromContext(c, models.ActionBrandingLogoUpload, "branding", map[string]interface{}{
+		"file": name,
+		"size": len(data),
+	})
+
+	return c.JSON(http.StatusOK, loadBrandingSettings())
+}
+
+// deleteBrandingLogoHandler reverts to the default logo
+func deleteBrandingLogoHandler(c echo.Context) error {
+	name, _ := settingsRepo.Get(database.SettingBrandingLogo)
+	if name == "" {
+		return c.JSON(http.StatusOK, loadBrandingSettings())
+	}
+
+	if err := settingsRepo.Set(database.SettingBrandingLogo, ""); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save branding: " + err.Error(),
+		})
+	}
+	os.Remove(filepath.Join(brandingBaseDir, filepath.Base(name)))
+	settingsRepo.Set(database.SettingBrandingUpdated, time.Now().Format(time.RFC3339))
+
+	Audit.LogFromContext(c, models.ActionBrandingLogoDelete, "branding", nil)
+
+	return c.JSON(http.StatusOK, loadBrandingSettings())
+}
+
+// serveBrandingLogoHandler serves the uploaded logo (public, used by the login page)
+func serveBrandingLogoHandler(c echo.Context) error {
+	name, _ := settingsRepo.Get(database.SettingBrandingLogo)
+	if name == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "No custom logo configured",
+		})
+	}
+	name = filepath.Base(name)
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", "public, max-age=86400")
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	// Same treatment as uploaded icons: SVG markup must not be able to run scripts
+	if filepath.Ext(name) == ".svg" {
+		header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+		header.Set("Content-Type", "image/svg+xml")
+	}
+	return c.File(filepath.Join(brandingBaseDir, name))
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	maxCaptureDuration = 300    // Seconds
+	maxCapturePackets  = 100000 // Per capture
+	defaultSnapLen     = 262144 // tcpdump's default, whole packets
+)
+
+// captureMu allows one capture at a time
+var captureMu sync.Mutex
+
+// checkCaptureRequest applies defaults and limits to a capture request
+func checkCaptureRequest(req *models.PacketCaptureRequest) error {
+	req.Interface = strings.TrimSpace(req.Interface)
+	req.Filter = strings.TrimSpace(req.Filter)
+	if _, err := net.InterfaceByName(req.Interface); err != nil {
+		return fmt.Errorf("interface '%s' not found", req.Interface)
+	}
+	if req.Duration <= 0 {
+		req.Duration = 30
+	}
+	if req.Duration > maxCaptureDuration {
+		return fmt.Errorf("duration can be at most %d seconds", maxCaptureDuration)
+	}
+	if req.MaxPackets < 0 || req.MaxPackets > maxCapturePackets {
+		return fmt.Errorf("max_packets must be between 0 and %d", maxCapturePackets)
+	}
+	if req.MaxPackets == 0 {
+		req.MaxPackets = maxCapturePackets
+	}
+	if req.SnapLen < 0 || req.SnapLen > defaultSnapLen {
+		return fmt.Errorf("snap_len must be between 0 and %d", defaultSnapLen)
+	}
+	if req.SnapLen == 0 {
+		req.SnapLen = defaultSnapLen
+	}
+	return system.ValidateCaptureFilter(req.Interface, req.Filter)
+}
+
+// capturePacketsHandler runs a packet capture with WebSocket progress. The
+// first message is the capture request; sending {"action":"stop"} or closing
+// the socket ends the capture early, keeping what was captured.
+func capturePacketsHandler(c echo.Context) error {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	var writeMu sync.Mutex
+	sendStatus := func(step, message string, isError bool, details map[string]interface{}) {
+		payload := map[string]interface{}{
+			"step":    step,
+			"message": message,
+			"error":   isError,
+		}
+		for k, v := range details {
+			payload[k] = v
+		}
+		writeMu.Lock()
+		ws.WriteJSON(payload)
+		writeMu.Unlock()
+	}
+
+	_, message, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	var req models.PacketCaptureRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		sendStatus("error", "Invalid request: "+err.Error(), true, nil)
+		return nil
+	}
+
+	user := c.Get("user").(*models.User)
+
+	if !system.TcpdumpInstalled() {
+		sendStatus("validate", "tcpdump is not installed", true, nil)
+		return nil
+	}
+	if err := checkCaptureRequest(&req); err != nil {
+		sendStatus("validate", err.Error(), true, nil)
+		return nil
+	}
+	if !captureMu.TryLock() {
+		sendStatus("validate", "A capture is already running", true, nil)
+		return nil
+	}
+	defer captureMu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// Any message or a closed socket stops the capture
+		ws.ReadMessage()
+		cancel()
+	}()
+
+	Audit.LogFromContext(c, models.ActionPacketCapture, req.Interface, map[string]interface{}{
+		"filter":      req.Filter,
+		"duration":    req.Duration,
+		"max_packets": req.MaxPackets,
+	})
+	sendStatus("capture", fmt.Sprintf("Capturing on %s for up to %d seconds...", req.Interface, req.Duration), false, map[string]interface{}{
+		"duration": req.Duration,
+	})
+
+	capture, err := system.RunCapture(ctx, req, user.Username, func(size int64, elapsed time.Duration) {
+		sendStatus("capture", "Capturing...", false, map[string]interface{}{
+			"size":    size,
+			"elapsed": int(elapsed.Seconds()),
+		})
+	})
+	if err != nil {
+		sendStatus("capture", err.Error(), true, nil)
+		return nil
+	}
+
+	sendStatus("complete", fmt.Sprintf("Captured %d packets", capture.Packets), false, map[string]interface{}{
+		"capture": capture,
+	})
+	return nil
+}
+
+// listCapturesHandler handles GET /api/network/captures
+func listCapturesHandler(c echo.Context) error {
+	captures, err := system.ListCaptures()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list captures: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, captures)
+}
+
+// downloadCaptureHandler handles GET /api/network/captures/:id/download
+func downloadCaptureHandler(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := system.GetCapture(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Capture not found",
+		})
+	}
+	path, _ := system.CapturePath(id)
+	if _, err := os.Stat(path); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Capture file is missing",
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionPacketCaptureDownload, id, nil)
+
+	c.Response().Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".pcap"))
+	return c.File(path)
+}
+
+// deleteCaptureHandler handles DELETE /api/network/captures/:id
+func deleteCaptureHandler(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := system.GetCapture(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Capture not found",
+		})
+	}
+	if err := system.RemoveCapture(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete capture: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionPacketCaptureDelete, id, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Capture deleted",
+	})
+}
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var certMonitorRepo *database.CertMonitorRepo
+
+const (
+	// certMonitorInterval is how often certificates are checked
+	certMonitorInterval = 6 * time.Hour
+	// selfCertWarnDays is the warning window for Stardeck's own certificate
+	selfCertWarnDays = 21
+)
+
+// certAlertSeverity orders alert states so only worsening ones notify
+var certAlertSeverity = map[string]int{
+	models.CertAlertNone:    0,
+	models.CertAlertWarning: 1,
+	models.CertAlertExpired: 2,
+}
+
+// InitCertMonitorRepo initializes the certificate monitor repository and
+// starts the periodic checks
+func InitCertMonitorRepo() {
+	certMonitorRepo = database.NewCertMonitorRepo()
+	go runCertMonitors()
+}
+
+func runCertMonitors() {
+	// Give the HTTPS listener time to load its certificate
+	time.Sleep(time.Minute)
+	ticker := time.NewTicker(certMonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		if !maintenanceModeActive() {
+			checkAllCertMonitors()
+		}
+		<-ticker.C
+	}
+}
+
+// checkAllCertMonitors checks every enabled monitor and Stardeck's own certificate
+func checkAllCertMonitors() {
+	if m := selfCertMonitor(); m != nil {
+		checkSelfCertMonitor(m)
+	}
+	monitors, err := certMonitorRepo.List()
+	if err != nil {
+		log.Printf("Warning: failed to list certificate monitors: %v", err)
+		return
+	}
+	for i := range monitors {
+		if monitors[i].Enabled {
+			checkCertMonitor(&monitors[i])
+		}
+	}
+}
+
+// selfCertMonitor describes the HTTPS listener's certificate, or nil when
+// Stardeck serves plain HTTP
+func selfCertMonitor() *models.CertMonitor {
+	tlsListener.mu.Lock()
+	enabled, certPath, port := tlsListener.enabled, tlsListener.certPath, tlsListener.httpsPort
+	tlsListener.mu.Unlock()
+	if !enabled {
+		return nil
+	}
+	host, _ := os.Hostname()
+	portNum, _ := strconv.Atoi(port)
+	state, _ := settingsRepo.Get(database.SettingCertMonitorSelf)
+	m := &models.CertMonitor{
+		Name:       "Stardeck",
+		Host:       host,
+		Port:       portNum,
+		WarnDays:   selfCertWarnDays,
+		Enabled:    true,
+		Builtin:    true,
+		AlertState: state,
+	}
+	m.CertCheck = system.CheckCertificateFile(certPath, host)
+	return m
+}
+
+// checkSelfCertMonitor alerts on Stardeck's own certificate. It is read from
+// disk, so a renewed file is picked up without a restart.
+func checkSelfCertMonitor(m *models.CertMonitor) {
+	state := certAlertState(m)
+	if state != m.AlertState {
+		if err := settingsRepo.Set(database.SettingCertMonitorSelf, state); err != nil {
+			log.Printf("Warning: failed to save certificate alert state: %v", err)
+		}
+	}
+	notifyCertAlert(m, state)
+	m.AlertState = state
+}
+
+// certAlertState judges a checked certificate against the monitor's warning
+// window. A failed check keeps the previous state.
+func certAlertState(m *models.CertMonitor) string {
+	if m.Error != "" || m.DaysLeft == nil {
+		return m.AlertState
+	}
+	switch {
+	case *m.DaysLeft < 0:
+		return models.CertAlertExpired
+	case *m.DaysLeft <= m.WarnDays:
+		return models.CertAlertWarning
+	}
+	return models.CertAlertNone
+}
+
+// notifyCertAlert notifies admins and operators when a certificate's alert
+// state worsens, so each stage alerts once per certificate
+func notifyCertAlert(m *models.CertMonitor, state string) {
+	if certAlertSeverity[state] <= certAlertSeverity[m.AlertState] {
+		return
+	}
+	level, key := models.NotificationWarning, "notification.cert_expiring"
+	if state == models.CertAlertExpired {
+		level, key = models.NotificationError, "notification.cert_expired"
+	}
+	notifyRoles(localized(models.Notification{
+		Type:  models.NotificationCertExpiring,
+		Level: level,
+		Data: map[string]interface{}{
+			"monitor_id":   m.ID,
+			"monitor_host": m.Host, // "host" is the Stardeck host's label on notifications
+			"port":         m.Port,
+			"container_id": m.ContainerID,
+			"not_after":    m.NotAfter,
+			"days_left":    *m.DaysLeft,
+		},
+	}, key,
+		"monitor", m.Name,
+		"days", strconv.Itoa(*m.DaysLeft),
+		"host", m.Host,
+		"port", strconv.Itoa(m.Port),
+		"subject", m.Subject,
+		"issuer", m.Issuer,
+		"not_after", m.NotAfter.Format(time.RFC1123),
+	), models.RoleAdmin, models.RoleOperator)
+}
+
+// checkCertMonitor checks a monitor's endpoint, records the result and alerts
+func checkCertMonitor(m *models.CertMonitor) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	check := system.CheckEndpointCertificate(ctx, m.Host, m.Port, m.ServerName)
+	if check.Error != "" {
+		// Keep the last certificate seen so an outage doesn't hide the expiry date
+		prev := m.CertCheck
+		prev.Error, prev.CheckedAt = check.Error, check.CheckedAt
+		check = prev
+	}
+	m.CertCheck = check
+	withDaysLeft(m)
+	state := certAlertState(m)
+	if err := certMonitorRepo.RecordCheck(m.ID, check, state); err != nil {
+		log.Printf("Warning: failed to save certificate check for %s: %v", m.Name, err)
+	}
+	notifyCertAlert(m, state)
+	m.AlertState = state
+}
+
+// withDaysLeft fills in the days left from the stored expiry date
+func withDaysLeft(m *models.CertMonitor) {
+	if m.NotAfter != nil {
+		days := system.CertDaysLeft(*m.NotAfter)
+		m.DaysLeft = &days
+	}
+}
+
+// checkCertMonitorRequest validates a request, including its container
+func checkCertMonitorRequest(req *models.CertMonitorRequest) error {
+	if err := system.ValidateCertMonitor(req); err != nil {
+		return err
+	}
+	if req.ContainerID != "" {
+		container, err := lookupManagedContainer(req.ContainerID)
+		if err != nil {
+			return fmt.Errorf("container not found")
+		}
+		req.ContainerID = container.ID
+	}
+	return nil
+}
+
+// listCertMonitorsHandler handles GET /api/system/cert-monitors. Stardeck's
+// own certificate is listed first.
+func listCertMonitorsHandler(c echo.Context) error {
+	monitors, err := certMonitorRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list certificate monitors: " + err.Error(),
+		})
+	}
+	for i := range monitors {
+		withDaysLeft(&monitors[i])
+	}
+	if self := selfCertMonitor(); self != nil {
+		monitors = append([]models.CertMonitor{*self}, monitors...)
+	}
+	return c.JSON(http.StatusOK, monitors)
+}
+
+// createCertMonitorHandler handles POST /api/system/cert-monitors and runs
+// the first check immediately
+func createCertMonitorHandler(c echo.Context) error {
+	var req models.CertMonitorRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := checkCertMonitorRequest(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	m := &models.CertMonitor{
+		Name:        req.Name,
+		Host:        req.Host,
+		Port:        req.Port,
+		ServerName:  req.ServerName,
+		ContainerID: req.ContainerID,
+		WarnDays:    req.WarnDays,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedBy:   user.Username,
+	}
+	if err := certMonitorRepo.Create(m); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create certificate monitor: " + err.Error(),
+		})
+	}
+	if m.Enabled {
+		checkCertMonitor(m)
+	}
+
+	Audit.LogFromContext(c, models.ActionCertMonitorCreate, m.Name, map[string]interface{}{
+		"host":      m.Host,
+		"port":      m.Port,
+		"warn_days": m.WarnDays,
+	})
+
+	return c.JSON(http.StatusCreated, m)
+}
+
+// updateCertMonitorHandler handles PUT /api/system/cert-monitors/:id
+func updateCertMonitorHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid monitor ID",
+		})
+	}
+	m, err := certMonitorRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Certificate monitor not found",
+		})
+	}
+
+	req := models.CertMonitorRequest{Enabled: &m.Enabled}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := checkCertMonitorRequest(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	endpointChanged := req.Host != m.Host || req.Port != m.Port || req.ServerName != m.ServerName
+	m.Name, m.Host, m.Port, m.ServerName = req.Name, req.Host, req.Port, req.ServerName
+	m.ContainerID, m.WarnDays, m.Enabled = req.ContainerID, req.WarnDays, *req.Enabled
+	if err := certMonitorRepo.Update(m, endpointChanged); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update certificate monitor: " + err.Error(),
+		})
+	}
+	if m.Enabled {
+		checkCertMonitor(m)
+	}
+
+	Audit.LogFromContext(c, models.ActionCertMonitorUpdate, m.Name, map[string]interface{}{
+		"host":      m.Host,
+		"port":      m.Port,
+		"warn_days": m.WarnDays,
+		"enabled":   m.Enabled,
+	})
+
+	return c.JSON(http.StatusOK, m)
+}
+
+// checkCertMonitorHandler handles POST /api/system/cert-monitors/:id/check
+func checkCertMonitorHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid monitor ID",
+		})
+	}
+	m, err := certMonitorRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Certificate monitor not found",
+		})
+	}
+	checkCertMonitor(m)
+	return c.JSON(http.StatusOK, m)
+}
+
+// deleteCertMonitorHandler handles DELETE /api/system/cert-monitors/:id
+func deleteCertMonitorHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid monitor ID",
+		})
+	}
+	m, err := certMonitorRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Certificate monitor not found",
+		})
+	}
+	if err := certMonitorRepo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete certificate monitor: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionCertMonitorDelete, m.Name, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Certificate monitor deleted",
+	})
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/certs"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	defaultClientCertDays = 365
+	maxClientCertDays     = 3650
+)
+
+// clientCertNamePattern restricts CNs and DNS SANs to hostname-like names
+var clientCertNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]{0,252}[A-Za-z0-9])?$`)
+
+var clientCertRepo *database.ClientCertRepo
+
+// InitClientCertRepo initializes the client certificate repository
+func InitClientCertRepo() {
+	clientCertRepo = database.NewClientCertRepo()
+}
+
+// loadMTLSMode reads the client certificate mode, defaulting to off
+func loadMTLSMode() models.MTLSMode {
+	mode, err := settingsRepo.Get(database.SettingMTLSMode)
+	if err != nil {
+		return models.MTLSOff
+	}
+	switch m := models.MTLSMode(mode); m {
+	case models.MTLSOptional, models.MTLSRequired:
+		return m
+	}
+	return models.MTLSOff
+}
+
+// clientCA returns the internal CA, or nil when the backend serves plain HTTP
+func clientCA() *certs.CA {
+	tlsListener.mu.Lock()
+	defer tlsListener.mu.Unlock()
+	return tlsListener.ca
+}
+
+// mtlsStatus reports the mode and the CA clients must trust
+func mtlsStatus() models.MTLSStatus {
+	status := models.MTLSStatus{Mode: loadMTLSMode()}
+	if ca := clientCA(); ca != nil {
+		status.Available = true
+		status.CAFingerprint = ca.Fingerprint()
+		status.CANotAfter = ca.Cert.NotAfter
+	}
+	return status
+}
+
+// getMTLSHandler handles GET /api/system/mtls
+func getMTLSHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, mtlsStatus())
+}
+
+// updateMTLSHandler handles PUT /api/system/mtls
+func updateMTLSHandler(c echo.Context) error {
+	var req models.UpdateMTLSRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	switch req.Mode {
+	case models.MTLSOff, models.MTLSOptional, models.MTLSRequired:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "mode must be off, optional or required",
+		})
+	}
+	if req.Mode != models.MTLSOff && clientCA() == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Client certificates require the HTTPS listener",
+		})
+	}
+	// Requiring certificates from a browser without one would end this admin's access
+	if req.Mode == models.MTLSRequired && auth.VerifiedClientCert(c) == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Connect with a client certificate before requiring them for every connection",
+		})
+	}
+
+	if err := settingsRepo.Set(database.SettingMTLSMode, string(req.Mode)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save mTLS mode: " + err.Error(),
+		})
+	}
+	applyTLSSettings(loadTLSSettings())
+
+	Audit.LogFromContext(c, models.ActionMTLSSettings, "mtls", map[string]interface{}{
+		"mode": req.Mode,
+	})
+
+	return c.JSON(http.StatusOK, mtlsStatus())
+}
+
+// downloadClientCAHandler handles GET /api/system/mtls/ca.crt
+func downloadClientCAHandler(c echo.Context) error {
+	ca := clientCA()
+	if ca == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Client certificates require the HTTPS listener",
+		})
+	}
+	c.Response().Header().Set("Content-Disposition", `attachment; filename="stardeck-client-ca.crt"`)
+	return c.Blob(http.StatusOK, "application/x-pem-file", ca.CertPEM())
+}
+
+// listClientCertsHandler handles GET /api/system/mtls/certificates
+func listClientCertsHandler(c echo.Context) error {
+	list, err := clientCertRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list client certificates: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// issueClientCertHandler handles POST /api/system/mtls/certificates
+func issueClientCertHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	var req models.IssueClientCertRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.CommonName = strings.TrimSpace(req.CommonName)
+	if !clientCertNamePattern.MatchString(req.CommonName) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "common_name must be a hostname-like name",
+		})
+	}
+	for _, name := range req.DNSNames {
+		if !clientCertNamePattern.MatchString(name) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid DNS name: " + name,
+			})
+		}
+	}
+	if req.Scope == "" {
+		req.Scope = models.ClientCertScopeFull
+	}
+	if req.Scope != models.ClientCertScopeFull && req.Scope != models.ClientCertScopeReadOnly {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "scope must be full or read_only",
+		})
+	}
+	if req.ValidDays == 0 {
+		req.ValidDays = defaultClientCertDays
+	}
+	if req.ValidDays < 1 || req.ValidDays > maxClientCertDays {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "valid_days must be between 1 and " + strconv.Itoa(maxClientCertDays),
+		})
+	}
+
+	target, err := userRepo.GetByID(req.UserID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "User not found",
+		})
+	}
+
+	ca := clientCA()
+	if ca == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Client certificates require the HTTPS listener",
+		})
+	}
+	issued, err := ca.IssueClientCert(req.CommonName, req.DNSNames, time.Duration(req.ValidDays)*24*time.Hour)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to issue certificate: " + err.Error(),
+		})
+	}
+
+	record := &models.ClientCertificate{
+		Serial:     certs.SerialHex(issued.Cert),
+		CommonName: req.CommonName,
+		DNSNames:   req.DNSNames,
+		UserID:     target.ID,
+		Username:   target.Username,
+		Scope:      req.Scope,
+		NotAfter:   issued.Cert.NotAfter,
+		CreatedBy:  user.Username,
+	}
+	if record.DNSNames == nil {
+		record.DNSNames = []string{}
+	}
+	if err := clientCertRepo.Create(record); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save certificate: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionClientCertIssue, req.CommonName, map[string]interface{}{
+		"id":        record.ID,
+		"serial":    record.Serial,
+		"user":      target.Username,
+		"scope":     record.Scope,
+		"not_after": record.NotAfter,
+	})
+
+	return c.JSON(http.StatusCreated, models.IssuedClientCert{
+		Certificate: *record,
+		CertPEM:     string(issued.CertPEM),
+		KeyPEM:      string(issued.KeyPEM),
+		CAPEM:       string(ca.CertPEM()),
+	})
+}
+
+// revokeClientCertHandler handles DELETE /api/system/mtls/certificates/:id
+func revokeClientCertHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid certificate ID",
+		})
+	}
+	record, err := clientCertRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Certificate not found",
+		})
+	}
+
+	if err := clientCertRepo.Revoke(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Certificate is already revoked",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to revoke certificate: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionClientCertRevoke, record.CommonName, map[string]interface{}{
+		"id":     record.ID,
+		"serial": record.Serial,
+		"user":   record.Username,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Certificate revoked",
+	})
+}
@@ -0,0 +1,476 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var cloudMountRepo *database.CloudMountRepo
+
+// cloudMountCheckInterval is how often mounts are health checked
+const cloudMountCheckInterval = 2 * time.Minute
+
+// cloudMountDown holds the states that alert when a mount enters them
+var cloudMountDown = map[string]bool{
+	models.CloudMountUnhealthy: true,
+	models.CloudMountUnmounted: true,
+}
+
+// InitCloudStorage initializes the cloud mount repository and starts the health checks
+func InitCloudStorage() {
+	cloudMountRepo = database.NewCloudMountRepo()
+	go runCloudMountChecks()
+}
+
+func runCloudMountChecks() {
+	ticker := time.NewTicker(cloudMountCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		mounts, err := cloudMountRepo.List()
+		if err != nil {
+			log.Printf("Warning: failed to list cloud mounts: %v", err)
+			continue
+		}
+		for i := range mounts {
+			if mounts[i].Enabled {
+				checkCloudMount(&mounts[i], true)
+			}
+		}
+	}
+}
+
+// checkCloudMount checks a mount, records the result and alerts admins when it
+// goes down. With recover set, a mount that has dropped is restarted once.
+func checkCloudMount(m *models.CloudMount, recover bool) {
+	status, err := system.CheckCloudMount(m)
+	if status == models.CloudMountUnmounted && recover {
+		if restartErr := system.RestartCloudMount(m); restartErr == nil {
+			status, err = system.CheckCloudMount(m)
+		}
+	}
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if err := cloudMountRepo.RecordCheck(m.ID, status, errMsg); err != nil {
+		log.Printf("Warning: failed to save cloud mount check for %s: %v", m.Name, err)
+	}
+
+	if cloudMountDown[status] && !cloudMountDown[m.Status] {
+		notifyRoles(localized(models.Notification{
+			Type:  models.NotificationCloudMountDown,
+			Level: models.NotificationError,
+			Data: map[string]interface{}{
+				"mount_id": m.ID,
+				"remote":   m.Remote,
+				"volume":   m.VolumeName,
+			},
+		}, "notification.cloud_mount_down",
+			"mount", m.Name,
+			"status", status,
+			"remote", m.Remote,
+			"remote_path", m.RemotePath,
+			"path", m.MountPath,
+			"error", errMsg,
+		), models.RoleAdmin)
+	}
+	now := time.Now()
+	m.Status, m.LastError, m.CheckedAt = status, errMsg, &now
+}
+
+// cloudMountPath returns where a mount appears on the host
+func cloudMountPath(name string) string {
+	return filepath.Join(appDataRoot(), "cloud", name)
+}
+
+// checkCloudMountRequest validates a mount request against the configured remotes
+func checkCloudMountRequest(ctx context.Context, req *models.CloudMountRequest) error {
+	if !system.CloudNamePattern.MatchString(req.Name) {
+		return fmt.Errorf("name must be up to 32 lowercase letters, digits, '-' or '_'")
+	}
+	if _, err := system.GetCloudRemote(ctx, req.Remote); err != nil {
+		return fmt.Errorf("remote %q is not configured", req.Remote)
+	}
+	req.RemotePath = strings.Trim(strings.TrimSpace(req.RemotePath), "/")
+	if strings.ContainsAny(req.RemotePath, "\r\n") {
+		return fmt.Errorf("remote_path can't span lines")
+	}
+	if req.CacheMode == "" {
+		req.CacheMode = "writes"
+	}
+	for _, mode := range system.CloudCacheModes {
+		if req.CacheMode == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("cache_mode must be one of %s", strings.Join(system.CloudCacheModes, ", "))
+}
+
+// listCloudRemotesHandler handles GET /api/storage/cloud/remotes
+func listCloudRemotesHandler(c echo.Context) error {
+	remotes, err := system.ListCloudRemotes(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list remotes: " + err.Error(),
+		})
+	}
+	for i := range remotes {
+		remotes[i].Mounts, _ = cloudMountRepo.CountByRemote(remotes[i].Name)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"available": system.RcloneAvailable(),
+		"remotes":   remotes,
+	})
+}
+
+// createCloudRemoteHandler handles POST /api/storage/cloud/remotes
+func createCloudRemoteHandler(c echo.Context) error {
+	var req models.CloudRemoteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := system.ValidateCloudRemote(&req, false); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	if _, err := system.GetCloudRemote(ctx, req.Name); err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "A remote with this name already exists",
+		})
+	}
+	if err := system.SaveCloudRemote(ctx, &req, false); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create remote: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionCloudRemoteCreate, req.Name, map[string]interface{}{
+		"type": req.Type,
+	})
+
+	remote, err := system.GetCloudRemote(ctx, req.Name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read remote: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, remote)
+}
+
+// updateCloudRemoteHandler handles PUT /api/storage/cloud/remotes/:name
+func updateCloudRemoteHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	existing, err := system.GetCloudRemote(ctx, c.Param("name"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Remote not found",
+		})
+	}
+
+	var req models.CloudRemoteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	req.Name, req.Type = existing.Name, existing.Type
+	if err := system.ValidateCloudRemote(&req, true); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if len(req.Options) > 0 {
+		if err := system.SaveCloudRemote(ctx, &req, true); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to update remote: " + err.Error(),
+			})
+		}
+	}
+
+	Audit.LogFromContext(c, models.ActionCloudRemoteUpdate, req.Name, nil)
+
+	remote, err := system.GetCloudRemote(ctx, req.Name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read remote: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, remote)
+}
+
+// testCloudRemoteHandler handles POST /api/storage/cloud/remotes/:name/test
+func testCloudRemoteHandler(c echo.Context) error {
+	name := c.Param("name")
+	if _, err := system.GetCloudRemote(c.Request().Context(), name); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Remote not found",
+		})
+	}
+	if err := system.TestCloudRemote(c.Request().Context(), name, c.QueryParam("path")); err != nil {
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
+
+// deleteCloudRemoteHandler handles DELETE /api/storage/cloud/remotes/:name
+func deleteCloudRemoteHandler(c echo.Context) error {
+	name := c.Param("name")
+	if _, err := system.GetCloudRemote(c.Request().Context(), name); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Remote not found",
+		})
+	}
+	if n, _ := cloudMountRepo.CountByRemote(name); n > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": fmt.Sprintf("Remote is used by %d cloud mount(s)", n),
+		})
+	}
+	if err := system.DeleteCloudRemote(c.Request().Context(), name); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete remote: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionCloudRemoteDelete, name, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Remote deleted",
+	})
+}
+
+// listCloudMountsHandler handles GET /api/storage/cloud/mounts
+func listCloudMountsHandler(c echo.Context) error {
+	mounts, err := cloudMountRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list cloud mounts: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, mounts)
+}
+
+// createCloudMountHandler handles POST /api/storage/cloud/mounts. The mount is
+// started and bound to a Podman volume that containers can use like any other.
+func createCloudMountHandler(c echo.Context) error {
+	var req models.CloudMountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	if err := checkCloudMountRequest(ctx, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	m := &models.CloudMount{
+		Name:       req.Name,
+		Remote:     req.Remote,
+		RemotePath: req.RemotePath,
+		MountPath:  cloudMountPath(req.Name),
+		VolumeName: "cloud-" + req.Name,
+		ReadOnly:   req.ReadOnly,
+		CacheMode:  req.CacheMode,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+		CreatedBy:  &user.ID,
+	}
+	if err := cloudMountRepo.Create(m); err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Failed to create cloud mount: " + err.Error(),
+		})
+	}
+
+	// Undo everything if the mount doesn't come up, so a bad remote leaves nothing behind
+	fail := func(msg string, err error) error {
+		system.StopCloudMount(m)
+		cloudMountRepo.Delete(m.ID)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": msg + err.Error(),
+		})
+	}
+	if m.Enabled {
+		if err := system.StartCloudMount(m); err != nil {
+			return fail("Failed to start cloud mount: ", err)
+		}
+	} else if err := os.MkdirAll(m.MountPath, 0755); err != nil {
+		return fail("Failed to create mount point: ", err)
+	}
+	if err := podmanService.CreateVolume(ctx, &models.CreateVolumeRequest{
+		Name:    m.VolumeName,
+		Labels:  map[string]string{"stardeck.cloud_mount": m.Name},
+		Options: map[string]string{"type": "none", "o": "bind", "device": m.MountPath},
+	}); err != nil {
+		return fail("Failed to create volume: ", err)
+	}
+	checkCloudMount(m, false)
+
+	Audit.LogFromContext(c, models.ActionCloudMountCreate, m.Name, map[string]interface{}{
+		"remote":      m.Remote,
+		"remote_path": m.RemotePath,
+		"read_only":   m.ReadOnly,
+		"volume":      m.VolumeName,
+	})
+
+	return c.JSON(http.StatusCreated, m)
+}
+
+// updateCloudMountHandler handles PUT /api/storage/cloud/mounts/:id and
+// remounts with the new settings
+func updateCloudMountHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid mount ID",
+		})
+	}
+	m, err := cloudMountRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Cloud mount not found",
+		})
+	}
+
+	req := models.CloudMountRequest{Enabled: &m.Enabled}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	req.Name = m.Name
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	if err := checkCloudMountRequest(ctx, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	m.Remote, m.RemotePath, m.ReadOnly = req.Remote, req.RemotePath, req.ReadOnly
+	m.CacheMode, m.Enabled = req.CacheMode, *req.Enabled
+	if err := cloudMountRepo.Update(m); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update cloud mount: " + err.Error(),
+		})
+	}
+	if m.Enabled {
+		err = system.StartCloudMount(m)
+	} else {
+		err = system.StopCloudMount(m)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Cloud mount saved but could not be applied: " + err.Error(),
+		})
+	}
+	checkCloudMount(m, false)
+
+	Audit.LogFromContext(c, models.ActionCloudMountUpdate, m.Name, map[string]interface{}{
+		"remote":      m.Remote,
+		"remote_path": m.RemotePath,
+		"read_only":   m.ReadOnly,
+		"enabled":     m.Enabled,
+	})
+
+	return c.JSON(http.StatusOK, m)
+}
+
+// checkCloudMountHandler handles POST /api/storage/cloud/mounts/:id/check
+func checkCloudMountHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid mount ID",
+		})
+	}
+	m, err := cloudMountRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Cloud mount not found",
+		})
+	}
+	checkCloudMount(m, c.QueryParam("recover") == "true")
+	return c.JSON(http.StatusOK, m)
+}
+
+// deleteCloudMountHandler handles DELETE /api/storage/cloud/mounts/:id. A
+// mount whose volume is still used by containers is refused.
+func deleteCloudMountHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid mount ID",
+		})
+	}
+	m, err := cloudMountRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Cloud mount not found",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	if _, err := podmanService.InspectVolume(ctx, m.VolumeName); err == nil {
+		if err := podmanService.RemoveVolume(ctx, m.VolumeName, false); err != nil {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Volume " + m.VolumeName + " could not be removed, it may still be in use: " + err.Error(),
+			})
+		}
+	}
+	if err := system.StopCloudMount(m); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	os.Remove(m.MountPath) // Only succeeds once unmounted and empty
+	if err := cloudMountRepo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete cloud mount: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionCloudMountDelete, m.Name, map[string]interface{}{
+		"volume": m.VolumeName,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Cloud mount deleted",
+	})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// composeExportService reads a container's configuration with its Stardeck
+// metadata. images caches image inspections across containers.
+func composeExportService(ctx context.Context, containerID string, images map[string]*system.ImageConfig) (system.ComposeExportService, error) {
+	config, err := podmanService.GetContainerConfig(ctx, containerID)
+	if err != nil {
+		return system.ComposeExportService{}, err
+	}
+	if dbContainer, err := containerRepo.GetByContainerID(containerID); err == nil {
+		config.HasWebUI = dbContainer.HasWebUI
+		config.WebUIPort = dbContainer.WebUIPort
+		config.WebUIPath = dbContainer.WebUIPath
+		config.Icon = dbContainer.Icon
+	}
+
+	image, ok := images[config.Image]
+	if !ok {
+		// Without the image every setting is exported, which is still valid
+		image, _ = podmanService.InspectImage(ctx, config.Image, false)
+		images[config.Image] = image
+	}
+	return system.ComposeExportService{Config: config, Image: image}, nil
+}
+
+// sendCompose responds with a compose file, as a download when ?download=true
+func sendCompose(c echo.Context, filename, content string) error {
+	if c.QueryParam("download") == "true" {
+		c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	return c.Blob(http.StatusOK, "application/yaml", []byte(content))
+}
+
+// exportContainerComposeHandler handles GET /api/containers/:id/export-compose,
+// rendering the container as a compose service
+func exportContainerComposeHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	containerID := resolveContainerID(c.Param("id"))
+	service, err := composeExportService(ctx, containerID, make(map[string]*system.ImageConfig))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get container config: " + err.Error(),
+		})
+	}
+	return sendCompose(c, service.Config.Name+"-compose.yaml", system.RenderCompose([]system.ComposeExportService{service}))
+}
+
+// exportAllComposeHandler handles GET /api/containers/export-compose, rendering
+// every Stardeck container the user can see as one compose file
+func exportAllComposeHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+	defer cancel()
+
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.list_containers", "error", err.Error()),
+		})
+	}
+	containerIDs := make([]string, len(containers))
+	for i, container := range containers {
+		containerIDs[i] = container.ContainerID
+	}
+	managed, err := containerRepo.GetByContainerIDs(containerIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load containers: " + err.Error(),
+		})
+	}
+
+	images := make(map[string]*system.ImageConfig)
+	var services []system.ComposeExportService
+	for _, container := range containers {
+		dbContainer, ok := managed[container.ContainerID]
+		if !ok || !view.visible(view.containerProject(dbContainer.ID, container.Stack)) {
+			continue
+		}
+		service, err := composeExportService(ctx, container.ContainerID, images)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to get config of %s: %v", container.Name, err),
+			})
+		}
+		services = append(services, service)
+	}
+	return sendCompose(c, "stardeck-compose.yaml", system.RenderCompose(services))
+}
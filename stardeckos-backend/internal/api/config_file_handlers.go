@@ -0,0 +1,518 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	// configBackupDir holds copies of config files taken before each save
+	configBackupDir = "/var/lib/stardeck/config-backups"
+
+	// configBackupsKept is how many backups are retained per file
+	configBackupsKept = 10
+
+	maxConfigFileSize  = 1024 * 1024
+	maxConfigScanDepth = 3
+	maxConfigFiles     = 200
+)
+
+// configLanguages maps file extensions to syntax highlighting hints
+var configLanguages = map[string]string{
+	".yml":        "yaml",
+	".yaml":       "yaml",
+	".json":       "json",
+	".toml":       "toml",
+	".ini":        "ini",
+	".cfg":        "ini",
+	".conf":       "nginx",
+	".xml":        "xml",
+	".properties": "ini",
+	".env":        "shell",
+	".sh":         "shell",
+}
+
+// configFileNames are well-known config files without a recognised extension
+var configFileNames = map[string]string{
+	".env":      "shell",
+	"Caddyfile": "plaintext",
+	"config":    "plaintext",
+}
+
+// configSkipDirs are directories that never contain hand-edited config
+var configSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, "cache": true, "logs": true, "log": true, "tmp": true,
+}
+
+// configLanguage returns the highlighting hint for a file, or "" if it is not a config file
+func configLanguage(name string) string {
+	if lang, ok := configFileNames[name]; ok {
+		return lang
+	}
+	return configLanguages[strings.ToLower(filepath.Ext(name))]
+}
+
+// containerBindMounts returns the bind mounts of a container
+func containerBindMounts(ctx context.Context, containerID string) ([]BindMount, error) {
+	inspect, err := podmanService.InspectContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	var mounts []BindMount
+	for _, m := range inspect.Mounts {
+		if m.Type == "bind" {
+			mounts = append(mounts, BindMount{
+				HostPath:      m.Source,
+				ContainerPath: m.Destination,
+				ContainerID:   inspect.ID,
+				ContainerName: strings.TrimPrefix(inspect.Name, "/"),
+				ReadWrite:     m.RW,
+			})
+		}
+	}
+	return mounts, nil
+}
+
+// configFileInfo describes a host file relative to the bind mount it was found in
+func configFileInfo(path string, info fs.FileInfo, mount BindMount) models.ConfigFile {
+	rel, _ := filepath.Rel(mount.HostPath, path)
+	lang := configLanguage(info.Name())
+	if lang == "" {
+		lang = "plaintext"
+	}
+	return models.ConfigFile{
+		Path:          path,
+		ContainerPath: filepath.Join(mount.ContainerPath, rel),
+		Name:          info.Name(),
+		Language:      lang,
+		Size:          info.Size(),
+		ModifiedAt:    info.ModTime(),
+		ReadOnly:      !mount.ReadWrite,
+	}
+}
+
+// resolveConfigFile checks that a host path is a regular file inside one of the container's bind mounts
+func resolveConfigFile(ctx context.Context, containerID, path string) (string, fs.FileInfo, BindMount, error) {
+	if !filepath.IsAbs(path) {
+		return "", nil, BindMount{}, fmt.Errorf("path must be absolute")
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", nil, BindMount{}, fmt.Errorf("file not found")
+	}
+	info, err := os.Stat(resolved)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", nil, BindMount{}, fmt.Errorf("not a regular file")
+	}
+	if info.Size() > maxConfigFileSize {
+		return "", nil, BindMount{}, fmt.Errorf("file is larger than %d bytes", maxConfigFileSize)
+	}
+
+	mounts, err := containerBindMounts(ctx, containerID)
+	if err != nil {
+		return "", nil, BindMount{}, err
+	}
+	for _, m := range mounts {
+		root, err := filepath.EvalSymlinks(m.HostPath)
+		if err != nil {
+			continue
+		}
+		if resolved == root || strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+			m.HostPath = root
+			return resolved, info, m, nil
+		}
+	}
+	return "", nil, BindMount{}, fmt.Errorf("file is not inside any of the container's bind mounts")
+}
+
+// validateConfigContent performs a syntax check for formats the standard library can parse
+func validateConfigContent(language, content string) error {
+	switch language {
+	case "json":
+		var v interface{}
+		if err := json.Unmarshal([]byte(content), &v); err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+	case "xml":
+		dec := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("invalid XML: %w", err)
+			}
+		}
+	case "yaml":
+		// YAML forbids tabs in indentation, the most common hand-edit mistake
+		for i, line := range strings.Split(content, "\n") {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			if strings.Contains(indent, "\t") {
+				return fmt.Errorf("invalid YAML: line %d is indented with a tab", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// configBackupPath returns the directory backups of a host file are kept in
+func configBackupPath(containerID, path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(configBackupDir, containerID, hex.EncodeToString(sum[:8]))
+}
+
+// backupConfigFile copies a file's current contents aside and prunes old backups
+func backupConfigFile(containerID, path string, data []byte) (string, error) {
+	dir := configBackupPath(containerID, path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	backup := filepath.Join(dir, filepath.Base(path)+"."+time.Now().Format("20060102-150405.000"))
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return "", err
+	}
+
+	backups, _ := listConfigBackups(containerID, path)
+	for i := configBackupsKept; i < len(backups); i++ {
+		os.Remove(backups[i].Path)
+	}
+	return backup, nil
+}
+
+// listConfigBackups returns a file's backups, newest first
+func listConfigBackups(containerID, path string) ([]models.ConfigFileBackup, error) {
+	entries, err := os.ReadDir(configBackupPath(containerID, path))
+	if os.IsNotExist(err) {
+		return []models.ConfigFileBackup{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []models.ConfigFileBackup{}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		backups = append(backups, models.ConfigFileBackup{
+			Path:      filepath.Join(configBackupPath(containerID, path), e.Name()),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// listConfigFilesHandler finds config files inside a container's bind mounts
+func listConfigFilesHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	mounts, err := containerBindMounts(ctx, container.ContainerID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to inspect container: " + err.Error(),
+		})
+	}
+
+	files := []models.ConfigFile{}
+	for _, m := range mounts {
+		info, err := os.Stat(m.HostPath)
+		if err != nil {
+			continue
+		}
+		// A single file mounted directly is always offered, whatever its name
+		if info.Mode().IsRegular() {
+			if info.Size() <= maxConfigFileSize {
+				files = append(files, configFileInfo(m.HostPath, info, m))
+			}
+			continue
+		}
+
+		filepath.WalkDir(m.HostPath, func(path string, d fs.DirEntry, err error) error {
+			if len(files) >= maxConfigFiles {
+				return filepath.SkipAll
+			}
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				rel, _ := filepath.Rel(m.HostPath, path)
+				if path != m.HostPath && (configSkipDirs[d.Name()] || strings.Count(rel, string(filepath.Separator)) >= maxConfigScanDepth-1) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || configLanguage(d.Name()) == "" {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && info.Size() <= maxConfigFileSize {
+				files = append(files, configFileInfo(path, info, m))
+			}
+			return nil
+		})
+	}
+
+	return c.JSON(http.StatusOK, files)
+}
+
+// getConfigFileHandler returns a config file's content for editing
+func getConfigFileHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	path, info, mount, err := resolveConfigFile(ctx, container.ContainerID, c.QueryParam("path"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read file: " + err.Error(),
+		})
+	}
+	if !isValidUTF8(data) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "File appears to be binary",
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ConfigFileContent{
+		ConfigFile: configFileInfo(path, info, mount),
+		Content:    string(data),
+		Hash:       contentHash(data),
+	})
+}
+
+// saveConfigFileHandler validates and writes a config file, backing up the previous version
+func saveConfigFileHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.SaveConfigFileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if len(req.Content) > maxConfigFileSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Content is larger than %d bytes", maxConfigFileSize),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
+	defer cancel()
+
+	path, info, mount, err := resolveConfigFile(ctx, container.ContainerID, req.Path)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read file: " + err.Error(),
+		})
+	}
+	if req.ExpectedHash != "" && req.ExpectedHash != contentHash(current) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "File has changed since it was opened; reload before saving",
+		})
+	}
+
+	file := configFileInfo(path, info, mount)
+	if !req.SkipValidate {
+		if err := validateConfigContent(file.Language, req.Content); err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	resp := models.SaveConfigFileResponse{}
+	if !bytes.Equal(current, []byte(req.Content)) {
+		backup, err := backupConfigFile(container.ContainerID, path, current)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to back up file: " + err.Error(),
+			})
+		}
+		resp.BackupPath = backup
+
+		if err := system.WriteFileContent(path, []byte(req.Content), info.Mode()); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to write file: " + err.Error(),
+			})
+		}
+	}
+
+	if newInfo, err := os.Stat(path); err == nil {
+		file = configFileInfo(path, newInfo, mount)
+	}
+	resp.File = file
+	resp.Hash = contentHash([]byte(req.Content))
+
+	if resp.BackupPath != "" {
+		if req.Restart {
+			if err := podmanService.RestartContainer(ctx, container.ContainerID, 10); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "File saved but restart failed: " + err.Error(),
+				})
+			}
+			resp.Restarted = true
+		} else if inspect, err := podmanService.InspectContainer(ctx, container.ContainerID); err == nil {
+			resp.RestartRequired = inspect.State.Running
+		}
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionConfigFileSave, container.Name, map[string]interface{}{
+		"path":      path,
+		"backup":    resp.BackupPath,
+		"restarted": resp.Restarted,
+	})
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// listConfigBackupsHandler returns the saved backups of a config file
+func listConfigBackupsHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	path, _, _, err := resolveConfigFile(ctx, container.ContainerID, c.QueryParam("path"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	backups, err := listConfigBackups(container.ContainerID, path)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list backups: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, backups)
+}
+
+// restoreConfigBackupHandler writes a backup back over its config file
+func restoreConfigBackupHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req struct {
+		Path   string `json:"path"`
+		Backup string `json:"backup"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	path, info, _, err := resolveConfigFile(ctx, container.ContainerID, req.Path)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// Only backups taken for this file may be restored
+	backup := filepath.Clean(req.Backup)
+	if filepath.Dir(backup) != configBackupPath(container.ContainerID, path) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Backup does not belong to this file",
+		})
+	}
+	data, err := os.ReadFile(backup)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Backup not found",
+		})
+	}
+
+	// Keep the version being replaced so a restore can itself be undone
+	if current, err := os.ReadFile(path); err == nil {
+		backupConfigFile(container.ContainerID, path, current)
+	}
+	if err := system.WriteFileContent(path, data, info.Mode()); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to write file: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionConfigFileRestore, container.Name, map[string]interface{}{
+		"path":   path,
+		"backup": backup,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Backup restored",
+		"hash":    contentHash(data),
+	})
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var configHistoryRepo *database.ConfigHistoryRepo
+
+// InitConfigHistoryRepo initializes the container configuration history repository
+func InitConfigHistoryRepo() {
+	configHistoryRepo = database.NewConfigHistoryRepo()
+}
+
+// withContainerMetadata overlays the Stardeck metadata kept in the database
+// on a configuration read from podman
+func withContainerMetadata(config *models.ContainerConfig, dbContainer *models.Container) {
+	config.HasWebUI = dbContainer.HasWebUI
+	config.WebUIPort = dbContainer.WebUIPort
+	config.WebUIPath = dbContainer.WebUIPath
+	config.Icon = dbContainer.Icon
+	config.IconLight = dbContainer.IconLight
+	config.IconDark = dbContainer.IconDark
+	config.AutoStart = dbContainer.AutoStart
+}
+
+// liveContainerConfig reads a managed container's current configuration
+func liveContainerConfig(ctx context.Context, dbContainer *models.Container) (*models.ContainerConfig, error) {
+	config, err := podmanService.GetContainerConfig(ctx, dbContainer.ContainerID)
+	if err != nil {
+		return nil, err
+	}
+	withContainerMetadata(config, dbContainer)
+	return config, nil
+}
+
+// recordContainerConfig snapshots a container's configuration after a change
+// made through Stardeck. Nothing is recorded when the configuration matches
+// the latest snapshot. Failures are logged rather than failing the change.
+func recordContainerConfig(ctx context.Context, dbContainer *models.Container, reason string, userID *int64) {
+	if configHistoryRepo == nil || dbContainer == nil || dbContainer.ID == "" {
+		return
+	}
+	config, err := liveContainerConfig(ctx, dbContainer)
+	if err != nil {
+		log.Printf("Config history: failed to read config of %s: %v", dbContainer.Name, err)
+		return
+	}
+	hash := system.HashContainerConfig(config)
+	if latest, err := configHistoryRepo.Latest(dbContainer.ID); err == nil && latest.ConfigHash == hash {
+		return
+	}
+	snapshot := &models.ContainerConfigSnapshot{
+		ContainerID: dbContainer.ID,
+		PodmanID:    dbContainer.ContainerID,
+		Reason:      reason,
+		Config:      config,
+		ConfigHash:  hash,
+		CreatedBy:   userID,
+	}
+	if err := configHistoryRepo.Add(snapshot); err != nil {
+		log.Printf("Config history: failed to record config of %s: %v", dbContainer.Name, err)
+	}
+}
+
+// listConfigHistoryHandler returns a container's recorded configurations,
+// newest first, each with its changes from the version before it
+func listConfigHistoryHandler(c echo.Context) error {
+	dbContainer, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	snapshots, err := configHistoryRepo.List(dbContainer.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load config history: " + err.Error(),
+		})
+	}
+
+	entries := make([]models.ConfigHistoryEntry, len(snapshots))
+	for i := range snapshots {
+		entry := models.ConfigHistoryEntry{ContainerConfigSnapshot: snapshots[i], Changes: []models.ConfigChange{}}
+		if i > 0 {
+			entry.Changes = system.DiffContainerConfigs(snapshots[i-1].Config, snapshots[i].Config)
+		}
+		entry.Config = nil
+		entries[len(snapshots)-1-i] = entry
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+// getConfigVersionHandler returns one recorded configuration in full
+func getConfigVersionHandler(c echo.Context) error {
+	dbContainer, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid version",
+		})
+	}
+
+	snapshot, err := configHistoryRepo.GetVersion(dbContainer.ID, version)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Version not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load config version: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, snapshot)
+}
+
+// diffConfigHistoryHandler compares two recorded configurations, or one with
+// the container's live configuration. from defaults to the latest snapshot
+// and to to the live configuration, so with no parameters it shows what has
+// changed outside Stardeck since the last recorded change.
+func diffConfigHistoryHandler(c echo.Context) error {
+	dbContainer, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	diff := models.ConfigDiff{}
+	for param, version := range map[string]*int{"from": &diff.From, "to": &diff.To} {
+		if value := c.QueryParam(param); value != "" && value != "live" {
+			if *version, err = strconv.Atoi(value); err != nil || *version < 1 {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid " + param + " version",
+				})
+			}
+		}
+	}
+
+	var from, to *models.ContainerConfig
+	var snapshot *models.ContainerConfigSnapshot
+	if diff.From > 0 {
+		snapshot, err = configHistoryRepo.GetVersion(dbContainer.ID, diff.From)
+	} else {
+		snapshot, err = configHistoryRepo.Latest(dbContainer.ID)
+	}
+	if err == nil {
+		diff.From, from = snapshot.Version, snapshot.Config
+		if diff.To > 0 {
+			if snapshot, err = configHistoryRepo.GetVersion(dbContainer.ID, diff.To); err == nil {
+				to = snapshot.Config
+			}
+		}
+	}
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Version not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load config version: " + err.Error(),
+		})
+	}
+
+	if diff.To == 0 {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+		defer cancel()
+		if to, err = liveContainerConfig(ctx, dbContainer); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to get container config: " + err.Error(),
+			})
+		}
+	}
+
+	diff.Changes = system.DiffContainerConfigs(from, to)
+	return c.JSON(http.StatusOK, diff)
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// attachContainerHandler provides a WebSocket to a container's main process,
+// for console-based apps such as game servers and REPLs. Unlike exec it
+// doesn't start a shell; input goes to the process's own stdin. The session
+// ends when the detach keys (?detach_keys=, default ctrl-p,ctrl-q) are typed
+// or sent with a "detach" message, and the container keeps running.
+func attachContainerHandler(c echo.Context) error {
+	id := c.Param("id")
+	containerID := resolveContainerID(id)
+
+	detachKeys := system.DefaultDetachKeys
+	if c.QueryParams().Has("detach_keys") {
+		detachKeys = c.QueryParam("detach_keys")
+	}
+	detachSeq, err := system.ParseDetachKeys(detachKeys)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	inspect, err := podmanService.InspectContainer(c.Request().Context(), containerID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+	if !inspect.State.Running {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Container is not running",
+		})
+	}
+
+	// Upgrade to WebSocket
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	// The output goroutine and the read loop both reply on the socket
+	var writeMu sync.Mutex
+	send := func(msg map[string]interface{}) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		ws.WriteJSON(msg)
+	}
+
+	// Create context that cancels when WebSocket closes
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	stdin, output, err := podmanService.AttachInteractive(ctx, containerID, detachKeys)
+	if err != nil {
+		send(map[string]interface{}{
+			"type":    "error",
+			"message": "Failed to attach: " + err.Error(),
+		})
+		return nil
+	}
+	defer stdin.Close()
+	defer output.Close()
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionContainerAttach, inspect.Name, map[string]interface{}{
+		"container_id": containerID,
+	})
+
+	attached := map[string]interface{}{
+		"type":        "attached",
+		"tty":         inspect.Config.Tty,
+		"stdin":       inspect.Config.OpenStdin,
+		"detach_keys": detachKeys,
+	}
+	if !inspect.Config.OpenStdin {
+		attached["warning"] = "The container was not started with stdin open (-i), so input can't reach it"
+	}
+	send(attached)
+
+	// Read from the container and send to WebSocket
+	go func() {
+		// Closing the socket ends the read loop below
+		defer ws.Close()
+		defer cancel()
+		buf := make([]byte, 4096)
+		for {
+			n, err := output.Read(buf)
+			if n > 0 {
+				send(map[string]interface{}{
+					"type": "output",
+					"data": string(buf[:n]),
+				})
+			}
+			if err != nil {
+				// podman exits cleanly on detach and when the container stops
+				if err == io.EOF || ctx.Err() != nil {
+					send(map[string]interface{}{"type": "detached"})
+				} else {
+					send(map[string]interface{}{
+						"type":    "error",
+						"message": "Attach session ended: " + err.Error(),
+					})
+				}
+				return
+			}
+		}
+	}()
+
+	// Read from WebSocket and send to the container's stdin
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			return nil
+		}
+
+		var msg struct {
+			Type  string `json:"type"`
+			Data  string `json:"data"`
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(message, &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "auth":
+			// Auth is handled by middleware, just acknowledge
+			send(map[string]interface{}{
+				"type":    "auth",
+				"success": true,
+			})
+		case "input":
+			stdin.Write([]byte(msg.Data))
+		case "detach":
+			if len(detachSeq) > 0 {
+				stdin.Write(detachSeq)
+			} else {
+				// Detaching is disabled; ending the attach process leaves the container running
+				cancel()
+			}
+		}
+	}
+}
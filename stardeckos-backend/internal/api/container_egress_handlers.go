@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var containerEgressRepo *database.ContainerEgressRepo
+
+// egressState records why each policy is or isn't enforced after the last
+// rule load, keyed by Stardeck container ID
+var egressState struct {
+	mu      sync.Mutex
+	applyMu sync.Mutex // Serializes rule loads
+	errors  map[string]string
+}
+
+// InitContainerEgressRepo initializes the egress policy repository and loads the rules
+func InitContainerEgressRepo() {
+	containerEgressRepo = database.NewContainerEgressRepo()
+	egressState.errors = make(map[string]string)
+	go func() {
+		if err := applyEgressPolicies(); err != nil {
+			log.Printf("Warning: failed to load container egress rules: %v", err)
+		}
+	}()
+}
+
+// applyEgressPolicies loads the rules for every policy against the
+// containers' current addresses. Addresses change when a container restarts,
+// so this also runs on container start events.
+func applyEgressPolicies() error {
+	egressState.applyMu.Lock()
+	defer egressState.applyMu.Unlock()
+
+	policies, err := containerEgressRepo.List()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	errs := make(map[string]string)
+	var targets []system.EgressTarget
+	for _, p := range policies {
+		if podmanService.GetMode() == "rootless" {
+			errs[p.ContainerID] = "Rootless containers share the host's network stack and can't be filtered"
+			continue
+		}
+		container, err := containerRepo.GetByID(p.ContainerID)
+		if err != nil {
+			errs[p.ContainerID] = "Container not found"
+			continue
+		}
+		inspect, err := podmanService.InspectContainer(ctx, container.ContainerID)
+		if err != nil {
+			errs[p.ContainerID] = "Container not found in Podman"
+			continue
+		}
+		if inspect.HostConfig.NetworkMode == "host" {
+			errs[p.ContainerID] = "Containers on the host network can't be filtered"
+			continue
+		}
+		var addrs []string
+		for _, n := range inspect.NetworkSettings.Networks {
+			for _, addr := range []string{n.IPAddress, n.GlobalIPv6Address} {
+				if addr != "" {
+					addrs = append(addrs, addr)
+				}
+			}
+		}
+		if len(addrs) == 0 {
+			// Not running; the rules apply once it starts
+			errs[p.ContainerID] = "Container has no network address"
+			continue
+		}
+		targets = append(targets, system.EgressTarget{Key: p.ContainerID, Addresses: addrs, Policy: p.EgressPolicySpec})
+	}
+
+	applyErr := system.ApplyEgressRules(targets)
+	if applyErr != nil {
+		for _, t := range targets {
+			errs[t.Key] = applyErr.Error()
+		}
+	}
+
+	egressState.mu.Lock()
+	egressState.errors = errs
+	egressState.mu.Unlock()
+	return applyErr
+}
+
+// containerStarted reloads egress rules when a filtered container starts
+// with a new address. The container runs unfiltered until the reload, which
+// follows the start event immediately.
+func containerStarted(e system.ContainerEvent) {
+	container, err := containerRepo.GetByContainerID(e.ID)
+	if err != nil {
+		return
+	}
+	if _, err := containerEgressRepo.Get(container.ID); err != nil {
+		return
+	}
+	if err := applyEgressPolicies(); err != nil {
+		log.Printf("Warning: failed to reload container egress rules: %v", err)
+	}
+}
+
+// withEgressState fills in whether a policy is currently enforced
+func withEgressState(p *models.ContainerEgressPolicy) {
+	egressState.mu.Lock()
+	defer egressState.mu.Unlock()
+	p.EnforceError = egressState.errors[p.ContainerID]
+	p.Enforced = p.EnforceError == ""
+}
+
+// saveEgressPolicy stores and loads a container's policy, which must have
+// passed system.ValidateEgressPolicy
+func saveEgressPolicy(container *models.Container, spec models.EgressPolicySpec, user *models.User) (*models.ContainerEgressPolicy, error) {
+	p := &models.ContainerEgressPolicy{
+		ContainerID:      container.ID,
+		ContainerName:    container.Name,
+		EgressPolicySpec: spec,
+		UpdatedBy:        user.Username,
+	}
+	if err := containerEgressRepo.Upsert(p); err != nil {
+		return nil, err
+	}
+	if err := applyEgressPolicies(); err != nil {
+		log.Printf("Warning: failed to load container egress rules: %v", err)
+	}
+
+	logAudit(user, models.ActionContainerEgressUpdate, container.Name, map[string]interface{}{
+		"default_action": spec.DefaultAction,
+		"block_internet": spec.BlockInternet,
+		"rules":          spec.Rules,
+	})
+
+	saved, err := containerEgressRepo.Get(container.ID)
+	if err != nil {
+		return nil, err
+	}
+	withEgressState(saved)
+	return saved, nil
+}
+
+// listContainerEgressHandler handles GET /api/containers/egress
+func listContainerEgressHandler(c echo.Context) error {
+	policies, err := containerEgressRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list egress policies: " + err.Error(),
+		})
+	}
+	for i := range policies {
+		withEgressState(&policies[i])
+	}
+	return c.JSON(http.StatusOK, policies)
+}
+
+// getContainerEgressHandler handles GET /api/containers/:id/egress. Containers
+// without a policy report the unrestricted default.
+func getContainerEgressHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	p, err := containerEgressRepo.Get(container.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(http.StatusOK, models.ContainerEgressPolicy{
+			ContainerID:   container.ID,
+			ContainerName: container.Name,
+			EgressPolicySpec: models.EgressPolicySpec{
+				DefaultAction: models.EgressAllow,
+				Rules:         []models.EgressRule{},
+			},
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get egress policy: " + err.Error(),
+		})
+	}
+	withEgressState(p)
+	return c.JSON(http.StatusOK, p)
+}
+
+// updateContainerEgressHandler handles PUT /api/containers/:id/egress
+func updateContainerEgressHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var spec models.EgressPolicySpec
+	if err := c.Bind(&spec); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := system.ValidateEgressPolicy(&spec); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	p, err := saveEgressPolicy(container, spec, user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save egress policy: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// deleteContainerEgressHandler handles DELETE /api/containers/:id/egress,
+// lifting all outbound restrictions
+func deleteContainerEgressHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	if err := containerEgressRepo.Delete(container.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete egress policy: " + err.Error(),
+		})
+	}
+	if err := applyEgressPolicies(); err != nil {
+		log.Printf("Warning: failed to load container egress rules: %v", err)
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionContainerEgressDelete, container.Name, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Egress policy removed",
+	})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// containerEventStatuses are the Podman events streamed to clients, in
+// addition to the oom events Stardeck derives from died ones and the
+// unhealthy events of the health monitor
+var containerEventStatuses = []string{"create", "start", "stop", "kill", "died", "pause", "unpause", "restart", "remove"}
+
+// containerEventHub fans container events out to connected WebSocket clients
+type containerEventHub struct {
+	mu      sync.Mutex
+	clients map[chan models.ContainerEventMessage]*projectView
+}
+
+var containerEvents = &containerEventHub{
+	clients: make(map[chan models.ContainerEventMessage]*projectView),
+}
+
+// publishContainerEvent sends a Podman event to every client that can see
+// the container
+func publishContainerEvent(e system.ContainerEvent) {
+	msg := models.ContainerEventMessage{
+		Action:      e.Status,
+		ContainerID: e.ID,
+		Name:        e.Name,
+		Image:       e.Image,
+		Stack:       e.Stack,
+		ExitCode:    e.ExitCode,
+		Time:        e.Time,
+	}
+	if container, err := containerRepo.GetByContainerID(e.ID); err == nil {
+		msg.ID = container.ID
+	}
+	containerEvents.publish(msg)
+}
+
+func (h *containerEventHub) publish(msg models.ContainerEventMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch, view := range h.clients {
+		if !view.visible(view.containerProject(msg.ID, msg.Stack)) {
+			continue
+		}
+		// Drop rather than block on a slow client
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+func (h *containerEventHub) subscribe(view *projectView) chan models.ContainerEventMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan models.ContainerEventMessage, 64)
+	h.clients[ch] = view
+	return ch
+}
+
+func (h *containerEventHub) unsubscribe(ch chan models.ContainerEventMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, ch)
+}
+
+// containerEventsHandler streams container lifecycle events over a WebSocket,
+// so clients can follow state changes without polling the container list.
+// Containers in projects the user isn't a member of are left out.
+func containerEventsHandler(c echo.Context) error {
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+
+	// Upgrade to WebSocket
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	ch := containerEvents.subscribe(view)
+	defer containerEvents.unsubscribe(ch)
+
+	// Clients only listen; reading notices when they go away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case msg := <-ch:
+			if err := ws.WriteJSON(msg); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return nil
+			}
+		case <-closed:
+			return nil
+		}
+	}
+}
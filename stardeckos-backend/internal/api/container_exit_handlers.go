@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var containerExitRepo *database.ContainerExitRepo
+
+// stopGracePeriod is how long after a stop or kill request an exit is booked as requested
+const stopGracePeriod = 2 * time.Minute
+
+// InitContainerExitRepo initializes the exit history repository and starts watching Podman events
+func InitContainerExitRepo() {
+	containerExitRepo = database.NewContainerExitRepo()
+	go watchContainerExits()
+}
+
+// watchContainerExits follows the Podman event stream, reconnecting with backoff
+// if it ends. Start events are passed on so egress rules follow new addresses,
+// and every event refreshes the container state published over MQTT and is
+// sent to /api/containers/events clients.
+func watchContainerExits() {
+	backoff := 10 * time.Second
+	for {
+		events := make(chan system.ContainerEvent)
+		done := make(chan error, 1)
+		started := time.Now()
+		go func() {
+			done <- podmanService.StreamContainerEvents(context.Background(), events, containerEventStatuses...)
+		}()
+
+		stops := make(map[string]time.Time) // Podman ID -> when a stop or kill was requested
+	stream:
+		for {
+			select {
+			case e := <-events:
+				refreshMQTTState()
+				publishContainerEvent(e)
+				switch e.Status {
+				case "start":
+					go containerStarted(e)
+				case "died", "stop", "kill":
+					handleContainerEvent(e, stops)
+				}
+			case err := <-done:
+				if time.Since(started) > time.Minute {
+					backoff = 10 * time.Second
+				}
+				log.Printf("Warning: podman event stream ended (%v), retrying in %s", err, backoff)
+				break stream
+			}
+		}
+
+		time.Sleep(backoff)
+		if backoff < 5*time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// handleContainerEvent tracks stop requests and records exits
+func handleContainerEvent(e system.ContainerEvent, stops map[string]time.Time) {
+	if e.Status != "died" {
+		stops[e.ID] = e.Time
+		return
+	}
+
+	exit := &models.ContainerExit{
+		ContainerID:   e.ID,
+		ContainerName: e.Name,
+		Image:         e.Image,
+		ExitedAt:      e.Time,
+	}
+	if e.ExitCode != nil {
+		exit.ExitCode = *e.ExitCode
+	}
+
+	// The event lacks the OOM flag and memory limit; the container is usually still inspectable
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if inspect, err := podmanService.InspectContainer(ctx, e.ID); err == nil {
+		exit.OOMKilled = inspect.State.OOMKilled
+		exit.MemoryLimit = inspect.HostConfig.Memory
+		if e.ExitCode == nil {
+			exit.ExitCode = inspect.State.ExitCode
+		}
+		if startedAt, err := time.Parse(time.RFC3339Nano, inspect.State.StartedAt); err == nil && startedAt.Before(exit.ExitedAt) {
+			exit.RuntimeSeconds = int64(exit.ExitedAt.Sub(startedAt).Seconds())
+		}
+	}
+	cancel()
+
+	requested, stopped := stops[e.ID]
+	delete(stops, e.ID)
+	switch {
+	case exit.OOMKilled:
+		exit.Reason = models.ExitReasonOOM
+	case stopped && exit.ExitedAt.Sub(requested) < stopGracePeriod:
+		exit.Reason = models.ExitReasonStopped
+	case exit.ExitCode != 0:
+		exit.Reason = models.ExitReasonCrashed
+	default:
+		exit.Reason = models.ExitReasonCompleted
+	}
+	for id, at := range stops {
+		if time.Since(at) > stopGracePeriod {
+			delete(stops, id)
+		}
+	}
+
+	if err := containerExitRepo.Create(exit); err != nil {
+		log.Printf("Warning: failed to record exit of %s: %v", exit.ContainerName, err)
+	}
+
+	if exit.Reason == models.ExitReasonOOM {
+		oom := e
+		oom.Status = "oom"
+		oom.ExitCode = &exit.ExitCode
+		publishContainerEvent(oom)
+
+		key, params := "notification.container_oom_host", []string{"container", exit.ContainerName}
+		if exit.MemoryLimit > 0 {
+			key, params = "notification.container_oom_limit", append(params, "limit", humanBytes(exit.MemoryLimit))
+		}
+		notifyRoles(localized(models.Notification{
+			Type:  models.NotificationContainerOOM,
+			Level: models.NotificationWarning,
+			Data:  map[string]interface{}{"container_id": exit.ContainerID, "container_name": exit.ContainerName},
+		}, key, params...), models.RoleAdmin, models.RoleOperator)
+	}
+}
+
+// memorySuggestion proposes a higher memory limit for a container that was
+// OOM killed in the last 30 days and still runs with the same or lower limit
+func memorySuggestion(stats *models.ContainerReliability, currentLimit int64) *models.MemorySuggestion {
+	if stats.OOMKills30d == 0 || currentLimit <= 0 {
+		return nil
+	}
+	oomLimit, err := containerExitRepo.LastOOMLimit(stats.ContainerName)
+	if err != nil || oomLimit <= 0 || currentLimit > oomLimit {
+		return nil
+	}
+
+	// 50% headroom, rounded up to a 64 MiB boundary
+	const step = 64 << 20
+	suggested := (currentLimit*3/2 + step - 1) / step * step
+	return &models.MemorySuggestion{
+		CurrentLimit:   currentLimit,
+		SuggestedLimit: suggested,
+		Reason: fmt.Sprintf("OOM killed %d time(s) in the last 30 days at a %s limit",
+			stats.OOMKills30d, humanBytes(oomLimit)),
+	}
+}
+
+// currentMemoryLimit reads a container's memory limit from Podman, 0 if unlimited or unknown
+func currentMemoryLimit(ctx context.Context, name string) int64 {
+	inspect, err := podmanService.InspectContainer(ctx, name)
+	if err != nil {
+		return 0
+	}
+	return inspect.HostConfig.Memory
+}
+
+// listContainerReliabilityHandler summarises exit history across containers, flagging memory limits that are too low
+func listContainerReliabilityHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	stats, err := containerExitRepo.Reliability("")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get reliability stats: " + err.Error(),
+		})
+	}
+
+	if stats == nil {
+		stats = []models.ContainerReliability{}
+	}
+	for i := range stats {
+		if stats[i].OOMKills30d > 0 {
+			stats[i].MemorySuggestion = memorySuggestion(&stats[i], currentMemoryLimit(ctx, stats[i].ContainerName))
+		}
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// getContainerReliabilityHandler returns a container's exit statistics and recent exits
+func getContainerReliabilityHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	inspect, err := podmanService.InspectContainer(ctx, resolveContainerID(c.Param("id")))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	exits, err := containerExitRepo.ListByName(inspect.Name, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get exit history: " + err.Error(),
+		})
+	}
+	if exits == nil {
+		exits = []models.ContainerExit{}
+	}
+
+	stats := models.ContainerReliability{ContainerName: inspect.Name}
+	if all, err := containerExitRepo.Reliability(inspect.Name); err == nil && len(all) > 0 {
+		stats = all[0]
+	}
+	stats.MemorySuggestion = memorySuggestion(&stats, inspect.HostConfig.Memory)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"stats":        stats,
+		"exits":        exits,
+		"memory_limit": inspect.HostConfig.Memory,
+	})
+}
@@ -4,19 +4,23 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 
 	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/i18n"
 	"stardeckos-backend/internal/models"
 	"stardeckos-backend/internal/system"
 )
@@ -40,42 +44,41 @@ func InitContainerRepos() {
 
 // checkPodmanHandler verifies Podman is available
 func checkPodmanHandler(c echo.Context) error {
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 15*time.Second)
 	defer cancel()
 
-	version, err := podmanService.CheckPodman(ctx)
-	if err != nil {
-		// Check if podman-compose is available
-		composeAvailable := podmanService.CheckPodmanCompose(ctx)
-
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"available":         false,
-			"compose_available": composeAvailable,
-			"error":             err.Error(),
-			"mode":              podmanService.GetMode(),
-			"target_user":       podmanService.GetTargetUser(),
-			"running_as_root":   podmanService.IsRunningAsRoot(),
-		})
-	}
-
-	// Check if podman-compose is available
-	composeAvailable := podmanService.CheckPodmanCompose(ctx)
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	// Look for a compose engine afresh, in case one was installed since
+	compose := podmanService.ComposeEngine(ctx)
+	resp := map[string]interface{}{
 		"available":         true,
-		"version":           version,
-		"compose_available": composeAvailable,
+		"compose_available": compose != nil,
+		"compose_engine":    "",
+		"compose_version":   "",
 		"mode":              podmanService.GetMode(),
 		"target_user":       podmanService.GetTargetUser(),
 		"running_as_root":   podmanService.IsRunningAsRoot(),
-	})
+		"api_socket":        podmanService.APISocket(), // Empty when the podman CLI is used
+	}
+	if compose != nil {
+		resp["compose_engine"] = compose.Engine
+		resp["compose_version"] = compose.Version
+	}
+
+	version, err := podmanService.CheckPodman(ctx)
+	if err != nil {
+		resp["available"] = false
+		resp["error"] = err.Error()
+		return c.JSON(http.StatusOK, resp)
+	}
+	resp["version"] = version
+	return c.JSON(http.StatusOK, resp)
 }
 
 // installPodmanHandler installs Podman and related packages via WebSocket for streaming output
 func installPodmanHandler(c echo.Context) error {
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: checkWebSocketOrigin,
 	}
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
@@ -139,8 +142,10 @@ func installPodmanHandler(c echo.Context) error {
 	}
 	sendStatus("podman", "Podman installed successfully", false)
 
-	// Step 3: Install podman-compose
-	if err := installPackage("compose", "podman-compose"); err != nil {
+	// Step 3: Install podman-compose, unless a compose engine is already there
+	if engine := podmanService.ComposeEngine(c.Request().Context()); engine != nil {
+		sendStatus("compose", "Using "+engine.Engine+" for stacks", false)
+	} else if err := installPackage("compose", "podman-compose"); err != nil {
 		sendStatus("compose", "Failed to install podman-compose: "+err.Error(), true)
 		// Not critical, continue
 	} else {
@@ -186,7 +191,7 @@ func listContainersHandler(c echo.Context) error {
 	if err != nil {
 		c.Logger().Error("Failed to list containers: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to list containers: " + err.Error(),
+			"error": tr(c, "error.list_containers", "error", err.Error()),
 		})
 	}
 
@@ -213,10 +218,26 @@ func listContainersHandler(c echo.Context) error {
 				containers[i].IconLight = dbContainer.IconLight
 				containers[i].IconDark = dbContainer.IconDark
 				containers[i].CreatedAt = dbContainer.CreatedAt
+				containers[i].CreatedBy = dbContainer.CreatedBy
 			}
 		}
 	}
 
+	// Hide containers in projects the user isn't a member of
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+	visible := containers[:0]
+	for _, container := range containers {
+		if view.visible(view.containerProject(container.ID, container.Stack)) {
+			visible = append(visible, container)
+		}
+	}
+	containers = visible
+
 	c.Logger().Info("Returning ", len(containers), " containers")
 	return c.JSON(http.StatusOK, containers)
 }
@@ -274,6 +295,7 @@ func getContainerHandler(c echo.Context) error {
 		"memory_limit":  inspect.HostConfig.Memory,
 		"mounts":        inspect.Mounts,
 		"networks":      inspect.NetworkSettings.Networks,
+		"health":        system.ContainerHealth(inspect),
 	}
 
 	// Add Stardeck metadata if available
@@ -305,7 +327,7 @@ func validateContainerHandler(c echo.Context) error {
 	var req models.CreateContainerRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -443,7 +465,58 @@ func validateContainerHandler(c echo.Context) error {
 		})
 	}
 
-	// 5. Check overall validity
+	// 5. Check the requesting user's quota
+	user := c.Get("user").(*models.User)
+	quotaResults, _, err := checkQuota(ctx, user.ID, containerQuotaDemand(&req), "")
+	if err != nil {
+		results = append(results, ValidationResult{
+			Check:   "quota",
+			Status:  "warning",
+			Message: "Could not check quota",
+			Details: err.Error(),
+		})
+	}
+	results = append(results, quotaResults...)
+
+	// 6. Check privileges and confinement
+	securityResults, _ := checkContainerSecurity(&req)
+	results = append(results, securityResults...)
+
+	// 7. Check log options
+	if err := prepareContainerLogging(&req); err != nil {
+		results = append(results, ValidationResult{
+			Check:   "logging",
+			Status:  "error",
+			Message: "Invalid log options",
+			Details: err.Error(),
+		})
+	} else if (req.LogDriver == "" || req.LogDriver == "k8s-file") && req.LogMaxSize == "" {
+		results = append(results, ValidationResult{
+			Check:   "logging",
+			Status:  "warning",
+			Message: "Container log has no size cap",
+			Details: "Set log_max_size or a host-wide default so a noisy container can't fill the disk",
+		})
+	}
+
+	// 8. Check the health check
+	if err := prepareContainerHealthCheck(&req); err != nil {
+		results = append(results, ValidationResult{
+			Check:   "health",
+			Status:  "error",
+			Message: "Invalid health check",
+			Details: err.Error(),
+		})
+	} else if req.HealthOnFailure == models.HealthOnFailureRestart && req.HealthCmd == "" {
+		results = append(results, ValidationResult{
+			Check:   "health",
+			Status:  "warning",
+			Message: "Restart on failure relies on the image's health check",
+			Details: "Set health_cmd unless the image defines a HEALTHCHECK; without one the container is never restarted",
+		})
+	}
+
+	// 9. Check overall validity
 	hasErrors := false
 	for _, r := range results {
 		if r.Status == "error" {
@@ -462,7 +535,7 @@ func validateContainerHandler(c echo.Context) error {
 func deployContainerHandler(c echo.Context) error {
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: checkWebSocketOrigin,
 	}
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
@@ -480,7 +553,7 @@ func deployContainerHandler(c echo.Context) error {
 	if err := json.Unmarshal(message, &req); err != nil {
 		ws.WriteJSON(map[string]interface{}{
 			"step":    "error",
-			"message": "Invalid configuration: " + err.Error(),
+			"message": tr(c, "deploy.invalid_config", "error", err.Error()),
 			"error":   true,
 		})
 		return nil
@@ -504,11 +577,11 @@ func deployContainerHandler(c echo.Context) error {
 	ctx := context.Background()
 
 	// Step 1: Validate configuration
-	sendStatus("validate", "Validating configuration...", false, nil)
+	sendStatus("validate", tr(c, "deploy.validating"), false, nil)
 	time.Sleep(300 * time.Millisecond) // Brief pause for UX
 
 	if req.Image == "" {
-		sendStatus("validate", "No image specified", true, nil)
+		sendStatus("validate", tr(c, "deploy.no_image"), true, nil)
 		return nil
 	}
 
@@ -516,21 +589,42 @@ func deployContainerHandler(c echo.Context) error {
 	if req.Name != "" {
 		exists, _ := podmanService.ContainerExists(ctx, req.Name)
 		if exists {
-			sendStatus("validate", fmt.Sprintf("Container name '%s' already exists", req.Name), true, nil)
+			sendStatus("validate", tr(c, "deploy.name_exists", "name", req.Name), true, nil)
 			return nil
 		}
 	}
 
-	sendStatus("validate", "Configuration validated", false, map[string]interface{}{"complete": true})
+	quotaResults, ok, err := checkQuota(ctx, user.ID, containerQuotaDemand(&req), "")
+	if err != nil {
+		sendStatus("validate", tr(c, "deploy.quota_check_failed", "error", err.Error()), true, nil)
+		return nil
+	}
+	if !ok {
+		sendStatus("validate", tr(c, "deploy.quota_exceeded"), true, map[string]interface{}{"results": quotaResults})
+		return nil
+	}
+
+	securityResults, ok := checkContainerSecurity(&req)
+	if !ok {
+		sendStatus("validate", tr(c, "deploy.invalid_security"), true, map[string]interface{}{"results": securityResults})
+		return nil
+	}
+	for _, r := range securityResults {
+		if r.Status == "warning" {
+			sendStatus("validate", r.Message+": "+r.Details, false, map[string]interface{}{"warning": true})
+		}
+	}
+
+	sendStatus("validate", tr(c, "deploy.validated"), false, map[string]interface{}{"complete": true})
 
 	// Step 2: Check/Pull image
-	sendStatus("pull", "Checking for image...", false, nil)
+	sendStatus("pull", tr(c, "deploy.checking_image"), false, nil)
 
 	imageExists := podmanService.ImageExists(ctx, req.Image)
 	if imageExists {
-		sendStatus("pull", "Image found locally", false, map[string]interface{}{"complete": true})
+		sendStatus("pull", tr(c, "deploy.image_local"), false, map[string]interface{}{"complete": true})
 	} else {
-		sendStatus("pull", "Pulling image from registry...", false, map[string]interface{}{
+		sendStatus("pull", tr(c, "deploy.pulling"), false, map[string]interface{}{
 			"pulling": true,
 		})
 
@@ -548,39 +642,52 @@ func deployContainerHandler(c echo.Context) error {
 		}
 
 		if err := <-errChan; err != nil {
-			sendStatus("pull", "Failed to pull image: "+err.Error(), true, nil)
+			sendStatus("pull", tr(c, "deploy.pull_failed", "error", err.Error()), true, nil)
 			return nil
 		}
 
-		sendStatus("pull", "Image pulled successfully", false, map[string]interface{}{"complete": true})
+		sendStatus("pull", tr(c, "deploy.pulled"), false, map[string]interface{}{"complete": true})
 	}
 
 	// Step 3: Create volume directories
 	if len(req.Volumes) > 0 {
-		sendStatus("volumes", "Creating volume directories...", false, nil)
+		sendStatus("volumes", tr(c, "deploy.creating_volumes"), false, nil)
 		for _, vol := range req.Volumes {
 			if vol.Source != "" && filepath.IsAbs(vol.Source) {
 				if _, err := os.Stat(vol.Source); os.IsNotExist(err) {
 					if err := os.MkdirAll(vol.Source, 0755); err != nil {
-						sendStatus("volumes", fmt.Sprintf("Failed to create directory '%s': %s", vol.Source, err.Error()), true, nil)
+						sendStatus("volumes", tr(c, "deploy.volume_failed", "path", vol.Source, "error", err.Error()), true, nil)
 						return nil
 					}
 				}
 			}
 		}
-		sendStatus("volumes", "Volume directories ready", false, map[string]interface{}{"complete": true})
+		sendStatus("volumes", tr(c, "deploy.volumes_ready"), false, map[string]interface{}{"complete": true})
 	}
 
 	// Step 4: Create container
-	sendStatus("create", "Creating container...", false, nil)
+	if err := prepareContainerNetwork(ctx, &req); err != nil {
+		sendStatus("network", err.Error(), true, nil)
+		return nil
+	}
+	if err := prepareContainerLogging(&req); err != nil {
+		sendStatus("create", err.Error(), true, nil)
+		return nil
+	}
+	if err := prepareContainerHealthCheck(&req); err != nil {
+		sendStatus("create", err.Error(), true, nil)
+		return nil
+	}
+
+	sendStatus("create", tr(c, "deploy.creating"), false, nil)
 
 	containerID, err := podmanService.CreateContainer(ctx, &req)
 	if err != nil {
-		sendStatus("create", "Failed to create container: "+err.Error(), true, nil)
+		sendStatus("create", tr(c, "deploy.create_failed", "error", err.Error()), true, nil)
 		return nil
 	}
 
-	sendStatus("create", "Container created", false, map[string]interface{}{
+	sendStatus("create", tr(c, "deploy.created"), false, map[string]interface{}{
 		"complete":     true,
 		"container_id": containerID,
 	})
@@ -606,22 +713,30 @@ func deployContainerHandler(c echo.Context) error {
 		dbContainer.Labels = string(labelsJSON)
 	}
 
-	containerRepo.Create(dbContainer)
+	if err := containerRepo.Create(dbContainer); err == nil {
+		if req.Egress != nil {
+			if _, err := saveEgressPolicy(dbContainer, *req.Egress, user); err != nil {
+				sendStatus("create", tr(c, "deploy.egress_failed", "error", err.Error()), true, nil)
+				return nil
+			}
+		}
+		recordContainerConfig(ctx, dbContainer, models.ConfigSnapshotCreate, &user.ID)
+	}
 
 	// Step 5: Start container (if auto-start enabled)
 	if req.AutoStart {
-		sendStatus("start", "Starting container...", false, nil)
+		sendStatus("start", tr(c, "deploy.starting"), false, nil)
 
 		if err := podmanService.StartContainer(ctx, containerID); err != nil {
-			sendStatus("start", "Failed to start container: "+err.Error(), true, nil)
+			sendStatus("start", tr(c, "deploy.start_failed", "error", err.Error()), true, nil)
 			return nil
 		}
 
-		sendStatus("start", "Container started", false, map[string]interface{}{"complete": true})
+		sendStatus("start", tr(c, "deploy.started"), false, map[string]interface{}{"complete": true})
 	}
 
 	// Final success
-	sendStatus("complete", "Container deployed successfully!", false, map[string]interface{}{
+	sendStatus("complete", tr(c, "deploy.complete"), false, map[string]interface{}{
 		"container_id":   containerID,
 		"container_name": req.Name,
 		"complete":       true,
@@ -631,6 +746,8 @@ func deployContainerHandler(c echo.Context) error {
 	logAudit(user, models.ActionContainerCreate, req.Name, map[string]interface{}{
 		"image":        req.Image,
 		"container_id": containerID,
+		"privileged":   req.Privileged,
+		"cap_add":      req.CapAdd,
 	})
 
 	return nil
@@ -641,13 +758,47 @@ func createContainerHandler(c echo.Context) error {
 	var req models.CreateContainerRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
 	defer cancel()
 
+	// Get user from context
+	user := c.Get("user").(*models.User)
+
+	quotaResults, ok, err := checkQuota(ctx, user.ID, containerQuotaDemand(&req), "")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to check quota: " + err.Error(),
+		})
+	}
+	if !ok {
+		return quotaExceededResponse(c, quotaResults)
+	}
+
+	securityResults, ok := checkContainerSecurity(&req)
+	if !ok {
+		return invalidSecurityOptionsResponse(c, securityResults)
+	}
+
+	if err := prepareContainerNetwork(ctx, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := prepareContainerLogging(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := prepareContainerHealthCheck(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
 	// Create container via Podman
 	containerID, err := podmanService.CreateContainer(ctx, &req)
 	if err != nil {
@@ -656,9 +807,6 @@ func createContainerHandler(c echo.Context) error {
 		})
 	}
 
-	// Get user from context
-	user := c.Get("user").(*models.User)
-
 	// Store in database
 	dbContainer := &models.Container{
 		ContainerID: containerID,
@@ -684,19 +832,36 @@ func createContainerHandler(c echo.Context) error {
 		// Container was created in Podman but failed to save metadata
 		// Log but don't fail the request
 		c.Logger().Errorf("Failed to save container metadata: %v", err)
+	} else {
+		if req.Egress != nil {
+			if _, err := saveEgressPolicy(dbContainer, *req.Egress, user); err != nil {
+				c.Logger().Errorf("Failed to save egress policy: %v", err)
+			}
+		}
+		recordContainerConfig(ctx, dbContainer, models.ConfigSnapshotCreate, &user.ID)
 	}
 
 	// Audit log
 	logAudit(user, models.ActionContainerCreate, req.Name, map[string]interface{}{
 		"image":        req.Image,
 		"container_id": containerID,
+		"privileged":   req.Privileged,
+		"cap_add":      req.CapAdd,
 	})
 
+	warnings := []ValidationResult{}
+	for _, r := range securityResults {
+		if r.Status == "warning" {
+			warnings = append(warnings, r)
+		}
+	}
+
 	return c.JSON(http.StatusCreated, map[string]interface{}{
 		"id":           dbContainer.ID,
 		"container_id": containerID,
 		"name":         req.Name,
 		"status":       "created",
+		"warnings":     warnings,
 	})
 }
 
@@ -721,6 +886,7 @@ func startContainerHandler(c echo.Context) error {
 	// Audit log
 	user := c.Get("user").(*models.User)
 	logAudit(user, models.ActionContainerStart, containerID, nil)
+	recordScheduleOverride(id, models.ScheduleActionStart, user)
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"status": "started",
@@ -750,6 +916,7 @@ func stopContainerHandler(c echo.Context) error {
 
 	user := c.Get("user").(*models.User)
 	logAudit(user, models.ActionContainerStop, containerID, nil)
+	recordScheduleOverride(id, models.ScheduleActionStop, user)
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"status": "stopped",
@@ -785,6 +952,25 @@ func restartContainerHandler(c echo.Context) error {
 	})
 }
 
+// deleteContainerRecords removes a container from the database in one
+// transaction; metrics, schedules and the container's other rows go with it
+// through ON DELETE CASCADE
+func deleteContainerRecords(managedID, containerID string) error {
+	return database.WithTx(func(tx *sql.Tx) error {
+		if err := projectRepo.InTx(tx).RemoveResource(models.ProjectResourceContainer, managedID); err != nil {
+			return err
+		}
+		if err := envVarRepo.InTx(tx).DeleteByContainerID(managedID); err != nil {
+			return err
+		}
+		containers := containerRepo.InTx(tx)
+		if err := containers.Delete(managedID); err != nil {
+			return err
+		}
+		return containers.DeleteByContainerID(containerID)
+	})
+}
+
 // removeContainerHandler removes a container
 func removeContainerHandler(c echo.Context) error {
 	id := c.Param("id")
@@ -794,24 +980,61 @@ func removeContainerHandler(c echo.Context) error {
 	defer cancel()
 
 	containerID := resolveContainerID(id)
+	user := c.Get("user").(*models.User)
+
+	// Keep a restorable snapshot in the recycle bin unless permanently deleting
+	var trashItem *models.TrashItem
+	if c.QueryParam("permanent") != "true" && trashEnabled() {
+		trashCtx, trashCancel := context.WithTimeout(c.Request().Context(), 10*time.Minute)
+		item, err := trashContainer(trashCtx, user, id, containerID, c.QueryParam("backup") == "true")
+		trashCancel()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to move container to trash: " + err.Error(),
+			})
+		}
+		trashItem = item
+	}
 
-	if err := podmanService.RemoveContainer(ctx, containerID, force); err != nil {
+	remove := podmanService.RemoveContainer
+	if removesContainerVolumes(c) {
+		remove = podmanService.RemoveContainerWithVolumes
+	}
+	if err := remove(ctx, containerID, force); err != nil {
+		if trashItem != nil {
+			purgeTrashItem(trashItem)
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to remove container: " + err.Error(),
 		})
 	}
 
-	// Remove from database
-	containerRepo.Delete(id)
-	containerRepo.DeleteByContainerID(containerID)
-	envVarRepo.DeleteByContainerID(id)
+	managedID := id
+	if managed, err := lookupManagedContainer(id); err == nil {
+		managedID = managed.ID
+	}
+	if err := deleteContainerRecords(managedID, containerID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Container removed but its records could not be deleted: " + err.Error(),
+		})
+	}
 
-	user := c.Get("user").(*models.User)
-	logAudit(user, models.ActionContainerRemove, containerID, nil)
+	details := map[string]interface{}{}
+	if trashItem != nil {
+		details["trash_id"] = trashItem.ID
+	}
+	if removesContainerVolumes(c) {
+		details["volumes"] = true
+	}
+	logAudit(user, models.ActionContainerRemove, containerID, details)
 
-	return c.JSON(http.StatusOK, map[string]string{
+	resp := map[string]string{
 		"status": "removed",
-	})
+	}
+	if trashItem != nil {
+		resp["trash_id"] = trashItem.ID
+	}
+	return c.JSON(http.StatusOK, resp)
 }
 
 // adoptContainerHandler adopts an existing Podman container into Stardeck's database
@@ -820,7 +1043,7 @@ func adoptContainerHandler(c echo.Context) error {
 	var req models.AdoptContainerRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -892,6 +1115,7 @@ func adoptContainerHandler(c echo.Context) error {
 			"error": "Failed to adopt container: " + err.Error(),
 		})
 	}
+	recordContainerConfig(ctx, dbContainer, models.ConfigSnapshotAdopt, &userID)
 
 	logAudit(user, models.ActionContainerCreate, dbContainer.Name, map[string]interface{}{
 		"action":       "adopt",
@@ -918,7 +1142,7 @@ func updateContainerHandler(c echo.Context) error {
 	var req models.UpdateContainerRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -928,7 +1152,7 @@ func updateContainerHandler(c echo.Context) error {
 	}
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Container not found",
+			"error": tr(c, "error.container_not_found"),
 		})
 	}
 
@@ -969,6 +1193,7 @@ func updateContainerHandler(c echo.Context) error {
 	}
 
 	user := c.Get("user").(*models.User)
+	recordContainerConfig(c.Request().Context(), dbContainer, models.ConfigSnapshotEdit, &user.ID)
 	logAudit(user, models.ActionContainerUpdate, dbContainer.Name, nil)
 
 	return c.JSON(http.StatusOK, dbContainer)
@@ -1007,7 +1232,7 @@ func execContainerHandler(c echo.Context) error {
 
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: checkWebSocketOrigin,
 	}
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
@@ -1099,7 +1324,7 @@ func getContainerLogsHandler(c echo.Context) error {
 
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: checkWebSocketOrigin,
 	}
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
@@ -1189,7 +1414,8 @@ func getContainerStatsHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, stats)
 }
 
-// getContainerMetricsHandler returns historical metrics
+// getContainerMetricsHandler returns historical metrics: the stored samples,
+// or with ?step= (e.g. 5m) their averages and peaks per step
 func getContainerMetricsHandler(c echo.Context) error {
 	id := c.Param("id")
 	hours, _ := strconv.Atoi(c.QueryParam("hours"))
@@ -1197,6 +1423,16 @@ func getContainerMetricsHandler(c echo.Context) error {
 		hours = 24
 	}
 
+	var step time.Duration
+	if v := c.QueryParam("step"); v != "" {
+		var ok bool
+		if step, ok = parseMetricsStep(v); !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "step must be a duration such as 5m or a number of seconds",
+			})
+		}
+	}
+
 	metrics, err := metricsRepo.GetRecent(id, hours)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -1204,6 +1440,9 @@ func getContainerMetricsHandler(c echo.Context) error {
 		})
 	}
 
+	if step > 0 {
+		return c.JSON(http.StatusOK, aggregateMetrics(id, hours, step, metrics))
+	}
 	return c.JSON(http.StatusOK, metrics)
 }
 
@@ -1249,7 +1488,7 @@ func inspectImageHandler(c echo.Context) error {
 func inspectImageWSHandler(c echo.Context) error {
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: checkWebSocketOrigin,
 	}
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
@@ -1269,7 +1508,7 @@ func inspectImageWSHandler(c echo.Context) error {
 	}
 	if err := json.Unmarshal(message, &req); err != nil {
 		ws.WriteJSON(map[string]interface{}{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 		return nil
 	}
@@ -1377,7 +1616,7 @@ func pullImageHandler(c echo.Context) error {
 	var req models.PullImageRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -1437,14 +1676,24 @@ func listBindMountsHandler(c echo.Context) error {
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
 	defer cancel()
 
-	// Get all containers
-	containers, err := podmanService.ListContainers(ctx)
+	bindMounts, err := collectBindMounts(ctx)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to list containers: " + err.Error(),
+			"error": tr(c, "error.list_containers", "error", err.Error()),
 		})
 	}
 
+	return c.JSON(http.StatusOK, bindMounts)
+}
+
+// collectBindMounts inspects every container and returns its bind mounts
+func collectBindMounts(ctx context.Context) ([]BindMount, error) {
+	// Get all containers
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	bindMounts := []BindMount{}
 
 	// Inspect each container to get its mounts
@@ -1468,7 +1717,7 @@ func listBindMountsHandler(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusOK, bindMounts)
+	return bindMounts, nil
 }
 
 // listVolumesHandler returns all volumes
@@ -1483,7 +1732,21 @@ func listVolumesHandler(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, volumes)
+	// Hide volumes in projects the user isn't a member of
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+	visible := volumes[:0]
+	for _, volume := range volumes {
+		if view.visible(view.project(models.ProjectResourceVolume, volume.Name)) {
+			visible = append(visible, volume)
+		}
+	}
+
+	return c.JSON(http.StatusOK, visible)
 }
 
 // createVolumeHandler creates a new volume
@@ -1491,7 +1754,7 @@ func createVolumeHandler(c echo.Context) error {
 	var req models.CreateVolumeRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -1517,22 +1780,61 @@ func createVolumeHandler(c echo.Context) error {
 func removeVolumeHandler(c echo.Context) error {
 	name := c.Param("name")
 	force := c.QueryParam("force") == "true"
+	archive := c.QueryParam("archive") == "true"
 
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	timeout := 30 * time.Second
+	if archive {
+		timeout = 30 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
 	defer cancel()
 
+	user := c.Get("user").(*models.User)
+	result := map[string]interface{}{}
+
+	// Archive then delete: tar the volume's data into the backup path first
+	var volume *models.Volume
+	if archive {
+		var err error
+		volume, err = podmanService.InspectVolume(ctx, name)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Volume not found",
+			})
+		}
+		archivePath, err := archiveVolume(ctx, volume)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to archive volume: " + err.Error(),
+			})
+		}
+		result["archive_path"] = archivePath
+	}
+
 	if err := podmanService.RemoveVolume(ctx, name, force); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to remove volume: " + err.Error(),
 		})
 	}
+	projectRepo.RemoveResource(models.ProjectResourceVolume, name)
 
-	user := c.Get("user").(*models.User)
-	logAudit(user, models.ActionVolumeRemove, name, nil)
+	if archivePath, ok := result["archive_path"].(string); ok && c.QueryParam("permanent") != "true" && trashEnabled() {
+		item, err := trashData(user, models.TrashItemVolume, name, &models.TrashedData{Volume: volume, ArchivePath: archivePath})
+		if err != nil {
+			c.Logger().Warnf("Failed to record volume %s in trash: %v", name, err)
+		} else {
+			result["trash_id"] = item.ID
+		}
+	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"status": "removed",
-	})
+	var details map[string]interface{}
+	if archive {
+		details = map[string]interface{}{"archive_path": result["archive_path"]}
+	}
+	logAudit(user, models.ActionVolumeRemove, name, details)
+
+	result["status"] = "removed"
+	return c.JSON(http.StatusOK, result)
 }
 
 // Network handlers
@@ -1549,7 +1851,21 @@ func listPodmanNetworksHandler(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, networks)
+	// Hide networks in projects the user isn't a member of
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+	visible := networks[:0]
+	for _, network := range networks {
+		if view.visible(view.project(models.ProjectResourceNetwork, network.Name)) {
+			visible = append(visible, network)
+		}
+	}
+
+	return c.JSON(http.StatusOK, visible)
 }
 
 // createPodmanNetworkHandler creates a new network
@@ -1557,7 +1873,7 @@ func createPodmanNetworkHandler(c echo.Context) error {
 	var req models.CreateNetworkRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -1579,22 +1895,240 @@ func createPodmanNetworkHandler(c echo.Context) error {
 	})
 }
 
-// removePodmanNetworkHandler removes a network
+// networkDetail inspects a network and the containers on it, filling in
+// which of them Stardeck manages
+func networkDetail(ctx context.Context, name string) (*models.NetworkDetail, error) {
+	detail, err := podmanService.InspectNetwork(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	members, err := podmanService.NetworkContainers(ctx, detail.Name, detail.IPAMDriver)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(members))
+	for i, m := range members {
+		ids[i] = m.ContainerID
+	}
+	if managed, err := containerRepo.GetByContainerIDs(ids); err == nil {
+		for i := range members {
+			if dc, ok := managed[members[i].ContainerID]; ok {
+				members[i].ID = dc.ID
+			}
+		}
+	}
+	detail.Containers = members
+	return detail, nil
+}
+
+// memberNames lists the names of a network's containers for error messages
+func memberNames(members []models.NetworkMember) []string {
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// getPodmanNetworkHandler returns a network's configuration and the
+// containers attached to it, with their addresses
+func getPodmanNetworkHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	detail, err := networkDetail(ctx, c.Param("name"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Network not found: " + err.Error(),
+		})
+	}
+
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+	attached := len(detail.Containers)
+	visible := detail.Containers[:0]
+	for _, m := range detail.Containers {
+		if view.visible(view.containerProject(m.ID, m.Stack)) {
+			visible = append(visible, m)
+		}
+	}
+	detail.Containers = visible
+
+	if attached > 0 {
+		detail.Warnings = append(detail.Warnings, fmt.Sprintf("%d container(s) use this network; removing or changing it disconnects them", attached))
+	}
+	if detail.Name == managedNetworkName() {
+		detail.Warnings = append(detail.Warnings, "This is the Stardeck-managed network; containers resolve each other by name on it")
+	}
+
+	return c.JSON(http.StatusOK, detail)
+}
+
+// updatePodmanNetworkHandler changes a network's subnet or turns its DNS on or
+// off. Podman can't do either in place, so the network is recreated and its
+// containers reconnected; they lose connectivity on it for a moment.
+func updatePodmanNetworkHandler(c echo.Context) error {
+	var req models.UpdateNetworkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	name := c.Param("name")
+	if name == "podman" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "The default podman network can't be changed",
+		})
+	}
+	old, err := networkDetail(ctx, name)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Network not found: " + err.Error(),
+		})
+	}
+
+	updated := *old
+	updated.Subnets = append([]models.NetworkSubnet(nil), old.Subnets...)
+	changes := make(map[string]interface{})
+
+	if req.Subnet != nil {
+		if old.IPAMDriver == "dhcp" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Addresses on this network are leased by DHCP, it has no subnet to change",
+			})
+		}
+		_, ipnet, err := net.ParseCIDR(strings.TrimSpace(*req.Subnet))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid subnet, use CIDR notation such as 10.89.5.0/24",
+			})
+		}
+		subnet := models.NetworkSubnet{Subnet: ipnet.String()}
+		if req.Gateway != nil && *req.Gateway != "" {
+			gateway := net.ParseIP(strings.TrimSpace(*req.Gateway))
+			if gateway == nil || !ipnet.Contains(gateway) {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "The gateway must be an address inside the subnet",
+				})
+			}
+			subnet.Gateway = gateway.String()
+		}
+
+		// Replace the subnet of the same address family; a lease range is tied
+		// to the old subnet and goes with it
+		v4 := ipnet.IP.To4() != nil
+		replaced := false
+		for i, s := range updated.Subnets {
+			if _, existing, err := net.ParseCIDR(s.Subnet); err == nil && (existing.IP.To4() != nil) == v4 {
+				if s.Subnet == subnet.Subnet && req.Gateway == nil {
+					subnet = s
+				}
+				updated.Subnets[i] = subnet
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			updated.Subnets = append(updated.Subnets, subnet)
+		}
+		changes["subnet"] = subnet.Subnet
+		if subnet.Gateway != "" {
+			changes["gateway"] = subnet.Gateway
+		}
+	}
+	if req.DNSEnabled != nil && *req.DNSEnabled != old.DNSEnabled {
+		if *req.DNSEnabled && old.Driver != "bridge" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Container DNS is only available on bridge networks",
+			})
+		}
+		updated.DNSEnabled = *req.DNSEnabled
+		changes["dns_enabled"] = *req.DNSEnabled
+	}
+
+	if len(changes) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Nothing to change",
+		})
+	}
+
+	warnings, err := podmanService.RecreateNetwork(ctx, old, &updated)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":    "Failed to update network: " + err.Error(),
+			"warnings": warnings,
+		})
+	}
+
+	// Container addresses on the network have changed
+	proxyTargetCacheMu.Lock()
+	proxyTargetCache = make(map[string]proxyTarget)
+	proxyTargetCacheMu.Unlock()
+
+	changes["containers"] = len(old.Containers)
+	if len(warnings) > 0 {
+		changes["warnings"] = warnings
+	}
+	Audit.LogFromContext(c, models.ActionNetworkUpdate, name, changes)
+
+	detail, err := networkDetail(ctx, name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Network updated but could not be inspected: " + err.Error(),
+		})
+	}
+	detail.Warnings = warnings
+	return c.JSON(http.StatusOK, detail)
+}
+
+// removePodmanNetworkHandler removes a network. While containers still use it
+// the request is refused with the list of them; force disconnects them first.
 func removePodmanNetworkHandler(c echo.Context) error {
 	name := c.Param("name")
 	force := c.QueryParam("force") == "true"
 
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
 	defer cancel()
 
-	if err := podmanService.RemoveNetwork(ctx, name, force); err != nil {
+	var members []models.NetworkMember
+	if detail, err := networkDetail(ctx, name); err == nil {
+		members = detail.Containers
+	}
+	if len(members) > 0 && !force {
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error":      fmt.Sprintf("Network is used by %d container(s); remove with force=true to disconnect them", len(members)),
+			"containers": memberNames(members),
+		})
+	}
+	for _, m := range members {
+		if err := podmanService.DisconnectNetwork(ctx, name, m.ContainerID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to disconnect %s: %s", m.Name, err.Error()),
+			})
+		}
+	}
+
+	if err := podmanService.RemoveNetwork(ctx, name, false); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to remove network: " + err.Error(),
 		})
 	}
+	projectRepo.RemoveResource(models.ProjectResourceNetwork, name)
 
 	user := c.Get("user").(*models.User)
-	logAudit(user, models.ActionNetworkRemove, name, nil)
+	var details map[string]interface{}
+	if len(members) > 0 {
+		details = map[string]interface{}{"disconnected": memberNames(members)}
+	}
+	logAudit(user, models.ActionNetworkRemove, name, details)
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"status": "removed",
@@ -1622,7 +2156,7 @@ func getTemplateHandler(c echo.Context) error {
 	template, err := templateRepo.GetByID(id)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Template not found",
+			"error": tr(c, "error.template_not_found"),
 		})
 	}
 
@@ -1634,7 +2168,7 @@ func createTemplateHandler(c echo.Context) error {
 	var req models.CreateTemplateRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -1646,6 +2180,7 @@ func createTemplateHandler(c echo.Context) error {
 		Author:         user.Username,
 		Version:        req.Version,
 		ComposeContent: req.ComposeContent,
+		Icon:           req.Icon,
 	}
 
 	if req.EnvDefaults != nil {
@@ -1679,7 +2214,7 @@ func deleteTemplateHandler(c echo.Context) error {
 	template, err := templateRepo.GetByID(id)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Template not found",
+			"error": tr(c, "error.template_not_found"),
 		})
 	}
 
@@ -1704,14 +2239,14 @@ func updateTemplateHandler(c echo.Context) error {
 	template, err := templateRepo.GetByID(id)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Template not found",
+			"error": tr(c, "error.template_not_found"),
 		})
 	}
 
 	var req models.CreateTemplateRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -1728,6 +2263,9 @@ func updateTemplateHandler(c echo.Context) error {
 	if req.ComposeContent != "" {
 		template.ComposeContent = req.ComposeContent
 	}
+	if req.Icon != "" {
+		template.Icon = req.Icon
+	}
 	if req.EnvDefaults != nil {
 		envJSON, _ := json.Marshal(req.EnvDefaults)
 		template.EnvDefaults = string(envJSON)
@@ -1760,14 +2298,14 @@ func deployTemplateHandler(c echo.Context) error {
 	template, err := templateRepo.GetByID(id)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Template not found",
+			"error": tr(c, "error.template_not_found"),
 		})
 	}
 
 	var req models.DeployTemplateRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -1810,6 +2348,36 @@ func deployTemplateHandler(c echo.Context) error {
 		stack.EnvContent = strings.Join(envLines, "\n")
 	}
 
+	if existing, _ := stackRepo.GetByName(projectName); existing != nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Stack with this name already exists",
+		})
+	}
+
+	// Write the stack files so it can be deployed
+	dir, err := ensureStackDir(projectName)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := writeComposeFiles(dir, composeContent, stack.EnvContent); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	stack.Path = dir
+
+	// Mount shared data pools into media, *arr and download apps
+	var wiring []models.DataPoolWiring
+	if req.DataPools == nil || *req.DataPools {
+		if wiring, err = writeDataPoolOverride(dir, composeContent); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to wire data pools: " + err.Error(),
+			})
+		}
+	}
+
 	// Create the stack in the database
 	if err := stackRepo.Create(stack); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -1824,12 +2392,17 @@ func deployTemplateHandler(c echo.Context) error {
 		"template_id":  template.ID,
 		"project_name": projectName,
 		"stack_id":     stack.ID,
+		"data_pools":   wiring,
 	})
 
+	// The client opens deploy_url as a WebSocket to deploy the stack and follow
+	// each service coming up
 	return c.JSON(http.StatusCreated, map[string]interface{}{
-		"status":   "created",
-		"stack_id": stack.ID,
-		"message":  "Stack created from template. Use the stack deploy endpoint to deploy it.",
+		"status":     "created",
+		"stack_id":   stack.ID,
+		"data_pools": wiring,
+		"deploy_url": "/api/stacks/" + stack.ID + "/deploy",
+		"message":    "Stack created from template. Connect to deploy_url to deploy it.",
 	})
 }
 
@@ -1840,7 +2413,7 @@ func exportTemplateHandler(c echo.Context) error {
 	template, err := templateRepo.GetByID(id)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Template not found",
+			"error": tr(c, "error.template_not_found"),
 		})
 	}
 
@@ -1959,30 +2532,117 @@ func listDesktopAppsHandler(c echo.Context) error {
 		liveStatus[c.ContainerID] = c.Status
 	}
 
-	// Build response
-	apps := make([]map[string]interface{}, 0, len(dbContainers))
-	for _, c := range dbContainers {
-		status := c.Status
-		if s, ok := liveStatus[c.ContainerID]; ok {
-			status = s
+	// Public (kiosk) URLs for apps that are published without a session
+	publicURLs := make(map[string]string)
+	if publicApps, err := publicAppRepo.List(); err == nil {
+		for _, app := range publicApps {
+			publicURLs[app.ContainerID] = publicAppPath(app.Slug) + "/"
+		}
+	}
+
+	// Probe running web UIs and read image versions concurrently so one slow app doesn't stall the desktop
+	health := make([]desktopAppHealth, len(dbContainers))
+	var wg sync.WaitGroup
+	for i := range dbContainers {
+		container := &dbContainers[i]
+		if s, ok := liveStatus[container.ContainerID]; ok {
+			container.Status = s
 		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			health[i].Version = imageVersion(ctx, container.Image)
+			if container.Status == models.ContainerStatusRunning {
+				health[i].Reachable, health[i].HTTPStatus = probeWebUI(ctx, container)
+			}
+		}(i)
+	}
+	wg.Wait()
 
+	// Build response
+	apps := make([]map[string]interface{}, 0, len(dbContainers))
+	for i, c := range dbContainers {
 		apps = append(apps, map[string]interface{}{
-			"id":           c.ID,
-			"container_id": c.ContainerID,
-			"name":         c.Name,
-			"icon":         c.Icon,
-			"icon_light":   c.IconLight,
-			"icon_dark":    c.IconDark,
-			"status":       status,
-			"web_ui_port":  c.WebUIPort,
-			"web_ui_path":  c.WebUIPath,
+			"id":            c.ID,
+			"container_id":  c.ContainerID,
+			"name":          c.Name,
+			"icon":          c.Icon,
+			"icon_light":    c.IconLight,
+			"icon_dark":     c.IconDark,
+			"status":        c.Status,
+			"web_ui_port":   c.WebUIPort,
+			"web_ui_path":   c.WebUIPath,
+			"thumbnail_url": thumbnailURL(&c),
+			"launch_url":    webUILaunchURL(&c),
+			"public_url":    publicURLs[c.ID],
+			"version":       health[i].Version,
+			"reachable":     health[i].Reachable,
+			"http_status":   health[i].HTTPStatus,
 		})
 	}
 
 	return c.JSON(http.StatusOK, apps)
 }
 
+// desktopAppHealth is the computed badge state of a desktop app
+type desktopAppHealth struct {
+	Reachable  bool
+	HTTPStatus int
+	Version    string
+}
+
+// webUILaunchURL is the proxied URL that opens a container's web UI at its configured path
+func webUILaunchURL(container *models.Container) string {
+	return externalPath(fmt.Sprintf("/api/containers/%s/proxy/%s", container.ID, strings.TrimPrefix(container.WebUIPath, "/")))
+}
+
+// probeWebUI checks whether a container's web UI answers HTTP. Any response
+// below 500, including redirects and auth challenges, counts as online.
+func probeWebUI(ctx context.Context, container *models.Container) (bool, int) {
+	path := container.WebUIPath
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	host, port := resolveProxyTarget(ctx, container)
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s:%d%s", host, port, path), nil)
+	if err != nil {
+		return false, 0
+	}
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 500, resp.StatusCode
+}
+
+// imageVersion reads the application version from an image's OCI labels
+func imageVersion(ctx context.Context, image string) string {
+	config, err := podmanService.InspectImage(ctx, image, false)
+	if err != nil {
+		return ""
+	}
+	return labelVersion(config.Labels)
+}
+
+// labelVersion reads the application version from OCI or label-schema labels
+func labelVersion(labels map[string]string) string {
+	for _, key := range []string{"org.opencontainers.image.version", "org.label-schema.version", "version"} {
+		if v := labels[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 // proxyContainerWebUIHandler proxies requests to a container's web UI
 func proxyContainerWebUIHandler(c echo.Context) error {
 	containerID := c.Param("id")
@@ -1997,11 +2657,17 @@ func proxyContainerWebUIHandler(c echo.Context) error {
 		container, err = containerRepo.GetByID(containerID)
 		if err != nil {
 			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Container not found",
+				"error": tr(c, "error.container_not_found"),
 			})
 		}
 	}
 
+	return proxyWebUI(c, container, externalPath(fmt.Sprintf("/api/containers/%s/proxy", containerID)))
+}
+
+// proxyWebUI forwards the request to a container's web UI, rewriting redirects
+// and HTML base URLs so the app works when served under basePath
+func proxyWebUI(c echo.Context, container *models.Container, basePath string) error {
 	if !container.HasWebUI || container.WebUIPort == 0 {
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "Container does not have a web UI configured",
@@ -2022,8 +2688,9 @@ func proxyContainerWebUIHandler(c echo.Context) error {
 		proxyPath = "/" + proxyPath
 	}
 
-	// Build target URL - use localhost since container port is mapped to host
-	targetURL := fmt.Sprintf("http://localhost:%d%s", container.WebUIPort, proxyPath)
+	// Build target URL - localhost port mapping by default, or container DNS address on the managed network
+	host, port := resolveProxyTarget(c.Request().Context(), container)
+	targetURL := fmt.Sprintf("http://%s:%d%s", host, port, proxyPath)
 	if c.QueryString() != "" {
 		targetURL += "?" + c.QueryString()
 	}
@@ -2066,14 +2733,11 @@ func proxyContainerWebUIHandler(c echo.Context) error {
 	}
 	defer resp.Body.Close()
 
-	// Build the proxy base path for URL rewriting
-	proxyBasePath := fmt.Sprintf("/api/containers/%s/proxy", containerID)
-
 	// Handle redirects - rewrite Location header to go through proxy
 	if location := resp.Header.Get("Location"); location != "" {
 		// If it's an absolute path on the container, rewrite to proxy path
 		if strings.HasPrefix(location, "/") {
-			resp.Header.Set("Location", proxyBasePath+location)
+			resp.Header.Set("Location", basePath+location)
 		}
 	}
 
@@ -2114,7 +2778,7 @@ func proxyContainerWebUIHandler(c echo.Context) error {
 				headEndIdx := strings.Index(bodyStr[headIdx:], ">")
 				if headEndIdx != -1 {
 					insertPos := headIdx + headEndIdx + 1
-					baseTag := fmt.Sprintf(`<base href="%s/">`, proxyBasePath)
+					baseTag := fmt.Sprintf(`<base href="%s/">`, basePath)
 					bodyStr = bodyStr[:insertPos] + baseTag + bodyStr[insertPos:]
 				}
 			}
@@ -2197,7 +2861,7 @@ func updateStorageConfigHandler(c echo.Context) error {
 	var req UpdateStorageConfigRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -2243,6 +2907,9 @@ func updateStorageConfigHandler(c echo.Context) error {
 		}
 	}
 
+	user := c.Get("user").(*models.User)
+	snapshots := snapshotBeforeRisk(models.SnapshotReasonStorageMigrate, "moving container storage to "+req.GraphRoot, user)
+
 	// Update the storage config
 	if err := podmanService.UpdateStorageConfig(ctx, req.GraphRoot); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -2250,9 +2917,9 @@ func updateStorageConfigHandler(c echo.Context) error {
 		})
 	}
 
-	user := c.Get("user").(*models.User)
 	logAudit(user, "storage.config.update", req.GraphRoot, map[string]interface{}{
 		"graph_root": req.GraphRoot,
+		"snapshots":  snapshotNames(snapshots),
 	})
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -2282,13 +2949,7 @@ func getContainerConfigHandler(c echo.Context) error {
 
 	// Enrich with database metadata
 	if dbContainer, err := containerRepo.GetByContainerID(containerID); err == nil {
-		config.HasWebUI = dbContainer.HasWebUI
-		config.WebUIPort = dbContainer.WebUIPort
-		config.WebUIPath = dbContainer.WebUIPath
-		config.Icon = dbContainer.Icon
-		config.IconLight = dbContainer.IconLight
-		config.IconDark = dbContainer.IconDark
-		config.AutoStart = dbContainer.AutoStart
+		withContainerMetadata(config, dbContainer)
 	}
 
 	return c.JSON(http.StatusOK, config)
@@ -2306,7 +2967,7 @@ func listContainerBackupsHandler(c echo.Context) error {
 	inspect, err := podmanService.InspectContainer(ctx, containerID)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Container not found",
+			"error": tr(c, "error.container_not_found"),
 		})
 	}
 
@@ -2341,84 +3002,109 @@ func checkContainerUpdateHandler(c echo.Context) error {
 	inspect, err := podmanService.InspectContainer(ctx, containerID)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Container not found",
+			"error": tr(c, "error.container_not_found"),
 		})
 	}
 
 	currentImage := inspect.Config.Image
 
-	// Check for updates
-	hasUpdate, localDigest, remoteDigest, err := podmanService.CheckImageUpdate(ctx, currentImage)
+	// Check for updates against registry metadata; the image is only pulled when the update runs
+	check, err := podmanService.CheckImageUpdate(ctx, currentImage)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to check for updates: " + err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"has_update":    hasUpdate,
+	response := map[string]interface{}{
+		"has_update":    check.HasUpdate,
 		"current_image": currentImage,
-		"local_digest":  localDigest,
-		"remote_digest": remoteDigest,
-	})
-}
-
-// updateContainerImageHandler handles the container update workflow via WebSocket
-func updateContainerImageHandler(c echo.Context) error {
-	// Upgrade to WebSocket
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		"local_digest":  check.LocalDigest,
+		"remote_digest": check.RemoteDigest,
+		"method":        check.Method,
 	}
-	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
-	if err != nil {
-		return err
-	}
-	defer ws.Close()
-
-	// Read the update request from WebSocket
-	_, message, err := ws.ReadMessage()
-	if err != nil {
-		return err
+	if check.HasUpdate {
+		response["changelog"] = imageChangelog(ctx, inspect.Config.Labels, check.RemoteLabels)
 	}
+	return c.JSON(http.StatusOK, response)
+}
 
-	var req models.UpdateContainerImageRequest
-	if err := json.Unmarshal(message, &req); err != nil {
-		ws.WriteJSON(map[string]interface{}{
-			"step":    "error",
-			"message": "Invalid request: " + err.Error(),
-			"error":   true,
-		})
-		return nil
+// configToCreateRequest builds a create request that reproduces an existing container's configuration
+func configToCreateRequest(config *models.ContainerConfig, image string) *models.CreateContainerRequest {
+	return &models.CreateContainerRequest{
+		Name:          config.Name,
+		Image:         image,
+		Ports:         config.Ports,
+		Volumes:       config.Volumes,
+		Environment:   config.Environment,
+		Labels:        config.Labels,
+		RestartPolicy: config.RestartPolicy,
+		NetworkMode:   config.NetworkMode,
+		NetworkAliases: config.NetworkAliases,
+		Hostname:      config.Hostname,
+		User:          config.User,
+		WorkDir:       config.WorkDir,
+		Entrypoint:    config.Entrypoint,
+		Command:       config.Command,
+		CPULimit:      config.CPULimit,
+		MemoryLimit:   config.MemoryLimit,
+		ContainerSecurityOptions: config.ContainerSecurityOptions,
+		ContainerLogOptions: config.ContainerLogOptions,
+		HasWebUI:      config.HasWebUI,
+		WebUIPort:     config.WebUIPort,
+		WebUIPath:     config.WebUIPath,
+		Icon:          config.Icon,
+		IconLight:     config.IconLight,
+		IconDark:      config.IconDark,
+		AutoStart:     config.AutoStart,
 	}
+}
 
-	user := c.Get("user").(*models.User)
+// updateStatusFunc receives the progress of a container update
+type updateStatusFunc func(step, message string, isError bool, progress int, details map[string]interface{})
+
+// containerUpdateResult is the outcome of a finished container update
+type containerUpdateResult struct {
+	Name            string
+	OldImage        string
+	NewImage        string
+	NewContainerID  string
+	BackupContainer string
+	Backup          *models.ContainerBackup
+}
 
-	// Helper to send status updates
-	sendStatus := func(step, message string, isError bool, progress int, details map[string]interface{}) {
-		payload := map[string]interface{}{
-			"step":     step,
-			"message":  message,
-			"error":    isError,
-			"progress": progress,
-		}
-		for k, v := range details {
-			payload[k] = v
+// runContainerUpdate replaces a container with one on a freshly pulled image
+// and the same configuration, rolling back if the new container won't start.
+// Each step is reported to send in lang; on failure the error is the last
+// message sent. A nil user means the system runs the update.
+func runContainerUpdate(ctx context.Context, req *models.UpdateContainerImageRequest, user *models.User, lang string, send updateStatusFunc) (*containerUpdateResult, error) {
+	t := func(key string, params ...string) string {
+		return i18n.T(lang, key, params...)
+	}
+	var userID *int64
+	audit := func(action, target string, details map[string]interface{}) {
+		Audit.Log(0, "system", action, target, details, "")
+	}
+	if user != nil {
+		userID = &user.ID
+		audit = func(action, target string, details map[string]interface{}) {
+			logAudit(user, action, target, details)
 		}
-		ws.WriteJSON(payload)
 	}
-
-	ctx := context.Background()
+	fail := func(step, message string) error {
+		send(step, message, true, 0, nil)
+		return errors.New(message)
+	}
 
 	// Resolve container ID
 	containerID := resolveContainerID(req.ContainerID)
 
 	// Step 1: Get current container configuration
-	sendStatus("config", "Reading container configuration...", false, 5, nil)
+	send("config", t("update.reading_config"), false, 5, nil)
 
 	config, err := podmanService.GetContainerConfig(ctx, containerID)
 	if err != nil {
-		sendStatus("config", "Failed to read container config: "+err.Error(), true, 0, nil)
-		return nil
+		return nil, fail("config", t("update.read_config_failed", "error", err.Error()))
 	}
 
 	// Enrich with database metadata
@@ -2440,7 +3126,7 @@ func updateContainerImageHandler(c echo.Context) error {
 		newImage = config.Image // Use same image (will pull latest)
 	}
 
-	sendStatus("config", "Configuration read successfully", false, 10, map[string]interface{}{
+	send("config", t("update.config_read"), false, 10, map[string]interface{}{
 		"container_name": config.Name,
 		"current_image":  config.Image,
 		"new_image":      newImage,
@@ -2458,7 +3144,7 @@ func updateContainerImageHandler(c echo.Context) error {
 	}
 
 	if req.CreateBackup && hasBindMounts {
-		sendStatus("backup", "Creating backup of bind mounts...", false, 15, nil)
+		send("backup", t("update.backing_up"), false, 15, nil)
 
 		// Determine backup path
 		backupPath := req.BackupPath
@@ -2472,8 +3158,7 @@ func updateContainerImageHandler(c echo.Context) error {
 
 		// Create backup directory
 		if err := os.MkdirAll(backupPath, 0755); err != nil {
-			sendStatus("backup", "Failed to create backup directory: "+err.Error(), true, 0, nil)
-			return nil
+			return nil, fail("backup", t("update.backup_dir_failed", "error", err.Error()))
 		}
 
 		// Create progress channel for backup
@@ -2489,30 +3174,29 @@ func updateContainerImageHandler(c echo.Context) error {
 
 		// Stream backup progress
 		for msg := range progressChan {
-			sendStatus("backup", msg, false, 20, nil)
+			send("backup", msg, false, 20, nil)
 		}
 
 		if err := <-backupDone; err != nil {
-			sendStatus("backup", "Backup failed: "+err.Error(), true, 0, nil)
-			return nil
+			return nil, fail("backup", t("update.backup_failed", "error", err.Error()))
 		}
 
-		sendStatus("backup", fmt.Sprintf("Backup created: %s (%.2f MB)", backup.ID, float64(backup.SizeBytes)/(1024*1024)), false, 25, map[string]interface{}{
+		send("backup", t("update.backup_created", "id", backup.ID, "size", fmt.Sprintf("%.2f", float64(backup.SizeBytes)/(1024*1024))), false, 25, map[string]interface{}{
 			"backup_id":   backup.ID,
 			"backup_path": backup.BackupPath,
 			"backup_size": backup.SizeBytes,
 		})
 
-		logAudit(user, models.ActionContainerBackup, config.Name, map[string]interface{}{
+		audit(models.ActionContainerBackup, config.Name, map[string]interface{}{
 			"backup_id":   backup.ID,
 			"backup_path": backup.BackupPath,
 		})
 	} else if req.CreateBackup && !hasBindMounts {
-		sendStatus("backup", "No bind mounts to backup, skipping...", false, 25, nil)
+		send("backup", t("update.no_backup"), false, 25, nil)
 	}
 
 	// Step 3: Pull new image
-	sendStatus("pull", "Pulling new image: "+newImage, false, 30, nil)
+	send("pull", t("update.pulling", "image", newImage), false, 30, nil)
 
 	pullChan := make(chan string, 100)
 	pullDone := make(chan error, 1)
@@ -2522,15 +3206,14 @@ func updateContainerImageHandler(c echo.Context) error {
 	}()
 
 	for line := range pullChan {
-		sendStatus("pull", line, false, 35, map[string]interface{}{"output": true})
+		send("pull", line, false, 35, map[string]interface{}{"output": true})
 	}
 
 	if err := <-pullDone; err != nil {
-		sendStatus("pull", "Failed to pull image: "+err.Error(), true, 0, nil)
-		return nil
+		return nil, fail("pull", t("update.pull_failed", "error", err.Error()))
 	}
 
-	sendStatus("pull", "Image pulled successfully", false, 45, nil)
+	send("pull", t("update.pulled"), false, 45, nil)
 
 	// Step 4: Stop current container
 	stopTimeout := req.StopTimeout
@@ -2538,80 +3221,56 @@ func updateContainerImageHandler(c echo.Context) error {
 		stopTimeout = 30
 	}
 
-	sendStatus("stop", "Stopping current container...", false, 50, nil)
+	send("stop", t("update.stopping"), false, 50, nil)
 
 	if err := podmanService.StopContainer(ctx, containerID, stopTimeout); err != nil {
 		// Container might already be stopped, that's okay
-		sendStatus("stop", "Container stopped (or was already stopped)", false, 55, nil)
+		send("stop", t("update.stopped_already"), false, 55, nil)
 	} else {
-		sendStatus("stop", "Container stopped", false, 55, nil)
+		send("stop", t("update.stopped"), false, 55, nil)
 	}
 
 	// Step 5: Rename old container
 	backupContainerName := fmt.Sprintf("%s_backup_%s", config.Name, time.Now().Format("20060102_150405"))
-	sendStatus("rename", "Renaming old container to: "+backupContainerName, false, 60, nil)
+	send("rename", t("update.renaming", "name", backupContainerName), false, 60, nil)
 
 	if err := podmanService.RenameContainer(ctx, containerID, backupContainerName); err != nil {
-		sendStatus("rename", "Failed to rename container: "+err.Error(), true, 0, nil)
-		return nil
+		return nil, fail("rename", t("update.rename_failed", "error", err.Error()))
 	}
 
-	sendStatus("rename", "Old container renamed", false, 65, nil)
+	send("rename", t("update.renamed"), false, 65, nil)
 
 	// Step 6: Create new container with updated image
-	sendStatus("create", "Creating new container with updated image...", false, 70, nil)
+	send("create", t("update.creating"), false, 70, nil)
 
-	createReq := &models.CreateContainerRequest{
-		Name:          config.Name,
-		Image:         newImage,
-		Ports:         config.Ports,
-		Volumes:       config.Volumes,
-		Environment:   config.Environment,
-		Labels:        config.Labels,
-		RestartPolicy: config.RestartPolicy,
-		NetworkMode:   config.NetworkMode,
-		Hostname:      config.Hostname,
-		User:          config.User,
-		WorkDir:       config.WorkDir,
-		Entrypoint:    config.Entrypoint,
-		Command:       config.Command,
-		CPULimit:      config.CPULimit,
-		MemoryLimit:   config.MemoryLimit,
-		HasWebUI:      config.HasWebUI,
-		WebUIPort:     config.WebUIPort,
-		WebUIPath:     config.WebUIPath,
-		Icon:          config.Icon,
-		IconLight:     config.IconLight,
-		IconDark:      config.IconDark,
-		AutoStart:     config.AutoStart,
-	}
+	createReq := configToCreateRequest(config, newImage)
 
 	newContainerID, err := podmanService.CreateContainer(ctx, createReq)
 	if err != nil {
 		// Rollback: rename the backup container back
-		sendStatus("create", "Failed to create new container, rolling back...", true, 0, nil)
+		send("create", t("update.create_failed"), true, 0, nil)
 		podmanService.RenameContainer(ctx, backupContainerName, config.Name)
-		sendStatus("create", "Rollback complete. Original container restored.", true, 0, nil)
-		return nil
+		send("create", t("update.rolled_back"), true, 0, nil)
+		return nil, fmt.Errorf("%s: %w", t("update.create_failed"), err)
 	}
 
-	sendStatus("create", "New container created", false, 80, map[string]interface{}{
+	send("create", t("update.created"), false, 80, map[string]interface{}{
 		"new_container_id": newContainerID,
 	})
 
 	// Step 7: Start new container
-	sendStatus("start", "Starting new container...", false, 85, nil)
+	send("start", t("update.starting"), false, 85, nil)
 
 	if err := podmanService.StartContainer(ctx, newContainerID); err != nil {
 		// Rollback: remove new container and rename backup back
-		sendStatus("start", "Failed to start new container, rolling back...", true, 0, nil)
+		send("start", t("update.start_failed"), true, 0, nil)
 		podmanService.RemoveContainer(ctx, newContainerID, true)
 		podmanService.RenameContainer(ctx, backupContainerName, config.Name)
-		sendStatus("start", "Rollback complete. Original container restored.", true, 0, nil)
-		return nil
+		send("start", t("update.rolled_back"), true, 0, nil)
+		return nil, fmt.Errorf("%s: %w", t("update.start_failed"), err)
 	}
 
-	sendStatus("start", "New container started", false, 90, nil)
+	send("start", t("update.started"), false, 90, nil)
 
 	// Step 8: Update database record
 	if dbContainer != nil {
@@ -2619,49 +3278,39 @@ func updateContainerImageHandler(c echo.Context) error {
 		dbContainer.Image = newImage
 		dbContainer.Status = models.ContainerStatusRunning
 		containerRepo.Update(dbContainer)
+		recordContainerConfig(ctx, dbContainer, models.ConfigSnapshotUpdate, userID)
 	}
 
 	// Step 9: Optionally remove old container
 	if req.RemoveOld {
-		sendStatus("cleanup", "Removing old container backup...", false, 95, nil)
+		send("cleanup", t("update.removing_old"), false, 95, nil)
 		if err := podmanService.RemoveContainer(ctx, backupContainerName, true); err != nil {
-			sendStatus("cleanup", "Warning: Failed to remove old container: "+err.Error(), false, 95, nil)
+			send("cleanup", t("update.remove_old_failed", "error", err.Error()), false, 95, nil)
 		} else {
-			sendStatus("cleanup", "Old container removed", false, 97, nil)
+			send("cleanup", t("update.old_removed"), false, 97, nil)
 		}
 	} else {
-		sendStatus("cleanup", fmt.Sprintf("Old container kept as: %s", backupContainerName), false, 97, nil)
+		send("cleanup", t("update.old_kept", "name", backupContainerName), false, 97, nil)
 	}
 
 	// Final success
-	sendStatus("complete", "Container updated successfully!", false, 100, map[string]interface{}{
-		"new_container_id":    newContainerID,
-		"new_image":           newImage,
-		"backup_container":    backupContainerName,
-		"backup_removed":      req.RemoveOld,
-		"volume_backup_id":    "",
-		"volume_backup_path":  "",
-		"complete":            true,
-	})
-
-	// Update details if backup was created
+	complete := map[string]interface{}{
+		"new_container_id":   newContainerID,
+		"new_image":          newImage,
+		"backup_container":   backupContainerName,
+		"backup_removed":     req.RemoveOld,
+		"volume_backup_id":   "",
+		"volume_backup_path": "",
+		"complete":           true,
+	}
 	if backup != nil {
-		ws.WriteJSON(map[string]interface{}{
-			"step":              "complete",
-			"message":           "Container updated successfully!",
-			"progress":          100,
-			"complete":          true,
-			"new_container_id":  newContainerID,
-			"new_image":         newImage,
-			"backup_container":  backupContainerName,
-			"backup_removed":    req.RemoveOld,
-			"volume_backup_id":  backup.ID,
-			"volume_backup_path": backup.BackupPath,
-		})
+		complete["volume_backup_id"] = backup.ID
+		complete["volume_backup_path"] = backup.BackupPath
 	}
+	send("complete", t("update.complete"), false, 100, complete)
 
 	// Audit log
-	logAudit(user, models.ActionContainerUpdate, config.Name, map[string]interface{}{
+	audit(models.ActionContainerUpdate, config.Name, map[string]interface{}{
 		"old_image":        config.Image,
 		"new_image":        newImage,
 		"old_container_id": containerID,
@@ -2669,6 +3318,63 @@ func updateContainerImageHandler(c echo.Context) error {
 		"backup_created":   backup != nil,
 	})
 
+	return &containerUpdateResult{
+		Name:            config.Name,
+		OldImage:        config.Image,
+		NewImage:        newImage,
+		NewContainerID:  newContainerID,
+		BackupContainer: backupContainerName,
+		Backup:          backup,
+	}, nil
+}
+
+// updateContainerImageHandler handles the container update workflow via WebSocket
+func updateContainerImageHandler(c echo.Context) error {
+	// Upgrade to WebSocket
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	// Read the update request from WebSocket
+	_, message, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateContainerImageRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		ws.WriteJSON(map[string]interface{}{
+			"step":    "error",
+			"message": tr(c, "error.invalid_request", "error", err.Error()),
+			"error":   true,
+		})
+		return nil
+	}
+
+	user := c.Get("user").(*models.User)
+
+	// Helper to send status updates
+	sendStatus := func(step, message string, isError bool, progress int, details map[string]interface{}) {
+		payload := map[string]interface{}{
+			"step":     step,
+			"message":  message,
+			"error":    isError,
+			"progress": progress,
+		}
+		for k, v := range details {
+			payload[k] = v
+		}
+		ws.WriteJSON(payload)
+	}
+
+	entry := newUpdateHistory(req.ContainerID, models.UpdateTriggerManual, user.Username)
+	result, err := runContainerUpdate(context.Background(), &req, user, requestLanguage(c), sendStatus)
+	recordUpdateHistory(entry, result, err)
 	return nil
 }
 
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	containerHealthInterval = 30 * time.Second
+	// A container that keeps failing its check after this many restarts in
+	// healthRestartWindow is left alone, so a broken app isn't bounced forever
+	healthMaxRestarts   = 3
+	healthRestartWindow = time.Hour
+)
+
+// InitContainerHealthMonitor starts watching the health of containers with a
+// health check
+func InitContainerHealthMonitor() {
+	go runContainerHealthMonitor()
+}
+
+// prepareContainerHealthCheck validates a create request's health check
+func prepareContainerHealthCheck(req *models.CreateContainerRequest) error {
+	return system.ValidateHealthCheck(&req.ContainerHealthCheck)
+}
+
+// runContainerHealthMonitor polls container health and acts on each
+// container that turns unhealthy
+func runContainerHealthMonitor() {
+	last := make(map[string]string)          // Podman ID -> health at the last pass
+	restarts := make(map[string][]time.Time) // Podman ID -> recent health restarts
+	for {
+		time.Sleep(containerHealthInterval)
+		if maintenanceModeActive() {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		states, err := podmanService.ListContainerHealth(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to check container health: %v", err)
+			continue
+		}
+
+		seen := make(map[string]string, len(states))
+		for _, state := range states {
+			seen[state.ContainerID] = state.Health
+			if state.Health == models.HealthStatusUnhealthy && last[state.ContainerID] != models.HealthStatusUnhealthy {
+				containerTurnedUnhealthy(state, restarts)
+			}
+		}
+		last = seen
+
+		cutoff := time.Now().Add(-healthRestartWindow)
+		for id, times := range restarts {
+			recent := times[:0]
+			for _, at := range times {
+				if at.After(cutoff) {
+					recent = append(recent, at)
+				}
+			}
+			if len(recent) == 0 {
+				delete(restarts, id)
+			} else {
+				restarts[id] = recent
+			}
+		}
+	}
+}
+
+// containerTurnedUnhealthy flags an unhealthy container to event stream
+// clients and admins, restarting it first when its policy says so
+func containerTurnedUnhealthy(state system.ContainerHealthState, restarts map[string][]time.Time) {
+	publishContainerEvent(system.ContainerEvent{
+		ID:     state.ContainerID,
+		Name:   state.Name,
+		Image:  state.Image,
+		Status: models.HealthStatusUnhealthy,
+		Stack:  state.Stack,
+		Time:   time.Now(),
+	})
+
+	key, params := "notification.container_unhealthy", []string{"container", state.Name}
+	if state.OnFailure == models.HealthOnFailureRestart {
+		if n := len(restarts[state.ContainerID]); n >= healthMaxRestarts {
+			key, params = "notification.unhealthy_limit", append(params, "count", strconv.Itoa(n))
+		} else {
+			restarts[state.ContainerID] = append(restarts[state.ContainerID], time.Now())
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			err := podmanService.RestartContainer(ctx, state.ContainerID, 0)
+			cancel()
+			if err != nil {
+				log.Printf("Warning: failed to restart unhealthy container %s: %v", state.Name, err)
+				key, params = "notification.unhealthy_failed", append(params, "error", err.Error())
+			} else {
+				log.Printf("Restarted unhealthy container %s", state.Name)
+				key = "notification.unhealthy_restarted"
+			}
+		}
+	}
+
+	notifyRoles(localized(models.Notification{
+		Type:  models.NotificationContainerUnhealthy,
+		Level: models.NotificationWarning,
+		Data: map[string]interface{}{
+			"container_id":   state.ContainerID,
+			"container_name": state.Name,
+			"on_failure":     state.OnFailure,
+		},
+	}, key, params...), models.RoleAdmin, models.RoleOperator)
+}
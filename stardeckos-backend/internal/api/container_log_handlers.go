@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// loadContainerLogSettings reads the host-wide container log default
+func loadContainerLogSettings() models.ContainerLogSettings {
+	var s models.ContainerLogSettings
+	s.Driver, _ = settingsRepo.Get(database.SettingContainerLogDriver)
+	s.MaxSize, _ = settingsRepo.Get(database.SettingContainerLogMaxSize)
+	if v, err := settingsRepo.Get(database.SettingContainerLogFiles); err == nil && v != "" {
+		s.MaxFiles, _ = strconv.Atoi(v)
+	}
+	return s
+}
+
+// prepareContainerLogging fills a create request's empty log options from the
+// host default and validates the result. The default size cap isn't applied
+// to a driver that doesn't write a log file.
+func prepareContainerLogging(req *models.CreateContainerRequest) error {
+	defaults := loadContainerLogSettings()
+	opts := &req.ContainerLogOptions
+	if opts.LogDriver == "" {
+		opts.LogDriver = defaults.Driver
+	}
+	if opts.LogMaxSize == "" && (opts.LogDriver == "" || opts.LogDriver == "k8s-file") {
+		opts.LogMaxSize = defaults.MaxSize
+		if opts.LogMaxFiles == 0 {
+			opts.LogMaxFiles = defaults.MaxFiles
+		}
+	}
+	return system.ValidateLogOptions(opts)
+}
+
+// getContainerLogSettingsHandler handles GET /api/system/container-logs
+func getContainerLogSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadContainerLogSettings())
+}
+
+// updateContainerLogSettingsHandler handles PUT /api/system/container-logs.
+// The default applies to containers created afterwards; existing containers
+// keep their options until they are recreated.
+func updateContainerLogSettingsHandler(c echo.Context) error {
+	var s models.ContainerLogSettings
+	if err := c.Bind(&s); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	opts := models.ContainerLogOptions{LogDriver: s.Driver, LogMaxSize: s.MaxSize, LogMaxFiles: s.MaxFiles}
+	if err := system.ValidateLogOptions(&opts); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	s = models.ContainerLogSettings{Driver: opts.LogDriver, MaxSize: opts.LogMaxSize, MaxFiles: opts.LogMaxFiles}
+
+	values := map[string]string{
+		database.SettingContainerLogDriver:  s.Driver,
+		database.SettingContainerLogMaxSize: s.MaxSize,
+		database.SettingContainerLogFiles:   strconv.Itoa(s.MaxFiles),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save container log settings: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionContainerLogSettings, "container_logs", s)
+
+	return c.JSON(http.StatusOK, s)
+}
+
+// listContainerLogUsageHandler handles GET /api/containers/log-usage, reporting
+// each container's log size and cap so runaway logs can be spotted before
+// they fill the disk
+func listContainerLogUsageHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
+	defer cancel()
+
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.list_containers", "error", err.Error()),
+		})
+	}
+	containerIDs := make([]string, len(containers))
+	for i, container := range containers {
+		containerIDs[i] = container.ContainerID
+	}
+
+	usage, err := podmanService.ContainerLogUsage(ctx, containerIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to inspect container logs: " + err.Error(),
+		})
+	}
+	managed, err := containerRepo.GetByContainerIDs(containerIDs)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load containers: " + err.Error(),
+		})
+	}
+
+	report := models.ContainerLogUsageReport{Containers: usage, Default: loadContainerLogSettings()}
+	for i := range usage {
+		if dbContainer, ok := managed[usage[i].ContainerID]; ok {
+			usage[i].ID = dbContainer.ID
+		}
+		report.TotalBytes += usage[i].SizeBytes
+		if usage[i].Uncapped {
+			report.Uncapped++
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].SizeBytes > usage[j].SizeBytes })
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	defaultMetricsInterval     = 60 // Seconds
+	minMetricsInterval         = 10
+	maxMetricsInterval         = 3600
+	defaultMetricsRawRetention = 24 // Hours of full-resolution samples
+
+	// metricsDownsampleEvery is how often old samples are averaged down
+	metricsDownsampleEvery = time.Hour
+)
+
+// metricsLastSampled is how many containers the collector stored last run
+var metricsLastSampled atomic.Int64
+
+var hostMetricsRepo *database.HostMetricsRepo
+
+// InitMetricsCollector starts sampling host and container stats into the
+// metrics history
+func InitMetricsCollector() {
+	hostMetricsRepo = database.NewHostMetricsRepo()
+	go runMetricsCollector()
+}
+
+// loadMetricsCollectorSettings reads the collector settings, applying defaults for missing values
+func loadMetricsCollectorSettings() models.MetricsCollectorSettings {
+	s := models.MetricsCollectorSettings{
+		Enabled:           true,
+		IntervalSeconds:   defaultMetricsInterval,
+		RawRetentionHours: defaultMetricsRawRetention,
+	}
+	if v, err := settingsRepo.GetBool(database.SettingMetricsEnabled); err == nil {
+		s.Enabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingMetricsInterval); err == nil && v >= minMetricsInterval && v <= maxMetricsInterval {
+		s.IntervalSeconds = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingMetricsRawRetention); err == nil && v > 0 {
+		s.RawRetentionHours = v
+	}
+	s.LastRun, _ = settingsRepo.Get(database.SettingMetricsLastRun)
+	s.LastSampled = int(metricsLastSampled.Load())
+	return s
+}
+
+// runMetricsCollector samples at the configured interval, re-reading the
+// settings each round so changes apply without a restart
+func runMetricsCollector() {
+	var lastDownsample time.Time
+	var hostPrev *system.HostCounters
+	for {
+		settings := loadMetricsCollectorSettings()
+		time.Sleep(time.Duration(settings.IntervalSeconds) * time.Second)
+		if !settings.Enabled || maintenanceModeActive() {
+			hostPrev = nil // A gap must not become one long sample
+			continue
+		}
+
+		if cur, err := system.ReadHostCounters(); err != nil {
+			log.Printf("Warning: host metrics collection failed: %v", err)
+		} else {
+			if hostPrev != nil {
+				sample := system.HostMetricsSince(hostPrev, cur)
+				if err := hostMetricsRepo.Save(&sample); err != nil {
+					log.Printf("Warning: failed to store host metrics: %v", err)
+				}
+			}
+			hostPrev = cur
+		}
+		if err := collectContainerMetrics(); err != nil {
+			log.Printf("Warning: container metrics collection failed: %v", err)
+		}
+
+		if time.Since(lastDownsample) >= metricsDownsampleEvery {
+			lastDownsample = time.Now()
+			cutoff := lastDownsample.Add(-time.Duration(settings.RawRetentionHours) * time.Hour)
+			var removed int64
+			err := database.WithTx(func(tx *sql.Tx) error {
+				var err error
+				if removed, err = metricsRepo.InTx(tx).Downsample(cutoff); err != nil {
+					return err
+				}
+				hostRemoved, err := hostMetricsRepo.InTx(tx).Downsample(cutoff)
+				removed += hostRemoved
+				return err
+			})
+			if err != nil {
+				log.Printf("Warning: container metrics downsampling failed: %v", err)
+			} else if removed > 0 {
+				log.Printf("Container metrics: averaged %d samples older than %dh into hourly points", removed, settings.RawRetentionHours)
+			}
+		}
+	}
+}
+
+// collectContainerMetrics stores one sample for every running container
+// Stardeck tracks
+func collectContainerMetrics() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return err
+	}
+	var running []string
+	for _, c := range containers {
+		if c.Status == models.ContainerStatusRunning {
+			running = append(running, c.ContainerID)
+		}
+	}
+	if len(running) == 0 {
+		metricsLastSampled.Store(0)
+		return nil
+	}
+
+	managed, err := containerRepo.GetByContainerIDs(running)
+	if err != nil {
+		return err
+	}
+	if len(managed) == 0 {
+		metricsLastSampled.Store(0)
+		return nil
+	}
+
+	stats, err := podmanService.GetAllContainerStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var samples []models.ContainerMetrics
+	for _, s := range stats {
+		if s.ContainerID == "" {
+			continue
+		}
+		// The CLI reports short IDs
+		for podmanID, container := range managed {
+			if !strings.HasPrefix(podmanID, s.ContainerID) {
+				continue
+			}
+			samples = append(samples, models.ContainerMetrics{
+				ContainerID: container.ID,
+				Timestamp:   now,
+				CPUPercent:  s.CPUPercent,
+				MemoryUsed:  s.MemoryUsed,
+				MemoryLimit: s.MemoryLimit,
+				NetworkRx:   s.NetworkRx,
+				NetworkTx:   s.NetworkTx,
+				BlockRead:   s.BlockRead,
+				BlockWrite:  s.BlockWrite,
+			})
+			break
+		}
+	}
+
+	err = database.WithTx(func(tx *sql.Tx) error {
+		repo := metricsRepo.InTx(tx)
+		for i := range samples {
+			if err := repo.Save(&samples[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	metricsLastSampled.Store(int64(len(samples)))
+	settingsRepo.Set(database.SettingMetricsLastRun, now.Format(time.RFC3339))
+	return nil
+}
+
+// aggregateMetrics groups samples into steps of the given length, averaging
+// usage and keeping the I/O counters at the end of each step
+func aggregateMetrics(containerID string, hours int, step time.Duration, metrics []models.ContainerMetrics) models.ContainerMetricsHistory {
+	history := models.ContainerMetricsHistory{
+		ContainerID: containerID,
+		Hours:       hours,
+		StepSeconds: int(step.Seconds()),
+		Points:      []models.ContainerMetricsPoint{},
+	}
+
+	var cpuSum float64
+	var memorySum int64
+	for _, m := range metrics {
+		start := m.Timestamp.Truncate(step)
+		n := len(history.Points)
+		if n == 0 || !history.Points[n-1].Timestamp.Equal(start) {
+			history.Points = append(history.Points, models.ContainerMetricsPoint{Timestamp: start})
+			n++
+		}
+		p := &history.Points[n-1]
+
+		// Running averages, so a step needs no second pass
+		p.Samples++
+		p.CPUAvg += (m.CPUPercent - p.CPUAvg) / float64(p.Samples)
+		p.MemoryAvg += (m.MemoryUsed - p.MemoryAvg) / int64(p.Samples)
+		if m.CPUPercent > p.CPUMax {
+			p.CPUMax = m.CPUPercent
+		}
+		if m.MemoryUsed > p.MemoryMax {
+			p.MemoryMax = m.MemoryUsed
+		}
+		p.MemoryLimit = m.MemoryLimit
+		p.NetworkRx, p.NetworkTx = m.NetworkRx, m.NetworkTx
+		p.BlockRead, p.BlockWrite = m.BlockRead, m.BlockWrite
+
+		cpuSum += m.CPUPercent
+		memorySum += m.MemoryUsed
+		if m.CPUPercent > history.CPUMax {
+			history.CPUMax = m.CPUPercent
+		}
+		if m.MemoryUsed > history.MemoryMax {
+			history.MemoryMax = m.MemoryUsed
+		}
+	}
+	if len(metrics) > 0 {
+		history.CPUAvg = cpuSum / float64(len(metrics))
+		history.MemoryAvg = memorySum / int64(len(metrics))
+	}
+	return history
+}
+
+// parseMetricsStep reads a step such as "5m" or "300" (seconds)
+func parseMetricsStep(value string) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, seconds > 0
+	}
+	step, err := time.ParseDuration(value)
+	return step, err == nil && step >= time.Second
+}
+
+// getMetricsCollectorSettingsHandler handles GET /api/system/metrics-collector
+func getMetricsCollectorSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadMetricsCollectorSettings())
+}
+
+// updateMetricsCollectorSettingsHandler handles PUT /api/system/metrics-collector
+func updateMetricsCollectorSettingsHandler(c echo.Context) error {
+	settings := loadMetricsCollectorSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if settings.IntervalSeconds < minMetricsInterval || settings.IntervalSeconds > maxMetricsInterval {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "interval_seconds must be between " + strconv.Itoa(minMetricsInterval) + " and " + strconv.Itoa(maxMetricsInterval),
+		})
+	}
+	if settings.RawRetentionHours <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "raw_retention_hours must be positive",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingMetricsEnabled:      strconv.FormatBool(settings.Enabled),
+		database.SettingMetricsInterval:     strconv.Itoa(settings.IntervalSeconds),
+		database.SettingMetricsRawRetention: strconv.Itoa(settings.RawRetentionHours),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionMetricsCollectorSettings, "metrics", values)
+
+	return c.JSON(http.StatusOK, loadMetricsCollectorSettings())
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// idOutputPattern picks the IDs out of `id` output, e.g. uid=1000(abc) gid=1000(abc)
+var idOutputPattern = regexp.MustCompile(`uid=(\d+).*?gid=(\d+)`)
+
+// parseUserSpec splits a numeric user setting such as 1000 or 1000:100. Without
+// a group the GID is taken to match the UID, as it does for most image users.
+func parseUserSpec(spec string) (int, int, bool) {
+	user, group, hasGroup := strings.Cut(spec, ":")
+	uid, err := strconv.Atoi(user)
+	if err != nil || uid < 0 {
+		return 0, 0, false
+	}
+	if !hasGroup {
+		return uid, uid, true
+	}
+	gid, err := strconv.Atoi(group)
+	if err != nil || gid < 0 {
+		return 0, 0, false
+	}
+	return uid, gid, true
+}
+
+// containerEnv returns a variable from a container's environment
+func containerEnv(env []string, key string) string {
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// bindMountProtected reports whether a host path is a system location that
+// the permission fixer must never touch
+func bindMountProtected(path string) bool {
+	clean := filepath.Clean(path)
+	if strings.Count(clean, "/") < 2 {
+		return true
+	}
+	for _, prefix := range bindMountProtectedPrefixes {
+		if pathWithin(clean, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerPermissionReport works out who a container's process runs as and
+// checks each of its bind mounts against that user
+func containerPermissionReport(ctx context.Context, containerID string) (*models.ContainerPermissionReport, error) {
+	inspect, err := podmanService.InspectContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.ContainerPermissionReport{
+		ContainerID: inspect.ID,
+		Name:        strings.TrimPrefix(inspect.Name, "/"),
+		Mounts:      []models.MountPermission{},
+	}
+
+	// linuxserver.io style images start as root and drop to PUID/PGID
+	u := &report.User
+	puid, pgid := containerEnv(inspect.Config.Env, "PUID"), containerEnv(inspect.Config.Env, "PGID")
+	switch {
+	case puid != "":
+		if pgid == "" {
+			pgid = puid
+		}
+		uid, gid, ok := parseUserSpec(puid + ":" + pgid)
+		if !ok {
+			return nil, fmt.Errorf("PUID and PGID must be numeric, got %q and %q", puid, pgid)
+		}
+		u.Source, u.User, u.UID, u.GID = models.RuntimeUserPUID, puid+":"+pgid, uid, gid
+	case inspect.Config.User != "" && inspect.Config.User != "root" && inspect.Config.User != "0":
+		u.Source, u.User = models.RuntimeUserConfig, inspect.Config.User
+		uid, gid, ok := parseUserSpec(inspect.Config.User)
+		if inspect.State.Running {
+			// exec runs as the configured user, so id resolves names and primary groups
+			if out, code, err := podmanService.ExecCapture(ctx, inspect.ID, []string{"id"}); err == nil && code == 0 {
+				if m := idOutputPattern.FindStringSubmatch(out); m != nil {
+					uid, _ = strconv.Atoi(m[1])
+					gid, _ = strconv.Atoi(m[2])
+					ok = true
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("can't resolve user %q while the container is stopped; start it and check again", inspect.Config.User)
+		}
+		u.UID, u.GID = uid, gid
+	default:
+		u.Source, u.User = models.RuntimeUserDefault, inspect.Config.User
+	}
+
+	// User namespaces (rootless Podman, userns=auto or keep-id) shift IDs on the host
+	u.HostUID, u.HostGID = u.UID, u.GID
+	if inspect.State.Running && inspect.State.Pid > 0 {
+		hostUID, uidMapped, uidErr := system.MapContainerID(inspect.State.Pid, u.UID, false)
+		hostGID, gidMapped, gidErr := system.MapContainerID(inspect.State.Pid, u.GID, true)
+		switch {
+		case uidErr != nil || gidErr != nil:
+			report.Warnings = append(report.Warnings, "Could not read the container's user namespace; host IDs are assumed to match")
+		case !uidMapped || !gidMapped:
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%d:%d is not mapped in the container's user namespace, so it can't own host files", u.UID, u.GID))
+		default:
+			u.HostUID, u.HostGID, u.Mapped = hostUID, hostGID, true
+		}
+	} else if inspect.HostConfig.UsernsMode != "" {
+		report.Warnings = append(report.Warnings, "The container uses a user namespace but isn't running, so host IDs are assumed to match; start it for an exact check")
+	}
+
+	for _, m := range inspect.Mounts {
+		if m.Type != "bind" {
+			continue
+		}
+		mp := models.MountPermission{
+			Source:      m.Source,
+			Destination: m.Destination,
+			ReadOnly:    !m.RW,
+			Protected:   bindMountProtected(m.Source),
+		}
+		info, err := os.Stat(m.Source)
+		if err != nil {
+			mp.Problem = "Host path is missing"
+			report.Mounts = append(report.Mounts, mp)
+			continue
+		}
+		mp.Exists, mp.IsDir = true, info.IsDir()
+		mp.OwnerUID, mp.OwnerGID = system.FileOwner(info)
+		mp.Mode = fmt.Sprintf("%04o", octalMode(info.Mode()))
+		mp.Readable, mp.Writable = system.PathAccess(info, u.HostUID, u.HostGID)
+
+		switch {
+		case !mp.Readable:
+			mp.Problem = fmt.Sprintf("Not readable by %d:%d (owned by %d:%d, mode %s)", u.HostUID, u.HostGID, mp.OwnerUID, mp.OwnerGID, mp.Mode)
+		case !mp.Writable && !mp.ReadOnly:
+			mp.Problem = fmt.Sprintf("Not writable by %d:%d (owned by %d:%d, mode %s)", u.HostUID, u.HostGID, mp.OwnerUID, mp.OwnerGID, mp.Mode)
+		}
+		report.Mounts = append(report.Mounts, mp)
+	}
+	return report, nil
+}
+
+// octalMode returns a mode's permission and special bits in chmod's numbering
+func octalMode(mode os.FileMode) uint32 {
+	n := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		n |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		n |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		n |= 01000
+	}
+	return n
+}
+
+// getContainerPermissionsHandler handles GET /api/containers/:id/permissions
+func getContainerPermissionsHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	report, err := containerPermissionReport(ctx, container.ContainerID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to check permissions: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// fixContainerPermissionsHandler handles POST /api/containers/:id/permissions/fix.
+// It re-owns bind mounts to the container's runtime user, and only reports what
+// would change unless dry_run is false.
+func fixContainerPermissionsHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.ContainerPermissionFixRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Minute)
+	defer cancel()
+
+	report, err := containerPermissionReport(ctx, container.ContainerID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to check permissions: " + err.Error(),
+		})
+	}
+
+	fix := system.PermissionFix{
+		UID:       report.User.HostUID,
+		GID:       report.User.HostGID,
+		KeepMode:  req.Mode == "",
+		Recursive: req.Recursive == nil || *req.Recursive,
+		DryRun:    req.DryRun == nil || *req.DryRun,
+	}
+	if req.UID != nil {
+		fix.UID = *req.UID
+	}
+	if req.GID != nil {
+		fix.GID = *req.GID
+	}
+	if fix.UID < 0 || fix.GID < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "uid and gid can't be negative",
+		})
+	}
+	if req.Mode != "" {
+		if fix.Mode, err = system.ParseOctalMode(req.Mode); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	// Pick the requested mounts, or every mount with a problem
+	var targets []models.MountPermission
+	if len(req.Mounts) > 0 {
+		byDest := make(map[string]models.MountPermission)
+		for _, m := range report.Mounts {
+			byDest[m.Destination] = m
+		}
+		for _, dest := range req.Mounts {
+			m, ok := byDest[dest]
+			if !ok {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": fmt.Sprintf("The container has no bind mount at %s", dest),
+				})
+			}
+			targets = append(targets, m)
+		}
+	} else {
+		for _, m := range report.Mounts {
+			if m.Problem != "" && m.Exists {
+				targets = append(targets, m)
+			}
+		}
+	}
+
+	result := models.ContainerPermissionFixResult{
+		DryRun: fix.DryRun,
+		UID:    fix.UID,
+		GID:    fix.GID,
+		Mode:   req.Mode,
+		Mounts: []models.MountPermissionFix{},
+	}
+	for _, m := range targets {
+		mf := models.MountPermissionFix{Source: m.Source, Destination: m.Destination}
+		switch {
+		case m.Protected:
+			mf.Errors = []string{"Refusing to change a system path"}
+		case !m.Exists:
+			mf.Errors = []string{"Host path is missing"}
+		default:
+			mf.Changed, mf.Errors = system.FixPermissions(m.Source, fix)
+		}
+		result.Mounts = append(result.Mounts, mf)
+	}
+
+	if !fix.DryRun {
+		sources := make([]string, 0, len(result.Mounts))
+		for _, m := range result.Mounts {
+			sources = append(sources, m.Source)
+		}
+		Audit.LogFromContext(c, models.ActionContainerPermissionsFix, container.Name, map[string]interface{}{
+			"owner":     fmt.Sprintf("%d:%d", fix.UID, fix.GID),
+			"mode":      req.Mode,
+			"recursive": fix.Recursive,
+			"paths":     sources,
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
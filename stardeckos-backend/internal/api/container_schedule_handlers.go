@@ -0,0 +1,368 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var containerScheduleRepo *database.ContainerScheduleRepo
+
+// InitContainerScheduleRepo initializes the container schedule repository and starts the scheduler
+func InitContainerScheduleRepo() {
+	containerScheduleRepo = database.NewContainerScheduleRepo()
+	go runContainerScheduler()
+}
+
+// scheduleLocation resolves a schedule's timezone, defaulting to the host's
+func scheduleLocation(tz string) (*time.Location, error) {
+	if tz == "" || tz == "Local" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// scheduleActionAt returns the action due at minute t, if any. When both
+// schedules fire in the same minute the stop wins.
+func scheduleActionAt(s *models.ContainerSchedule, t time.Time) string {
+	loc, err := scheduleLocation(s.Timezone)
+	if err != nil {
+		return ""
+	}
+	t = t.In(loc)
+	if cron, err := system.ParseCron(s.StopSchedule); err == nil && cron.Matches(t) {
+		return models.ScheduleActionStop
+	}
+	if cron, err := system.ParseCron(s.StartSchedule); err == nil && cron.Matches(t) {
+		return models.ScheduleActionStart
+	}
+	return ""
+}
+
+// withNextActions fills in the computed next start and stop times for display
+func withNextActions(s *models.ContainerSchedule) {
+	loc, err := scheduleLocation(s.Timezone)
+	if err != nil || !s.Enabled {
+		return
+	}
+	now := time.Now().In(loc)
+	if cron, err := system.ParseCron(s.StartSchedule); err == nil {
+		if next := cron.Next(now); !next.IsZero() {
+			s.NextStartAt = &next
+		}
+	}
+	if cron, err := system.ParseCron(s.StopSchedule); err == nil {
+		if next := cron.Next(now); !next.IsZero() {
+			s.NextStopAt = &next
+		}
+	}
+}
+
+// nextScheduledAction returns when the schedule next starts or stops the container
+func nextScheduledAction(s *models.ContainerSchedule) time.Time {
+	withNextActions(s)
+	switch {
+	case s.NextStartAt == nil && s.NextStopAt == nil:
+		return time.Time{}
+	case s.NextStartAt == nil:
+		return *s.NextStopAt
+	case s.NextStopAt == nil || s.NextStartAt.Before(*s.NextStopAt):
+		return *s.NextStartAt
+	default:
+		return *s.NextStopAt
+	}
+}
+
+// runContainerScheduler wakes at the top of every minute and starts or stops scheduled containers
+func runContainerScheduler() {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(next.Sub(now))
+
+		if maintenanceModeActive() {
+			continue
+		}
+
+		schedules, err := containerScheduleRepo.ListEnabled()
+		if err != nil {
+			log.Printf("Warning: failed to load container schedules: %v", err)
+			continue
+		}
+
+		for i := range schedules {
+			s := &schedules[i]
+			action := scheduleActionAt(s, next)
+			if action == "" {
+				continue
+			}
+			if s.OverrideUntil != nil {
+				if next.Before(*s.OverrideUntil) {
+					log.Printf("Skipping scheduled %s of %s: overridden by %s until %s",
+						action, s.ContainerName, s.OverrideBy, s.OverrideUntil.Format(time.RFC3339))
+					continue
+				}
+				containerScheduleRepo.ClearOverride(s.ContainerID)
+			}
+			go runScheduledAction(s, action)
+		}
+	}
+}
+
+// runScheduledAction starts or stops a scheduled container and records the outcome
+func runScheduledAction(s *models.ContainerSchedule, action string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	container, err := containerRepo.GetByID(s.ContainerID)
+	if err == nil {
+		if action == models.ScheduleActionStart {
+			if err = podmanService.StartContainer(ctx, container.ContainerID); err == nil {
+				updateContainerStatus(container.ID, models.ContainerStatusRunning)
+			}
+		} else {
+			if err = podmanService.StopContainer(ctx, container.ContainerID, 10); err == nil {
+				updateContainerStatus(container.ID, models.ContainerStatusExited)
+			}
+		}
+	}
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+		log.Printf("Warning: scheduled %s of %s failed: %v", action, s.ContainerName, err)
+	}
+	if err := containerScheduleRepo.RecordAction(s.ContainerID, action, errMsg); err != nil {
+		log.Printf("Warning: failed to record scheduled %s of %s: %v", action, s.ContainerName, err)
+	}
+
+	details := map[string]interface{}{"action": action}
+	if errMsg != "" {
+		details["error"] = errMsg
+	}
+	Audit.Log(0, "system", models.ActionContainerScheduleRun, s.ContainerName, details, "")
+}
+
+// recordScheduleOverride notes a manual start or stop of a scheduled container.
+// The schedule resumes with its next action, so the manual state holds until then.
+func recordScheduleOverride(id, action string, user *models.User) {
+	container, err := lookupManagedContainer(id)
+	if err != nil {
+		return
+	}
+	s, err := containerScheduleRepo.Get(container.ID)
+	if err != nil || !s.Enabled {
+		return
+	}
+	if until := nextScheduledAction(s); !until.IsZero() {
+		containerScheduleRepo.SetOverride(container.ID, action, user.Username, until)
+	}
+}
+
+// getContainerSchedule loads the schedule of the container in the URL
+func getContainerSchedule(c echo.Context) (*models.ContainerSchedule, error) {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	s, err := containerScheduleRepo.Get(container.ID)
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Container has no schedule",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get schedule: " + err.Error(),
+		})
+	}
+	return s, nil
+}
+
+// listContainerSchedulesHandler lists the power schedules of all containers
+func listContainerSchedulesHandler(c echo.Context) error {
+	schedules, err := containerScheduleRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list schedules: " + err.Error(),
+		})
+	}
+
+	if schedules == nil {
+		schedules = []models.ContainerSchedule{}
+	}
+	for i := range schedules {
+		withNextActions(&schedules[i])
+	}
+
+	return c.JSON(http.StatusOK, schedules)
+}
+
+// getContainerScheduleHandler returns a container's power schedule
+func getContainerScheduleHandler(c echo.Context) error {
+	s, err := getContainerSchedule(c)
+	if s == nil {
+		return err
+	}
+
+	withNextActions(s)
+	return c.JSON(http.StatusOK, s)
+}
+
+// updateContainerScheduleHandler creates or replaces a container's power schedule
+func updateContainerScheduleHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.UpdateContainerScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.StartSchedule == "" && req.StopSchedule == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "start_schedule or stop_schedule is required",
+		})
+	}
+	for _, expr := range []string{req.StartSchedule, req.StopSchedule} {
+		if expr == "" {
+			continue
+		}
+		if _, err := system.ParseCron(expr); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid schedule: " + err.Error(),
+			})
+		}
+	}
+	if req.Timezone == "" {
+		req.Timezone = "Local"
+	}
+	if _, err := scheduleLocation(req.Timezone); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid timezone: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	s := &models.ContainerSchedule{
+		ContainerID:   container.ID,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+		StartSchedule: req.StartSchedule,
+		StopSchedule:  req.StopSchedule,
+		Timezone:      req.Timezone,
+		CreatedBy:     &user.ID,
+	}
+	if err := containerScheduleRepo.Upsert(s); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save schedule: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionContainerScheduleUpdate, container.Name, map[string]interface{}{
+		"enabled":        s.Enabled,
+		"start_schedule": s.StartSchedule,
+		"stop_schedule":  s.StopSchedule,
+		"timezone":       s.Timezone,
+	})
+
+	saved, err := containerScheduleRepo.Get(container.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get schedule: " + err.Error(),
+		})
+	}
+	withNextActions(saved)
+	return c.JSON(http.StatusOK, saved)
+}
+
+// deleteContainerScheduleHandler removes a container's power schedule
+func deleteContainerScheduleHandler(c echo.Context) error {
+	s, err := getContainerSchedule(c)
+	if s == nil {
+		return err
+	}
+
+	if err := containerScheduleRepo.Delete(s.ContainerID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete schedule: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionContainerScheduleDelete, s.ContainerName, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Schedule deleted",
+	})
+}
+
+// setContainerScheduleOverrideHandler holds the container's current state, skipping scheduled actions until a time
+func setContainerScheduleOverrideHandler(c echo.Context) error {
+	s, err := getContainerSchedule(c)
+	if s == nil {
+		return err
+	}
+
+	var req models.ScheduleOverrideRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if !req.Until.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "until must be in the future",
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	if err := containerScheduleRepo.SetOverride(s.ContainerID, "hold", user.Username, req.Until); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to set override: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionContainerScheduleOverride, s.ContainerName, map[string]interface{}{
+		"until": req.Until,
+	})
+
+	return getContainerScheduleHandler(c)
+}
+
+// clearContainerScheduleOverrideHandler resumes a container's schedule immediately
+func clearContainerScheduleOverrideHandler(c echo.Context) error {
+	s, err := getContainerSchedule(c)
+	if s == nil {
+		return err
+	}
+
+	if err := containerScheduleRepo.ClearOverride(s.ContainerID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to clear override: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionContainerScheduleOverride, s.ContainerName, map[string]interface{}{
+		"cleared": true,
+	})
+
+	return getContainerScheduleHandler(c)
+}
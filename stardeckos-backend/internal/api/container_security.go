@@ -0,0 +1,174 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// linuxCapabilities are the capability names podman accepts, without the CAP_ prefix
+var linuxCapabilities = map[string]bool{
+	"AUDIT_CONTROL": true, "AUDIT_READ": true, "AUDIT_WRITE": true, "BLOCK_SUSPEND": true,
+	"BPF": true, "CHECKPOINT_RESTORE": true, "CHOWN": true, "DAC_OVERRIDE": true,
+	"DAC_READ_SEARCH": true, "FOWNER": true, "FSETID": true, "IPC_LOCK": true,
+	"IPC_OWNER": true, "KILL": true, "LEASE": true, "LINUX_IMMUTABLE": true,
+	"MAC_ADMIN": true, "MAC_OVERRIDE": true, "MKNOD": true, "NET_ADMIN": true,
+	"NET_BIND_SERVICE": true, "NET_BROADCAST": true, "NET_RAW": true, "PERFMON": true,
+	"SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true,
+	"SYS_ADMIN": true, "SYS_BOOT": true, "SYS_CHROOT": true, "SYS_MODULE": true,
+	"SYS_NICE": true, "SYS_PACCT": true, "SYS_PTRACE": true, "SYS_RAWIO": true,
+	"SYS_RESOURCE": true, "SYS_TIME": true, "SYS_TTY_CONFIG": true, "SYSLOG": true,
+	"WAKE_ALARM": true,
+}
+
+// dangerousCapabilities each come close to handing the container the host
+var dangerousCapabilities = map[string]bool{
+	"SYS_ADMIN": true, "SYS_MODULE": true, "SYS_PTRACE": true, "SYS_RAWIO": true,
+	"DAC_READ_SEARCH": true, "BPF": true, "NET_ADMIN": true, "MAC_ADMIN": true,
+}
+
+// userNSPattern matches the --userns modes Stardeck passes through
+var userNSPattern = regexp.MustCompile(`^(auto(:[a-z]+=[0-9]+(,[a-z]+=[0-9]+)*)?|keep-id(:[a-z]+=[0-9]+(,[a-z]+=[0-9]+)*)?|nomap|host|private|ns:/\S+)$`)
+
+// normalizeCapabilities upper-cases capability names and strips the CAP_
+// prefix, returning the names that aren't Linux capabilities
+func normalizeCapabilities(caps []string, allowAll bool) ([]string, []string) {
+	normalized := make([]string, 0, len(caps))
+	var invalid []string
+	for _, capability := range caps {
+		name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(capability)), "CAP_")
+		if !linuxCapabilities[name] && !(allowAll && name == "ALL") {
+			invalid = append(invalid, capability)
+			continue
+		}
+		normalized = append(normalized, name)
+	}
+	return normalized, invalid
+}
+
+// checkContainerSecurity validates a request's privilege and confinement
+// options and its outbound network policy, normalizing them in place. It
+// returns one validation result per concern and whether the request can be
+// created; privileged containers and broad capabilities produce warnings
+// rather than errors.
+func checkContainerSecurity(req *models.CreateContainerRequest) ([]ValidationResult, bool) {
+	opts := &req.ContainerSecurityOptions
+	results := []ValidationResult{}
+	ok := true
+	fail := func(check, message, details string) {
+		results = append(results, ValidationResult{Check: check, Status: "error", Message: message, Details: details})
+		ok = false
+	}
+	warn := func(check, message, details string) {
+		results = append(results, ValidationResult{Check: check, Status: "warning", Message: message, Details: details})
+	}
+
+	var invalidAdd, invalidDrop []string
+	opts.CapAdd, invalidAdd = normalizeCapabilities(opts.CapAdd, true)
+	opts.CapDrop, invalidDrop = normalizeCapabilities(opts.CapDrop, true)
+	if invalid := append(invalidAdd, invalidDrop...); len(invalid) > 0 {
+		fail("capabilities", "Unknown capabilities", strings.Join(invalid, ", "))
+	}
+
+	if opts.UserNS != "" && !userNSPattern.MatchString(opts.UserNS) {
+		fail("userns", "Invalid user namespace mode",
+			fmt.Sprintf("'%s' is not one of auto, keep-id, nomap, host, private or ns:<path>", opts.UserNS))
+	}
+
+	switch profile := opts.SeccompProfile; {
+	case profile == "":
+	case profile == "unconfined":
+		if !opts.Privileged {
+			warn("seccomp", "Seccomp filtering is disabled",
+				"The container can make any system call, including ones the default profile blocks")
+		}
+	case !filepath.IsAbs(profile):
+		fail("seccomp", "Invalid seccomp profile", "The profile must be an absolute path or 'unconfined'")
+	default:
+		if info, err := os.Stat(profile); err != nil || info.IsDir() {
+			fail("seccomp", "Seccomp profile not found", profile)
+		}
+	}
+
+	switch opts.AppArmorProfile {
+	case "":
+	case "unconfined":
+		if !opts.Privileged {
+			warn("apparmor", "AppArmor confinement is disabled", "")
+		}
+	default:
+		if !system.AppArmorEnabled() {
+			fail("apparmor", "AppArmor is not enabled on this host", "")
+		} else if !system.SecurityProfileNamePattern.MatchString(opts.AppArmorProfile) {
+			fail("apparmor", "Invalid AppArmor profile name", opts.AppArmorProfile)
+		}
+	}
+
+	if opts.Privileged {
+		details := "The container gets every capability and the host's devices, and seccomp is disabled. " +
+			"A compromise of the container is a compromise of the host."
+		if len(opts.CapAdd) > 0 || len(opts.CapDrop) > 0 || (opts.SeccompProfile != "" && opts.SeccompProfile != "unconfined") {
+			details += " Capability and seccomp settings have no effect on a privileged container."
+		}
+		warn("privileged", "Container will run privileged", details)
+	} else {
+		var dangerous []string
+		for _, capability := range opts.CapAdd {
+			if capability == "ALL" || dangerousCapabilities[capability] {
+				dangerous = append(dangerous, capability)
+			}
+		}
+		if len(dangerous) > 0 {
+			warn("capabilities", "Container is granted broad capabilities",
+				strings.Join(dangerous, ", ")+" can be used to escape the container or control the host")
+		}
+	}
+
+	if opts.UserNS == "host" && (opts.Privileged || len(opts.CapAdd) > 0) {
+		warn("userns", "Added privileges apply to the host's user IDs",
+			"With the host user namespace, root in the container is root on the host")
+	}
+
+	if req.Egress != nil {
+		if err := system.ValidateEgressPolicy(req.Egress); err != nil {
+			fail("egress", "Invalid outbound network policy", err.Error())
+		} else if req.NetworkMode == "host" {
+			fail("egress", "Outbound network policy needs a container network",
+				"Containers on the host network can't be filtered")
+		}
+	}
+
+	if ok && len(results) == 0 && (len(opts.CapDrop) > 0 || opts.NoNewPrivileges || opts.ReadOnlyRootfs || opts.SeccompProfile != "" || opts.AppArmorProfile != "" || opts.UserNS != "") {
+		results = append(results, ValidationResult{
+			Check:   "security",
+			Status:  "ok",
+			Message: "Security options configured",
+		})
+	}
+
+	return results, ok
+}
+
+// invalidSecurityOptionsResponse rejects a request with invalid security options
+func invalidSecurityOptionsResponse(c echo.Context, results []ValidationResult) error {
+	message := "Invalid security options"
+	for _, r := range results {
+		if r.Status == "error" {
+			message += ": " + r.Message
+			break
+		}
+	}
+	return c.JSON(http.StatusBadRequest, map[string]interface{}{
+		"error":   message,
+		"valid":   false,
+		"results": results,
+	})
+}
@@ -0,0 +1,508 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var dataPoolRepo *database.DataPoolRepo
+
+// dataPoolMeasureInterval is how often pool usage is measured against quotas
+const dataPoolMeasureInterval = time.Hour
+
+// dataPoolTemplates are the permission presets offered when creating a pool
+var dataPoolTemplates = []models.DataPoolPermissionTemplate{
+	{
+		Name:        "linuxserver",
+		Description: "Owned by 1000:1000 and group-writable, matching the PUID/PGID defaults of linuxserver.io images",
+		UID:         1000,
+		GID:         1000,
+		Mode:        "2775",
+		Umask:       "002",
+	},
+	{
+		Name:        "shared-group",
+		Description: "Group-writable by the users group (100), for apps running as different users",
+		UID:         1000,
+		GID:         100,
+		Mode:        "2775",
+		Umask:       "002",
+	},
+	{
+		Name:        "private",
+		Description: "Readable by the group, writable only by the owner",
+		UID:         1000,
+		GID:         1000,
+		Mode:        "0750",
+		Umask:       "027",
+	},
+}
+
+// dataPoolSubdirs are created in new pools, following the common layout the
+// *arr apps expect so hardlinks and atomic moves work
+var dataPoolSubdirs = map[string][]string{
+	models.DataPoolMedia:     {"movies", "tv", "music", "books"},
+	models.DataPoolDownloads: {"torrents", "usenet", "incomplete"},
+}
+
+// dataPoolApps maps image names to the pool purposes they are wired into on
+// template deploys. Media servers read the library, download clients write
+// downloads, and the *arr apps move files from one to the other.
+var dataPoolApps = map[string][]string{
+	"jellyfin":     {models.DataPoolMedia},
+	"plex":         {models.DataPoolMedia},
+	"emby":         {models.DataPoolMedia},
+	"sonarr":       {models.DataPoolMedia, models.DataPoolDownloads},
+	"radarr":       {models.DataPoolMedia, models.DataPoolDownloads},
+	"lidarr":       {models.DataPoolMedia, models.DataPoolDownloads},
+	"readarr":      {models.DataPoolMedia, models.DataPoolDownloads},
+	"whisparr":     {models.DataPoolMedia, models.DataPoolDownloads},
+	"bazarr":       {models.DataPoolMedia},
+	"qbittorrent":  {models.DataPoolDownloads},
+	"transmission": {models.DataPoolDownloads},
+	"deluge":       {models.DataPoolDownloads},
+	"rtorrent":     {models.DataPoolDownloads},
+	"sabnzbd":      {models.DataPoolDownloads},
+	"nzbget":       {models.DataPoolDownloads},
+}
+
+// InitDataPools initializes the data pool repository and starts usage measurement
+func InitDataPools() {
+	dataPoolRepo = database.NewDataPoolRepo()
+	go runDataPoolMeasurements()
+}
+
+func runDataPoolMeasurements() {
+	ticker := time.NewTicker(dataPoolMeasureInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		pools, err := dataPoolRepo.List()
+		if err != nil {
+			log.Printf("Warning: failed to list data pools: %v", err)
+			continue
+		}
+		for i := range pools {
+			measureDataPool(&pools[i])
+		}
+	}
+}
+
+// measureDataPool records a pool's size and alerts admins when it goes over quota
+func measureDataPool(p *models.DataPool) {
+	used := dirSize(p.Path)
+	over := p.QuotaBytes > 0 && used > p.QuotaBytes
+	if err := dataPoolRepo.RecordUsage(p.ID, used, over); err != nil {
+		log.Printf("Warning: failed to save data pool usage for %s: %v", p.Name, err)
+	}
+
+	if over && !p.OverQuota {
+		notifyRoles(localized(models.Notification{
+			Type:  models.NotificationDataPoolFull,
+			Level: models.NotificationWarning,
+			Data: map[string]interface{}{
+				"pool_id":     p.ID,
+				"used_bytes":  used,
+				"quota_bytes": p.QuotaBytes,
+			},
+		}, "notification.data_pool_over_quota", "pool", p.Name, "path", p.Path,
+			"used", strconv.FormatInt(used, 10), "quota", strconv.FormatInt(p.QuotaBytes, 10)), models.RoleAdmin)
+	}
+	now := time.Now()
+	p.UsedBytes, p.OverQuota, p.MeasuredAt = used, over, &now
+}
+
+// applyDataPoolRequest validates a request and copies it onto a pool, filling
+// unset fields from the permission template and the pool's current values
+func applyDataPoolRequest(p *models.DataPool, req *models.DataPoolRequest) error {
+	if p.ID == 0 {
+		if !system.CloudNamePattern.MatchString(req.Name) {
+			return errors.New("name must be up to 32 lowercase letters, digits, '-' or '_'")
+		}
+		p.Name = req.Name
+	}
+
+	switch req.Purpose {
+	case "":
+		if p.Purpose == "" {
+			p.Purpose = models.DataPoolGeneral
+		}
+	case models.DataPoolMedia, models.DataPoolDownloads, models.DataPoolGeneral:
+		p.Purpose = req.Purpose
+	default:
+		return errors.New("purpose must be media, downloads or general")
+	}
+
+	if req.Path != "" {
+		if !filepath.IsAbs(req.Path) {
+			return errors.New("path must be absolute")
+		}
+		p.Path = filepath.Clean(req.Path)
+		if p.Path == "/" {
+			return errors.New("path can't be the root directory")
+		}
+	} else if p.Path == "" {
+		p.Path = filepath.Join(appDataRoot(), "pools", p.Name)
+	}
+
+	if req.ContainerPath != "" {
+		if !filepath.IsAbs(req.ContainerPath) || strings.ContainsAny(req.ContainerPath, ":,") {
+			return errors.New("container_path must be an absolute path without ':' or ','")
+		}
+		p.ContainerPath = filepath.Clean(req.ContainerPath)
+	} else if p.ContainerPath == "" {
+		p.ContainerPath = "/data/" + p.Name
+	}
+
+	if req.QuotaBytes < 0 {
+		return errors.New("quota_bytes can't be negative")
+	}
+	p.QuotaBytes = req.QuotaBytes
+
+	if req.Template != "" {
+		found := false
+		for _, t := range dataPoolTemplates {
+			if t.Name == req.Template {
+				p.UID, p.GID, p.Mode, p.Umask = t.UID, t.GID, t.Mode, t.Umask
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown permission template %q", req.Template)
+		}
+	} else if p.ID == 0 {
+		t := dataPoolTemplates[0]
+		p.UID, p.GID, p.Mode, p.Umask = t.UID, t.GID, t.Mode, t.Umask
+	}
+
+	if req.UID != nil {
+		p.UID = *req.UID
+	}
+	if req.GID != nil {
+		p.GID = *req.GID
+	}
+	if p.UID < 0 || p.GID < 0 {
+		return errors.New("uid and gid can't be negative")
+	}
+	if req.Mode != "" {
+		p.Mode = req.Mode
+	}
+	if req.Umask != "" {
+		p.Umask = req.Umask
+	}
+	if _, err := system.ParseOctalMode(p.Mode); err != nil {
+		return err
+	}
+	if _, err := system.ParseUmask(p.Umask); err != nil {
+		return err
+	}
+	return nil
+}
+
+// prepareDataPoolDir creates a pool's directory and purpose subdirectories and
+// sets their ownership. Existing contents are left alone.
+func prepareDataPoolDir(p *models.DataPool) error {
+	mode, err := system.ParseOctalMode(p.Mode)
+	if err != nil {
+		return err
+	}
+	dirs := []string{p.Path}
+	for _, sub := range dataPoolSubdirs[p.Purpose] {
+		dirs = append(dirs, filepath.Join(p.Path, sub))
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+		if _, errs := system.FixPermissions(dir, system.PermissionFix{UID: p.UID, GID: p.GID, Mode: mode}); len(errs) > 0 {
+			return errors.New(errs[0])
+		}
+	}
+	return nil
+}
+
+// dataPoolOverride works out which pools each service of a compose file gets,
+// from the app its image runs
+func dataPoolOverride(composeContent string, pools []models.DataPool) ([]system.DataPoolOverrideService, []models.DataPoolWiring) {
+	var services []system.DataPoolOverrideService
+	var wiring []models.DataPoolWiring
+	for _, svc := range system.ParseComposeServices(composeContent) {
+		purposes := dataPoolApps[imageIconSlug(svc.Image)]
+		if len(purposes) == 0 {
+			continue
+		}
+
+		var matched []models.DataPool
+		for _, p := range pools {
+			for _, purpose := range purposes {
+				if p.Purpose == purpose {
+					matched = append(matched, p)
+					break
+				}
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		// Ownership comes from the first pool, so files written to every pool match
+		override := system.DataPoolOverrideService{
+			Name: svc.Name,
+			Environment: map[string]string{
+				"PUID":  strconv.Itoa(matched[0].UID),
+				"PGID":  strconv.Itoa(matched[0].GID),
+				"UMASK": matched[0].Umask,
+			},
+		}
+		w := models.DataPoolWiring{Service: svc.Name}
+		for _, p := range matched {
+			override.Volumes = append(override.Volumes, p.Path+":"+p.ContainerPath)
+			w.Pools = append(w.Pools, p.Name)
+		}
+		services = append(services, override)
+		wiring = append(wiring, w)
+	}
+	return services, wiring
+}
+
+// writeDataPoolOverride writes a stack's pool override file, or removes it
+// when no service uses a pool
+func writeDataPoolOverride(dir, composeContent string) ([]models.DataPoolWiring, error) {
+	pools, err := dataPoolRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, system.DataPoolOverrideFile)
+	services, wiring := dataPoolOverride(composeContent, pools)
+	if len(services) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if err := os.WriteFile(path, []byte(system.RenderDataPoolOverride(services)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write data pool override: %w", err)
+	}
+	return wiring, nil
+}
+
+// refreshDataPoolOverride rewrites the override of a stack that was deployed
+// with data pools, keeping it in step with the compose file and the pools
+func refreshDataPoolOverride(dir, composeContent string) error {
+	if _, err := os.Stat(filepath.Join(dir, system.DataPoolOverrideFile)); err != nil {
+		return nil
+	}
+	_, err := writeDataPoolOverride(dir, composeContent)
+	return err
+}
+
+// refreshDataPoolStacks rewrites every stack's override after a pool changes.
+// Running containers pick up the change when their stack is next deployed.
+func refreshDataPoolStacks() {
+	stacks, err := stackRepo.List()
+	if err != nil {
+		log.Printf("Warning: failed to list stacks for data pool wiring: %v", err)
+		return
+	}
+	for _, item := range stacks {
+		stack, err := stackRepo.GetByID(item.ID)
+		if err != nil || stack.Path == "" {
+			continue
+		}
+		if err := refreshDataPoolOverride(stack.Path, stack.ComposeContent); err != nil {
+			log.Printf("Warning: failed to update data pools for stack %s: %v", stack.Name, err)
+		}
+	}
+}
+
+// listDataPoolsHandler handles GET /api/storage/pools
+func listDataPoolsHandler(c echo.Context) error {
+	pools, err := dataPoolRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list data pools: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, pools)
+}
+
+// listDataPoolTemplatesHandler handles GET /api/storage/pools/templates
+func listDataPoolTemplatesHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, dataPoolTemplates)
+}
+
+// createDataPoolHandler handles POST /api/storage/pools
+func createDataPoolHandler(c echo.Context) error {
+	var req models.DataPoolRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	p := &models.DataPool{CreatedBy: &user.ID}
+	if err := applyDataPoolRequest(p, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := prepareDataPoolDir(p); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to prepare pool directory: " + err.Error(),
+		})
+	}
+	if err := dataPoolRepo.Create(p); err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Failed to create data pool: " + err.Error(),
+		})
+	}
+	measureDataPool(p)
+	refreshDataPoolStacks()
+
+	Audit.LogFromContext(c, models.ActionDataPoolCreate, p.Name, map[string]interface{}{
+		"purpose":     p.Purpose,
+		"path":        p.Path,
+		"quota_bytes": p.QuotaBytes,
+		"owner":       fmt.Sprintf("%d:%d", p.UID, p.GID),
+		"mode":        p.Mode,
+	})
+
+	return c.JSON(http.StatusCreated, p)
+}
+
+// updateDataPoolHandler handles PUT /api/storage/pools/:id. Ownership is applied
+// to the pool directory itself; use the permissions endpoint to fix its contents.
+func updateDataPoolHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid pool ID",
+		})
+	}
+	p, err := dataPoolRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Data pool not found",
+		})
+	}
+
+	var req models.DataPoolRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := applyDataPoolRequest(p, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := prepareDataPoolDir(p); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to prepare pool directory: " + err.Error(),
+		})
+	}
+	if err := dataPoolRepo.Update(p); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update data pool: " + err.Error(),
+		})
+	}
+	measureDataPool(p)
+	refreshDataPoolStacks()
+
+	Audit.LogFromContext(c, models.ActionDataPoolUpdate, p.Name, map[string]interface{}{
+		"purpose":     p.Purpose,
+		"path":        p.Path,
+		"quota_bytes": p.QuotaBytes,
+		"owner":       fmt.Sprintf("%d:%d", p.UID, p.GID),
+		"mode":        p.Mode,
+	})
+
+	return c.JSON(http.StatusOK, p)
+}
+
+// fixDataPoolPermissionsHandler handles POST /api/storage/pools/:id/permissions,
+// resetting the owner and mode of everything in the pool
+func fixDataPoolPermissionsHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid pool ID",
+		})
+	}
+	p, err := dataPoolRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Data pool not found",
+		})
+	}
+	mode, err := system.ParseOctalMode(p.Mode)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	var result models.DataPoolPermissionResult
+	result.Changed, result.Errors = system.FixPermissions(p.Path, system.PermissionFix{
+		UID:       p.UID,
+		GID:       p.GID,
+		Mode:      mode,
+		Recursive: true,
+	})
+
+	Audit.LogFromContext(c, models.ActionDataPoolPermissions, p.Name, map[string]interface{}{
+		"owner":   fmt.Sprintf("%d:%d", p.UID, p.GID),
+		"mode":    p.Mode,
+		"changed": result.Changed,
+		"errors":  len(result.Errors),
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// deleteDataPoolHandler handles DELETE /api/storage/pools/:id. The directory and
+// its contents are kept; stacks lose the mount on their next deploy.
+func deleteDataPoolHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid pool ID",
+		})
+	}
+	p, err := dataPoolRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Data pool not found",
+		})
+	}
+	if err := dataPoolRepo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete data pool: " + err.Error(),
+		})
+	}
+	refreshDataPoolStacks()
+
+	Audit.LogFromContext(c, models.ActionDataPoolDelete, p.Name, map[string]interface{}{
+		"path": p.Path,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Data pool deleted",
+	})
+}
@@ -64,7 +64,7 @@ func createDatabaseHandler(c echo.Context) error {
 	var req models.CreateDatabaseRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -206,7 +206,7 @@ func adoptDatabaseHandler(c echo.Context) error {
 	var req models.AdoptDatabaseRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -258,7 +258,7 @@ func createDatabaseConnectionHandler(c echo.Context) error {
 	var req models.CreateDatabaseConnectionRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -0,0 +1,474 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var dhcpRepo *database.DHCPRepo
+
+// InitDHCPRepo initializes the DHCP repository
+func InitDHCPRepo() {
+	dhcpRepo = database.NewDHCPRepo()
+}
+
+// dhcpInterfaces returns the interfaces the DHCP server answers on
+func dhcpInterfaces() []string {
+	v, err := settingsRepo.Get(database.SettingDHCPInterfaces)
+	if err != nil {
+		return []string{}
+	}
+	return strings.Fields(v)
+}
+
+// getDHCPStatusHandler handles GET /api/network/dhcp
+func getDHCPStatusHandler(c echo.Context) error {
+	status := system.GetDHCPStatus()
+
+	scopes, err := dhcpRepo.ListScopes()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DHCP scopes: " + err.Error(),
+		})
+	}
+	counts := make(map[string]int)
+	for _, s := range scopes {
+		counts[s.Interface]++
+	}
+
+	interfaces, err := system.GetNetworkInterfaces()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list interfaces: " + err.Error(),
+		})
+	}
+	enabled := dhcpInterfaces()
+	for _, iface := range interfaces {
+		if iface.Type == "loopback" || (len(iface.IPv4) == 0 && counts[iface.Name] == 0) {
+			continue
+		}
+		status.Interfaces = append(status.Interfaces, models.DHCPInterface{
+			Name:    iface.Name,
+			State:   iface.State,
+			IPv4:    iface.IPv4,
+			Enabled: slices.Contains(enabled, iface.Name),
+			Scopes:  counts[iface.Name],
+		})
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// installDHCPHandler handles POST /api/network/dhcp/install
+func installDHCPHandler(c echo.Context) error {
+	if err := installDnsmasq(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDHCPInstall, "dnsmasq", nil)
+
+	return getDHCPStatusHandler(c)
+}
+
+// setDHCPInterfaceHandler handles PUT /api/network/dhcp/interfaces/:name
+func setDHCPInterfaceHandler(c echo.Context) error {
+	name := c.Param("name")
+	var req models.SetDHCPInterfaceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	previous := dhcpInterfaces()
+	interfaces := slices.DeleteFunc(slices.Clone(previous), func(n string) bool { return n == name })
+	if req.Enabled {
+		if _, err := system.GetInterfaceByName(name); err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Interface not found",
+			})
+		}
+		if !system.DnsmasqInstalled() {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Install the DHCP server first",
+			})
+		}
+		scopes, err := dhcpRepo.ListScopes()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to list DHCP scopes: " + err.Error(),
+			})
+		}
+		if !slices.ContainsFunc(scopes, func(s models.DHCPScope) bool { return s.Interface == name }) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Add a scope for " + name + " before enabling DHCP on it",
+			})
+		}
+		interfaces = append(interfaces, name)
+	}
+
+	if err := settingsRepo.Set(database.SettingDHCPInterfaces, strings.Join(interfaces, " ")); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save DHCP interfaces: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		settingsRepo.Set(database.SettingDHCPInterfaces, strings.Join(previous, " "))
+		applyDnsmasq()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DHCP configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDHCPInterface, name, map[string]interface{}{
+		"enabled": req.Enabled,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"interface": name,
+		"enabled":   req.Enabled,
+	})
+}
+
+// checkDHCPScope validates a scope and rejects ranges that overlap another
+// scope on the same interface
+func checkDHCPScope(s *models.DHCPScope) (int, error) {
+	if err := system.ValidateDHCPScope(s); err != nil {
+		return http.StatusBadRequest, err
+	}
+	scopes, err := dhcpRepo.ListScopes()
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to list DHCP scopes: %w", err)
+	}
+	for i := range scopes {
+		other := &scopes[i]
+		if other.ID == s.ID {
+			continue
+		}
+		if other.Name == s.Name {
+			return http.StatusConflict, fmt.Errorf("a scope named '%s' already exists", s.Name)
+		}
+		if other.Interface == s.Interface && system.DHCPRangesOverlap(s, other) {
+			return http.StatusConflict, fmt.Errorf("the range overlaps scope '%s'", other.Name)
+		}
+	}
+	return 0, nil
+}
+
+// listDHCPScopesHandler handles GET /api/network/dhcp/scopes
+func listDHCPScopesHandler(c echo.Context) error {
+	scopes, err := dhcpRepo.ListScopes()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DHCP scopes: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, scopes)
+}
+
+// createDHCPScopeHandler handles POST /api/network/dhcp/scopes
+func createDHCPScopeHandler(c echo.Context) error {
+	var scope models.DHCPScope
+	if err := c.Bind(&scope); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	scope.ID = 0
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	if status, err := checkDHCPScope(&scope); err != nil {
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := dhcpRepo.CreateScope(&scope); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save DHCP scope: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		dhcpRepo.DeleteScope(scope.ID)
+		applyDnsmasq()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DHCP configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDHCPScopeCreate, scope.Name, scope)
+
+	return c.JSON(http.StatusCreated, scope)
+}
+
+// updateDHCPScopeHandler handles PUT /api/network/dhcp/scopes/:id
+func updateDHCPScopeHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid scope ID",
+		})
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	existing, err := dhcpRepo.GetScope(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Scope not found",
+		})
+	}
+	scope := *existing
+	if err := c.Bind(&scope); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	scope.ID = id
+
+	if status, err := checkDHCPScope(&scope); err != nil {
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	reservations, err := dhcpRepo.ListReservations(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DHCP reservations: " + err.Error(),
+		})
+	}
+	for _, res := range reservations {
+		if !system.DHCPScopeContains(&scope, res.IPAddress) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Reservation %s (%s) would fall outside the scope's network", res.IPAddress, res.MAC),
+			})
+		}
+	}
+
+	if err := dhcpRepo.UpdateScope(&scope); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save DHCP scope: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		dhcpRepo.UpdateScope(existing)
+		applyDnsmasq()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DHCP configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDHCPScopeUpdate, scope.Name, scope)
+
+	return c.JSON(http.StatusOK, scope)
+}
+
+// deleteDHCPScopeHandler handles DELETE /api/network/dhcp/scopes/:id
+func deleteDHCPScopeHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid scope ID",
+		})
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	scope, err := dhcpRepo.GetScope(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Scope not found",
+		})
+	}
+	if err := dhcpRepo.DeleteScope(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete DHCP scope: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Scope deleted, but applying the DHCP configuration failed: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDHCPScopeDelete, scope.Name, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Scope deleted",
+	})
+}
+
+// listDHCPReservationsHandler handles GET /api/network/dhcp/reservations,
+// optionally filtered by ?scope_id=
+func listDHCPReservationsHandler(c echo.Context) error {
+	var scopeID int64
+	if v := c.QueryParam("scope_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid scope ID",
+			})
+		}
+		scopeID = id
+	}
+	reservations, err := dhcpRepo.ListReservations(scopeID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DHCP reservations: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, reservations)
+}
+
+// createDHCPReservationHandler handles POST /api/network/dhcp/reservations
+func createDHCPReservationHandler(c echo.Context) error {
+	var res models.DHCPReservation
+	if err := c.Bind(&res); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	scope, err := dhcpRepo.GetScope(res.ScopeID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Scope not found",
+		})
+	}
+	if err := system.ValidateDHCPReservation(&res, scope); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	existing, err := dhcpRepo.ListReservations(0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DHCP reservations: " + err.Error(),
+		})
+	}
+	for _, other := range existing {
+		if other.MAC == res.MAC {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": fmt.Sprintf("%s already has a reservation for %s", res.MAC, other.IPAddress),
+			})
+		}
+		if other.IPAddress == res.IPAddress {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": fmt.Sprintf("%s is already reserved for %s", res.IPAddress, other.MAC),
+			})
+		}
+	}
+
+	if err := dhcpRepo.CreateReservation(&res); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save DHCP reservation: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		dhcpRepo.DeleteReservation(res.ID)
+		applyDnsmasq()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DHCP configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDHCPReservationCreate, res.MAC, res)
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+// deleteDHCPReservationHandler handles DELETE /api/network/dhcp/reservations/:id
+func deleteDHCPReservationHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid reservation ID",
+		})
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	res, err := dhcpRepo.GetReservation(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Reservation not found",
+		})
+	}
+	if err := dhcpRepo.DeleteReservation(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete DHCP reservation: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Reservation deleted, but applying the DHCP configuration failed: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDHCPReservationDelete, res.MAC, map[string]interface{}{
+		"ip_address": res.IPAddress,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Reservation deleted",
+	})
+}
+
+// listDHCPLeasesHandler handles GET /api/network/dhcp/leases
+func listDHCPLeasesHandler(c echo.Context) error {
+	leases, err := system.GetDHCPLeases()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	scopes, err := dhcpRepo.ListScopes()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DHCP scopes: " + err.Error(),
+		})
+	}
+	reservations, err := dhcpRepo.ListReservations(0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DHCP reservations: " + err.Error(),
+		})
+	}
+
+	for i := range leases {
+		for j := range scopes {
+			if system.DHCPScopeContains(&scopes[j], leases[i].IPAddress) {
+				leases[i].ScopeID = scopes[j].ID
+				break
+			}
+		}
+		for _, res := range reservations {
+			if strings.EqualFold(res.MAC, leases[i].MAC) && res.IPAddress == leases[i].IPAddress {
+				leases[i].Reserved = true
+				break
+			}
+		}
+	}
+	return c.JSON(http.StatusOK, leases)
+}
@@ -0,0 +1,554 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var digestRepo *database.DigestRepo
+
+const (
+	// digestCheckInterval is how often due digests are looked for
+	digestCheckInterval = 10 * time.Minute
+	// defaultDigestHour is when digests go out until a user picks a time
+	defaultDigestHour = 8
+	// digestMaxPackages is how many pending packages a digest names
+	digestMaxPackages = 10
+	// digestMaxAddresses is how many failed sign-in sources a digest names
+	digestMaxAddresses = 5
+)
+
+// digestPseudoMounts are filesystem types left out of the disk section
+var digestPseudoMounts = []string{"overlay", "squashfs", "fuse.rclone", "nsfs"}
+
+// InitDigests initializes the digest repository and starts the scheduler
+func InitDigests() {
+	digestRepo = database.NewDigestRepo()
+	go runDigests()
+}
+
+func runDigests() {
+	time.Sleep(time.Minute)
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if !maintenanceModeActive() {
+			sendDueDigests(time.Now())
+		}
+		<-ticker.C
+	}
+}
+
+// loadDigestSubscription returns a user's subscription, or the default of no
+// digest with every section and email delivery
+func loadDigestSubscription(userID int64) models.DigestSubscription {
+	if sub, err := digestRepo.Get(userID); err == nil {
+		return *sub
+	}
+	return models.DigestSubscription{
+		UserID:    userID,
+		Frequency: models.DigestOff,
+		Hour:      defaultDigestHour,
+		Weekday:   int(time.Monday),
+		Sections:  slices.Clone(models.DigestSections),
+		Email:     true,
+	}
+}
+
+// digestPeriod is how much time one digest covers
+func digestPeriod(frequency string) time.Duration {
+	if frequency == models.DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// digestSlot returns the latest time at or before now a digest was due
+func digestSlot(sub models.DigestSubscription, now time.Time) time.Time {
+	slot := time.Date(now.Year(), now.Month(), now.Day(), sub.Hour, 0, 0, 0, now.Location())
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	if sub.Frequency == models.DigestWeekly {
+		for slot.Weekday() != time.Weekday(sub.Weekday) {
+			slot = slot.AddDate(0, 0, -1)
+		}
+	}
+	return slot
+}
+
+// digestSections returns the sections a user gets, in digest order. Security
+// events are only reported to admins.
+func digestSections(sub models.DigestSubscription, user *models.User) []string {
+	var sections []string
+	for _, s := range models.DigestSections {
+		if slices.Contains(sub.Sections, s) && (s != models.DigestSectionSecurity || user.IsAdmin()) {
+			sections = append(sections, s)
+		}
+	}
+	return sections
+}
+
+// digestSources caches what every digest in one run reports the same way, so
+// dnf is asked for updates once however many users are due
+type digestSources struct {
+	updates *models.DigestUpdates
+}
+
+// pendingUpdates summarises the available package updates
+func (src *digestSources) pendingUpdates() *models.DigestUpdates {
+	if src.updates != nil {
+		return src.updates
+	}
+	src.updates = &models.DigestUpdates{Packages: []string{}}
+	updates, err := system.GetAvailableUpdates()
+	if err != nil {
+		src.updates.Error = err.Error()
+		return src.updates
+	}
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].SecurityUpdate && !updates[j].SecurityUpdate
+	})
+	src.updates.Available = len(updates)
+	for _, u := range updates {
+		if u.SecurityUpdate {
+			src.updates.Security++
+		}
+		if len(src.updates.Packages) < digestMaxPackages {
+			src.updates.Packages = append(src.updates.Packages, u.Name)
+		}
+	}
+	return src.updates
+}
+
+// digestBackups lists the container backups and host snapshots taken since from
+func digestBackups(from time.Time) *models.DigestBackups {
+	backups := &models.DigestBackups{ContainerBackups: []string{}, Snapshots: []string{}}
+	logs, _, err := auditRepo.List(models.AuditFilter{
+		Action:    models.ActionContainerBackup,
+		StartTime: from,
+		Limit:     100,
+	})
+	if err == nil {
+		for _, l := range logs {
+			backups.ContainerBackups = append(backups.ContainerBackups, l.Target)
+		}
+	}
+	if snapshots, err := hostSnapshotRepo.List(); err == nil {
+		for _, s := range snapshots {
+			if !s.CreatedAt.Before(from) {
+				backups.Snapshots = append(backups.Snapshots, s.MountPoint+": "+s.Reason)
+			}
+		}
+	}
+	return backups
+}
+
+// digestDisk reports filesystem usage and its change since the baseline,
+// returning the usage to compare the next digest with
+func digestDisk(baseline string) ([]models.DigestDiskUsage, map[string]uint64) {
+	previous := map[string]uint64{}
+	if baseline != "" {
+		json.Unmarshal([]byte(baseline), &previous)
+	}
+
+	mounts, err := system.GetMounts()
+	if err != nil {
+		return []models.DigestDiskUsage{}, previous
+	}
+	current := make(map[string]uint64, len(mounts))
+	usage := make([]models.DigestDiskUsage, 0, len(mounts))
+	for _, m := range mounts {
+		if m.Total == 0 || slices.Contains(digestPseudoMounts, m.FSType) ||
+			strings.HasPrefix(m.MountPoint, "/run/") || strings.HasPrefix(m.MountPoint, "/var/lib/containers/") {
+			continue
+		}
+		if _, seen := current[m.MountPoint]; seen {
+			continue
+		}
+		current[m.MountPoint] = m.Used
+		d := models.DigestDiskUsage{
+			MountPoint: m.MountPoint,
+			Total:      m.Total,
+			Used:       m.Used,
+			UsePercent: m.UsePercent,
+		}
+		if before, ok := previous[m.MountPoint]; ok {
+			d.Change, d.HasChange = int64(m.Used)-int64(before), true
+		}
+		usage = append(usage, d)
+	}
+	return usage, current
+}
+
+// digestSecurity counts sign-ins and security changes since from
+func digestSecurity(from time.Time) *models.DigestSecurity {
+	count := func(filter models.AuditFilter) int {
+		filter.StartTime, filter.Limit = from, 1
+		_, total, _ := auditRepo.List(filter)
+		return total
+	}
+	security := &models.DigestSecurity{
+		Logins:         count(models.AuditFilter{Action: models.ActionLogin}),
+		SessionRevokes: count(models.AuditFilter{Action: models.ActionSessionRevoke}),
+		UserChanges:    count(models.AuditFilter{ActionPrefix: "user."}),
+		FirewallEvents: count(models.AuditFilter{ActionPrefix: "firewall."}),
+		FailedFrom:     []string{},
+	}
+
+	failed, total, err := auditRepo.List(models.AuditFilter{
+		Action:    models.ActionLoginFailed,
+		StartTime: from,
+		Limit:     1000,
+	})
+	if err != nil {
+		return security
+	}
+	security.FailedLogins = total
+	bySource := make(map[string]int)
+	for _, l := range failed {
+		if l.IPAddress != "" {
+			bySource[l.IPAddress]++
+		}
+	}
+	for ip := range bySource {
+		security.FailedFrom = append(security.FailedFrom, ip)
+	}
+	sort.Slice(security.FailedFrom, func(i, j int) bool {
+		a, b := security.FailedFrom[i], security.FailedFrom[j]
+		return bySource[a] > bySource[b] || bySource[a] == bySource[b] && a < b
+	})
+	for i, ip := range security.FailedFrom {
+		security.FailedFrom[i] = fmt.Sprintf("%s (%d)", ip, bySource[ip])
+	}
+	if len(security.FailedFrom) > digestMaxAddresses {
+		security.FailedFrom = security.FailedFrom[:digestMaxAddresses]
+	}
+	return security
+}
+
+// buildDigest gathers a user's digest for the period from to, returning the
+// disk usage to store as the next baseline
+func buildDigest(sub models.DigestSubscription, user *models.User, from, to time.Time, src *digestSources) (*models.Digest, map[string]uint64) {
+	digest := &models.Digest{Frequency: sub.Frequency, From: from, To: to}
+	var disk map[string]uint64
+	for _, section := range digestSections(sub, user) {
+		switch section {
+		case models.DigestSectionUpdates:
+			digest.Updates = src.pendingUpdates()
+		case models.DigestSectionBackups:
+			digest.Backups = digestBackups(from)
+		case models.DigestSectionDisk:
+			digest.Disk, disk = digestDisk(sub.DiskBaseline)
+		case models.DigestSectionRestarts:
+			digest.Restarts, _ = containerExitRepo.SummarySince(from)
+			if digest.Restarts == nil {
+				digest.Restarts = []models.DigestRestart{}
+			}
+		case models.DigestSectionSecurity:
+			digest.Security = digestSecurity(from)
+		}
+	}
+	return digest, disk
+}
+
+// plural formats a count with its noun
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	if strings.HasSuffix(noun, "sh") {
+		return fmt.Sprintf("%d %ses", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// renderDigestText writes a digest as plain text for email
+func renderDigestText(d *models.Digest) string {
+	var b strings.Builder
+	if u := d.Updates; u != nil {
+		b.WriteString("Updates\n")
+		switch {
+		case u.Error != "":
+			fmt.Fprintf(&b, "  Couldn't check for updates: %s\n", u.Error)
+		case u.Available == 0:
+			b.WriteString("  The system is up to date\n")
+		default:
+			fmt.Fprintf(&b, "  %s available, %d security\n", plural(u.Available, "package update"), u.Security)
+			fmt.Fprintf(&b, "  %s", strings.Join(u.Packages, ", "))
+			if u.Available > len(u.Packages) {
+				fmt.Fprintf(&b, " and %d more", u.Available-len(u.Packages))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if bk := d.Backups; bk != nil {
+		b.WriteString("Backups\n")
+		if len(bk.ContainerBackups) == 0 && len(bk.Snapshots) == 0 {
+			b.WriteString("  No backups or snapshots were taken\n")
+		}
+		if len(bk.ContainerBackups) > 0 {
+			fmt.Fprintf(&b, "  %s: %s\n", plural(len(bk.ContainerBackups), "container backup"), strings.Join(bk.ContainerBackups, ", "))
+		}
+		if len(bk.Snapshots) > 0 {
+			fmt.Fprintf(&b, "  %s: %s\n", plural(len(bk.Snapshots), "host snapshot"), strings.Join(bk.Snapshots, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	if d.Disk != nil {
+		b.WriteString("Disk usage\n")
+		for _, m := range d.Disk {
+			fmt.Fprintf(&b, "  %s: %.0f%% of %s used", m.MountPoint, m.UsePercent, humanBytes(int64(m.Total)))
+			if m.HasChange {
+				sign := "+"
+				if m.Change < 0 {
+					sign = "-"
+				}
+				fmt.Fprintf(&b, ", %s%s since the last digest", sign, humanBytes(max(m.Change, -m.Change)))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if d.Restarts != nil {
+		b.WriteString("Container restarts\n")
+		if len(d.Restarts) == 0 {
+			b.WriteString("  No containers crashed or were stopped\n")
+		}
+		for _, r := range d.Restarts {
+			var counts []string
+			if r.Crashes > 0 {
+				counts = append(counts, plural(r.Crashes, "crash"))
+			}
+			if r.OOMKills > 0 {
+				counts = append(counts, plural(r.OOMKills, "OOM kill"))
+			}
+			if r.Stops > 0 {
+				counts = append(counts, plural(r.Stops, "stop"))
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", r.Container, strings.Join(counts, ", "))
+		}
+		b.WriteString("\n")
+	}
+
+	if s := d.Security; s != nil {
+		b.WriteString("Security\n")
+		fmt.Fprintf(&b, "  %s, %s\n", plural(s.Logins, "sign-in"), plural(s.FailedLogins, "failed sign-in"))
+		if len(s.FailedFrom) > 0 {
+			fmt.Fprintf(&b, "  Failed sign-ins from %s\n", strings.Join(s.FailedFrom, ", "))
+		}
+		fmt.Fprintf(&b, "  %s revoked, %s, %s\n", plural(s.SessionRevokes, "session"),
+			plural(s.UserChanges, "user account change"), plural(s.FirewallEvents, "firewall change"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// sendDigest builds a digest and delivers it by email and notification
+func sendDigest(ctx context.Context, sub models.DigestSubscription, user *models.User, from, to time.Time, src *digestSources) (map[string]uint64, error) {
+	digest, disk := buildDigest(sub, user, from, to, src)
+	frequency := sub.Frequency
+	if frequency == models.DigestOff {
+		frequency = models.DigestDaily
+	}
+
+	var err error
+	if sub.Email && user.Email != "" {
+		err = sendEmail(ctx, user.Email, emailTemplateDigest, emailTemplateData{
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			Email:       user.Email,
+			Period:      frequency,
+			Digest:      renderDigestText(digest),
+		})
+	}
+	if sub.Notification {
+		notifyUser(user.ID, localized(models.Notification{
+			Type:  models.NotificationDigest,
+			Level: models.NotificationInfo,
+			Data:  map[string]interface{}{"digest": digest},
+		}, "notification.digest_"+frequency, "host", loadHostProfile().DisplayName()))
+	}
+	return disk, err
+}
+
+// sendDueDigests sends every digest whose time has come. A digest missed
+// while Stardeck was down goes out once, covering the whole gap.
+func sendDueDigests(now time.Time) {
+	subs, err := digestRepo.ListActive()
+	if err != nil {
+		log.Printf("Warning: failed to list digest subscriptions: %v", err)
+		return
+	}
+
+	src := &digestSources{}
+	for _, sub := range subs {
+		slot := digestSlot(sub, now)
+		if sub.LastSentAt != nil && !sub.LastSentAt.Before(slot) {
+			continue
+		}
+		user, err := userRepo.GetByID(sub.UserID)
+		if err != nil || user.Disabled {
+			continue
+		}
+
+		from := now.Add(-digestPeriod(sub.Frequency))
+		if sub.LastSentAt != nil {
+			from = *sub.LastSentAt
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+		disk, err := sendDigest(ctx, sub, user, from, now, src)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to email digest to %s: %v", user.Username, err)
+		}
+
+		baseline := sub.DiskBaseline
+		if disk != nil {
+			if b, err := json.Marshal(disk); err == nil {
+				baseline = string(b)
+			}
+		}
+		if err := digestRepo.MarkSent(sub.UserID, now, baseline); err != nil {
+			log.Printf("Warning: failed to record digest for %s: %v", user.Username, err)
+		}
+	}
+}
+
+// getDigestSubscriptionHandler handles GET /api/user/digest
+func getDigestSubscriptionHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	return c.JSON(http.StatusOK, loadDigestSubscription(user.ID))
+}
+
+// updateDigestSubscriptionHandler handles PUT /api/user/digest
+func updateDigestSubscriptionHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	current := loadDigestSubscription(user.ID)
+	sub := current
+	if err := c.Bind(&sub); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	sub.UserID = user.ID
+
+	switch {
+	case sub.Frequency != models.DigestOff && sub.Frequency != models.DigestDaily && sub.Frequency != models.DigestWeekly:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "frequency must be off, daily or weekly",
+		})
+	case sub.Hour < 0 || sub.Hour > 23:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "hour must be between 0 and 23",
+		})
+	case sub.Weekday < 0 || sub.Weekday > 6:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "weekday must be between 0 (Sunday) and 6",
+		})
+	}
+	for _, s := range sub.Sections {
+		if !slices.Contains(models.DigestSections, s) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Unknown digest section: " + s,
+			})
+		}
+		if s == models.DigestSectionSecurity && !user.IsAdmin() {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Only admins can receive security events",
+			})
+		}
+	}
+	if sub.Frequency != models.DigestOff {
+		if !sub.Email && !sub.Notification {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Choose email, notification or both",
+			})
+		}
+		if sub.Email && user.Email == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Add an email address to your account to get digests by email",
+			})
+		}
+	}
+
+	// A new subscription starts with the next scheduled digest
+	if current.Frequency == models.DigestOff && sub.Frequency != models.DigestOff {
+		now := time.Now()
+		sub.LastSentAt = &now
+	}
+	if err := digestRepo.Upsert(&sub); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save digest: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, loadDigestSubscription(user.ID))
+}
+
+// digestPreviewRange is the period the next digest would cover if sent now
+func digestPreviewRange(sub models.DigestSubscription, now time.Time) time.Time {
+	if sub.LastSentAt != nil && sub.Frequency != models.DigestOff {
+		return *sub.LastSentAt
+	}
+	return now.Add(-digestPeriod(sub.Frequency))
+}
+
+// previewDigestHandler handles GET /api/user/digest/preview, building the
+// next digest without sending it
+func previewDigestHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	sub := loadDigestSubscription(user.ID)
+	now := time.Now()
+
+	digest, _ := buildDigest(sub, user, digestPreviewRange(sub, now), now, &digestSources{})
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"digest": digest,
+		"text":   renderDigestText(digest),
+	})
+}
+
+// sendDigestNowHandler handles POST /api/user/digest/send, delivering the
+// digest now to check it arrives. The schedule is left as it is.
+func sendDigestNowHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	sub := loadDigestSubscription(user.ID)
+	if sub.Email && user.Email == "" && !sub.Notification {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Add an email address to your account to get digests by email",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), emailSendTimeout)
+	defer cancel()
+
+	now := time.Now()
+	if _, err := sendDigest(ctx, sub, user, digestPreviewRange(sub, now), now, &digestSources{}); err != nil {
+		if err == errEmailDisabled {
+			return emailUnavailable(c)
+		}
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to send digest: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Digest sent",
+	})
+}
@@ -0,0 +1,553 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var dnsRecordRepo *database.DNSRecordRepo
+
+// dnsmasqMu serializes configuration changes so each applied dnsmasq
+// configuration matches the database. dnsmasq serves both DHCP and DNS.
+var dnsmasqMu sync.Mutex
+
+// dnsBlocklistMaxAge is how old a blocklist may get before it is downloaded again
+const dnsBlocklistMaxAge = 24 * time.Hour
+
+// InitDNSRecordRepo initializes the DNS record repository and starts keeping
+// container records and blocklists current
+func InitDNSRecordRepo() {
+	dnsRecordRepo = database.NewDNSRecordRepo()
+	go runDNSMaintenance()
+}
+
+// loadDNSSettings reads the resolver settings, applying defaults for missing values
+func loadDNSSettings() models.DNSSettings {
+	s := models.DNSSettings{
+		Interfaces: []string{},
+		Upstreams:  []string{},
+		CacheSize:  1000,
+		Blocklists: []string{},
+	}
+	if v, err := settingsRepo.GetBool(database.SettingDNSEnabled); err == nil {
+		s.Enabled = v
+	}
+	if v, err := settingsRepo.Get(database.SettingDNSInterfaces); err == nil && v != "" {
+		s.Interfaces = strings.Fields(v)
+	}
+	if v, err := settingsRepo.Get(database.SettingDNSUpstreams); err == nil && v != "" {
+		s.Upstreams = strings.Fields(v)
+	}
+	if v, err := settingsRepo.Get(database.SettingDNSDomain); err == nil {
+		s.Domain = v
+	}
+	if v, err := settingsRepo.GetBool(database.SettingDNSAutoRecords); err == nil {
+		s.AutoRecords = v
+	}
+	if v, err := settingsRepo.Get(database.SettingDNSHostAddress); err == nil {
+		s.HostAddress = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingDNSCacheSize); err == nil && v >= 0 {
+		s.CacheSize = v
+	}
+	if v, err := settingsRepo.Get(database.SettingDNSBlocklists); err == nil && v != "" {
+		s.Blocklists = strings.Fields(v)
+	}
+	return s
+}
+
+// saveDNSSettings stores the resolver settings
+func saveDNSSettings(s models.DNSSettings) error {
+	values := map[string]string{
+		database.SettingDNSEnabled:     strconv.FormatBool(s.Enabled),
+		database.SettingDNSInterfaces:  strings.Join(s.Interfaces, " "),
+		database.SettingDNSUpstreams:   strings.Join(s.Upstreams, " "),
+		database.SettingDNSDomain:      s.Domain,
+		database.SettingDNSAutoRecords: strconv.FormatBool(s.AutoRecords),
+		database.SettingDNSHostAddress: s.HostAddress,
+		database.SettingDNSCacheSize:   strconv.Itoa(s.CacheSize),
+		database.SettingDNSBlocklists:  strings.Join(s.Blocklists, " "),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return err
+	}
+	return nil
+}
+
+// dnsHostAddress returns the address generated records point at, and their type
+func dnsHostAddress(s models.DNSSettings) (string, models.DNSRecordType) {
+	address := s.HostAddress
+	if address == "" {
+		address = system.DefaultHostAddress(append(slices.Clone(s.Interfaces), dhcpInterfaces()...))
+	}
+	if !strings.Contains(address, ".") {
+		return address, models.DNSRecordAAAA
+	}
+	return address, models.DNSRecordA
+}
+
+// dnsAutoRecords generates a record for each container with a web UI,
+// pointing at the host that publishes it, and one for each ingress host
+func dnsAutoRecords(s models.DNSSettings) []models.DNSRecord {
+	records := []models.DNSRecord{}
+	address, recordType := dnsHostAddress(s)
+	if address == "" {
+		return records
+	}
+
+	if s.AutoRecords {
+		containers, err := containerRepo.ListWithWebUI()
+		if err != nil {
+			log.Printf("Warning: failed to list containers for DNS records: %v", err)
+			return records
+		}
+		for _, container := range containers {
+			name := system.ContainerRecordName(container.Name)
+			if name == "" {
+				continue
+			}
+			records = append(records, models.DNSRecord{
+				Name:      name,
+				Type:      recordType,
+				Value:     address,
+				Container: container.Name,
+			})
+		}
+	}
+
+	// Ingress names resolve to Stardeck, which routes them by host name
+	if loadIngressSettings().Enabled {
+		hosts, _, err := ingressHosts()
+		if err != nil {
+			log.Printf("Warning: failed to list ingress hosts for DNS records: %v", err)
+			return records
+		}
+		generated := make(map[string]bool, len(records))
+		for _, rec := range records {
+			generated[rec.Name+"."+s.Domain] = true
+		}
+		for _, host := range hosts {
+			if host.Enabled && !generated[host.Hostname] {
+				records = append(records, models.DNSRecord{
+					Name:      host.Hostname,
+					Type:      recordType,
+					Value:     address,
+					Container: host.ContainerName,
+				})
+			}
+		}
+	}
+	return records
+}
+
+// dnsmasqConfig gathers the DHCP and DNS configuration from the database
+func dnsmasqConfig() (*system.DnsmasqConfig, error) {
+	scopes, err := dhcpRepo.ListScopes()
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := dhcpRepo.ListReservations(0)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &system.DnsmasqConfig{
+		DHCPInterfaces: dhcpInterfaces(),
+		Scopes:         scopes,
+		Reservations:   reservations,
+		DNS:            loadDNSSettings(),
+	}
+	if cfg.DNS.Enabled {
+		records, err := dnsRecordRepo.List()
+		if err != nil {
+			return nil, err
+		}
+		// Records users define take precedence over generated ones
+		defined := make(map[string]bool, len(records))
+		for _, rec := range records {
+			defined[rec.Name] = true
+		}
+		for _, rec := range dnsAutoRecords(cfg.DNS) {
+			if !defined[rec.Name] {
+				records = append(records, rec)
+			}
+		}
+		cfg.Records = records
+	}
+	return cfg, nil
+}
+
+// applyDnsmasq renders the stored DHCP and DNS configuration into dnsmasq.
+// Until dnsmasq is installed the configuration is only stored.
+func applyDnsmasq() error {
+	if !system.DnsmasqInstalled() {
+		return nil
+	}
+	cfg, err := dnsmasqConfig()
+	if err != nil {
+		return err
+	}
+	return system.ApplyDnsmasqConfig(cfg)
+}
+
+// installDnsmasq installs the dnsmasq package and applies the stored configuration
+func installDnsmasq() error {
+	if !system.DnsmasqInstalled() {
+		result, err := system.InstallPackages([]string{"dnsmasq"})
+		if err != nil {
+			return fmt.Errorf("failed to install dnsmasq: %w", err)
+		}
+		if !result.Success {
+			return fmt.Errorf("%s", result.Message)
+		}
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+	if err := applyDnsmasq(); err != nil {
+		return fmt.Errorf("failed to configure dnsmasq: %w", err)
+	}
+	return nil
+}
+
+// runDNSMaintenance keeps container records in step with the containers and
+// downloads blocklists again once they are a day old
+func runDNSMaintenance() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if maintenanceModeActive() || !system.DnsmasqInstalled() {
+			continue
+		}
+		settings := loadDNSSettings()
+		if !settings.Enabled {
+			continue
+		}
+
+		refreshed := false
+		for _, list := range settings.Blocklists {
+			state := system.GetDNSBlocklist(list)
+			if state.UpdatedAt != nil && time.Since(*state.UpdatedAt) < dnsBlocklistMaxAge {
+				continue
+			}
+			if _, err := system.RefreshDNSBlocklist(list); err != nil {
+				log.Printf("Warning: failed to refresh DNS blocklist: %v", err)
+				continue
+			}
+			refreshed = true
+		}
+
+		dnsmasqMu.Lock()
+		cfg, err := dnsmasqConfig()
+		if err == nil {
+			current, _ := os.ReadFile(system.DnsmasqConfigPath)
+			if refreshed || string(current) != system.RenderDnsmasqConfig(cfg) {
+				err = system.ApplyDnsmasqConfig(cfg)
+			}
+		}
+		dnsmasqMu.Unlock()
+		if err != nil {
+			log.Printf("Warning: failed to update DNS resolver: %v", err)
+		}
+	}
+}
+
+// getDNSResolverHandler handles GET /api/network/dns/resolver
+func getDNSResolverHandler(c echo.Context) error {
+	status := system.GetDNSStatus()
+	status.Settings = loadDNSSettings()
+	for _, list := range status.Settings.Blocklists {
+		status.Blocklists = append(status.Blocklists, system.GetDNSBlocklist(list))
+	}
+	status.AutoRecords = dnsAutoRecords(status.Settings)
+	return c.JSON(http.StatusOK, status)
+}
+
+// installDNSResolverHandler handles POST /api/network/dns/resolver/install
+func installDNSResolverHandler(c echo.Context) error {
+	if err := installDnsmasq(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDNSInstall, "dnsmasq", nil)
+
+	return getDNSResolverHandler(c)
+}
+
+// updateDNSResolverHandler handles PUT /api/network/dns/resolver
+func updateDNSResolverHandler(c echo.Context) error {
+	previous := loadDNSSettings()
+	settings := loadDNSSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := system.ValidateDNSSettings(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if settings.Enabled && !system.DnsmasqInstalled() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Install the DNS resolver first",
+		})
+	}
+
+	// New lists are downloaded up front so a bad URL is reported now
+	for _, list := range settings.Blocklists {
+		if slices.Contains(previous.Blocklists, list) && system.GetDNSBlocklist(list).UpdatedAt != nil {
+			continue
+		}
+		if _, err := system.RefreshDNSBlocklist(list); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	if err := saveDNSSettings(settings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save DNS settings: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		saveDNSSettings(previous)
+		applyDnsmasq()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DNS configuration: " + err.Error(),
+		})
+	}
+	for _, list := range previous.Blocklists {
+		if !slices.Contains(settings.Blocklists, list) {
+			system.RemoveDNSBlocklist(list)
+		}
+	}
+
+	Audit.LogFromContext(c, models.ActionDNSConfigure, "dns", settings)
+
+	return getDNSResolverHandler(c)
+}
+
+// refreshDNSBlocklistsHandler handles POST /api/network/dns/blocklists/refresh
+func refreshDNSBlocklistsHandler(c echo.Context) error {
+	settings := loadDNSSettings()
+	lists := make([]models.DNSBlocklist, 0, len(settings.Blocklists))
+	for _, list := range settings.Blocklists {
+		if _, err := system.RefreshDNSBlocklist(list); err != nil {
+			state := system.GetDNSBlocklist(list)
+			state.Error = err.Error()
+			lists = append(lists, state)
+			continue
+		}
+		lists = append(lists, system.GetDNSBlocklist(list))
+	}
+
+	dnsmasqMu.Lock()
+	err := applyDnsmasq()
+	dnsmasqMu.Unlock()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DNS configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDNSBlocklistUpdate, "dns", map[string]interface{}{
+		"blocklists": settings.Blocklists,
+	})
+
+	return c.JSON(http.StatusOK, lists)
+}
+
+// checkDNSRecord validates a record against the others. dnsmasq only answers
+// a CNAME whose target it serves itself, so the target must be a local record.
+func checkDNSRecord(rec *models.DNSRecord) (int, error) {
+	if err := system.ValidateDNSRecord(rec); err != nil {
+		return http.StatusBadRequest, err
+	}
+	records, err := dnsRecordRepo.List()
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to list DNS records: %w", err)
+	}
+	settings := loadDNSSettings()
+	records = append(records, dnsAutoRecords(settings)...)
+
+	targetFound := false
+	for _, other := range records {
+		if other.ID == rec.ID && other.Container == "" {
+			continue
+		}
+		if other.Name == rec.Name && (rec.Type == models.DNSRecordCNAME || other.Type == models.DNSRecordCNAME) && other.Container == "" {
+			return http.StatusConflict, fmt.Errorf("%s already has a record; a CNAME can't share its name", rec.Name)
+		}
+		if other.Name == rec.Name && other.Type == rec.Type && other.Value == rec.Value && other.Container == "" {
+			return http.StatusConflict, fmt.Errorf("the record already exists")
+		}
+		local := other.Name
+		if settings.Domain != "" && !strings.Contains(local, ".") {
+			local += "." + settings.Domain
+		}
+		if rec.Value == other.Name || rec.Value == local {
+			targetFound = true
+		}
+	}
+	if rec.Type == models.DNSRecordCNAME && !targetFound {
+		return http.StatusBadRequest, fmt.Errorf("a CNAME must point at another local record; dnsmasq doesn't answer CNAMEs for upstream names")
+	}
+	return 0, nil
+}
+
+// listDNSRecordsHandler handles GET /api/network/dns/records
+func listDNSRecordsHandler(c echo.Context) error {
+	records, err := dnsRecordRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list DNS records: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, records)
+}
+
+// createDNSRecordHandler handles POST /api/network/dns/records
+func createDNSRecordHandler(c echo.Context) error {
+	var rec models.DNSRecord
+	if err := c.Bind(&rec); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	rec.ID = 0
+	rec.Container = ""
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	if status, err := checkDNSRecord(&rec); err != nil {
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := dnsRecordRepo.Create(&rec); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save DNS record: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		dnsRecordRepo.Delete(rec.ID)
+		applyDnsmasq()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DNS configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDNSRecordCreate, rec.Name, rec)
+
+	return c.JSON(http.StatusCreated, rec)
+}
+
+// updateDNSRecordHandler handles PUT /api/network/dns/records/:id
+func updateDNSRecordHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid record ID",
+		})
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	existing, err := dnsRecordRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Record not found",
+		})
+	}
+	rec := *existing
+	if err := c.Bind(&rec); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	rec.ID = id
+	rec.Container = ""
+
+	if status, err := checkDNSRecord(&rec); err != nil {
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := dnsRecordRepo.Update(&rec); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save DNS record: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		dnsRecordRepo.Update(existing)
+		applyDnsmasq()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DNS configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDNSRecordUpdate, rec.Name, rec)
+
+	return c.JSON(http.StatusOK, rec)
+}
+
+// deleteDNSRecordHandler handles DELETE /api/network/dns/records/:id
+func deleteDNSRecordHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid record ID",
+		})
+	}
+
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+
+	rec, err := dnsRecordRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Record not found",
+		})
+	}
+	if err := dnsRecordRepo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete DNS record: " + err.Error(),
+		})
+	}
+	if err := applyDnsmasq(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Record deleted, but applying the DNS configuration failed: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDNSRecordDelete, rec.Name, map[string]interface{}{
+		"type":  rec.Type,
+		"value": rec.Value,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Record deleted",
+	})
+}
@@ -0,0 +1,319 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var domainRoleMappingRepo *database.DomainRoleMappingRepo
+
+// domainJoinTimeout bounds realm join/leave, which install packages on first use
+const domainJoinTimeout = 10 * time.Minute
+
+// domainNamePattern accepts DNS domain names such as corp.example.com
+var domainNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.\-]*[a-zA-Z0-9])?$`)
+
+// InitDomainRoleMappingRepo initializes the domain role mapping repository
+func InitDomainRoleMappingRepo() {
+	domainRoleMappingRepo = database.NewDomainRoleMappingRepo()
+}
+
+// domainStatus reports realmd availability and the host's current memberships
+func domainStatus(ctx context.Context) (*models.DomainStatus, error) {
+	status := &models.DomainStatus{
+		Available: system.RealmdAvailable(),
+		Domains:   []models.DomainInfo{},
+	}
+	status.PAMLogins, _ = settingsRepo.GetBool(database.SettingAuthPAMEnabled)
+	if !status.Available {
+		return status, nil
+	}
+
+	domains, err := system.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status.Domains = domains
+	for _, d := range domains {
+		if d.Configured {
+			status.Joined = true
+		}
+	}
+	status.SSSDActive = system.SSSDActive()
+	return status, nil
+}
+
+// getDomainStatusHandler returns the host's AD/FreeIPA join status
+func getDomainStatusHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	status, err := domainStatus(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get domain status: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// discoverDomainHandler looks up a domain (or the network's default) without joining it
+func discoverDomainHandler(c echo.Context) error {
+	if !system.RealmdAvailable() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "realmd is not installed (dnf install realmd sssd oddjob-mkhomedir adcli)",
+		})
+	}
+
+	domain := strings.TrimSpace(c.QueryParam("domain"))
+	if domain != "" && !domainNamePattern.MatchString(domain) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid domain name",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	domains, err := system.DiscoverDomain(ctx, domain)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to discover domain: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, domains)
+}
+
+// joinDomainHandler joins the host to an AD or FreeIPA domain through realmd
+func joinDomainHandler(c echo.Context) error {
+	var req models.JoinDomainRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.Domain = strings.TrimSpace(req.Domain)
+	req.Username = strings.TrimSpace(req.Username)
+	if !domainNamePattern.MatchString(req.Domain) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid domain name",
+		})
+	}
+	if req.Username == "" || req.Password == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "username and password of an account allowed to join computers are required",
+		})
+	}
+	if strings.ContainsAny(req.Username+req.ComputerName, " \n") || strings.Contains(req.ComputerOU, "\n") {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "username, computer_name and computer_ou must be single values",
+		})
+	}
+	if !system.RealmdAvailable() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "realmd is not installed (dnf install realmd sssd oddjob-mkhomedir adcli)",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), domainJoinTimeout)
+	defer cancel()
+
+	user := c.Get("user").(*models.User)
+	details := map[string]interface{}{
+		"domain":      req.Domain,
+		"username":    req.Username,
+		"computer_ou": req.ComputerOU,
+	}
+
+	output, err := system.JoinDomain(ctx, &req)
+	if err != nil {
+		details["error"] = err.Error()
+		logAudit(user, models.ActionDomainJoin, req.Domain, details)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":  err.Error(),
+			"output": output,
+		})
+	}
+	logAudit(user, models.ActionDomainJoin, req.Domain, details)
+
+	status, err := domainStatus(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Joined, but failed to read domain status: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": status,
+		"output": output,
+	})
+}
+
+// leaveDomainHandler removes the host from a domain
+func leaveDomainHandler(c echo.Context) error {
+	var req models.LeaveDomainRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.Domain = strings.TrimSpace(req.Domain)
+	req.Username = strings.TrimSpace(req.Username)
+	if !domainNamePattern.MatchString(req.Domain) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid domain name",
+		})
+	}
+	if req.Username != "" && req.Password == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "password is required to remove the computer account",
+		})
+	}
+	if !system.RealmdAvailable() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "realmd is not installed",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), domainJoinTimeout)
+	defer cancel()
+
+	user := c.Get("user").(*models.User)
+	details := map[string]interface{}{
+		"domain":          req.Domain,
+		"removed_account": req.Username != "",
+	}
+
+	output, err := system.LeaveDomain(ctx, &req)
+	if err != nil {
+		details["error"] = err.Error()
+		logAudit(user, models.ActionDomainLeave, req.Domain, details)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":  err.Error(),
+			"output": output,
+		})
+	}
+	logAudit(user, models.ActionDomainLeave, req.Domain, details)
+
+	status, err := domainStatus(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Left, but failed to read domain status: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": status,
+		"output": output,
+	})
+}
+
+// listDomainRoleMappingsHandler returns the group to role mappings
+func listDomainRoleMappingsHandler(c echo.Context) error {
+	mappings, err := domainRoleMappingRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list mappings: " + err.Error(),
+		})
+	}
+	if mappings == nil {
+		mappings = []models.DomainRoleMapping{}
+	}
+	return c.JSON(http.StatusOK, mappings)
+}
+
+// createDomainRoleMappingHandler maps a domain group to a Stardeck role. The role
+// is applied the next time a member signs in with their system account.
+func createDomainRoleMappingHandler(c echo.Context) error {
+	var req models.CreateDomainRoleMappingRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.GroupName = strings.TrimSpace(req.GroupName)
+	if req.GroupName == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "group_name is required",
+		})
+	}
+	switch req.Role {
+	case models.RoleAdmin, models.RoleOperator, models.RoleViewer:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "role must be admin, operator or viewer",
+		})
+	}
+
+	mapping := &models.DomainRoleMapping{
+		GroupName: req.GroupName,
+		Role:      req.Role,
+	}
+	if err := domainRoleMappingRepo.Create(mapping); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "A mapping for this group already exists",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create mapping: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionDomainMappingCreate, mapping.GroupName, map[string]interface{}{
+		"role": mapping.Role,
+	})
+
+	return c.JSON(http.StatusCreated, mapping)
+}
+
+// deleteDomainRoleMappingHandler removes a group to role mapping. Users keep the
+// role they were last given until an admin changes it.
+func deleteDomainRoleMappingHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid mapping ID",
+		})
+	}
+
+	mapping, err := domainRoleMappingRepo.GetByID(id)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Mapping not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get mapping: " + err.Error(),
+		})
+	}
+
+	if err := domainRoleMappingRepo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete mapping: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionDomainMappingDelete, mapping.GroupName, map[string]interface{}{
+		"role": mapping.Role,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
@@ -0,0 +1,481 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// smtpPasswordSecret names the Podman secret holding the SMTP password
+const smtpPasswordSecret = "stardeck-smtp-password"
+
+// Email defaults
+const (
+	defaultSMTPPort        = 587
+	defaultInviteTTLHours  = 72
+	defaultResetTTLMinutes = 60
+)
+
+// errEmailDisabled is returned when mail is off or not fully configured
+var errEmailDisabled = errors.New("email is not configured")
+
+// Email template names
+const (
+	emailTemplateInvitation    = "invitation"
+	emailTemplatePasswordReset = "password_reset"
+	emailTemplateTest          = "test"
+	emailTemplateDigest        = "digest"
+)
+
+// emailTemplateDefaults are the built-in templates, overridable per instance
+var emailTemplateDefaults = []models.EmailTemplate{
+	{
+		Name:        emailTemplateInvitation,
+		Description: "Sent when an administrator invites someone to create an account",
+		Subject:     "You're invited to {{.InstanceName}}",
+		Body: `Hello{{if .DisplayName}} {{.DisplayName}}{{end}},
+
+{{.InvitedBy}} has invited you to {{.InstanceName}} with the {{.Role}} role.
+
+Create your account here:
+{{.Link}}
+
+This link expires in {{.ExpiresIn}}. If you weren't expecting this invitation you can ignore this email.
+`,
+	},
+	{
+		Name:        emailTemplatePasswordReset,
+		Description: "Sent when a password reset is requested for a local account",
+		Subject:     "Reset your {{.InstanceName}} password",
+		Body: `Hello {{.DisplayName}},
+
+A password reset was requested for the account {{.Username}} on {{.InstanceName}}.
+
+Choose a new password here:
+{{.Link}}
+
+This link expires in {{.ExpiresIn}} and can be used once. If you didn't ask for a reset you can ignore this email; your password stays the same.
+`,
+	},
+	{
+		Name:        emailTemplateTest,
+		Description: "Sent from the email settings to check delivery",
+		Subject:     "{{.InstanceName}} test email",
+		Body: `This is a test email from {{.InstanceName}} on {{.HostName}}, sent by {{.Username}}.
+
+Outgoing mail is working.
+`,
+	},
+	{
+		Name:        emailTemplateDigest,
+		Description: "Sent on a user's daily or weekly digest schedule",
+		Subject:     "{{.InstanceName}} {{.Period}} digest for {{.HostName}}",
+		Body: `Hello {{.DisplayName}},
+
+Here is your {{.Period}} summary of {{.HostName}}.
+
+{{.Digest}}
+You can change or turn off this digest in your {{.InstanceName}} preferences.
+`,
+	},
+}
+
+// emailTemplateData holds the values templates can reference
+type emailTemplateData struct {
+	InstanceName string
+	HostName     string
+	Username     string
+	DisplayName  string
+	Email        string
+	Role         string
+	InvitedBy    string
+	Link         string
+	ExpiresIn    string
+	Period       string // "daily" or "weekly" in a digest
+	Digest       string // Rendered digest sections
+}
+
+// sampleEmailData is used to check a template renders before saving it
+var sampleEmailData = emailTemplateData{
+	InstanceName: "Stardeck",
+	HostName:     "nas-01",
+	Username:     "jdoe",
+	DisplayName:  "Jane Doe",
+	Email:        "jdoe@example.com",
+	Role:         string(models.RoleOperator),
+	InvitedBy:    "admin",
+	Link:         "https://stardeck.example.com/invite?token=example",
+	ExpiresIn:    "72 hours",
+	Period:       models.DigestDaily,
+	Digest:       "Updates\n  3 package updates available, 1 security\n",
+}
+
+// loadEmailSettings reads the email settings, applying defaults
+func loadEmailSettings() models.EmailSettings {
+	s := models.EmailSettings{
+		SMTPPort:        defaultSMTPPort,
+		SMTPSecurity:    models.SMTPSecurityStartTLS,
+		InviteTTLHours:  defaultInviteTTLHours,
+		ResetTTLMinutes: defaultResetTTLMinutes,
+	}
+	s.Enabled, _ = settingsRepo.GetBool(database.SettingEmailEnabled)
+	s.SMTPHost, _ = settingsRepo.Get(database.SettingSMTPHost)
+	if v, err := settingsRepo.GetInt(database.SettingSMTPPort); err == nil && v > 0 {
+		s.SMTPPort = v
+	}
+	if v, err := settingsRepo.Get(database.SettingSMTPSecurity); err == nil && v != "" {
+		s.SMTPSecurity = v
+	}
+	s.SMTPUsername, _ = settingsRepo.Get(database.SettingSMTPUsername)
+	if v, err := settingsRepo.Get(database.SettingSMTPPasswordSecret); err == nil && v != "" {
+		s.PasswordSet = true
+	}
+	s.From, _ = settingsRepo.Get(database.SettingEmailFrom)
+	s.PublicURL, _ = settingsRepo.Get(database.SettingEmailPublicURL)
+	if v, err := settingsRepo.GetInt(database.SettingInviteTTL); err == nil && v > 0 {
+		s.InviteTTLHours = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingResetTTL); err == nil && v > 0 {
+		s.ResetTTLMinutes = v
+	}
+	return s
+}
+
+// emailReady reports whether mail can be sent with these settings
+func emailReady(s models.EmailSettings) bool {
+	return s.Enabled && s.SMTPHost != "" && s.From != "" && s.PublicURL != ""
+}
+
+// emailLink builds a link into the web UI carrying a single-use token
+func emailLink(s models.EmailSettings, path, token string) string {
+	return strings.TrimRight(s.PublicURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// formatTTL describes a token lifetime for email text, e.g. "3 days"
+func formatTTL(d time.Duration) string {
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+		return strconv.Itoa(n) + " " + name + "s"
+	}
+	switch {
+	case d >= 48*time.Hour && d%(24*time.Hour) == 0:
+		return unit(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour && d%time.Hour == 0:
+		return unit(int(d/time.Hour), "hour")
+	}
+	return unit(int(d/time.Minute), "minute")
+}
+
+// defaultEmailTemplate returns a built-in template
+func defaultEmailTemplate(name string) (models.EmailTemplate, bool) {
+	for _, tpl := range emailTemplateDefaults {
+		if tpl.Name == name {
+			return tpl, true
+		}
+	}
+	return models.EmailTemplate{}, false
+}
+
+// loadEmailTemplate returns a template with any saved override applied
+func loadEmailTemplate(name string) (models.EmailTemplate, bool) {
+	tpl, ok := defaultEmailTemplate(name)
+	if !ok {
+		return tpl, false
+	}
+	if v, err := settingsRepo.Get(database.EmailTemplateSetting(name, "subject")); err == nil && v != "" {
+		tpl.Subject, tpl.Customized = v, true
+	}
+	if v, err := settingsRepo.Get(database.EmailTemplateSetting(name, "body")); err == nil && v != "" {
+		tpl.Body, tpl.Customized = v, true
+	}
+	return tpl, true
+}
+
+// renderEmailTemplate fills in a template's subject and body
+func renderEmailTemplate(tpl models.EmailTemplate, data emailTemplateData) (string, string, error) {
+	render := func(field, text string) (string, error) {
+		t, err := template.New(tpl.Name + "." + field).Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	subject, err := render("subject", tpl.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := render("body", tpl.Body)
+	if err != nil {
+		return "", "", err
+	}
+	// A header can't span lines
+	return strings.Join(strings.Fields(subject), " "), body, nil
+}
+
+// sendEmail renders a template and delivers it to one recipient
+func sendEmail(ctx context.Context, to, templateName string, data emailTemplateData) error {
+	settings := loadEmailSettings()
+	if !emailReady(settings) {
+		return errEmailDisabled
+	}
+	tpl, ok := loadEmailTemplate(templateName)
+	if !ok {
+		return errors.New("unknown email template " + templateName)
+	}
+	if data.InstanceName == "" {
+		data.InstanceName = loadBrandingSettings().InstanceName
+	}
+	if data.HostName == "" {
+		data.HostName = loadHostProfile().DisplayName()
+	}
+	subject, body, err := renderEmailTemplate(tpl, data)
+	if err != nil {
+		return err
+	}
+
+	cfg := system.MailConfig{
+		Host:     settings.SMTPHost,
+		Port:     settings.SMTPPort,
+		Security: settings.SMTPSecurity,
+		Username: settings.SMTPUsername,
+		From:     settings.From,
+	}
+	if secret, _ := settingsRepo.Get(database.SettingSMTPPasswordSecret); secret != "" {
+		if cfg.Password, err = podmanService.SecretValue(ctx, secret); err != nil {
+			return errors.New("failed to read SMTP password: " + err.Error())
+		}
+	}
+	return system.SendMail(ctx, cfg, system.MailMessage{To: to, Subject: subject, Body: body})
+}
+
+// getEmailSettingsHandler returns the email settings (without the password)
+func getEmailSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadEmailSettings())
+}
+
+// updateEmailSettingsHandler replaces the email settings, storing any new SMTP
+// password as a Podman secret
+func updateEmailSettingsHandler(c echo.Context) error {
+	req := models.UpdateEmailSettingsRequest{EmailSettings: loadEmailSettings()}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	s := req.EmailSettings
+	s.SMTPHost = strings.TrimSpace(s.SMTPHost)
+	s.SMTPUsername = strings.TrimSpace(s.SMTPUsername)
+	s.From = strings.TrimSpace(s.From)
+	s.PublicURL = strings.TrimRight(strings.TrimSpace(s.PublicURL), "/")
+
+	switch s.SMTPSecurity {
+	case models.SMTPSecurityNone, models.SMTPSecurityStartTLS, models.SMTPSecurityTLS:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "smtp_security must be none, starttls or tls",
+		})
+	}
+	if s.SMTPPort < 1 || s.SMTPPort > 65535 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "smtp_port must be between 1 and 65535",
+		})
+	}
+	if s.InviteTTLHours < 1 || s.InviteTTLHours > 30*24 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invite_ttl_hours must be between 1 and 720",
+		})
+	}
+	if s.ResetTTLMinutes < 5 || s.ResetTTLMinutes > 24*60 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "reset_ttl_minutes must be between 5 and 1440",
+		})
+	}
+	if s.From != "" {
+		if _, err := mail.ParseAddress(s.From); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "from must be an email address, optionally with a name",
+			})
+		}
+	}
+	if s.PublicURL != "" {
+		// Links are built from this rather than the request's Host header, which a
+		// reset requester controls
+		u, err := url.Parse(s.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "public_url must be an http(s) URL",
+			})
+		}
+	}
+	if s.Enabled && (s.SMTPHost == "" || s.From == "" || s.PublicURL == "") {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "smtp_host, from and public_url are required to enable email",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	values := map[string]string{
+		database.SettingEmailEnabled:   strconv.FormatBool(s.Enabled),
+		database.SettingSMTPHost:       s.SMTPHost,
+		database.SettingSMTPPort:       strconv.Itoa(s.SMTPPort),
+		database.SettingSMTPSecurity:   s.SMTPSecurity,
+		database.SettingSMTPUsername:   s.SMTPUsername,
+		database.SettingEmailFrom:      s.From,
+		database.SettingEmailPublicURL: s.PublicURL,
+		database.SettingInviteTTL:      strconv.Itoa(s.InviteTTLHours),
+		database.SettingResetTTL:       strconv.Itoa(s.ResetTTLMinutes),
+	}
+	details := map[string]interface{}{}
+	for key, value := range values {
+		details[key] = value
+	}
+	if req.Password != nil {
+		if *req.Password == "" {
+			if err := podmanService.RemoveSecret(ctx, smtpPasswordSecret); err != nil {
+				c.Logger().Warnf("Failed to remove secret %s: %v", smtpPasswordSecret, err)
+			}
+			values[database.SettingSMTPPasswordSecret] = ""
+		} else {
+			if err := podmanService.CreateSecret(ctx, smtpPasswordSecret, *req.Password); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to store password: " + err.Error(),
+				})
+			}
+			values[database.SettingSMTPPasswordSecret] = smtpPasswordSecret
+		}
+		details["password_changed"] = true
+	}
+
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionEmailSettings, "email", details)
+
+	return c.JSON(http.StatusOK, loadEmailSettings())
+}
+
+// listEmailTemplatesHandler returns every email template with overrides applied
+func listEmailTemplatesHandler(c echo.Context) error {
+	templates := make([]models.EmailTemplate, 0, len(emailTemplateDefaults))
+	for _, def := range emailTemplateDefaults {
+		tpl, _ := loadEmailTemplate(def.Name)
+		templates = append(templates, tpl)
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+// updateEmailTemplateHandler overrides a template's subject and body. Empty
+// fields go back to the built-in text.
+func updateEmailTemplateHandler(c echo.Context) error {
+	name := c.Param("name")
+	check, ok := defaultEmailTemplate(name)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Email template not found",
+		})
+	}
+
+	var req models.UpdateEmailTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if strings.TrimSpace(req.Subject) != "" {
+		check.Subject = req.Subject
+	}
+	if strings.TrimSpace(req.Body) != "" {
+		check.Body = req.Body
+	}
+	if _, _, err := renderEmailTemplate(check, sampleEmailData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid template: " + err.Error(),
+		})
+	}
+
+	values := map[string]string{
+		database.EmailTemplateSetting(name, "subject"): strings.TrimSpace(req.Subject),
+		database.EmailTemplateSetting(name, "body"):    strings.TrimSpace(req.Body),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save template: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionEmailTemplate, name, nil)
+
+	tpl, _ := loadEmailTemplate(name)
+	return c.JSON(http.StatusOK, tpl)
+}
+
+// sendTestEmailHandler sends the test template, by default to the caller's own address
+func sendTestEmailHandler(c echo.Context) error {
+	var req models.TestEmailRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	to := strings.TrimSpace(req.To)
+	if to == "" {
+		to = user.Email
+	}
+	if to == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "to is required when your account has no email address",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	err := sendEmail(ctx, to, emailTemplateTest, emailTemplateData{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Email:       to,
+	})
+	if errors.Is(err, errEmailDisabled) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Email is disabled or not fully configured",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to send email: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "sent",
+		"to":     to,
+	})
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	// notificationBacklog is how many recent notifications are kept for reconnecting clients
+	notificationBacklog = 100
+
+	// eventHeartbeat keeps idle SSE and WebSocket connections open through proxies
+	eventHeartbeat = 25 * time.Second
+)
+
+// notificationHub fans notifications out to connected SSE clients
+type notificationHub struct {
+	mu      sync.Mutex
+	nextID  int64
+	recent  []models.Notification
+	clients map[chan models.Notification]*models.User
+}
+
+var notifications = &notificationHub{
+	clients: make(map[chan models.Notification]*models.User),
+}
+
+// deliversTo reports whether a notification's audience includes the user
+func deliversTo(n *models.Notification, user *models.User) bool {
+	if n.UserID != 0 && n.UserID == user.ID {
+		return true
+	}
+	for _, role := range n.Roles {
+		if user.Role == role || (role == models.RoleAdmin && user.IsAdmin()) {
+			return true
+		}
+	}
+	return false
+}
+
+// publish assigns an ID to a notification and sends it to every client in its
+// audience, returning it as sent
+func (h *notificationHub) publish(n models.Notification) models.Notification {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	n.ID = h.nextID
+	if n.Timestamp.IsZero() {
+		n.Timestamp = time.Now()
+	}
+
+	h.recent = append(h.recent, n)
+	if len(h.recent) > notificationBacklog {
+		h.recent = h.recent[len(h.recent)-notificationBacklog:]
+	}
+
+	for ch, user := range h.clients {
+		if !deliversTo(&n, user) {
+			continue
+		}
+		// Drop rather than block on a slow client
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+	return n
+}
+
+// subscribe registers a client and returns the notifications it missed since lastID
+func (h *notificationHub) subscribe(user *models.User, lastID int64) (chan models.Notification, []models.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan models.Notification, 32)
+	h.clients[ch] = user
+
+	var missed []models.Notification
+	if lastID > 0 {
+		for _, n := range h.recent {
+			if n.ID > lastID && deliversTo(&n, user) {
+				missed = append(missed, n)
+			}
+		}
+	}
+	return ch, missed
+}
+
+func (h *notificationHub) unsubscribe(ch chan models.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, ch)
+}
+
+// notifyUser sends a notification to a single user
+func notifyUser(userID int64, n models.Notification) {
+	if userID == 0 {
+		return
+	}
+	n.UserID = userID
+	labelNotification(&n)
+	deliverToPluginChannels(notifications.publish(n))
+}
+
+// notifyRoles sends a notification to every user holding one of the roles
+func notifyRoles(n models.Notification, roles ...models.Role) {
+	n.Roles = roles
+	labelNotification(&n)
+	deliverToPluginChannels(notifications.publish(n))
+}
+
+// eventsHandler streams the current user's notifications as Server-Sent Events
+func eventsHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	// EventSource sends Last-Event-ID on reconnect; also accept it as a query parameter
+	lastID, _ := strconv.ParseInt(c.Request().Header.Get("Last-Event-ID"), 10, 64)
+	if lastID == 0 {
+		lastID, _ = strconv.ParseInt(c.QueryParam("last_event_id"), 10, 64)
+	}
+
+	ch, missed := notifications.subscribe(user, lastID)
+	defer notifications.unsubscribe(ch)
+
+	res := c.Response()
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	lang := requestLanguage(c)
+	send := func(n models.Notification) error {
+		data, err := json.Marshal(translateNotification(n, lang))
+		if err != nil {
+			return nil
+		}
+		if _, err := fmt.Fprintf(res, "id: %d\nevent: notification\ndata: %s\n\n", n.ID, data); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	}
+
+	fmt.Fprint(res, "retry: 5000\n\n")
+	res.Flush()
+	for _, n := range missed {
+		if err := send(n); err != nil {
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-ch:
+			if err := send(n); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
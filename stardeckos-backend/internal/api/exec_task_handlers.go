@@ -0,0 +1,389 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var execTaskRepo *database.ExecTaskRepo
+
+const (
+	defaultExecTaskTimeout = 300
+	maxExecTaskTimeout     = 6 * 60 * 60
+	maxExecTaskOutput      = 64 * 1024 // Keep the tail of very chatty commands
+)
+
+// runningExecTasks tracks in-flight tasks so a slow run is never started twice
+var (
+	runningExecTasks   = make(map[string]bool)
+	runningExecTasksMu sync.Mutex
+)
+
+// InitExecTaskRepo initializes the exec task repository and starts the scheduler
+func InitExecTaskRepo() {
+	execTaskRepo = database.NewExecTaskRepo()
+	go runExecTaskScheduler()
+}
+
+// runExecTaskScheduler wakes at the top of every minute and runs due tasks
+func runExecTaskScheduler() {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(next.Sub(now))
+
+		if maintenanceModeActive() {
+			continue
+		}
+
+		tasks, err := execTaskRepo.ListEnabled()
+		if err != nil {
+			log.Printf("Warning: failed to load exec tasks: %v", err)
+			continue
+		}
+
+		for i := range tasks {
+			schedule, err := system.ParseCron(tasks[i].Schedule)
+			if err != nil || !schedule.Matches(next) {
+				continue
+			}
+			go runExecTask(&tasks[i], "schedule")
+		}
+	}
+}
+
+// runExecTask executes a task inside its container and records the result
+func runExecTask(task *models.ExecTask, trigger string) *models.ExecTaskRun {
+	runningExecTasksMu.Lock()
+	if runningExecTasks[task.ID] {
+		runningExecTasksMu.Unlock()
+		return nil
+	}
+	runningExecTasks[task.ID] = true
+	runningExecTasksMu.Unlock()
+
+	defer func() {
+		runningExecTasksMu.Lock()
+		delete(runningExecTasks, task.ID)
+		runningExecTasksMu.Unlock()
+	}()
+
+	run := &models.ExecTaskRun{TaskID: task.ID, Trigger: trigger}
+	if err := execTaskRepo.StartRun(run); err != nil {
+		log.Printf("Warning: failed to record exec task run for %s: %v", task.Name, err)
+	}
+
+	timeout := task.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = defaultExecTaskTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	output, exitCode, err := podmanService.ExecCapture(ctx, resolveContainerID(task.ContainerID), []string{"/bin/sh", "-c", task.Command})
+	if len(output) > maxExecTaskOutput {
+		output = "...(truncated)\n" + output[len(output)-maxExecTaskOutput:]
+	}
+	run.Output = output
+	run.ExitCode = exitCode
+
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		run.Status = models.ExecTaskStatusTimeout
+	case err != nil:
+		run.Status = models.ExecTaskStatusFailed
+		run.Output += err.Error()
+	case exitCode != 0:
+		run.Status = models.ExecTaskStatusFailed
+	default:
+		run.Status = models.ExecTaskStatusSuccess
+	}
+
+	if err := execTaskRepo.FinishRun(run); err != nil {
+		log.Printf("Warning: failed to record exec task result for %s: %v", task.Name, err)
+	}
+
+	level := models.NotificationSuccess
+	if run.Status != models.ExecTaskStatusSuccess {
+		level = models.NotificationError
+	}
+	if task.CreatedBy != nil {
+		notifyUser(*task.CreatedBy, localized(models.Notification{
+			Type:  models.NotificationTaskFinished,
+			Level: level,
+			Data:  map[string]interface{}{"task_id": task.ID, "container_id": task.ContainerID, "run_id": run.ID, "trigger": trigger},
+		}, "notification.exec_task_finished", "task", task.Name, "status", string(run.Status), "exit_code", strconv.Itoa(run.ExitCode)))
+	}
+
+	if run.Status != models.ExecTaskStatusSuccess && task.NotifyOnFailure {
+		log.Printf("Exec task %s failed with status %s (exit code %d)", task.Name, run.Status, run.ExitCode)
+		Audit.Log(0, "system", models.ActionExecTaskFailure, task.Name, map[string]interface{}{
+			"task_id":      task.ID,
+			"container_id": task.ContainerID,
+			"status":       run.Status,
+			"exit_code":    run.ExitCode,
+		}, "")
+	}
+
+	return run
+}
+
+// withNextRun fills in the computed next run time for display
+func withNextRun(task *models.ExecTask) {
+	if !task.Enabled {
+		return
+	}
+	if schedule, err := system.ParseCron(task.Schedule); err == nil {
+		if next := schedule.Next(time.Now()); !next.IsZero() {
+			task.NextRunAt = &next
+		}
+	}
+}
+
+// getExecTaskForContainer loads a task and checks it belongs to the container in the URL
+func getExecTaskForContainer(c echo.Context) (*models.ExecTask, error) {
+	task, err := execTaskRepo.GetByID(c.Param("task_id"))
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Task not found",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get task: " + err.Error(),
+		})
+	}
+
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil || container.ID != task.ContainerID {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Task not found",
+		})
+	}
+	return task, nil
+}
+
+// listExecTasksHandler lists the scheduled tasks for a container
+func listExecTasksHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	tasks, err := execTaskRepo.ListByContainer(container.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list tasks: " + err.Error(),
+		})
+	}
+
+	if tasks == nil {
+		tasks = []models.ExecTask{}
+	}
+	for i := range tasks {
+		withNextRun(&tasks[i])
+	}
+
+	return c.JSON(http.StatusOK, tasks)
+}
+
+// createExecTaskHandler creates a scheduled task for a container
+func createExecTaskHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.CreateExecTaskRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.Name == "" || req.Command == "" || req.Schedule == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "name, command, and schedule are required",
+		})
+	}
+	if _, err := system.ParseCron(req.Schedule); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid schedule: " + err.Error(),
+		})
+	}
+	if req.TimeoutSeconds < 0 || req.TimeoutSeconds > maxExecTaskTimeout {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "timeout_seconds must be between 1 and " + strconv.Itoa(maxExecTaskTimeout),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	task := &models.ExecTask{
+		ContainerID:     container.ID,
+		Name:            req.Name,
+		Command:         req.Command,
+		Schedule:        req.Schedule,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		TimeoutSeconds:  req.TimeoutSeconds,
+		NotifyOnFailure: req.NotifyOnFailure,
+		CreatedBy:       &user.ID,
+	}
+	if task.TimeoutSeconds == 0 {
+		task.TimeoutSeconds = defaultExecTaskTimeout
+	}
+
+	if err := execTaskRepo.Create(task); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create task: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionExecTaskCreate, task.Name, map[string]interface{}{
+		"container": container.Name,
+		"schedule":  task.Schedule,
+		"command":   task.Command,
+	})
+
+	withNextRun(task)
+	return c.JSON(http.StatusCreated, task)
+}
+
+// updateExecTaskHandler updates a scheduled task
+func updateExecTaskHandler(c echo.Context) error {
+	task, err := getExecTaskForContainer(c)
+	if task == nil {
+		return err
+	}
+
+	var req models.UpdateExecTaskRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.Name != nil {
+		task.Name = *req.Name
+	}
+	if req.Command != nil {
+		task.Command = *req.Command
+	}
+	if req.Schedule != nil {
+		if _, err := system.ParseCron(*req.Schedule); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid schedule: " + err.Error(),
+			})
+		}
+		task.Schedule = *req.Schedule
+	}
+	if req.Enabled != nil {
+		task.Enabled = *req.Enabled
+	}
+	if req.TimeoutSeconds != nil {
+		if *req.TimeoutSeconds <= 0 || *req.TimeoutSeconds > maxExecTaskTimeout {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "timeout_seconds must be between 1 and " + strconv.Itoa(maxExecTaskTimeout),
+			})
+		}
+		task.TimeoutSeconds = *req.TimeoutSeconds
+	}
+	if req.NotifyOnFailure != nil {
+		task.NotifyOnFailure = *req.NotifyOnFailure
+	}
+
+	if task.Name == "" || task.Command == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "name and command cannot be empty",
+		})
+	}
+
+	if err := execTaskRepo.Update(task); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update task: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionExecTaskUpdate, task.Name, nil)
+
+	withNextRun(task)
+	return c.JSON(http.StatusOK, task)
+}
+
+// deleteExecTaskHandler deletes a scheduled task and its history
+func deleteExecTaskHandler(c echo.Context) error {
+	task, err := getExecTaskForContainer(c)
+	if task == nil {
+		return err
+	}
+
+	if err := execTaskRepo.Delete(task.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete task: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionExecTaskDelete, task.Name, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
+
+// runExecTaskHandler runs a task immediately and returns the result
+func runExecTaskHandler(c echo.Context) error {
+	task, err := getExecTaskForContainer(c)
+	if task == nil {
+		return err
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionExecTaskRun, task.Name, nil)
+
+	run := runExecTask(task, "manual")
+	if run == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Task is already running",
+		})
+	}
+
+	return c.JSON(http.StatusOK, run)
+}
+
+// listExecTaskRunsHandler returns a task's run history
+func listExecTaskRunsHandler(c echo.Context) error {
+	task, err := getExecTaskForContainer(c)
+	if task == nil {
+		return err
+	}
+
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	runs, err := execTaskRepo.ListRuns(task.ID, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list runs: " + err.Error(),
+		})
+	}
+
+	if runs == nil {
+		runs = []models.ExecTaskRun{}
+	}
+
+	return c.JSON(http.StatusOK, runs)
+}
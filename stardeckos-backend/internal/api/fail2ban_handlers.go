@@ -0,0 +1,212 @@
+package api
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// loadFail2banSettings reads the managed jail settings, applying defaults for missing values
+func loadFail2banSettings() models.Fail2banSettings {
+	s := models.Fail2banSettings{
+		SSHDEnabled:     true,
+		StardeckEnabled: true,
+		MaxRetry:        5,
+		FindTime:        600,
+		BanTime:         3600,
+		IgnoreIP:        []string{},
+	}
+	if v, err := settingsRepo.GetBool(database.SettingFail2banSSHD); err == nil {
+		s.SSHDEnabled = v
+	}
+	if v, err := settingsRepo.GetBool(database.SettingFail2banStardeck); err == nil {
+		s.StardeckEnabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingFail2banMaxRetry); err == nil && v > 0 {
+		s.MaxRetry = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingFail2banFindTime); err == nil && v > 0 {
+		s.FindTime = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingFail2banBanTime); err == nil && v != 0 {
+		s.BanTime = v
+	}
+	if v, err := settingsRepo.Get(database.SettingFail2banIgnoreIP); err == nil && v != "" {
+		s.IgnoreIP = strings.Fields(v)
+	}
+	return s
+}
+
+// reportAuthFailure feeds a failed sign-in to the stardeck fail2ban jail. The
+// firewall can only ban the connecting peer, so that address is used rather
+// than a forwarded client IP, which the client could also forge.
+func reportAuthFailure(c echo.Context, username string) {
+	if !system.Fail2banInstalled() {
+		return
+	}
+	if enabled, err := settingsRepo.GetBool(database.SettingFail2banStardeck); err != nil || !enabled {
+		return
+	}
+	host, _, err := net.SplitHostPort(c.Request().RemoteAddr)
+	if err != nil || net.ParseIP(host) == nil {
+		return
+	}
+	if err := system.RecordAuthFailure(username, host); err != nil {
+		log.Printf("Failed to record auth failure for fail2ban: %v", err)
+	}
+}
+
+// getFail2banStatusHandler handles GET /api/security/fail2ban
+func getFail2banStatusHandler(c echo.Context) error {
+	status, err := system.GetFail2banStatus()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get fail2ban status: " + err.Error(),
+		})
+	}
+	status.Settings = loadFail2banSettings()
+
+	_, failed, err := auditRepo.List(models.AuditFilter{
+		Action:    models.ActionLoginFailed,
+		StartTime: time.Now().Add(-24 * time.Hour),
+		Limit:     1,
+	})
+	if err == nil {
+		status.FailedLogins24h = failed
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// installFail2banHandler handles POST /api/security/fail2ban/install
+func installFail2banHandler(c echo.Context) error {
+	if !system.Fail2banInstalled() {
+		result, err := system.InstallPackages([]string{"fail2ban"})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to install fail2ban: " + err.Error(),
+			})
+		}
+		if !result.Success {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": result.Message,
+			})
+		}
+	}
+
+	settings := loadFail2banSettings()
+	if err := system.ConfigureFail2ban(settings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to configure fail2ban: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionFail2banInstall, "fail2ban", settings)
+
+	return getFail2banStatusHandler(c)
+}
+
+// updateFail2banSettingsHandler handles PUT /api/security/fail2ban
+func updateFail2banSettingsHandler(c echo.Context) error {
+	settings := loadFail2banSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if settings.MaxRetry < 1 || settings.MaxRetry > 100 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "max_retry must be between 1 and 100",
+		})
+	}
+	if settings.FindTime < 60 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "find_time must be at least 60 seconds",
+		})
+	}
+	if settings.BanTime < 60 && settings.BanTime != -1 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "ban_time must be at least 60 seconds, or -1 to ban permanently",
+		})
+	}
+	for _, entry := range settings.IgnoreIP {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid ignore_ip entry: " + entry,
+				})
+			}
+		}
+	}
+
+	if system.Fail2banInstalled() {
+		if err := system.ConfigureFail2ban(settings); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to configure fail2ban: " + err.Error(),
+			})
+		}
+	}
+
+	values := map[string]string{
+		database.SettingFail2banSSHD:     strconv.FormatBool(settings.SSHDEnabled),
+		database.SettingFail2banStardeck: strconv.FormatBool(settings.StardeckEnabled),
+		database.SettingFail2banMaxRetry: strconv.Itoa(settings.MaxRetry),
+		database.SettingFail2banFindTime: strconv.Itoa(settings.FindTime),
+		database.SettingFail2banBanTime:  strconv.Itoa(settings.BanTime),
+		database.SettingFail2banIgnoreIP: strings.Join(settings.IgnoreIP, " "),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save fail2ban settings: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionFail2banConfigure, "fail2ban", settings)
+
+	return getFail2banStatusHandler(c)
+}
+
+// unbanFail2banHandler handles POST /api/security/fail2ban/unban
+func unbanFail2banHandler(c echo.Context) error {
+	var req models.Fail2banUnbanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if net.ParseIP(req.IP) == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "A valid ip is required",
+		})
+	}
+	if !system.Fail2banInstalled() {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "fail2ban is not installed",
+		})
+	}
+
+	if err := system.Fail2banUnban(req.Jail, req.IP); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionFail2banUnban, req.IP, map[string]interface{}{
+		"ip":   req.IP,
+		"jail": req.Jail,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "IP unbanned",
+	})
+}
@@ -77,7 +77,7 @@ func getFileInfoHandler(c echo.Context) error {
 	path := c.QueryParam("path")
 	if path == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "path is required",
+			"error": tr(c, "error.path_required"),
 		})
 	}
 
@@ -103,7 +103,7 @@ func downloadFileHandler(c echo.Context) error {
 	path := c.QueryParam("path")
 	if path == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "path is required",
+			"error": tr(c, "error.path_required"),
 		})
 	}
 
@@ -141,7 +141,7 @@ func previewFileHandler(c echo.Context) error {
 	path := c.QueryParam("path")
 	if path == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "path is required",
+			"error": tr(c, "error.path_required"),
 		})
 	}
 
@@ -255,7 +255,7 @@ func createDirectoryHandler(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -298,7 +298,7 @@ func createFileHandler(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -340,13 +340,13 @@ func updateFileHandler(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
 	if req.Path == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "path is required",
+			"error": tr(c, "error.path_required"),
 		})
 	}
 
@@ -399,7 +399,7 @@ func renameFileHandler(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -444,7 +444,7 @@ func copyFileHandler(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -485,7 +485,7 @@ func deleteFileHandler(c echo.Context) error {
 	path := c.QueryParam("path")
 	if path == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "path is required",
+			"error": tr(c, "error.path_required"),
 		})
 	}
 
@@ -515,13 +515,13 @@ func changePermissionsHandler(c echo.Context) error {
 
 	if err := c.Bind(&change); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
 	if change.Path == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "path is required",
+			"error": tr(c, "error.path_required"),
 		})
 	}
 
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// Bounds for the window in which a staged firewall change must be confirmed
+const (
+	firewallConfirmMin = 10 * time.Second
+	firewallConfirmMax = 10 * time.Minute
+)
+
+// pendingFirewallChange is a runtime-only firewall change that is reverted
+// unless confirmed before it expires
+type pendingFirewallChange struct {
+	ID        string                  `json:"id"`
+	Change    system.FirewallChange   `json:"change"`
+	Revert    system.FirewallChange   `json:"revert"`
+	Preview   *system.FirewallPreview `json:"preview,omitempty"`
+	CreatedBy string                  `json:"created_by"`
+	CreatedAt time.Time               `json:"created_at"`
+	ExpiresAt time.Time               `json:"expires_at"`
+
+	timer *time.Timer
+}
+
+// firewallChanges tracks staged firewall changes. The lock is held while a
+// change is confirmed or reverted so expiry cannot race an admin's action.
+type firewallChanges struct {
+	mu      sync.Mutex
+	pending map[string]*pendingFirewallChange
+}
+
+var pendingFirewall = &firewallChanges{pending: make(map[string]*pendingFirewallChange)}
+
+// list returns the pending changes, oldest first
+func (f *firewallChanges) list() []*pendingFirewallChange {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	changes := make([]*pendingFirewallChange, 0, len(f.pending))
+	for _, p := range f.pending {
+		changes = append(changes, p)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].CreatedAt.Before(changes[j].CreatedAt) })
+	return changes
+}
+
+// count returns the number of pending changes
+func (f *firewallChanges) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending)
+}
+
+// resolve runs fn on a pending change and forgets the change once fn succeeds
+func (f *firewallChanges) resolve(id string, fn func(*pendingFirewallChange) error) (*pendingFirewallChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.pending[id]
+	if !ok {
+		return nil, nil
+	}
+	if err := fn(p); err != nil {
+		return p, err
+	}
+	p.timer.Stop()
+	delete(f.pending, id)
+	return p, nil
+}
+
+// expire reverts a change whose window ran out. It is forgotten even if the
+// revert fails, since there is nothing left to wait for.
+func (f *firewallChanges) expire(id string) {
+	f.mu.Lock()
+	p, ok := f.pending[id]
+	delete(f.pending, id)
+	f.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	err := system.ApplyFirewallChange(p.Revert, false)
+	details := map[string]interface{}{
+		"change":     p.Change,
+		"created_by": p.CreatedBy,
+		"reason":     "confirmation window expired",
+	}
+	n := models.Notification{
+		Type:  models.NotificationFirewallRevert,
+		Level: models.NotificationWarning,
+		Data:  map[string]interface{}{"id": p.ID, "change": p.Change},
+	}
+	key, params := "notification.firewall_reverted", []string{"action", p.Change.Action, "kind", string(p.Change.Kind), "user", p.CreatedBy}
+	if err != nil {
+		log.Printf("Firewall auto-revert of %s failed: %v", p.ID, err)
+		details["error"] = err.Error()
+		n.Level = models.NotificationError
+		key, params = "notification.firewall_revert_fail", append(params, "error", err.Error())
+	}
+	Audit.Log(0, "system", models.ActionFirewallRevert, p.Change.Zone, details, "")
+	notifyRoles(localized(n, key, params...), models.RoleAdmin)
+}
+
+// requestConfirmTimeout reads the confirm_timeout query parameter, in seconds
+func requestConfirmTimeout(c echo.Context) int {
+	seconds, _ := strconv.Atoi(c.QueryParam("confirm_timeout"))
+	return seconds
+}
+
+// webUIPort returns the local port the current request arrived on
+func webUIPort(c echo.Context) int {
+	if addr, ok := c.Request().Context().Value(http.LocalAddrContextKey).(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
+
+// stageFirewallChange applies a change to the runtime configuration only and
+// schedules its revert. The admin confirms it to keep it, which also writes
+// it to the permanent configuration when the change asked for that.
+func stageFirewallChange(c echo.Context, ch system.FirewallChange, seconds int) error {
+	window := time.Duration(seconds) * time.Second
+	if window < firewallConfirmMin || window > firewallConfirmMax {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("confirm_timeout must be between %d and %d seconds",
+				int(firewallConfirmMin.Seconds()), int(firewallConfirmMax.Seconds())),
+		})
+	}
+	if err := ch.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// The preview records the previous default zone so the change can be inverted
+	preview, err := system.PreviewFirewallChange(ch, webUIPort(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	ch = preview.Change
+
+	if err := system.ApplyFirewallChange(ch, false); err != nil {
+		c.Logger().Error("stage firewall change error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	user := c.Get("user").(*models.User)
+	now := time.Now()
+	p := &pendingFirewallChange{
+		ID:        uuid.New().String(),
+		Change:    ch,
+		Revert:    ch.Inverse(),
+		Preview:   preview,
+		CreatedBy: user.Username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(window),
+	}
+	pendingFirewall.mu.Lock()
+	p.timer = time.AfterFunc(window, func() { pendingFirewall.expire(p.ID) })
+	pendingFirewall.pending[p.ID] = p
+	pendingFirewall.mu.Unlock()
+
+	Audit.LogFromContext(c, models.ActionFirewallStage, ch.Zone, map[string]interface{}{
+		"id":              p.ID,
+		"change":          ch,
+		"confirm_timeout": seconds,
+		"locks_out_ui":    preview.LocksOutWebUI,
+	})
+
+	return c.JSON(http.StatusAccepted, p)
+}
+
+// previewFirewallChangeHandler handles POST /api/network/firewall/preview
+func previewFirewallChangeHandler(c echo.Context) error {
+	var ch system.FirewallChange
+	if err := c.Bind(&ch); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": tr(c, "error.invalid_request_body")})
+	}
+	if err := ch.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	preview, err := system.PreviewFirewallChange(ch, webUIPort(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, preview)
+}
+
+// listPendingFirewallChangesHandler handles GET /api/network/firewall/pending
+func listPendingFirewallChangesHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, pendingFirewall.list())
+}
+
+// confirmFirewallChangeHandler handles POST /api/network/firewall/pending/:id/confirm
+func confirmFirewallChangeHandler(c echo.Context) error {
+	p, err := pendingFirewall.resolve(c.Param("id"), func(p *pendingFirewallChange) error {
+		if !p.Change.Permanent || p.Change.Kind == system.FirewallChangeDefaultZone {
+			return nil
+		}
+		return system.ApplyFirewallChange(p.Change, true)
+	})
+	if p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "pending change not found or already reverted"})
+	}
+	if err != nil {
+		c.Logger().Error("confirm firewall change error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	Audit.LogFromContext(c, models.ActionFirewallConfirm, p.Change.Zone, map[string]interface{}{
+		"id":         p.ID,
+		"change":     p.Change,
+		"created_by": p.CreatedBy,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "firewall change confirmed"})
+}
+
+// revertFirewallChangeHandler handles DELETE /api/network/firewall/pending/:id
+func revertFirewallChangeHandler(c echo.Context) error {
+	p, err := pendingFirewall.resolve(c.Param("id"), func(p *pendingFirewallChange) error {
+		return system.ApplyFirewallChange(p.Revert, false)
+	})
+	if p == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "pending change not found or already reverted"})
+	}
+	if err != nil {
+		c.Logger().Error("revert firewall change error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	Audit.LogFromContext(c, models.ActionFirewallRevert, p.Change.Zone, map[string]interface{}{
+		"id":         p.ID,
+		"change":     p.Change,
+		"created_by": p.CreatedBy,
+		"reason":     "reverted by admin",
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "firewall change reverted"})
+}
@@ -0,0 +1,550 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var gameServerRepo *database.GameServerRepo
+
+// defaultRestartWarnings are the minutes before a scheduled restart players are warned
+var defaultRestartWarnings = []int{15, 5, 1}
+
+// InitGameServerRepo initializes the game server profile repository and starts the monitor
+func InitGameServerRepo() {
+	gameServerRepo = database.NewGameServerRepo()
+	go runGameServerMonitor()
+}
+
+// gameServerAddress finds where Stardeck can reach a port of a running game
+// server, and returns the container's environment for the RCON password
+func gameServerAddress(ctx context.Context, p *models.GameServerProfile, port int, protocol string) (string, []string, error) {
+	container, err := containerRepo.GetByID(p.ContainerID)
+	if err != nil {
+		return "", nil, fmt.Errorf("container not found")
+	}
+	inspect, err := podmanService.InspectContainer(ctx, container.ContainerID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !inspect.State.Running {
+		return "", nil, fmt.Errorf("container is not running")
+	}
+	addr, err := inspect.ReachableAddress(podmanService.GetMode() == "rootless", port, protocol)
+	return addr, inspect.Config.Env, err
+}
+
+// gameServerRCON runs a console command on a game server
+func gameServerRCON(ctx context.Context, p *models.GameServerProfile, command string) (string, error) {
+	if p.RCONPort == 0 {
+		return "", fmt.Errorf("game server has no RCON port")
+	}
+	addr, env, err := gameServerAddress(ctx, p, p.RCONPort, "tcp")
+	if err != nil {
+		return "", err
+	}
+
+	password := p.RCONPassword
+	if password == "" && p.RCONPasswordEnv != "" {
+		for _, e := range env {
+			if value, ok := strings.CutPrefix(e, p.RCONPasswordEnv+"="); ok {
+				password = value
+			}
+		}
+	}
+	if password == "" {
+		return "", fmt.Errorf("no RCON password is configured")
+	}
+	return system.RunRCONCommand(addr, password, command)
+}
+
+// probeGameServerPlayers counts the players on a running game server
+func probeGameServerPlayers(ctx context.Context, p *models.GameServerProfile) (*models.GameServerPlayers, error) {
+	switch p.Probe {
+	case models.GameProbeRCON:
+		output, err := gameServerRCON(ctx, p, p.PlayerCommand)
+		if err != nil {
+			return nil, err
+		}
+		return system.ParsePlayerCount(p.PlayerPattern, output)
+	case models.GameProbeA2S:
+		addr, _, err := gameServerAddress(ctx, p, p.QueryPort, "udp")
+		if err != nil {
+			return nil, err
+		}
+		return system.QueryA2SInfo(addr, 3*time.Second)
+	}
+	return nil, fmt.Errorf("game server has no player-count probe")
+}
+
+// nextGameServerRestart fills in the computed next restart time for display
+func nextGameServerRestart(p *models.GameServerProfile) {
+	loc, err := scheduleLocation(p.Timezone)
+	if err != nil || !p.Enabled {
+		return
+	}
+	if cron, err := system.ParseCron(p.RestartSchedule); err == nil {
+		if next := cron.Next(time.Now().In(loc)); !next.IsZero() {
+			p.NextRestartAt = &next
+		}
+	}
+}
+
+// runGameServerMonitor wakes at the top of every minute to warn players of
+// upcoming restarts, restart servers on schedule, count players and stop
+// servers that have been empty too long
+func runGameServerMonitor() {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(next.Sub(now))
+
+		if maintenanceModeActive() {
+			continue
+		}
+
+		profiles, err := gameServerRepo.ListEnabled()
+		if err != nil {
+			log.Printf("Warning: failed to load game server profiles: %v", err)
+			continue
+		}
+		for i := range profiles {
+			go checkGameServer(&profiles[i], next)
+		}
+	}
+}
+
+// checkGameServer runs a game server's scheduled actions for minute t, then probes it
+func checkGameServer(p *models.GameServerProfile, t time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if loc, err := scheduleLocation(p.Timezone); err == nil && p.RestartSchedule != "" {
+		if cron, err := system.ParseCron(p.RestartSchedule); err == nil {
+			if cron.Matches(t.In(loc)) {
+				restartGameServer(ctx, p)
+				return
+			}
+			for _, minutes := range p.RestartWarnings {
+				if cron.Matches(t.Add(time.Duration(minutes) * time.Minute).In(loc)) {
+					warnGameServer(ctx, p, minutes)
+				}
+			}
+		}
+	}
+
+	if p.Probe == models.GameProbeNone {
+		return
+	}
+	container, err := containerRepo.GetByID(p.ContainerID)
+	if err != nil {
+		return
+	}
+	inspect, err := podmanService.InspectContainer(ctx, container.ContainerID)
+	if err != nil || !inspect.State.Running {
+		if p.Players != nil || p.IdleSince != nil {
+			gameServerRepo.ClearPlayers(p.ContainerID)
+		}
+		return
+	}
+
+	players, err := probeGameServerPlayers(ctx, p)
+	if err != nil {
+		// Servers take a while to come up; a failed probe doesn't count as empty
+		gameServerRepo.RecordProbe(p.ContainerID, nil, err.Error(), p.IdleSince)
+		return
+	}
+	idleSince := p.IdleSince
+	if players.Players > 0 {
+		idleSince = nil
+	} else if idleSince == nil {
+		idleSince = &t
+	}
+	if err := gameServerRepo.RecordProbe(p.ContainerID, players, "", idleSince); err != nil {
+		log.Printf("Warning: failed to record player count of %s: %v", p.ContainerName, err)
+	}
+
+	if p.IdleShutdownMinutes > 0 && idleSince != nil && t.Sub(*idleSince) >= time.Duration(p.IdleShutdownMinutes)*time.Minute {
+		idleStopGameServer(ctx, p, container)
+	}
+}
+
+// warnGameServer announces an upcoming restart on the server console
+func warnGameServer(ctx context.Context, p *models.GameServerProfile, minutes int) {
+	if p.WarningCommand == "" {
+		return
+	}
+	errMsg := ""
+	if _, err := gameServerRCON(ctx, p, system.FormatGameWarning(p.WarningCommand, minutes)); err != nil {
+		errMsg = err.Error()
+		log.Printf("Warning: failed to warn players on %s of a restart: %v", p.ContainerName, err)
+	}
+	gameServerRepo.RecordAction(p.ContainerID, models.GameServerActionWarn, errMsg)
+}
+
+// saveGameServer asks the server to save its world before it goes down.
+// A failed save doesn't stop the restart or shutdown.
+func saveGameServer(ctx context.Context, p *models.GameServerProfile) {
+	if p.SaveCommand == "" {
+		return
+	}
+	if _, err := gameServerRCON(ctx, p, p.SaveCommand); err != nil {
+		log.Printf("Warning: failed to save %s before stopping it: %v", p.ContainerName, err)
+		return
+	}
+	// Saves run asynchronously on most servers
+	time.Sleep(5 * time.Second)
+}
+
+// restartGameServer runs a scheduled restart of a running game server
+func restartGameServer(ctx context.Context, p *models.GameServerProfile) {
+	container, err := containerRepo.GetByID(p.ContainerID)
+	if err == nil {
+		inspect, inspectErr := podmanService.InspectContainer(ctx, container.ContainerID)
+		if inspectErr == nil && !inspect.State.Running {
+			// Stopped servers stay stopped
+			return
+		}
+		saveGameServer(ctx, p)
+		if err = podmanService.RestartContainer(ctx, container.ContainerID, 30); err == nil {
+			updateContainerStatus(container.ID, models.ContainerStatusRunning)
+			gameServerRepo.ClearPlayers(p.ContainerID)
+		}
+	}
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+		log.Printf("Warning: scheduled restart of %s failed: %v", p.ContainerName, err)
+	}
+	gameServerRepo.RecordAction(p.ContainerID, models.GameServerActionRestart, errMsg)
+
+	details := map[string]interface{}{"schedule": p.RestartSchedule}
+	if errMsg != "" {
+		details["error"] = errMsg
+	}
+	Audit.Log(0, "system", models.ActionGameServerRestart, p.ContainerName, details, "")
+}
+
+// idleStopGameServer stops a game server nobody has played on for the idle period
+func idleStopGameServer(ctx context.Context, p *models.GameServerProfile, container *models.Container) {
+	saveGameServer(ctx, p)
+	err := podmanService.StopContainer(ctx, container.ContainerID, 30)
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+		log.Printf("Warning: idle shutdown of %s failed: %v", p.ContainerName, err)
+	} else {
+		updateContainerStatus(container.ID, models.ContainerStatusExited)
+		gameServerRepo.ClearPlayers(p.ContainerID)
+	}
+	gameServerRepo.RecordAction(p.ContainerID, models.GameServerActionIdleStop, errMsg)
+
+	details := map[string]interface{}{"idle_minutes": p.IdleShutdownMinutes}
+	if errMsg != "" {
+		details["error"] = errMsg
+	}
+	Audit.Log(0, "system", models.ActionGameServerIdleStop, p.ContainerName, details, "")
+	if errMsg != "" {
+		return
+	}
+
+	notifyRoles(localized(models.Notification{
+		Type:  models.NotificationGameServerIdle,
+		Level: models.NotificationInfo,
+		Data: map[string]interface{}{
+			"container_id": p.ContainerID,
+		},
+	}, "notification.game_server_idle", "container", p.ContainerName, "minutes", strconv.Itoa(p.IdleShutdownMinutes)),
+		models.RoleAdmin, models.RoleOperator)
+}
+
+// getGameServer loads the game server profile of the container in the URL
+func getGameServer(c echo.Context) (*models.GameServerProfile, error) {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	p, err := gameServerRepo.Get(container.ID)
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Container has no game server profile",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get game server profile: " + err.Error(),
+		})
+	}
+	return p, nil
+}
+
+// listGameServerPresetsHandler lists the known game server presets
+func listGameServerPresetsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, system.GameServerPresets())
+}
+
+// checkHostPortsHandler reports whether host ports are free to publish, e.g.
+// ?ports=2456-2458/udp,25565. UDP ports are easy to get wrong because a
+// clash only shows when the second server fails to bind.
+func checkHostPortsHandler(c echo.Context) error {
+	ports, err := system.ParsePortList(c.QueryParam("ports"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.list_containers", "error", err.Error()),
+		})
+	}
+	usedBy := make(map[string]string)
+	for _, container := range containers {
+		for _, port := range container.Ports {
+			if port.HostPort > 0 {
+				usedBy[fmt.Sprintf("%d/%s", port.HostPort, port.Protocol)] = container.Name
+			}
+		}
+	}
+
+	statuses := make([]models.HostPortStatus, 0, len(ports))
+	for _, port := range ports {
+		status := models.HostPortStatus{
+			Port:     port.HostPort,
+			Protocol: port.Protocol,
+			UsedBy:   usedBy[fmt.Sprintf("%d/%s", port.HostPort, port.Protocol)],
+		}
+		status.Available = status.UsedBy == "" && system.HostPortFree(port.Protocol, port.HostPort)
+		statuses = append(statuses, status)
+	}
+	return c.JSON(http.StatusOK, statuses)
+}
+
+// listGameServersHandler lists all game server profiles
+func listGameServersHandler(c echo.Context) error {
+	profiles, err := gameServerRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list game servers: " + err.Error(),
+		})
+	}
+
+	if profiles == nil {
+		profiles = []models.GameServerProfile{}
+	}
+	for i := range profiles {
+		nextGameServerRestart(&profiles[i])
+	}
+	return c.JSON(http.StatusOK, profiles)
+}
+
+// getGameServerHandler returns a container's game server profile
+func getGameServerHandler(c echo.Context) error {
+	p, err := getGameServer(c)
+	if p == nil {
+		return err
+	}
+
+	nextGameServerRestart(p)
+	return c.JSON(http.StatusOK, p)
+}
+
+// updateGameServerHandler creates or replaces a container's game server
+// profile. Empty fields take the preset's value, and a restart schedule
+// without warnings warns players 15, 5 and 1 minutes ahead.
+func updateGameServerHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.GameServerProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	preset := &models.GameServerPreset{Probe: models.GameProbeNone}
+	if req.Preset != "" {
+		if preset = system.GameServerPreset(req.Preset); preset == nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Unknown preset: " + req.Preset,
+			})
+		}
+	}
+	orDefault := func(value, fallback string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	}
+	orDefaultPort := func(value, fallback int) int {
+		if value == 0 {
+			return fallback
+		}
+		return value
+	}
+
+	user := c.Get("user").(*models.User)
+	p := &models.GameServerProfile{
+		ContainerID:         container.ID,
+		Preset:              req.Preset,
+		Enabled:             req.Enabled == nil || *req.Enabled,
+		RCONPort:            orDefaultPort(req.RCONPort, preset.RCONPort),
+		RCONPasswordEnv:     orDefault(req.RCONPasswordEnv, preset.RCONPasswordEnv),
+		Probe:               orDefault(req.Probe, preset.Probe),
+		QueryPort:           orDefaultPort(req.QueryPort, preset.QueryPort),
+		PlayerCommand:       orDefault(req.PlayerCommand, preset.PlayerCommand),
+		PlayerPattern:       orDefault(req.PlayerPattern, preset.PlayerPattern),
+		RestartSchedule:     req.RestartSchedule,
+		Timezone:            orDefault(req.Timezone, "Local"),
+		RestartWarnings:     req.RestartWarnings,
+		WarningCommand:      orDefault(req.WarningCommand, preset.WarningCommand),
+		SaveCommand:         orDefault(req.SaveCommand, preset.SaveCommand),
+		IdleShutdownMinutes: req.IdleShutdownMinutes,
+		CreatedBy:           &user.ID,
+	}
+	if p.RestartWarnings == nil {
+		p.RestartWarnings = []int{}
+		if p.RestartSchedule != "" && p.WarningCommand != "" {
+			p.RestartWarnings = defaultRestartWarnings
+		}
+	}
+	if req.RCONPassword != nil {
+		p.RCONPassword = *req.RCONPassword
+	} else if existing, err := gameServerRepo.Get(container.ID); err == nil {
+		p.RCONPassword = existing.RCONPassword
+	}
+
+	if err := system.ValidateGameServerProfile(p); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if _, err := scheduleLocation(p.Timezone); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid timezone: " + err.Error(),
+		})
+	}
+
+	if err := gameServerRepo.Upsert(p); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save game server profile: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionGameServerUpdate, container.Name, map[string]interface{}{
+		"preset":                p.Preset,
+		"enabled":               p.Enabled,
+		"probe":                 p.Probe,
+		"restart_schedule":      p.RestartSchedule,
+		"idle_shutdown_minutes": p.IdleShutdownMinutes,
+	})
+
+	saved, err := gameServerRepo.Get(container.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get game server profile: " + err.Error(),
+		})
+	}
+	nextGameServerRestart(saved)
+	return c.JSON(http.StatusOK, saved)
+}
+
+// deleteGameServerHandler removes a container's game server profile
+func deleteGameServerHandler(c echo.Context) error {
+	p, err := getGameServer(c)
+	if p == nil {
+		return err
+	}
+
+	if err := gameServerRepo.Delete(p.ContainerID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete game server profile: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionGameServerDelete, p.ContainerName, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Game server profile deleted",
+	})
+}
+
+// probeGameServerHandler counts a game server's players now
+func probeGameServerHandler(c echo.Context) error {
+	p, err := getGameServer(c)
+	if p == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 20*time.Second)
+	defer cancel()
+
+	players, err := probeGameServerPlayers(ctx, p)
+	if err != nil {
+		gameServerRepo.RecordProbe(p.ContainerID, nil, err.Error(), p.IdleSince)
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Probe failed: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, players)
+}
+
+// gameServerRCONHandler runs a console command on a game server
+func gameServerRCONHandler(c echo.Context) error {
+	p, err := getGameServer(c)
+	if p == nil {
+		return err
+	}
+
+	var req models.RCONCommandRequest
+	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.Command) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "command is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 20*time.Second)
+	defer cancel()
+
+	output, err := gameServerRCON(ctx, p, req.Command)
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionGameServerRCON, p.ContainerName, map[string]interface{}{
+		"command": req.Command,
+	})
+
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "RCON command failed: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"output": output,
+	})
+}
@@ -36,7 +36,7 @@ func createGroupHandler(c echo.Context) error {
 	var req models.CreateGroupRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -149,7 +149,7 @@ func updateGroupHandler(c echo.Context) error {
 	var req models.UpdateGroupRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -225,7 +225,7 @@ func addGroupMembersHandler(c echo.Context) error {
 	var req models.AddGroupMembersRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -276,7 +276,7 @@ func removeGroupMemberHandler(c echo.Context) error {
 	userID, err := parseID(c.Param("userId"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid user ID",
+			"error": tr(c, "error.invalid_user_id"),
 		})
 	}
 
@@ -7,15 +7,29 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"stardeckos-backend/internal/database"
 	"stardeckos-backend/internal/models"
 	"stardeckos-backend/internal/system"
 )
 
+var settingsRepo *database.SettingsRepo
+
+// InitSettingsRepo initializes the settings repository
+func InitSettingsRepo() {
+	settingsRepo = database.NewSettingsRepo()
+}
+
 // Health check
 func healthCheck(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]string{
+	resp := map[string]interface{}{
 		"status": "ok",
-	})
+	}
+	// Unauthenticated clients (login page) use this to show the maintenance banner
+	if m := loadMaintenanceMode(); m.Enabled {
+		resp["status"] = "maintenance"
+		resp["maintenance"] = m
+	}
+	return c.JSON(http.StatusOK, resp)
 }
 
 // Process handlers
@@ -176,6 +190,9 @@ func applyUpdates(c echo.Context) error {
 	}
 	c.Bind(&req)
 
+	user := c.Get("user").(*models.User)
+	snapshots := snapshotBeforeRisk(models.SnapshotReasonOSUpdate, "OS updates", user)
+
 	result, err := system.ApplyUpdates(req.Packages)
 	if err != nil {
 		c.Logger().Error("apply updates error: ", err)
@@ -192,6 +209,7 @@ func applyUpdates(c echo.Context) error {
 	Audit.LogFromContext(c, models.ActionUpdateApply, "system", map[string]interface{}{
 		"packages":         req.Packages,
 		"packages_updated": result.PackagesUpdated,
+		"snapshots":        snapshotNames(snapshots),
 	})
 
 	return c.JSON(http.StatusOK, result)
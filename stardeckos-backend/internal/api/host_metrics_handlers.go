@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// hostMetricsNow keeps the snapshot the current metrics endpoint last
+// measured against, separate from the collector's so dashboard polling
+// doesn't shorten the collector's sample window
+var hostMetricsNow struct {
+	mu   sync.Mutex
+	prev *system.HostCounters
+	last *models.HostMetrics
+}
+
+// currentHostMetrics measures the host since the previous call. Calls less
+// than a second apart share a result, and the first call measures over a
+// second.
+func currentHostMetrics() (models.HostMetrics, error) {
+	hostMetricsNow.mu.Lock()
+	defer hostMetricsNow.mu.Unlock()
+
+	prev := hostMetricsNow.prev
+	if prev != nil && hostMetricsNow.last != nil && time.Since(prev.Time) < time.Second {
+		return *hostMetricsNow.last, nil
+	}
+	if prev == nil {
+		var err error
+		if prev, err = system.ReadHostCounters(); err != nil {
+			return models.HostMetrics{}, err
+		}
+		time.Sleep(time.Second)
+	}
+
+	cur, err := system.ReadHostCounters()
+	if err != nil {
+		return models.HostMetrics{}, err
+	}
+	m := system.HostMetricsSince(prev, cur)
+	hostMetricsNow.prev, hostMetricsNow.last = cur, &m
+	return m, nil
+}
+
+// aggregateHostMetrics groups samples into steps of the given length,
+// averaging each figure and keeping the peaks of CPU, memory and temperature
+func aggregateHostMetrics(hours int, step time.Duration, metrics []models.HostMetrics) models.HostMetricsHistory {
+	history := models.HostMetricsHistory{
+		Hours:       hours,
+		StepSeconds: int(step.Seconds()),
+		Points:      []models.HostMetricsPoint{},
+	}
+
+	for _, m := range metrics {
+		start := m.Timestamp.Truncate(step)
+		n := len(history.Points)
+		if n == 0 || !history.Points[n-1].Timestamp.Equal(start) {
+			history.Points = append(history.Points, models.HostMetricsPoint{Timestamp: start})
+			n++
+		}
+		p := &history.Points[n-1]
+
+		// Running averages, so a step needs no second pass
+		p.Samples++
+		k := float64(p.Samples)
+		p.CPUAvg += (m.CPUPercent - p.CPUAvg) / k
+		p.IOWaitAvg += (m.IOWaitPercent - p.IOWaitAvg) / k
+		p.MemoryAvg += (m.MemoryUsed - p.MemoryAvg) / int64(p.Samples)
+		p.SwapAvg += (m.SwapUsed - p.SwapAvg) / int64(p.Samples)
+		p.Load1Avg += (m.Load1 - p.Load1Avg) / k
+		p.DiskReadRate += (m.DiskReadRate - p.DiskReadRate) / int64(p.Samples)
+		p.DiskWriteRate += (m.DiskWriteRate - p.DiskWriteRate) / int64(p.Samples)
+		p.DiskBusyAvg += (m.DiskBusyPercent - p.DiskBusyAvg) / k
+		p.MemoryTotal = m.MemoryTotal
+		if m.CPUPercent > p.CPUMax {
+			p.CPUMax = m.CPUPercent
+		}
+		if m.MemoryUsed > p.MemoryMax {
+			p.MemoryMax = m.MemoryUsed
+		}
+		if m.TemperatureMax != nil && (p.TemperatureMax == nil || *m.TemperatureMax > *p.TemperatureMax) {
+			p.TemperatureMax = m.TemperatureMax
+		}
+	}
+	return history
+}
+
+// getHostMetricsHandler handles GET /api/system/metrics
+func getHostMetricsHandler(c echo.Context) error {
+	metrics, err := currentHostMetrics()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read host metrics: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, metrics)
+}
+
+// getHostMetricsHistoryHandler handles GET /api/system/metrics/history,
+// returning the stored samples or with ?step= (e.g. 5m) their averages and
+// peaks per step
+func getHostMetricsHistoryHandler(c echo.Context) error {
+	hours, _ := strconv.Atoi(c.QueryParam("hours"))
+	if hours <= 0 {
+		hours = 24
+	}
+
+	var step time.Duration
+	if v := c.QueryParam("step"); v != "" {
+		var ok bool
+		if step, ok = parseMetricsStep(v); !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "step must be a duration such as 5m or a number of seconds",
+			})
+		}
+	}
+
+	metrics, err := hostMetricsRepo.GetRecent(hours)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get metrics: " + err.Error(),
+		})
+	}
+
+	if step > 0 {
+		return c.JSON(http.StatusOK, aggregateHostMetrics(hours, step, metrics))
+	}
+	return c.JSON(http.StatusOK, metrics)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	maxHostFieldLength = 128
+	maxHostTags        = 32
+)
+
+// hostTagPattern accepts lowercase tags such as "prod", "site-a" or "role.gateway"
+var hostTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,31}$`)
+
+// loadHostProfile reads the host profile
+func loadHostProfile() models.HostProfile {
+	p := models.HostProfile{Tags: []string{}}
+	p.Hostname, _ = os.Hostname()
+	p.Name, _ = settingsRepo.Get(database.SettingHostName)
+	p.Location, _ = settingsRepo.Get(database.SettingHostLocation)
+	p.Contact, _ = settingsRepo.Get(database.SettingHostContact)
+	if v, err := settingsRepo.Get(database.SettingHostTags); err == nil && v != "" {
+		p.Tags = strings.Fields(v)
+	}
+	p.UpdatedAt, _ = settingsRepo.Get(database.SettingHostUpdated)
+	return p
+}
+
+// normalizeHostTags lowercases, de-duplicates and validates tags
+func normalizeHostTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !hostTagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q: use up to 32 lowercase letters, digits, '.', '_' or '-'", tag)
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) > maxHostTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxHostTags)
+	}
+	return out, nil
+}
+
+// labelNotification prefixes a notification's title with the host's friendly
+// name so notifications from several hosts can be told apart
+func labelNotification(n *models.Notification) {
+	name, _ := settingsRepo.Get(database.SettingHostName)
+	if name == "" {
+		return
+	}
+	n.Title = "[" + name + "] " + n.Title
+	if n.Data == nil {
+		n.Data = make(map[string]interface{})
+	}
+	n.Data["host"] = name
+}
+
+// getHostProfileHandler returns the host profile
+func getHostProfileHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadHostProfile())
+}
+
+// updateHostProfileHandler replaces the host profile
+func updateHostProfileHandler(c echo.Context) error {
+	var req models.HostProfile
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	fields := map[string]*string{"name": &req.Name, "location": &req.Location, "contact": &req.Contact}
+	for field, value := range fields {
+		*value = strings.Join(strings.Fields(*value), " ")
+		if len(*value) > maxHostFieldLength {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("%s must be at most %d characters", field, maxHostFieldLength),
+			})
+		}
+	}
+	tags, err := normalizeHostTags(req.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	values := map[string]string{
+		database.SettingHostName:     req.Name,
+		database.SettingHostLocation: req.Location,
+		database.SettingHostTags:     strings.Join(tags, " "),
+		database.SettingHostContact:  req.Contact,
+		database.SettingHostUpdated:  time.Now().Format(time.RFC3339),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save host profile: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionHostProfileUpdate, "host_profile", map[string]interface{}{
+		"name":     req.Name,
+		"location": req.Location,
+		"tags":     tags,
+	})
+
+	return c.JSON(http.StatusOK, loadHostProfile())
+}
@@ -0,0 +1,414 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var hostSnapshotRepo *database.HostSnapshotRepo
+
+// defaultSnapshotKeep is how many automatic snapshots are kept per filesystem
+const defaultSnapshotKeep = 5
+
+// hostSnapshotMu serializes snapshots, so two risky operations starting
+// together don't snapshot the same filesystem twice in a second
+var hostSnapshotMu sync.Mutex
+
+// InitHostSnapshots initializes the host snapshot repository
+func InitHostSnapshots() {
+	hostSnapshotRepo = database.NewHostSnapshotRepo()
+}
+
+// loadHostSnapshotSettings reads the automatic snapshot settings. Snapshots
+// are on by default; filesystems that can't be snapshotted are skipped.
+func loadHostSnapshotSettings() models.HostSnapshotSettings {
+	s := models.HostSnapshotSettings{Enabled: true, Paths: []string{"/"}, Keep: defaultSnapshotKeep}
+	if v, err := settingsRepo.GetBool(database.SettingSnapshotAuto); err == nil {
+		s.Enabled = v
+	}
+	if v, err := settingsRepo.Get(database.SettingSnapshotPaths); err == nil && v != "" {
+		s.Paths = strings.Fields(v)
+	}
+	if v, err := settingsRepo.GetInt(database.SettingSnapshotKeep); err == nil && v > 0 {
+		s.Keep = v
+	}
+	return s
+}
+
+// snapshotTargets detects the filesystems holding paths. Paths on the same
+// filesystem give one target.
+func snapshotTargets(paths []string) []models.HostSnapshotTarget {
+	seen := make(map[string]bool)
+	targets := make([]models.HostSnapshotTarget, 0, len(paths))
+	for _, path := range paths {
+		target := system.DetectSnapshotTarget(path)
+		key := target.Backend + ":" + target.Source
+		if target.Backend != "" && seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// takeHostSnapshots snapshots every filesystem holding paths that supports it.
+// Filesystems that can't be snapshotted are skipped; failures are returned
+// together once the others have been tried.
+func takeHostSnapshots(paths []string, reason, description string, userID *int64) ([]models.HostSnapshot, error) {
+	hostSnapshotMu.Lock()
+	defer hostSnapshotMu.Unlock()
+
+	taken := []models.HostSnapshot{}
+	var failures []string
+	for _, target := range snapshotTargets(paths) {
+		if target.Backend == "" {
+			continue
+		}
+		now := time.Now()
+		name, err := system.CreateHostSnapshot(target, now)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", target.MountPoint, err))
+			continue
+		}
+		snapshot := models.HostSnapshot{
+			Backend:     target.Backend,
+			Path:        target.Path,
+			MountPoint:  target.MountPoint,
+			Device:      target.Device,
+			Source:      target.Source,
+			Name:        name,
+			Reason:      reason,
+			Description: description,
+			CreatedAt:   now,
+			CreatedBy:   userID,
+		}
+		if err := hostSnapshotRepo.Create(&snapshot); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: snapshot %s taken but not recorded: %v", target.MountPoint, name, err))
+			continue
+		}
+		taken = append(taken, snapshot)
+	}
+	if len(failures) > 0 {
+		return taken, fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return taken, nil
+}
+
+// pruneHostSnapshots removes a filesystem's oldest automatic snapshots beyond
+// the number kept. Manual snapshots are only removed by hand.
+func pruneHostSnapshots(s models.HostSnapshot, keep int) {
+	snapshots, err := hostSnapshotRepo.ListAutomatic(s.Backend, s.Source)
+	if err != nil || len(snapshots) <= keep {
+		return
+	}
+	for _, old := range snapshots[keep:] {
+		if system.HostSnapshotExists(&old) {
+			if err := system.DeleteHostSnapshot(&old); err != nil {
+				log.Printf("Warning: failed to remove old snapshot %s: %v", old.Name, err)
+				continue
+			}
+		}
+		hostSnapshotRepo.Delete(old.ID)
+	}
+}
+
+// snapshotBeforeRisk takes the automatic snapshots before an operation that
+// could leave the host broken. A failed snapshot doesn't stop the operation;
+// admins are told it ran without one.
+func snapshotBeforeRisk(reason, description string, user *models.User) []models.HostSnapshot {
+	settings := loadHostSnapshotSettings()
+	if !settings.Enabled {
+		return nil
+	}
+
+	var userID *int64
+	if user != nil {
+		userID = &user.ID
+	}
+	taken, err := takeHostSnapshots(settings.Paths, reason, description, userID)
+	if err != nil {
+		log.Printf("Warning: snapshot before %s failed: %v", description, err)
+		notifyRoles(localized(models.Notification{
+			Type:  models.NotificationSnapshotFailed,
+			Level: models.NotificationWarning,
+			Data:  map[string]interface{}{"reason": reason},
+		}, "notification.snapshot_failed", "operation", description, "error", err.Error()), models.RoleAdmin)
+	}
+
+	for _, s := range taken {
+		pruneHostSnapshots(s, settings.Keep)
+		if user != nil {
+			logAudit(user, models.ActionSnapshotCreate, s.MountPoint, map[string]interface{}{
+				"name":   s.Name,
+				"reason": reason,
+			})
+		}
+	}
+	return taken
+}
+
+// snapshotNames lists snapshot names for audit details
+func snapshotNames(snapshots []models.HostSnapshot) []string {
+	names := make([]string, 0, len(snapshots))
+	for _, s := range snapshots {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// imageMajorVersion splits an image into its repository and the major version
+// of its tag, such as postgres:15.4 into postgres and 15. Tags that don't start
+// with a number have no major version.
+func imageMajorVersion(image string) (string, int, bool) {
+	image, _, _ = strings.Cut(image, "@")
+	i := strings.LastIndex(image, ":")
+	if i <= strings.LastIndex(image, "/") {
+		return image, 0, false
+	}
+	repo, tag := image[:i], strings.TrimPrefix(image[i+1:], "v")
+	digits := tag
+	if end := strings.IndexFunc(tag, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+		digits = tag[:end]
+	}
+	major, err := strconv.Atoi(digits)
+	return repo, major, err == nil
+}
+
+// stackMajorUpdates lists the services whose image moves to a new major
+// version between two compose files
+func stackMajorUpdates(oldCompose, newCompose string) []string {
+	oldMajor := make(map[string]int)
+	oldRepo := make(map[string]string)
+	for _, svc := range system.ParseComposeServices(oldCompose) {
+		if repo, major, ok := imageMajorVersion(svc.Image); ok {
+			oldRepo[svc.Name], oldMajor[svc.Name] = repo, major
+		}
+	}
+
+	var updates []string
+	for _, svc := range system.ParseComposeServices(newCompose) {
+		repo, major, ok := imageMajorVersion(svc.Image)
+		previous, had := oldMajor[svc.Name]
+		if ok && had && repo == oldRepo[svc.Name] && major != previous {
+			updates = append(updates, fmt.Sprintf("%s (%d to %d)", svc.Name, previous, major))
+		}
+	}
+	return updates
+}
+
+// getHostSnapshotsHandler handles GET /api/system/snapshots
+func getHostSnapshotsHandler(c echo.Context) error {
+	settings := loadHostSnapshotSettings()
+	snapshots, err := hostSnapshotRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list snapshots: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.HostSnapshotStatus{
+		Settings:  settings,
+		Targets:   snapshotTargets(settings.Paths),
+		Snapshots: snapshots,
+	})
+}
+
+// updateHostSnapshotSettingsHandler handles PUT /api/system/snapshots/settings
+func updateHostSnapshotSettingsHandler(c echo.Context) error {
+	settings := loadHostSnapshotSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	paths := make([]string, 0, len(settings.Paths))
+	for _, p := range settings.Paths {
+		if !filepath.IsAbs(p) || strings.ContainsAny(p, " \t\n") {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "paths must be absolute without spaces: " + p,
+			})
+		}
+		paths = append(paths, filepath.Clean(p))
+	}
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	settings.Paths = paths
+	if settings.Keep < 1 || settings.Keep > 100 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "keep must be between 1 and 100",
+		})
+	}
+
+	err := settingsRepo.SetMany(map[string]string{
+		database.SettingSnapshotAuto:  strconv.FormatBool(settings.Enabled),
+		database.SettingSnapshotPaths: strings.Join(settings.Paths, " "),
+		database.SettingSnapshotKeep:  strconv.Itoa(settings.Keep),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save snapshot settings: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionSnapshotSettings, "snapshots", settings)
+
+	return getHostSnapshotsHandler(c)
+}
+
+// createHostSnapshotHandler handles POST /api/system/snapshots
+func createHostSnapshotHandler(c echo.Context) error {
+	var req models.CreateHostSnapshotRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	paths := req.Paths
+	if len(paths) == 0 {
+		paths = loadHostSnapshotSettings().Paths
+	}
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "paths must be absolute: " + p,
+			})
+		}
+	}
+
+	user := c.Get("user").(*models.User)
+	taken, err := takeHostSnapshots(paths, models.SnapshotReasonManual, strings.TrimSpace(req.Description), &user.ID)
+	for _, s := range taken {
+		logAudit(user, models.ActionSnapshotCreate, s.MountPoint, map[string]interface{}{
+			"name":   s.Name,
+			"reason": models.SnapshotReasonManual,
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"error":     "Failed to take snapshots: " + err.Error(),
+			"snapshots": taken,
+		})
+	}
+	if len(taken) == 0 {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "None of the filesystems support snapshots",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, taken)
+}
+
+// getHostSnapshot looks up the snapshot named by the id parameter, writing
+// the error response when it can't
+func getHostSnapshot(c echo.Context) (*models.HostSnapshot, error) {
+	snapshot, err := hostSnapshotRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Snapshot not found",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get snapshot: " + err.Error(),
+		})
+	}
+	return snapshot, nil
+}
+
+// deleteHostSnapshotHandler handles DELETE /api/system/snapshots/:id
+func deleteHostSnapshotHandler(c echo.Context) error {
+	snapshot, err := getHostSnapshot(c)
+	if snapshot == nil {
+		return err
+	}
+
+	// A merged LVM snapshot is consumed by the rollback; only the record is left
+	merged := snapshot.Backend == models.SnapshotBackendLVMThin && snapshot.Status == models.SnapshotStatusRolledBack
+	if !merged && system.HostSnapshotExists(snapshot) {
+		if err := system.DeleteHostSnapshot(snapshot); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to delete snapshot: " + err.Error(),
+			})
+		}
+	}
+	if err := hostSnapshotRepo.Delete(snapshot.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete snapshot: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionSnapshotDelete, snapshot.MountPoint, map[string]interface{}{
+		"name": snapshot.Name,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Snapshot deleted",
+	})
+}
+
+// rollbackHostSnapshotHandler handles POST /api/system/snapshots/:id/rollback
+func rollbackHostSnapshotHandler(c echo.Context) error {
+	snapshot, err := getHostSnapshot(c)
+	if snapshot == nil {
+		return err
+	}
+	if snapshot.Status != models.SnapshotStatusReady {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "This snapshot has already been rolled back to",
+		})
+	}
+	if !system.HostSnapshotExists(snapshot) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "The snapshot no longer exists on disk",
+		})
+	}
+
+	hostSnapshotMu.Lock()
+	rebootRequired, err := system.RollbackHostSnapshot(snapshot)
+	hostSnapshotMu.Unlock()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to roll back: " + err.Error(),
+		})
+	}
+
+	if snapshot.Backend == models.SnapshotBackendZFS {
+		// zfs rollback -r destroyed every later snapshot of the dataset
+		hostSnapshotRepo.DeleteNewer(snapshot.Backend, snapshot.Source, snapshot.CreatedAt)
+	}
+	if err := hostSnapshotRepo.MarkRolledBack(snapshot.ID, rebootRequired); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Rolled back, but failed to record it: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionSnapshotRollback, snapshot.MountPoint, map[string]interface{}{
+		"name":            snapshot.Name,
+		"backend":         snapshot.Backend,
+		"reboot_required": rebootRequired,
+	})
+
+	snapshot, err = hostSnapshotRepo.GetByID(snapshot.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get snapshot: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, snapshot)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/i18n"
+	"stardeckos-backend/internal/models"
+)
+
+// requestLanguage returns the language a request's messages are shown in: the
+// lang query parameter if it names a supported language, else the best match
+// for Accept-Language. EventSource and WebSocket clients can't set headers,
+// hence the query parameter.
+func requestLanguage(c echo.Context) string {
+	if lang, ok := c.Get("lang").(string); ok {
+		return lang
+	}
+	lang := strings.ToLower(c.QueryParam("lang"))
+	if !i18n.Supported(lang) {
+		lang = i18n.Resolve(c.Request().Header.Get("Accept-Language"))
+	}
+	c.Set("lang", lang)
+	return lang
+}
+
+// tr translates a message into the request's language. params are name/value
+// pairs for the message's placeholders.
+func tr(c echo.Context, key string, params ...string) string {
+	return i18n.T(requestLanguage(c), key, params...)
+}
+
+// localized renders a notification's title and message from the catalog
+// entries key.title and key.message. They're rendered in English for the
+// server's own use; eventsHandler renders them again in each subscriber's
+// language.
+func localized(n models.Notification, key string, params ...string) models.Notification {
+	n.Key = key
+	n.Params = i18n.Params(params...)
+	n.Title = i18n.T(i18n.DefaultLanguage, key+".title", params...)
+	n.Message = i18n.T(i18n.DefaultLanguage, key+".message", params...)
+	return n
+}
+
+// translateNotification renders a localized notification in a language,
+// keeping the host label
+func translateNotification(n models.Notification, lang string) models.Notification {
+	if n.Key == "" || lang == i18n.DefaultLanguage {
+		return n
+	}
+	n.Title = i18n.Format(i18n.T(lang, n.Key+".title"), n.Params)
+	n.Message = i18n.Format(i18n.T(lang, n.Key+".message"), n.Params)
+	if host, ok := n.Data["host"].(string); ok && host != "" {
+		n.Title = "[" + host + "] " + n.Title
+	}
+	return n
+}
+
+// listLanguagesHandler handles GET /api/i18n
+func listLanguagesHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"languages": i18n.Languages,
+		"default":   i18n.DefaultLanguage,
+		"preferred": requestLanguage(c),
+	})
+}
+
+// getTranslationsHandler handles GET /api/i18n/:lang, returning every message
+// in the language with English filling any gaps
+func getTranslationsHandler(c echo.Context) error {
+	lang := strings.ToLower(c.Param("lang"))
+	if !i18n.Supported(lang) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.language_not_supported", "lang", lang),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"language": lang,
+		"messages": i18n.Catalog(lang),
+	})
+}
@@ -0,0 +1,379 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var iconRepo *database.IconRepo
+
+const (
+	iconBaseDir     = "/var/lib/stardeck/icons"
+	maxIconSize     = 2 * 1024 * 1024 // 2 MB
+	defaultIconLib  = "https://cdn.jsdelivr.net/gh/selfhst/icons"
+	minIconVariant  = 16
+	maxIconVariant  = 512
+	iconCacheMaxAge = "private, max-age=86400"
+)
+
+// iconSlugPattern restricts library slugs to the naming used by dashboard icon sets
+var iconSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// allowedIconTypes maps accepted content types to stored file extensions
+var allowedIconTypes = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/svg+xml": ".svg",
+}
+
+// InitIconRepo initializes the icon repository
+func InitIconRepo() {
+	iconRepo = database.NewIconRepo()
+}
+
+// iconURL returns the API URL used to reference an icon
+func iconURL(id string) string {
+	return "/api/icons/" + id
+}
+
+// detectIconType sniffs the content type of icon data
+func detectIconType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if strings.HasPrefix(contentType, "text/") || contentType == "application/octet-stream" {
+		trimmed := bytes.TrimSpace(data)
+		if bytes.Contains(trimmed[:min(len(trimmed), 1024)], []byte("<svg")) {
+			return "image/svg+xml"
+		}
+	}
+	return contentType
+}
+
+// storeIcon writes icon data to disk and records it in the database
+func storeIcon(icon *models.Icon, data []byte) error {
+	ext, ok := allowedIconTypes[icon.ContentType]
+	if !ok {
+		return fmt.Errorf("unsupported icon type %s (use PNG, JPEG, or SVG)", icon.ContentType)
+	}
+
+	if err := os.MkdirAll(iconBaseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create icon directory: %w", err)
+	}
+
+	icon.ID = uuid.New().String()
+	icon.FileName = icon.ID + ext
+	icon.SizeBytes = int64(len(data))
+
+	path := filepath.Join(iconBaseDir, icon.FileName)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save icon: %w", err)
+	}
+
+	if err := iconRepo.Create(icon); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	icon.URL = iconURL(icon.ID)
+	return nil
+}
+
+// listIconsHandler returns all stored icons
+func listIconsHandler(c echo.Context) error {
+	icons, err := iconRepo.List(c.QueryParam("source"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list icons: " + err.Error(),
+		})
+	}
+
+	if icons == nil {
+		icons = []models.Icon{}
+	}
+	for i := range icons {
+		icons[i].URL = iconURL(icons[i].ID)
+	}
+
+	return c.JSON(http.StatusOK, icons)
+}
+
+// uploadIconHandler stores a custom icon uploaded as multipart form data
+func uploadIconHandler(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "No file uploaded",
+		})
+	}
+
+	if file.Size > maxIconSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Icon too large (max %d MB)", maxIconSize/(1024*1024)),
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxIconSize+1))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read uploaded file",
+		})
+	}
+
+	name := c.FormValue("name")
+	if name == "" {
+		name = strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
+	}
+
+	user := c.Get("user").(*models.User)
+	icon := &models.Icon{
+		Name:        name,
+		Source:      models.IconSourceUpload,
+		ContentType: detectIconType(data),
+		CreatedBy:   &user.ID,
+	}
+
+	if err := storeIcon(icon, data); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Failed to store icon: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionIconUpload, icon.Name, map[string]interface{}{
+		"icon_id": icon.ID,
+	})
+
+	return c.JSON(http.StatusCreated, icon)
+}
+
+// downloadLibraryIcon fetches an icon from the dashboard icon library. On
+// failure it also returns the HTTP status to report.
+func downloadLibraryIcon(ctx context.Context, slug, format string) ([]byte, int, error) {
+	baseURL, err := settingsRepo.Get(database.SettingIconLibraryURL)
+	if err != nil || baseURL == "" {
+		baseURL = defaultIconLib
+	}
+	url := fmt.Sprintf("%s/%s/%s.%s", strings.TrimSuffix(baseURL, "/"), format, slug, format)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("Failed to build library request: " + err.Error())
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, http.StatusBadGateway, errors.New("Failed to reach icon library: " + err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, http.StatusNotFound, errors.New("Icon not found in library: " + slug)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, http.StatusBadGateway, fmt.Errorf("Icon library returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIconSize+1))
+	if err != nil {
+		return nil, http.StatusBadGateway, errors.New("Failed to download icon: " + err.Error())
+	}
+	if len(data) > maxIconSize {
+		return nil, http.StatusBadGateway, errors.New("Library icon exceeds the maximum icon size")
+	}
+	return data, http.StatusOK, nil
+}
+
+// fetchLibraryIconHandler imports an icon from the dashboard icon library by slug
+func fetchLibraryIconHandler(c echo.Context) error {
+	var req models.FetchLibraryIconRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	if !iconSlugPattern.MatchString(req.Slug) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid icon slug",
+		})
+	}
+
+	format := req.Format
+	if format == "" {
+		format = "png"
+	}
+	contentType := "image/png"
+	switch format {
+	case "png":
+	case "svg":
+		contentType = "image/svg+xml"
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Format must be png or svg",
+		})
+	}
+
+	// Library icons are cached, so repeated fetches reuse the stored copy
+	if existing, err := iconRepo.GetBySlug(req.Slug, contentType); err == nil {
+		existing.URL = iconURL(existing.ID)
+		return c.JSON(http.StatusOK, existing)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 15*time.Second)
+	defer cancel()
+
+	data, status, err := downloadLibraryIcon(ctx, req.Slug, format)
+	if err != nil {
+		return c.JSON(status, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	name := req.Name
+	if name == "" {
+		name = req.Slug
+	}
+
+	user := c.Get("user").(*models.User)
+	icon := &models.Icon{
+		Name:        name,
+		Slug:        req.Slug,
+		Source:      models.IconSourceLibrary,
+		ContentType: contentType,
+		CreatedBy:   &user.ID,
+	}
+
+	if err := storeIcon(icon, data); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to store icon: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionIconFetch, req.Slug, map[string]interface{}{
+		"icon_id": icon.ID,
+		"format":  format,
+	})
+
+	return c.JSON(http.StatusCreated, icon)
+}
+
+// serveIconHandler serves an icon, optionally resized with ?size=N (raster icons only)
+func serveIconHandler(c echo.Context) error {
+	icon, err := iconRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Icon not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get icon: " + err.Error(),
+		})
+	}
+
+	path := filepath.Join(iconBaseDir, icon.FileName)
+
+	header := c.Response().Header()
+	header.Set("Cache-Control", iconCacheMaxAge)
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	// SVGs are served as-is with a locked-down CSP so uploaded markup cannot run scripts
+	if icon.ContentType == "image/svg+xml" {
+		header.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+		header.Set("Content-Type", icon.ContentType)
+		return c.File(path)
+	}
+
+	if sizeStr := c.QueryParam("size"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size < minIconVariant || size > maxIconVariant {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("size must be between %d and %d", minIconVariant, maxIconVariant),
+			})
+		}
+
+		variant := filepath.Join(iconBaseDir, "cache", fmt.Sprintf("%s-%d.png", icon.ID, size))
+		if _, err := os.Stat(variant); err != nil {
+			if err := system.ResizeIcon(path, variant, size); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to resize icon: " + err.Error(),
+				})
+			}
+		}
+		return c.File(variant)
+	}
+
+	header.Set("Content-Type", icon.ContentType)
+	return c.File(path)
+}
+
+// deleteIconHandler removes an icon and its cached variants
+func deleteIconHandler(c echo.Context) error {
+	icon, err := iconRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Icon not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get icon: " + err.Error(),
+		})
+	}
+
+	// Refuse to delete icons still in use unless forced
+	if c.QueryParam("force") != "true" {
+		refs, err := iconRepo.CountReferences(iconURL(icon.ID))
+		if err == nil && refs > 0 {
+			return c.JSON(http.StatusConflict, map[string]interface{}{
+				"error":      "Icon is still referenced by containers, templates, or stacks",
+				"references": refs,
+			})
+		}
+	}
+
+	if err := iconRepo.Delete(icon.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete icon: " + err.Error(),
+		})
+	}
+
+	os.Remove(filepath.Join(iconBaseDir, icon.FileName))
+	variants, _ := filepath.Glob(filepath.Join(iconBaseDir, "cache", icon.ID+"-*.png"))
+	for _, v := range variants {
+		os.Remove(v)
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionIconDelete, icon.Name, map[string]interface{}{
+		"icon_id": icon.ID,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// releaseNotesTTL is how long a repository's releases are reused, keeping well
+// inside GitHub's unauthenticated rate limit
+const releaseNotesTTL = time.Hour
+
+// releaseNotesFetchLimit is how many recent releases are fetched per repository
+const releaseNotesFetchLimit = 30
+
+// releaseNotesFallback is how many of the latest releases are shown when the
+// running version can't be found among them
+const releaseNotesFallback = 5
+
+type cachedReleases struct {
+	notes   []models.ReleaseNote
+	err     error
+	expires time.Time
+}
+
+var (
+	releaseNotesCache   = make(map[string]cachedReleases)
+	releaseNotesCacheMu sync.Mutex
+)
+
+// sourceLabelKeys are the labels that point at an image's source repository
+var sourceLabelKeys = []string{"org.opencontainers.image.source", "org.label-schema.vcs-url", "org.opencontainers.image.url"}
+
+// githubReleases returns a repository's recent releases, cached
+func githubReleases(ctx context.Context, repo string) ([]models.ReleaseNote, error) {
+	releaseNotesCacheMu.Lock()
+	cached, ok := releaseNotesCache[repo]
+	releaseNotesCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.notes, cached.err
+	}
+
+	notes, err := system.FetchGitHubReleases(ctx, repo, releaseNotesFetchLimit)
+	releaseNotesCacheMu.Lock()
+	releaseNotesCache[repo] = cachedReleases{notes: notes, err: err, expires: time.Now().Add(releaseNotesTTL)}
+	releaseNotesCacheMu.Unlock()
+	return notes, err
+}
+
+// releaseVersionKey normalizes a tag or version label for comparison, so v1.2.3 matches 1.2.3
+func releaseVersionKey(v string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "v")
+}
+
+// releaseIndex returns the position of a version among releases, or -1
+func releaseIndex(notes []models.ReleaseNote, version string) int {
+	if version == "" {
+		return -1
+	}
+	key := releaseVersionKey(version)
+	for i, n := range notes {
+		if releaseVersionKey(n.Tag) == key || releaseVersionKey(n.Name) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// imageChangelog finds the release notes between the version a container runs
+// and the version of the image it would update to, both read from image labels
+func imageChangelog(ctx context.Context, currentLabels, newLabels map[string]string) *models.ImageChangelog {
+	changelog := &models.ImageChangelog{
+		CurrentVersion: labelVersion(currentLabels),
+		NewVersion:     labelVersion(newLabels),
+		Releases:       []models.ReleaseNote{},
+	}
+
+	for _, labels := range []map[string]string{newLabels, currentLabels} {
+		for _, key := range sourceLabelKeys {
+			if repo := system.GitHubRepository(labels[key]); repo != "" {
+				changelog.Source, changelog.Repository = labels[key], repo
+				break
+			}
+		}
+		if changelog.Repository != "" {
+			break
+		}
+	}
+	if changelog.Repository == "" {
+		changelog.Error = "The image doesn't name a GitHub source repository"
+		return changelog
+	}
+	changelog.ReleasesURL = "https://github.com/" + changelog.Repository + "/releases"
+
+	notes, err := githubReleases(ctx, changelog.Repository)
+	if err != nil {
+		changelog.Error = err.Error()
+		return changelog
+	}
+
+	// Releases come newest first: skip any newer than the update, stop at the running version
+	start := releaseIndex(notes, changelog.NewVersion)
+	if start < 0 {
+		start = 0
+	}
+	if end := releaseIndex(notes, changelog.CurrentVersion); end >= start {
+		changelog.Releases = notes[start:end]
+		changelog.Matched = true
+		return changelog
+	}
+	changelog.Releases = notes[start:min(start+releaseNotesFallback, len(notes))]
+	return changelog
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+	"stardeckos-backend/internal/templates"
+)
+
+const (
+	maxPrePullConcurrency = 4
+	maxPrePullJobs        = 20 // Finished jobs kept for review
+	prePullTimeout        = 30 * time.Minute
+)
+
+// prePullJobs holds recent pre-pull jobs in memory, newest last
+var prePullJobs struct {
+	mu   sync.Mutex
+	jobs []*models.PrePullJob
+}
+
+// referencedImages collects the images used by templates and stacks, keyed
+// by image with the names of what references them. Images built from
+// variables can't be resolved and are skipped.
+func referencedImages() (map[string][]string, error) {
+	refs := make(map[string][]string)
+	add := func(source, content string) {
+		for _, svc := range system.ParseComposeServices(content) {
+			if svc.Image != "" && !strings.Contains(svc.Image, "$") && !slices.Contains(refs[svc.Image], source) {
+				refs[svc.Image] = append(refs[svc.Image], source)
+			}
+		}
+	}
+
+	for _, t := range templates.GetBuiltInTemplates() {
+		add("template:"+t.Name, t.ComposeContent)
+	}
+	userTemplates, err := templateRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	for _, t := range userTemplates {
+		add("template:"+t.Name, t.ComposeContent)
+	}
+	stacks, err := stackRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stacks: %w", err)
+	}
+	for _, item := range stacks {
+		if stack, err := stackRepo.GetByID(item.ID); err == nil {
+			add("stack:"+stack.Name, stack.ComposeContent)
+		}
+	}
+	return refs, nil
+}
+
+// snapshotPrePullJob copies a job so it can be encoded while pulls update it
+func snapshotPrePullJob(job *models.PrePullJob) models.PrePullJob {
+	copied := *job
+	copied.Images = append([]models.PrePullImage(nil), job.Images...)
+	return copied
+}
+
+// runPrePullJob pulls a job's images with limited parallelism
+func runPrePullJob(job *models.PrePullJob, req models.PrePullRequest, userID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), prePullTimeout)
+	defer cancel()
+
+	sem := make(chan struct{}, req.Concurrency)
+	var wg sync.WaitGroup
+	for i := range job.Images {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prePullJobs.mu.Lock()
+			image := job.Images[i].Image
+			job.Images[i].Status = models.PrePullPulling
+			prePullJobs.mu.Unlock()
+
+			started := time.Now()
+			status, errMsg := models.PrePullPulled, ""
+			if req.MissingOnly && podmanService.ImageExists(ctx, image) {
+				status = models.PrePullPresent
+			} else if err := podmanService.PullImage(ctx, image); err != nil {
+				status, errMsg = models.PrePullFailed, err.Error()
+			}
+
+			prePullJobs.mu.Lock()
+			job.Images[i].Status = status
+			job.Images[i].Error = errMsg
+			job.Images[i].DurationMs = time.Since(started).Milliseconds()
+			switch status {
+			case models.PrePullPulled:
+				job.Pulled++
+			case models.PrePullFailed:
+				job.Failed++
+			}
+			prePullJobs.mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	prePullJobs.mu.Lock()
+	now := time.Now()
+	job.Status = models.PrePullCompleted
+	job.FinishedAt = &now
+	pulled, failed := job.Pulled, job.Failed
+	prePullJobs.mu.Unlock()
+
+	log.Printf("Image pre-pull %s finished: %d pulled, %d failed", job.ID, pulled, failed)
+
+	level := models.NotificationSuccess
+	if failed > 0 {
+		level = models.NotificationError
+	}
+	notifyUser(userID, localized(models.Notification{
+		Type:  models.NotificationTaskFinished,
+		Level: level,
+		Data:  map[string]interface{}{"prepull_id": job.ID},
+	}, "notification.prepull_finished", "pulled", strconv.Itoa(pulled), "total", strconv.Itoa(len(job.Images)),
+		"failed", strconv.Itoa(failed)))
+}
+
+// startPrePullHandler handles POST /api/images/prepull. The pulls run in the
+// background; poll GET /api/images/prepull/:id for progress.
+func startPrePullHandler(c echo.Context) error {
+	var req models.PrePullRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = 2
+	}
+	if req.Concurrency < 1 || req.Concurrency > maxPrePullConcurrency {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("concurrency must be between 1 and %d", maxPrePullConcurrency),
+		})
+	}
+
+	sources := make(map[string][]string)
+	for _, image := range req.Images {
+		if image = strings.TrimSpace(image); image != "" {
+			sources[image] = nil
+		}
+	}
+	if req.Referenced {
+		refs, err := referencedImages()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		for image, refSources := range refs {
+			sources[image] = append(sources[image], refSources...)
+		}
+	}
+	if len(sources) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "No images to pull",
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	job := &models.PrePullJob{
+		ID:        uuid.New().String(),
+		Status:    models.PrePullRunning,
+		StartedBy: user.Username,
+		StartedAt: time.Now(),
+	}
+	for image, refSources := range sources {
+		job.Images = append(job.Images, models.PrePullImage{Image: image, Sources: refSources, Status: models.PrePullPending})
+	}
+	sort.Slice(job.Images, func(i, j int) bool { return job.Images[i].Image < job.Images[j].Image })
+
+	prePullJobs.mu.Lock()
+	prePullJobs.jobs = append(prePullJobs.jobs, job)
+	if len(prePullJobs.jobs) > maxPrePullJobs {
+		prePullJobs.jobs = prePullJobs.jobs[len(prePullJobs.jobs)-maxPrePullJobs:]
+	}
+	snapshot := snapshotPrePullJob(job)
+	prePullJobs.mu.Unlock()
+
+	go runPrePullJob(job, req, user.ID)
+
+	images := make([]string, len(job.Images))
+	for i, img := range job.Images {
+		images[i] = img.Image
+	}
+	logAudit(user, models.ActionImagePrePull, job.ID, map[string]interface{}{
+		"images":       images,
+		"missing_only": req.MissingOnly,
+	})
+
+	return c.JSON(http.StatusAccepted, snapshot)
+}
+
+// listPrePullJobsHandler handles GET /api/images/prepull, newest first
+func listPrePullJobsHandler(c echo.Context) error {
+	prePullJobs.mu.Lock()
+	defer prePullJobs.mu.Unlock()
+
+	jobs := make([]models.PrePullJob, 0, len(prePullJobs.jobs))
+	for i := len(prePullJobs.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, snapshotPrePullJob(prePullJobs.jobs[i]))
+	}
+	return c.JSON(http.StatusOK, jobs)
+}
+
+// getPrePullJobHandler handles GET /api/images/prepull/:id
+func getPrePullJobHandler(c echo.Context) error {
+	prePullJobs.mu.Lock()
+	defer prePullJobs.mu.Unlock()
+
+	for _, job := range prePullJobs.jobs {
+		if job.ID == c.Param("id") {
+			return c.JSON(http.StatusOK, snapshotPrePullJob(job))
+		}
+	}
+	return c.JSON(http.StatusNotFound, map[string]string{
+		"error": "Pre-pull job not found",
+	})
+}
+
+// listReferencedImagesHandler handles GET /api/images/referenced, the images
+// a pre-pull with referenced set would stage
+func listReferencedImagesHandler(c echo.Context) error {
+	refs, err := referencedImages()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, refs)
+}
@@ -0,0 +1,594 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var ingressHostRepo *database.IngressHostRepo
+
+// ingressRouteTTL is how long the host name table is used before it is built
+// again, so new web UI containers get a name without a restart
+const ingressRouteTTL = 15 * time.Second
+
+// defaultIngressDomain is used when neither ingress nor DNS has a domain set
+const defaultIngressDomain = "stardeck.lan"
+
+const (
+	// ingressCookieName records on an ingress host that the browser came
+	// from a Stardeck session
+	ingressCookieName = "stardeck_ingress"
+	ingressCookieTTL  = 12 * time.Hour
+	// ingressTicketTTL is how long the link from Stardeck to an ingress host
+	// can be followed
+	ingressTicketTTL = time.Minute
+	// Paths on every ingress host that Stardeck answers instead of the app
+	ingressLoginPath  = "/.stardeck/login"
+	ingressUnlockPath = "/.stardeck/unlock"
+)
+
+// ingressTarget is where an ingress host name leads
+type ingressTarget struct {
+	host      models.IngressHost
+	container *models.Container
+	app       *models.PublicApp // Set when the web UI is also a public app
+}
+
+var (
+	ingressRoutes        map[string]*ingressTarget // Host name -> target
+	ingressRoutesExpires time.Time
+	ingressRoutesMu      sync.Mutex
+)
+
+// InitIngress initializes the ingress host repository
+func InitIngress() {
+	ingressHostRepo = database.NewIngressHostRepo()
+}
+
+// loadIngressSettings reads the ingress settings. The domain follows the local
+// DNS domain until one is set.
+func loadIngressSettings() models.IngressSettings {
+	var s models.IngressSettings
+	if v, err := settingsRepo.GetBool(database.SettingIngressEnabled); err == nil {
+		s.Enabled = v
+	}
+	if v, err := settingsRepo.Get(database.SettingIngressDomain); err == nil {
+		s.Domain = v
+	}
+	if s.Domain == "" {
+		s.Domain = loadDNSSettings().Domain
+	}
+	if s.Domain == "" {
+		s.Domain = defaultIngressDomain
+	}
+	return s
+}
+
+// saveIngressSettings stores the ingress settings
+func saveIngressSettings(s models.IngressSettings) error {
+	return settingsRepo.SetMany(map[string]string{
+		database.SettingIngressEnabled: strconv.FormatBool(s.Enabled),
+		database.SettingIngressDomain:  s.Domain,
+	})
+}
+
+// ingressHosts builds a host name for every container with a web UI, along
+// with the table of names the middleware routes by. A name is only served
+// once an admin has turned it on. When two containers end up with the same
+// name the first keeps it.
+func ingressHosts() ([]models.IngressHost, map[string]*ingressTarget, error) {
+	settings := loadIngressSettings()
+	containers, err := containerRepo.ListWithWebUI()
+	if err != nil {
+		return nil, nil, err
+	}
+	overrides, err := ingressHostRepo.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	publicApps, err := publicAppRepo.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	apps := make(map[string]*models.PublicApp, len(publicApps))
+	for i := range publicApps {
+		apps[publicApps[i].ContainerID] = &publicApps[i]
+	}
+
+	hosts := make([]models.IngressHost, 0, len(containers))
+	routes := make(map[string]*ingressTarget, len(containers))
+	for i := range containers {
+		container := &containers[i]
+		host := models.IngressHost{
+			ContainerID:   container.ID,
+			ContainerName: container.Name,
+			Label:         system.ContainerRecordName(container.Name),
+		}
+		if o, ok := overrides[container.ID]; ok {
+			if o.Label != "" {
+				host.Label, host.Custom = o.Label, true
+			}
+			host.Enabled = container.WebUIPort != 0 && o.Enabled
+			host.Public = o.Public
+		}
+		if host.Label == "" {
+			continue
+		}
+		host.Hostname = host.Label + "." + settings.Domain
+		if _, taken := routes[host.Hostname]; taken {
+			host.Enabled = false
+		} else {
+			// Names that are off stay in the table so they get a 404
+			// instead of the Stardeck UI
+			routes[host.Hostname] = &ingressTarget{host: host, container: container, app: apps[container.ID]}
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, routes, nil
+}
+
+// ingressHostname normalizes a request's Host header to the name it routes by
+func ingressHostname(requestHost string) string {
+	host := requestHost
+	if h, _, err := net.SplitHostPort(requestHost); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// ingressRoute returns the target a request's host name belongs to, or nil
+// when the request is for Stardeck itself
+func ingressRoute(requestHost string) *ingressTarget {
+	host := ingressHostname(requestHost)
+
+	ingressRoutesMu.Lock()
+	defer ingressRoutesMu.Unlock()
+	if time.Now().After(ingressRoutesExpires) {
+		ingressRoutes = nil
+		if loadIngressSettings().Enabled {
+			_, routes, err := ingressHosts()
+			if err != nil {
+				log.Printf("Warning: failed to build ingress routes: %v", err)
+			}
+			ingressRoutes = routes
+		}
+		ingressRoutesExpires = time.Now().Add(ingressRouteTTL)
+	}
+	return ingressRoutes[host]
+}
+
+// invalidateIngressRoutes makes the next request rebuild the host name table
+func invalidateIngressRoutes() {
+	ingressRoutesMu.Lock()
+	ingressRoutesExpires = time.Time{}
+	ingressRoutesMu.Unlock()
+}
+
+// applyIngressDNS updates the local resolver after ingress names changed
+func applyIngressDNS() error {
+	if !loadDNSSettings().Enabled {
+		return nil
+	}
+	dnsmasqMu.Lock()
+	defer dnsmasqMu.Unlock()
+	return applyDnsmasq()
+}
+
+// ingressSignature binds a login ticket or cookie to the host name it was
+// issued for and its expiry
+func ingressSignature(key []byte, kind, hostname string, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "ingress|%s|%s|%d", kind, hostname, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ingressToken returns a signed "<expiry>.<signature>" value for a host name
+func ingressToken(kind, hostname string, ttl time.Duration) (string, error) {
+	key, err := kioskCookieKey()
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(ttl).Unix()
+	return fmt.Sprintf("%d.%s", expires, ingressSignature(key, kind, hostname, expires)), nil
+}
+
+// ingressTokenValid checks a value made by ingressToken
+func ingressTokenValid(value, kind, hostname string) bool {
+	expiry, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	key, err := kioskCookieKey()
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(ingressSignature(key, kind, hostname, expires)))
+}
+
+// ingressAllowed reports whether a request may reach an ingress host's app:
+// the host is public, the browser came from a Stardeck session, or the
+// container's public app lets it in
+func ingressAllowed(c echo.Context, target *ingressTarget) bool {
+	if target.host.Public {
+		return true
+	}
+	if cookie, err := c.Cookie(ingressCookieName); err == nil &&
+		ingressTokenValid(cookie.Value, "cookie", target.host.Hostname) {
+		return true
+	}
+	if app := target.app; app != nil {
+		return !app.PINRequired || kioskUnlocked(c, app)
+	}
+	return false
+}
+
+// ingressLogin turns a ticket from openIngressHostHandler into a cookie on the
+// ingress host
+func ingressLogin(c echo.Context, target *ingressTarget) error {
+	if !ingressTokenValid(c.QueryParam("ticket"), "ticket", target.host.Hostname) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": tr(c, "error.login_link_expired"),
+		})
+	}
+	value, err := ingressToken("cookie", target.host.Hostname, ingressCookieTTL)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to sign in: " + err.Error(),
+		})
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     ingressCookieName,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(ingressCookieTTL.Seconds()),
+	})
+	return c.Redirect(http.StatusSeeOther, "/")
+}
+
+// ingressPINKey counts PIN attempts per client and ingress host, like
+// publicAppPINKey does for the app's public path
+func ingressPINKey(c echo.Context) string {
+	return c.RealIP() + "|" + ingressHostname(c.Request().Host)
+}
+
+// ingressUnlock checks a public app's PIN from the form on its ingress host
+func ingressUnlock(c echo.Context, target *ingressTarget) error {
+	app := target.app
+	if app == nil || !app.PINRequired || c.Request().Method != http.MethodPost {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.not_found"),
+		})
+	}
+	var req models.UnlockPublicAppRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request_body"),
+		})
+	}
+	if ok, err := auth.VerifyPassword(req.PIN, app.PINHash); err != nil || !ok {
+		Audit.Log(0, "", models.ActionPublicAppUnlockFailed, app.Slug, nil, c.RealIP())
+		return renderPINPage(c, app.Name, ingressUnlockPath, true)
+	}
+	auth.PINRateLimiter.RecordSuccess(ingressPINKey(c))
+
+	key, err := kioskCookieKey()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to unlock app: " + err.Error(),
+		})
+	}
+	expires := time.Now().Add(kioskUnlockTTL).Unix()
+	c.SetCookie(&http.Cookie{
+		Name:     kioskCookieName(app.Slug),
+		Value:    fmt.Sprintf("%d.%s", expires, kioskSignature(key, app, expires)),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(kioskUnlockTTL.Seconds()),
+	})
+	return c.Redirect(http.StatusSeeOther, "/")
+}
+
+// IngressMiddleware passes requests for a container's ingress host name to
+// its web UI, and everything else on to Stardeck. Names that are off get a
+// 404. Unless the host is public, the browser must have come from a Stardeck
+// session or got past the container's public app PIN; the app then handles
+// its own logins, as it does when reached on its published port.
+func IngressMiddleware() echo.MiddlewareFunc {
+	unlock := auth.PINRateLimiter.MiddlewareBy(ingressPINKey)(func(c echo.Context) error {
+		return ingressUnlock(c, c.Get("ingress").(*ingressTarget))
+	})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			target := ingressRoute(c.Request().Host)
+			if target == nil {
+				return next(c)
+			}
+			if !target.host.Enabled {
+				return c.JSON(http.StatusNotFound, map[string]string{
+					"error": tr(c, "error.not_found"),
+				})
+			}
+
+			switch c.Request().URL.Path {
+			case ingressLoginPath:
+				return ingressLogin(c, target)
+			case ingressUnlockPath:
+				c.Set("ingress", target)
+				return unlock(c)
+			}
+			if !ingressAllowed(c, target) {
+				if app := target.app; app != nil && c.Request().Method == http.MethodGet &&
+					strings.Contains(c.Request().Header.Get("Accept"), "text/html") {
+					return renderPINPage(c, app.Name, ingressUnlockPath, false)
+				}
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": tr(c, "error.open_from_stardeck"),
+				})
+			}
+
+			// Never hand Stardeck credentials to the app
+			strip := []string{"session_token", ingressCookieName}
+			if target.app != nil {
+				strip = append(strip, kioskCookieName(target.app.Slug))
+			}
+			stripCookies(c.Request(), strip...)
+
+			container := target.container
+			host, port := resolveProxyTarget(c.Request().Context(), container)
+			proxyTarget := &url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(port))}
+			proxy := &httputil.ReverseProxy{
+				Rewrite: func(r *httputil.ProxyRequest) {
+					r.SetURL(proxyTarget)
+					r.SetXForwarded()
+					r.Out.Host = r.In.Host // Apps build links from the name they were reached by
+				},
+				ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+					http.Error(w, fmt.Sprintf("%s is not responding: %v", container.Name, err), http.StatusBadGateway)
+				},
+			}
+			proxy.ServeHTTP(c.Response(), c.Request())
+			return nil
+		}
+	}
+}
+
+// ingressURL returns the address a host name is reached at, using the scheme
+// and port of the current request
+func ingressURL(c echo.Context, hostname string) string {
+	u := url.URL{Scheme: c.Scheme(), Host: hostname}
+	if _, port, err := net.SplitHostPort(c.Request().Host); err == nil {
+		u.Host = net.JoinHostPort(hostname, port)
+	}
+	return u.String() + "/"
+}
+
+// getIngressHandler handles GET /api/network/ingress
+func getIngressHandler(c echo.Context) error {
+	settings := loadIngressSettings()
+	hosts, _, err := ingressHosts()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list ingress hosts: " + err.Error(),
+		})
+	}
+	for i := range hosts {
+		if !settings.Enabled || !hosts[i].Enabled {
+			continue
+		}
+		if hosts[i].Public {
+			hosts[i].URL = ingressURL(c, hosts[i].Hostname)
+		} else {
+			// Goes by Stardeck first to sign the browser in on the host
+			hosts[i].URL = externalPath("/api/network/ingress/hosts/" + hosts[i].ContainerID + "/open")
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.IngressStatus{
+		Settings: settings,
+		Hosts:    hosts,
+		DNS:      settings.Enabled && loadDNSSettings().Enabled,
+	})
+}
+
+// updateIngressHandler handles PUT /api/network/ingress
+func updateIngressHandler(c echo.Context) error {
+	previous := loadIngressSettings()
+	settings := previous
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := system.ValidateIngressSettings(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := saveIngressSettings(settings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save ingress settings: " + err.Error(),
+		})
+	}
+	invalidateIngressRoutes()
+	if err := applyIngressDNS(); err != nil {
+		saveIngressSettings(previous)
+		invalidateIngressRoutes()
+		applyIngressDNS()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DNS configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionIngressConfigure, "ingress", settings)
+
+	return getIngressHandler(c)
+}
+
+// updateIngressHostHandler handles PUT /api/network/ingress/hosts/:id
+func updateIngressHostHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.UpdateIngressHostRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	overrides, err := ingressHostRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list ingress hosts: " + err.Error(),
+		})
+	}
+	override := overrides[container.ID]
+	override.ContainerID = container.ID
+	override.Label = strings.ToLower(strings.TrimSpace(req.Label))
+	if req.Enabled != nil {
+		override.Enabled = *req.Enabled
+	}
+	if req.Public != nil {
+		override.Public = *req.Public
+	}
+	if override.Label != "" && system.ContainerRecordName(override.Label) != override.Label {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_dns_label"),
+		})
+	}
+
+	label := override.Label
+	if label == "" {
+		label = system.ContainerRecordName(container.Name)
+	}
+	hosts, _, err := ingressHosts()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list ingress hosts: " + err.Error(),
+		})
+	}
+	for _, host := range hosts {
+		if host.ContainerID != container.ID && host.Label == label {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": tr(c, "error.hostname_in_use", "hostname", host.Hostname, "container", host.ContainerName),
+			})
+		}
+	}
+
+	if err := ingressHostRepo.Upsert(&override); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save ingress host: " + err.Error(),
+		})
+	}
+	invalidateIngressRoutes()
+	if err := applyIngressDNS(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DNS configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionIngressHostUpdate, container.Name, map[string]interface{}{
+		"label":   override.Label,
+		"enabled": override.Enabled,
+		"public":  override.Public,
+	})
+
+	return getIngressHandler(c)
+}
+
+// resetIngressHostHandler handles DELETE /api/network/ingress/hosts/:id,
+// going back to the host name generated from the container name
+func resetIngressHostHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	if err := ingressHostRepo.Delete(container.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to reset ingress host: " + err.Error(),
+		})
+	}
+	invalidateIngressRoutes()
+	if err := applyIngressDNS(); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply DNS configuration: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionIngressHostUpdate, container.Name, map[string]interface{}{
+		"reset": true,
+	})
+
+	return getIngressHandler(c)
+}
+
+// openIngressHostHandler handles GET /api/network/ingress/hosts/:id/open, sending
+// a signed-in browser on to the container's ingress host with a short-lived
+// ticket that signs it in there
+func openIngressHostHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+	hosts, _, err := ingressHosts()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list ingress hosts: " + err.Error(),
+		})
+	}
+	for _, host := range hosts {
+		if host.ContainerID != container.ID {
+			continue
+		}
+		if !loadIngressSettings().Enabled || !host.Enabled {
+			break
+		}
+		ticket, err := ingressToken("ticket", host.Hostname, ingressTicketTTL)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to open app: " + err.Error(),
+			})
+		}
+		target := strings.TrimSuffix(ingressURL(c, host.Hostname), "/") + ingressLoginPath + "?ticket=" + url.QueryEscape(ticket)
+		return c.Redirect(http.StatusFound, target)
+	}
+	return c.JSON(http.StatusNotFound, map[string]string{
+		"error": tr(c, "error.no_ingress_host"),
+	})
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/models"
+)
+
+// withIngressRoutes serves the given host name table instead of building one
+// from the database
+func withIngressRoutes(t *testing.T, routes map[string]*ingressTarget) {
+	t.Helper()
+	ingressRoutesMu.Lock()
+	ingressRoutes, ingressRoutesExpires = routes, time.Now().Add(time.Hour)
+	ingressRoutesMu.Unlock()
+	t.Cleanup(invalidateIngressRoutes)
+}
+
+// serveIngress runs a request for host through IngressMiddleware, with
+// Stardeck itself answering 200
+func serveIngress(host, accept string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Use(IngressMiddleware())
+	e.GET("/*", func(c echo.Context) error { return c.String(http.StatusOK, "stardeck") })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = host
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIngressHostGated(t *testing.T) {
+	container := &models.Container{ID: "c1", Name: "jellyfin", WebUIPort: 8096}
+	withIngressRoutes(t, map[string]*ingressTarget{
+		"jellyfin.stardeck.lan": {
+			host:      models.IngressHost{ContainerID: "c1", Hostname: "jellyfin.stardeck.lan"},
+			container: container,
+		},
+		"grafana.stardeck.lan": {
+			host:      models.IngressHost{ContainerID: "c2", Hostname: "grafana.stardeck.lan", Enabled: true},
+			container: &models.Container{ID: "c2", Name: "grafana", WebUIPort: 3000},
+		},
+		"kiosk.stardeck.lan": {
+			host:      models.IngressHost{ContainerID: "c3", Hostname: "kiosk.stardeck.lan", Enabled: true},
+			container: &models.Container{ID: "c3", Name: "kiosk", WebUIPort: 80},
+			app:       &models.PublicApp{ContainerID: "c3", Slug: "kiosk", Name: "Kiosk", PINRequired: true},
+		},
+	})
+
+	// A generated name that was never turned on
+	if rec := serveIngress("jellyfin.stardeck.lan", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unconfigured host: got %d, want 404", rec.Code)
+	}
+	// Turned on but not public, with no Stardeck login
+	if rec := serveIngress("Grafana.stardeck.lan:8443", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("private host without a session: got %d, want 401", rec.Code)
+	}
+	// A public app's PIN gate applies on its ingress host too
+	rec := serveIngress("kiosk.stardeck.lan", "text/html")
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), `action="`+ingressUnlockPath+`"`) {
+		t.Errorf("PIN host: got %d, want 401 with the PIN form", rec.Code)
+	}
+	// Anything else is Stardeck itself
+	if rec := serveIngress("stardeck.lan", ""); rec.Code != http.StatusOK || rec.Body.String() != "stardeck" {
+		t.Errorf("Stardeck host: got %d %q, want 200 from Stardeck", rec.Code, rec.Body.String())
+	}
+}
+
+func TestIngressTokenBoundToHost(t *testing.T) {
+	key := []byte("test key")
+	expires := time.Now().Add(time.Minute).Unix()
+	sig := ingressSignature(key, "ticket", "grafana.stardeck.lan", expires)
+	for _, other := range []string{
+		ingressSignature(key, "ticket", "jellyfin.stardeck.lan", expires),
+		ingressSignature(key, "cookie", "grafana.stardeck.lan", expires),
+		ingressSignature(key, "ticket", "grafana.stardeck.lan", expires+1),
+	} {
+		if other == sig {
+			t.Errorf("signature %s is not bound to its host, kind and expiry", sig)
+		}
+	}
+}
+
+func TestIngressPINUnlockKeepsLoginLockout(t *testing.T) {
+	openTestDB(t)
+	app := createTestPublicApp(t, "kiosk", "1357")
+	withIngressRoutes(t, map[string]*ingressTarget{
+		"kiosk.stardeck.lan": {
+			host:      models.IngressHost{ContainerID: app.ContainerID, Hostname: "kiosk.stardeck.lan", Enabled: true},
+			container: &models.Container{ID: app.ContainerID, Name: "kiosk", WebUIPort: 80},
+			app:       app,
+		},
+	})
+
+	const ip = "192.0.2.11"
+	for i := 0; i < 5; i++ {
+		auth.LoginRateLimiter.Allow(ip) // Failed password guesses
+	}
+	t.Cleanup(func() { auth.LoginRateLimiter.RecordSuccess(ip) })
+
+	e := echo.New()
+	e.Use(IngressMiddleware())
+	req := httptest.NewRequest(http.MethodPost, ingressUnlockPath, strings.NewReader(url.Values{"pin": {"1357"}}.Encode()))
+	req.Host = "kiosk.stardeck.lan"
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.RemoteAddr = ip + ":40000"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("unlock with the right PIN: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if left := auth.LoginRateLimiter.GetRemainingAttempts(ip); left != 0 {
+		t.Errorf("login attempts left after an ingress PIN unlock: %d, want 0", left)
+	}
+}
+
+func TestIngressErrorsLocalized(t *testing.T) {
+	withIngressRoutes(t, map[string]*ingressTarget{
+		"grafana.stardeck.lan": {
+			host:      models.IngressHost{ContainerID: "c2", Hostname: "grafana.stardeck.lan", Enabled: true},
+			container: &models.Container{ID: "c2", Name: "grafana", WebUIPort: 3000},
+		},
+	})
+
+	e := echo.New()
+	e.Use(IngressMiddleware())
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "grafana.stardeck.lan"
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.5")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if want := "Öffnen Sie diese App aus Stardeck"; rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("private host in German: got %d %s, want 401 with %q", rec.Code, rec.Body.String(), want)
+	}
+}
@@ -0,0 +1,488 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var (
+	invitationRepo    *database.InvitationRepo
+	passwordResetRepo *database.PasswordResetRepo
+)
+
+// InitInvitationRepos initializes the invitation and password reset repositories
+func InitInvitationRepos() {
+	invitationRepo = database.NewInvitationRepo()
+	passwordResetRepo = database.NewPasswordResetRepo()
+}
+
+// emailSendTimeout bounds one SMTP delivery
+const emailSendTimeout = time.Minute
+
+// emailUnavailable answers a request that needs outgoing mail while it's off
+func emailUnavailable(c echo.Context) error {
+	return c.JSON(http.StatusBadRequest, map[string]string{
+		"error": "Email is disabled or not fully configured",
+	})
+}
+
+// sendInvitation emails the account creation link for an invitation
+func sendInvitation(ctx context.Context, inv *models.UserInvitation, token string, invitedBy *models.User) error {
+	settings := loadEmailSettings()
+	return sendEmail(ctx, inv.Email, emailTemplateInvitation, emailTemplateData{
+		DisplayName: inv.DisplayName,
+		Email:       inv.Email,
+		Role:        string(inv.Role),
+		InvitedBy:   invitedBy.DisplayName,
+		Link:        emailLink(settings, "/invite", token),
+		ExpiresIn:   formatTTL(time.Until(inv.ExpiresAt).Round(time.Minute)),
+	})
+}
+
+// sendPasswordReset creates a reset token for a local account and emails its link
+func sendPasswordReset(ctx context.Context, user *models.User) error {
+	settings := loadEmailSettings()
+	ttl := time.Duration(settings.ResetTTLMinutes) * time.Minute
+	token, err := passwordResetRepo.Create(user.ID, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+	return sendEmail(ctx, user.Email, emailTemplatePasswordReset, emailTemplateData{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+		Link:        emailLink(settings, "/reset-password", token),
+		ExpiresIn:   formatTTL(ttl),
+	})
+}
+
+// listInvitationsHandler returns all invitations, newest first
+func listInvitationsHandler(c echo.Context) error {
+	invitations, err := invitationRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list invitations: " + err.Error(),
+		})
+	}
+	if invitations == nil {
+		invitations = []models.UserInvitation{}
+	}
+	return c.JSON(http.StatusOK, invitations)
+}
+
+// createInvitationHandler invites someone by email to create a local account
+// with a preset role
+func createInvitationHandler(c echo.Context) error {
+	var req models.CreateInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" || !validEmail(req.Email) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "a valid email address is required",
+		})
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+	switch req.Role {
+	case models.RoleAdmin, models.RoleOperator, models.RoleViewer:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "role must be admin, operator or viewer",
+		})
+	}
+
+	settings := loadEmailSettings()
+	if !emailReady(settings) {
+		return emailUnavailable(c)
+	}
+
+	user := c.Get("user").(*models.User)
+	inv := &models.UserInvitation{
+		Email:       req.Email,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Role:        req.Role,
+		ExpiresAt:   time.Now().Add(time.Duration(settings.InviteTTLHours) * time.Hour),
+		CreatedBy:   &user.ID,
+	}
+	token, err := invitationRepo.Create(inv)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create invitation: " + err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), emailSendTimeout)
+	defer cancel()
+	if err := sendInvitation(ctx, inv, token, user); err != nil {
+		invitationRepo.Delete(inv.ID)
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to send invitation: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionInvitationCreate, inv.Email, map[string]interface{}{
+		"invitation_id": inv.ID,
+		"role":          inv.Role,
+	})
+
+	return c.JSON(http.StatusCreated, inv)
+}
+
+// resendInvitationHandler emails a pending invitation again with a fresh link
+// and expiry; the previous link stops working
+func resendInvitationHandler(c echo.Context) error {
+	inv, err := invitationRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Invitation not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get invitation: " + err.Error(),
+		})
+	}
+	if inv.AcceptedAt != nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Invitation was already accepted",
+		})
+	}
+
+	settings := loadEmailSettings()
+	if !emailReady(settings) {
+		return emailUnavailable(c)
+	}
+
+	inv.ExpiresAt = time.Now().Add(time.Duration(settings.InviteTTLHours) * time.Hour)
+	token, err := invitationRepo.Renew(inv.ID, inv.ExpiresAt)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to renew invitation: " + err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), emailSendTimeout)
+	defer cancel()
+	if err := sendInvitation(ctx, inv, token, c.Get("user").(*models.User)); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to send invitation: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionInvitationResend, inv.Email, map[string]interface{}{
+		"invitation_id": inv.ID,
+	})
+
+	return c.JSON(http.StatusOK, inv)
+}
+
+// revokeInvitationHandler deletes an invitation so its link stops working
+func revokeInvitationHandler(c echo.Context) error {
+	inv, err := invitationRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Invitation not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get invitation: " + err.Error(),
+		})
+	}
+
+	if err := invitationRepo.Delete(inv.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to revoke invitation: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionInvitationRevoke, inv.Email, map[string]interface{}{
+		"invitation_id": inv.ID,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "revoked",
+	})
+}
+
+// pendingInvitation loads the invitation for a token from its link, writing
+// the error response when it's unknown, used or expired
+func pendingInvitation(c echo.Context, token string) (*models.UserInvitation, error) {
+	inv, err := invitationRepo.GetByToken(token)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get invitation: " + err.Error(),
+		})
+	}
+	if err != nil || token == "" || inv.AcceptedAt != nil || time.Now().After(inv.ExpiresAt) {
+		return nil, c.JSON(http.StatusGone, map[string]string{
+			"error": "This invitation link is invalid or has expired",
+		})
+	}
+	return inv, nil
+}
+
+// lookupInvitationHandler describes the invitation behind a link so the
+// account form can show the invited email and role (public)
+func lookupInvitationHandler(c echo.Context) error {
+	var req models.InvitationTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	inv, err := pendingInvitation(c, req.Token)
+	if inv == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"email":        inv.Email,
+		"display_name": inv.DisplayName,
+		"role":         inv.Role,
+		"expires_at":   inv.ExpiresAt,
+	})
+}
+
+// acceptInvitationHandler creates the invited local account (public)
+func acceptInvitationHandler(c echo.Context) error {
+	var req models.AcceptInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	inv, err := pendingInvitation(c, req.Token)
+	if inv == nil {
+		return err
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if len(req.Username) < 3 || len(req.Username) > 32 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "username must be 3-32 characters",
+		})
+	}
+	if len(req.Password) < 8 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.password_too_short"),
+		})
+	}
+	if exists, _ := userRepo.ExistsByUsername(req.Username); exists {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "username already exists",
+		})
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to create user",
+		})
+	}
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" {
+		displayName = inv.DisplayName
+	}
+	if displayName == "" {
+		displayName = req.Username
+	}
+
+	user := &models.User{
+		Username:     req.Username,
+		DisplayName:  displayName,
+		Email:        inv.Email,
+		PasswordHash: passwordHash,
+		Role:         inv.Role,
+		AuthType:     models.AuthTypeLocal,
+	}
+	if err := userRepo.Create(user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to create user",
+		})
+	}
+	// Two tabs racing on the same link: only the first account survives
+	if err := invitationRepo.MarkAccepted(inv.ID, user.ID); err != nil {
+		userRepo.Delete(user.ID)
+		return c.JSON(http.StatusGone, map[string]string{
+			"error": "This invitation link is invalid or has expired",
+		})
+	}
+
+	Audit.Log(user.ID, user.Username, models.ActionInvitationAccept, user.Username, map[string]interface{}{
+		"invitation_id": inv.ID,
+		"email":         inv.Email,
+		"role":          user.Role,
+	}, c.RealIP())
+
+	return c.JSON(http.StatusCreated, user)
+}
+
+// sendUserPasswordResetHandler emails a password reset link to a local user
+func sendUserPasswordResetHandler(c echo.Context) error {
+	user, err := userFromParam(c)
+	if user == nil {
+		return err
+	}
+	if user.AuthType != models.AuthTypeLocal {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Only local accounts have a Stardeck password",
+		})
+	}
+	if user.Email == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "User has no email address",
+		})
+	}
+	if !emailReady(loadEmailSettings()) {
+		return emailUnavailable(c)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), emailSendTimeout)
+	defer cancel()
+	if err := sendPasswordReset(ctx, user); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to send password reset: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionPasswordResetSend, user.Username, map[string]interface{}{
+		"user_id": user.ID,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "sent",
+	})
+}
+
+// passwordResetStatusHandler tells the login page whether to offer a reset link (public)
+func passwordResetStatusHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]bool{
+		"enabled": emailReady(loadEmailSettings()),
+	})
+}
+
+// requestPasswordResetHandler emails a reset link to the local account named
+// by username or email (public). The answer is the same whether or not an
+// account matched, and mail goes out in the background so timing doesn't
+// reveal it either.
+func requestPasswordResetHandler(c echo.Context) error {
+	if !emailReady(loadEmailSettings()) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Password reset by email is not enabled",
+		})
+	}
+
+	var req models.RequestPasswordResetRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	login := strings.TrimSpace(req.Login)
+	if login == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "login is required",
+		})
+	}
+
+	var users []*models.User
+	if strings.Contains(login, "@") {
+		users, _ = userRepo.ListByEmail(login)
+	} else if user, err := userRepo.GetByUsername(login); err == nil {
+		users = append(users, user)
+	}
+
+	ipAddress := c.RealIP()
+	go func() {
+		for _, user := range users {
+			if user.AuthType != models.AuthTypeLocal || user.Disabled || user.Email == "" {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+			err := sendPasswordReset(ctx, user)
+			cancel()
+			details := map[string]interface{}{"self_service": true}
+			if err != nil {
+				details["error"] = err.Error()
+			}
+			Audit.Log(user.ID, user.Username, models.ActionPasswordResetSend, user.Username, details, ipAddress)
+		}
+	}()
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"status": "If an account matches, a reset link has been emailed to it",
+	})
+}
+
+// confirmPasswordResetHandler sets a new password with a reset token and signs
+// the account out everywhere (public)
+func confirmPasswordResetHandler(c echo.Context) error {
+	var req models.ConfirmPasswordResetRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if len(req.Password) < 8 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.password_too_short"),
+		})
+	}
+
+	invalid := func() error {
+		return c.JSON(http.StatusGone, map[string]string{
+			"error": "This reset link is invalid or has expired",
+		})
+	}
+	reset, err := passwordResetRepo.GetByToken(req.Token)
+	if err != nil || req.Token == "" || reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return invalid()
+	}
+	user, err := userRepo.GetByID(reset.UserID)
+	if err != nil || user.AuthType != models.AuthTypeLocal || user.Disabled {
+		return invalid()
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to reset password",
+		})
+	}
+	if err := passwordResetRepo.Consume(req.Token, user.ID); errors.Is(err, sql.ErrNoRows) {
+		return invalid()
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to reset password",
+		})
+	}
+	user.PasswordHash = passwordHash
+	if err := userRepo.Update(user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to reset password",
+		})
+	}
+	authService.RevokeAllSessions(user.ID)
+
+	Audit.Log(user.ID, user.Username, models.ActionPasswordResetFinish, user.Username, nil, c.RealIP())
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "password updated",
+	})
+}
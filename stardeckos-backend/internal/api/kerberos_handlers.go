@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// loadKerberosSettings reads the Kerberos sign-on settings and lists the HTTP
+// service principals available in the keytab
+func loadKerberosSettings() models.KerberosSettings {
+	s := models.KerberosSettings{
+		Enabled: authService.NegotiateEnabled(),
+		Keytab:  authService.KerberosKeytab(),
+		Realms:  []string{},
+	}
+	if v, err := settingsRepo.Get(database.SettingKerberosRealms); err == nil {
+		for _, realm := range strings.Split(v, ",") {
+			if realm = strings.TrimSpace(realm); realm != "" {
+				s.Realms = append(s.Realms, realm)
+			}
+		}
+	}
+
+	entries, err := auth.ReadKeytab(s.Keytab)
+	if err != nil {
+		s.KeytabError = err.Error()
+		return s
+	}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		principal := e.Principal + "@" + e.Realm
+		if strings.HasPrefix(strings.ToUpper(e.Principal), "HTTP/") && !seen[principal] {
+			seen[principal] = true
+			s.Principals = append(s.Principals, principal)
+		}
+	}
+	sort.Strings(s.Principals)
+	if len(s.Principals) == 0 {
+		s.KeytabError = "keytab has no HTTP/<hostname> service principal"
+	}
+	return s
+}
+
+// getKerberosSettingsHandler returns the Kerberos sign-on settings
+func getKerberosSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadKerberosSettings())
+}
+
+// updateKerberosSettingsHandler turns Kerberos sign-on on or off and sets the keytab and trusted realms
+func updateKerberosSettingsHandler(c echo.Context) error {
+	settings := loadKerberosSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	settings.Keytab = strings.TrimSpace(settings.Keytab)
+	if settings.Keytab == "" {
+		settings.Keytab = auth.DefaultKerberosKeytab
+	}
+	if !filepath.IsAbs(settings.Keytab) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "keytab must be an absolute path",
+		})
+	}
+	if settings.Enabled {
+		if _, err := auth.ReadKeytab(settings.Keytab); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Cannot read keytab: " + err.Error(),
+			})
+		}
+	}
+
+	var realms []string
+	for _, realm := range settings.Realms {
+		if realm = strings.ToUpper(strings.TrimSpace(realm)); realm != "" {
+			if strings.ContainsAny(realm, ", ") {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "Invalid realm: " + realm,
+				})
+			}
+			realms = append(realms, realm)
+		}
+	}
+
+	values := map[string]string{
+		database.SettingKerberosEnabled: strconv.FormatBool(settings.Enabled),
+		database.SettingKerberosKeytab:  settings.Keytab,
+		database.SettingKerberosRealms:  strings.Join(realms, ","),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionKerberosSettings, "kerberos", values)
+
+	return c.JSON(http.StatusOK, loadKerberosSettings())
+}
+
+// negotiateLoginHandler handles GET /api/auth/negotiate. Without a token it
+// challenges the browser with WWW-Authenticate: Negotiate; browsers configured
+// to trust this site answer with a Kerberos ticket and are signed in. Any other
+// outcome is a plain JSON error so the login page falls back to its usual form.
+func negotiateLoginHandler(c echo.Context) error {
+	if !authService.NegotiateEnabled() {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Kerberos sign-in is not enabled",
+		})
+	}
+
+	header := c.Request().Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Negotiate ") {
+		c.Response().Header().Set("WWW-Authenticate", "Negotiate")
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "negotiate",
+		})
+	}
+
+	ipAddress := c.RealIP()
+	token, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(header, "Negotiate ")))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid Negotiate token",
+		})
+	}
+
+	resp, identity, err := authService.LoginNegotiate(token, ingressHostname(c.Request().Host), ipAddress, c.Request().UserAgent())
+	if err != nil {
+		principal := ""
+		if identity != nil {
+			principal = identity.Principal()
+		}
+		Audit.Log(0, principal, models.ActionLoginFailed, principal, map[string]string{
+			"method": "kerberos",
+			"reason": err.Error(),
+		}, ipAddress)
+		reportAuthFailure(c, principal)
+
+		switch {
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			// No new challenge here, or the browser would loop retrying the same ticket
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Kerberos sign-in failed",
+			})
+		case errors.Is(err, auth.ErrUserDisabled):
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "user account is disabled",
+			})
+		default:
+			c.Logger().Error("kerberos login error: ", err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "authentication failed",
+			})
+		}
+	}
+
+	return finishLogin(c, resp, map[string]string{
+		"method":    "kerberos",
+		"principal": identity.Principal(),
+	})
+}
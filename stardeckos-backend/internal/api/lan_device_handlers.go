@@ -0,0 +1,337 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var lanDeviceRepo *database.LANDeviceRepo
+
+// lanRecordMu keeps the watcher and scans from announcing the same device twice
+var lanRecordMu sync.Mutex
+
+// InitLANDeviceRepo initializes the LAN device repository and starts
+// recording the devices that appear in the neighbor table
+func InitLANDeviceRepo() {
+	lanDeviceRepo = database.NewLANDeviceRepo()
+	go runLANDeviceWatcher()
+}
+
+// lanDeviceAlertsEnabled reports whether new devices raise a notification
+func lanDeviceAlertsEnabled() bool {
+	enabled, err := settingsRepo.GetBool(database.SettingLANDeviceAlerts)
+	return err != nil || enabled
+}
+
+// lanNeighbors returns the neighbor table without containers, whose
+// addresses on podman bridges come and go with every restart
+func lanNeighbors(ctx context.Context) ([]models.Neighbor, error) {
+	neighbors, err := system.GetNeighbors()
+	if err != nil {
+		return nil, err
+	}
+	bridges := make(map[string]bool)
+	if networks, err := podmanService.ListNetworks(ctx); err == nil {
+		for _, n := range networks {
+			if n.Driver == "bridge" && n.Interface != "" {
+				bridges[n.Interface] = true
+			}
+		}
+	}
+	lan := neighbors[:0]
+	for _, n := range neighbors {
+		if !bridges[n.Interface] && !strings.HasPrefix(n.Interface, "veth") {
+			lan = append(lan, n)
+		}
+	}
+	return lan, nil
+}
+
+// recordNeighbors adds the neighbors to the device history and returns how
+// many were seen for the first time. The first devices ever recorded are
+// the baseline and don't raise alerts.
+func recordNeighbors(ctx context.Context, neighbors []models.Neighbor) int {
+	lanRecordMu.Lock()
+	defer lanRecordMu.Unlock()
+
+	count, err := lanDeviceRepo.Count()
+	if err != nil {
+		log.Printf("Warning: failed to count LAN devices: %v", err)
+		return 0
+	}
+	baseline := count == 0
+	alerts := lanDeviceAlertsEnabled()
+
+	found := 0
+	for _, n := range neighbors {
+		_, err := lanDeviceRepo.Get(n.MAC)
+		if err == nil {
+			if err := lanDeviceRepo.RecordSighting(n.MAC, n.IPAddress, n.Interface, n.Hostname); err != nil {
+				log.Printf("Warning: failed to record LAN device %s: %v", n.MAC, err)
+			}
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Warning: failed to look up LAN device %s: %v", n.MAC, err)
+			continue
+		}
+
+		hostname := n.Hostname
+		if hostname == "" {
+			hostname = system.ReverseLookup(ctx, n.IPAddress)
+		}
+		device := &models.LANDevice{
+			MAC:       n.MAC,
+			IPAddress: n.IPAddress,
+			Interface: n.Interface,
+			Hostname:  hostname,
+			Vendor:    n.Vendor,
+		}
+		if err := lanDeviceRepo.Create(device); err != nil {
+			log.Printf("Warning: failed to record LAN device %s: %v", n.MAC, err)
+			continue
+		}
+		found++
+
+		if baseline || !alerts {
+			continue
+		}
+		description := n.IPAddress
+		if hostname != "" {
+			description = fmt.Sprintf("%s (%s)", hostname, n.IPAddress)
+		}
+		if n.Vendor != "" {
+			description += ", " + n.Vendor
+		}
+		notifyRoles(localized(models.Notification{
+			Type:  models.NotificationLANDeviceNew,
+			Level: models.NotificationWarning,
+			Data: map[string]interface{}{
+				"mac":        n.MAC,
+				"ip_address": n.IPAddress,
+				"interface":  n.Interface,
+				"vendor":     n.Vendor,
+			},
+		}, "notification.lan_device_new", "device", description, "interface", n.Interface, "mac", n.MAC), models.RoleAdmin)
+	}
+	return found
+}
+
+// runLANDeviceWatcher records the neighbor table every few minutes
+func runLANDeviceWatcher() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		if neighbors, err := lanNeighbors(ctx); err == nil {
+			recordNeighbors(ctx, neighbors)
+		} else {
+			log.Printf("Warning: failed to read neighbor table: %v", err)
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+// listNeighborsHandler handles GET /api/network/neighbors. ?resolve=true adds
+// reverse DNS names, which can be slow on large networks.
+func listNeighborsHandler(c echo.Context) error {
+	neighbors, err := system.GetNeighbors()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if resolve, _ := strconv.ParseBool(c.QueryParam("resolve")); resolve {
+		for i := range neighbors {
+			neighbors[i].Hostname = system.ReverseLookup(c.Request().Context(), neighbors[i].IPAddress)
+		}
+	}
+	return c.JSON(http.StatusOK, neighbors)
+}
+
+// scanLANHandler handles POST /api/network/scan
+func scanLANHandler(c echo.Context) error {
+	var req models.LANScanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	cidr := req.CIDR
+	if cidr == "" {
+		if req.Interface == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Either interface or cidr is required",
+			})
+		}
+		subnet, err := system.InterfaceSubnet(req.Interface)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		cidr = subnet
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	started := time.Now()
+	scanned, responded, err := system.PingSweep(ctx, cidr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	neighbors, err := lanNeighbors(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	_, network, _ := net.ParseCIDR(cidr)
+	devices := []models.Neighbor{}
+	for _, n := range neighbors {
+		if !network.Contains(net.ParseIP(n.IPAddress)) {
+			continue
+		}
+		n.Hostname = system.ReverseLookup(ctx, n.IPAddress)
+		devices = append(devices, n)
+	}
+
+	result := models.LANScanResult{
+		CIDR:       cidr,
+		Scanned:    scanned,
+		Responded:  responded,
+		NewDevices: recordNeighbors(ctx, devices),
+		Devices:    devices,
+		Duration:   time.Since(started).Seconds(),
+	}
+
+	Audit.LogFromContext(c, models.ActionLANScan, cidr, map[string]interface{}{
+		"scanned": scanned,
+		"devices": len(devices),
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// listLANDevicesHandler handles GET /api/network/devices
+func listLANDevicesHandler(c echo.Context) error {
+	devices, err := lanDeviceRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list devices: " + err.Error(),
+		})
+	}
+	if neighbors, err := system.GetNeighbors(); err == nil {
+		online := make(map[string]bool, len(neighbors))
+		for _, n := range neighbors {
+			online[n.MAC] = true
+		}
+		for i := range devices {
+			devices[i].Online = online[devices[i].MAC]
+		}
+	}
+	return c.JSON(http.StatusOK, devices)
+}
+
+// updateLANDeviceHandler handles PUT /api/network/devices/:mac
+func updateLANDeviceHandler(c echo.Context) error {
+	mac := strings.ToLower(c.Param("mac"))
+	device, err := lanDeviceRepo.Get(mac)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Device not found",
+		})
+	}
+
+	var req models.UpdateLANDeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if req.Name != nil {
+		device.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Known != nil {
+		device.Known = *req.Known
+	}
+	if err := lanDeviceRepo.Update(device); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save device: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionLANDeviceUpdate, mac, map[string]interface{}{
+		"name":  device.Name,
+		"known": device.Known,
+	})
+
+	return c.JSON(http.StatusOK, device)
+}
+
+// deleteLANDeviceHandler handles DELETE /api/network/devices/:mac. A
+// forgotten device still on the network is announced again as new.
+func deleteLANDeviceHandler(c echo.Context) error {
+	mac := strings.ToLower(c.Param("mac"))
+	if _, err := lanDeviceRepo.Get(mac); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Device not found",
+		})
+	}
+	if err := lanDeviceRepo.Delete(mac); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete device: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionLANDeviceDelete, mac, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Device forgotten",
+	})
+}
+
+// getLANDeviceAlertsHandler handles GET /api/network/devices/alerts
+func getLANDeviceAlertsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.LANDeviceAlertSettings{Enabled: lanDeviceAlertsEnabled()})
+}
+
+// updateLANDeviceAlertsHandler handles PUT /api/network/devices/alerts
+func updateLANDeviceAlertsHandler(c echo.Context) error {
+	var settings models.LANDeviceAlertSettings
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := settingsRepo.Set(database.SettingLANDeviceAlerts, strconv.FormatBool(settings.Enabled)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save alert settings: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionLANDeviceAlerts, "lan", settings)
+
+	return c.JSON(http.StatusOK, settings)
+}
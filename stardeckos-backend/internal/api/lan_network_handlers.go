@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// lanNetworkModes are the modes each LAN network driver accepts; the first
+// is podman's default
+var lanNetworkModes = map[string][]string{
+	"macvlan": {"bridge", "private", "vepa", "passthru"},
+	"ipvlan":  {"l2", "l3", "l3s"},
+}
+
+// lanNetworkWarning is shown with every LAN network. The kernel doesn't pass
+// traffic between a macvlan or ipvlan child and its parent interface.
+const lanNetworkWarning = "The host can't reach containers on this network directly, only other LAN devices can"
+
+// podmanBridges returns the interfaces backing podman networks, which can't
+// be the parent of a LAN network
+func podmanBridges(ctx context.Context) map[string]bool {
+	bridges := map[string]bool{}
+	if networks, err := podmanService.ListNetworks(ctx); err == nil {
+		for _, n := range networks {
+			if n.Driver == "bridge" && n.Interface != "" {
+				bridges[n.Interface] = true
+			}
+		}
+	}
+	return bridges
+}
+
+// listLANInterfacesHandler handles GET /api/podman-networks/lan/interfaces,
+// the host interfaces a macvlan or ipvlan network can be bound to with the
+// subnet and gateway detected on each
+func listLANInterfacesHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	interfaces, err := system.LANInterfaces(podmanBridges(ctx))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get network interfaces: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, interfaces)
+}
+
+// createLANNetworkHandler handles POST /api/podman-networks/lan, creating a
+// macvlan or ipvlan network on a host interface so containers get addresses
+// routable on the LAN
+func createLANNetworkHandler(c echo.Context) error {
+	var req models.CreateLANNetworkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if podmanService.GetMode() == "rootless" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "macvlan and ipvlan networks need rootful podman",
+		})
+	}
+	if !networkAliasPattern.MatchString(req.Name) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid network name",
+		})
+	}
+	modes, ok := lanNetworkModes[req.Driver]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "driver must be macvlan or ipvlan",
+		})
+	}
+	if req.Mode == "" {
+		req.Mode = modes[0]
+	}
+	validMode := false
+	for _, m := range modes {
+		validMode = validMode || m == req.Mode
+	}
+	if !validMode {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "mode for " + req.Driver + " must be one of " + strings.Join(modes, ", "),
+		})
+	}
+	if req.DHCP && req.Driver != "macvlan" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "DHCP leases need a MAC address per container, which only macvlan gives",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	if podmanBridges(ctx)[req.Interface] {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": req.Interface + " is the bridge of a podman network",
+		})
+	}
+	lan, err := system.LANInterfaceByName(req.Interface)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	create := models.CreateNetworkRequest{
+		Name:      req.Name,
+		Driver:    req.Driver,
+		Interface: lan.Name,
+		Options:   map[string]string{"mode": req.Mode},
+		Labels:    map[string]string{"stardeck.lan": "true"},
+	}
+	if req.DHCP {
+		// Addresses, subnet and gateway all come from the LAN's DHCP server
+		create.IPAMDriver = "dhcp"
+	} else {
+		subnet := lan.Subnet
+		if req.Subnet != "" {
+			subnet = strings.TrimSpace(req.Subnet)
+		}
+		_, ipnet, err := net.ParseCIDR(subnet)
+		if err != nil || ipnet.IP.To4() == nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid subnet, use IPv4 CIDR notation such as 192.168.1.0/24",
+			})
+		}
+		gateway := lan.Gateway
+		if req.Gateway != "" {
+			gateway = strings.TrimSpace(req.Gateway)
+		}
+		if gateway != "" {
+			if ip := net.ParseIP(gateway); ip == nil || !ipnet.Contains(ip) {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "The gateway must be an address inside the subnet",
+				})
+			}
+		}
+		if req.IPRange != "" {
+			_, block, err := net.ParseCIDR(strings.TrimSpace(req.IPRange))
+			if err != nil || !ipnet.Contains(block.IP) {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "ip_range must be a CIDR block inside the subnet",
+				})
+			}
+			create.IPRange = block.String()
+		}
+		create.Subnet, create.Gateway = ipnet.String(), gateway
+	}
+
+	if err := podmanService.CreateNetwork(ctx, &create); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create network: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionNetworkCreate, req.Name, map[string]interface{}{
+		"driver":    req.Driver,
+		"interface": lan.Name,
+		"mode":      req.Mode,
+		"subnet":    create.Subnet,
+		"gateway":   create.Gateway,
+		"ip_range":  create.IPRange,
+		"dhcp":      req.DHCP,
+	})
+
+	detail, err := networkDetail(ctx, req.Name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Network created but could not be inspected: " + err.Error(),
+		})
+	}
+	detail.Warnings = append(detail.Warnings, lanNetworkWarning)
+	if req.DHCP {
+		detail.Warnings = append(detail.Warnings, "DHCP leases need netavark's dhcp-proxy service running on the host")
+	} else if create.IPRange == "" {
+		detail.Warnings = append(detail.Warnings, "Without an ip_range, container addresses may collide with the LAN's DHCP pool")
+	}
+	return c.JSON(http.StatusCreated, detail)
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// activeListeners records what Serve opened at startup
+var activeListeners struct {
+	mu       sync.Mutex
+	settings models.ListenerSettings
+	hosts    []string // Bind hosts of the TCP listeners; "" is every address
+	addrs    []string
+	socket   string
+	errors   []string
+}
+
+// loadListenerSettings reads the listener settings. The port defaults to
+// STARDECK_PORT, then 443.
+func loadListenerSettings() models.ListenerSettings {
+	s := models.ListenerSettings{Addresses: []string{}, Port: 443}
+	if v, err := strconv.Atoi(os.Getenv("STARDECK_PORT")); err == nil && v > 0 && v <= 65535 {
+		s.Port = v
+	}
+	if v, err := settingsRepo.Get(database.SettingListenAddresses); err == nil && v != "" {
+		s.Addresses = strings.Split(v, ",")
+	}
+	if v, err := settingsRepo.GetInt(database.SettingListenPort); err == nil && v > 0 && v <= 65535 {
+		s.Port = v
+	}
+	s.UnixSocket, _ = settingsRepo.Get(database.SettingListenUnixSocket)
+	return s
+}
+
+// listenAddrs returns the TCP addresses to listen on. STARDECK_LISTEN, a
+// comma-separated list of host:port, replaces the settings so a bad bind
+// address can be fixed without database access.
+func listenAddrs(s models.ListenerSettings) []string {
+	var addrs []string
+	if env := os.Getenv("STARDECK_LISTEN"); env != "" {
+		for _, addr := range strings.Split(env, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+		return addrs
+	}
+
+	port := strconv.Itoa(s.Port)
+	if len(s.Addresses) == 0 {
+		return []string{":" + port}
+	}
+	for _, host := range s.Addresses {
+		addrs = append(addrs, net.JoinHostPort(host, port))
+	}
+	return addrs
+}
+
+// ListenPort returns the port of the first listener, which the HTTP redirect
+// sends clients to. It must be called after RegisterRoutes.
+func ListenPort() string {
+	_, port, _ := net.SplitHostPort(listenAddrs(loadListenerSettings())[0])
+	return port
+}
+
+// Serve runs e on the configured listeners: the TCP addresses, with TLS
+// unless tlsConfig is nil, and the Unix socket when one is set. Listeners
+// that fail to open are logged and skipped, so one stale bind address
+// doesn't lock everyone out; it fails only when no TCP listener opens.
+func Serve(e *echo.Echo, tlsConfig *tls.Config) error {
+	settings := loadListenerSettings()
+
+	server := e.Server
+	scheme := "HTTP"
+	if tlsConfig != nil {
+		server = e.TLSServer
+		server.TLSConfig = tlsConfig
+		scheme = "HTTPS"
+	}
+	server.Handler = e
+
+	errs := make(chan error, 1)
+	var hosts, opened, failures []string
+	for _, addr := range listenAddrs(settings) {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			failures = append(failures, err.Error())
+			log.Printf("Failed to listen on %s: %v", addr, err)
+			continue
+		}
+		if tlsConfig != nil {
+			ln = tls.NewListener(ln, tlsConfig)
+		}
+		host, _, _ := net.SplitHostPort(addr)
+		hosts = append(hosts, host)
+		opened = append(opened, addr)
+		go func() { errs <- server.Serve(ln) }()
+	}
+	if len(opened) == 0 {
+		return fmt.Errorf("no listener could be opened: %s", strings.Join(failures, "; "))
+	}
+
+	socket := ""
+	if settings.UnixSocket != "" {
+		ln, err := listenUnix(settings.UnixSocket)
+		if err != nil {
+			failures = append(failures, err.Error())
+			log.Printf("Failed to listen on %s: %v", settings.UnixSocket, err)
+		} else {
+			socket = settings.UnixSocket
+			local := &http.Server{Handler: e, ReadHeaderTimeout: 10 * time.Second}
+			go func() { errs <- local.Serve(ln) }()
+			log.Printf("Serving HTTP on Unix socket %s", socket)
+		}
+	}
+
+	activeListeners.mu.Lock()
+	activeListeners.settings = settings
+	activeListeners.hosts, activeListeners.addrs = hosts, opened
+	activeListeners.socket, activeListeners.errors = socket, failures
+	activeListeners.mu.Unlock()
+
+	log.Printf("Starting Stardeck backend on %s %s", scheme, strings.Join(opened, ", "))
+	return <-errs
+}
+
+// listenUnix opens a Unix socket readable by its owner and group, replacing
+// a stale socket left by an earlier run
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		os.Remove(path)
+	}
+	// Create the socket without world access rather than narrowing it
+	// afterwards, so it is never reachable by other users
+	mask := syscall.Umask(0o117)
+	ln, err := net.Listen("unix", path)
+	syscall.Umask(mask)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0660); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// listenHosts returns the bind hosts of the TCP listeners, which the HTTP
+// redirect listener binds as well. Before Serve runs they come from the
+// settings.
+func listenHosts() []string {
+	activeListeners.mu.Lock()
+	running := activeListeners.hosts
+	activeListeners.mu.Unlock()
+	if running == nil {
+		for _, addr := range listenAddrs(loadListenerSettings()) {
+			host, _, _ := net.SplitHostPort(addr)
+			running = append(running, host)
+		}
+	}
+
+	var hosts []string
+	for _, host := range running {
+		if !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 || slices.Contains(hosts, "") {
+		return []string{""}
+	}
+	return hosts
+}
+
+// listenerStatus reports the saved settings and the running listeners
+func listenerStatus() models.ListenerStatus {
+	status := models.ListenerStatus{
+		Settings:    loadListenerSettings(),
+		EnvOverride: os.Getenv("STARDECK_LISTEN"),
+	}
+	activeListeners.mu.Lock()
+	defer activeListeners.mu.Unlock()
+	status.Active = append([]string{}, activeListeners.addrs...)
+	status.ActiveSocket = activeListeners.socket
+	status.Errors = activeListeners.errors
+
+	started := activeListeners.settings
+	status.RestartRequired = !slices.Equal(status.Settings.Addresses, started.Addresses) ||
+		status.Settings.Port != started.Port ||
+		status.Settings.UnixSocket != started.UnixSocket
+	return status
+}
+
+// getListenersHandler handles GET /api/system/listeners
+func getListenersHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, listenerStatus())
+}
+
+// updateListenersHandler handles PUT /api/system/listeners
+func updateListenersHandler(c echo.Context) error {
+	settings := loadListenerSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	addresses := []string{}
+	for _, a := range settings.Addresses {
+		ip := net.ParseIP(strings.Trim(strings.TrimSpace(a), "[]"))
+		if ip == nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("%q is not an IP address", a),
+			})
+		}
+		if !slices.Contains(addresses, ip.String()) {
+			addresses = append(addresses, ip.String())
+		}
+	}
+	if settings.Port < 1 || settings.Port > 65535 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "port must be between 1 and 65535",
+		})
+	}
+	if tlsSettings := loadTLSSettings(); tlsSettings.HTTPRedirect && tlsSettings.HTTPRedirectPort == settings.Port {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "port must differ from the HTTP redirect port",
+		})
+	}
+	if settings.UnixSocket != "" {
+		if !filepath.IsAbs(settings.UnixSocket) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "unix_socket must be an absolute path",
+			})
+		}
+		if info, err := os.Stat(filepath.Dir(settings.UnixSocket)); err != nil || !info.IsDir() {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "The directory for unix_socket does not exist",
+			})
+		}
+	}
+
+	values := map[string]string{
+		database.SettingListenAddresses:  strings.Join(addresses, ","),
+		database.SettingListenPort:       strconv.Itoa(settings.Port),
+		database.SettingListenUnixSocket: settings.UnixSocket,
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionListenerSettings, "listeners", values)
+
+	return c.JSON(http.StatusOK, listenerStatus())
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestListenUnixSocketMode(t *testing.T) {
+	mask := syscall.Umask(0o022)
+	defer syscall.Umask(mask)
+
+	path := filepath.Join(t.TempDir(), "stardeck.sock")
+	ln, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("listenUnix: %v", err)
+	}
+	defer ln.Close()
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("stat socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0660 {
+		t.Errorf("socket mode = %o, want 660", perm)
+	}
+	if restored := syscall.Umask(0o022); restored != 0o022 {
+		t.Errorf("umask after listenUnix = %o, want 022", restored)
+	}
+}
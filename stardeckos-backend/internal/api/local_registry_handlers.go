@@ -0,0 +1,845 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/certs"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	localRegistryContainer   = "stardeck-registry"
+	localRegistryImage       = "docker.io/library/registry:2"
+	localRegistryBaseDir     = "/var/lib/stardeck/registry"
+	localRegistryConfig      = "/etc/docker/registry/config.yml"
+	defaultLocalRegistryPort = 5000
+)
+
+// localRegistryMu serialises deploy, removal and garbage collection of the registry
+var localRegistryMu sync.Mutex
+
+// InitLocalRegistry starts the registry garbage-collection scheduler
+func InitLocalRegistry() {
+	go runLocalRegistryGCScheduler()
+}
+
+// localRegistryAuthFile is the htpasswd file mounted into the registry container
+func localRegistryAuthFile() string {
+	return filepath.Join(localRegistryBaseDir, "auth", "htpasswd")
+}
+
+// localRegistryCertDir holds the registry's TLS certificate, generated by the certs module
+func localRegistryCertDir() string {
+	return filepath.Join(localRegistryBaseDir, "certs")
+}
+
+// localRegistryAddress is the host:port clients on this machine push to
+func localRegistryAddress(port int) string {
+	return fmt.Sprintf("localhost:%d", port)
+}
+
+// loadLocalRegistrySettings reads the local registry settings, applying defaults for missing values
+func loadLocalRegistrySettings() models.LocalRegistrySettings {
+	s := models.LocalRegistrySettings{
+		StoragePath: filepath.Join(localRegistryBaseDir, "data"),
+		Port:        defaultLocalRegistryPort,
+		GCEnabled:   true,
+		GCWeekday:   0,
+		GCHour:      4,
+	}
+	if v, err := settingsRepo.Get(database.SettingLocalRegistryPath); err == nil && v != "" {
+		s.StoragePath = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingLocalRegistryPort); err == nil && v > 0 {
+		s.Port = v
+	}
+	s.AuthEnabled, _ = settingsRepo.GetBool(database.SettingLocalRegistryAuth)
+	s.Username, _ = settingsRepo.Get(database.SettingLocalRegistryUser)
+	s.TLSEnabled, _ = settingsRepo.GetBool(database.SettingLocalRegistryTLS)
+	s.CredentialID, _ = settingsRepo.Get(database.SettingLocalRegistryCred)
+	if v, err := settingsRepo.GetBool(database.SettingLocalRegistryGC); err == nil {
+		s.GCEnabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingLocalRegistryGCDay); err == nil && v >= -1 && v <= 6 {
+		s.GCWeekday = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingLocalRegistryGCHour); err == nil && v >= 0 && v <= 23 {
+		s.GCHour = v
+	}
+	s.LastGC, _ = settingsRepo.Get(database.SettingLocalRegistryLastGC)
+	return s
+}
+
+// localRegistryStatus inspects the registry container
+func localRegistryStatus(ctx context.Context) models.LocalRegistryStatus {
+	settings := loadLocalRegistrySettings()
+	status := models.LocalRegistryStatus{
+		Image:    localRegistryImage,
+		Settings: settings,
+	}
+
+	inspect, err := podmanService.InspectContainer(ctx, localRegistryContainer)
+	if err != nil {
+		return status
+	}
+	status.Deployed = true
+	status.Running = inspect.State.Running
+	status.State = inspect.State.Status
+	status.Address = localRegistryAddress(settings.Port)
+	if hostname, err := os.Hostname(); err == nil {
+		status.RemoteAddress = fmt.Sprintf("%s:%d", hostname, settings.Port)
+	}
+	return status
+}
+
+// validateRegistryStoragePath cleans the storage path and keeps it out of system directories
+func validateRegistryStoragePath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("storage_path must be absolute")
+	}
+	clean := filepath.Clean(path)
+	if strings.Count(clean, "/") < 2 {
+		return "", fmt.Errorf("storage_path cannot be a top-level directory")
+	}
+	if pathWithin(clean, localRegistryBaseDir) {
+		if pathWithin(clean, localRegistryCertDir()) || pathWithin(clean, filepath.Dir(localRegistryAuthFile())) {
+			return "", fmt.Errorf("storage_path cannot overlap the registry's auth or certificate directories")
+		}
+		return clean, nil
+	}
+	for _, prefix := range bindMountProtectedPrefixes {
+		if pathWithin(clean, prefix) {
+			return "", fmt.Errorf("storage_path cannot be inside %s", prefix)
+		}
+	}
+	return clean, nil
+}
+
+// saveLocalRegistryCredential replaces the saved login Stardeck uses to push to the registry
+func saveLocalRegistryCredential(ctx context.Context, user *models.User, address, username, password string) (string, error) {
+	removeLocalRegistryCredential(ctx)
+
+	cred := &models.RegistryCredential{
+		ID:        uuid.New().String(),
+		Name:      "Local registry",
+		Registry:  address,
+		Username:  username,
+		CreatedBy: &user.ID,
+	}
+	cred.SecretName = registryCredentialSecret(cred.ID)
+
+	if err := podmanService.CreateSecret(ctx, cred.SecretName, password); err != nil {
+		return "", fmt.Errorf("failed to store password: %w", err)
+	}
+	if err := registryCredentialRepo.Create(cred); err != nil {
+		podmanService.RemoveSecret(ctx, cred.SecretName)
+		return "", fmt.Errorf("failed to save credential: %w", err)
+	}
+	return cred.ID, nil
+}
+
+// removeLocalRegistryCredential deletes the saved login for the registry, if any
+func removeLocalRegistryCredential(ctx context.Context) {
+	id, _ := settingsRepo.Get(database.SettingLocalRegistryCred)
+	if id == "" {
+		return
+	}
+	if cred, err := registryCredentialRepo.GetByID(id); err == nil {
+		registryCredentialRepo.Delete(cred.ID)
+		podmanService.RemoveSecret(ctx, cred.SecretName)
+	}
+	settingsRepo.Set(database.SettingLocalRegistryCred, "")
+}
+
+// getLocalRegistryHandler returns the local registry's state and settings
+func getLocalRegistryHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	return c.JSON(http.StatusOK, localRegistryStatus(ctx))
+}
+
+// deployLocalRegistryHandler creates (or recreates) the registry container. Stored
+// images survive a redeploy because they live on the storage path.
+func deployLocalRegistryHandler(c echo.Context) error {
+	current := loadLocalRegistrySettings()
+	req := models.DeployLocalRegistryRequest{
+		StoragePath: current.StoragePath,
+		Port:        current.Port,
+		AuthEnabled: current.AuthEnabled,
+		Username:    current.Username,
+		TLSEnabled:  current.TLSEnabled,
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	storagePath, err := validateRegistryStoragePath(req.StoragePath)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "port must be between 1 and 65535",
+		})
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.AuthEnabled {
+		if req.Username == "" || strings.Contains(req.Username, ":") {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "a username without colons is required when auth is enabled",
+			})
+		}
+		// A new password is needed unless the existing login is being kept as-is
+		keepLogin := current.AuthEnabled && current.Username == req.Username && current.Port == req.Port && current.CredentialID != ""
+		if req.Password == "" && !keepLogin {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "password is required when enabling auth or changing the username or port",
+			})
+		}
+		if req.Password != "" && !system.HtpasswdAvailable() {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "htpasswd is not installed (dnf install httpd-tools)",
+			})
+		}
+	}
+
+	localRegistryMu.Lock()
+	defer localRegistryMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Minute)
+	defer cancel()
+
+	user := c.Get("user").(*models.User)
+	address := localRegistryAddress(req.Port)
+
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create storage path: " + err.Error(),
+		})
+	}
+	if err := podmanService.ChownToTargetUser(storagePath); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to set storage path owner: " + err.Error(),
+		})
+	}
+
+	createReq := &models.CreateContainerRequest{
+		Name:  localRegistryContainer,
+		Image: localRegistryImage,
+		Ports: []models.PortMapping{{HostPort: req.Port, ContainerPort: 5000, Protocol: "tcp"}},
+		Volumes: []models.VolumeMount{
+			{Source: storagePath, Target: "/var/lib/registry", Type: "bind"},
+		},
+		Environment: map[string]string{
+			// Deleting tags only frees space once garbage collection runs
+			"REGISTRY_STORAGE_DELETE_ENABLED": "true",
+		},
+		Labels:        map[string]string{"stardeck.system": "registry"},
+		RestartPolicy: "always",
+	}
+
+	credentialID := current.CredentialID
+	if req.AuthEnabled {
+		if req.Password != "" {
+			authFile := localRegistryAuthFile()
+			if err := system.WriteHtpasswd(ctx, authFile, req.Username, req.Password); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to write htpasswd: " + err.Error(),
+				})
+			}
+			podmanService.ChownToTargetUser(filepath.Dir(authFile), authFile)
+
+			if credentialID, err = saveLocalRegistryCredential(ctx, user, address, req.Username, req.Password); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": err.Error(),
+				})
+			}
+		}
+		createReq.Volumes = append(createReq.Volumes, models.VolumeMount{
+			Source: filepath.Dir(localRegistryAuthFile()), Target: "/auth", ReadOnly: true, Type: "bind",
+		})
+		createReq.Environment["REGISTRY_AUTH"] = "htpasswd"
+		createReq.Environment["REGISTRY_AUTH_HTPASSWD_REALM"] = "Stardeck Registry"
+		createReq.Environment["REGISTRY_AUTH_HTPASSWD_PATH"] = "/auth/htpasswd"
+	} else {
+		removeLocalRegistryCredential(ctx)
+		credentialID = ""
+	}
+
+	// Clients on this host trust the registry through /etc/containers/certs.d, keyed by address
+	system.UntrustRegistryCA(localRegistryAddress(current.Port))
+	if req.TLSEnabled {
+		certPath, keyPath, err := certs.EnsureCertificates(localRegistryCertDir())
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to create certificate: " + err.Error(),
+			})
+		}
+		podmanService.ChownToTargetUser(localRegistryCertDir(), certPath, keyPath)
+		if err := system.TrustRegistryCA(address, certPath); err != nil {
+			c.Logger().Warnf("Failed to trust registry certificate: %v", err)
+		}
+		createReq.Volumes = append(createReq.Volumes, models.VolumeMount{
+			Source: localRegistryCertDir(), Target: "/certs", ReadOnly: true, Type: "bind",
+		})
+		createReq.Environment["REGISTRY_HTTP_TLS_CERTIFICATE"] = "/certs/" + filepath.Base(certPath)
+		createReq.Environment["REGISTRY_HTTP_TLS_KEY"] = "/certs/" + filepath.Base(keyPath)
+	}
+
+	if !podmanService.ImageExists(ctx, localRegistryImage) {
+		if err := podmanService.PullImage(ctx, localRegistryImage); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to pull registry image: " + err.Error(),
+			})
+		}
+	}
+
+	if exists, _ := podmanService.ContainerExists(ctx, localRegistryContainer); exists {
+		if err := podmanService.RemoveContainer(ctx, localRegistryContainer, true); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to remove existing registry: " + err.Error(),
+			})
+		}
+	}
+
+	if _, err := podmanService.CreateContainer(ctx, createReq); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create registry container: " + err.Error(),
+		})
+	}
+	if err := podmanService.StartContainer(ctx, localRegistryContainer); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to start registry: " + err.Error(),
+		})
+	}
+
+	values := map[string]string{
+		database.SettingLocalRegistryPath: storagePath,
+		database.SettingLocalRegistryPort: strconv.Itoa(req.Port),
+		database.SettingLocalRegistryAuth: strconv.FormatBool(req.AuthEnabled),
+		database.SettingLocalRegistryUser: req.Username,
+		database.SettingLocalRegistryTLS:  strconv.FormatBool(req.TLSEnabled),
+		database.SettingLocalRegistryCred: credentialID,
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save registry settings: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionLocalRegistryDeploy, localRegistryContainer, map[string]interface{}{
+		"storage_path": storagePath,
+		"port":         req.Port,
+		"auth_enabled": req.AuthEnabled,
+		"tls_enabled":  req.TLSEnabled,
+	})
+
+	return c.JSON(http.StatusOK, localRegistryStatus(ctx))
+}
+
+// removeLocalRegistryHandler removes the registry container. Stored images are kept
+// unless ?purge=true.
+func removeLocalRegistryHandler(c echo.Context) error {
+	localRegistryMu.Lock()
+	defer localRegistryMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	settings := loadLocalRegistrySettings()
+	purge := c.QueryParam("purge") == "true"
+
+	if exists, _ := podmanService.ContainerExists(ctx, localRegistryContainer); exists {
+		if err := podmanService.RemoveContainer(ctx, localRegistryContainer, true); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to remove registry: " + err.Error(),
+			})
+		}
+	}
+
+	system.UntrustRegistryCA(localRegistryAddress(settings.Port))
+	removeLocalRegistryCredential(ctx)
+	settingsRepo.Set(database.SettingLocalRegistryAuth, "false")
+	os.Remove(localRegistryAuthFile())
+
+	if purge {
+		if err := system.DeletePath(settings.StoragePath, true); err != nil && !os.IsNotExist(err) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Registry removed but failed to delete storage: " + err.Error(),
+			})
+		}
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionLocalRegistryRemove, localRegistryContainer, map[string]interface{}{
+		"storage_path": settings.StoragePath,
+		"purged":       purge,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "removed",
+	})
+}
+
+// updateLocalRegistryScheduleHandler changes when garbage collection runs
+func updateLocalRegistryScheduleHandler(c echo.Context) error {
+	var req models.UpdateLocalRegistryScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.GCWeekday < -1 || req.GCWeekday > 6 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "gc_weekday must be between 0 (Sunday) and 6, or -1 for daily",
+		})
+	}
+	if req.GCHour < 0 || req.GCHour > 23 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "gc_hour must be between 0 and 23",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingLocalRegistryGC:     strconv.FormatBool(req.GCEnabled),
+		database.SettingLocalRegistryGCDay:  strconv.Itoa(req.GCWeekday),
+		database.SettingLocalRegistryGCHour: strconv.Itoa(req.GCHour),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save schedule: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionLocalRegistrySchedule, localRegistryContainer, values)
+
+	return c.JSON(http.StatusOK, loadLocalRegistrySettings())
+}
+
+// garbageCollectLocalRegistryHandler runs garbage collection now
+func garbageCollectLocalRegistryHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Minute)
+	defer cancel()
+
+	result, err := garbageCollectLocalRegistry(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Garbage collection failed: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionLocalRegistryGC, localRegistryContainer, map[string]interface{}{
+		"bytes_freed": result.BytesBefore - result.BytesAfter,
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// garbageCollectLocalRegistry stops the registry, removes unreferenced blobs and
+// untagged manifests with a one-off container, then starts it again. The registry
+// must not accept pushes while blobs are being swept.
+func garbageCollectLocalRegistry(ctx context.Context) (*models.GarbageCollectResult, error) {
+	localRegistryMu.Lock()
+	defer localRegistryMu.Unlock()
+
+	if exists, _ := podmanService.ContainerExists(ctx, localRegistryContainer); !exists {
+		return nil, fmt.Errorf("local registry is not deployed")
+	}
+
+	settings := loadLocalRegistrySettings()
+	result := &models.GarbageCollectResult{
+		RanAt:       time.Now().Format(time.RFC3339),
+		BytesBefore: system.DirectorySize(settings.StoragePath),
+	}
+
+	if err := podmanService.StopContainer(ctx, localRegistryContainer, 30); err != nil {
+		return nil, fmt.Errorf("failed to stop registry: %w", err)
+	}
+
+	output, gcErr := podmanService.RunRemoved(ctx, localRegistryImage,
+		[]models.VolumeMount{{Source: settings.StoragePath, Target: "/var/lib/registry"}},
+		"garbage-collect", "--delete-untagged", localRegistryConfig)
+
+	// Bring the registry back whether or not the sweep succeeded
+	if err := podmanService.StartContainer(context.Background(), localRegistryContainer); err != nil {
+		return nil, fmt.Errorf("failed to restart registry: %w", err)
+	}
+	if gcErr != nil {
+		return nil, gcErr
+	}
+
+	result.Output = output
+	result.BytesAfter = system.DirectorySize(settings.StoragePath)
+	settingsRepo.Set(database.SettingLocalRegistryLastGC, result.RanAt)
+	return result, nil
+}
+
+// runLocalRegistryGCScheduler checks every few minutes whether the garbage-collection window has arrived
+func runLocalRegistryGCScheduler() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		settings := loadLocalRegistrySettings()
+		if !settings.GCEnabled || maintenanceModeActive() {
+			continue
+		}
+
+		now := time.Now()
+		today := now.Format("2006-01-02")
+		if now.Hour() != settings.GCHour || (settings.GCWeekday >= 0 && int(now.Weekday()) != settings.GCWeekday) {
+			continue
+		}
+		if len(settings.LastGC) >= 10 && settings.LastGC[:10] == today {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		if exists, _ := podmanService.ContainerExists(ctx, localRegistryContainer); !exists {
+			cancel()
+			continue
+		}
+		result, err := garbageCollectLocalRegistry(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: local registry garbage collection failed: %v", err)
+			continue
+		}
+		log.Printf("Local registry garbage collection: %d -> %d bytes", result.BytesBefore, result.BytesAfter)
+	}
+}
+
+// localRegistryClient returns an HTTP client and base URL for the registry API,
+// trusting the registry's own certificate when TLS is on
+func localRegistryClient(settings models.LocalRegistrySettings) (*http.Client, string, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if !settings.TLSEnabled {
+		return client, "http://" + localRegistryAddress(settings.Port), nil
+	}
+
+	pem, err := os.ReadFile(filepath.Join(localRegistryCertDir(), "server.crt"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read registry certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(pem)
+	client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	return client, "https://" + localRegistryAddress(settings.Port), nil
+}
+
+// localRegistryRequest calls the registry's HTTP API with the saved login
+func localRegistryRequest(ctx context.Context, method, path string, header http.Header) (*http.Response, error) {
+	settings := loadLocalRegistrySettings()
+	client, base, err := localRegistryClient(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if settings.AuthEnabled {
+		auth, err := resolveRegistryAuth(ctx, &settings.CredentialID)
+		if err != nil {
+			return nil, err
+		}
+		if auth != nil {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+	}
+	return client.Do(req)
+}
+
+// listLocalRegistryRepositoriesHandler lists repositories and tags stored in the registry
+func listLocalRegistryRepositoriesHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	resp, err := localRegistryRequest(ctx, http.MethodGet, "/v2/_catalog?n=1000", nil)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to reach registry: " + err.Error(),
+		})
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Registry returned " + resp.Status,
+		})
+	}
+
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to parse catalog: " + err.Error(),
+		})
+	}
+
+	repositories := make([]models.LocalRegistryRepository, 0, len(catalog.Repositories))
+	for _, name := range catalog.Repositories {
+		repo := models.LocalRegistryRepository{Name: name, Tags: []string{}}
+		if tagResp, err := localRegistryRequest(ctx, http.MethodGet, "/v2/"+name+"/tags/list", nil); err == nil {
+			var tags struct {
+				Tags []string `json:"tags"`
+			}
+			if tagResp.StatusCode == http.StatusOK && json.NewDecoder(tagResp.Body).Decode(&tags) == nil && tags.Tags != nil {
+				sort.Strings(tags.Tags)
+				repo.Tags = tags.Tags
+			}
+			tagResp.Body.Close()
+		}
+		// Repositories whose tags were all deleted linger in the catalog until GC
+		if len(repo.Tags) > 0 {
+			repositories = append(repositories, repo)
+		}
+	}
+
+	return c.JSON(http.StatusOK, repositories)
+}
+
+// deleteLocalRegistryTagHandler deletes ?repository=&tag= from the registry. The
+// space is reclaimed by the next garbage collection.
+func deleteLocalRegistryTagHandler(c echo.Context) error {
+	repository := c.QueryParam("repository")
+	tag := c.QueryParam("tag")
+	if !imageReferencePattern.MatchString(repository) || tag == "" || strings.ContainsAny(tag, "/:@") {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "repository and tag are required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	// Manifests are deleted by digest, so resolve the tag first
+	header := http.Header{}
+	header.Set("Accept", strings.Join([]string{
+		"application/vnd.oci.image.index.v1+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+	}, ", "))
+	resp, err := localRegistryRequest(ctx, http.MethodHead, "/v2/"+repository+"/manifests/"+url.PathEscape(tag), header)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to reach registry: " + err.Error(),
+		})
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Tag not found",
+		})
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if resp.StatusCode != http.StatusOK || digest == "" {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Registry returned " + resp.Status,
+		})
+	}
+
+	resp, err = localRegistryRequest(ctx, http.MethodDelete, "/v2/"+repository+"/manifests/"+digest, nil)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Failed to reach registry: " + err.Error(),
+		})
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Registry returned " + resp.Status,
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionLocalRegistryDelete, repository+":"+tag, map[string]interface{}{
+		"digest": digest,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+		"digest": digest,
+	})
+}
+
+// stripRegistryHost drops a leading registry host from an image reference so
+// docker.io/library/nginx:latest is stored as library/nginx:latest
+func stripRegistryHost(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[1]
+	}
+	return image
+}
+
+// localRegistryTransferHandler pushes a local image to the registry or pulls one
+// from it over a WebSocket, streaming skopeo progress. The first message is a
+// LocalRegistryTransferRequest.
+func localRegistryTransferHandler(c echo.Context) error {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	_, message, err := ws.ReadMessage()
+	if err != nil {
+		return nil
+	}
+
+	var req models.LocalRegistryTransferRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  tr(c, "error.invalid_request", "error", err.Error()),
+		})
+		return nil
+	}
+
+	if !system.SkopeoAvailable() {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "skopeo is not installed (dnf install skopeo)",
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), imagePromoteTimeout)
+	defer cancel()
+
+	if status := localRegistryStatus(ctx); !status.Running {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "local registry is not running",
+		})
+		return nil
+	}
+
+	req.Image = strings.TrimSpace(req.Image)
+	req.Target = strings.TrimSpace(req.Target)
+	if req.Target == "" {
+		req.Target = stripRegistryHost(req.Image)
+	}
+	if !imageReferencePattern.MatchString(req.Image) || !imageReferencePattern.MatchString(req.Target) {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "image must be a reference such as myapp:latest",
+		})
+		return nil
+	}
+
+	settings := loadLocalRegistrySettings()
+	address := localRegistryAddress(settings.Port)
+	auth, err := resolveRegistryAuth(ctx, &settings.CredentialID)
+	if err != nil {
+		ws.WriteJSON(map[string]interface{}{"status": "error", "error": err.Error()})
+		return nil
+	}
+
+	opts := models.ImageCopyOptions{
+		SrcTLSVerify:  true,
+		DestTLSVerify: true,
+	}
+	var action, ref string
+	switch req.Direction {
+	case "push":
+		ref = address + "/" + req.Target
+		opts.Source = "containers-storage:" + req.Image
+		opts.Destination = "docker://" + ref
+		opts.DestTLSVerify = settings.TLSEnabled
+		opts.DestAuth = auth
+		action = models.ActionLocalRegistryPush
+	case "pull":
+		ref = address + "/" + stripRegistryHost(req.Image)
+		opts.Source = "docker://" + ref
+		opts.Destination = "containers-storage:" + ref
+		opts.SrcTLSVerify = settings.TLSEnabled
+		opts.SourceAuth = auth
+		action = models.ActionLocalRegistryPull
+	default:
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "direction must be push or pull",
+		})
+		return nil
+	}
+
+	ws.WriteJSON(map[string]interface{}{
+		"status":  "copying",
+		"message": fmt.Sprintf("Copying %s to %s", opts.Source, opts.Destination),
+	})
+
+	outputChan := make(chan string, 100)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- podmanService.CopyImage(ctx, opts, outputChan)
+	}()
+
+	for line := range outputChan {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "copying",
+			"output": line,
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	details := map[string]interface{}{
+		"image":     req.Image,
+		"reference": ref,
+	}
+
+	if err := <-errChan; err != nil {
+		details["error"] = err.Error()
+		logAudit(user, action, ref, details)
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "Failed to " + req.Direction + " image: " + err.Error(),
+		})
+		return nil
+	}
+
+	logAudit(user, action, ref, details)
+	ws.WriteJSON(map[string]interface{}{
+		"status":    "complete",
+		"message":   "Image " + req.Direction + "ed successfully",
+		"reference": ref,
+	})
+	return nil
+}
@@ -0,0 +1,537 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var logRuleRepo *database.LogRuleRepo
+
+const (
+	defaultLogRuleCooldown = 300
+	minLogRuleCooldown     = 10
+	maxLogRuleCooldown     = 24 * 60 * 60
+	maxLogRulePattern      = 512
+	maxLogRuleMatchLength  = 1000 // Longer matched lines are truncated before storing or sending
+	logWatcherSyncInterval = 30 * time.Second
+)
+
+// compiledLogRule is a rule with its regex compiled and in-memory cooldown state
+type compiledLogRule struct {
+	rule      models.LogRule
+	re        *regexp.Regexp
+	lastFired time.Time
+}
+
+// logWatcher follows one container's log stream and evaluates its rules
+type logWatcher struct {
+	podmanID string
+	name     string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu    sync.Mutex
+	rules []*compiledLogRule
+}
+
+// logWatchers is keyed by Stardeck container ID
+var (
+	logWatchers   = make(map[string]*logWatcher)
+	logWatchersMu sync.Mutex
+)
+
+// InitLogRuleEngine initializes the log rule repository and starts watching container logs
+func InitLogRuleEngine() {
+	logRuleRepo = database.NewLogRuleRepo()
+	go func() {
+		for {
+			syncLogWatchers()
+			time.Sleep(logWatcherSyncInterval)
+		}
+	}()
+}
+
+// syncLogWatchers starts, updates, or stops log watchers to match enabled rules and running containers
+func syncLogWatchers() {
+	rules, err := logRuleRepo.ListEnabled()
+	if err != nil {
+		log.Printf("Warning: failed to load log rules: %v", err)
+		return
+	}
+
+	byContainer := make(map[string][]models.LogRule)
+	for _, rule := range rules {
+		byContainer[rule.ContainerID] = append(byContainer[rule.ContainerID], rule)
+	}
+
+	running := make(map[string]bool)
+	if len(byContainer) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		live, err := podmanService.ListContainers(ctx)
+		cancel()
+		if err != nil {
+			return
+		}
+		for _, lc := range live {
+			running[lc.ContainerID] = lc.Status == models.ContainerStatusRunning
+		}
+	}
+
+	logWatchersMu.Lock()
+	defer logWatchersMu.Unlock()
+
+	for id, containerRules := range byContainer {
+		container, err := containerRepo.GetByID(id)
+		if err != nil || !running[container.ContainerID] {
+			continue
+		}
+
+		w, ok := logWatchers[id]
+		if ok && w.podmanID == container.ContainerID && !w.finished() {
+			w.setRules(containerRules)
+			continue
+		}
+		if ok {
+			w.cancel()
+		}
+
+		w = newLogWatcher(container)
+		w.setRules(containerRules)
+		logWatchers[id] = w
+		go w.run()
+	}
+
+	// Stop watchers whose rules were removed or whose container stopped
+	for id, w := range logWatchers {
+		if _, ok := byContainer[id]; !ok || !running[w.podmanID] {
+			w.cancel()
+			delete(logWatchers, id)
+		}
+	}
+}
+
+func newLogWatcher(container *models.Container) *logWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &logWatcher{
+		podmanID: container.ContainerID,
+		name:     container.Name,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// finished reports whether the watcher's log stream has ended
+func (w *logWatcher) finished() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// setRules replaces the watcher's rules, keeping cooldown state for rules that are unchanged
+func (w *logWatcher) setRules(rules []models.LogRule) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	previous := make(map[string]*compiledLogRule)
+	for _, cr := range w.rules {
+		previous[cr.rule.ID] = cr
+	}
+
+	compiled := make([]*compiledLogRule, 0, len(rules))
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		cr := &compiledLogRule{rule: rule, re: re}
+		if prev, ok := previous[rule.ID]; ok {
+			cr.lastFired = prev.lastFired
+		} else if rule.LastTriggeredAt != nil {
+			cr.lastFired = *rule.LastTriggeredAt
+		}
+		compiled = append(compiled, cr)
+	}
+	w.rules = compiled
+}
+
+// run follows new log lines until the stream ends or the watcher is cancelled
+func (w *logWatcher) run() {
+	defer close(w.done)
+
+	logChan := make(chan models.ContainerLog, 100)
+	go func() {
+		podmanService.StreamLogs(w.ctx, w.podmanID, -1, logChan)
+		close(logChan)
+	}()
+
+	for entry := range logChan {
+		w.evaluate(entry)
+	}
+}
+
+// evaluate matches a log line against the watcher's rules and fires those outside their cooldown
+func (w *logWatcher) evaluate(entry models.ContainerLog) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for _, cr := range w.rules {
+		if cr.rule.Stream != "" && cr.rule.Stream != entry.Stream {
+			continue
+		}
+		if now.Sub(cr.lastFired) < time.Duration(cr.rule.CooldownSeconds)*time.Second {
+			continue
+		}
+		if !cr.re.MatchString(entry.Message) {
+			continue
+		}
+		cr.lastFired = now
+		go fireLogRule(cr.rule, w.name, w.podmanID, entry)
+	}
+}
+
+// fireLogRule performs a rule's action and records the trigger
+func fireLogRule(rule models.LogRule, containerName, podmanID string, entry models.ContainerLog) {
+	line := entry.Message
+	if len(line) > maxLogRuleMatchLength {
+		line = line[:maxLogRuleMatchLength]
+	}
+
+	if err := logRuleRepo.RecordTrigger(rule.ID, time.Now(), line); err != nil {
+		log.Printf("Warning: failed to record log rule trigger for %s: %v", rule.Name, err)
+	}
+
+	var actionErr error
+	switch rule.Action {
+	case models.LogRuleActionRestart:
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		actionErr = podmanService.RestartContainer(ctx, podmanID, 10)
+		cancel()
+		// The log stream ends with the old process; pick the container back up right away
+		go syncLogWatchers()
+	case models.LogRuleActionWebhook:
+		actionErr = sendLogRuleWebhook(rule, containerName, entry.Stream, line, entry.Timestamp)
+	}
+
+	details := map[string]interface{}{
+		"rule_id":      rule.ID,
+		"rule":         rule.Name,
+		"container_id": rule.ContainerID,
+		"action":       rule.Action,
+		"line":         line,
+	}
+	if actionErr != nil {
+		log.Printf("Log rule %s action %s failed: %v", rule.Name, rule.Action, actionErr)
+		details["error"] = actionErr.Error()
+	}
+	Audit.Log(0, "system", models.ActionLogRuleTriggered, containerName, details, "")
+
+	level := models.NotificationWarning
+	if actionErr != nil {
+		level = models.NotificationError
+	}
+	notifyRoles(localized(models.Notification{
+		Type:  models.NotificationAlertFired,
+		Level: level,
+		Data:  details,
+	}, "notification.log_rule_fired", "rule", rule.Name, "container", containerName, "line", line), models.RoleAdmin, models.RoleOperator)
+}
+
+// sendLogRuleWebhook POSTs a matched line to the rule's webhook URL
+func sendLogRuleWebhook(rule models.LogRule, containerName, stream, line string, ts time.Time) error {
+	body, err := json.Marshal(models.LogRuleWebhookPayload{
+		Rule:      rule.Name,
+		RuleID:    rule.ID,
+		Container: containerName,
+		Stream:    stream,
+		Line:      line,
+		Timestamp: ts,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(rule.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// validateLogRule checks a rule's pattern, action, stream, and cooldown
+func validateLogRule(rule *models.LogRule) error {
+	if rule.Name == "" || rule.Pattern == "" {
+		return fmt.Errorf("name and pattern are required")
+	}
+	if len(rule.Pattern) > maxLogRulePattern {
+		return fmt.Errorf("pattern must be at most %d characters", maxLogRulePattern)
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+	if rule.Stream != "" && rule.Stream != "stdout" && rule.Stream != "stderr" {
+		return fmt.Errorf("stream must be stdout, stderr, or empty")
+	}
+	switch rule.Action {
+	case models.LogRuleActionNotify, models.LogRuleActionRestart:
+	case models.LogRuleActionWebhook:
+		u, err := url.Parse(rule.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook_url must be an http or https URL")
+		}
+	default:
+		return fmt.Errorf("action must be notify, restart, or webhook")
+	}
+	if rule.CooldownSeconds < minLogRuleCooldown || rule.CooldownSeconds > maxLogRuleCooldown {
+		return fmt.Errorf("cooldown_seconds must be between %d and %d", minLogRuleCooldown, maxLogRuleCooldown)
+	}
+	return nil
+}
+
+// getLogRuleForContainer loads a rule and checks it belongs to the container in the path
+func getLogRuleForContainer(c echo.Context) (*models.LogRule, error) {
+	rule, err := logRuleRepo.GetByID(c.Param("rule_id"))
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Log rule not found",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get log rule: " + err.Error(),
+		})
+	}
+
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil || container.ID != rule.ContainerID {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Log rule not found",
+		})
+	}
+	return rule, nil
+}
+
+// listLogRulesHandler lists the log rules for a container
+func listLogRulesHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	rules, err := logRuleRepo.ListByContainer(container.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list log rules: " + err.Error(),
+		})
+	}
+
+	if rules == nil {
+		rules = []models.LogRule{}
+	}
+
+	return c.JSON(http.StatusOK, rules)
+}
+
+// createLogRuleHandler creates a log rule for a container
+func createLogRuleHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.CreateLogRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	rule := &models.LogRule{
+		ContainerID:     container.ID,
+		Name:            req.Name,
+		Pattern:         req.Pattern,
+		Stream:          req.Stream,
+		Action:          req.Action,
+		WebhookURL:      req.WebhookURL,
+		CooldownSeconds: req.CooldownSeconds,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		CreatedBy:       &user.ID,
+	}
+	if rule.CooldownSeconds == 0 {
+		rule.CooldownSeconds = defaultLogRuleCooldown
+	}
+
+	if err := validateLogRule(rule); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := logRuleRepo.Create(rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create log rule: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionLogRuleCreate, rule.Name, map[string]interface{}{
+		"container": container.Name,
+		"pattern":   rule.Pattern,
+		"action":    rule.Action,
+	})
+
+	go syncLogWatchers()
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// updateLogRuleHandler updates a log rule
+func updateLogRuleHandler(c echo.Context) error {
+	rule, err := getLogRuleForContainer(c)
+	if rule == nil {
+		return err
+	}
+
+	var req models.UpdateLogRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Pattern != nil {
+		rule.Pattern = *req.Pattern
+	}
+	if req.Stream != nil {
+		rule.Stream = *req.Stream
+	}
+	if req.Action != nil {
+		rule.Action = *req.Action
+	}
+	if req.WebhookURL != nil {
+		rule.WebhookURL = *req.WebhookURL
+	}
+	if req.CooldownSeconds != nil {
+		rule.CooldownSeconds = *req.CooldownSeconds
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+
+	if err := validateLogRule(rule); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := logRuleRepo.Update(rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update log rule: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionLogRuleUpdate, rule.Name, nil)
+
+	go syncLogWatchers()
+	return c.JSON(http.StatusOK, rule)
+}
+
+// deleteLogRuleHandler deletes a log rule
+func deleteLogRuleHandler(c echo.Context) error {
+	rule, err := getLogRuleForContainer(c)
+	if rule == nil {
+		return err
+	}
+
+	if err := logRuleRepo.Delete(rule.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete log rule: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionLogRuleDelete, rule.Name, nil)
+
+	go syncLogWatchers()
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
+
+// testLogRuleHandler runs a pattern against the container's recent logs without triggering anything
+func testLogRuleHandler(c echo.Context) error {
+	var req struct {
+		Pattern string `json:"pattern"`
+		Tail    int    `json:"tail,omitempty"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	re, err := regexp.Compile(req.Pattern)
+	if err != nil || req.Pattern == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid pattern",
+		})
+	}
+	if req.Tail <= 0 || req.Tail > 5000 {
+		req.Tail = 500
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	lines, err := podmanService.GetLogs(ctx, resolveContainerID(c.Param("id")), strconv.Itoa(req.Tail), false)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get logs: " + err.Error(),
+		})
+	}
+
+	matches := []string{}
+	for _, line := range lines {
+		if re.MatchString(line) {
+			matches = append(matches, line)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"scanned": len(lines),
+		"matches": matches,
+	})
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// maintenanceMu prevents scheduled and on-demand maintenance from overlapping
+var maintenanceMu sync.Mutex
+
+// InitMaintenance starts the nightly database maintenance loop
+func InitMaintenance() {
+	go runNightlyMaintenance()
+}
+
+// loadMaintenanceSettings reads maintenance settings, applying defaults for missing values
+func loadMaintenanceSettings() models.MaintenanceSettings {
+	s := models.MaintenanceSettings{
+		NightlyEnabled:        true,
+		Hour:                  3,
+		MetricsRetentionHours: 168,
+		AuditRetentionDays:    90,
+		Vacuum:                true,
+	}
+	if v, err := settingsRepo.GetBool(database.SettingMaintenanceEnabled); err == nil {
+		s.NightlyEnabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingMaintenanceHour); err == nil && v >= 0 && v <= 23 {
+		s.Hour = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingMetricsRetention); err == nil && v > 0 {
+		s.MetricsRetentionHours = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingAuditRetention); err == nil && v > 0 {
+		s.AuditRetentionDays = v
+	}
+	if v, err := settingsRepo.GetBool(database.SettingMaintenanceVacuum); err == nil {
+		s.Vacuum = v
+	}
+	s.LastRun, _ = settingsRepo.Get(database.SettingMaintenanceLastRun)
+	return s
+}
+
+// runNightlyMaintenance checks every few minutes whether the nightly window has arrived
+func runNightlyMaintenance() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		settings := loadMaintenanceSettings()
+		if !settings.NightlyEnabled || maintenanceModeActive() {
+			continue
+		}
+
+		now := time.Now()
+		today := now.Format("2006-01-02")
+		if now.Hour() != settings.Hour || (len(settings.LastRun) >= 10 && settings.LastRun[:10] == today) {
+			continue
+		}
+
+		result, err := runMaintenance(settings.MetricsRetentionHours, settings.AuditRetentionDays, settings.Vacuum)
+		if err != nil {
+			log.Printf("Warning: nightly database maintenance failed: %v", err)
+			continue
+		}
+		log.Printf("Nightly database maintenance: removed %d metrics and %d audit entries, %d -> %d bytes",
+			result.MetricsDeleted, result.AuditDeleted, result.SizeBefore, result.SizeAfter)
+	}
+}
+
+// runMaintenance prunes old metrics and audit entries and optionally vacuums
+func runMaintenance(metricsRetentionHours, auditRetentionDays int, vacuum bool) (*models.MaintenanceResult, error) {
+	maintenanceMu.Lock()
+	defer maintenanceMu.Unlock()
+
+	result := &models.MaintenanceResult{RanAt: time.Now()}
+	result.SizeBefore, _ = database.FileSize()
+
+	var err error
+	if metricsRetentionHours > 0 {
+		if result.MetricsDeleted, err = metricsRepo.Cleanup(metricsRetentionHours); err != nil {
+			return nil, err
+		}
+		hostDeleted, err := hostMetricsRepo.Cleanup(metricsRetentionHours)
+		if err != nil {
+			return nil, err
+		}
+		result.MetricsDeleted += hostDeleted
+	}
+	// Volume usage history is kept for its own window, since growth rates
+	// need more than the raw metrics retention
+	usageDeleted, err := pruneVolumeUsageSamples()
+	if err != nil {
+		return nil, err
+	}
+	result.MetricsDeleted += usageDeleted
+	if auditRetentionDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -auditRetentionDays)
+		if result.AuditDeleted, err = auditRepo.DeleteOlderThan(cutoff); err != nil {
+			return nil, err
+		}
+	}
+
+	if vacuum {
+		if err := database.Vacuum(); err != nil {
+			return nil, err
+		}
+		result.Vacuumed = true
+	}
+	database.Optimize()
+
+	result.SizeAfter, _ = database.FileSize()
+	settingsRepo.Set(database.SettingMaintenanceLastRun, result.RanAt.Format(time.RFC3339))
+
+	return result, nil
+}
+
+// getDatabaseStatsHandler reports database size per table
+func getDatabaseStatsHandler(c echo.Context) error {
+	stats, err := database.Stats()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get database stats: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// getDatabaseConnectionsHandler reports connection pool use, write queuing
+// and busy errors without the cost of counting every table
+func getDatabaseConnectionsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, database.ConnectionStats())
+}
+
+// integrityCheckHandler runs an SQLite integrity check
+func integrityCheckHandler(c echo.Context) error {
+	quick := c.QueryParam("quick") == "true"
+
+	maintenanceMu.Lock()
+	result, err := database.IntegrityCheck(quick)
+	maintenanceMu.Unlock()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to run integrity check: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDBMaintenanceIntegrity, "database", map[string]interface{}{
+		"quick":    quick,
+		"ok":       result.OK,
+		"problems": len(result.Problems),
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// vacuumDatabaseHandler runs VACUUM to reclaim free space
+func vacuumDatabaseHandler(c echo.Context) error {
+	maintenanceMu.Lock()
+	before, _ := database.FileSize()
+	err := database.Vacuum()
+	after, _ := database.FileSize()
+	maintenanceMu.Unlock()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to vacuum database: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDBMaintenanceVacuum, "database", map[string]interface{}{
+		"size_before": before,
+		"size_after":  after,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":      "vacuumed",
+		"size_before": before,
+		"size_after":  after,
+	})
+}
+
+// compactDatabaseHandler prunes metrics and audit history on demand
+func compactDatabaseHandler(c echo.Context) error {
+	settings := loadMaintenanceSettings()
+	req := models.CompactRequest{
+		MetricsRetentionHours: settings.MetricsRetentionHours,
+		AuditRetentionDays:    settings.AuditRetentionDays,
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.MetricsRetentionHours < 0 || req.AuditRetentionDays < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Retention values cannot be negative",
+		})
+	}
+
+	result, err := runMaintenance(req.MetricsRetentionHours, req.AuditRetentionDays, req.Vacuum)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to compact database: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDBMaintenanceCompact, "database", map[string]interface{}{
+		"metrics_deleted": result.MetricsDeleted,
+		"audit_deleted":   result.AuditDeleted,
+		"vacuumed":        result.Vacuumed,
+	})
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// getMaintenanceSettingsHandler returns the nightly maintenance configuration
+func getMaintenanceSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadMaintenanceSettings())
+}
+
+// updateMaintenanceSettingsHandler updates the nightly maintenance configuration
+func updateMaintenanceSettingsHandler(c echo.Context) error {
+	settings := loadMaintenanceSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if settings.Hour < 0 || settings.Hour > 23 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "hour must be between 0 and 23",
+		})
+	}
+	if settings.MetricsRetentionHours <= 0 || settings.AuditRetentionDays <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Retention values must be positive",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingMaintenanceEnabled: strconv.FormatBool(settings.NightlyEnabled),
+		database.SettingMaintenanceHour:    strconv.Itoa(settings.Hour),
+		database.SettingMetricsRetention:   strconv.Itoa(settings.MetricsRetentionHours),
+		database.SettingAuditRetention:     strconv.Itoa(settings.AuditRetentionDays),
+		database.SettingMaintenanceVacuum:  strconv.FormatBool(settings.Vacuum),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionDBMaintenanceSettings, "database", values)
+
+	return c.JSON(http.StatusOK, loadMaintenanceSettings())
+}
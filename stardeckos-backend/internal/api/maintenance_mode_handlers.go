@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// loadMaintenanceMode reads the maintenance mode state
+func loadMaintenanceMode() models.MaintenanceMode {
+	var m models.MaintenanceMode
+	if v, err := settingsRepo.GetBool(database.SettingMaintenanceMode); err == nil {
+		m.Enabled = v
+	}
+	if !m.Enabled {
+		return m
+	}
+	m.Message, _ = settingsRepo.Get(database.SettingMaintenanceMessage)
+	m.BlockLogins, _ = settingsRepo.GetBool(database.SettingMaintenanceBlock)
+	m.StartedAt, _ = settingsRepo.Get(database.SettingMaintenanceStarted)
+	m.StartedBy, _ = settingsRepo.Get(database.SettingMaintenanceBy)
+	m.ExpectedEnd, _ = settingsRepo.Get(database.SettingMaintenanceEnd)
+	return m
+}
+
+// maintenanceModeActive reports whether background schedulers should stand down
+func maintenanceModeActive() bool {
+	enabled, err := settingsRepo.GetBool(database.SettingMaintenanceMode)
+	return err == nil && enabled
+}
+
+// maintenanceBlocksLogin reports whether a freshly authenticated user must be turned away
+func maintenanceBlocksLogin(user *models.User) bool {
+	if user.IsAdmin() {
+		return false
+	}
+	m := loadMaintenanceMode()
+	return m.Enabled && m.BlockLogins
+}
+
+// getMaintenanceModeHandler returns the current maintenance mode state
+func getMaintenanceModeHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadMaintenanceMode())
+}
+
+// updateMaintenanceModeHandler turns maintenance mode on or off
+func updateMaintenanceModeHandler(c echo.Context) error {
+	var req models.MaintenanceMode
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.Message = strings.TrimSpace(req.Message)
+	if len(req.Message) > maxBannerLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "message is too long",
+		})
+	}
+	if req.ExpectedEnd != "" {
+		if _, err := time.Parse(time.RFC3339, req.ExpectedEnd); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "expected_end must be an RFC 3339 timestamp",
+			})
+		}
+	}
+
+	user := c.Get("user").(*models.User)
+	current := loadMaintenanceMode()
+
+	values := map[string]string{
+		database.SettingMaintenanceMode:    strconv.FormatBool(req.Enabled),
+		database.SettingMaintenanceMessage: req.Message,
+		database.SettingMaintenanceBlock:   strconv.FormatBool(req.BlockLogins),
+		database.SettingMaintenanceEnd:     req.ExpectedEnd,
+	}
+	// Keep the original start when only the message or end time changes
+	if req.Enabled && !current.Enabled {
+		values[database.SettingMaintenanceStarted] = time.Now().Format(time.RFC3339)
+		values[database.SettingMaintenanceBy] = user.Username
+	} else if !req.Enabled {
+		values[database.SettingMaintenanceStarted] = ""
+		values[database.SettingMaintenanceBy] = ""
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save maintenance mode: " + err.Error(),
+		})
+	}
+
+	updated := loadMaintenanceMode()
+
+	if req.Enabled != current.Enabled {
+		action := models.ActionMaintenanceModeOff
+		title := "Maintenance finished"
+		level := models.NotificationSuccess
+		if req.Enabled {
+			action = models.ActionMaintenanceModeOn
+			title = "Maintenance mode"
+			level = models.NotificationWarning
+		}
+		logAudit(user, action, "system", map[string]interface{}{
+			"message":      req.Message,
+			"block_logins": req.BlockLogins,
+		})
+		notifyRoles(models.Notification{
+			Type:    models.NotificationMaintenanceMode,
+			Level:   level,
+			Title:   title,
+			Message: req.Message,
+			Data: map[string]interface{}{
+				"maintenance": updated,
+			},
+		}, models.RoleAdmin, models.RoleOperator, models.RoleViewer)
+	}
+
+	return c.JSON(http.StatusOK, updated)
+}
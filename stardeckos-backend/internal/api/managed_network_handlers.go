@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// networkAliasPattern matches a single DNS label usable as a container alias
+var networkAliasPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// proxyTargetTTL is how long a resolved DNS proxy target is reused before re-inspecting
+const proxyTargetTTL = 30 * time.Second
+
+type proxyTarget struct {
+	host    string
+	port    int
+	expires time.Time
+}
+
+var (
+	proxyTargetCache   = make(map[string]proxyTarget)
+	proxyTargetCacheMu sync.Mutex
+)
+
+// managedNetworkName returns the name of the Stardeck-managed DNS network
+func managedNetworkName() string {
+	name, err := settingsRepo.Get(database.SettingManagedNetwork)
+	if err != nil || name == "" {
+		return "stardeck"
+	}
+	return name
+}
+
+// ensureManagedNetwork creates the managed network if needed and returns its name
+func ensureManagedNetwork(ctx context.Context) (string, error) {
+	name := managedNetworkName()
+	if err := podmanService.EnsureNetwork(ctx, name, map[string]string{"stardeck.managed": "true"}); err != nil {
+		return "", fmt.Errorf("failed to create managed network %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// validateNetworkAliases normalises aliases and rejects ones that are not valid DNS labels
+func validateNetworkAliases(aliases []string) ([]string, error) {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" || seen[a] {
+			continue
+		}
+		if !networkAliasPattern.MatchString(a) {
+			return nil, fmt.Errorf("invalid network alias %q: must be a DNS label (a-z, 0-9, -)", a)
+		}
+		seen[a] = true
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// prepareContainerNetwork applies the managed network option and validates aliases on a create request
+func prepareContainerNetwork(ctx context.Context, req *models.CreateContainerRequest) error {
+	aliases, err := validateNetworkAliases(req.NetworkAliases)
+	if err != nil {
+		return err
+	}
+	req.NetworkAliases = aliases
+
+	if req.UseManagedNetwork {
+		name, err := ensureManagedNetwork(ctx)
+		if err != nil {
+			return err
+		}
+		req.NetworkMode = name
+	}
+
+	// Aliases are only resolvable on user-defined networks with DNS enabled
+	if len(req.NetworkAliases) > 0 {
+		switch {
+		case req.NetworkMode == "", req.NetworkMode == "bridge", req.NetworkMode == "podman",
+			req.NetworkMode == "host", req.NetworkMode == "none",
+			strings.HasPrefix(req.NetworkMode, "container:"):
+			return fmt.Errorf("network aliases require a user-defined network, enable use_managed_network")
+		}
+	}
+
+	return nil
+}
+
+// resolveProxyTarget returns the host and port the web UI proxy should connect to
+func resolveProxyTarget(ctx context.Context, container *models.Container) (string, int) {
+	mode, _ := settingsRepo.Get(database.SettingProxyTargetMode)
+	if mode != models.ProxyTargetDNS {
+		return "localhost", container.WebUIPort
+	}
+
+	proxyTargetCacheMu.Lock()
+	cached, ok := proxyTargetCache[container.ContainerID]
+	proxyTargetCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.host, cached.port
+	}
+
+	config, err := podmanService.GetContainerConfig(ctx, container.ContainerID)
+	if err != nil {
+		return "localhost", container.WebUIPort
+	}
+	ip, _, err := podmanService.GetContainerNetwork(ctx, container.ContainerID, managedNetworkName())
+	if err != nil || ip == "" {
+		return "localhost", container.WebUIPort
+	}
+
+	// The stored web UI port is the published host port; map it back to the container port
+	port := container.WebUIPort
+	for _, pm := range config.Ports {
+		if pm.HostPort == container.WebUIPort {
+			port = pm.ContainerPort
+			break
+		}
+	}
+
+	proxyTargetCacheMu.Lock()
+	proxyTargetCache[container.ContainerID] = proxyTarget{host: ip, port: port, expires: time.Now().Add(proxyTargetTTL)}
+	proxyTargetCacheMu.Unlock()
+
+	return ip, port
+}
+
+// getManagedNetworkHandler returns the managed network and the containers attached to it
+func getManagedNetworkHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	mode, _ := settingsRepo.Get(database.SettingProxyTargetMode)
+	if mode == "" {
+		mode = models.ProxyTargetLocalhost
+	}
+
+	name := managedNetworkName()
+	info := models.ManagedNetworkInfo{
+		Name:            name,
+		Exists:          podmanService.NetworkExists(ctx, name),
+		ProxyTargetMode: mode,
+		Members:         []models.ManagedNetworkMember{},
+	}
+
+	if info.Exists {
+		containers, err := containerRepo.List()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": tr(c, "error.list_containers", "error", err.Error()),
+			})
+		}
+		for _, dc := range containers {
+			ip, aliases, err := podmanService.GetContainerNetwork(ctx, dc.ContainerID, name)
+			if err != nil {
+				continue
+			}
+			info.Members = append(info.Members, models.ManagedNetworkMember{
+				ID:          dc.ID,
+				ContainerID: dc.ContainerID,
+				Name:        dc.Name,
+				IPAddress:   ip,
+				Aliases:     aliases,
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, info)
+}
+
+// updateManagedNetworkHandler changes the managed network name or how the web UI proxy targets containers
+func updateManagedNetworkHandler(c echo.Context) error {
+	var req models.UpdateManagedNetworkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	values := make(map[string]interface{})
+	if req.Name != nil {
+		if !networkAliasPattern.MatchString(*req.Name) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid network name",
+			})
+		}
+		if err := settingsRepo.Set(database.SettingManagedNetwork, *req.Name); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": tr(c, "error.save_settings", "error", err.Error()),
+			})
+		}
+		values["name"] = *req.Name
+	}
+	if req.ProxyTargetMode != nil {
+		if *req.ProxyTargetMode != models.ProxyTargetLocalhost && *req.ProxyTargetMode != models.ProxyTargetDNS {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "proxy_target_mode must be localhost or dns",
+			})
+		}
+		if err := settingsRepo.Set(database.SettingProxyTargetMode, *req.ProxyTargetMode); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": tr(c, "error.save_settings", "error", err.Error()),
+			})
+		}
+		values["proxy_target_mode"] = *req.ProxyTargetMode
+	}
+
+	proxyTargetCacheMu.Lock()
+	proxyTargetCache = make(map[string]proxyTarget)
+	proxyTargetCacheMu.Unlock()
+
+	Audit.LogFromContext(c, models.ActionManagedNetworkSettings, "network", values)
+
+	return getManagedNetworkHandler(c)
+}
+
+// setContainerAliasesHandler attaches an existing container to the managed network with the given aliases
+func setContainerAliasesHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.SetContainerAliasesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	aliases, err := validateNetworkAliases(req.Aliases)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
+	defer cancel()
+
+	name, err := ensureManagedNetwork(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// Podman cannot change aliases in place, so reconnect to replace them
+	if _, _, err := podmanService.GetContainerNetwork(ctx, container.ContainerID, name); err == nil {
+		if err := podmanService.DisconnectNetwork(ctx, name, container.ContainerID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to disconnect from managed network: " + err.Error(),
+			})
+		}
+	}
+	if err := podmanService.ConnectNetwork(ctx, name, container.ContainerID, aliases); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to connect to managed network: " + err.Error(),
+		})
+	}
+
+	proxyTargetCacheMu.Lock()
+	delete(proxyTargetCache, container.ContainerID)
+	proxyTargetCacheMu.Unlock()
+
+	ip, current, _ := podmanService.GetContainerNetwork(ctx, container.ContainerID, name)
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionNetworkAliases, container.Name, map[string]interface{}{
+		"network": name,
+		"aliases": aliases,
+	})
+
+	return c.JSON(http.StatusOK, models.ManagedNetworkMember{
+		ID:          container.ID,
+		ContainerID: container.ContainerID,
+		Name:        container.Name,
+		IPAddress:   ip,
+		Aliases:     current,
+	})
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	oomGuardInterval    = time.Second
+	maxRecentOOMKills   = 20
+	defaultOOMGuardSkip = `^(systemd|systemd-.*|sshd|dbus-.*|conmon|stardeck.*)$`
+)
+
+var validZRAMAlgorithms = map[string]bool{"zstd": true, "lz4": true, "lzo-rle": true, "lzo": true}
+
+// oomGuard holds the running guard's configuration and recent activity
+var oomGuard struct {
+	mu       sync.Mutex
+	settings models.MemoryProtectionSettings
+	avoid    *regexp.Regexp
+	kills    []models.OOMGuardKill
+	savedAdj *int // Backend oom_score_adj before the guard lowered it
+}
+
+// InitMemoryProtection loads memory protection settings and starts the OOM guard
+func InitMemoryProtection() {
+	applyOOMGuardSettings(loadMemoryProtectionSettings())
+	go runOOMGuard()
+}
+
+// loadMemoryProtectionSettings reads memory protection settings, applying defaults for missing values
+func loadMemoryProtectionSettings() models.MemoryProtectionSettings {
+	s := models.MemoryProtectionSettings{
+		ZRAMSizePercent:     50,
+		ZRAMMaxMB:           8192,
+		ZRAMAlgorithm:       "zstd",
+		OOMGuardMemPercent:  5,
+		OOMGuardSwapPercent: 10,
+		OOMGuardAvoid:       defaultOOMGuardSkip,
+	}
+	if v, err := settingsRepo.GetBool(database.SettingZRAMEnabled); err == nil {
+		s.ZRAMEnabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingZRAMSizePercent); err == nil && v > 0 {
+		s.ZRAMSizePercent = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingZRAMMaxMB); err == nil && v > 0 {
+		s.ZRAMMaxMB = v
+	}
+	if v, err := settingsRepo.Get(database.SettingZRAMAlgorithm); err == nil && validZRAMAlgorithms[v] {
+		s.ZRAMAlgorithm = v
+	}
+	if v, err := settingsRepo.GetBool(database.SettingOOMGuardEnabled); err == nil {
+		s.OOMGuardEnabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingOOMGuardMemPercent); err == nil && v > 0 {
+		s.OOMGuardMemPercent = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingOOMGuardSwapPercent); err == nil && v >= 0 {
+		s.OOMGuardSwapPercent = v
+	}
+	if v, err := settingsRepo.Get(database.SettingOOMGuardAvoid); err == nil {
+		s.OOMGuardAvoid = v
+	}
+	return s
+}
+
+// applyOOMGuardSettings reconfigures the running guard. While enabled the
+// backend exempts itself from the kernel OOM killer as well.
+func applyOOMGuardSettings(s models.MemoryProtectionSettings) {
+	avoid, err := regexp.Compile(s.OOMGuardAvoid)
+	if err != nil {
+		avoid = regexp.MustCompile(defaultOOMGuardSkip)
+	}
+
+	oomGuard.mu.Lock()
+	defer oomGuard.mu.Unlock()
+	oomGuard.settings = s
+	oomGuard.avoid = avoid
+
+	pid := os.Getpid()
+	if s.OOMGuardEnabled && oomGuard.savedAdj == nil {
+		if adj, err := system.OOMScoreAdj(pid); err == nil {
+			if err := system.SetOOMScoreAdj(pid, -1000); err != nil {
+				log.Printf("Warning: failed to exempt backend from the OOM killer: %v", err)
+				return
+			}
+			oomGuard.savedAdj = &adj
+		}
+	} else if !s.OOMGuardEnabled && oomGuard.savedAdj != nil {
+		system.SetOOMScoreAdj(pid, *oomGuard.savedAdj)
+		oomGuard.savedAdj = nil
+	}
+}
+
+// runOOMGuard checks memory pressure every second and frees memory before the kernel has to.
+// It keeps running in maintenance mode: it protects the host rather than changing it on a schedule.
+func runOOMGuard() {
+	ticker := time.NewTicker(oomGuardInterval)
+	defer ticker.Stop()
+
+	protected := map[int]bool{os.Getpid(): true, os.Getppid(): true}
+	for range ticker.C {
+		oomGuard.mu.Lock()
+		s, avoid := oomGuard.settings, oomGuard.avoid
+		oomGuard.mu.Unlock()
+		if !s.OOMGuardEnabled {
+			continue
+		}
+
+		memPct, swapPct, err := system.MemoryPressure()
+		if err != nil || memPct > float64(s.OOMGuardMemPercent) || swapPct > float64(s.OOMGuardSwapPercent) {
+			continue
+		}
+
+		victim, err := system.SelectOOMVictim(protected, avoid)
+		if err != nil {
+			log.Printf("Warning: OOM guard found memory low (%.1f%% available) but %v", memPct, err)
+			continue
+		}
+
+		sig, sigName := syscall.SIGTERM, "SIGTERM"
+		if memPct <= float64(s.OOMGuardMemPercent)/2 {
+			sig, sigName = syscall.SIGKILL, "SIGKILL"
+		}
+		if err := system.KillProcess(victim.PID, sig); err != nil {
+			log.Printf("Warning: OOM guard failed to signal %s (%d): %v", victim.Name, victim.PID, err)
+			continue
+		}
+		recordOOMGuardKill(models.OOMGuardKill{
+			PID:                 victim.PID,
+			Name:                victim.Name,
+			OOMScore:            victim.OOMScore,
+			RSS:                 victim.RSS,
+			Signal:              sigName,
+			MemAvailablePercent: memPct,
+			SwapFreePercent:     swapPct,
+			At:                  time.Now(),
+		})
+	}
+}
+
+// recordOOMGuardKill keeps a kill in the recent list, audits it and alerts administrators
+func recordOOMGuardKill(kill models.OOMGuardKill) {
+	oomGuard.mu.Lock()
+	oomGuard.kills = append(oomGuard.kills, kill)
+	if len(oomGuard.kills) > maxRecentOOMKills {
+		oomGuard.kills = oomGuard.kills[len(oomGuard.kills)-maxRecentOOMKills:]
+	}
+	oomGuard.mu.Unlock()
+
+	log.Printf("OOM guard sent %s to %s (%d) with %.1f%% memory available", kill.Signal, kill.Name, kill.PID, kill.MemAvailablePercent)
+	Audit.Log(0, "system", models.ActionOOMGuardKill, kill.Name, kill, "")
+	notifyRoles(localized(models.Notification{
+		Type:  models.NotificationAlertFired,
+		Level: models.NotificationError,
+		Data:  map[string]interface{}{"pid": kill.PID, "name": kill.Name, "signal": kill.Signal},
+	}, "notification.oom_guard_kill",
+		"signal", kill.Signal,
+		"process", kill.Name,
+		"pid", strconv.Itoa(kill.PID),
+		"rss", humanBytes(kill.RSS),
+		"available", strconv.FormatFloat(kill.MemAvailablePercent, 'f', 1, 64),
+	), models.RoleAdmin)
+}
+
+// getMemoryProtectionHandler returns memory protection settings with zram and OOM guard status
+func getMemoryProtectionHandler(c echo.Context) error {
+	status := models.OOMGuardStatus{ProtectedPID: os.Getpid()}
+	status.MemAvailablePercent, status.SwapFreePercent, _ = system.MemoryPressure()
+	status.OOMScoreAdj, _ = system.OOMScoreAdj(status.ProtectedPID)
+
+	oomGuard.mu.Lock()
+	status.Running = oomGuard.settings.OOMGuardEnabled
+	status.RecentKills = append([]models.OOMGuardKill{}, oomGuard.kills...)
+	oomGuard.mu.Unlock()
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"settings":  loadMemoryProtectionSettings(),
+		"zram":      system.GetZRAMStatus(),
+		"oom_guard": status,
+	})
+}
+
+// updateMemoryProtectionHandler updates zram and OOM guard settings and applies them
+func updateMemoryProtectionHandler(c echo.Context) error {
+	previous := loadMemoryProtectionSettings()
+	settings := previous
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if settings.ZRAMSizePercent < 10 || settings.ZRAMSizePercent > 200 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "zram_size_percent must be between 10 and 200",
+		})
+	}
+	if settings.ZRAMMaxMB < 256 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "zram_max_mb must be at least 256",
+		})
+	}
+	if !validZRAMAlgorithms[settings.ZRAMAlgorithm] {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "zram_algorithm must be one of zstd, lz4, lzo-rle or lzo",
+		})
+	}
+	if settings.OOMGuardMemPercent < 1 || settings.OOMGuardMemPercent > 50 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "oom_guard_mem_percent must be between 1 and 50",
+		})
+	}
+	if settings.OOMGuardSwapPercent < 0 || settings.OOMGuardSwapPercent > 100 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "oom_guard_swap_percent must be between 0 and 100",
+		})
+	}
+	if _, err := regexp.Compile(settings.OOMGuardAvoid); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid oom_guard_avoid pattern: " + err.Error(),
+		})
+	}
+
+	zramChanged := settings.ZRAMEnabled != previous.ZRAMEnabled ||
+		settings.ZRAMSizePercent != previous.ZRAMSizePercent ||
+		settings.ZRAMMaxMB != previous.ZRAMMaxMB ||
+		settings.ZRAMAlgorithm != previous.ZRAMAlgorithm
+	if zramChanged {
+		if err := system.ConfigureZRAM(settings.ZRAMEnabled, settings.ZRAMSizePercent, settings.ZRAMMaxMB, settings.ZRAMAlgorithm); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to configure zram: " + err.Error(),
+			})
+		}
+	}
+
+	values := map[string]string{
+		database.SettingZRAMEnabled:         strconv.FormatBool(settings.ZRAMEnabled),
+		database.SettingZRAMSizePercent:     strconv.Itoa(settings.ZRAMSizePercent),
+		database.SettingZRAMMaxMB:           strconv.Itoa(settings.ZRAMMaxMB),
+		database.SettingZRAMAlgorithm:       settings.ZRAMAlgorithm,
+		database.SettingOOMGuardEnabled:     strconv.FormatBool(settings.OOMGuardEnabled),
+		database.SettingOOMGuardMemPercent:  strconv.Itoa(settings.OOMGuardMemPercent),
+		database.SettingOOMGuardSwapPercent: strconv.Itoa(settings.OOMGuardSwapPercent),
+		database.SettingOOMGuardAvoid:       settings.OOMGuardAvoid,
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save memory protection settings: " + err.Error(),
+		})
+	}
+	applyOOMGuardSettings(settings)
+
+	Audit.LogFromContext(c, models.ActionMemoryProtection, "memory", settings)
+
+	return getMemoryProtectionHandler(c)
+}
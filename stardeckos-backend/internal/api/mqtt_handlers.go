@@ -0,0 +1,630 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// mqttPasswordSecret names the Podman secret holding the MQTT broker password
+const mqttPasswordSecret = "stardeck-mqtt-password"
+
+// MQTT defaults
+const (
+	defaultMQTTDiscoveryPrefix = "homeassistant"
+	defaultMQTTInterval        = 60
+)
+
+// mqttBridge holds the running bridge; restartMQTTBridge replaces it when
+// the settings change
+var mqttBridge struct {
+	mu        sync.Mutex
+	cancel    context.CancelFunc
+	status    models.MQTTStatus
+	announced map[string]bool // Container object IDs with discovery configs
+}
+
+// mqttRefresh asks the bridge to publish container state now rather than
+// at the next interval
+var mqttRefresh = make(chan struct{}, 1)
+
+// mqttHostSensors are the host values announced as Home Assistant sensors
+var mqttHostSensors = []struct {
+	key, name, unit, icon string
+}{
+	{"cpu_percent", "CPU usage", "%", "mdi:cpu-64-bit"},
+	{"memory_percent", "Memory usage", "%", "mdi:memory"},
+	{"disk_percent", "Disk usage", "%", "mdi:harddisk"},
+	{"load_1", "Load average", "", "mdi:gauge"},
+	{"containers_running", "Containers running", "", "mdi:docker"},
+}
+
+// InitMQTT starts the MQTT bridge if it is enabled
+func InitMQTT() {
+	restartMQTTBridge()
+}
+
+// mqttSlug turns a name into a Home Assistant object ID
+func mqttSlug(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// mqttHostSlug identifies this host in topics and entity IDs
+func mqttHostSlug() string {
+	hostname, _ := os.Hostname()
+	if slug := mqttSlug(hostname); slug != "" {
+		return slug
+	}
+	return "stardeck"
+}
+
+// loadMQTTSettings reads the MQTT settings, filling in per-host defaults
+func loadMQTTSettings() models.MQTTSettings {
+	host := mqttHostSlug()
+	s := models.MQTTSettings{
+		ClientID:          "stardeck-" + host,
+		TopicPrefix:       "stardeck/" + host,
+		DiscoveryPrefix:   defaultMQTTDiscoveryPrefix,
+		PublishInterval:   defaultMQTTInterval,
+		CommandContainers: []string{},
+	}
+	s.Enabled, _ = settingsRepo.GetBool(database.SettingMQTTEnabled)
+	s.BrokerURL, _ = settingsRepo.Get(database.SettingMQTTBrokerURL)
+	s.Username, _ = settingsRepo.Get(database.SettingMQTTUsername)
+	if v, err := settingsRepo.Get(database.SettingMQTTPasswordSecret); err == nil && v != "" {
+		s.PasswordSet = true
+	}
+	if v, err := settingsRepo.Get(database.SettingMQTTClientID); err == nil && v != "" {
+		s.ClientID = v
+	}
+	if v, err := settingsRepo.Get(database.SettingMQTTTopicPrefix); err == nil && v != "" {
+		s.TopicPrefix = v
+	}
+	if v, err := settingsRepo.Get(database.SettingMQTTDiscoveryPrefix); err == nil && v != "" {
+		s.DiscoveryPrefix = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingMQTTInterval); err == nil && v > 0 {
+		s.PublishInterval = v
+	}
+	s.CommandsEnabled, _ = settingsRepo.GetBool(database.SettingMQTTCommands)
+	if v, err := settingsRepo.Get(database.SettingMQTTCommandACL); err == nil && v != "" {
+		s.CommandContainers = strings.Split(v, ",")
+	}
+	return s
+}
+
+// mqttCommandAllowed reports whether a container may be started and stopped over MQTT
+func mqttCommandAllowed(s models.MQTTSettings, name string) bool {
+	return s.CommandsEnabled && (slices.Contains(s.CommandContainers, "*") || slices.Contains(s.CommandContainers, name))
+}
+
+// restartMQTTBridge stops the running bridge and starts one with the saved settings
+func restartMQTTBridge() {
+	mqttBridge.mu.Lock()
+	defer mqttBridge.mu.Unlock()
+
+	if mqttBridge.cancel != nil {
+		mqttBridge.cancel()
+		mqttBridge.cancel = nil
+	}
+	s := loadMQTTSettings()
+	mqttBridge.status = models.MQTTStatus{Enabled: s.Enabled, Broker: s.BrokerURL}
+	mqttBridge.announced = make(map[string]bool)
+	if !s.Enabled || s.BrokerURL == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mqttBridge.cancel = cancel
+	go runMQTTBridge(ctx, s)
+}
+
+// updateMQTTStatus changes the bridge status unless the bridge was replaced
+func updateMQTTStatus(ctx context.Context, update func(*models.MQTTStatus)) {
+	mqttBridge.mu.Lock()
+	defer mqttBridge.mu.Unlock()
+	if ctx.Err() == nil {
+		update(&mqttBridge.status)
+	}
+}
+
+// runMQTTBridge keeps a broker session open, reconnecting with backoff
+func runMQTTBridge(ctx context.Context, s models.MQTTSettings) {
+	backoff := 5 * time.Second
+	for {
+		started := time.Now()
+		err := runMQTTSession(ctx, s)
+		if ctx.Err() != nil {
+			return
+		}
+		updateMQTTStatus(ctx, func(st *models.MQTTStatus) {
+			st.Connected = false
+			st.ConnectedAt = nil
+			st.LastError = err.Error()
+		})
+		if time.Since(started) > time.Minute {
+			backoff = 5 * time.Second
+		}
+		log.Printf("Warning: MQTT bridge disconnected (%v), retrying in %s", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 5*time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+// runMQTTSession announces Stardeck to Home Assistant and publishes state
+// until the connection drops or the bridge is stopped
+func runMQTTSession(ctx context.Context, s models.MQTTSettings) error {
+	cfg := system.MQTTConfig{
+		BrokerURL: s.BrokerURL,
+		Username:  s.Username,
+		ClientID:  s.ClientID,
+		Will:      &system.MQTTMessage{Topic: s.TopicPrefix + "/status", Payload: []byte("offline"), Retain: true},
+	}
+	if secret, _ := settingsRepo.Get(database.SettingMQTTPasswordSecret); secret != "" {
+		password, err := podmanService.SecretValue(ctx, secret)
+		if err != nil {
+			return fmt.Errorf("failed to read MQTT password: %w", err)
+		}
+		cfg.Password = password
+	}
+
+	messages := make(chan system.MQTTMessage, 16)
+	client, err := system.DialMQTT(ctx, cfg, func(msg system.MQTTMessage) {
+		select {
+		case messages <- msg:
+		default:
+			log.Printf("Warning: MQTT bridge busy, dropping message on %s", msg.Topic)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	now := time.Now()
+	updateMQTTStatus(ctx, func(st *models.MQTTStatus) {
+		st.Connected = true
+		st.ConnectedAt = &now
+		st.LastError = ""
+	})
+
+	// Home Assistant announces itself on <discovery>/status when it restarts
+	filters := []string{s.DiscoveryPrefix + "/status"}
+	if s.CommandsEnabled {
+		filters = append(filters, s.TopicPrefix+"/container/+/set")
+	}
+	if err := client.Subscribe(filters...); err != nil {
+		return err
+	}
+	if err := client.Publish(s.TopicPrefix+"/status", []byte("online"), true); err != nil {
+		return err
+	}
+	if err := publishMQTTState(ctx, client, s, true); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(time.Duration(s.PublishInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			client.Publish(s.TopicPrefix+"/status", []byte("offline"), true)
+			return nil
+		case <-client.Done():
+			return client.Err()
+		case <-ticker.C:
+			err = publishMQTTState(ctx, client, s, false)
+		case <-mqttRefresh:
+			err = publishMQTTState(ctx, client, s, false)
+		case msg := <-messages:
+			if msg.Topic != s.DiscoveryPrefix+"/status" {
+				handleMQTTCommand(ctx, s, msg)
+			} else if string(msg.Payload) == "online" {
+				err = publishMQTTState(ctx, client, s, true)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// mqttDevice is the Home Assistant device every Stardeck entity belongs to
+func mqttDevice() map[string]interface{} {
+	profile := loadHostProfile()
+	device := map[string]interface{}{
+		"identifiers":  []string{"stardeck_" + mqttHostSlug()},
+		"name":         profile.DisplayName(),
+		"manufacturer": "Stardeck",
+		"model":        "Stardeck OS",
+	}
+	if profile.Location != "" {
+		device["suggested_area"] = profile.Location
+	}
+	return device
+}
+
+// publishMQTTJSON publishes a retained JSON payload
+func publishMQTTJSON(client *system.MQTTClient, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return client.Publish(topic, data, true)
+}
+
+// publishMQTTState publishes host and container state. Discovery configs are
+// republished when announce is set or the set of containers changed; configs
+// of removed containers are cleared so Home Assistant drops the entities.
+func publishMQTTState(ctx context.Context, client *system.MQTTClient, s models.MQTTSettings, announce bool) error {
+	listCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	containers, err := podmanService.ListContainers(listCtx)
+	if err != nil {
+		// Podman hiccups shouldn't drop the broker connection
+		log.Printf("Warning: MQTT bridge failed to list containers: %v", err)
+		return nil
+	}
+
+	host := "stardeck_" + mqttHostSlug()
+	availability := s.TopicPrefix + "/status"
+	hostTopic := s.TopicPrefix + "/host/state"
+
+	current := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		current[mqttSlug(container.Name)] = true
+	}
+	mqttBridge.mu.Lock()
+	announced := mqttBridge.announced
+	mqttBridge.mu.Unlock()
+	if !announce {
+		for slug := range current {
+			if !announced[slug] {
+				announce = true
+			}
+		}
+		for slug := range announced {
+			if !current[slug] {
+				announce = true
+			}
+		}
+	}
+
+	if announce {
+		for _, sensor := range mqttHostSensors {
+			config := map[string]interface{}{
+				"name":               sensor.name,
+				"unique_id":          host + "_" + sensor.key,
+				"state_topic":        hostTopic,
+				"value_template":     "{{ value_json." + sensor.key + " }}",
+				"availability_topic": availability,
+				"state_class":        "measurement",
+				"icon":               sensor.icon,
+				"device":             mqttDevice(),
+			}
+			if sensor.unit != "" {
+				config["unit_of_measurement"] = sensor.unit
+			}
+			if err := publishMQTTJSON(client, s.DiscoveryPrefix+"/sensor/"+host+"/"+sensor.key+"/config", config); err != nil {
+				return err
+			}
+		}
+
+		for _, container := range containers {
+			slug := mqttSlug(container.Name)
+			base := s.TopicPrefix + "/container/" + slug
+			config := map[string]interface{}{
+				"name":                  container.Name,
+				"unique_id":             host + "_container_" + slug,
+				"state_topic":           base + "/state",
+				"json_attributes_topic": base + "/attributes",
+				"availability_topic":    availability,
+				"payload_on":            "ON",
+				"payload_off":           "OFF",
+				"icon":                  "mdi:docker",
+				"device":                mqttDevice(),
+			}
+			// Controllable containers are switches; the rest only report whether they run
+			component, other := "binary_sensor", "switch"
+			if mqttCommandAllowed(s, container.Name) {
+				component, other = "switch", "binary_sensor"
+				config["command_topic"] = base + "/set"
+			} else {
+				config["device_class"] = "running"
+			}
+			if err := publishMQTTJSON(client, s.DiscoveryPrefix+"/"+component+"/"+host+"/container_"+slug+"/config", config); err != nil {
+				return err
+			}
+			if err := client.Publish(s.DiscoveryPrefix+"/"+other+"/"+host+"/container_"+slug+"/config", nil, true); err != nil {
+				return err
+			}
+		}
+
+		for slug := range announced {
+			if current[slug] {
+				continue
+			}
+			for _, component := range []string{"switch", "binary_sensor"} {
+				if err := client.Publish(s.DiscoveryPrefix+"/"+component+"/"+host+"/container_"+slug+"/config", nil, true); err != nil {
+					return err
+				}
+			}
+		}
+		mqttBridge.mu.Lock()
+		if ctx.Err() == nil {
+			mqttBridge.announced = current
+		}
+		mqttBridge.mu.Unlock()
+	}
+
+	running := 0
+	for _, container := range containers {
+		slug := mqttSlug(container.Name)
+		base := s.TopicPrefix + "/container/" + slug
+		state := "OFF"
+		if container.Status == models.ContainerStatusRunning {
+			state = "ON"
+			running++
+		}
+		if err := client.Publish(base+"/state", []byte(state), true); err != nil {
+			return err
+		}
+		if err := publishMQTTJSON(client, base+"/attributes", map[string]interface{}{
+			"status": container.Status,
+			"image":  container.Image,
+			"uptime": container.Uptime,
+			"stack":  container.Stack,
+		}); err != nil {
+			return err
+		}
+	}
+
+	state := map[string]interface{}{
+		"containers_running": running,
+		"containers_total":   len(containers),
+	}
+	if res, err := system.GetResources(); err == nil {
+		state["cpu_percent"] = round1(res.CPU.UsagePercent)
+		state["load_1"] = res.LoadAvg.Load1
+		state["uptime"] = res.Uptime
+		if res.Memory.Total > 0 {
+			state["memory_percent"] = round1(float64(res.Memory.Used) / float64(res.Memory.Total) * 100)
+		}
+		if res.Disk.Total > 0 {
+			state["disk_percent"] = round1(float64(res.Disk.Used) / float64(res.Disk.Total) * 100)
+		}
+	}
+	if err := publishMQTTJSON(client, hostTopic, state); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updateMQTTStatus(ctx, func(st *models.MQTTStatus) {
+		st.LastPublishAt = &now
+		st.Containers = len(containers)
+	})
+	return nil
+}
+
+// round1 rounds to one decimal place for display in Home Assistant
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// handleMQTTCommand starts or stops a container from a message on
+// <prefix>/container/<name>/set. Containers outside the command list are
+// refused, whatever the broker's own ACLs allow.
+func handleMQTTCommand(ctx context.Context, s models.MQTTSettings, msg system.MQTTMessage) {
+	slug, ok := strings.CutPrefix(msg.Topic, s.TopicPrefix+"/container/")
+	if !ok {
+		return
+	}
+	slug = strings.TrimSuffix(slug, "/set")
+	if msg.Retain {
+		// A retained command would replay every time the bridge reconnects
+		log.Printf("Warning: ignoring retained MQTT command on %s", msg.Topic)
+		return
+	}
+
+	var action string
+	switch strings.ToUpper(strings.TrimSpace(string(msg.Payload))) {
+	case "ON", "START":
+		action = models.ScheduleActionStart
+	case "OFF", "STOP":
+		action = models.ScheduleActionStop
+	default:
+		log.Printf("Warning: ignoring MQTT command %q on %s", msg.Payload, msg.Topic)
+		return
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	containers, err := podmanService.ListContainers(cmdCtx)
+	if err != nil {
+		log.Printf("Warning: MQTT command on %s failed: %v", msg.Topic, err)
+		return
+	}
+	var target *models.ContainerListItem
+	for i := range containers {
+		if mqttSlug(containers[i].Name) == slug {
+			target = &containers[i]
+			break
+		}
+	}
+	if target == nil || !mqttCommandAllowed(s, target.Name) {
+		log.Printf("Warning: refusing MQTT command on %s: container is not in the command list", msg.Topic)
+		return
+	}
+
+	if action == models.ScheduleActionStart {
+		if err = podmanService.StartContainer(cmdCtx, target.ContainerID); err == nil {
+			updateContainerStatus(target.ContainerID, models.ContainerStatusRunning)
+		}
+	} else {
+		if err = podmanService.StopContainer(cmdCtx, target.ContainerID, 10); err == nil {
+			updateContainerStatus(target.ContainerID, models.ContainerStatusExited)
+		}
+	}
+
+	details := map[string]interface{}{"action": action, "topic": msg.Topic}
+	if err != nil {
+		details["error"] = err.Error()
+		log.Printf("Warning: MQTT %s of %s failed: %v", action, target.Name, err)
+	}
+	Audit.Log(0, "mqtt", models.ActionMQTTCommand, target.Name, details, "")
+
+	now := time.Now()
+	updateMQTTStatus(ctx, func(st *models.MQTTStatus) {
+		st.LastCommandAt = &now
+	})
+	refreshMQTTState()
+}
+
+// refreshMQTTState asks a connected bridge to publish container state now
+func refreshMQTTState() {
+	select {
+	case mqttRefresh <- struct{}{}:
+	default:
+	}
+}
+
+// getMQTTSettingsHandler returns the MQTT settings (without the password)
+func getMQTTSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadMQTTSettings())
+}
+
+// getMQTTStatusHandler reports the bridge's broker connection
+func getMQTTStatusHandler(c echo.Context) error {
+	mqttBridge.mu.Lock()
+	defer mqttBridge.mu.Unlock()
+	return c.JSON(http.StatusOK, mqttBridge.status)
+}
+
+// updateMQTTSettingsHandler replaces the MQTT settings, storing any new
+// broker password as a Podman secret, and reconnects the bridge
+func updateMQTTSettingsHandler(c echo.Context) error {
+	req := models.UpdateMQTTSettingsRequest{MQTTSettings: loadMQTTSettings()}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	s := req.MQTTSettings
+	s.BrokerURL = strings.TrimSpace(s.BrokerURL)
+	s.Username = strings.TrimSpace(s.Username)
+	s.ClientID = strings.TrimSpace(s.ClientID)
+	s.TopicPrefix = strings.Trim(strings.TrimSpace(s.TopicPrefix), "/")
+	s.DiscoveryPrefix = strings.Trim(strings.TrimSpace(s.DiscoveryPrefix), "/")
+
+	if s.BrokerURL != "" {
+		if _, _, err := system.ValidateMQTTBrokerURL(s.BrokerURL); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	} else if s.Enabled {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "broker_url is required to enable MQTT",
+		})
+	}
+	for _, prefix := range []string{s.TopicPrefix, s.DiscoveryPrefix} {
+		if prefix == "" || strings.ContainsAny(prefix, "+#") {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "topic_prefix and discovery_prefix can't be empty or contain wildcards",
+			})
+		}
+	}
+	if s.ClientID == "" || len(s.ClientID) > 64 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "client_id must be 1 to 64 characters",
+		})
+	}
+	if s.PublishInterval < 10 || s.PublishInterval > 3600 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "publish_interval must be between 10 and 3600 seconds",
+		})
+	}
+	var acl []string
+	for _, name := range s.CommandContainers {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(acl, name) {
+			acl = append(acl, name)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	values := map[string]string{
+		database.SettingMQTTEnabled:         strconv.FormatBool(s.Enabled),
+		database.SettingMQTTBrokerURL:       s.BrokerURL,
+		database.SettingMQTTUsername:        s.Username,
+		database.SettingMQTTClientID:        s.ClientID,
+		database.SettingMQTTTopicPrefix:     s.TopicPrefix,
+		database.SettingMQTTDiscoveryPrefix: s.DiscoveryPrefix,
+		database.SettingMQTTInterval:        strconv.Itoa(s.PublishInterval),
+		database.SettingMQTTCommands:        strconv.FormatBool(s.CommandsEnabled),
+		database.SettingMQTTCommandACL:      strings.Join(acl, ","),
+	}
+	details := map[string]interface{}{}
+	for key, value := range values {
+		details[key] = value
+	}
+	if req.Password != nil {
+		if *req.Password == "" {
+			if err := podmanService.RemoveSecret(ctx, mqttPasswordSecret); err != nil {
+				c.Logger().Warnf("Failed to remove secret %s: %v", mqttPasswordSecret, err)
+			}
+			values[database.SettingMQTTPasswordSecret] = ""
+		} else {
+			if err := podmanService.CreateSecret(ctx, mqttPasswordSecret, *req.Password); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to store password: " + err.Error(),
+				})
+			}
+			values[database.SettingMQTTPasswordSecret] = mqttPasswordSecret
+		}
+		details["password_changed"] = true
+	}
+
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionMQTTSettings, "mqtt", details)
+	restartMQTTBridge()
+
+	return c.JSON(http.StatusOK, loadMQTTSettings())
+}
@@ -64,7 +64,7 @@ func setInterfaceStateHandler(c echo.Context) error {
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -137,7 +137,7 @@ func createFirewallZoneHandler(c echo.Context) error {
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -190,12 +190,13 @@ func addFirewallServiceHandler(c echo.Context) error {
 	zoneName := c.Param("zone")
 
 	var req struct {
-		Service   string `json:"service"`
-		Permanent bool   `json:"permanent"`
+		Service        string `json:"service"`
+		Permanent      bool   `json:"permanent"`
+		ConfirmTimeout int    `json:"confirm_timeout"` // seconds; stage the change and revert it unless confirmed
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -205,6 +206,13 @@ func addFirewallServiceHandler(c echo.Context) error {
 		})
 	}
 
+	if req.ConfirmTimeout > 0 {
+		return stageFirewallChange(c, system.FirewallChange{
+			Kind: system.FirewallChangeService, Action: system.FirewallActionAdd,
+			Zone: zoneName, Value: req.Service, Permanent: req.Permanent,
+		}, req.ConfirmTimeout)
+	}
+
 	if err := system.AddFirewallService(zoneName, req.Service, req.Permanent); err != nil {
 		c.Logger().Error("add firewall service error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -230,6 +238,13 @@ func removeFirewallServiceHandler(c echo.Context) error {
 	service := c.Param("service")
 	permanent := c.QueryParam("permanent") == "true"
 
+	if seconds := requestConfirmTimeout(c); seconds > 0 {
+		return stageFirewallChange(c, system.FirewallChange{
+			Kind: system.FirewallChangeService, Action: system.FirewallActionRemove,
+			Zone: zoneName, Value: service, Permanent: permanent,
+		}, seconds)
+	}
+
 	if err := system.RemoveFirewallService(zoneName, service, permanent); err != nil {
 		c.Logger().Error("remove firewall service error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -255,12 +270,13 @@ func addFirewallPortHandler(c echo.Context) error {
 
 	var req struct {
 		Port      int    `json:"port"`
-		Protocol  string `json:"protocol"` // tcp or udp
-		Permanent bool   `json:"permanent"`
+		Protocol       string `json:"protocol"` // tcp or udp
+		Permanent      bool   `json:"permanent"`
+		ConfirmTimeout int    `json:"confirm_timeout"` // seconds; stage the change and revert it unless confirmed
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -276,6 +292,13 @@ func addFirewallPortHandler(c echo.Context) error {
 		})
 	}
 
+	if req.ConfirmTimeout > 0 {
+		return stageFirewallChange(c, system.FirewallChange{
+			Kind: system.FirewallChangePort, Action: system.FirewallActionAdd,
+			Zone: zoneName, Value: fmt.Sprintf("%d/%s", req.Port, req.Protocol), Permanent: req.Permanent,
+		}, req.ConfirmTimeout)
+	}
+
 	if err := system.AddFirewallPort(zoneName, req.Port, req.Protocol, req.Permanent); err != nil {
 		c.Logger().Error("add firewall port error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -313,6 +336,13 @@ func removeFirewallPortHandler(c echo.Context) error {
 
 	permanent := c.QueryParam("permanent") == "true"
 
+	if seconds := requestConfirmTimeout(c); seconds > 0 {
+		return stageFirewallChange(c, system.FirewallChange{
+			Kind: system.FirewallChangePort, Action: system.FirewallActionRemove,
+			Zone: zoneName, Value: fmt.Sprintf("%d/%s", port, protocol), Permanent: permanent,
+		}, seconds)
+	}
+
 	if err := system.RemoveFirewallPort(zoneName, port, protocol, permanent); err != nil {
 		c.Logger().Error("remove firewall port error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -338,12 +368,13 @@ func addFirewallRichRuleHandler(c echo.Context) error {
 	zoneName := c.Param("zone")
 
 	var req struct {
-		Rule      string `json:"rule"`
-		Permanent bool   `json:"permanent"`
+		Rule           string `json:"rule"`
+		Permanent      bool   `json:"permanent"`
+		ConfirmTimeout int    `json:"confirm_timeout"` // seconds; stage the change and revert it unless confirmed
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -353,6 +384,13 @@ func addFirewallRichRuleHandler(c echo.Context) error {
 		})
 	}
 
+	if req.ConfirmTimeout > 0 {
+		return stageFirewallChange(c, system.FirewallChange{
+			Kind: system.FirewallChangeRichRule, Action: system.FirewallActionAdd,
+			Zone: zoneName, Value: req.Rule, Permanent: req.Permanent,
+		}, req.ConfirmTimeout)
+	}
+
 	if err := system.AddFirewallRichRule(zoneName, req.Rule, req.Permanent); err != nil {
 		c.Logger().Error("add firewall rich rule error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -377,12 +415,13 @@ func removeFirewallRichRuleHandler(c echo.Context) error {
 	zoneName := c.Param("zone")
 
 	var req struct {
-		Rule      string `json:"rule"`
-		Permanent bool   `json:"permanent"`
+		Rule           string `json:"rule"`
+		Permanent      bool   `json:"permanent"`
+		ConfirmTimeout int    `json:"confirm_timeout"` // seconds; stage the change and revert it unless confirmed
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -392,6 +431,13 @@ func removeFirewallRichRuleHandler(c echo.Context) error {
 		})
 	}
 
+	if req.ConfirmTimeout > 0 {
+		return stageFirewallChange(c, system.FirewallChange{
+			Kind: system.FirewallChangeRichRule, Action: system.FirewallActionRemove,
+			Zone: zoneName, Value: req.Rule, Permanent: req.Permanent,
+		}, req.ConfirmTimeout)
+	}
+
 	if err := system.RemoveFirewallRichRule(zoneName, req.Rule, req.Permanent); err != nil {
 		c.Logger().Error("remove firewall rich rule error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -413,6 +459,13 @@ func removeFirewallRichRuleHandler(c echo.Context) error {
 
 // reloadFirewallHandler handles POST /api/network/firewall/reload
 func reloadFirewallHandler(c echo.Context) error {
+	// A reload drops runtime changes, leaving staged changes nothing to revert
+	if n := pendingFirewall.count(); n > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": fmt.Sprintf("%d firewall change(s) awaiting confirmation; confirm or revert them first", n),
+		})
+	}
+
 	if err := system.ReloadFirewall(); err != nil {
 		c.Logger().Error("reload firewall error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -446,11 +499,12 @@ func getAvailableServicesHandler(c echo.Context) error {
 // setDefaultZoneHandler handles POST /api/network/firewall/default-zone
 func setDefaultZoneHandler(c echo.Context) error {
 	var req struct {
-		Zone string `json:"zone"`
+		Zone           string `json:"zone"`
+		ConfirmTimeout int    `json:"confirm_timeout"` // seconds; restore the previous default unless confirmed
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -460,6 +514,12 @@ func setDefaultZoneHandler(c echo.Context) error {
 		})
 	}
 
+	if req.ConfirmTimeout > 0 {
+		return stageFirewallChange(c, system.FirewallChange{
+			Kind: system.FirewallChangeDefaultZone, Action: system.FirewallActionSet, Value: req.Zone,
+		}, req.ConfirmTimeout)
+	}
+
 	if err := system.SetDefaultZone(req.Zone); err != nil {
 		c.Logger().Error("set default zone error: ", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -498,7 +558,7 @@ func addRouteHandler(c echo.Context) error {
 	var req system.AddRouteRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
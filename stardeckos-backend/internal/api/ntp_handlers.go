@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// loadNTPServerSettings reads the NTP server settings
+func loadNTPServerSettings() models.NTPServerSettings {
+	s := models.NTPServerSettings{Allow: []string{}}
+	s.Enabled, _ = settingsRepo.GetBool(database.SettingNTPServerEnabled)
+	if v, err := settingsRepo.Get(database.SettingNTPAllow); err == nil && v != "" {
+		s.Allow = strings.Fields(v)
+	}
+	if v, err := settingsRepo.GetInt(database.SettingNTPLocalStratum); err == nil {
+		s.LocalStratum = v
+	}
+	return s
+}
+
+// getNTPStatusHandler handles GET /api/system/ntp
+func getNTPStatusHandler(c echo.Context) error {
+	status := system.GetNTPStatus()
+	status.Server = loadNTPServerSettings()
+	return c.JSON(http.StatusOK, status)
+}
+
+// updateNTPServerHandler handles PUT /api/system/ntp/server
+func updateNTPServerHandler(c echo.Context) error {
+	s := loadNTPServerSettings()
+	if err := c.Bind(&s); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := system.ValidateNTPServerSettings(&s); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if !system.ChronyInstalled() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "chrony is not installed",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingNTPServerEnabled: strconv.FormatBool(s.Enabled),
+		database.SettingNTPAllow:         strings.Join(s.Allow, " "),
+		database.SettingNTPLocalStratum:  strconv.Itoa(s.LocalStratum),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save NTP server settings: " + err.Error(),
+		})
+	}
+	if err := system.ApplyNTPServerSettings(s); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply NTP server settings: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionNTPServer, "chrony", s)
+
+	status := system.GetNTPStatus()
+	status.Server = s
+	return c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// originPolicy is the parsed set of origins allowed to make cross-origin
+// requests. It is swapped whole when the settings change.
+type originPolicy struct {
+	exact    map[string]bool
+	patterns []string // Normalized origins with a *. host prefix
+	allowAll bool
+}
+
+var currentOrigins atomic.Pointer[originPolicy]
+
+// CORSMiddleware answers cross-origin requests from trusted origins only.
+// Same-origin requests need no CORS headers and are unaffected.
+func CORSMiddleware() echo.MiddlewareFunc {
+	if _, allowAll := devOrigins(); allowAll {
+		log.Println("Warning: STARDECK_DEV_ORIGINS=* allows requests from any origin")
+	}
+	return middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOriginFunc: func(origin string) (bool, error) {
+			return originPolicyFor().allowed(origin), nil
+		},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodOptions},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-CSRF-Token", "Upgrade", "Connection", "Sec-WebSocket-Key", "Sec-WebSocket-Version"},
+		AllowCredentials: true,
+	})
+}
+
+// checkWebSocketOrigin is the CheckOrigin of every WebSocket upgrader.
+// Clients that send no Origin aren't browsers and can't be used for
+// cross-site requests, so they are let through like the same origin.
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if originPolicyFor().allowed(origin) {
+		return true
+	}
+	log.Printf("Rejected WebSocket from untrusted origin %s", origin)
+	return false
+}
+
+// originPolicyFor returns the current policy, loading it on first use
+func originPolicyFor() *originPolicy {
+	if p := currentOrigins.Load(); p != nil {
+		return p
+	}
+	applyOriginSettings(loadOriginSettings())
+	return currentOrigins.Load()
+}
+
+func (p *originPolicy) allowed(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	normalized, err := normalizeOrigin(origin)
+	if err != nil {
+		return false
+	}
+	if p.exact[normalized] {
+		return true
+	}
+
+	u, _ := url.Parse(normalized)
+	for _, pattern := range p.patterns {
+		pu, _ := url.Parse(strings.Replace(pattern, "*.", "", 1))
+		if u.Scheme == pu.Scheme && u.Port() == pu.Port() && strings.HasSuffix(u.Hostname(), "."+pu.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeOrigin reduces an origin to lowercase scheme://host[:port],
+// dropping default ports, and rejects anything that carries a path
+func normalizeOrigin(origin string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("origin %q must start with http:// or https://", origin)
+	}
+	if u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("origin %q must be only a scheme, host and optional port", origin)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return "", fmt.Errorf("origin %q may only use * as the first label of the host", origin)
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	return u.Scheme + "://" + host, nil
+}
+
+// devOrigins reads STARDECK_DEV_ORIGINS, extra origins that let a frontend
+// dev server on another port reach the API. An entry of * allows any origin.
+func devOrigins() ([]string, bool) {
+	var origins []string
+	allowAll := false
+	for _, o := range strings.Split(os.Getenv("STARDECK_DEV_ORIGINS"), ",") {
+		switch o = strings.TrimSpace(o); o {
+		case "":
+		case "*":
+			allowAll = true
+		default:
+			if normalized, err := normalizeOrigin(o); err == nil {
+				origins = append(origins, normalized)
+			} else {
+				log.Printf("Ignoring STARDECK_DEV_ORIGINS entry: %v", err)
+			}
+		}
+	}
+	return origins, allowAll
+}
+
+// loadOriginSettings reads the trusted origins and the dev override
+func loadOriginSettings() models.OriginSettings {
+	s := models.OriginSettings{TrustedOrigins: []string{}}
+	s.DevOrigins, s.AllowAll = devOrigins()
+	if v, err := settingsRepo.Get(database.SettingTrustedOrigins); err == nil && v != "" {
+		s.TrustedOrigins = strings.Split(v, ",")
+	}
+	return s
+}
+
+// applyOriginSettings swaps in the policy the settings describe
+func applyOriginSettings(s models.OriginSettings) {
+	p := &originPolicy{exact: make(map[string]bool), allowAll: s.AllowAll}
+	for _, o := range append(append([]string{}, s.TrustedOrigins...), s.DevOrigins...) {
+		if strings.Contains(o, "://*.") {
+			p.patterns = append(p.patterns, o)
+		} else {
+			p.exact[o] = true
+		}
+	}
+	currentOrigins.Store(p)
+}
+
+// getOriginSettingsHandler handles GET /api/security/origins
+func getOriginSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadOriginSettings())
+}
+
+// updateOriginSettingsHandler handles PUT /api/security/origins
+func updateOriginSettingsHandler(c echo.Context) error {
+	var req struct {
+		TrustedOrigins []string `json:"trusted_origins"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	origins := []string{}
+	seen := make(map[string]bool)
+	for _, o := range req.TrustedOrigins {
+		if strings.TrimSpace(o) == "*" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Trusting every origin is only possible with STARDECK_DEV_ORIGINS=*",
+			})
+		}
+		normalized, err := normalizeOrigin(o)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			origins = append(origins, normalized)
+		}
+	}
+
+	values := map[string]string{
+		database.SettingTrustedOrigins: strings.Join(origins, ","),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	settings := loadOriginSettings()
+	applyOriginSettings(settings)
+	Audit.LogFromContext(c, models.ActionTrustedOrigins, "origins", values)
+
+	return c.JSON(http.StatusOK, settings)
+}
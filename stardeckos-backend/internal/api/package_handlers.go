@@ -3,13 +3,14 @@ package api
 import (
 	"bufio"
 	"log"
-	"net/http"
 	"os/exec"
 	"strings"
 	"sync"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
 )
 
 // PackageOperationMessage represents a message sent during package operations
@@ -34,18 +35,25 @@ func HandlePackageOperationWebSocket(c echo.Context) error {
 	}
 
 	// Validate token
-	user, _, err := authService.ValidateToken(token)
+	user, session, err := authService.ValidateToken(token)
 	if err != nil {
 		return echo.NewHTTPError(401, "Invalid authentication token")
 	}
+	if refused, err := authService.RefuseReadOnly(c, user, session); refused {
+		return err
+	}
+
+	// dnf changes the host, so it takes an admin or the system:updates permission
+	if user.IsReadOnly() || !user.HasPermission(models.PermSystemUpdates) {
+		log.Printf("Package Operation WebSocket: User %s denied (no %s)", user.Username, models.PermSystemUpdates)
+		return echo.NewHTTPError(403, "Package operations require the "+models.PermSystemUpdates+" permission")
+	}
 
 	log.Printf("Package Operation WebSocket: User %s connecting...", user.Username)
 
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
+		CheckOrigin: checkWebSocketOrigin,
 	}
 
 	ws, err := upgrader.Upgrade(c.Response().Writer, c.Request(), nil)
@@ -66,7 +74,7 @@ func HandlePackageOperationWebSocket(c echo.Context) error {
 	if err := ws.ReadJSON(&req); err != nil {
 		sendPackageMessage(ws, PackageOperationMessage{
 			Type:    "error",
-			Message: "Invalid request: " + err.Error(),
+			Message: tr(c, "error.invalid_request", "error", err.Error()),
 		})
 		return nil
 	}
@@ -76,6 +84,14 @@ func HandlePackageOperationWebSocket(c echo.Context) error {
 	// Execute the operation with streaming output
 	switch req.Operation {
 	case "update":
+		if loadHostSnapshotSettings().Enabled {
+			sendPackageMessage(ws, PackageOperationMessage{
+				Type:    "status",
+				Message: "Taking a filesystem snapshot...",
+				Phase:   "snapshot",
+			})
+		}
+		snapshotBeforeRisk(models.SnapshotReasonOSUpdate, "OS updates", user)
 		streamDNFOperation(ws, "update", req.Packages)
 	case "install":
 		streamDNFOperation(ws, "install", req.Packages)
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+func TestPackageOperationsRefusedInReadOnlyMode(t *testing.T) {
+	openTestDB(t)
+	token := loginTestUser(t, "admin", models.RoleAdmin, false)
+	if err := database.NewSettingsRepo().Set(database.SettingReadOnlyMode, "true"); err != nil {
+		t.Fatalf("set read-only mode: %v", err)
+	}
+
+	rec := serveWebSocketRoute("/api/packages/ws", HandlePackageOperationWebSocket, token)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "read_only_mode") {
+		t.Errorf("dnf operation in read-only mode: got %d %s, want 403", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPackageOperationsRefusedForViewer(t *testing.T) {
+	openTestDB(t)
+	token := loginTestUser(t, "viewer", models.RoleViewer, false)
+
+	if rec := serveWebSocketRoute("/api/packages/ws", HandlePackageOperationWebSocket, token); rec.Code != http.StatusForbidden {
+		t.Errorf("dnf operation as a viewer: got %d, want 403", rec.Code)
+	}
+}
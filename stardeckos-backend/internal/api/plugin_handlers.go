@@ -0,0 +1,882 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	pluginsBaseDir  = "/var/lib/stardeck/plugins"
+	pluginSocketDir = "/run/stardeck/plugins"
+
+	// maxPluginUpload limits the size of an uploaded plugin archive
+	maxPluginUpload = 256 << 20
+
+	// pluginCallTimeout bounds capability and notification calls to a plugin
+	pluginCallTimeout = 10 * time.Second
+
+	// pluginJobTimeout bounds a single job run
+	pluginJobTimeout = 10 * time.Minute
+
+	// pluginRestartDelay is how long a crashed plugin waits before it is started again
+	pluginRestartDelay = 30 * time.Second
+
+	// maxPluginJobOutput is how much of a job's response is kept
+	maxPluginJobOutput = 64 << 10
+
+	// maxPluginLog is how much of a plugin's log the logs endpoint returns
+	maxPluginLog = 64 << 10
+)
+
+var (
+	pluginRepo *database.PluginRepo
+
+	// pluginRuntimes holds the live state of every enabled plugin, by name
+	pluginRuntimes   = make(map[string]*pluginRuntime)
+	pluginRuntimesMu sync.RWMutex
+
+	// pluginLifecycleMu serializes starting and stopping plugins
+	pluginLifecycleMu sync.Mutex
+
+	// runningPluginJobs tracks in-flight jobs so a slow run is never started twice
+	runningPluginJobs   = make(map[int64]bool)
+	runningPluginJobsMu sync.Mutex
+)
+
+// pluginRuntime is an enabled plugin's process and what it registered
+type pluginRuntime struct {
+	proc   *system.PluginProcess
+	caps   *models.PluginCapabilities
+	proxy  *httputil.ReverseProxy
+	status string
+	err    string
+}
+
+// InitPlugins starts the enabled plugins and the plugin job scheduler
+func InitPlugins() {
+	pluginRepo = database.NewPluginRepo()
+
+	installed, err := pluginRepo.List()
+	if err != nil {
+		log.Printf("Warning: failed to load plugins: %v", err)
+	}
+	for i := range installed {
+		if installed[i].Enabled {
+			go func(p models.Plugin) {
+				if err := startPlugin(&p); err != nil {
+					log.Printf("Warning: failed to start plugin %s: %v", p.Name, err)
+				}
+			}(installed[i])
+		}
+	}
+
+	go runPluginJobScheduler()
+}
+
+func pluginDir(name string) string {
+	return filepath.Join(pluginsBaseDir, name)
+}
+
+func pluginLogPath(name string) string {
+	return filepath.Join(pluginDir(name), "plugin.log")
+}
+
+// startPlugin runs a plugin's process and registers its capabilities,
+// replacing any process already running for it
+func startPlugin(p *models.Plugin) error {
+	pluginLifecycleMu.Lock()
+	defer pluginLifecycleMu.Unlock()
+	stopPluginProcess(p.Name)
+
+	pluginRuntimesMu.Lock()
+	pluginRuntimes[p.Name] = &pluginRuntime{status: models.PluginStatusStarting}
+	pluginRuntimesMu.Unlock()
+
+	fail := func(err error) error {
+		pluginRuntimesMu.Lock()
+		pluginRuntimes[p.Name] = &pluginRuntime{status: models.PluginStatusError, err: err.Error()}
+		pluginRuntimesMu.Unlock()
+		return err
+	}
+
+	socket := filepath.Join(pluginSocketDir, p.Name+".sock")
+	proc, err := system.StartPlugin(p.Name, pluginDir(p.Name), p.Command, socket, pluginLogPath(p.Name))
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginCallTimeout)
+	defer cancel()
+	caps, err := proc.Capabilities(ctx)
+	if err != nil {
+		proc.Stop()
+		return fail(fmt.Errorf("failed to read capabilities: %w", err))
+	}
+
+	rt := &pluginRuntime{
+		proc: proc,
+		caps: caps,
+		proxy: &httputil.ReverseProxy{
+			Transport: proc.Transport(),
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(&url.URL{Scheme: "http", Host: "plugin"})
+			},
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				http.Error(w, fmt.Sprintf("Plugin %s is not responding: %v", p.Name, err), http.StatusBadGateway)
+			},
+		},
+		status: models.PluginStatusRunning,
+	}
+
+	pluginRuntimesMu.Lock()
+	pluginRuntimes[p.Name] = rt
+	pluginRuntimesMu.Unlock()
+
+	go watchPlugin(p.Name, rt)
+	log.Printf("Plugin %s %s started", p.Name, p.Version)
+	return nil
+}
+
+// watchPlugin restarts a plugin whose process exits while it is enabled
+func watchPlugin(name string, rt *pluginRuntime) {
+	<-rt.proc.Done()
+
+	msg := fmt.Sprintf("plugin exited: %v", rt.proc.ExitError())
+	pluginRuntimesMu.Lock()
+	current := pluginRuntimes[name] == rt
+	crashed := &pluginRuntime{status: models.PluginStatusError, err: msg}
+	if current {
+		pluginRuntimes[name] = crashed
+	}
+	pluginRuntimesMu.Unlock()
+	if !current {
+		return // Stopped or replaced on purpose
+	}
+
+	log.Printf("Warning: %s %s, restarting in %s", name, msg, pluginRestartDelay)
+	time.Sleep(pluginRestartDelay)
+
+	pluginRuntimesMu.RLock()
+	current = pluginRuntimes[name] == crashed
+	pluginRuntimesMu.RUnlock()
+	p, err := pluginRepo.Get(name)
+	if !current || err != nil || !p.Enabled {
+		return
+	}
+	if err := startPlugin(p); err != nil {
+		log.Printf("Warning: failed to restart plugin %s: %v", name, err)
+	}
+}
+
+// stopPlugin stops a plugin's process, if it has one
+func stopPlugin(name string) {
+	pluginLifecycleMu.Lock()
+	defer pluginLifecycleMu.Unlock()
+	stopPluginProcess(name)
+}
+
+func stopPluginProcess(name string) {
+	pluginRuntimesMu.Lock()
+	rt := pluginRuntimes[name]
+	delete(pluginRuntimes, name)
+	pluginRuntimesMu.Unlock()
+
+	if rt != nil && rt.proc != nil {
+		rt.proc.Stop()
+	}
+}
+
+// runningPlugin returns a plugin's runtime if it is up
+func runningPlugin(name string) *pluginRuntime {
+	pluginRuntimesMu.RLock()
+	defer pluginRuntimesMu.RUnlock()
+	rt := pluginRuntimes[name]
+	if rt == nil || rt.status != models.PluginStatusRunning {
+		return nil
+	}
+	return rt
+}
+
+// withPluginState fills in a plugin's live status and capabilities
+func withPluginState(p *models.Plugin) {
+	pluginRuntimesMu.RLock()
+	rt := pluginRuntimes[p.Name]
+	pluginRuntimesMu.RUnlock()
+
+	switch {
+	case rt != nil:
+		p.Status = rt.status
+		p.Error = rt.err
+		p.Capabilities = rt.caps
+	case p.Enabled:
+		p.Status = models.PluginStatusStarting
+	default:
+		p.Status = models.PluginStatusStopped
+	}
+}
+
+// matchPluginRoute finds the route a plugin registered for a request
+func matchPluginRoute(routes []models.PluginRoute, method, path string) *models.PluginRoute {
+	for i := range routes {
+		r := &routes[i]
+		if r.Method != "*" && !strings.EqualFold(r.Method, method) {
+			continue
+		}
+		prefix := strings.TrimSuffix(r.Path, "/")
+		if path == r.Path || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return r
+		}
+	}
+	return nil
+}
+
+// deliverToPluginChannels passes a notification to every running plugin
+// notification channel that takes its level
+func deliverToPluginChannels(n models.Notification) {
+	type target struct {
+		plugin  string
+		proc    *system.PluginProcess
+		channel string
+	}
+	var targets []target
+
+	pluginRuntimesMu.RLock()
+	for name, rt := range pluginRuntimes {
+		if rt.status != models.PluginStatusRunning {
+			continue
+		}
+		for _, ch := range rt.caps.NotificationChannels {
+			takes := len(ch.Levels) == 0
+			for _, level := range ch.Levels {
+				takes = takes || level == n.Level
+			}
+			if takes {
+				targets = append(targets, target{name, rt.proc, ch.Type})
+			}
+		}
+	}
+	pluginRuntimesMu.RUnlock()
+
+	if len(targets) == 0 {
+		return
+	}
+	delivery := models.PluginChannelDelivery{Notification: n, UserID: n.UserID, Roles: n.Roles}
+	go func() {
+		for _, t := range targets {
+			ctx, cancel := context.WithTimeout(context.Background(), pluginCallTimeout)
+			if _, err := t.proc.Post(ctx, "/stardeck/channels/"+url.PathEscape(t.channel), delivery); err != nil {
+				log.Printf("Warning: plugin %s channel %s failed: %v", t.plugin, t.channel, err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// runPluginJobScheduler wakes at the top of every minute and runs due plugin jobs
+func runPluginJobScheduler() {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		time.Sleep(next.Sub(now))
+
+		if maintenanceModeActive() {
+			continue
+		}
+
+		jobs, err := pluginRepo.ListJobs(true)
+		if err != nil {
+			log.Printf("Warning: failed to load plugin jobs: %v", err)
+			continue
+		}
+
+		for i := range jobs {
+			schedule, err := system.ParseCron(jobs[i].Schedule)
+			if err != nil || !schedule.Matches(next) {
+				continue
+			}
+			go runPluginJob(&jobs[i], "schedule")
+		}
+	}
+}
+
+// runPluginJob posts a job run to its plugin and records the result. It
+// returns false if the job was already running.
+func runPluginJob(job *models.PluginJob, trigger string) bool {
+	runningPluginJobsMu.Lock()
+	if runningPluginJobs[job.ID] {
+		runningPluginJobsMu.Unlock()
+		return false
+	}
+	runningPluginJobs[job.ID] = true
+	runningPluginJobsMu.Unlock()
+
+	defer func() {
+		runningPluginJobsMu.Lock()
+		delete(runningPluginJobs, job.ID)
+		runningPluginJobsMu.Unlock()
+	}()
+
+	started := time.Now()
+	status, output := models.PluginJobSuccess, ""
+	if rt := runningPlugin(job.Plugin); rt == nil {
+		status, output = models.PluginJobFailed, "plugin is not running"
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), pluginJobTimeout)
+		body, err := rt.proc.Post(ctx, "/stardeck/jobs/"+url.PathEscape(job.Type), models.PluginJobRun{
+			JobID:   job.ID,
+			Name:    job.Name,
+			Trigger: trigger,
+			Params:  job.Params,
+		})
+		cancel()
+		output = string(body)
+		if err != nil {
+			status, output = models.PluginJobFailed, err.Error()
+		}
+	}
+	if len(output) > maxPluginJobOutput {
+		output = output[:maxPluginJobOutput]
+	}
+	if status == models.PluginJobFailed {
+		log.Printf("Warning: plugin job %s (%s/%s) failed: %s", job.Name, job.Plugin, job.Type, output)
+	}
+
+	if err := pluginRepo.RecordJobRun(job.ID, started, status, output); err != nil {
+		log.Printf("Warning: failed to record run of plugin job %s: %v", job.Name, err)
+	}
+	job.LastRunAt = &started
+	job.LastStatus = status
+	job.LastOutput = output
+	return true
+}
+
+// getPlugin loads the plugin named in the URL, writing the error response if
+// there is none
+func getPlugin(c echo.Context) (*models.Plugin, error) {
+	p, err := pluginRepo.Get(c.Param("name"))
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Plugin not found",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get plugin: " + err.Error(),
+		})
+	}
+	withPluginState(p)
+	return p, nil
+}
+
+// listPluginsHandler handles GET /api/plugins
+func listPluginsHandler(c echo.Context) error {
+	installed, err := pluginRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list plugins: " + err.Error(),
+		})
+	}
+	if installed == nil {
+		installed = []models.Plugin{}
+	}
+	for i := range installed {
+		withPluginState(&installed[i])
+	}
+	return c.JSON(http.StatusOK, installed)
+}
+
+// getPluginHandler handles GET /api/plugins/:name
+func getPluginHandler(c echo.Context) error {
+	p, err := getPlugin(c)
+	if p == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// installPluginHandler handles POST /api/plugins. The plugin is uploaded as a
+// .tar.gz archive holding plugin.json; uploading a plugin that is already
+// installed upgrades it, keeping its data directory and whether it is enabled.
+// Set the form value enable=true to start a new plugin straight away.
+func installPluginHandler(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "No file uploaded",
+		})
+	}
+	if file.Size > maxPluginUpload {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Plugin archive too large (max %d MB)", maxPluginUpload>>20),
+		})
+	}
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read uploaded file",
+		})
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(pluginsBaseDir, 0750); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create plugin directory: " + err.Error(),
+		})
+	}
+	staging := filepath.Join(pluginsBaseDir, ".install-"+uuid.New().String())
+	defer os.RemoveAll(staging)
+
+	manifest, err := system.ExtractPluginArchive(src, staging)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid plugin: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	p := &models.Plugin{
+		Name:        manifest.Name,
+		Version:     manifest.Version,
+		Description: manifest.Description,
+		Author:      manifest.Author,
+		Command:     manifest.Command,
+		Enabled:     c.FormValue("enable") == "true",
+		InstalledBy: &user.ID,
+	}
+	existing, err := pluginRepo.Get(manifest.Name)
+	upgrade := err == nil
+	if upgrade {
+		p.Enabled = existing.Enabled
+		p.InstalledAt = existing.InstalledAt
+		p.InstalledBy = existing.InstalledBy
+	}
+
+	// Swap the new files in, carrying the plugin's data over
+	stopPlugin(p.Name)
+	dir := pluginDir(p.Name)
+	if _, err := os.Stat(filepath.Join(dir, "data")); err == nil {
+		os.RemoveAll(filepath.Join(staging, "data"))
+		if err := os.Rename(filepath.Join(dir, "data"), filepath.Join(staging, "data")); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to keep plugin data: " + err.Error(),
+			})
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to remove old plugin files: " + err.Error(),
+		})
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to install plugin files: " + err.Error(),
+		})
+	}
+
+	if err := pluginRepo.Save(p); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save plugin: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionPluginInstall, p.Name, map[string]interface{}{
+		"version": p.Version,
+		"upgrade": upgrade,
+	})
+
+	if p.Enabled {
+		if err := startPlugin(p); err != nil {
+			log.Printf("Warning: failed to start plugin %s: %v", p.Name, err)
+		}
+	}
+	withPluginState(p)
+
+	status := http.StatusCreated
+	if upgrade {
+		status = http.StatusOK
+	}
+	return c.JSON(status, p)
+}
+
+// enablePluginHandler handles POST /api/plugins/:name/enable
+func enablePluginHandler(c echo.Context) error {
+	p, err := getPlugin(c)
+	if p == nil {
+		return err
+	}
+
+	if err := pluginRepo.SetEnabled(p.Name, true); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to enable plugin: " + err.Error(),
+		})
+	}
+	p.Enabled = true
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionPluginEnable, p.Name, nil)
+
+	if err := startPlugin(p); err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{
+			"error": "Plugin enabled but failed to start: " + err.Error(),
+		})
+	}
+	withPluginState(p)
+	return c.JSON(http.StatusOK, p)
+}
+
+// disablePluginHandler handles POST /api/plugins/:name/disable
+func disablePluginHandler(c echo.Context) error {
+	p, err := getPlugin(c)
+	if p == nil {
+		return err
+	}
+
+	if err := pluginRepo.SetEnabled(p.Name, false); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to disable plugin: " + err.Error(),
+		})
+	}
+	p.Enabled = false
+	stopPlugin(p.Name)
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionPluginDisable, p.Name, nil)
+
+	withPluginState(p)
+	return c.JSON(http.StatusOK, p)
+}
+
+// uninstallPluginHandler handles DELETE /api/plugins/:name, removing the
+// plugin's files, data and jobs
+func uninstallPluginHandler(c echo.Context) error {
+	p, err := getPlugin(c)
+	if p == nil {
+		return err
+	}
+
+	stopPlugin(p.Name)
+	if err := pluginRepo.Delete(p.Name); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete plugin: " + err.Error(),
+		})
+	}
+	if err := os.RemoveAll(pluginDir(p.Name)); err != nil {
+		log.Printf("Warning: failed to remove files of plugin %s: %v", p.Name, err)
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionPluginUninstall, p.Name, map[string]interface{}{
+		"version": p.Version,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Plugin uninstalled",
+	})
+}
+
+// getPluginLogsHandler handles GET /api/plugins/:name/logs, returning the end
+// of the plugin's output
+func getPluginLogsHandler(c echo.Context) error {
+	p, err := getPlugin(c)
+	if p == nil {
+		return err
+	}
+
+	f, err := os.Open(pluginLogPath(p.Name))
+	if os.IsNotExist(err) {
+		return c.String(http.StatusOK, "")
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read plugin log: " + err.Error(),
+		})
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() > maxPluginLog {
+		f.Seek(-maxPluginLog, io.SeekEnd)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read plugin log: " + err.Error(),
+		})
+	}
+	return c.String(http.StatusOK, string(data))
+}
+
+// listPluginWidgetsHandler handles GET /api/plugins/widgets, returning the
+// dashboard widgets of running plugins that the user may see
+func listPluginWidgetsHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	widgets := []models.PluginWidget{}
+	pluginRuntimesMu.RLock()
+	for name, rt := range pluginRuntimes {
+		if rt.status != models.PluginStatusRunning {
+			continue
+		}
+		for _, w := range rt.caps.Widgets {
+			if roleRank(user) < minRoleRank(w.Role) {
+				continue
+			}
+			w.Plugin = name
+			widgets = append(widgets, w)
+		}
+	}
+	pluginRuntimesMu.RUnlock()
+
+	sort.Slice(widgets, func(i, j int) bool {
+		if widgets[i].Plugin != widgets[j].Plugin {
+			return widgets[i].Plugin < widgets[j].Plugin
+		}
+		return widgets[i].Title < widgets[j].Title
+	})
+	return c.JSON(http.StatusOK, widgets)
+}
+
+// pluginAPIHandler handles /api/plugins/:name/api/*, passing requests for a
+// route the plugin registered to its socket. The plugin learns who is asking
+// from the X-Stardeck-User and X-Stardeck-Role headers; the session cookie and
+// token are not passed on.
+func pluginAPIHandler(c echo.Context) error {
+	name := c.Param("name")
+	rt := runningPlugin(name)
+	if rt == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": fmt.Sprintf("Plugin %s is not running", name),
+		})
+	}
+
+	path := "/" + c.Param("*")
+	route := matchPluginRoute(rt.caps.Routes, c.Request().Method, path)
+	if route == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Plugin route not found",
+		})
+	}
+	user := c.Get("user").(*models.User)
+	if roleRank(user) < minRoleRank(route.Role) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "insufficient permissions",
+		})
+	}
+
+	req := c.Request().Clone(c.Request().Context())
+	req.URL.Path = "/api" + path
+	req.URL.RawPath = ""
+	req.Header.Del("Cookie")
+	req.Header.Del(echo.HeaderAuthorization)
+	req.Header.Del("X-CSRF-Token")
+	role := user.Role
+	if user.IsAdmin() {
+		role = models.RoleAdmin
+	}
+	req.Header.Set("X-Stardeck-User", user.Username)
+	req.Header.Set("X-Stardeck-Role", string(role))
+	req.Header.Set("Accept-Language", requestLanguage(c))
+
+	rt.proxy.ServeHTTP(c.Response(), req)
+	return nil
+}
+
+// pluginJobFromRequest checks a job request against the running plugin's job
+// types and applies it to job. It returns false once it has written an error
+// response.
+func pluginJobFromRequest(c echo.Context, job *models.PluginJob) (bool, error) {
+	var req models.PluginJobRequest
+	if err := c.Bind(&req); err != nil {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "name is required",
+		})
+	}
+	if _, err := system.ParseCron(req.Schedule); err != nil {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid schedule: " + err.Error(),
+		})
+	}
+
+	rt := runningPlugin(req.Plugin)
+	if rt == nil {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Plugin %s is not installed or not running", req.Plugin),
+		})
+	}
+	known := false
+	for _, t := range rt.caps.JobTypes {
+		known = known || t.Type == req.Type
+	}
+	if !known {
+		return false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Plugin %s has no job type %q", req.Plugin, req.Type),
+		})
+	}
+
+	job.Plugin = req.Plugin
+	job.Type = req.Type
+	job.Name = req.Name
+	job.Schedule = req.Schedule
+	job.Params = req.Params
+	if req.Enabled != nil {
+		job.Enabled = *req.Enabled
+	}
+	return true, nil
+}
+
+// getPluginJob loads the plugin job in the URL, writing the error response if
+// there is none
+func getPluginJob(c echo.Context) (*models.PluginJob, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid job ID",
+		})
+	}
+	job, err := pluginRepo.GetJob(id)
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Plugin job not found",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get plugin job: " + err.Error(),
+		})
+	}
+	return job, nil
+}
+
+// listPluginJobsHandler handles GET /api/plugins/jobs
+func listPluginJobsHandler(c echo.Context) error {
+	jobs, err := pluginRepo.ListJobs(false)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list plugin jobs: " + err.Error(),
+		})
+	}
+	if jobs == nil {
+		jobs = []models.PluginJob{}
+	}
+	return c.JSON(http.StatusOK, jobs)
+}
+
+// createPluginJobHandler handles POST /api/plugins/jobs
+func createPluginJobHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	job := &models.PluginJob{Enabled: true, CreatedBy: &user.ID}
+	if ok, err := pluginJobFromRequest(c, job); !ok {
+		return err
+	}
+
+	if err := pluginRepo.CreateJob(job); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create plugin job: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionPluginJobUpdate, job.Name, map[string]interface{}{
+		"job_id":   job.ID,
+		"plugin":   job.Plugin,
+		"type":     job.Type,
+		"schedule": job.Schedule,
+	})
+	return c.JSON(http.StatusCreated, job)
+}
+
+// updatePluginJobHandler handles PUT /api/plugins/jobs/:id
+func updatePluginJobHandler(c echo.Context) error {
+	job, err := getPluginJob(c)
+	if job == nil {
+		return err
+	}
+	if ok, err := pluginJobFromRequest(c, job); !ok {
+		return err
+	}
+
+	if err := pluginRepo.UpdateJob(job); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update plugin job: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionPluginJobUpdate, job.Name, map[string]interface{}{
+		"job_id":   job.ID,
+		"plugin":   job.Plugin,
+		"type":     job.Type,
+		"schedule": job.Schedule,
+		"enabled":  job.Enabled,
+	})
+	return c.JSON(http.StatusOK, job)
+}
+
+// deletePluginJobHandler handles DELETE /api/plugins/jobs/:id
+func deletePluginJobHandler(c echo.Context) error {
+	job, err := getPluginJob(c)
+	if job == nil {
+		return err
+	}
+
+	if err := pluginRepo.DeleteJob(job.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete plugin job: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionPluginJobDelete, job.Name, map[string]interface{}{
+		"job_id": job.ID,
+		"plugin": job.Plugin,
+	})
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Plugin job deleted",
+	})
+}
+
+// runPluginJobHandler handles POST /api/plugins/jobs/:id/run, running a job
+// now and returning its result
+func runPluginJobHandler(c echo.Context) error {
+	job, err := getPluginJob(c)
+	if job == nil {
+		return err
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionPluginJobRun, job.Name, map[string]interface{}{
+		"job_id": job.ID,
+		"plugin": job.Plugin,
+	})
+
+	if !runPluginJob(job, "manual") {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Job is already running",
+		})
+	}
+	return c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var portExposureRepo *database.PortExposureRepo
+
+const (
+	defaultExposeMinutes = 60
+	maxExposeMinutes     = 24 * 60
+)
+
+// portForwarders holds the running socat relay for each active exposure
+var (
+	portForwarders   = make(map[string]*system.PortForwarder)
+	portForwardersMu sync.Mutex
+)
+
+// InitPortExposure initializes exposure storage, restores unexpired exposures and starts the revoker
+func InitPortExposure() {
+	portExposureRepo = database.NewPortExposureRepo()
+	go func() {
+		restorePortExposures()
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			revokeExpiredExposures()
+		}
+	}()
+}
+
+// restorePortExposures re-establishes exposures that were active when the service stopped
+func restorePortExposures() {
+	exposures, err := portExposureRepo.ListActive("")
+	if err != nil {
+		log.Printf("Warning: failed to load port exposures: %v", err)
+		return
+	}
+
+	for i := range exposures {
+		e := &exposures[i]
+		remaining := time.Until(e.ExpiresAt)
+		if remaining <= 0 {
+			revokePortExposure(e)
+			continue
+		}
+		if err := openPortExposure(e, remaining); err != nil {
+			log.Printf("Warning: failed to restore port exposure %s (%s:%d): %v", e.ID, e.ContainerName, e.ContainerPort, err)
+			revokePortExposure(e)
+		}
+	}
+}
+
+// revokeExpiredExposures closes every exposure past its expiry time
+func revokeExpiredExposures() {
+	exposures, err := portExposureRepo.ListActive("")
+	if err != nil {
+		return
+	}
+	for i := range exposures {
+		e := &exposures[i]
+		if time.Now().After(e.ExpiresAt) {
+			revokePortExposure(e)
+			Audit.Log(0, "system", models.ActionPortExposeRevoke, e.ContainerName, map[string]interface{}{
+				"host_port": e.HostPort,
+				"protocol":  e.Protocol,
+				"reason":    "expired",
+			}, "")
+		}
+	}
+}
+
+// openPortExposure starts the forwarder and opens the firewall port for the remaining duration
+func openPortExposure(e *models.PortExposure, duration time.Duration) error {
+	forwarder, err := system.StartPortForwarder(e.Protocol, e.HostPort, e.TargetIP, e.ContainerPort)
+	if err != nil {
+		return err
+	}
+
+	// The firewall rule carries its own timeout so it is revoked even if Stardeck is not running
+	if e.Zone != "" {
+		if err := system.AddFirewallPortTimeout(e.Zone, e.HostPort, e.Protocol, duration); err != nil {
+			forwarder.Stop()
+			return err
+		}
+	}
+
+	portForwardersMu.Lock()
+	portForwarders[e.ID] = forwarder
+	portForwardersMu.Unlock()
+	return nil
+}
+
+// revokePortExposure stops the forwarder, closes the firewall port and marks the exposure revoked
+func revokePortExposure(e *models.PortExposure) {
+	portForwardersMu.Lock()
+	forwarder := portForwarders[e.ID]
+	delete(portForwarders, e.ID)
+	portForwardersMu.Unlock()
+
+	if forwarder != nil {
+		forwarder.Stop()
+	}
+
+	// Ignore errors: firewalld may already have dropped the rule on its own timeout
+	if e.Zone != "" {
+		system.RemoveFirewallPort(e.Zone, e.HostPort, e.Protocol, false)
+	}
+
+	if err := portExposureRepo.MarkRevoked(e.ID); err != nil {
+		log.Printf("Warning: failed to mark port exposure %s revoked: %v", e.ID, err)
+	}
+}
+
+// withForwarderState fills in whether each exposure's forwarder is running
+func withForwarderState(exposures []models.PortExposure) []models.PortExposure {
+	if exposures == nil {
+		return []models.PortExposure{}
+	}
+	portForwardersMu.Lock()
+	defer portForwardersMu.Unlock()
+	for i := range exposures {
+		if f, ok := portForwarders[exposures[i].ID]; ok {
+			exposures[i].Active = f.Running()
+		}
+	}
+	return exposures
+}
+
+// listAllPortExposuresHandler returns every active temporary exposure
+func listAllPortExposuresHandler(c echo.Context) error {
+	exposures, err := portExposureRepo.ListActive("")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list port exposures: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, withForwarderState(exposures))
+}
+
+// listPortExposuresHandler returns the active temporary exposures for a container
+func listPortExposuresHandler(c echo.Context) error {
+	podmanID := resolveContainerID(c.Param("id"))
+	exposures, err := portExposureRepo.ListActive(podmanID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list port exposures: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, withForwarderState(exposures))
+}
+
+// createPortExposureHandler temporarily publishes a container port on the host
+func createPortExposureHandler(c echo.Context) error {
+	var req models.CreatePortExposureRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.ContainerPort < 1 || req.ContainerPort > 65535 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "container_port must be between 1 and 65535",
+		})
+	}
+	if req.HostPort == 0 {
+		req.HostPort = req.ContainerPort
+	}
+	if req.HostPort < 1 || req.HostPort > 65535 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "host_port must be between 1 and 65535",
+		})
+	}
+	req.Protocol = strings.ToLower(req.Protocol)
+	if req.Protocol == "" {
+		req.Protocol = "tcp"
+	}
+	if req.Protocol != "tcp" && req.Protocol != "udp" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "protocol must be tcp or udp",
+		})
+	}
+	if req.DurationMinutes == 0 {
+		req.DurationMinutes = defaultExposeMinutes
+	}
+	if req.DurationMinutes < 1 || req.DurationMinutes > maxExposeMinutes {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("duration_minutes must be between 1 and %d", maxExposeMinutes),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	podmanID := resolveContainerID(c.Param("id"))
+	inspect, err := podmanService.InspectContainer(ctx, podmanID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+	if !inspect.State.Running {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Container must be running to expose a port",
+		})
+	}
+
+	targetIP, err := podmanService.GetContainerIPAddress(ctx, inspect.ID, managedNetworkName())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Cannot expose port: " + err.Error(),
+		})
+	}
+
+	if busy, _ := portExposureRepo.IsHostPortActive(req.HostPort, req.Protocol); busy || !hostPortFree(req.Protocol, req.HostPort) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": fmt.Sprintf("Host port %d/%s is already in use", req.HostPort, req.Protocol),
+		})
+	}
+
+	zone := req.Zone
+	if zone == "" {
+		if status, err := system.GetFirewallStatus(); err == nil && status.Running {
+			zone = status.DefaultZone
+		}
+	}
+
+	user := c.Get("user").(*models.User)
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+	exposure := &models.PortExposure{
+		ContainerID:   inspect.ID,
+		ContainerName: strings.TrimPrefix(inspect.Name, "/"),
+		ContainerPort: req.ContainerPort,
+		HostPort:      req.HostPort,
+		Protocol:      req.Protocol,
+		TargetIP:      targetIP,
+		Zone:          zone,
+		ExpiresAt:     time.Now().Add(duration),
+		CreatedBy:     &user.ID,
+	}
+
+	if err := openPortExposure(exposure, duration); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to expose port: " + err.Error(),
+		})
+	}
+
+	if err := portExposureRepo.Create(exposure); err != nil {
+		revokePortExposure(exposure)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save port exposure: " + err.Error(),
+		})
+	}
+	exposure.Active = true
+
+	logAudit(user, models.ActionPortExpose, exposure.ContainerName, map[string]interface{}{
+		"container_port": exposure.ContainerPort,
+		"host_port":      exposure.HostPort,
+		"protocol":       exposure.Protocol,
+		"zone":           exposure.Zone,
+		"expires_at":     exposure.ExpiresAt,
+	})
+
+	return c.JSON(http.StatusCreated, exposure)
+}
+
+// revokePortExposureHandler closes a temporary exposure before it expires
+func revokePortExposureHandler(c echo.Context) error {
+	exposure, err := portExposureRepo.GetByID(c.Param("expose_id"))
+	if err == sql.ErrNoRows || (err == nil && exposure.ContainerID != resolveContainerID(c.Param("id"))) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Port exposure not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get port exposure: " + err.Error(),
+		})
+	}
+	if exposure.RevokedAt != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Port exposure has already been revoked",
+		})
+	}
+
+	revokePortExposure(exposure)
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionPortExposeRevoke, exposure.ContainerName, map[string]interface{}{
+		"host_port": exposure.HostPort,
+		"protocol":  exposure.Protocol,
+		"reason":    "manual",
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Port exposure revoked",
+	})
+}
+
+// hostPortFree reports whether nothing on the host is bound to the port
+func hostPortFree(protocol string, port int) bool {
+	addr := fmt.Sprintf(":%d", port)
+	if protocol == "udp" {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return false
+	}
+	ln.Close()
+	return true
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var energyRepo *database.EnergyRepo
+
+const (
+	powerSampleInterval = time.Minute
+	energyRetentionDays = 90
+)
+
+// powerSample is the counter state at the previous sample, used to compute deltas
+type powerSample struct {
+	at         time.Time
+	rapl       system.RAPLReading
+	hasRAPL    bool
+	busy       float64
+	total      float64
+	containers map[string]float64 // Podman ID -> cumulative CPU seconds
+}
+
+var (
+	powerMu      sync.Mutex
+	lastPower    *powerSample
+	currentWatts float64
+	powerSource  = models.PowerSourceEstimated
+)
+
+// InitEnergyRepo initializes the energy repository and starts the power sampler
+func InitEnergyRepo() {
+	energyRepo = database.NewEnergyRepo()
+	go runPowerSampler()
+}
+
+// loadPowerSettings reads power settings, applying defaults for missing values
+func loadPowerSettings() models.PowerSettings {
+	s := models.PowerSettings{IdleWatts: 10, MaxWatts: 65}
+	if v, err := settingsRepo.Get(database.SettingPowerIdleWatts); err == nil {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			s.IdleWatts = f
+		}
+	}
+	if v, err := settingsRepo.Get(database.SettingPowerMaxWatts); err == nil {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			s.MaxWatts = f
+		}
+	}
+	if v, err := settingsRepo.Get(database.SettingPowerPrice); err == nil {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			s.PricePerKWh = f
+		}
+	}
+	s.Currency, _ = settingsRepo.Get(database.SettingPowerCurrency)
+	return s
+}
+
+// runPowerSampler samples energy and CPU counters every minute and accumulates daily usage
+func runPowerSampler() {
+	ticker := time.NewTicker(powerSampleInterval)
+	defer ticker.Stop()
+
+	lastCleanup := ""
+	for range ticker.C {
+		if maintenanceModeActive() {
+			// Restart from a fresh baseline so the paused period isn't booked as one sample
+			powerMu.Lock()
+			lastPower = nil
+			powerMu.Unlock()
+			continue
+		}
+
+		samplePower()
+
+		if today := time.Now().Format("2006-01-02"); today != lastCleanup {
+			cutoff := time.Now().AddDate(0, 0, -energyRetentionDays).Format("2006-01-02")
+			if _, err := energyRepo.DeleteBefore(cutoff); err != nil {
+				log.Printf("Warning: failed to prune energy usage: %v", err)
+			}
+			lastCleanup = today
+		}
+	}
+}
+
+// samplePower records the energy used since the previous sample. Host energy
+// comes from RAPL when available, otherwise from a linear model between the
+// configured idle and max watts. Each container is attributed the fraction of
+// that energy matching its share of total CPU capacity; the rest is idle draw
+// and host processes.
+func samplePower() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	busy, total, err := system.HostCPUTime()
+	if err != nil {
+		log.Printf("Warning: failed to read host CPU time: %v", err)
+		return
+	}
+	rapl, hasRAPL := system.ReadRAPL()
+
+	names := make(map[string]string)
+	var ids []string
+	if containers, err := podmanService.ListContainers(ctx); err == nil {
+		for _, c := range containers {
+			if c.Status == models.ContainerStatusRunning {
+				ids = append(ids, c.ContainerID)
+				names[c.ContainerID] = c.Name
+			}
+		}
+	}
+	cpu, err := podmanService.ContainerCPUTime(ctx, ids)
+	if err != nil {
+		cpu = make(map[string]float64)
+	}
+
+	sample := &powerSample{at: time.Now(), rapl: rapl, hasRAPL: hasRAPL, busy: busy, total: total, containers: cpu}
+
+	powerMu.Lock()
+	prev := lastPower
+	lastPower = sample
+	powerMu.Unlock()
+
+	if prev == nil || prev.hasRAPL != hasRAPL {
+		return
+	}
+	interval := sample.at.Sub(prev.at).Seconds()
+	capacity := total - prev.total
+	busyDelta := busy - prev.busy
+	if interval <= 0 || capacity <= 0 {
+		return
+	}
+
+	var joules float64
+	source := models.PowerSourceEstimated
+	if hasRAPL {
+		joules = rapl.JoulesSince(prev.rapl)
+		source = models.PowerSourceRAPL
+	} else {
+		settings := loadPowerSettings()
+		watts := settings.IdleWatts + (settings.MaxWatts-settings.IdleWatts)*busyDelta/capacity
+		joules = watts * interval
+	}
+
+	powerMu.Lock()
+	currentWatts = joules / interval
+	powerSource = source
+	powerMu.Unlock()
+
+	day := sample.at.Format("2006-01-02")
+	if err := energyRepo.Add(day, "", joules, busyDelta); err != nil {
+		log.Printf("Warning: failed to record energy usage: %v", err)
+		return
+	}
+	for id, seconds := range cpu {
+		before, ok := prev.containers[id]
+		if !ok || seconds < before {
+			continue
+		}
+		delta := seconds - before
+		energyRepo.Add(day, names[id], joules*delta/capacity, delta)
+	}
+}
+
+// getPowerReportHandler returns the current power draw and daily energy use per container
+func getPowerReportHandler(c echo.Context) error {
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+	if days <= 0 {
+		days = 7
+	}
+	if days > energyRetentionDays {
+		days = energyRetentionDays
+	}
+
+	since := time.Now().AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+	usage, err := energyRepo.ListSince(since)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get energy usage: " + err.Error(),
+		})
+	}
+
+	settings := loadPowerSettings()
+	if usage == nil {
+		usage = []models.EnergyDay{}
+	}
+	for i := range usage {
+		day := &usage[i]
+		day.HostCost = day.HostKWh * settings.PricePerKWh
+		for j := range day.Containers {
+			ce := &day.Containers[j]
+			ce.Cost = ce.KWh * settings.PricePerKWh
+			if day.HostKWh > 0 {
+				ce.Share = ce.KWh / day.HostKWh
+			}
+		}
+	}
+
+	powerMu.Lock()
+	report := models.PowerReport{
+		Source:       powerSource,
+		CurrentWatts: currentWatts,
+		Settings:     settings,
+		Days:         usage,
+	}
+	powerMu.Unlock()
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// updatePowerSettingsHandler updates the power model and electricity price
+func updatePowerSettingsHandler(c echo.Context) error {
+	settings := loadPowerSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if settings.IdleWatts < 0 || settings.MaxWatts < settings.IdleWatts {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idle_watts must be non-negative and no greater than max_watts",
+		})
+	}
+	if settings.PricePerKWh < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "price_per_kwh cannot be negative",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingPowerIdleWatts: strconv.FormatFloat(settings.IdleWatts, 'f', -1, 64),
+		database.SettingPowerMaxWatts:  strconv.FormatFloat(settings.MaxWatts, 'f', -1, 64),
+		database.SettingPowerPrice:     strconv.FormatFloat(settings.PricePerKWh, 'f', -1, 64),
+		database.SettingPowerCurrency:  settings.Currency,
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save power settings: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionPowerSettings, "power", settings)
+
+	return c.JSON(http.StatusOK, settings)
+}
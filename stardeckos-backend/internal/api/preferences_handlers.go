@@ -52,7 +52,7 @@ func updateUserPreferencesHandler(c echo.Context) error {
 	var req models.UpdatePreferencesRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -100,7 +100,7 @@ func patchUserPreferencesHandler(c echo.Context) error {
 	var req models.UpdatePreferencesRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -0,0 +1,590 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var projectRepo *database.ProjectRepo
+
+// InitProjectRepo initializes the project repository
+func InitProjectRepo() {
+	projectRepo = database.NewProjectRepo()
+}
+
+// projectView is what a non-admin user can see of the project assignments.
+// A nil view belongs to a global admin and sees everything.
+type projectView struct {
+	roles       map[string]models.Role                           // Project ID -> the user's project role
+	assignments map[models.ProjectResourceType]map[string]string // Resource ID -> project ID
+	stacks      map[string]string                                // Stack name -> project ID
+}
+
+// loadProjectView loads the user's project memberships and all resource assignments
+func loadProjectView(user *models.User) (*projectView, error) {
+	if user.IsAdmin() {
+		return nil, nil
+	}
+	roles, err := projectRepo.MemberRoles(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := projectRepo.Assignments()
+	if err != nil {
+		return nil, err
+	}
+	view := &projectView{roles: roles, assignments: assignments, stacks: make(map[string]string)}
+
+	// Containers follow their stack's project, and they only carry the stack's name
+	if stackProjects := assignments[models.ProjectResourceStack]; len(stackProjects) > 0 {
+		stacks, err := stackRepo.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, stack := range stacks {
+			if projectID, ok := stackProjects[stack.ID]; ok {
+				view.stacks[stack.Name] = projectID
+			}
+		}
+	}
+	return view, nil
+}
+
+// project returns the project holding a resource, or "" when it's unassigned
+func (v *projectView) project(kind models.ProjectResourceType, id string) string {
+	if v == nil {
+		return ""
+	}
+	return v.assignments[kind][id]
+}
+
+// containerProject returns the project holding a container: its own
+// assignment, otherwise that of the stack it was deployed with
+func (v *projectView) containerProject(id, stack string) string {
+	if projectID := v.project(models.ProjectResourceContainer, id); projectID != "" {
+		return projectID
+	}
+	if v == nil {
+		return ""
+	}
+	return v.stacks[stack]
+}
+
+// visible reports whether the user may see a resource in the given project
+func (v *projectView) visible(projectID string) bool {
+	if v == nil || projectID == "" {
+		return true
+	}
+	_, ok := v.roles[projectID]
+	return ok
+}
+
+// requireProjectAccess guards the routes under route (e.g. /api/stacks/:id)
+// for resources assigned to a project. Users outside the project get a 404 as
+// if the resource didn't exist; members act with their project role in place
+// of their global one, so the routes' own role checks apply to it. Global
+// viewers stay read-only in every project.
+func requireProjectAccess(kind models.ProjectResourceType, route string) echo.MiddlewareFunc {
+	param := route[strings.LastIndex(route, ":")+1:]
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := c.Get(auth.ContextKeyUser).(*models.User)
+			if !ok || user.IsAdmin() {
+				return next(c)
+			}
+			if c.Path() != route && !strings.HasPrefix(c.Path(), route+"/") {
+				return next(c)
+			}
+
+			view, err := loadProjectView(user)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to load projects: " + err.Error(),
+				})
+			}
+
+			var projectID string
+			if kind == models.ProjectResourceContainer {
+				projectID = requestContainerProject(c.Request().Context(), view, c.Param(param))
+			} else {
+				projectID = view.project(kind, c.Param(param))
+			}
+			if projectID == "" {
+				return next(c)
+			}
+
+			role, member := view.roles[projectID]
+			if !member {
+				return c.JSON(http.StatusNotFound, map[string]string{
+					"error": strings.ToUpper(string(kind[:1])) + string(kind[1:]) + " not found",
+				})
+			}
+
+			scoped := *user
+			scoped.Role = role
+			if scoped.IsReadOnly() && !auth.ViewerAllowed(c) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "project viewer role is read-only",
+				})
+			}
+			c.Set(auth.ContextKeyUser, &scoped)
+			return next(c)
+		}
+	}
+}
+
+// requestContainerProject places a container named by a Stardeck or Podman ID
+func requestContainerProject(ctx context.Context, view *projectView, id string) string {
+	if container, err := lookupManagedContainer(id); err == nil {
+		if projectID := view.project(models.ProjectResourceContainer, container.ID); projectID != "" {
+			return projectID
+		}
+	}
+	if len(view.stacks) == 0 {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	inspect, err := podmanService.InspectContainer(ctx, resolveContainerID(id))
+	if err != nil {
+		return ""
+	}
+	return view.stacks[inspect.Config.Labels["com.docker.compose.project"]]
+}
+
+// requestProject loads the :id project with the user's role in it. Projects
+// the user doesn't belong to are reported missing; global admins act as
+// project admins everywhere.
+func requestProject(c echo.Context) (*models.Project, error) {
+	user := c.Get("user").(*models.User)
+	project, err := projectRepo.GetByID(c.Param("id"))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get project: " + err.Error(),
+		})
+	}
+	if err == nil {
+		if user.IsAdmin() {
+			project.Role = models.RoleAdmin
+			return project, nil
+		}
+		roles, err := projectRepo.MemberRoles(user.ID)
+		if err != nil {
+			return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to get project: " + err.Error(),
+			})
+		}
+		if role, ok := roles[project.ID]; ok {
+			project.Role = role
+			return project, nil
+		}
+	}
+	return nil, c.JSON(http.StatusNotFound, map[string]string{
+		"error": "Project not found",
+	})
+}
+
+// projectAdminRequired answers a project change by a member without the project admin role
+func projectAdminRequired(c echo.Context) error {
+	return c.JSON(http.StatusForbidden, map[string]string{
+		"error": "requires the project admin role",
+	})
+}
+
+// validProjectRole reports whether role can be bound to a project member
+func validProjectRole(role models.Role) bool {
+	switch role {
+	case models.RoleAdmin, models.RoleOperator, models.RoleViewer:
+		return true
+	}
+	return false
+}
+
+// listProjectsHandler returns the projects the user belongs to, or all of them for admins
+func listProjectsHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	projects, err := projectRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list projects: " + err.Error(),
+		})
+	}
+
+	roles := map[string]models.Role{}
+	if !user.IsAdmin() {
+		if roles, err = projectRepo.MemberRoles(user.ID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to list projects: " + err.Error(),
+			})
+		}
+	}
+
+	result := make([]models.Project, 0, len(projects))
+	for _, project := range projects {
+		if user.IsAdmin() {
+			project.Role = models.RoleAdmin
+		} else if role, ok := roles[project.ID]; ok {
+			project.Role = role
+		} else {
+			continue
+		}
+		result = append(result, project)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// getProjectHandler returns a project with its members and resources
+func getProjectHandler(c echo.Context) error {
+	project, err := requestProject(c)
+	if project == nil {
+		return err
+	}
+
+	if project.Members, err = projectRepo.ListMembers(project.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list project members: " + err.Error(),
+		})
+	}
+	if project.Resources, err = projectRepo.ListResources(project.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list project resources: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, project)
+}
+
+// createProjectHandler creates an empty project
+func createProjectHandler(c echo.Context) error {
+	var req models.CreateProjectRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "name must be 1-64 characters",
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	project := &models.Project{
+		Name:        req.Name,
+		Description: strings.TrimSpace(req.Description),
+		CreatedBy:   &user.ID,
+	}
+	if err := projectRepo.Create(project); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "A project with this name already exists",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create project: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionProjectCreate, project.Name, map[string]interface{}{
+		"project_id": project.ID,
+	})
+
+	project.Role = models.RoleAdmin
+	return c.JSON(http.StatusCreated, project)
+}
+
+// updateProjectHandler changes a project's description
+func updateProjectHandler(c echo.Context) error {
+	project, err := requestProject(c)
+	if project == nil {
+		return err
+	}
+	if project.Role != models.RoleAdmin {
+		return projectAdminRequired(c)
+	}
+
+	var req models.UpdateProjectRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	project.Description = strings.TrimSpace(req.Description)
+	if err := projectRepo.UpdateDescription(project.ID, project.Description); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update project: " + err.Error(),
+		})
+	}
+
+	logAudit(c.Get("user").(*models.User), models.ActionProjectUpdate, project.Name, nil)
+
+	return c.JSON(http.StatusOK, project)
+}
+
+// deleteProjectHandler deletes a project. Its resources become unassigned and
+// visible to everyone again; nothing is removed from Podman.
+func deleteProjectHandler(c echo.Context) error {
+	project, err := requestProject(c)
+	if project == nil {
+		return err
+	}
+
+	if err := projectRepo.Delete(project.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete project: " + err.Error(),
+		})
+	}
+
+	logAudit(c.Get("user").(*models.User), models.ActionProjectDelete, project.Name, map[string]interface{}{
+		"project_id": project.ID,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
+
+// setProjectMemberHandler adds a user to a project or changes their project role
+func setProjectMemberHandler(c echo.Context) error {
+	project, err := requestProject(c)
+	if project == nil {
+		return err
+	}
+	if project.Role != models.RoleAdmin {
+		return projectAdminRequired(c)
+	}
+
+	var req models.AddProjectMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if !validProjectRole(req.Role) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "role must be admin, operator or viewer",
+		})
+	}
+	member, err := userRepo.GetByID(req.UserID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.user_not_found"),
+		})
+	}
+
+	if err := projectRepo.SetMember(project.ID, member.ID, req.Role); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to add project member: " + err.Error(),
+		})
+	}
+
+	logAudit(c.Get("user").(*models.User), models.ActionProjectMemberAdd, project.Name, map[string]interface{}{
+		"user": member.Username,
+		"role": req.Role,
+	})
+
+	members, err := projectRepo.ListMembers(project.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list project members: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, members)
+}
+
+// removeProjectMemberHandler removes a user from a project
+func removeProjectMemberHandler(c echo.Context) error {
+	project, err := requestProject(c)
+	if project == nil {
+		return err
+	}
+	if project.Role != models.RoleAdmin {
+		return projectAdminRequired(c)
+	}
+
+	userID, err := parseID(c.Param("user_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_user_id"),
+		})
+	}
+	if err := projectRepo.RemoveMember(project.ID, userID); err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "User is not a member of this project",
+		})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to remove project member: " + err.Error(),
+		})
+	}
+
+	logAudit(c.Get("user").(*models.User), models.ActionProjectMemberRemove, project.Name, map[string]interface{}{
+		"user_id": userID,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "removed",
+	})
+}
+
+// addProjectResourceHandler assigns a container, stack, volume or network to a
+// project. Global admins can assign anything, moving it out of any other
+// project; project admins can bring in unassigned containers and stacks they own.
+func addProjectResourceHandler(c echo.Context) error {
+	project, err := requestProject(c)
+	if project == nil {
+		return err
+	}
+	if project.Role != models.RoleAdmin {
+		return projectAdminRequired(c)
+	}
+
+	var req models.AddProjectResourceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if req.ID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "id is required",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	resource := &models.ProjectResource{ProjectID: project.ID, Type: req.Type}
+	var owner *int64
+	switch req.Type {
+	case models.ProjectResourceContainer:
+		container, err := lookupManagedContainer(req.ID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Container not found or not managed by Stardeck",
+			})
+		}
+		resource.ResourceID, resource.Name, owner = container.ID, container.Name, container.CreatedBy
+	case models.ProjectResourceStack:
+		stack, err := stackRepo.GetByID(req.ID)
+		if err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": tr(c, "error.stack_not_found"),
+			})
+		}
+		resource.ResourceID, resource.Name, owner = stack.ID, stack.Name, stack.CreatedBy
+	case models.ProjectResourceVolume:
+		if _, err := podmanService.InspectVolume(ctx, req.ID); err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Volume not found",
+			})
+		}
+		resource.ResourceID, resource.Name = req.ID, req.ID
+	case models.ProjectResourceNetwork:
+		networks, err := podmanService.ListNetworks(ctx)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to list networks: " + err.Error(),
+			})
+		}
+		for _, network := range networks {
+			if network.Name == req.ID {
+				resource.ResourceID, resource.Name = network.Name, network.Name
+			}
+		}
+		if resource.ResourceID == "" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Network not found",
+			})
+		}
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "type must be container, stack, volume or network",
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	if !user.IsAdmin() {
+		view, err := loadProjectView(user)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to load projects: " + err.Error(),
+			})
+		}
+		if owner == nil || *owner != user.ID {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Only administrators can assign resources you don't own",
+			})
+		}
+		if current := view.project(resource.Type, resource.ResourceID); current != "" && current != project.ID {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Resource already belongs to another project",
+			})
+		}
+	}
+
+	if err := projectRepo.AddResource(resource); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to assign resource: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionProjectResourceAdd, project.Name, map[string]interface{}{
+		"type":     resource.Type,
+		"resource": resource.Name,
+	})
+
+	return c.JSON(http.StatusCreated, resource)
+}
+
+// removeProjectResourceHandler unassigns a resource from a project. The
+// resource itself is untouched and becomes visible to everyone again.
+func removeProjectResourceHandler(c echo.Context) error {
+	project, err := requestProject(c)
+	if project == nil {
+		return err
+	}
+	if project.Role != models.RoleAdmin {
+		return projectAdminRequired(c)
+	}
+
+	resources, err := projectRepo.ListResources(project.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list project resources: " + err.Error(),
+		})
+	}
+	kind := models.ProjectResourceType(c.Param("type"))
+	for _, resource := range resources {
+		if resource.Type != kind || resource.ResourceID != c.Param("resource_id") {
+			continue
+		}
+		if err := projectRepo.RemoveResource(kind, resource.ResourceID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to unassign resource: " + err.Error(),
+			})
+		}
+
+		logAudit(c.Get("user").(*models.User), models.ActionProjectResourceRemove, project.Name, map[string]interface{}{
+			"type":     resource.Type,
+			"resource": resource.Name,
+		})
+
+		return c.JSON(http.StatusOK, map[string]string{
+			"status": "removed",
+		})
+	}
+
+	return c.JSON(http.StatusNotFound, map[string]string{
+		"error": "Resource is not assigned to this project",
+	})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// InitOutboundProxy applies the saved proxy settings before anything makes
+// outbound requests
+func InitOutboundProxy() {
+	if err := system.ApplyProxySettings(loadOutboundProxySettings()); err != nil {
+		log.Printf("Warning: failed to apply proxy settings: %v", err)
+	}
+}
+
+// loadOutboundProxySettings reads the proxy settings
+func loadOutboundProxySettings() models.OutboundProxySettings {
+	s := models.OutboundProxySettings{NoProxy: []string{}}
+	s.HTTPProxy, _ = settingsRepo.Get(database.SettingOutboundHTTPProxy)
+	s.HTTPSProxy, _ = settingsRepo.Get(database.SettingOutboundHTTPSProxy)
+	if v, err := settingsRepo.Get(database.SettingOutboundNoProxy); err == nil && v != "" {
+		s.NoProxy = strings.Split(v, ",")
+	}
+	return s
+}
+
+// getOutboundProxyHandler handles GET /api/system/proxy
+func getOutboundProxyHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadOutboundProxySettings())
+}
+
+// updateOutboundProxyHandler handles PUT /api/system/proxy. Changes apply to
+// new requests and commands immediately; running containers keep the
+// environment they started with.
+func updateOutboundProxyHandler(c echo.Context) error {
+	s := loadOutboundProxySettings()
+	if err := c.Bind(&s); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if err := system.ValidateProxySettings(&s); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	values := map[string]string{
+		database.SettingOutboundHTTPProxy:  s.HTTPProxy,
+		database.SettingOutboundHTTPSProxy: s.HTTPSProxy,
+		database.SettingOutboundNoProxy:    strings.Join(s.NoProxy, ","),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save proxy settings: " + err.Error(),
+		})
+	}
+	if err := system.ApplyProxySettings(s); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to apply proxy settings: " + err.Error(),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionOutboundProxySettings, "proxy", system.RedactProxySettings(s))
+
+	return c.JSON(http.StatusOK, s)
+}
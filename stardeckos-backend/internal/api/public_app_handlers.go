@@ -0,0 +1,415 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var publicAppRepo *database.PublicAppRepo
+
+// InitPublicAppRepo initializes the public app repository
+func InitPublicAppRepo() {
+	publicAppRepo = database.NewPublicAppRepo()
+}
+
+// kioskUnlockTTL is how long a correct PIN keeps a browser unlocked
+const kioskUnlockTTL = 30 * 24 * time.Hour
+
+var publicAppSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// publicAppPath is the proxy base path a public app is served under, as the
+// browser sees it
+func publicAppPath(slug string) string {
+	return externalPath("/api/public/apps/" + slug)
+}
+
+// publicAppPINKey counts PIN attempts per client and app, so knowing one app's
+// PIN doesn't allow unlimited guesses at another's
+func publicAppPINKey(c echo.Context) string {
+	return c.RealIP() + "|" + c.Param("slug")
+}
+
+// kioskCookieName is the per-app cookie that records a PIN unlock
+func kioskCookieName(slug string) string {
+	return "stardeck_kiosk_" + slug
+}
+
+// kioskCookieKey returns the HMAC key for unlock cookies, generating it on first use
+func kioskCookieKey() ([]byte, error) {
+	if v, err := settingsRepo.Get(database.SettingKioskCookieKey); err == nil && v != "" {
+		return hex.DecodeString(v)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := settingsRepo.Set(database.SettingKioskCookieKey, hex.EncodeToString(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// kioskSignature binds an unlock cookie to the app, its expiry and the current
+// PIN, so changing the PIN locks out every browser
+func kioskSignature(key []byte, app *models.PublicApp, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s|%d|%s", app.Slug, expires, app.PINHash)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// kioskUnlocked reports whether the request carries a valid unlock cookie for the app
+func kioskUnlocked(c echo.Context, app *models.PublicApp) bool {
+	cookie, err := c.Cookie(kioskCookieName(app.Slug))
+	if err != nil {
+		return false
+	}
+	expiry, sig, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	key, err := kioskCookieKey()
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(kioskSignature(key, app, expires)))
+}
+
+// stripCookies removes the named cookies from a request before it is proxied
+func stripCookies(r *http.Request, names ...string) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		strip := false
+		for _, name := range names {
+			if cookie.Name == name {
+				strip = true
+				break
+			}
+		}
+		if !strip {
+			r.AddCookie(cookie)
+		}
+	}
+}
+
+var kioskPINPage = template.Must(template.New("pin").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body{font-family:system-ui,sans-serif;background:#0f172a;color:#e2e8f0;display:flex;align-items:center;justify-content:center;min-height:100vh;margin:0}
+form{background:#1e293b;padding:2rem;border-radius:.75rem;display:flex;flex-direction:column;gap:1rem;min-width:16rem}
+input,button{font-size:1.1rem;padding:.6rem;border-radius:.5rem;border:1px solid #334155}
+button{background:#2563eb;color:#fff;border:none;cursor:pointer}
+.error{color:#f87171;margin:0}
+</style>
+</head>
+<body>
+<form method="post" action="{{.Action}}">
+<h1>{{.Name}}</h1>
+{{if .Failed}}<p class="error">Incorrect PIN</p>{{end}}
+<input type="password" name="pin" inputmode="numeric" autocomplete="off" placeholder="PIN" autofocus required>
+<button type="submit">Unlock</button>
+</form>
+</body>
+</html>
+`))
+
+// renderKioskPINPage serves the PIN prompt for a locked public app
+func renderKioskPINPage(c echo.Context, app *models.PublicApp, failed bool) error {
+	return renderPINPage(c, app.Name, externalPath("/api/public/unlock/"+app.Slug), failed)
+}
+
+// renderPINPage serves a PIN prompt that posts to action
+func renderPINPage(c echo.Context, name, action string, failed bool) error {
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().WriteHeader(http.StatusUnauthorized)
+	return kioskPINPage.Execute(c.Response(), map[string]interface{}{
+		"Name":   name,
+		"Action": action,
+		"Failed": failed,
+	})
+}
+
+// servePublicAppHandler proxies a public app's web UI without a Stardeck session
+func servePublicAppHandler(c echo.Context) error {
+	app, err := publicAppRepo.GetBySlug(c.Param("slug"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "App not found",
+		})
+	}
+
+	if app.PINRequired && !kioskUnlocked(c, app) {
+		if c.Request().Method == http.MethodGet && strings.Contains(c.Request().Header.Get("Accept"), "text/html") {
+			return renderKioskPINPage(c, app, false)
+		}
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "PIN required",
+		})
+	}
+
+	container, err := containerRepo.GetByID(app.ContainerID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "App not found",
+		})
+	}
+
+	// Never hand Stardeck credentials to the app
+	stripCookies(c.Request(), "session_token", kioskCookieName(app.Slug))
+	c.Request().Header.Del("Authorization")
+
+	return proxyWebUI(c, container, publicAppPath(app.Slug))
+}
+
+// listPublicAppsHandler returns the public apps shown on the kiosk index
+func listPublicAppsHandler(c echo.Context) error {
+	apps, err := publicAppRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list apps: " + err.Error(),
+		})
+	}
+
+	listed := make([]map[string]interface{}, 0, len(apps))
+	for _, app := range apps {
+		if !app.Listed {
+			continue
+		}
+		listed = append(listed, map[string]interface{}{
+			"slug":         app.Slug,
+			"name":         app.Name,
+			"icon":         app.Icon,
+			"pin_required": app.PINRequired,
+			"url":          publicAppPath(app.Slug) + "/",
+		})
+	}
+	return c.JSON(http.StatusOK, listed)
+}
+
+// unlockPublicAppHandler checks the shared PIN and sets the unlock cookie
+func unlockPublicAppHandler(c echo.Context) error {
+	app, err := publicAppRepo.GetBySlug(c.Param("slug"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "App not found",
+		})
+	}
+
+	var req models.UnlockPublicAppRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	fromForm := strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationForm)
+
+	if app.PINRequired {
+		if ok, err := auth.VerifyPassword(req.PIN, app.PINHash); err != nil || !ok {
+			Audit.Log(0, "", models.ActionPublicAppUnlockFailed, app.Slug, nil, c.RealIP())
+			if fromForm {
+				return renderKioskPINPage(c, app, true)
+			}
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "Incorrect PIN",
+			})
+		}
+		auth.PINRateLimiter.RecordSuccess(publicAppPINKey(c))
+
+		key, err := kioskCookieKey()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to unlock app: " + err.Error(),
+			})
+		}
+		expires := time.Now().Add(kioskUnlockTTL).Unix()
+		c.SetCookie(&http.Cookie{
+			Name:     kioskCookieName(app.Slug),
+			Value:    fmt.Sprintf("%d.%s", expires, kioskSignature(key, app, expires)),
+			Path:     publicAppPath(app.Slug),
+			HttpOnly: true,
+			Secure:   c.Scheme() == "https",
+			SameSite: http.SameSiteLaxMode,
+			MaxAge:   int(kioskUnlockTTL.Seconds()),
+		})
+	}
+
+	if fromForm {
+		return c.Redirect(http.StatusSeeOther, publicAppPath(app.Slug)+"/")
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"url": publicAppPath(app.Slug) + "/",
+	})
+}
+
+// listContainerPublicAppsHandler returns every published container web UI
+func listContainerPublicAppsHandler(c echo.Context) error {
+	apps, err := publicAppRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list public apps: " + err.Error(),
+		})
+	}
+	if apps == nil {
+		apps = []models.PublicApp{}
+	}
+	for i := range apps {
+		apps[i].URL = publicAppPath(apps[i].Slug) + "/"
+	}
+	return c.JSON(http.StatusOK, apps)
+}
+
+// getContainerPublicAppHandler returns a container's public app settings
+func getContainerPublicAppHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	app, err := publicAppRepo.GetByContainerID(container.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Container web UI is not public",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get public app: " + err.Error(),
+		})
+	}
+	app.URL = publicAppPath(app.Slug) + "/"
+	return c.JSON(http.StatusOK, app)
+}
+
+// updateContainerPublicAppHandler publishes a container's web UI or changes its slug, PIN or listing
+func updateContainerPublicAppHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+	if !container.HasWebUI || container.WebUIPort == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Container does not have a web UI configured",
+		})
+	}
+
+	var req models.UpdatePublicAppRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if !publicAppSlugPattern.MatchString(req.Slug) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Slug must be 1-63 lowercase letters, digits or hyphens",
+		})
+	}
+	if other, err := publicAppRepo.GetBySlug(req.Slug); err == nil && other.ContainerID != container.ID {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Slug is already used by " + other.Name,
+		})
+	}
+
+	app := &models.PublicApp{
+		ContainerID: container.ID,
+		Slug:        req.Slug,
+		Listed:      req.Listed,
+		CreatedBy:   &user.ID,
+	}
+	if existing, err := publicAppRepo.GetByContainerID(container.ID); err == nil {
+		app.PINHash = existing.PINHash
+	}
+	if req.PIN != nil {
+		switch {
+		case *req.PIN == "":
+			app.PINHash = ""
+		case len(*req.PIN) < 4 || len(*req.PIN) > 32:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "PIN must be 4-32 characters",
+			})
+		default:
+			hash, err := auth.HashPassword(*req.PIN)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to hash PIN: " + err.Error(),
+				})
+			}
+			app.PINHash = hash
+		}
+	}
+
+	if err := publicAppRepo.Upsert(app); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to publish web UI: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionPublicAppUpdate, container.Name, map[string]interface{}{
+		"slug":         app.Slug,
+		"pin_required": app.PINHash != "",
+		"listed":       app.Listed,
+	})
+
+	saved, err := publicAppRepo.GetByContainerID(container.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get public app: " + err.Error(),
+		})
+	}
+	saved.URL = publicAppPath(saved.Slug) + "/"
+	return c.JSON(http.StatusOK, saved)
+}
+
+// deleteContainerPublicAppHandler stops serving a container's web UI publicly
+func deleteContainerPublicAppHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+	if err := publicAppRepo.Delete(container.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to unpublish web UI: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionPublicAppRemove, container.Name, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Web UI is no longer public",
+	})
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// createTestPublicApp publishes a container's web UI behind a PIN. Needs openTestDB.
+func createTestPublicApp(t *testing.T, slug, pin string) *models.PublicApp {
+	t.Helper()
+	settingsRepo = database.NewSettingsRepo()
+	InitPublicAppRepo()
+	container := &models.Container{ContainerID: "podman-" + slug, Name: slug, Image: "nginx", HasWebUI: true, WebUIPort: 80}
+	if err := database.NewContainerRepo().Create(container); err != nil {
+		t.Fatalf("create container: %v", err)
+	}
+	hash, err := auth.HashPassword(pin)
+	if err != nil {
+		t.Fatalf("hash PIN: %v", err)
+	}
+	app := &models.PublicApp{ContainerID: container.ID, Slug: slug, Name: slug, PINRequired: true, PINHash: hash}
+	if err := publicAppRepo.Upsert(app); err != nil {
+		t.Fatalf("publish app: %v", err)
+	}
+	return app
+}
+
+func TestPINUnlockKeepsLoginLockout(t *testing.T) {
+	openTestDB(t)
+	createTestPublicApp(t, "media", "2468")
+
+	const ip = "192.0.2.10"
+	for i := 0; i < 5; i++ {
+		auth.LoginRateLimiter.Allow(ip) // Failed password guesses
+	}
+	t.Cleanup(func() { auth.LoginRateLimiter.RecordSuccess(ip) })
+
+	e := echo.New()
+	e.POST("/api/public/unlock/:slug", unlockPublicAppHandler, auth.PINRateLimiter.MiddlewareBy(publicAppPINKey))
+	req := httptest.NewRequest(http.MethodPost, "/api/public/unlock/media", strings.NewReader(url.Values{"pin": {"2468"}}.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.RemoteAddr = ip + ":40000"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("unlock with the right PIN: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if left := auth.LoginRateLimiter.GetRemainingAttempts(ip); left != 0 {
+		t.Errorf("login attempts left after a PIN unlock: %d, want 0", left)
+	}
+}
@@ -0,0 +1,572 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var userQuotaRepo *database.UserQuotaRepo
+
+// defaultAppDataRoot is where container data counted against disk quotas lives
+// unless quota.app_data_root says otherwise
+const defaultAppDataRoot = "/srv"
+
+// InitUserQuotaRepo initializes the user quota repository
+func InitUserQuotaRepo() {
+	userQuotaRepo = database.NewUserQuotaRepo()
+}
+
+// appDataRoot returns the directory whose bind-mounted data counts towards disk quotas
+func appDataRoot() string {
+	if v, err := settingsRepo.Get(database.SettingQuotaAppDataRoot); err == nil && filepath.IsAbs(v) {
+		return filepath.Clean(v)
+	}
+	return defaultAppDataRoot
+}
+
+// quotaUsage totals what a user's containers and stacks reserve. A stack ID in
+// exclude is skipped, so redeploying a stack doesn't count its old containers.
+// Disk usage covers owned stack directories and bind mounts under the app data root.
+func quotaUsage(ctx context.Context, userID int64, exclude string) (models.QuotaUsage, error) {
+	var usage models.QuotaUsage
+	root := appDataRoot()
+	var dirs []string
+
+	count := func(nameOrID string) {
+		inspect, err := podmanService.InspectContainer(ctx, nameOrID)
+		if err != nil {
+			return // Removed outside Stardeck
+		}
+		usage.Containers++
+		usage.MemoryBytes += inspect.HostConfig.Memory
+		usage.CPUs += float64(inspect.HostConfig.NanoCpus) / 1e9
+		for _, m := range inspect.Mounts {
+			if m.Type == "bind" && pathWithin(filepath.Clean(m.Source), root) {
+				dirs = append(dirs, filepath.Clean(m.Source))
+			}
+		}
+	}
+
+	containers, err := containerRepo.ListByOwner(userID)
+	if err != nil {
+		return usage, err
+	}
+	for _, ct := range containers {
+		count(ct.ContainerID)
+	}
+
+	stacks, err := stackRepo.ListByOwner(userID)
+	if err != nil {
+		return usage, err
+	}
+	for _, stack := range stacks {
+		if stack.Path != "" {
+			dirs = append(dirs, filepath.Clean(stack.Path))
+		}
+		if stack.ID == exclude {
+			continue
+		}
+		stackContainers, err := podmanService.GetStackContainers(ctx, stack.Name)
+		if err != nil {
+			continue
+		}
+		for _, sc := range stackContainers {
+			count(sc.Name)
+		}
+	}
+
+	// Count each directory once, even when one is mounted inside another
+	sort.Strings(dirs)
+	var counted []string
+	for _, dir := range dirs {
+		if len(counted) > 0 && pathWithin(dir, counted[len(counted)-1]) {
+			continue
+		}
+		counted = append(counted, dir)
+		usage.DiskBytes += system.DirectorySize(dir)
+	}
+
+	return usage, nil
+}
+
+// containerQuotaDemand is what creating a single container adds to its owner's usage
+func containerQuotaDemand(req *models.CreateContainerRequest) models.QuotaDemand {
+	demand := models.QuotaDemand{
+		Containers:  1,
+		MemoryBytes: req.MemoryLimit,
+		CPUs:        req.CPULimit,
+	}
+	name := req.Name
+	if name == "" {
+		name = req.Image
+	}
+	if req.MemoryLimit <= 0 {
+		demand.MissingMemory = []string{name}
+	}
+	if req.CPULimit <= 0 {
+		demand.MissingCPU = []string{name}
+	}
+	return demand
+}
+
+// stackQuotaDemand is what deploying a stack's active services adds to its owner's usage
+func stackQuotaDemand(stack *models.Stack) models.QuotaDemand {
+	var demand models.QuotaDemand
+	active := make(map[string]bool)
+	for _, p := range stack.Profiles {
+		active[p] = true
+	}
+	for _, svc := range system.ParseComposeServices(stack.ComposeContent) {
+		enabled := len(svc.Profiles) == 0
+		for _, p := range svc.Profiles {
+			enabled = enabled || active[p]
+		}
+		if !enabled {
+			continue
+		}
+		demand.Containers++
+		demand.MemoryBytes += svc.MemoryLimit
+		demand.CPUs += svc.CPULimit
+		if svc.MemoryLimit <= 0 {
+			demand.MissingMemory = append(demand.MissingMemory, svc.Name)
+		}
+		if svc.CPULimit <= 0 {
+			demand.MissingCPU = append(demand.MissingCPU, svc.Name)
+		}
+	}
+	return demand
+}
+
+// checkQuota evaluates a deployment against its owner's quota. It returns one
+// validation result per limit and whether the deployment fits; users without
+// a quota always fit and get no results.
+func checkQuota(ctx context.Context, ownerID int64, demand models.QuotaDemand, exclude string) ([]ValidationResult, bool, error) {
+	quota, err := userQuotaRepo.Get(ownerID)
+	if err == sql.ErrNoRows {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	usage, err := quotaUsage(ctx, ownerID, exclude)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var results []ValidationResult
+	add := func(check string, exceeded bool, message, details string) {
+		status := "ok"
+		if exceeded {
+			status = "error"
+		}
+		results = append(results, ValidationResult{Check: check, Status: status, Message: message, Details: details})
+	}
+
+	if quota.MaxContainers > 0 {
+		total := usage.Containers + demand.Containers
+		add("quota_containers", total > quota.MaxContainers,
+			fmt.Sprintf("%d of %d containers", total, quota.MaxContainers),
+			fmt.Sprintf("%d in use, %d requested", usage.Containers, demand.Containers))
+	}
+	// Unlimited containers could use the whole host, so a quota needs every limit declared
+	if quota.MaxMemoryBytes > 0 && len(demand.MissingMemory) > 0 {
+		add("quota_memory_limit", true, "A memory limit is required under a memory quota",
+			"Set a memory limit for: "+strings.Join(demand.MissingMemory, ", "))
+	}
+	if quota.MaxCPUs > 0 && len(demand.MissingCPU) > 0 {
+		add("quota_cpu_limit", true, "A CPU limit is required under a CPU quota",
+			"Set a CPU limit for: "+strings.Join(demand.MissingCPU, ", "))
+	}
+	if quota.MaxMemoryBytes > 0 {
+		total := usage.MemoryBytes + demand.MemoryBytes
+		add("quota_memory", total > quota.MaxMemoryBytes,
+			fmt.Sprintf("%s of %s memory reserved", humanBytes(total), humanBytes(quota.MaxMemoryBytes)),
+			fmt.Sprintf("%s in use, %s requested", humanBytes(usage.MemoryBytes), humanBytes(demand.MemoryBytes)))
+	}
+	if quota.MaxCPUs > 0 {
+		total := usage.CPUs + demand.CPUs
+		add("quota_cpu", total > quota.MaxCPUs+1e-9,
+			fmt.Sprintf("%.2f of %.2f CPUs reserved", total, quota.MaxCPUs),
+			fmt.Sprintf("%.2f in use, %.2f requested", usage.CPUs, demand.CPUs))
+	}
+	if quota.MaxDiskBytes > 0 {
+		// New data can't be predicted, so this only blocks once the limit is reached
+		add("quota_disk", usage.DiskBytes >= quota.MaxDiskBytes,
+			fmt.Sprintf("%s of %s disk used", humanBytes(usage.DiskBytes), humanBytes(quota.MaxDiskBytes)),
+			"Counted under "+appDataRoot()+" and in stack directories")
+	}
+
+	for _, r := range results {
+		if r.Status == "error" {
+			return results, false, nil
+		}
+	}
+	return results, true, nil
+}
+
+// quotaExceededResponse is the rejection for a deployment over quota
+func quotaExceededResponse(c echo.Context, results []ValidationResult) error {
+	message := "Quota exceeded"
+	for _, r := range results {
+		if r.Status == "error" {
+			message += ": " + r.Message
+			break
+		}
+	}
+	return c.JSON(http.StatusForbidden, map[string]interface{}{
+		"error":   message,
+		"valid":   false,
+		"results": results,
+	})
+}
+
+// humanBytes formats a byte count for messages
+func humanBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// userQuotaStatus builds a user's quota and usage view
+func userQuotaStatus(ctx context.Context, user *models.User) (*models.UserQuotaStatus, error) {
+	status := &models.UserQuotaStatus{UserID: user.ID, Username: user.Username}
+	quota, err := userQuotaRepo.Get(user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	status.Quota = quota
+	status.Usage, err = quotaUsage(ctx, user.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// listUserQuotasHandler returns every user's quota and usage
+func listUserQuotasHandler(c echo.Context) error {
+	users, err := userRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list users: " + err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+	defer cancel()
+
+	result := make([]models.UserQuotaStatus, 0, len(users))
+	for _, u := range users {
+		status, err := userQuotaStatus(ctx, u)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to get quota usage: " + err.Error(),
+			})
+		}
+		result = append(result, *status)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// userFromParam loads the user named by the :id route parameter
+func userFromParam(c echo.Context) (*models.User, error) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_user_id"),
+		})
+	}
+	user, err := userRepo.GetByID(id)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.user_not_found"),
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get user: " + err.Error(),
+		})
+	}
+	return user, nil
+}
+
+// getUserQuotaHandler returns a user's quota and usage
+func getUserQuotaHandler(c echo.Context) error {
+	user, err := userFromParam(c)
+	if user == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	status, err := userQuotaStatus(ctx, user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get quota usage: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// getMyQuotaHandler returns the current user's quota and usage
+func getMyQuotaHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	status, err := userQuotaStatus(ctx, user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get quota usage: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// updateUserQuotaHandler sets a user's quota. Existing deployments are never
+// stopped; a lowered quota only blocks new ones.
+func updateUserQuotaHandler(c echo.Context) error {
+	target, err := userFromParam(c)
+	if target == nil {
+		return err
+	}
+
+	var req models.UpdateUserQuotaRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if req.MaxContainers < 0 || req.MaxMemoryBytes < 0 || req.MaxCPUs < 0 || req.MaxDiskBytes < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "quota limits cannot be negative (use 0 for unlimited)",
+		})
+	}
+
+	quota := &models.UserQuota{
+		UserID:         target.ID,
+		MaxContainers:  req.MaxContainers,
+		MaxMemoryBytes: req.MaxMemoryBytes,
+		MaxCPUs:        req.MaxCPUs,
+		MaxDiskBytes:   req.MaxDiskBytes,
+	}
+	if err := userQuotaRepo.Upsert(quota); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save quota: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionQuotaUpdate, target.Username, map[string]interface{}{
+		"max_containers":   quota.MaxContainers,
+		"max_memory_bytes": quota.MaxMemoryBytes,
+		"max_cpus":         quota.MaxCPUs,
+		"max_disk_bytes":   quota.MaxDiskBytes,
+	})
+
+	return c.JSON(http.StatusOK, quota)
+}
+
+// deleteUserQuotaHandler removes a user's quota, leaving them unlimited
+func deleteUserQuotaHandler(c echo.Context) error {
+	target, err := userFromParam(c)
+	if target == nil {
+		return err
+	}
+
+	if err := userQuotaRepo.Delete(target.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete quota: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionQuotaDelete, target.Username, nil)
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
+
+// getQuotaSettingsHandler returns the app data root used for disk quotas
+func getQuotaSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{
+		"app_data_root": appDataRoot(),
+	})
+}
+
+// updateQuotaSettingsHandler sets the app data root used for disk quotas
+func updateQuotaSettingsHandler(c echo.Context) error {
+	var req struct {
+		AppDataRoot string `json:"app_data_root"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	root := filepath.Clean(strings.TrimSpace(req.AppDataRoot))
+	if !filepath.IsAbs(root) || root == "/" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "app_data_root must be an absolute path below /",
+		})
+	}
+
+	if err := settingsRepo.Set(database.SettingQuotaAppDataRoot, root); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+	Audit.LogFromContext(c, models.ActionQuotaUpdate, "app_data_root", map[string]string{
+		database.SettingQuotaAppDataRoot: root,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"app_data_root": root,
+	})
+}
+
+// checkStackQuotaHandler previews whether deploying a stack fits its owner's quota
+func checkStackQuotaHandler(c echo.Context) error {
+	stack, err := stackRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+
+	results, ok := []ValidationResult{}, true
+	if stack.CreatedBy != nil {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+		defer cancel()
+
+		results, ok, err = checkQuota(ctx, *stack.CreatedBy, stackQuotaDemand(stack), stack.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to check quota: " + err.Error(),
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"valid":   ok,
+		"results": results,
+	})
+}
+
+// setOwnerTarget validates the user a container or stack is being transferred to
+func setOwnerTarget(c echo.Context) (*models.User, error) {
+	var req models.SetOwnerRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	owner, err := userRepo.GetByID(req.UserID)
+	if errors.Is(err, database.ErrUserNotFound) {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.user_not_found"),
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get user: " + err.Error(),
+		})
+	}
+	return owner, nil
+}
+
+// setContainerOwnerHandler transfers a managed container to another user. The
+// new owner's quota isn't checked; only new deployments are held to quotas.
+func setContainerOwnerHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Container is not managed by Stardeck; adopt it first",
+		})
+	}
+
+	owner, err := setOwnerTarget(c)
+	if owner == nil {
+		return err
+	}
+
+	if err := containerRepo.SetOwner(container.ID, owner.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to set owner: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionContainerOwner, container.Name, map[string]interface{}{
+		"owner": owner.Username,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"id":         container.ID,
+		"created_by": owner.ID,
+	})
+}
+
+// setStackOwnerHandler transfers a stack to another user
+func setStackOwnerHandler(c echo.Context) error {
+	stack, err := stackRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+
+	owner, err := setOwnerTarget(c)
+	if owner == nil {
+		return err
+	}
+
+	if err := stackRepo.SetOwner(stack.ID, owner.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to set owner: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionStackOwner, stack.Name, map[string]interface{}{
+		"owner": owner.Username,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"id":         stack.ID,
+		"created_by": owner.ID,
+	})
+}
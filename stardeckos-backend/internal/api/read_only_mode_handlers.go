@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// loadReadOnlyMode reads the read-only mode state
+func loadReadOnlyMode() models.ReadOnlyMode {
+	var m models.ReadOnlyMode
+	if v, err := settingsRepo.GetBool(database.SettingReadOnlyMode); err == nil {
+		m.Enabled = v
+	}
+	if !m.Enabled {
+		return m
+	}
+	m.Message, _ = settingsRepo.Get(database.SettingReadOnlyMessage)
+	m.StartedAt, _ = settingsRepo.Get(database.SettingReadOnlyStarted)
+	m.StartedBy, _ = settingsRepo.Get(database.SettingReadOnlyBy)
+	return m
+}
+
+// getReadOnlyModeHandler returns the current read-only mode state
+func getReadOnlyModeHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, loadReadOnlyMode())
+}
+
+// updateReadOnlyModeHandler turns read-only mode on or off
+func updateReadOnlyModeHandler(c echo.Context) error {
+	var req models.ReadOnlyMode
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.Message = strings.TrimSpace(req.Message)
+	if len(req.Message) > maxBannerLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "message is too long",
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	current := loadReadOnlyMode()
+
+	values := map[string]string{
+		database.SettingReadOnlyMode:    strconv.FormatBool(req.Enabled),
+		database.SettingReadOnlyMessage: req.Message,
+	}
+	// Keep the original start when only the message changes
+	if req.Enabled && !current.Enabled {
+		values[database.SettingReadOnlyStarted] = time.Now().Format(time.RFC3339)
+		values[database.SettingReadOnlyBy] = user.Username
+	} else if !req.Enabled {
+		values[database.SettingReadOnlyStarted] = ""
+		values[database.SettingReadOnlyBy] = ""
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save read-only mode: " + err.Error(),
+		})
+	}
+
+	updated := loadReadOnlyMode()
+
+	if req.Enabled != current.Enabled {
+		action := models.ActionReadOnlyModeOff
+		title := "Changes enabled again"
+		level := models.NotificationSuccess
+		if req.Enabled {
+			action = models.ActionReadOnlyModeOn
+			title = "Read-only mode"
+			level = models.NotificationWarning
+		}
+		logAudit(user, action, "system", map[string]interface{}{
+			"message": req.Message,
+		})
+		notifyRoles(models.Notification{
+			Type:    models.NotificationReadOnlyMode,
+			Level:   level,
+			Title:   title,
+			Message: req.Message,
+			Data: map[string]interface{}{
+				"read_only": updated,
+			},
+		}, models.RoleAdmin, models.RoleOperator, models.RoleViewer)
+	}
+
+	return c.JSON(http.StatusOK, updated)
+}
@@ -35,7 +35,7 @@ func createRealmHandler(c echo.Context) error {
 	var req models.CreateRealmRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -128,7 +128,7 @@ func updateRealmHandler(c echo.Context) error {
 	var req models.UpdateRealmRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
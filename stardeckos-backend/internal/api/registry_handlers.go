@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var registryCredentialRepo *database.RegistryCredentialRepo
+
+// imagePromoteTimeout bounds a single promotion; multi-arch copies of large images are slow
+const imagePromoteTimeout = time.Hour
+
+// imageReferencePattern accepts registry/repository[:tag][@digest] without a transport
+var imageReferencePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._\-/:@]*$`)
+
+// InitRegistryCredentialRepo initializes the registry credential repository
+func InitRegistryCredentialRepo() {
+	registryCredentialRepo = database.NewRegistryCredentialRepo()
+}
+
+// registryCredentialSecret names the Podman secret holding a credential's password
+func registryCredentialSecret(id string) string {
+	return "stardeck-registry-" + id
+}
+
+// resolveRegistryAuth loads a saved credential and its password from the secret store
+func resolveRegistryAuth(ctx context.Context, id *string) (*models.RegistryAuth, error) {
+	if id == nil || *id == "" {
+		return nil, nil
+	}
+	cred, err := registryCredentialRepo.GetByID(*id)
+	if err != nil {
+		return nil, fmt.Errorf("registry credential %s not found", *id)
+	}
+	password, err := podmanService.SecretValue(ctx, cred.SecretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password for %s: %w", cred.Name, err)
+	}
+	return &models.RegistryAuth{
+		Registry: cred.Registry,
+		Username: cred.Username,
+		Password: password,
+	}, nil
+}
+
+// listRegistryCredentialsHandler returns saved registry logins (without passwords)
+func listRegistryCredentialsHandler(c echo.Context) error {
+	creds, err := registryCredentialRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list credentials: " + err.Error(),
+		})
+	}
+	if creds == nil {
+		creds = []models.RegistryCredential{}
+	}
+	return c.JSON(http.StatusOK, creds)
+}
+
+// createRegistryCredentialHandler saves a registry login, storing the password as a Podman secret
+func createRegistryCredentialHandler(c echo.Context) error {
+	var req models.CreateRegistryCredentialRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Registry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(req.Registry), "https://"), "/")
+	if req.Name == "" || req.Registry == "" || req.Username == "" || req.Password == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "name, registry, username and password are required",
+		})
+	}
+	if strings.ContainsAny(req.Registry, "/ ") {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "registry must be a host name, optionally with a port",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	user := c.Get("user").(*models.User)
+	cred := &models.RegistryCredential{
+		Name:      req.Name,
+		Registry:  req.Registry,
+		Username:  req.Username,
+		CreatedBy: &user.ID,
+	}
+	cred.ID = uuid.New().String()
+	cred.SecretName = registryCredentialSecret(cred.ID)
+
+	if err := podmanService.CreateSecret(ctx, cred.SecretName, req.Password); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to store password: " + err.Error(),
+		})
+	}
+	if err := registryCredentialRepo.Create(cred); err != nil {
+		podmanService.RemoveSecret(ctx, cred.SecretName)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save credential: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionRegistryCredentialCreate, cred.Name, map[string]interface{}{
+		"registry": cred.Registry,
+		"username": cred.Username,
+	})
+
+	return c.JSON(http.StatusCreated, cred)
+}
+
+// deleteRegistryCredentialHandler removes a saved registry login and its secret
+func deleteRegistryCredentialHandler(c echo.Context) error {
+	cred, err := registryCredentialRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Credential not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get credential: " + err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	if err := registryCredentialRepo.Delete(cred.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete credential: " + err.Error(),
+		})
+	}
+	if err := podmanService.RemoveSecret(ctx, cred.SecretName); err != nil {
+		c.Logger().Warnf("Failed to remove secret %s: %v", cred.SecretName, err)
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionRegistryCredentialDelete, cred.Name, map[string]interface{}{
+		"registry": cred.Registry,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
+
+// promoteImageHandler copies an image from one registry to another over a WebSocket,
+// streaming skopeo progress. The first message is a PromoteImageRequest.
+func promoteImageHandler(c echo.Context) error {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	_, message, err := ws.ReadMessage()
+	if err != nil {
+		return nil
+	}
+
+	var req models.PromoteImageRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  tr(c, "error.invalid_request", "error", err.Error()),
+		})
+		return nil
+	}
+
+	if !system.SkopeoAvailable() {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "skopeo is not installed (dnf install skopeo)",
+		})
+		return nil
+	}
+
+	req.Source = strings.TrimSpace(req.Source)
+	req.Destination = strings.TrimSpace(req.Destination)
+	if !imageReferencePattern.MatchString(req.Source) || !imageReferencePattern.MatchString(req.Destination) {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "source and destination must be image references such as docker.io/library/nginx:latest",
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), imagePromoteTimeout)
+	defer cancel()
+
+	opts := models.ImageCopyOptions{
+		Source:           "docker://" + req.Source,
+		Destination:      "docker://" + req.Destination,
+		AllArchitectures: req.AllArchitectures,
+		SrcTLSVerify:     req.SourceTLSVerify == nil || *req.SourceTLSVerify,
+		DestTLSVerify:    req.DestTLSVerify == nil || *req.DestTLSVerify,
+	}
+	if req.SourceLocal {
+		opts.Source = "containers-storage:" + req.Source
+	}
+
+	if opts.SourceAuth, err = resolveRegistryAuth(ctx, req.SourceCredentialID); err != nil {
+		ws.WriteJSON(map[string]interface{}{"status": "error", "error": err.Error()})
+		return nil
+	}
+	if opts.DestAuth, err = resolveRegistryAuth(ctx, req.DestCredentialID); err != nil {
+		ws.WriteJSON(map[string]interface{}{"status": "error", "error": err.Error()})
+		return nil
+	}
+
+	ws.WriteJSON(map[string]interface{}{
+		"status":  "copying",
+		"message": fmt.Sprintf("Copying %s to %s", req.Source, req.Destination),
+	})
+
+	outputChan := make(chan string, 100)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- podmanService.CopyImage(ctx, opts, outputChan)
+	}()
+
+	for line := range outputChan {
+		ws.WriteJSON(map[string]interface{}{
+			"status": "copying",
+			"output": line,
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	details := map[string]interface{}{
+		"source":            req.Source,
+		"destination":       req.Destination,
+		"all_architectures": req.AllArchitectures,
+	}
+
+	if err := <-errChan; err != nil {
+		details["error"] = err.Error()
+		logAudit(user, models.ActionImagePromote, req.Destination, details)
+		ws.WriteJSON(map[string]interface{}{
+			"status": "error",
+			"error":  "Failed to copy image: " + err.Error(),
+		})
+		return nil
+	}
+
+	logAudit(user, models.ActionImagePromote, req.Destination, details)
+	ws.WriteJSON(map[string]interface{}{
+		"status":      "complete",
+		"message":     "Image promoted successfully",
+		"destination": req.Destination,
+	})
+	return nil
+}
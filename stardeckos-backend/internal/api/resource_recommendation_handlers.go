@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	// recommendationWindowHours is how much metric history is analysed by default
+	recommendationWindowHours = 7 * 24
+	// recommendationMinSamples is how many samples a container needs before its
+	// usage is trusted
+	recommendationMinSamples = 60
+
+	cpuHeadroom    = 1.5 // CPU bursts are throttled, not fatal, so p95 gets 50% extra
+	memoryHeadroom = 1.3 // Going over the memory limit kills the container
+	peakHeadroom   = 1.1 // Applied to the observed memory peak, which the limit never goes below
+
+	minCPULimit    = 0.1
+	cpuStep        = 0.05
+	minMemoryLimit = 64 << 20
+	memoryStep     = 16 << 20
+
+	// recommendationSlack is how far above the recommendation a limit may be
+	// before lowering it is suggested
+	recommendationSlack = 1.5
+)
+
+// percentile returns the p-th percentile of sorted values, by nearest rank
+func percentile[T int64 | float64](sorted []T, p float64) T {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// limitAction compares a current limit with a recommended one
+func limitAction(current, recommended float64) string {
+	switch {
+	case current <= 0:
+		return models.RecommendationSet
+	case current < recommended:
+		return models.RecommendationRaise
+	case current > recommended*recommendationSlack:
+		return models.RecommendationLower
+	}
+	return models.RecommendationKeep
+}
+
+// recommendResources sizes a container's limits from its recorded metrics
+func recommendResources(ctx context.Context, container *models.Container, hours int) (*models.ResourceRecommendation, error) {
+	rec := &models.ResourceRecommendation{
+		ContainerID: container.ID,
+		Name:        container.Name,
+		WindowHours: hours,
+	}
+	if config, err := podmanService.GetContainerConfig(ctx, container.ContainerID); err == nil {
+		rec.CurrentCPULimit, rec.CurrentMemoryLimit = config.CPULimit, config.MemoryLimit
+		rec.Stack = config.Labels["com.docker.compose.project"]
+	}
+
+	metrics, err := metricsRepo.GetRecent(container.ID, hours)
+	if err != nil {
+		return nil, err
+	}
+	rec.Samples = len(metrics)
+	if rec.Samples < recommendationMinSamples {
+		rec.CPUAction, rec.MemoryAction = models.RecommendationInsufficient, models.RecommendationInsufficient
+		return rec, nil
+	}
+
+	cpu := make([]float64, 0, len(metrics))
+	memory := make([]int64, 0, len(metrics))
+	for _, m := range metrics {
+		cpu = append(cpu, m.CPUPercent/100) // Podman reports 100% per core
+		memory = append(memory, m.MemoryUsed)
+	}
+	slices.Sort(cpu)
+	slices.Sort(memory)
+	rec.CPUP95, rec.CPUMax = percentile(cpu, 95), cpu[len(cpu)-1]
+	rec.MemoryP95, rec.MemoryMax = percentile(memory, 95), memory[len(memory)-1]
+
+	cpuLimit := math.Ceil(rec.CPUP95*cpuHeadroom/cpuStep) * cpuStep
+	rec.RecommendedCPULimit = math.Min(math.Max(cpuLimit, minCPULimit), float64(runtime.NumCPU()))
+	memoryLimit := math.Max(float64(rec.MemoryP95)*memoryHeadroom, float64(rec.MemoryMax)*peakHeadroom)
+	rec.RecommendedMemoryLimit = max(int64(math.Ceil(memoryLimit/memoryStep))*memoryStep, minMemoryLimit)
+
+	rec.CPUAction = limitAction(rec.CurrentCPULimit, rec.RecommendedCPULimit)
+	rec.MemoryAction = limitAction(float64(rec.CurrentMemoryLimit), float64(rec.RecommendedMemoryLimit))
+	return rec, nil
+}
+
+// recommendationHours reads the analysis window from the hours query parameter
+func recommendationHours(c echo.Context) int {
+	if hours, err := strconv.Atoi(c.QueryParam("hours")); err == nil && hours > 0 {
+		return hours
+	}
+	return recommendationWindowHours
+}
+
+// listResourceRecommendationsHandler handles GET /api/containers/recommendations
+func listResourceRecommendationsHandler(c echo.Context) error {
+	containers, err := containerRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.list_containers", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
+	defer cancel()
+
+	hours := recommendationHours(c)
+	recs := make([]*models.ResourceRecommendation, 0, len(containers))
+	for i := range containers {
+		rec, err := recommendResources(ctx, &containers[i], hours)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to analyse metrics: " + err.Error(),
+			})
+		}
+		recs = append(recs, rec)
+	}
+	return c.JSON(http.StatusOK, recs)
+}
+
+// getResourceRecommendationHandler handles GET /api/containers/:id/recommendation
+func getResourceRecommendationHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	rec, err := recommendResources(ctx, container, recommendationHours(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to analyse metrics: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, rec)
+}
+
+// updateContainerResources changes a container's limits in place and records
+// the new configuration
+func updateContainerResources(c echo.Context, container *models.Container, req models.UpdateContainerResourcesRequest, details map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	if err := podmanService.UpdateContainerResources(ctx, container.ContainerID, req.CPULimit, req.MemoryLimit); err != nil {
+		return err
+	}
+
+	user := c.Get("user").(*models.User)
+	recordContainerConfig(ctx, container, models.ConfigSnapshotEdit, &user.ID)
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["cpu_limit"] = req.CPULimit
+	details["memory_limit"] = req.MemoryLimit
+	logAudit(user, models.ActionContainerResources, container.Name, details)
+	return nil
+}
+
+// updateContainerResourcesHandler handles PUT /api/containers/:id/resources.
+// Limits change without a restart.
+func updateContainerResourcesHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.UpdateContainerResourcesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if req.CPULimit < 0 || req.MemoryLimit < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "cpu_limit and memory_limit can't be negative",
+		})
+	}
+	if req.MemoryLimit > 0 && req.MemoryLimit < 6<<20 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "memory_limit must be at least 6 MiB",
+		})
+	}
+
+	if err := updateContainerResources(c, container, req, nil); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update resources: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, req)
+}
+
+// applyResourceRecommendationHandler handles POST
+// /api/containers/:id/recommendation/apply, setting the recommended limits
+// through the live update
+func applyResourceRecommendationHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	var req models.ApplyRecommendationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	applyCPU := req.CPU == nil && req.Memory == nil || req.CPU != nil && *req.CPU
+	applyMemory := req.CPU == nil && req.Memory == nil || req.Memory != nil && *req.Memory
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	rec, err := recommendResources(ctx, container, recommendationHours(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to analyse metrics: " + err.Error(),
+		})
+	}
+	if rec.Samples < recommendationMinSamples {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Not enough metrics recorded yet to recommend limits",
+		})
+	}
+
+	var update models.UpdateContainerResourcesRequest
+	if applyCPU {
+		update.CPULimit = rec.RecommendedCPULimit
+	}
+	if applyMemory {
+		update.MemoryLimit = rec.RecommendedMemoryLimit
+	}
+	err = updateContainerResources(c, container, update, map[string]interface{}{
+		"recommendation": true,
+		"samples":        rec.Samples,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update resources: " + err.Error(),
+		})
+	}
+
+	if applyCPU {
+		rec.CurrentCPULimit = update.CPULimit
+	}
+	if applyMemory {
+		rec.CurrentMemoryLimit = update.MemoryLimit
+	}
+	rec.CPUAction = limitAction(rec.CurrentCPULimit, rec.RecommendedCPULimit)
+	rec.MemoryAction = limitAction(float64(rec.CurrentMemoryLimit), float64(rec.RecommendedMemoryLimit))
+	return c.JSON(http.StatusOK, rec)
+}
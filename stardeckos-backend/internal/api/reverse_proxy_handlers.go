@@ -0,0 +1,272 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// forwardedHeaders are the headers a proxy uses to pass on the client's
+// address, scheme and host. Echo reads them for RealIP and Scheme, so they
+// are dropped from requests that don't come from a trusted proxy.
+var forwardedHeaders = []string{
+	"Forwarded",
+	echo.HeaderXForwardedFor,
+	echo.HeaderXForwardedProto,
+	echo.HeaderXForwardedProtocol,
+	echo.HeaderXForwardedSsl,
+	echo.HeaderXUrlScheme,
+	echo.HeaderXRealIP,
+	"X-Forwarded-Host",
+	"X-Forwarded-Port",
+	"X-Forwarded-Prefix",
+}
+
+// proxyPolicy is the parsed reverse proxy settings, swapped whole when they
+// change
+type proxyPolicy struct {
+	trusted  []*net.IPNet
+	basePath string
+}
+
+var currentProxies atomic.Pointer[proxyPolicy]
+
+// proxyPolicyFor returns the current policy, loading it on first use
+func proxyPolicyFor() *proxyPolicy {
+	if p := currentProxies.Load(); p != nil {
+		return p
+	}
+	applyReverseProxySettings(loadReverseProxySettings())
+	return currentProxies.Load()
+}
+
+func (p *proxyPolicy) trusts(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, n := range p.trusted {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the address of the peer a request came from
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ClientIP is Echo's IPExtractor. Behind trusted proxies it is the last
+// X-Forwarded-For hop that isn't one of them; otherwise it is the peer, so a
+// client can't put another address in the audit log or dodge rate limits.
+func ClientIP(r *http.Request) string {
+	p := proxyPolicyFor()
+	ip := remoteIP(r)
+	if !p.trusts(ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values(echo.HeaderXForwardedFor) {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	if len(hops) == 0 {
+		if real := r.Header.Get(echo.HeaderXRealIP); net.ParseIP(real) != nil {
+			return real
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		ip = hops[i]
+		if !p.trusts(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// ReverseProxyMiddleware runs before routing. It drops forwarding headers
+// from untrusted peers, takes the host from X-Forwarded-Host for trusted
+// ones, and strips the base path so routes match when Stardeck is served
+// from a subdirectory. Requests without the base path are served too, for
+// proxies that strip it themselves.
+func ReverseProxyMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := proxyPolicyFor()
+			r := c.Request()
+
+			if p.trusts(remoteIP(r)) {
+				if host, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Host"), ","); strings.TrimSpace(host) != "" {
+					r.Host = strings.TrimSpace(host)
+				}
+			} else {
+				for _, header := range forwardedHeaders {
+					r.Header.Del(header)
+				}
+			}
+
+			if base := p.basePath; base != "" {
+				if r.URL.Path == base {
+					target := base + "/"
+					if r.URL.RawQuery != "" {
+						target += "?" + r.URL.RawQuery
+					}
+					return c.Redirect(http.StatusPermanentRedirect, target)
+				}
+				if strings.HasPrefix(r.URL.Path, base+"/") {
+					r.URL.Path = strings.TrimPrefix(r.URL.Path, base)
+					r.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, base)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// externalPath returns the path the browser uses for a route, with the base
+// path in front
+func externalPath(p string) string {
+	return proxyPolicyFor().basePath + p
+}
+
+// loadReverseProxySettings reads the reverse proxy settings
+func loadReverseProxySettings() models.ReverseProxySettings {
+	s := models.ReverseProxySettings{TrustedProxies: []string{}}
+	if v, err := settingsRepo.Get(database.SettingTrustedProxies); err == nil && v != "" {
+		s.TrustedProxies = strings.Split(v, ",")
+	}
+	s.BasePath, _ = settingsRepo.Get(database.SettingBasePath)
+	return s
+}
+
+// applyReverseProxySettings swaps in the policy the settings describe.
+// Entries were validated when saved; any that no longer parse are skipped.
+func applyReverseProxySettings(s models.ReverseProxySettings) {
+	p := &proxyPolicy{basePath: s.BasePath}
+	for _, entry := range s.TrustedProxies {
+		if n, err := parseTrustedProxy(entry); err == nil {
+			p.trusted = append(p.trusted, n)
+		}
+	}
+	currentProxies.Store(p)
+}
+
+// parseTrustedProxy parses a CIDR, or an address as a network of one
+func parseTrustedProxy(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if ip := net.ParseIP(entry); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, n, err := net.ParseCIDR(entry)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not an IP address or CIDR", entry)
+	}
+	return n, nil
+}
+
+// normalizeBasePath cleans a base path to /a/b form, or "" for the root
+func normalizeBasePath(base string) (string, error) {
+	base = strings.TrimSpace(base)
+	if base == "" || base == "/" {
+		return "", nil
+	}
+	if !strings.HasPrefix(base, "/") || strings.ContainsAny(base, "?#%\\ ") {
+		return "", fmt.Errorf("base_path must be an absolute path such as /stardeck")
+	}
+	cleaned := path.Clean(base)
+	if cleaned == "/api" || strings.HasPrefix(cleaned, "/api/") {
+		return "", fmt.Errorf("base_path can't be under /api")
+	}
+	return cleaned, nil
+}
+
+// reverseProxyStatus reports the settings and how the request was read
+func reverseProxyStatus(c echo.Context) models.ReverseProxyStatus {
+	remote := remoteIP(c.Request())
+	return models.ReverseProxyStatus{
+		Settings:   loadReverseProxySettings(),
+		RemoteAddr: remote,
+		Trusted:    proxyPolicyFor().trusts(remote),
+		ClientIP:   c.RealIP(),
+		Scheme:     c.Scheme(),
+		Host:       c.Request().Host,
+	}
+}
+
+// getReverseProxySettingsHandler handles GET /api/system/reverse-proxy
+func getReverseProxySettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, reverseProxyStatus(c))
+}
+
+// updateReverseProxySettingsHandler handles PUT /api/system/reverse-proxy
+func updateReverseProxySettingsHandler(c echo.Context) error {
+	var req models.ReverseProxySettings
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	proxies := []string{}
+	for _, entry := range req.TrustedProxies {
+		n, err := parseTrustedProxy(entry)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		if ones, _ := n.Mask.Size(); ones == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("%s would trust every address", entry),
+			})
+		}
+		if !slices.Contains(proxies, n.String()) {
+			proxies = append(proxies, n.String())
+		}
+	}
+	basePath, err := normalizeBasePath(req.BasePath)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	values := map[string]string{
+		database.SettingTrustedProxies: strings.Join(proxies, ","),
+		database.SettingBasePath:       basePath,
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	applyReverseProxySettings(loadReverseProxySettings())
+	Audit.LogFromContext(c, models.ActionReverseProxySettings, "reverse-proxy", values)
+
+	return c.JSON(http.StatusOK, reverseProxyStatus(c))
+}
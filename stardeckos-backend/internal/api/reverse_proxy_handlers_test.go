@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+)
+
+// withBasePath serves Stardeck from a subdirectory for the test
+func withBasePath(t *testing.T, base string) {
+	t.Helper()
+	previous := currentProxies.Load()
+	currentProxies.Store(&proxyPolicy{basePath: base})
+	t.Cleanup(func() { currentProxies.Store(previous) })
+}
+
+func TestBrowserURLsUseBasePath(t *testing.T) {
+	withBasePath(t, "/stardeck")
+
+	container := &models.Container{ID: "c1", Name: "jellyfin", WebUIPort: 8096, WebUIPath: "/web/"}
+	if got, want := webUILaunchURL(container), "/stardeck/api/containers/c1/proxy/web/"; got != want {
+		t.Errorf("webUILaunchURL = %q, want %q", got, want)
+	}
+	if got, want := publicAppPath("media"), "/stardeck/api/public/apps/media"; got != want {
+		t.Errorf("publicAppPath = %q, want %q", got, want)
+	}
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	app := &models.PublicApp{Slug: "media", Name: "Media", PINRequired: true}
+	if err := renderKioskPINPage(c, app, false); err != nil {
+		t.Fatalf("renderKioskPINPage: %v", err)
+	}
+	if want := `action="/stardeck/api/public/unlock/media"`; rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("PIN page: got %d, want 401 with %s in %s", rec.Code, want, rec.Body.String())
+	}
+}
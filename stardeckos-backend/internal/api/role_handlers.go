@@ -0,0 +1,260 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var roleRepo *database.RoleRepo
+
+// InitRoleRepo initializes the role repository
+func InitRoleRepo() {
+	roleRepo = database.NewRoleRepo()
+}
+
+// listRolesHandler handles GET /api/roles
+func listRolesHandler(c echo.Context) error {
+	roles, err := roleRepo.List()
+	if err != nil {
+		c.Logger().Error("list roles error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to list roles",
+		})
+	}
+	return c.JSON(http.StatusOK, roles)
+}
+
+// listPermissionsHandler handles GET /api/roles/permissions
+func listPermissionsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.PermissionCatalog)
+}
+
+// getRoleHandler handles GET /api/roles/:id
+func getRoleHandler(c echo.Context) error {
+	role, err := roleFromParam(c)
+	if role == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, role)
+}
+
+// createRoleHandler handles POST /api/roles
+func createRoleHandler(c echo.Context) error {
+	var req models.RoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request_body"),
+		})
+	}
+
+	role := &models.RoleDefinition{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		BaseRole:    req.BaseRole,
+	}
+	if role.BaseRole == "" {
+		role.BaseRole = models.RoleViewer
+	}
+	if msg := validateRole(c, role, req.Permissions); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	if err := roleRepo.Create(role); err != nil {
+		if errors.Is(err, database.ErrRoleAlreadyExists) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": tr(c, "error.role_exists"),
+			})
+		}
+		c.Logger().Error("create role error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to create role",
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionRoleCreate, role.Name, map[string]interface{}{
+		"role_id":     role.ID,
+		"base_role":   role.BaseRole,
+		"permissions": role.Permissions,
+	})
+
+	return c.JSON(http.StatusCreated, role)
+}
+
+// updateRoleHandler handles PUT /api/roles/:id. The admin role can't be
+// changed; the operator and viewer roles only take a new description and
+// permissions.
+func updateRoleHandler(c echo.Context) error {
+	role, err := roleFromParam(c)
+	if role == nil {
+		return err
+	}
+	if role.Name == string(models.RoleAdmin) && role.BuiltIn {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": tr(c, "error.admin_role_locked"),
+		})
+	}
+
+	var req models.RoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request_body"),
+		})
+	}
+
+	role.Description = strings.TrimSpace(req.Description)
+	if !role.BuiltIn {
+		role.Name = strings.TrimSpace(req.Name)
+		if req.BaseRole != "" {
+			role.BaseRole = req.BaseRole
+		}
+	}
+	if msg := validateRole(c, role, req.Permissions); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
+
+	err = database.WithTx(func(tx *sql.Tx) error {
+		return roleRepo.InTx(tx).Update(role)
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrRoleAlreadyExists) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": tr(c, "error.role_exists"),
+			})
+		}
+		c.Logger().Error("update role error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to update role",
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionRoleUpdate, role.Name, map[string]interface{}{
+		"role_id":     role.ID,
+		"base_role":   role.BaseRole,
+		"permissions": role.Permissions,
+	})
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// deleteRoleHandler handles DELETE /api/roles/:id. A custom role still
+// assigned to users can't be deleted, since they'd fall back to their base
+// role's broader permissions.
+func deleteRoleHandler(c echo.Context) error {
+	role, err := roleFromParam(c)
+	if role == nil {
+		return err
+	}
+	if role.BuiltIn {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": tr(c, "error.builtin_role_delete"),
+		})
+	}
+	if role.UserCount > 0 {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": tr(c, "error.role_in_use", "count", strconv.Itoa(role.UserCount)),
+		})
+	}
+
+	if err := roleRepo.Delete(role.ID); err != nil {
+		c.Logger().Error("delete role error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to delete role",
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionRoleDelete, role.Name, map[string]interface{}{
+		"role_id": role.ID,
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// roleFromParam loads the role named by the :id route parameter
+func roleFromParam(c echo.Context) (*models.RoleDefinition, error) {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_role_id"),
+		})
+	}
+	role, err := roleRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, database.ErrRoleNotFound) {
+			return nil, c.JSON(http.StatusNotFound, map[string]string{
+				"error": tr(c, "error.role_not_found"),
+			})
+		}
+		c.Logger().Error("get role error: ", err)
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to get role",
+		})
+	}
+	return role, nil
+}
+
+// validateRole checks a role's name and base role and sets its permissions
+// from the requested ones, deduplicated. It returns the problem, or "".
+func validateRole(c echo.Context, role *models.RoleDefinition, permissions []string) string {
+	if !role.BuiltIn {
+		if role.Name == "" || len(role.Name) > 64 {
+			return tr(c, "error.name_length", "max", "64")
+		}
+		if models.IsBuiltInRole(models.Role(role.Name)) {
+			return tr(c, "error.role_builtin", "name", role.Name)
+		}
+		// An admin base role would bypass the permissions entirely
+		if role.BaseRole != models.RoleOperator && role.BaseRole != models.RoleViewer {
+			return tr(c, "error.invalid_base_role")
+		}
+	}
+
+	role.Permissions = []string{}
+	for _, p := range permissions {
+		p = strings.TrimSpace(p)
+		if !models.ValidPermission(p) {
+			return tr(c, "error.unknown_permission", "permission", p)
+		}
+		// Viewers are read-only whatever their role grants
+		if _, access, _ := strings.Cut(p, ":"); role.BaseRole == models.RoleViewer && access != "read" {
+			return tr(c, "error.permission_viewer", "permission", p)
+		}
+		if !slices.Contains(role.Permissions, p) {
+			role.Permissions = append(role.Permissions, p)
+		}
+	}
+	return ""
+}
+
+// assignRole sets the user's role from a request: a custom role by ID, or a
+// built-in role by name, which clears any custom role. It returns the
+// problem, or "".
+func assignRole(c echo.Context, user *models.User, role *models.Role, roleID *int64) string {
+	if roleID != nil {
+		def, err := roleRepo.GetByID(*roleID)
+		if err != nil {
+			return tr(c, "error.role_not_found")
+		}
+		if def.BuiltIn {
+			user.Role, user.RoleID = models.Role(def.Name), nil
+		} else {
+			user.Role, user.RoleID = def.BaseRole, &def.ID
+		}
+		return ""
+	}
+	if role != nil {
+		if !models.IsBuiltInRole(*role) {
+			return tr(c, "error.invalid_role")
+		}
+		user.Role, user.RoleID = *role, nil
+	}
+	return ""
+}
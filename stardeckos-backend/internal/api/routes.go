@@ -13,10 +13,56 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	InitAuthService()
 	InitUserRepo()
 	InitGroupRepo()
+	InitRoleRepo()
+	InitAPITokenRepo()
 	InitRealmRepo()
 	InitAuditRepo()
 	InitContainerRepos()
 	InitStackRepo()
+	InitSettingsRepo()
+	InitOutboundProxy()
+	InitTrashRepo()
+	InitThumbnailService()
+	InitIconRepo()
+	InitExecTaskRepo()
+	InitMaintenance()
+	InitMetricsCollector()
+	InitVolumeUsage()
+	InitBootReconciler()
+	InitPortExposure()
+	InitLogRuleEngine()
+	InitTerminalSessions()
+	InitWebDAV()
+	InitApprovalRepo()
+	InitRegistryCredentialRepo()
+	InitLocalRegistry()
+	InitDomainRoleMappingRepo()
+	InitUserQuotaRepo()
+	InitProjectRepo()
+	InitInvitationRepos()
+	InitPublicAppRepo()
+	InitContainerScheduleRepo()
+	InitAutoUpdateRepo()
+	InitEnergyRepo()
+	InitContainerExitRepo()
+	InitContainerHealthMonitor()
+	InitMemoryProtection()
+	InitClientCertRepo()
+	InitSecurityProfileRepo()
+	InitContainerEgressRepo()
+	InitDHCPRepo()
+	InitDNSRecordRepo()
+	InitLANDeviceRepo()
+	InitCertMonitorRepo()
+	InitGameServerRepo()
+	InitMQTT()
+	InitConfigHistoryRepo()
+	InitCloudStorage()
+	InitDataPools()
+	InitIngress()
+	InitHostSnapshots()
+	InitDigests()
+	InitPlugins()
 
 	// Store authSvc for use in handlers
 	authService = authSvc
@@ -24,35 +70,99 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	// Health check (public)
 	api.GET("/health", healthCheck)
 
+	// Instance branding (public reads for the login page, admin edits)
+	api.GET("/branding", getBrandingHandler)
+	api.GET("/branding/logo", serveBrandingLogoHandler)
+	brandingAdmin := api.Group("/branding")
+	brandingAdmin.Use(auth.RequireAuth(authSvc))
+	brandingAdmin.Use(auth.RequireRole(models.RoleAdmin))
+	brandingAdmin.PUT("", updateBrandingHandler)
+	brandingAdmin.POST("/logo", uploadBrandingLogoHandler)
+	brandingAdmin.DELETE("/logo", deleteBrandingLogoHandler)
+
+	// Translations of backend messages (public, the login page needs them)
+	api.GET("/i18n", listLanguagesHandler)
+	api.GET("/i18n/:lang", getTranslationsHandler)
+
+	// Public (kiosk) container web UIs, reachable without a session and optionally PIN protected
+	public := api.Group("/public")
+	public.Use(auth.StripAuthHeaders())
+	public.GET("/apps", listPublicAppsHandler)
+	public.Any("/apps/:slug", servePublicAppHandler)
+	public.Any("/apps/:slug/*", servePublicAppHandler)
+	public.POST("/unlock/:slug", unlockPublicAppHandler, auth.PINRateLimiter.MiddlewareBy(publicAppPINKey))
+
 	// Auth routes (public - no auth required for login)
 	authGroup := api.Group("/auth")
 	authGroup.POST("/login", loginHandler, auth.LoginRateLimiter.Middleware())
+	authGroup.GET("/negotiate", negotiateLoginHandler, auth.LoginRateLimiter.Middleware())
 	authGroup.POST("/logout", logoutHandler)
 	authGroup.POST("/refresh", refreshTokenHandler)
 	authGroup.GET("/me", getCurrentUser)
 
+	// Invitation links and self-service password reset (public, rate limited)
+	authGroup.POST("/invitations/lookup", lookupInvitationHandler, auth.LoginRateLimiter.Middleware())
+	authGroup.POST("/invitations/accept", acceptInvitationHandler, auth.LoginRateLimiter.Middleware())
+	authGroup.GET("/password-reset", passwordResetStatusHandler)
+	authGroup.POST("/password-reset", requestPasswordResetHandler, auth.LoginRateLimiter.Middleware())
+	authGroup.POST("/password-reset/confirm", confirmPasswordResetHandler, auth.LoginRateLimiter.Middleware())
+
 	// Protected auth routes
 	authProtected := authGroup.Group("")
 	authProtected.Use(auth.RequireAuth(authSvc))
 	authProtected.GET("/sessions", getUserSessions)
 	authProtected.DELETE("/sessions/:id", revokeSession)
 
+	// Two-factor authentication (TOTP) for the signed-in local user
+	authProtected.GET("/2fa", getTwoFactorStatusHandler)
+	authProtected.POST("/2fa/setup", setupTwoFactorHandler)
+	authProtected.POST("/2fa/verify", verifyTwoFactorHandler, auth.LoginRateLimiter.Middleware())
+	authProtected.POST("/2fa/disable", disableTwoFactorHandler, auth.LoginRateLimiter.Middleware())
+	authProtected.POST("/2fa/recovery-codes", regenerateRecoveryCodesHandler, auth.LoginRateLimiter.Middleware())
+
+	// Personal API tokens for scripts, used as "Authorization: Bearer sdt_..."
+	authProtected.GET("/tokens", listAPITokensHandler)
+	authProtected.POST("/tokens", createAPITokenHandler)
+	authProtected.DELETE("/tokens/:id", revokeAPITokenHandler)
+	authProtected.GET("/session-policy", getSessionPolicyHandler, auth.RequireAdmin())
+	authProtected.PUT("/session-policy", updateSessionPolicyHandler, auth.RequireAdmin())
+
+	// Real-time desktop notifications (Server-Sent Events, scoped to the current user)
+	api.GET("/events", eventsHandler, auth.RequireAuth(authSvc))
+
 	// User preferences routes (authenticated)
 	userGroup := api.Group("/user")
 	userGroup.Use(auth.RequireAuth(authSvc))
 	userGroup.GET("/preferences", getUserPreferencesHandler)
 	userGroup.PUT("/preferences", updateUserPreferencesHandler)
 	userGroup.PATCH("/preferences", patchUserPreferencesHandler)
+	userGroup.GET("/quota", getMyQuotaHandler)
+	userGroup.GET("/digest", getDigestSubscriptionHandler)
+	userGroup.PUT("/digest", updateDigestSubscriptionHandler)
+	userGroup.GET("/digest/preview", previewDigestHandler)
+	userGroup.POST("/digest/send", sendDigestNowHandler)
 
 	// User management routes (requires wheel group or root for PAM users, admin for local users)
 	users := api.Group("/users")
 	users.Use(auth.RequireAuth(authSvc))
 	users.Use(auth.RequireWheelOrRoot(authSvc))
 	users.GET("", listUsersHandler)
+	users.GET("/quotas", listUserQuotasHandler)
+	users.GET("/quotas/settings", getQuotaSettingsHandler)
+	users.PUT("/quotas/settings", updateQuotaSettingsHandler)
 	users.POST("", createUserHandler)
+	users.GET("/invitations", listInvitationsHandler)
+	users.POST("/invitations", createInvitationHandler)
+	users.POST("/invitations/:id/resend", resendInvitationHandler)
+	users.DELETE("/invitations/:id", revokeInvitationHandler)
 	users.GET("/:id", getUserHandler)
 	users.PUT("/:id", updateUserHandler)
 	users.DELETE("/:id", deleteUserHandler)
+	users.GET("/:id/quota", getUserQuotaHandler)
+	users.PUT("/:id/quota", updateUserQuotaHandler)
+	users.DELETE("/:id/quota", deleteUserQuotaHandler)
+	users.POST("/:id/password-reset", sendUserPasswordResetHandler)
+	users.DELETE("/:id/2fa", resetUserTwoFactorHandler)
 
 	// Group management routes (requires wheel group or root)
 	groups := api.Group("/groups")
@@ -67,6 +177,17 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	groups.DELETE("/:id/members/:userId", removeGroupMemberHandler)
 	groups.GET("/:id/members", listGroupMembersHandler)
 
+	// Role management: built-in and custom roles and the permissions they grant
+	roles := api.Group("/roles")
+	roles.Use(auth.RequireAuth(authSvc))
+	roles.Use(auth.RequireWheelOrRoot(authSvc))
+	roles.GET("", listRolesHandler)
+	roles.GET("/permissions", listPermissionsHandler)
+	roles.POST("", createRoleHandler)
+	roles.GET("/:id", getRoleHandler)
+	roles.PUT("/:id", updateRoleHandler)
+	roles.DELETE("/:id", deleteRoleHandler)
+
 	// Realm management routes (requires wheel group or root)
 	realms := api.Group("/realms")
 	realms.Use(auth.RequireAuth(authSvc))
@@ -77,16 +198,120 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	realms.PUT("/:id", updateRealmHandler)
 	realms.DELETE("/:id", deleteRealmHandler)
 
+	// Host domain membership (AD/FreeIPA via realmd/SSSD) - wheel/root only
+	domain := api.Group("/domain")
+	domain.Use(auth.RequireAuth(authSvc))
+	domain.Use(auth.RequireWheelOrRoot(authSvc))
+	domain.GET("", getDomainStatusHandler)
+	domain.GET("/discover", discoverDomainHandler)
+	domain.POST("/join", joinDomainHandler)
+	domain.POST("/leave", leaveDomainHandler)
+	domain.GET("/mappings", listDomainRoleMappingsHandler)
+	domain.POST("/mappings", createDomainRoleMappingHandler)
+	domain.GET("/kerberos", getKerberosSettingsHandler)
+	domain.PUT("/kerberos", updateKerberosSettingsHandler)
+	domain.DELETE("/mappings/:id", deleteDomainRoleMappingHandler)
+
 	// System routes (authenticated, admin for critical operations)
 	system := api.Group("/system")
 	system.Use(auth.RequireAuth(authSvc))
 	system.GET("/resources", getResourcesHandler)
+	system.GET("/metrics", getHostMetricsHandler)
+	system.GET("/metrics/history", getHostMetricsHistoryHandler)
 	system.GET("/info", getSystemInfoHandler)
+	system.GET("/host-profile", getHostProfileHandler)
+	system.PUT("/host-profile", updateHostProfileHandler, auth.RequireRole(models.RoleAdmin))
 	system.GET("/groups", listSystemGroupsHandler) // View system groups
 	system.POST("/groups/:name/members", addSystemGroupMemberHandler, auth.RequireRole(models.RoleAdmin))
 	system.DELETE("/groups/:name/members/:username", removeSystemGroupMemberHandler, auth.RequireRole(models.RoleAdmin))
 	system.POST("/reboot", rebootSystemHandler, auth.RequireRole(models.RoleAdmin))
 
+	// Outgoing email (SMTP settings, templates, test message) - admin only
+	system.GET("/email", getEmailSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/email", updateEmailSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/email/test", sendTestEmailHandler, auth.RequireRole(models.RoleAdmin))
+	system.GET("/email/templates", listEmailTemplatesHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/email/templates/:name", updateEmailTemplateHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Energy use (RAPL or modeled host power, split across containers by CPU time)
+	system.GET("/power", getPowerReportHandler)
+	system.PUT("/power", updatePowerSettingsHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Host memory protection (zram swap, userspace OOM guard)
+	system.GET("/memory-protection", getMemoryProtectionHandler)
+	system.PUT("/memory-protection", updateMemoryProtectionHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Outbound HTTP proxy for dnf, image pulls and Stardeck's own requests
+	system.GET("/proxy", getOutboundProxyHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/proxy", updateOutboundProxyHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Time synchronization (chrony sources, NTP server mode for the LAN)
+	system.GET("/ntp", getNTPStatusHandler)
+	system.PUT("/ntp/server", updateNTPServerHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Host-wide container log default
+	system.GET("/container-logs", getContainerLogSettingsHandler)
+	system.PUT("/container-logs", updateContainerLogSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.GET("/metrics-collector", getMetricsCollectorSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/metrics-collector", updateMetricsCollectorSettingsHandler, auth.RequireRole(models.RoleAdmin))
+
+	// TLS certificate expiry monitoring (external endpoints and Stardeck's own)
+	system.GET("/cert-monitors", listCertMonitorsHandler)
+	system.POST("/cert-monitors", createCertMonitorHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/cert-monitors/:id", updateCertMonitorHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/cert-monitors/:id/check", checkCertMonitorHandler, auth.RequireRole(models.RoleAdmin, models.RoleOperator))
+	system.DELETE("/cert-monitors/:id", deleteCertMonitorHandler, auth.RequireRole(models.RoleAdmin))
+
+	// MQTT bridge to Home Assistant (discovery, state, container start/stop commands)
+	system.GET("/mqtt", getMQTTSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/mqtt", updateMQTTSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.GET("/mqtt/status", getMQTTStatusHandler, auth.RequireRole(models.RoleAdmin))
+
+	// HTTPS listener hardening (applies to new connections without a restart)
+	system.GET("/tls", getTLSSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/tls", updateTLSSettingsHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Reverse proxies in front of Stardeck and the subdirectory it is served from
+	system.GET("/reverse-proxy", getReverseProxySettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/reverse-proxy", updateReverseProxySettingsHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Listen addresses and the Unix socket (apply on restart)
+	system.GET("/listeners", getListenersHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/listeners", updateListenersHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Client certificate (mTLS) authentication for automation hosts
+	system.GET("/mtls", getMTLSHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/mtls", updateMTLSHandler, auth.RequireRole(models.RoleAdmin))
+	system.GET("/mtls/ca.crt", downloadClientCAHandler, auth.RequireRole(models.RoleAdmin))
+	system.GET("/mtls/certificates", listClientCertsHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/mtls/certificates", issueClientCertHandler, auth.RequireRole(models.RoleAdmin))
+	system.DELETE("/mtls/certificates/:id", revokeClientCertHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Maintenance mode (pauses schedulers, optional login block)
+	system.GET("/maintenance-mode", getMaintenanceModeHandler)
+	system.PUT("/maintenance-mode", updateMaintenanceModeHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Read-only mode for demos and change freezes (admin only to change)
+	system.GET("/read-only-mode", getReadOnlyModeHandler)
+	system.PUT("/read-only-mode", updateReadOnlyModeHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Filesystem snapshots, also taken automatically before OS updates,
+	// storage migrations and stack major updates
+	system.GET("/snapshots", getHostSnapshotsHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/snapshots/settings", updateHostSnapshotSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/snapshots", createHostSnapshotHandler, auth.RequireRole(models.RoleAdmin))
+	system.DELETE("/snapshots/:id", deleteHostSnapshotHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/snapshots/:id/rollback", rollbackHostSnapshotHandler, auth.RequireRole(models.RoleAdmin), requireApproval(models.DestructiveSnapshotRollback, nil))
+
+	// Database maintenance (admin only)
+	system.GET("/database", getDatabaseStatsHandler, auth.RequireRole(models.RoleAdmin))
+	system.GET("/database/connections", getDatabaseConnectionsHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/database/integrity", integrityCheckHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/database/vacuum", vacuumDatabaseHandler, auth.RequireRole(models.RoleAdmin))
+	system.POST("/database/compact", compactDatabaseHandler, auth.RequireRole(models.RoleAdmin))
+	system.GET("/database/maintenance", getMaintenanceSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	system.PUT("/database/maintenance", updateMaintenanceSettingsHandler, auth.RequireRole(models.RoleAdmin))
+
 	// Process routes (authenticated, kill requires operator+)
 	processes := api.Group("/processes")
 	processes.Use(auth.RequireAuth(authSvc))
@@ -137,11 +362,29 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	// Storage management (requires wheel/root)
 	storage.GET("/partitions/:device", getPartitionTableHandler, auth.RequireWheelOrRoot(authSvc))
 	storage.POST("/partitions", createPartitionHandler, auth.RequireWheelOrRoot(authSvc))
-	storage.DELETE("/partitions", deletePartitionHandler, auth.RequireWheelOrRoot(authSvc))
-	storage.POST("/format", formatPartitionHandler, auth.RequireWheelOrRoot(authSvc))
+	storage.DELETE("/partitions", deletePartitionHandler, auth.RequireWheelOrRoot(authSvc), requireApproval(models.DestructivePartitionDelete, nil))
+	storage.POST("/format", formatPartitionHandler, auth.RequireWheelOrRoot(authSvc), requireApproval(models.DestructiveDiskFormat, nil))
 	storage.POST("/mount", mountHandler, auth.RequireWheelOrRoot(authSvc))
 	storage.POST("/unmount", unmountHandler, auth.RequireWheelOrRoot(authSvc))
 
+	// Cloud storage mounts via rclone (admin only)
+	storage.GET("/cloud/remotes", listCloudRemotesHandler, auth.RequireRole(models.RoleAdmin))
+	storage.POST("/cloud/remotes", createCloudRemoteHandler, auth.RequireRole(models.RoleAdmin))
+	storage.PUT("/cloud/remotes/:name", updateCloudRemoteHandler, auth.RequireRole(models.RoleAdmin))
+	storage.POST("/cloud/remotes/:name/test", testCloudRemoteHandler, auth.RequireRole(models.RoleAdmin))
+	storage.DELETE("/cloud/remotes/:name", deleteCloudRemoteHandler, auth.RequireRole(models.RoleAdmin))
+	storage.GET("/cloud/mounts", listCloudMountsHandler, auth.RequireRole(models.RoleAdmin))
+	storage.POST("/cloud/mounts", createCloudMountHandler, auth.RequireRole(models.RoleAdmin))
+	storage.PUT("/cloud/mounts/:id", updateCloudMountHandler, auth.RequireRole(models.RoleAdmin))
+	storage.POST("/cloud/mounts/:id/check", checkCloudMountHandler, auth.RequireRole(models.RoleAdmin))
+	storage.DELETE("/cloud/mounts/:id", deleteCloudMountHandler, auth.RequireRole(models.RoleAdmin))
+	storage.GET("/pools", listDataPoolsHandler, auth.RequireRole(models.RoleAdmin))
+	storage.GET("/pools/templates", listDataPoolTemplatesHandler, auth.RequireRole(models.RoleAdmin))
+	storage.POST("/pools", createDataPoolHandler, auth.RequireRole(models.RoleAdmin))
+	storage.PUT("/pools/:id", updateDataPoolHandler, auth.RequireRole(models.RoleAdmin))
+	storage.POST("/pools/:id/permissions", fixDataPoolPermissionsHandler, auth.RequireRole(models.RoleAdmin))
+	storage.DELETE("/pools/:id", deleteDataPoolHandler, auth.RequireRole(models.RoleAdmin))
+
 	// File browser routes (authenticated)
 	files := api.Group("/files")
 	files.Use(auth.RequireAuth(authSvc))
@@ -158,6 +401,17 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	files.DELETE("", deleteFileHandler)
 	files.PATCH("/permissions", changePermissionsHandler)
 
+	// Four-eyes approvals for destructive actions (a second admin confirms)
+	approvals := api.Group("/approvals")
+	approvals.Use(auth.RequireAuth(authSvc))
+	approvals.Use(auth.RequireAdminOrPAMAdmin(authSvc))
+	approvals.GET("", listApprovalsHandler)
+	approvals.GET("/settings", getApprovalSettingsHandler)
+	approvals.PUT("/settings", updateApprovalSettingsHandler)
+	approvals.GET("/:id", getApprovalHandler)
+	approvals.POST("/:id/approve", approveRequestHandler)
+	approvals.POST("/:id/reject", rejectRequestHandler)
+
 	// Audit log routes (requires admin)
 	audit := api.Group("/audit")
 	audit.Use(auth.RequireAuth(authSvc))
@@ -167,8 +421,37 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	audit.GET("/stats", getAuditStatsHandler)
 	audit.GET("/:id", getAuditLogHandler)
 
+	// Intrusion detection and security reporting (admin only)
+	security := api.Group("/security")
+	security.Use(auth.RequireAuth(authSvc))
+	security.Use(auth.RequireRole(models.RoleAdmin))
+	security.GET("/fail2ban", getFail2banStatusHandler)
+	security.PUT("/fail2ban", updateFail2banSettingsHandler)
+	security.POST("/fail2ban/install", installFail2banHandler)
+	security.POST("/fail2ban/unban", unbanFail2banHandler)
+	security.GET("/report", getSecurityReportHandler)
+	security.GET("/origins", getOriginSettingsHandler)
+	security.PUT("/origins", updateOriginSettingsHandler)
+	security.GET("/profiles", listSecurityProfilesHandler)
+	security.POST("/profiles", createSecurityProfileHandler)
+	security.GET("/profiles/:id", getSecurityProfileHandler)
+	security.PUT("/profiles/:id", updateSecurityProfileHandler)
+	security.DELETE("/profiles/:id", deleteSecurityProfileHandler)
+	security.GET("/confinement", listContainerConfinementHandler)
+	security.PUT("/confinement/:id", assignSecurityProfileHandler)
+
 	// Terminal WebSocket route (authentication handled inside handler due to WebSocket limitations)
 	api.GET("/terminal/ws", HandleTerminalWebSocket)
+	api.GET("/terminal/sessions/:id/watch", watchTerminalSessionHandler) // Read-only view of a live session
+
+	// Host terminal session history and recordings (admin only)
+	terminalSessions := api.Group("/terminal/sessions")
+	terminalSessions.Use(auth.RequireAuth(authSvc))
+	terminalSessions.Use(auth.RequireRole(models.RoleAdmin))
+	terminalSessions.GET("", listTerminalSessionsHandler)
+	terminalSessions.GET("/:id", getTerminalSessionHandler)
+	terminalSessions.GET("/:id/recording", getTerminalRecordingHandler)
+	terminalSessions.DELETE("/:id", deleteTerminalSessionHandler)
 
 	// Package operation WebSocket route (streaming DNF output)
 	api.GET("/packages/ws", HandlePackageOperationWebSocket)
@@ -198,6 +481,10 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	network.DELETE("/firewall/zones/:zone/rules", removeFirewallRichRuleHandler, auth.RequireRole(models.RoleAdmin))
 	network.POST("/firewall/reload", reloadFirewallHandler, auth.RequireRole(models.RoleAdmin))
 	network.POST("/firewall/default-zone", setDefaultZoneHandler, auth.RequireRole(models.RoleAdmin))
+	network.POST("/firewall/preview", previewFirewallChangeHandler, auth.RequireRole(models.RoleAdmin))
+	network.GET("/firewall/pending", listPendingFirewallChangesHandler, auth.RequireRole(models.RoleAdmin))
+	network.POST("/firewall/pending/:id/confirm", confirmFirewallChangeHandler, auth.RequireRole(models.RoleAdmin))
+	network.DELETE("/firewall/pending/:id", revertFirewallChangeHandler, auth.RequireRole(models.RoleAdmin))
 
 	// Route management (read: all users, write: admin only)
 	network.GET("/routes", listRoutesHandler)
@@ -207,9 +494,57 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	// DNS configuration (read-only)
 	network.GET("/dns", getDNSConfigHandler)
 
+	// Local DNS resolver (dnsmasq)
+	network.GET("/dns/resolver", getDNSResolverHandler)
+	network.PUT("/dns/resolver", updateDNSResolverHandler, auth.RequireRole(models.RoleAdmin))
+	network.POST("/dns/resolver/install", installDNSResolverHandler, auth.RequireRole(models.RoleAdmin))
+	network.POST("/dns/blocklists/refresh", refreshDNSBlocklistsHandler, auth.RequireRole(models.RoleAdmin))
+	network.GET("/dns/records", listDNSRecordsHandler)
+	network.POST("/dns/records", createDNSRecordHandler, auth.RequireRole(models.RoleAdmin))
+	network.PUT("/dns/records/:id", updateDNSRecordHandler, auth.RequireRole(models.RoleAdmin))
+	network.DELETE("/dns/records/:id", deleteDNSRecordHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Host name routing to container web UIs
+	network.GET("/ingress", getIngressHandler)
+	network.GET("/ingress/hosts/:id/open", openIngressHostHandler)
+	network.PUT("/ingress", updateIngressHandler, auth.RequireRole(models.RoleAdmin))
+	network.PUT("/ingress/hosts/:id", updateIngressHostHandler, auth.RequireRole(models.RoleAdmin))
+	network.DELETE("/ingress/hosts/:id", resetIngressHostHandler, auth.RequireRole(models.RoleAdmin))
+
 	// Active connections (read-only)
 	network.GET("/connections", listConnectionsHandler)
 
+	// Topology graph of interfaces, podman networks and containers (read-only)
+	network.GET("/topology", getNetworkTopologyHandler)
+
+	// Neighbor table and LAN device discovery
+	network.GET("/neighbors", listNeighborsHandler)
+	network.POST("/scan", scanLANHandler, auth.RequireRole(models.RoleAdmin))
+	network.GET("/devices", listLANDevicesHandler)
+	network.GET("/devices/alerts", getLANDeviceAlertsHandler)
+	network.PUT("/devices/alerts", updateLANDeviceAlertsHandler, auth.RequireRole(models.RoleAdmin))
+	network.PUT("/devices/:mac", updateLANDeviceHandler, auth.RequireRole(models.RoleAdmin))
+	network.DELETE("/devices/:mac", deleteLANDeviceHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Packet capture (admin only)
+	network.GET("/capture", capturePacketsHandler, auth.RequireRole(models.RoleAdmin)) // WebSocket
+	network.GET("/captures", listCapturesHandler, auth.RequireRole(models.RoleAdmin))
+	network.GET("/captures/:id/download", downloadCaptureHandler, auth.RequireRole(models.RoleAdmin))
+	network.DELETE("/captures/:id", deleteCaptureHandler, auth.RequireRole(models.RoleAdmin))
+
+	// DHCP server (dnsmasq)
+	network.GET("/dhcp", getDHCPStatusHandler)
+	network.POST("/dhcp/install", installDHCPHandler, auth.RequireRole(models.RoleAdmin))
+	network.PUT("/dhcp/interfaces/:name", setDHCPInterfaceHandler, auth.RequireRole(models.RoleAdmin))
+	network.GET("/dhcp/scopes", listDHCPScopesHandler)
+	network.POST("/dhcp/scopes", createDHCPScopeHandler, auth.RequireRole(models.RoleAdmin))
+	network.PUT("/dhcp/scopes/:id", updateDHCPScopeHandler, auth.RequireRole(models.RoleAdmin))
+	network.DELETE("/dhcp/scopes/:id", deleteDHCPScopeHandler, auth.RequireRole(models.RoleAdmin))
+	network.GET("/dhcp/reservations", listDHCPReservationsHandler)
+	network.POST("/dhcp/reservations", createDHCPReservationHandler, auth.RequireRole(models.RoleAdmin))
+	network.DELETE("/dhcp/reservations/:id", deleteDHCPReservationHandler, auth.RequireRole(models.RoleAdmin))
+	network.GET("/dhcp/leases", listDHCPLeasesHandler)
+
 	// Docker Hub image search (public endpoint with auth)
 	api.GET("/dockerhub/search", searchDockerHubHandler, auth.RequireAuth(authSvc))
 
@@ -219,6 +554,7 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	// Container management routes (Phase 2B)
 	containers := api.Group("/containers")
 	containers.Use(auth.RequireAuth(authSvc))
+	containers.Use(requireProjectAccess(models.ProjectResourceContainer, "/api/containers/:id"))
 
 	// Podman availability check
 	containers.GET("/check", checkPodmanHandler)
@@ -228,13 +564,24 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 
 	// Container operations (read: all, write: operator+, create/delete: admin)
 	containers.GET("", listContainersHandler)
+	containers.GET("/boot-report", getLatestBootReportHandler)
+	containers.GET("/boot-reports", listBootReportsHandler)
+	containers.GET("/boot-reports/:id", getBootReportHandler)
+	containers.POST("/reconcile", reconcileAutoStartHandler, auth.RequireOperatorOrAdmin())
+	containers.GET("/exposures", listAllPortExposuresHandler)
+	containers.GET("/egress", listContainerEgressHandler, auth.RequireRole(models.RoleAdmin))
+	containers.GET("/export-compose", exportAllComposeHandler)
+	containers.GET("/log-usage", listContainerLogUsageHandler)
+	containers.GET("/storage-usage", listStorageUsageHandler)
+	containers.GET("/events", containerEventsHandler) // WebSocket: lifecycle events
 	containers.GET("/:id", getContainerHandler)
 	containers.POST("", createContainerHandler, auth.RequireRole(models.RoleAdmin))
 	containers.POST("/adopt", adoptContainerHandler, auth.RequireRole(models.RoleAdmin)) // Adopt existing containers
 	containers.POST("/validate", validateContainerHandler, auth.RequireRole(models.RoleAdmin))
 	containers.GET("/deploy", deployContainerHandler, auth.RequireRole(models.RoleAdmin)) // WebSocket
 	containers.PUT("/:id", updateContainerHandler, auth.RequireRole(models.RoleAdmin))
-	containers.DELETE("/:id", removeContainerHandler, auth.RequireRole(models.RoleAdmin))
+	containers.PUT("/:id/owner", setContainerOwnerHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id", removeContainerHandler, auth.RequireRole(models.RoleAdmin), requireApproval(models.DestructiveContainerRemoveVolumes, removesContainerVolumes))
 	containers.POST("/:id/start", startContainerHandler, auth.RequireRole(models.RoleAdmin))
 	containers.POST("/:id/stop", stopContainerHandler, auth.RequireRole(models.RoleAdmin))
 	containers.POST("/:id/restart", restartContainerHandler, auth.RequireRole(models.RoleAdmin))
@@ -242,11 +589,16 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	containers.GET("/:id/logs", getContainerLogsRESTHandler)       // REST: fetch logs
 	containers.GET("/:id/logs/stream", getContainerLogsHandler)    // WebSocket: stream logs
 	containers.GET("/:id/exec", execContainerHandler)              // WebSocket: terminal shell
+	containers.GET("/:id/attach", attachContainerHandler, auth.RequireOperatorOrAdmin()) // WebSocket: main process console
 	containers.GET("/:id/stats", getContainerStatsHandler)
 	containers.GET("/:id/metrics", getContainerMetricsHandler)
 
 	// Container update & backup routes
 	containers.GET("/:id/config", getContainerConfigHandler)                                        // Get full container config
+	containers.GET("/:id/config/history", listConfigHistoryHandler)                                 // Recorded configs with changes
+	containers.GET("/:id/config/history/:version", getConfigVersionHandler)                         // One recorded config
+	containers.GET("/:id/config/diff", diffConfigHistoryHandler)                                    // Compare versions or live config
+	containers.GET("/:id/export-compose", exportContainerComposeHandler)                            // Export as a compose service
 	containers.GET("/:id/backups", listContainerBackupsHandler)                                     // List backups
 	containers.GET("/:id/check-update", checkContainerUpdateHandler)                                // Check for image updates
 	containers.GET("/:id/update", updateContainerImageHandler, auth.RequireRole(models.RoleAdmin))  // WebSocket: update container
@@ -256,6 +608,91 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	containers.Any("/:id/proxy", proxyContainerWebUIHandler)
 	containers.Any("/:id/proxy/*", proxyContainerWebUIHandler)
 
+	// Public (kiosk) access to a container's web UI (admin only)
+	containers.GET("/public", listContainerPublicAppsHandler, auth.RequireRole(models.RoleAdmin))
+	containers.GET("/:id/public", getContainerPublicAppHandler, auth.RequireRole(models.RoleAdmin))
+	containers.PUT("/:id/public", updateContainerPublicAppHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/public", deleteContainerPublicAppHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Web UI preview thumbnails for desktop icons
+	containers.GET("/:id/thumbnail", getContainerThumbnailHandler)
+	containers.POST("/:id/thumbnail/refresh", refreshContainerThumbnailHandler, auth.RequireOperatorOrAdmin())
+
+	// DNS aliases on the Stardeck-managed network
+	containers.POST("/:id/aliases", setContainerAliasesHandler, auth.RequireOperatorOrAdmin())
+
+	// Log-based trigger rules (regex on log stream -> notify, restart, webhook)
+	containers.GET("/:id/log-rules", listLogRulesHandler)
+	containers.POST("/:id/log-rules", createLogRuleHandler, auth.RequireRole(models.RoleAdmin))
+	containers.POST("/:id/log-rules/test", testLogRuleHandler, auth.RequireOperatorOrAdmin())
+	containers.PUT("/:id/log-rules/:rule_id", updateLogRuleHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/log-rules/:rule_id", deleteLogRuleHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Temporary port exposure (host forwarder + firewall rule with TTL)
+	containers.GET("/:id/expose", listPortExposuresHandler)
+	containers.POST("/:id/expose", createPortExposureHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/expose/:expose_id", revokePortExposureHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Config files inside bind mounts (list: all, read: operator/admin, write: admin)
+	containers.GET("/:id/config-files", listConfigFilesHandler)
+	containers.GET("/:id/config-files/content", getConfigFileHandler, auth.RequireOperatorOrAdmin())
+	containers.PUT("/:id/config-files/content", saveConfigFileHandler, auth.RequireRole(models.RoleAdmin))
+	containers.GET("/:id/config-files/backups", listConfigBackupsHandler, auth.RequireOperatorOrAdmin())
+	containers.POST("/:id/config-files/restore", restoreConfigBackupHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Exit history and reliability (OOM kills, crashes, memory limit suggestions)
+	containers.GET("/reliability", listContainerReliabilityHandler)
+	containers.GET("/:id/exits", getContainerReliabilityHandler)
+
+	// Power schedules (start/stop on cron schedules, with manual override tracking)
+	containers.GET("/schedules", listContainerSchedulesHandler, auth.RequireRole(models.RoleAdmin))
+	containers.GET("/:id/schedule", getContainerScheduleHandler)
+	containers.PUT("/:id/schedule", updateContainerScheduleHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/schedule", deleteContainerScheduleHandler, auth.RequireRole(models.RoleAdmin))
+	containers.PUT("/:id/schedule/override", setContainerScheduleOverrideHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/schedule/override", clearContainerScheduleOverrideHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Image auto-updates (periodic checks, updates in a maintenance window) and update history
+	containers.GET("/auto-updates", listAutoUpdatesHandler, auth.RequireRole(models.RoleAdmin))
+	containers.GET("/update-history", listUpdateHistoryHandler, auth.RequireRole(models.RoleAdmin))
+	containers.GET("/:id/auto-update", getAutoUpdateHandler)
+	containers.PUT("/:id/auto-update", updateAutoUpdateHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/auto-update", deleteAutoUpdateHandler, auth.RequireRole(models.RoleAdmin))
+	containers.POST("/:id/auto-update/check", checkAutoUpdateHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Game server profiles (scheduled restarts with RCON warnings, player probes, idle shutdown)
+	containers.GET("/game-servers", listGameServersHandler, auth.RequireRole(models.RoleAdmin))
+	containers.GET("/game-servers/presets", listGameServerPresetsHandler)
+	containers.GET("/game-servers/ports", checkHostPortsHandler)
+	containers.GET("/:id/game-server", getGameServerHandler)
+	containers.PUT("/:id/game-server", updateGameServerHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/game-server", deleteGameServerHandler, auth.RequireRole(models.RoleAdmin))
+	containers.POST("/:id/game-server/probe", probeGameServerHandler)
+	containers.POST("/:id/game-server/rcon", gameServerRCONHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Bind mount ownership against the container's runtime user
+	containers.GET("/:id/permissions", getContainerPermissionsHandler)
+	containers.POST("/:id/permissions/fix", fixContainerPermissionsHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Right-sizing from recorded metrics, applied live with podman update
+	containers.GET("/recommendations", listResourceRecommendationsHandler)
+	containers.GET("/:id/recommendation", getResourceRecommendationHandler)
+	containers.POST("/:id/recommendation/apply", applyResourceRecommendationHandler, auth.RequireRole(models.RoleAdmin))
+	containers.PUT("/:id/resources", updateContainerResourcesHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Outbound network policy
+	containers.GET("/:id/egress", getContainerEgressHandler)
+	containers.PUT("/:id/egress", updateContainerEgressHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/egress", deleteContainerEgressHandler, auth.RequireRole(models.RoleAdmin))
+
+	// Scheduled exec tasks (recurring commands inside a container)
+	containers.GET("/:id/tasks", listExecTasksHandler)
+	containers.POST("/:id/tasks", createExecTaskHandler, auth.RequireRole(models.RoleAdmin))
+	containers.PUT("/:id/tasks/:task_id", updateExecTaskHandler, auth.RequireRole(models.RoleAdmin))
+	containers.DELETE("/:id/tasks/:task_id", deleteExecTaskHandler, auth.RequireRole(models.RoleAdmin))
+	containers.POST("/:id/tasks/:task_id/run", runExecTaskHandler, auth.RequireOperatorOrAdmin())
+	containers.GET("/:id/tasks/:task_id/runs", listExecTaskRunsHandler)
+
 	// Image management (read: all, write: admin)
 	images := api.Group("/images")
 	images.Use(auth.RequireAuth(authSvc))
@@ -263,13 +700,44 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	images.GET("/inspect", inspectImageHandler)      // Check if image exists and get config
 	images.GET("/inspect/ws", inspectImageWSHandler) // WebSocket: pull + inspect with progress
 	images.POST("/pull", pullImageHandler, auth.RequireRole(models.RoleAdmin))
+	images.GET("/referenced", listReferencedImagesHandler) // Images used by templates and stacks
+	images.POST("/prepull", startPrePullHandler, auth.RequireRole(models.RoleAdmin))
+	images.GET("/prepull", listPrePullJobsHandler)
+	images.GET("/prepull/:id", getPrePullJobHandler)
 	images.DELETE("/:id", removeImageHandler, auth.RequireRole(models.RoleAdmin))
-
-	// Volume management (read: all, write: admin)
+	images.GET("/promote", promoteImageHandler, auth.RequireRole(models.RoleAdmin)) // WebSocket: skopeo copy between registries
+
+	// Saved registry logins for image promotion (admin only)
+	registries := api.Group("/registries")
+	registries.Use(auth.RequireAuth(authSvc))
+	registries.Use(auth.RequireRole(models.RoleAdmin))
+	registries.GET("/credentials", listRegistryCredentialsHandler)
+	registries.POST("/credentials", createRegistryCredentialHandler)
+	registries.DELETE("/credentials/:id", deleteRegistryCredentialHandler)
+
+	// Stardeck-managed local registry (registry:2)
+	registries.GET("/local", getLocalRegistryHandler)
+	registries.POST("/local", deployLocalRegistryHandler)
+	registries.DELETE("/local", removeLocalRegistryHandler)
+	registries.PUT("/local/schedule", updateLocalRegistryScheduleHandler)
+	registries.POST("/local/gc", garbageCollectLocalRegistryHandler)
+	registries.GET("/local/repositories", listLocalRegistryRepositoriesHandler)
+	registries.DELETE("/local/tags", deleteLocalRegistryTagHandler)
+	registries.GET("/local/transfer", localRegistryTransferHandler) // WebSocket: push to or pull from the registry
+
+	// Volume management (read: all, write and file contents: admin)
 	volumes := api.Group("/volumes")
 	volumes.Use(auth.RequireAuth(authSvc))
+	volumes.Use(requireProjectAccess(models.ProjectResourceVolume, "/api/volumes/:name"))
 	volumes.GET("", listVolumesHandler)
 	volumes.POST("", createVolumeHandler, auth.RequireRole(models.RoleAdmin))
+	volumes.GET("/:name/preview", getVolumePreviewHandler, auth.RequireRole(models.RoleAdmin))
+	volumes.GET("/:name/files", listVolumeFilesHandler)
+	volumes.GET("/:name/files/download", downloadVolumeFileHandler, auth.RequireRole(models.RoleAdmin))
+	volumes.POST("/:name/files/upload", uploadVolumeFileHandler, auth.RequireRole(models.RoleAdmin))
+	volumes.POST("/:name/files/mkdir", createVolumeDirectoryHandler, auth.RequireRole(models.RoleAdmin))
+	volumes.DELETE("/:name/files", deleteVolumeFileHandler, auth.RequireRole(models.RoleAdmin))
+	volumes.GET("/:name/export", exportVolumeHandler, auth.RequireRole(models.RoleAdmin))
 	volumes.DELETE("/:name", removeVolumeHandler, auth.RequireRole(models.RoleAdmin))
 
 	// Podman storage configuration (admin only)
@@ -278,14 +746,37 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 
 	// Bind mounts endpoint (aggregates bind mounts from all containers)
 	api.GET("/bind-mounts", listBindMountsHandler, auth.RequireAuth(authSvc))
+	api.GET("/bind-mounts/preview", getBindMountPreviewHandler, auth.RequireAuth(authSvc), auth.RequireRole(models.RoleAdmin))
+	api.DELETE("/bind-mounts", removeBindMountHandler, auth.RequireAuth(authSvc), auth.RequireRole(models.RoleAdmin))
 
 	// Podman network management (read: all, write: admin)
 	podmanNetworks := api.Group("/podman-networks")
 	podmanNetworks.Use(auth.RequireAuth(authSvc))
+	podmanNetworks.Use(requireProjectAccess(models.ProjectResourceNetwork, "/api/podman-networks/:name"))
 	podmanNetworks.GET("", listPodmanNetworksHandler)
+	podmanNetworks.GET("/managed", getManagedNetworkHandler)
+	podmanNetworks.PUT("/managed", updateManagedNetworkHandler, auth.RequireRole(models.RoleAdmin))
 	podmanNetworks.POST("", createPodmanNetworkHandler, auth.RequireRole(models.RoleAdmin))
+	podmanNetworks.GET("/lan/interfaces", listLANInterfacesHandler, auth.RequireRole(models.RoleAdmin))
+	podmanNetworks.POST("/lan", createLANNetworkHandler, auth.RequireRole(models.RoleAdmin))
+	podmanNetworks.GET("/:name", getPodmanNetworkHandler)
+	podmanNetworks.PUT("/:name", updatePodmanNetworkHandler, auth.RequireRole(models.RoleAdmin))
 	podmanNetworks.DELETE("/:name", removePodmanNetworkHandler, auth.RequireRole(models.RoleAdmin))
 
+	// WebDAV access to bind-mount directories (Basic auth for native OS clients)
+	api.Match(webdavMethods, "/dav/:share", serveWebDAVHandler, auth.RequireBasicOrToken(authSvc, "Stardeck"))
+	api.Match(webdavMethods, "/dav/:share/*", serveWebDAVHandler, auth.RequireBasicOrToken(authSvc, "Stardeck"))
+
+	// WebDAV share management (admin only)
+	webdavShares := api.Group("/webdav")
+	webdavShares.Use(auth.RequireAuth(authSvc))
+	webdavShares.Use(auth.RequireRole(models.RoleAdmin))
+	webdavShares.GET("/shares", listWebDAVSharesHandler)
+	webdavShares.POST("/shares", createWebDAVShareHandler)
+	webdavShares.PUT("/shares/:id", updateWebDAVShareHandler)
+	webdavShares.DELETE("/shares/:id", deleteWebDAVShareHandler)
+	webdavShares.PUT("/enabled", setWebDAVEnabledHandler)
+
 	// Template management (read: all, write: admin)
 	templates := api.Group("/templates")
 	templates.Use(auth.RequireAuth(authSvc))
@@ -301,18 +792,64 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	// Stack management (compose-based deployments)
 	stacks := api.Group("/stacks")
 	stacks.Use(auth.RequireAuth(authSvc))
+	stacks.Use(requireProjectAccess(models.ProjectResourceStack, "/api/stacks/:id"))
 	stacks.GET("", listStacksHandler)
 	stacks.GET("/:id", getStackHandler)
 	stacks.GET("/:id/containers", getStackContainersHandler)
+	stacks.GET("/:id/graph", getStackGraphHandler)
+	stacks.GET("/:id/profiles", getStackProfilesHandler)
+	stacks.GET("/:id/secrets", getStackSecretsHandler)
+	stacks.GET("/:id/render", renderStackHandler) // Compose file with variables interpolated
+	stacks.GET("/:id/preflight", stackPreflightHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.GET("/secrets", listPodmanSecretsHandler, auth.RequireRole(models.RoleAdmin))
 	stacks.POST("", createStackHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.GET("/discover", discoverStacksHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.POST("/import", importStackHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.POST("/migrate/scan", scanMigrationSourceHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.POST("/migrate", migrateStacksHandler, auth.RequireRole(models.RoleAdmin))
 	stacks.PUT("/:id", updateStackHandler, auth.RequireRole(models.RoleAdmin))
-	stacks.DELETE("/:id", deleteStackHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.PUT("/:id/owner", setStackOwnerHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.GET("/:id/quota", checkStackQuotaHandler, auth.RequireRole(models.RoleAdmin))
+	stacks.DELETE("/:id", deleteStackHandler, auth.RequireRole(models.RoleAdmin), requireApproval(models.DestructiveStackDelete, nil))
 	stacks.GET("/:id/deploy", deployStackHandler, auth.RequireRole(models.RoleAdmin)) // WebSocket
+	stacks.GET("/:id/teardown", teardownStackHandler, auth.RequireRole(models.RoleAdmin)) // WebSocket
 	stacks.POST("/:id/start", startStackHandler, auth.RequireOperatorOrAdmin())
 	stacks.POST("/:id/stop", stopStackHandler, auth.RequireOperatorOrAdmin())
 	stacks.POST("/:id/restart", restartStackHandler, auth.RequireOperatorOrAdmin())
 	stacks.GET("/:id/pull", pullStackHandler, auth.RequireRole(models.RoleAdmin)) // WebSocket
 
+	// Projects: workloads shared by a team (create/delete: admin, members and resources: project admin)
+	projects := api.Group("/projects")
+	projects.Use(auth.RequireAuth(authSvc))
+	projects.GET("", listProjectsHandler)
+	projects.POST("", createProjectHandler, auth.RequireRole(models.RoleAdmin))
+	projects.GET("/:id", getProjectHandler)
+	projects.PUT("/:id", updateProjectHandler)
+	projects.DELETE("/:id", deleteProjectHandler, auth.RequireRole(models.RoleAdmin))
+	projects.PUT("/:id/members", setProjectMemberHandler)
+	projects.DELETE("/:id/members/:user_id", removeProjectMemberHandler)
+	projects.POST("/:id/resources", addProjectResourceHandler)
+	projects.DELETE("/:id/resources/:type/:resource_id", removeProjectResourceHandler)
+
+	// Recycle bin for removed containers and stacks (admin only)
+	trash := api.Group("/trash")
+	trash.Use(auth.RequireAuth(authSvc))
+	trash.Use(auth.RequireRole(models.RoleAdmin))
+	trash.GET("", listTrashHandler)
+	trash.GET("/:id", getTrashItemHandler)
+	trash.POST("/:id/restore", restoreTrashItemHandler)
+	trash.DELETE("/:id", purgeTrashItemHandler)
+	trash.DELETE("", emptyTrashHandler)
+
+	// Icon library and uploads (referenced by URL from containers, templates, stacks)
+	icons := api.Group("/icons")
+	icons.Use(auth.RequireAuth(authSvc))
+	icons.GET("", listIconsHandler)
+	icons.GET("/:id", serveIconHandler)
+	icons.POST("", uploadIconHandler, auth.RequireOperatorOrAdmin())
+	icons.POST("/library", fetchLibraryIconHandler, auth.RequireOperatorOrAdmin())
+	icons.DELETE("/:id", deleteIconHandler, auth.RequireRole(models.RoleAdmin))
+
 	// Desktop apps endpoint (containers with web UIs)
 	api.GET("/desktop-apps", listDesktopAppsHandler, auth.RequireAuth(authSvc))
 
@@ -337,6 +874,7 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 
 	// Phase 3: Starfleet Alliance (SSO/Identity Federation)
 	InitAllianceRepo()
+	InitAllianceHealth()
 
 	// OIDC authentication endpoints (public - no auth required)
 	api.GET("/alliance/providers/:id/login", oidcLoginHandler)
@@ -358,12 +896,18 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	alliance.PUT("/providers/:id", updateProviderHandler, auth.RequireRole(models.RoleAdmin))
 	alliance.DELETE("/providers/:id", deleteProviderHandler, auth.RequireRole(models.RoleAdmin))
 	alliance.POST("/providers/:id/test", testProviderHandler, auth.RequireRole(models.RoleAdmin))
+	alliance.GET("/providers/:id/health", getProviderHealthHandler)
+	alliance.POST("/providers/:id/health", checkProviderHealthHandler, auth.RequireRole(models.RoleAdmin))
 
 	// Client management (admin only)
 	alliance.GET("/clients", listClientsHandler)
 	alliance.GET("/clients/:id", getClientHandler)
 	alliance.POST("/clients", createClientHandler, auth.RequireRole(models.RoleAdmin))
 	alliance.DELETE("/clients/:id", deleteClientHandler, auth.RequireRole(models.RoleAdmin))
+	alliance.POST("/clients/:id/rotate-secret", rotateClientSecretHandler, auth.RequireRole(models.RoleAdmin))
+	alliance.POST("/clients/:id/verify-secret", verifyClientSecretHandler, auth.RequireRole(models.RoleAdmin))
+	alliance.GET("/clients/secret-settings", getClientSecretSettingsHandler, auth.RequireRole(models.RoleAdmin))
+	alliance.PUT("/clients/secret-settings", updateClientSecretSettingsHandler, auth.RequireRole(models.RoleAdmin))
 
 	// Federated users and groups (read: all, sync: operator+)
 	alliance.GET("/users", listAllianceUsersHandler)
@@ -371,6 +915,27 @@ func RegisterRoutes(api *echo.Group, authSvc *auth.Service) {
 	alliance.POST("/users/sync", syncUsersHandler, auth.RequireOperatorOrAdmin())
 	alliance.POST("/users/:id/link", linkAllianceUserHandler, auth.RequireRole(models.RoleAdmin))
 
+	// Plugins: sidecar processes adding API routes, dashboard widgets, job
+	// types and notification channels. Managing them is admin only; a plugin's
+	// own routes carry the role each one needs.
+	plugins := api.Group("/plugins")
+	plugins.Use(auth.RequireAuth(authSvc))
+	plugins.GET("/widgets", listPluginWidgetsHandler)
+	plugins.Any("/:name/api/*", pluginAPIHandler)
+	pluginAdmin := plugins.Group("", auth.RequireRole(models.RoleAdmin))
+	pluginAdmin.GET("", listPluginsHandler)
+	pluginAdmin.POST("", installPluginHandler)
+	pluginAdmin.GET("/jobs", listPluginJobsHandler)
+	pluginAdmin.POST("/jobs", createPluginJobHandler)
+	pluginAdmin.PUT("/jobs/:id", updatePluginJobHandler)
+	pluginAdmin.DELETE("/jobs/:id", deletePluginJobHandler)
+	pluginAdmin.POST("/jobs/:id/run", runPluginJobHandler)
+	pluginAdmin.GET("/:name", getPluginHandler)
+	pluginAdmin.DELETE("/:name", uninstallPluginHandler)
+	pluginAdmin.POST("/:name/enable", enablePluginHandler)
+	pluginAdmin.POST("/:name/disable", disablePluginHandler)
+	pluginAdmin.GET("/:name/logs", getPluginLogsHandler)
+
 	// Built-in templates (Phase 3: Office Suite, Auth Providers)
 	builtIn := api.Group("/builtin-templates")
 	builtIn.Use(auth.RequireAuth(authSvc))
@@ -0,0 +1,494 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var securityProfileRepo *database.SecurityProfileRepo
+
+// InitSecurityProfileRepo initializes the security profile repository
+func InitSecurityProfileRepo() {
+	securityProfileRepo = database.NewSecurityProfileRepo()
+}
+
+// selinuxEnforcing reports whether SELinux confines containers on this host
+func selinuxEnforcing() bool {
+	data, err := os.ReadFile("/sys/fs/selinux/enforce")
+	return err == nil && strings.TrimSpace(string(data)) == "1"
+}
+
+// containerConfinements reports how every container is confined, naming the
+// store profiles in use
+func containerConfinements(ctx context.Context, profiles []models.SecurityProfile) ([]models.ContainerConfinement, error) {
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]string)
+	byAppArmor := make(map[string]string)
+	for _, p := range profiles {
+		if p.Type == models.SecurityProfileAppArmor {
+			byAppArmor[p.Name] = p.Name
+		} else {
+			byPath[p.Path] = p.Name
+		}
+	}
+	enforcing := selinuxEnforcing()
+
+	confinements := []models.ContainerConfinement{}
+	for _, item := range containers {
+		inspect, err := podmanService.InspectContainer(ctx, item.ContainerID)
+		if err != nil {
+			continue // Removed since listing
+		}
+		conf := models.ContainerConfinement{
+			ContainerID:  item.ContainerID,
+			Name:         item.Name,
+			Running:      item.Status == models.ContainerStatusRunning,
+			Privileged:   inspect.HostConfig.Privileged,
+			Seccomp:      "default",
+			AppArmor:     inspect.AppArmorProfile,
+			SELinuxLabel: inspect.ProcessLabel,
+		}
+		labelDisabled := false
+		for _, opt := range inspect.HostConfig.SecurityOpt {
+			switch {
+			case strings.HasPrefix(opt, "seccomp="):
+				conf.Seccomp = strings.TrimPrefix(opt, "seccomp=")
+			case opt == "label=disable" || opt == "label:disable":
+				labelDisabled = true
+			}
+		}
+		if conf.Privileged {
+			conf.Seccomp = "unconfined" // Podman never filters privileged containers
+		}
+		conf.SeccompProfile = byPath[conf.Seccomp]
+		conf.AppArmorProfile = byAppArmor[conf.AppArmor]
+
+		conf.Unconfined = conf.Seccomp == "unconfined" || conf.AppArmor == "unconfined" ||
+			(enforcing && (labelDisabled || conf.SELinuxLabel == "" || strings.Contains(conf.SELinuxLabel, ":spc_t:")))
+		confinements = append(confinements, conf)
+	}
+	return confinements, nil
+}
+
+// listSecurityProfilesHandler handles GET /api/security/profiles
+func listSecurityProfilesHandler(c echo.Context) error {
+	profiles, err := securityProfileRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list security profiles: " + err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+	if confinements, err := containerConfinements(ctx, profiles); err == nil {
+		for i := range profiles {
+			for _, conf := range confinements {
+				if conf.SeccompProfile == profiles[i].Name && profiles[i].Type == models.SecurityProfileSeccomp ||
+					conf.AppArmorProfile == profiles[i].Name && profiles[i].Type == models.SecurityProfileAppArmor {
+					profiles[i].Containers = append(profiles[i].Containers, conf.Name)
+				}
+			}
+		}
+	}
+
+	// Content is only returned for a single profile
+	for i := range profiles {
+		profiles[i].Content = ""
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"profiles":          profiles,
+		"curated":           system.CuratedSecurityProfiles(),
+		"apparmor_enabled":  system.AppArmorEnabled(),
+		"selinux_enforcing": selinuxEnforcing(),
+		"default_seccomp":   system.DefaultSeccompPath(),
+	})
+}
+
+// getSecurityProfileHandler handles GET /api/security/profiles/:id
+func getSecurityProfileHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid profile ID",
+		})
+	}
+	profile, err := securityProfileRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Profile not found",
+		})
+	}
+	return c.JSON(http.StatusOK, profile)
+}
+
+// createSecurityProfileHandler handles POST /api/security/profiles
+func createSecurityProfileHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	var req models.CreateSecurityProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	profile := &models.SecurityProfile{
+		Name:        strings.TrimSpace(req.Name),
+		Type:        req.Type,
+		Description: req.Description,
+		Content:     req.Content,
+		CreatedBy:   user.Username,
+	}
+	if req.Curated != "" {
+		curated, err := system.CuratedSecurityProfile(req.Curated)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		curated.CreatedBy = user.Username
+		if profile.Name != "" {
+			curated.Name = profile.Name
+		}
+		if profile.Description != "" {
+			curated.Description = profile.Description
+		}
+		profile = curated
+	}
+
+	if err := system.ValidateSecurityProfile(profile); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if _, err := securityProfileRepo.GetByName(profile.Type, profile.Name); err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": fmt.Sprintf("A %s profile named '%s' already exists", profile.Type, profile.Name),
+		})
+	}
+
+	if err := system.InstallSecurityProfile(profile); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Failed to install profile: " + err.Error(),
+		})
+	}
+	if err := securityProfileRepo.Create(profile); err != nil {
+		system.RemoveSecurityProfile(profile)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save profile: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionSecurityProfileCreate, profile.Name, map[string]interface{}{
+		"id":      profile.ID,
+		"type":    profile.Type,
+		"curated": profile.Curated,
+	})
+
+	return c.JSON(http.StatusCreated, profile)
+}
+
+// updateSecurityProfileHandler handles PUT /api/security/profiles/:id. New
+// seccomp content applies to containers as they are next created.
+func updateSecurityProfileHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid profile ID",
+		})
+	}
+	profile, err := securityProfileRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Profile not found",
+		})
+	}
+
+	var req models.UpdateSecurityProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+	if req.Description != nil {
+		profile.Description = *req.Description
+	}
+	if req.Content != nil {
+		profile.Content = *req.Content
+		if err := system.ValidateSecurityProfile(profile); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		if err := system.InstallSecurityProfile(profile); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Failed to install profile: " + err.Error(),
+			})
+		}
+	}
+
+	if err := securityProfileRepo.Update(profile); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save profile: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionSecurityProfileUpdate, profile.Name, map[string]interface{}{
+		"id":              profile.ID,
+		"type":            profile.Type,
+		"content_changed": req.Content != nil,
+	})
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+// deleteSecurityProfileHandler handles DELETE /api/security/profiles/:id
+func deleteSecurityProfileHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid profile ID",
+		})
+	}
+	profile, err := securityProfileRepo.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Profile not found",
+		})
+	}
+
+	// Containers keep referring to the profile and would fail to recreate without it
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+	confinements, err := containerConfinements(ctx, []models.SecurityProfile{*profile})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to check containers using the profile: " + err.Error(),
+		})
+	}
+	var inUse []string
+	for _, conf := range confinements {
+		if conf.SeccompProfile != "" || conf.AppArmorProfile != "" {
+			inUse = append(inUse, conf.Name)
+		}
+	}
+	if len(inUse) > 0 {
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error":      "Profile is assigned to containers",
+			"containers": inUse,
+		})
+	}
+
+	if err := system.RemoveSecurityProfile(profile); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := securityProfileRepo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete profile: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionSecurityProfileDelete, profile.Name, map[string]interface{}{
+		"id":   profile.ID,
+		"type": profile.Type,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Profile deleted",
+	})
+}
+
+// listContainerConfinementHandler handles GET /api/security/confinement
+func listContainerConfinementHandler(c echo.Context) error {
+	profiles, err := securityProfileRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list security profiles: " + err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+	confinements, err := containerConfinements(ctx, profiles)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to inspect containers: " + err.Error(),
+		})
+	}
+
+	unconfined := 0
+	for _, conf := range confinements {
+		if conf.Unconfined {
+			unconfined++
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"containers": confinements,
+		"unconfined": unconfined,
+	})
+}
+
+// assignSecurityProfileHandler handles PUT /api/security/confinement/:id.
+// Profiles are fixed when a container is created, so the container is
+// recreated with the same configuration and restored if that fails.
+func assignSecurityProfileHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	var req models.AssignSecurityProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	seccompPath, appArmorName := "", ""
+	if req.SeccompProfileID != 0 {
+		p, err := securityProfileRepo.GetByID(req.SeccompProfileID)
+		if err != nil || p.Type != models.SecurityProfileSeccomp {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Seccomp profile not found",
+			})
+		}
+		seccompPath = p.Path
+	}
+	if req.AppArmorProfileID != 0 {
+		p, err := securityProfileRepo.GetByID(req.AppArmorProfileID)
+		if err != nil || p.Type != models.SecurityProfileAppArmor {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "AppArmor profile not found",
+			})
+		}
+		appArmorName = p.Name
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+	defer cancel()
+
+	containerID := resolveContainerID(c.Param("id"))
+	inspect, err := podmanService.InspectContainer(ctx, containerID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+	if inspect.HostConfig.Privileged {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Privileged containers run without seccomp and AppArmor; remove privileged mode first",
+		})
+	}
+	config, err := podmanService.GetContainerConfig(ctx, containerID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read container config: " + err.Error(),
+		})
+	}
+	dbContainer, _ := containerRepo.GetByContainerID(containerID)
+	if dbContainer != nil {
+		config.HasWebUI = dbContainer.HasWebUI
+		config.WebUIPort = dbContainer.WebUIPort
+		config.WebUIPath = dbContainer.WebUIPath
+		config.Icon = dbContainer.Icon
+		config.IconLight = dbContainer.IconLight
+		config.IconDark = dbContainer.IconDark
+		config.AutoStart = dbContainer.AutoStart
+	}
+	previousSeccomp, previousAppArmor := config.SeccompProfile, config.AppArmorProfile
+	config.SeccompProfile = seccompPath
+	config.AppArmorProfile = appArmorName
+
+	// Swap the containers: stop, keep the old one aside, create and start the new one
+	wasRunning := inspect.State.Running
+	if wasRunning {
+		if err := podmanService.StopContainer(ctx, containerID, 30); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to stop container: " + err.Error(),
+			})
+		}
+	}
+	backupName := fmt.Sprintf("%s_backup_%s", config.Name, time.Now().Format("20060102_150405"))
+	restore := func() {
+		podmanService.RenameContainer(ctx, backupName, config.Name)
+		if wasRunning {
+			podmanService.StartContainer(ctx, containerID)
+		}
+	}
+	if err := podmanService.RenameContainer(ctx, containerID, backupName); err != nil {
+		if wasRunning {
+			podmanService.StartContainer(ctx, containerID)
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to rename container: " + err.Error(),
+		})
+	}
+
+	newContainerID, err := podmanService.CreateContainer(ctx, configToCreateRequest(config, config.Image))
+	if err != nil {
+		restore()
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to recreate container, original restored: " + err.Error(),
+		})
+	}
+	if wasRunning {
+		if err := podmanService.StartContainer(ctx, newContainerID); err != nil {
+			podmanService.RemoveContainer(ctx, newContainerID, true)
+			restore()
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Container failed to start with the new profile, original restored: " + err.Error(),
+			})
+		}
+	}
+	if err := podmanService.RemoveContainer(ctx, backupName, true); err != nil {
+		c.Logger().Warnf("Failed to remove previous container %s: %v", backupName, err)
+	}
+
+	if dbContainer != nil {
+		dbContainer.ContainerID = newContainerID
+		if wasRunning {
+			dbContainer.Status = models.ContainerStatusRunning
+		} else {
+			dbContainer.Status = models.ContainerStatusCreated
+		}
+		containerRepo.Update(dbContainer)
+		recordContainerConfig(ctx, dbContainer, models.ConfigSnapshotSecurityProfile, &user.ID)
+	}
+
+	logAudit(user, models.ActionSecurityProfileAssign, config.Name, map[string]interface{}{
+		"old_container_id":  containerID,
+		"new_container_id":  newContainerID,
+		"seccomp":           seccompPath,
+		"apparmor":          appArmorName,
+		"previous_seccomp":  previousSeccomp,
+		"previous_apparmor": previousAppArmor,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"container_id": newContainerID,
+		"name":         config.Name,
+		"seccomp":      seccompPath,
+		"apparmor":     appArmorName,
+	})
+}
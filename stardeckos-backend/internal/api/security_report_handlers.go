@@ -0,0 +1,360 @@
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/pdf"
+	"stardeckos-backend/internal/system"
+)
+
+// certExpiryWarningDays is how close to expiry a certificate is flagged
+const certExpiryWarningDays = 30
+
+// buildSecurityReport gathers the host's security posture. Checks that cannot
+// run, such as podman or dnf being unavailable, become info findings rather
+// than failing the whole report.
+func buildSecurityReport(ctx context.Context, user *models.User) *models.SecurityReport {
+	report := &models.SecurityReport{
+		GeneratedAt:     time.Now(),
+		GeneratedBy:     user.Username,
+		Summary:         map[models.FindingSeverity]int{},
+		Findings:        []models.SecurityFinding{},
+		Ports:           []models.ReportPort{},
+		Containers:      []models.ContainerSecurity{},
+		SecurityUpdates: []models.SecurityAdvisory{},
+		Certificates:    []models.CertificateExpiry{},
+	}
+	report.Hostname, _ = os.Hostname()
+	add := func(check string, severity models.FindingSeverity, title, detail string) {
+		report.Findings = append(report.Findings, models.SecurityFinding{
+			Check: check, Severity: severity, Title: title, Detail: detail,
+		})
+		report.Summary[severity]++
+	}
+
+	// Default admin password
+	if admin, err := userRepo.GetByUsername("admin"); err == nil && admin.AuthType == models.AuthTypeLocal {
+		if ok, _ := auth.VerifyPassword("admin", admin.PasswordHash); ok {
+			report.DefaultAdminPassword = true
+		}
+	}
+	if report.DefaultAdminPassword {
+		add("default_password", models.FindingCritical, "The admin account still uses the default password",
+			"Change the password of the admin account or disable it.")
+	} else {
+		add("default_password", models.FindingPass, "The admin account does not use the default password", "")
+	}
+
+	// Firewall and listening services
+	if status, err := system.GetFirewallStatus(); err == nil {
+		report.FirewallRunning = status.Running
+	}
+	if !report.FirewallRunning {
+		add("firewall", models.FindingCritical, "The firewall is not running",
+			"Every listening service is reachable from the network.")
+	}
+	if ports, err := system.GetPortExposure(); err != nil {
+		add("ports", models.FindingInfo, "Could not compare firewall ports with listening services", err.Error())
+	} else {
+		report.Ports = ports
+		var exposed, unused []string
+		for _, p := range ports {
+			spec := fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+			switch {
+			case p.FirewallOpen && p.Listening:
+				exposed = append(exposed, spec+" ("+strings.Join(p.Processes, ", ")+")")
+			case p.FirewallOpen:
+				unused = append(unused, spec)
+			}
+		}
+		if report.FirewallRunning {
+			add("firewall", models.FindingPass, "The firewall is running", "")
+		}
+		if len(exposed) > 0 {
+			add("exposed_services", models.FindingInfo, fmt.Sprintf("%d listening services are reachable through the firewall", len(exposed)),
+				strings.Join(exposed, "; "))
+		}
+		if len(unused) > 0 {
+			add("unused_ports", models.FindingWarning, fmt.Sprintf("%d firewall ports are open with nothing listening", len(unused)),
+				"Close ports that are no longer needed: "+strings.Join(unused, ", "))
+		}
+	}
+
+	// Container privileges
+	if containers, err := podmanService.ListContainers(ctx); err != nil {
+		add("containers", models.FindingInfo, "Could not inspect containers", err.Error())
+	} else {
+		var privileged, root []string
+		for _, item := range containers {
+			inspect, err := podmanService.InspectContainer(ctx, item.ContainerID)
+			if err != nil {
+				continue
+			}
+			entry := models.ContainerSecurity{
+				Name:       item.Name,
+				Image:      item.Image,
+				Running:    item.Status == models.ContainerStatusRunning,
+				Privileged: inspect.HostConfig.Privileged,
+				User:       inspect.Config.User,
+				CapAdd:     inspect.HostConfig.CapAdd,
+			}
+			switch entry.User {
+			case "", "root", "0", "0:0", "root:root":
+				entry.RunsAsRoot = true
+			}
+			if entry.CapAdd == nil {
+				entry.CapAdd = []string{}
+			}
+			report.Containers = append(report.Containers, entry)
+			if entry.Privileged {
+				privileged = append(privileged, entry.Name)
+			}
+			if entry.RunsAsRoot {
+				root = append(root, entry.Name)
+			}
+		}
+		if len(privileged) > 0 {
+			add("privileged_containers", models.FindingCritical, fmt.Sprintf("%d containers run privileged", len(privileged)),
+				strings.Join(privileged, ", "))
+		}
+		if len(root) > 0 {
+			add("root_containers", models.FindingWarning, fmt.Sprintf("%d containers run as root", len(root)),
+				strings.Join(root, ", "))
+		}
+		if len(privileged) == 0 && len(root) == 0 {
+			add("containers", models.FindingPass, "No containers run privileged or as root", "")
+		}
+	}
+
+	// Pending security updates
+	if advisories, err := system.SecurityUpdates(); err != nil {
+		add("security_updates", models.FindingInfo, "Could not check for security updates", err.Error())
+	} else {
+		report.SecurityUpdates = advisories
+		critical := 0
+		for _, a := range advisories {
+			if a.Severity == "Critical" || a.Severity == "Important" {
+				critical++
+			}
+		}
+		switch {
+		case critical > 0:
+			add("security_updates", models.FindingCritical, fmt.Sprintf("%d critical or important security updates are pending", critical),
+				fmt.Sprintf("%d security advisories in total.", len(advisories)))
+		case len(advisories) > 0:
+			add("security_updates", models.FindingWarning, fmt.Sprintf("%d security updates are pending", len(advisories)), "")
+		default:
+			add("security_updates", models.FindingPass, "No security updates are pending", "")
+		}
+	}
+
+	// Certificate expiry
+	tlsListener.mu.Lock()
+	var serverCert *x509.Certificate
+	if tlsListener.cert != nil {
+		serverCert = tlsListener.cert.Leaf
+		if serverCert == nil && len(tlsListener.cert.Certificate) > 0 {
+			serverCert, _ = x509.ParseCertificate(tlsListener.cert.Certificate[0])
+		}
+	}
+	ca := tlsListener.ca
+	tlsListener.mu.Unlock()
+	if serverCert != nil {
+		report.Certificates = append(report.Certificates, certificateExpiry("HTTPS server", serverCert))
+	}
+	if ca != nil {
+		report.Certificates = append(report.Certificates, certificateExpiry("Client CA", ca.Cert))
+	}
+	if serverCert == nil {
+		add("certificates", models.FindingWarning, "The web UI is served over plain HTTP", "")
+	}
+	for _, cert := range report.Certificates {
+		switch {
+		case cert.DaysLeft < 0:
+			add("certificates", models.FindingCritical, cert.Name+" certificate has expired",
+				"Expired "+cert.NotAfter.Format("2006-01-02")+".")
+		case cert.DaysLeft <= certExpiryWarningDays:
+			add("certificates", models.FindingWarning, fmt.Sprintf("%s certificate expires in %d days", cert.Name, cert.DaysLeft), "")
+		default:
+			add("certificates", models.FindingPass, fmt.Sprintf("%s certificate is valid for %d days", cert.Name, cert.DaysLeft), "")
+		}
+	}
+
+	// Most severe first, keeping check order within a severity
+	rank := map[models.FindingSeverity]int{
+		models.FindingCritical: 0, models.FindingWarning: 1, models.FindingInfo: 2, models.FindingPass: 3,
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return rank[report.Findings[i].Severity] < rank[report.Findings[j].Severity]
+	})
+
+	return report
+}
+
+// certificateExpiry summarizes a certificate's remaining validity
+func certificateExpiry(name string, cert *x509.Certificate) models.CertificateExpiry {
+	return models.CertificateExpiry{
+		Name:     name,
+		Subject:  cert.Subject.CommonName,
+		NotAfter: cert.NotAfter,
+		DaysLeft: int(time.Until(cert.NotAfter).Hours() / 24),
+	}
+}
+
+var securityReportPage = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"date": func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"count": func(summary map[models.FindingSeverity]int, severity string) int {
+		return summary[models.FindingSeverity(severity)]
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Security report - {{.Hostname}}</title>
+<style>
+body{font-family:system-ui,sans-serif;margin:2rem;color:#0f172a}
+table{border-collapse:collapse;width:100%;margin-bottom:1.5rem}
+th,td{border:1px solid #cbd5e1;padding:.35rem .6rem;text-align:left;vertical-align:top}
+th{background:#f1f5f9}
+.critical{color:#b91c1c;font-weight:600}.warning{color:#b45309;font-weight:600}.info{color:#1d4ed8}.pass{color:#15803d}
+</style>
+</head>
+<body>
+<h1>Security report - {{.Hostname}}</h1>
+<p>Generated {{date .GeneratedAt}} by {{.GeneratedBy}}.
+Critical: {{count .Summary "critical"}}, warnings: {{count .Summary "warning"}}, info: {{count .Summary "info"}}, passed: {{count .Summary "pass"}}.</p>
+<h2>Findings</h2>
+<table><tr><th>Severity</th><th>Finding</th><th>Detail</th></tr>
+{{range .Findings}}<tr><td class="{{.Severity}}">{{.Severity}}</td><td>{{.Title}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+<h2>Ports</h2>
+<table><tr><th>Port</th><th>Firewall</th><th>Listening</th></tr>
+{{range .Ports}}<tr><td>{{.Port}}/{{.Protocol}}</td><td>{{if .FirewallOpen}}open {{join .Zones ", "}}{{else}}closed{{end}}</td><td>{{if .Listening}}{{join .Processes ", "}}{{else}}-{{end}}</td></tr>
+{{end}}</table>
+<h2>Containers</h2>
+<table><tr><th>Name</th><th>Image</th><th>User</th><th>Privileged</th><th>Added capabilities</th></tr>
+{{range .Containers}}<tr><td>{{.Name}}</td><td>{{.Image}}</td><td>{{if .RunsAsRoot}}root{{else}}{{.User}}{{end}}</td><td>{{if .Privileged}}yes{{else}}no{{end}}</td><td>{{join .CapAdd ", "}}</td></tr>
+{{end}}</table>
+<h2>Security updates</h2>
+<table><tr><th>Advisory</th><th>Severity</th><th>Package</th></tr>
+{{range .SecurityUpdates}}<tr><td>{{.ID}}</td><td>{{.Severity}}</td><td>{{.Package}}</td></tr>
+{{end}}</table>
+<h2>Certificates</h2>
+<table><tr><th>Certificate</th><th>Subject</th><th>Expires</th><th>Days left</th></tr>
+{{range .Certificates}}<tr><td>{{.Name}}</td><td>{{.Subject}}</td><td>{{date .NotAfter}}</td><td>{{.DaysLeft}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// renderSecurityReportPDF lays the report out as a text PDF
+func renderSecurityReportPDF(report *models.SecurityReport) []byte {
+	doc := pdf.New("Security report - " + report.Hostname)
+	doc.Text(fmt.Sprintf("Generated %s by %s.", report.GeneratedAt.Format("2006-01-02 15:04 MST"), report.GeneratedBy))
+	doc.Text(fmt.Sprintf("Critical: %d, warnings: %d, info: %d, passed: %d.",
+		report.Summary[models.FindingCritical], report.Summary[models.FindingWarning],
+		report.Summary[models.FindingInfo], report.Summary[models.FindingPass]))
+
+	doc.Heading("Findings")
+	for _, f := range report.Findings {
+		doc.Text("[" + strings.ToUpper(string(f.Severity)) + "] " + f.Title)
+		if f.Detail != "" {
+			doc.Text("    " + f.Detail)
+		}
+	}
+
+	doc.Heading("Ports")
+	for _, p := range report.Ports {
+		firewall := "closed"
+		if p.FirewallOpen {
+			firewall = "open " + strings.Join(p.Zones, ", ")
+		}
+		listening := "nothing listening"
+		if p.Listening {
+			listening = strings.Join(p.Processes, ", ")
+		}
+		doc.Text(fmt.Sprintf("%d/%s - firewall %s - %s", p.Port, p.Protocol, firewall, listening))
+	}
+
+	doc.Heading("Containers")
+	for _, ct := range report.Containers {
+		user := ct.User
+		if ct.RunsAsRoot {
+			user = "root"
+		}
+		line := fmt.Sprintf("%s (%s) - user %s", ct.Name, ct.Image, user)
+		if ct.Privileged {
+			line += " - privileged"
+		}
+		if len(ct.CapAdd) > 0 {
+			line += " - adds " + strings.Join(ct.CapAdd, ", ")
+		}
+		doc.Text(line)
+	}
+
+	doc.Heading("Security updates")
+	if len(report.SecurityUpdates) == 0 {
+		doc.Text("None pending.")
+	}
+	for _, a := range report.SecurityUpdates {
+		doc.Text(fmt.Sprintf("%s (%s) - %s", a.ID, a.Severity, a.Package))
+	}
+
+	doc.Heading("Certificates")
+	for _, cert := range report.Certificates {
+		doc.Text(fmt.Sprintf("%s (%s) - expires %s, %d days left",
+			cert.Name, cert.Subject, cert.NotAfter.Format("2006-01-02"), cert.DaysLeft))
+	}
+
+	return doc.Bytes()
+}
+
+// getSecurityReportHandler handles GET /api/security/report?format=json|html|pdf
+func getSecurityReportHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "html" && format != "pdf" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "format must be json, html or pdf",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+	defer cancel()
+	report := buildSecurityReport(ctx, user)
+
+	logAudit(user, models.ActionSecurityReport, report.Hostname, map[string]interface{}{
+		"format":   format,
+		"critical": report.Summary[models.FindingCritical],
+		"warning":  report.Summary[models.FindingWarning],
+	})
+
+	filename := "stardeck-security-report-" + report.GeneratedAt.Format("20060102-150405")
+	switch format {
+	case "html":
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		return securityReportPage.Execute(c.Response(), report)
+	case "pdf":
+		c.Response().Header().Set("Content-Disposition", `attachment; filename="`+filename+`.pdf"`)
+		return c.Blob(http.StatusOK, "application/pdf", renderSecurityReportPDF(report))
+	}
+	return c.JSON(http.StatusOK, report)
+}
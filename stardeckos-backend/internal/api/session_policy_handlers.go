@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// validateSessionPolicy checks a session policy's bounds
+func validateSessionPolicy(p models.SessionPolicy) error {
+	if p.IdleTimeoutMinutes < 1 || p.IdleTimeoutMinutes > 7*24*60 {
+		return errors.New("idle_timeout_minutes must be between 1 and 10080")
+	}
+	if p.AbsoluteLifetimeHours < 0 || p.AbsoluteLifetimeHours > 365*24 {
+		return errors.New("absolute_lifetime_hours must be between 0 (no limit) and 8760")
+	}
+	if p.AbsoluteLifetimeHours > 0 && p.AbsoluteLifetimeHours*60 < p.IdleTimeoutMinutes {
+		return errors.New("absolute_lifetime_hours must not be shorter than the idle timeout")
+	}
+	if p.MaxSessions < 0 || p.MaxSessions > 100 {
+		return errors.New("max_sessions must be between 0 (unlimited) and 100")
+	}
+	return nil
+}
+
+// getSessionPolicyHandler returns the default session policy and per-role overrides
+func getSessionPolicyHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, authService.SessionPolicies())
+}
+
+// updateSessionPolicyHandler replaces the session policies. Roles left out of
+// the request lose their override. Changes apply to existing sessions on their
+// next request.
+func updateSessionPolicyHandler(c echo.Context) error {
+	var req models.SessionPolicySettings
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if err := validateSessionPolicy(req.Default); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	for role, policy := range req.Roles {
+		switch role {
+		case models.RoleAdmin, models.RoleOperator, models.RoleViewer:
+		default:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Unknown role: " + string(role),
+			})
+		}
+		if err := validateSessionPolicy(policy); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": string(role) + ": " + err.Error(),
+			})
+		}
+	}
+
+	values := map[string]string{
+		database.SettingSessionTimeout:    strconv.Itoa(req.Default.IdleTimeoutMinutes),
+		database.SettingSessionLifetime:   strconv.Itoa(req.Default.AbsoluteLifetimeHours),
+		database.SettingSessionMaxPerUser: strconv.Itoa(req.Default.MaxSessions),
+	}
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleOperator, models.RoleViewer} {
+		idle, lifetime, limit := "", "", ""
+		if policy, ok := req.Roles[role]; ok {
+			idle = strconv.Itoa(policy.IdleTimeoutMinutes)
+			lifetime = strconv.Itoa(policy.AbsoluteLifetimeHours)
+			limit = strconv.Itoa(policy.MaxSessions)
+		}
+		values[database.RoleSetting(database.SettingSessionTimeout, role)] = idle
+		values[database.RoleSetting(database.SettingSessionLifetime, role)] = lifetime
+		values[database.RoleSetting(database.SettingSessionMaxPerUser, role)] = limit
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionSessionPolicy, "sessions", values)
+
+	return c.JSON(http.StatusOK, authService.SessionPolicies())
+}
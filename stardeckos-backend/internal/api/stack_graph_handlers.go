@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// serviceStateRank orders service states from best to worst. A service with
+// several replicas takes the state of its worst container.
+var serviceStateRank = map[string]int{
+	models.ServiceStateHealthy:   0,
+	models.ServiceStateRunning:   0,
+	models.ServiceStateCompleted: 0,
+	models.ServiceStateStarting:  1,
+	models.ServiceStateUnhealthy: 2,
+	models.ServiceStateStopped:   3,
+	models.ServiceStateMissing:   3,
+}
+
+// activeComposeServices returns the services a stack runs with its active
+// profiles. Services without profiles always run.
+func activeComposeServices(stack *models.Stack) []models.ComposeService {
+	all := system.ParseComposeServices(stack.ComposeContent)
+	services := all[:0]
+	for _, svc := range all {
+		if len(svc.Profiles) == 0 || slices.ContainsFunc(svc.Profiles, func(p string) bool {
+			return slices.Contains(stack.Profiles, p)
+		}) {
+			services = append(services, svc)
+		}
+	}
+	return services
+}
+
+// containerServiceState maps a container to a service state. An exited
+// container only counts as completed for a one-off service another waits on.
+func containerServiceState(c models.StackContainer, oneOff bool) string {
+	switch {
+	case c.Status == models.ContainerStatusRunning:
+		switch c.Health {
+		case "healthy":
+			return models.ServiceStateHealthy
+		case "unhealthy":
+			return models.ServiceStateUnhealthy
+		case "starting":
+			return models.ServiceStateStarting
+		}
+		return models.ServiceStateRunning
+	case c.Status == models.ContainerStatusRestarting:
+		return models.ServiceStateStarting
+	case c.Status == models.ContainerStatusExited && c.ExitCode == 0 && oneOff:
+		return models.ServiceStateCompleted
+	}
+	return models.ServiceStateStopped
+}
+
+// stackServiceStates works out every service's state from its containers.
+// Services in the compose file without a container are missing; containers
+// for services the file no longer names are still counted.
+func stackServiceStates(services []models.ComposeService, containers []models.StackContainer) map[string]string {
+	oneOff := make(map[string]bool)
+	states := make(map[string]string, len(services))
+	for _, svc := range services {
+		states[svc.Name] = models.ServiceStateMissing
+		for _, dep := range svc.DependsOn {
+			if dep.Condition == models.DependsOnCompleted {
+				oneOff[dep.Service] = true
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range containers {
+		state := containerServiceState(c, oneOff[c.Service])
+		if current, ok := states[c.Service]; !ok || !seen[c.Service] || serviceStateRank[state] > serviceStateRank[current] {
+			states[c.Service] = state
+		}
+		seen[c.Service] = true
+	}
+	return states
+}
+
+// aggregateStackHealth sums service states up into one badge
+func aggregateStackHealth(states map[string]string) models.StackHealth {
+	up, starting, failing := 0, false, false
+	for _, state := range states {
+		switch state {
+		case models.ServiceStateHealthy, models.ServiceStateRunning:
+			up++
+		case models.ServiceStateStarting:
+			up++
+			starting = true
+		case models.ServiceStateUnhealthy:
+			up++
+			failing = true
+		case models.ServiceStateStopped, models.ServiceStateMissing:
+			failing = true
+		}
+	}
+	switch {
+	case up == 0:
+		return models.StackHealthDown
+	case failing:
+		return models.StackHealthDegraded
+	case starting:
+		return models.StackHealthStarting
+	}
+	return models.StackHealthHealthy
+}
+
+// edgeWaiting reports whether a dependency's target hasn't met its condition
+func edgeWaiting(condition, target string) bool {
+	switch condition {
+	case models.DependsOnHealthy:
+		return target != models.ServiceStateHealthy
+	case models.DependsOnCompleted:
+		return target != models.ServiceStateCompleted
+	}
+	return serviceStateRank[target] >= serviceStateRank[models.ServiceStateStopped]
+}
+
+// buildStackGraph links a stack's services by depends_on and links entries
+func buildStackGraph(stack *models.Stack, containers []models.StackContainer) models.StackGraph {
+	services := activeComposeServices(stack)
+	states := stackServiceStates(services, containers)
+	graph := models.StackGraph{
+		StackID: stack.ID,
+		Health:  aggregateStackHealth(states),
+		Nodes:   make([]models.StackGraphNode, 0, len(states)),
+		Edges:   []models.StackGraphEdge{},
+	}
+
+	counts := make(map[string][2]int) // Service -> containers, running
+	for _, c := range containers {
+		n := counts[c.Service]
+		n[0]++
+		if c.Status == models.ContainerStatusRunning {
+			n[1]++
+		}
+		counts[c.Service] = n
+	}
+
+	declared := make(map[string]bool, len(services))
+	for _, svc := range services {
+		declared[svc.Name] = true
+	}
+	for _, svc := range services {
+		networks := svc.Networks
+		if len(networks) == 0 {
+			networks = []string{"default"}
+		}
+		graph.Nodes = append(graph.Nodes, models.StackGraphNode{
+			Service:    svc.Name,
+			Image:      svc.Image,
+			State:      states[svc.Name],
+			Containers: counts[svc.Name][0],
+			Running:    counts[svc.Name][1],
+			Networks:   networks,
+			Profiles:   svc.Profiles,
+		})
+
+		for _, dep := range svc.DependsOn {
+			graph.Edges = append(graph.Edges, models.StackGraphEdge{
+				From:      svc.Name,
+				To:        dep.Service,
+				Kind:      models.StackEdgeDependsOn,
+				Condition: dep.Condition,
+				Missing:   !declared[dep.Service],
+				Waiting:   edgeWaiting(dep.Condition, states[dep.Service]),
+			})
+		}
+		for _, link := range svc.Links {
+			target, alias, _ := strings.Cut(link, ":")
+			graph.Edges = append(graph.Edges, models.StackGraphEdge{
+				From:    svc.Name,
+				To:      target,
+				Kind:    models.StackEdgeLink,
+				Alias:   alias,
+				Missing: !declared[target],
+				Waiting: edgeWaiting(models.DependsOnStarted, states[target]),
+			})
+		}
+	}
+
+	// Containers left from services the compose file no longer names
+	for service, state := range states {
+		if !declared[service] {
+			graph.Nodes = append(graph.Nodes, models.StackGraphNode{
+				Service:    service,
+				State:      state,
+				Containers: counts[service][0],
+				Running:    counts[service][1],
+				Networks:   []string{},
+			})
+		}
+	}
+	slices.SortStableFunc(graph.Nodes[len(services):], func(a, b models.StackGraphNode) int {
+		return strings.Compare(a.Service, b.Service)
+	})
+	return graph
+}
+
+// getStackGraphHandler handles GET /api/stacks/:id/graph
+func getStackGraphHandler(c echo.Context) error {
+	stack, err := stackRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	containers, err := podmanService.GetStackContainers(ctx, stack.Name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get stack containers: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, buildStackGraph(stack, containers))
+}
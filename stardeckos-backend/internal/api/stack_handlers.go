@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -14,6 +16,7 @@ import (
 
 	"stardeckos-backend/internal/database"
 	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
 )
 
 var stackRepo *database.StackRepo
@@ -37,9 +40,9 @@ func ensureStackDir(stackName string) (string, error) {
 
 // writeComposeFiles writes the compose and env files to the stack directory
 func writeComposeFiles(dir, composeContent, envContent string) error {
-	// Write docker-compose.yml
+	// Write docker-compose.yml, with secret references reduced to variables resolved at deploy time
 	composePath := filepath.Join(dir, "docker-compose.yml")
-	if err := os.WriteFile(composePath, []byte(composeContent), 0644); err != nil {
+	if err := os.WriteFile(composePath, []byte(rewriteSecretRefs(composeContent)), 0644); err != nil {
 		return fmt.Errorf("failed to write compose file: %w", err)
 	}
 
@@ -51,7 +54,41 @@ func writeComposeFiles(dir, composeContent, envContent string) error {
 		}
 	}
 
-	return nil
+	// Keep data pool mounts in step with the services
+	return refreshDataPoolOverride(dir, composeContent)
+}
+
+// declaredProfileNames returns every profile declared in a compose file
+func declaredProfileNames(composeContent string) []string {
+	profiles, _ := system.ParseComposeProfiles(composeContent)
+	names := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// validateStackProfiles de-duplicates the requested profiles and rejects any the compose file does not declare
+func validateStackProfiles(composeContent string, requested []string) ([]string, error) {
+	declared := make(map[string]bool)
+	for _, name := range declaredProfileNames(composeContent) {
+		declared[name] = true
+	}
+
+	seen := make(map[string]bool)
+	profiles := []string{}
+	for _, p := range requested {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if !declared[p] {
+			return nil, fmt.Errorf("profile %q is not declared by any service in the compose file", p)
+		}
+		seen[p] = true
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
 }
 
 // listStacksHandler returns all stacks
@@ -63,6 +100,21 @@ func listStacksHandler(c echo.Context) error {
 		})
 	}
 
+	// Hide stacks in projects the user isn't a member of
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+	visible := stacks[:0]
+	for _, stack := range stacks {
+		if view.visible(view.project(models.ProjectResourceStack, stack.ID)) {
+			visible = append(visible, stack)
+		}
+	}
+	stacks = visible
+
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
 	defer cancel()
 
@@ -89,6 +141,18 @@ func listStacksHandler(c echo.Context) error {
 			} else {
 				stacks[i].Status = models.StackStatusStopped
 			}
+
+			// Health follows the compose file, so services that never started count against it
+			var services []models.ComposeService
+			if stack, err := stackRepo.GetByID(stacks[i].ID); err == nil {
+				services = activeComposeServices(stack)
+			}
+			stacks[i].Health = aggregateStackHealth(stackServiceStates(services, containers))
+			for _, cont := range containers {
+				if cont.Status == models.ContainerStatusRunning && cont.Health != "unhealthy" && cont.Health != "starting" {
+					stacks[i].HealthyCount++
+				}
+			}
 		}
 	}
 
@@ -102,12 +166,12 @@ func getStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
@@ -135,12 +199,12 @@ func getStackContainersHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
@@ -157,12 +221,42 @@ func getStackContainersHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, containers)
 }
 
+// getStackProfilesHandler returns the profiles a stack declares and which are active
+func getStackProfilesHandler(c echo.Context) error {
+	stack, err := stackRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+
+	available, defaults := system.ParseComposeProfiles(stack.ComposeContent)
+	if defaults == nil {
+		defaults = []string{}
+	}
+	active := stack.Profiles
+	if active == nil {
+		active = []string{}
+	}
+
+	return c.JSON(http.StatusOK, models.StackProfiles{
+		Available:       available,
+		Active:          active,
+		DefaultServices: defaults,
+	})
+}
+
 // createStackHandler creates a new stack
 func createStackHandler(c echo.Context) error {
 	var req models.CreateStackRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -180,6 +274,13 @@ func createStackHandler(c echo.Context) error {
 		})
 	}
 
+	profiles, err := validateStackProfiles(req.ComposeContent, req.Profiles)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
 	// Create stack directory and write files
 	dir, err := ensureStackDir(req.Name)
 	if err != nil {
@@ -201,6 +302,8 @@ func createStackHandler(c echo.Context) error {
 		Description:    req.Description,
 		ComposeContent: req.ComposeContent,
 		EnvContent:     req.EnvContent,
+		Icon:           req.Icon,
+		Profiles:       profiles,
 		Status:         models.StackStatusStopped,
 		Path:           dir,
 		CreatedBy:      &user.ID,
@@ -224,19 +327,19 @@ func updateStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
 	var req models.UpdateStackRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request: " + err.Error(),
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
 		})
 	}
 
@@ -247,12 +350,48 @@ func updateStackHandler(c echo.Context) error {
 	if req.Description != nil {
 		stack.Description = *req.Description
 	}
+	var majorUpdates []string
 	if req.ComposeContent != nil {
+		majorUpdates = stackMajorUpdates(stack.ComposeContent, *req.ComposeContent)
 		stack.ComposeContent = *req.ComposeContent
 	}
 	if req.EnvContent != nil {
 		stack.EnvContent = *req.EnvContent
 	}
+	if req.Icon != nil {
+		stack.Icon = *req.Icon
+	}
+	if req.Profiles != nil {
+		profiles, err := validateStackProfiles(stack.ComposeContent, *req.Profiles)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		stack.Profiles = profiles
+	} else if req.ComposeContent != nil {
+		// Drop active profiles the new compose file no longer declares
+		declared := make(map[string]bool)
+		for _, name := range declaredProfileNames(stack.ComposeContent) {
+			declared[name] = true
+		}
+		kept := []string{}
+		for _, p := range stack.Profiles {
+			if declared[p] {
+				kept = append(kept, p)
+			}
+		}
+		stack.Profiles = kept
+	}
+
+	user := c.Get("user").(*models.User)
+
+	// A new major version of an image may migrate its data on the next deploy
+	var snapshots []models.HostSnapshot
+	if len(majorUpdates) > 0 {
+		snapshots = snapshotBeforeRisk(models.SnapshotReasonStackUpdate,
+			fmt.Sprintf("updating stack %s: %s", stack.Name, strings.Join(majorUpdates, ", ")), user)
+	}
 
 	// Write updated files
 	if req.ComposeContent != nil || req.EnvContent != nil {
@@ -269,8 +408,21 @@ func updateStackHandler(c echo.Context) error {
 		})
 	}
 
-	user := c.Get("user").(*models.User)
-	logAudit(user, models.ActionStackUpdate, stack.Name, nil)
+	var details map[string]interface{}
+	if len(majorUpdates) > 0 {
+		details = map[string]interface{}{
+			"major_updates": majorUpdates,
+			"snapshots":     snapshotNames(snapshots),
+		}
+	}
+	if req.EnvContent != nil {
+		// The audit repo keeps the variable names and redacts their values
+		if details == nil {
+			details = map[string]interface{}{}
+		}
+		details["env_content"] = stack.EnvContent
+	}
+	logAudit(user, models.ActionStackUpdate, stack.Name, details)
 
 	return c.JSON(http.StatusOK, stack)
 }
@@ -283,41 +435,65 @@ func deleteStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
 	defer cancel()
 
-	// Stop and remove containers
+	// Stop and remove containers, including those from inactive profiles
 	if stack.Path != "" {
-		podmanService.ComposeDown(ctx, stack.Path, stack.Name, removeVolumes, nil)
+		podmanService.ComposeDown(ctx, stack.Path, stack.Name, declaredProfileNames(stack.ComposeContent), removeVolumes, nil)
 	}
 
-	// Remove stack directory
-	if stack.Path != "" {
+	user := c.Get("user").(*models.User)
+
+	// Move stack files to the recycle bin, or remove them outright
+	var trashItem *models.TrashItem
+	if c.QueryParam("permanent") != "true" && trashEnabled() {
+		item, err := trashStack(user, stack, removeVolumes)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to move stack to trash: " + err.Error(),
+			})
+		}
+		trashItem = item
+	} else if stack.Path != "" {
 		os.RemoveAll(stack.Path)
 	}
 
 	// Delete from database
-	if err := stackRepo.Delete(id); err != nil {
+	err = database.WithTx(func(tx *sql.Tx) error {
+		if err := stackRepo.InTx(tx).Delete(id); err != nil {
+			return err
+		}
+		return projectRepo.InTx(tx).RemoveResource(models.ProjectResourceStack, id)
+	})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to delete stack: " + err.Error(),
 		})
 	}
 
-	user := c.Get("user").(*models.User)
-	logAudit(user, models.ActionStackDelete, stack.Name, nil)
+	details := map[string]interface{}{}
+	if trashItem != nil {
+		details["trash_id"] = trashItem.ID
+	}
+	logAudit(user, models.ActionStackDelete, stack.Name, details)
 
-	return c.JSON(http.StatusOK, map[string]string{
+	resp := map[string]string{
 		"status": "deleted",
-	})
+	}
+	if trashItem != nil {
+		resp["trash_id"] = trashItem.ID
+	}
+	return c.JSON(http.StatusOK, resp)
 }
 
 // deployStackHandler deploys a stack via WebSocket for streaming output
@@ -327,18 +503,51 @@ func deployStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
+	// Profiles chosen at deploy time replace the stack's active profiles
+	if _, ok := c.QueryParams()["profiles"]; ok {
+		var requested []string
+		if raw := c.QueryParam("profiles"); raw != "" {
+			requested = strings.Split(raw, ",")
+		}
+		profiles, err := validateStackProfiles(stack.ComposeContent, requested)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		stack.Profiles = profiles
+		if err := stackRepo.Update(stack); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to save stack profiles: " + err.Error(),
+			})
+		}
+	}
+
+	// The stack's owner is charged, whoever deploys it; a redeploy replaces its own containers
+	if stack.CreatedBy != nil {
+		quotaResults, ok, err := checkQuota(c.Request().Context(), *stack.CreatedBy, stackQuotaDemand(stack), stack.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to check quota: " + err.Error(),
+			})
+		}
+		if !ok {
+			return quotaExceededResponse(c, quotaResults)
+		}
+	}
+
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: checkWebSocketOrigin,
 	}
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
@@ -348,9 +557,6 @@ func deployStackHandler(c echo.Context) error {
 
 	user := c.Get("user").(*models.User)
 
-	// Update status
-	stackRepo.UpdateStatus(stack.ID, models.StackStatusDeploying)
-
 	// Send status updates
 	sendStatus := func(message string, isError bool) {
 		ws.WriteJSON(map[string]interface{}{
@@ -360,12 +566,40 @@ func deployStackHandler(c echo.Context) error {
 	}
 
 	ctx := c.Request().Context()
+
+	// Check what the stack needs before compose runs, unless asked not to
+	if c.QueryParam("skip_preflight") != "true" {
+		sendStatus("Running pre-flight checks", false)
+		preflightCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+		results := stackPreflight(preflightCtx, stack)
+		cancel()
+
+		passed := preflightPassed(results)
+		ws.WriteJSON(map[string]interface{}{
+			"preflight": map[string]interface{}{
+				"valid":   passed,
+				"results": results,
+			},
+		})
+		if !passed {
+			sendStatus("Pre-flight checks failed", true)
+			ws.WriteJSON(map[string]interface{}{
+				"complete": true,
+				"success":  false,
+				"error":    "pre-flight checks failed",
+			})
+			return nil
+		}
+	}
+
+	// Update status
+	stackRepo.UpdateStatus(stack.ID, models.StackStatusDeploying)
 	outputChan := make(chan string, 100)
 
 	// Start goroutine to send output
 	done := make(chan error, 1)
 	go func() {
-		done <- podmanService.ComposeUp(ctx, stack.Path, stack.Name, outputChan)
+		done <- composeUpOrdered(ctx, stack, outputChan)
 		close(outputChan)
 	}()
 
@@ -390,12 +624,202 @@ func deployStackHandler(c echo.Context) error {
 	}
 
 	stackRepo.UpdateStatus(stack.ID, models.StackStatusActive)
-	logAudit(user, models.ActionStackDeploy, stack.Name, nil)
+
+	// Report where each service ended up and bring its containers under Stardeck
+	sendStatus("Checking services", false)
+	inspectCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	services, adopted, err := stackServiceReport(inspectCtx, stack, user)
+	cancel()
+	if err != nil {
+		sendStatus("Failed to inspect services: "+err.Error(), true)
+	}
+	states := make(map[string]string, len(services))
+	for _, svc := range services {
+		states[svc.Service] = svc.State
+	}
+	health := aggregateStackHealth(states)
+	ws.WriteJSON(map[string]interface{}{
+		"services": services,
+		"health":   health,
+	})
+
+	logAudit(user, models.ActionStackDeploy, stack.Name, map[string]interface{}{
+		"profiles": stack.Profiles,
+		"adopted":  adopted,
+		"health":   health,
+	})
 
 	sendStatus("Stack deployed successfully", false)
 	ws.WriteJSON(map[string]interface{}{
 		"complete": true,
 		"success":  true,
+		"services": services,
+		"health":   health,
+		"adopted":  adopted,
+	})
+
+	return nil
+}
+
+// stackServiceReport inspects a deployed stack's containers, adopting any
+// Stardeck doesn't know yet, and returns each service's state in compose file
+// order along with how many containers were adopted
+func stackServiceReport(ctx context.Context, stack *models.Stack, user *models.User) ([]models.StackServiceStatus, int, error) {
+	active := activeComposeServices(stack)
+	containers, err := podmanService.GetStackContainers(ctx, stack.Name)
+	if err != nil {
+		return nil, 0, err
+	}
+	states := stackServiceStates(active, containers)
+
+	byService := make(map[string]*models.StackServiceStatus, len(states))
+	var services []*models.StackServiceStatus
+	report := func(name string) *models.StackServiceStatus {
+		if svc, ok := byService[name]; ok {
+			return svc
+		}
+		svc := &models.StackServiceStatus{Service: name, State: states[name], Containers: []string{}}
+		byService[name] = svc
+		services = append(services, svc)
+		return svc
+	}
+	for _, svc := range active {
+		report(svc.Name)
+	}
+
+	adopted := 0
+	for _, sc := range containers {
+		svc := report(sc.Service)
+		svc.Containers = append(svc.Containers, sc.Name)
+		record, created, err := adoptStackContainer(ctx, sc, nil, user)
+		if err != nil {
+			svc.Error = fmt.Sprintf("%s: %v", sc.Name, err)
+			continue
+		}
+		svc.ManagedIDs = append(svc.ManagedIDs, record.ID)
+		if created {
+			svc.Adopted++
+			adopted++
+		}
+	}
+
+	result := make([]models.StackServiceStatus, len(services))
+	for i, svc := range services {
+		result[i] = *svc
+	}
+	return result, adopted, nil
+}
+
+// teardownStackHandler removes a stack's containers via WebSocket, streaming
+// compose output, and drops their Stardeck records. The stack itself
+// and its files stay so it can be deployed again.
+func teardownStackHandler(c echo.Context) error {
+	id := c.Param("id")
+	removeVolumes := c.QueryParam("volumes") == "true"
+
+	stack, err := stackRepo.GetByID(id)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+	if stack.Path == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Stack has no compose files to tear down",
+		})
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	user := c.Get("user").(*models.User)
+	ctx := c.Request().Context()
+
+	sendStatus := func(message string, isError bool) {
+		ws.WriteJSON(map[string]interface{}{
+			"message": message,
+			"error":   isError,
+		})
+	}
+
+	// Note the containers now; once they are gone podman can't say whose they were
+	type stackMember struct {
+		name, containerID string
+	}
+	var members []stackMember
+	if containers, err := podmanService.GetStackContainers(ctx, stack.Name); err == nil {
+		for _, sc := range containers {
+			if info, err := podmanService.InspectContainer(ctx, sc.Name); err == nil {
+				members = append(members, stackMember{sc.Name, info.ID})
+			}
+		}
+	}
+
+	sendStatus("Tearing down stack", false)
+	outputChan := make(chan string, 100)
+	done := make(chan error, 1)
+	go func() {
+		// Services of inactive profiles may still have containers from an earlier deploy
+		done <- podmanService.ComposeDown(ctx, stack.Path, stack.Name, declaredProfileNames(stack.ComposeContent), removeVolumes, outputChan)
+		close(outputChan)
+	}()
+
+	for line := range outputChan {
+		ws.WriteJSON(map[string]interface{}{
+			"output": line,
+		})
+	}
+
+	if downErr := <-done; downErr != nil {
+		stackRepo.UpdateStatus(stack.ID, models.StackStatusError)
+		sendStatus("Teardown failed: "+downErr.Error(), true)
+		ws.WriteJSON(map[string]interface{}{
+			"complete": true,
+			"success":  false,
+			"error":    downErr.Error(),
+		})
+		return nil
+	}
+
+	// Forget the containers that are gone; any left behind keep their records
+	removed := []string{}
+	for _, m := range members {
+		if _, err := podmanService.InspectContainer(ctx, m.containerID); err == nil {
+			continue
+		}
+		managedID := m.containerID
+		if managed, err := containerRepo.GetByContainerID(m.containerID); err == nil && managed != nil {
+			managedID = managed.ID
+		}
+		if err := deleteContainerRecords(managedID, m.containerID); err != nil {
+			sendStatus(fmt.Sprintf("Failed to delete the records of %s: %v", m.name, err), true)
+			continue
+		}
+		removed = append(removed, m.name)
+	}
+
+	stackRepo.UpdateStatus(stack.ID, models.StackStatusStopped)
+	logAudit(user, models.ActionStackTeardown, stack.Name, map[string]interface{}{
+		"volumes":    removeVolumes,
+		"containers": removed,
+	})
+
+	sendStatus("Stack torn down", false)
+	ws.WriteJSON(map[string]interface{}{
+		"complete":   true,
+		"success":    true,
+		"containers": removed,
 	})
 
 	return nil
@@ -408,19 +832,19 @@ func stopStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
 	defer cancel()
 
-	if err := podmanService.ComposeStop(ctx, stack.Path, stack.Name); err != nil {
+	if err := podmanService.ComposeStop(ctx, stack.Path, stack.Name, declaredProfileNames(stack.ComposeContent)); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to stop stack: " + err.Error(),
 		})
@@ -443,19 +867,19 @@ func startStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
 	defer cancel()
 
-	if err := podmanService.ComposeStart(ctx, stack.Path, stack.Name); err != nil {
+	if err := podmanService.ComposeStart(ctx, stack.Path, stack.Name, stack.Profiles); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to start stack: " + err.Error(),
 		})
@@ -478,19 +902,19 @@ func restartStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request().Context(), 120*time.Second)
 	defer cancel()
 
-	if err := podmanService.ComposeRestart(ctx, stack.Path, stack.Name); err != nil {
+	if err := podmanService.ComposeRestart(ctx, stack.Path, stack.Name, stack.Profiles); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to restart stack: " + err.Error(),
 		})
@@ -513,18 +937,18 @@ func pullStackHandler(c echo.Context) error {
 	stack, err := stackRepo.GetByID(id)
 	if err == sql.ErrNoRows {
 		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "Stack not found",
+			"error": tr(c, "error.stack_not_found"),
 		})
 	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to get stack: " + err.Error(),
+			"error": tr(c, "error.get_stack", "error", err.Error()),
 		})
 	}
 
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
+		CheckOrigin: checkWebSocketOrigin,
 	}
 	ws, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
 	if err != nil {
@@ -537,7 +961,7 @@ func pullStackHandler(c echo.Context) error {
 
 	done := make(chan error, 1)
 	go func() {
-		done <- podmanService.ComposePull(ctx, stack.Path, stack.Name, outputChan)
+		done <- podmanService.ComposePull(ctx, stack.Path, stack.Name, stack.Profiles, outputChan)
 		close(outputChan)
 	}()
 
@@ -565,3 +989,195 @@ func pullStackHandler(c echo.Context) error {
 
 	return nil
 }
+
+// stackHealthTimeout is how long a service waits for a dependency to become healthy
+const stackHealthTimeout = 5 * time.Minute
+
+// composeUpOrdered deploys a stack, starting services in dependency order and waiting for
+// depends_on conditions that podman-compose does not enforce itself
+func composeUpOrdered(ctx context.Context, stack *models.Stack, outputChan chan<- string) error {
+	active := make(map[string]bool)
+	for _, p := range stack.Profiles {
+		active[p] = true
+	}
+
+	// Only services enabled by the active profiles take part
+	services := make(map[string]models.ComposeService)
+	gated := false
+	for _, svc := range system.ParseComposeServices(stack.ComposeContent) {
+		enabled := len(svc.Profiles) == 0
+		for _, p := range svc.Profiles {
+			if active[p] {
+				enabled = true
+			}
+		}
+		if !enabled {
+			continue
+		}
+		services[svc.Name] = svc
+		for _, dep := range svc.DependsOn {
+			if dep.Condition == models.DependsOnHealthy || dep.Condition == models.DependsOnCompleted {
+				gated = true
+			}
+		}
+	}
+
+	// Secrets are passed to compose through its environment and never written to disk
+	env, err := resolveStackSecrets(ctx, stack.ComposeContent)
+	if err != nil {
+		return err
+	}
+	if len(env) > 0 {
+		if err := writeComposeFiles(stack.Path, stack.ComposeContent, stack.EnvContent); err != nil {
+			return err
+		}
+	}
+
+	if !gated {
+		return podmanService.ComposeUpServices(ctx, stack.Path, stack.Name, stack.Profiles, nil, env, outputChan)
+	}
+
+	waves, err := dependencyWaves(services)
+	if err != nil {
+		return err
+	}
+
+	satisfied := make(map[string]bool)
+	for _, wave := range waves {
+		for _, name := range wave {
+			for _, dep := range services[name].DependsOn {
+				if dep.Condition != models.DependsOnHealthy && dep.Condition != models.DependsOnCompleted {
+					continue
+				}
+				key := dep.Service + "/" + dep.Condition
+				if satisfied[key] {
+					continue
+				}
+				if err := waitForServiceCondition(ctx, stack.Name, name, dep, outputChan); err != nil {
+					return err
+				}
+				satisfied[key] = true
+			}
+		}
+
+		if outputChan != nil {
+			outputChan <- "Starting " + strings.Join(wave, ", ")
+		}
+		if err := podmanService.ComposeUpServices(ctx, stack.Path, stack.Name, stack.Profiles, wave, env, outputChan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dependencyWaves groups services so each group only depends on services in earlier groups
+func dependencyWaves(services map[string]models.ComposeService) ([][]string, error) {
+	placed := make(map[string]bool)
+	var waves [][]string
+
+	for len(placed) < len(services) {
+		var wave []string
+		for name, svc := range services {
+			if placed[name] {
+				continue
+			}
+			ready := true
+			for _, dep := range svc.DependsOn {
+				// Dependencies outside the active profiles are ignored, as compose does
+				if _, ok := services[dep.Service]; ok && !placed[dep.Service] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, name)
+			}
+		}
+		if len(wave) == 0 {
+			var remaining []string
+			for name := range services {
+				if !placed[name] {
+					remaining = append(remaining, name)
+				}
+			}
+			sort.Strings(remaining)
+			return nil, fmt.Errorf("depends_on cycle between services: %s", strings.Join(remaining, ", "))
+		}
+		sort.Strings(wave)
+		for _, name := range wave {
+			placed[name] = true
+		}
+		waves = append(waves, wave)
+	}
+
+	return waves, nil
+}
+
+// waitForServiceCondition polls a dependency's container until it is healthy or has exited successfully
+func waitForServiceCondition(ctx context.Context, project, service string, dep models.ComposeDependency, outputChan chan<- string) error {
+	if outputChan != nil {
+		outputChan <- fmt.Sprintf("Waiting for %s to satisfy %s before starting %s", dep.Service, dep.Condition, service)
+	}
+
+	deadline := time.Now().Add(stackHealthTimeout)
+	for {
+		state, done, err := serviceConditionState(ctx, project, dep)
+		if err != nil {
+			return fmt.Errorf("service %s is blocked by %s: %w", service, dep.Service, err)
+		}
+		if done {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s is blocked by %s: %s not met within %s (last state: %s)",
+				service, dep.Service, dep.Condition, stackHealthTimeout, state)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("service %s is blocked by %s: %w", service, dep.Service, ctx.Err())
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// serviceConditionState reports a dependency's current state and whether its condition is met
+func serviceConditionState(ctx context.Context, project string, dep models.ComposeDependency) (string, bool, error) {
+	containers, err := podmanService.GetStackContainers(ctx, project)
+	if err != nil {
+		return "unknown", false, nil
+	}
+
+	for _, sc := range containers {
+		if sc.Service != dep.Service {
+			continue
+		}
+		inspect, err := podmanService.InspectContainer(ctx, sc.Name)
+		if err != nil {
+			return "unknown", false, nil
+		}
+
+		if dep.Condition == models.DependsOnCompleted {
+			if inspect.State.Running || inspect.State.Status == "created" {
+				return inspect.State.Status, false, nil
+			}
+			if inspect.State.ExitCode != 0 {
+				return "", false, fmt.Errorf("exited with code %d", inspect.State.ExitCode)
+			}
+			return "exited", true, nil
+		}
+
+		health := inspect.State.Health.Status
+		if health == "" {
+			return "", false, fmt.Errorf("no healthcheck is defined")
+		}
+		if health == "unhealthy" && !inspect.State.Running {
+			return "", false, fmt.Errorf("container stopped while unhealthy")
+		}
+		return health, health == "healthy", nil
+	}
+
+	return "not created", false, nil
+}
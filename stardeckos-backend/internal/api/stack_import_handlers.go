@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// composeFileNames are the file names recognised as compose projects, in compose's lookup order
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// maxStackScanDepth limits how deep below each import path the scanner looks
+const maxStackScanDepth = 3
+
+// invalidProjectChars matches characters compose strips from directory-derived project names
+var invalidProjectChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// stackImportPaths returns the directories scanned for existing compose files
+func stackImportPaths(override string) []string {
+	value := override
+	if value == "" {
+		value, _ = settingsRepo.Get(database.SettingStackImportPaths)
+	}
+
+	var paths []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" && filepath.IsAbs(p) {
+			paths = append(paths, filepath.Clean(p))
+		}
+	}
+	return paths
+}
+
+// isComposeFileName reports whether a file name is a recognised compose file
+func isComposeFileName(name string) bool {
+	for _, n := range composeFileNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// projectNameFromDir derives a compose project name from a directory, as compose does by default
+func projectNameFromDir(dir string) string {
+	return invalidProjectChars.ReplaceAllString(strings.ToLower(filepath.Base(dir)), "")
+}
+
+// findComposeFiles walks root up to maxStackScanDepth and returns one compose file per directory
+func findComposeFiles(root string) []string {
+	var found []string
+	rootDepth := strings.Count(root, string(os.PathSeparator))
+
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+		if strings.Count(path, string(os.PathSeparator))-rootDepth > maxStackScanDepth {
+			return filepath.SkipDir
+		}
+
+		for _, name := range composeFileNames {
+			candidate := filepath.Join(path, name)
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+				found = append(found, candidate)
+				break
+			}
+		}
+		return nil
+	})
+
+	return found
+}
+
+// errComposeLinkConflict means a project directory has a docker-compose.yml other than its compose file
+var errComposeLinkConflict = errors.New("Directory already contains a different docker-compose.yml")
+
+// linkStandardComposeFile links docker-compose.yml, which Stardeck drives compose
+// through, to a project's own compose file
+func linkStandardComposeFile(composeFile string) error {
+	standardPath := filepath.Join(filepath.Dir(composeFile), "docker-compose.yml")
+	if composeFile == standardPath {
+		return nil
+	}
+	if _, err := os.Lstat(standardPath); !os.IsNotExist(err) {
+		return errComposeLinkConflict
+	}
+	return os.Symlink(filepath.Base(composeFile), standardPath)
+}
+
+// liveStackStatus derives a stack's status from any containers already running under its project name
+func liveStackStatus(ctx context.Context, name string) models.StackStatus {
+	containers, err := podmanService.GetStackContainers(ctx, name)
+	if err != nil || len(containers) == 0 {
+		return models.StackStatusStopped
+	}
+	running := 0
+	for _, sc := range containers {
+		if sc.Status == models.ContainerStatusRunning {
+			running++
+		}
+	}
+	switch {
+	case running == len(containers):
+		return models.StackStatusActive
+	case running > 0:
+		return models.StackStatusPartial
+	}
+	return models.StackStatusStopped
+}
+
+// discoverStacksHandler finds compose projects on disk and in Podman that are not yet managed
+func discoverStacksHandler(c echo.Context) error {
+	known, err := stackRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list stacks: " + err.Error(),
+		})
+	}
+
+	knownNames := make(map[string]bool)
+	knownDirs := make(map[string]bool)
+	for _, s := range known {
+		knownNames[s.Name] = true
+		if full, err := stackRepo.GetByID(s.ID); err == nil && full.Path != "" {
+			knownDirs[filepath.Clean(full.Path)] = true
+		}
+	}
+
+	discovered := make(map[string]*models.DiscoveredStack) // keyed by compose file path
+	var order []string
+
+	for _, root := range stackImportPaths(c.QueryParam("path")) {
+		for _, file := range findComposeFiles(root) {
+			dir := filepath.Dir(file)
+			if knownDirs[dir] || strings.HasPrefix(dir, stacksBaseDir+string(os.PathSeparator)) {
+				continue
+			}
+			discovered[file] = &models.DiscoveredStack{
+				Name:        projectNameFromDir(dir),
+				Path:        dir,
+				ComposeFile: file,
+				Source:      "filesystem",
+			}
+			order = append(order, file)
+		}
+	}
+
+	// Running compose projects are matched to their files via compose labels
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 15*time.Second)
+	defer cancel()
+
+	projects, _ := podmanService.ListComposeProjects(ctx)
+	for _, p := range projects {
+		if knownNames[p.Name] {
+			continue
+		}
+
+		file := p.ConfigFile
+		if file != "" && !filepath.IsAbs(file) && p.WorkingDir != "" {
+			file = filepath.Join(p.WorkingDir, file)
+		}
+		if file != "" {
+			file = filepath.Clean(file)
+			if knownDirs[filepath.Dir(file)] {
+				continue
+			}
+		}
+
+		if existing, ok := discovered[file]; ok && file != "" {
+			existing.Name = p.Name
+			existing.Source = "running"
+			existing.ContainerCount = p.ContainerCount
+			existing.RunningCount = p.RunningCount
+			continue
+		}
+
+		key := file
+		if key == "" {
+			key = "project:" + p.Name
+		}
+		discovered[key] = &models.DiscoveredStack{
+			Name:           p.Name,
+			Path:           p.WorkingDir,
+			ComposeFile:    file,
+			Source:         "running",
+			ContainerCount: p.ContainerCount,
+			RunningCount:   p.RunningCount,
+		}
+		order = append(order, key)
+	}
+
+	result := make([]models.DiscoveredStack, 0, len(order))
+	for _, key := range order {
+		result = append(result, *discovered[key])
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// importStackHandler adopts an existing compose project as a Stardeck stack, leaving its files in place
+func importStackHandler(c echo.Context) error {
+	var req models.ImportStackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.ComposeFile == "" || !filepath.IsAbs(req.ComposeFile) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "compose_file must be an absolute path",
+		})
+	}
+	composeFile := filepath.Clean(req.ComposeFile)
+	if !isComposeFileName(filepath.Base(composeFile)) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "compose_file must be named compose.yaml, compose.yml, docker-compose.yaml, or docker-compose.yml",
+		})
+	}
+
+	content, err := os.ReadFile(composeFile)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Failed to read compose file: " + err.Error(),
+		})
+	}
+
+	dir := filepath.Dir(composeFile)
+	name := req.Name
+	if name == "" {
+		name = projectNameFromDir(dir)
+	}
+	if name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not derive a stack name, please provide one",
+		})
+	}
+
+	if existing, _ := stackRepo.GetByName(name); existing != nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Stack with this name already exists",
+			"id":    existing.ID,
+		})
+	}
+
+	if err := linkStandardComposeFile(composeFile); err == errComposeLinkConflict {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to link compose file: " + err.Error(),
+		})
+	}
+
+	envContent := ""
+	if data, err := os.ReadFile(filepath.Join(dir, ".env")); err == nil {
+		envContent = string(data)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 15*time.Second)
+	defer cancel()
+	status := liveStackStatus(ctx, name)
+
+	user := c.Get("user").(*models.User)
+	stack := &models.Stack{
+		Name:           name,
+		Description:    req.Description,
+		ComposeContent: string(content),
+		EnvContent:     envContent,
+		Status:         status,
+		Path:           dir,
+		CreatedBy:      &user.ID,
+	}
+
+	if err := stackRepo.Create(stack); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to import stack: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionStackImport, stack.Name, map[string]interface{}{
+		"compose_file": composeFile,
+		"status":       status,
+	})
+
+	return c.JSON(http.StatusCreated, stack)
+}
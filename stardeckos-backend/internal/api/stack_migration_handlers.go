@@ -0,0 +1,408 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// defaultDockgeStacksDir is where Dockge keeps its stacks unless told otherwise
+const defaultDockgeStacksDir = "/opt/stacks"
+
+// migrationSourceNames are shown in stack descriptions
+var migrationSourceNames = map[string]string{
+	models.MigrationSourcePortainer: "Portainer",
+	models.MigrationSourceDockge:    "Dockge",
+	models.MigrationSourceYacht:     "Yacht",
+}
+
+// migrationStack is a stack read from another manager, with the files to import
+type migrationStack struct {
+	models.MigrationCandidate
+	compose     string
+	env         string
+	composeFile string // Directory sources are imported in place; others are written to the stacks directory
+	images      []string
+}
+
+// loadMigrationStacks reads every stack from a source and checks it against Podman and existing stacks
+func loadMigrationStacks(ctx context.Context, src models.MigrationSource) ([]migrationStack, error) {
+	var stacks []migrationStack
+	var err error
+	switch src.Source {
+	case models.MigrationSourcePortainer:
+		stacks, err = loadPortainerStacks(ctx, src)
+	case models.MigrationSourceDockge, models.MigrationSourceYacht:
+		stacks, err = loadDirectoryStacks(src)
+	default:
+		return nil, errors.New("source must be portainer, dockge or yacht")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stacks {
+		s := &stacks[i]
+		s.Services = []string{}
+		for _, svc := range system.ParseComposeServices(s.compose) {
+			s.Services = append(s.Services, svc.Name)
+			if svc.Image != "" {
+				s.images = append(s.images, svc.Image)
+			}
+		}
+		for _, line := range strings.Split(s.env, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				s.EnvVars++
+			}
+		}
+
+		switch {
+		case s.Error != "":
+		case s.Name == "":
+			s.Error = "Could not derive a stack name"
+		case strings.TrimSpace(s.compose) == "":
+			s.Error = "No compose file"
+		case len(s.Services) == 0:
+			s.Error = "Compose file defines no services"
+		}
+		if existing, _ := stackRepo.GetByName(s.Name); existing != nil {
+			s.ExistingStack = existing.ID
+		}
+
+		if s.Name != "" {
+			if containers, err := podmanService.GetStackContainers(ctx, s.Name); err == nil {
+				s.ContainerCount = len(containers)
+				for _, sc := range containers {
+					if sc.Status == models.ContainerStatusRunning {
+						s.RunningCount++
+					}
+				}
+			}
+		}
+	}
+	return stacks, nil
+}
+
+// loadPortainerStacks reads the compose stacks and their environment from the Portainer API
+func loadPortainerStacks(ctx context.Context, src models.MigrationSource) ([]migrationStack, error) {
+	client, err := system.NewPortainerClient(src.URL, src.APIKey, src.InsecureTLS)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.ListStacks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make([]migrationStack, 0, len(list))
+	for _, ps := range list {
+		s := migrationStack{env: system.PortainerEnvFile(ps.Env)}
+		// Portainer names the compose project after the stack
+		s.Name = projectNameFromDir(ps.Name)
+		s.SourceID = strconv.Itoa(ps.ID)
+		if s.compose, err = client.StackFile(ctx, ps.ID); err != nil {
+			s.Error = err.Error()
+		}
+		stacks = append(stacks, s)
+	}
+	return stacks, nil
+}
+
+// loadDirectoryStacks reads a Dockge or Yacht stacks directory, where each
+// subdirectory holds one compose project
+func loadDirectoryStacks(src models.MigrationSource) ([]migrationStack, error) {
+	root := src.Path
+	if root == "" && src.Source == models.MigrationSourceDockge {
+		root = defaultDockgeStacksDir
+	}
+	if root == "" || !filepath.IsAbs(root) {
+		return nil, errors.New("path must be the absolute path of the stacks directory")
+	}
+	root = filepath.Clean(root)
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, errors.New("Failed to read stacks directory: " + err.Error())
+	}
+
+	var stacks []migrationStack
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		for _, name := range composeFileNames {
+			file := filepath.Join(dir, name)
+			content, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			s := migrationStack{compose: string(content), composeFile: file}
+			s.Name = projectNameFromDir(dir)
+			s.SourceID = entry.Name()
+			s.Path = dir
+			if data, err := os.ReadFile(filepath.Join(dir, ".env")); err == nil {
+				s.env = string(data)
+			}
+			stacks = append(stacks, s)
+			break
+		}
+	}
+	return stacks, nil
+}
+
+// imageIconSlug guesses an icon library slug from an image reference, e.g.
+// lscr.io/linuxserver/sonarr:latest becomes sonarr
+func imageIconSlug(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.ToLower(strings.TrimPrefix(name, "docker-"))
+	if !iconSlugPattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// libraryIconForImage returns the URL of a library icon matching an image,
+// fetching and caching it on first use. It returns "" when there is none.
+func libraryIconForImage(ctx context.Context, image string, user *models.User) string {
+	slug := imageIconSlug(image)
+	if slug == "" {
+		return ""
+	}
+	if existing, err := iconRepo.GetBySlug(slug, "image/png"); err == nil {
+		return iconURL(existing.ID)
+	}
+	data, _, err := downloadLibraryIcon(ctx, slug, "png")
+	if err != nil {
+		return ""
+	}
+	icon := &models.Icon{
+		Name:        slug,
+		Slug:        slug,
+		Source:      models.IconSourceLibrary,
+		ContentType: "image/png",
+		CreatedBy:   &user.ID,
+	}
+	if err := storeIcon(icon, data); err != nil {
+		return ""
+	}
+	return icon.URL
+}
+
+// adoptStackContainers adds a compose project's existing containers to Stardeck,
+// using the icons found for their images, keyed by icon slug
+func adoptStackContainers(ctx context.Context, project string, icons map[string]string, user *models.User) (int, error) {
+	containers, err := podmanService.GetStackContainers(ctx, project)
+	if err != nil {
+		return 0, err
+	}
+
+	adopted := 0
+	for _, sc := range containers {
+		_, created, err := adoptStackContainer(ctx, sc, icons, user)
+		if err != nil {
+			return adopted, err
+		}
+		if created {
+			adopted++
+		}
+	}
+	return adopted, nil
+}
+
+// adoptStackContainer adds one of a compose project's containers to Stardeck
+// unless it's there already, returning its record and whether it is new
+func adoptStackContainer(ctx context.Context, sc models.StackContainer, icons map[string]string, user *models.User) (*models.Container, bool, error) {
+	info, err := podmanService.InspectContainer(ctx, sc.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing, _ := containerRepo.GetByContainerID(info.ID); existing != nil {
+		return existing, false, nil
+	}
+
+	dbContainer := &models.Container{
+		ContainerID: info.ID,
+		Name:        info.Name,
+		Image:       info.Config.Image,
+		Status:      sc.Status,
+		WebUIPath:   "/",
+		Icon:        icons[imageIconSlug(sc.Image)],
+		CreatedBy:   &user.ID,
+	}
+	// The first published TCP port is the likeliest web UI
+	for _, p := range sc.Ports {
+		if p.HostPort > 0 && (p.Protocol == "" || p.Protocol == "tcp") {
+			dbContainer.HasWebUI = true
+			dbContainer.WebUIPort = p.HostPort
+			break
+		}
+	}
+
+	if err := containerRepo.Create(dbContainer); err != nil {
+		return nil, false, err
+	}
+	recordContainerConfig(ctx, dbContainer, models.ConfigSnapshotAdopt, &user.ID)
+	return dbContainer, true, nil
+}
+
+// importMigrationStack creates a Stardeck stack from another manager's stack
+func importMigrationStack(ctx context.Context, s migrationStack, req models.MigrateStacksRequest, user *models.User) models.MigrationResult {
+	result := models.MigrationResult{Name: s.Name}
+	switch {
+	case s.Error != "":
+		result.Error = s.Error
+		return result
+	case s.ExistingStack != "":
+		result.Error = "Stack with this name already exists"
+		result.StackID = s.ExistingStack
+		return result
+	}
+
+	icons := make(map[string]string)
+	if req.FetchIcons {
+		// Keyed by slug, since Podman reports images fully qualified
+		for _, image := range s.images {
+			slug := imageIconSlug(image)
+			if _, ok := icons[slug]; !ok {
+				icons[slug] = libraryIconForImage(ctx, image, user)
+			}
+			if result.Icon == "" {
+				result.Icon = icons[slug]
+			}
+		}
+	}
+
+	stack := &models.Stack{
+		Name:           s.Name,
+		Description:    "Imported from " + migrationSourceNames[req.Source],
+		ComposeContent: s.compose,
+		EnvContent:     s.env,
+		Icon:           result.Icon,
+		Status:         liveStackStatus(ctx, s.Name),
+		CreatedBy:      &user.ID,
+	}
+	if s.composeFile != "" {
+		if err := linkStandardComposeFile(s.composeFile); err != nil {
+			result.Error = "Failed to link compose file: " + err.Error()
+			return result
+		}
+		stack.Path = s.Path
+	} else {
+		dir, err := ensureStackDir(s.Name)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if err := writeComposeFiles(dir, s.compose, s.env); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		stack.Path = dir
+	}
+
+	if err := stackRepo.Create(stack); err != nil {
+		result.Error = "Failed to import stack: " + err.Error()
+		return result
+	}
+	result.StackID = stack.ID
+	result.Imported = true
+
+	if req.AdoptContainers {
+		adopted, err := adoptStackContainers(ctx, s.Name, icons, user)
+		result.Adopted = adopted
+		if err != nil {
+			result.Error = "Stack imported but adopting its containers failed: " + err.Error()
+		}
+	}
+
+	logAudit(user, models.ActionStackMigrate, stack.Name, map[string]interface{}{
+		"source":    req.Source,
+		"source_id": s.SourceID,
+		"adopted":   result.Adopted,
+	})
+	return result
+}
+
+// scanMigrationSourceHandler lists the stacks another manager holds and whether each can be imported
+func scanMigrationSourceHandler(c echo.Context) error {
+	var src models.MigrationSource
+	if err := c.Bind(&src); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), time.Minute)
+	defer cancel()
+
+	stacks, err := loadMigrationStacks(ctx, src)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	candidates := make([]models.MigrationCandidate, 0, len(stacks))
+	for _, s := range stacks {
+		candidates = append(candidates, s.MigrationCandidate)
+	}
+	return c.JSON(http.StatusOK, candidates)
+}
+
+// migrateStacksHandler imports stacks from Portainer, Dockge or Yacht, optionally
+// adopting their running containers
+func migrateStacksHandler(c echo.Context) error {
+	var req models.MigrateStacksRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	stacks, err := loadMigrationStacks(ctx, req.MigrationSource)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range req.Stacks {
+		selected[name] = true
+	}
+
+	user := c.Get("user").(*models.User)
+	results := []models.MigrationResult{}
+	for _, s := range stacks {
+		if len(selected) > 0 && !selected[s.Name] {
+			continue
+		}
+		delete(selected, s.Name)
+		results = append(results, importMigrationStack(ctx, s, req, user))
+	}
+	for name := range selected {
+		results = append(results, models.MigrationResult{Name: name, Error: "Stack not found in source"})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,398 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	// Free space below these fails or warns about a deploy
+	preflightDiskError   = 1 << 30 // 1 GiB
+	preflightDiskWarning = 5 << 30 // 5 GiB
+)
+
+// stackPreflight checks what a stack needs before podman-compose runs:
+// variables, images, host ports, bind mount paths and disk space. It runs
+// the same checks validateContainerHandler does for single containers.
+func stackPreflight(ctx context.Context, stack *models.Stack) []ValidationResult {
+	results := []ValidationResult{}
+
+	// 1. Variables the compose file needs
+	render := renderStack(ctx, stack, true)
+	for _, msg := range render.Errors {
+		results = append(results, ValidationResult{
+			Check:   "environment",
+			Status:  "error",
+			Message: "Compose variables are not satisfied",
+			Details: msg,
+		})
+	}
+	if len(render.Unresolved) > 0 {
+		results = append(results, ValidationResult{
+			Check:   "environment",
+			Status:  "warning",
+			Message: "Variables are not set and will be empty",
+			Details: strings.Join(render.Unresolved, ", "),
+		})
+	}
+	if len(render.Errors) == 0 && len(render.Unresolved) == 0 {
+		results = append(results, ValidationResult{
+			Check:   "environment",
+			Status:  "ok",
+			Message: fmt.Sprintf("%d variable(s) resolved", len(render.Variables)),
+		})
+	}
+
+	// The rest reads the file as it will be deployed
+	services := activeComposeServices(&models.Stack{ComposeContent: render.ComposeContent, Profiles: stack.Profiles})
+
+	results = append(results, preflightImages(ctx, services)...)
+	results = append(results, preflightPorts(ctx, stack, services)...)
+	results = append(results, preflightMounts(stack, services)...)
+	results = append(results, preflightDisk(ctx, stack)...)
+	return results
+}
+
+// preflightImages checks that every image is present locally or in its registry
+func preflightImages(ctx context.Context, services []models.ComposeService) []ValidationResult {
+	var results []ValidationResult
+
+	images := make(map[string]bool)
+	for _, svc := range services {
+		switch {
+		case svc.Image == "" && !svc.Build:
+			results = append(results, ValidationResult{
+				Check:   "images",
+				Status:  "error",
+				Message: "Service has no image",
+				Details: fmt.Sprintf("Service '%s' needs an image or a build section", svc.Name),
+			})
+		case svc.Build:
+			// Built during the deploy when missing
+		default:
+			images[svc.Image] = true
+		}
+	}
+
+	type resolved struct {
+		image, method string
+		err           error
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var found []resolved
+	for image := range images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			method, err := podmanService.ResolveImage(ctx, image)
+			mu.Lock()
+			found = append(found, resolved{image, method, err})
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(found, func(i, j int) bool { return found[i].image < found[j].image })
+
+	var pulls []string
+	failed := false
+	for _, r := range found {
+		if r.err != nil {
+			failed = true
+			results = append(results, ValidationResult{
+				Check:   "images",
+				Status:  "error",
+				Message: "Image cannot be resolved",
+				Details: fmt.Sprintf("%s: %v", r.image, r.err),
+			})
+		} else if r.method != "local" {
+			pulls = append(pulls, r.image)
+		}
+	}
+	if !failed && len(results) == 0 {
+		result := ValidationResult{
+			Check:   "images",
+			Status:  "ok",
+			Message: fmt.Sprintf("%d image(s) available", len(images)),
+		}
+		if len(pulls) > 0 {
+			result.Details = "Will be pulled: " + strings.Join(pulls, ", ")
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// preflightPorts checks that the host ports the stack publishes are free.
+// Ports held by the stack's own containers are fine, as a redeploy replaces
+// them.
+func preflightPorts(ctx context.Context, stack *models.Stack, services []models.ComposeService) []ValidationResult {
+	var results []ValidationResult
+
+	type holder struct {
+		ip, name string
+		own      bool
+	}
+	held := make(map[string][]holder) // "port/protocol" to whatever is bound on it
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		results = append(results, ValidationResult{
+			Check:   "ports",
+			Status:  "warning",
+			Message: "Could not list containers",
+			Details: err.Error(),
+		})
+	}
+	for _, c := range containers {
+		for _, p := range c.Ports {
+			if p.HostPort > 0 {
+				key := strconv.Itoa(p.HostPort) + "/" + p.Protocol
+				held[key] = append(held[key], holder{ip: p.HostIP, name: "container " + c.Name, own: c.Stack == stack.Name})
+			}
+		}
+	}
+
+	// Podman holds host sockets for published container ports, which the
+	// container entries already cover
+	containerKeys := make(map[string]bool, len(held))
+	for key := range held {
+		containerKeys[key] = true
+	}
+	if conns, err := system.GetConnections("", ""); err != nil {
+		results = append(results, ValidationResult{
+			Check:   "ports",
+			Status:  "warning",
+			Message: "Could not list host sockets",
+			Details: err.Error(),
+		})
+	} else {
+		for _, conn := range conns {
+			if (conn.State != "LISTEN" && conn.State != "UNCONN") || conn.LocalPort == 0 {
+				continue
+			}
+			key := strconv.Itoa(conn.LocalPort) + "/" + strings.TrimSuffix(conn.Protocol, "6")
+			if containerKeys[key] {
+				continue
+			}
+			process := conn.Process
+			if process == "" {
+				process = "unknown"
+			}
+			held[key] = append(held[key], holder{ip: conn.LocalAddr, name: "process " + process})
+		}
+	}
+
+	published := make(map[string]string) // Ports claimed by earlier services of the stack
+	conflicts := 0
+	count := 0
+	for _, svc := range services {
+		for _, p := range svc.Ports {
+			count++
+			key := strconv.Itoa(p.Host) + "/" + p.Protocol
+			if other, ok := published[key]; ok && other != svc.Name {
+				conflicts++
+				results = append(results, ValidationResult{
+					Check:   "ports",
+					Status:  "error",
+					Message: "Port published twice in the stack",
+					Details: fmt.Sprintf("Services '%s' and '%s' both publish %s", other, svc.Name, key),
+				})
+				continue
+			}
+			published[key] = svc.Name
+
+			for _, h := range held[key] {
+				if h.own || !hostAddrsOverlap(p.HostIP, h.ip) {
+					continue
+				}
+				conflicts++
+				results = append(results, ValidationResult{
+					Check:   "ports",
+					Status:  "error",
+					Message: "Port already in use",
+					Details: fmt.Sprintf("Service '%s' publishes %s, which %s holds", svc.Name, key, h.name),
+				})
+				break
+			}
+		}
+	}
+	if conflicts == 0 && count > 0 {
+		results = append(results, ValidationResult{
+			Check:   "ports",
+			Status:  "ok",
+			Message: fmt.Sprintf("%d published port(s) are free", count),
+		})
+	}
+	return results
+}
+
+// hostAddrsOverlap reports whether two bind addresses can clash, which they
+// do when they are equal or either is a wildcard
+func hostAddrsOverlap(a, b string) bool {
+	normalize := func(addr string) string {
+		addr, _, _ = strings.Cut(strings.Trim(addr, "[]"), "%")
+		switch addr {
+		case "", "*", "0.0.0.0", "::":
+			return ""
+		}
+		return addr
+	}
+	a, b = normalize(a), normalize(b)
+	return a == "" || b == "" || a == b
+}
+
+// preflightMounts checks the host paths of bind mounts. podman-compose
+// creates missing ones, so those only warn when their parent is writable.
+func preflightMounts(stack *models.Stack, services []models.ComposeService) []ValidationResult {
+	var results []ValidationResult
+
+	binds := 0
+	var missing []string
+	for _, svc := range services {
+		for _, m := range svc.Mounts {
+			if m.Type != "bind" || m.Source == "" {
+				continue
+			}
+			binds++
+			path := m.Source
+			if rest, ok := strings.CutPrefix(path, "~"); ok {
+				home, _ := os.UserHomeDir()
+				path = home + rest
+			} else if !filepath.IsAbs(path) {
+				path = filepath.Join(stack.Path, path)
+			}
+
+			if _, err := os.Stat(path); err == nil {
+				continue
+			} else if !os.IsNotExist(err) {
+				results = append(results, ValidationResult{
+					Check:   "volumes",
+					Status:  "error",
+					Message: "Bind mount path is not accessible",
+					Details: fmt.Sprintf("Service '%s': %v", svc.Name, err),
+				})
+				continue
+			}
+
+			parent := filepath.Dir(path)
+			for {
+				if _, err := os.Stat(parent); err == nil || filepath.Dir(parent) == parent {
+					break
+				}
+				parent = filepath.Dir(parent)
+			}
+			if err := syscall.Access(parent, 2); err != nil { // W_OK
+				results = append(results, ValidationResult{
+					Check:   "volumes",
+					Status:  "error",
+					Message: "Bind mount path cannot be created",
+					Details: fmt.Sprintf("Service '%s': %s does not exist and %s is not writable", svc.Name, path, parent),
+				})
+				continue
+			}
+			missing = append(missing, path)
+		}
+	}
+
+	if len(missing) > 0 {
+		results = append(results, ValidationResult{
+			Check:   "volumes",
+			Status:  "warning",
+			Message: "Bind mount paths do not exist (will be created)",
+			Details: strings.Join(missing, ", "),
+		})
+	}
+	if len(results) == 0 && binds > 0 {
+		results = append(results, ValidationResult{
+			Check:   "volumes",
+			Status:  "ok",
+			Message: fmt.Sprintf("%d bind mount(s) found", binds),
+		})
+	}
+	return results
+}
+
+// preflightDisk checks the free space under Podman's storage, where images
+// and volumes land, and under the stack's directory
+func preflightDisk(ctx context.Context, stack *models.Stack) []ValidationResult {
+	var results []ValidationResult
+
+	paths := map[string]string{"stack directory": stack.Path}
+	if storage, err := podmanService.GetStorageConfig(ctx); err == nil && storage.GraphRoot != "" {
+		paths["container storage"] = storage.GraphRoot
+	}
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if paths[name] == "" {
+			continue
+		}
+		free, err := system.FreeSpace(paths[name])
+		result := ValidationResult{Check: "disk", Details: paths[name]}
+		switch {
+		case err != nil:
+			result.Status, result.Message, result.Details = "warning", "Could not check free space in "+name, err.Error()
+		case free < preflightDiskError:
+			result.Status, result.Message = "error", fmt.Sprintf("Only %s free in %s", humanBytes(int64(free)), name)
+		case free < preflightDiskWarning:
+			result.Status, result.Message = "warning", fmt.Sprintf("Only %s free in %s", humanBytes(int64(free)), name)
+		default:
+			result.Status, result.Message = "ok", fmt.Sprintf("%s free in %s", humanBytes(int64(free)), name)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// preflightPassed reports whether no check failed
+func preflightPassed(results []ValidationResult) bool {
+	for _, r := range results {
+		if r.Status == "error" {
+			return false
+		}
+	}
+	return true
+}
+
+// stackPreflightHandler handles GET /api/stacks/:id/preflight
+func stackPreflightHandler(c echo.Context) error {
+	stack, err := stackRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 60*time.Second)
+	defer cancel()
+
+	results := stackPreflight(ctx, stack)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"valid":   preflightPassed(results),
+		"results": results,
+	})
+}
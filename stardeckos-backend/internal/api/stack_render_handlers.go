@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// renderStack interpolates a stack's compose file from the same sources
+// podman-compose uses at deploy time: secrets passed in its environment,
+// Stardeck's own environment, then the stack's .env content. Secret values
+// are never shown, and host values only to admins.
+func renderStack(ctx context.Context, stack *models.Stack, showHost bool) models.StackRender {
+	render := models.StackRender{
+		Variables:  []models.StackVariable{},
+		Unresolved: []string{},
+	}
+
+	existing := make(map[string]bool)
+	secretsListed := true
+	if secrets, err := podmanService.ListSecrets(ctx); err == nil {
+		for _, s := range secrets {
+			existing[s.Name] = true
+		}
+	} else {
+		secretsListed = false
+		render.Errors = append(render.Errors, "Failed to list secrets: "+err.Error())
+	}
+
+	secretVars := make(map[string]string)
+	for _, name := range stackSecretNames(stack.ComposeContent) {
+		secretVars[secretEnvVar(name)] = name
+		if secretsListed && !existing[name] {
+			render.Errors = append(render.Errors, fmt.Sprintf("secret %q does not exist in the Podman secret store", name))
+		}
+	}
+
+	hostLookup := os.LookupEnv
+	if !showHost {
+		hostLookup = nil
+	}
+	envFile := system.ParseEnvFile(stack.EnvContent, hostLookup)
+
+	sources := make(map[string]models.StackVariable)
+	lookup := func(name string) (string, bool) {
+		if secret, ok := secretVars[name]; ok {
+			sources[name] = models.StackVariable{Name: name, Source: models.StackVariableSecret, Secret: secret, Masked: true}
+			return database.AuditRedacted, existing[secret] || !secretsListed
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			sources[name] = models.StackVariable{Name: name, Source: models.StackVariableHost, Masked: !showHost}
+			if !showHost {
+				return database.AuditRedacted, true
+			}
+			return value, true
+		}
+		if value, ok := envFile[name]; ok {
+			sources[name] = models.StackVariable{Name: name, Source: models.StackVariableEnvFile}
+			return value, true
+		}
+		return "", false
+	}
+
+	content, refs := system.InterpolateCompose(rewriteSecretRefs(stack.ComposeContent), lookup)
+	render.ComposeContent = content
+
+	seen := make(map[string]bool)
+	unresolved := make(map[string]bool)
+	for _, ref := range refs {
+		if ref.Error != "" {
+			render.Errors = append(render.Errors, ref.Error)
+		}
+		if ref.Unresolved() && !unresolved[ref.Name] {
+			unresolved[ref.Name] = true
+			render.Unresolved = append(render.Unresolved, ref.Name)
+		}
+		if ref.Name == "" || seen[ref.Name] {
+			continue
+		}
+		seen[ref.Name] = true
+
+		v, ok := sources[ref.Name]
+		if !ok {
+			v = models.StackVariable{Name: ref.Name}
+			if ref.Defaulted {
+				v.Source = models.StackVariableDefault
+			}
+		}
+		render.Variables = append(render.Variables, v)
+	}
+	return render
+}
+
+// renderStackHandler handles GET /api/stacks/:id/render
+func renderStackHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	stack, err := stackRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	return c.JSON(http.StatusOK, renderStack(ctx, stack, user.IsAdmin()))
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+)
+
+// stackSecretPattern matches ${secret:name} references in compose content
+var stackSecretPattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9][A-Za-z0-9_.-]*)\}`)
+
+// secretEnvVar returns the interpolation variable a secret reference is rewritten to
+func secretEnvVar(name string) string {
+	var b strings.Builder
+	b.WriteString("STARDECK_SECRET_")
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// stackSecretNames returns the distinct secrets referenced by compose content
+func stackSecretNames(composeContent string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, m := range stackSecretPattern.FindAllStringSubmatch(composeContent, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// rewriteSecretRefs replaces secret references with plain variables so the compose file
+// written to disk only names secrets; values are supplied at deploy time
+func rewriteSecretRefs(composeContent string) string {
+	return stackSecretPattern.ReplaceAllStringFunc(composeContent, func(ref string) string {
+		name := stackSecretPattern.FindStringSubmatch(ref)[1]
+		return "${" + secretEnvVar(name) + "}"
+	})
+}
+
+// resolveStackSecrets reads every referenced secret from the Podman secret store and
+// returns them as environment entries for podman-compose
+func resolveStackSecrets(ctx context.Context, composeContent string) ([]string, error) {
+	var env []string
+	for _, name := range stackSecretNames(composeContent) {
+		value, err := podmanService.SecretValue(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q could not be read from the Podman secret store: %w", name, err)
+		}
+		env = append(env, secretEnvVar(name)+"="+value)
+	}
+	return env, nil
+}
+
+// listPodmanSecretsHandler returns the secrets stacks can reference, without their values
+func listPodmanSecretsHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	secrets, err := podmanService.ListSecrets(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list secrets: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, secrets)
+}
+
+// getStackSecretsHandler returns the secrets a stack references and whether each exists
+func getStackSecretsHandler(c echo.Context) error {
+	stack, err := stackRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.stack_not_found"),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.get_stack", "error", err.Error()),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+	defer cancel()
+
+	existing := make(map[string]bool)
+	if secrets, err := podmanService.ListSecrets(ctx); err == nil {
+		for _, s := range secrets {
+			existing[s.Name] = true
+		}
+	}
+
+	refs := []models.StackSecretRef{}
+	for _, name := range stackSecretNames(stack.ComposeContent) {
+		refs = append(refs, models.StackSecretRef{
+			Name:   name,
+			EnvVar: secretEnvVar(name),
+			Exists: existing[name],
+		})
+	}
+
+	return c.JSON(http.StatusOK, refs)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 
+	"stardeckos-backend/internal/models"
 	"stardeckos-backend/internal/system"
 )
 
@@ -18,7 +19,10 @@ func getSystemInfoHandler(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, info)
+	return c.JSON(http.StatusOK, struct {
+		*system.SystemInfo
+		Profile models.HostProfile `json:"profile"`
+	}{info, loadHostProfile()})
 }
 
 // getResourcesHandler handles GET /api/system/resources
@@ -62,7 +66,7 @@ func addRepositoryHandler(c echo.Context) error {
 	var repo system.Repository
 	if err := c.Bind(&repo); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -85,7 +89,7 @@ func updateRepositoryHandler(c echo.Context) error {
 	var repo system.Repository
 	if err := c.Bind(&repo); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -165,7 +169,7 @@ func installPackagesHandler(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -194,7 +198,7 @@ func removePackagesHandler(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -256,7 +260,7 @@ func createPartitionHandler(c echo.Context) error {
 	var req system.PartitionRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -286,7 +290,7 @@ func deletePartitionHandler(c echo.Context) error {
 	var req system.DeletePartitionRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -322,7 +326,7 @@ func formatPartitionHandler(c echo.Context) error {
 	var req system.FormatRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -358,7 +362,7 @@ func mountHandler(c echo.Context) error {
 	var req system.MountRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -396,7 +400,7 @@ func unmountHandler(c echo.Context) error {
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -2,16 +2,24 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/creack/pty"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
 )
 
 // TerminalMessage represents a message sent to/from the terminal
@@ -22,7 +30,7 @@ type TerminalMessage struct {
 	Cols uint16 `json:"cols,omitempty"`
 }
 
-// HandleTerminalWebSocket handles WebSocket connections for terminal sessions
+// HandleTerminalWebSocket handles WebSocket connections for host shell sessions (admin only)
 func HandleTerminalWebSocket(c echo.Context) error {
 	// Validate authentication from query parameter
 	token := c.QueryParam("token")
@@ -32,20 +40,26 @@ func HandleTerminalWebSocket(c echo.Context) error {
 	}
 
 	// Validate token
-	user, _, err := authService.ValidateToken(token)
+	user, authSession, err := authService.ValidateToken(token)
 	if err != nil {
 		log.Printf("Terminal WebSocket: Invalid token: %v", err)
 		return echo.NewHTTPError(401, "Invalid authentication token")
 	}
+	if refused, err := authService.RefuseReadOnly(c, user, authSession); refused {
+		return err
+	}
+
+	// A host shell is full system access, so only admins may open one
+	if !user.IsAdmin() {
+		log.Printf("Terminal WebSocket: User %s denied (not admin)", user.Username)
+		return echo.NewHTTPError(403, "Host terminal requires admin role")
+	}
 
 	log.Printf("Terminal WebSocket: User %s connecting...", user.Username)
 
 	// Upgrade to WebSocket
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			log.Printf("WebSocket CheckOrigin called from: %s", r.Header.Get("Origin"))
-			return true // Allow all origins in development
-		},
+		CheckOrigin: checkWebSocketOrigin,
 		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
 			log.Printf("WebSocket upgrade error: status=%d, reason=%v", status, reason)
 			http.Error(w, reason.Error(), status)
@@ -92,12 +106,81 @@ func HandleTerminalWebSocket(c echo.Context) error {
 		log.Printf("Failed to set PTY size: %v", err)
 	}
 
+	// Record the session and make it visible to other admins
+	session := &models.TerminalSession{
+		ID:        uuid.New().String(),
+		UserID:    user.ID,
+		Username:  user.Username,
+		IPAddress: c.RealIP(),
+		StartedAt: time.Now(),
+	}
+
+	var recorder *asciicastRecorder
+	if enabled, _ := settingsRepo.GetBool(database.SettingTerminalRecording); enabled {
+		path := filepath.Join(terminalRecordingDir, session.ID+".cast")
+		if recorder, err = newAsciicastRecorder(path, 80, 24); err != nil {
+			log.Printf("Warning: failed to start terminal recording: %v", err)
+			recorder = nil
+		} else {
+			session.RecordingPath = path
+		}
+	}
+	if err := terminalSessionRepo.Create(session); err != nil {
+		log.Printf("Warning: failed to save terminal session: %v", err)
+	}
+
+	live := &liveTerminal{session: session, owner: ws, watchers: make(map[*websocket.Conn]bool)}
+	liveTerminalsMu.Lock()
+	liveTerminals[session.ID] = live
+	liveTerminalsMu.Unlock()
+
+	Audit.Log(user.ID, user.Username, models.ActionTerminalSessionStart, session.ID, map[string]interface{}{
+		"shell":     shell,
+		"recording": recorder != nil,
+	}, c.RealIP())
+
+	// The first goroutine to stop decides why the session ended
+	var endOnce sync.Once
+	endReason := models.TerminalEndClosed
+	endSession := func(reason string) {
+		endOnce.Do(func() {
+			endReason = reason
+			ws.Close()
+			ptmx.Close()
+		})
+	}
+
+	// Idle timeout: close the session when no input arrives for the configured time
+	var lastInput atomic.Int64
+	lastInput.Store(time.Now().UnixNano())
+	idleDone := make(chan struct{})
+	if idleMinutes, _ := settingsRepo.GetInt(database.SettingTerminalIdleTimeout); idleMinutes > 0 {
+		idleTimeout := time.Duration(idleMinutes) * time.Minute
+		go func() {
+			ticker := time.NewTicker(30 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-idleDone:
+					return
+				case <-ticker.C:
+					if time.Since(time.Unix(0, lastInput.Load())) >= idleTimeout {
+						live.writeToOwner([]byte(fmt.Sprintf("\r\n[Session closed after %d minutes of inactivity]\r\n", idleMinutes)))
+						endSession(models.TerminalEndIdleTimeout)
+						return
+					}
+				}
+			}
+		}()
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(2)
 
 	// Read from PTY and send to WebSocket
 	go func() {
 		defer wg.Done()
+		defer endSession(models.TerminalEndExited)
 		buf := make([]byte, 8192)
 		for {
 			n, err := ptmx.Read(buf)
@@ -108,7 +191,11 @@ func HandleTerminalWebSocket(c echo.Context) error {
 				return
 			}
 			if n > 0 {
-				if err := ws.WriteMessage(websocket.TextMessage, buf[:n]); err != nil {
+				if recorder != nil {
+					recorder.output(buf[:n])
+				}
+				live.broadcast(buf[:n])
+				if err := live.writeToOwner(buf[:n]); err != nil {
 					log.Printf("WebSocket send error: %v", err)
 					return
 				}
@@ -119,6 +206,7 @@ func HandleTerminalWebSocket(c echo.Context) error {
 	// Read from WebSocket and write to PTY
 	go func() {
 		defer wg.Done()
+		defer endSession(models.TerminalEndClosed)
 		for {
 			_, msgBytes, err := ws.ReadMessage()
 			if err != nil {
@@ -127,6 +215,7 @@ func HandleTerminalWebSocket(c echo.Context) error {
 				}
 				return
 			}
+			lastInput.Store(time.Now().UnixNano())
 
 			// Try to parse as JSON for resize events
 			var msg TerminalMessage
@@ -140,6 +229,9 @@ func HandleTerminalWebSocket(c echo.Context) error {
 						}); err != nil {
 							log.Printf("Failed to resize PTY: %v", err)
 						}
+						if recorder != nil {
+							recorder.resize(msg.Cols, msg.Rows)
+						}
 					}
 				case "input":
 					if _, err := ptmx.Write([]byte(msg.Data)); err != nil {
@@ -158,6 +250,26 @@ func HandleTerminalWebSocket(c echo.Context) error {
 	}()
 
 	wg.Wait()
+	close(idleDone)
+
+	liveTerminalsMu.Lock()
+	delete(liveTerminals, session.ID)
+	liveTerminalsMu.Unlock()
+	live.closeWatchers()
+
+	var recordingSize int64
+	if recorder != nil {
+		recordingSize = recorder.close()
+	}
+	if err := terminalSessionRepo.Finish(session.ID, endReason, recordingSize); err != nil {
+		log.Printf("Warning: failed to finish terminal session: %v", err)
+	}
+
+	Audit.Log(user.ID, user.Username, models.ActionTerminalSessionEnd, session.ID, map[string]interface{}{
+		"reason":           endReason,
+		"duration_seconds": int(time.Since(session.StartedAt).Seconds()),
+	}, c.RealIP())
 
+	log.Printf("Terminal WebSocket: User %s session ended (%s)", user.Username, endReason)
 	return nil
 }
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// loginTestUser creates a local user with the role and signs it in, returning
+// the session token. Needs openTestDB.
+func loginTestUser(t *testing.T, username string, role models.Role, readOnly bool) string {
+	t.Helper()
+	const password = "correct horse battery"
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &models.User{Username: username, Role: role, AuthType: models.AuthTypeLocal, PasswordHash: hash}
+	if err := database.NewUserRepo().Create(user); err != nil {
+		t.Fatalf("create %s: %v", username, err)
+	}
+	if err := database.NewSettingsRepo().Set(database.SettingAuthLocalEnabled, "true"); err != nil {
+		t.Fatalf("enable local auth: %v", err)
+	}
+	authService = auth.NewService()
+	login, err := authService.Login(auth.LoginRequest{Username: username, Password: password, ReadOnly: readOnly}, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("log in %s: %v", username, err)
+	}
+	return login.Token
+}
+
+// serveWebSocketRoute requests a WebSocket route that authenticates with
+// ?token=, without upgrading
+func serveWebSocketRoute(route string, handler echo.HandlerFunc, token string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.GET(route, handler)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, route+"?token="+token, nil))
+	return rec
+}
+
+func TestTerminalRefusedInReadOnlyMode(t *testing.T) {
+	openTestDB(t)
+	token := loginTestUser(t, "admin", models.RoleAdmin, false)
+	if err := database.NewSettingsRepo().Set(database.SettingReadOnlyMode, "true"); err != nil {
+		t.Fatalf("set read-only mode: %v", err)
+	}
+
+	rec := serveWebSocketRoute("/api/terminal/ws", HandleTerminalWebSocket, token)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "read_only_mode") {
+		t.Errorf("host shell in read-only mode: got %d %s, want 403", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTerminalRefusedForReadOnlySession(t *testing.T) {
+	openTestDB(t)
+	token := loginTestUser(t, "admin", models.RoleAdmin, true)
+
+	if rec := serveWebSocketRoute("/api/terminal/ws", HandleTerminalWebSocket, token); rec.Code != http.StatusForbidden {
+		t.Errorf("host shell from a read-only session: got %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// terminalRecordingDir holds asciicast recordings of host shell sessions
+const terminalRecordingDir = "/var/lib/stardeck/terminal-sessions"
+
+var terminalSessionRepo *database.TerminalSessionRepo
+
+// liveTerminal is an attached host shell session that other admins can watch
+type liveTerminal struct {
+	session *models.TerminalSession
+	owner   *websocket.Conn
+
+	mu       sync.Mutex // Serialises writes to owner and watchers
+	watchers map[*websocket.Conn]bool
+}
+
+var (
+	liveTerminals   = make(map[string]*liveTerminal)
+	liveTerminalsMu sync.Mutex
+)
+
+// InitTerminalSessions initializes session storage and prunes old recordings daily
+func InitTerminalSessions() {
+	terminalSessionRepo = database.NewTerminalSessionRepo()
+	if err := terminalSessionRepo.MarkInterrupted(); err != nil {
+		log.Printf("Warning: failed to close out stale terminal sessions: %v", err)
+	}
+
+	go func() {
+		for {
+			pruneTerminalRecordings()
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
+
+// pruneTerminalRecordings deletes recordings and records past the retention period
+func pruneTerminalRecordings() {
+	days, err := settingsRepo.GetInt(database.SettingTerminalRetention)
+	if err != nil || days <= 0 {
+		return
+	}
+
+	sessions, err := terminalSessionRepo.ListStartedBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return
+	}
+	for _, s := range sessions {
+		if s.RecordingPath != "" {
+			os.Remove(s.RecordingPath)
+		}
+		terminalSessionRepo.Delete(s.ID)
+	}
+}
+
+// writeToOwner sends data to the session owner's WebSocket
+func (t *liveTerminal) writeToOwner(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.owner.WriteMessage(websocket.TextMessage, data)
+}
+
+// broadcast sends shell output to every watcher, dropping any that fail
+func (t *liveTerminal) broadcast(data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ws := range t.watchers {
+		if err := ws.WriteMessage(websocket.TextMessage, data); err != nil {
+			ws.Close()
+			delete(t.watchers, ws)
+		}
+	}
+}
+
+// closeWatchers disconnects every watcher when the session ends
+func (t *liveTerminal) closeWatchers() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ws := range t.watchers {
+		ws.WriteMessage(websocket.TextMessage, []byte("\r\n[Session ended]\r\n"))
+		ws.Close()
+	}
+	t.watchers = nil
+}
+
+func (t *liveTerminal) watcherCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.watchers)
+}
+
+// asciicastRecorder writes terminal output in asciicast v2 format
+type asciicastRecorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	start time.Time
+	size  int64
+}
+
+// newAsciicastRecorder creates a recording file and writes its header
+func newAsciicastRecorder(path string, cols, rows int) (*asciicastRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &asciicastRecorder{file: f, start: time.Now()}
+	header, _ := json.Marshal(map[string]interface{}{
+		"version":   2,
+		"width":     cols,
+		"height":    rows,
+		"timestamp": r.start.Unix(),
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+	r.writeLine(header)
+	return r, nil
+}
+
+func (r *asciicastRecorder) writeLine(line []byte) {
+	n, _ := r.file.Write(append(line, '\n'))
+	r.size += int64(n)
+}
+
+func (r *asciicastRecorder) event(kind, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	line, _ := json.Marshal([]interface{}{time.Since(r.start).Seconds(), kind, data})
+	r.writeLine(line)
+}
+
+// output records data written by the shell
+func (r *asciicastRecorder) output(data []byte) {
+	r.event("o", string(data))
+}
+
+// resize records a terminal size change
+func (r *asciicastRecorder) resize(cols, rows uint16) {
+	r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// close finishes the recording and returns its size in bytes
+func (r *asciicastRecorder) close() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.file.Close()
+	return r.size
+}
+
+// withLiveState fills in whether each session is attached and how many admins are watching
+func withLiveState(sessions []models.TerminalSession) []models.TerminalSession {
+	liveTerminalsMu.Lock()
+	defer liveTerminalsMu.Unlock()
+	for i := range sessions {
+		if t, ok := liveTerminals[sessions[i].ID]; ok {
+			sessions[i].Active = true
+			sessions[i].Watchers = t.watcherCount()
+		}
+	}
+	return sessions
+}
+
+// listTerminalSessionsHandler returns recent host shell sessions
+func listTerminalSessionsHandler(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	sessions, err := terminalSessionRepo.List(limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list terminal sessions: " + err.Error(),
+		})
+	}
+
+	if sessions == nil {
+		sessions = []models.TerminalSession{}
+	}
+
+	return c.JSON(http.StatusOK, withLiveState(sessions))
+}
+
+// getTerminalSessionHandler returns a single session
+func getTerminalSessionHandler(c echo.Context) error {
+	session, err := terminalSessionRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Terminal session not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get terminal session: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, withLiveState([]models.TerminalSession{*session})[0])
+}
+
+// getTerminalRecordingHandler serves a session's asciicast recording
+func getTerminalRecordingHandler(c echo.Context) error {
+	session, err := terminalSessionRepo.GetByID(c.Param("id"))
+	if err != nil || session.RecordingPath == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Recording not found",
+		})
+	}
+	if _, err := os.Stat(session.RecordingPath); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Recording file is missing",
+		})
+	}
+
+	c.Response().Header().Set("Content-Type", "application/x-asciicast")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", session.ID+".cast"))
+	return c.File(session.RecordingPath)
+}
+
+// deleteTerminalSessionHandler removes an ended session and its recording
+func deleteTerminalSessionHandler(c echo.Context) error {
+	session, err := terminalSessionRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Terminal session not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get terminal session: " + err.Error(),
+		})
+	}
+	if session.EndedAt == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Cannot delete an active session",
+		})
+	}
+
+	if session.RecordingPath != "" {
+		os.Remove(session.RecordingPath)
+	}
+	if err := terminalSessionRepo.Delete(session.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete terminal session: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
+
+// watchTerminalSessionHandler streams a live session's output read-only to another admin
+func watchTerminalSessionHandler(c echo.Context) error {
+	// Authentication handled here because browsers cannot set headers on WebSocket requests
+	user, _, err := authService.ValidateToken(c.QueryParam("token"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid authentication token")
+	}
+	if !user.IsAdmin() {
+		return echo.NewHTTPError(http.StatusForbidden, "Host terminal requires admin role")
+	}
+
+	liveTerminalsMu.Lock()
+	live, ok := liveTerminals[c.Param("id")]
+	liveTerminalsMu.Unlock()
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "Session is not active")
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: checkWebSocketOrigin,
+	}
+	ws, err := upgrader.Upgrade(c.Response().Writer, c.Request(), nil)
+	if err != nil {
+		return err
+	}
+
+	live.mu.Lock()
+	if live.watchers == nil {
+		live.mu.Unlock()
+		ws.Close()
+		return nil
+	}
+	live.watchers[ws] = true
+	live.mu.Unlock()
+
+	// Let the session owner know they are being watched
+	live.writeToOwner([]byte(fmt.Sprintf("\r\n[%s is now viewing this session]\r\n", user.Username)))
+
+	Audit.Log(user.ID, user.Username, models.ActionTerminalSessionWatch, live.session.ID, map[string]interface{}{
+		"session_owner": live.session.Username,
+	}, c.RealIP())
+
+	// Watchers are read-only; drain and discard input until they disconnect
+	for {
+		if _, _, err := ws.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	live.mu.Lock()
+	if live.watchers != nil {
+		delete(live.watchers, ws)
+	}
+	live.mu.Unlock()
+	ws.Close()
+	return nil
+}
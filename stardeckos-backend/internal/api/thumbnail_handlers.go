@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const thumbnailBaseDir = "/var/lib/stardeck/thumbnails"
+
+// thumbnailMu serializes captures so only one headless browser runs at a time
+var thumbnailMu sync.Mutex
+
+// InitThumbnailService starts the background web UI screenshot loop
+func InitThumbnailService() {
+	go runThumbnailCapturer()
+}
+
+// thumbnailInterval returns how often thumbnails are refreshed
+func thumbnailInterval() time.Duration {
+	minutes, err := settingsRepo.GetInt(database.SettingThumbnailInterval)
+	if err != nil || minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// runThumbnailCapturer periodically screenshots every running container with a web UI
+func runThumbnailCapturer() {
+	// Give containers a chance to come up after boot
+	time.Sleep(time.Minute)
+
+	for {
+		enabled, err := settingsRepo.GetBool(database.SettingThumbnailsEnabled)
+		if err == nil && enabled && !maintenanceModeActive() {
+			if _, err := system.FindHeadlessBrowser(); err == nil {
+				captureAllThumbnails()
+			}
+		}
+		time.Sleep(thumbnailInterval())
+	}
+}
+
+// captureAllThumbnails refreshes thumbnails for all running web UI containers
+func captureAllThumbnails() {
+	containers, err := containerRepo.ListWithWebUI()
+	if err != nil {
+		log.Printf("Warning: failed to list web UI containers for thumbnails: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	live, _ := podmanService.ListContainers(ctx)
+	cancel()
+
+	running := make(map[string]bool)
+	for _, lc := range live {
+		if lc.Status == models.ContainerStatusRunning {
+			running[lc.ContainerID] = true
+		}
+	}
+
+	for i := range containers {
+		if !running[containers[i].ContainerID] {
+			continue
+		}
+		if err := captureThumbnail(&containers[i]); err != nil {
+			log.Printf("Warning: thumbnail capture failed for %s: %v", containers[i].Name, err)
+		}
+	}
+}
+
+// captureThumbnail screenshots a single container's web UI
+func captureThumbnail(container *models.Container) error {
+	if !container.HasWebUI || container.WebUIPort == 0 {
+		return fmt.Errorf("container does not have a web UI configured")
+	}
+
+	path := container.WebUIPath
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := fmt.Sprintf("http://localhost:%d%s", container.WebUIPort, path)
+
+	thumbnailMu.Lock()
+	defer thumbnailMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+	defer cancel()
+
+	return system.CaptureThumbnail(ctx, url, thumbnailPath(container.ID))
+}
+
+// thumbnailPath returns where a container's cached thumbnail is stored
+func thumbnailPath(id string) string {
+	return filepath.Join(thumbnailBaseDir, filepath.Base(id)+".png")
+}
+
+// thumbnailURL returns the API URL for a container's thumbnail, or "" if none is cached
+func thumbnailURL(container *models.Container) string {
+	info, err := os.Stat(thumbnailPath(container.ID))
+	if err != nil {
+		return ""
+	}
+	return externalPath(fmt.Sprintf("/api/containers/%s/thumbnail?t=%d", container.ID, info.ModTime().Unix()))
+}
+
+// lookupManagedContainer finds a managed container by Stardeck or Podman ID
+func lookupManagedContainer(id string) (*models.Container, error) {
+	container, err := containerRepo.GetByID(id)
+	if err != nil {
+		container, err = containerRepo.GetByContainerID(resolveContainerID(id))
+	}
+	return container, err
+}
+
+// getContainerThumbnailHandler serves the cached web UI thumbnail for a container
+func getContainerThumbnailHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	path := thumbnailPath(container.ID)
+	if _, err := os.Stat(path); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "No thumbnail available",
+		})
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=300")
+	return c.File(path)
+}
+
+// refreshContainerThumbnailHandler captures a fresh thumbnail immediately
+func refreshContainerThumbnailHandler(c echo.Context) error {
+	container, err := lookupManagedContainer(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.container_not_found"),
+		})
+	}
+
+	if err := captureThumbnail(container); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to capture thumbnail: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status":        "captured",
+		"thumbnail_url": thumbnailURL(container),
+	})
+}
@@ -0,0 +1,375 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/certs"
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var tlsVersions = map[string]uint16{"1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13}
+
+// tlsListener holds the running HTTPS listener's configuration. Handshakes
+// read the current config, so settings changes apply to new connections.
+var tlsListener struct {
+	mu        sync.Mutex // Guards the fields up to the redirect listener
+	enabled   bool
+	httpsPort string
+	config    atomic.Pointer[tls.Config]
+	hsts      atomic.Value // string header value, empty when disabled
+
+	certPath, keyPath string
+	cert              *tls.Certificate
+	certModTime       time.Time
+	ca                *certs.CA // Issues and verifies client certificates
+
+	// Separate lock so handshakes never wait on the redirect listener shutting down
+	redirectMu  sync.Mutex
+	redirect    *http.Server
+	redirectErr string
+}
+
+// NewTLSConfig builds the HTTPS listener's TLS config from the persisted
+// settings and starts the HTTP redirect listener when enabled. It must be
+// called after RegisterRoutes. The certificate is reloaded when its file changes.
+func NewTLSConfig(certPath, keyPath, httpsPort string) (*tls.Config, error) {
+	tlsListener.mu.Lock()
+	tlsListener.enabled = true
+	tlsListener.certPath, tlsListener.keyPath = certPath, keyPath
+	tlsListener.httpsPort = httpsPort
+	tlsListener.mu.Unlock()
+
+	if _, err := listenerCertificate(nil); err != nil {
+		return nil, err
+	}
+	ca, err := certs.EnsureCA(filepath.Dir(certPath))
+	if err != nil {
+		return nil, err
+	}
+	tlsListener.mu.Lock()
+	tlsListener.ca = ca
+	tlsListener.mu.Unlock()
+
+	applyTLSSettings(loadTLSSettings())
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return tlsListener.config.Load(), nil
+		},
+	}, nil
+}
+
+// HSTSMiddleware adds the Strict-Transport-Security header to HTTPS responses when enabled
+func HSTSMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Scheme() == "https" {
+				if v, _ := tlsListener.hsts.Load().(string); v != "" {
+					c.Response().Header().Set("Strict-Transport-Security", v)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// listenerCertificate returns the server certificate, reloading it when the file was modified
+func listenerCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	tlsListener.mu.Lock()
+	defer tlsListener.mu.Unlock()
+
+	info, err := os.Stat(tlsListener.certPath)
+	if err != nil {
+		if tlsListener.cert != nil {
+			return tlsListener.cert, nil
+		}
+		return nil, err
+	}
+	if tlsListener.cert != nil && info.ModTime().Equal(tlsListener.certModTime) {
+		return tlsListener.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(tlsListener.certPath, tlsListener.keyPath)
+	if err != nil {
+		if tlsListener.cert != nil {
+			// Keep serving the old certificate while a renewal is half written
+			log.Printf("Failed to reload TLS certificate: %v", err)
+			return tlsListener.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	tlsListener.cert = &cert
+	tlsListener.certModTime = info.ModTime()
+	return tlsListener.cert, nil
+}
+
+// loadTLSSettings reads TLS settings, applying defaults for missing values
+func loadTLSSettings() models.TLSSettings {
+	s := models.TLSSettings{
+		MinVersion:       "1.2",
+		CipherSuites:     []string{},
+		HSTSMaxAge:       31536000,
+		HTTPRedirectPort: 80,
+	}
+	if v, err := settingsRepo.Get(database.SettingTLSMinVersion); err == nil && tlsVersions[v] != 0 {
+		s.MinVersion = v
+	}
+	if v, err := settingsRepo.Get(database.SettingTLSCipherSuites); err == nil && v != "" {
+		s.CipherSuites = strings.Split(v, ",")
+	}
+	if v, err := settingsRepo.GetBool(database.SettingTLSHSTS); err == nil {
+		s.HSTSEnabled = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingTLSHSTSMaxAge); err == nil && v > 0 {
+		s.HSTSMaxAge = v
+	}
+	if v, err := settingsRepo.GetBool(database.SettingTLSHSTSSubdomains); err == nil {
+		s.HSTSIncludeSubdomains = v
+	}
+	if v, err := settingsRepo.GetBool(database.SettingTLSHTTPRedirect); err == nil {
+		s.HTTPRedirect = v
+	}
+	if v, err := settingsRepo.GetInt(database.SettingTLSHTTPRedirectPort); err == nil && v > 0 {
+		s.HTTPRedirectPort = v
+	}
+	return s
+}
+
+// tlsCipherSuiteIDs maps the configurable TLS 1.2 cipher suite names to their IDs.
+// TLS 1.3 suites are not configurable in Go, and insecure suites are never offered.
+func tlsCipherSuiteIDs() map[string]uint16 {
+	ids := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		for _, v := range suite.SupportedVersions {
+			if v == tls.VersionTLS12 {
+				ids[suite.Name] = suite.ID
+			}
+		}
+	}
+	return ids
+}
+
+// applyTLSSettings swaps in a new handshake config and HSTS header and starts
+// or stops the redirect listener to match the settings
+func applyTLSSettings(s models.TLSSettings) {
+	tlsListener.mu.Lock()
+	enabled := tlsListener.enabled
+	httpsPort := tlsListener.httpsPort
+	ca := tlsListener.ca
+	tlsListener.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	config := &tls.Config{
+		MinVersion:     tlsVersions[s.MinVersion],
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: listenerCertificate,
+	}
+	ids := tlsCipherSuiteIDs()
+	for _, name := range s.CipherSuites {
+		if id, ok := ids[name]; ok {
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+	switch loadMTLSMode() {
+	case models.MTLSOptional:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		config.ClientCAs = ca.Pool()
+	case models.MTLSRequired:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = ca.Pool()
+	}
+	tlsListener.config.Store(config)
+
+	hsts := ""
+	if s.HSTSEnabled {
+		hsts = "max-age=" + strconv.Itoa(s.HSTSMaxAge)
+		if s.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+	tlsListener.hsts.Store(hsts)
+
+	configureHTTPRedirect(s, httpsPort)
+}
+
+// configureHTTPRedirect restarts the plain HTTP listener that redirects to HTTPS
+func configureHTTPRedirect(s models.TLSSettings, httpsPort string) {
+	tlsListener.redirectMu.Lock()
+	defer tlsListener.redirectMu.Unlock()
+
+	if tlsListener.redirect != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		tlsListener.redirect.Shutdown(ctx)
+		cancel()
+		tlsListener.redirect = nil
+	}
+	tlsListener.redirectErr = ""
+	if !s.HTTPRedirect {
+		return
+	}
+
+	// Bound to the same addresses as the HTTPS listeners
+	var listeners []net.Listener
+	var addrs []string
+	for _, host := range listenHosts() {
+		addr := net.JoinHostPort(host, strconv.Itoa(s.HTTPRedirectPort))
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			tlsListener.redirectErr = err.Error()
+			log.Printf("Failed to start HTTP redirect listener on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, ln)
+		addrs = append(addrs, addr)
+	}
+	if len(listeners) == 0 {
+		return
+	}
+
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			if httpsPort != "443" {
+				host += ":" + httpsPort
+			}
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+		}),
+	}
+	tlsListener.redirect = server
+	for _, ln := range listeners {
+		go func() {
+			if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+				log.Printf("HTTP redirect listener stopped: %v", err)
+			}
+		}()
+	}
+	log.Printf("Redirecting HTTP on %s to HTTPS", strings.Join(addrs, ", "))
+}
+
+// tlsStatus reports the current settings and listener state
+func tlsStatus() models.TLSStatus {
+	tlsListener.mu.Lock()
+	status := models.TLSStatus{
+		Settings: loadTLSSettings(),
+		Enabled:  tlsListener.enabled,
+	}
+	tlsListener.mu.Unlock()
+	tlsListener.redirectMu.Lock()
+	status.RedirectListening = tlsListener.redirect != nil
+	status.RedirectError = tlsListener.redirectErr
+	tlsListener.redirectMu.Unlock()
+
+	ids := tlsCipherSuiteIDs()
+	for _, suite := range tls.CipherSuites() {
+		if _, ok := ids[suite.Name]; ok {
+			// Go leaves RSA key exchange out of its defaults
+			status.AvailableCipherSuites = append(status.AvailableCipherSuites, models.TLSCipherSuite{
+				Name:    suite.Name,
+				Default: !strings.HasPrefix(suite.Name, "TLS_RSA_"),
+			})
+		}
+	}
+	return status
+}
+
+// getTLSSettingsHandler handles GET /api/system/tls
+func getTLSSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, tlsStatus())
+}
+
+// updateTLSSettingsHandler handles PUT /api/system/tls
+func updateTLSSettingsHandler(c echo.Context) error {
+	settings := loadTLSSettings()
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if tlsVersions[settings.MinVersion] == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "min_version must be 1.2 or 1.3",
+		})
+	}
+	ids := tlsCipherSuiteIDs()
+	http2Suite := false
+	for _, name := range settings.CipherSuites {
+		if _, ok := ids[name]; !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Unknown or insecure cipher suite: " + name,
+			})
+		}
+		if name == "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" || name == "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256" {
+			http2Suite = true
+		}
+	}
+	if len(settings.CipherSuites) > 0 && !http2Suite {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "cipher_suites must include an ECDHE AES_128_GCM_SHA256 suite, which HTTP/2 requires",
+		})
+	}
+	if settings.HSTSEnabled && settings.HSTSMaxAge < 300 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "hsts_max_age must be at least 300 seconds",
+		})
+	}
+	if settings.HTTPRedirectPort < 1 || settings.HTTPRedirectPort > 65535 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "http_redirect_port must be between 1 and 65535",
+		})
+	}
+	tlsListener.mu.Lock()
+	httpsPort := tlsListener.httpsPort
+	tlsListener.mu.Unlock()
+	if settings.HTTPRedirect && strconv.Itoa(settings.HTTPRedirectPort) == httpsPort {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "http_redirect_port must differ from the HTTPS port",
+		})
+	}
+
+	values := map[string]string{
+		database.SettingTLSMinVersion:       settings.MinVersion,
+		database.SettingTLSCipherSuites:     strings.Join(settings.CipherSuites, ","),
+		database.SettingTLSHSTS:             strconv.FormatBool(settings.HSTSEnabled),
+		database.SettingTLSHSTSMaxAge:       strconv.Itoa(settings.HSTSMaxAge),
+		database.SettingTLSHSTSSubdomains:   strconv.FormatBool(settings.HSTSIncludeSubdomains),
+		database.SettingTLSHTTPRedirect:     strconv.FormatBool(settings.HTTPRedirect),
+		database.SettingTLSHTTPRedirectPort: strconv.Itoa(settings.HTTPRedirectPort),
+	}
+	if err := settingsRepo.SetMany(values); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save TLS settings: " + err.Error(),
+		})
+	}
+	applyTLSSettings(settings)
+
+	Audit.LogFromContext(c, models.ActionTLSSettings, "tls", settings)
+
+	return c.JSON(http.StatusOK, tlsStatus())
+}
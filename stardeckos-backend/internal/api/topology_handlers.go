@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// topologyHostNode is the ID of the host in the topology graph
+const topologyHostNode = "host"
+
+// getNetworkTopologyHandler handles GET /api/network/topology. It assembles
+// the host's interfaces and bridges, the podman networks on them, and the
+// containers attached to those networks or publishing ports on the host.
+func getNetworkTopologyHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+
+	hostname, _ := os.Hostname()
+	topology := models.Topology{
+		Nodes:       []models.TopologyNode{{ID: topologyHostNode, Type: models.TopologyHost, Label: hostname}},
+		Edges:       []models.TopologyEdge{},
+		Mode:        podmanService.GetMode(),
+		GeneratedAt: time.Now(),
+	}
+
+	interfaces, err := system.GetNetworkInterfaces()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get network interfaces: " + err.Error(),
+		})
+	}
+	interfaceNodes := make(map[string]string)
+	addressNodes := make(map[string]string) // Host address -> interface node
+	for _, iface := range interfaces {
+		// Container ends of veth pairs appear as attachments to their networks
+		if iface.Type == "loopback" || strings.HasPrefix(iface.Name, "veth") {
+			continue
+		}
+		id := "if:" + iface.Name
+		nodeType := models.TopologyInterface
+		if iface.Type == "bridge" {
+			nodeType = models.TopologyBridge
+		}
+		addresses := append(append([]string{}, iface.IPv4...), iface.IPv6...)
+		topology.Nodes = append(topology.Nodes, models.TopologyNode{
+			ID:        id,
+			Type:      nodeType,
+			Label:     iface.Name,
+			State:     iface.State,
+			Addresses: addresses,
+		})
+		interfaceNodes[iface.Name] = id
+		for _, addr := range addresses {
+			if ip, _, err := net.ParseCIDR(addr); err == nil {
+				addressNodes[ip.String()] = id
+			}
+		}
+	}
+	for _, iface := range interfaces {
+		id, ok := interfaceNodes[iface.Name]
+		if !ok {
+			continue
+		}
+		if master, ok := interfaceNodes[iface.Master]; ok {
+			topology.Edges = append(topology.Edges, models.TopologyEdge{From: id, To: master, Type: models.TopologyEdgeMember})
+		} else {
+			topology.Edges = append(topology.Edges, models.TopologyEdge{From: topologyHostNode, To: id, Type: models.TopologyEdgeLink})
+		}
+	}
+
+	networks, err := podmanService.ListNetworks(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list networks: " + err.Error(),
+		})
+	}
+	networkNodes := make(map[string]string)
+	for _, n := range networks {
+		if !view.visible(view.project(models.ProjectResourceNetwork, n.Name)) {
+			continue
+		}
+		id := "net:" + n.Name
+		topology.Nodes = append(topology.Nodes, models.TopologyNode{
+			ID:       id,
+			Type:     models.TopologyNetwork,
+			Label:    n.Name,
+			Driver:   n.Driver,
+			Subnet:   n.Subnet,
+			Internal: n.Internal,
+		})
+		networkNodes[n.Name] = id
+		// Rootless networks' bridges are in a separate namespace and don't match
+		if bridge, ok := interfaceNodes[n.Interface]; ok {
+			topology.Edges = append(topology.Edges, models.TopologyEdge{From: id, To: bridge, Type: models.TopologyEdgeBackedBy})
+		}
+	}
+
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.list_containers", "error", err.Error()),
+		})
+	}
+	containerIDs := make([]string, len(containers))
+	for i, container := range containers {
+		containerIDs[i] = container.ContainerID
+	}
+	managed, err := containerRepo.GetByContainerIDs(containerIDs)
+	if err != nil {
+		c.Logger().Warn("Failed to fetch container metadata from database: ", err)
+	}
+
+	for _, container := range containers {
+		dbContainer, isManaged := managed[container.ContainerID]
+		stardeckID := ""
+		if isManaged {
+			stardeckID = dbContainer.ID
+		}
+		if !view.visible(view.containerProject(stardeckID, container.Stack)) {
+			continue
+		}
+		id := "ctr:" + container.ContainerID
+		node := models.TopologyNode{
+			ID:      id,
+			Type:    models.TopologyContainer,
+			Label:   container.Name,
+			State:   string(container.Status),
+			Image:   container.Image,
+			Stack:   container.Stack,
+			Managed: isManaged,
+		}
+
+		if inspect, err := podmanService.InspectContainer(ctx, container.ContainerID); err == nil {
+			if inspect.HostConfig.NetworkMode == "host" {
+				topology.Edges = append(topology.Edges, models.TopologyEdge{From: id, To: topologyHostNode, Type: models.TopologyEdgeHostShare})
+			}
+			names := make([]string, 0, len(inspect.NetworkSettings.Networks))
+			for name := range inspect.NetworkSettings.Networks {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				attachment := inspect.NetworkSettings.Networks[name]
+				for _, addr := range []string{attachment.IPAddress, attachment.GlobalIPv6Address} {
+					if addr != "" {
+						node.Addresses = append(node.Addresses, addr)
+					}
+				}
+				if networkID, ok := networkNodes[name]; ok {
+					topology.Edges = append(topology.Edges, models.TopologyEdge{
+						From:  id,
+						To:    networkID,
+						Type:  models.TopologyEdgeAttached,
+						Label: attachment.IPAddress,
+					})
+				}
+			}
+		}
+		topology.Nodes = append(topology.Nodes, node)
+
+		// Ports bound to every address enter through the host; others through the interface holding the address
+		published := make(map[string][]models.PortMapping)
+		var sources []string
+		for _, port := range container.Ports {
+			source := topologyHostNode
+			if port.HostIP != "" && port.HostIP != "0.0.0.0" && port.HostIP != "::" {
+				if ifaceID, ok := addressNodes[port.HostIP]; ok {
+					source = ifaceID
+				}
+			}
+			if _, seen := published[source]; !seen {
+				sources = append(sources, source)
+			}
+			published[source] = append(published[source], port)
+		}
+		for _, source := range sources {
+			ports := published[source]
+			labels := make([]string, 0, len(ports))
+			for _, port := range ports {
+				labels = append(labels, fmt.Sprintf("%d:%d/%s", port.HostPort, port.ContainerPort, port.Protocol))
+			}
+			topology.Edges = append(topology.Edges, models.TopologyEdge{
+				From:  source,
+				To:    id,
+				Type:  models.TopologyEdgePublish,
+				Label: strings.Join(labels, ", "),
+				Ports: ports,
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, topology)
+}
@@ -0,0 +1,565 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var trashRepo *database.TrashRepo
+
+const trashBaseDir = "/var/lib/stardeck/trash"
+
+// InitTrashRepo initializes the recycle bin repository and starts the purge loop
+func InitTrashRepo() {
+	trashRepo = database.NewTrashRepo()
+	go runTrashPurger()
+}
+
+// trashEnabled reports whether removals should go to the recycle bin
+func trashEnabled() bool {
+	enabled, err := settingsRepo.GetBool(database.SettingTrashEnabled)
+	if err != nil {
+		return true // Default to safe behaviour
+	}
+	return enabled
+}
+
+// trashRetention returns how long trashed items are kept before purging
+func trashRetention() time.Duration {
+	days, err := settingsRepo.GetInt(database.SettingTrashRetentionDays)
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// runTrashPurger periodically removes expired items from the recycle bin
+func runTrashPurger() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if !maintenanceModeActive() {
+			purgeExpiredTrash()
+		}
+		<-ticker.C
+	}
+}
+
+// purgeExpiredTrash permanently deletes all trash items past their retention
+func purgeExpiredTrash() int {
+	items, err := trashRepo.ListExpired(time.Now())
+	if err != nil {
+		log.Printf("Warning: failed to list expired trash items: %v", err)
+		return 0
+	}
+
+	purged := 0
+	for _, item := range items {
+		if err := purgeTrashItem(item); err != nil {
+			log.Printf("Warning: failed to purge trash item %s: %v", item.ID, err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+// purgeTrashItem removes the item's preserved data and its record
+func purgeTrashItem(item *models.TrashItem) error {
+	if item.BackupPath != "" {
+		if err := os.RemoveAll(item.BackupPath); err != nil {
+			return fmt.Errorf("failed to remove trash data: %w", err)
+		}
+	}
+	return trashRepo.Delete(item.ID)
+}
+
+// trashContainer snapshots a container's configuration (and optionally its bind
+// mount data) into the recycle bin before it is removed from Podman
+func trashContainer(ctx context.Context, user *models.User, id, containerID string, withBackup bool) (*models.TrashItem, error) {
+	config, err := podmanService.GetContainerConfig(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read container config: %w", err)
+	}
+
+	payload := models.TrashedContainer{Config: *config}
+	if dc, err := containerRepo.GetByID(id); err == nil {
+		payload.Container = dc
+	} else if dc, err := containerRepo.GetByContainerID(containerID); err == nil {
+		payload.Container = dc
+	}
+
+	item := &models.TrashItem{
+		ID:         uuid.New().String(),
+		ItemType:   models.TrashItemContainer,
+		Name:       config.Name,
+		OriginalID: containerID,
+		DeletedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(trashRetention()),
+		DeletedBy:  &user.ID,
+	}
+
+	if withBackup {
+		itemDir := filepath.Join(trashBaseDir, item.ID)
+		if err := os.MkdirAll(itemDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create trash directory: %w", err)
+		}
+		backup, err := podmanService.BackupBindMounts(ctx, containerID, itemDir, true, nil)
+		if err != nil {
+			os.RemoveAll(itemDir)
+			return nil, fmt.Errorf("failed to back up container data: %w", err)
+		}
+		payload.Backup = backup
+		item.BackupPath = itemDir
+		item.HasBackup = true
+		item.SizeBytes = backup.SizeBytes
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	item.Payload = string(payloadJSON)
+
+	if err := trashRepo.Create(item); err != nil {
+		if item.BackupPath != "" {
+			os.RemoveAll(item.BackupPath)
+		}
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// trashStack moves a stack's files into the recycle bin and records its definition
+func trashStack(user *models.User, stack *models.Stack, volumesRemoved bool) (*models.TrashItem, error) {
+	item := &models.TrashItem{
+		ID:         uuid.New().String(),
+		ItemType:   models.TrashItemStack,
+		Name:       stack.Name,
+		OriginalID: stack.ID,
+		DeletedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(trashRetention()),
+		DeletedBy:  &user.ID,
+	}
+
+	if stack.Path != "" {
+		if _, err := os.Stat(stack.Path); err == nil {
+			itemDir := filepath.Join(trashBaseDir, item.ID)
+			if err := os.MkdirAll(itemDir, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create trash directory: %w", err)
+			}
+			if err := os.Rename(stack.Path, filepath.Join(itemDir, "stack")); err != nil {
+				os.RemoveAll(itemDir)
+				return nil, fmt.Errorf("failed to move stack files to trash: %w", err)
+			}
+			item.BackupPath = itemDir
+			item.HasBackup = true
+			item.SizeBytes = dirSize(itemDir)
+		}
+	}
+
+	payloadJSON, err := json.Marshal(models.TrashedStack{
+		Stack:          *stack,
+		VolumesRemoved: volumesRemoved,
+	})
+	if err != nil {
+		return nil, err
+	}
+	item.Payload = string(payloadJSON)
+
+	if err := trashRepo.Create(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// trashData records a volume or bind mount directory whose data was archived before
+// deletion. The archive lives in the backup path and outlives the trash entry.
+func trashData(user *models.User, itemType models.TrashItemType, name string, payload *models.TrashedData) (*models.TrashItem, error) {
+	item := &models.TrashItem{
+		ID:         uuid.New().String(),
+		ItemType:   itemType,
+		Name:       name,
+		OriginalID: name,
+		HasBackup:  true,
+		DeletedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(trashRetention()),
+		DeletedBy:  &user.ID,
+	}
+	if info, err := os.Stat(payload.ArchivePath); err == nil {
+		item.SizeBytes = info.Size()
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	item.Payload = string(payloadJSON)
+
+	if err := trashRepo.Create(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// listTrashHandler returns all items in the recycle bin
+func listTrashHandler(c echo.Context) error {
+	items, err := trashRepo.List(c.QueryParam("type"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list trash: " + err.Error(),
+		})
+	}
+
+	if items == nil {
+		items = []*models.TrashItem{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"items":          items,
+		"enabled":        trashEnabled(),
+		"retention_days": int(trashRetention().Hours() / 24),
+	})
+}
+
+// getTrashItemHandler returns a trash item with its stored snapshot
+func getTrashItemHandler(c echo.Context) error {
+	item, err := trashRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Trash item not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get trash item: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"item":    item,
+		"payload": json.RawMessage(item.Payload),
+	})
+}
+
+// restoreTrashItemHandler restores a container, stack, volume or bind mount from the recycle bin
+func restoreTrashItemHandler(c echo.Context) error {
+	item, err := trashRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Trash item not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get trash item: " + err.Error(),
+		})
+	}
+
+	var req models.RestoreTrashRequest
+	c.Bind(&req)
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	user := c.Get("user").(*models.User)
+
+	var result map[string]interface{}
+	switch item.ItemType {
+	case models.TrashItemContainer:
+		result, err = restoreTrashedContainer(ctx, user, item, req.Start)
+	case models.TrashItemStack:
+		result, err = restoreTrashedStack(ctx, item, req.Start)
+	case models.TrashItemVolume, models.TrashItemBindMount:
+		result, err = restoreTrashedData(ctx, item)
+	default:
+		err = fmt.Errorf("unknown trash item type: %s", item.ItemType)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to restore: " + err.Error(),
+		})
+	}
+
+	// Stack files were moved back, only the container backup needs cleanup
+	if err := purgeTrashItem(item); err != nil {
+		c.Logger().Warnf("Failed to clean up restored trash item %s: %v", item.ID, err)
+	}
+
+	logAudit(user, models.ActionTrashRestore, item.Name, map[string]interface{}{
+		"type":  item.ItemType,
+		"start": req.Start,
+	})
+
+	result["status"] = "restored"
+	return c.JSON(http.StatusOK, result)
+}
+
+// restoreTrashedContainer recreates a container from its trashed snapshot
+func restoreTrashedContainer(ctx context.Context, user *models.User, item *models.TrashItem, start bool) (map[string]interface{}, error) {
+	var payload models.TrashedContainer
+	if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("corrupt trash payload: %w", err)
+	}
+
+	exists, err := podmanService.ContainerExists(ctx, payload.Config.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("a container named %s already exists", payload.Config.Name)
+	}
+
+	if payload.Backup != nil {
+		if err := podmanService.RestoreBindMounts(ctx, payload.Backup, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	newContainerID, err := podmanService.CreateContainer(ctx, configToCreateRequest(&payload.Config, payload.Config.Image))
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.ContainerStatusCreated
+	if start {
+		if err := podmanService.StartContainer(ctx, newContainerID); err != nil {
+			return nil, fmt.Errorf("container restored but failed to start: %w", err)
+		}
+		status = models.ContainerStatusRunning
+	}
+
+	if payload.Container != nil {
+		restored := payload.Container
+		restored.ContainerID = newContainerID
+		restored.Status = status
+		if _, err := containerRepo.GetByID(restored.ID); err == nil {
+			containerRepo.Update(restored)
+		} else if err := containerRepo.Create(restored); err != nil {
+			return nil, fmt.Errorf("container restored but metadata could not be saved: %w", err)
+		}
+		recordContainerConfig(ctx, restored, models.ConfigSnapshotRestore, &user.ID)
+	}
+
+	return map[string]interface{}{
+		"container_id": newContainerID,
+		"name":         payload.Config.Name,
+	}, nil
+}
+
+// restoreTrashedStack moves a stack's files back and recreates its record
+func restoreTrashedStack(ctx context.Context, item *models.TrashItem, deploy bool) (map[string]interface{}, error) {
+	var payload models.TrashedStack
+	if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("corrupt trash payload: %w", err)
+	}
+	stack := payload.Stack
+
+	if existing, _ := stackRepo.GetByName(stack.Name); existing != nil {
+		return nil, fmt.Errorf("a stack named %s already exists", stack.Name)
+	}
+
+	dir := filepath.Join(stacksBaseDir, stack.Name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("stack directory %s already exists", dir)
+	}
+
+	trashedFiles := filepath.Join(item.BackupPath, "stack")
+	if item.BackupPath != "" {
+		if err := os.MkdirAll(stacksBaseDir, 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(trashedFiles, dir); err != nil {
+			return nil, fmt.Errorf("failed to restore stack files: %w", err)
+		}
+	} else {
+		if _, err := ensureStackDir(stack.Name); err != nil {
+			return nil, err
+		}
+		if err := writeComposeFiles(dir, stack.ComposeContent, stack.EnvContent); err != nil {
+			return nil, err
+		}
+	}
+
+	stack.Path = dir
+	stack.Status = models.StackStatusStopped
+	if err := stackRepo.Create(&stack); err != nil {
+		return nil, err
+	}
+
+	if deploy {
+		if err := composeUpOrdered(ctx, &stack, nil); err != nil {
+			stackRepo.UpdateStatus(stack.ID, models.StackStatusError)
+			return nil, fmt.Errorf("stack restored but deploy failed: %w", err)
+		}
+		stackRepo.UpdateStatus(stack.ID, models.StackStatusActive)
+	}
+
+	return map[string]interface{}{
+		"stack_id":        stack.ID,
+		"name":            stack.Name,
+		"volumes_removed": payload.VolumesRemoved,
+	}, nil
+}
+
+// restoreTrashedData recreates a volume or bind mount directory from its archive
+func restoreTrashedData(ctx context.Context, item *models.TrashItem) (map[string]interface{}, error) {
+	var payload models.TrashedData
+	if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+		return nil, fmt.Errorf("corrupt trash payload: %w", err)
+	}
+	if _, err := os.Stat(payload.ArchivePath); err != nil {
+		return nil, fmt.Errorf("archive %s is missing: %w", payload.ArchivePath, err)
+	}
+
+	if item.ItemType == models.TrashItemBindMount {
+		if _, err := os.Stat(payload.Path); err == nil {
+			return nil, fmt.Errorf("directory %s already exists", payload.Path)
+		}
+		if err := system.ExtractArchive(ctx, payload.ArchivePath, payload.Path); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"path":         payload.Path,
+			"archive_path": payload.ArchivePath,
+		}, nil
+	}
+
+	if payload.Volume == nil {
+		return nil, fmt.Errorf("trash payload has no volume definition")
+	}
+	if _, err := podmanService.InspectVolume(ctx, payload.Volume.Name); err == nil {
+		return nil, fmt.Errorf("a volume named %s already exists", payload.Volume.Name)
+	}
+	if err := podmanService.CreateVolume(ctx, &models.CreateVolumeRequest{
+		Name:    payload.Volume.Name,
+		Driver:  payload.Volume.Driver,
+		Labels:  payload.Volume.Labels,
+		Options: payload.Volume.Options,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to recreate volume: %w", err)
+	}
+
+	// Archives written straight from disk are gzipped; podman exports are plain tar
+	var err error
+	if strings.HasSuffix(payload.ArchivePath, ".tar.gz") {
+		var volume *models.Volume
+		volume, err = podmanService.InspectVolume(ctx, payload.Volume.Name)
+		if err == nil {
+			err = system.ExtractArchive(ctx, payload.ArchivePath, volume.MountPoint)
+		}
+	} else {
+		err = podmanService.ImportVolume(ctx, payload.Volume.Name, payload.ArchivePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("volume recreated but data restore failed: %w", err)
+	}
+
+	return map[string]interface{}{
+		"volume":       payload.Volume.Name,
+		"archive_path": payload.ArchivePath,
+	}, nil
+}
+
+// purgeTrashItemHandler permanently deletes a single trash item
+func purgeTrashItemHandler(c echo.Context) error {
+	item, err := trashRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Trash item not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get trash item: " + err.Error(),
+		})
+	}
+
+	if err := purgeTrashItem(item); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to purge trash item: " + err.Error(),
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionTrashPurge, item.Name, map[string]interface{}{
+		"type": item.ItemType,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "purged",
+	})
+}
+
+// emptyTrashHandler purges every item (or only expired ones with ?expired=true)
+func emptyTrashHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+
+	if c.QueryParam("expired") == "true" {
+		purged := purgeExpiredTrash()
+		logAudit(user, models.ActionTrashPurge, "expired", map[string]interface{}{
+			"count": purged,
+		})
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"status": "purged",
+			"count":  purged,
+		})
+	}
+
+	items, err := trashRepo.List("")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list trash: " + err.Error(),
+		})
+	}
+
+	purged := 0
+	var failed []string
+	for _, item := range items {
+		if err := purgeTrashItem(item); err != nil {
+			failed = append(failed, item.Name)
+			continue
+		}
+		purged++
+	}
+
+	logAudit(user, models.ActionTrashPurge, "all", map[string]interface{}{
+		"count": purged,
+	})
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": "purged",
+		"count":  purged,
+		"failed": failed,
+	})
+}
+
+// dirSize returns the total size of regular files under a directory
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/auth"
+	"stardeckos-backend/internal/models"
+)
+
+// twoFactorError writes the response for an error from the two-factor
+// service calls
+func twoFactorError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidTwoFactorCode), errors.Is(err, auth.ErrTwoFactorRequired):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid password"})
+	case errors.Is(err, auth.ErrTwoFactorUnavailable):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrTwoFactorEnabled), errors.Is(err, auth.ErrTwoFactorNotEnabled):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		c.Logger().Error("two-factor error: ", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "two-factor authentication failed",
+		})
+	}
+}
+
+// getTwoFactorStatusHandler handles GET /api/auth/2fa
+func getTwoFactorStatusHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	return c.JSON(http.StatusOK, authService.TwoFactorStatus(user))
+}
+
+// setupTwoFactorHandler handles POST /api/auth/2fa/setup. It returns a new
+// secret and the otpauth:// URL to show as a QR code; nothing changes for
+// sign-in until a code from it is verified.
+func setupTwoFactorHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	setup, err := authService.SetupTwoFactor(user)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, setup)
+}
+
+// verifyTwoFactorHandler handles POST /api/auth/2fa/verify, enabling
+// two-factor authentication once a code from the new secret checks out
+func verifyTwoFactorHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	var req models.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request_body"),
+		})
+	}
+
+	codes, err := authService.VerifyTwoFactorSetup(user, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	Audit.LogFromContext(c, models.ActionTwoFactorEnable, user.Username, nil)
+	return c.JSON(http.StatusOK, models.TwoFactorRecoveryCodes{RecoveryCodes: codes})
+}
+
+// disableTwoFactorHandler handles POST /api/auth/2fa/disable
+func disableTwoFactorHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	var req models.TwoFactorDisableRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request_body"),
+		})
+	}
+
+	if err := authService.DisableTwoFactor(user, req); err != nil {
+		return twoFactorError(c, err)
+	}
+
+	Audit.LogFromContext(c, models.ActionTwoFactorDisable, user.Username, nil)
+	return c.JSON(http.StatusOK, authService.TwoFactorStatus(user))
+}
+
+// regenerateRecoveryCodesHandler handles POST /api/auth/2fa/recovery-codes,
+// replacing the recovery codes; the old ones stop working
+func regenerateRecoveryCodesHandler(c echo.Context) error {
+	user := c.Get("user").(*models.User)
+	var req models.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request_body"),
+		})
+	}
+
+	codes, err := authService.RegenerateRecoveryCodes(user, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+
+	Audit.LogFromContext(c, models.ActionTwoFactorCodesRenew, user.Username, nil)
+	return c.JSON(http.StatusOK, models.TwoFactorRecoveryCodes{RecoveryCodes: codes})
+}
+
+// resetUserTwoFactorHandler handles DELETE /api/users/:id/2fa, for a user who
+// lost both their authenticator and recovery codes. Their sessions are
+// revoked so they sign in again with the password alone.
+func resetUserTwoFactorHandler(c echo.Context) error {
+	target, err := userFromParam(c)
+	if target == nil {
+		return err
+	}
+
+	if err := authService.ResetTwoFactor(target.ID); err != nil {
+		return twoFactorError(c, err)
+	}
+	authService.RevokeAllSessions(target.ID)
+
+	Audit.LogFromContext(c, models.ActionTwoFactorReset, target.Username, map[string]interface{}{
+		"user_id": target.ID,
+	})
+	return c.NoContent(http.StatusNoContent)
+}
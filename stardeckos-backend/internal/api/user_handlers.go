@@ -3,7 +3,9 @@ package api
 import (
 	"errors"
 	"net/http"
+	"net/mail"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
@@ -37,7 +39,7 @@ func createUserHandler(c echo.Context) error {
 	var req models.CreateUserRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -54,7 +56,13 @@ func createUserHandler(c echo.Context) error {
 	}
 	if len(req.Password) < 8 {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "password must be at least 8 characters",
+			"error": tr(c, "error.password_too_short"),
+		})
+	}
+
+	if !validEmail(req.Email) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid email address",
 		})
 	}
 
@@ -89,10 +97,14 @@ func createUserHandler(c echo.Context) error {
 	user := &models.User{
 		Username:     req.Username,
 		DisplayName:  displayName,
+		Email:        strings.TrimSpace(req.Email),
 		PasswordHash: passwordHash,
 		Role:         role,
 		AuthType:     models.AuthTypeLocal,
 	}
+	if msg := assignRole(c, user, &role, req.RoleID); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	}
 
 	if err := userRepo.Create(user); err != nil {
 		c.Logger().Error("create user error: ", err)
@@ -106,6 +118,7 @@ func createUserHandler(c echo.Context) error {
 		"user_id":  user.ID,
 		"username": user.Username,
 		"role":     user.Role,
+		"role_id":  user.RoleID,
 	})
 
 	return c.JSON(http.StatusCreated, user)
@@ -116,7 +129,7 @@ func getUserHandler(c echo.Context) error {
 	id, err := parseID(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid user ID",
+			"error": tr(c, "error.invalid_user_id"),
 		})
 	}
 
@@ -124,7 +137,7 @@ func getUserHandler(c echo.Context) error {
 	if err != nil {
 		if errors.Is(err, database.ErrUserNotFound) {
 			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "user not found",
+				"error": tr(c, "error.user_not_found"),
 			})
 		}
 		c.Logger().Error("get user error: ", err)
@@ -141,7 +154,7 @@ func updateUserHandler(c echo.Context) error {
 	id, err := parseID(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid user ID",
+			"error": tr(c, "error.invalid_user_id"),
 		})
 	}
 
@@ -150,7 +163,7 @@ func updateUserHandler(c echo.Context) error {
 	if err != nil {
 		if errors.Is(err, database.ErrUserNotFound) {
 			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "user not found",
+				"error": tr(c, "error.user_not_found"),
 			})
 		}
 		c.Logger().Error("get user error: ", err)
@@ -162,7 +175,7 @@ func updateUserHandler(c echo.Context) error {
 	var req models.UpdateUserRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request body",
+			"error": tr(c, "error.invalid_request_body"),
 		})
 	}
 
@@ -170,8 +183,16 @@ func updateUserHandler(c echo.Context) error {
 	if req.DisplayName != nil {
 		user.DisplayName = *req.DisplayName
 	}
-	if req.Role != nil {
-		user.Role = *req.Role
+	if req.Email != nil {
+		if !validEmail(*req.Email) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "invalid email address",
+			})
+		}
+		user.Email = strings.TrimSpace(*req.Email)
+	}
+	if msg := assignRole(c, user, req.Role, req.RoleID); msg != "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
 	}
 	if req.Disabled != nil {
 		user.Disabled = *req.Disabled
@@ -179,7 +200,7 @@ func updateUserHandler(c echo.Context) error {
 	if req.Password != nil && *req.Password != "" {
 		if len(*req.Password) < 8 {
 			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "password must be at least 8 characters",
+				"error": tr(c, "error.password_too_short"),
 			})
 		}
 		passwordHash, err := auth.HashPassword(*req.Password)
@@ -213,7 +234,7 @@ func deleteUserHandler(c echo.Context) error {
 	id, err := parseID(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid user ID",
+			"error": tr(c, "error.invalid_user_id"),
 		})
 	}
 
@@ -231,7 +252,7 @@ func deleteUserHandler(c echo.Context) error {
 	if err := userRepo.Delete(id); err != nil {
 		if errors.Is(err, database.ErrUserNotFound) {
 			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "user not found",
+				"error": tr(c, "error.user_not_found"),
 			})
 		}
 		c.Logger().Error("delete user error: ", err)
@@ -257,6 +278,16 @@ func deleteUserHandler(c echo.Context) error {
 
 // Helper functions
 
+// validEmail accepts an empty address or a single bare address
+func validEmail(email string) bool {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return true
+	}
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
 func parseID(s string) (int64, error) {
 	return strconv.ParseInt(s, 10, 64)
 }
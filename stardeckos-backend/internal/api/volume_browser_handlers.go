@@ -0,0 +1,368 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+var errLeavesVolume = errors.New("path is outside the volume")
+
+// volumeRoot finds the host directory holding a volume's data. Volumes whose
+// driver doesn't keep them mounted are mounted first; release unmounts them
+// again.
+func volumeRoot(ctx context.Context, name string) (root string, release func(), err error) {
+	volume, err := podmanService.InspectVolume(ctx, name)
+	if err != nil {
+		return "", nil, err
+	}
+	noop := func() {}
+	if info, err := os.Stat(volume.MountPoint); err == nil && info.IsDir() {
+		root, err = filepath.EvalSymlinks(volume.MountPoint)
+		return root, noop, err
+	}
+
+	mounted, err := podmanService.MountVolume(ctx, name)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to mount volume: %w", err)
+	}
+	release = func() {
+		unmountCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		podmanService.UnmountVolume(unmountCtx, name)
+	}
+	// A rootless mount lives in podman's user namespace, out of our sight
+	if info, err := os.Stat(mounted); err != nil || !info.IsDir() {
+		release()
+		return "", nil, fmt.Errorf("the %s driver doesn't expose the volume's data on the host", volume.Driver)
+	}
+	root, err = filepath.EvalSymlinks(mounted)
+	if err != nil {
+		release()
+		return "", nil, err
+	}
+	return root, release, nil
+}
+
+// volumePath maps a path inside a volume to the host. Symlinks in a volume
+// point into the container's filesystem, so they may only resolve within the
+// volume; follow also resolves the last element, for reads.
+func volumePath(root, rel string, follow bool) (string, error) {
+	clean := filepath.Clean("/" + rel)
+	if clean == "/" {
+		return root, nil
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Join(root, filepath.Dir(clean)))
+	if err != nil {
+		return "", err
+	}
+	if !pathWithin(parent, root) {
+		return "", errLeavesVolume
+	}
+	path := filepath.Join(parent, filepath.Base(clean))
+	if !follow {
+		return path, nil
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	if !pathWithin(resolved, root) {
+		return "", errLeavesVolume
+	}
+	return resolved, nil
+}
+
+// relativeToVolume turns a host path back into the path shown to the user
+func relativeToVolume(root, path string) string {
+	return "/" + strings.TrimPrefix(strings.TrimPrefix(path, root), "/")
+}
+
+// matchParentOwner gives a new file or directory the owner of the directory
+// it was made in. In a rootless volume that is the container user, who
+// otherwise couldn't touch what root wrote.
+func matchParentOwner(path string) {
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Lchown(path, int(st.Uid), int(st.Gid))
+	}
+}
+
+// openVolumePath resolves the volume and path of a request, writing the
+// error response itself. Callers must call release when root is set.
+func openVolumePath(ctx context.Context, c echo.Context, rel string, follow bool) (root, path string, release func()) {
+	root, release, err := volumeRoot(ctx, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.volume_unavailable", "error", err.Error()),
+		})
+		return "", "", nil
+	}
+	path, err = volumePath(root, rel, follow)
+	if err != nil {
+		release()
+		status := http.StatusNotFound
+		if errors.Is(err, errLeavesVolume) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, map[string]string{
+			"error": strings.ReplaceAll(err.Error(), root, ""),
+		})
+		return "", "", nil
+	}
+	return root, path, release
+}
+
+// listVolumeFilesHandler handles GET /api/volumes/:name/files?path=/sub
+func listVolumeFilesHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	root, path, release := openVolumePath(ctx, c, c.QueryParam("path"), true)
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	listing, err := system.ListDirectory(path)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": strings.ReplaceAll(err.Error(), root, ""),
+		})
+	}
+
+	listing.Path = relativeToVolume(root, listing.Path)
+	if listing.Path == "/" {
+		listing.Parent = ""
+	} else {
+		listing.Parent = filepath.Dir(listing.Path)
+	}
+	for i := range listing.Files {
+		listing.Files[i].Path = relativeToVolume(root, listing.Files[i].Path)
+		if target := listing.Files[i].LinkTarget; filepath.IsAbs(target) && pathWithin(target, root) {
+			listing.Files[i].LinkTarget = relativeToVolume(root, target)
+		}
+	}
+	return c.JSON(http.StatusOK, listing)
+}
+
+// downloadVolumeFileHandler handles GET /api/volumes/:name/files/download?path=
+func downloadVolumeFileHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	_, path, release := openVolumePath(ctx, c, c.QueryParam("path"), true)
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.file_not_found"),
+		})
+	}
+	if info.IsDir() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.download_directory"),
+		})
+	}
+
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filepath.Base(path)))
+	c.Response().Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	return c.File(path)
+}
+
+// uploadVolumeFileHandler handles POST /api/volumes/:name/files/upload, a
+// multipart form with the target directory in path and the file in file
+func uploadVolumeFileHandler(c echo.Context) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.no_file_uploaded"),
+		})
+	}
+	if file.Size > maxUploadSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.file_too_large", "max", strconv.Itoa(maxUploadSize/(1024*1024))),
+		})
+	}
+	name := filepath.Base(file.Filename)
+	if name == "." || name == "/" || name == ".." {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_file_name"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Minute)
+	defer cancel()
+
+	root, dir, release := openVolumePath(ctx, c, c.FormValue("path"), true)
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	dest := filepath.Join(dir, name)
+	// Writing through a symlink would land wherever it points
+	if info, err := os.Lstat(dest); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.symlink_exists"),
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to read uploaded file",
+		})
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.create_file", "error", strings.ReplaceAll(err.Error(), root, "")),
+		})
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dest)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "failed to save file",
+		})
+	}
+	dst.Close()
+	matchParentOwner(dest)
+
+	rel := relativeToVolume(root, dest)
+	Audit.LogFromContext(c, models.ActionVolumeFileUpload, c.Param("name")+":"+rel, map[string]interface{}{
+		"size": file.Size,
+	})
+
+	info, _ := system.GetFileInfo(dest)
+	if info != nil {
+		info.Path = rel
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "file uploaded successfully",
+		"file":    info,
+	})
+}
+
+// createVolumeDirectoryHandler handles POST /api/volumes/:name/files/mkdir
+func createVolumeDirectoryHandler(c echo.Context) error {
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := c.Bind(&req); err != nil || filepath.Clean("/"+req.Path) == "/" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.path_required"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	root, path, release := openVolumePath(ctx, c, req.Path, false)
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	if err := os.Mkdir(path, 0755); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": strings.ReplaceAll(err.Error(), root, ""),
+		})
+	}
+	matchParentOwner(path)
+
+	Audit.LogFromContext(c, models.ActionVolumeMkdir, c.Param("name")+":"+relativeToVolume(root, path), nil)
+	return c.JSON(http.StatusCreated, map[string]string{
+		"message": "directory created successfully",
+	})
+}
+
+// deleteVolumeFileHandler handles DELETE /api/volumes/:name/files?path=. A
+// symlink is removed itself, not what it points at.
+func deleteVolumeFileHandler(c echo.Context) error {
+	if filepath.Clean("/"+c.QueryParam("path")) == "/" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.delete_volume_root"),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+
+	root, path, release := openVolumePath(ctx, c, c.QueryParam("path"), false)
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": tr(c, "error.file_not_found"),
+		})
+	}
+	if info.IsDir() && c.QueryParam("recursive") == "true" {
+		err = os.RemoveAll(path)
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": strings.ReplaceAll(err.Error(), root, ""),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionVolumeFileDelete, c.Param("name")+":"+relativeToVolume(root, path), nil)
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "deleted successfully",
+	})
+}
+
+// exportVolumeHandler handles GET /api/volumes/:name/export, streaming the
+// whole volume as a gzipped tarball with ownership kept
+func exportVolumeHandler(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Hour)
+	defer cancel()
+
+	name := c.Param("name")
+	root, _, release := openVolumePath(ctx, c, "/", false)
+	if release == nil {
+		return nil
+	}
+	defer release()
+
+	Audit.LogFromContext(c, models.ActionVolumeExport, name, nil)
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/gzip")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", archiveName(name, ".tar.gz")))
+	c.Response().WriteHeader(http.StatusOK)
+	if err := system.StreamArchive(ctx, root, c.Response()); err != nil {
+		// The headers are gone; all that's left is to cut the download short
+		c.Logger().Error("volume export error: ", err)
+		return err
+	}
+	return nil
+}
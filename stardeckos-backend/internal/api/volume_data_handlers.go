@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// previewMaxEntries caps how many top-level entries a data preview lists
+const previewMaxEntries = 50
+
+// bindMountProtectedPrefixes are host trees a bind mount directory may never be deleted from
+var bindMountProtectedPrefixes = []string{"/bin", "/boot", "/dev", "/etc", "/lib", "/lib64", "/proc", "/sbin", "/sys", "/usr", "/var/lib/stardeck"}
+
+// backupBasePath returns the directory container, volume and bind mount backups are written to
+func backupBasePath() string {
+	backupPath := os.Getenv("STARDECK_BACKUP_PATH")
+	if backupPath == "" {
+		homeDir, _ := os.UserHomeDir()
+		backupPath = filepath.Join(homeDir, ".stardeck", "backups")
+	}
+	return backupPath
+}
+
+// archiveName builds a timestamped archive file name from a volume name or host path
+func archiveName(name, ext string) string {
+	safe := strings.Trim(strings.ReplaceAll(filepath.Clean(name), "/", "_"), "_")
+	return fmt.Sprintf("%s_%s%s", safe, time.Now().Format("20060102-150405"), ext)
+}
+
+// volumeUsers returns the names of containers that mount a volume
+func volumeUsers(ctx context.Context, volume *models.Volume) []string {
+	users := []string{}
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return users
+	}
+	for _, container := range containers {
+		inspect, err := podmanService.InspectContainer(ctx, container.ContainerID)
+		if err != nil {
+			continue
+		}
+		for _, mount := range inspect.Mounts {
+			if mount.Type == "volume" && mount.Source == volume.MountPoint {
+				users = append(users, container.Name)
+				break
+			}
+		}
+	}
+	return users
+}
+
+// bindMountUsers returns the names of containers whose bind mounts are at,
+// inside or above the host path
+func bindMountUsers(ctx context.Context, path string) ([]string, error) {
+	mounts, err := collectBindMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users := []string{}
+	seen := make(map[string]bool)
+	for _, mount := range mounts {
+		source := filepath.Clean(mount.HostPath)
+		if !pathWithin(source, path) && !pathWithin(path, source) {
+			continue
+		}
+		if !seen[mount.ContainerName] {
+			seen[mount.ContainerName] = true
+			users = append(users, mount.ContainerName)
+		}
+	}
+	return users, nil
+}
+
+// pathWithin reports whether path is base or lies under it
+func pathWithin(path, base string) bool {
+	return path == base || strings.HasPrefix(path, strings.TrimSuffix(base, "/")+"/")
+}
+
+// validateBindMountPath cleans a host path and rejects anything that is not a
+// deletable data directory
+func validateBindMountPath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path must be absolute")
+	}
+
+	clean := filepath.Clean(path)
+	if strings.Count(clean, "/") < 2 {
+		return "", fmt.Errorf("refusing to operate on top-level directory %s", clean)
+	}
+	for _, prefix := range bindMountProtectedPrefixes {
+		if pathWithin(clean, prefix) {
+			return "", fmt.Errorf("refusing to operate on protected path %s", clean)
+		}
+	}
+
+	info, err := os.Stat(clean)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", clean)
+	}
+	return clean, nil
+}
+
+// archiveVolume saves a volume's data under the backup path and returns the archive
+func archiveVolume(ctx context.Context, volume *models.Volume) (string, error) {
+	dir := filepath.Join(backupBasePath(), "volumes")
+
+	// Local volumes are read straight from disk so ownership survives; other drivers go through podman
+	if volume.Driver == "local" && volume.MountPoint != "" {
+		dest := filepath.Join(dir, archiveName(volume.Name, ".tar.gz"))
+		if err := system.ArchiveDirectory(ctx, volume.MountPoint, dest); err == nil {
+			return dest, nil
+		}
+	}
+
+	dest := filepath.Join(dir, archiveName(volume.Name, ".tar"))
+	if err := podmanService.ExportVolume(ctx, volume.Name, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// getVolumePreviewHandler reports a volume's size, top-level contents and users before deletion
+func getVolumePreviewHandler(c echo.Context) error {
+	name := c.Param("name")
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+	defer cancel()
+
+	volume, err := podmanService.InspectVolume(ctx, name)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Volume not found",
+		})
+	}
+
+	preview, err := system.PreviewDirectory(volume.MountPoint, previewMaxEntries)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read volume data: " + err.Error(),
+		})
+	}
+	preview.Name = volume.Name
+	preview.UsedBy = volumeUsers(ctx, volume)
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// getBindMountPreviewHandler reports a bind mount directory's size, top-level contents and users
+func getBindMountPreviewHandler(c echo.Context) error {
+	path, err := validateBindMountPath(c.QueryParam("path"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 2*time.Minute)
+	defer cancel()
+
+	preview, err := system.PreviewDirectory(path, previewMaxEntries)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read directory: " + err.Error(),
+		})
+	}
+	preview.Name = path
+
+	users, err := bindMountUsers(ctx, path)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list bind mounts: " + err.Error(),
+		})
+	}
+	preview.UsedBy = users
+
+	return c.JSON(http.StatusOK, preview)
+}
+
+// removeBindMountHandler deletes a bind mount directory that no container uses,
+// optionally archiving it to the backup path first
+func removeBindMountHandler(c echo.Context) error {
+	path, err := validateBindMountPath(c.QueryParam("path"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Minute)
+	defer cancel()
+
+	users, err := bindMountUsers(ctx, path)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list bind mounts: " + err.Error(),
+		})
+	}
+	if len(users) > 0 {
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error":   "Directory is still mounted by containers",
+			"used_by": users,
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	result := map[string]interface{}{}
+
+	if c.QueryParam("archive") == "true" {
+		archivePath := filepath.Join(backupBasePath(), "bind-mounts", archiveName(path, ".tar.gz"))
+		if err := system.ArchiveDirectory(ctx, path, archivePath); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to archive directory: " + err.Error(),
+			})
+		}
+		result["archive_path"] = archivePath
+	}
+
+	if err := system.DeletePath(path, true); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to remove directory: " + err.Error(),
+		})
+	}
+
+	// The archive makes the deletion restorable from the recycle bin
+	if archivePath, ok := result["archive_path"].(string); ok && c.QueryParam("permanent") != "true" && trashEnabled() {
+		item, err := trashData(user, models.TrashItemBindMount, path, &models.TrashedData{Path: path, ArchivePath: archivePath})
+		if err != nil {
+			c.Logger().Warnf("Failed to record %s in trash: %v", path, err)
+		} else {
+			result["trash_id"] = item.ID
+		}
+	}
+
+	logAudit(user, models.ActionBindMountRemove, path, map[string]interface{}{
+		"archive_path": result["archive_path"],
+	})
+
+	result["status"] = "removed"
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+const (
+	volumeUsageCacheTTL       = 15 * time.Minute
+	volumeUsageSampleInterval = time.Hour
+	volumeUsageGrowthWindow   = 7 * 24 * time.Hour
+	volumeUsageMinHistory     = 24 * time.Hour // Less is too noisy to extrapolate
+	volumeUsageRetention      = 30 * 24 * time.Hour
+	defaultVolumeUsageWarn    = 30 // Days
+)
+
+var volumeUsageRepo *database.VolumeUsageRepo
+
+// measuredUsage is a cached du result for a host path
+type measuredUsage struct {
+	size       int64
+	measuredAt time.Time
+	err        error
+}
+
+var volumeUsageCache = struct {
+	sync.Mutex
+	sizes map[string]measuredUsage
+}{sizes: map[string]measuredUsage{}}
+
+// volumeUsageMeasureMu keeps concurrent requests and the sampler from
+// walking the same trees at once
+var volumeUsageMeasureMu sync.Mutex
+
+// InitVolumeUsage initializes the volume usage repository and starts the
+// hourly sampler that builds the history growth rates are worked out from
+func InitVolumeUsage() {
+	volumeUsageRepo = database.NewVolumeUsageRepo()
+	go runVolumeUsageSampler()
+}
+
+func runVolumeUsageSampler() {
+	for {
+		time.Sleep(volumeUsageSampleInterval)
+		if maintenanceModeActive() {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+		if _, err := collectAppStorage(ctx, nil, true); err != nil {
+			log.Printf("Warning: volume usage sampling failed: %v", err)
+		}
+		cancel()
+	}
+}
+
+// measureMount returns a path's disk usage, from the cache unless it is
+// stale or refresh is set. Fresh measurements are recorded as samples.
+func measureMount(ctx context.Context, path string, refresh bool) measuredUsage {
+	volumeUsageCache.Lock()
+	cached, ok := volumeUsageCache.sizes[path]
+	volumeUsageCache.Unlock()
+	if ok && !refresh && time.Since(cached.measuredAt) < volumeUsageCacheTTL {
+		return cached
+	}
+
+	size, err := system.DiskUsage(ctx, path)
+	m := measuredUsage{size: size, measuredAt: time.Now(), err: err}
+	if err != nil && ok {
+		// Keep showing the last good size, but say why it wasn't updated
+		m.size, m.measuredAt = cached.size, cached.measuredAt
+	}
+	if err == nil {
+		sample := &models.VolumeUsageSample{HostPath: path, SizeBytes: size, Timestamp: m.measuredAt}
+		if rerr := volumeUsageRepo.Record(sample, volumeUsageSampleInterval-time.Minute); rerr != nil {
+			log.Printf("Warning: failed to record volume usage of %s: %v", path, rerr)
+		}
+	}
+
+	volumeUsageCache.Lock()
+	volumeUsageCache.sizes[path] = m
+	volumeUsageCache.Unlock()
+	return m
+}
+
+// growthPerDay extrapolates a mount's growth from its oldest sample in the
+// window to the current size. It is 0 until there is enough history.
+func growthPerDay(samples []models.VolumeUsageSample, size int64, at time.Time) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	first := samples[0]
+	span := at.Sub(first.Timestamp)
+	if span < volumeUsageMinHistory {
+		return 0
+	}
+	return float64(size-first.SizeBytes) / span.Hours() * 24
+}
+
+// collectAppStorage sizes every volume and bind mount of the containers the
+// view shows, grouped by app. Containers in a compose stack form one app;
+// any other container is an app of its own.
+func collectAppStorage(ctx context.Context, view *projectView, refresh bool) ([]models.AppStorageUsage, error) {
+	containers, err := podmanService.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	history, err := volumeUsageRepo.Since(time.Now().Add(-volumeUsageGrowthWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	volumeUsageMeasureMu.Lock()
+	defer volumeUsageMeasureMu.Unlock()
+
+	apps := map[string]*models.AppStorageUsage{}
+	var order []string
+	appsByPath := map[string]map[string]bool{}
+	for _, container := range containers {
+		if !view.visible(view.containerProject(container.ID, container.Stack)) {
+			continue
+		}
+		inspect, err := podmanService.InspectContainer(ctx, container.ContainerID)
+		if err != nil {
+			continue // Skip containers that can't be inspected
+		}
+
+		key := container.Name
+		if container.Stack != "" {
+			key = "stack:" + container.Stack
+		}
+		app, ok := apps[key]
+		if !ok {
+			app = &models.AppStorageUsage{App: container.Name, Containers: []string{}, Mounts: []models.MountUsage{}}
+			if container.Stack != "" {
+				app.App, app.Stack = container.Stack, true
+			}
+			apps[key] = app
+			order = append(order, key)
+		}
+		app.Containers = append(app.Containers, container.Name)
+
+	mounts:
+		for _, mount := range inspect.Mounts {
+			if (mount.Type != "volume" && mount.Type != "bind") || mount.Source == "" {
+				continue
+			}
+			if appsByPath[mount.Source] == nil {
+				appsByPath[mount.Source] = map[string]bool{}
+			}
+			appsByPath[mount.Source][key] = true
+
+			// Containers of one app often share a mount
+			for i := range app.Mounts {
+				if app.Mounts[i].HostPath == mount.Source {
+					app.Mounts[i].Containers = append(app.Mounts[i].Containers, container.Name)
+					continue mounts
+				}
+			}
+			usage := models.MountUsage{Type: mount.Type, HostPath: mount.Source, Containers: []string{container.Name}}
+			if mount.Type == "volume" {
+				usage.Name = mount.Name
+			}
+			app.Mounts = append(app.Mounts, usage)
+		}
+	}
+
+	result := make([]models.AppStorageUsage, 0, len(order))
+	for _, key := range order {
+		app := apps[key]
+		for i := range app.Mounts {
+			mount := &app.Mounts[i]
+			m := measureMount(ctx, mount.HostPath, refresh)
+			mount.SizeBytes, mount.MeasuredAt = m.size, m.measuredAt
+			if m.err != nil {
+				mount.Error = m.err.Error()
+			}
+			mount.Shared = len(appsByPath[mount.HostPath]) > 1
+			mount.GrowthBytesPerDay = growthPerDay(history[mount.HostPath], m.size, m.measuredAt)
+			mount.FreeBytes, _ = system.FreeSpace(mount.HostPath)
+			if mount.GrowthBytesPerDay > 0 {
+				days := float64(mount.FreeBytes) / mount.GrowthBytesPerDay
+				mount.DaysUntilFull = &days
+				if app.DaysUntilFull == nil || days < *app.DaysUntilFull {
+					app.DaysUntilFull = &days
+				}
+			}
+			app.TotalBytes += mount.SizeBytes
+			app.GrowthBytesPerDay += mount.GrowthBytesPerDay
+		}
+		result = append(result, *app)
+	}
+	return result, nil
+}
+
+// listStorageUsageHandler handles GET /api/containers/storage-usage, reporting
+// the disk each app's volumes and bind mounts use and warning about apps
+// whose data will fill its disk within warn_days (default 30) at the rate it
+// has grown over the last week. Sizes are cached for 15 minutes unless
+// refresh=true.
+func listStorageUsageHandler(c echo.Context) error {
+	warnDays := defaultVolumeUsageWarn
+	if v := c.QueryParam("warn_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 3650 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "warn_days must be between 1 and 3650",
+			})
+		}
+		warnDays = n
+	}
+
+	view, err := loadProjectView(c.Get("user").(*models.User))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load projects: " + err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 5*time.Minute)
+	defer cancel()
+	apps, err := collectAppStorage(ctx, view, c.QueryParam("refresh") == "true")
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.list_containers", "error", err.Error()),
+		})
+	}
+
+	report := models.StorageUsageReport{Apps: apps, WarnDays: warnDays}
+	counted := map[string]bool{}
+	for i := range apps {
+		if d := apps[i].DaysUntilFull; d != nil && *d < float64(warnDays) {
+			apps[i].Warning = true
+			report.Warnings++
+		}
+		for _, mount := range apps[i].Mounts {
+			if !counted[mount.HostPath] {
+				counted[mount.HostPath] = true
+				report.TotalBytes += mount.SizeBytes
+			}
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].TotalBytes > apps[j].TotalBytes })
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// pruneVolumeUsageSamples drops samples older than the growth history needs
+func pruneVolumeUsageSamples() (int64, error) {
+	return volumeUsageRepo.DeleteOlderThan(time.Now().Add(-volumeUsageRetention))
+}
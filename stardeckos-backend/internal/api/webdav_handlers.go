@@ -0,0 +1,396 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/webdav"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+	"stardeckos-backend/internal/system"
+)
+
+// webdavPrefix is where shares are mounted; clients connect to <prefix>/<share name>/
+const webdavPrefix = "/api/dav"
+
+// webdavMethods are the HTTP methods a WebDAV client may use
+var webdavMethods = []string{
+	http.MethodOptions, http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete,
+	"PROPFIND", "PROPPATCH", "MKCOL", "COPY", "MOVE", "LOCK", "UNLOCK",
+}
+
+// webdavWriteMethods modify the share and are refused on read-only shares and for viewers
+var webdavWriteMethods = map[string]bool{
+	http.MethodPut: true, http.MethodDelete: true,
+	"PROPPATCH": true, "MKCOL": true, "COPY": true, "MOVE": true, "LOCK": true, "UNLOCK": true,
+}
+
+// webdavShareNamePattern restricts share names to a safe URL segment
+var webdavShareNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// webdavDeniedPaths are host directories that may never be shared, even if bind-mounted
+var webdavDeniedPaths = []string{"/", "/etc", "/root", "/proc", "/sys", "/dev", "/boot", "/usr", "/bin", "/sbin", "/lib", "/lib64", "/var/lib/stardeck"}
+
+var webdavShareRepo *database.WebDAVShareRepo
+
+// webdavHandlers caches one handler (and lock system) per share ID
+var (
+	webdavHandlers   = make(map[string]*webdav.Handler)
+	webdavHandlersMu sync.Mutex
+)
+
+// InitWebDAV initializes the WebDAV share repository
+func InitWebDAV() {
+	webdavShareRepo = database.NewWebDAVShareRepo()
+}
+
+// roleRank orders roles so shares can require a minimum role
+func roleRank(user *models.User) int {
+	if user.IsAdmin() {
+		return 3
+	}
+	switch user.Role {
+	case models.RoleOperator:
+		return 2
+	case models.RoleViewer:
+		return 1
+	}
+	return 0
+}
+
+func minRoleRank(role models.Role) int {
+	switch role {
+	case models.RoleAdmin:
+		return 3
+	case models.RoleOperator:
+		return 2
+	}
+	return 1
+}
+
+// webdavHandlerFor returns the cached handler for a share, creating it on first use
+func webdavHandlerFor(share *models.WebDAVShare) (*webdav.Handler, error) {
+	webdavHandlersMu.Lock()
+	defer webdavHandlersMu.Unlock()
+
+	if h, ok := webdavHandlers[share.ID]; ok {
+		return h, nil
+	}
+
+	fs, err := system.NewConfinedDir(share.Path)
+	if err != nil {
+		return nil, err
+	}
+	h := &webdav.Handler{
+		Prefix:     webdavPrefix + "/" + share.Name,
+		FileSystem: fs,
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !os.IsNotExist(err) {
+				log.Printf("WebDAV %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	webdavHandlers[share.ID] = h
+	return h, nil
+}
+
+// dropWebDAVHandler discards a share's cached handler after it changes
+func dropWebDAVHandler(id string) {
+	webdavHandlersMu.Lock()
+	delete(webdavHandlers, id)
+	webdavHandlersMu.Unlock()
+}
+
+// serveWebDAVHandler serves WebDAV requests for a share to authenticated users
+func serveWebDAVHandler(c echo.Context) error {
+	if enabled, _ := settingsRepo.GetBool(database.SettingWebDAVEnabled); !enabled {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	share, err := webdavShareRepo.GetByName(c.Param("share"))
+	if err != nil || !share.Enabled {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	user := c.Get("user").(*models.User)
+	if roleRank(user) < minRoleRank(share.MinRole) {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	// Viewers only get read access, even on writable shares
+	readOnly := share.ReadOnly || roleRank(user) < minRoleRank(models.RoleOperator)
+	if webdavWriteMethods[c.Request().Method] && readOnly {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	handler, err := webdavHandlerFor(share)
+	if err != nil {
+		log.Printf("WebDAV share %s unavailable: %v", share.Name, err)
+		return c.NoContent(http.StatusServiceUnavailable)
+	}
+
+	handler.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// validateWebDAVPath checks that a path is an existing directory within a container bind mount
+func validateWebDAVPath(ctx context.Context, path string) (string, error) {
+	if path == "" || !filepath.IsAbs(path) {
+		return "", errors.New("path must be an absolute directory")
+	}
+	path = filepath.Clean(path)
+
+	for _, denied := range webdavDeniedPaths {
+		if path == denied {
+			return "", errors.New("this directory cannot be shared")
+		}
+	}
+	if strings.HasPrefix(path, "/var/lib/stardeck/") {
+		return "", errors.New("Stardeck's own data cannot be shared")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", errors.New("path must be an existing directory")
+	}
+
+	mounts, err := collectBindMounts(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list bind mounts: %w", err)
+	}
+	for _, m := range mounts {
+		source := filepath.Clean(m.HostPath)
+		if path == source || strings.HasPrefix(path, source+string(os.PathSeparator)) {
+			return path, nil
+		}
+	}
+	return "", errors.New("path must be a container bind mount or a directory inside one")
+}
+
+func validMinRole(role models.Role) bool {
+	return role == models.RoleViewer || role == models.RoleOperator || role == models.RoleAdmin
+}
+
+func withWebDAVURL(share *models.WebDAVShare) {
+	share.URL = webdavPrefix + "/" + share.Name + "/"
+}
+
+// listWebDAVSharesHandler returns all WebDAV shares and whether WebDAV is enabled
+func listWebDAVSharesHandler(c echo.Context) error {
+	shares, err := webdavShareRepo.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to list shares: " + err.Error(),
+		})
+	}
+
+	if shares == nil {
+		shares = []models.WebDAVShare{}
+	}
+	for i := range shares {
+		withWebDAVURL(&shares[i])
+	}
+
+	enabled, _ := settingsRepo.GetBool(database.SettingWebDAVEnabled)
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"enabled": enabled,
+		"shares":  shares,
+	})
+}
+
+// createWebDAVShareHandler shares a bind-mount directory over WebDAV
+func createWebDAVShareHandler(c echo.Context) error {
+	var req models.CreateWebDAVShareRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if !webdavShareNamePattern.MatchString(req.Name) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "name must be lowercase letters, digits, '-' or '_'",
+		})
+	}
+	if req.MinRole == "" {
+		req.MinRole = models.RoleOperator
+	}
+	if !validMinRole(req.MinRole) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "min_role must be viewer, operator, or admin",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	path, err := validateWebDAVPath(ctx, req.Path)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if existing, _ := webdavShareRepo.GetByName(req.Name); existing != nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "A share with this name already exists",
+		})
+	}
+
+	user := c.Get("user").(*models.User)
+	share := &models.WebDAVShare{
+		Name:        req.Name,
+		Path:        path,
+		Description: req.Description,
+		ReadOnly:    req.ReadOnly,
+		MinRole:     req.MinRole,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreatedBy:   &user.ID,
+	}
+
+	if err := webdavShareRepo.Create(share); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create share: " + err.Error(),
+		})
+	}
+
+	logAudit(user, models.ActionWebDAVShareCreate, share.Name, map[string]interface{}{
+		"path":      share.Path,
+		"read_only": share.ReadOnly,
+		"min_role":  share.MinRole,
+	})
+
+	withWebDAVURL(share)
+	return c.JSON(http.StatusCreated, share)
+}
+
+// updateWebDAVShareHandler updates a share's access settings
+func updateWebDAVShareHandler(c echo.Context) error {
+	share, err := webdavShareRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Share not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get share: " + err.Error(),
+		})
+	}
+
+	var req models.UpdateWebDAVShareRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	if req.Description != nil {
+		share.Description = *req.Description
+	}
+	if req.ReadOnly != nil {
+		share.ReadOnly = *req.ReadOnly
+	}
+	if req.MinRole != nil {
+		if !validMinRole(*req.MinRole) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "min_role must be viewer, operator, or admin",
+			})
+		}
+		share.MinRole = *req.MinRole
+	}
+	if req.Enabled != nil {
+		share.Enabled = *req.Enabled
+	}
+
+	if err := webdavShareRepo.Update(share); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update share: " + err.Error(),
+		})
+	}
+	dropWebDAVHandler(share.ID)
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionWebDAVShareUpdate, share.Name, map[string]interface{}{
+		"read_only": share.ReadOnly,
+		"min_role":  share.MinRole,
+		"enabled":   share.Enabled,
+	})
+
+	withWebDAVURL(share)
+	return c.JSON(http.StatusOK, share)
+}
+
+// deleteWebDAVShareHandler stops sharing a directory (files are left untouched)
+func deleteWebDAVShareHandler(c echo.Context) error {
+	share, err := webdavShareRepo.GetByID(c.Param("id"))
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Share not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get share: " + err.Error(),
+		})
+	}
+
+	if err := webdavShareRepo.Delete(share.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete share: " + err.Error(),
+		})
+	}
+	dropWebDAVHandler(share.ID)
+
+	user := c.Get("user").(*models.User)
+	logAudit(user, models.ActionWebDAVShareDelete, share.Name, map[string]interface{}{
+		"path": share.Path,
+	})
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "deleted",
+	})
+}
+
+// setWebDAVEnabledHandler turns the WebDAV endpoint on or off globally
+func setWebDAVEnabledHandler(c echo.Context) error {
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tr(c, "error.invalid_request", "error", err.Error()),
+		})
+	}
+
+	value := "false"
+	if req.Enabled {
+		value = "true"
+	}
+	if err := settingsRepo.Set(database.SettingWebDAVEnabled, value); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": tr(c, "error.save_settings", "error", err.Error()),
+		})
+	}
+
+	Audit.LogFromContext(c, models.ActionWebDAVShareUpdate, "webdav", map[string]interface{}{
+		"enabled": req.Enabled,
+	})
+
+	return c.JSON(http.StatusOK, map[string]bool{
+		"enabled": req.Enabled,
+	})
+}
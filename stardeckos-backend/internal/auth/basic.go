@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+)
+
+// basicAuthCacheTTL is how long verified Basic credentials are trusted before re-checking
+// WebDAV clients send credentials on every request, and PAM/argon2 checks are slow
+const basicAuthCacheTTL = 5 * time.Minute
+
+type basicAuthEntry struct {
+	user    *models.User
+	expires time.Time
+}
+
+var (
+	basicAuthCache   = make(map[string]basicAuthEntry)
+	basicAuthCacheMu sync.Mutex
+)
+
+// RequireBasicOrToken authenticates with a session token or HTTP Basic credentials,
+// for clients such as native WebDAV mounts that cannot perform the login flow
+func RequireBasicOrToken(authSvc *Service, realm string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token := getTokenFromRequest(c); token != "" {
+				if user, session, err := authSvc.ValidateToken(token); err == nil {
+					if refused, err := authSvc.RefuseReadOnly(c, user, session); refused {
+						return err
+					}
+					c.Set(ContextKeyUser, user)
+					c.Set(ContextKeySession, session)
+					return next(c)
+				}
+			}
+
+			username, password, ok := c.Request().BasicAuth()
+			if ok && username != "" {
+				if user := authenticateBasic(authSvc, username, password); user != nil {
+					if refused, err := authSvc.RefuseReadOnly(c, user, nil); refused {
+						return err
+					}
+					c.Set(ContextKeyUser, user)
+					return next(c)
+				}
+				// Slow down password guessing
+				time.Sleep(time.Second)
+			}
+
+			c.Response().Header().Set("WWW-Authenticate", `Basic realm="`+realm+`", charset="UTF-8"`)
+			return c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "authentication required",
+			})
+		}
+	}
+}
+
+// authenticateBasic verifies Basic credentials, using a short-lived cache of successful checks
+func authenticateBasic(authSvc *Service, username, password string) *models.User {
+	sum := sha256.Sum256([]byte(username + "\x00" + password))
+	key := hex.EncodeToString(sum[:])
+
+	basicAuthCacheMu.Lock()
+	entry, ok := basicAuthCache[key]
+	basicAuthCacheMu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.user
+	}
+
+	user, err := authSvc.Authenticate(username, password, "")
+	if err != nil {
+		return nil
+	}
+	// A password alone isn't enough for an account with a second factor
+	if authSvc.TwoFactorEnabled(user) {
+		return nil
+	}
+
+	basicAuthCacheMu.Lock()
+	for k, e := range basicAuthCache {
+		if time.Now().After(e.expires) {
+			delete(basicAuthCache, k)
+		}
+	}
+	basicAuthCache[key] = basicAuthEntry{user: user, expires: time.Now().Add(basicAuthCacheTTL)}
+	basicAuthCacheMu.Unlock()
+
+	return user
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/certs"
+	"stardeckos-backend/internal/models"
+)
+
+// ContextKeyClientCert holds the certificate a request authenticated with
+const ContextKeyClientCert = "client_cert"
+
+// clientCertTouchInterval limits how often last_used_at is written for busy automation
+const clientCertTouchInterval = time.Minute
+
+var ErrClientCertRevoked = errors.New("client certificate has been revoked")
+
+// AuthenticateClientCert maps a client certificate the TLS layer verified
+// against the internal CA to the user it was issued for
+func (s *Service) AuthenticateClientCert(cert *x509.Certificate) (*models.User, *models.ClientCertificate, error) {
+	record, err := s.clientCertRepo.GetBySerial(certs.SerialHex(cert))
+	if err != nil || record.CommonName != cert.Subject.CommonName {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if record.RevokedAt != nil {
+		return nil, nil, ErrClientCertRevoked
+	}
+
+	user, err := s.userRepo.GetByID(record.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, nil, ErrUserDisabled
+	}
+	if user.AuthType == models.AuthTypePAM {
+		user.IsPAMAdmin = s.pamAuth.IsAdmin(user.Username)
+	}
+	s.loadPermissions(user)
+
+	if record.LastUsedAt == nil || time.Since(*record.LastUsedAt) > clientCertTouchInterval {
+		s.clientCertRepo.TouchLastUsed(record.ID)
+	}
+	return user, record, nil
+}
+
+// VerifiedClientCert returns the leaf client certificate of a request when the
+// TLS handshake verified it, or nil
+func VerifiedClientCert(c echo.Context) *x509.Certificate {
+	state := c.Request().TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// authenticateClientCert authenticates a request without a session token by
+// its client certificate
+func authenticateClientCert(c echo.Context, authSvc *Service, cert *x509.Certificate, next echo.HandlerFunc) error {
+	user, record, err := authSvc.AuthenticateClientCert(cert)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "client certificate rejected: " + err.Error(),
+			"code":  "client_cert_rejected",
+		})
+	}
+
+	// A read-only certificate gets what a viewer gets, whatever the user's role
+	readOnly := user.IsReadOnly() || record.Scope == models.ClientCertScopeReadOnly
+	if readOnly && !ViewerAllowed(c) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "client certificate is read-only",
+		})
+	}
+	if authSvc.readOnlyModeBlocks(c, user) {
+		return authSvc.readOnlyModeError(c)
+	}
+	if perm := missingPermission(c, user); perm != "" {
+		return permissionError(c, perm)
+	}
+
+	c.Set(ContextKeyUser, user)
+	c.Set(ContextKeyClientCert, record)
+	return next(c)
+}
@@ -0,0 +1,388 @@
+package auth
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kerberosClockSkew is the tolerated difference between client and server clocks
+const kerberosClockSkew = 5 * time.Minute
+
+var (
+	oidSPNEGO     = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 2}
+	oidKerberos5  = asn1.ObjectIdentifier{1, 2, 840, 113554, 1, 2, 2}
+	oidMSKerberos = asn1.ObjectIdentifier{1, 2, 840, 48018, 1, 2, 2} // Sent by Windows clients
+)
+
+var errNTLMUnsupported = errors.New("NTLM is not supported; the client did not obtain a Kerberos ticket")
+
+// KerberosIdentity is the client principal proven by a Negotiate token
+type KerberosIdentity struct {
+	Name  string // Principal name without the realm, e.g. jdoe
+	Realm string // e.g. EXAMPLE.COM
+}
+
+// Principal returns name@REALM
+func (k *KerberosIdentity) Principal() string {
+	return k.Name + "@" + k.Realm
+}
+
+// KeytabEntry is one key from a keytab file
+type KeytabEntry struct {
+	Principal string `json:"principal"` // Components joined by "/", e.g. HTTP/host.example.com
+	Realm     string `json:"realm"`
+	KVNO      uint32 `json:"kvno"`
+	EType     int32  `json:"etype"`
+	key       []byte
+}
+
+// ReadKeytab parses an MIT keytab file (format version 0x502)
+func ReadKeytab(path string) ([]KeytabEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[0] != 0x05 || data[1] != 0x02 {
+		return nil, errors.New("unsupported keytab format")
+	}
+
+	var entries []KeytabEntry
+	buf := data[2:]
+	for len(buf) >= 4 {
+		size := int32(binary.BigEndian.Uint32(buf))
+		buf = buf[4:]
+		if size < 0 {
+			// A hole left by a deleted entry
+			if int(-size) > len(buf) {
+				break
+			}
+			buf = buf[-size:]
+			continue
+		}
+		if int(size) > len(buf) {
+			return nil, errors.New("truncated keytab entry")
+		}
+		entry, err := parseKeytabEntry(buf[:size])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+		buf = buf[size:]
+	}
+	return entries, nil
+}
+
+// parseKeytabEntry decodes a single keytab record
+func parseKeytabEntry(rec []byte) (*KeytabEntry, error) {
+	r := &keytabReader{buf: rec}
+	components := int(r.uint16())
+	realm := r.string()
+	names := make([]string, components)
+	for i := range names {
+		names[i] = r.string()
+	}
+	r.uint32() // name type
+	r.uint32() // timestamp
+	kvno := uint32(r.uint8())
+	etype := int32(r.uint16())
+	key := r.bytes()
+	// Newer keytabs carry a 32-bit kvno after the key
+	if len(r.buf) >= 4 {
+		if v := r.uint32(); v != 0 {
+			kvno = v
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &KeytabEntry{
+		Principal: strings.Join(names, "/"),
+		Realm:     realm,
+		KVNO:      kvno,
+		EType:     etype,
+		key:       key,
+	}, nil
+}
+
+// keytabReader reads big-endian keytab fields, recording the first short read
+type keytabReader struct {
+	buf []byte
+	err error
+}
+
+func (r *keytabReader) take(n int) []byte {
+	if r.err != nil || len(r.buf) < n {
+		r.err = errors.New("truncated keytab entry")
+		return make([]byte, n)
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *keytabReader) uint8() uint8   { return r.take(1)[0] }
+func (r *keytabReader) uint16() uint16 { return binary.BigEndian.Uint16(r.take(2)) }
+func (r *keytabReader) uint32() uint32 { return binary.BigEndian.Uint32(r.take(4)) }
+func (r *keytabReader) bytes() []byte  { return r.take(int(r.uint16())) }
+func (r *keytabReader) string() string { return string(r.bytes()) }
+
+// ASN.1 structures from RFC 4120 and RFC 4178, limited to the fields needed to
+// validate an AP-REQ
+
+type krbPrincipalName struct {
+	NameType   int32    `asn1:"explicit,tag:0"`
+	NameString []string `asn1:"explicit,tag:1"`
+}
+
+type krbEncryptedData struct {
+	EType  int32  `asn1:"explicit,tag:0"`
+	KVNO   int64  `asn1:"optional,explicit,tag:1"`
+	Cipher []byte `asn1:"explicit,tag:2"`
+}
+
+type krbEncryptionKey struct {
+	KeyType  int32  `asn1:"explicit,tag:0"`
+	KeyValue []byte `asn1:"explicit,tag:1"`
+}
+
+type krbAPReq struct {
+	PVNO          int              `asn1:"explicit,tag:0"`
+	MsgType       int              `asn1:"explicit,tag:1"`
+	APOptions     asn1.BitString   `asn1:"explicit,tag:2"`
+	Ticket        asn1.RawValue    `asn1:"explicit,tag:3"`
+	Authenticator krbEncryptedData `asn1:"explicit,tag:4"`
+}
+
+type krbTicket struct {
+	TktVNO  int              `asn1:"explicit,tag:0"`
+	Realm   string           `asn1:"explicit,tag:1"`
+	SName   krbPrincipalName `asn1:"explicit,tag:2"`
+	EncPart krbEncryptedData `asn1:"explicit,tag:3"`
+}
+
+type krbEncTicketPart struct {
+	Flags     asn1.BitString   `asn1:"explicit,tag:0"`
+	Key       krbEncryptionKey `asn1:"explicit,tag:1"`
+	CRealm    string           `asn1:"explicit,tag:2"`
+	CName     krbPrincipalName `asn1:"explicit,tag:3"`
+	Transited asn1.RawValue    `asn1:"explicit,tag:4"`
+	AuthTime  time.Time        `asn1:"generalized,explicit,tag:5"`
+	StartTime time.Time        `asn1:"generalized,optional,explicit,tag:6"`
+	EndTime   time.Time        `asn1:"generalized,explicit,tag:7"`
+}
+
+type krbAuthenticator struct {
+	AVNO   int              `asn1:"explicit,tag:0"`
+	CRealm string           `asn1:"explicit,tag:1"`
+	CName  krbPrincipalName `asn1:"explicit,tag:2"`
+	Cksum  asn1.RawValue    `asn1:"optional,explicit,tag:3"`
+	CUSec  int              `asn1:"explicit,tag:4"`
+	CTime  time.Time        `asn1:"generalized,explicit,tag:5"`
+}
+
+type negTokenInit struct {
+	MechTypes []asn1.ObjectIdentifier `asn1:"explicit,tag:0"`
+	ReqFlags  asn1.BitString          `asn1:"optional,explicit,tag:1"`
+	MechToken []byte                  `asn1:"optional,explicit,tag:2"`
+}
+
+// Key usage numbers from RFC 4120 section 7.5.1
+const (
+	keyUsageTicket        = 2
+	keyUsageAuthenticator = 11
+)
+
+// unmarshalApplication decodes a Kerberos [APPLICATION n] wrapped structure
+func unmarshalApplication(data []byte, tag int, v interface{}) error {
+	var raw asn1.RawValue
+	if rest, err := asn1.Unmarshal(data, &raw); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return errors.New("trailing data after message")
+	}
+	if raw.Class != asn1.ClassApplication || raw.Tag != tag {
+		return fmt.Errorf("expected APPLICATION %d, got class %d tag %d", tag, raw.Class, raw.Tag)
+	}
+	_, err := asn1.Unmarshal(raw.Bytes, v)
+	return err
+}
+
+// extractAPReq unwraps a SPNEGO or raw Kerberos GSS-API token down to its AP-REQ
+func extractAPReq(token []byte) ([]byte, error) {
+	if strings.HasPrefix(string(token), "NTLMSSP\x00") {
+		return nil, errNTLMUnsupported
+	}
+
+	// InitialContextToken ::= [APPLICATION 0] IMPLICIT SEQUENCE { thisMech, innerContextToken }
+	var outer asn1.RawValue
+	rest, err := asn1.Unmarshal(token, &outer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GSS-API token: %w", err)
+	}
+	if len(rest) > 0 || outer.Class != asn1.ClassApplication || outer.Tag != 0 {
+		return nil, errors.New("invalid GSS-API token")
+	}
+	var mech asn1.ObjectIdentifier
+	inner, err := asn1.Unmarshal(outer.Bytes, &mech)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GSS-API mechanism: %w", err)
+	}
+
+	switch {
+	case mech.Equal(oidSPNEGO):
+		var choice asn1.RawValue
+		if _, err := asn1.Unmarshal(inner, &choice); err != nil {
+			return nil, fmt.Errorf("invalid SPNEGO token: %w", err)
+		}
+		if choice.Class != asn1.ClassContextSpecific || choice.Tag != 0 {
+			return nil, errors.New("expected a SPNEGO NegTokenInit")
+		}
+		var init negTokenInit
+		if _, err := asn1.Unmarshal(choice.Bytes, &init); err != nil {
+			return nil, fmt.Errorf("invalid NegTokenInit: %w", err)
+		}
+		if len(init.MechToken) == 0 {
+			return nil, errors.New("SPNEGO token carries no mechanism token")
+		}
+		return extractAPReq(init.MechToken)
+
+	case mech.Equal(oidKerberos5), mech.Equal(oidMSKerberos):
+		// Two-byte TOK_ID 0x0100 marks a KRB_AP_REQ
+		if len(inner) < 2 || inner[0] != 0x01 || inner[1] != 0x00 {
+			return nil, errors.New("Kerberos token is not an AP-REQ")
+		}
+		return inner[2:], nil
+
+	default:
+		return nil, errNTLMUnsupported
+	}
+}
+
+// replayCache remembers recently seen authenticators so a captured token
+// cannot be replayed within the clock-skew window
+type replayCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+var kerberosReplays = &replayCache{seen: make(map[string]time.Time)}
+
+// check records an authenticator and reports whether it was new
+func (r *replayCache) check(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, expires := range r.seen {
+		if now.After(expires) {
+			delete(r.seen, k)
+		}
+	}
+	if _, ok := r.seen[key]; ok {
+		return false
+	}
+	r.seen[key] = now.Add(2 * kerberosClockSkew)
+	return true
+}
+
+// VerifyNegotiate validates the token from an "Authorization: Negotiate" header
+// against the service keys in a keytab and returns the authenticated client.
+// The ticket must be for service, e.g. HTTP/host.example.com, in the realm of
+// its keytab key; tickets for the host's other services are refused.
+func VerifyNegotiate(token []byte, keytab []KeytabEntry, service string, now time.Time) (*KerberosIdentity, error) {
+	raw, err := extractAPReq(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var apReq krbAPReq
+	if err := unmarshalApplication(raw, 14, &apReq); err != nil {
+		return nil, fmt.Errorf("invalid AP-REQ: %w", err)
+	}
+	if apReq.PVNO != 5 || apReq.MsgType != 14 {
+		return nil, errors.New("invalid AP-REQ")
+	}
+
+	// encoding/asn1 leaves the explicit [3] wrapper on raw values
+	var ticket krbTicket
+	if err := unmarshalApplication(apReq.Ticket.Bytes, 1, &ticket); err != nil {
+		return nil, fmt.Errorf("invalid ticket: %w", err)
+	}
+	if sname := strings.Join(ticket.SName.NameString, "/"); !strings.EqualFold(sname, service) {
+		return nil, fmt.Errorf("ticket is for %s@%s, not %s", sname, ticket.Realm, service)
+	}
+
+	// Decrypt the ticket with the matching service key
+	var encPart []byte
+	found := false
+	for _, entry := range keytab {
+		if !strings.EqualFold(entry.Principal, service) || !strings.EqualFold(entry.Realm, ticket.Realm) ||
+			entry.EType != ticket.EncPart.EType {
+			continue
+		}
+		if ticket.EncPart.KVNO != 0 && entry.KVNO != uint32(ticket.EncPart.KVNO) {
+			continue
+		}
+		found = true
+		if encPart, err = krbDecrypt(entry.EType, entry.key, keyUsageTicket, ticket.EncPart.Cipher); err == nil {
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no key in keytab for %s@%s (etype %d, kvno %d)",
+			service, ticket.Realm, ticket.EncPart.EType, ticket.EncPart.KVNO)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt ticket: %w", err)
+	}
+
+	var tkt krbEncTicketPart
+	if err := unmarshalApplication(encPart, 3, &tkt); err != nil {
+		return nil, fmt.Errorf("invalid ticket contents: %w", err)
+	}
+	start := tkt.AuthTime
+	if !tkt.StartTime.IsZero() {
+		start = tkt.StartTime
+	}
+	if now.Add(kerberosClockSkew).Before(start) {
+		return nil, errors.New("ticket is not yet valid")
+	}
+	if now.Add(-kerberosClockSkew).After(tkt.EndTime) {
+		return nil, errors.New("ticket has expired")
+	}
+
+	// The authenticator proves the client holds the ticket's session key, so
+	// it must be sealed with that key's own encryption type
+	if apReq.Authenticator.EType != tkt.Key.KeyType {
+		return nil, fmt.Errorf("authenticator etype %d does not match session key etype %d",
+			apReq.Authenticator.EType, tkt.Key.KeyType)
+	}
+	authPart, err := krbDecrypt(apReq.Authenticator.EType, tkt.Key.KeyValue, keyUsageAuthenticator, apReq.Authenticator.Cipher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt authenticator: %w", err)
+	}
+	var authenticator krbAuthenticator
+	if err := unmarshalApplication(authPart, 2, &authenticator); err != nil {
+		return nil, fmt.Errorf("invalid authenticator: %w", err)
+	}
+
+	client := strings.Join(tkt.CName.NameString, "/")
+	if !strings.EqualFold(authenticator.CRealm, tkt.CRealm) || strings.Join(authenticator.CName.NameString, "/") != client {
+		return nil, errors.New("authenticator does not match ticket")
+	}
+	skew := now.Sub(authenticator.CTime)
+	if skew > kerberosClockSkew || skew < -kerberosClockSkew {
+		return nil, errors.New("clock skew too great")
+	}
+	replayKey := fmt.Sprintf("%s@%s|%d|%d", client, tkt.CRealm, authenticator.CTime.UnixNano(), authenticator.CUSec)
+	if !kerberosReplays.check(replayKey, now) {
+		return nil, errors.New("replayed authenticator")
+	}
+
+	return &KerberosIdentity{Name: client, Realm: tkt.CRealm}, nil
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// Kerberos encryption types accepted for service tickets. RC4 and DES are not
+// supported; AD and FreeIPA issue AES tickets by default.
+const (
+	etypeAES128SHA1   = 17 // aes128-cts-hmac-sha1-96 (RFC 3962)
+	etypeAES256SHA1   = 18 // aes256-cts-hmac-sha1-96 (RFC 3962)
+	etypeAES128SHA256 = 19 // aes128-cts-hmac-sha256-128 (RFC 8009)
+	etypeAES256SHA384 = 20 // aes256-cts-hmac-sha384-192 (RFC 8009)
+)
+
+var errKrbIntegrity = errors.New("kerberos: integrity check failed")
+
+// krbDecrypt decrypts the cipher of a Kerberos EncryptedData with the given key
+// and key usage number, verifying its checksum and stripping the confounder
+func krbDecrypt(etype int32, key []byte, usage uint32, ciphertext []byte) ([]byte, error) {
+	if size := krbKeySize(etype); size != 0 && len(key) != size {
+		return nil, fmt.Errorf("kerberos: %d-byte key for encryption type %d", len(key), etype)
+	}
+	switch etype {
+	case etypeAES128SHA1, etypeAES256SHA1:
+		return decryptAESSHA1(key, usage, ciphertext)
+	case etypeAES128SHA256:
+		return decryptAESSHA2(key, usage, ciphertext, sha256.New, 16)
+	case etypeAES256SHA384:
+		return decryptAESSHA2(key, usage, ciphertext, sha512.New384, 24)
+	default:
+		return nil, fmt.Errorf("kerberos: unsupported encryption type %d", etype)
+	}
+}
+
+// krbKeySize returns the key length an encryption type uses, or 0 if unsupported
+func krbKeySize(etype int32) int {
+	switch etype {
+	case etypeAES128SHA1, etypeAES128SHA256:
+		return 16
+	case etypeAES256SHA1, etypeAES256SHA384:
+		return 32
+	}
+	return 0
+}
+
+// decryptAESSHA1 implements aes*-cts-hmac-sha1-96 decryption (RFC 3962)
+func decryptAESSHA1(key []byte, usage uint32, ciphertext []byte) ([]byte, error) {
+	const macLen = 12
+	if len(ciphertext) < aes.BlockSize+macLen {
+		return nil, errors.New("kerberos: ciphertext too short")
+	}
+
+	ke, err := deriveKeyDK(key, usageConstant(usage, 0xAA))
+	if err != nil {
+		return nil, err
+	}
+	ki, err := deriveKeyDK(key, usageConstant(usage, 0x55))
+	if err != nil {
+		return nil, err
+	}
+
+	data, mac := ciphertext[:len(ciphertext)-macLen], ciphertext[len(ciphertext)-macLen:]
+	plain, err := decryptCTS(ke, data)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha1.New, ki)
+	h.Write(plain)
+	if !hmac.Equal(h.Sum(nil)[:macLen], mac) {
+		return nil, errKrbIntegrity
+	}
+	return plain[aes.BlockSize:], nil
+}
+
+// decryptAESSHA2 implements aes*-cts-hmac-sha2 decryption (RFC 8009)
+func decryptAESSHA2(key []byte, usage uint32, ciphertext []byte, newHash func() hash.Hash, macLen int) ([]byte, error) {
+	if len(ciphertext) < aes.BlockSize+macLen {
+		return nil, errors.New("kerberos: ciphertext too short")
+	}
+
+	ke := kdfHMACSHA2(newHash, key, usageConstant(usage, 0xAA), len(key)*8)
+	ki := kdfHMACSHA2(newHash, key, usageConstant(usage, 0x55), macLen*8)
+
+	data, mac := ciphertext[:len(ciphertext)-macLen], ciphertext[len(ciphertext)-macLen:]
+
+	// The MAC covers the (all-zero) IV and the cipher text
+	h := hmac.New(newHash, ki)
+	h.Write(make([]byte, aes.BlockSize))
+	h.Write(data)
+	if !hmac.Equal(h.Sum(nil)[:macLen], mac) {
+		return nil, errKrbIntegrity
+	}
+
+	plain, err := decryptCTS(ke, data)
+	if err != nil {
+		return nil, err
+	}
+	return plain[aes.BlockSize:], nil
+}
+
+// usageConstant builds the 5-byte key derivation constant for a key usage
+func usageConstant(usage uint32, suffix byte) []byte {
+	c := make([]byte, 5)
+	binary.BigEndian.PutUint32(c, usage)
+	c[4] = suffix
+	return c
+}
+
+// kdfHMACSHA2 is KDF-HMAC-SHA2 from RFC 8009 (a single-iteration SP 800-108 counter KDF)
+func kdfHMACSHA2(newHash func() hash.Hash, key, label []byte, bits int) []byte {
+	h := hmac.New(newHash, key)
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(label)
+	h.Write([]byte{0})
+	var k [4]byte
+	binary.BigEndian.PutUint32(k[:], uint32(bits))
+	h.Write(k[:])
+	return h.Sum(nil)[:bits/8]
+}
+
+// deriveKeyDK is DK(key, constant) from RFC 3961 for AES, where random-to-key is the identity
+func deriveKeyDK(key, constant []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	in := nFold(constant, aes.BlockSize)
+	out := make([]byte, 0, len(key)+aes.BlockSize)
+	for len(out) < len(key) {
+		next := make([]byte, aes.BlockSize)
+		block.Encrypt(next, in)
+		out = append(out, next...)
+		in = next
+	}
+	return out[:len(key)], nil
+}
+
+// nFold stretches or folds input to n bytes as defined in RFC 3961 section 5.1
+func nFold(in []byte, n int) []byte {
+	inLen := len(in)
+	lcm := inLen * n / gcd(inLen, n)
+
+	// Concatenate copies of the input, each rotated a further 13 bits to the right
+	buf := make([]byte, 0, lcm)
+	for i := 0; i < lcm/inLen; i++ {
+		buf = append(buf, rotateRight(in, 13*i)...)
+	}
+
+	// One's complement addition of the n-byte chunks
+	out := make([]byte, n)
+	for offset := 0; offset < lcm; offset += n {
+		carry := 0
+		for i := n - 1; i >= 0; i-- {
+			sum := int(out[i]) + int(buf[offset+i]) + carry
+			out[i] = byte(sum)
+			carry = sum >> 8
+		}
+		for i := n - 1; carry != 0 && i >= 0; i-- {
+			sum := int(out[i]) + carry
+			out[i] = byte(sum)
+			carry = sum >> 8
+		}
+	}
+	return out
+}
+
+// rotateRight rotates a byte string right by the given number of bits
+func rotateRight(in []byte, bits int) []byte {
+	total := len(in) * 8
+	bits %= total
+	out := make([]byte, len(in))
+	for i := 0; i < total; i++ {
+		src := (i - bits + total) % total
+		if in[src/8]&(0x80>>(src%8)) != 0 {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// decryptCTS decrypts AES-CBC with ciphertext stealing and a zero IV, the mode
+// Kerberos uses (the last two blocks are swapped, as in CBC-CS3)
+func decryptCTS(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := aes.BlockSize
+	if len(ciphertext) < bs {
+		return nil, errors.New("kerberos: ciphertext shorter than one block")
+	}
+	iv := make([]byte, bs)
+	if len(ciphertext) == bs {
+		plain := make([]byte, bs)
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+		return plain, nil
+	}
+
+	// Everything before the final two (possibly partial) blocks is plain CBC
+	tail := len(ciphertext) % bs
+	if tail == 0 {
+		tail = bs
+	}
+	headLen := len(ciphertext) - bs - tail
+	plain := make([]byte, len(ciphertext))
+	prev := iv
+	if headLen > 0 {
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain[:headLen], ciphertext[:headLen])
+		prev = ciphertext[headLen-bs : headLen]
+	}
+
+	penultimate := ciphertext[headLen : headLen+bs]
+	last := ciphertext[headLen+bs:]
+
+	d := make([]byte, bs)
+	block.Decrypt(d, penultimate)
+	for i := 0; i < tail; i++ {
+		plain[headLen+bs+i] = d[i] ^ last[i]
+	}
+
+	// Rebuild the stolen block from the final fragment and the tail of d
+	rebuilt := make([]byte, bs)
+	copy(rebuilt, last)
+	copy(rebuilt[tail:], d[tail:])
+	block.Decrypt(d, rebuilt)
+	for i := 0; i < bs; i++ {
+		plain[headLen+i] = d[i] ^ prev[i]
+	}
+	return plain, nil
+}
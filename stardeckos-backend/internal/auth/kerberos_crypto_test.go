@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// unhex decodes a test vector, ignoring spaces
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatalf("bad test vector %q: %v", s, err)
+	}
+	return b
+}
+
+// encryptCTS is the inverse of decryptCTS, for building test tokens
+func encryptCTS(t *testing.T, key, plain []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("aes key: %v", err)
+	}
+	bs := aes.BlockSize
+	padded := append(append([]byte{}, plain...), make([]byte, (bs-len(plain)%bs)%bs)...)
+	out := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, make([]byte, bs)).CryptBlocks(out, padded)
+	if len(out) == bs {
+		return out
+	}
+
+	// Swap the last two blocks and drop the padding
+	n := len(out)
+	tail := len(plain) - (n - bs)
+	result := append([]byte{}, out[:n-2*bs]...)
+	result = append(result, out[n-bs:]...)
+	return append(result, out[n-2*bs:n-2*bs+tail]...)
+}
+
+// krbEncrypt is the inverse of krbDecrypt, with an all-zero confounder
+func krbEncrypt(t *testing.T, etype int32, key []byte, usage uint32, plain []byte) []byte {
+	t.Helper()
+	msg := append(make([]byte, aes.BlockSize), plain...)
+	switch etype {
+	case etypeAES128SHA1, etypeAES256SHA1:
+		ke, err := deriveKeyDK(key, usageConstant(usage, 0xAA))
+		if err != nil {
+			t.Fatalf("derive Ke: %v", err)
+		}
+		ki, err := deriveKeyDK(key, usageConstant(usage, 0x55))
+		if err != nil {
+			t.Fatalf("derive Ki: %v", err)
+		}
+		h := hmac.New(sha1.New, ki)
+		h.Write(msg)
+		return append(encryptCTS(t, ke, msg), h.Sum(nil)[:12]...)
+	case etypeAES128SHA256, etypeAES256SHA384:
+		newHash, macLen := sha256.New, 16
+		if etype == etypeAES256SHA384 {
+			newHash, macLen = sha512.New384, 24
+		}
+		ke := kdfHMACSHA2(newHash, key, usageConstant(usage, 0xAA), len(key)*8)
+		ki := kdfHMACSHA2(newHash, key, usageConstant(usage, 0x55), macLen*8)
+		data := encryptCTS(t, ke, msg)
+		h := hmac.New(newHash, ki)
+		h.Write(make([]byte, aes.BlockSize))
+		h.Write(data)
+		return append(data, h.Sum(nil)[:macLen]...)
+	}
+	t.Fatalf("unsupported test etype %d", etype)
+	return nil
+}
+
+// RFC 3961 appendix A.1
+func TestNFold(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		bits int
+		want string
+	}{
+		{"012345", 64, "be072631276b1955"},
+		{"password", 56, "78a07b6caf85fa"},
+		{"Rough Consensus, and Running Code", 64, "bb6ed30870b7f0e0"},
+		{"password", 168, "59e4a8ca7c0385c3c37b3f6d2000247cb6e6bd5b3e"},
+		{"MASSACHVSETTS INSTITVTE OF TECHNOLOGY", 192, "db3b0d8f0b061e603282b308a50841229ad798fab9540c1b"},
+		{"Q", 168, "518a54a215a8452a518a54a215a8452a518a54a215"},
+		{"ba", 168, "fb25d531ae8974499f52fd92ea9857c4ba24cf297e"},
+		{"kerberos", 64, "6b65726265726f73"},
+		{"kerberos", 128, "6b65726265726f737b9b5b2b93132b93"},
+		{"kerberos", 168, "8372c236344e5f1550cd0747e15d62ca7a5a3bcea4"},
+		{"kerberos", 256, "6b65726265726f737b9b5b2b93132b935c9bdcdad95c9899c4cae4dee6d6cae4"},
+	} {
+		if got := hex.EncodeToString(nFold([]byte(tt.in), tt.bits/8)); got != tt.want {
+			t.Errorf("%d-fold(%q) = %s, want %s", tt.bits, tt.in, got, tt.want)
+		}
+	}
+}
+
+// RFC 3962 appendix B
+func TestDecryptCTS(t *testing.T) {
+	key := []byte("chicken teriyaki")
+	const plain = "I would like the General Gau's Chicken, please, and wonton soup."
+	for _, tt := range []struct {
+		n          int
+		ciphertext string
+	}{
+		{17, "c6353568f2bf8cb4d8a580362da7ff7f97"},
+		{31, "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+		{32, "39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584"},
+		{47, "97687268d6ecccc0c07b25e25ecfe584b3fffd940c16a18c1b5549d2f838029e39312523a78662d5be7fcbcc98ebf5"},
+		{48, "97687268d6ecccc0c07b25e25ecfe5849dad8bbb96c4cdc03bc103e1a194bbd839312523a78662d5be7fcbcc98ebf5a8"},
+		{64, "97687268d6ecccc0c07b25e25ecfe58439312523a78662d5be7fcbcc98ebf5a84807efe836ee89a526730dbc2f7bc8409dad8bbb96c4cdc03bc103e1a194bbd8"},
+	} {
+		ciphertext := unhex(t, tt.ciphertext)
+		got, err := decryptCTS(key, ciphertext)
+		if err != nil {
+			t.Errorf("%d bytes: %v", tt.n, err)
+			continue
+		}
+		if string(got) != plain[:tt.n] {
+			t.Errorf("%d bytes: got %q, want %q", tt.n, got, plain[:tt.n])
+		}
+		if enc := encryptCTS(t, key, []byte(plain[:tt.n])); !bytes.Equal(enc, ciphertext) {
+			t.Errorf("%d bytes: test encryption gives %x", tt.n, enc)
+		}
+	}
+	if _, err := decryptCTS(key, make([]byte, aes.BlockSize-1)); err == nil {
+		t.Error("decryptCTS accepted less than one block")
+	}
+}
+
+// RFC 8009 appendix A
+func TestKDFHMACSHA2(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		etype  int32
+		base   string
+		suffix byte
+		bits   int
+		want   string
+	}{
+		{"aes128 Kc", etypeAES128SHA256, "3705d96080c17728a0e800eab6e0d23c", 0x99, 128, "b31a018a48f54776f403e9a396325dc3"},
+		{"aes128 Ke", etypeAES128SHA256, "3705d96080c17728a0e800eab6e0d23c", 0xAA, 128, "9b197dd1e8c5609d6e67c3e37c62c72e"},
+		{"aes128 Ki", etypeAES128SHA256, "3705d96080c17728a0e800eab6e0d23c", 0x55, 128, "9fda0e56ab2d85e1569a688696c26a6c"},
+		{"aes256 Kc", etypeAES256SHA384, "6d404d37faf79f9df0d33568d320669800eb4836472ea8a026d16b7182460c52", 0x99, 192, "ef5718be86cc84963d8bbb5031e9f5c4ba41f28faf69e73d"},
+		{"aes256 Ke", etypeAES256SHA384, "6d404d37faf79f9df0d33568d320669800eb4836472ea8a026d16b7182460c52", 0xAA, 256, "56ab22bee63d82d7bc5227f6773f8ea7a5eb1c825160c38312980c442e5c7e49"},
+		{"aes256 Ki", etypeAES256SHA384, "6d404d37faf79f9df0d33568d320669800eb4836472ea8a026d16b7182460c52", 0x55, 192, "69b16514e3cd8e56b82010d5c73012b622c4d00ffc23ed1f"},
+	} {
+		newHash := sha256.New
+		if tt.etype == etypeAES256SHA384 {
+			newHash = sha512.New384
+		}
+		got := kdfHMACSHA2(newHash, unhex(t, tt.base), usageConstant(2, tt.suffix), tt.bits)
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("%s = %x, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// RFC 8009 appendix A, with key usage 2
+func TestDecryptAESSHA2(t *testing.T) {
+	aes128 := unhex(t, "3705d96080c17728a0e800eab6e0d23c")
+	aes256 := unhex(t, "6d404d37faf79f9df0d33568d320669800eb4836472ea8a026d16b7182460c52")
+	for _, tt := range []struct {
+		etype      int32
+		key        []byte
+		plain      string
+		ciphertext string
+	}{
+		{etypeAES128SHA256, aes128, "", "ef85fb890bb8472f4dab20394dca781dad877eda39d50c870c0d5a0a8e48c718"},
+		{etypeAES128SHA256, aes128, "000102030405", "84d7f30754ed987bab0bf3506beb09cfb55402cef7e6877ce99e247e52d16ed4421dfdf8976c"},
+		{etypeAES128SHA256, aes128, "000102030405060708090a0b0c0d0e0f", "3517d640f50ddc8ad3628722b3569d2ae07493fa8263254080ea65c1008e8fc295fb4852e7d83e1e7c48c37eebe6b0d3"},
+		{etypeAES128SHA256, aes128, "000102030405060708090a0b0c0d0e0f1011121314", "720f73b18d9859cd6ccb4346115cd336c70f58edc0c4437c5573544c31c813bce1e6d072c186b39a413c2f92ca9b8334a287ffcbfc"},
+		{etypeAES256SHA384, aes256, "", "41f53fa5bfe7026d91faf9be959195a058707273a96a40f0a01960621ac612748b9bbfbe7eb4ce3c"},
+	} {
+		ciphertext := unhex(t, tt.ciphertext)
+		got, err := krbDecrypt(tt.etype, tt.key, 2, ciphertext)
+		if err != nil {
+			t.Errorf("etype %d, %d bytes: %v", tt.etype, len(tt.plain)/2, err)
+			continue
+		}
+		if hex.EncodeToString(got) != tt.plain {
+			t.Errorf("etype %d, %d bytes: got %x, want %s", tt.etype, len(tt.plain)/2, got, tt.plain)
+		}
+
+		ciphertext[len(ciphertext)-1] ^= 1
+		if _, err := krbDecrypt(tt.etype, tt.key, 2, ciphertext); !errors.Is(err, errKrbIntegrity) {
+			t.Errorf("etype %d, %d bytes with a bad MAC: got %v, want an integrity error", tt.etype, len(tt.plain)/2, err)
+		}
+	}
+}
+
+func TestKrbDecryptRefusesBadInput(t *testing.T) {
+	msg := krbEncrypt(t, etypeAES256SHA1, bytes.Repeat([]byte{1}, 32), keyUsageTicket, []byte("ticket"))
+	for name, tt := range map[string]struct {
+		etype      int32
+		key        []byte
+		ciphertext []byte
+	}{
+		"rc4":              {23, bytes.Repeat([]byte{1}, 16), msg},
+		"short key":        {etypeAES256SHA1, bytes.Repeat([]byte{1}, 16), msg},
+		"wrong key":        {etypeAES256SHA1, bytes.Repeat([]byte{2}, 32), msg},
+		"wrong etype":      {etypeAES128SHA256, bytes.Repeat([]byte{1}, 16), msg},
+		"short ciphertext": {etypeAES256SHA1, bytes.Repeat([]byte{1}, 32), msg[:aes.BlockSize+11]},
+	} {
+		if _, err := krbDecrypt(tt.etype, tt.key, keyUsageTicket, tt.ciphertext); err == nil {
+			t.Errorf("%s: krbDecrypt succeeded", name)
+		}
+	}
+	if got, err := krbDecrypt(etypeAES256SHA1, bytes.Repeat([]byte{1}, 32), keyUsageTicket, msg); err != nil || string(got) != "ticket" {
+		t.Errorf("round trip: got %q, %v", got, err)
+	}
+}
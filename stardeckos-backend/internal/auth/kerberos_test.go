@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testService = "HTTP/stardeck.example.com"
+
+var testServiceKey = bytes.Repeat([]byte{0x42}, 32)
+
+func testKeytab() []KeytabEntry {
+	return []KeytabEntry{
+		{Principal: testService, Realm: "EXAMPLE.COM", KVNO: 2, EType: etypeAES256SHA1, key: testServiceKey},
+		{Principal: "host/stardeck.example.com", Realm: "EXAMPLE.COM", KVNO: 2, EType: etypeAES256SHA1, key: testServiceKey},
+	}
+}
+
+// withReplayCache gives the test an empty replay cache
+func withReplayCache(t *testing.T) {
+	t.Helper()
+	previous := kerberosReplays
+	kerberosReplays = &replayCache{seen: make(map[string]time.Time)}
+	t.Cleanup(func() { kerberosReplays = previous })
+}
+
+// derTLV encodes content under the given class and tag
+func derTLV(t *testing.T, class, tag int, content []byte) []byte {
+	t.Helper()
+	b, err := asn1.Marshal(asn1.RawValue{Class: class, Tag: tag, IsCompound: true, Bytes: content})
+	if err != nil {
+		t.Fatalf("marshal tag %d: %v", tag, err)
+	}
+	return b
+}
+
+// derApplication marshals v and wraps it in [APPLICATION tag]
+func derApplication(t *testing.T, tag int, v interface{}) []byte {
+	t.Helper()
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal APPLICATION %d: %v", tag, err)
+	}
+	return derTLV(t, asn1.ClassApplication, tag, b)
+}
+
+// explicitRaw wraps a marshalled value in an explicit context tag, which
+// encoding/asn1 does not add around raw values
+func explicitRaw(t *testing.T, tag int, v interface{}) asn1.RawValue {
+	t.Helper()
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal [%d]: %v", tag, err)
+	}
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: b}
+}
+
+// gssToken builds an InitialContextToken for a mechanism
+func gssToken(t *testing.T, mech asn1.ObjectIdentifier, inner []byte) []byte {
+	t.Helper()
+	oid, err := asn1.Marshal(mech)
+	if err != nil {
+		t.Fatalf("marshal mechanism: %v", err)
+	}
+	return derTLV(t, asn1.ClassApplication, 0, append(oid, inner...))
+}
+
+// testAPReq holds the parts of a client's AP-REQ that test cases vary
+type testAPReq struct {
+	service    []string
+	sessionKey krbEncryptionKey
+	authEType  int32
+	ctime      time.Time
+}
+
+func newTestAPReq(now time.Time) *testAPReq {
+	return &testAPReq{
+		service:    strings.Split(testService, "/"),
+		sessionKey: krbEncryptionKey{KeyType: etypeAES128SHA256, KeyValue: bytes.Repeat([]byte{0x17}, 16)},
+		authEType:  etypeAES128SHA256,
+		ctime:      now,
+	}
+}
+
+// apReq returns the bare KRB_AP_REQ message
+func (r *testAPReq) apReq(t *testing.T) []byte {
+	t.Helper()
+	client := krbPrincipalName{NameType: 1, NameString: []string{"jdoe"}}
+	encTicket := derApplication(t, 3, krbEncTicketPart{
+		Flags:  asn1.BitString{Bytes: make([]byte, 4), BitLength: 32},
+		Key:    r.sessionKey,
+		CRealm: "EXAMPLE.COM",
+		CName:  client,
+		Transited: explicitRaw(t, 4, struct {
+			Type     int32  `asn1:"explicit,tag:0"`
+			Contents []byte `asn1:"explicit,tag:1"`
+		}{1, []byte{}}),
+		AuthTime: r.ctime.Add(-time.Minute),
+		EndTime:  r.ctime.Add(time.Hour),
+	})
+	ticket := derApplication(t, 1, krbTicket{
+		TktVNO: 5,
+		Realm:  "EXAMPLE.COM",
+		SName:  krbPrincipalName{NameType: 2, NameString: r.service},
+		EncPart: krbEncryptedData{
+			EType:  etypeAES256SHA1,
+			KVNO:   2,
+			Cipher: krbEncrypt(t, etypeAES256SHA1, testServiceKey, keyUsageTicket, encTicket),
+		},
+	})
+	authenticator := derApplication(t, 2, krbAuthenticator{
+		AVNO:   5,
+		CRealm: "EXAMPLE.COM",
+		CName:  client,
+		Cksum: explicitRaw(t, 3, struct {
+			Type     int32  `asn1:"explicit,tag:0"`
+			Checksum []byte `asn1:"explicit,tag:1"`
+		}{0x8003, make([]byte, 24)}),
+		CUSec: 0,
+		CTime: r.ctime,
+	})
+	return derApplication(t, 14, krbAPReq{
+		PVNO:      5,
+		MsgType:   14,
+		APOptions: asn1.BitString{Bytes: make([]byte, 4), BitLength: 32},
+		Ticket:    asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 3, IsCompound: true, Bytes: ticket},
+		Authenticator: krbEncryptedData{
+			EType:  r.authEType,
+			Cipher: krbEncrypt(t, r.authEType, r.sessionKey.KeyValue, keyUsageAuthenticator, authenticator),
+		},
+	})
+}
+
+// token wraps the AP-REQ in a SPNEGO NegTokenInit, as browsers send it
+func (r *testAPReq) token(t *testing.T) []byte {
+	t.Helper()
+	mechToken := gssToken(t, oidKerberos5, append([]byte{0x01, 0x00}, r.apReq(t)...))
+	init, err := asn1.Marshal(negTokenInit{MechTypes: []asn1.ObjectIdentifier{oidKerberos5}, MechToken: mechToken})
+	if err != nil {
+		t.Fatalf("marshal NegTokenInit: %v", err)
+	}
+	return gssToken(t, oidSPNEGO, derTLV(t, asn1.ClassContextSpecific, 0, init))
+}
+
+func TestVerifyNegotiate(t *testing.T) {
+	withReplayCache(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	token := newTestAPReq(now).token(t)
+	identity, err := VerifyNegotiate(token, testKeytab(), testService, now)
+	if err != nil {
+		t.Fatalf("VerifyNegotiate: %v", err)
+	}
+	if identity.Principal() != "jdoe@EXAMPLE.COM" {
+		t.Errorf("principal = %s, want jdoe@EXAMPLE.COM", identity.Principal())
+	}
+	if _, err := VerifyNegotiate(token, testKeytab(), testService, now); err == nil || !strings.Contains(err.Error(), "replayed") {
+		t.Errorf("replayed token: got %v, want a replay error", err)
+	}
+
+	// A raw Kerberos token without SPNEGO is accepted too
+	raw := gssToken(t, oidKerberos5, append([]byte{0x01, 0x00}, newTestAPReq(now.Add(time.Second)).apReq(t)...))
+	if _, err := VerifyNegotiate(raw, testKeytab(), testService, now); err != nil {
+		t.Errorf("raw Kerberos token: %v", err)
+	}
+}
+
+func TestVerifyNegotiateRefusesOtherServices(t *testing.T) {
+	withReplayCache(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// The keytab can decrypt a host/ ticket, but it isn't for Stardeck
+	req := newTestAPReq(now)
+	req.service = []string{"host", "stardeck.example.com"}
+	if _, err := VerifyNegotiate(req.token(t), testKeytab(), testService, now); err == nil {
+		t.Error("accepted a ticket for host/stardeck.example.com")
+	}
+
+	// Nor is a ticket for the name on another of the host's sites
+	if _, err := VerifyNegotiate(newTestAPReq(now).token(t), testKeytab(), "HTTP/other.example.com", now); err == nil {
+		t.Error("accepted a ticket for HTTP/stardeck.example.com as HTTP/other.example.com")
+	}
+
+	// A service with no key at all
+	other := newTestAPReq(now)
+	other.service = []string{"HTTP", "other.example.com"}
+	if _, err := VerifyNegotiate(other.token(t), testKeytab(), "HTTP/other.example.com", now); err == nil || !strings.Contains(err.Error(), "no key") {
+		t.Errorf("ticket without a keytab key: got %v, want a missing key error", err)
+	}
+}
+
+func TestVerifyNegotiateRefusesBadAuthenticators(t *testing.T) {
+	withReplayCache(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// Sealed with a different etype than the ticket's session key
+	req := newTestAPReq(now)
+	req.authEType = etypeAES128SHA1
+	if _, err := VerifyNegotiate(req.token(t), testKeytab(), testService, now); err == nil || !strings.Contains(err.Error(), "etype") {
+		t.Errorf("authenticator etype mismatch: got %v, want an etype error", err)
+	}
+
+	// Sent well outside the clock skew window
+	stale := newTestAPReq(now.Add(-10 * time.Minute))
+	if _, err := VerifyNegotiate(stale.token(t), testKeytab(), testService, now); err == nil || !strings.Contains(err.Error(), "skew") {
+		t.Errorf("stale authenticator: got %v, want a clock skew error", err)
+	}
+}
+
+func TestVerifyNegotiateRefusesMalformedTokens(t *testing.T) {
+	withReplayCache(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	token := newTestAPReq(now).token(t)
+	for n := 0; n < len(token); n++ {
+		if _, err := VerifyNegotiate(token[:n], testKeytab(), testService, now); err == nil {
+			t.Fatalf("accepted a token truncated to %d of %d bytes", n, len(token))
+		}
+	}
+
+	apReq := newTestAPReq(now.Add(time.Second)).apReq(t)
+	for name, token := range map[string][]byte{
+		"garbage":        []byte("not a token"),
+		"empty":          {},
+		"trailing data":  append(newTestAPReq(now.Add(2*time.Second)).token(t), 0),
+		"not an AP-REQ":  gssToken(t, oidKerberos5, append([]byte{0x02, 0x00}, apReq...)),
+		"wrong app tag":  gssToken(t, oidKerberos5, append([]byte{0x01, 0x00}, derTLV(t, asn1.ClassApplication, 15, apReq[2:])...)),
+		"no mech token":  gssToken(t, oidSPNEGO, derTLV(t, asn1.ClassContextSpecific, 0, mustMarshal(t, negTokenInit{MechTypes: []asn1.ObjectIdentifier{oidKerberos5}}))),
+		"unknown mech":   gssToken(t, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 2, 10}, []byte("NTLMSSP\x00")),
+		"corrupt cipher": corruptLast(gssToken(t, oidKerberos5, append([]byte{0x01, 0x00}, newTestAPReq(now.Add(3*time.Second)).apReq(t)...))),
+	} {
+		if _, err := VerifyNegotiate(token, testKeytab(), testService, now); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+
+	if _, err := VerifyNegotiate([]byte("NTLMSSP\x00\x01\x00\x00\x00"), testKeytab(), testService, now); !errors.Is(err, errNTLMUnsupported) {
+		t.Errorf("NTLM token: got %v, want errNTLMUnsupported", err)
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return b
+}
+
+// corruptLast flips a bit in the final byte, the authenticator's MAC
+func corruptLast(b []byte) []byte {
+	b[len(b)-1] ^= 1
+	return b
+}
+
+func TestReplayCache(t *testing.T) {
+	cache := &replayCache{seen: make(map[string]time.Time)}
+	now := time.Now()
+	if !cache.check("jdoe@EXAMPLE.COM|1", now) {
+		t.Fatal("first authenticator reported as a replay")
+	}
+	if cache.check("jdoe@EXAMPLE.COM|1", now.Add(time.Minute)) {
+		t.Error("replayed authenticator reported as new")
+	}
+	if !cache.check("jdoe@EXAMPLE.COM|2", now) {
+		t.Error("a different authenticator reported as a replay")
+	}
+	// Entries are forgotten once the clock skew window has passed
+	if !cache.check("jdoe@EXAMPLE.COM|1", now.Add(2*kerberosClockSkew+time.Second)) {
+		t.Error("expired authenticator still reported as a replay")
+	}
+	if len(cache.seen) != 1 {
+		t.Errorf("cache holds %d entries, want 1", len(cache.seen))
+	}
+}
+
+// keytabRecord encodes one keytab entry for HTTP/<host>@EXAMPLE.COM
+func keytabRecord(host string, key []byte) []byte {
+	var rec []byte
+	str := func(s string) {
+		rec = binary.BigEndian.AppendUint16(rec, uint16(len(s)))
+		rec = append(rec, s...)
+	}
+	rec = binary.BigEndian.AppendUint16(rec, 2) // components
+	str("EXAMPLE.COM")
+	str("HTTP")
+	str(host)
+	rec = binary.BigEndian.AppendUint32(rec, 1) // name type
+	rec = binary.BigEndian.AppendUint32(rec, 0) // timestamp
+	rec = append(rec, 3)                        // 8-bit kvno
+	rec = binary.BigEndian.AppendUint16(rec, uint16(etypeAES256SHA1))
+	rec = binary.BigEndian.AppendUint16(rec, uint16(len(key)))
+	rec = append(rec, key...)
+	return binary.BigEndian.AppendUint32(rec, 300) // 32-bit kvno
+}
+
+func TestReadKeytab(t *testing.T) {
+	record := keytabRecord("stardeck.example.com", testServiceKey)
+	data := []byte{0x05, 0x02}
+	data = binary.BigEndian.AppendUint32(data, uint32(len(record)))
+	data = append(data, record...)
+	// A hole left by a deleted entry
+	data = binary.BigEndian.AppendUint32(data, uint32(0xFFFFFFF8))
+	data = append(data, make([]byte, 8)...)
+
+	write := func(b []byte) string {
+		path := filepath.Join(t.TempDir(), "krb5.keytab")
+		if err := os.WriteFile(path, b, 0600); err != nil {
+			t.Fatalf("write keytab: %v", err)
+		}
+		return path
+	}
+
+	entries, err := ReadKeytab(write(data))
+	if err != nil {
+		t.Fatalf("ReadKeytab: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Principal != testService || e.Realm != "EXAMPLE.COM" || e.KVNO != 300 || e.EType != etypeAES256SHA1 || !bytes.Equal(e.key, testServiceKey) {
+		t.Errorf("entry = %+v", e)
+	}
+
+	// The entry claims more bytes than the file holds
+	if _, err := ReadKeytab(write(data[:6+len(record)-1])); err == nil {
+		t.Error("accepted a truncated keytab")
+	}
+	// The entry's own fields run past its declared size
+	short := []byte{0x05, 0x02}
+	short = binary.BigEndian.AppendUint32(short, 10)
+	short = append(short, record[:10]...)
+	if _, err := ReadKeytab(write(short)); err == nil {
+		t.Error("accepted a keytab entry with truncated fields")
+	}
+	if _, err := ReadKeytab(write([]byte{0x05, 0x01})); err == nil {
+		t.Error("accepted a version 1 keytab")
+	}
+}
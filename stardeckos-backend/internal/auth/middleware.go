@@ -1,11 +1,14 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"stardeckos-backend/internal/database"
 	"stardeckos-backend/internal/models"
 )
 
@@ -15,22 +18,79 @@ const (
 	ContextKeySession = "session"
 )
 
+// Session headers exchanged with the frontend
+const (
+	// HeaderBackgroundRequest marks a request that isn't user activity, so it
+	// doesn't renew the session's idle timeout
+	HeaderBackgroundRequest = "X-Background-Request"
+	// HeaderSessionExpires carries the session's current expiry on authenticated responses
+	HeaderSessionExpires = "X-Session-Expires-At"
+)
+
+// SessionError writes the 401 for a missing or rejected session token. The code
+// tells the frontend whether to show the login form fresh or explain the
+// session ran out; err is nil when no token was sent.
+func SessionError(c echo.Context, err error) error {
+	code, message := "authentication_required", "authentication required"
+	switch {
+	case err == nil:
+	case errors.Is(err, database.ErrSessionExpired):
+		code, message = "session_idle_timeout", "session expired after inactivity"
+	case errors.Is(err, ErrSessionLifetimeExceeded):
+		code, message = "session_lifetime_exceeded", "session reached its maximum lifetime"
+	case errors.Is(err, ErrUserDisabled):
+		code, message = "account_disabled", "user account is disabled"
+	default:
+		code, message = "session_invalid", "invalid or expired session"
+	}
+
+	challenge := `Bearer realm="stardeck"`
+	if err != nil {
+		challenge += `, error="invalid_token"`
+	}
+	c.Response().Header().Set("WWW-Authenticate", challenge)
+	return c.JSON(http.StatusUnauthorized, map[string]string{
+		"error": message,
+		"code":  code,
+	})
+}
+
 // RequireAuth middleware checks for valid authentication
 func RequireAuth(authSvc *Service) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			token := getTokenFromRequest(c)
 			if token == "" {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "authentication required",
-				})
+				// Automation hosts authenticate with a certificate from the internal CA instead
+				if cert := VerifiedClientCert(c); cert != nil {
+					return authenticateClientCert(c, authSvc, cert, next)
+				}
+				return SessionError(c, nil)
+			}
+			if strings.HasPrefix(token, models.APITokenPrefix) {
+				return authenticateAPIToken(c, authSvc, token, next)
 			}
 
 			user, session, err := authSvc.ValidateToken(token)
 			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "invalid or expired session",
-				})
+				return SessionError(c, err)
+			}
+
+			// Activity slides the idle timeout forward. Polling the frontend does on
+			// its own (metrics, notifications) marks itself so it doesn't count.
+			if c.Request().Header.Get(HeaderBackgroundRequest) == "" {
+				if err := authSvc.TouchSession(user, session); err != nil {
+					c.Logger().Warn("failed to renew session: ", err)
+				}
+			}
+			c.Response().Header().Set(HeaderSessionExpires, session.ExpiresAt.UTC().Format(time.RFC3339))
+
+			// Viewers can look but not touch, whatever role checks the route itself has
+			if refused, err := authSvc.RefuseReadOnly(c, user, session); refused {
+				return err
+			}
+			if perm := missingPermission(c, user); perm != "" {
+				return permissionError(c, perm)
 			}
 
 			// Store user and session in context for handlers
@@ -55,10 +115,17 @@ func RequireRole(roles ...models.Role) echo.MiddlewareFunc {
 			}
 
 			// Check if user's role is in allowed roles
+			adminOnly := true
 			for _, role := range roles {
 				if user.Role == role {
 					return next(c)
 				}
+				if role != models.RoleAdmin {
+					adminOnly = false
+				}
+			}
+			if customRoleGrants(c, user, adminOnly) {
+				return next(c)
 			}
 
 			return c.JSON(http.StatusForbidden, map[string]string{
@@ -93,7 +160,7 @@ func RequireWheelOrRoot(authSvc *Service) echo.MiddlewareFunc {
 			// For PAM users, check actual system groups
 			if user.AuthType == models.AuthTypePAM {
 				pamAuth := NewPAMAuth()
-				if !pamAuth.IsAdmin(user.Username) {
+				if !pamAuth.IsAdmin(user.Username) && !customRoleGrants(c, user, true) {
 					return c.JSON(http.StatusForbidden, map[string]string{
 						"error": "requires wheel group membership or root access",
 					})
@@ -102,8 +169,8 @@ func RequireWheelOrRoot(authSvc *Service) echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			// For local users, require admin role
-			if user.Role != models.RoleAdmin {
+			// For local users, require admin role or a custom role granting the route
+			if user.Role != models.RoleAdmin && !customRoleGrants(c, user, true) {
 				return c.JSON(http.StatusForbidden, map[string]string{
 					"error": "requires administrator privileges",
 				})
@@ -135,7 +202,7 @@ func RequireAdminOrPAMAdmin(authSvc *Service) echo.MiddlewareFunc {
 			}
 
 			// Check if user has admin role
-			if user.Role == models.RoleAdmin {
+			if user.Role == models.RoleAdmin || customRoleGrants(c, user, true) {
 				return next(c)
 			}
 
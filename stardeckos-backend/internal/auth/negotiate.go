@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"fmt"
+	"os/user"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/database"
+)
+
+// DefaultKerberosKeytab is the host keytab written by realm join. It needs an
+// HTTP/<fqdn> service key, e.g. adcli update --add-service-principal=HTTP/<fqdn>
+// on AD or ipa-getkeytab -p HTTP/<fqdn> on FreeIPA.
+const DefaultKerberosKeytab = "/etc/krb5.keytab"
+
+// NegotiateEnabled reports whether Kerberos single sign-on is turned on
+func (s *Service) NegotiateEnabled() bool {
+	enabled, err := s.settingsRepo.GetBool(database.SettingKerberosEnabled)
+	return err == nil && enabled
+}
+
+// KerberosKeytab returns the configured keytab path
+func (s *Service) KerberosKeytab() string {
+	if path, err := s.settingsRepo.Get(database.SettingKerberosKeytab); err == nil && path != "" {
+		return path
+	}
+	return DefaultKerberosKeytab
+}
+
+// kerberosRealms returns the realms whose users may sign in. By default only the
+// realms the service keys belong to are trusted, not cross-realm clients.
+func (s *Service) kerberosRealms(keytab []KeytabEntry) map[string]bool {
+	realms := make(map[string]bool)
+	if v, err := s.settingsRepo.Get(database.SettingKerberosRealms); err == nil && strings.TrimSpace(v) != "" {
+		for _, realm := range strings.Split(v, ",") {
+			if realm = strings.TrimSpace(realm); realm != "" {
+				realms[strings.ToUpper(realm)] = true
+			}
+		}
+		return realms
+	}
+	for _, entry := range keytab {
+		realms[strings.ToUpper(entry.Realm)] = true
+	}
+	return realms
+}
+
+// LoginNegotiate verifies the token from an "Authorization: Negotiate" header,
+// maps the Kerberos principal to its SSSD system account and creates a session.
+// host is the name the browser reached Stardeck by, which its ticket is for.
+func (s *Service) LoginNegotiate(token []byte, host, ipAddress, userAgent string) (*LoginResponse, *KerberosIdentity, error) {
+	if !s.NegotiateEnabled() {
+		return nil, nil, ErrAuthMethodDisabled
+	}
+
+	keytab, err := ReadKeytab(s.KerberosKeytab())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read keytab: %w", err)
+	}
+
+	identity, err := VerifyNegotiate(token, keytab, "HTTP/"+host, time.Now())
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if !s.kerberosRealms(keytab)[strings.ToUpper(identity.Realm)] {
+		return nil, identity, fmt.Errorf("%w: realm %s is not trusted", ErrInvalidCredentials, identity.Realm)
+	}
+	if strings.Contains(identity.Name, "/") {
+		return nil, identity, fmt.Errorf("%w: service principals cannot sign in", ErrInvalidCredentials)
+	}
+
+	// SSSD resolves either form; its canonical name matches what a password login would use
+	var account *user.User
+	for _, name := range []string{identity.Name + "@" + strings.ToLower(identity.Realm), identity.Name} {
+		if account, err = user.Lookup(name); err == nil {
+			break
+		}
+	}
+	if account == nil {
+		return nil, identity, fmt.Errorf("%w: no system account for %s", ErrInvalidCredentials, identity.Principal())
+	}
+
+	u, err := s.systemAccount(account.Username)
+	if err != nil {
+		return nil, identity, err
+	}
+	if u == nil {
+		return nil, identity, fmt.Errorf("%w: %s is a local Stardeck account", ErrInvalidCredentials, account.Username)
+	}
+	if u.Disabled {
+		return nil, identity, ErrUserDisabled
+	}
+
+	resp, err := s.createSession(u, ipAddress, userAgent, false)
+	return resp, identity, err
+}
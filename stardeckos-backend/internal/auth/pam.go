@@ -103,6 +103,27 @@ func (p *PAMAuth) IsAdmin(username string) bool {
 	return u.Uid == "0"
 }
 
+// GroupNames returns the names of all groups the user belongs to, including
+// domain groups resolved through SSSD
+func (p *PAMAuth) GroupNames(username string) []string {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return nil
+	}
+	gids, err := u.GroupIds()
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, gid := range gids {
+		if g, err := user.LookupGroupId(gid); err == nil {
+			names = append(names, g.Name)
+		}
+	}
+	return names
+}
+
 // SystemUser represents a Linux system user
 type SystemUser struct {
 	Username string
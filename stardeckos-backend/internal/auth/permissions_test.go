@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/models"
+)
+
+// serveAs runs a request through the permission check RequireAuth makes and
+// then the route's own middleware, as the given user
+func serveAs(t *testing.T, user *models.User, route, target string, mw ...echo.MiddlewareFunc) int {
+	t.Helper()
+	e := echo.New()
+	authenticated := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if perm := missingPermission(c, user); perm != "" {
+				return permissionError(c, perm)
+			}
+			c.Set(ContextKeyUser, user)
+			return next(c)
+		}
+	}
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET(route, ok, append([]echo.MiddlewareFunc{authenticated}, mw...)...)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec.Code
+}
+
+func customRole(perms ...string) *models.User {
+	roleID := int64(10)
+	return &models.User{ID: 2, Username: "reader", Role: models.RoleViewer, RoleID: &roleID, Permissions: perms}
+}
+
+// readMethodWriteRoutes are the GET routes that change state or leak data,
+// with the middleware routes.go gives them
+var readMethodWriteRoutes = []struct {
+	route, target string
+	read, write   string
+	mw            []echo.MiddlewareFunc
+}{
+	{"/api/images/promote", "/api/images/promote", models.PermImagesRead, models.PermImagesWrite,
+		[]echo.MiddlewareFunc{RequireRole(models.RoleAdmin)}},
+	{"/api/registries/local/transfer", "/api/registries/local/transfer", models.PermImagesRead, models.PermImagesWrite,
+		[]echo.MiddlewareFunc{RequireRole(models.RoleAdmin)}},
+	{"/api/network/capture", "/api/network/capture", models.PermNetworkRead, models.PermNetworkWrite,
+		[]echo.MiddlewareFunc{RequireRole(models.RoleAdmin)}},
+	{"/api/network/captures/:id/download", "/api/network/captures/abc/download", models.PermNetworkRead, models.PermNetworkWrite,
+		[]echo.MiddlewareFunc{RequireRole(models.RoleAdmin)}},
+	{"/api/containers/:id/attach", "/api/containers/web/attach", models.PermContainersRead, models.PermContainersWrite,
+		[]echo.MiddlewareFunc{RequireOperatorOrAdmin()}},
+	{"/api/terminal/ws", "/api/terminal/ws", models.PermSystemRead, models.PermSystemWrite, nil},
+	{"/api/packages/ws", "/api/packages/ws", models.PermSystemRead, models.PermSystemUpdates, nil},
+	{"/api/volumes/:name/files/download", "/api/volumes/pgdata/files/download", models.PermVolumesRead, models.PermVolumesWrite,
+		[]echo.MiddlewareFunc{RequireRole(models.RoleAdmin)}},
+	{"/api/volumes/:name/export", "/api/volumes/pgdata/export", models.PermVolumesRead, models.PermVolumesWrite,
+		[]echo.MiddlewareFunc{RequireRole(models.RoleAdmin)}},
+}
+
+func TestReadOnlyCustomRoleDeniedReadMethodWrites(t *testing.T) {
+	for _, r := range readMethodWriteRoutes {
+		for _, perms := range [][]string{{r.read}, {"*:read"}} {
+			if code := serveAs(t, customRole(perms...), r.route, r.target, r.mw...); code != http.StatusForbidden {
+				t.Errorf("GET %s with %v: got %d, want 403", r.target, perms, code)
+			}
+		}
+	}
+}
+
+func TestWriteCustomRoleAllowedReadMethodWrites(t *testing.T) {
+	for _, r := range readMethodWriteRoutes {
+		if code := serveAs(t, customRole(r.write), r.route, r.target, r.mw...); code != http.StatusOK {
+			t.Errorf("GET %s with %s: got %d, want 200", r.target, r.write, code)
+		}
+	}
+}
+
+func TestReadGrantDoesNotPassAdminRole(t *testing.T) {
+	// A plain admin-only read, such as the saved registry logins
+	route := "/api/registries/credentials"
+	if code := serveAs(t, customRole(models.PermImagesRead), route, route, RequireRole(models.RoleAdmin)); code != http.StatusForbidden {
+		t.Errorf("admin-only GET with a read grant: got %d, want 403", code)
+	}
+	// Operator routes still take the read grant for a read
+	route = "/api/images/prepull"
+	if code := serveAs(t, customRole(models.PermImagesRead), route, route, RequireOperatorOrAdmin()); code != http.StatusOK {
+		t.Errorf("operator GET with a read grant: got %d, want 200", code)
+	}
+}
+
+func TestReadMethodWritesNeedWritePermission(t *testing.T) {
+	e := echo.New()
+	for _, r := range readMethodWriteRoutes {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, r.target, nil), httptest.NewRecorder())
+		c.SetPath(r.route)
+		if got := RoutePermission(c); got != r.write {
+			t.Errorf("RoutePermission(%s) = %q, want %q", r.route, got, r.write)
+		}
+		if ViewerAllowed(c) {
+			t.Errorf("ViewerAllowed(%s) = true, want false", r.route)
+		}
+	}
+}
+
+func TestAttachNeedsOperator(t *testing.T) {
+	route, target := "/api/containers/:id/attach", "/api/containers/web/attach"
+	// Permissions as the built-in roles grant them
+	viewer := &models.User{ID: 3, Username: "viewer", Role: models.RoleViewer, Permissions: []string{"*:read"}}
+	if code := serveAs(t, viewer, route, target, RequireOperatorOrAdmin()); code != http.StatusForbidden {
+		t.Errorf("viewer attach: got %d, want 403", code)
+	}
+	operator := &models.User{ID: 4, Username: "operator", Role: models.RoleOperator, Permissions: []string{"*"}}
+	if code := serveAs(t, operator, route, target, RequireOperatorOrAdmin()); code != http.StatusOK {
+		t.Errorf("operator attach: got %d, want 200", code)
+	}
+}
+
+func TestVolumeContentsNeedAdmin(t *testing.T) {
+	viewer := &models.User{ID: 3, Username: "viewer", Role: models.RoleViewer, Permissions: []string{"*:read"}}
+	operator := &models.User{ID: 4, Username: "operator", Role: models.RoleOperator, Permissions: []string{"*"}}
+	admin := &models.User{ID: 1, Username: "admin", Role: models.RoleAdmin, Permissions: []string{"*"}}
+	for _, route := range []string{"/api/volumes/:name/files/download", "/api/volumes/:name/export"} {
+		target := strings.Replace(route, ":name", "pgdata", 1)
+		for _, user := range []*models.User{viewer, operator} {
+			if code := serveAs(t, user, route, target, RequireRole(models.RoleAdmin)); code != http.StatusForbidden {
+				t.Errorf("%s GET %s: got %d, want 403", user.Username, target, code)
+			}
+		}
+		if code := serveAs(t, admin, route, target, RequireRole(models.RoleAdmin)); code != http.StatusOK {
+			t.Errorf("admin GET %s: got %d, want 200", target, code)
+		}
+	}
+}
@@ -172,9 +172,15 @@ func (rl *RateLimiter) cleanup() {
 
 // Middleware returns an Echo middleware that rate limits requests
 func (rl *RateLimiter) Middleware() echo.MiddlewareFunc {
+	return rl.MiddlewareBy(func(c echo.Context) string { return c.RealIP() })
+}
+
+// MiddlewareBy rate limits requests counted under the key the function
+// returns, e.g. the client address and the resource it is guessing at
+func (rl *RateLimiter) MiddlewareBy(keyOf func(c echo.Context) string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			key := c.RealIP()
+			key := keyOf(c)
 
 			if !rl.Allow(key) {
 				blockedUntil := rl.GetBlockedUntil(key)
@@ -198,3 +204,7 @@ func (rl *RateLimiter) Middleware() echo.MiddlewareFunc {
 
 // Global rate limiter instance
 var LoginRateLimiter = DefaultRateLimiter()
+
+// PINRateLimiter counts kiosk PIN attempts apart from logins, so unlocking an
+// app never resets the login lockout
+var PINRateLimiter = DefaultRateLimiter()
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// ReadOnlyModeRoute is the route admins turn read-only mode off with, so it
+// stays open while everything else is frozen
+const ReadOnlyModeRoute = "/api/system/read-only-mode"
+
+// RefuseReadOnly turns a write away when the user's role or session is
+// read-only or read-only mode is on, writing the 403. It reports whether it
+// did. Routes that authenticate on their own call it after ValidateToken, as
+// RequireAuth does; session is nil for credentials that have none.
+func (s *Service) RefuseReadOnly(c echo.Context, user *models.User, session *models.Session) (bool, error) {
+	if ViewerAllowed(c) {
+		return false, nil
+	}
+	if user.IsReadOnly() {
+		return true, c.JSON(http.StatusForbidden, map[string]string{
+			"error": "viewer role is read-only",
+		})
+	}
+	if session != nil && session.ReadOnly {
+		return true, c.JSON(http.StatusForbidden, map[string]string{
+			"error": "session is read-only",
+		})
+	}
+	if s.readOnlyModeBlocks(c, user) {
+		return true, s.readOnlyModeError(c)
+	}
+	return false, nil
+}
+
+// readOnlyModeBlocks reports whether the global read-only switch turns this
+// request away. It blocks what a viewer can't do, for every role.
+func (s *Service) readOnlyModeBlocks(c echo.Context, user *models.User) bool {
+	if ViewerAllowed(c) {
+		return false
+	}
+	if user.IsAdmin() && c.Path() == ReadOnlyModeRoute {
+		return false
+	}
+	enabled, err := s.settingsRepo.GetBool(database.SettingReadOnlyMode)
+	return err == nil && enabled
+}
+
+// readOnlyModeError writes the 403 for a request blocked by read-only mode,
+// with the reason the admin gave
+func (s *Service) readOnlyModeError(c echo.Context) error {
+	message := "Stardeck is in read-only mode; changes are disabled"
+	if reason, err := s.settingsRepo.Get(database.SettingReadOnlyMessage); err == nil && reason != "" {
+		message += ": " + reason
+	}
+	return c.JSON(http.StatusForbidden, map[string]string{
+		"error": message,
+		"code":  "read_only_mode",
+	})
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// openTestService opens a fresh database with one local admin and returns
+// the auth service and the admin's password
+func openTestService(t *testing.T) (*Service, *models.User, string) {
+	t.Helper()
+	if err := database.Open(database.Config{Path: filepath.Join(t.TempDir(), "stardeck.db")}); err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	const password = "correct horse battery"
+	hash, err := HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	admin := &models.User{Username: "root-admin", Role: models.RoleAdmin, AuthType: models.AuthTypeLocal, PasswordHash: hash}
+	if err := database.NewUserRepo().Create(admin); err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	svc := NewService()
+	if err := svc.settingsRepo.Set(database.SettingAuthLocalEnabled, "true"); err != nil {
+		t.Fatalf("enable local auth: %v", err)
+	}
+	return svc, admin, password
+}
+
+// setReadOnlyMode turns the global read-only switch on or off
+func setReadOnlyMode(t *testing.T, svc *Service, on bool) {
+	t.Helper()
+	if err := svc.settingsRepo.Set(database.SettingReadOnlyMode, strconv.FormatBool(on)); err != nil {
+		t.Fatalf("set read-only mode: %v", err)
+	}
+}
+
+// serveDAV sends a WebDAV request through RequireBasicOrToken
+func serveDAV(svc *Service, method string, prepare func(*http.Request)) *httptest.ResponseRecorder {
+	e := echo.New()
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.Match([]string{method}, "/api/dav/:share/*", ok, RequireBasicOrToken(svc, "Stardeck"))
+
+	req := httptest.NewRequest(method, "/api/dav/media/notes.txt", strings.NewReader("changed"))
+	prepare(req)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebDAVRefusedInReadOnlyMode(t *testing.T) {
+	svc, admin, password := openTestService(t)
+	login, err := svc.createSession(admin, "127.0.0.1", "test", false)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	credentials := map[string]func(*http.Request){
+		"session": func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+login.Token) },
+		"basic":   func(r *http.Request) { r.SetBasicAuth(admin.Username, password) },
+	}
+
+	setReadOnlyMode(t, svc, true)
+	for name, prepare := range credentials {
+		for _, method := range []string{http.MethodPut, http.MethodDelete, "MKCOL", "MOVE"} {
+			rec := serveDAV(svc, method, prepare)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "read_only_mode") {
+				t.Errorf("%s %s in read-only mode: got %d %s, want 403", name, method, rec.Code, rec.Body.String())
+			}
+		}
+		for _, method := range []string{http.MethodGet, "PROPFIND"} {
+			if rec := serveDAV(svc, method, prepare); rec.Code != http.StatusOK {
+				t.Errorf("%s %s in read-only mode: got %d, want 200", name, method, rec.Code)
+			}
+		}
+	}
+
+	setReadOnlyMode(t, svc, false)
+	for name, prepare := range credentials {
+		if rec := serveDAV(svc, http.MethodPut, prepare); rec.Code != http.StatusOK {
+			t.Errorf("%s PUT with read-only mode off: got %d, want 200", name, rec.Code)
+		}
+	}
+}
+
+func TestWebDAVRefusedForReadOnlySession(t *testing.T) {
+	svc, admin, _ := openTestService(t)
+	login, err := svc.createSession(admin, "127.0.0.1", "test", true)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	rec := serveDAV(svc, http.MethodPut, func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+login.Token) })
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("PUT from a read-only session: got %d, want 403", rec.Code)
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"stardeckos-backend/internal/database"
@@ -17,19 +18,29 @@ var (
 
 // Service handles authentication logic
 type Service struct {
-	userRepo     *database.UserRepo
-	sessionRepo  *database.SessionRepo
-	settingsRepo *database.SettingsRepo
-	pamAuth      *PAMAuth
+	userRepo       *database.UserRepo
+	sessionRepo    *database.SessionRepo
+	settingsRepo   *database.SettingsRepo
+	mappingRepo    *database.DomainRoleMappingRepo
+	clientCertRepo *database.ClientCertRepo
+	roleRepo       *database.RoleRepo
+	twoFactorRepo  *database.TwoFactorRepo
+	apiTokenRepo   *database.APITokenRepo
+	pamAuth        *PAMAuth
 }
 
 // NewService creates a new auth service
 func NewService() *Service {
 	return &Service{
-		userRepo:     database.NewUserRepo(),
-		sessionRepo:  database.NewSessionRepo(),
-		settingsRepo: database.NewSettingsRepo(),
-		pamAuth:      NewPAMAuth(),
+		userRepo:       database.NewUserRepo(),
+		sessionRepo:    database.NewSessionRepo(),
+		settingsRepo:   database.NewSettingsRepo(),
+		mappingRepo:    database.NewDomainRoleMappingRepo(),
+		clientCertRepo: database.NewClientCertRepo(),
+		roleRepo:       database.NewRoleRepo(),
+		twoFactorRepo:  database.NewTwoFactorRepo(),
+		apiTokenRepo:   database.NewAPITokenRepo(),
+		pamAuth:        NewPAMAuth(),
 	}
 }
 
@@ -38,6 +49,11 @@ type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	AuthType string `json:"auth_type"` // "local" or "pam", empty defaults to trying both
+	ReadOnly bool   `json:"read_only"` // Limit the session to what a viewer may do
+
+	// Second factor, for accounts with two-factor authentication on
+	TOTPCode     string `json:"totp_code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
 }
 
 // LoginResponse represents a successful login
@@ -45,10 +61,72 @@ type LoginResponse struct {
 	User      *models.User `json:"user"`
 	Token     string       `json:"token"`
 	ExpiresAt time.Time    `json:"expires_at"`
+	ReadOnly  bool         `json:"read_only,omitempty"`
+
+	RecoveryCodeUsed bool `json:"-"` // Signed in with a recovery code
 }
 
 // Login authenticates a user and creates a session
 func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginResponse, error) {
+	user, err := s.Authenticate(req.Username, req.Password, req.AuthType)
+	if err != nil {
+		return nil, err
+	}
+
+	var usedRecovery bool
+	if s.TwoFactorEnabled(user) {
+		if usedRecovery, err = s.checkSecondFactor(user, req.TOTPCode, req.RecoveryCode); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := s.createSession(user, ipAddress, userAgent, req.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodeUsed = usedRecovery
+	return resp, nil
+}
+
+// createSession starts a session for an authenticated user, optionally
+// limited to what a viewer may do
+func (s *Service) createSession(user *models.User, ipAddress, userAgent string, readOnly bool) (*LoginResponse, error) {
+	policy := s.SessionPolicy(user.Role)
+	s.enforceSessionLimit(user.ID, policy)
+
+	// A new session's idle timeout never reaches past its absolute lifetime
+	duration := time.Duration(policy.IdleTimeoutMinutes) * time.Minute
+	if policy.AbsoluteLifetimeHours > 0 && duration > time.Duration(policy.AbsoluteLifetimeHours)*time.Hour {
+		duration = time.Duration(policy.AbsoluteLifetimeHours) * time.Hour
+	}
+
+	// Create session
+	token, session, err := s.sessionRepo.Create(user.ID, ipAddress, userAgent, duration)
+	if err != nil {
+		return nil, err
+	}
+	if readOnly {
+		if err := s.sessionRepo.SetReadOnly(session.ID); err != nil {
+			s.sessionRepo.Delete(session.ID)
+			return nil, err
+		}
+	}
+
+	// Update last login
+	s.userRepo.UpdateLastLogin(user.ID)
+	s.loadPermissions(user)
+
+	return &LoginResponse{
+		User:      user,
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		ReadOnly:  readOnly,
+	}, nil
+}
+
+// Authenticate verifies a username and password without creating a session
+// authType is "local", "pam", or empty to try both
+func (s *Service) Authenticate(username, password, authType string) (*models.User, error) {
 	var user *models.User
 	var err error
 
@@ -57,24 +135,24 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 	pamEnabled, _ := s.settingsRepo.GetBool(database.SettingAuthPAMEnabled)
 
 	// Try to authenticate based on auth_type preference
-	switch req.AuthType {
+	switch authType {
 	case "local":
 		if !localEnabled {
 			return nil, ErrAuthMethodDisabled
 		}
-		user, err = s.authenticateLocal(req.Username, req.Password)
+		user, err = s.authenticateLocal(username, password)
 	case "pam":
 		if !pamEnabled {
 			return nil, ErrAuthMethodDisabled
 		}
-		user, err = s.authenticatePAM(req.Username, req.Password)
+		user, err = s.authenticatePAM(username, password)
 	default:
 		// Try local first, then PAM
 		if localEnabled {
-			user, err = s.authenticateLocal(req.Username, req.Password)
+			user, err = s.authenticateLocal(username, password)
 		}
 		if user == nil && pamEnabled {
-			user, err = s.authenticatePAM(req.Username, req.Password)
+			user, err = s.authenticatePAM(username, password)
 		}
 	}
 
@@ -90,40 +168,7 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 		return nil, ErrUserDisabled
 	}
 
-	// Check session limit
-	maxSessions, _ := s.settingsRepo.GetInt(database.SettingSessionMaxPerUser)
-	if maxSessions > 0 {
-		count, _ := s.sessionRepo.CountByUserID(user.ID)
-		if count >= maxSessions {
-			// Delete oldest session to make room
-			sessions, _ := s.sessionRepo.GetByUserID(user.ID)
-			if len(sessions) > 0 {
-				s.sessionRepo.Delete(sessions[len(sessions)-1].ID)
-			}
-		}
-	}
-
-	// Get session timeout
-	timeoutMinutes, err := s.settingsRepo.GetInt(database.SettingSessionTimeout)
-	if err != nil || timeoutMinutes <= 0 {
-		timeoutMinutes = 60 // Default 1 hour
-	}
-	duration := time.Duration(timeoutMinutes) * time.Minute
-
-	// Create session
-	token, session, err := s.sessionRepo.Create(user.ID, ipAddress, userAgent, duration)
-	if err != nil {
-		return nil, err
-	}
-
-	// Update last login
-	s.userRepo.UpdateLastLogin(user.ID)
-
-	return &LoginResponse{
-		User:      user,
-		Token:     token,
-		ExpiresAt: session.ExpiresAt,
-	}, nil
+	return user, nil
 }
 
 // authenticateLocal verifies credentials against local database
@@ -157,6 +202,12 @@ func (s *Service) authenticatePAM(username, password string) (*models.User, erro
 		return nil, nil // Invalid credentials
 	}
 
+	return s.systemAccount(username)
+}
+
+// systemAccount returns the Stardeck user for an authenticated system account,
+// creating it on first sign-in and applying domain group role mappings
+func (s *Service) systemAccount(username string) (*models.User, error) {
 	// Check if user exists in our database
 	user, err := s.userRepo.GetByUsername(username)
 	if err != nil && !errors.Is(err, database.ErrUserNotFound) {
@@ -168,6 +219,14 @@ func (s *Service) authenticatePAM(username, password string) (*models.User, erro
 		if user.AuthType != models.AuthTypePAM {
 			return nil, nil // Username conflict with local user
 		}
+		// Domain group membership may have changed since the last login; a
+		// mapped role replaces a custom one
+		if role, ok := s.mappedRole(username); ok && role != user.Role {
+			user.Role, user.RoleID = role, nil
+			if err := s.userRepo.Update(user); err != nil {
+				return nil, err
+			}
+		}
 		return user, nil
 	}
 
@@ -184,6 +243,8 @@ func (s *Service) authenticatePAM(username, password string) (*models.User, erro
 	if s.pamAuth.IsAdmin(username) {
 		role = models.RoleAdmin
 		isPAMAdmin = true
+	} else if mapped, ok := s.mappedRole(username); ok {
+		role = mapped
 	}
 
 	displayName := sysUser.Name
@@ -206,6 +267,41 @@ func (s *Service) authenticatePAM(username, password string) (*models.User, erro
 	return user, nil
 }
 
+// roleRank orders roles so the most privileged mapping wins
+var roleRank = map[models.Role]int{
+	models.RoleViewer:   1,
+	models.RoleOperator: 2,
+	models.RoleAdmin:    3,
+}
+
+// mappedRole returns the highest role granted to a system user by the domain
+// group mappings. ok is false when none of the user's groups are mapped, in which
+// case the user's role is left alone.
+func (s *Service) mappedRole(username string) (role models.Role, ok bool) {
+	mappings, err := s.mappingRepo.List()
+	if err != nil || len(mappings) == 0 {
+		return "", false
+	}
+
+	// SSSD reports groups as "name" or "name@domain" depending on use_fully_qualified_names
+	groups := make(map[string]bool)
+	for _, name := range s.pamAuth.GroupNames(username) {
+		name = strings.ToLower(name)
+		groups[name] = true
+		if short, _, found := strings.Cut(name, "@"); found {
+			groups[short] = true
+		}
+	}
+
+	for _, m := range mappings {
+		if groups[strings.ToLower(m.GroupName)] && roleRank[m.Role] > roleRank[role] {
+			role = m.Role
+			ok = true
+		}
+	}
+	return role, ok
+}
+
 // Logout invalidates a session
 func (s *Service) Logout(token string) error {
 	return s.sessionRepo.DeleteByToken(token)
@@ -227,33 +323,35 @@ func (s *Service) ValidateToken(token string) (*models.User, *models.Session, er
 		return nil, nil, ErrUserDisabled
 	}
 
+	// The absolute lifetime is checked here rather than folded into expires_at,
+	// so lowering it applies to sessions that already exist
+	if deadline := sessionDeadline(session, s.SessionPolicy(user.Role)); !deadline.IsZero() && time.Now().After(deadline) {
+		s.sessionRepo.Delete(session.ID)
+		return nil, nil, ErrSessionLifetimeExceeded
+	}
+
 	// For PAM users, dynamically check admin status from system groups
 	if user.AuthType == models.AuthTypePAM {
 		user.IsPAMAdmin = s.pamAuth.IsAdmin(user.Username)
 	}
+	s.loadPermissions(user)
 
 	return user, session, nil
 }
 
-// RefreshToken extends the session expiration
+// RefreshToken extends the session expiration, up to its absolute lifetime
 func (s *Service) RefreshToken(token string) (*models.Session, error) {
-	session, err := s.sessionRepo.GetByToken(token)
+	user, session, err := s.ValidateToken(token)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get session timeout
-	timeoutMinutes, err := s.settingsRepo.GetInt(database.SettingSessionTimeout)
-	if err != nil || timeoutMinutes <= 0 {
-		timeoutMinutes = 60
-	}
-	duration := time.Duration(timeoutMinutes) * time.Minute
-
-	if err := s.sessionRepo.Extend(session.ID, duration); err != nil {
+	expiry := sessionExpiry(session, s.SessionPolicy(user.Role), time.Now())
+	if err := s.sessionRepo.SetExpiry(session.ID, expiry); err != nil {
 		return nil, err
 	}
 
-	session.ExpiresAt = time.Now().Add(duration)
+	session.ExpiresAt = expiry
 	return session, nil
 }
 
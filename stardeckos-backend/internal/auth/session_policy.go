@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+// ErrSessionLifetimeExceeded is returned for a session older than its role's
+// absolute lifetime, however recently it was used
+var ErrSessionLifetimeExceeded = errors.New("session lifetime exceeded")
+
+// defaultIdleTimeout applies when session.timeout_minutes is unset
+const defaultIdleTimeout = 60
+
+// sessionRenewInterval limits how often activity rewrites a session's expiry
+const sessionRenewInterval = time.Minute
+
+// sessionRoles are the roles that can carry a session policy override
+var sessionRoles = []models.Role{models.RoleAdmin, models.RoleOperator, models.RoleViewer}
+
+// SessionPolicies returns the default session policy and the per-role overrides
+func (s *Service) SessionPolicies() models.SessionPolicySettings {
+	settings := models.SessionPolicySettings{
+		Default: s.defaultSessionPolicy(),
+		Roles:   make(map[models.Role]models.SessionPolicy),
+	}
+	for _, role := range sessionRoles {
+		if policy, ok := s.rolePolicy(role); ok {
+			settings.Roles[role] = policy
+		}
+	}
+	return settings
+}
+
+// defaultSessionPolicy returns the policy for roles without an override
+func (s *Service) defaultSessionPolicy() models.SessionPolicy {
+	policy := models.SessionPolicy{IdleTimeoutMinutes: defaultIdleTimeout}
+	if v, err := s.settingsRepo.GetInt(database.SettingSessionTimeout); err == nil && v > 0 {
+		policy.IdleTimeoutMinutes = v
+	}
+	if v, err := s.settingsRepo.GetInt(database.SettingSessionLifetime); err == nil && v > 0 {
+		policy.AbsoluteLifetimeHours = v
+	}
+	if v, err := s.settingsRepo.GetInt(database.SettingSessionMaxPerUser); err == nil && v > 0 {
+		policy.MaxSessions = v
+	}
+	return policy
+}
+
+// rolePolicy returns a role's session policy override. An override exists once
+// its idle timeout is set; cleared overrides are stored empty.
+func (s *Service) rolePolicy(role models.Role) (models.SessionPolicy, bool) {
+	idle, err := s.settingsRepo.GetInt(database.RoleSetting(database.SettingSessionTimeout, role))
+	if err != nil || idle <= 0 {
+		return models.SessionPolicy{}, false
+	}
+	policy := models.SessionPolicy{IdleTimeoutMinutes: idle}
+	policy.AbsoluteLifetimeHours, _ = s.settingsRepo.GetInt(database.RoleSetting(database.SettingSessionLifetime, role))
+	policy.MaxSessions, _ = s.settingsRepo.GetInt(database.RoleSetting(database.SettingSessionMaxPerUser, role))
+	return policy, true
+}
+
+// SessionPolicy returns the policy that applies to sessions of the given role
+func (s *Service) SessionPolicy(role models.Role) models.SessionPolicy {
+	if policy, ok := s.rolePolicy(role); ok {
+		return policy
+	}
+	return s.defaultSessionPolicy()
+}
+
+// sessionDeadline returns when a session must end regardless of activity, or
+// the zero time when the policy has no absolute lifetime
+func sessionDeadline(session *models.Session, policy models.SessionPolicy) time.Time {
+	if policy.AbsoluteLifetimeHours <= 0 {
+		return time.Time{}
+	}
+	return session.CreatedAt.Add(time.Duration(policy.AbsoluteLifetimeHours) * time.Hour)
+}
+
+// sessionExpiry returns the idle expiry for activity at now, capped at the deadline
+func sessionExpiry(session *models.Session, policy models.SessionPolicy, now time.Time) time.Time {
+	expiry := now.Add(time.Duration(policy.IdleTimeoutMinutes) * time.Minute)
+	if deadline := sessionDeadline(session, policy); !deadline.IsZero() && expiry.After(deadline) {
+		expiry = deadline
+	}
+	return expiry
+}
+
+// enforceSessionLimit deletes a user's oldest sessions until a new one fits
+// within the policy's concurrent session limit
+func (s *Service) enforceSessionLimit(userID int64, policy models.SessionPolicy) {
+	if policy.MaxSessions <= 0 {
+		return
+	}
+	sessions, err := s.sessionRepo.GetByUserID(userID)
+	if err != nil {
+		return
+	}
+	// Sessions are newest first; already expired ones don't count
+	now := time.Now()
+	var active []*models.Session
+	for _, session := range sessions {
+		if session.ExpiresAt.After(now) {
+			active = append(active, session)
+		}
+	}
+	for i := len(active) - 1; i >= policy.MaxSessions-1 && i >= 0; i-- {
+		s.sessionRepo.Delete(active[i].ID)
+	}
+}
+
+// TouchSession slides a session's idle expiry forward after activity. The
+// session's expiry is only rewritten when it moves by more than a minute, which
+// also applies a shortened idle timeout to sessions created before the change.
+func (s *Service) TouchSession(user *models.User, session *models.Session) error {
+	expiry := sessionExpiry(session, s.SessionPolicy(user.Role), time.Now())
+	diff := expiry.Sub(session.ExpiresAt)
+	if diff < sessionRenewInterval && diff > -sessionRenewInterval {
+		return nil
+	}
+	if err := s.sessionRepo.SetExpiry(session.ID, expiry); err != nil {
+		return err
+	}
+	session.ExpiresAt = expiry
+	return nil
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/database"
+	"stardeckos-backend/internal/models"
+)
+
+var (
+	ErrTwoFactorRequired    = errors.New("two-factor code required")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrTwoFactorUnavailable = errors.New("two-factor authentication is only available for local accounts")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+)
+
+// TOTP parameters, the defaults every authenticator app supports (RFC 6238)
+const (
+	totpDigits   = 6
+	totpPeriod   = 30
+	totpSkew     = 1 // Steps either side of now that are accepted, for clock drift
+	recoveryKeys = 10
+)
+
+var base32NoPad = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode computes the code for a time step
+func totpCode(key []byte, step int64) string {
+	mac := hmac.New(sha1.New, key)
+	binary.Write(mac, binary.BigEndian, step)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP returns the time step a code is valid for around now, or false
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := base32NoPad.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// normalizeCode drops the spaces and dashes people type into codes
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+// newRecoveryCodes generates single-use codes, formatted xxxxx-xxxxx
+func newRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryKeys)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(base32NoPad.EncodeToString(b))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// recoveryCodeKeys returns recovery codes in the form they are hashed in
+func recoveryCodeKeys(codes []string) []string {
+	keys := make([]string, len(codes))
+	for i, code := range codes {
+		keys[i] = normalizeCode(code)
+	}
+	return keys
+}
+
+// TwoFactorEnabled reports whether the user must give a second factor
+func (s *Service) TwoFactorEnabled(user *models.User) bool {
+	if user.AuthType != models.AuthTypeLocal {
+		return false
+	}
+	enabled, err := s.twoFactorRepo.IsEnabled(user.ID)
+	// Fail closed: a lookup error must not let a password alone through
+	return enabled || err != nil
+}
+
+// TwoFactorStatus reports the user's two-factor authentication state
+func (s *Service) TwoFactorStatus(user *models.User) models.TwoFactorStatus {
+	status := models.TwoFactorStatus{Available: user.AuthType == models.AuthTypeLocal}
+	tf, err := s.twoFactorRepo.Get(user.ID)
+	if err != nil || !tf.Enabled {
+		return status
+	}
+	status.Enabled, status.EnabledAt = true, tf.EnabledAt
+	status.RecoveryCodesRemaining, _ = s.twoFactorRepo.CountRecoveryCodes(user.ID)
+	return status
+}
+
+// SetupTwoFactor generates a secret for the user to add to an authenticator
+// app. It takes effect once VerifyTwoFactorSetup confirms a code from it.
+func (s *Service) SetupTwoFactor(user *models.User) (*models.TwoFactorSetupResponse, error) {
+	if user.AuthType != models.AuthTypeLocal {
+		return nil, ErrTwoFactorUnavailable
+	}
+	if enabled, err := s.twoFactorRepo.IsEnabled(user.ID); err != nil {
+		return nil, err
+	} else if enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	secret := base32NoPad.EncodeToString(key)
+	if err := s.twoFactorRepo.SetPending(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	issuer, _ := s.settingsRepo.Get(database.SettingBrandingName)
+	if issuer == "" {
+		issuer = "Stardeck"
+	}
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	otpURL := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + user.Username,
+		RawQuery: query.Encode(),
+	}
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURL: otpURL.String(),
+		Issuer:     issuer,
+		Account:    user.Username,
+		Digits:     totpDigits,
+		Period:     totpPeriod,
+	}, nil
+}
+
+// VerifyTwoFactorSetup enables a pending enrollment when the code matches
+// its secret and returns the recovery codes, which are only shown now
+func (s *Service) VerifyTwoFactorSetup(user *models.User, code string) ([]string, error) {
+	tf, err := s.twoFactorRepo.Get(user.ID)
+	if errors.Is(err, database.ErrTwoFactorNotFound) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	step, ok := matchTOTP(tf.Secret, normalizeCode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = database.WithTx(func(tx *sql.Tx) error {
+		repo := s.twoFactorRepo.InTx(tx)
+		if err := repo.Enable(user.ID, step); err != nil {
+			return err
+		}
+		return repo.ReplaceRecoveryCodes(user.ID, recoveryCodeKeys(codes))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkSecondFactor verifies a TOTP code or, failing that, a recovery code,
+// and reports whether a recovery code was spent
+func (s *Service) checkSecondFactor(user *models.User, code, recoveryCode string) (bool, error) {
+	if code = normalizeCode(code); code != "" {
+		tf, err := s.twoFactorRepo.Get(user.ID)
+		if err != nil {
+			return false, err
+		}
+		step, ok := matchTOTP(tf.Secret, code, time.Now())
+		if !ok {
+			return false, ErrInvalidTwoFactorCode
+		}
+		if fresh, err := s.twoFactorRepo.UseStep(user.ID, step); err != nil {
+			return false, err
+		} else if !fresh {
+			return false, ErrInvalidTwoFactorCode // Already used
+		}
+		return false, nil
+	}
+	if recoveryCode = normalizeCode(recoveryCode); recoveryCode != "" {
+		used, err := s.twoFactorRepo.UseRecoveryCode(user.ID, recoveryCode)
+		if err != nil {
+			return false, err
+		}
+		if !used {
+			return false, ErrInvalidTwoFactorCode
+		}
+		return true, nil
+	}
+	return false, ErrTwoFactorRequired
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking the
+// password and a second factor
+func (s *Service) DisableTwoFactor(user *models.User, req models.TwoFactorDisableRequest) error {
+	if !s.TwoFactorEnabled(user) {
+		return ErrTwoFactorNotEnabled
+	}
+	if valid, err := VerifyPassword(req.Password, user.PasswordHash); err != nil || !valid {
+		return ErrInvalidCredentials
+	}
+	if _, err := s.checkSecondFactor(user, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+	return s.twoFactorRepo.Delete(user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking
+// a current code
+func (s *Service) RegenerateRecoveryCodes(user *models.User, code string) ([]string, error) {
+	if !s.TwoFactorEnabled(user) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if normalizeCode(code) == "" {
+		return nil, ErrTwoFactorRequired
+	}
+	if _, err := s.checkSecondFactor(user, code, ""); err != nil {
+		return nil, err
+	}
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(user.ID, recoveryCodeKeys(codes)); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetTwoFactor removes a user's enrollment, for an admin to let back in a
+// user who lost their authenticator and recovery codes
+func (s *Service) ResetTwoFactor(userID int64) error {
+	return s.twoFactorRepo.Delete(userID)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// methodPropfind is the WebDAV directory listing, a read like GET
+const methodPropfind = "PROPFIND"
+
+// viewerSelfService lists the mutating routes a viewer may call; they only
+// touch the caller's own sessions, credentials and preferences
+var viewerSelfService = map[string]bool{
+	http.MethodDelete + " /api/auth/sessions/:id":     true,
+	http.MethodPost + " /api/auth/2fa/setup":          true,
+	http.MethodPost + " /api/auth/2fa/verify":         true,
+	http.MethodPost + " /api/auth/2fa/disable":        true,
+	http.MethodPost + " /api/auth/2fa/recovery-codes": true,
+	http.MethodPost + " /api/auth/tokens":             true,
+	http.MethodDelete + " /api/auth/tokens/:id":       true,
+	http.MethodPut + " /api/user/preferences":         true,
+	http.MethodPatch + " /api/user/preferences":       true,
+}
+
+// viewerBlockedSuffixes are read-method routes that still change state or open
+// an interactive session (WebSockets are upgraded from GET)
+var viewerBlockedSuffixes = []string{
+	"/exec",       // Container shell
+	"/attach",     // Container main process console
+	"/install",    // Podman installation
+	"/inspect/ws", // Pulls the image
+	"/deploy",     // Container and stack deployment
+	"/update",     // Container image update
+	"/pull",       // Stack image pull
+	"/teardown",   // Stack teardown
+}
+
+// ViewerAllowed reports whether a read-only viewer may make this request
+func ViewerAllowed(c echo.Context) bool {
+	method := c.Request().Method
+	route := c.Path()
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, methodPropfind:
+		return !isWriteRequest(c)
+	}
+	return viewerSelfService[method+" "+route]
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// AllianceHealthRepo handles the health check history of identity providers
+type AllianceHealthRepo struct {
+	db DBTX
+}
+
+// NewAllianceHealthRepo creates a new provider health repository
+func NewAllianceHealthRepo() *AllianceHealthRepo {
+	return &AllianceHealthRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *AllianceHealthRepo) InTx(tx *sql.Tx) *AllianceHealthRepo {
+	return &AllianceHealthRepo{db: tx}
+}
+
+// Record stores a health check
+func (r *AllianceHealthRepo) Record(check *models.ProviderHealthCheck) error {
+	if check.CheckedAt.IsZero() {
+		check.CheckedAt = time.Now()
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO alliance_provider_health (provider_id, healthy, discovery_ok, discovery_ms,
+			jwks_ok, jwks_ms, jwks_keys, token_ok, token_ms, error, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, check.ProviderID, check.Healthy, check.DiscoveryOK, check.DiscoveryMS,
+		check.JWKSOK, check.JWKSMS, check.JWKSKeys, check.TokenOK, check.TokenMS, check.Error, check.CheckedAt)
+	if err != nil {
+		return err
+	}
+	check.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListRecent returns a provider's latest checks, newest first
+func (r *AllianceHealthRepo) ListRecent(providerID string, limit int) ([]models.ProviderHealthCheck, error) {
+	rows, err := r.db.Query(`
+		SELECT id, provider_id, healthy, discovery_ok, discovery_ms, jwks_ok, jwks_ms,
+			jwks_keys, token_ok, token_ms, error, checked_at
+		FROM alliance_provider_health
+		WHERE provider_id = ?
+		ORDER BY checked_at DESC, id DESC LIMIT ?
+	`, providerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []models.ProviderHealthCheck{}
+	for rows.Next() {
+		var h models.ProviderHealthCheck
+		if err := rows.Scan(
+			&h.ID, &h.ProviderID, &h.Healthy, &h.DiscoveryOK, &h.DiscoveryMS, &h.JWKSOK, &h.JWKSMS,
+			&h.JWKSKeys, &h.TokenOK, &h.TokenMS, &h.Error, &h.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		checks = append(checks, h)
+	}
+	return checks, rows.Err()
+}
+
+// DeleteOlderThan removes checks made before cutoff and returns how many
+// were deleted
+func (r *AllianceHealthRepo) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM alliance_provider_health WHERE checked_at < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
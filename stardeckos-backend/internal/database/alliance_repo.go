@@ -10,11 +10,18 @@ import (
 )
 
 // AllianceRepo handles Alliance database operations
-type AllianceRepo struct{}
+type AllianceRepo struct {
+	db DBTX
+}
 
 // NewAllianceRepo creates a new Alliance repository
 func NewAllianceRepo() *AllianceRepo {
-	return &AllianceRepo{}
+	return &AllianceRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *AllianceRepo) InTx(tx *sql.Tx) *AllianceRepo {
+	return &AllianceRepo{db: tx}
 }
 
 // Provider operations
@@ -27,7 +34,7 @@ func (r *AllianceRepo) CreateProvider(provider *models.AllianceProvider) error {
 	provider.CreatedAt = time.Now()
 	provider.UpdatedAt = time.Now()
 
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		INSERT INTO alliance_providers (id, name, type, enabled, is_managed, container_id, config, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, provider.ID, provider.Name, provider.Type, provider.Enabled, provider.IsManaged,
@@ -39,7 +46,7 @@ func (r *AllianceRepo) CreateProvider(provider *models.AllianceProvider) error {
 func (r *AllianceRepo) GetProvider(id string) (*models.AllianceProvider, error) {
 	var p models.AllianceProvider
 	var containerID sql.NullString
-	err := DB.QueryRow(`
+	err := r.db.QueryRow(`
 		SELECT id, name, type, enabled, is_managed, container_id, config, created_at, updated_at
 		FROM alliance_providers WHERE id = ?
 	`, id).Scan(&p.ID, &p.Name, &p.Type, &p.Enabled, &p.IsManaged, &containerID,
@@ -55,7 +62,7 @@ func (r *AllianceRepo) GetProvider(id string) (*models.AllianceProvider, error)
 
 // ListProviders returns all providers
 func (r *AllianceRepo) ListProviders() ([]models.AllianceProvider, error) {
-	rows, err := DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT id, name, type, enabled, is_managed, container_id, config, created_at, updated_at
 		FROM alliance_providers ORDER BY created_at DESC
 	`)
@@ -82,7 +89,7 @@ func (r *AllianceRepo) ListProviders() ([]models.AllianceProvider, error) {
 
 // ListEnabledProviders returns only enabled providers
 func (r *AllianceRepo) ListEnabledProviders() ([]models.AllianceProvider, error) {
-	rows, err := DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT id, name, type, enabled, is_managed, container_id, config, created_at, updated_at
 		FROM alliance_providers WHERE enabled = 1 ORDER BY created_at DESC
 	`)
@@ -110,7 +117,7 @@ func (r *AllianceRepo) ListEnabledProviders() ([]models.AllianceProvider, error)
 // UpdateProvider updates a provider
 func (r *AllianceRepo) UpdateProvider(provider *models.AllianceProvider) error {
 	provider.UpdatedAt = time.Now()
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		UPDATE alliance_providers
 		SET name = ?, type = ?, enabled = ?, is_managed = ?, container_id = ?, config = ?, updated_at = ?
 		WHERE id = ?
@@ -121,14 +128,14 @@ func (r *AllianceRepo) UpdateProvider(provider *models.AllianceProvider) error {
 
 // DeleteProvider deletes a provider
 func (r *AllianceRepo) DeleteProvider(id string) error {
-	_, err := DB.Exec("DELETE FROM alliance_providers WHERE id = ?", id)
+	_, err := r.db.Exec("DELETE FROM alliance_providers WHERE id = ?", id)
 	return err
 }
 
 // CountProviders returns the number of providers
 func (r *AllianceRepo) CountProviders() (int, error) {
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM alliance_providers").Scan(&count)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM alliance_providers").Scan(&count)
 	return count, err
 }
 
@@ -141,60 +148,48 @@ func (r *AllianceRepo) CreateClient(client *models.AllianceClient) error {
 	}
 	client.CreatedAt = time.Now()
 	client.UpdatedAt = time.Now()
+	client.SecretCreatedAt = client.CreatedAt
 
-	_, err := DB.Exec(`
-		INSERT INTO alliance_clients (id, provider_id, container_id, app_name, client_id, client_secret, redirect_uris, scopes, sso_tier, config, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	_, err := r.db.Exec(`
+		INSERT INTO alliance_clients (id, provider_id, container_id, app_name, client_id, client_secret, redirect_uris, scopes, sso_tier, config, created_at, updated_at, secret_created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, client.ID, client.ProviderID, client.ContainerID, client.AppName, client.ClientID,
 		client.ClientSecret, client.RedirectURIs, client.Scopes, client.SSOTier,
-		client.Config, client.CreatedAt, client.UpdatedAt)
+		client.Config, client.CreatedAt, client.UpdatedAt, client.SecretCreatedAt)
 	return err
 }
 
-// GetClient retrieves a client by ID
-func (r *AllianceRepo) GetClient(id string) (*models.AllianceClient, error) {
+const allianceClientColumns = `id, provider_id, container_id, app_name, client_id, client_secret, redirect_uris, scopes, sso_tier, config, created_at, updated_at,
+	secret_created_at, previous_secret, previous_secret_expires_at, secret_age_notified_at`
+
+func scanAllianceClient(row rowScanner) (*models.AllianceClient, error) {
 	var c models.AllianceClient
 	var containerID sql.NullString
-	err := DB.QueryRow(`
-		SELECT id, provider_id, container_id, app_name, client_id, client_secret, redirect_uris, scopes, sso_tier, config, created_at, updated_at
-		FROM alliance_clients WHERE id = ?
-	`, id).Scan(&c.ID, &c.ProviderID, &containerID, &c.AppName, &c.ClientID,
+	var secretCreatedAt, previousExpiresAt, notifiedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.ProviderID, &containerID, &c.AppName, &c.ClientID,
 		&c.ClientSecret, &c.RedirectURIs, &c.Scopes, &c.SSOTier, &c.Config,
-		&c.CreatedAt, &c.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
+		&c.CreatedAt, &c.UpdatedAt,
+		&secretCreatedAt, &c.PreviousSecret, &previousExpiresAt, &notifiedAt); err != nil {
+		return nil, err
 	}
 	if containerID.Valid {
 		c.ContainerID = &containerID.String
 	}
-	return &c, err
-}
-
-// GetClientByContainerID retrieves a client by container ID
-func (r *AllianceRepo) GetClientByContainerID(containerID string) (*models.AllianceClient, error) {
-	var c models.AllianceClient
-	var cID sql.NullString
-	err := DB.QueryRow(`
-		SELECT id, provider_id, container_id, app_name, client_id, client_secret, redirect_uris, scopes, sso_tier, config, created_at, updated_at
-		FROM alliance_clients WHERE container_id = ?
-	`, containerID).Scan(&c.ID, &c.ProviderID, &cID, &c.AppName, &c.ClientID,
-		&c.ClientSecret, &c.RedirectURIs, &c.Scopes, &c.SSOTier, &c.Config,
-		&c.CreatedAt, &c.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	c.SecretCreatedAt = c.CreatedAt
+	if secretCreatedAt.Valid {
+		c.SecretCreatedAt = secretCreatedAt.Time
 	}
-	if cID.Valid {
-		c.ContainerID = &cID.String
+	if previousExpiresAt.Valid {
+		c.PreviousSecretExpiresAt = &previousExpiresAt.Time
 	}
-	return &c, err
+	if notifiedAt.Valid {
+		c.SecretAgeNotifiedAt = &notifiedAt.Time
+	}
+	return &c, nil
 }
 
-// ListClients returns all clients
-func (r *AllianceRepo) ListClients() ([]models.AllianceClient, error) {
-	rows, err := DB.Query(`
-		SELECT id, provider_id, container_id, app_name, client_id, client_secret, redirect_uris, scopes, sso_tier, config, created_at, updated_at
-		FROM alliance_clients ORDER BY created_at DESC
-	`)
+func (r *AllianceRepo) queryClients(query string, args ...interface{}) ([]models.AllianceClient, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -202,53 +197,54 @@ func (r *AllianceRepo) ListClients() ([]models.AllianceClient, error) {
 
 	var clients []models.AllianceClient
 	for rows.Next() {
-		var c models.AllianceClient
-		var containerID sql.NullString
-		if err := rows.Scan(&c.ID, &c.ProviderID, &containerID, &c.AppName, &c.ClientID,
-			&c.ClientSecret, &c.RedirectURIs, &c.Scopes, &c.SSOTier, &c.Config,
-			&c.CreatedAt, &c.UpdatedAt); err != nil {
+		c, err := scanAllianceClient(rows)
+		if err != nil {
 			return nil, err
 		}
-		if containerID.Valid {
-			c.ContainerID = &containerID.String
-		}
-		clients = append(clients, c)
+		clients = append(clients, *c)
 	}
 	return clients, rows.Err()
 }
 
-// ListClientsByProvider returns clients for a specific provider
-func (r *AllianceRepo) ListClientsByProvider(providerID string) ([]models.AllianceClient, error) {
-	rows, err := DB.Query(`
-		SELECT id, provider_id, container_id, app_name, client_id, client_secret, redirect_uris, scopes, sso_tier, config, created_at, updated_at
-		FROM alliance_clients WHERE provider_id = ? ORDER BY created_at DESC
-	`, providerID)
-	if err != nil {
-		return nil, err
+// GetClient retrieves a client by ID
+func (r *AllianceRepo) GetClient(id string) (*models.AllianceClient, error) {
+	c, err := scanAllianceClient(r.db.QueryRow(
+		"SELECT "+allianceClientColumns+" FROM alliance_clients WHERE id = ?", id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	defer rows.Close()
+	return c, err
+}
 
-	var clients []models.AllianceClient
-	for rows.Next() {
-		var c models.AllianceClient
-		var containerID sql.NullString
-		if err := rows.Scan(&c.ID, &c.ProviderID, &containerID, &c.AppName, &c.ClientID,
-			&c.ClientSecret, &c.RedirectURIs, &c.Scopes, &c.SSOTier, &c.Config,
-			&c.CreatedAt, &c.UpdatedAt); err != nil {
-			return nil, err
-		}
-		if containerID.Valid {
-			c.ContainerID = &containerID.String
-		}
-		clients = append(clients, c)
+// GetClientByContainerID retrieves a client by container ID
+func (r *AllianceRepo) GetClientByContainerID(containerID string) (*models.AllianceClient, error) {
+	c, err := scanAllianceClient(r.db.QueryRow(
+		"SELECT "+allianceClientColumns+" FROM alliance_clients WHERE container_id = ?", containerID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	return clients, rows.Err()
+	return c, err
+}
+
+// ListClients returns all clients
+func (r *AllianceRepo) ListClients() ([]models.AllianceClient, error) {
+	return r.queryClients("SELECT " + allianceClientColumns + " FROM alliance_clients ORDER BY created_at DESC")
+}
+
+// ListClientsByProvider returns clients for a specific provider
+func (r *AllianceRepo) ListClientsByProvider(providerID string) ([]models.AllianceClient, error) {
+	return r.queryClients(
+		"SELECT "+allianceClientColumns+" FROM alliance_clients WHERE provider_id = ? ORDER BY created_at DESC",
+		providerID,
+	)
 }
 
 // UpdateClient updates a client
 func (r *AllianceRepo) UpdateClient(client *models.AllianceClient) error {
 	client.UpdatedAt = time.Now()
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		UPDATE alliance_clients
 		SET provider_id = ?, container_id = ?, app_name = ?, client_id = ?, client_secret = ?, redirect_uris = ?, scopes = ?, sso_tier = ?, config = ?, updated_at = ?
 		WHERE id = ?
@@ -258,16 +254,50 @@ func (r *AllianceRepo) UpdateClient(client *models.AllianceClient) error {
 	return err
 }
 
+// RotateClientSecret replaces a client's secret. With previousUntil set the
+// current secret stays valid until then; without, it stops working at once.
+func (r *AllianceRepo) RotateClientSecret(id, secret string, previousUntil *time.Time) error {
+	now := time.Now()
+	_, err := r.db.Exec(`
+		UPDATE alliance_clients
+		SET previous_secret = CASE WHEN ? THEN client_secret ELSE '' END,
+			previous_secret_expires_at = ?,
+			client_secret = ?, secret_created_at = ?, secret_age_notified_at = NULL, updated_at = ?
+		WHERE id = ?
+	`, previousUntil != nil, previousUntil, secret, now, now, id)
+	return err
+}
+
+// RevokePreviousSecrets forgets replaced secrets whose grace period has
+// ended, returning how many were revoked
+func (r *AllianceRepo) RevokePreviousSecrets(now time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE alliance_clients SET previous_secret = '', previous_secret_expires_at = NULL
+		WHERE previous_secret_expires_at IS NOT NULL AND previous_secret_expires_at <= ?
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SetSecretAgeNotified records that admins were alerted about a client's
+// secret age
+func (r *AllianceRepo) SetSecretAgeNotified(id string, at time.Time) error {
+	_, err := r.db.Exec("UPDATE alliance_clients SET secret_age_notified_at = ? WHERE id = ?", at, id)
+	return err
+}
+
 // DeleteClient deletes a client
 func (r *AllianceRepo) DeleteClient(id string) error {
-	_, err := DB.Exec("DELETE FROM alliance_clients WHERE id = ?", id)
+	_, err := r.db.Exec("DELETE FROM alliance_clients WHERE id = ?", id)
 	return err
 }
 
 // CountClients returns the number of clients
 func (r *AllianceRepo) CountClients() (int, error) {
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM alliance_clients").Scan(&count)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM alliance_clients").Scan(&count)
 	return count, err
 }
 
@@ -281,7 +311,7 @@ func (r *AllianceRepo) CreateUser(user *models.AllianceUser) error {
 	user.CreatedAt = time.Now()
 	user.LastSync = time.Now()
 
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		INSERT INTO alliance_users (id, provider_id, external_id, username, email, display_name, groups, local_user_id, last_sync, created_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(provider_id, external_id) DO UPDATE SET
@@ -299,7 +329,7 @@ func (r *AllianceRepo) CreateUser(user *models.AllianceUser) error {
 func (r *AllianceRepo) GetUser(id string) (*models.AllianceUser, error) {
 	var u models.AllianceUser
 	var localUserID sql.NullInt64
-	err := DB.QueryRow(`
+	err := r.db.QueryRow(`
 		SELECT id, provider_id, external_id, username, email, display_name, groups, local_user_id, last_sync, created_at
 		FROM alliance_users WHERE id = ?
 	`, id).Scan(&u.ID, &u.ProviderID, &u.ExternalID, &u.Username, &u.Email,
@@ -317,7 +347,7 @@ func (r *AllianceRepo) GetUser(id string) (*models.AllianceUser, error) {
 func (r *AllianceRepo) GetUserByExternalID(providerID, externalID string) (*models.AllianceUser, error) {
 	var u models.AllianceUser
 	var localUserID sql.NullInt64
-	err := DB.QueryRow(`
+	err := r.db.QueryRow(`
 		SELECT id, provider_id, external_id, username, email, display_name, groups, local_user_id, last_sync, created_at
 		FROM alliance_users WHERE provider_id = ? AND external_id = ?
 	`, providerID, externalID).Scan(&u.ID, &u.ProviderID, &u.ExternalID, &u.Username, &u.Email,
@@ -333,7 +363,7 @@ func (r *AllianceRepo) GetUserByExternalID(providerID, externalID string) (*mode
 
 // ListUsers returns all alliance users
 func (r *AllianceRepo) ListUsers() ([]models.AllianceUser, error) {
-	rows, err := DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT id, provider_id, external_id, username, email, display_name, groups, local_user_id, last_sync, created_at
 		FROM alliance_users ORDER BY username
 	`)
@@ -360,7 +390,7 @@ func (r *AllianceRepo) ListUsers() ([]models.AllianceUser, error) {
 
 // ListUsersByProvider returns users for a specific provider
 func (r *AllianceRepo) ListUsersByProvider(providerID string) ([]models.AllianceUser, error) {
-	rows, err := DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT id, provider_id, external_id, username, email, display_name, groups, local_user_id, last_sync, created_at
 		FROM alliance_users WHERE provider_id = ? ORDER BY username
 	`, providerID)
@@ -387,7 +417,7 @@ func (r *AllianceRepo) ListUsersByProvider(providerID string) ([]models.Alliance
 
 // UpdateAllianceUser updates an alliance user's information
 func (r *AllianceRepo) UpdateAllianceUser(user *models.AllianceUser) error {
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		UPDATE alliance_users
 		SET username = ?, email = ?, display_name = ?, groups = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
@@ -397,7 +427,7 @@ func (r *AllianceRepo) UpdateAllianceUser(user *models.AllianceUser) error {
 
 // LinkLocalUser links an alliance user to a local Stardeck user
 func (r *AllianceRepo) LinkLocalUser(allianceUserID string, localUserID int64) error {
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		UPDATE alliance_users SET local_user_id = ? WHERE id = ?
 	`, localUserID, allianceUserID)
 	return err
@@ -405,20 +435,20 @@ func (r *AllianceRepo) LinkLocalUser(allianceUserID string, localUserID int64) e
 
 // DeleteUser deletes an alliance user
 func (r *AllianceRepo) DeleteUser(id string) error {
-	_, err := DB.Exec("DELETE FROM alliance_users WHERE id = ?", id)
+	_, err := r.db.Exec("DELETE FROM alliance_users WHERE id = ?", id)
 	return err
 }
 
 // DeleteUsersByProvider deletes all users for a provider
 func (r *AllianceRepo) DeleteUsersByProvider(providerID string) error {
-	_, err := DB.Exec("DELETE FROM alliance_users WHERE provider_id = ?", providerID)
+	_, err := r.db.Exec("DELETE FROM alliance_users WHERE provider_id = ?", providerID)
 	return err
 }
 
 // CountUsers returns the number of alliance users
 func (r *AllianceRepo) CountUsers() (int, error) {
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM alliance_users").Scan(&count)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM alliance_users").Scan(&count)
 	return count, err
 }
 
@@ -432,7 +462,7 @@ func (r *AllianceRepo) CreateGroup(group *models.AllianceGroup) error {
 	group.CreatedAt = time.Now()
 	group.LastSync = time.Now()
 
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		INSERT INTO alliance_groups (id, provider_id, external_id, name, description, local_group_id, last_sync, created_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(provider_id, external_id) DO UPDATE SET
@@ -448,7 +478,7 @@ func (r *AllianceRepo) CreateGroup(group *models.AllianceGroup) error {
 func (r *AllianceRepo) GetGroup(id string) (*models.AllianceGroup, error) {
 	var g models.AllianceGroup
 	var localGroupID sql.NullInt64
-	err := DB.QueryRow(`
+	err := r.db.QueryRow(`
 		SELECT id, provider_id, external_id, name, description, local_group_id, last_sync, created_at
 		FROM alliance_groups WHERE id = ?
 	`, id).Scan(&g.ID, &g.ProviderID, &g.ExternalID, &g.Name, &g.Description,
@@ -464,7 +494,7 @@ func (r *AllianceRepo) GetGroup(id string) (*models.AllianceGroup, error) {
 
 // ListGroups returns all alliance groups
 func (r *AllianceRepo) ListGroups() ([]models.AllianceGroup, error) {
-	rows, err := DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT id, provider_id, external_id, name, description, local_group_id, last_sync, created_at
 		FROM alliance_groups ORDER BY name
 	`)
@@ -491,7 +521,7 @@ func (r *AllianceRepo) ListGroups() ([]models.AllianceGroup, error) {
 
 // ListGroupsByProvider returns groups for a specific provider
 func (r *AllianceRepo) ListGroupsByProvider(providerID string) ([]models.AllianceGroup, error) {
-	rows, err := DB.Query(`
+	rows, err := r.db.Query(`
 		SELECT id, provider_id, external_id, name, description, local_group_id, last_sync, created_at
 		FROM alliance_groups WHERE provider_id = ? ORDER BY name
 	`, providerID)
@@ -518,7 +548,7 @@ func (r *AllianceRepo) ListGroupsByProvider(providerID string) ([]models.Allianc
 
 // LinkLocalGroup links an alliance group to a local Stardeck group
 func (r *AllianceRepo) LinkLocalGroup(allianceGroupID string, localGroupID int64) error {
-	_, err := DB.Exec(`
+	_, err := r.db.Exec(`
 		UPDATE alliance_groups SET local_group_id = ? WHERE id = ?
 	`, localGroupID, allianceGroupID)
 	return err
@@ -526,20 +556,20 @@ func (r *AllianceRepo) LinkLocalGroup(allianceGroupID string, localGroupID int64
 
 // DeleteGroup deletes an alliance group
 func (r *AllianceRepo) DeleteGroup(id string) error {
-	_, err := DB.Exec("DELETE FROM alliance_groups WHERE id = ?", id)
+	_, err := r.db.Exec("DELETE FROM alliance_groups WHERE id = ?", id)
 	return err
 }
 
 // DeleteGroupsByProvider deletes all groups for a provider
 func (r *AllianceRepo) DeleteGroupsByProvider(providerID string) error {
-	_, err := DB.Exec("DELETE FROM alliance_groups WHERE provider_id = ?", providerID)
+	_, err := r.db.Exec("DELETE FROM alliance_groups WHERE provider_id = ?", providerID)
 	return err
 }
 
 // CountGroups returns the number of alliance groups
 func (r *AllianceRepo) CountGroups() (int, error) {
 	var count int
-	err := DB.QueryRow("SELECT COUNT(*) FROM alliance_groups").Scan(&count)
+	err := r.db.QueryRow("SELECT COUNT(*) FROM alliance_groups").Scan(&count)
 	return count, err
 }
 
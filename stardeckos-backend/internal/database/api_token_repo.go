@@ -0,0 +1,150 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+var ErrAPITokenNotFound = errors.New("API token not found")
+
+// APITokenRepo handles API token database operations
+type APITokenRepo struct {
+	db DBTX
+}
+
+// NewAPITokenRepo creates a new API token repository
+func NewAPITokenRepo() *APITokenRepo {
+	return &APITokenRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *APITokenRepo) InTx(tx *sql.Tx) *APITokenRepo {
+	return &APITokenRepo{db: tx}
+}
+
+const apiTokenColumns = `
+	t.id, t.user_id, COALESCE(u.username, ''), t.name, t.prefix, t.scopes, t.expires_at,
+	t.created_at, t.last_used_at, t.last_used_ip, t.revoked_at
+	FROM api_tokens t LEFT JOIN users u ON u.id = t.user_id`
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	token := &models.APIToken{}
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Username, &token.Name, &token.Prefix, &scopes, &expiresAt,
+		&token.CreatedAt, &lastUsedAt, &token.LastUsedIP, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil || token.Scopes == nil {
+		token.Scopes = []string{}
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+// Create generates a token, stores its hash and returns the plain token
+func (r *APITokenRepo) Create(token *models.APIToken) (string, error) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", err
+	}
+	secret := models.APITokenPrefix + hex.EncodeToString(secretBytes)
+	scopes, err := json.Marshal(token.Scopes)
+	if err != nil {
+		return "", err
+	}
+
+	token.Prefix = secret[:len(models.APITokenPrefix)+8]
+	token.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO api_tokens (user_id, name, token_hash, prefix, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, token.UserID, token.Name, hashToken(secret), token.Prefix, string(scopes), token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+	token.ID, _ = result.LastInsertId()
+	return secret, nil
+}
+
+// GetByID retrieves a token by ID
+func (r *APITokenRepo) GetByID(id int64) (*models.APIToken, error) {
+	return scanAPIToken(r.db.QueryRow("SELECT "+apiTokenColumns+" WHERE t.id = ?", id))
+}
+
+// GetByToken retrieves a token by its plain value
+func (r *APITokenRepo) GetByToken(secret string) (*models.APIToken, error) {
+	return scanAPIToken(r.db.QueryRow("SELECT "+apiTokenColumns+" WHERE t.token_hash = ?", hashToken(secret)))
+}
+
+// List returns the tokens of a user, or of everyone when userID is 0,
+// newest first
+func (r *APITokenRepo) List(userID int64) ([]models.APIToken, error) {
+	query := "SELECT " + apiTokenColumns
+	var args []interface{}
+	if userID != 0 {
+		query += " WHERE t.user_id = ?"
+		args = append(args, userID)
+	}
+	rows, err := r.db.Query(query+" ORDER BY t.created_at DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke marks a token revoked; it stays listed so its use can be traced
+func (r *APITokenRepo) Revoke(id int64) error {
+	result, err := r.db.Exec("UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// RevokeAllForUser revokes every active token of a user and returns how
+// many there were
+func (r *APITokenRepo) RevokeAllForUser(userID int64) (int64, error) {
+	result, err := r.db.Exec("UPDATE api_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", time.Now(), userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// TouchLastUsed records when and from where a token was last used
+func (r *APITokenRepo) TouchLastUsed(id int64, ip string) error {
+	_, err := r.db.Exec("UPDATE api_tokens SET last_used_at = ?, last_used_ip = ? WHERE id = ?", time.Now(), ip, id)
+	return err
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ApprovalRepo handles four-eyes approval request database operations
+type ApprovalRepo struct {
+	db *sql.DB
+}
+
+// NewApprovalRepo creates a new approval repository
+func NewApprovalRepo() *ApprovalRepo {
+	return &ApprovalRepo{db: DB}
+}
+
+const approvalColumns = `
+	a.id, a.action, a.method, a.path, a.query, a.body, a.fingerprint, a.status, a.reason,
+	a.requested_by, COALESCE(r.username, ''), a.decided_by, COALESCE(d.username, ''),
+	a.created_at, a.decided_at, a.expires_at, a.executed_at`
+
+const approvalFrom = `
+	FROM approval_requests a
+	LEFT JOIN users r ON r.id = a.requested_by
+	LEFT JOIN users d ON d.id = a.decided_by`
+
+// Create adds a new pending approval request
+func (r *ApprovalRepo) Create(a *models.ApprovalRequest) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	a.Status = models.ApprovalPending
+	a.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO approval_requests (
+			id, action, method, path, query, body, fingerprint, status, reason,
+			requested_by, created_at, expires_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		a.ID, a.Action, a.Method, a.Path, a.Query, a.Body, a.Fingerprint, a.Status, a.Reason,
+		a.RequestedBy, a.CreatedAt, a.ExpiresAt,
+	)
+	return err
+}
+
+// GetByID retrieves an approval request by ID
+func (r *ApprovalRepo) GetByID(id string) (*models.ApprovalRequest, error) {
+	return scanApproval(r.db.QueryRow("SELECT "+approvalColumns+approvalFrom+" WHERE a.id = ?", id))
+}
+
+// List returns approval requests, newest first, optionally filtered by status
+func (r *ApprovalRepo) List(status string) ([]models.ApprovalRequest, error) {
+	query := "SELECT " + approvalColumns + approvalFrom
+	var args []interface{}
+	if status != "" {
+		query += " WHERE a.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY a.created_at DESC LIMIT 200"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var approvals []models.ApprovalRequest
+	for rows.Next() {
+		a, err := scanApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, *a)
+	}
+	return approvals, rows.Err()
+}
+
+// Decide moves a pending request to approved or rejected. It returns false if the
+// request was no longer pending, so two reviewers cannot both decide it.
+func (r *ApprovalRepo) Decide(id string, status models.ApprovalStatus, decidedBy int64, reason string, expiresAt time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE approval_requests SET status = ?, decided_by = ?, decided_at = ?, expires_at = ?,
+			reason = CASE WHEN ? = '' THEN reason ELSE ? END
+		WHERE id = ? AND status = 'pending'
+	`, status, decidedBy, time.Now(), expiresAt, reason, reason, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// MarkExecuted consumes an approved request. It returns false if the request was
+// not approved or was already used.
+func (r *ApprovalRepo) MarkExecuted(id string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE approval_requests SET status = 'executed', executed_at = ?
+		WHERE id = ? AND status = 'approved'
+	`, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// ExpireStale marks pending and approved requests past their deadline as expired
+func (r *ApprovalRepo) ExpireStale(now time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE approval_requests SET status = 'expired'
+		WHERE status IN ('pending', 'approved') AND expires_at < ?
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanApproval(row rowScanner) (*models.ApprovalRequest, error) {
+	a := &models.ApprovalRequest{}
+	var query, body, reason sql.NullString
+	var decidedBy sql.NullInt64
+	var decidedAt, executedAt sql.NullTime
+	if err := row.Scan(
+		&a.ID, &a.Action, &a.Method, &a.Path, &query, &body, &a.Fingerprint, &a.Status, &reason,
+		&a.RequestedBy, &a.Requester, &decidedBy, &a.Decider,
+		&a.CreatedAt, &decidedAt, &a.ExpiresAt, &executedAt,
+	); err != nil {
+		return nil, err
+	}
+	a.Query = query.String
+	a.Body = body.String
+	a.Reason = reason.String
+	if decidedBy.Valid {
+		a.DecidedBy = &decidedBy.Int64
+	}
+	if decidedAt.Valid {
+		a.DecidedAt = &decidedAt.Time
+	}
+	if executedAt.Valid {
+		a.ExecutedAt = &executedAt.Time
+	}
+	return a, nil
+}
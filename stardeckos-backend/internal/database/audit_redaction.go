@@ -0,0 +1,173 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// AuditRedacted replaces secret values in audit details
+const AuditRedacted = "[REDACTED]"
+
+var (
+	// auditSecretKeyPattern matches detail keys with a secret word as one of
+	// their segments, such as db_password or client_secret but not tokenizer
+	auditSecretKeyPattern = regexp.MustCompile(`(^|_)(password|passwd|passphrase|secrets?|tokens?|api_?key|private_?key|credentials?|authorization|cookies?)($|_)`)
+
+	// auditKeptKeyPattern matches keys that name or describe a secret rather
+	// than hold it, such as token_id or secret_created_at
+	auditKeptKeyPattern = regexp.MustCompile(`_(id|ids|at|days|name|names|count|type|ref)$`)
+
+	// auditCamelBoundary splits camelCase keys into segments
+	auditCamelBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+	// auditEnvKeyPattern matches environment details. Variable names are kept
+	// so the entry still shows what changed; their values are not.
+	auditEnvKeyPattern = regexp.MustCompile(`^(env|envs|environment|env_vars|variables|env_content|dotenv)$`)
+
+	// auditContentKeyPattern matches file contents, such as a compose file,
+	// that may embed secrets anywhere
+	auditContentKeyPattern = regexp.MustCompile(`^(content|compose|compose_content|compose_yaml|file_content)$`)
+)
+
+// RedactAuditDetails removes secret values from an audit entry's details JSON.
+// Details that aren't JSON, or hold nothing to redact, are returned unchanged.
+//
+// Redaction doesn't depend on who reads the log: details are redacted before
+// they are stored, so admins see the same [REDACTED] values as everyone else
+// and a secret never outlives its rotation in the audit log or its backups.
+// Entries stored before redaction existed are redacted again when read.
+func RedactAuditDetails(details string) string {
+	if details == "" {
+		return details
+	}
+	dec := json.NewDecoder(strings.NewReader(details))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return details
+	}
+
+	redacted, changed := redactAuditValue(v)
+	if !changed {
+		return details
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redacted); err != nil {
+		return "{}"
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// redactAuditValue walks decoded details, redacting by key
+func redactAuditValue(v interface{}) (interface{}, bool) {
+	changed := false
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			key := auditKeyName(k)
+			var redacted interface{}
+			var c bool
+			switch {
+			case auditSecretKeyPattern.MatchString(key) && !auditKeptKeyPattern.MatchString(key),
+				auditContentKeyPattern.MatchString(key):
+				redacted, c = redactAuditSecret(child)
+			case auditEnvKeyPattern.MatchString(key):
+				redacted, c = redactAuditEnv(child)
+			default:
+				redacted, c = redactAuditValue(child)
+			}
+			if c {
+				val[k] = redacted
+				changed = true
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			if redacted, c := redactAuditValue(child); c {
+				val[i] = redacted
+				changed = true
+			}
+		}
+	}
+	return v, changed
+}
+
+// auditKeyName turns a detail key into lower snake case segments, so
+// clientSecret, Client-Secret and client_secret are matched alike
+func auditKeyName(k string) string {
+	k = auditCamelBoundary.ReplaceAllString(k, "${1}_${2}")
+	return strings.ReplaceAll(strings.ToLower(k), "-", "_")
+}
+
+// redactAuditSecret replaces a secret value. Empty values stay as they are,
+// since they show that a secret was cleared or never set.
+func redactAuditSecret(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case nil:
+		return v, false
+	case string:
+		if val == "" {
+			return v, false
+		}
+	case bool:
+		return v, false
+	}
+	return AuditRedacted, true
+}
+
+// redactAuditEnv keeps the variable names of an environment, given as a map,
+// a list of NAME=value entries or .env content, and redacts the values
+func redactAuditEnv(v interface{}) (interface{}, bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		changed := false
+		for k, child := range val {
+			if redacted, c := redactAuditSecret(child); c {
+				val[k] = redacted
+				changed = true
+			}
+		}
+		return val, changed
+	case []interface{}:
+		changed := false
+		for i, child := range val {
+			if s, ok := child.(string); ok {
+				if redacted := redactEnvLines(s); redacted != s {
+					val[i] = redacted
+					changed = true
+				}
+				continue
+			}
+			if redacted, c := redactAuditEnv(child); c {
+				val[i] = redacted
+				changed = true
+			}
+		}
+		return val, changed
+	case string:
+		redacted := redactEnvLines(val)
+		return redacted, redacted != val
+	}
+	return redactAuditSecret(v)
+}
+
+// redactEnvLines redacts the values of NAME=value lines
+func redactEnvLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// A bare NAME passes the host's value through and holds no secret
+		name, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(value) != "" {
+			lines[i] = name + "=" + AuditRedacted
+		}
+	}
+	return strings.Join(lines, "\n")
+}
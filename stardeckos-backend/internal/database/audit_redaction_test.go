@@ -0,0 +1,121 @@
+package database
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// redactJSON redacts details and decodes the result for comparison
+func redactJSON(t *testing.T, details string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(RedactAuditDetails(details)), &v); err != nil {
+		t.Fatalf("redacted details are not JSON: %v", err)
+	}
+	return v
+}
+
+func decodeJSON(t *testing.T, details string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(details), &v); err != nil {
+		t.Fatalf("bad test JSON: %v", err)
+	}
+	return v
+}
+
+func TestRedactAuditDetailsKeyPatterns(t *testing.T) {
+	for _, tt := range []struct {
+		key    string
+		redact bool
+	}{
+		{"password", true},
+		{"db_password", true},
+		{"passwd", true},
+		{"passphrase", true},
+		{"client_secret", true},
+		{"clientSecret", true},
+		{"SECRET", true},
+		{"new_secret_value", true},
+		{"token", true},
+		{"access_token", true},
+		{"X-Auth-Token", true},
+		{"api_key", true},
+		{"apikey", true},
+		{"apiKey", true},
+		{"private_key", true},
+		{"privateKey", true},
+		{"credentials", true},
+		{"Authorization", true},
+		{"cookie", true},
+		{"content", true},
+		{"compose_yaml", true},
+
+		// Keys that only name or describe a secret
+		{"token_id", false},
+		{"tokenId", false},
+		{"token_name", false},
+		{"api_token_ref", false},
+		{"secret_created_at", false},
+		{"secret_age_days", false},
+		{"secret_count", false},
+		{"tokenizer", false},
+		{"passwordless", false},
+		{"keyboard", false},
+	} {
+		details := `{"` + tt.key + `":"hunter2","name":"web"}`
+		want := map[string]interface{}{tt.key: "hunter2", "name": "web"}
+		if tt.redact {
+			want[tt.key] = AuditRedacted
+		}
+		if got := redactJSON(t, details); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", tt.key, got, want)
+		}
+	}
+}
+
+func TestRedactAuditDetailsNested(t *testing.T) {
+	details := `{
+		"container": {"name": "db", "env": {"POSTGRES_PASSWORD": "hunter2", "TZ": "UTC"}},
+		"registries": [{"url": "ghcr.io", "token": "ghp_abc"}, {"url": "quay.io", "password": ""}],
+		"options": {"auth": {"api-key": 12345, "secret_enabled": true}}
+	}`
+	want := decodeJSON(t, `{
+		"container": {"name": "db", "env": {"POSTGRES_PASSWORD": "[REDACTED]", "TZ": "[REDACTED]"}},
+		"registries": [{"url": "ghcr.io", "token": "[REDACTED]"}, {"url": "quay.io", "password": ""}],
+		"options": {"auth": {"api-key": "[REDACTED]", "secret_enabled": true}}
+	}`)
+	if got := redactJSON(t, details); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRedactAuditDetailsEnvForms(t *testing.T) {
+	details := `{
+		"environment": ["DB_HOST=db", "DB_PASSWORD=hunter2", "PATH"],
+		"env_content": "# database\nDB_USER=app\nDB_PASS=hunter2\n",
+		"env_vars": [{"DB_PASSWORD": "hunter2"}]
+	}`
+	want := decodeJSON(t, `{
+		"environment": ["DB_HOST=[REDACTED]", "DB_PASSWORD=[REDACTED]", "PATH"],
+		"env_content": "# database\nDB_USER=[REDACTED]\nDB_PASS=[REDACTED]\n",
+		"env_vars": [{"DB_PASSWORD": "[REDACTED]"}]
+	}`)
+	if got := redactJSON(t, details); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRedactAuditDetailsUnchanged(t *testing.T) {
+	for _, details := range []string{
+		"",
+		"not json",
+		`{"name":"web","image":"nginx:latest"}`,
+		`{"ports":[80,443]}`,
+	} {
+		if got := RedactAuditDetails(details); got != details {
+			t.Errorf("RedactAuditDetails(%q) = %q, want it unchanged", details, got)
+		}
+	}
+}
@@ -16,8 +16,10 @@ func NewAuditRepo() *AuditRepo {
 	return &AuditRepo{}
 }
 
-// Create creates a new audit log entry
+// Create creates a new audit log entry. Secret values in its details are
+// redacted before it is stored, for every role (see RedactAuditDetails).
 func (r *AuditRepo) Create(log *models.AuditLog) error {
+	log.Details = RedactAuditDetails(log.Details)
 	result, err := DB.Exec(`
 		INSERT INTO audit_logs (timestamp, user_id, username, action, target, details, ip_address)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
@@ -136,7 +138,7 @@ func (r *AuditRepo) List(filter models.AuditFilter) ([]*models.AuditLog, int, er
 			log.Target = target.String
 		}
 		if details.Valid {
-			log.Details = details.String
+			log.Details = RedactAuditDetails(details.String) // Entries written before redaction existed
 		}
 		if ipAddress.Valid {
 			log.IPAddress = ipAddress.String
@@ -178,7 +180,7 @@ func (r *AuditRepo) GetByID(id int64) (*models.AuditLog, error) {
 		log.Target = target.String
 	}
 	if details.Valid {
-		log.Details = details.String
+		log.Details = RedactAuditDetails(details.String) // Entries written before redaction existed
 	}
 	if ipAddress.Valid {
 		log.IPAddress = ipAddress.String
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// AutoUpdateRepo handles container auto-update policies and the update history
+type AutoUpdateRepo struct {
+	db DBTX
+}
+
+// NewAutoUpdateRepo creates a new auto-update repository
+func NewAutoUpdateRepo() *AutoUpdateRepo {
+	return &AutoUpdateRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *AutoUpdateRepo) InTx(tx *sql.Tx) *AutoUpdateRepo {
+	return &AutoUpdateRepo{db: tx}
+}
+
+const autoUpdateQuery = `
+	SELECT a.container_id, c.name, a.enabled, a.policy, a.check_interval_hours,
+		a.window_schedule, a.window_minutes, a.timezone, a.create_backup, a.remove_old,
+		a.update_available, a.local_digest, a.remote_digest, a.notified_digest,
+		a.last_checked_at, a.last_attempt_at, a.last_error, a.created_at, a.updated_at, a.created_by
+	FROM container_auto_updates a JOIN containers c ON c.id = a.container_id`
+
+// scanAutoUpdate scans an auto-update policy row
+func scanAutoUpdate(row rowScanner) (*models.ContainerAutoUpdate, error) {
+	a := &models.ContainerAutoUpdate{}
+	var lastCheckedAt, lastAttemptAt sql.NullTime
+	var createdBy sql.NullInt64
+	if err := row.Scan(
+		&a.ContainerID, &a.ContainerName, &a.Enabled, &a.Policy, &a.CheckIntervalHours,
+		&a.WindowSchedule, &a.WindowMinutes, &a.Timezone, &a.CreateBackup, &a.RemoveOld,
+		&a.UpdateAvailable, &a.LocalDigest, &a.RemoteDigest, &a.NotifiedDigest,
+		&lastCheckedAt, &lastAttemptAt, &a.LastError, &a.CreatedAt, &a.UpdatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	if lastCheckedAt.Valid {
+		a.LastCheckedAt = &lastCheckedAt.Time
+	}
+	if lastAttemptAt.Valid {
+		a.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if createdBy.Valid {
+		a.CreatedBy = &createdBy.Int64
+	}
+	return a, nil
+}
+
+// Get retrieves the auto-update policy for a Stardeck container ID
+func (r *AutoUpdateRepo) Get(containerID string) (*models.ContainerAutoUpdate, error) {
+	return scanAutoUpdate(r.db.QueryRow(autoUpdateQuery+" WHERE a.container_id = ?", containerID))
+}
+
+// List returns all policies ordered by container name
+func (r *AutoUpdateRepo) List() ([]models.ContainerAutoUpdate, error) {
+	return r.list(autoUpdateQuery + " ORDER BY c.name")
+}
+
+// ListEnabled returns the policies the scheduler should evaluate
+func (r *AutoUpdateRepo) ListEnabled() ([]models.ContainerAutoUpdate, error) {
+	return r.list(autoUpdateQuery + " WHERE a.enabled = 1")
+}
+
+func (r *AutoUpdateRepo) list(query string) ([]models.ContainerAutoUpdate, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []models.ContainerAutoUpdate{}
+	for rows.Next() {
+		a, err := scanAutoUpdate(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *a)
+	}
+	return policies, rows.Err()
+}
+
+// Upsert creates or replaces a container's policy, keeping its check state
+func (r *AutoUpdateRepo) Upsert(a *models.ContainerAutoUpdate) error {
+	a.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO container_auto_updates (
+			container_id, enabled, policy, check_interval_hours, window_schedule, window_minutes,
+			timezone, create_backup, remove_old, created_at, updated_at, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(container_id) DO UPDATE SET
+			enabled = excluded.enabled, policy = excluded.policy,
+			check_interval_hours = excluded.check_interval_hours,
+			window_schedule = excluded.window_schedule, window_minutes = excluded.window_minutes,
+			timezone = excluded.timezone, create_backup = excluded.create_backup,
+			remove_old = excluded.remove_old, updated_at = excluded.updated_at
+	`, a.ContainerID, a.Enabled, a.Policy, a.CheckIntervalHours, a.WindowSchedule, a.WindowMinutes,
+		a.Timezone, a.CreateBackup, a.RemoveOld, a.UpdatedAt, a.UpdatedAt, a.CreatedBy)
+	return err
+}
+
+// RecordCheck stores the outcome of an image check. A failed check keeps the
+// digests of the last good one.
+func (r *AutoUpdateRepo) RecordCheck(containerID string, available bool, local, remote, errMsg string) error {
+	if errMsg != "" {
+		_, err := r.db.Exec(
+			"UPDATE container_auto_updates SET last_checked_at = ?, last_error = ? WHERE container_id = ?",
+			time.Now(), errMsg, containerID,
+		)
+		return err
+	}
+	_, err := r.db.Exec(`
+		UPDATE container_auto_updates SET update_available = ?, local_digest = ?, remote_digest = ?,
+			last_checked_at = ?, last_error = ''
+		WHERE container_id = ?
+	`, available, local, remote, time.Now(), containerID)
+	return err
+}
+
+// SetNotified records the remote digest admins were told about
+func (r *AutoUpdateRepo) SetNotified(containerID, digest string) error {
+	_, err := r.db.Exec("UPDATE container_auto_updates SET notified_digest = ? WHERE container_id = ?", digest, containerID)
+	return err
+}
+
+// RecordAttempt stores when an automatic update ran and its error, empty
+// when it succeeded
+func (r *AutoUpdateRepo) RecordAttempt(containerID string, at time.Time, errMsg string) error {
+	_, err := r.db.Exec(
+		"UPDATE container_auto_updates SET last_attempt_at = ?, last_error = ? WHERE container_id = ?",
+		at, errMsg, containerID,
+	)
+	return err
+}
+
+// MarkUpdated notes that a container now runs the image it was last checked
+// against, however it was updated
+func (r *AutoUpdateRepo) MarkUpdated(containerID string) error {
+	_, err := r.db.Exec(`
+		UPDATE container_auto_updates SET update_available = 0, local_digest = remote_digest
+		WHERE container_id = ? AND update_available = 1
+	`, containerID)
+	return err
+}
+
+// Delete removes a container's policy
+func (r *AutoUpdateRepo) Delete(containerID string) error {
+	_, err := r.db.Exec("DELETE FROM container_auto_updates WHERE container_id = ?", containerID)
+	return err
+}
+
+// AddHistory records a finished update
+func (r *AutoUpdateRepo) AddHistory(h *models.ContainerUpdateHistory) error {
+	result, err := r.db.Exec(`
+		INSERT INTO container_update_history (
+			container_id, container_name, source, triggered_by, old_image, new_image,
+			old_digest, new_digest, status, error, backup_container, started_at, finished_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, h.ContainerID, h.ContainerName, h.Trigger, h.TriggeredBy, h.OldImage, h.NewImage,
+		h.OldDigest, h.NewDigest, h.Status, h.Error, h.BackupContainer, h.StartedAt, h.FinishedAt)
+	if err != nil {
+		return err
+	}
+	h.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListHistory returns the most recent updates, newest first, of one container
+// or of all of them when containerID is empty
+func (r *AutoUpdateRepo) ListHistory(containerID string, limit int) ([]models.ContainerUpdateHistory, error) {
+	query := `
+		SELECT id, container_id, container_name, source, triggered_by, old_image, new_image,
+			old_digest, new_digest, status, error, backup_container, started_at, finished_at
+		FROM container_update_history`
+	args := []interface{}{}
+	if containerID != "" {
+		query += " WHERE container_id = ?"
+		args = append(args, containerID)
+	}
+	query += " ORDER BY started_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.ContainerUpdateHistory{}
+	for rows.Next() {
+		var h models.ContainerUpdateHistory
+		var finishedAt sql.NullTime
+		if err := rows.Scan(
+			&h.ID, &h.ContainerID, &h.ContainerName, &h.Trigger, &h.TriggeredBy, &h.OldImage, &h.NewImage,
+			&h.OldDigest, &h.NewDigest, &h.Status, &h.Error, &h.BackupContainer, &h.StartedAt, &finishedAt,
+		); err != nil {
+			return nil, err
+		}
+		if finishedAt.Valid {
+			h.FinishedAt = &finishedAt.Time
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"stardeckos-backend/internal/models"
+)
+
+// maxBootReports is how many reconciliation reports are retained
+const maxBootReports = 20
+
+// BootReportRepo handles boot reconciliation report storage
+type BootReportRepo struct {
+	db *sql.DB
+}
+
+// NewBootReportRepo creates a new boot report repository
+func NewBootReportRepo() *BootReportRepo {
+	return &BootReportRepo{db: DB}
+}
+
+// Create stores a report and prunes old ones
+func (r *BootReportRepo) Create(report *models.BootReport) error {
+	results, err := json.Marshal(report.Results)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.db.Exec(`
+		INSERT INTO boot_reports (
+			boot_id, trigger_type, started_at, finished_at, total,
+			already_running, started, failed, missing, results
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		report.BootID, report.Trigger, report.StartedAt, report.FinishedAt, report.Total,
+		report.AlreadyRunning, report.Started, report.Failed, report.Missing, string(results),
+	)
+	if err != nil {
+		return err
+	}
+	report.ID, _ = res.LastInsertId()
+
+	_, err = r.db.Exec(`
+		DELETE FROM boot_reports WHERE id NOT IN (
+			SELECT id FROM boot_reports ORDER BY started_at DESC LIMIT ?
+		)
+	`, maxBootReports)
+	return err
+}
+
+// GetLatest returns the most recent report
+func (r *BootReportRepo) GetLatest() (*models.BootReport, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT id, boot_id, trigger_type, started_at, finished_at, total,
+			already_running, started, failed, missing, results
+		FROM boot_reports ORDER BY started_at DESC LIMIT 1
+	`))
+}
+
+// GetByID returns a report by ID
+func (r *BootReportRepo) GetByID(id int64) (*models.BootReport, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT id, boot_id, trigger_type, started_at, finished_at, total,
+			already_running, started, failed, missing, results
+		FROM boot_reports WHERE id = ?
+	`, id))
+}
+
+// GetLatestBootID returns the kernel boot ID of the most recent boot-triggered report
+func (r *BootReportRepo) GetLatestBootID() (string, error) {
+	var bootID string
+	err := r.db.QueryRow(`
+		SELECT boot_id FROM boot_reports WHERE trigger_type = 'boot'
+		ORDER BY started_at DESC LIMIT 1
+	`).Scan(&bootID)
+	return bootID, err
+}
+
+// List returns report summaries (without per-container results), newest first
+func (r *BootReportRepo) List() ([]models.BootReport, error) {
+	rows, err := r.db.Query(`
+		SELECT id, boot_id, trigger_type, started_at, finished_at, total,
+			already_running, started, failed, missing
+		FROM boot_reports ORDER BY started_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []models.BootReport
+	for rows.Next() {
+		var b models.BootReport
+		if err := rows.Scan(
+			&b.ID, &b.BootID, &b.Trigger, &b.StartedAt, &b.FinishedAt, &b.Total,
+			&b.AlreadyRunning, &b.Started, &b.Failed, &b.Missing,
+		); err != nil {
+			return nil, err
+		}
+		reports = append(reports, b)
+	}
+	return reports, rows.Err()
+}
+
+func (r *BootReportRepo) scanOne(row *sql.Row) (*models.BootReport, error) {
+	b := &models.BootReport{}
+	var results string
+	if err := row.Scan(
+		&b.ID, &b.BootID, &b.Trigger, &b.StartedAt, &b.FinishedAt, &b.Total,
+		&b.AlreadyRunning, &b.Started, &b.Failed, &b.Missing, &results,
+	); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(results), &b.Results)
+	return b, nil
+}
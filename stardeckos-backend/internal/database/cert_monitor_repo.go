@@ -0,0 +1,116 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// CertMonitorRepo handles certificate monitor database operations
+type CertMonitorRepo struct {
+	db *sql.DB
+}
+
+// NewCertMonitorRepo creates a new certificate monitor repository
+func NewCertMonitorRepo() *CertMonitorRepo {
+	return &CertMonitorRepo{db: DB}
+}
+
+const certMonitorColumns = `id, name, host, port, server_name, container_id, warn_days, enabled,
+	subject, issuer, dns_names, not_after, trusted, verify_error, check_error, checked_at,
+	alert_state, created_at, created_by`
+
+// scanCertMonitor scans a certificate monitor row
+func scanCertMonitor(row rowScanner) (*models.CertMonitor, error) {
+	m := &models.CertMonitor{}
+	var enabled, trusted int
+	var dnsNames string
+	var notAfter, checkedAt sql.NullTime
+	if err := row.Scan(&m.ID, &m.Name, &m.Host, &m.Port, &m.ServerName, &m.ContainerID, &m.WarnDays, &enabled,
+		&m.Subject, &m.Issuer, &dnsNames, &notAfter, &trusted, &m.VerifyError, &m.Error, &checkedAt,
+		&m.AlertState, &m.CreatedAt, &m.CreatedBy); err != nil {
+		return nil, err
+	}
+	m.Enabled = enabled == 1
+	m.Trusted = trusted == 1
+	if dnsNames != "" {
+		m.DNSNames = strings.Split(dnsNames, ",")
+	}
+	if notAfter.Valid {
+		m.NotAfter = &notAfter.Time
+	}
+	if checkedAt.Valid {
+		m.CheckedAt = &checkedAt.Time
+	}
+	return m, nil
+}
+
+// Create adds a monitor
+func (r *CertMonitorRepo) Create(m *models.CertMonitor) error {
+	m.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO cert_monitors (name, host, port, server_name, container_id, warn_days, enabled, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.Name, m.Host, m.Port, m.ServerName, m.ContainerID, m.WarnDays, m.Enabled, m.CreatedAt, m.CreatedBy)
+	if err != nil {
+		return err
+	}
+	m.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves a monitor by ID
+func (r *CertMonitorRepo) GetByID(id int64) (*models.CertMonitor, error) {
+	return scanCertMonitor(r.db.QueryRow("SELECT "+certMonitorColumns+" FROM cert_monitors WHERE id = ?", id))
+}
+
+// List returns all monitors ordered by name
+func (r *CertMonitorRepo) List() ([]models.CertMonitor, error) {
+	rows, err := r.db.Query("SELECT " + certMonitorColumns + " FROM cert_monitors ORDER BY name, host")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	monitors := []models.CertMonitor{}
+	for rows.Next() {
+		m, err := scanCertMonitor(rows)
+		if err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, *m)
+	}
+	return monitors, rows.Err()
+}
+
+// Update saves a monitor's configuration. A changed endpoint resets the alert
+// state so the new certificate is judged afresh.
+func (r *CertMonitorRepo) Update(m *models.CertMonitor, endpointChanged bool) error {
+	query := "UPDATE cert_monitors SET name = ?, host = ?, port = ?, server_name = ?, container_id = ?, warn_days = ?, enabled = ?"
+	if endpointChanged {
+		query += ", alert_state = ''"
+		m.AlertState = models.CertAlertNone
+	}
+	_, err := r.db.Exec(query+" WHERE id = ?",
+		m.Name, m.Host, m.Port, m.ServerName, m.ContainerID, m.WarnDays, m.Enabled, m.ID)
+	return err
+}
+
+// RecordCheck stores the result of a check and the resulting alert state
+func (r *CertMonitorRepo) RecordCheck(id int64, check models.CertCheck, alertState string) error {
+	_, err := r.db.Exec(`
+		UPDATE cert_monitors SET subject = ?, issuer = ?, dns_names = ?, not_after = ?, trusted = ?,
+			verify_error = ?, check_error = ?, checked_at = ?, alert_state = ?
+		WHERE id = ?
+	`, check.Subject, check.Issuer, strings.Join(check.DNSNames, ","), check.NotAfter, check.Trusted,
+		check.VerifyError, check.Error, check.CheckedAt, alertState, id)
+	return err
+}
+
+// Delete removes a monitor
+func (r *CertMonitorRepo) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM cert_monitors WHERE id = ?", id)
+	return err
+}
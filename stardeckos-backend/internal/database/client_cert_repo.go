@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ClientCertRepo handles client certificate database operations
+type ClientCertRepo struct {
+	db *sql.DB
+}
+
+// NewClientCertRepo creates a new client certificate repository
+func NewClientCertRepo() *ClientCertRepo {
+	return &ClientCertRepo{db: DB}
+}
+
+const clientCertColumns = `
+	cc.id, cc.serial, cc.common_name, cc.dns_names, cc.user_id, COALESCE(u.username, ''), cc.scope,
+	cc.not_after, cc.created_at, cc.created_by, cc.revoked_at, cc.last_used_at
+	FROM client_certificates cc LEFT JOIN users u ON u.id = cc.user_id`
+
+// scanClientCert scans a client certificate row
+func scanClientCert(row rowScanner) (*models.ClientCertificate, error) {
+	cert := &models.ClientCertificate{DNSNames: []string{}}
+	var dnsNames string
+	var revokedAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&cert.ID, &cert.Serial, &cert.CommonName, &dnsNames, &cert.UserID, &cert.Username, &cert.Scope,
+		&cert.NotAfter, &cert.CreatedAt, &cert.CreatedBy, &revokedAt, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	if dnsNames != "" {
+		cert.DNSNames = strings.Split(dnsNames, ",")
+	}
+	if revokedAt.Valid {
+		cert.RevokedAt = &revokedAt.Time
+	}
+	if lastUsedAt.Valid {
+		cert.LastUsedAt = &lastUsedAt.Time
+	}
+	return cert, nil
+}
+
+// Create records an issued certificate
+func (r *ClientCertRepo) Create(cert *models.ClientCertificate) error {
+	cert.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO client_certificates (serial, common_name, dns_names, user_id, scope, not_after, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, cert.Serial, cert.CommonName, strings.Join(cert.DNSNames, ","), cert.UserID, cert.Scope,
+		cert.NotAfter, cert.CreatedAt, cert.CreatedBy)
+	if err != nil {
+		return err
+	}
+	cert.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves a certificate by ID
+func (r *ClientCertRepo) GetByID(id int64) (*models.ClientCertificate, error) {
+	return scanClientCert(r.db.QueryRow("SELECT "+clientCertColumns+" WHERE cc.id = ?", id))
+}
+
+// GetBySerial retrieves a certificate by its hex serial number
+func (r *ClientCertRepo) GetBySerial(serial string) (*models.ClientCertificate, error) {
+	return scanClientCert(r.db.QueryRow("SELECT "+clientCertColumns+" WHERE cc.serial = ?", serial))
+}
+
+// List returns all issued certificates, newest first
+func (r *ClientCertRepo) List() ([]models.ClientCertificate, error) {
+	rows, err := r.db.Query("SELECT " + clientCertColumns + " ORDER BY cc.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	certs := []models.ClientCertificate{}
+	for rows.Next() {
+		cert, err := scanClientCert(rows)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, *cert)
+	}
+	return certs, rows.Err()
+}
+
+// Revoke marks a certificate as revoked so it no longer authenticates
+func (r *ClientCertRepo) Revoke(id int64) error {
+	result, err := r.db.Exec("UPDATE client_certificates SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchLastUsed records that a certificate authenticated a request
+func (r *ClientCertRepo) TouchLastUsed(id int64) error {
+	_, err := r.db.Exec("UPDATE client_certificates SET last_used_at = ? WHERE id = ?", time.Now(), id)
+	return err
+}
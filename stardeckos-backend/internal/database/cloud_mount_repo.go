@@ -0,0 +1,109 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// CloudMountRepo handles cloud mount database operations
+type CloudMountRepo struct {
+	db *sql.DB
+}
+
+// NewCloudMountRepo creates a new cloud mount repository
+func NewCloudMountRepo() *CloudMountRepo {
+	return &CloudMountRepo{db: DB}
+}
+
+const cloudMountColumns = `id, name, remote, remote_path, mount_path, volume_name, read_only, cache_mode,
+	enabled, status, last_error, checked_at, created_at, created_by`
+
+// scanCloudMount scans a cloud mount row
+func scanCloudMount(row rowScanner) (*models.CloudMount, error) {
+	m := &models.CloudMount{}
+	var readOnly, enabled int
+	var checkedAt sql.NullTime
+	var createdBy sql.NullInt64
+	if err := row.Scan(&m.ID, &m.Name, &m.Remote, &m.RemotePath, &m.MountPath, &m.VolumeName, &readOnly, &m.CacheMode,
+		&enabled, &m.Status, &m.LastError, &checkedAt, &m.CreatedAt, &createdBy); err != nil {
+		return nil, err
+	}
+	m.ReadOnly = readOnly == 1
+	m.Enabled = enabled == 1
+	if checkedAt.Valid {
+		m.CheckedAt = &checkedAt.Time
+	}
+	if createdBy.Valid {
+		m.CreatedBy = &createdBy.Int64
+	}
+	return m, nil
+}
+
+// Create adds a mount
+func (r *CloudMountRepo) Create(m *models.CloudMount) error {
+	m.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO cloud_mounts (name, remote, remote_path, mount_path, volume_name, read_only, cache_mode, enabled, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.Name, m.Remote, m.RemotePath, m.MountPath, m.VolumeName, m.ReadOnly, m.CacheMode, m.Enabled, m.CreatedAt, m.CreatedBy)
+	if err != nil {
+		return err
+	}
+	m.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves a mount
+func (r *CloudMountRepo) GetByID(id int64) (*models.CloudMount, error) {
+	return scanCloudMount(r.db.QueryRow("SELECT "+cloudMountColumns+" FROM cloud_mounts WHERE id = ?", id))
+}
+
+// List returns all mounts ordered by name
+func (r *CloudMountRepo) List() ([]models.CloudMount, error) {
+	rows, err := r.db.Query("SELECT " + cloudMountColumns + " FROM cloud_mounts ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mounts := []models.CloudMount{}
+	for rows.Next() {
+		m, err := scanCloudMount(rows)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, *m)
+	}
+	return mounts, rows.Err()
+}
+
+// CountByRemote returns how many mounts use a remote
+func (r *CloudMountRepo) CountByRemote(remote string) (int, error) {
+	var n int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM cloud_mounts WHERE remote = ?", remote).Scan(&n)
+	return n, err
+}
+
+// Update replaces a mount's settings
+func (r *CloudMountRepo) Update(m *models.CloudMount) error {
+	_, err := r.db.Exec(`
+		UPDATE cloud_mounts SET remote = ?, remote_path = ?, read_only = ?, cache_mode = ?, enabled = ? WHERE id = ?
+	`, m.Remote, m.RemotePath, m.ReadOnly, m.CacheMode, m.Enabled, m.ID)
+	return err
+}
+
+// RecordCheck stores the outcome of a health check
+func (r *CloudMountRepo) RecordCheck(id int64, status, errMsg string) error {
+	_, err := r.db.Exec(`
+		UPDATE cloud_mounts SET status = ?, last_error = ?, checked_at = ? WHERE id = ?
+	`, status, errMsg, time.Now(), id)
+	return err
+}
+
+// Delete removes a mount
+func (r *CloudMountRepo) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM cloud_mounts WHERE id = ?", id)
+	return err
+}
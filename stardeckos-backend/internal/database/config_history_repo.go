@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// configHistoryLimit is how many snapshots are kept per container
+const configHistoryLimit = 50
+
+// ConfigHistoryRepo handles container configuration history database operations
+type ConfigHistoryRepo struct {
+	db *sql.DB
+}
+
+// NewConfigHistoryRepo creates a new configuration history repository
+func NewConfigHistoryRepo() *ConfigHistoryRepo {
+	return &ConfigHistoryRepo{db: DB}
+}
+
+const configSnapshotQuery = `
+	SELECT h.id, h.container_id, h.podman_id, h.version, h.reason, h.config, h.config_hash,
+		h.created_at, h.created_by, COALESCE(u.username, '')
+	FROM container_config_history h LEFT JOIN users u ON u.id = h.created_by`
+
+// scanConfigSnapshot scans a configuration snapshot row
+func scanConfigSnapshot(row rowScanner) (*models.ContainerConfigSnapshot, error) {
+	s := &models.ContainerConfigSnapshot{}
+	var config string
+	var createdBy sql.NullInt64
+	if err := row.Scan(
+		&s.ID, &s.ContainerID, &s.PodmanID, &s.Version, &s.Reason, &config, &s.ConfigHash,
+		&s.CreatedAt, &createdBy, &s.CreatedByName,
+	); err != nil {
+		return nil, err
+	}
+	s.Config = &models.ContainerConfig{}
+	if err := json.Unmarshal([]byte(config), s.Config); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		s.CreatedBy = &createdBy.Int64
+	}
+	return s, nil
+}
+
+// Add records a snapshot as the container's next version and prunes the
+// oldest beyond the retention limit
+func (r *ConfigHistoryRepo) Add(s *models.ContainerConfigSnapshot) error {
+	config, err := json.Marshal(s.Config)
+	if err != nil {
+		return err
+	}
+	s.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO container_config_history (
+			container_id, podman_id, version, reason, config, config_hash, created_at, created_by
+		) SELECT ?, ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ?
+		FROM container_config_history WHERE container_id = ?
+	`, s.ContainerID, s.PodmanID, s.Reason, string(config), s.ConfigHash, s.CreatedAt, s.CreatedBy, s.ContainerID)
+	if err != nil {
+		return err
+	}
+	s.ID, _ = result.LastInsertId()
+	if err := r.db.QueryRow("SELECT version FROM container_config_history WHERE id = ?", s.ID).Scan(&s.Version); err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		DELETE FROM container_config_history WHERE container_id = ? AND version <= ?
+	`, s.ContainerID, s.Version-configHistoryLimit)
+	return err
+}
+
+// Latest returns a container's most recent snapshot
+func (r *ConfigHistoryRepo) Latest(containerID string) (*models.ContainerConfigSnapshot, error) {
+	return scanConfigSnapshot(r.db.QueryRow(
+		configSnapshotQuery+" WHERE h.container_id = ? ORDER BY h.version DESC LIMIT 1", containerID))
+}
+
+// GetVersion returns one version of a container's configuration
+func (r *ConfigHistoryRepo) GetVersion(containerID string, version int) (*models.ContainerConfigSnapshot, error) {
+	return scanConfigSnapshot(r.db.QueryRow(
+		configSnapshotQuery+" WHERE h.container_id = ? AND h.version = ?", containerID, version))
+}
+
+// List returns a container's snapshots, oldest first
+func (r *ConfigHistoryRepo) List(containerID string) ([]models.ContainerConfigSnapshot, error) {
+	rows, err := r.db.Query(configSnapshotQuery+" WHERE h.container_id = ? ORDER BY h.version", containerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []models.ContainerConfigSnapshot
+	for rows.Next() {
+		s, err := scanConfigSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *s)
+	}
+	return snapshots, rows.Err()
+}
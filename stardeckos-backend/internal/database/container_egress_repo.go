@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ContainerEgressRepo handles container egress policy database operations
+type ContainerEgressRepo struct {
+	db *sql.DB
+}
+
+// NewContainerEgressRepo creates a new container egress policy repository
+func NewContainerEgressRepo() *ContainerEgressRepo {
+	return &ContainerEgressRepo{db: DB}
+}
+
+const containerEgressQuery = `
+	SELECT p.container_id, c.name, p.default_action, p.block_internet, p.rules, p.created_at, p.updated_at, p.updated_by
+	FROM container_egress_policies p JOIN containers c ON c.id = p.container_id`
+
+// scanContainerEgress scans a container egress policy row
+func scanContainerEgress(row rowScanner) (*models.ContainerEgressPolicy, error) {
+	p := &models.ContainerEgressPolicy{}
+	var blockInternet int
+	var rules string
+	if err := row.Scan(&p.ContainerID, &p.ContainerName, &p.DefaultAction, &blockInternet, &rules,
+		&p.CreatedAt, &p.UpdatedAt, &p.UpdatedBy); err != nil {
+		return nil, err
+	}
+	p.BlockInternet = blockInternet == 1
+	if err := json.Unmarshal([]byte(rules), &p.Rules); err != nil || p.Rules == nil {
+		p.Rules = []models.EgressRule{}
+	}
+	return p, nil
+}
+
+// Get retrieves the policy for a Stardeck container ID
+func (r *ContainerEgressRepo) Get(containerID string) (*models.ContainerEgressPolicy, error) {
+	return scanContainerEgress(r.db.QueryRow(containerEgressQuery+" WHERE p.container_id = ?", containerID))
+}
+
+// List returns all policies ordered by container name
+func (r *ContainerEgressRepo) List() ([]models.ContainerEgressPolicy, error) {
+	rows, err := r.db.Query(containerEgressQuery + " ORDER BY c.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []models.ContainerEgressPolicy{}
+	for rows.Next() {
+		p, err := scanContainerEgress(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+// Upsert creates or replaces a container's policy
+func (r *ContainerEgressRepo) Upsert(p *models.ContainerEgressPolicy) error {
+	rules, err := json.Marshal(p.Rules)
+	if err != nil {
+		return err
+	}
+	p.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+		INSERT INTO container_egress_policies (container_id, default_action, block_internet, rules, created_at, updated_at, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(container_id) DO UPDATE SET
+			default_action = excluded.default_action, block_internet = excluded.block_internet,
+			rules = excluded.rules, updated_at = excluded.updated_at, updated_by = excluded.updated_by
+	`, p.ContainerID, p.DefaultAction, p.BlockInternet, string(rules), p.UpdatedAt, p.UpdatedAt, p.UpdatedBy)
+	return err
+}
+
+// Delete removes a container's policy
+func (r *ContainerEgressRepo) Delete(containerID string) error {
+	_, err := r.db.Exec("DELETE FROM container_egress_policies WHERE container_id = ?", containerID)
+	return err
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// containerExitRetention is how long exit history is kept
+const containerExitRetention = 90 * 24 * time.Hour
+
+// ContainerExitRepo handles container exit history database operations
+type ContainerExitRepo struct {
+	db *sql.DB
+}
+
+// NewContainerExitRepo creates a new container exit repository
+func NewContainerExitRepo() *ContainerExitRepo {
+	return &ContainerExitRepo{db: DB}
+}
+
+// Create records a container exit and prunes history past the retention window
+func (r *ContainerExitRepo) Create(e *models.ContainerExit) error {
+	result, err := r.db.Exec(`
+		INSERT INTO container_exits (
+			container_id, container_name, image, exit_code, oom_killed, reason,
+			memory_limit, runtime_seconds, exited_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.ContainerID, e.ContainerName, e.Image, e.ExitCode, e.OOMKilled, e.Reason,
+		e.MemoryLimit, e.RuntimeSeconds, e.ExitedAt)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = result.LastInsertId()
+
+	_, err = r.db.Exec("DELETE FROM container_exits WHERE exited_at < ?", time.Now().Add(-containerExitRetention))
+	return err
+}
+
+// ListByName returns a container's most recent exits, newest first
+func (r *ContainerExitRepo) ListByName(name string, limit int) ([]models.ContainerExit, error) {
+	rows, err := r.db.Query(`
+		SELECT id, container_id, container_name, image, exit_code, oom_killed, reason,
+			memory_limit, runtime_seconds, exited_at
+		FROM container_exits WHERE container_name = ? ORDER BY exited_at DESC LIMIT ?
+	`, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exits []models.ContainerExit
+	for rows.Next() {
+		var e models.ContainerExit
+		var oom int
+		if err := rows.Scan(&e.ID, &e.ContainerID, &e.ContainerName, &e.Image, &e.ExitCode, &oom, &e.Reason,
+			&e.MemoryLimit, &e.RuntimeSeconds, &e.ExitedAt); err != nil {
+			return nil, err
+		}
+		e.OOMKilled = oom == 1
+		exits = append(exits, e)
+	}
+	return exits, rows.Err()
+}
+
+// Reliability aggregates exit history per container, most failures first.
+// An empty name returns every container with recorded exits.
+func (r *ContainerExitRepo) Reliability(name string) ([]models.ContainerReliability, error) {
+	now := time.Now()
+	rows, err := r.db.Query(`
+		SELECT container_name,
+			COUNT(*),
+			SUM(reason = 'crashed'),
+			SUM(reason = 'oom'),
+			SUM(reason = 'stopped'),
+			SUM(reason IN ('crashed', 'oom') AND exited_at >= ?),
+			SUM(reason IN ('crashed', 'oom') AND exited_at >= ?),
+			SUM(reason = 'oom' AND exited_at >= ?),
+			COALESCE(CAST(AVG(CASE WHEN reason IN ('crashed', 'oom') THEN runtime_seconds END) AS INTEGER), 0),
+			MAX(exited_at),
+			MAX(CASE WHEN reason IN ('crashed', 'oom') THEN exited_at END)
+		FROM container_exits
+		WHERE ? = '' OR container_name = ?
+		GROUP BY container_name
+		ORDER BY SUM(reason IN ('crashed', 'oom')) DESC, container_name
+	`, now.Add(-24*time.Hour), now.AddDate(0, 0, -7), now.AddDate(0, 0, -30), name, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.ContainerReliability
+	for rows.Next() {
+		var s models.ContainerReliability
+		var lastExit, lastFailure sql.NullString
+		if err := rows.Scan(&s.ContainerName, &s.TotalExits, &s.Crashes, &s.OOMKills, &s.Stops,
+			&s.Failures24h, &s.Failures7d, &s.OOMKills30d, &s.MeanTimeToFailure, &lastExit, &lastFailure); err != nil {
+			return nil, err
+		}
+		s.LastExitAt = parseAggregateTime(lastExit)
+		s.LastFailureAt = parseAggregateTime(lastFailure)
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// LastOOMLimit returns the memory limit in force at a container's most recent OOM kill
+func (r *ContainerExitRepo) LastOOMLimit(name string) (int64, error) {
+	var limit int64
+	err := r.db.QueryRow(`
+		SELECT memory_limit FROM container_exits WHERE container_name = ? AND reason = 'oom'
+		ORDER BY exited_at DESC LIMIT 1
+	`, name).Scan(&limit)
+	return limit, err
+}
+
+// parseAggregateTime parses a DATETIME returned by an aggregate, which the
+// driver hands back as the time.Time.String text it was stored with
+func parseAggregateTime(v sql.NullString) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	s := v.String
+	if i := strings.Index(s, " m="); i > 0 {
+		s = s[:i]
+	}
+	t, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// SummarySince counts each container's exits since a time, ignoring clean
+// completions, most failures first
+func (r *ContainerExitRepo) SummarySince(since time.Time) ([]models.DigestRestart, error) {
+	rows, err := r.db.Query(`
+		SELECT container_name, SUM(reason = 'crashed'), SUM(reason = 'oom'), SUM(reason = 'stopped')
+		FROM container_exits
+		WHERE exited_at >= ? AND reason != 'completed'
+		GROUP BY container_name
+		ORDER BY SUM(reason IN ('crashed', 'oom')) DESC, COUNT(*) DESC, container_name
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restarts []models.DigestRestart
+	for rows.Next() {
+		var d models.DigestRestart
+		if err := rows.Scan(&d.Container, &d.Crashes, &d.OOMKills, &d.Stops); err != nil {
+			return nil, err
+		}
+		restarts = append(restarts, d)
+	}
+	return restarts, rows.Err()
+}
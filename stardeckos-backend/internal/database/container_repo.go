@@ -13,7 +13,7 @@ import (
 
 // ContainerRepo handles container database operations
 type ContainerRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewContainerRepo creates a new container repository
@@ -21,6 +21,11 @@ func NewContainerRepo() *ContainerRepo {
 	return &ContainerRepo{db: DB}
 }
 
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *ContainerRepo) InTx(tx *sql.Tx) *ContainerRepo {
+	return &ContainerRepo{db: tx}
+}
+
 // Create adds a new container to the database
 func (r *ContainerRepo) Create(c *models.Container) error {
 	if c.ID == "" {
@@ -188,6 +193,46 @@ func (r *ContainerRepo) List() ([]models.Container, error) {
 	return containers, nil
 }
 
+// ListByOwner retrieves the containers created by or transferred to a user
+func (r *ContainerRepo) ListByOwner(userID int64) ([]models.Container, error) {
+	rows, err := r.db.Query(`
+		SELECT id, container_id, name, image, status, compose_file, compose_path,
+			has_web_ui, web_ui_port, web_ui_path, icon, icon_light, icon_dark, auto_start,
+			created_at, updated_at, created_by, labels, metadata
+		FROM containers WHERE created_by = ? ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var containers []models.Container
+	for rows.Next() {
+		var c models.Container
+		var hasWebUI, autoStart int
+		if err := rows.Scan(
+			&c.ID, &c.ContainerID, &c.Name, &c.Image, &c.Status, &c.ComposeFile, &c.ComposePath,
+			&hasWebUI, &c.WebUIPort, &c.WebUIPath, &c.Icon, &c.IconLight, &c.IconDark, &autoStart,
+			&c.CreatedAt, &c.UpdatedAt, &c.CreatedBy, &c.Labels, &c.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		c.HasWebUI = hasWebUI == 1
+		c.AutoStart = autoStart == 1
+		containers = append(containers, c)
+	}
+
+	return containers, nil
+}
+
+// SetOwner transfers a container to another user
+func (r *ContainerRepo) SetOwner(id string, userID int64) error {
+	_, err := r.db.Exec(`
+		UPDATE containers SET created_by = ?, updated_at = ? WHERE id = ?
+	`, userID, time.Now(), id)
+	return err
+}
+
 // Update updates a container in the database
 func (r *ContainerRepo) Update(c *models.Container) error {
 	c.UpdatedAt = time.Now()
@@ -314,11 +359,11 @@ func (r *TemplateRepo) Create(t *models.Template) error {
 	_, err := r.db.Exec(`
 		INSERT INTO templates (
 			id, name, description, author, version, compose_content,
-			env_defaults, volume_hints, tags, created_at, updated_at, usage_count
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			env_defaults, volume_hints, tags, icon, created_at, updated_at, usage_count
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		t.ID, t.Name, t.Description, t.Author, t.Version, t.ComposeContent,
-		t.EnvDefaults, t.VolumeHints, t.Tags, t.CreatedAt, t.UpdatedAt, t.UsageCount,
+		t.EnvDefaults, t.VolumeHints, t.Tags, t.Icon, t.CreatedAt, t.UpdatedAt, t.UsageCount,
 	)
 	return err
 }
@@ -328,11 +373,11 @@ func (r *TemplateRepo) GetByID(id string) (*models.Template, error) {
 	t := &models.Template{}
 	err := r.db.QueryRow(`
 		SELECT id, name, description, author, version, compose_content,
-			env_defaults, volume_hints, tags, created_at, updated_at, usage_count
+			env_defaults, volume_hints, tags, COALESCE(icon, ''), created_at, updated_at, usage_count
 		FROM templates WHERE id = ?
 	`, id).Scan(
 		&t.ID, &t.Name, &t.Description, &t.Author, &t.Version, &t.ComposeContent,
-		&t.EnvDefaults, &t.VolumeHints, &t.Tags, &t.CreatedAt, &t.UpdatedAt, &t.UsageCount,
+		&t.EnvDefaults, &t.VolumeHints, &t.Tags, &t.Icon, &t.CreatedAt, &t.UpdatedAt, &t.UsageCount,
 	)
 	if err != nil {
 		return nil, err
@@ -344,7 +389,7 @@ func (r *TemplateRepo) GetByID(id string) (*models.Template, error) {
 func (r *TemplateRepo) List() ([]models.Template, error) {
 	rows, err := r.db.Query(`
 		SELECT id, name, description, author, version, compose_content,
-			env_defaults, volume_hints, tags, created_at, updated_at, usage_count
+			env_defaults, volume_hints, tags, COALESCE(icon, ''), created_at, updated_at, usage_count
 		FROM templates ORDER BY name
 	`)
 	if err != nil {
@@ -357,7 +402,7 @@ func (r *TemplateRepo) List() ([]models.Template, error) {
 		var t models.Template
 		if err := rows.Scan(
 			&t.ID, &t.Name, &t.Description, &t.Author, &t.Version, &t.ComposeContent,
-			&t.EnvDefaults, &t.VolumeHints, &t.Tags, &t.CreatedAt, &t.UpdatedAt, &t.UsageCount,
+			&t.EnvDefaults, &t.VolumeHints, &t.Tags, &t.Icon, &t.CreatedAt, &t.UpdatedAt, &t.UsageCount,
 		); err != nil {
 			return nil, err
 		}
@@ -373,11 +418,11 @@ func (r *TemplateRepo) Update(t *models.Template) error {
 	_, err := r.db.Exec(`
 		UPDATE templates SET
 			name = ?, description = ?, author = ?, version = ?, compose_content = ?,
-			env_defaults = ?, volume_hints = ?, tags = ?, updated_at = ?
+			env_defaults = ?, volume_hints = ?, tags = ?, icon = ?, updated_at = ?
 		WHERE id = ?
 	`,
 		t.Name, t.Description, t.Author, t.Version, t.ComposeContent,
-		t.EnvDefaults, t.VolumeHints, t.Tags, t.UpdatedAt, t.ID,
+		t.EnvDefaults, t.VolumeHints, t.Tags, t.Icon, t.UpdatedAt, t.ID,
 	)
 	return err
 }
@@ -398,7 +443,7 @@ func (r *TemplateRepo) IncrementUsage(id string) error {
 
 // ContainerMetricsRepo handles container metrics database operations
 type ContainerMetricsRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewContainerMetricsRepo creates a new metrics repository
@@ -406,9 +451,16 @@ func NewContainerMetricsRepo() *ContainerMetricsRepo {
 	return &ContainerMetricsRepo{db: DB}
 }
 
-// Save stores container metrics
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *ContainerMetricsRepo) InTx(tx *sql.Tx) *ContainerMetricsRepo {
+	return &ContainerMetricsRepo{db: tx}
+}
+
+// Save stores container metrics, stamped now unless a timestamp is set
 func (r *ContainerMetricsRepo) Save(m *models.ContainerMetrics) error {
-	m.Timestamp = time.Now()
+	if m.Timestamp.IsZero() {
+		m.Timestamp = time.Now()
+	}
 	_, err := r.db.Exec(`
 		INSERT INTO container_metrics (
 			container_id, timestamp, cpu_percent, memory_used, memory_limit,
@@ -450,18 +502,104 @@ func (r *ContainerMetricsRepo) GetRecent(containerID string, hours int) ([]model
 	return metrics, nil
 }
 
-// Cleanup removes old metrics (older than specified hours)
-func (r *ContainerMetricsRepo) Cleanup(retentionHours int) error {
-	_, err := r.db.Exec(`
+// Cleanup removes old metrics (older than specified hours) and returns how many were deleted
+func (r *ContainerMetricsRepo) Cleanup(retentionHours int) (int64, error) {
+	result, err := r.db.Exec(`
 		DELETE FROM container_metrics
 		WHERE timestamp < datetime('now', '-' || ? || ' hours')
 	`, retentionHours)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Downsample replaces the samples taken before cutoff with one averaged
+// sample per container per hour and returns how many rows it removed. Hours
+// already down to a single sample are left alone, so it can run repeatedly.
+func (r *ContainerMetricsRepo) Downsample(cutoff time.Time) (int64, error) {
+	// Only whole hours, so an hour is never averaged again with later samples
+	cutoff = cutoff.Truncate(time.Hour)
+
+	rows, err := r.db.Query(`
+		SELECT id, container_id, timestamp, cpu_percent, memory_used, memory_limit,
+			network_rx, network_tx, block_read, block_write
+		FROM container_metrics
+		WHERE timestamp < ?
+		ORDER BY container_id, timestamp ASC
+	`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	type hourKey struct {
+		containerID string
+		hour        time.Time
+	}
+	hours := make(map[hourKey][]models.ContainerMetrics)
+	var order []hourKey
+	for rows.Next() {
+		var m models.ContainerMetrics
+		if err := rows.Scan(
+			&m.ID, &m.ContainerID, &m.Timestamp, &m.CPUPercent, &m.MemoryUsed, &m.MemoryLimit,
+			&m.NetworkRx, &m.NetworkTx, &m.BlockRead, &m.BlockWrite,
+		); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		key := hourKey{m.ContainerID, m.Timestamp.Truncate(time.Hour)}
+		if _, ok := hours[key]; !ok {
+			order = append(order, key)
+		}
+		hours[key] = append(hours[key], m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	for _, key := range order {
+		samples := hours[key]
+		if len(samples) < 2 {
+			continue
+		}
+
+		// Usage is averaged; the I/O counters are cumulative, so the last one stands
+		avg := samples[len(samples)-1]
+		var cpu float64
+		var memory int64
+		for _, m := range samples {
+			cpu += m.CPUPercent
+			memory += m.MemoryUsed
+		}
+		avg.CPUPercent = cpu / float64(len(samples))
+		avg.MemoryUsed = memory / int64(len(samples))
+
+		for _, m := range samples {
+			if _, err := r.db.Exec("DELETE FROM container_metrics WHERE id = ?", m.ID); err != nil {
+				return removed, err
+			}
+		}
+		if _, err := r.db.Exec(`
+			INSERT INTO container_metrics (
+				container_id, timestamp, cpu_percent, memory_used, memory_limit,
+				network_rx, network_tx, block_read, block_write
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			avg.ContainerID, key.hour, avg.CPUPercent, avg.MemoryUsed, avg.MemoryLimit,
+			avg.NetworkRx, avg.NetworkTx, avg.BlockRead, avg.BlockWrite,
+		); err != nil {
+			return removed, err
+		}
+		removed += int64(len(samples) - 1)
+	}
+	return removed, nil
 }
 
 // ContainerEnvVarRepo handles container environment variable operations
 type ContainerEnvVarRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
 // NewContainerEnvVarRepo creates a new env var repository
@@ -469,6 +607,11 @@ func NewContainerEnvVarRepo() *ContainerEnvVarRepo {
 	return &ContainerEnvVarRepo{db: DB}
 }
 
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *ContainerEnvVarRepo) InTx(tx *sql.Tx) *ContainerEnvVarRepo {
+	return &ContainerEnvVarRepo{db: tx}
+}
+
 // Save stores or updates an environment variable
 func (r *ContainerEnvVarRepo) Save(e *models.ContainerEnvVar) error {
 	if e.ID == "" {
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ContainerScheduleRepo handles container power schedule database operations
+type ContainerScheduleRepo struct {
+	db *sql.DB
+}
+
+// NewContainerScheduleRepo creates a new container schedule repository
+func NewContainerScheduleRepo() *ContainerScheduleRepo {
+	return &ContainerScheduleRepo{db: DB}
+}
+
+const containerScheduleQuery = `
+	SELECT s.container_id, c.name, s.enabled, s.start_schedule, s.stop_schedule, s.timezone,
+		s.override_action, s.override_by, s.override_at, s.override_until,
+		s.last_action, s.last_action_at, s.last_error, s.created_at, s.updated_at, s.created_by
+	FROM container_schedules s JOIN containers c ON c.id = s.container_id`
+
+// scanContainerSchedule scans a container schedule row
+func scanContainerSchedule(row rowScanner) (*models.ContainerSchedule, error) {
+	s := &models.ContainerSchedule{}
+	var enabled int
+	var overrideAt, overrideUntil, lastActionAt sql.NullTime
+	var createdBy sql.NullInt64
+	if err := row.Scan(
+		&s.ContainerID, &s.ContainerName, &enabled, &s.StartSchedule, &s.StopSchedule, &s.Timezone,
+		&s.OverrideAction, &s.OverrideBy, &overrideAt, &overrideUntil,
+		&s.LastAction, &lastActionAt, &s.LastError, &s.CreatedAt, &s.UpdatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	s.Enabled = enabled == 1
+	if overrideAt.Valid {
+		s.OverrideAt = &overrideAt.Time
+	}
+	if overrideUntil.Valid {
+		s.OverrideUntil = &overrideUntil.Time
+	}
+	if lastActionAt.Valid {
+		s.LastActionAt = &lastActionAt.Time
+	}
+	if createdBy.Valid {
+		s.CreatedBy = &createdBy.Int64
+	}
+	return s, nil
+}
+
+// Get retrieves the schedule for a Stardeck container ID
+func (r *ContainerScheduleRepo) Get(containerID string) (*models.ContainerSchedule, error) {
+	return scanContainerSchedule(r.db.QueryRow(containerScheduleQuery+" WHERE s.container_id = ?", containerID))
+}
+
+// List returns all schedules ordered by container name
+func (r *ContainerScheduleRepo) List() ([]models.ContainerSchedule, error) {
+	return r.list(containerScheduleQuery + " ORDER BY c.name")
+}
+
+// ListEnabled returns the schedules the scheduler should evaluate
+func (r *ContainerScheduleRepo) ListEnabled() ([]models.ContainerSchedule, error) {
+	return r.list(containerScheduleQuery + " WHERE s.enabled = 1")
+}
+
+func (r *ContainerScheduleRepo) list(query string) ([]models.ContainerSchedule, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []models.ContainerSchedule
+	for rows.Next() {
+		s, err := scanContainerSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *s)
+	}
+	return schedules, rows.Err()
+}
+
+// Upsert creates or replaces a container's schedule definition, keeping its run state
+func (r *ContainerScheduleRepo) Upsert(s *models.ContainerSchedule) error {
+	s.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO container_schedules (
+			container_id, enabled, start_schedule, stop_schedule, timezone, created_at, updated_at, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(container_id) DO UPDATE SET
+			enabled = excluded.enabled, start_schedule = excluded.start_schedule,
+			stop_schedule = excluded.stop_schedule, timezone = excluded.timezone, updated_at = excluded.updated_at
+	`, s.ContainerID, s.Enabled, s.StartSchedule, s.StopSchedule, s.Timezone, s.UpdatedAt, s.UpdatedAt, s.CreatedBy)
+	return err
+}
+
+// SetOverride records a manual action or hold; scheduled actions are skipped until the given time
+func (r *ContainerScheduleRepo) SetOverride(containerID, action, by string, until time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE container_schedules SET override_action = ?, override_by = ?, override_at = ?, override_until = ?
+		WHERE container_id = ?
+	`, action, by, time.Now(), until, containerID)
+	return err
+}
+
+// ClearOverride resumes the schedule
+func (r *ContainerScheduleRepo) ClearOverride(containerID string) error {
+	_, err := r.db.Exec(`
+		UPDATE container_schedules SET override_action = '', override_by = '', override_at = NULL, override_until = NULL
+		WHERE container_id = ?
+	`, containerID)
+	return err
+}
+
+// RecordAction stores the outcome of a scheduled start or stop
+func (r *ContainerScheduleRepo) RecordAction(containerID, action, errMsg string) error {
+	_, err := r.db.Exec(`
+		UPDATE container_schedules SET last_action = ?, last_action_at = ?, last_error = ? WHERE container_id = ?
+	`, action, time.Now(), errMsg, containerID)
+	return err
+}
+
+// Delete removes a container's schedule
+func (r *ContainerScheduleRepo) Delete(containerID string) error {
+	_, err := r.db.Exec("DELETE FROM container_schedules WHERE container_id = ?", containerID)
+	return err
+}
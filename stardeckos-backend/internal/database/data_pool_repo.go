@@ -0,0 +1,102 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// DataPoolRepo handles data pool database operations
+type DataPoolRepo struct {
+	db *sql.DB
+}
+
+// NewDataPoolRepo creates a new data pool repository
+func NewDataPoolRepo() *DataPoolRepo {
+	return &DataPoolRepo{db: DB}
+}
+
+const dataPoolColumns = `id, name, purpose, path, container_path, quota_bytes, uid, gid, mode, umask,
+	used_bytes, measured_at, over_quota, created_at, created_by`
+
+// scanDataPool scans a data pool row
+func scanDataPool(row rowScanner) (*models.DataPool, error) {
+	p := &models.DataPool{}
+	var overQuota int
+	var measuredAt sql.NullTime
+	var createdBy sql.NullInt64
+	if err := row.Scan(&p.ID, &p.Name, &p.Purpose, &p.Path, &p.ContainerPath, &p.QuotaBytes, &p.UID, &p.GID, &p.Mode, &p.Umask,
+		&p.UsedBytes, &measuredAt, &overQuota, &p.CreatedAt, &createdBy); err != nil {
+		return nil, err
+	}
+	p.OverQuota = overQuota == 1
+	if measuredAt.Valid {
+		p.MeasuredAt = &measuredAt.Time
+	}
+	if createdBy.Valid {
+		p.CreatedBy = &createdBy.Int64
+	}
+	return p, nil
+}
+
+// Create adds a pool
+func (r *DataPoolRepo) Create(p *models.DataPool) error {
+	p.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO data_pools (name, purpose, path, container_path, quota_bytes, uid, gid, mode, umask, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.Name, p.Purpose, p.Path, p.ContainerPath, p.QuotaBytes, p.UID, p.GID, p.Mode, p.Umask, p.CreatedAt, p.CreatedBy)
+	if err != nil {
+		return err
+	}
+	p.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves a pool
+func (r *DataPoolRepo) GetByID(id int64) (*models.DataPool, error) {
+	return scanDataPool(r.db.QueryRow("SELECT "+dataPoolColumns+" FROM data_pools WHERE id = ?", id))
+}
+
+// List returns all pools in creation order
+func (r *DataPoolRepo) List() ([]models.DataPool, error) {
+	rows, err := r.db.Query("SELECT " + dataPoolColumns + " FROM data_pools ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pools := []models.DataPool{}
+	for rows.Next() {
+		p, err := scanDataPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, *p)
+	}
+	return pools, rows.Err()
+}
+
+// Update replaces a pool's settings
+func (r *DataPoolRepo) Update(p *models.DataPool) error {
+	_, err := r.db.Exec(`
+		UPDATE data_pools SET purpose = ?, path = ?, container_path = ?, quota_bytes = ?, uid = ?, gid = ?, mode = ?, umask = ?
+		WHERE id = ?
+	`, p.Purpose, p.Path, p.ContainerPath, p.QuotaBytes, p.UID, p.GID, p.Mode, p.Umask, p.ID)
+	return err
+}
+
+// RecordUsage stores a pool's measured size
+func (r *DataPoolRepo) RecordUsage(id, usedBytes int64, overQuota bool) error {
+	_, err := r.db.Exec(`
+		UPDATE data_pools SET used_bytes = ?, measured_at = ?, over_quota = ? WHERE id = ?
+	`, usedBytes, time.Now(), overQuota, id)
+	return err
+}
+
+// Delete removes a pool; its directory is left in place
+func (r *DataPoolRepo) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM data_pools WHERE id = ?", id)
+	return err
+}
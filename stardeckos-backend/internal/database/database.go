@@ -6,12 +6,18 @@ import (
 	"os"
 	"path/filepath"
 
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
 )
 
 // DB is the global database connection
 var DB *sql.DB
 
+// maxOpenConns is the size of the connection pool
+const maxOpenConns = 4
+
+// dbPath is the file path of the open database, used for size reporting
+var dbPath string
+
 // Config holds database configuration
 type Config struct {
 	Path string
@@ -29,26 +35,27 @@ func Open(cfg Config) error {
 	// - journal_mode=WAL: Write-Ahead Logging for concurrent reads/writes
 	// - busy_timeout=5000: Wait up to 5 seconds if database is locked (fixes SQLITE_BUSY)
 	// - synchronous=NORMAL: Safe with WAL mode, better performance
-	// - cache_size=-64000: 64MB cache for better read performance
+	// - cache_size=-16000: 16MB cache per connection
 	// - foreign_keys=1: Enable foreign key constraints
+	// - _txlock=immediate: Transactions take the write lock when they begin,
+	//   so they wait on busy_timeout instead of failing on their first write
 	dsn := cfg.Path + "?" +
 		"_pragma=foreign_keys(1)&" +
 		"_pragma=journal_mode(WAL)&" +
 		"_pragma=busy_timeout(5000)&" +
 		"_pragma=synchronous(NORMAL)&" +
-		"_pragma=cache_size(-64000)"
+		"_pragma=cache_size(-16000)&" +
+		"_txlock=immediate"
 
-	var err error
-	DB, err = sql.Open("sqlite", dsn)
-	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
-	}
+	dbPath = cfg.Path
+	DB = sql.OpenDB(&gatedConnector{dsn: dsn, base: &sqlite.Driver{}})
 
 	// Configure connection pool for SQLite
-	// SQLite works best with limited connections due to file-level locking
-	DB.SetMaxOpenConns(1)        // Single writer for SQLite
-	DB.SetMaxIdleConns(1)        // Keep one connection ready
-	DB.SetConnMaxLifetime(0)     // Don't close connections due to age
+	// WAL lets several connections read while one writes; writes from all of
+	// them are queued through the write gate (see sqlite_conn.go)
+	DB.SetMaxOpenConns(maxOpenConns)
+	DB.SetMaxIdleConns(maxOpenConns) // Keep connections and their caches warm
+	DB.SetConnMaxLifetime(0)         // Don't close connections due to age
 
 	// Test connection
 	if err := DB.Ping(); err != nil {
@@ -613,4 +620,928 @@ var migrations = []migration{
 			CREATE INDEX idx_db_connections_container ON database_connections(container_id);
 		`,
 	},
+	// Recycle bin for removed containers and stacks
+	{
+		name: "027_create_trash_items",
+		up: `
+			CREATE TABLE trash_items (
+				id TEXT PRIMARY KEY,
+				item_type TEXT NOT NULL,
+				name TEXT NOT NULL,
+				original_id TEXT,
+				payload TEXT NOT NULL DEFAULT '{}',
+				backup_path TEXT,
+				has_backup INTEGER DEFAULT 0,
+				size_bytes INTEGER DEFAULT 0,
+				deleted_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				expires_at DATETIME NOT NULL,
+				deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE INDEX idx_trash_items_type ON trash_items(item_type);
+			CREATE INDEX idx_trash_items_expires ON trash_items(expires_at);
+
+			INSERT OR IGNORE INTO settings (key, value) VALUES
+				('trash.enabled', 'true'),
+				('trash.retention_days', '7');
+		`,
+	},
+	// Web UI screenshot thumbnails for desktop icons
+	{
+		name: "028_thumbnail_settings",
+		up: `
+			INSERT OR IGNORE INTO settings (key, value) VALUES
+				('thumbnails.enabled', 'true'),
+				('thumbnails.interval_minutes', '15');
+		`,
+	},
+	// Icon library and uploads, referenced from containers, templates, and stacks
+	{
+		name: "029_create_icons",
+		up: `
+			CREATE TABLE icons (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL,
+				slug TEXT,
+				source TEXT NOT NULL DEFAULT 'upload',
+				content_type TEXT NOT NULL,
+				file_name TEXT NOT NULL,
+				size_bytes INTEGER DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE INDEX idx_icons_source ON icons(source);
+			CREATE INDEX idx_icons_slug ON icons(slug);
+
+			ALTER TABLE templates ADD COLUMN icon TEXT DEFAULT '';
+			ALTER TABLE stacks ADD COLUMN icon TEXT DEFAULT '';
+
+			INSERT OR IGNORE INTO settings (key, value) VALUES
+				('icons.library_url', 'https://cdn.jsdelivr.net/gh/selfhst/icons');
+		`,
+	},
+	// Per-container scheduled exec tasks
+	{
+		name: "030_create_exec_tasks",
+		up: `
+			CREATE TABLE exec_tasks (
+				id TEXT PRIMARY KEY,
+				container_id TEXT NOT NULL REFERENCES containers(id) ON DELETE CASCADE,
+				name TEXT NOT NULL,
+				command TEXT NOT NULL,
+				schedule TEXT NOT NULL,
+				enabled INTEGER DEFAULT 1,
+				timeout_seconds INTEGER DEFAULT 300,
+				notify_on_failure INTEGER DEFAULT 0,
+				last_run_at DATETIME,
+				last_status TEXT,
+				last_exit_code INTEGER,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE INDEX idx_exec_tasks_container ON exec_tasks(container_id);
+
+			CREATE TABLE exec_task_runs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				task_id TEXT NOT NULL REFERENCES exec_tasks(id) ON DELETE CASCADE,
+				trigger_type TEXT NOT NULL DEFAULT 'schedule',
+				status TEXT NOT NULL,
+				exit_code INTEGER,
+				output TEXT,
+				started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				finished_at DATETIME,
+				duration_ms INTEGER
+			);
+			CREATE INDEX idx_exec_task_runs_task ON exec_task_runs(task_id, started_at);
+		`,
+	},
+	// Database maintenance settings
+	{
+		name: "031_maintenance_settings",
+		up: `
+			INSERT OR IGNORE INTO settings (key, value) VALUES
+				('maintenance.nightly_enabled', 'true'),
+				('maintenance.hour', '3'),
+				('maintenance.metrics_retention_hours', '168'),
+				('maintenance.audit_retention_days', '90'),
+				('maintenance.vacuum', 'true');
+		`,
+	},
+	// Directories scanned when importing existing compose stacks
+	{
+		name: "032_stack_import_settings",
+		up: `
+			INSERT OR IGNORE INTO settings (key, value) VALUES
+				('stacks.import_paths', '/opt/stacks,/srv/stacks');
+		`,
+	},
+	// Auto-start reconciliation reports recorded after host boot
+	{
+		name: "033_create_boot_reports",
+		up: `
+			CREATE TABLE boot_reports (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				boot_id TEXT,
+				trigger_type TEXT NOT NULL DEFAULT 'boot',
+				started_at DATETIME NOT NULL,
+				finished_at DATETIME NOT NULL,
+				total INTEGER DEFAULT 0,
+				already_running INTEGER DEFAULT 0,
+				started INTEGER DEFAULT 0,
+				failed INTEGER DEFAULT 0,
+				missing INTEGER DEFAULT 0,
+				results TEXT NOT NULL DEFAULT '[]'
+			);
+			CREATE INDEX idx_boot_reports_started ON boot_reports(started_at);
+		`,
+	},
+	// Stardeck-managed DNS network and web UI proxy targeting
+	{
+		name: "034_managed_network_settings",
+		up: `
+			INSERT OR IGNORE INTO settings (key, value) VALUES
+				('network.managed_name', 'stardeck'),
+				('proxy.target_mode', 'localhost');
+		`,
+	},
+	// Temporary container port exposures with automatic revocation
+	{
+		name: "035_create_port_exposures",
+		up: `
+			CREATE TABLE port_exposures (
+				id TEXT PRIMARY KEY,
+				container_id TEXT NOT NULL,
+				container_name TEXT NOT NULL,
+				container_port INTEGER NOT NULL,
+				host_port INTEGER NOT NULL,
+				protocol TEXT NOT NULL DEFAULT 'tcp',
+				target_ip TEXT NOT NULL,
+				zone TEXT,
+				expires_at DATETIME NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				revoked_at DATETIME
+			);
+			CREATE INDEX idx_port_exposures_active ON port_exposures(revoked_at, expires_at);
+		`,
+	},
+	// Regex rules that watch container logs and trigger actions
+	{
+		name: "036_create_log_rules",
+		up: `
+			CREATE TABLE log_rules (
+				id TEXT PRIMARY KEY,
+				container_id TEXT NOT NULL REFERENCES containers(id) ON DELETE CASCADE,
+				name TEXT NOT NULL,
+				pattern TEXT NOT NULL,
+				stream TEXT,
+				action TEXT NOT NULL,
+				webhook_url TEXT,
+				cooldown_seconds INTEGER NOT NULL DEFAULT 300,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				last_triggered_at DATETIME,
+				last_match TEXT,
+				trigger_count INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE INDEX idx_log_rules_container ON log_rules(container_id);
+		`,
+	},
+	// Recorded host terminal sessions
+	{
+		name: "037_create_terminal_sessions",
+		up: `
+			CREATE TABLE terminal_sessions (
+				id TEXT PRIMARY KEY,
+				user_id INTEGER NOT NULL,
+				username TEXT NOT NULL,
+				ip_address TEXT,
+				started_at DATETIME NOT NULL,
+				ended_at DATETIME,
+				end_reason TEXT,
+				recording_path TEXT,
+				recording_size INTEGER NOT NULL DEFAULT 0
+			);
+			CREATE INDEX idx_terminal_sessions_started ON terminal_sessions(started_at);
+			INSERT OR IGNORE INTO settings (key, value) VALUES
+				('terminal.recording_enabled', 'true'),
+				('terminal.idle_timeout_minutes', '30'),
+				('terminal.recording_retention_days', '30');
+		`,
+	},
+	// Bind-mount directories shared over WebDAV
+	{
+		name: "038_create_webdav_shares",
+		up: `
+			CREATE TABLE webdav_shares (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE,
+				path TEXT NOT NULL,
+				description TEXT,
+				read_only INTEGER NOT NULL DEFAULT 0,
+				min_role TEXT NOT NULL DEFAULT 'operator',
+				enabled INTEGER NOT NULL DEFAULT 1,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			INSERT OR IGNORE INTO settings (key, value) VALUES ('webdav.enabled', 'false');
+		`,
+	},
+	// Compose profiles enabled per stack
+	{
+		name: "039_add_stack_profiles",
+		up: `
+			ALTER TABLE stacks ADD COLUMN profiles TEXT DEFAULT '[]';
+		`,
+	},
+	// Four-eyes approval requests for destructive actions
+	{
+		name: "040_create_approval_requests",
+		up: `
+			CREATE TABLE approval_requests (
+				id TEXT PRIMARY KEY,
+				action TEXT NOT NULL,
+				method TEXT NOT NULL,
+				path TEXT NOT NULL,
+				query TEXT,
+				body TEXT,
+				fingerprint TEXT NOT NULL,
+				status TEXT NOT NULL DEFAULT 'pending',
+				reason TEXT,
+				requested_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				decided_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				decided_at DATETIME,
+				expires_at DATETIME NOT NULL,
+				executed_at DATETIME
+			);
+			CREATE INDEX idx_approval_requests_status ON approval_requests(status, expires_at);
+			INSERT OR IGNORE INTO settings (key, value) VALUES ('approvals.enabled', 'false');
+			INSERT OR IGNORE INTO settings (key, value) VALUES ('approvals.ttl_minutes', '30');
+		`,
+	},
+	// Saved container registry logins (passwords live in the Podman secret store)
+	{
+		name: "041_create_registry_credentials",
+		up: `
+			CREATE TABLE registry_credentials (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE,
+				registry TEXT NOT NULL,
+				username TEXT NOT NULL,
+				secret_name TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+		`,
+	},
+	// Host domain (AD/FreeIPA via SSSD) group to Stardeck role mappings
+	{
+		name: "042_create_domain_role_mappings",
+		up: `
+			CREATE TABLE domain_role_mappings (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				group_name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+				role TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+		`,
+	},
+	// Per-user container quotas; ownership itself is the containers/stacks created_by column
+	{
+		name: "043_create_user_quotas",
+		up: `
+			CREATE TABLE user_quotas (
+				user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+				max_containers INTEGER NOT NULL DEFAULT 0,
+				max_memory_bytes INTEGER NOT NULL DEFAULT 0,
+				max_cpus REAL NOT NULL DEFAULT 0,
+				max_disk_bytes INTEGER NOT NULL DEFAULT 0,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+		`,
+	},
+	// Projects: groups of workloads shared by a team, with per-project role bindings
+	{
+		name: "044_create_projects",
+		up: `
+			CREATE TABLE projects (
+				id TEXT PRIMARY KEY,
+				name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+				description TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE TABLE project_members (
+				project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				role TEXT NOT NULL,
+				added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (project_id, user_id)
+			);
+			CREATE INDEX idx_project_members_user ON project_members(user_id);
+			-- A resource belongs to at most one project
+			CREATE TABLE project_resources (
+				project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+				resource_type TEXT NOT NULL,
+				resource_id TEXT NOT NULL,
+				name TEXT NOT NULL DEFAULT '',
+				added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (resource_type, resource_id)
+			);
+			CREATE INDEX idx_project_resources_project ON project_resources(project_id);
+		`,
+	},
+	{
+		name: "045_create_invitations_and_password_resets",
+		up: `
+			CREATE TABLE user_invitations (
+				id TEXT PRIMARY KEY,
+				email TEXT NOT NULL,
+				display_name TEXT NOT NULL DEFAULT '',
+				role TEXT NOT NULL,
+				token_hash TEXT NOT NULL UNIQUE,
+				expires_at DATETIME NOT NULL,
+				accepted_at DATETIME,
+				accepted_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE TABLE password_resets (
+				token_hash TEXT PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				expires_at DATETIME NOT NULL,
+				used_at DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX idx_password_resets_user ON password_resets(user_id);
+		`,
+	},
+	{
+		name: "046_create_public_apps",
+		up: `
+			CREATE TABLE public_apps (
+				container_id TEXT PRIMARY KEY REFERENCES containers(id) ON DELETE CASCADE,
+				slug TEXT NOT NULL UNIQUE,
+				pin_hash TEXT NOT NULL DEFAULT '',
+				listed INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+		`,
+	},
+	{
+		name: "047_create_container_schedules",
+		up: `
+			CREATE TABLE container_schedules (
+				container_id TEXT PRIMARY KEY REFERENCES containers(id) ON DELETE CASCADE,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				start_schedule TEXT NOT NULL DEFAULT '',
+				stop_schedule TEXT NOT NULL DEFAULT '',
+				timezone TEXT NOT NULL DEFAULT 'Local',
+				override_action TEXT NOT NULL DEFAULT '',
+				override_by TEXT NOT NULL DEFAULT '',
+				override_at DATETIME,
+				override_until DATETIME,
+				last_action TEXT NOT NULL DEFAULT '',
+				last_action_at DATETIME,
+				last_error TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+		`,
+	},
+	{
+		name: "048_create_energy_usage",
+		up: `
+			CREATE TABLE energy_usage (
+				day TEXT NOT NULL,
+				container_name TEXT NOT NULL DEFAULT '',
+				joules REAL NOT NULL DEFAULT 0,
+				cpu_seconds REAL NOT NULL DEFAULT 0,
+				PRIMARY KEY (day, container_name)
+			);
+		`,
+	},
+	{
+		name: "049_create_container_exits",
+		up: `
+			CREATE TABLE container_exits (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				container_id TEXT NOT NULL,
+				container_name TEXT NOT NULL,
+				image TEXT NOT NULL DEFAULT '',
+				exit_code INTEGER NOT NULL DEFAULT 0,
+				oom_killed INTEGER NOT NULL DEFAULT 0,
+				reason TEXT NOT NULL,
+				memory_limit INTEGER NOT NULL DEFAULT 0,
+				runtime_seconds INTEGER NOT NULL DEFAULT 0,
+				exited_at DATETIME NOT NULL
+			);
+			CREATE INDEX idx_container_exits_name_time ON container_exits(container_name, exited_at);
+			CREATE INDEX idx_container_exits_time ON container_exits(exited_at);
+		`,
+	},
+	{
+		name: "050_create_client_certificates",
+		up: `
+			CREATE TABLE client_certificates (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				serial TEXT NOT NULL UNIQUE,
+				common_name TEXT NOT NULL,
+				dns_names TEXT NOT NULL DEFAULT '',
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				scope TEXT NOT NULL DEFAULT 'full',
+				not_after DATETIME NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by TEXT NOT NULL DEFAULT '',
+				revoked_at DATETIME,
+				last_used_at DATETIME
+			);
+		`,
+	},
+	{
+		name: "051_create_security_profiles",
+		up: `
+			CREATE TABLE security_profiles (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				type TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				content TEXT NOT NULL,
+				curated TEXT NOT NULL DEFAULT '',
+				path TEXT NOT NULL,
+				created_by TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE(type, name)
+			);
+		`,
+	},
+	{
+		name: "052_create_container_egress_policies",
+		up: `
+			CREATE TABLE container_egress_policies (
+				container_id TEXT PRIMARY KEY REFERENCES containers(id) ON DELETE CASCADE,
+				default_action TEXT NOT NULL DEFAULT 'allow',
+				block_internet INTEGER NOT NULL DEFAULT 0,
+				rules TEXT NOT NULL DEFAULT '[]',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_by TEXT NOT NULL DEFAULT ''
+			);
+		`,
+	},
+	{
+		name: "053_create_dhcp",
+		up: `
+			CREATE TABLE dhcp_scopes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				interface TEXT NOT NULL,
+				range_start TEXT NOT NULL,
+				range_end TEXT NOT NULL,
+				netmask TEXT NOT NULL,
+				lease_time INTEGER NOT NULL DEFAULT 86400,
+				gateway TEXT NOT NULL DEFAULT '',
+				dns_servers TEXT NOT NULL DEFAULT '',
+				domain_name TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE dhcp_reservations (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				scope_id INTEGER NOT NULL REFERENCES dhcp_scopes(id) ON DELETE CASCADE,
+				mac TEXT NOT NULL UNIQUE,
+				ip_address TEXT NOT NULL UNIQUE,
+				hostname TEXT NOT NULL DEFAULT '',
+				description TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX idx_dhcp_reservations_scope ON dhcp_reservations(scope_id);
+		`,
+	},
+	{
+		name: "054_create_dns_records",
+		up: `
+			CREATE TABLE dns_records (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				type TEXT NOT NULL,
+				value TEXT NOT NULL,
+				comment TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE(name, type, value)
+			);
+		`,
+	},
+	{
+		name: "055_create_lan_devices",
+		up: `
+			CREATE TABLE lan_devices (
+				mac TEXT PRIMARY KEY,
+				ip_address TEXT NOT NULL DEFAULT '',
+				interface TEXT NOT NULL DEFAULT '',
+				hostname TEXT NOT NULL DEFAULT '',
+				vendor TEXT NOT NULL DEFAULT '',
+				name TEXT NOT NULL DEFAULT '',
+				known INTEGER NOT NULL DEFAULT 0,
+				first_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+				last_seen DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+		`,
+	},
+	{
+		name: "056_create_cert_monitors",
+		up: `
+			CREATE TABLE cert_monitors (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				host TEXT NOT NULL,
+				port INTEGER NOT NULL DEFAULT 443,
+				server_name TEXT NOT NULL DEFAULT '',
+				container_id TEXT NOT NULL DEFAULT '',
+				warn_days INTEGER NOT NULL DEFAULT 21,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				subject TEXT NOT NULL DEFAULT '',
+				issuer TEXT NOT NULL DEFAULT '',
+				dns_names TEXT NOT NULL DEFAULT '',
+				not_after DATETIME,
+				trusted INTEGER NOT NULL DEFAULT 0,
+				verify_error TEXT NOT NULL DEFAULT '',
+				check_error TEXT NOT NULL DEFAULT '',
+				checked_at DATETIME,
+				alert_state TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by TEXT NOT NULL DEFAULT ''
+			);
+		`,
+	},
+	{
+		name: "057_create_game_server_profiles",
+		up: `
+			CREATE TABLE game_server_profiles (
+				container_id TEXT PRIMARY KEY REFERENCES containers(id) ON DELETE CASCADE,
+				preset TEXT NOT NULL DEFAULT '',
+				enabled INTEGER NOT NULL DEFAULT 1,
+				rcon_port INTEGER NOT NULL DEFAULT 0,
+				rcon_password TEXT NOT NULL DEFAULT '',
+				rcon_password_env TEXT NOT NULL DEFAULT '',
+				probe TEXT NOT NULL DEFAULT 'none',
+				query_port INTEGER NOT NULL DEFAULT 0,
+				player_command TEXT NOT NULL DEFAULT '',
+				player_pattern TEXT NOT NULL DEFAULT '',
+				restart_schedule TEXT NOT NULL DEFAULT '',
+				timezone TEXT NOT NULL DEFAULT 'Local',
+				restart_warnings TEXT NOT NULL DEFAULT '[]',
+				warning_command TEXT NOT NULL DEFAULT '',
+				save_command TEXT NOT NULL DEFAULT '',
+				idle_shutdown_minutes INTEGER NOT NULL DEFAULT 0,
+				players INTEGER,
+				max_players INTEGER NOT NULL DEFAULT 0,
+				last_probe_at DATETIME,
+				probe_error TEXT NOT NULL DEFAULT '',
+				idle_since DATETIME,
+				last_action TEXT NOT NULL DEFAULT '',
+				last_action_at DATETIME,
+				last_error TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+		`,
+	},
+	{
+		name: "058_create_container_config_history",
+		up: `
+			CREATE TABLE container_config_history (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				container_id TEXT NOT NULL REFERENCES containers(id) ON DELETE CASCADE,
+				podman_id TEXT NOT NULL DEFAULT '',
+				version INTEGER NOT NULL,
+				reason TEXT NOT NULL DEFAULT '',
+				config TEXT NOT NULL,
+				config_hash TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				UNIQUE(container_id, version)
+			);
+		`,
+	},
+	{
+		name: "059_create_cloud_mounts",
+		up: `
+			CREATE TABLE cloud_mounts (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				remote TEXT NOT NULL,
+				remote_path TEXT NOT NULL DEFAULT '',
+				mount_path TEXT NOT NULL,
+				volume_name TEXT NOT NULL DEFAULT '',
+				read_only INTEGER NOT NULL DEFAULT 0,
+				cache_mode TEXT NOT NULL DEFAULT 'writes',
+				enabled INTEGER NOT NULL DEFAULT 1,
+				status TEXT NOT NULL DEFAULT '',
+				last_error TEXT NOT NULL DEFAULT '',
+				checked_at DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+		`,
+	},
+	{
+		name: "060_create_data_pools",
+		up: `
+			CREATE TABLE data_pools (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				purpose TEXT NOT NULL DEFAULT 'general',
+				path TEXT NOT NULL UNIQUE,
+				container_path TEXT NOT NULL,
+				quota_bytes INTEGER NOT NULL DEFAULT 0,
+				uid INTEGER NOT NULL DEFAULT 1000,
+				gid INTEGER NOT NULL DEFAULT 1000,
+				mode TEXT NOT NULL DEFAULT '2775',
+				umask TEXT NOT NULL DEFAULT '002',
+				used_bytes INTEGER NOT NULL DEFAULT 0,
+				measured_at DATETIME,
+				over_quota INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+		`,
+	},
+	{
+		name: "061_create_ingress_hosts",
+		up: `
+			CREATE TABLE ingress_hosts (
+				container_id TEXT PRIMARY KEY REFERENCES containers(id) ON DELETE CASCADE,
+				label TEXT NOT NULL DEFAULT '',
+				enabled INTEGER NOT NULL DEFAULT 1
+			);
+		`,
+	},
+	{
+		name: "062_add_session_read_only",
+		up: `
+			ALTER TABLE sessions ADD COLUMN read_only INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
+	{
+		name: "063_create_host_snapshots",
+		up: `
+			CREATE TABLE host_snapshots (
+				id TEXT PRIMARY KEY,
+				backend TEXT NOT NULL,
+				path TEXT NOT NULL,
+				mount_point TEXT NOT NULL,
+				device TEXT NOT NULL DEFAULT '',
+				source TEXT NOT NULL,
+				name TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				status TEXT NOT NULL DEFAULT 'ready',
+				reboot_required INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+				rolled_back_at DATETIME
+			);
+			CREATE INDEX idx_host_snapshots_source ON host_snapshots(backend, source, created_at);
+		`,
+	},
+	{
+		name: "064_create_digest_subscriptions",
+		up: `
+			CREATE TABLE digest_subscriptions (
+				user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+				frequency TEXT NOT NULL DEFAULT 'off',
+				hour INTEGER NOT NULL DEFAULT 8,
+				weekday INTEGER NOT NULL DEFAULT 1,
+				sections TEXT NOT NULL DEFAULT '',
+				email INTEGER NOT NULL DEFAULT 1,
+				notification INTEGER NOT NULL DEFAULT 0,
+				last_sent_at DATETIME,
+				disk_baseline TEXT NOT NULL DEFAULT ''
+			);
+		`,
+	},
+	{
+		name: "065_create_plugins",
+		up: `
+			CREATE TABLE plugins (
+				name TEXT PRIMARY KEY,
+				version TEXT NOT NULL DEFAULT '',
+				description TEXT NOT NULL DEFAULT '',
+				author TEXT NOT NULL DEFAULT '',
+				command TEXT NOT NULL,
+				enabled INTEGER NOT NULL DEFAULT 0,
+				installed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				installed_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE TABLE plugin_jobs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				plugin TEXT NOT NULL REFERENCES plugins(name) ON DELETE CASCADE,
+				type TEXT NOT NULL,
+				name TEXT NOT NULL,
+				schedule TEXT NOT NULL,
+				params TEXT NOT NULL DEFAULT '{}',
+				enabled INTEGER NOT NULL DEFAULT 1,
+				last_run_at DATETIME,
+				last_status TEXT NOT NULL DEFAULT '',
+				last_output TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+		`,
+	},
+	{
+		name: "066_create_host_metrics",
+		up: `
+			CREATE TABLE host_metrics (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				timestamp DATETIME NOT NULL,
+				cpu_percent REAL NOT NULL DEFAULT 0,
+				iowait_percent REAL NOT NULL DEFAULT 0,
+				memory_total INTEGER NOT NULL DEFAULT 0,
+				memory_used INTEGER NOT NULL DEFAULT 0,
+				swap_total INTEGER NOT NULL DEFAULT 0,
+				swap_used INTEGER NOT NULL DEFAULT 0,
+				load_1 REAL NOT NULL DEFAULT 0,
+				load_5 REAL NOT NULL DEFAULT 0,
+				load_15 REAL NOT NULL DEFAULT 0,
+				disk_read_rate INTEGER NOT NULL DEFAULT 0,
+				disk_write_rate INTEGER NOT NULL DEFAULT 0,
+				disk_busy_percent REAL NOT NULL DEFAULT 0,
+				temperature_max REAL
+			);
+			CREATE INDEX idx_host_metrics_time ON host_metrics(timestamp);
+		`,
+	},
+	{
+		name: "067_create_roles",
+		up: `
+			CREATE TABLE roles (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				description TEXT NOT NULL DEFAULT '',
+				base_role TEXT NOT NULL,
+				permissions TEXT NOT NULL DEFAULT '[]',
+				built_in INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+
+			-- The built-in roles keep the access they had: admins and operators
+			-- everything their role checks allow, viewers every read
+			INSERT INTO roles (name, description, base_role, permissions, built_in) VALUES
+				('admin', 'Full access', 'admin', '["*"]', 1),
+				('operator', 'Day-to-day operations', 'operator', '["*"]', 1),
+				('viewer', 'Read-only access', 'viewer', '["*:read"]', 1);
+
+			ALTER TABLE users ADD COLUMN role_id INTEGER REFERENCES roles(id);
+			CREATE INDEX idx_users_role_id ON users(role_id);
+
+			-- Anything but the three roles never granted more than a viewer gets
+			UPDATE users SET role = 'viewer' WHERE role NOT IN ('admin', 'operator', 'viewer');
+		`,
+	},
+	{
+		name: "068_create_volume_usage_samples",
+		up: `
+			CREATE TABLE volume_usage_samples (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				host_path TEXT NOT NULL,
+				size_bytes INTEGER NOT NULL,
+				timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX idx_volume_usage_samples_path_time ON volume_usage_samples(host_path, timestamp);
+		`,
+	},
+	{
+		name: "069_create_user_two_factor",
+		up: `
+			CREATE TABLE user_two_factor (
+				user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+				secret TEXT NOT NULL,
+				enabled INTEGER NOT NULL DEFAULT 0,
+				last_step INTEGER NOT NULL DEFAULT 0,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				enabled_at DATETIME
+			);
+
+			CREATE TABLE user_recovery_codes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				code_hash TEXT NOT NULL,
+				used_at DATETIME
+			);
+			CREATE INDEX idx_user_recovery_codes_user ON user_recovery_codes(user_id);
+		`,
+	},
+	{
+		name: "070_create_api_tokens",
+		up: `
+			CREATE TABLE api_tokens (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+				name TEXT NOT NULL,
+				token_hash TEXT NOT NULL UNIQUE,
+				prefix TEXT NOT NULL,
+				scopes TEXT NOT NULL DEFAULT '[]',
+				expires_at DATETIME,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				last_used_at DATETIME,
+				last_used_ip TEXT NOT NULL DEFAULT '',
+				revoked_at DATETIME
+			);
+			CREATE INDEX idx_api_tokens_user ON api_tokens(user_id);
+		`,
+	},
+	{
+		name: "071_create_container_auto_updates",
+		up: `
+			CREATE TABLE container_auto_updates (
+				container_id TEXT PRIMARY KEY REFERENCES containers(id) ON DELETE CASCADE,
+				enabled INTEGER NOT NULL DEFAULT 1,
+				policy TEXT NOT NULL DEFAULT 'notify',
+				check_interval_hours INTEGER NOT NULL DEFAULT 24,
+				window_schedule TEXT NOT NULL DEFAULT '',
+				window_minutes INTEGER NOT NULL DEFAULT 60,
+				timezone TEXT NOT NULL DEFAULT 'Local',
+				create_backup INTEGER NOT NULL DEFAULT 0,
+				remove_old INTEGER NOT NULL DEFAULT 0,
+				update_available INTEGER NOT NULL DEFAULT 0,
+				local_digest TEXT NOT NULL DEFAULT '',
+				remote_digest TEXT NOT NULL DEFAULT '',
+				notified_digest TEXT NOT NULL DEFAULT '',
+				last_checked_at DATETIME,
+				last_attempt_at DATETIME,
+				last_error TEXT NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				created_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+			);
+			CREATE TABLE container_update_history (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				container_id TEXT NOT NULL,
+				container_name TEXT NOT NULL DEFAULT '',
+				source TEXT NOT NULL,
+				triggered_by TEXT NOT NULL DEFAULT '',
+				old_image TEXT NOT NULL DEFAULT '',
+				new_image TEXT NOT NULL DEFAULT '',
+				old_digest TEXT NOT NULL DEFAULT '',
+				new_digest TEXT NOT NULL DEFAULT '',
+				status TEXT NOT NULL,
+				error TEXT NOT NULL DEFAULT '',
+				backup_container TEXT NOT NULL DEFAULT '',
+				started_at DATETIME NOT NULL,
+				finished_at DATETIME
+			);
+			CREATE INDEX idx_container_update_history_container ON container_update_history(container_id, started_at);
+		`,
+	},
+	{
+		name: "072_create_alliance_provider_health",
+		up: `
+			CREATE TABLE alliance_provider_health (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				provider_id TEXT NOT NULL REFERENCES alliance_providers(id) ON DELETE CASCADE,
+				healthy INTEGER NOT NULL DEFAULT 0,
+				discovery_ok INTEGER NOT NULL DEFAULT 0,
+				discovery_ms INTEGER NOT NULL DEFAULT 0,
+				jwks_ok INTEGER NOT NULL DEFAULT 0,
+				jwks_ms INTEGER NOT NULL DEFAULT 0,
+				jwks_keys INTEGER NOT NULL DEFAULT 0,
+				token_ok INTEGER NOT NULL DEFAULT 0,
+				token_ms INTEGER NOT NULL DEFAULT 0,
+				error TEXT NOT NULL DEFAULT '',
+				checked_at DATETIME NOT NULL
+			);
+			CREATE INDEX idx_alliance_provider_health_provider ON alliance_provider_health(provider_id, checked_at);
+		`,
+	},
+	{
+		name: "073_add_alliance_client_secret_rotation",
+		up: `
+			ALTER TABLE alliance_clients ADD COLUMN secret_created_at DATETIME;
+			ALTER TABLE alliance_clients ADD COLUMN previous_secret TEXT NOT NULL DEFAULT '';
+			ALTER TABLE alliance_clients ADD COLUMN previous_secret_expires_at DATETIME;
+			ALTER TABLE alliance_clients ADD COLUMN secret_age_notified_at DATETIME;
+			UPDATE alliance_clients SET secret_created_at = created_at;
+		`,
+	},
+	{
+		name: "074_add_ingress_host_public",
+		up: `
+			ALTER TABLE ingress_hosts ADD COLUMN public INTEGER NOT NULL DEFAULT 0;
+		`,
+	},
 }
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// DHCPRepo handles DHCP scope and reservation database operations
+type DHCPRepo struct {
+	db *sql.DB
+}
+
+// NewDHCPRepo creates a new DHCP repository
+func NewDHCPRepo() *DHCPRepo {
+	return &DHCPRepo{db: DB}
+}
+
+const dhcpScopeColumns = `id, name, interface, range_start, range_end, netmask, lease_time, gateway, dns_servers, domain_name, created_at, updated_at`
+
+// scanDHCPScope scans a DHCP scope row
+func scanDHCPScope(row rowScanner) (*models.DHCPScope, error) {
+	s := &models.DHCPScope{}
+	var dnsServers string
+	if err := row.Scan(&s.ID, &s.Name, &s.Interface, &s.RangeStart, &s.RangeEnd, &s.Netmask, &s.LeaseTime,
+		&s.Gateway, &dnsServers, &s.DomainName, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	s.DNSServers = strings.Fields(dnsServers)
+	return s, nil
+}
+
+// CreateScope adds a scope
+func (r *DHCPRepo) CreateScope(s *models.DHCPScope) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	result, err := r.db.Exec(`
+		INSERT INTO dhcp_scopes (name, interface, range_start, range_end, netmask, lease_time, gateway, dns_servers, domain_name, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, s.Name, s.Interface, s.RangeStart, s.RangeEnd, s.Netmask, s.LeaseTime, s.Gateway,
+		strings.Join(s.DNSServers, " "), s.DomainName, s.CreatedAt, s.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	s.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetScope retrieves a scope by ID
+func (r *DHCPRepo) GetScope(id int64) (*models.DHCPScope, error) {
+	return scanDHCPScope(r.db.QueryRow("SELECT "+dhcpScopeColumns+" FROM dhcp_scopes WHERE id = ?", id))
+}
+
+// ListScopes returns all scopes ordered by interface and name
+func (r *DHCPRepo) ListScopes() ([]models.DHCPScope, error) {
+	rows, err := r.db.Query("SELECT " + dhcpScopeColumns + " FROM dhcp_scopes ORDER BY interface, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scopes := []models.DHCPScope{}
+	for rows.Next() {
+		s, err := scanDHCPScope(rows)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, *s)
+	}
+	return scopes, rows.Err()
+}
+
+// UpdateScope saves a scope
+func (r *DHCPRepo) UpdateScope(s *models.DHCPScope) error {
+	s.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		UPDATE dhcp_scopes SET name = ?, interface = ?, range_start = ?, range_end = ?, netmask = ?, lease_time = ?,
+			gateway = ?, dns_servers = ?, domain_name = ?, updated_at = ?
+		WHERE id = ?
+	`, s.Name, s.Interface, s.RangeStart, s.RangeEnd, s.Netmask, s.LeaseTime, s.Gateway,
+		strings.Join(s.DNSServers, " "), s.DomainName, s.UpdatedAt, s.ID)
+	return err
+}
+
+// DeleteScope removes a scope and its reservations
+func (r *DHCPRepo) DeleteScope(id int64) error {
+	_, err := r.db.Exec("DELETE FROM dhcp_scopes WHERE id = ?", id)
+	return err
+}
+
+const dhcpReservationColumns = `id, scope_id, mac, ip_address, hostname, description, created_at`
+
+// scanDHCPReservation scans a DHCP reservation row
+func scanDHCPReservation(row rowScanner) (*models.DHCPReservation, error) {
+	res := &models.DHCPReservation{}
+	if err := row.Scan(&res.ID, &res.ScopeID, &res.MAC, &res.IPAddress, &res.Hostname, &res.Description, &res.CreatedAt); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CreateReservation adds a reservation
+func (r *DHCPRepo) CreateReservation(res *models.DHCPReservation) error {
+	res.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO dhcp_reservations (scope_id, mac, ip_address, hostname, description, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, res.ScopeID, res.MAC, res.IPAddress, res.Hostname, res.Description, res.CreatedAt)
+	if err != nil {
+		return err
+	}
+	res.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetReservation retrieves a reservation by ID
+func (r *DHCPRepo) GetReservation(id int64) (*models.DHCPReservation, error) {
+	return scanDHCPReservation(r.db.QueryRow("SELECT "+dhcpReservationColumns+" FROM dhcp_reservations WHERE id = ?", id))
+}
+
+// ListReservations returns the reservations of a scope, or of every scope when scopeID is 0
+func (r *DHCPRepo) ListReservations(scopeID int64) ([]models.DHCPReservation, error) {
+	query := "SELECT " + dhcpReservationColumns + " FROM dhcp_reservations"
+	args := []interface{}{}
+	if scopeID != 0 {
+		query += " WHERE scope_id = ?"
+		args = append(args, scopeID)
+	}
+	rows, err := r.db.Query(query+" ORDER BY ip_address", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []models.DHCPReservation{}
+	for rows.Next() {
+		res, err := scanDHCPReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, *res)
+	}
+	return reservations, rows.Err()
+}
+
+// DeleteReservation removes a reservation
+func (r *DHCPRepo) DeleteReservation(id int64) error {
+	_, err := r.db.Exec("DELETE FROM dhcp_reservations WHERE id = ?", id)
+	return err
+}
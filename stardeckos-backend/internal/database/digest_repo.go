@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// DigestRepo handles users' digest subscriptions
+type DigestRepo struct {
+	db *sql.DB
+}
+
+// NewDigestRepo creates a new digest repository
+func NewDigestRepo() *DigestRepo {
+	return &DigestRepo{db: DB}
+}
+
+const digestColumns = "user_id, frequency, hour, weekday, sections, email, notification, last_sent_at, disk_baseline"
+
+// Get returns a user's subscription, or sql.ErrNoRows when they have none
+func (r *DigestRepo) Get(userID int64) (*models.DigestSubscription, error) {
+	subs, err := r.query("SELECT "+digestColumns+" FROM digest_subscriptions WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &subs[0], nil
+}
+
+// ListActive returns every subscription that sends digests
+func (r *DigestRepo) ListActive() ([]models.DigestSubscription, error) {
+	return r.query("SELECT "+digestColumns+" FROM digest_subscriptions WHERE frequency != ?", models.DigestOff)
+}
+
+// Upsert creates or replaces a user's subscription, keeping the disk baseline
+func (r *DigestRepo) Upsert(s *models.DigestSubscription) error {
+	_, err := r.db.Exec(`
+		INSERT INTO digest_subscriptions (user_id, frequency, hour, weekday, sections, email, notification, last_sent_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			frequency = excluded.frequency, hour = excluded.hour, weekday = excluded.weekday,
+			sections = excluded.sections, email = excluded.email, notification = excluded.notification,
+			last_sent_at = excluded.last_sent_at
+	`, s.UserID, s.Frequency, s.Hour, s.Weekday, strings.Join(s.Sections, " "), s.Email, s.Notification, s.LastSentAt)
+	return err
+}
+
+// MarkSent records when a digest went out and the disk usage it reported
+func (r *DigestRepo) MarkSent(userID int64, at time.Time, diskBaseline string) error {
+	_, err := r.db.Exec(`
+		UPDATE digest_subscriptions SET last_sent_at = ?, disk_baseline = ? WHERE user_id = ?
+	`, at, diskBaseline, userID)
+	return err
+}
+
+func (r *DigestRepo) query(query string, args ...interface{}) ([]models.DigestSubscription, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.DigestSubscription
+	for rows.Next() {
+		var s models.DigestSubscription
+		var sections string
+		var email, notification int
+		var lastSent sql.NullTime
+		if err := rows.Scan(&s.UserID, &s.Frequency, &s.Hour, &s.Weekday, &sections, &email, &notification,
+			&lastSent, &s.DiskBaseline); err != nil {
+			return nil, err
+		}
+		s.Sections = strings.Fields(sections)
+		s.Email = email == 1
+		s.Notification = notification == 1
+		if lastSent.Valid {
+			s.LastSentAt = &lastSent.Time
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// DNSRecordRepo handles local DNS record database operations
+type DNSRecordRepo struct {
+	db *sql.DB
+}
+
+// NewDNSRecordRepo creates a new DNS record repository
+func NewDNSRecordRepo() *DNSRecordRepo {
+	return &DNSRecordRepo{db: DB}
+}
+
+const dnsRecordColumns = `id, name, type, value, comment, created_at, updated_at`
+
+// scanDNSRecord scans a DNS record row
+func scanDNSRecord(row rowScanner) (*models.DNSRecord, error) {
+	rec := &models.DNSRecord{}
+	if err := row.Scan(&rec.ID, &rec.Name, &rec.Type, &rec.Value, &rec.Comment, &rec.CreatedAt, &rec.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// Create adds a record
+func (r *DNSRecordRepo) Create(rec *models.DNSRecord) error {
+	rec.CreatedAt = time.Now()
+	rec.UpdatedAt = rec.CreatedAt
+	result, err := r.db.Exec(`
+		INSERT INTO dns_records (name, type, value, comment, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, rec.Name, rec.Type, rec.Value, rec.Comment, rec.CreatedAt, rec.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	rec.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves a record by ID
+func (r *DNSRecordRepo) GetByID(id int64) (*models.DNSRecord, error) {
+	return scanDNSRecord(r.db.QueryRow("SELECT "+dnsRecordColumns+" FROM dns_records WHERE id = ?", id))
+}
+
+// List returns all records ordered by name and type
+func (r *DNSRecordRepo) List() ([]models.DNSRecord, error) {
+	rows, err := r.db.Query("SELECT " + dnsRecordColumns + " FROM dns_records ORDER BY name, type, value")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.DNSRecord{}
+	for rows.Next() {
+		rec, err := scanDNSRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *rec)
+	}
+	return records, rows.Err()
+}
+
+// Update saves a record
+func (r *DNSRecordRepo) Update(rec *models.DNSRecord) error {
+	rec.UpdatedAt = time.Now()
+	_, err := r.db.Exec("UPDATE dns_records SET name = ?, type = ?, value = ?, comment = ?, updated_at = ? WHERE id = ?",
+		rec.Name, rec.Type, rec.Value, rec.Comment, rec.UpdatedAt, rec.ID)
+	return err
+}
+
+// Delete removes a record
+func (r *DNSRecordRepo) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM dns_records WHERE id = ?", id)
+	return err
+}
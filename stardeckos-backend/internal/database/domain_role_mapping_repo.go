@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// DomainRoleMappingRepo handles domain group to role mapping database operations
+type DomainRoleMappingRepo struct {
+	db *sql.DB
+}
+
+// NewDomainRoleMappingRepo creates a new domain role mapping repository
+func NewDomainRoleMappingRepo() *DomainRoleMappingRepo {
+	return &DomainRoleMappingRepo{db: DB}
+}
+
+// Create adds a new mapping
+func (r *DomainRoleMappingRepo) Create(m *models.DomainRoleMapping) error {
+	m.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO domain_role_mappings (group_name, role, created_at) VALUES (?, ?, ?)
+	`, m.GroupName, m.Role, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+	m.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetByID retrieves a mapping by ID
+func (r *DomainRoleMappingRepo) GetByID(id int64) (*models.DomainRoleMapping, error) {
+	m := &models.DomainRoleMapping{}
+	err := r.db.QueryRow(`
+		SELECT id, group_name, role, created_at FROM domain_role_mappings WHERE id = ?
+	`, id).Scan(&m.ID, &m.GroupName, &m.Role, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// List returns all mappings ordered by group name
+func (r *DomainRoleMappingRepo) List() ([]models.DomainRoleMapping, error) {
+	rows, err := r.db.Query(`
+		SELECT id, group_name, role, created_at FROM domain_role_mappings ORDER BY group_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []models.DomainRoleMapping
+	for rows.Next() {
+		var m models.DomainRoleMapping
+		if err := rows.Scan(&m.ID, &m.GroupName, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, rows.Err()
+}
+
+// Delete removes a mapping
+func (r *DomainRoleMappingRepo) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM domain_role_mappings WHERE id = ?", id)
+	return err
+}
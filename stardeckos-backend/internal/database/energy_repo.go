@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql"
+
+	"stardeckos-backend/internal/models"
+)
+
+// EnergyRepo handles daily energy usage database operations
+type EnergyRepo struct {
+	db *sql.DB
+}
+
+// NewEnergyRepo creates a new energy repository
+func NewEnergyRepo() *EnergyRepo {
+	return &EnergyRepo{db: DB}
+}
+
+// Add accumulates energy and CPU time for a day. An empty container name is the whole host.
+func (r *EnergyRepo) Add(day, containerName string, joules, cpuSeconds float64) error {
+	_, err := r.db.Exec(`
+		INSERT INTO energy_usage (day, container_name, joules, cpu_seconds) VALUES (?, ?, ?, ?)
+		ON CONFLICT(day, container_name) DO UPDATE SET
+			joules = joules + excluded.joules, cpu_seconds = cpu_seconds + excluded.cpu_seconds
+	`, day, containerName, joules, cpuSeconds)
+	return err
+}
+
+// ListSince returns daily usage from the given day onwards, newest first,
+// with host totals (empty container name) ahead of containers
+func (r *EnergyRepo) ListSince(day string) ([]models.EnergyDay, error) {
+	rows, err := r.db.Query(`
+		SELECT day, container_name, joules, cpu_seconds FROM energy_usage
+		WHERE day >= ? ORDER BY day DESC, container_name = '' DESC, joules DESC
+	`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []models.EnergyDay
+	for rows.Next() {
+		var d, name string
+		var joules, cpuSeconds float64
+		if err := rows.Scan(&d, &name, &joules, &cpuSeconds); err != nil {
+			return nil, err
+		}
+		if len(days) == 0 || days[len(days)-1].Day != d {
+			days = append(days, models.EnergyDay{Day: d, Containers: []models.ContainerEnergy{}})
+		}
+		current := &days[len(days)-1]
+		if name == "" {
+			current.HostKWh = joules / 3.6e6
+			continue
+		}
+		current.Containers = append(current.Containers, models.ContainerEnergy{
+			Name:       name,
+			KWh:        joules / 3.6e6,
+			CPUSeconds: cpuSeconds,
+		})
+	}
+	return days, rows.Err()
+}
+
+// DeleteBefore removes usage older than the given day
+func (r *EnergyRepo) DeleteBefore(day string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM energy_usage WHERE day < ?", day)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// maxExecTaskRuns is how many runs of history are kept per task
+const maxExecTaskRuns = 50
+
+// ExecTaskRepo handles scheduled exec task database operations
+type ExecTaskRepo struct {
+	db *sql.DB
+}
+
+// NewExecTaskRepo creates a new exec task repository
+func NewExecTaskRepo() *ExecTaskRepo {
+	return &ExecTaskRepo{db: DB}
+}
+
+const execTaskColumns = `
+	id, container_id, name, command, schedule, enabled, timeout_seconds, notify_on_failure,
+	last_run_at, last_status, last_exit_code, created_at, updated_at, created_by`
+
+// Create adds a new exec task
+func (r *ExecTaskRepo) Create(t *models.ExecTask) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO exec_tasks (
+			id, container_id, name, command, schedule, enabled, timeout_seconds,
+			notify_on_failure, created_at, updated_at, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		t.ID, t.ContainerID, t.Name, t.Command, t.Schedule, t.Enabled, t.TimeoutSeconds,
+		t.NotifyOnFailure, t.CreatedAt, t.UpdatedAt, t.CreatedBy,
+	)
+	return err
+}
+
+// GetByID retrieves an exec task by ID
+func (r *ExecTaskRepo) GetByID(id string) (*models.ExecTask, error) {
+	return scanExecTask(r.db.QueryRow("SELECT "+execTaskColumns+" FROM exec_tasks WHERE id = ?", id))
+}
+
+// ListByContainer retrieves all exec tasks for a container
+func (r *ExecTaskRepo) ListByContainer(containerID string) ([]models.ExecTask, error) {
+	return r.list("SELECT "+execTaskColumns+" FROM exec_tasks WHERE container_id = ? ORDER BY name", containerID)
+}
+
+// ListEnabled retrieves all enabled exec tasks
+func (r *ExecTaskRepo) ListEnabled() ([]models.ExecTask, error) {
+	return r.list("SELECT " + execTaskColumns + " FROM exec_tasks WHERE enabled = 1")
+}
+
+// Update updates an exec task's definition
+func (r *ExecTaskRepo) Update(t *models.ExecTask) error {
+	t.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		UPDATE exec_tasks SET
+			name = ?, command = ?, schedule = ?, enabled = ?, timeout_seconds = ?,
+			notify_on_failure = ?, updated_at = ?
+		WHERE id = ?
+	`,
+		t.Name, t.Command, t.Schedule, t.Enabled, t.TimeoutSeconds,
+		t.NotifyOnFailure, t.UpdatedAt, t.ID,
+	)
+	return err
+}
+
+// Delete removes an exec task and its run history
+func (r *ExecTaskRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM exec_tasks WHERE id = ?", id)
+	return err
+}
+
+// StartRun records the start of a task run and returns its ID
+func (r *ExecTaskRepo) StartRun(run *models.ExecTaskRun) error {
+	run.StartedAt = time.Now()
+	run.Status = models.ExecTaskStatusRunning
+	result, err := r.db.Exec(`
+		INSERT INTO exec_task_runs (task_id, trigger_type, status, started_at)
+		VALUES (?, ?, ?, ?)
+	`, run.TaskID, run.Trigger, run.Status, run.StartedAt)
+	if err != nil {
+		return err
+	}
+	run.ID, err = result.LastInsertId()
+	return err
+}
+
+// FinishRun stores a run's result, updates the task's last-run summary, and prunes old history
+func (r *ExecTaskRepo) FinishRun(run *models.ExecTaskRun) error {
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+
+	if _, err := r.db.Exec(`
+		UPDATE exec_task_runs SET status = ?, exit_code = ?, output = ?, finished_at = ?, duration_ms = ?
+		WHERE id = ?
+	`, run.Status, run.ExitCode, run.Output, finished, run.DurationMs, run.ID); err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(`
+		UPDATE exec_tasks SET last_run_at = ?, last_status = ?, last_exit_code = ? WHERE id = ?
+	`, run.StartedAt, run.Status, run.ExitCode, run.TaskID); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(`
+		DELETE FROM exec_task_runs WHERE task_id = ? AND id NOT IN (
+			SELECT id FROM exec_task_runs WHERE task_id = ? ORDER BY started_at DESC LIMIT ?
+		)
+	`, run.TaskID, run.TaskID, maxExecTaskRuns)
+	return err
+}
+
+// ListRuns retrieves the run history for a task, newest first
+func (r *ExecTaskRepo) ListRuns(taskID string, limit int) ([]models.ExecTaskRun, error) {
+	if limit <= 0 || limit > maxExecTaskRuns {
+		limit = maxExecTaskRuns
+	}
+	rows, err := r.db.Query(`
+		SELECT id, task_id, trigger_type, status, COALESCE(exit_code, 0), COALESCE(output, ''),
+			started_at, finished_at, COALESCE(duration_ms, 0)
+		FROM exec_task_runs WHERE task_id = ? ORDER BY started_at DESC LIMIT ?
+	`, taskID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.ExecTaskRun
+	for rows.Next() {
+		var run models.ExecTaskRun
+		var finishedAt sql.NullTime
+		if err := rows.Scan(
+			&run.ID, &run.TaskID, &run.Trigger, &run.Status, &run.ExitCode, &run.Output,
+			&run.StartedAt, &finishedAt, &run.DurationMs,
+		); err != nil {
+			return nil, err
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *ExecTaskRepo) list(query string, args ...interface{}) ([]models.ExecTask, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.ExecTask
+	for rows.Next() {
+		t, err := scanExecTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *t)
+	}
+	return tasks, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExecTask(row rowScanner) (*models.ExecTask, error) {
+	t := &models.ExecTask{}
+	var enabled, notify int
+	var lastRunAt sql.NullTime
+	var lastStatus sql.NullString
+	var lastExitCode, createdBy sql.NullInt64
+	if err := row.Scan(
+		&t.ID, &t.ContainerID, &t.Name, &t.Command, &t.Schedule, &enabled, &t.TimeoutSeconds, &notify,
+		&lastRunAt, &lastStatus, &lastExitCode, &t.CreatedAt, &t.UpdatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	t.Enabled = enabled == 1
+	t.NotifyOnFailure = notify == 1
+	if lastRunAt.Valid {
+		t.LastRunAt = &lastRunAt.Time
+	}
+	t.LastStatus = models.ExecTaskStatus(lastStatus.String)
+	if lastExitCode.Valid {
+		code := int(lastExitCode.Int64)
+		t.LastExitCode = &code
+	}
+	if createdBy.Valid {
+		t.CreatedBy = &createdBy.Int64
+	}
+	return t, nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// GameServerRepo handles game server profile database operations
+type GameServerRepo struct {
+	db *sql.DB
+}
+
+// NewGameServerRepo creates a new game server profile repository
+func NewGameServerRepo() *GameServerRepo {
+	return &GameServerRepo{db: DB}
+}
+
+const gameServerQuery = `
+	SELECT g.container_id, c.name, g.preset, g.enabled, g.rcon_port, g.rcon_password, g.rcon_password_env,
+		g.probe, g.query_port, g.player_command, g.player_pattern, g.restart_schedule, g.timezone,
+		g.restart_warnings, g.warning_command, g.save_command, g.idle_shutdown_minutes,
+		g.players, g.max_players, g.last_probe_at, g.probe_error, g.idle_since,
+		g.last_action, g.last_action_at, g.last_error, g.created_at, g.updated_at, g.created_by
+	FROM game_server_profiles g JOIN containers c ON c.id = g.container_id`
+
+// scanGameServer scans a game server profile row
+func scanGameServer(row rowScanner) (*models.GameServerProfile, error) {
+	p := &models.GameServerProfile{}
+	var enabled int
+	var warnings string
+	var players, createdBy sql.NullInt64
+	var lastProbeAt, idleSince, lastActionAt sql.NullTime
+	if err := row.Scan(
+		&p.ContainerID, &p.ContainerName, &p.Preset, &enabled, &p.RCONPort, &p.RCONPassword, &p.RCONPasswordEnv,
+		&p.Probe, &p.QueryPort, &p.PlayerCommand, &p.PlayerPattern, &p.RestartSchedule, &p.Timezone,
+		&warnings, &p.WarningCommand, &p.SaveCommand, &p.IdleShutdownMinutes,
+		&players, &p.MaxPlayers, &lastProbeAt, &p.ProbeError, &idleSince,
+		&p.LastAction, &lastActionAt, &p.LastError, &p.CreatedAt, &p.UpdatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	p.Enabled = enabled == 1
+	p.RCONPasswordSet = p.RCONPassword != ""
+	if err := json.Unmarshal([]byte(warnings), &p.RestartWarnings); err != nil || p.RestartWarnings == nil {
+		p.RestartWarnings = []int{}
+	}
+	if players.Valid {
+		n := int(players.Int64)
+		p.Players = &n
+	}
+	if lastProbeAt.Valid {
+		p.LastProbeAt = &lastProbeAt.Time
+	}
+	if idleSince.Valid {
+		p.IdleSince = &idleSince.Time
+	}
+	if lastActionAt.Valid {
+		p.LastActionAt = &lastActionAt.Time
+	}
+	if createdBy.Valid {
+		p.CreatedBy = &createdBy.Int64
+	}
+	return p, nil
+}
+
+// Get retrieves the profile for a Stardeck container ID
+func (r *GameServerRepo) Get(containerID string) (*models.GameServerProfile, error) {
+	return scanGameServer(r.db.QueryRow(gameServerQuery+" WHERE g.container_id = ?", containerID))
+}
+
+// List returns all profiles ordered by container name
+func (r *GameServerRepo) List() ([]models.GameServerProfile, error) {
+	return r.list(gameServerQuery + " ORDER BY c.name")
+}
+
+// ListEnabled returns the profiles the game server monitor should evaluate
+func (r *GameServerRepo) ListEnabled() ([]models.GameServerProfile, error) {
+	return r.list(gameServerQuery + " WHERE g.enabled = 1")
+}
+
+func (r *GameServerRepo) list(query string) ([]models.GameServerProfile, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []models.GameServerProfile
+	for rows.Next() {
+		p, err := scanGameServer(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+// Upsert creates or replaces a container's profile definition, keeping its probe and action state
+func (r *GameServerRepo) Upsert(p *models.GameServerProfile) error {
+	warnings, err := json.Marshal(p.RestartWarnings)
+	if err != nil {
+		return err
+	}
+	p.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+		INSERT INTO game_server_profiles (
+			container_id, preset, enabled, rcon_port, rcon_password, rcon_password_env, probe, query_port,
+			player_command, player_pattern, restart_schedule, timezone, restart_warnings, warning_command,
+			save_command, idle_shutdown_minutes, created_at, updated_at, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(container_id) DO UPDATE SET
+			preset = excluded.preset, enabled = excluded.enabled, rcon_port = excluded.rcon_port,
+			rcon_password = excluded.rcon_password, rcon_password_env = excluded.rcon_password_env,
+			probe = excluded.probe, query_port = excluded.query_port, player_command = excluded.player_command,
+			player_pattern = excluded.player_pattern, restart_schedule = excluded.restart_schedule,
+			timezone = excluded.timezone, restart_warnings = excluded.restart_warnings,
+			warning_command = excluded.warning_command, save_command = excluded.save_command,
+			idle_shutdown_minutes = excluded.idle_shutdown_minutes, updated_at = excluded.updated_at
+	`, p.ContainerID, p.Preset, p.Enabled, p.RCONPort, p.RCONPassword, p.RCONPasswordEnv, p.Probe, p.QueryPort,
+		p.PlayerCommand, p.PlayerPattern, p.RestartSchedule, p.Timezone, string(warnings), p.WarningCommand,
+		p.SaveCommand, p.IdleShutdownMinutes, p.UpdatedAt, p.UpdatedAt, p.CreatedBy)
+	return err
+}
+
+// RecordProbe stores the outcome of a player-count probe. A nil count marks a
+// failed probe and keeps the last known count.
+func (r *GameServerRepo) RecordProbe(containerID string, players *models.GameServerPlayers, errMsg string, idleSince *time.Time) error {
+	if players == nil {
+		_, err := r.db.Exec(`
+			UPDATE game_server_profiles SET last_probe_at = ?, probe_error = ?, idle_since = ? WHERE container_id = ?
+		`, time.Now(), errMsg, idleSince, containerID)
+		return err
+	}
+	_, err := r.db.Exec(`
+		UPDATE game_server_profiles SET players = ?, max_players = ?, last_probe_at = ?, probe_error = '', idle_since = ?
+		WHERE container_id = ?
+	`, players.Players, players.MaxPlayers, time.Now(), idleSince, containerID)
+	return err
+}
+
+// ClearPlayers forgets the player count and idle time of a stopped server
+func (r *GameServerRepo) ClearPlayers(containerID string) error {
+	_, err := r.db.Exec(`
+		UPDATE game_server_profiles SET players = NULL, idle_since = NULL WHERE container_id = ?
+	`, containerID)
+	return err
+}
+
+// RecordAction stores the outcome of a warning, scheduled restart or idle shutdown
+func (r *GameServerRepo) RecordAction(containerID, action, errMsg string) error {
+	_, err := r.db.Exec(`
+		UPDATE game_server_profiles SET last_action = ?, last_action_at = ?, last_error = ? WHERE container_id = ?
+	`, action, time.Now(), errMsg, containerID)
+	return err
+}
+
+// Delete removes a container's profile
+func (r *GameServerRepo) Delete(containerID string) error {
+	_, err := r.db.Exec("DELETE FROM game_server_profiles WHERE container_id = ?", containerID)
+	return err
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// HostMetricsRepo handles host metrics database operations
+type HostMetricsRepo struct {
+	db DBTX
+}
+
+// NewHostMetricsRepo creates a new host metrics repository
+func NewHostMetricsRepo() *HostMetricsRepo {
+	return &HostMetricsRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *HostMetricsRepo) InTx(tx *sql.Tx) *HostMetricsRepo {
+	return &HostMetricsRepo{db: tx}
+}
+
+const hostMetricsColumns = `id, timestamp, cpu_percent, iowait_percent, memory_total, memory_used,
+	swap_total, swap_used, load_1, load_5, load_15,
+	disk_read_rate, disk_write_rate, disk_busy_percent, temperature_max`
+
+func scanHostMetrics(rows *sql.Rows) (models.HostMetrics, error) {
+	var m models.HostMetrics
+	var temperature sql.NullFloat64
+	err := rows.Scan(
+		&m.ID, &m.Timestamp, &m.CPUPercent, &m.IOWaitPercent, &m.MemoryTotal, &m.MemoryUsed,
+		&m.SwapTotal, &m.SwapUsed, &m.Load1, &m.Load5, &m.Load15,
+		&m.DiskReadRate, &m.DiskWriteRate, &m.DiskBusyPercent, &temperature,
+	)
+	m.MemoryAvailable = m.MemoryTotal - m.MemoryUsed
+	if temperature.Valid {
+		m.TemperatureMax = &temperature.Float64
+	}
+	return m, err
+}
+
+// Save stores a host metrics sample, stamped now unless a timestamp is set
+func (r *HostMetricsRepo) Save(m *models.HostMetrics) error {
+	if m.Timestamp.IsZero() {
+		m.Timestamp = time.Now()
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO host_metrics (
+			timestamp, cpu_percent, iowait_percent, memory_total, memory_used,
+			swap_total, swap_used, load_1, load_5, load_15,
+			disk_read_rate, disk_write_rate, disk_busy_percent, temperature_max
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		m.Timestamp, m.CPUPercent, m.IOWaitPercent, m.MemoryTotal, m.MemoryUsed,
+		m.SwapTotal, m.SwapUsed, m.Load1, m.Load5, m.Load15,
+		m.DiskReadRate, m.DiskWriteRate, m.DiskBusyPercent, m.TemperatureMax,
+	)
+	if err != nil {
+		return err
+	}
+	m.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetRecent retrieves the host metrics of the last hours
+func (r *HostMetricsRepo) GetRecent(hours int) ([]models.HostMetrics, error) {
+	rows, err := r.db.Query(`
+		SELECT `+hostMetricsColumns+`
+		FROM host_metrics
+		WHERE timestamp > datetime('now', '-' || ? || ' hours')
+		ORDER BY timestamp ASC
+	`, hours)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []models.HostMetrics{}
+	for rows.Next() {
+		m, err := scanHostMetrics(rows)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// Cleanup removes old samples (older than specified hours) and returns how many were deleted
+func (r *HostMetricsRepo) Cleanup(retentionHours int) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM host_metrics
+		WHERE timestamp < datetime('now', '-' || ? || ' hours')
+	`, retentionHours)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Downsample replaces the samples taken before cutoff with one averaged
+// sample per hour and returns how many rows it removed, like
+// ContainerMetricsRepo.Downsample. Peaks within an hour are lost.
+func (r *HostMetricsRepo) Downsample(cutoff time.Time) (int64, error) {
+	cutoff = cutoff.Truncate(time.Hour)
+
+	rows, err := r.db.Query(`
+		SELECT `+hostMetricsColumns+`
+		FROM host_metrics
+		WHERE timestamp < ?
+		ORDER BY timestamp ASC
+	`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	hours := make(map[time.Time][]models.HostMetrics)
+	var order []time.Time
+	for rows.Next() {
+		m, err := scanHostMetrics(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		hour := m.Timestamp.Truncate(time.Hour)
+		if _, ok := hours[hour]; !ok {
+			order = append(order, hour)
+		}
+		hours[hour] = append(hours[hour], m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	for _, hour := range order {
+		samples := hours[hour]
+		if len(samples) < 2 {
+			continue
+		}
+
+		avg := models.HostMetrics{Timestamp: hour, MemoryTotal: samples[len(samples)-1].MemoryTotal, SwapTotal: samples[len(samples)-1].SwapTotal}
+		var temperature float64
+		temperatures := 0
+		for _, m := range samples {
+			avg.CPUPercent += m.CPUPercent
+			avg.IOWaitPercent += m.IOWaitPercent
+			avg.MemoryUsed += m.MemoryUsed
+			avg.SwapUsed += m.SwapUsed
+			avg.Load1 += m.Load1
+			avg.Load5 += m.Load5
+			avg.Load15 += m.Load15
+			avg.DiskReadRate += m.DiskReadRate
+			avg.DiskWriteRate += m.DiskWriteRate
+			avg.DiskBusyPercent += m.DiskBusyPercent
+			if m.TemperatureMax != nil {
+				temperature += *m.TemperatureMax
+				temperatures++
+			}
+		}
+		n := len(samples)
+		avg.CPUPercent /= float64(n)
+		avg.IOWaitPercent /= float64(n)
+		avg.MemoryUsed /= int64(n)
+		avg.SwapUsed /= int64(n)
+		avg.Load1 /= float64(n)
+		avg.Load5 /= float64(n)
+		avg.Load15 /= float64(n)
+		avg.DiskReadRate /= int64(n)
+		avg.DiskWriteRate /= int64(n)
+		avg.DiskBusyPercent /= float64(n)
+		if temperatures > 0 {
+			temperature /= float64(temperatures)
+			avg.TemperatureMax = &temperature
+		}
+
+		for _, m := range samples {
+			if _, err := r.db.Exec("DELETE FROM host_metrics WHERE id = ?", m.ID); err != nil {
+				return removed, err
+			}
+		}
+		if err := r.Save(&avg); err != nil {
+			return removed, err
+		}
+		removed += int64(n - 1)
+	}
+	return removed, nil
+}
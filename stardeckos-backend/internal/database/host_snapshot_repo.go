@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// HostSnapshotRepo handles the record of filesystem snapshots taken
+type HostSnapshotRepo struct {
+	db *sql.DB
+}
+
+// NewHostSnapshotRepo creates a new host snapshot repository
+func NewHostSnapshotRepo() *HostSnapshotRepo {
+	return &HostSnapshotRepo{db: DB}
+}
+
+const hostSnapshotColumns = `id, backend, path, mount_point, device, source, name, reason, description,
+	status, reboot_required, created_at, created_by, rolled_back_at`
+
+// Create records a snapshot
+func (r *HostSnapshotRepo) Create(s *models.HostSnapshot) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	if s.Status == "" {
+		s.Status = models.SnapshotStatusReady
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO host_snapshots (`+hostSnapshotColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		s.ID, s.Backend, s.Path, s.MountPoint, s.Device, s.Source, s.Name, s.Reason, s.Description,
+		s.Status, s.RebootRequired, s.CreatedAt, s.CreatedBy, s.RolledBackAt,
+	)
+	return err
+}
+
+// GetByID retrieves a snapshot by ID
+func (r *HostSnapshotRepo) GetByID(id string) (*models.HostSnapshot, error) {
+	snapshots, err := r.query("SELECT "+hostSnapshotColumns+" FROM host_snapshots WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &snapshots[0], nil
+}
+
+// List returns every snapshot, newest first
+func (r *HostSnapshotRepo) List() ([]models.HostSnapshot, error) {
+	return r.query("SELECT " + hostSnapshotColumns + " FROM host_snapshots ORDER BY created_at DESC")
+}
+
+// ListAutomatic returns the automatic snapshots of one filesystem that haven't
+// been rolled back to, newest first
+func (r *HostSnapshotRepo) ListAutomatic(backend, source string) ([]models.HostSnapshot, error) {
+	return r.query(`
+		SELECT `+hostSnapshotColumns+` FROM host_snapshots
+		WHERE backend = ? AND source = ? AND reason != ? AND status = ?
+		ORDER BY created_at DESC
+	`, backend, source, models.SnapshotReasonManual, models.SnapshotStatusReady)
+}
+
+// MarkRolledBack records that a filesystem was returned to a snapshot
+func (r *HostSnapshotRepo) MarkRolledBack(id string, rebootRequired bool) error {
+	_, err := r.db.Exec(`
+		UPDATE host_snapshots SET status = ?, reboot_required = ?, rolled_back_at = ?
+		WHERE id = ?
+	`, models.SnapshotStatusRolledBack, rebootRequired, time.Now(), id)
+	return err
+}
+
+// Delete removes a snapshot's record
+func (r *HostSnapshotRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM host_snapshots WHERE id = ?", id)
+	return err
+}
+
+// DeleteNewer removes the records of a filesystem's snapshots taken after a
+// time, which a ZFS rollback destroys
+func (r *HostSnapshotRepo) DeleteNewer(backend, source string, after time.Time) error {
+	_, err := r.db.Exec(`
+		DELETE FROM host_snapshots WHERE backend = ? AND source = ? AND created_at > ?
+	`, backend, source, after)
+	return err
+}
+
+func (r *HostSnapshotRepo) query(query string, args ...interface{}) ([]models.HostSnapshot, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []models.HostSnapshot{}
+	for rows.Next() {
+		var s models.HostSnapshot
+		var rebootRequired int
+		var createdBy sql.NullInt64
+		var rolledBackAt sql.NullTime
+		if err := rows.Scan(
+			&s.ID, &s.Backend, &s.Path, &s.MountPoint, &s.Device, &s.Source, &s.Name, &s.Reason, &s.Description,
+			&s.Status, &rebootRequired, &s.CreatedAt, &createdBy, &rolledBackAt,
+		); err != nil {
+			return nil, err
+		}
+		s.RebootRequired = rebootRequired == 1
+		if createdBy.Valid {
+			s.CreatedBy = &createdBy.Int64
+		}
+		if rolledBackAt.Valid {
+			s.RolledBackAt = &rolledBackAt.Time
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
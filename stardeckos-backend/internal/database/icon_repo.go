@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// IconRepo handles icon database operations
+type IconRepo struct {
+	db *sql.DB
+}
+
+// NewIconRepo creates a new icon repository
+func NewIconRepo() *IconRepo {
+	return &IconRepo{db: DB}
+}
+
+// Create adds a new icon
+func (r *IconRepo) Create(i *models.Icon) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	i.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO icons (id, name, slug, source, content_type, file_name, size_bytes, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		i.ID, i.Name, i.Slug, i.Source, i.ContentType, i.FileName, i.SizeBytes, i.CreatedAt, i.CreatedBy,
+	)
+	return err
+}
+
+// GetByID retrieves an icon by ID
+func (r *IconRepo) GetByID(id string) (*models.Icon, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT id, name, slug, source, content_type, file_name, size_bytes, created_at, created_by
+		FROM icons WHERE id = ?
+	`, id))
+}
+
+// GetBySlug retrieves a library icon by slug and content type
+func (r *IconRepo) GetBySlug(slug, contentType string) (*models.Icon, error) {
+	return r.scanOne(r.db.QueryRow(`
+		SELECT id, name, slug, source, content_type, file_name, size_bytes, created_at, created_by
+		FROM icons WHERE source = ? AND slug = ? AND content_type = ?
+	`, models.IconSourceLibrary, slug, contentType))
+}
+
+// List retrieves all icons, optionally filtered by source
+func (r *IconRepo) List(source string) ([]models.Icon, error) {
+	query := `
+		SELECT id, name, slug, source, content_type, file_name, size_bytes, created_at, created_by
+		FROM icons`
+	args := []interface{}{}
+	if source != "" {
+		query += " WHERE source = ?"
+		args = append(args, source)
+	}
+	query += " ORDER BY name"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var icons []models.Icon
+	for rows.Next() {
+		var i models.Icon
+		var slug sql.NullString
+		var createdBy sql.NullInt64
+		if err := rows.Scan(
+			&i.ID, &i.Name, &slug, &i.Source, &i.ContentType, &i.FileName, &i.SizeBytes, &i.CreatedAt, &createdBy,
+		); err != nil {
+			return nil, err
+		}
+		i.Slug = slug.String
+		if createdBy.Valid {
+			i.CreatedBy = &createdBy.Int64
+		}
+		icons = append(icons, i)
+	}
+	return icons, rows.Err()
+}
+
+// Delete removes an icon record
+func (r *IconRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM icons WHERE id = ?", id)
+	return err
+}
+
+// CountReferences returns how many containers, templates, and stacks reference an icon URL
+func (r *IconRepo) CountReferences(url string) (int, error) {
+	pattern := "%" + url + "%"
+	var count int
+	err := r.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM containers WHERE icon LIKE ? OR icon_light LIKE ? OR icon_dark LIKE ?) +
+			(SELECT COUNT(*) FROM templates WHERE icon LIKE ?) +
+			(SELECT COUNT(*) FROM stacks WHERE icon LIKE ?)
+	`, pattern, pattern, pattern, pattern, pattern).Scan(&count)
+	return count, err
+}
+
+func (r *IconRepo) scanOne(row *sql.Row) (*models.Icon, error) {
+	i := &models.Icon{}
+	var slug sql.NullString
+	var createdBy sql.NullInt64
+	err := row.Scan(
+		&i.ID, &i.Name, &slug, &i.Source, &i.ContentType, &i.FileName, &i.SizeBytes, &i.CreatedAt, &createdBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	i.Slug = slug.String
+	if createdBy.Valid {
+		i.CreatedBy = &createdBy.Int64
+	}
+	return i, nil
+}
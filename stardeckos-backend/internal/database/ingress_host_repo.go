@@ -0,0 +1,56 @@
+package database
+
+import (
+	"database/sql"
+
+	"stardeckos-backend/internal/models"
+)
+
+// IngressHostRepo handles stored changes to generated ingress hosts
+type IngressHostRepo struct {
+	db *sql.DB
+}
+
+// NewIngressHostRepo creates a new ingress host repository
+func NewIngressHostRepo() *IngressHostRepo {
+	return &IngressHostRepo{db: DB}
+}
+
+// List returns every override, keyed by container ID
+func (r *IngressHostRepo) List() (map[string]models.IngressHostOverride, error) {
+	rows, err := r.db.Query("SELECT container_id, label, enabled, public FROM ingress_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]models.IngressHostOverride)
+	for rows.Next() {
+		var o models.IngressHostOverride
+		var enabled, public int
+		if err := rows.Scan(&o.ContainerID, &o.Label, &enabled, &public); err != nil {
+			return nil, err
+		}
+		o.Enabled = enabled == 1
+		o.Public = public == 1
+		overrides[o.ContainerID] = o
+	}
+	return overrides, rows.Err()
+}
+
+// Upsert creates or replaces a container's override
+func (r *IngressHostRepo) Upsert(o *models.IngressHostOverride) error {
+	_, err := r.db.Exec(`
+		INSERT INTO ingress_hosts (container_id, label, enabled, public)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(container_id) DO UPDATE SET label = excluded.label, enabled = excluded.enabled, public = excluded.public
+	`, o.ContainerID, o.Label, o.Enabled, o.Public)
+	return err
+}
+
+// Delete removes a container's override, going back to the generated host,
+// which is off until it is turned on again
+func (r *IngressHostRepo) Delete(containerID string) error {
+	_, err := r.db.Exec("DELETE FROM ingress_hosts WHERE container_id = ?", containerID)
+	return err
+}
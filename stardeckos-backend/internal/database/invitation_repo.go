@@ -0,0 +1,197 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// InvitationRepo handles user invitation database operations
+type InvitationRepo struct {
+	db *sql.DB
+}
+
+// NewInvitationRepo creates a new invitation repository
+func NewInvitationRepo() *InvitationRepo {
+	return &InvitationRepo{db: DB}
+}
+
+const invitationColumns = `id, email, display_name, role, expires_at, accepted_at, accepted_user_id, created_at, created_by`
+
+// newEmailToken returns a random token for an emailed link and the hash stored for it
+func newEmailToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// scanInvitation scans an invitation row
+func scanInvitation(row rowScanner) (*models.UserInvitation, error) {
+	inv := &models.UserInvitation{}
+	var acceptedAt sql.NullTime
+	var acceptedUserID, createdBy sql.NullInt64
+	if err := row.Scan(&inv.ID, &inv.Email, &inv.DisplayName, &inv.Role, &inv.ExpiresAt,
+		&acceptedAt, &acceptedUserID, &inv.CreatedAt, &createdBy); err != nil {
+		return nil, err
+	}
+	if acceptedAt.Valid {
+		inv.AcceptedAt = &acceptedAt.Time
+	}
+	if acceptedUserID.Valid {
+		inv.AcceptedUserID = &acceptedUserID.Int64
+	}
+	if createdBy.Valid {
+		inv.CreatedBy = &createdBy.Int64
+	}
+	return inv, nil
+}
+
+// Create stores a new invitation and returns the plain token for its link
+func (r *InvitationRepo) Create(inv *models.UserInvitation) (string, error) {
+	token, tokenHash, err := newEmailToken()
+	if err != nil {
+		return "", err
+	}
+	inv.ID = uuid.New().String()
+	inv.CreatedAt = time.Now()
+	_, err = r.db.Exec(`
+		INSERT INTO user_invitations (id, email, display_name, role, token_hash, expires_at, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, inv.ID, inv.Email, inv.DisplayName, inv.Role, tokenHash, inv.ExpiresAt, inv.CreatedAt, inv.CreatedBy)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Renew replaces an invitation's token and expiry, invalidating the old link
+func (r *InvitationRepo) Renew(id string, expiresAt time.Time) (string, error) {
+	token, tokenHash, err := newEmailToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = r.db.Exec("UPDATE user_invitations SET token_hash = ?, expires_at = ? WHERE id = ?", tokenHash, expiresAt, id)
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetByID retrieves an invitation by ID
+func (r *InvitationRepo) GetByID(id string) (*models.UserInvitation, error) {
+	return scanInvitation(r.db.QueryRow("SELECT "+invitationColumns+" FROM user_invitations WHERE id = ?", id))
+}
+
+// GetByToken retrieves an invitation by the plain token from its link
+func (r *InvitationRepo) GetByToken(token string) (*models.UserInvitation, error) {
+	return scanInvitation(r.db.QueryRow("SELECT "+invitationColumns+" FROM user_invitations WHERE token_hash = ?", hashToken(token)))
+}
+
+// List returns all invitations, newest first
+func (r *InvitationRepo) List() ([]models.UserInvitation, error) {
+	rows, err := r.db.Query("SELECT " + invitationColumns + " FROM user_invitations ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []models.UserInvitation
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, rows.Err()
+}
+
+// MarkAccepted records the account created from an invitation. It fails with
+// sql.ErrNoRows when the invitation was already used.
+func (r *InvitationRepo) MarkAccepted(id string, userID int64) error {
+	result, err := r.db.Exec(`
+		UPDATE user_invitations SET accepted_at = ?, accepted_user_id = ? WHERE id = ? AND accepted_at IS NULL
+	`, time.Now(), userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes an invitation
+func (r *InvitationRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM user_invitations WHERE id = ?", id)
+	return err
+}
+
+// PasswordResetRepo handles password reset token database operations
+type PasswordResetRepo struct {
+	db *sql.DB
+}
+
+// NewPasswordResetRepo creates a new password reset repository
+func NewPasswordResetRepo() *PasswordResetRepo {
+	return &PasswordResetRepo{db: DB}
+}
+
+// Create stores a reset token for a user and returns the plain token. Expired
+// tokens are cleared out at the same time.
+func (r *PasswordResetRepo) Create(userID int64, expiresAt time.Time) (string, error) {
+	token, tokenHash, err := newEmailToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.db.Exec("DELETE FROM password_resets WHERE expires_at < ?", time.Now()); err != nil {
+		return "", err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO password_resets (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)
+	`, tokenHash, userID, expiresAt, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetByToken retrieves a reset by its plain token
+func (r *PasswordResetRepo) GetByToken(token string) (*models.PasswordReset, error) {
+	reset := &models.PasswordReset{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT user_id, expires_at, used_at FROM password_resets WHERE token_hash = ?
+	`, hashToken(token)).Scan(&reset.UserID, &reset.ExpiresAt, &usedAt)
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		reset.UsedAt = &usedAt.Time
+	}
+	return reset, nil
+}
+
+// Consume marks a reset token used, failing with sql.ErrNoRows when it
+// already was. The user's other outstanding tokens are discarded.
+func (r *PasswordResetRepo) Consume(token string, userID int64) error {
+	result, err := r.db.Exec(`
+		UPDATE password_resets SET used_at = ? WHERE token_hash = ? AND used_at IS NULL
+	`, time.Now(), hashToken(token))
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = r.db.Exec("DELETE FROM password_resets WHERE user_id = ? AND used_at IS NULL", userID)
+	return err
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// LANDeviceRepo handles the history of devices seen on the LAN
+type LANDeviceRepo struct {
+	db *sql.DB
+}
+
+// NewLANDeviceRepo creates a new LAN device repository
+func NewLANDeviceRepo() *LANDeviceRepo {
+	return &LANDeviceRepo{db: DB}
+}
+
+const lanDeviceColumns = `mac, ip_address, interface, hostname, vendor, name, known, first_seen, last_seen`
+
+// scanLANDevice scans a LAN device row
+func scanLANDevice(row rowScanner) (*models.LANDevice, error) {
+	d := &models.LANDevice{}
+	var known int
+	if err := row.Scan(&d.MAC, &d.IPAddress, &d.Interface, &d.Hostname, &d.Vendor, &d.Name, &known,
+		&d.FirstSeen, &d.LastSeen); err != nil {
+		return nil, err
+	}
+	d.Known = known == 1
+	return d, nil
+}
+
+// Get retrieves a device by MAC address
+func (r *LANDeviceRepo) Get(mac string) (*models.LANDevice, error) {
+	return scanLANDevice(r.db.QueryRow("SELECT "+lanDeviceColumns+" FROM lan_devices WHERE mac = ?", mac))
+}
+
+// List returns all devices, most recently seen first
+func (r *LANDeviceRepo) List() ([]models.LANDevice, error) {
+	rows, err := r.db.Query("SELECT " + lanDeviceColumns + " FROM lan_devices ORDER BY last_seen DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []models.LANDevice{}
+	for rows.Next() {
+		d, err := scanLANDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *d)
+	}
+	return devices, rows.Err()
+}
+
+// Count returns how many devices have been seen
+func (r *LANDeviceRepo) Count() (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM lan_devices").Scan(&count)
+	return count, err
+}
+
+// Create records a device seen for the first time
+func (r *LANDeviceRepo) Create(d *models.LANDevice) error {
+	d.FirstSeen = time.Now()
+	d.LastSeen = d.FirstSeen
+	_, err := r.db.Exec(`
+		INSERT INTO lan_devices (mac, ip_address, interface, hostname, vendor, name, known, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.MAC, d.IPAddress, d.Interface, d.Hostname, d.Vendor, d.Name, d.Known, d.FirstSeen, d.LastSeen)
+	return err
+}
+
+// RecordSighting updates where a known device was last seen. An empty
+// hostname keeps the one recorded earlier.
+func (r *LANDeviceRepo) RecordSighting(mac, ip, iface, hostname string) error {
+	_, err := r.db.Exec(`
+		UPDATE lan_devices SET ip_address = ?, interface = ?, last_seen = ?,
+			hostname = CASE WHEN ? != '' THEN ? ELSE hostname END
+		WHERE mac = ?
+	`, ip, iface, time.Now(), hostname, hostname, mac)
+	return err
+}
+
+// Update saves a device's label and acknowledgement
+func (r *LANDeviceRepo) Update(d *models.LANDevice) error {
+	_, err := r.db.Exec("UPDATE lan_devices SET name = ?, known = ? WHERE mac = ?", d.Name, d.Known, d.MAC)
+	return err
+}
+
+// Delete forgets a device
+func (r *LANDeviceRepo) Delete(mac string) error {
+	_, err := r.db.Exec("DELETE FROM lan_devices WHERE mac = ?", mac)
+	return err
+}
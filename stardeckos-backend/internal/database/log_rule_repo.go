@@ -0,0 +1,133 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// LogRuleRepo handles container log rule database operations
+type LogRuleRepo struct {
+	db *sql.DB
+}
+
+// NewLogRuleRepo creates a new log rule repository
+func NewLogRuleRepo() *LogRuleRepo {
+	return &LogRuleRepo{db: DB}
+}
+
+const logRuleColumns = `
+	id, container_id, name, pattern, stream, action, webhook_url, cooldown_seconds, enabled,
+	last_triggered_at, last_match, trigger_count, created_at, updated_at, created_by`
+
+// Create adds a new log rule
+func (r *LogRuleRepo) Create(rule *models.LogRule) error {
+	if rule.ID == "" {
+		rule.ID = uuid.New().String()
+	}
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO log_rules (
+			id, container_id, name, pattern, stream, action, webhook_url, cooldown_seconds,
+			enabled, created_at, updated_at, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		rule.ID, rule.ContainerID, rule.Name, rule.Pattern, rule.Stream, rule.Action, rule.WebhookURL,
+		rule.CooldownSeconds, rule.Enabled, rule.CreatedAt, rule.UpdatedAt, rule.CreatedBy,
+	)
+	return err
+}
+
+// GetByID retrieves a log rule by ID
+func (r *LogRuleRepo) GetByID(id string) (*models.LogRule, error) {
+	return scanLogRule(r.db.QueryRow("SELECT "+logRuleColumns+" FROM log_rules WHERE id = ?", id))
+}
+
+// ListByContainer retrieves all log rules for a container
+func (r *LogRuleRepo) ListByContainer(containerID string) ([]models.LogRule, error) {
+	return r.list("SELECT "+logRuleColumns+" FROM log_rules WHERE container_id = ? ORDER BY name", containerID)
+}
+
+// ListEnabled retrieves all enabled log rules
+func (r *LogRuleRepo) ListEnabled() ([]models.LogRule, error) {
+	return r.list("SELECT " + logRuleColumns + " FROM log_rules WHERE enabled = 1")
+}
+
+// Update updates a log rule's definition
+func (r *LogRuleRepo) Update(rule *models.LogRule) error {
+	rule.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		UPDATE log_rules SET
+			name = ?, pattern = ?, stream = ?, action = ?, webhook_url = ?,
+			cooldown_seconds = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`,
+		rule.Name, rule.Pattern, rule.Stream, rule.Action, rule.WebhookURL,
+		rule.CooldownSeconds, rule.Enabled, rule.UpdatedAt, rule.ID,
+	)
+	return err
+}
+
+// RecordTrigger stores the time and line of a rule's latest match
+func (r *LogRuleRepo) RecordTrigger(id string, at time.Time, line string) error {
+	_, err := r.db.Exec(`
+		UPDATE log_rules SET last_triggered_at = ?, last_match = ?, trigger_count = trigger_count + 1
+		WHERE id = ?
+	`, at, line, id)
+	return err
+}
+
+// Delete removes a log rule
+func (r *LogRuleRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM log_rules WHERE id = ?", id)
+	return err
+}
+
+func (r *LogRuleRepo) list(query string, args ...interface{}) ([]models.LogRule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.LogRule
+	for rows.Next() {
+		rule, err := scanLogRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanLogRule(row rowScanner) (*models.LogRule, error) {
+	rule := &models.LogRule{}
+	var enabled int
+	var stream, webhookURL, lastMatch sql.NullString
+	var lastTriggeredAt sql.NullTime
+	var createdBy sql.NullInt64
+	if err := row.Scan(
+		&rule.ID, &rule.ContainerID, &rule.Name, &rule.Pattern, &stream, &rule.Action, &webhookURL,
+		&rule.CooldownSeconds, &enabled, &lastTriggeredAt, &lastMatch, &rule.TriggerCount,
+		&rule.CreatedAt, &rule.UpdatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	rule.Enabled = enabled == 1
+	rule.Stream = stream.String
+	rule.WebhookURL = webhookURL.String
+	rule.LastMatch = lastMatch.String
+	if lastTriggeredAt.Valid {
+		rule.LastTriggeredAt = &lastTriggeredAt.Time
+	}
+	if createdBy.Valid {
+		rule.CreatedBy = &createdBy.Int64
+	}
+	return rule, nil
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// FileSize returns the size of the database file and its write-ahead log
+func FileSize() (int64, int64) {
+	var size, walSize int64
+	if info, err := os.Stat(dbPath); err == nil {
+		size = info.Size()
+	}
+	if info, err := os.Stat(dbPath + "-wal"); err == nil {
+		walSize = info.Size()
+	}
+	return size, walSize
+}
+
+// Stats reports database size, page usage, and per-table row counts and sizes
+func Stats() (*models.DatabaseStats, error) {
+	stats := &models.DatabaseStats{Path: dbPath}
+	stats.SizeBytes, stats.WALSizeBytes = FileSize()
+
+	DB.QueryRow("PRAGMA page_size").Scan(&stats.PageSize)
+	DB.QueryRow("PRAGMA page_count").Scan(&stats.PageCount)
+	DB.QueryRow("PRAGMA freelist_count").Scan(&stats.FreelistCount)
+	stats.Connections = ConnectionStats()
+
+	rows, err := DB.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	sizes := objectSizes()
+
+	for _, name := range tables {
+		t := models.TableStats{Name: name, SizeBytes: sizes[name]}
+		DB.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, name)).Scan(&t.Rows)
+		stats.Tables = append(stats.Tables, t)
+	}
+
+	return stats, nil
+}
+
+// objectSizes returns bytes used per table (including its indexes) via the dbstat virtual table
+// An empty map is returned when SQLite was built without dbstat support
+func objectSizes() map[string]int64 {
+	sizes := make(map[string]int64)
+	rows, err := DB.Query(`
+		SELECT COALESCE(m.tbl_name, s.name), SUM(s.pgsize)
+		FROM dbstat s LEFT JOIN sqlite_master m ON m.name = s.name
+		GROUP BY 1
+	`)
+	if err != nil {
+		return sizes
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var size int64
+		if rows.Scan(&name, &size) == nil {
+			sizes[name] = size
+		}
+	}
+	return sizes
+}
+
+// IntegrityCheck runs PRAGMA integrity_check (or quick_check) and returns any reported problems
+func IntegrityCheck(quick bool) (*models.IntegrityResult, error) {
+	pragma := "PRAGMA integrity_check"
+	if quick {
+		pragma = "PRAGMA quick_check"
+	}
+
+	start := time.Now()
+	rows, err := DB.Query(pragma)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &models.IntegrityResult{Quick: quick}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			result.Problems = append(result.Problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result.OK = len(result.Problems) == 0
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// Vacuum rebuilds the database file to reclaim free pages and truncates the WAL
+func Vacuum() error {
+	if _, err := DB.Exec("VACUUM"); err != nil {
+		return err
+	}
+	_, err := DB.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
+// Optimize refreshes query planner statistics
+func Optimize() error {
+	_, err := DB.Exec("PRAGMA optimize")
+	return err
+}
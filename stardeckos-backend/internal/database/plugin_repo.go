@@ -0,0 +1,193 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// PluginRepo handles installed plugins and their scheduled jobs
+type PluginRepo struct {
+	db *sql.DB
+}
+
+// NewPluginRepo creates a new plugin repository
+func NewPluginRepo() *PluginRepo {
+	return &PluginRepo{db: DB}
+}
+
+const pluginColumns = "name, version, description, author, command, enabled, installed_at, updated_at, installed_by"
+
+// List returns every installed plugin by name
+func (r *PluginRepo) List() ([]models.Plugin, error) {
+	rows, err := r.db.Query("SELECT " + pluginColumns + " FROM plugins ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plugins []models.Plugin
+	for rows.Next() {
+		p, err := scanPlugin(rows)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, *p)
+	}
+	return plugins, rows.Err()
+}
+
+// Get returns an installed plugin
+func (r *PluginRepo) Get(name string) (*models.Plugin, error) {
+	return scanPlugin(r.db.QueryRow("SELECT "+pluginColumns+" FROM plugins WHERE name = ?", name))
+}
+
+// Save records a newly installed plugin, or a new version of one, keeping
+// whether it was enabled
+func (r *PluginRepo) Save(p *models.Plugin) error {
+	command, err := json.Marshal(p.Command)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	p.UpdatedAt = now
+	if p.InstalledAt.IsZero() {
+		p.InstalledAt = now
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO plugins (name, version, description, author, command, enabled, installed_at, updated_at, installed_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			version = excluded.version, description = excluded.description, author = excluded.author,
+			command = excluded.command, updated_at = excluded.updated_at
+	`, p.Name, p.Version, p.Description, p.Author, string(command), p.Enabled, p.InstalledAt, p.UpdatedAt, p.InstalledBy)
+	return err
+}
+
+// SetEnabled turns a plugin on or off
+func (r *PluginRepo) SetEnabled(name string, enabled bool) error {
+	_, err := r.db.Exec("UPDATE plugins SET enabled = ? WHERE name = ?", enabled, name)
+	return err
+}
+
+// Delete removes a plugin and its jobs
+func (r *PluginRepo) Delete(name string) error {
+	_, err := r.db.Exec("DELETE FROM plugins WHERE name = ?", name)
+	return err
+}
+
+func scanPlugin(row rowScanner) (*models.Plugin, error) {
+	p := &models.Plugin{}
+	var command string
+	var enabled int
+	var installedBy sql.NullInt64
+	if err := row.Scan(&p.Name, &p.Version, &p.Description, &p.Author, &command, &enabled,
+		&p.InstalledAt, &p.UpdatedAt, &installedBy); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(command), &p.Command)
+	p.Enabled = enabled == 1
+	if installedBy.Valid {
+		p.InstalledBy = &installedBy.Int64
+	}
+	return p, nil
+}
+
+const pluginJobColumns = `
+	id, plugin, type, name, schedule, params, enabled, last_run_at, last_status, last_output, created_at, created_by`
+
+// ListJobs returns every plugin job, optionally only the enabled ones
+func (r *PluginRepo) ListJobs(enabledOnly bool) ([]models.PluginJob, error) {
+	query := "SELECT " + pluginJobColumns + " FROM plugin_jobs"
+	if enabledOnly {
+		query += " WHERE enabled = 1"
+	}
+	rows, err := r.db.Query(query + " ORDER BY plugin, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.PluginJob
+	for rows.Next() {
+		j, err := scanPluginJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+// GetJob returns a plugin job
+func (r *PluginRepo) GetJob(id int64) (*models.PluginJob, error) {
+	return scanPluginJob(r.db.QueryRow("SELECT "+pluginJobColumns+" FROM plugin_jobs WHERE id = ?", id))
+}
+
+// CreateJob adds a plugin job
+func (r *PluginRepo) CreateJob(j *models.PluginJob) error {
+	params, err := json.Marshal(j.Params)
+	if err != nil {
+		return err
+	}
+	j.CreatedAt = time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO plugin_jobs (plugin, type, name, schedule, params, enabled, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, j.Plugin, j.Type, j.Name, j.Schedule, string(params), j.Enabled, j.CreatedAt, j.CreatedBy)
+	if err != nil {
+		return err
+	}
+	j.ID, err = result.LastInsertId()
+	return err
+}
+
+// UpdateJob replaces a plugin job's definition
+func (r *PluginRepo) UpdateJob(j *models.PluginJob) error {
+	params, err := json.Marshal(j.Params)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		UPDATE plugin_jobs SET plugin = ?, type = ?, name = ?, schedule = ?, params = ?, enabled = ?
+		WHERE id = ?
+	`, j.Plugin, j.Type, j.Name, j.Schedule, string(params), j.Enabled, j.ID)
+	return err
+}
+
+// RecordJobRun stores the result of a job's latest run
+func (r *PluginRepo) RecordJobRun(id int64, at time.Time, status, output string) error {
+	_, err := r.db.Exec(`
+		UPDATE plugin_jobs SET last_run_at = ?, last_status = ?, last_output = ? WHERE id = ?
+	`, at, status, output, id)
+	return err
+}
+
+// DeleteJob removes a plugin job
+func (r *PluginRepo) DeleteJob(id int64) error {
+	_, err := r.db.Exec("DELETE FROM plugin_jobs WHERE id = ?", id)
+	return err
+}
+
+func scanPluginJob(row rowScanner) (*models.PluginJob, error) {
+	j := &models.PluginJob{}
+	var params string
+	var enabled int
+	var lastRunAt sql.NullTime
+	var createdBy sql.NullInt64
+	if err := row.Scan(&j.ID, &j.Plugin, &j.Type, &j.Name, &j.Schedule, &params, &enabled,
+		&lastRunAt, &j.LastStatus, &j.LastOutput, &j.CreatedAt, &createdBy); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(params), &j.Params)
+	j.Enabled = enabled == 1
+	if lastRunAt.Valid {
+		j.LastRunAt = &lastRunAt.Time
+	}
+	if createdBy.Valid {
+		j.CreatedBy = &createdBy.Int64
+	}
+	return j, nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// PortExposureRepo handles temporary port exposure storage
+type PortExposureRepo struct {
+	db *sql.DB
+}
+
+// NewPortExposureRepo creates a new port exposure repository
+func NewPortExposureRepo() *PortExposureRepo {
+	return &PortExposureRepo{db: DB}
+}
+
+// Create stores a new exposure
+func (r *PortExposureRepo) Create(e *models.PortExposure) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO port_exposures (
+			id, container_id, container_name, container_port, host_port, protocol,
+			target_ip, zone, expires_at, created_at, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		e.ID, e.ContainerID, e.ContainerName, e.ContainerPort, e.HostPort, e.Protocol,
+		e.TargetIP, e.Zone, e.ExpiresAt, e.CreatedAt, e.CreatedBy,
+	)
+	return err
+}
+
+// GetByID retrieves an exposure by ID
+func (r *PortExposureRepo) GetByID(id string) (*models.PortExposure, error) {
+	e := &models.PortExposure{}
+	var revokedAt sql.NullTime
+	var createdBy sql.NullInt64
+	err := r.db.QueryRow(`
+		SELECT id, container_id, container_name, container_port, host_port, protocol,
+			target_ip, zone, expires_at, created_at, created_by, revoked_at
+		FROM port_exposures WHERE id = ?
+	`, id).Scan(
+		&e.ID, &e.ContainerID, &e.ContainerName, &e.ContainerPort, &e.HostPort, &e.Protocol,
+		&e.TargetIP, &e.Zone, &e.ExpiresAt, &e.CreatedAt, &createdBy, &revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		e.CreatedBy = &createdBy.Int64
+	}
+	if revokedAt.Valid {
+		e.RevokedAt = &revokedAt.Time
+	}
+	return e, nil
+}
+
+// ListActive returns exposures that have not been revoked, optionally for one container
+func (r *PortExposureRepo) ListActive(containerID string) ([]models.PortExposure, error) {
+	query := `
+		SELECT id, container_id, container_name, container_port, host_port, protocol,
+			target_ip, zone, expires_at, created_at, created_by
+		FROM port_exposures WHERE revoked_at IS NULL`
+	args := []interface{}{}
+	if containerID != "" {
+		query += " AND container_id = ?"
+		args = append(args, containerID)
+	}
+	query += " ORDER BY expires_at"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exposures []models.PortExposure
+	for rows.Next() {
+		var e models.PortExposure
+		var createdBy sql.NullInt64
+		if err := rows.Scan(
+			&e.ID, &e.ContainerID, &e.ContainerName, &e.ContainerPort, &e.HostPort, &e.Protocol,
+			&e.TargetIP, &e.Zone, &e.ExpiresAt, &e.CreatedAt, &createdBy,
+		); err != nil {
+			return nil, err
+		}
+		if createdBy.Valid {
+			e.CreatedBy = &createdBy.Int64
+		}
+		exposures = append(exposures, e)
+	}
+	return exposures, rows.Err()
+}
+
+// IsHostPortActive reports whether a host port is already held by an active exposure
+func (r *PortExposureRepo) IsHostPortActive(hostPort int, protocol string) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM port_exposures
+		WHERE revoked_at IS NULL AND host_port = ? AND protocol = ?
+	`, hostPort, protocol).Scan(&count)
+	return count > 0, err
+}
+
+// MarkRevoked records that an exposure has been closed
+func (r *PortExposureRepo) MarkRevoked(id string) error {
+	_, err := r.db.Exec("UPDATE port_exposures SET revoked_at = ? WHERE id = ?", time.Now(), id)
+	return err
+}
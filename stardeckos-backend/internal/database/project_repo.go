@@ -0,0 +1,214 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ProjectRepo handles project, membership and resource assignment database operations
+type ProjectRepo struct {
+	db DBTX
+}
+
+// NewProjectRepo creates a new project repository
+func NewProjectRepo() *ProjectRepo {
+	return &ProjectRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *ProjectRepo) InTx(tx *sql.Tx) *ProjectRepo {
+	return &ProjectRepo{db: tx}
+}
+
+// scanProject scans a project row
+func scanProject(row rowScanner) (*models.Project, error) {
+	p := &models.Project{}
+	var createdBy sql.NullInt64
+	if err := row.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt, &createdBy); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		p.CreatedBy = &createdBy.Int64
+	}
+	return p, nil
+}
+
+// Create adds a new project
+func (r *ProjectRepo) Create(p *models.Project) error {
+	p.ID = uuid.New().String()
+	p.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO projects (id, name, description, created_at, created_by) VALUES (?, ?, ?, ?, ?)
+	`, p.ID, p.Name, p.Description, p.CreatedAt, p.CreatedBy)
+	return err
+}
+
+// GetByID retrieves a project by ID
+func (r *ProjectRepo) GetByID(id string) (*models.Project, error) {
+	return scanProject(r.db.QueryRow(`
+		SELECT id, name, description, created_at, created_by FROM projects WHERE id = ?
+	`, id))
+}
+
+// List returns all projects ordered by name
+func (r *ProjectRepo) List() ([]models.Project, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, description, created_at, created_by FROM projects ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *p)
+	}
+	return projects, rows.Err()
+}
+
+// UpdateDescription changes a project's description
+func (r *ProjectRepo) UpdateDescription(id, description string) error {
+	_, err := r.db.Exec("UPDATE projects SET description = ? WHERE id = ?", description, id)
+	return err
+}
+
+// Delete removes a project with its memberships and resource assignments
+func (r *ProjectRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM projects WHERE id = ?", id)
+	return err
+}
+
+// SetMember adds a user to a project or changes their role
+func (r *ProjectRepo) SetMember(projectID string, userID int64, role models.Role) error {
+	_, err := r.db.Exec(`
+		INSERT INTO project_members (project_id, user_id, role, added_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(project_id, user_id) DO UPDATE SET role = excluded.role
+	`, projectID, userID, role, time.Now())
+	return err
+}
+
+// RemoveMember removes a user from a project
+func (r *ProjectRepo) RemoveMember(projectID string, userID int64) error {
+	result, err := r.db.Exec("DELETE FROM project_members WHERE project_id = ? AND user_id = ?", projectID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListMembers returns a project's members ordered by username
+func (r *ProjectRepo) ListMembers(projectID string) ([]models.ProjectMember, error) {
+	rows, err := r.db.Query(`
+		SELECT m.project_id, m.user_id, u.username, m.role, m.added_at
+		FROM project_members m JOIN users u ON u.id = m.user_id
+		WHERE m.project_id = ? ORDER BY u.username
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.ProjectMember
+	for rows.Next() {
+		var m models.ProjectMember
+		if err := rows.Scan(&m.ProjectID, &m.UserID, &m.Username, &m.Role, &m.AddedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// MemberRoles returns the user's role in each project they belong to, keyed by project ID
+func (r *ProjectRepo) MemberRoles(userID int64) (map[string]models.Role, error) {
+	rows, err := r.db.Query("SELECT project_id, role FROM project_members WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[string]models.Role)
+	for rows.Next() {
+		var projectID string
+		var role models.Role
+		if err := rows.Scan(&projectID, &role); err != nil {
+			return nil, err
+		}
+		roles[projectID] = role
+	}
+	return roles, rows.Err()
+}
+
+// AddResource assigns a resource to a project, moving it from any other project
+func (r *ProjectRepo) AddResource(res *models.ProjectResource) error {
+	res.AddedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO project_resources (project_id, resource_type, resource_id, name, added_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(resource_type, resource_id) DO UPDATE SET
+			project_id = excluded.project_id, name = excluded.name, added_at = excluded.added_at
+	`, res.ProjectID, res.Type, res.ResourceID, res.Name, res.AddedAt)
+	return err
+}
+
+// RemoveResource unassigns a resource from whichever project holds it
+func (r *ProjectRepo) RemoveResource(resourceType models.ProjectResourceType, resourceID string) error {
+	_, err := r.db.Exec("DELETE FROM project_resources WHERE resource_type = ? AND resource_id = ?", resourceType, resourceID)
+	return err
+}
+
+// ListResources returns a project's resources
+func (r *ProjectRepo) ListResources(projectID string) ([]models.ProjectResource, error) {
+	rows, err := r.db.Query(`
+		SELECT project_id, resource_type, resource_id, name, added_at
+		FROM project_resources WHERE project_id = ? ORDER BY resource_type, name
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []models.ProjectResource
+	for rows.Next() {
+		var res models.ProjectResource
+		if err := rows.Scan(&res.ProjectID, &res.Type, &res.ResourceID, &res.Name, &res.AddedAt); err != nil {
+			return nil, err
+		}
+		resources = append(resources, res)
+	}
+	return resources, rows.Err()
+}
+
+// Assignments returns every resource assignment, keyed by resource type then resource ID
+func (r *ProjectRepo) Assignments() (map[models.ProjectResourceType]map[string]string, error) {
+	rows, err := r.db.Query("SELECT resource_type, resource_id, project_id FROM project_resources")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := make(map[models.ProjectResourceType]map[string]string)
+	for rows.Next() {
+		var resourceType models.ProjectResourceType
+		var resourceID, projectID string
+		if err := rows.Scan(&resourceType, &resourceID, &projectID); err != nil {
+			return nil, err
+		}
+		if assignments[resourceType] == nil {
+			assignments[resourceType] = make(map[string]string)
+		}
+		assignments[resourceType][resourceID] = projectID
+	}
+	return assignments, rows.Err()
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// PublicAppRepo handles public (kiosk) web app database operations
+type PublicAppRepo struct {
+	db *sql.DB
+}
+
+// NewPublicAppRepo creates a new public app repository
+func NewPublicAppRepo() *PublicAppRepo {
+	return &PublicAppRepo{db: DB}
+}
+
+const publicAppQuery = `
+	SELECT p.container_id, p.slug, c.name, COALESCE(c.icon, ''), p.pin_hash, p.listed, p.created_at, p.created_by
+	FROM public_apps p JOIN containers c ON c.id = p.container_id`
+
+// scanPublicApp scans a public app row
+func scanPublicApp(row rowScanner) (*models.PublicApp, error) {
+	app := &models.PublicApp{}
+	var listed int
+	var createdBy sql.NullInt64
+	if err := row.Scan(&app.ContainerID, &app.Slug, &app.Name, &app.Icon, &app.PINHash, &listed,
+		&app.CreatedAt, &createdBy); err != nil {
+		return nil, err
+	}
+	app.PINRequired = app.PINHash != ""
+	app.Listed = listed == 1
+	if createdBy.Valid {
+		app.CreatedBy = &createdBy.Int64
+	}
+	return app, nil
+}
+
+// GetByContainerID retrieves the public app for a Stardeck container ID
+func (r *PublicAppRepo) GetByContainerID(containerID string) (*models.PublicApp, error) {
+	return scanPublicApp(r.db.QueryRow(publicAppQuery+" WHERE p.container_id = ?", containerID))
+}
+
+// GetBySlug retrieves a public app by its URL slug
+func (r *PublicAppRepo) GetBySlug(slug string) (*models.PublicApp, error) {
+	return scanPublicApp(r.db.QueryRow(publicAppQuery+" WHERE p.slug = ?", slug))
+}
+
+// List returns all public apps ordered by slug
+func (r *PublicAppRepo) List() ([]models.PublicApp, error) {
+	rows, err := r.db.Query(publicAppQuery + " ORDER BY p.slug")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []models.PublicApp
+	for rows.Next() {
+		app, err := scanPublicApp(rows)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, *app)
+	}
+	return apps, rows.Err()
+}
+
+// Upsert publishes a container's web UI or updates its settings
+func (r *PublicAppRepo) Upsert(app *models.PublicApp) error {
+	listed := 0
+	if app.Listed {
+		listed = 1
+	}
+	app.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO public_apps (container_id, slug, pin_hash, listed, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(container_id) DO UPDATE SET
+			slug = excluded.slug, pin_hash = excluded.pin_hash, listed = excluded.listed
+	`, app.ContainerID, app.Slug, app.PINHash, listed, app.CreatedAt, app.CreatedBy)
+	return err
+}
+
+// Delete unpublishes a container's web UI
+func (r *PublicAppRepo) Delete(containerID string) error {
+	_, err := r.db.Exec("DELETE FROM public_apps WHERE container_id = ?", containerID)
+	return err
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// RegistryCredentialRepo handles saved registry login database operations
+type RegistryCredentialRepo struct {
+	db *sql.DB
+}
+
+// NewRegistryCredentialRepo creates a new registry credential repository
+func NewRegistryCredentialRepo() *RegistryCredentialRepo {
+	return &RegistryCredentialRepo{db: DB}
+}
+
+const registryCredentialColumns = `id, name, registry, username, secret_name, created_at, created_by`
+
+// Create adds a new credential
+func (r *RegistryCredentialRepo) Create(cred *models.RegistryCredential) error {
+	if cred.ID == "" {
+		cred.ID = uuid.New().String()
+	}
+	cred.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO registry_credentials (id, name, registry, username, secret_name, created_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, cred.ID, cred.Name, cred.Registry, cred.Username, cred.SecretName, cred.CreatedAt, cred.CreatedBy)
+	return err
+}
+
+// GetByID retrieves a credential by ID
+func (r *RegistryCredentialRepo) GetByID(id string) (*models.RegistryCredential, error) {
+	return scanRegistryCredential(r.db.QueryRow("SELECT "+registryCredentialColumns+" FROM registry_credentials WHERE id = ?", id))
+}
+
+// List returns all credentials
+func (r *RegistryCredentialRepo) List() ([]models.RegistryCredential, error) {
+	rows, err := r.db.Query("SELECT " + registryCredentialColumns + " FROM registry_credentials ORDER BY registry, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creds []models.RegistryCredential
+	for rows.Next() {
+		cred, err := scanRegistryCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, *cred)
+	}
+	return creds, rows.Err()
+}
+
+// Delete removes a credential
+func (r *RegistryCredentialRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM registry_credentials WHERE id = ?", id)
+	return err
+}
+
+func scanRegistryCredential(row rowScanner) (*models.RegistryCredential, error) {
+	cred := &models.RegistryCredential{}
+	var createdBy sql.NullInt64
+	if err := row.Scan(
+		&cred.ID, &cred.Name, &cred.Registry, &cred.Username, &cred.SecretName, &cred.CreatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		cred.CreatedBy = &createdBy.Int64
+	}
+	return cred, nil
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleAlreadyExists = errors.New("role already exists")
+)
+
+// RoleRepo handles role database operations
+type RoleRepo struct {
+	db DBTX
+}
+
+// NewRoleRepo creates a new role repository
+func NewRoleRepo() *RoleRepo {
+	return &RoleRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *RoleRepo) InTx(tx *sql.Tx) *RoleRepo {
+	return &RoleRepo{db: tx}
+}
+
+const roleColumns = `r.id, r.name, r.description, r.base_role, r.permissions, r.built_in, r.created_at, r.updated_at,
+	(SELECT COUNT(*) FROM users u WHERE u.role_id = r.id OR (r.built_in = 1 AND u.role_id IS NULL AND u.role = r.name))`
+
+func scanRole(row rowScanner) (*models.RoleDefinition, error) {
+	role := &models.RoleDefinition{}
+	var permissions string
+	err := row.Scan(
+		&role.ID, &role.Name, &role.Description, &role.BaseRole, &permissions, &role.BuiltIn,
+		&role.CreatedAt, &role.UpdatedAt, &role.UserCount,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(permissions), &role.Permissions); err != nil || role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+	return role, nil
+}
+
+// List returns the built-in roles followed by the custom ones by name
+func (r *RoleRepo) List() ([]*models.RoleDefinition, error) {
+	rows, err := r.db.Query(`SELECT ` + roleColumns + ` FROM roles r ORDER BY r.built_in DESC, r.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []*models.RoleDefinition{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GetByID retrieves a role by ID
+func (r *RoleRepo) GetByID(id int64) (*models.RoleDefinition, error) {
+	return scanRole(r.db.QueryRow(`SELECT `+roleColumns+` FROM roles r WHERE r.id = ?`, id))
+}
+
+// GetByName retrieves a role by name
+func (r *RoleRepo) GetByName(name string) (*models.RoleDefinition, error) {
+	return scanRole(r.db.QueryRow(`SELECT `+roleColumns+` FROM roles r WHERE r.name = ?`, name))
+}
+
+// ForUser returns the role whose permissions a user holds: their custom role
+// if they have one, otherwise the built-in role of the same name
+func (r *RoleRepo) ForUser(user *models.User) (*models.RoleDefinition, error) {
+	if user.RoleID != nil {
+		return r.GetByID(*user.RoleID)
+	}
+	return r.GetByName(string(user.Role))
+}
+
+// Create creates a custom role
+func (r *RoleRepo) Create(role *models.RoleDefinition) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return err
+	}
+	var exists int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM roles WHERE name = ?", role.Name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrRoleAlreadyExists
+	}
+
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = role.CreatedAt
+	result, err := r.db.Exec(`
+		INSERT INTO roles (name, description, base_role, permissions, built_in, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?)
+	`, role.Name, role.Description, role.BaseRole, string(permissions), role.CreatedAt, role.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	role.ID, err = result.LastInsertId()
+	return err
+}
+
+// Update saves a role's name, description, base role and permissions, and
+// moves the users holding it onto the new base role
+func (r *RoleRepo) Update(role *models.RoleDefinition) error {
+	permissions, err := json.Marshal(role.Permissions)
+	if err != nil {
+		return err
+	}
+	var exists int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM roles WHERE name = ? AND id != ?", role.Name, role.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return ErrRoleAlreadyExists
+	}
+
+	role.UpdatedAt = time.Now()
+	result, err := r.db.Exec(`
+		UPDATE roles SET name = ?, description = ?, base_role = ?, permissions = ?, updated_at = ?
+		WHERE id = ?
+	`, role.Name, role.Description, role.BaseRole, string(permissions), role.UpdatedAt, role.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrRoleNotFound
+	}
+	if !role.BuiltIn {
+		_, err = r.db.Exec("UPDATE users SET role = ?, updated_at = ? WHERE role_id = ?", role.BaseRole, role.UpdatedAt, role.ID)
+	}
+	return err
+}
+
+// Delete removes a custom role. Built-in roles can't be deleted.
+func (r *RoleRepo) Delete(id int64) error {
+	result, err := r.db.Exec("DELETE FROM roles WHERE id = ? AND built_in = 0", id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// SecurityProfileRepo handles security profile database operations
+type SecurityProfileRepo struct {
+	db *sql.DB
+}
+
+// NewSecurityProfileRepo creates a new security profile repository
+func NewSecurityProfileRepo() *SecurityProfileRepo {
+	return &SecurityProfileRepo{db: DB}
+}
+
+const securityProfileColumns = `id, name, type, description, content, curated, path, created_by, created_at, updated_at`
+
+// scanSecurityProfile scans a security profile row
+func scanSecurityProfile(row rowScanner) (*models.SecurityProfile, error) {
+	p := &models.SecurityProfile{Containers: []string{}}
+	if err := row.Scan(&p.ID, &p.Name, &p.Type, &p.Description, &p.Content, &p.Curated, &p.Path,
+		&p.CreatedBy, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Create adds a profile to the store
+func (r *SecurityProfileRepo) Create(p *models.SecurityProfile) error {
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	result, err := r.db.Exec(`
+		INSERT INTO security_profiles (name, type, description, content, curated, path, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.Name, p.Type, p.Description, p.Content, p.Curated, p.Path, p.CreatedBy, p.CreatedAt, p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	p.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetByID retrieves a profile by ID
+func (r *SecurityProfileRepo) GetByID(id int64) (*models.SecurityProfile, error) {
+	return scanSecurityProfile(r.db.QueryRow("SELECT "+securityProfileColumns+" FROM security_profiles WHERE id = ?", id))
+}
+
+// GetByName retrieves a profile by type and name
+func (r *SecurityProfileRepo) GetByName(profileType models.SecurityProfileType, name string) (*models.SecurityProfile, error) {
+	return scanSecurityProfile(r.db.QueryRow("SELECT "+securityProfileColumns+" FROM security_profiles WHERE type = ? AND name = ?", profileType, name))
+}
+
+// List returns all profiles ordered by type and name
+func (r *SecurityProfileRepo) List() ([]models.SecurityProfile, error) {
+	rows, err := r.db.Query("SELECT " + securityProfileColumns + " FROM security_profiles ORDER BY type, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.SecurityProfile{}
+	for rows.Next() {
+		p, err := scanSecurityProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+// Update saves a profile's description and content
+func (r *SecurityProfileRepo) Update(p *models.SecurityProfile) error {
+	p.UpdatedAt = time.Now()
+	_, err := r.db.Exec("UPDATE security_profiles SET description = ?, content = ?, updated_at = ? WHERE id = ?",
+		p.Description, p.Content, p.UpdatedAt, p.ID)
+	return err
+}
+
+// Delete removes a profile from the store
+func (r *SecurityProfileRepo) Delete(id int64) error {
+	_, err := r.db.Exec("DELETE FROM security_profiles WHERE id = ?", id)
+	return err
+}
@@ -62,6 +62,12 @@ func (r *SessionRepo) Create(userID int64, ipAddress, userAgent string, duration
 	return token, session, nil
 }
 
+// SetReadOnly limits a session to what a viewer may do
+func (r *SessionRepo) SetReadOnly(id int64) error {
+	_, err := DB.Exec("UPDATE sessions SET read_only = 1 WHERE id = ?", id)
+	return err
+}
+
 // GetByToken retrieves a session by its plain token
 func (r *SessionRepo) GetByToken(token string) (*models.Session, error) {
 	tokenHash := hashToken(token)
@@ -73,11 +79,11 @@ func (r *SessionRepo) GetByTokenHash(tokenHash string) (*models.Session, error)
 	session := &models.Session{}
 
 	err := DB.QueryRow(`
-		SELECT id, user_id, token_hash, created_at, expires_at, ip_address, user_agent
+		SELECT id, user_id, token_hash, created_at, expires_at, ip_address, user_agent, read_only
 		FROM sessions WHERE token_hash = ?
 	`, tokenHash).Scan(
 		&session.ID, &session.UserID, &session.TokenHash,
-		&session.CreatedAt, &session.ExpiresAt, &session.IPAddress, &session.UserAgent,
+		&session.CreatedAt, &session.ExpiresAt, &session.IPAddress, &session.UserAgent, &session.ReadOnly,
 	)
 	if err == sql.ErrNoRows {
 		return nil, ErrSessionNotFound
@@ -99,7 +105,7 @@ func (r *SessionRepo) GetByTokenHash(tokenHash string) (*models.Session, error)
 // GetByUserID retrieves all sessions for a user
 func (r *SessionRepo) GetByUserID(userID int64) ([]*models.Session, error) {
 	rows, err := DB.Query(`
-		SELECT id, user_id, token_hash, created_at, expires_at, ip_address, user_agent
+		SELECT id, user_id, token_hash, created_at, expires_at, ip_address, user_agent, read_only
 		FROM sessions WHERE user_id = ? ORDER BY created_at DESC
 	`, userID)
 	if err != nil {
@@ -112,7 +118,7 @@ func (r *SessionRepo) GetByUserID(userID int64) ([]*models.Session, error) {
 		session := &models.Session{}
 		err := rows.Scan(
 			&session.ID, &session.UserID, &session.TokenHash,
-			&session.CreatedAt, &session.ExpiresAt, &session.IPAddress, &session.UserAgent,
+			&session.CreatedAt, &session.ExpiresAt, &session.IPAddress, &session.UserAgent, &session.ReadOnly,
 		)
 		if err != nil {
 			return nil, err
@@ -142,6 +148,24 @@ func (r *SessionRepo) Extend(id int64, duration time.Duration) error {
 	return nil
 }
 
+// SetExpiry moves a session's expiration to the given time
+func (r *SessionRepo) SetExpiry(id int64, expiresAt time.Time) error {
+	result, err := DB.Exec("UPDATE sessions SET expires_at = ? WHERE id = ?", expiresAt, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
 // Delete deletes a session by ID
 func (r *SessionRepo) Delete(id int64) error {
 	_, err := DB.Exec("DELETE FROM sessions WHERE id = ?", id)
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"database/sql"
 	"strconv"
 	"time"
+
+	"stardeckos-backend/internal/models"
 )
 
 // SettingsRepo handles settings database operations
@@ -22,7 +25,24 @@ func (r *SettingsRepo) Get(key string) (string, error) {
 
 // Set sets a setting value
 func (r *SettingsRepo) Set(key, value string) error {
-	_, err := DB.Exec(`
+	return setSetting(DB, key, value)
+}
+
+// SetMany sets several settings in one transaction, so a failure leaves
+// none of them changed
+func (r *SettingsRepo) SetMany(values map[string]string) error {
+	return WithTx(func(tx *sql.Tx) error {
+		for key, value := range values {
+			if err := setSetting(tx, key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func setSetting(db DBTX, key, value string) error {
+	_, err := db.Exec(`
 		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
 		ON CONFLICT(key) DO UPDATE SET value = ?, updated_at = ?
 	`, key, value, time.Now(), value, time.Now())
@@ -71,6 +91,159 @@ func (r *SettingsRepo) GetAll() (map[string]string, error) {
 const (
 	SettingAuthLocalEnabled    = "auth.local_enabled"
 	SettingAuthPAMEnabled      = "auth.pam_enabled"
+	SettingKerberosEnabled     = "auth.kerberos_enabled"
+	SettingKerberosKeytab      = "auth.kerberos_keytab"
+	SettingKerberosRealms      = "auth.kerberos_realms"
 	SettingSessionTimeout      = "session.timeout_minutes"
 	SettingSessionMaxPerUser   = "session.max_per_user"
+	SettingSessionLifetime     = "session.absolute_lifetime_hours"
+	SettingTrashEnabled        = "trash.enabled"
+	SettingTrashRetentionDays  = "trash.retention_days"
+	SettingThumbnailsEnabled   = "thumbnails.enabled"
+	SettingThumbnailInterval   = "thumbnails.interval_minutes"
+	SettingIconLibraryURL      = "icons.library_url"
+	SettingMaintenanceEnabled  = "maintenance.nightly_enabled"
+	SettingMaintenanceHour     = "maintenance.hour"
+	SettingMetricsRetention    = "maintenance.metrics_retention_hours"
+	SettingAuditRetention      = "maintenance.audit_retention_days"
+	SettingMaintenanceVacuum   = "maintenance.vacuum"
+	SettingMaintenanceLastRun  = "maintenance.last_run"
+	SettingStackImportPaths    = "stacks.import_paths"
+	SettingManagedNetwork      = "network.managed_name"
+	SettingProxyTargetMode     = "proxy.target_mode"
+	SettingTerminalRecording   = "terminal.recording_enabled"
+	SettingTerminalIdleTimeout = "terminal.idle_timeout_minutes"
+	SettingTerminalRetention   = "terminal.recording_retention_days"
+	SettingWebDAVEnabled       = "webdav.enabled"
+	SettingBrandingName        = "branding.instance_name"
+	SettingBrandingAccent      = "branding.accent_color"
+	SettingBrandingBanner      = "branding.banner"
+	SettingBrandingLegal       = "branding.legal_notice"
+	SettingBrandingRequireAck  = "branding.require_ack"
+	SettingBrandingLogo        = "branding.logo_file"
+	SettingBrandingUpdated     = "branding.updated_at"
+	SettingApprovalsEnabled    = "approvals.enabled"
+	SettingApprovalTTL         = "approvals.ttl_minutes"
+	SettingMaintenanceMode     = "maintenance_mode.enabled"
+	SettingMaintenanceMessage  = "maintenance_mode.message"
+	SettingMaintenanceBlock    = "maintenance_mode.block_logins"
+	SettingMaintenanceStarted  = "maintenance_mode.started_at"
+	SettingMaintenanceBy       = "maintenance_mode.started_by"
+	SettingMaintenanceEnd      = "maintenance_mode.expected_end"
+	SettingLocalRegistryPath   = "local_registry.storage_path"
+	SettingLocalRegistryPort   = "local_registry.port"
+	SettingLocalRegistryAuth   = "local_registry.auth_enabled"
+	SettingLocalRegistryUser   = "local_registry.username"
+	SettingLocalRegistryTLS    = "local_registry.tls_enabled"
+	SettingLocalRegistryCred   = "local_registry.credential_id"
+	SettingLocalRegistryGC     = "local_registry.gc_enabled"
+	SettingLocalRegistryGCDay  = "local_registry.gc_weekday"
+	SettingLocalRegistryGCHour = "local_registry.gc_hour"
+	SettingLocalRegistryLastGC = "local_registry.last_gc"
+	SettingQuotaAppDataRoot    = "quota.app_data_root"
+	SettingEmailEnabled        = "email.enabled"
+	SettingSMTPHost            = "email.smtp_host"
+	SettingSMTPPort            = "email.smtp_port"
+	SettingSMTPSecurity        = "email.smtp_security"
+	SettingSMTPUsername        = "email.smtp_username"
+	SettingSMTPPasswordSecret  = "email.smtp_password_secret"
+	SettingEmailFrom           = "email.from"
+	SettingEmailPublicURL      = "email.public_url"
+	SettingInviteTTL           = "email.invite_ttl_hours"
+	SettingResetTTL            = "email.reset_ttl_minutes"
+	SettingKioskCookieKey      = "kiosk.cookie_key"
+	SettingPowerIdleWatts      = "power.idle_watts"
+	SettingPowerMaxWatts       = "power.max_watts"
+	SettingPowerPrice          = "power.price_per_kwh"
+	SettingPowerCurrency       = "power.currency"
+	SettingZRAMEnabled         = "memory.zram_enabled"
+	SettingZRAMSizePercent     = "memory.zram_size_percent"
+	SettingZRAMMaxMB           = "memory.zram_max_mb"
+	SettingZRAMAlgorithm       = "memory.zram_algorithm"
+	SettingOOMGuardEnabled     = "memory.oom_guard_enabled"
+	SettingOOMGuardMemPercent  = "memory.oom_guard_mem_percent"
+	SettingOOMGuardSwapPercent = "memory.oom_guard_swap_percent"
+	SettingOOMGuardAvoid       = "memory.oom_guard_avoid"
+	SettingFail2banSSHD        = "fail2ban.sshd_enabled"
+	SettingFail2banStardeck    = "fail2ban.stardeck_enabled"
+	SettingFail2banMaxRetry    = "fail2ban.max_retry"
+	SettingFail2banFindTime    = "fail2ban.find_time"
+	SettingFail2banBanTime     = "fail2ban.ban_time"
+	SettingFail2banIgnoreIP    = "fail2ban.ignore_ip"
+	SettingTLSMinVersion       = "tls.min_version"
+	SettingTLSCipherSuites     = "tls.cipher_suites"
+	SettingTLSHSTS             = "tls.hsts_enabled"
+	SettingTLSHSTSMaxAge       = "tls.hsts_max_age"
+	SettingTLSHSTSSubdomains   = "tls.hsts_include_subdomains"
+	SettingTLSHTTPRedirect     = "tls.http_redirect"
+	SettingTLSHTTPRedirectPort = "tls.http_redirect_port"
+	SettingMTLSMode            = "tls.mtls_mode"
+	SettingDHCPInterfaces      = "dhcp.interfaces"
+	SettingDNSEnabled          = "dns.enabled"
+	SettingDNSInterfaces       = "dns.interfaces"
+	SettingDNSUpstreams        = "dns.upstreams"
+	SettingDNSDomain           = "dns.domain"
+	SettingDNSAutoRecords      = "dns.auto_records"
+	SettingDNSHostAddress      = "dns.host_address"
+	SettingDNSCacheSize        = "dns.cache_size"
+	SettingDNSBlocklists       = "dns.blocklists"
+	SettingLANDeviceAlerts     = "lan.new_device_alerts"
+	SettingOutboundHTTPProxy   = "outbound_proxy.http"
+	SettingOutboundHTTPSProxy  = "outbound_proxy.https"
+	SettingOutboundNoProxy     = "outbound_proxy.no_proxy"
+	SettingNTPServerEnabled    = "ntp.server_enabled"
+	SettingNTPAllow            = "ntp.allow"
+	SettingNTPLocalStratum     = "ntp.local_stratum"
+	SettingCertMonitorSelf     = "cert_monitor.stardeck_alert_state"
+	SettingMQTTEnabled         = "mqtt.enabled"
+	SettingMQTTBrokerURL       = "mqtt.broker_url"
+	SettingMQTTUsername        = "mqtt.username"
+	SettingMQTTPasswordSecret  = "mqtt.password_secret"
+	SettingMQTTClientID        = "mqtt.client_id"
+	SettingMQTTTopicPrefix     = "mqtt.topic_prefix"
+	SettingMQTTDiscoveryPrefix = "mqtt.discovery_prefix"
+	SettingMQTTInterval        = "mqtt.publish_interval"
+	SettingMQTTCommands        = "mqtt.commands_enabled"
+	SettingMQTTCommandACL      = "mqtt.command_containers"
+	SettingContainerLogDriver  = "container_logs.driver"
+	SettingContainerLogMaxSize = "container_logs.max_size"
+	SettingContainerLogFiles   = "container_logs.max_files"
+	SettingHostName            = "host.name"
+	SettingHostLocation        = "host.location"
+	SettingHostTags            = "host.tags"
+	SettingHostContact         = "host.contact"
+	SettingHostUpdated         = "host.updated_at"
+	SettingIngressEnabled      = "ingress.enabled"
+	SettingIngressDomain       = "ingress.domain"
+	SettingReadOnlyMode        = "read_only.enabled"
+	SettingReadOnlyMessage     = "read_only.message"
+	SettingReadOnlyStarted     = "read_only.started_at"
+	SettingReadOnlyBy          = "read_only.started_by"
+	SettingSnapshotAuto        = "snapshots.auto_enabled"
+	SettingSnapshotPaths       = "snapshots.paths"
+	SettingSnapshotKeep        = "snapshots.keep"
+	SettingMetricsEnabled      = "metrics.collection_enabled"
+	SettingMetricsInterval     = "metrics.interval_seconds"
+	SettingMetricsRawRetention = "metrics.raw_retention_hours"
+	SettingMetricsLastRun      = "metrics.last_run"
+	SettingTrustedOrigins      = "security.trusted_origins"
+	SettingListenAddresses     = "listen.addresses"
+	SettingListenPort          = "listen.port"
+	SettingListenUnixSocket    = "listen.unix_socket"
+	SettingTrustedProxies      = "reverse_proxy.trusted_proxies"
+	SettingBasePath            = "reverse_proxy.base_path"
+	SettingClientSecretMaxAge  = "alliance.client_secret_max_age_days"
+	SettingClientSecretGrace   = "alliance.client_secret_grace_hours"
 )
+
+// RoleSetting returns the key of a per-role override for a setting, e.g.
+// session.timeout_minutes.viewer
+func RoleSetting(key string, role models.Role) string {
+	return key + "." + string(role)
+}
+
+// EmailTemplateSetting returns the key of an email template override, e.g.
+// email.template.invitation.subject
+func EmailTemplateSetting(name, field string) string {
+	return "email.template." + name + "." + field
+}
@@ -0,0 +1,275 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"modernc.org/sqlite"
+
+	"stardeckos-backend/internal/models"
+)
+
+// writeGate serializes writes from every pooled connection. WAL lets readers
+// run alongside a writer, but two connections writing at once still make one
+// wait on SQLite's file lock and, past the busy timeout, fail with
+// SQLITE_BUSY; queuing writes in-process avoids that and lets a waiting
+// caller give up when its context is cancelled.
+type writeGate struct {
+	slot chan struct{}
+
+	writes       atomic.Int64
+	transactions atomic.Int64
+	waits        atomic.Int64
+	waitNanos    atomic.Int64
+	maxWaitNanos atomic.Int64
+	busyErrors   atomic.Int64
+
+	mu         sync.Mutex
+	lastBusy   string
+	lastBusyAt time.Time
+}
+
+var gate = &writeGate{slot: make(chan struct{}, 1)}
+
+// acquire waits for the write slot
+func (g *writeGate) acquire(ctx context.Context) error {
+	select {
+	case g.slot <- struct{}{}:
+		return nil
+	default:
+	}
+
+	start := time.Now()
+	select {
+	case g.slot <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	wait := time.Since(start).Nanoseconds()
+	g.waits.Add(1)
+	g.waitNanos.Add(wait)
+	for {
+		max := g.maxWaitNanos.Load()
+		if wait <= max || g.maxWaitNanos.CompareAndSwap(max, wait) {
+			break
+		}
+	}
+	return nil
+}
+
+func (g *writeGate) release() {
+	<-g.slot
+}
+
+// observe counts busy and locked errors that got through the busy timeout
+func (g *writeGate) observe(err error) error {
+	var sqliteErr *sqlite.Error
+	if err != nil && errors.As(err, &sqliteErr) {
+		// Extended result codes keep the primary code in the low byte
+		if code := sqliteErr.Code() & 0xff; code == 5 || code == 6 {
+			g.busyErrors.Add(1)
+			g.mu.Lock()
+			g.lastBusy = err.Error()
+			g.lastBusyAt = time.Now()
+			g.mu.Unlock()
+		}
+	}
+	return err
+}
+
+// gatedConnector opens SQLite connections whose writes pass through the gate
+type gatedConnector struct {
+	dsn  string
+	base driver.Driver
+}
+
+func (c *gatedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &gatedConn{conn: conn}, nil
+}
+
+func (c *gatedConnector) Driver() driver.Driver {
+	return c.base
+}
+
+// gatedConn wraps a SQLite connection. Exec calls take the write slot for the
+// statement; a read-write transaction holds it from begin to commit, so the
+// statements inside it don't take it again. Queries never wait, so a write
+// made through Query (INSERT ... RETURNING) relies on the busy timeout.
+type gatedConn struct {
+	conn driver.Conn
+	inTx bool
+}
+
+func (c *gatedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *gatedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+	if err != nil {
+		return nil, gate.observe(err)
+	}
+	return &gatedStmt{stmt: stmt, conn: c}, nil
+}
+
+func (c *gatedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *gatedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *gatedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	beginTx := c.conn.(driver.ConnBeginTx).BeginTx
+	if opts.ReadOnly {
+		tx, err := beginTx(ctx, opts)
+		return tx, gate.observe(err)
+	}
+	if err := gate.acquire(ctx); err != nil {
+		return nil, err
+	}
+	tx, err := beginTx(ctx, opts)
+	if err != nil {
+		gate.release()
+		return nil, gate.observe(err)
+	}
+	gate.transactions.Add(1)
+	c.inTx = true
+	return &gatedTx{tx: tx, conn: c}, nil
+}
+
+func (c *gatedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.exec(ctx, func() (driver.Result, error) {
+		return c.conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	})
+}
+
+func (c *gatedConn) exec(ctx context.Context, run func() (driver.Result, error)) (driver.Result, error) {
+	if !c.inTx {
+		if err := gate.acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer gate.release()
+	}
+	gate.writes.Add(1)
+	result, err := run()
+	return result, gate.observe(err)
+}
+
+func (c *gatedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	return rows, gate.observe(err)
+}
+
+func (c *gatedConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *gatedConn) ResetSession(ctx context.Context) error {
+	return c.conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *gatedConn) IsValid() bool {
+	return c.conn.(driver.Validator).IsValid()
+}
+
+// gatedTx releases the write slot when the transaction ends
+type gatedTx struct {
+	tx   driver.Tx
+	conn *gatedConn
+}
+
+func (t *gatedTx) Commit() error {
+	defer t.end()
+	return gate.observe(t.tx.Commit())
+}
+
+func (t *gatedTx) Rollback() error {
+	defer t.end()
+	return gate.observe(t.tx.Rollback())
+}
+
+func (t *gatedTx) end() {
+	t.conn.inTx = false
+	gate.release()
+}
+
+// gatedStmt runs prepared writes through the gate like conn-level Exec
+type gatedStmt struct {
+	stmt driver.Stmt
+	conn *gatedConn
+}
+
+func (s *gatedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *gatedStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *gatedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *gatedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *gatedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.exec(ctx, func() (driver.Result, error) {
+		return s.stmt.(driver.StmtExecContext).ExecContext(ctx, args)
+	})
+}
+
+func (s *gatedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := s.stmt.(driver.StmtQueryContext).QueryContext(ctx, args)
+	return rows, gate.observe(err)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+// ConnectionStats reports the connection pool and write gate counters
+func ConnectionStats() models.DatabaseConnectionStats {
+	pool := DB.Stats()
+	stats := models.DatabaseConnectionStats{
+		MaxOpen:        pool.MaxOpenConnections,
+		Open:           pool.OpenConnections,
+		InUse:          pool.InUse,
+		Idle:           pool.Idle,
+		PoolWaits:      pool.WaitCount,
+		PoolWaitMs:     pool.WaitDuration.Milliseconds(),
+		Writes:         gate.writes.Load(),
+		Transactions:   gate.transactions.Load(),
+		WriteWaits:     gate.waits.Load(),
+		WriteWaitMs:    time.Duration(gate.waitNanos.Load()).Milliseconds(),
+		MaxWriteWaitMs: time.Duration(gate.maxWaitNanos.Load()).Milliseconds(),
+		BusyErrors:     gate.busyErrors.Load(),
+	}
+	gate.mu.Lock()
+	stats.LastBusyError = gate.lastBusy
+	if !gate.lastBusyAt.IsZero() {
+		at := gate.lastBusyAt
+		stats.LastBusyAt = &at
+	}
+	gate.mu.Unlock()
+
+	DB.QueryRow("PRAGMA journal_mode").Scan(&stats.JournalMode)
+	DB.QueryRow("PRAGMA busy_timeout").Scan(&stats.BusyTimeoutMs)
+	return stats
+}
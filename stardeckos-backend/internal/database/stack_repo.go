@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,11 +11,18 @@ import (
 )
 
 // StackRepo handles database operations for stacks
-type StackRepo struct{}
+type StackRepo struct {
+	db DBTX
+}
 
 // NewStackRepo creates a new StackRepo
 func NewStackRepo() *StackRepo {
-	return &StackRepo{}
+	return &StackRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *StackRepo) InTx(tx *sql.Tx) *StackRepo {
+	return &StackRepo{db: tx}
 }
 
 // InitStackTable creates the stacks table if it doesn't exist
@@ -41,12 +49,12 @@ func InitStackTable() error {
 // List returns all stacks
 func (r *StackRepo) List() ([]models.StackListItem, error) {
 	query := `
-		SELECT id, name, description, status, created_at, updated_at
+		SELECT id, name, description, status, created_at, updated_at, COALESCE(icon, ''), created_by
 		FROM stacks
 		ORDER BY created_at DESC
 	`
 
-	rows, err := DB.Query(query)
+	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +64,14 @@ func (r *StackRepo) List() ([]models.StackListItem, error) {
 	for rows.Next() {
 		var s models.StackListItem
 		var status string
-		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &status, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		var createdBy sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &status, &s.CreatedAt, &s.UpdatedAt, &s.Icon, &createdBy); err != nil {
 			return nil, err
 		}
 		s.Status = models.StackStatus(status)
+		if createdBy.Valid {
+			s.CreatedBy = &createdBy.Int64
+		}
 		stacks = append(stacks, s)
 	}
 
@@ -69,7 +81,7 @@ func (r *StackRepo) List() ([]models.StackListItem, error) {
 // GetByID returns a stack by ID
 func (r *StackRepo) GetByID(id string) (*models.Stack, error) {
 	query := `
-		SELECT id, name, description, compose_content, env_content, status, path, created_at, updated_at, created_by
+		SELECT id, name, description, compose_content, env_content, status, path, created_at, updated_at, created_by, COALESCE(icon, ''), COALESCE(profiles, '[]')
 		FROM stacks
 		WHERE id = ?
 	`
@@ -77,9 +89,10 @@ func (r *StackRepo) GetByID(id string) (*models.Stack, error) {
 	var s models.Stack
 	var status string
 	var createdBy sql.NullInt64
-	err := DB.QueryRow(query, id).Scan(
+	var profiles string
+	err := r.db.QueryRow(query, id).Scan(
 		&s.ID, &s.Name, &s.Description, &s.ComposeContent, &s.EnvContent,
-		&status, &s.Path, &s.CreatedAt, &s.UpdatedAt, &createdBy,
+		&status, &s.Path, &s.CreatedAt, &s.UpdatedAt, &createdBy, &s.Icon, &profiles,
 	)
 	if err != nil {
 		return nil, err
@@ -89,6 +102,7 @@ func (r *StackRepo) GetByID(id string) (*models.Stack, error) {
 	if createdBy.Valid {
 		s.CreatedBy = &createdBy.Int64
 	}
+	json.Unmarshal([]byte(profiles), &s.Profiles)
 
 	return &s, nil
 }
@@ -96,7 +110,7 @@ func (r *StackRepo) GetByID(id string) (*models.Stack, error) {
 // GetByName returns a stack by name
 func (r *StackRepo) GetByName(name string) (*models.Stack, error) {
 	query := `
-		SELECT id, name, description, compose_content, env_content, status, path, created_at, updated_at, created_by
+		SELECT id, name, description, compose_content, env_content, status, path, created_at, updated_at, created_by, COALESCE(icon, ''), COALESCE(profiles, '[]')
 		FROM stacks
 		WHERE name = ?
 	`
@@ -104,9 +118,10 @@ func (r *StackRepo) GetByName(name string) (*models.Stack, error) {
 	var s models.Stack
 	var status string
 	var createdBy sql.NullInt64
-	err := DB.QueryRow(query, name).Scan(
+	var profiles string
+	err := r.db.QueryRow(query, name).Scan(
 		&s.ID, &s.Name, &s.Description, &s.ComposeContent, &s.EnvContent,
-		&status, &s.Path, &s.CreatedAt, &s.UpdatedAt, &createdBy,
+		&status, &s.Path, &s.CreatedAt, &s.UpdatedAt, &createdBy, &s.Icon, &profiles,
 	)
 	if err != nil {
 		return nil, err
@@ -116,6 +131,7 @@ func (r *StackRepo) GetByName(name string) (*models.Stack, error) {
 	if createdBy.Valid {
 		s.CreatedBy = &createdBy.Int64
 	}
+	json.Unmarshal([]byte(profiles), &s.Profiles)
 
 	return &s, nil
 }
@@ -129,13 +145,13 @@ func (r *StackRepo) Create(s *models.Stack) error {
 	s.UpdatedAt = time.Now()
 
 	query := `
-		INSERT INTO stacks (id, name, description, compose_content, env_content, status, path, created_at, updated_at, created_by)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO stacks (id, name, description, compose_content, env_content, status, path, created_at, updated_at, created_by, icon, profiles)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := DB.Exec(query,
+	_, err := r.db.Exec(query,
 		s.ID, s.Name, s.Description, s.ComposeContent, s.EnvContent,
-		string(s.Status), s.Path, s.CreatedAt, s.UpdatedAt, s.CreatedBy,
+		string(s.Status), s.Path, s.CreatedAt, s.UpdatedAt, s.CreatedBy, s.Icon, sliceToJSON(s.Profiles),
 	)
 	return err
 }
@@ -146,26 +162,70 @@ func (r *StackRepo) Update(s *models.Stack) error {
 
 	query := `
 		UPDATE stacks
-		SET name = ?, description = ?, compose_content = ?, env_content = ?, status = ?, path = ?, updated_at = ?
+		SET name = ?, description = ?, compose_content = ?, env_content = ?, status = ?, path = ?, icon = ?, profiles = ?, updated_at = ?
 		WHERE id = ?
 	`
 
-	_, err := DB.Exec(query,
+	_, err := r.db.Exec(query,
 		s.Name, s.Description, s.ComposeContent, s.EnvContent,
-		string(s.Status), s.Path, s.UpdatedAt, s.ID,
+		string(s.Status), s.Path, s.Icon, sliceToJSON(s.Profiles), s.UpdatedAt, s.ID,
 	)
 	return err
 }
 
+// ListByOwner returns the stacks created by or transferred to a user
+func (r *StackRepo) ListByOwner(userID int64) ([]models.Stack, error) {
+	query := `
+		SELECT id, name, description, compose_content, env_content, status, path, created_at, updated_at, created_by, COALESCE(icon, ''), COALESCE(profiles, '[]')
+		FROM stacks
+		WHERE created_by = ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stacks []models.Stack
+	for rows.Next() {
+		var s models.Stack
+		var status string
+		var createdBy sql.NullInt64
+		var profiles string
+		if err := rows.Scan(
+			&s.ID, &s.Name, &s.Description, &s.ComposeContent, &s.EnvContent,
+			&status, &s.Path, &s.CreatedAt, &s.UpdatedAt, &createdBy, &s.Icon, &profiles,
+		); err != nil {
+			return nil, err
+		}
+		s.Status = models.StackStatus(status)
+		if createdBy.Valid {
+			s.CreatedBy = &createdBy.Int64
+		}
+		json.Unmarshal([]byte(profiles), &s.Profiles)
+		stacks = append(stacks, s)
+	}
+
+	return stacks, rows.Err()
+}
+
+// SetOwner transfers a stack to another user
+func (r *StackRepo) SetOwner(id string, userID int64) error {
+	_, err := r.db.Exec(`UPDATE stacks SET created_by = ?, updated_at = ? WHERE id = ?`, userID, time.Now(), id)
+	return err
+}
+
 // UpdateStatus updates only the status of a stack
 func (r *StackRepo) UpdateStatus(id string, status models.StackStatus) error {
 	query := `UPDATE stacks SET status = ?, updated_at = ? WHERE id = ?`
-	_, err := DB.Exec(query, string(status), time.Now(), id)
+	_, err := r.db.Exec(query, string(status), time.Now(), id)
 	return err
 }
 
 // Delete deletes a stack by ID
 func (r *StackRepo) Delete(id string) error {
-	_, err := DB.Exec("DELETE FROM stacks WHERE id = ?", id)
+	_, err := r.db.Exec("DELETE FROM stacks WHERE id = ?", id)
 	return err
 }
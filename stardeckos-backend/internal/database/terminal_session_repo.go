@@ -0,0 +1,124 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// TerminalSessionRepo handles host terminal session records
+type TerminalSessionRepo struct {
+	db *sql.DB
+}
+
+// NewTerminalSessionRepo creates a new terminal session repository
+func NewTerminalSessionRepo() *TerminalSessionRepo {
+	return &TerminalSessionRepo{db: DB}
+}
+
+const terminalSessionColumns = `
+	id, user_id, username, ip_address, started_at, ended_at, end_reason, recording_path, recording_size`
+
+// Create stores a newly opened session
+func (r *TerminalSessionRepo) Create(s *models.TerminalSession) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	if s.StartedAt.IsZero() {
+		s.StartedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO terminal_sessions (id, user_id, username, ip_address, started_at, recording_path)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, s.ID, s.UserID, s.Username, s.IPAddress, s.StartedAt, s.RecordingPath)
+	return err
+}
+
+// Finish records when and why a session ended and the final recording size
+func (r *TerminalSessionRepo) Finish(id, reason string, recordingSize int64) error {
+	_, err := r.db.Exec(`
+		UPDATE terminal_sessions SET ended_at = ?, end_reason = ?, recording_size = ? WHERE id = ?
+	`, time.Now(), reason, recordingSize, id)
+	return err
+}
+
+// GetByID retrieves a session by ID
+func (r *TerminalSessionRepo) GetByID(id string) (*models.TerminalSession, error) {
+	return scanTerminalSession(r.db.QueryRow("SELECT "+terminalSessionColumns+" FROM terminal_sessions WHERE id = ?", id))
+}
+
+// List returns the most recent sessions, newest first
+func (r *TerminalSessionRepo) List(limit int) ([]models.TerminalSession, error) {
+	rows, err := r.db.Query("SELECT "+terminalSessionColumns+" FROM terminal_sessions ORDER BY started_at DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.TerminalSession
+	for rows.Next() {
+		s, err := scanTerminalSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+// ListStartedBefore returns ended sessions older than the cutoff, for recording retention
+func (r *TerminalSessionRepo) ListStartedBefore(cutoff time.Time) ([]models.TerminalSession, error) {
+	rows, err := r.db.Query("SELECT "+terminalSessionColumns+" FROM terminal_sessions WHERE started_at < ? AND ended_at IS NOT NULL", cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.TerminalSession
+	for rows.Next() {
+		s, err := scanTerminalSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+// MarkInterrupted closes out sessions left open by a service restart
+func (r *TerminalSessionRepo) MarkInterrupted() error {
+	_, err := r.db.Exec(`
+		UPDATE terminal_sessions SET ended_at = ?, end_reason = 'interrupted' WHERE ended_at IS NULL
+	`, time.Now())
+	return err
+}
+
+// Delete removes a session record
+func (r *TerminalSessionRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM terminal_sessions WHERE id = ?", id)
+	return err
+}
+
+func scanTerminalSession(row rowScanner) (*models.TerminalSession, error) {
+	s := &models.TerminalSession{}
+	var endedAt sql.NullTime
+	var endReason, ipAddress, recordingPath sql.NullString
+	if err := row.Scan(
+		&s.ID, &s.UserID, &s.Username, &ipAddress, &s.StartedAt, &endedAt, &endReason,
+		&recordingPath, &s.RecordingSize,
+	); err != nil {
+		return nil, err
+	}
+	s.IPAddress = ipAddress.String
+	s.EndReason = endReason.String
+	s.RecordingPath = recordingPath.String
+	s.HasRecording = s.RecordingPath != ""
+	if endedAt.Valid {
+		s.EndedAt = &endedAt.Time
+	}
+	return s, nil
+}
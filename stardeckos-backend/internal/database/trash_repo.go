@@ -0,0 +1,120 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// TrashRepo handles recycle bin database operations
+type TrashRepo struct {
+	db *sql.DB
+}
+
+// NewTrashRepo creates a new trash repository
+func NewTrashRepo() *TrashRepo {
+	return &TrashRepo{db: DB}
+}
+
+// Create adds a new item to the recycle bin
+func (r *TrashRepo) Create(t *models.TrashItem) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.DeletedAt.IsZero() {
+		t.DeletedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(`
+		INSERT INTO trash_items (
+			id, item_type, name, original_id, payload, backup_path, has_backup,
+			size_bytes, deleted_at, expires_at, deleted_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		t.ID, t.ItemType, t.Name, t.OriginalID, t.Payload, t.BackupPath, t.HasBackup,
+		t.SizeBytes, t.DeletedAt, t.ExpiresAt, t.DeletedBy,
+	)
+	return err
+}
+
+// GetByID retrieves a trash item by ID, including its payload
+func (r *TrashRepo) GetByID(id string) (*models.TrashItem, error) {
+	t := &models.TrashItem{}
+	var hasBackup int
+	var backupPath, originalID sql.NullString
+	err := r.db.QueryRow(`
+		SELECT id, item_type, name, original_id, payload, backup_path, has_backup,
+			size_bytes, deleted_at, expires_at, deleted_by
+		FROM trash_items WHERE id = ?
+	`, id).Scan(
+		&t.ID, &t.ItemType, &t.Name, &originalID, &t.Payload, &backupPath, &hasBackup,
+		&t.SizeBytes, &t.DeletedAt, &t.ExpiresAt, &t.DeletedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	t.OriginalID = originalID.String
+	t.BackupPath = backupPath.String
+	t.HasBackup = hasBackup == 1
+	return t, nil
+}
+
+// List returns all trash items, optionally filtered by type
+func (r *TrashRepo) List(itemType string) ([]*models.TrashItem, error) {
+	query := `
+		SELECT id, item_type, name, original_id, payload, backup_path, has_backup,
+			size_bytes, deleted_at, expires_at, deleted_by
+		FROM trash_items`
+	args := []interface{}{}
+	if itemType != "" {
+		query += " WHERE item_type = ?"
+		args = append(args, itemType)
+	}
+	query += " ORDER BY deleted_at DESC"
+
+	return r.query(query, args...)
+}
+
+// ListExpired returns trash items whose retention period has elapsed
+func (r *TrashRepo) ListExpired(now time.Time) ([]*models.TrashItem, error) {
+	return r.query(`
+		SELECT id, item_type, name, original_id, payload, backup_path, has_backup,
+			size_bytes, deleted_at, expires_at, deleted_by
+		FROM trash_items WHERE expires_at <= ? ORDER BY expires_at ASC
+	`, now)
+}
+
+// Delete removes a trash item record
+func (r *TrashRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM trash_items WHERE id = ?", id)
+	return err
+}
+
+func (r *TrashRepo) query(query string, args ...interface{}) ([]*models.TrashItem, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.TrashItem
+	for rows.Next() {
+		t := &models.TrashItem{}
+		var hasBackup int
+		var backupPath, originalID sql.NullString
+		if err := rows.Scan(
+			&t.ID, &t.ItemType, &t.Name, &originalID, &t.Payload, &backupPath, &hasBackup,
+			&t.SizeBytes, &t.DeletedAt, &t.ExpiresAt, &t.DeletedBy,
+		); err != nil {
+			return nil, err
+		}
+		t.OriginalID = originalID.String
+		t.BackupPath = backupPath.String
+		t.HasBackup = hasBackup == 1
+		items = append(items, t)
+	}
+	return items, rows.Err()
+}
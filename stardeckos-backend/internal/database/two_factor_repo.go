@@ -0,0 +1,151 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+var ErrTwoFactorNotFound = errors.New("two-factor authentication not set up")
+
+// TwoFactorRepo handles TOTP enrollments and recovery codes
+type TwoFactorRepo struct {
+	db DBTX
+}
+
+// NewTwoFactorRepo creates a new two-factor repository
+func NewTwoFactorRepo() *TwoFactorRepo {
+	return &TwoFactorRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *TwoFactorRepo) InTx(tx *sql.Tx) *TwoFactorRepo {
+	return &TwoFactorRepo{db: tx}
+}
+
+// Get retrieves a user's enrollment, pending or enabled
+func (r *TwoFactorRepo) Get(userID int64) (*models.TwoFactor, error) {
+	tf := &models.TwoFactor{}
+	var enabledAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT user_id, secret, enabled, last_step, created_at, enabled_at
+		FROM user_two_factor WHERE user_id = ?
+	`, userID).Scan(&tf.UserID, &tf.Secret, &tf.Enabled, &tf.LastStep, &tf.CreatedAt, &enabledAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTwoFactorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if enabledAt.Valid {
+		tf.EnabledAt = &enabledAt.Time
+	}
+	return tf, nil
+}
+
+// IsEnabled reports whether a user must give a second factor to sign in
+func (r *TwoFactorRepo) IsEnabled(userID int64) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRow("SELECT enabled FROM user_two_factor WHERE user_id = ?", userID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled, err
+}
+
+// SetPending stores a new secret awaiting verification, replacing any
+// earlier pending one. An enabled enrollment is left alone.
+func (r *TwoFactorRepo) SetPending(userID int64, secret string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_two_factor (user_id, secret, enabled, last_step, created_at)
+		VALUES (?, ?, 0, 0, ?)
+		ON CONFLICT(user_id) DO UPDATE SET secret = excluded.secret, last_step = 0, created_at = excluded.created_at
+		WHERE enabled = 0
+	`, userID, secret, time.Now())
+	return err
+}
+
+// Enable turns on a pending enrollment, recording the step of the code that
+// verified it
+func (r *TwoFactorRepo) Enable(userID, step int64) error {
+	result, err := r.db.Exec(`
+		UPDATE user_two_factor SET enabled = 1, last_step = ?, enabled_at = ?
+		WHERE user_id = ? AND enabled = 0
+	`, step, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrTwoFactorNotFound
+	}
+	return nil
+}
+
+// UseStep records a code's time step as used. It reports false when that
+// step or a later one was already used, so each code works once.
+func (r *TwoFactorRepo) UseStep(userID, step int64) (bool, error) {
+	result, err := r.db.Exec(
+		"UPDATE user_two_factor SET last_step = ? WHERE user_id = ? AND last_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Delete removes a user's enrollment and recovery codes
+func (r *TwoFactorRepo) Delete(userID int64) error {
+	if _, err := r.db.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	_, err := r.db.Exec("DELETE FROM user_two_factor WHERE user_id = ?", userID)
+	return err
+}
+
+// ReplaceRecoveryCodes stores a user's new recovery codes, hashed, in place
+// of any earlier ones
+func (r *TwoFactorRepo) ReplaceRecoveryCodes(userID int64, codes []string) error {
+	if _, err := r.db.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, code := range codes {
+		if _, err := r.db.Exec(
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)",
+			userID, hashToken(code),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether
+// there was one
+func (r *TwoFactorRepo) UseRecoveryCode(userID int64, code string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE user_recovery_codes SET used_at = ?
+		WHERE id = (
+			SELECT id FROM user_recovery_codes
+			WHERE user_id = ? AND code_hash = ? AND used_at IS NULL LIMIT 1
+		)
+	`, time.Now(), userID, hashToken(code))
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has
+func (r *TwoFactorRepo) CountRecoveryCodes(userID int64) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL",
+		userID,
+	).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is the part of *sql.DB and *sql.Tx the repositories use, so a
+// repository can run its statements inside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// WithTx runs fn in a transaction, committing if it returns nil and rolling
+// back if it returns an error or panics. Repositories join the transaction
+// through their InTx method:
+//
+//	err := database.WithTx(func(tx *sql.Tx) error {
+//		if err := containerRepo.InTx(tx).Delete(id); err != nil {
+//			return err
+//		}
+//		return envVarRepo.InTx(tx).DeleteByContainerID(id)
+//	})
+//
+// The transaction holds the write lock until it ends, so fn should only do
+// database work, and must not write through DB: that write would wait for
+// the transaction to end and never run.
+func WithTx(fn func(tx *sql.Tx) error) (err error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// UserQuotaRepo handles per-user quota database operations
+type UserQuotaRepo struct {
+	db *sql.DB
+}
+
+// NewUserQuotaRepo creates a new user quota repository
+func NewUserQuotaRepo() *UserQuotaRepo {
+	return &UserQuotaRepo{db: DB}
+}
+
+// Get retrieves a user's quota
+func (r *UserQuotaRepo) Get(userID int64) (*models.UserQuota, error) {
+	q := &models.UserQuota{}
+	err := r.db.QueryRow(`
+		SELECT user_id, max_containers, max_memory_bytes, max_cpus, max_disk_bytes, updated_at
+		FROM user_quotas WHERE user_id = ?
+	`, userID).Scan(&q.UserID, &q.MaxContainers, &q.MaxMemoryBytes, &q.MaxCPUs, &q.MaxDiskBytes, &q.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// List returns all quotas keyed by user ID
+func (r *UserQuotaRepo) List() (map[int64]*models.UserQuota, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id, max_containers, max_memory_bytes, max_cpus, max_disk_bytes, updated_at
+		FROM user_quotas
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotas := make(map[int64]*models.UserQuota)
+	for rows.Next() {
+		q := &models.UserQuota{}
+		if err := rows.Scan(&q.UserID, &q.MaxContainers, &q.MaxMemoryBytes, &q.MaxCPUs, &q.MaxDiskBytes, &q.UpdatedAt); err != nil {
+			return nil, err
+		}
+		quotas[q.UserID] = q
+	}
+	return quotas, rows.Err()
+}
+
+// Upsert creates or replaces a user's quota
+func (r *UserQuotaRepo) Upsert(q *models.UserQuota) error {
+	q.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO user_quotas (user_id, max_containers, max_memory_bytes, max_cpus, max_disk_bytes, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			max_containers = excluded.max_containers,
+			max_memory_bytes = excluded.max_memory_bytes,
+			max_cpus = excluded.max_cpus,
+			max_disk_bytes = excluded.max_disk_bytes,
+			updated_at = excluded.updated_at
+	`, q.UserID, q.MaxContainers, q.MaxMemoryBytes, q.MaxCPUs, q.MaxDiskBytes, q.UpdatedAt)
+	return err
+}
+
+// Delete removes a user's quota, leaving them unlimited
+func (r *UserQuotaRepo) Delete(userID int64) error {
+	_, err := r.db.Exec("DELETE FROM user_quotas WHERE user_id = ?", userID)
+	return err
+}
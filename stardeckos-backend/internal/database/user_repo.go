@@ -24,9 +24,9 @@ func NewUserRepo() *UserRepo {
 // Create creates a new user
 func (r *UserRepo) Create(user *models.User) error {
 	result, err := DB.Exec(`
-		INSERT INTO users (username, display_name, password_hash, user_type, role, auth_type, disabled)
-		VALUES (?, ?, ?, 'system', ?, ?, ?)
-	`, user.Username, user.DisplayName, user.PasswordHash, user.Role, user.AuthType, user.Disabled)
+		INSERT INTO users (username, display_name, email, password_hash, user_type, role, role_id, auth_type, disabled)
+		VALUES (?, ?, NULLIF(?, ''), ?, 'system', ?, ?, ?, ?)
+	`, user.Username, user.DisplayName, user.Email, user.PasswordHash, user.Role, user.RoleID, user.AuthType, user.Disabled)
 	if err != nil {
 		return err
 	}
@@ -45,14 +45,15 @@ func (r *UserRepo) GetByID(id int64) (*models.User, error) {
 	user := &models.User{}
 	var lastLogin sql.NullTime
 	var userType string // Deprecated but still in DB
+	var roleID sql.NullInt64
 
 	err := DB.QueryRow(`
-		SELECT id, username, display_name, password_hash, user_type, role, auth_type, disabled,
+		SELECT id, username, display_name, COALESCE(email, ''), password_hash, user_type, role, role_id, auth_type, disabled,
 		       created_at, updated_at, last_login
 		FROM users WHERE id = ?
 	`, id).Scan(
-		&user.ID, &user.Username, &user.DisplayName, &user.PasswordHash,
-		&userType, &user.Role, &user.AuthType, &user.Disabled,
+		&user.ID, &user.Username, &user.DisplayName, &user.Email, &user.PasswordHash,
+		&userType, &user.Role, &roleID, &user.AuthType, &user.Disabled,
 		&user.CreatedAt, &user.UpdatedAt, &lastLogin,
 	)
 	if err == sql.ErrNoRows {
@@ -65,6 +66,9 @@ func (r *UserRepo) GetByID(id int64) (*models.User, error) {
 	if lastLogin.Valid {
 		user.LastLogin = lastLogin.Time
 	}
+	if roleID.Valid {
+		user.RoleID = &roleID.Int64
+	}
 
 	return user, nil
 }
@@ -74,14 +78,15 @@ func (r *UserRepo) GetByUsername(username string) (*models.User, error) {
 	user := &models.User{}
 	var lastLogin sql.NullTime
 	var userType string // Deprecated but still in DB
+	var roleID sql.NullInt64
 
 	err := DB.QueryRow(`
-		SELECT id, username, display_name, password_hash, user_type, role, auth_type, disabled,
+		SELECT id, username, display_name, COALESCE(email, ''), password_hash, user_type, role, role_id, auth_type, disabled,
 		       created_at, updated_at, last_login
 		FROM users WHERE username = ?
 	`, username).Scan(
-		&user.ID, &user.Username, &user.DisplayName, &user.PasswordHash,
-		&userType, &user.Role, &user.AuthType, &user.Disabled,
+		&user.ID, &user.Username, &user.DisplayName, &user.Email, &user.PasswordHash,
+		&userType, &user.Role, &roleID, &user.AuthType, &user.Disabled,
 		&user.CreatedAt, &user.UpdatedAt, &lastLogin,
 	)
 	if err == sql.ErrNoRows {
@@ -94,6 +99,9 @@ func (r *UserRepo) GetByUsername(username string) (*models.User, error) {
 	if lastLogin.Valid {
 		user.LastLogin = lastLogin.Time
 	}
+	if roleID.Valid {
+		user.RoleID = &roleID.Int64
+	}
 
 	return user, nil
 }
@@ -101,7 +109,7 @@ func (r *UserRepo) GetByUsername(username string) (*models.User, error) {
 // List retrieves all users
 func (r *UserRepo) List() ([]*models.User, error) {
 	rows, err := DB.Query(`
-		SELECT id, username, display_name, password_hash, user_type, role, auth_type, disabled,
+		SELECT id, username, display_name, COALESCE(email, ''), password_hash, user_type, role, role_id, auth_type, disabled,
 		       created_at, updated_at, last_login
 		FROM users ORDER BY username
 	`)
@@ -115,10 +123,11 @@ func (r *UserRepo) List() ([]*models.User, error) {
 		user := &models.User{}
 		var lastLogin sql.NullTime
 		var userType string // Deprecated but still in DB
+		var roleID sql.NullInt64
 
 		err := rows.Scan(
-			&user.ID, &user.Username, &user.DisplayName, &user.PasswordHash,
-			&userType, &user.Role, &user.AuthType, &user.Disabled,
+			&user.ID, &user.Username, &user.DisplayName, &user.Email, &user.PasswordHash,
+			&userType, &user.Role, &roleID, &user.AuthType, &user.Disabled,
 			&user.CreatedAt, &user.UpdatedAt, &lastLogin,
 		)
 		if err != nil {
@@ -128,6 +137,9 @@ func (r *UserRepo) List() ([]*models.User, error) {
 		if lastLogin.Valid {
 			user.LastLogin = lastLogin.Time
 		}
+		if roleID.Valid {
+			user.RoleID = &roleID.Int64
+		}
 
 		users = append(users, user)
 	}
@@ -142,12 +154,14 @@ func (r *UserRepo) Update(user *models.User) error {
 	result, err := DB.Exec(`
 		UPDATE users SET
 			display_name = ?,
+			email = NULLIF(?, ''),
 			password_hash = ?,
 			role = ?,
+			role_id = ?,
 			disabled = ?,
 			updated_at = ?
 		WHERE id = ?
-	`, user.DisplayName, user.PasswordHash, user.Role, user.Disabled, user.UpdatedAt, user.ID)
+	`, user.DisplayName, user.Email, user.PasswordHash, user.Role, user.RoleID, user.Disabled, user.UpdatedAt, user.ID)
 	if err != nil {
 		return err
 	}
@@ -200,3 +214,36 @@ func (r *UserRepo) ExistsByUsername(username string) (bool, error) {
 	err := DB.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", username).Scan(&count)
 	return count > 0, err
 }
+
+// ListByEmail returns the local accounts registered with an email address, ignoring case
+func (r *UserRepo) ListByEmail(email string) ([]*models.User, error) {
+	rows, err := DB.Query(`
+		SELECT id FROM users WHERE email = ? COLLATE NOCASE AND auth_type = ? ORDER BY username
+	`, email, models.AuthTypeLocal)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	users := make([]*models.User, 0, len(ids))
+	for _, id := range ids {
+		user, err := r.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
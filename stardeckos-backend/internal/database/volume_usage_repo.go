@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// VolumeUsageRepo handles volume usage sample database operations
+type VolumeUsageRepo struct {
+	db DBTX
+}
+
+// NewVolumeUsageRepo creates a new volume usage repository
+func NewVolumeUsageRepo() *VolumeUsageRepo {
+	return &VolumeUsageRepo{db: DB}
+}
+
+// InTx returns a copy of the repository that runs its statements in tx
+func (r *VolumeUsageRepo) InTx(tx *sql.Tx) *VolumeUsageRepo {
+	return &VolumeUsageRepo{db: tx}
+}
+
+// Record stores a mount's size unless it already has a sample newer than
+// minInterval, so frequent refreshes don't flood the table
+func (r *VolumeUsageRepo) Record(s *models.VolumeUsageSample, minInterval time.Duration) error {
+	if s.Timestamp.IsZero() {
+		s.Timestamp = time.Now()
+	}
+	var recent int
+	err := r.db.QueryRow(
+		"SELECT COUNT(*) FROM volume_usage_samples WHERE host_path = ? AND timestamp > ?",
+		s.HostPath, s.Timestamp.Add(-minInterval),
+	).Scan(&recent)
+	if err != nil || recent > 0 {
+		return err
+	}
+	_, err = r.db.Exec(
+		"INSERT INTO volume_usage_samples (host_path, size_bytes, timestamp) VALUES (?, ?, ?)",
+		s.HostPath, s.SizeBytes, s.Timestamp,
+	)
+	return err
+}
+
+// Since returns the samples taken after a time, oldest first, keyed by path
+func (r *VolumeUsageRepo) Since(since time.Time) (map[string][]models.VolumeUsageSample, error) {
+	rows, err := r.db.Query(`
+		SELECT host_path, size_bytes, timestamp
+		FROM volume_usage_samples
+		WHERE timestamp > ?
+		ORDER BY timestamp ASC
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := map[string][]models.VolumeUsageSample{}
+	for rows.Next() {
+		var s models.VolumeUsageSample
+		if err := rows.Scan(&s.HostPath, &s.SizeBytes, &s.Timestamp); err != nil {
+			return nil, err
+		}
+		samples[s.HostPath] = append(samples[s.HostPath], s)
+	}
+	return samples, rows.Err()
+}
+
+// DeleteOlderThan removes samples taken before cutoff and returns how many
+// were deleted
+func (r *VolumeUsageRepo) DeleteOlderThan(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM volume_usage_samples WHERE timestamp < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
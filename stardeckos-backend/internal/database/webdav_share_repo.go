@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"stardeckos-backend/internal/models"
+)
+
+// WebDAVShareRepo handles WebDAV share database operations
+type WebDAVShareRepo struct {
+	db *sql.DB
+}
+
+// NewWebDAVShareRepo creates a new WebDAV share repository
+func NewWebDAVShareRepo() *WebDAVShareRepo {
+	return &WebDAVShareRepo{db: DB}
+}
+
+const webdavShareColumns = `
+	id, name, path, description, read_only, min_role, enabled, created_at, updated_at, created_by`
+
+// Create adds a new share
+func (r *WebDAVShareRepo) Create(s *models.WebDAVShare) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO webdav_shares (
+			id, name, path, description, read_only, min_role, enabled, created_at, updated_at, created_by
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		s.ID, s.Name, s.Path, s.Description, s.ReadOnly, s.MinRole, s.Enabled,
+		s.CreatedAt, s.UpdatedAt, s.CreatedBy,
+	)
+	return err
+}
+
+// GetByID retrieves a share by ID
+func (r *WebDAVShareRepo) GetByID(id string) (*models.WebDAVShare, error) {
+	return scanWebDAVShare(r.db.QueryRow("SELECT "+webdavShareColumns+" FROM webdav_shares WHERE id = ?", id))
+}
+
+// GetByName retrieves a share by its URL name
+func (r *WebDAVShareRepo) GetByName(name string) (*models.WebDAVShare, error) {
+	return scanWebDAVShare(r.db.QueryRow("SELECT "+webdavShareColumns+" FROM webdav_shares WHERE name = ?", name))
+}
+
+// List returns all shares
+func (r *WebDAVShareRepo) List() ([]models.WebDAVShare, error) {
+	rows, err := r.db.Query("SELECT " + webdavShareColumns + " FROM webdav_shares ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []models.WebDAVShare
+	for rows.Next() {
+		s, err := scanWebDAVShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, *s)
+	}
+	return shares, rows.Err()
+}
+
+// Update updates a share's settings
+func (r *WebDAVShareRepo) Update(s *models.WebDAVShare) error {
+	s.UpdatedAt = time.Now()
+	_, err := r.db.Exec(`
+		UPDATE webdav_shares SET description = ?, read_only = ?, min_role = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`, s.Description, s.ReadOnly, s.MinRole, s.Enabled, s.UpdatedAt, s.ID)
+	return err
+}
+
+// Delete removes a share
+func (r *WebDAVShareRepo) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM webdav_shares WHERE id = ?", id)
+	return err
+}
+
+func scanWebDAVShare(row rowScanner) (*models.WebDAVShare, error) {
+	s := &models.WebDAVShare{}
+	var readOnly, enabled int
+	var description sql.NullString
+	var createdBy sql.NullInt64
+	if err := row.Scan(
+		&s.ID, &s.Name, &s.Path, &description, &readOnly, &s.MinRole, &enabled,
+		&s.CreatedAt, &s.UpdatedAt, &createdBy,
+	); err != nil {
+		return nil, err
+	}
+	s.Description = description.String
+	s.ReadOnly = readOnly == 1
+	s.Enabled = enabled == 1
+	if createdBy.Valid {
+		s.CreatedBy = &createdBy.Int64
+	}
+	return s, nil
+}
@@ -0,0 +1,127 @@
+// Package i18n holds the translations of user-facing strings the backend
+// produces: API errors, the steps of WebSocket workflows and notifications.
+// Errors a user can act on, such as validation failures, not found and
+// conflicts, are always translated; new handlers add their keys here rather
+// than returning English literals. Internal failures that wrap an underlying
+// error are still being moved over and may come back in English.
+//
+// Messages are looked up by key and may hold {name} placeholders, filled in
+// from name/value pairs. The frontend gets the same catalogs, so it can render
+// a notification's key and params in the user's language.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when a request names no supported language, and
+// for messages a catalog doesn't translate yet
+const DefaultLanguage = "en"
+
+// Language is a supported language
+type Language struct {
+	Code string `json:"code"`
+	Name string `json:"name"` // In the language itself
+}
+
+// Languages lists the supported languages
+var Languages = []Language{
+	{Code: "en", Name: "English"},
+	{Code: "es", Name: "Español"},
+	{Code: "de", Name: "Deutsch"},
+	{Code: "fr", Name: "Français"},
+}
+
+var catalogs = map[string]map[string]string{
+	"en": messagesEN,
+	"es": messagesES,
+	"de": messagesDE,
+	"fr": messagesFR,
+}
+
+// Supported reports whether a language code has a catalog
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Resolve picks the best supported language from an Accept-Language header,
+// by quality and then order. Regional variants such as de-AT match their
+// language.
+func Resolve(acceptLanguage string) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = v
+			}
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if quality > 0 && Supported(base) {
+			candidates = append(candidates, candidate{base, quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang
+}
+
+// T returns a message in a language, falling back to English and then to the
+// key itself. params are name/value pairs for the message's placeholders.
+func T(lang, key string, params ...string) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		if msg, ok = messagesEN[key]; !ok {
+			msg = key
+		}
+	}
+	return Format(msg, Params(params...))
+}
+
+// Params turns name/value pairs into a map
+func Params(pairs ...string) map[string]string {
+	if len(pairs) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		params[pairs[i]] = pairs[i+1]
+	}
+	return params
+}
+
+// Format fills a message's {name} placeholders
+func Format(msg string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// Catalog returns every message in a language, with English filling any gaps
+func Catalog(lang string) map[string]string {
+	catalog := make(map[string]string, len(messagesEN))
+	for key, msg := range messagesEN {
+		catalog[key] = msg
+	}
+	for key, msg := range catalogs[lang] {
+		catalog[key] = msg
+	}
+	return catalog
+}
@@ -0,0 +1,176 @@
+package i18n
+
+// messagesDE is the German catalog
+var messagesDE = map[string]string{
+	// API errors
+	"error.invalid_request":        "Ungültige Anfrage: {error}",
+	"error.invalid_request_body":   "ungültiger Anfragetext",
+	"error.container_not_found":    "Container nicht gefunden",
+	"error.stack_not_found":        "Stack nicht gefunden",
+	"error.template_not_found":     "Vorlage nicht gefunden",
+	"error.user_not_found":         "Benutzer nicht gefunden",
+	"error.invalid_user_id":        "ungültige Benutzer-ID",
+	"error.path_required":          "Pfad ist erforderlich",
+	"error.password_too_short":     "das Passwort muss mindestens 8 Zeichen lang sein",
+	"error.get_stack":              "Stack konnte nicht geladen werden: {error}",
+	"error.list_containers":        "Container konnten nicht aufgelistet werden: {error}",
+	"error.save_settings":          "Einstellungen konnten nicht gespeichert werden: {error}",
+	"error.language_not_supported": "Die Sprache {lang} wird nicht unterstützt",
+	"error.not_found":              "Nicht gefunden",
+	"error.login_link_expired":     "Der Anmeldelink ist abgelaufen, öffnen Sie die App erneut aus Stardeck",
+	"error.open_from_stardeck":     "Öffnen Sie diese App aus Stardeck, um sich anzumelden",
+	"error.invalid_dns_label":      "das Label muss ein DNS-Label aus Buchstaben, Ziffern und Bindestrichen sein",
+	"error.hostname_in_use":        "{hostname} wird bereits von {container} verwendet",
+	"error.no_ingress_host":        "Für den Container ist kein Ingress-Host aktiviert",
+	"error.name_length":            "der Name muss 1 bis {max} Zeichen lang sein",
+	"error.role_builtin":           "{name} ist eine integrierte Rolle",
+	"error.invalid_base_role":      "base_role muss operator oder viewer sein",
+	"error.unknown_permission":     "unbekannte Berechtigung {permission}",
+	"error.permission_viewer":      "{permission} erfordert die Basisrolle operator; Viewer haben nur Lesezugriff",
+	"error.invalid_role":           "die Rolle muss admin, operator oder viewer sein",
+	"error.invalid_role_id":        "ungültige Rollen-ID",
+	"error.role_not_found":         "Rolle nicht gefunden",
+	"error.role_exists":            "eine Rolle mit diesem Namen existiert bereits",
+	"error.admin_role_locked":      "die Rolle admin kann nicht geändert werden",
+	"error.builtin_role_delete":    "integrierte Rollen können nicht gelöscht werden",
+	"error.role_in_use":            "die Rolle ist {count} Benutzer(n) zugewiesen",
+	"error.field_range":            "{field} muss zwischen {min} und {max} liegen",
+	"error.unknown_scope":          "unbekannter Bereich {scope}",
+	"error.scope_not_held":         "Sie haben die Berechtigung {scope} nicht",
+	"error.scope_required":         "mindestens ein Bereich ist erforderlich",
+	"error.invalid_token_id":       "ungültige Token-ID",
+	"error.token_not_found":        "API-Token nicht gefunden",
+	"error.token_revoked":          "API-Token ist bereits widerrufen",
+	"error.volume_unavailable":     "Volume nicht verfügbar: {error}",
+	"error.file_not_found":         "Datei nicht gefunden",
+	"error.download_directory":     "Verzeichnisse können nicht heruntergeladen werden",
+	"error.no_file_uploaded":       "keine Datei hochgeladen",
+	"error.file_too_large":         "Datei zu groß (max. {max} MB)",
+	"error.invalid_file_name":      "ungültiger Dateiname",
+	"error.symlink_exists":         "ein symbolischer Link mit diesem Namen existiert bereits",
+	"error.create_file":            "Datei kann nicht erstellt werden: {error}",
+	"error.delete_volume_root":     "das Volume selbst kann hier nicht gelöscht werden",
+	"error.client_not_found":       "Client nicht gefunden",
+	"error.secret_required":        "Secret ist erforderlich",
+
+	// Container deploy workflow (WebSocket)
+	"deploy.invalid_config":     "Ungültige Konfiguration: {error}",
+	"deploy.validating":         "Konfiguration wird geprüft...",
+	"deploy.no_image":           "Kein Image angegeben",
+	"deploy.name_exists":        "Ein Container namens '{name}' existiert bereits",
+	"deploy.quota_check_failed": "Kontingent konnte nicht geprüft werden: {error}",
+	"deploy.quota_exceeded":     "Kontingent überschritten",
+	"deploy.invalid_security":   "Ungültige Sicherheitsoptionen",
+	"deploy.validated":          "Konfiguration geprüft",
+	"deploy.checking_image":     "Image wird gesucht...",
+	"deploy.image_local":        "Image lokal gefunden",
+	"deploy.pulling":            "Image wird aus der Registry geladen...",
+	"deploy.pull_failed":        "Image konnte nicht geladen werden: {error}",
+	"deploy.pulled":             "Image erfolgreich geladen",
+	"deploy.creating_volumes":   "Volume-Verzeichnisse werden angelegt...",
+	"deploy.volume_failed":      "Verzeichnis '{path}' konnte nicht angelegt werden: {error}",
+	"deploy.volumes_ready":      "Volume-Verzeichnisse bereit",
+	"deploy.creating":           "Container wird erstellt...",
+	"deploy.create_failed":      "Container konnte nicht erstellt werden: {error}",
+	"deploy.created":            "Container erstellt",
+	"deploy.egress_failed":      "Richtlinie für ausgehenden Verkehr konnte nicht gespeichert werden: {error}",
+	"deploy.starting":           "Container wird gestartet...",
+	"deploy.start_failed":       "Container konnte nicht gestartet werden: {error}",
+	"deploy.started":            "Container gestartet",
+	"deploy.complete":           "Container erfolgreich bereitgestellt!",
+
+	// Container update workflow (WebSocket)
+	"update.reading_config":     "Container-Konfiguration wird gelesen...",
+	"update.read_config_failed": "Container-Konfiguration konnte nicht gelesen werden: {error}",
+	"update.config_read":        "Konfiguration erfolgreich gelesen",
+	"update.backing_up":         "Sicherung der Bind-Mounts wird erstellt...",
+	"update.backup_dir_failed":  "Sicherungsverzeichnis konnte nicht angelegt werden: {error}",
+	"update.backup_failed":      "Sicherung fehlgeschlagen: {error}",
+	"update.backup_created":     "Sicherung erstellt: {id} ({size} MB)",
+	"update.no_backup":          "Keine Bind-Mounts zu sichern, wird übersprungen...",
+	"update.pulling":            "Neues Image wird geladen: {image}",
+	"update.pull_failed":        "Image konnte nicht geladen werden: {error}",
+	"update.pulled":             "Image erfolgreich geladen",
+	"update.stopping":           "Aktueller Container wird gestoppt...",
+	"update.stopped_already":    "Container gestoppt (oder war bereits gestoppt)",
+	"update.stopped":            "Container gestoppt",
+	"update.renaming":           "Alter Container wird umbenannt in: {name}",
+	"update.rename_failed":      "Container konnte nicht umbenannt werden: {error}",
+	"update.renamed":            "Alter Container umbenannt",
+	"update.creating":           "Neuer Container mit aktualisiertem Image wird erstellt...",
+	"update.create_failed":      "Neuer Container konnte nicht erstellt werden, wird zurückgesetzt...",
+	"update.rolled_back":        "Zurücksetzen abgeschlossen. Ursprünglicher Container wiederhergestellt.",
+	"update.created":            "Neuer Container erstellt",
+	"update.starting":           "Neuer Container wird gestartet...",
+	"update.start_failed":       "Neuer Container konnte nicht gestartet werden, wird zurückgesetzt...",
+	"update.started":            "Neuer Container gestartet",
+	"update.removing_old":       "Sicherung des alten Containers wird entfernt...",
+	"update.remove_old_failed":  "Warnung: Alter Container konnte nicht entfernt werden: {error}",
+	"update.old_removed":        "Alter Container entfernt",
+	"update.old_kept":           "Alter Container behalten als: {name}",
+	"update.complete":           "Container erfolgreich aktualisiert!",
+
+	// Notifications
+	"notification.login_failed.title":           "Fehlgeschlagene Anmeldung",
+	"notification.login_failed.message":         "Fehlgeschlagene Anmeldung für {username} von {ip}",
+	"notification.login_detected.title":         "Neue Anmeldung",
+	"notification.login_detected.message":       "Angemeldet von {ip}",
+	"notification.approval_requested.title":     "Freigabe erforderlich",
+	"notification.approval_requested.message":   "{username} hat {action} für {path} angefordert",
+	"notification.approval_approved.title":      "Anfrage genehmigt",
+	"notification.approval_approved.message":    "{username} hat {action} für {path} genehmigt",
+	"notification.approval_rejected.title":      "Anfrage abgelehnt",
+	"notification.approval_rejected.message":    "{username} hat {action} für {path} abgelehnt",
+	"notification.container_oom_host.title":     "{container} hat keinen Speicher mehr",
+	"notification.container_oom_host.message":   "Vom Kernel beendet, weil der Arbeitsspeicher des Hosts erschöpft war",
+	"notification.container_oom_limit.title":    "{container} hat keinen Speicher mehr",
+	"notification.container_oom_limit.message":  "Beendet, weil das Speicherlimit von {limit} überschritten wurde",
+	"notification.container_unhealthy.title":    "{container} ist nicht gesund",
+	"notification.container_unhealthy.message":  "Seine Zustandsprüfung schlägt fehl",
+	"notification.unhealthy_restarted.title":    "{container} ist nicht gesund",
+	"notification.unhealthy_restarted.message":  "Seine Zustandsprüfung schlägt fehl, daher hat Stardeck ihn neu gestartet",
+	"notification.unhealthy_failed.title":       "{container} ist nicht gesund",
+	"notification.unhealthy_failed.message":     "Seine Zustandsprüfung schlägt fehl und der Neustart ist fehlgeschlagen: {error}",
+	"notification.unhealthy_limit.title":        "{container} ist nicht gesund",
+	"notification.unhealthy_limit.message":      "Seine Zustandsprüfung schlägt nach {count} Neustarts in der letzten Stunde weiter fehl; er läuft unverändert weiter",
+	"notification.snapshot_failed.title":        "Snapshot fehlgeschlagen",
+	"notification.snapshot_failed.message":      "Vor {operation} wurde kein Snapshot erstellt: {error}",
+	"notification.data_pool_over_quota.title":   "Datenpool {pool} hat sein Kontingent überschritten",
+	"notification.data_pool_over_quota.message": "{path} belegt {used} von {quota} Bytes",
+	"notification.lan_device_new.title":         "Neues Gerät im Netzwerk",
+	"notification.lan_device_new.message":       "{device} ist an {interface} mit der MAC {mac} aufgetaucht",
+	"notification.prepull_finished.title":       "Vorabladen der Images abgeschlossen",
+	"notification.prepull_finished.message":     "{pulled} von {total} Images geladen, {failed} fehlgeschlagen",
+	"notification.game_server_idle.title":       "Spieleserver gestoppt",
+	"notification.game_server_idle.message":     "{container} wurde nach {minutes} Minuten ohne Spieler gestoppt",
+	"notification.exec_task_finished.title":     "Aufgabe {task} abgeschlossen",
+	"notification.exec_task_finished.message":   "Status {status}, Exit-Code {exit_code}",
+	"notification.digest_daily.title":           "Tägliche Zusammenfassung",
+	"notification.digest_daily.message":         "Deine tägliche Zusammenfassung von {host} ist bereit",
+	"notification.digest_weekly.title":          "Wöchentliche Zusammenfassung",
+	"notification.digest_weekly.message":        "Deine wöchentliche Zusammenfassung von {host} ist bereit",
+	"notification.log_rule_fired.title":         "Log-Regel {rule} hat bei {container} ausgelöst",
+	"notification.log_rule_fired.message":       "{line}",
+	"notification.firewall_reverted.title":      "Firewall-Änderung zurückgenommen",
+	"notification.firewall_reverted.message":    "Die Änderung {action} {kind} von {user} wurde nicht bestätigt und zurückgenommen",
+	"notification.firewall_revert_fail.title":   "Zurücknehmen der Firewall-Änderung fehlgeschlagen",
+	"notification.firewall_revert_fail.message": "Die unbestätigte Änderung {action} {kind} von {user} konnte nicht zurückgenommen werden: {error}",
+	"notification.oom_guard_kill.title":         "Wenig Speicher: Prozess beendet",
+	"notification.oom_guard_kill.message":       "{signal} an {process} (PID {pid}, {rss}) gesendet, {available} % Speicher verfügbar",
+	"notification.cloud_mount_down.title":       "Cloud-Einhängung {mount} ist {status}",
+	"notification.cloud_mount_down.message":     "{remote}:{remote_path} unter {path}: {error}",
+	"notification.cert_expiring.title":          "Zertifikat für {monitor} läuft in {days} Tagen ab",
+	"notification.cert_expiring.message":        "{host}:{port} liefert ein Zertifikat für {subject}, ausgestellt von {issuer}, gültig bis {not_after}",
+	"notification.cert_expired.title":           "Zertifikat für {monitor} ist abgelaufen",
+	"notification.cert_expired.message":         "{host}:{port} liefert ein Zertifikat für {subject}, ausgestellt von {issuer}, gültig bis {not_after}",
+	"notification.image_update.title":           "Update für {container} verfügbar",
+	"notification.image_update.message":         "Ein neues Image wurde veröffentlicht. Aktualisiere es auf der Seite des Containers.",
+	"notification.image_update_window.title":    "Update für {container} verfügbar",
+	"notification.image_update_window.message":  "Ein neues Image wurde veröffentlicht. Es wird im nächsten Wartungsfenster aktualisiert.",
+	"notification.image_update_at.title":        "Update für {container} verfügbar",
+	"notification.image_update_at.message":      "Ein neues Image wurde veröffentlicht. Es wird im Wartungsfenster am {window} aktualisiert.",
+	"notification.auto_update_failed.title":     "Automatisches Update von {container} fehlgeschlagen",
+	"notification.auto_update_failed.message":   "{error}",
+	"notification.client_secret_age.title":      "Client-Secret für {app_name} ist {age_days} Tage alt",
+	"notification.client_secret_age.message":    "Das Secret von {app_name} hat das Höchstalter von {max_age_days} Tagen überschritten; erneuere es auf der Seite der Alliance-Clients",
+}
@@ -0,0 +1,176 @@
+package i18n
+
+// messagesEN is the English catalog, and the fallback for every other language
+var messagesEN = map[string]string{
+	// API errors
+	"error.invalid_request":        "Invalid request: {error}",
+	"error.invalid_request_body":   "invalid request body",
+	"error.container_not_found":    "Container not found",
+	"error.stack_not_found":        "Stack not found",
+	"error.template_not_found":     "Template not found",
+	"error.user_not_found":         "user not found",
+	"error.invalid_user_id":        "invalid user ID",
+	"error.path_required":          "path is required",
+	"error.password_too_short":     "password must be at least 8 characters",
+	"error.get_stack":              "Failed to get stack: {error}",
+	"error.list_containers":        "Failed to list containers: {error}",
+	"error.save_settings":          "Failed to save settings: {error}",
+	"error.language_not_supported": "Language {lang} is not supported",
+	"error.not_found":              "Not found",
+	"error.login_link_expired":     "Login link has expired, open the app from Stardeck again",
+	"error.open_from_stardeck":     "Open this app from Stardeck to sign in",
+	"error.invalid_dns_label":      "label must be a DNS label of letters, digits and hyphens",
+	"error.hostname_in_use":        "{hostname} is already used by {container}",
+	"error.no_ingress_host":        "Container has no ingress host turned on",
+	"error.name_length":            "name must be 1 to {max} characters",
+	"error.role_builtin":           "{name} is a built-in role",
+	"error.invalid_base_role":      "base_role must be operator or viewer",
+	"error.unknown_permission":     "unknown permission {permission}",
+	"error.permission_viewer":      "{permission} needs the operator base role; viewers are read-only",
+	"error.invalid_role":           "role must be admin, operator or viewer",
+	"error.invalid_role_id":        "invalid role ID",
+	"error.role_not_found":         "role not found",
+	"error.role_exists":            "a role with this name already exists",
+	"error.admin_role_locked":      "the admin role can't be changed",
+	"error.builtin_role_delete":    "built-in roles can't be deleted",
+	"error.role_in_use":            "the role is assigned to {count} user(s)",
+	"error.field_range":            "{field} must be between {min} and {max}",
+	"error.unknown_scope":          "unknown scope {scope}",
+	"error.scope_not_held":         "you don't have the {scope} permission",
+	"error.scope_required":         "at least one scope is required",
+	"error.invalid_token_id":       "invalid token ID",
+	"error.token_not_found":        "API token not found",
+	"error.token_revoked":          "API token is already revoked",
+	"error.volume_unavailable":     "Volume not available: {error}",
+	"error.file_not_found":         "file not found",
+	"error.download_directory":     "cannot download directory",
+	"error.no_file_uploaded":       "no file uploaded",
+	"error.file_too_large":         "file too large (max {max} MB)",
+	"error.invalid_file_name":      "invalid file name",
+	"error.symlink_exists":         "a symlink with that name already exists",
+	"error.create_file":            "cannot create file: {error}",
+	"error.delete_volume_root":     "the volume itself can't be deleted here",
+	"error.client_not_found":       "Client not found",
+	"error.secret_required":        "secret is required",
+
+	// Container deploy workflow (WebSocket)
+	"deploy.invalid_config":     "Invalid configuration: {error}",
+	"deploy.validating":         "Validating configuration...",
+	"deploy.no_image":           "No image specified",
+	"deploy.name_exists":        "Container name '{name}' already exists",
+	"deploy.quota_check_failed": "Failed to check quota: {error}",
+	"deploy.quota_exceeded":     "Quota exceeded",
+	"deploy.invalid_security":   "Invalid security options",
+	"deploy.validated":          "Configuration validated",
+	"deploy.checking_image":     "Checking for image...",
+	"deploy.image_local":        "Image found locally",
+	"deploy.pulling":            "Pulling image from registry...",
+	"deploy.pull_failed":        "Failed to pull image: {error}",
+	"deploy.pulled":             "Image pulled successfully",
+	"deploy.creating_volumes":   "Creating volume directories...",
+	"deploy.volume_failed":      "Failed to create directory '{path}': {error}",
+	"deploy.volumes_ready":      "Volume directories ready",
+	"deploy.creating":           "Creating container...",
+	"deploy.create_failed":      "Failed to create container: {error}",
+	"deploy.created":            "Container created",
+	"deploy.egress_failed":      "Failed to save outbound network policy: {error}",
+	"deploy.starting":           "Starting container...",
+	"deploy.start_failed":       "Failed to start container: {error}",
+	"deploy.started":            "Container started",
+	"deploy.complete":           "Container deployed successfully!",
+
+	// Container update workflow (WebSocket)
+	"update.reading_config":     "Reading container configuration...",
+	"update.read_config_failed": "Failed to read container config: {error}",
+	"update.config_read":        "Configuration read successfully",
+	"update.backing_up":         "Creating backup of bind mounts...",
+	"update.backup_dir_failed":  "Failed to create backup directory: {error}",
+	"update.backup_failed":      "Backup failed: {error}",
+	"update.backup_created":     "Backup created: {id} ({size} MB)",
+	"update.no_backup":          "No bind mounts to backup, skipping...",
+	"update.pulling":            "Pulling new image: {image}",
+	"update.pull_failed":        "Failed to pull image: {error}",
+	"update.pulled":             "Image pulled successfully",
+	"update.stopping":           "Stopping current container...",
+	"update.stopped_already":    "Container stopped (or was already stopped)",
+	"update.stopped":            "Container stopped",
+	"update.renaming":           "Renaming old container to: {name}",
+	"update.rename_failed":      "Failed to rename container: {error}",
+	"update.renamed":            "Old container renamed",
+	"update.creating":           "Creating new container with updated image...",
+	"update.create_failed":      "Failed to create new container, rolling back...",
+	"update.rolled_back":        "Rollback complete. Original container restored.",
+	"update.created":            "New container created",
+	"update.starting":           "Starting new container...",
+	"update.start_failed":       "Failed to start new container, rolling back...",
+	"update.started":            "New container started",
+	"update.removing_old":       "Removing old container backup...",
+	"update.remove_old_failed":  "Warning: Failed to remove old container: {error}",
+	"update.old_removed":        "Old container removed",
+	"update.old_kept":           "Old container kept as: {name}",
+	"update.complete":           "Container updated successfully!",
+
+	// Notifications
+	"notification.login_failed.title":           "Failed sign-in",
+	"notification.login_failed.message":         "Failed sign-in for {username} from {ip}",
+	"notification.login_detected.title":         "New sign-in",
+	"notification.login_detected.message":       "Signed in from {ip}",
+	"notification.approval_requested.title":     "Approval required",
+	"notification.approval_requested.message":   "{username} requested {action} on {path}",
+	"notification.approval_approved.title":      "Request approved",
+	"notification.approval_approved.message":    "{username} approved {action} on {path}",
+	"notification.approval_rejected.title":      "Request rejected",
+	"notification.approval_rejected.message":    "{username} rejected {action} on {path}",
+	"notification.container_oom_host.title":     "{container} ran out of memory",
+	"notification.container_oom_host.message":   "Killed by the kernel after running out of host memory",
+	"notification.container_oom_limit.title":    "{container} ran out of memory",
+	"notification.container_oom_limit.message":  "Killed for exceeding its {limit} memory limit",
+	"notification.container_unhealthy.title":    "{container} is unhealthy",
+	"notification.container_unhealthy.message":  "Its health check is failing",
+	"notification.unhealthy_restarted.title":    "{container} is unhealthy",
+	"notification.unhealthy_restarted.message":  "Its health check is failing, so Stardeck restarted it",
+	"notification.unhealthy_failed.title":       "{container} is unhealthy",
+	"notification.unhealthy_failed.message":     "Its health check is failing and the restart failed: {error}",
+	"notification.unhealthy_limit.title":        "{container} is unhealthy",
+	"notification.unhealthy_limit.message":      "Its health check is still failing after {count} restarts in the last hour; it was left running",
+	"notification.snapshot_failed.title":        "Snapshot failed",
+	"notification.snapshot_failed.message":      "No snapshot was taken before {operation}: {error}",
+	"notification.data_pool_over_quota.title":   "Data pool {pool} is over quota",
+	"notification.data_pool_over_quota.message": "{path} uses {used} of {quota} bytes",
+	"notification.lan_device_new.title":         "New device on the network",
+	"notification.lan_device_new.message":       "{device} appeared on {interface} with MAC {mac}",
+	"notification.prepull_finished.title":       "Image pre-pull finished",
+	"notification.prepull_finished.message":     "{pulled} of {total} images pulled, {failed} failed",
+	"notification.game_server_idle.title":       "Game server stopped",
+	"notification.game_server_idle.message":     "{container} was stopped after {minutes} minutes without players",
+	"notification.exec_task_finished.title":     "Task {task} finished",
+	"notification.exec_task_finished.message":   "Status {status}, exit code {exit_code}",
+	"notification.digest_daily.title":           "Daily digest",
+	"notification.digest_daily.message":         "Your daily summary of {host} is ready",
+	"notification.digest_weekly.title":          "Weekly digest",
+	"notification.digest_weekly.message":        "Your weekly summary of {host} is ready",
+	"notification.log_rule_fired.title":         "Log rule {rule} fired on {container}",
+	"notification.log_rule_fired.message":       "{line}",
+	"notification.firewall_reverted.title":      "Firewall change reverted",
+	"notification.firewall_reverted.message":    "The {action} {kind} change by {user} was not confirmed and has been rolled back",
+	"notification.firewall_revert_fail.title":   "Firewall change revert failed",
+	"notification.firewall_revert_fail.message": "The unconfirmed {action} {kind} change by {user} could not be rolled back: {error}",
+	"notification.oom_guard_kill.title":         "Low memory: process terminated",
+	"notification.oom_guard_kill.message":       "Sent {signal} to {process} (pid {pid}, {rss}) with {available}% memory available",
+	"notification.cloud_mount_down.title":       "Cloud mount {mount} is {status}",
+	"notification.cloud_mount_down.message":     "{remote}:{remote_path} at {path}: {error}",
+	"notification.cert_expiring.title":          "Certificate for {monitor} expires in {days} days",
+	"notification.cert_expiring.message":        "{host}:{port} serves a certificate for {subject} issued by {issuer}, valid until {not_after}",
+	"notification.cert_expired.title":           "Certificate for {monitor} has expired",
+	"notification.cert_expired.message":         "{host}:{port} serves a certificate for {subject} issued by {issuer}, valid until {not_after}",
+	"notification.image_update.title":           "Update available for {container}",
+	"notification.image_update.message":         "A new image was published. Update it from the container's page.",
+	"notification.image_update_window.title":    "Update available for {container}",
+	"notification.image_update_window.message":  "A new image was published. It will be updated in the next maintenance window.",
+	"notification.image_update_at.title":        "Update available for {container}",
+	"notification.image_update_at.message":      "A new image was published. It will be updated in the maintenance window at {window}.",
+	"notification.auto_update_failed.title":     "Automatic update of {container} failed",
+	"notification.auto_update_failed.message":   "{error}",
+	"notification.client_secret_age.title":      "Client secret for {app_name} is {age_days} days old",
+	"notification.client_secret_age.message":    "The secret of {app_name} is past the {max_age_days} day maximum; rotate it from the Alliance clients page",
+}
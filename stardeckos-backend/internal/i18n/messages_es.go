@@ -0,0 +1,176 @@
+package i18n
+
+// messagesES is the Spanish catalog
+var messagesES = map[string]string{
+	// API errors
+	"error.invalid_request":        "Solicitud no válida: {error}",
+	"error.invalid_request_body":   "cuerpo de la solicitud no válido",
+	"error.container_not_found":    "Contenedor no encontrado",
+	"error.stack_not_found":        "Stack no encontrado",
+	"error.template_not_found":     "Plantilla no encontrada",
+	"error.user_not_found":         "usuario no encontrado",
+	"error.invalid_user_id":        "ID de usuario no válido",
+	"error.path_required":          "la ruta es obligatoria",
+	"error.password_too_short":     "la contraseña debe tener al menos 8 caracteres",
+	"error.get_stack":              "No se pudo obtener el stack: {error}",
+	"error.list_containers":        "No se pudieron listar los contenedores: {error}",
+	"error.save_settings":          "No se pudo guardar la configuración: {error}",
+	"error.language_not_supported": "El idioma {lang} no está disponible",
+	"error.not_found":              "No encontrado",
+	"error.login_link_expired":     "El enlace de inicio de sesión ha caducado, abra la aplicación desde Stardeck de nuevo",
+	"error.open_from_stardeck":     "Abra esta aplicación desde Stardeck para iniciar sesión",
+	"error.invalid_dns_label":      "la etiqueta debe ser una etiqueta DNS de letras, dígitos y guiones",
+	"error.hostname_in_use":        "{hostname} ya lo usa {container}",
+	"error.no_ingress_host":        "El contenedor no tiene ningún host de entrada activado",
+	"error.name_length":            "el nombre debe tener entre 1 y {max} caracteres",
+	"error.role_builtin":           "{name} es un rol predefinido",
+	"error.invalid_base_role":      "base_role debe ser operator o viewer",
+	"error.unknown_permission":     "permiso desconocido {permission}",
+	"error.permission_viewer":      "{permission} requiere el rol base operator; los viewers son de solo lectura",
+	"error.invalid_role":           "el rol debe ser admin, operator o viewer",
+	"error.invalid_role_id":        "ID de rol no válido",
+	"error.role_not_found":         "rol no encontrado",
+	"error.role_exists":            "ya existe un rol con este nombre",
+	"error.admin_role_locked":      "el rol admin no se puede modificar",
+	"error.builtin_role_delete":    "los roles predefinidos no se pueden eliminar",
+	"error.role_in_use":            "el rol está asignado a {count} usuario(s)",
+	"error.field_range":            "{field} debe estar entre {min} y {max}",
+	"error.unknown_scope":          "ámbito desconocido {scope}",
+	"error.scope_not_held":         "no tiene el permiso {scope}",
+	"error.scope_required":         "se requiere al menos un ámbito",
+	"error.invalid_token_id":       "ID de token no válido",
+	"error.token_not_found":        "token de API no encontrado",
+	"error.token_revoked":          "el token de API ya está revocado",
+	"error.volume_unavailable":     "Volumen no disponible: {error}",
+	"error.file_not_found":         "archivo no encontrado",
+	"error.download_directory":     "no se puede descargar un directorio",
+	"error.no_file_uploaded":       "no se ha subido ningún archivo",
+	"error.file_too_large":         "archivo demasiado grande (máx. {max} MB)",
+	"error.invalid_file_name":      "nombre de archivo no válido",
+	"error.symlink_exists":         "ya existe un enlace simbólico con ese nombre",
+	"error.create_file":            "no se puede crear el archivo: {error}",
+	"error.delete_volume_root":     "el volumen en sí no se puede eliminar aquí",
+	"error.client_not_found":       "Cliente no encontrado",
+	"error.secret_required":        "el secreto es obligatorio",
+
+	// Container deploy workflow (WebSocket)
+	"deploy.invalid_config":     "Configuración no válida: {error}",
+	"deploy.validating":         "Validando la configuración...",
+	"deploy.no_image":           "No se indicó ninguna imagen",
+	"deploy.name_exists":        "Ya existe un contenedor llamado '{name}'",
+	"deploy.quota_check_failed": "No se pudo comprobar la cuota: {error}",
+	"deploy.quota_exceeded":     "Cuota superada",
+	"deploy.invalid_security":   "Opciones de seguridad no válidas",
+	"deploy.validated":          "Configuración validada",
+	"deploy.checking_image":     "Buscando la imagen...",
+	"deploy.image_local":        "Imagen encontrada localmente",
+	"deploy.pulling":            "Descargando la imagen del registro...",
+	"deploy.pull_failed":        "No se pudo descargar la imagen: {error}",
+	"deploy.pulled":             "Imagen descargada correctamente",
+	"deploy.creating_volumes":   "Creando los directorios de volúmenes...",
+	"deploy.volume_failed":      "No se pudo crear el directorio '{path}': {error}",
+	"deploy.volumes_ready":      "Directorios de volúmenes listos",
+	"deploy.creating":           "Creando el contenedor...",
+	"deploy.create_failed":      "No se pudo crear el contenedor: {error}",
+	"deploy.created":            "Contenedor creado",
+	"deploy.egress_failed":      "No se pudo guardar la política de red saliente: {error}",
+	"deploy.starting":           "Iniciando el contenedor...",
+	"deploy.start_failed":       "No se pudo iniciar el contenedor: {error}",
+	"deploy.started":            "Contenedor iniciado",
+	"deploy.complete":           "¡Contenedor desplegado correctamente!",
+
+	// Container update workflow (WebSocket)
+	"update.reading_config":     "Leyendo la configuración del contenedor...",
+	"update.read_config_failed": "No se pudo leer la configuración del contenedor: {error}",
+	"update.config_read":        "Configuración leída correctamente",
+	"update.backing_up":         "Creando copia de seguridad de los bind mounts...",
+	"update.backup_dir_failed":  "No se pudo crear el directorio de copias: {error}",
+	"update.backup_failed":      "La copia de seguridad falló: {error}",
+	"update.backup_created":     "Copia de seguridad creada: {id} ({size} MB)",
+	"update.no_backup":          "No hay bind mounts que copiar, se omite...",
+	"update.pulling":            "Descargando la nueva imagen: {image}",
+	"update.pull_failed":        "No se pudo descargar la imagen: {error}",
+	"update.pulled":             "Imagen descargada correctamente",
+	"update.stopping":           "Deteniendo el contenedor actual...",
+	"update.stopped_already":    "Contenedor detenido (o ya estaba detenido)",
+	"update.stopped":            "Contenedor detenido",
+	"update.renaming":           "Renombrando el contenedor anterior a: {name}",
+	"update.rename_failed":      "No se pudo renombrar el contenedor: {error}",
+	"update.renamed":            "Contenedor anterior renombrado",
+	"update.creating":           "Creando el nuevo contenedor con la imagen actualizada...",
+	"update.create_failed":      "No se pudo crear el nuevo contenedor, revirtiendo...",
+	"update.rolled_back":        "Reversión completada. Se restauró el contenedor original.",
+	"update.created":            "Nuevo contenedor creado",
+	"update.starting":           "Iniciando el nuevo contenedor...",
+	"update.start_failed":       "No se pudo iniciar el nuevo contenedor, revirtiendo...",
+	"update.started":            "Nuevo contenedor iniciado",
+	"update.removing_old":       "Eliminando la copia del contenedor anterior...",
+	"update.remove_old_failed":  "Aviso: no se pudo eliminar el contenedor anterior: {error}",
+	"update.old_removed":        "Contenedor anterior eliminado",
+	"update.old_kept":           "Contenedor anterior conservado como: {name}",
+	"update.complete":           "¡Contenedor actualizado correctamente!",
+
+	// Notifications
+	"notification.login_failed.title":           "Inicio de sesión fallido",
+	"notification.login_failed.message":         "Inicio de sesión fallido de {username} desde {ip}",
+	"notification.login_detected.title":         "Nuevo inicio de sesión",
+	"notification.login_detected.message":       "Sesión iniciada desde {ip}",
+	"notification.approval_requested.title":     "Se requiere aprobación",
+	"notification.approval_requested.message":   "{username} solicitó {action} en {path}",
+	"notification.approval_approved.title":      "Solicitud aprobada",
+	"notification.approval_approved.message":    "{username} aprobó {action} en {path}",
+	"notification.approval_rejected.title":      "Solicitud rechazada",
+	"notification.approval_rejected.message":    "{username} rechazó {action} en {path}",
+	"notification.container_oom_host.title":     "{container} se quedó sin memoria",
+	"notification.container_oom_host.message":   "El kernel lo detuvo al agotarse la memoria del host",
+	"notification.container_oom_limit.title":    "{container} se quedó sin memoria",
+	"notification.container_oom_limit.message":  "Detenido por superar su límite de memoria de {limit}",
+	"notification.container_unhealthy.title":    "{container} no está sano",
+	"notification.container_unhealthy.message":  "Su comprobación de estado está fallando",
+	"notification.unhealthy_restarted.title":    "{container} no está sano",
+	"notification.unhealthy_restarted.message":  "Su comprobación de estado está fallando, así que Stardeck lo reinició",
+	"notification.unhealthy_failed.title":       "{container} no está sano",
+	"notification.unhealthy_failed.message":     "Su comprobación de estado está fallando y el reinicio falló: {error}",
+	"notification.unhealthy_limit.title":        "{container} no está sano",
+	"notification.unhealthy_limit.message":      "Su comprobación de estado sigue fallando tras {count} reinicios en la última hora; se dejó en ejecución",
+	"notification.snapshot_failed.title":        "La instantánea falló",
+	"notification.snapshot_failed.message":      "No se tomó ninguna instantánea antes de {operation}: {error}",
+	"notification.data_pool_over_quota.title":   "El pool de datos {pool} superó su cuota",
+	"notification.data_pool_over_quota.message": "{path} usa {used} de {quota} bytes",
+	"notification.lan_device_new.title":         "Nuevo dispositivo en la red",
+	"notification.lan_device_new.message":       "{device} apareció en {interface} con la MAC {mac}",
+	"notification.prepull_finished.title":       "Descarga previa de imágenes terminada",
+	"notification.prepull_finished.message":     "{pulled} de {total} imágenes descargadas, {failed} fallidas",
+	"notification.game_server_idle.title":       "Servidor de juegos detenido",
+	"notification.game_server_idle.message":     "{container} se detuvo tras {minutes} minutos sin jugadores",
+	"notification.exec_task_finished.title":     "La tarea {task} terminó",
+	"notification.exec_task_finished.message":   "Estado {status}, código de salida {exit_code}",
+	"notification.digest_daily.title":           "Resumen diario",
+	"notification.digest_daily.message":         "Tu resumen diario de {host} está listo",
+	"notification.digest_weekly.title":          "Resumen semanal",
+	"notification.digest_weekly.message":        "Tu resumen semanal de {host} está listo",
+	"notification.log_rule_fired.title":         "La regla de registro {rule} se activó en {container}",
+	"notification.log_rule_fired.message":       "{line}",
+	"notification.firewall_reverted.title":      "Cambio del cortafuegos revertido",
+	"notification.firewall_reverted.message":    "El cambio {action} {kind} de {user} no se confirmó y se ha revertido",
+	"notification.firewall_revert_fail.title":   "No se pudo revertir el cambio del cortafuegos",
+	"notification.firewall_revert_fail.message": "El cambio {action} {kind} sin confirmar de {user} no se pudo revertir: {error}",
+	"notification.oom_guard_kill.title":         "Poca memoria: proceso terminado",
+	"notification.oom_guard_kill.message":       "Se envió {signal} a {process} (pid {pid}, {rss}) con un {available} % de memoria disponible",
+	"notification.cloud_mount_down.title":       "El montaje en la nube {mount} está {status}",
+	"notification.cloud_mount_down.message":     "{remote}:{remote_path} en {path}: {error}",
+	"notification.cert_expiring.title":          "El certificado de {monitor} caduca en {days} días",
+	"notification.cert_expiring.message":        "{host}:{port} sirve un certificado para {subject} emitido por {issuer}, válido hasta {not_after}",
+	"notification.cert_expired.title":           "El certificado de {monitor} ha caducado",
+	"notification.cert_expired.message":         "{host}:{port} sirve un certificado para {subject} emitido por {issuer}, válido hasta {not_after}",
+	"notification.image_update.title":           "Actualización disponible para {container}",
+	"notification.image_update.message":         "Se publicó una imagen nueva. Actualízala desde la página del contenedor.",
+	"notification.image_update_window.title":    "Actualización disponible para {container}",
+	"notification.image_update_window.message":  "Se publicó una imagen nueva. Se actualizará en la próxima ventana de mantenimiento.",
+	"notification.image_update_at.title":        "Actualización disponible para {container}",
+	"notification.image_update_at.message":      "Se publicó una imagen nueva. Se actualizará en la ventana de mantenimiento del {window}.",
+	"notification.auto_update_failed.title":     "Falló la actualización automática de {container}",
+	"notification.auto_update_failed.message":   "{error}",
+	"notification.client_secret_age.title":      "El secreto de cliente de {app_name} tiene {age_days} días",
+	"notification.client_secret_age.message":    "El secreto de {app_name} supera el máximo de {max_age_days} días; rótalo desde la página de clientes de Alliance",
+}
@@ -0,0 +1,176 @@
+package i18n
+
+// messagesFR is the French catalog
+var messagesFR = map[string]string{
+	// API errors
+	"error.invalid_request":        "Requête invalide : {error}",
+	"error.invalid_request_body":   "corps de requête invalide",
+	"error.container_not_found":    "Conteneur introuvable",
+	"error.stack_not_found":        "Stack introuvable",
+	"error.template_not_found":     "Modèle introuvable",
+	"error.user_not_found":         "utilisateur introuvable",
+	"error.invalid_user_id":        "ID utilisateur invalide",
+	"error.path_required":          "le chemin est obligatoire",
+	"error.password_too_short":     "le mot de passe doit contenir au moins 8 caractères",
+	"error.get_stack":              "Impossible de récupérer le stack : {error}",
+	"error.list_containers":        "Impossible de lister les conteneurs : {error}",
+	"error.save_settings":          "Impossible d'enregistrer les paramètres : {error}",
+	"error.language_not_supported": "La langue {lang} n'est pas prise en charge",
+	"error.not_found":              "Introuvable",
+	"error.login_link_expired":     "Le lien de connexion a expiré, rouvrez l'application depuis Stardeck",
+	"error.open_from_stardeck":     "Ouvrez cette application depuis Stardeck pour vous connecter",
+	"error.invalid_dns_label":      "le libellé doit être un nom DNS composé de lettres, de chiffres et de tirets",
+	"error.hostname_in_use":        "{hostname} est déjà utilisé par {container}",
+	"error.no_ingress_host":        "Le conteneur n'a aucun hôte d'entrée activé",
+	"error.name_length":            "le nom doit contenir de 1 à {max} caractères",
+	"error.role_builtin":           "{name} est un rôle prédéfini",
+	"error.invalid_base_role":      "base_role doit être operator ou viewer",
+	"error.unknown_permission":     "permission inconnue {permission}",
+	"error.permission_viewer":      "{permission} nécessite le rôle de base operator ; les viewers sont en lecture seule",
+	"error.invalid_role":           "le rôle doit être admin, operator ou viewer",
+	"error.invalid_role_id":        "ID de rôle invalide",
+	"error.role_not_found":         "rôle introuvable",
+	"error.role_exists":            "un rôle portant ce nom existe déjà",
+	"error.admin_role_locked":      "le rôle admin ne peut pas être modifié",
+	"error.builtin_role_delete":    "les rôles prédéfinis ne peuvent pas être supprimés",
+	"error.role_in_use":            "le rôle est attribué à {count} utilisateur(s)",
+	"error.field_range":            "{field} doit être compris entre {min} et {max}",
+	"error.unknown_scope":          "portée inconnue {scope}",
+	"error.scope_not_held":         "vous n'avez pas la permission {scope}",
+	"error.scope_required":         "au moins une portée est requise",
+	"error.invalid_token_id":       "ID de jeton invalide",
+	"error.token_not_found":        "jeton d'API introuvable",
+	"error.token_revoked":          "le jeton d'API est déjà révoqué",
+	"error.volume_unavailable":     "Volume indisponible : {error}",
+	"error.file_not_found":         "fichier introuvable",
+	"error.download_directory":     "impossible de télécharger un répertoire",
+	"error.no_file_uploaded":       "aucun fichier envoyé",
+	"error.file_too_large":         "fichier trop volumineux (max. {max} Mo)",
+	"error.invalid_file_name":      "nom de fichier invalide",
+	"error.symlink_exists":         "un lien symbolique portant ce nom existe déjà",
+	"error.create_file":            "impossible de créer le fichier : {error}",
+	"error.delete_volume_root":     "le volume lui-même ne peut pas être supprimé ici",
+	"error.client_not_found":       "Client introuvable",
+	"error.secret_required":        "le secret est obligatoire",
+
+	// Container deploy workflow (WebSocket)
+	"deploy.invalid_config":     "Configuration invalide : {error}",
+	"deploy.validating":         "Validation de la configuration...",
+	"deploy.no_image":           "Aucune image indiquée",
+	"deploy.name_exists":        "Un conteneur nommé '{name}' existe déjà",
+	"deploy.quota_check_failed": "Impossible de vérifier le quota : {error}",
+	"deploy.quota_exceeded":     "Quota dépassé",
+	"deploy.invalid_security":   "Options de sécurité invalides",
+	"deploy.validated":          "Configuration validée",
+	"deploy.checking_image":     "Recherche de l'image...",
+	"deploy.image_local":        "Image trouvée localement",
+	"deploy.pulling":            "Téléchargement de l'image depuis le registre...",
+	"deploy.pull_failed":        "Impossible de télécharger l'image : {error}",
+	"deploy.pulled":             "Image téléchargée avec succès",
+	"deploy.creating_volumes":   "Création des répertoires de volumes...",
+	"deploy.volume_failed":      "Impossible de créer le répertoire '{path}' : {error}",
+	"deploy.volumes_ready":      "Répertoires de volumes prêts",
+	"deploy.creating":           "Création du conteneur...",
+	"deploy.create_failed":      "Impossible de créer le conteneur : {error}",
+	"deploy.created":            "Conteneur créé",
+	"deploy.egress_failed":      "Impossible d'enregistrer la politique réseau sortante : {error}",
+	"deploy.starting":           "Démarrage du conteneur...",
+	"deploy.start_failed":       "Impossible de démarrer le conteneur : {error}",
+	"deploy.started":            "Conteneur démarré",
+	"deploy.complete":           "Conteneur déployé avec succès !",
+
+	// Container update workflow (WebSocket)
+	"update.reading_config":     "Lecture de la configuration du conteneur...",
+	"update.read_config_failed": "Impossible de lire la configuration du conteneur : {error}",
+	"update.config_read":        "Configuration lue avec succès",
+	"update.backing_up":         "Sauvegarde des montages bind...",
+	"update.backup_dir_failed":  "Impossible de créer le répertoire de sauvegarde : {error}",
+	"update.backup_failed":      "Échec de la sauvegarde : {error}",
+	"update.backup_created":     "Sauvegarde créée : {id} ({size} Mo)",
+	"update.no_backup":          "Aucun montage bind à sauvegarder, étape ignorée...",
+	"update.pulling":            "Téléchargement de la nouvelle image : {image}",
+	"update.pull_failed":        "Impossible de télécharger l'image : {error}",
+	"update.pulled":             "Image téléchargée avec succès",
+	"update.stopping":           "Arrêt du conteneur actuel...",
+	"update.stopped_already":    "Conteneur arrêté (ou déjà arrêté)",
+	"update.stopped":            "Conteneur arrêté",
+	"update.renaming":           "Renommage de l'ancien conteneur en : {name}",
+	"update.rename_failed":      "Impossible de renommer le conteneur : {error}",
+	"update.renamed":            "Ancien conteneur renommé",
+	"update.creating":           "Création du nouveau conteneur avec l'image mise à jour...",
+	"update.create_failed":      "Impossible de créer le nouveau conteneur, retour arrière...",
+	"update.rolled_back":        "Retour arrière terminé. Conteneur d'origine restauré.",
+	"update.created":            "Nouveau conteneur créé",
+	"update.starting":           "Démarrage du nouveau conteneur...",
+	"update.start_failed":       "Impossible de démarrer le nouveau conteneur, retour arrière...",
+	"update.started":            "Nouveau conteneur démarré",
+	"update.removing_old":       "Suppression de la sauvegarde de l'ancien conteneur...",
+	"update.remove_old_failed":  "Attention : impossible de supprimer l'ancien conteneur : {error}",
+	"update.old_removed":        "Ancien conteneur supprimé",
+	"update.old_kept":           "Ancien conteneur conservé sous : {name}",
+	"update.complete":           "Conteneur mis à jour avec succès !",
+
+	// Notifications
+	"notification.login_failed.title":           "Échec de connexion",
+	"notification.login_failed.message":         "Échec de connexion pour {username} depuis {ip}",
+	"notification.login_detected.title":         "Nouvelle connexion",
+	"notification.login_detected.message":       "Connecté depuis {ip}",
+	"notification.approval_requested.title":     "Approbation requise",
+	"notification.approval_requested.message":   "{username} a demandé {action} sur {path}",
+	"notification.approval_approved.title":      "Demande approuvée",
+	"notification.approval_approved.message":    "{username} a approuvé {action} sur {path}",
+	"notification.approval_rejected.title":      "Demande refusée",
+	"notification.approval_rejected.message":    "{username} a refusé {action} sur {path}",
+	"notification.container_oom_host.title":     "{container} est à court de mémoire",
+	"notification.container_oom_host.message":   "Arrêté par le noyau après épuisement de la mémoire de l'hôte",
+	"notification.container_oom_limit.title":    "{container} est à court de mémoire",
+	"notification.container_oom_limit.message":  "Arrêté pour avoir dépassé sa limite de mémoire de {limit}",
+	"notification.container_unhealthy.title":    "{container} n'est pas en bonne santé",
+	"notification.container_unhealthy.message":  "Son contrôle de santé échoue",
+	"notification.unhealthy_restarted.title":    "{container} n'est pas en bonne santé",
+	"notification.unhealthy_restarted.message":  "Son contrôle de santé échoue, Stardeck l'a donc redémarré",
+	"notification.unhealthy_failed.title":       "{container} n'est pas en bonne santé",
+	"notification.unhealthy_failed.message":     "Son contrôle de santé échoue et le redémarrage a échoué : {error}",
+	"notification.unhealthy_limit.title":        "{container} n'est pas en bonne santé",
+	"notification.unhealthy_limit.message":      "Son contrôle de santé échoue toujours après {count} redémarrages dans la dernière heure ; il a été laissé en marche",
+	"notification.snapshot_failed.title":        "Échec de l'instantané",
+	"notification.snapshot_failed.message":      "Aucun instantané n'a été pris avant {operation} : {error}",
+	"notification.data_pool_over_quota.title":   "Le pool de données {pool} dépasse son quota",
+	"notification.data_pool_over_quota.message": "{path} utilise {used} octets sur {quota}",
+	"notification.lan_device_new.title":         "Nouvel appareil sur le réseau",
+	"notification.lan_device_new.message":       "{device} est apparu sur {interface} avec l'adresse MAC {mac}",
+	"notification.prepull_finished.title":       "Pré-téléchargement des images terminé",
+	"notification.prepull_finished.message":     "{pulled} images sur {total} téléchargées, {failed} en échec",
+	"notification.game_server_idle.title":       "Serveur de jeu arrêté",
+	"notification.game_server_idle.message":     "{container} a été arrêté après {minutes} minutes sans joueurs",
+	"notification.exec_task_finished.title":     "Tâche {task} terminée",
+	"notification.exec_task_finished.message":   "Statut {status}, code de sortie {exit_code}",
+	"notification.digest_daily.title":           "Résumé quotidien",
+	"notification.digest_daily.message":         "Votre résumé quotidien de {host} est prêt",
+	"notification.digest_weekly.title":          "Résumé hebdomadaire",
+	"notification.digest_weekly.message":        "Votre résumé hebdomadaire de {host} est prêt",
+	"notification.log_rule_fired.title":         "La règle de journal {rule} s'est déclenchée sur {container}",
+	"notification.log_rule_fired.message":       "{line}",
+	"notification.firewall_reverted.title":      "Modification du pare-feu annulée",
+	"notification.firewall_reverted.message":    "La modification {action} {kind} de {user} n'a pas été confirmée et a été annulée",
+	"notification.firewall_revert_fail.title":   "Échec de l'annulation de la modification du pare-feu",
+	"notification.firewall_revert_fail.message": "La modification {action} {kind} non confirmée de {user} n'a pas pu être annulée : {error}",
+	"notification.oom_guard_kill.title":         "Mémoire faible : processus arrêté",
+	"notification.oom_guard_kill.message":       "{signal} envoyé à {process} (pid {pid}, {rss}) avec {available} % de mémoire disponible",
+	"notification.cloud_mount_down.title":       "Le montage cloud {mount} est {status}",
+	"notification.cloud_mount_down.message":     "{remote}:{remote_path} sur {path} : {error}",
+	"notification.cert_expiring.title":          "Le certificat de {monitor} expire dans {days} jours",
+	"notification.cert_expiring.message":        "{host}:{port} sert un certificat pour {subject} émis par {issuer}, valable jusqu'au {not_after}",
+	"notification.cert_expired.title":           "Le certificat de {monitor} a expiré",
+	"notification.cert_expired.message":         "{host}:{port} sert un certificat pour {subject} émis par {issuer}, valable jusqu'au {not_after}",
+	"notification.image_update.title":           "Mise à jour disponible pour {container}",
+	"notification.image_update.message":         "Une nouvelle image a été publiée. Mettez-la à jour depuis la page du conteneur.",
+	"notification.image_update_window.title":    "Mise à jour disponible pour {container}",
+	"notification.image_update_window.message":  "Une nouvelle image a été publiée. Elle sera mise à jour lors de la prochaine fenêtre de maintenance.",
+	"notification.image_update_at.title":        "Mise à jour disponible pour {container}",
+	"notification.image_update_at.message":      "Une nouvelle image a été publiée. Elle sera mise à jour lors de la fenêtre de maintenance du {window}.",
+	"notification.auto_update_failed.title":     "Échec de la mise à jour automatique de {container}",
+	"notification.auto_update_failed.message":   "{error}",
+	"notification.client_secret_age.title":      "Le secret client de {app_name} a {age_days} jours",
+	"notification.client_secret_age.message":    "Le secret de {app_name} dépasse le maximum de {max_age_days} jours ; renouvelez-le depuis la page des clients Alliance",
+}
@@ -44,6 +44,26 @@ type OIDCConfig struct {
 	UsernameClaim string   `json:"username_claim,omitempty"` // Default: preferred_username
 	EmailClaim    string   `json:"email_claim,omitempty"`    // Default: email
 	GroupsClaim   string   `json:"groups_claim,omitempty"`   // Default: groups
+
+	// Managed is the IdP's admin API, for a managed provider
+	Managed *ManagedIdPConfig `json:"managed,omitempty"`
+}
+
+// Managed IdP kinds Stardeck can create clients in
+const (
+	ManagedIdPAuthentik = "authentik"
+	ManagedIdPKeycloak  = "keycloak"
+)
+
+// ManagedIdPConfig holds the admin API access Stardeck uses to create and
+// remove clients in a managed IdP
+type ManagedIdPConfig struct {
+	Kind          string `json:"kind"`                     // authentik or keycloak
+	BaseURL       string `json:"base_url"`                 // e.g. http://localhost:9000
+	APIToken      string `json:"api_token,omitempty"`      // Authentik API token; encrypted at rest
+	AdminUsername string `json:"admin_username,omitempty"` // Keycloak admin in the master realm
+	AdminPassword string `json:"admin_password,omitempty"` // Encrypted at rest
+	Realm         string `json:"realm,omitempty"`          // Keycloak realm clients go in; default master
 }
 
 // LDAPConfig holds LDAP provider configuration
@@ -85,8 +105,60 @@ type AllianceClient struct {
 	Config       string    `json:"config"`  // JSON: app-specific SSO config
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Secret rotation: after a rotation the previous secret stays valid until
+	// PreviousSecretExpiresAt, so the app and the IdP can be switched over
+	SecretCreatedAt         time.Time  `json:"secret_created_at"`
+	PreviousSecret          string     `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	SecretAgeNotifiedAt     *time.Time `json:"-"`               // When admins were told the secret is too old
+	SecretAgeDays           int        `json:"secret_age_days"` // Filled in for responses
+	SecretExpired           bool       `json:"secret_expired"`  // Older than the maximum secret age
+}
+
+// ProvisionedClient records the copy of a client Stardeck created in a
+// managed IdP
+type ProvisionedClient struct {
+	Kind          string    `json:"kind"`
+	RemoteID      string    `json:"remote_id"`      // Authentik provider pk or Keycloak client UUID
+	Slug          string    `json:"slug,omitempty"` // Authentik application slug
+	IssuerURL     string    `json:"issuer_url"`     // Issuer the app signs in against
+	ProvisionedAt time.Time `json:"provisioned_at"`
+}
+
+// AllianceClientConfig is the JSON kept in AllianceClient.Config
+type AllianceClientConfig struct {
+	Provisioned *ProvisionedClient `json:"provisioned,omitempty"`
+}
+
+// RotateClientSecretRequest replaces a client's secret. The old one stays
+// valid for GraceHours, the default grace period when unset; 0 revokes it
+// at once.
+type RotateClientSecretRequest struct {
+	GraceHours *int `json:"grace_hours,omitempty"`
+}
+
+// VerifyClientSecretRequest asks whether a secret is one a client accepts
+type VerifyClientSecretRequest struct {
+	Secret string `json:"secret"`
+}
+
+// VerifyClientSecretResponse says which of a client's secrets matched
+type VerifyClientSecretResponse struct {
+	Valid     bool       `json:"valid"`
+	Matched   string     `json:"matched,omitempty"`    // current or previous
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // When a previous secret stops working
 }
 
+// ClientSecretSettings controls client secret rotation
+type ClientSecretSettings struct {
+	MaxAgeDays        int `json:"max_age_days"`        // Admins are alerted about older secrets; 0 turns the alert off
+	DefaultGraceHours int `json:"default_grace_hours"` // How long a replaced secret stays valid
+}
+
+// NotificationClientSecretAge is sent when a client secret passes the maximum age
+const NotificationClientSecretAge = "alliance.client_secret_age"
+
 // AllianceUser represents a user synced from an identity provider
 type AllianceUser struct {
 	ID          string    `json:"id"`
@@ -121,20 +193,61 @@ type AllianceStatus struct {
 	UserCount      int               `json:"user_count"`
 	GroupCount     int               `json:"group_count"`
 	ActiveProvider *AllianceProvider `json:"active_provider,omitempty"`
+	Providers      []ProviderHealth  `json:"providers"` // Health of each configured provider
+}
+
+// Provider health states
+const (
+	ProviderHealthHealthy  = "healthy"
+	ProviderHealthDegraded = "degraded" // Discovery works but keys or the token endpoint don't
+	ProviderHealthDown     = "down"     // The discovery endpoint can't be reached
+	ProviderHealthUnknown  = "unknown"  // Not checked yet, disabled, or a type without checks
+)
+
+// ProviderHealthCheck is one health check of an identity provider. Latencies
+// are in milliseconds; steps after a failed discovery aren't run.
+type ProviderHealthCheck struct {
+	ID          int64     `json:"id"`
+	ProviderID  string    `json:"provider_id"`
+	Healthy     bool      `json:"healthy"`
+	DiscoveryOK bool      `json:"discovery_ok"`
+	DiscoveryMS int64     `json:"discovery_ms"`
+	JWKSOK      bool      `json:"jwks_ok"`
+	JWKSMS      int64     `json:"jwks_ms"`
+	JWKSKeys    int       `json:"jwks_keys"`
+	TokenOK     bool      `json:"token_ok"` // The token endpoint answered, even if it refused the probe
+	TokenMS     int64     `json:"token_ms"`
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// ProviderHealth is a provider's state from its latest check, with its
+// recent checks newest first
+type ProviderHealth struct {
+	ProviderID string                `json:"provider_id"`
+	Name       string                `json:"name"`
+	Type       ProviderType          `json:"type"`
+	Enabled    bool                  `json:"enabled"`
+	Status     string                `json:"status"`
+	Uptime     *float64              `json:"uptime,omitempty"` // Percentage of the recent checks that were healthy
+	Latest     *ProviderHealthCheck  `json:"latest,omitempty"`
+	History    []ProviderHealthCheck `json:"history"`
 }
 
 // CreateProviderRequest represents the request to create a provider
 type CreateProviderRequest struct {
-	Name   string       `json:"name" validate:"required,min=1,max=64"`
-	Type   ProviderType `json:"type" validate:"required,oneof=oidc saml ldap"`
-	Config interface{}  `json:"config" validate:"required"` // OIDCConfig, LDAPConfig, or SAMLConfig
+	Name      string       `json:"name" validate:"required,min=1,max=64"`
+	Type      ProviderType `json:"type" validate:"required,oneof=oidc saml ldap"`
+	IsManaged bool         `json:"is_managed,omitempty"`       // Clients are created in the IdP too; needs config.managed
+	Config    interface{}  `json:"config" validate:"required"` // OIDCConfig, LDAPConfig, or SAMLConfig
 }
 
 // UpdateProviderRequest represents the request to update a provider
 type UpdateProviderRequest struct {
-	Name    *string     `json:"name,omitempty"`
-	Enabled *bool       `json:"enabled,omitempty"`
-	Config  interface{} `json:"config,omitempty"`
+	Name      *string     `json:"name,omitempty"`
+	Enabled   *bool       `json:"enabled,omitempty"`
+	IsManaged *bool       `json:"is_managed,omitempty"`
+	Config    interface{} `json:"config,omitempty"`
 }
 
 // CreateClientRequest represents the request to register an app as a client
@@ -232,6 +345,8 @@ const (
 	ActionAllianceClientCreate   = "alliance.client.create"
 	ActionAllianceClientUpdate   = "alliance.client.update"
 	ActionAllianceClientDelete   = "alliance.client.delete"
+	ActionAllianceClientRotate   = "alliance.client.rotate"
+	ActionAllianceSecretSettings = "alliance.client.secret_settings"
 	ActionAllianceUserSync       = "alliance.user.sync"
 	ActionAllianceGroupSync      = "alliance.group.sync"
 )
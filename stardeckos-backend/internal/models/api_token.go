@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// APITokenPrefix starts every API token, which tells them apart from session
+// tokens in the Authorization header and makes leaked ones easy to scan for
+const APITokenPrefix = "sdt_"
+
+// APIToken is a personal access token for scripts and automation. It acts
+// as its user, limited to its scopes: permission codes like those of roles.
+type APIToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Username   string     `json:"username"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // The start of the token, to recognise it by
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // Never, when unset
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the token can still be used
+func (t *APIToken) Active() bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt))
+}
+
+// CreateAPITokenRequest asks for a new token
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 for a token that doesn't expire
+}
+
+// CreatedAPIToken is returned once when a token is created; only its hash
+// is kept
+type CreatedAPIToken struct {
+	APIToken APIToken `json:"api_token"`
+	Token    string   `json:"token"`
+}
+
+// Audit actions for API tokens
+const (
+	ActionAPITokenCreate = "api_token.create"
+	ActionAPITokenRevoke = "api_token.revoke"
+)
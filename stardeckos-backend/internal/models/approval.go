@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// ApprovalStatus is the lifecycle state of an approval request
+type ApprovalStatus string
+
+const (
+	ApprovalPending  ApprovalStatus = "pending"
+	ApprovalApproved ApprovalStatus = "approved"
+	ApprovalRejected ApprovalStatus = "rejected"
+	ApprovalExpired  ApprovalStatus = "expired"
+	ApprovalExecuted ApprovalStatus = "executed"
+)
+
+// Destructive actions that require a second admin when four-eyes mode is on
+const (
+	DestructiveContainerRemoveVolumes = "container.remove_with_volumes"
+	DestructiveStackDelete            = "stack.delete"
+	DestructiveDiskFormat             = "storage.format"
+	DestructivePartitionDelete        = "storage.partition_delete"
+	DestructiveSnapshotRollback       = "storage.snapshot_rollback"
+)
+
+// ApprovalRequest is a held destructive action waiting for a second admin.
+// Once approved, the requester repeats the original call with the approval ID.
+type ApprovalRequest struct {
+	ID          string         `json:"id"`
+	Action      string         `json:"action"`
+	Method      string         `json:"method"`
+	Path        string         `json:"path"`
+	Query       string         `json:"query,omitempty"`
+	Body        string         `json:"body,omitempty"`
+	Fingerprint string         `json:"-"` // sha256 of method, path, query and body
+	Status      ApprovalStatus `json:"status"`
+	Reason      string         `json:"reason,omitempty"` // Requester's justification or reviewer's comment
+	RequestedBy int64          `json:"requested_by"`
+	Requester   string         `json:"requester,omitempty"`
+	DecidedBy   *int64         `json:"decided_by,omitempty"`
+	Decider     string         `json:"decider,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	DecidedAt   *time.Time     `json:"decided_at,omitempty"`
+	ExpiresAt   time.Time      `json:"expires_at"` // Deadline to approve, then to execute once approved
+	ExecutedAt  *time.Time     `json:"executed_at,omitempty"`
+}
+
+// DecideApprovalRequest is the body of an approve or reject call
+type DecideApprovalRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// ApprovalSettings configures four-eyes mode
+type ApprovalSettings struct {
+	Enabled    bool `json:"enabled"`
+	TTLMinutes int  `json:"ttl_minutes"`
+}
+
+// Approval audit actions
+const (
+	ActionApprovalRequest  = "approval.request"
+	ActionApprovalApprove  = "approval.approve"
+	ActionApprovalReject   = "approval.reject"
+	ActionApprovalExecute  = "approval.execute"
+	ActionApprovalSettings = "approval.settings"
+)
@@ -89,4 +89,7 @@ const (
 	ActionFirewallZoneCreate = "firewall.zone.create"
 	ActionFirewallZoneUpdate = "firewall.zone.update"
 	ActionFirewallZoneDelete = "firewall.zone.delete"
+	ActionFirewallStage      = "firewall.change.stage"
+	ActionFirewallConfirm    = "firewall.change.confirm"
+	ActionFirewallRevert     = "firewall.change.revert"
 )
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// Container auto-update policies
+const (
+	AutoUpdateNotify = "notify" // Check for new images and notify, never update
+	AutoUpdateAuto   = "auto"   // Update during the maintenance window
+)
+
+// What started a container update
+const (
+	UpdateTriggerManual    = "manual"
+	UpdateTriggerScheduled = "scheduled"
+)
+
+// Outcomes of a container update
+const (
+	UpdateStatusSuccess = "success"
+	UpdateStatusFailed  = "failed"
+)
+
+// ContainerAutoUpdate checks a container's image for a new digest every
+// CheckIntervalHours. Under the notify policy admins are told once per new
+// image; under auto the update itself runs when the maintenance window opens,
+// WindowSchedule being a cron expression in Timezone and WindowMinutes how
+// long the window stays open.
+type ContainerAutoUpdate struct {
+	ContainerID        string     `json:"container_id"` // Stardeck container ID
+	ContainerName      string     `json:"container_name,omitempty"`
+	Enabled            bool       `json:"enabled"`
+	Policy             string     `json:"policy"`
+	CheckIntervalHours int        `json:"check_interval_hours"`
+	WindowSchedule     string     `json:"window_schedule,omitempty"`
+	WindowMinutes      int        `json:"window_minutes,omitempty"`
+	Timezone           string     `json:"timezone"`
+	CreateBackup       bool       `json:"create_backup"` // Back up bind mounts before updating
+	RemoveOld          bool       `json:"remove_old"`    // Remove the old container after a successful update
+	UpdateAvailable    bool       `json:"update_available"`
+	LocalDigest        string     `json:"local_digest,omitempty"`
+	RemoteDigest       string     `json:"remote_digest,omitempty"`
+	NotifiedDigest     string     `json:"-"` // Remote digest admins were last told about
+	LastCheckedAt      *time.Time `json:"last_checked_at,omitempty"`
+	LastAttemptAt      *time.Time `json:"last_attempt_at,omitempty"` // Last automatic update, successful or not
+	LastError          string     `json:"last_error,omitempty"`
+	NextWindowAt       *time.Time `json:"next_window_at,omitempty"` // Computed from WindowSchedule, not stored
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	CreatedBy          *int64     `json:"created_by,omitempty"`
+}
+
+// UpdateContainerAutoUpdateRequest creates or replaces a container's
+// auto-update policy
+type UpdateContainerAutoUpdateRequest struct {
+	Enabled            *bool  `json:"enabled,omitempty"`
+	Policy             string `json:"policy"`
+	CheckIntervalHours int    `json:"check_interval_hours,omitempty"` // Default 24
+	WindowSchedule     string `json:"window_schedule,omitempty"`      // Required for the auto policy
+	WindowMinutes      int    `json:"window_minutes,omitempty"`       // Default 60
+	Timezone           string `json:"timezone,omitempty"`
+	CreateBackup       bool   `json:"create_backup"`
+	RemoveOld          bool   `json:"remove_old"`
+}
+
+// ContainerUpdateHistory records one run of the container update workflow
+type ContainerUpdateHistory struct {
+	ID              int64      `json:"id"`
+	ContainerID     string     `json:"container_id"` // Stardeck container ID, or podman's for unmanaged containers
+	ContainerName   string     `json:"container_name"`
+	Trigger         string     `json:"trigger"`
+	TriggeredBy     string     `json:"triggered_by"`
+	OldImage        string     `json:"old_image"`
+	NewImage        string     `json:"new_image,omitempty"`
+	OldDigest       string     `json:"old_digest,omitempty"`
+	NewDigest       string     `json:"new_digest,omitempty"`
+	Status          string     `json:"status"`
+	Error           string     `json:"error,omitempty"`
+	BackupContainer string     `json:"backup_container,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+}
+
+// NotificationImageUpdate tells admins a container's image has a new version
+const NotificationImageUpdate = "container.update_available"
+
+// Audit actions for container auto-updates
+const (
+	ActionContainerAutoUpdateSet    = "container_auto_update.update"
+	ActionContainerAutoUpdateDelete = "container_auto_update.delete"
+	ActionContainerAutoUpdateRun    = "container_auto_update.run"
+)
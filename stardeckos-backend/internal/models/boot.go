@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// BootAction describes what the boot reconciler did for a container
+type BootAction string
+
+const (
+	BootActionAlreadyRunning BootAction = "already_running"
+	BootActionStarted        BootAction = "started"
+	BootActionFailed         BootAction = "failed"
+	BootActionMissing        BootAction = "missing" // Container no longer exists in Podman
+)
+
+// BootReport summarises an auto-start reconciliation run
+type BootReport struct {
+	ID             int64        `json:"id"`
+	BootID         string       `json:"boot_id"` // Kernel boot ID the run was performed for
+	Trigger        string       `json:"trigger"` // boot or manual
+	StartedAt      time.Time    `json:"started_at"`
+	FinishedAt     time.Time    `json:"finished_at"`
+	Total          int          `json:"total"`
+	AlreadyRunning int          `json:"already_running"`
+	Started        int          `json:"started"`
+	Failed         int          `json:"failed"`
+	Missing        int          `json:"missing"`
+	Results        []BootResult `json:"results"`
+}
+
+// BootResult records the reconciliation outcome for one auto-start container
+type BootResult struct {
+	ID          string     `json:"id"` // Stardeck container ID
+	ContainerID string     `json:"container_id"`
+	Name        string     `json:"name"`
+	Action      BootAction `json:"action"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Audit action constants for boot reconciliation
+const (
+	ActionBootReconcile = "boot.reconcile"
+)
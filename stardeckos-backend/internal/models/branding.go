@@ -0,0 +1,19 @@
+package models
+
+// BrandingSettings is the instance identity shown on the login page and desktop
+type BrandingSettings struct {
+	InstanceName string `json:"instance_name"`
+	AccentColor  string `json:"accent_color"`       // Hex color, e.g. #6366f1
+	Banner       string `json:"banner"`             // Message of the day shown on the login page
+	LegalNotice  string `json:"legal_notice"`       // Consent / acceptable use notice
+	RequireAck   bool   `json:"require_ack"`        // Users must acknowledge the legal notice before signing in
+	LogoURL      string `json:"logo_url,omitempty"` // Set when a custom logo has been uploaded
+	UpdatedAt    string `json:"updated_at,omitempty"`
+}
+
+// Branding audit actions
+const (
+	ActionBrandingUpdate     = "branding.update"
+	ActionBrandingLogoUpload = "branding.logo_upload"
+	ActionBrandingLogoDelete = "branding.logo_delete"
+)
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// PacketCaptureRequest starts a capture. It stops at whichever limit is reached first.
+type PacketCaptureRequest struct {
+	Interface  string `json:"interface"`
+	Filter     string `json:"filter"`      // BPF filter expression, e.g. "port 53"
+	Duration   int    `json:"duration"`    // Seconds
+	MaxPackets int    `json:"max_packets"` // 0 for the largest allowed count
+	SnapLen    int    `json:"snap_len"`    // Bytes kept per packet; 0 for whole packets
+}
+
+// PacketCapture is a finished capture available for download
+type PacketCapture struct {
+	ID        string    `json:"id"`
+	Interface string    `json:"interface"`
+	Filter    string    `json:"filter"`
+	Packets   int       `json:"packets"`
+	Size      int64     `json:"size"`
+	Duration  float64   `json:"duration_seconds"`
+	StartedAt time.Time `json:"started_at"`
+	StartedBy string    `json:"started_by"`
+}
+
+// Audit actions for traffic capture
+const (
+	ActionPacketCapture         = "network.capture"
+	ActionPacketCaptureDownload = "network.capture.download"
+	ActionPacketCaptureDelete   = "network.capture.delete"
+)
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Certificate monitor alert states, from least to most severe
+const (
+	CertAlertNone    = ""
+	CertAlertWarning = "warning" // Expires within the monitor's warning window
+	CertAlertExpired = "expired"
+)
+
+// CertCheck is the outcome of fetching an endpoint's TLS certificate
+type CertCheck struct {
+	Subject     string     `json:"subject,omitempty"`
+	Issuer      string     `json:"issuer,omitempty"`
+	DNSNames    []string   `json:"dns_names,omitempty"`
+	NotAfter    *time.Time `json:"not_after,omitempty"`
+	DaysLeft    *int       `json:"days_left,omitempty"`
+	Trusted     bool       `json:"trusted"`                // Chains to a system root and matches the name
+	VerifyError string     `json:"verify_error,omitempty"` // Why it isn't trusted
+	Error       string     `json:"error,omitempty"`        // Why the certificate couldn't be fetched
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+}
+
+// CertMonitor tracks the expiry of the TLS certificate served at host:port
+type CertMonitor struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	ServerName  string `json:"server_name,omitempty"`  // SNI name when it differs from host
+	ContainerID string `json:"container_id,omitempty"` // Stardeck container the endpoint serves
+	WarnDays    int    `json:"warn_days"`
+	Enabled     bool   `json:"enabled"`
+	Builtin     bool   `json:"builtin"` // Stardeck's own certificate, checked from disk
+	CertCheck
+	AlertState string    `json:"alert_state"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+}
+
+// CertMonitorRequest creates or replaces a certificate monitor
+type CertMonitorRequest struct {
+	Name        string `json:"name"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	ServerName  string `json:"server_name"`
+	ContainerID string `json:"container_id"`
+	WarnDays    int    `json:"warn_days"`
+	Enabled     *bool  `json:"enabled,omitempty"`
+}
+
+// Audit actions for certificate monitors
+const (
+	ActionCertMonitorCreate = "cert_monitor.create"
+	ActionCertMonitorUpdate = "cert_monitor.update"
+	ActionCertMonitorDelete = "cert_monitor.delete"
+)
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// MTLSMode controls whether the HTTPS listener asks for client certificates
+type MTLSMode string
+
+const (
+	MTLSOff      MTLSMode = "off"      // Client certificates are not requested
+	MTLSOptional MTLSMode = "optional" // A valid certificate authenticates the request in place of a session
+	MTLSRequired MTLSMode = "required" // Every connection must present a certificate from the internal CA
+)
+
+// ClientCertScope limits what a certificate-authenticated request may do
+type ClientCertScope string
+
+const (
+	ClientCertScopeFull     ClientCertScope = "full"      // Everything the mapped user may do
+	ClientCertScopeReadOnly ClientCertScope = "read_only" // Only what a viewer may do
+)
+
+// ClientCertificate is a client certificate issued by the internal CA and
+// mapped to the Stardeck user it authenticates as
+type ClientCertificate struct {
+	ID         int64           `json:"id"`
+	Serial     string          `json:"serial"`
+	CommonName string          `json:"common_name"`
+	DNSNames   []string        `json:"dns_names"`
+	UserID     int64           `json:"user_id"`
+	Username   string          `json:"username"`
+	Scope      ClientCertScope `json:"scope"`
+	NotAfter   time.Time       `json:"not_after"`
+	CreatedAt  time.Time       `json:"created_at"`
+	CreatedBy  string          `json:"created_by"`
+	RevokedAt  *time.Time      `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time      `json:"last_used_at,omitempty"`
+}
+
+// IssueClientCertRequest asks the internal CA for a new client certificate
+type IssueClientCertRequest struct {
+	CommonName string          `json:"common_name"`
+	DNSNames   []string        `json:"dns_names"`
+	UserID     int64           `json:"user_id"`
+	Scope      ClientCertScope `json:"scope"`
+	ValidDays  int             `json:"valid_days"`
+}
+
+// IssuedClientCert is returned once when a certificate is issued; the private
+// key is not kept
+type IssuedClientCert struct {
+	Certificate ClientCertificate `json:"certificate"`
+	CertPEM     string            `json:"cert_pem"`
+	KeyPEM      string            `json:"key_pem"`
+	CAPEM       string            `json:"ca_pem"`
+}
+
+// MTLSStatus reports the mTLS mode and the internal CA
+type MTLSStatus struct {
+	Mode          MTLSMode  `json:"mode"`
+	Available     bool      `json:"available"` // False when serving plain HTTP
+	CAFingerprint string    `json:"ca_fingerprint,omitempty"`
+	CANotAfter    time.Time `json:"ca_not_after,omitempty"`
+}
+
+// UpdateMTLSRequest changes the mTLS mode
+type UpdateMTLSRequest struct {
+	Mode MTLSMode `json:"mode"`
+}
+
+// Audit actions for client certificates
+const (
+	ActionMTLSSettings     = "system.mtls"
+	ActionClientCertIssue  = "client_cert.issue"
+	ActionClientCertRevoke = "client_cert.revoke"
+)
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Cloud storage providers rclone remotes can be configured for
+const (
+	CloudRemoteS3      = "s3"
+	CloudRemoteDrive   = "drive"
+	CloudRemoteDropbox = "dropbox"
+)
+
+// CloudRemote is an rclone remote. Its settings live in rclone's own config
+// file; secret options are never returned, only listed in SecretsSet.
+type CloudRemote struct {
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Options    map[string]string `json:"options"`
+	SecretsSet []string          `json:"secrets_set"`
+	Mounts     int               `json:"mounts"` // Cloud mounts using the remote
+}
+
+// CloudRemoteRequest creates or updates a remote. On update, omitted options
+// are left unchanged. Drive and Dropbox need a token from `rclone authorize`.
+type CloudRemoteRequest struct {
+	Name    string            `json:"name"`
+	Type    string            `json:"type"`
+	Options map[string]string `json:"options"`
+}
+
+// Cloud mount health states
+const (
+	CloudMountHealthy   = "healthy"
+	CloudMountUnhealthy = "unhealthy" // Mounted but not answering
+	CloudMountUnmounted = "unmounted"
+	CloudMountStopped   = "stopped" // Disabled
+)
+
+// CloudMount mounts a remote under the app data root and exposes it to
+// containers as a Podman volume
+type CloudMount struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Remote     string     `json:"remote"`
+	RemotePath string     `json:"remote_path"` // Bucket or folder within the remote
+	MountPath  string     `json:"mount_path"`
+	VolumeName string     `json:"volume_name"` // Podman volume bound to the mount
+	ReadOnly   bool       `json:"read_only"`
+	CacheMode  string     `json:"cache_mode"` // rclone --vfs-cache-mode: off, minimal, writes or full
+	Enabled    bool       `json:"enabled"`
+	Status     string     `json:"status"`
+	LastError  string     `json:"last_error,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	CreatedBy  *int64     `json:"created_by,omitempty"`
+}
+
+// CloudMountRequest creates or replaces a cloud mount
+type CloudMountRequest struct {
+	Name       string `json:"name"` // Fixed once created
+	Remote     string `json:"remote"`
+	RemotePath string `json:"remote_path"`
+	ReadOnly   bool   `json:"read_only"`
+	CacheMode  string `json:"cache_mode"`
+	Enabled    *bool  `json:"enabled,omitempty"`
+}
+
+// Audit actions for cloud storage
+const (
+	ActionCloudRemoteCreate = "cloud_remote.create"
+	ActionCloudRemoteUpdate = "cloud_remote.update"
+	ActionCloudRemoteDelete = "cloud_remote.delete"
+	ActionCloudMountCreate  = "cloud_mount.create"
+	ActionCloudMountUpdate  = "cloud_mount.update"
+	ActionCloudMountDelete  = "cloud_mount.delete"
+)
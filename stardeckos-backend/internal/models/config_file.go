@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// ConfigFile is an application config file found inside one of a container's bind mounts
+type ConfigFile struct {
+	Path          string    `json:"path"`           // Path on the host
+	ContainerPath string    `json:"container_path"` // Path as seen inside the container
+	Name          string    `json:"name"`
+	Language      string    `json:"language"` // Syntax highlighting hint: yaml, json, toml, ini, xml, shell, nginx, plaintext
+	Size          int64     `json:"size"`
+	ModifiedAt    time.Time `json:"modified_at"`
+	ReadOnly      bool      `json:"read_only"` // The bind mount is read-only in the container
+}
+
+// ConfigFileContent is a config file with its contents and a hash for conflict detection
+type ConfigFileContent struct {
+	ConfigFile
+	Content string `json:"content"`
+	Hash    string `json:"hash"` // sha256 of the content as read
+}
+
+// SaveConfigFileRequest represents a request to save an edited config file
+type SaveConfigFileRequest struct {
+	Path         string `json:"path"`
+	Content      string `json:"content"`
+	ExpectedHash string `json:"expected_hash,omitempty"` // Rejects the save if the file changed since it was read
+	SkipValidate bool   `json:"skip_validate,omitempty"`
+	Restart      bool   `json:"restart,omitempty"` // Restart the container after saving
+}
+
+// SaveConfigFileResponse is returned after a config file is saved
+type SaveConfigFileResponse struct {
+	File            ConfigFile `json:"file"`
+	Hash            string     `json:"hash"`
+	BackupPath      string     `json:"backup_path,omitempty"`
+	RestartRequired bool       `json:"restart_required"` // The container is running and has not picked up the change
+	Restarted       bool       `json:"restarted"`
+}
+
+// ConfigFileBackup is a copy of a config file taken before it was overwritten
+type ConfigFileBackup struct {
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Config file audit actions
+const (
+	ActionConfigFileSave    = "container.config_file_save"
+	ActionConfigFileRestore = "container.config_file_restore"
+)
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Reasons a container configuration snapshot was recorded
+const (
+	ConfigSnapshotCreate          = "create"
+	ConfigSnapshotAdopt           = "adopt"
+	ConfigSnapshotEdit            = "edit"
+	ConfigSnapshotUpdate          = "update"
+	ConfigSnapshotSecurityProfile = "security_profile"
+	ConfigSnapshotRestore         = "restore"
+)
+
+// ContainerConfigSnapshot is one recorded version of a container's configuration
+type ContainerConfigSnapshot struct {
+	ID            int64            `json:"id"`
+	ContainerID   string           `json:"container_id"` // Stardeck container ID
+	PodmanID      string           `json:"podman_id"`    // Podman container ID at the time
+	Version       int              `json:"version"`
+	Reason        string           `json:"reason"`
+	Config        *ContainerConfig `json:"config,omitempty"`
+	ConfigHash    string           `json:"config_hash"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CreatedBy     *int64           `json:"created_by,omitempty"`
+	CreatedByName string           `json:"created_by_name,omitempty"`
+}
+
+// ConfigChange is one difference between two configurations. Field is a
+// dotted path such as "environment.TZ" or "ports"; list fields report the
+// entries that were added or removed.
+type ConfigChange struct {
+	Field  string      `json:"field"`
+	Change string      `json:"change"` // added, removed or changed
+	Old    interface{} `json:"old,omitempty"`
+	New    interface{} `json:"new,omitempty"`
+}
+
+// ConfigHistoryEntry is a snapshot in a container's history with its changes
+// from the version before it
+type ConfigHistoryEntry struct {
+	ContainerConfigSnapshot
+	Changes []ConfigChange `json:"changes"`
+}
+
+// ConfigDiff compares two versions of a container's configuration. A To
+// version of 0 is the container's live configuration.
+type ConfigDiff struct {
+	From    int            `json:"from"`
+	To      int            `json:"to"`
+	Changes []ConfigChange `json:"changes"`
+}
@@ -53,6 +53,9 @@ type ContainerListItem struct {
 	CreatedAt   time.Time       `json:"created_at"`
 	Uptime      string          `json:"uptime,omitempty"`
 	Ports       []PortMapping   `json:"ports,omitempty"`
+	CreatedBy   *int64          `json:"created_by,omitempty"` // Owner, when Stardeck manages the container
+	Stack       string          `json:"stack,omitempty"`      // Compose project the container belongs to
+	Health      string          `json:"health,omitempty"`     // healthy, unhealthy or starting; empty without a health check
 }
 
 // PortMapping represents a container port mapping
@@ -65,10 +68,11 @@ type PortMapping struct {
 
 // VolumeMount represents a volume mount configuration
 type VolumeMount struct {
-	Source   string `json:"source"`    // Host path or volume name
-	Target   string `json:"target"`    // Container path
-	ReadOnly bool   `json:"read_only"` // Mount as read-only
-	Type     string `json:"type"`      // bind, volume, tmpfs
+	Source   string `json:"source"`         // Host path or volume name
+	Target   string `json:"target"`         // Container path
+	ReadOnly bool   `json:"read_only"`      // Mount as read-only
+	Type     string `json:"type"`           // bind, volume, tmpfs
+	Name     string `json:"name,omitempty"` // Volume name when Type is volume
 }
 
 // ContainerStats represents real-time container statistics
@@ -118,11 +122,30 @@ type CreateContainerRequest struct {
 	CPULimit     float64           `json:"cpu_limit,omitempty"`     // CPU cores limit
 	MemoryLimit  int64             `json:"memory_limit,omitempty"`  // Memory limit in bytes
 	NetworkMode  string            `json:"network_mode,omitempty"`  // bridge, host, none, container:<name|id>
+	NetworkAliases []string        `json:"network_aliases,omitempty"` // Extra DNS names on the container's network
+	UseManagedNetwork bool         `json:"use_managed_network"`       // Attach to the Stardeck-managed DNS network
 	Hostname     string            `json:"hostname,omitempty"`
 	User         string            `json:"user,omitempty"`          // User to run as
 	WorkDir      string            `json:"workdir,omitempty"`       // Working directory
 	Entrypoint   []string          `json:"entrypoint,omitempty"`
 	Command      []string          `json:"command,omitempty"`
+	Egress       *EgressPolicySpec `json:"egress,omitempty"`        // Outbound network policy to apply once created
+	ContainerSecurityOptions
+	ContainerLogOptions
+	ContainerHealthCheck
+}
+
+// ContainerSecurityOptions are the privilege and confinement settings of a
+// container, mapped to the matching podman create flags
+type ContainerSecurityOptions struct {
+	Privileged      bool     `json:"privileged,omitempty"`        // All capabilities and host devices; disables seccomp
+	CapAdd          []string `json:"cap_add,omitempty"`           // Capabilities to add, e.g. NET_ADMIN
+	CapDrop         []string `json:"cap_drop,omitempty"`          // Capabilities to drop, or ALL
+	NoNewPrivileges bool     `json:"no_new_privileges,omitempty"` // Block privilege escalation through setuid binaries
+	SeccompProfile  string   `json:"seccomp_profile,omitempty"`   // Path to a seccomp JSON profile, or "unconfined"
+	AppArmorProfile string   `json:"apparmor_profile,omitempty"`  // Loaded AppArmor profile name, or "unconfined"
+	ReadOnlyRootfs  bool     `json:"read_only_rootfs,omitempty"`  // Mount the image filesystem read-only
+	UserNS          string   `json:"userns,omitempty"`            // auto, keep-id, nomap, host or ns:<path>
 }
 
 // UpdateContainerRequest represents the request body for updating a container
@@ -192,11 +215,30 @@ type CreateVolumeRequest struct {
 	Options map[string]string `json:"options,omitempty"`
 }
 
+// DataPreview summarises the data a volume or bind mount directory holds before it is deleted
+type DataPreview struct {
+	Name      string             `json:"name"` // Volume name or host path
+	Path      string             `json:"path"` // Directory the data lives in on the host
+	SizeBytes int64              `json:"size_bytes"`
+	FileCount int                `json:"file_count"`
+	Entries   []DataPreviewEntry `json:"entries"`   // Top-level contents, largest first
+	Truncated bool               `json:"truncated"` // More top-level entries exist than were listed
+	UsedBy    []string           `json:"used_by"`   // Containers that mount it
+}
+
+// DataPreviewEntry is a top-level file or directory in a DataPreview
+type DataPreviewEntry struct {
+	Name      string `json:"name"`
+	IsDir     bool   `json:"is_dir"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
 // Network represents a Podman network
 type Network struct {
 	ID        string            `json:"id"`
 	Name      string            `json:"name"`
 	Driver    string            `json:"driver"`
+	Interface string            `json:"interface,omitempty"` // Bridge, or parent interface of a macvlan network
 	Subnet    string            `json:"subnet,omitempty"`
 	Gateway   string            `json:"gateway,omitempty"`
 	Internal  bool              `json:"internal"`
@@ -207,13 +249,95 @@ type Network struct {
 
 // CreateNetworkRequest represents a request to create a network
 type CreateNetworkRequest struct {
-	Name     string            `json:"name" validate:"required"`
-	Driver   string            `json:"driver,omitempty"`
-	Subnet   string            `json:"subnet,omitempty"`
-	Gateway  string            `json:"gateway,omitempty"`
-	Internal bool              `json:"internal"`
-	IPv6     bool              `json:"ipv6"`
-	Labels   map[string]string `json:"labels,omitempty"`
+	Name       string            `json:"name" validate:"required"`
+	Driver     string            `json:"driver,omitempty"`
+	Subnet     string            `json:"subnet,omitempty"`
+	Gateway    string            `json:"gateway,omitempty"`
+	Internal   bool              `json:"internal"`
+	IPv6       bool              `json:"ipv6"`
+	IPRange    string            `json:"ip_range,omitempty"` // Part of the subnet to assign from, CIDR or start-end
+	IPAMDriver string            `json:"ipam_driver,omitempty"`
+	DisableDNS bool              `json:"disable_dns,omitempty"`
+	Interface  string            `json:"interface,omitempty"` // Bridge name, or parent interface of a macvlan network
+	Options    map[string]string `json:"options,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// LANInterface is a host interface a macvlan or ipvlan network can be bound
+// to, with the LAN settings detected from the host's own configuration
+type LANInterface struct {
+	Name             string   `json:"name"`
+	Type             string   `json:"type"` // ethernet, bond, bridge, wireless
+	State            string   `json:"state"`
+	HostAddress      string   `json:"host_address"` // The host's address on the LAN, in CIDR notation
+	Subnet           string   `json:"subnet"`
+	Gateway          string   `json:"gateway,omitempty"`
+	SuggestedIPRange string   `json:"suggested_ip_range,omitempty"` // A block of the subnet clear of the host and gateway
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// CreateLANNetworkRequest creates a macvlan or ipvlan network that puts
+// containers directly on the LAN of a host interface. Subnet and gateway are
+// detected from the interface when left empty.
+type CreateLANNetworkRequest struct {
+	Name      string `json:"name"`
+	Driver    string `json:"driver"` // macvlan or ipvlan
+	Interface string `json:"interface"`
+	Subnet    string `json:"subnet,omitempty"`
+	Gateway   string `json:"gateway,omitempty"`
+	IPRange   string `json:"ip_range,omitempty"` // Keep it outside the LAN DHCP server's pool
+	Mode      string `json:"mode,omitempty"`     // macvlan: bridge, private, vepa, passthru; ipvlan: l2, l3, l3s
+	DHCP      bool   `json:"dhcp"`               // Lease addresses from the LAN's DHCP server (macvlan only)
+}
+
+// How a container got its address on a network
+const (
+	NetworkAddressDynamic = "dynamic" // Assigned by podman from the subnet
+	NetworkAddressStatic  = "static"  // Fixed with --ip when the container was created
+	NetworkAddressDHCP    = "dhcp"    // Leased from a DHCP server on the LAN
+)
+
+// NetworkSubnet is one of a network's address ranges
+type NetworkSubnet struct {
+	Subnet     string `json:"subnet"`
+	Gateway    string `json:"gateway,omitempty"`
+	LeaseStart string `json:"lease_start,omitempty"`
+	LeaseEnd   string `json:"lease_end,omitempty"`
+}
+
+// NetworkMember is a container attached to a network
+type NetworkMember struct {
+	ID          string   `json:"id,omitempty"` // Stardeck container ID, empty for unmanaged containers
+	ContainerID string   `json:"container_id"`
+	Name        string   `json:"name"`
+	State       string   `json:"state"`
+	Stack       string   `json:"stack,omitempty"`
+	IPv4        string   `json:"ipv4,omitempty"`
+	IPv6        string   `json:"ipv6,omitempty"`
+	MACAddress  string   `json:"mac_address,omitempty"`
+	Aliases     []string `json:"aliases"`
+	Addressing  string   `json:"addressing"`
+}
+
+// NetworkDetail is a network's full configuration and the containers
+// attached to it
+type NetworkDetail struct {
+	Network
+	Subnets    []NetworkSubnet   `json:"subnets"`
+	DNSEnabled bool              `json:"dns_enabled"`
+	DNSServers []string          `json:"dns_servers,omitempty"`
+	IPAMDriver string            `json:"ipam_driver"` // host-local, dhcp or none
+	Options    map[string]string `json:"options,omitempty"`
+	Containers []NetworkMember   `json:"containers"`
+	Warnings   []string          `json:"warnings,omitempty"`
+}
+
+// UpdateNetworkRequest changes a network's subnet or DNS. Podman can't change
+// either in place, so the network is recreated and its containers reconnected.
+type UpdateNetworkRequest struct {
+	Subnet     *string `json:"subnet,omitempty"`
+	Gateway    *string `json:"gateway,omitempty"`
+	DNSEnabled *bool   `json:"dns_enabled,omitempty"`
 }
 
 // Template represents a saved container configuration
@@ -227,6 +351,7 @@ type Template struct {
 	EnvDefaults    string    `json:"env_defaults"`  // JSON
 	VolumeHints    string    `json:"volume_hints"`  // JSON
 	Tags           string    `json:"tags"`          // JSON array
+	Icon           string    `json:"icon"`          // Icon URL or library reference (/api/icons/:id)
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 	UsageCount     int       `json:"usage_count"`
@@ -241,6 +366,7 @@ type CreateTemplateRequest struct {
 	EnvDefaults    map[string]string `json:"env_defaults,omitempty"`
 	VolumeHints    []VolumeHint      `json:"volume_hints,omitempty"`
 	Tags           []string          `json:"tags,omitempty"`
+	Icon           string            `json:"icon,omitempty"`
 }
 
 // VolumeHint provides guidance for volume configuration during template deployment
@@ -255,7 +381,8 @@ type VolumeHint struct {
 type DeployTemplateRequest struct {
 	ProjectName string            `json:"project_name,omitempty"`
 	Environment map[string]string `json:"environment,omitempty"`
-	Volumes     map[string]string `json:"volumes,omitempty"` // volume name -> host path
+	Volumes     map[string]string `json:"volumes,omitempty"`    // volume name -> host path
+	DataPools   *bool             `json:"data_pools,omitempty"` // Mount shared data pools into known media apps; default true
 }
 
 // ContainerEnvVar represents an environment variable for a container
@@ -301,6 +428,8 @@ type Stack struct {
 	CreatedBy      *int64      `json:"created_by,omitempty"`
 	EnvContent     string      `json:"env_content,omitempty"`
 	Path           string      `json:"path"`
+	Icon           string      `json:"icon"`     // Icon URL or library reference (/api/icons/:id)
+	Profiles       []string    `json:"profiles"` // Compose profiles passed to podman-compose
 }
 
 // StackListItem is a lightweight view for listing stacks
@@ -311,34 +440,180 @@ type StackListItem struct {
 	Status         StackStatus `json:"status"`
 	ContainerCount int         `json:"container_count"`
 	RunningCount   int         `json:"running_count"`
+	Health         StackHealth `json:"health"`
+	HealthyCount   int         `json:"healthy_count"` // Containers running and passing any health check
 	CreatedAt      time.Time   `json:"created_at"`
 	UpdatedAt      time.Time   `json:"updated_at"`
+	Icon           string      `json:"icon"`
+	CreatedBy      *int64      `json:"created_by,omitempty"`
 }
 
 // StackContainer represents a container belonging to a stack
 type StackContainer struct {
-	Name    string          `json:"name"`
-	Service string          `json:"service"`
-	Status  ContainerStatus `json:"status"`
-	Image   string          `json:"image"`
-	Ports   []PortMapping   `json:"ports,omitempty"`
+	Name     string          `json:"name"`
+	Service  string          `json:"service"`
+	Status   ContainerStatus `json:"status"`
+	Health   string          `json:"health,omitempty"` // healthy, unhealthy or starting; empty without a health check
+	ExitCode int             `json:"exit_code"`
+	Image    string          `json:"image"`
+	Ports    []PortMapping   `json:"ports,omitempty"`
+}
+
+// PodmanSecret is an entry in the Podman secret store; values are never included
+type PodmanSecret struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Driver    string    `json:"driver"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ContainerEventMessage is a container lifecycle event sent to
+// /api/containers/events clients
+type ContainerEventMessage struct {
+	Action      string    `json:"action"`       // create, start, stop, kill, died, oom, unhealthy, pause, ...
+	ID          string    `json:"id,omitempty"` // Stardeck ID, for containers Stardeck tracks
+	ContainerID string    `json:"container_id"`
+	Name        string    `json:"name"`
+	Image       string    `json:"image,omitempty"`
+	Stack       string    `json:"stack,omitempty"`
+	ExitCode    *int      `json:"exit_code,omitempty"` // Set on died and oom events
+	Time        time.Time `json:"time"`
+}
+
+// StackSecretRef is a ${secret:name} reference in a stack's compose file
+type StackSecretRef struct {
+	Name   string `json:"name"`
+	EnvVar string `json:"env_var"` // Variable the reference is rewritten to in the compose file on disk
+	Exists bool   `json:"exists"`
+}
+
+// Where the value of a stack's compose variable comes from
+const (
+	StackVariableSecret  = "secret"   // A ${secret:name} reference
+	StackVariableHost    = "host"     // Stardeck's environment, which podman-compose inherits
+	StackVariableEnvFile = "env_file" // The stack's .env content
+	StackVariableDefault = "default"  // The reference's default in the compose file
+)
+
+// StackVariable is a variable a stack's compose file references
+type StackVariable struct {
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"` // Empty when unset
+	Secret string `json:"secret,omitempty"` // Secret name for ${secret:name} references
+	Masked bool   `json:"masked,omitempty"` // Value hidden in the rendered content
+}
+
+// StackRender is a stack's compose file as podman-compose reads it, with
+// variables interpolated
+type StackRender struct {
+	ComposeContent string          `json:"compose_content"`
+	Variables      []StackVariable `json:"variables"`
+	Unresolved     []string        `json:"unresolved"`       // Unset without a default, so they render empty
+	Errors         []string        `json:"errors,omitempty"` // Problems that would stop a deploy
+}
+
+// ComposeProfile is a compose profile and the services it enables
+type ComposeProfile struct {
+	Name     string   `json:"name"`
+	Services []string `json:"services"`
+}
+
+// Compose depends_on conditions
+const (
+	DependsOnStarted   = "service_started"
+	DependsOnHealthy   = "service_healthy"
+	DependsOnCompleted = "service_completed_successfully"
+)
+
+// ComposeDependency is a depends_on entry and the condition it waits for
+type ComposeDependency struct {
+	Service   string `json:"service"`
+	Condition string `json:"condition"`
+}
+
+// ComposeService is a service parsed from a compose file
+type ComposeService struct {
+	Name        string              `json:"name"`
+	Image       string              `json:"image,omitempty"`
+	Profiles    []string            `json:"profiles,omitempty"`
+	DependsOn   []ComposeDependency `json:"depends_on,omitempty"`
+	Links       []string            `json:"links,omitempty"`        // service or service:alias
+	Networks    []string            `json:"networks,omitempty"`     // Networks named in the service, not the implicit default
+	MemoryLimit int64               `json:"memory_limit,omitempty"` // Bytes, from mem_limit or deploy.resources.limits
+	CPULimit    float64             `json:"cpu_limit,omitempty"`    // Cores, from cpus or deploy.resources.limits
+	Build       bool                `json:"build,omitempty"`        // Has a build section, so the image need not exist yet
+	Ports       []ComposePort       `json:"ports,omitempty"`        // Ports published on the host
+	Mounts      []ComposeMount      `json:"mounts,omitempty"`
+}
+
+// ComposePort is a container port a compose service publishes on the host
+type ComposePort struct {
+	HostIP   string `json:"host_ip,omitempty"`
+	Host     int    `json:"host"`
+	Target   int    `json:"target"`
+	Protocol string `json:"protocol"` // tcp or udp
+}
+
+// ComposeMount is a volume or bind mount of a compose service
+type ComposeMount struct {
+	Type     string `json:"type"`             // bind, volume or tmpfs
+	Source   string `json:"source,omitempty"` // Host path as written, or volume name
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+// StackProfiles lists the profiles a stack's compose file declares and which are active
+type StackProfiles struct {
+	Available       []ComposeProfile `json:"available"`
+	Active          []string         `json:"active"`
+	DefaultServices []string         `json:"default_services"` // Services that run regardless of profile
+}
+
+// ComposeProject is a compose project discovered from container labels
+type ComposeProject struct {
+	Name           string `json:"name"`
+	WorkingDir     string `json:"working_dir"`
+	ConfigFile     string `json:"config_file"`
+	ContainerCount int    `json:"container_count"`
+	RunningCount   int    `json:"running_count"`
+}
+
+// DiscoveredStack is a compose file found on disk or from a running project that Stardeck doesn't manage yet
+type DiscoveredStack struct {
+	Name           string `json:"name"`
+	Path           string `json:"path"`         // Project directory
+	ComposeFile    string `json:"compose_file"` // Full path to the compose file
+	Source         string `json:"source"`       // filesystem or running
+	ContainerCount int    `json:"container_count"`
+	RunningCount   int    `json:"running_count"`
+}
+
+// ImportStackRequest represents a request to adopt an existing compose project as a stack
+type ImportStackRequest struct {
+	Name        string `json:"name,omitempty"` // Defaults to the project directory name
+	ComposeFile string `json:"compose_file"`   // Full path to the compose file
+	Description string `json:"description,omitempty"`
 }
 
 // CreateStackRequest represents the request to create a stack
 type CreateStackRequest struct {
-	Name           string `json:"name" validate:"required,min=1,max=64"`
-	Description    string `json:"description,omitempty"`
-	ComposeContent string `json:"compose_content" validate:"required"`
-	EnvContent     string `json:"env_content,omitempty"`
-	Deploy         bool   `json:"deploy"`
+	Name           string   `json:"name" validate:"required,min=1,max=64"`
+	Description    string   `json:"description,omitempty"`
+	ComposeContent string   `json:"compose_content" validate:"required"`
+	EnvContent     string   `json:"env_content,omitempty"`
+	Icon           string   `json:"icon,omitempty"`
+	Profiles       []string `json:"profiles,omitempty"`
+	Deploy         bool     `json:"deploy"`
 }
 
 // UpdateStackRequest represents the request to update a stack
 type UpdateStackRequest struct {
-	Name           *string `json:"name,omitempty"`
-	Description    *string `json:"description,omitempty"`
-	ComposeContent *string `json:"compose_content,omitempty"`
-	EnvContent     *string `json:"env_content,omitempty"`
+	Name           *string   `json:"name,omitempty"`
+	Description    *string   `json:"description,omitempty"`
+	ComposeContent *string   `json:"compose_content,omitempty"`
+	EnvContent     *string   `json:"env_content,omitempty"`
+	Icon           *string   `json:"icon,omitempty"`
+	Profiles       *[]string `json:"profiles,omitempty"`
 }
 
 // ContainerBackup represents a backup of container volumes before an update
@@ -396,6 +671,7 @@ type ContainerConfig struct {
 	Labels        map[string]string `json:"labels"`
 	RestartPolicy string            `json:"restart_policy"`
 	NetworkMode   string            `json:"network_mode"`
+	NetworkAliases []string         `json:"network_aliases"`
 	Hostname      string            `json:"hostname"`
 	User          string            `json:"user"`
 	WorkDir       string            `json:"workdir"`
@@ -403,6 +679,8 @@ type ContainerConfig struct {
 	Command       []string          `json:"command"`
 	CPULimit      float64           `json:"cpu_limit"`
 	MemoryLimit   int64             `json:"memory_limit"`
+	ContainerSecurityOptions
+	ContainerLogOptions
 	// Stardeck metadata
 	HasWebUI   bool   `json:"has_web_ui"`
 	WebUIPort  int    `json:"web_ui_port"`
@@ -423,6 +701,7 @@ const (
 	ActionContainerUpdate  = "container.update"
 	ActionContainerBackup  = "container.backup"
 	ActionContainerRestore = "container.restore"
+	ActionContainerAttach  = "container.attach"
 	ActionImagePull        = "image.pull"
 	ActionImageRemove      = "image.remove"
 	ActionTemplateCreate   = "template.create"
@@ -430,11 +709,24 @@ const (
 	ActionTemplateDeploy   = "template.deploy"
 	ActionVolumCreate      = "volume.create"
 	ActionVolumeRemove     = "volume.remove"
+	ActionBindMountRemove  = "bind_mount.remove"
 	ActionNetworkCreate    = "network.create"
 	ActionNetworkRemove    = "network.remove"
+	ActionNetworkUpdate    = "network.update"
 	ActionStackCreate      = "stack.create"
 	ActionStackUpdate      = "stack.update"
 	ActionStackDelete      = "stack.delete"
 	ActionStackDeploy      = "stack.deploy"
 	ActionStackStop        = "stack.stop"
+	ActionStackTeardown    = "stack.teardown"
+	ActionStackImport      = "stack.import"
+	ActionStackMigrate     = "stack.migrate"
+)
+
+// Audit actions for browsing and changing a volume's files
+const (
+	ActionVolumeExport     = "volume.export"
+	ActionVolumeFileUpload = "volume.file_upload"
+	ActionVolumeFileDelete = "volume.file_delete"
+	ActionVolumeMkdir      = "volume.mkdir"
 )
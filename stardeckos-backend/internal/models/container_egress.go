@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// EgressAction is what happens to outbound traffic matching a rule
+type EgressAction string
+
+const (
+	EgressAllow EgressAction = "allow"
+	EgressDeny  EgressAction = "deny"
+)
+
+// EgressRule matches outbound traffic by destination network
+type EgressRule struct {
+	CIDR    string       `json:"cidr"` // Destination network, or a single address
+	Action  EgressAction `json:"action"`
+	Comment string       `json:"comment,omitempty"`
+}
+
+// EgressPolicySpec limits where a container may connect to. Rules are
+// evaluated in order and the first match wins; then, when BlockInternet is
+// set, destinations outside private and link-local ranges are dropped; any
+// other traffic gets DefaultAction. Replies to inbound connections are
+// always allowed.
+type EgressPolicySpec struct {
+	DefaultAction EgressAction `json:"default_action"`
+	BlockInternet bool         `json:"block_internet"`
+	Rules         []EgressRule `json:"rules"`
+}
+
+// ContainerEgressPolicy is the outbound network policy of a Stardeck container
+type ContainerEgressPolicy struct {
+	ContainerID   string `json:"container_id"` // Stardeck container ID
+	ContainerName string `json:"container_name,omitempty"`
+	EgressPolicySpec
+	Enforced     bool      `json:"enforced"`                // Rules are loaded for the container's current addresses
+	EnforceError string    `json:"enforce_error,omitempty"` // Why the policy isn't enforced
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	UpdatedBy    string    `json:"updated_by"`
+}
+
+// Audit actions for container egress policies
+const (
+	ActionContainerEgressUpdate = "container.egress.update"
+	ActionContainerEgressDelete = "container.egress.delete"
+)
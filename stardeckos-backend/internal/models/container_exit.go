@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Why a container exited
+const (
+	ExitReasonOOM       = "oom"       // Killed by the kernel for exceeding its memory limit
+	ExitReasonStopped   = "stopped"   // Stopped or killed on request
+	ExitReasonCrashed   = "crashed"   // Exited on its own with a non-zero code
+	ExitReasonCompleted = "completed" // Exited on its own with code 0
+)
+
+// ContainerExit records one container exit observed on the Podman event stream.
+// History is keyed by container name so it survives recreation.
+type ContainerExit struct {
+	ID             int64     `json:"id"`
+	ContainerID    string    `json:"container_id"` // Podman container ID
+	ContainerName  string    `json:"container_name"`
+	Image          string    `json:"image"`
+	ExitCode       int       `json:"exit_code"`
+	OOMKilled      bool      `json:"oom_killed"`
+	Reason         string    `json:"reason"`
+	MemoryLimit    int64     `json:"memory_limit"` // Limit at the time of exit, 0 if unlimited
+	RuntimeSeconds int64     `json:"runtime_seconds"`
+	ExitedAt       time.Time `json:"exited_at"`
+}
+
+// ContainerReliability summarises a container's exit history
+type ContainerReliability struct {
+	ContainerName     string            `json:"container_name"`
+	TotalExits        int               `json:"total_exits"`
+	Crashes           int               `json:"crashes"`
+	OOMKills          int               `json:"oom_kills"`
+	Stops             int               `json:"stops"`
+	Failures24h       int               `json:"failures_24h"` // Crashes and OOM kills
+	Failures7d        int               `json:"failures_7d"`
+	OOMKills30d       int               `json:"oom_kills_30d"`
+	MeanTimeToFailure int64             `json:"mean_time_to_failure_seconds,omitempty"`
+	LastExitAt        *time.Time        `json:"last_exit_at,omitempty"`
+	LastFailureAt     *time.Time        `json:"last_failure_at,omitempty"`
+	MemorySuggestion  *MemorySuggestion `json:"memory_suggestion,omitempty"`
+}
+
+// MemorySuggestion flags a memory limit that keeps getting a container OOM killed
+type MemorySuggestion struct {
+	CurrentLimit   int64  `json:"current_limit"`
+	SuggestedLimit int64  `json:"suggested_limit"`
+	Reason         string `json:"reason"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// Health states podman reports for a container with a health check
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+	HealthStatusStarting  = "starting"
+)
+
+// What Stardeck does when a container turns unhealthy
+const (
+	HealthOnFailureNone    = "none"    // Flag it and notify
+	HealthOnFailureRestart = "restart" // Also restart it, a few times an hour at most
+)
+
+// ContainerHealthCheck defines a container's health check, mapped to podman's
+// --health-* flags. Without a command the image's own check, if any, is used
+// with the timings given here.
+type ContainerHealthCheck struct {
+	HealthCmd         string `json:"health_cmd,omitempty"`          // Run with the container's shell; "none" turns the image's check off
+	HealthInterval    string `json:"health_interval,omitempty"`     // Time between checks, e.g. "30s"
+	HealthTimeout     string `json:"health_timeout,omitempty"`      // How long a check may take
+	HealthRetries     int    `json:"health_retries,omitempty"`      // Failures in a row before the container is unhealthy
+	HealthStartPeriod string `json:"health_start_period,omitempty"` // Startup time in which failures don't count
+	HealthOnFailure   string `json:"health_on_failure,omitempty"`   // none or restart
+}
+
+// ContainerHealth is a container's health check state from podman inspect
+type ContainerHealth struct {
+	Status        string              `json:"status"` // healthy, unhealthy or starting; empty without a health check
+	FailingStreak int                 `json:"failing_streak"`
+	OnFailure     string              `json:"on_failure"` // What Stardeck does when the container turns unhealthy
+	Log           []HealthCheckResult `json:"log"`        // The last few checks, oldest first
+}
+
+// HealthCheckResult is one run of a container's health check
+type HealthCheckResult struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// ContainerLogOptions choose where a container's output goes and how large
+// its log file may grow, mapped to podman's --log-driver and --log-opt flags.
+// Empty fields take the host-wide default from the container log settings.
+type ContainerLogOptions struct {
+	LogDriver   string `json:"log_driver,omitempty"`    // k8s-file, journald, none or passthrough
+	LogMaxSize  string `json:"log_max_size,omitempty"`  // e.g. "10m"; k8s-file only
+	LogMaxFiles int    `json:"log_max_files,omitempty"` // Rotated files to keep, for drivers that rotate
+}
+
+// ContainerLogSettings is the host-wide default applied to containers created
+// without log options of their own
+type ContainerLogSettings struct {
+	Driver   string `json:"driver"`    // Empty for podman's own default
+	MaxSize  string `json:"max_size"`  // Empty for no cap
+	MaxFiles int    `json:"max_files"` // 0 to leave unset
+}
+
+// ContainerLogUsage reports how much disk a container's log is using
+type ContainerLogUsage struct {
+	ID           string `json:"id,omitempty"` // Stardeck container ID, empty if unmanaged
+	ContainerID  string `json:"container_id"`
+	Name         string `json:"name"`
+	Driver       string `json:"driver"`
+	Path         string `json:"path,omitempty"` // Empty for drivers that don't write a file
+	SizeBytes    int64  `json:"size_bytes"`
+	MaxSize      string `json:"max_size,omitempty"`
+	MaxSizeBytes int64  `json:"max_size_bytes,omitempty"`
+	MaxFiles     int    `json:"max_files,omitempty"`
+	Uncapped     bool   `json:"uncapped"` // Writes a file with no size cap
+}
+
+// ContainerLogUsageReport lists log usage across the host's containers, largest first
+type ContainerLogUsageReport struct {
+	Containers []ContainerLogUsage  `json:"containers"`
+	TotalBytes int64                `json:"total_bytes"`
+	Uncapped   int                  `json:"uncapped"`
+	Default    ContainerLogSettings `json:"default"`
+}
+
+// ActionContainerLogSettings is the audit action for changes to the host's log default
+const ActionContainerLogSettings = "system.container_logs"
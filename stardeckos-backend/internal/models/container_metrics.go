@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// MetricsCollectorSettings control the background sampling of host and
+// container stats into the metrics history. Samples older than
+// RawRetentionHours are averaged down to one per hour; the maintenance
+// metrics retention decides when they are removed.
+type MetricsCollectorSettings struct {
+	Enabled           bool   `json:"enabled"`
+	IntervalSeconds   int    `json:"interval_seconds"`
+	RawRetentionHours int    `json:"raw_retention_hours"`
+	LastRun           string `json:"last_run,omitempty"`
+	LastSampled       int    `json:"last_sampled"` // Containers stored by the last run
+}
+
+// ContainerMetricsPoint summarizes a container's samples over one step of
+// its history
+type ContainerMetricsPoint struct {
+	Timestamp   time.Time `json:"timestamp"` // Start of the step
+	Samples     int       `json:"samples"`
+	CPUAvg      float64   `json:"cpu_avg"`
+	CPUMax      float64   `json:"cpu_max"`
+	MemoryAvg   int64     `json:"memory_avg"`
+	MemoryMax   int64     `json:"memory_max"`
+	MemoryLimit int64     `json:"memory_limit"`
+	NetworkRx   int64     `json:"network_rx"` // I/O counters at the end of the step
+	NetworkTx   int64     `json:"network_tx"`
+	BlockRead   int64     `json:"block_read"`
+	BlockWrite  int64     `json:"block_write"`
+}
+
+// ContainerMetricsHistory is a container's metrics history in fixed steps
+type ContainerMetricsHistory struct {
+	ContainerID string                  `json:"container_id"`
+	Hours       int                     `json:"hours"`
+	StepSeconds int                     `json:"step_seconds"`
+	Points      []ContainerMetricsPoint `json:"points"`
+	CPUAvg      float64                 `json:"cpu_avg"` // Over the whole window
+	CPUMax      float64                 `json:"cpu_max"`
+	MemoryAvg   int64                   `json:"memory_avg"`
+	MemoryMax   int64                   `json:"memory_max"`
+}
+
+// ActionMetricsCollectorSettings is the audit action for metrics collector changes
+const ActionMetricsCollectorSettings = "system.metrics_collector"
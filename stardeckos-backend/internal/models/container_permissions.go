@@ -0,0 +1,76 @@
+package models
+
+// Runtime user sources, in the order they are checked
+const (
+	RuntimeUserPUID    = "puid"    // PUID/PGID environment, used by linuxserver.io style images
+	RuntimeUserConfig  = "user"    // The user the image or container is set to run as
+	RuntimeUserDefault = "default" // No user set, so root
+)
+
+// ContainerRuntimeUser is the user a container's process writes files as
+type ContainerRuntimeUser struct {
+	Source  string `json:"source"`
+	User    string `json:"user,omitempty"` // As configured, e.g. "abc" or "1000:1000"
+	UID     int    `json:"uid"`            // Inside the container
+	GID     int    `json:"gid"`
+	HostUID int    `json:"host_uid"` // After user namespace mapping
+	HostGID int    `json:"host_gid"`
+	Mapped  bool   `json:"mapped"` // Host IDs were read from the running container's user namespace
+}
+
+// MountPermission is a bind mount checked against the container's runtime user
+type MountPermission struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	ReadOnly    bool   `json:"read_only"`
+	Exists      bool   `json:"exists"`
+	IsDir       bool   `json:"is_dir"`
+	OwnerUID    int    `json:"owner_uid"`
+	OwnerGID    int    `json:"owner_gid"`
+	Mode        string `json:"mode"` // Octal, e.g. 0755
+	Readable    bool   `json:"readable"`
+	Writable    bool   `json:"writable"`
+	Problem     string `json:"problem,omitempty"`
+	Protected   bool   `json:"protected"` // A system path that is never changed
+}
+
+// ContainerPermissionReport lists a container's bind mounts and whether its
+// runtime user can use them
+type ContainerPermissionReport struct {
+	ContainerID string               `json:"container_id"`
+	Name        string               `json:"name"`
+	User        ContainerRuntimeUser `json:"user"`
+	Mounts      []MountPermission    `json:"mounts"`
+	Warnings    []string             `json:"warnings,omitempty"`
+}
+
+// ContainerPermissionFixRequest re-owns a container's bind mounts to its
+// runtime user. It is a dry run unless dry_run is false.
+type ContainerPermissionFixRequest struct {
+	DryRun    *bool    `json:"dry_run,omitempty"`   // Default true
+	Mounts    []string `json:"mounts,omitempty"`    // Destinations to fix; default every mount with a problem
+	Recursive *bool    `json:"recursive,omitempty"` // Default true
+	UID       *int     `json:"uid,omitempty"`       // Host owner; default the runtime user's host UID
+	GID       *int     `json:"gid,omitempty"`
+	Mode      string   `json:"mode,omitempty"` // Octal directory mode; default only grants the owner access
+}
+
+// MountPermissionFix reports what a fix changed on one mount
+type MountPermissionFix struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	Changed     int      `json:"changed"` // Files and directories changed, or that would change on a dry run
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// ContainerPermissionFixResult reports a permission fix on a container
+type ContainerPermissionFixResult struct {
+	DryRun bool                 `json:"dry_run"`
+	UID    int                  `json:"uid"`
+	GID    int                  `json:"gid"`
+	Mode   string               `json:"mode,omitempty"`
+	Mounts []MountPermissionFix `json:"mounts"`
+}
+
+// ActionContainerPermissionsFix is the audit action for re-owning a container's bind mounts
+const ActionContainerPermissionsFix = "container.permissions_fix"
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Container schedule actions
+const (
+	ScheduleActionStart = "start"
+	ScheduleActionStop  = "stop"
+)
+
+// ContainerSchedule powers a container on and off on cron schedules evaluated
+// in a timezone, e.g. start at 07:00 and stop at 22:00 on weekdays. A manual
+// start or stop, or an explicit hold, is tracked as an override: scheduled
+// actions are skipped until OverrideUntil passes.
+type ContainerSchedule struct {
+	ContainerID    string     `json:"container_id"` // Stardeck container ID
+	ContainerName  string     `json:"container_name,omitempty"`
+	Enabled        bool       `json:"enabled"`
+	StartSchedule  string     `json:"start_schedule,omitempty"` // Cron expression, empty to never start
+	StopSchedule   string     `json:"stop_schedule,omitempty"`  // Cron expression, empty to never stop
+	Timezone       string     `json:"timezone"`                 // IANA zone, e.g. Europe/Berlin
+	OverrideAction string     `json:"override_action,omitempty"`
+	OverrideBy     string     `json:"override_by,omitempty"`
+	OverrideAt     *time.Time `json:"override_at,omitempty"`
+	OverrideUntil  *time.Time `json:"override_until,omitempty"`
+	LastAction     string     `json:"last_action,omitempty"`
+	LastActionAt   *time.Time `json:"last_action_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextStartAt    *time.Time `json:"next_start_at,omitempty"` // Computed from schedule, not stored
+	NextStopAt     *time.Time `json:"next_stop_at,omitempty"`  // Computed from schedule, not stored
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	CreatedBy      *int64     `json:"created_by,omitempty"`
+}
+
+// UpdateContainerScheduleRequest creates or replaces a container's power schedule
+type UpdateContainerScheduleRequest struct {
+	Enabled       *bool  `json:"enabled,omitempty"`
+	StartSchedule string `json:"start_schedule"`
+	StopSchedule  string `json:"stop_schedule"`
+	Timezone      string `json:"timezone"`
+}
+
+// ScheduleOverrideRequest holds the container's current state until a time
+type ScheduleOverrideRequest struct {
+	Until time.Time `json:"until"`
+}
+
+// Audit actions for container schedules
+const (
+	ActionContainerScheduleUpdate   = "container_schedule.update"
+	ActionContainerScheduleDelete   = "container_schedule.delete"
+	ActionContainerScheduleOverride = "container_schedule.override"
+	ActionContainerScheduleRun      = "container_schedule.run"
+)
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Data pool purposes decide which apps a pool is wired into on template deploys
+const (
+	DataPoolMedia     = "media"     // Libraries read by media servers and managed by the *arr apps
+	DataPoolDownloads = "downloads" // Torrent and Usenet client output picked up by the *arr apps
+	DataPoolGeneral   = "general"   // Never wired automatically
+)
+
+// DataPool is a shared host directory that several containers mount, such as
+// a media library or a downloads folder. Its quota is soft: usage is measured
+// periodically and admins are alerted when it is exceeded.
+type DataPool struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	Purpose       string     `json:"purpose"`
+	Path          string     `json:"path"`           // Host directory
+	ContainerPath string     `json:"container_path"` // Where containers see it, e.g. /data/media
+	QuotaBytes    int64      `json:"quota_bytes"`    // 0 for no quota
+	UID           int        `json:"uid"`            // Owner, passed to containers as PUID
+	GID           int        `json:"gid"`            // Group, passed to containers as PGID
+	Mode          string     `json:"mode"`           // Octal directory mode, e.g. 2775
+	Umask         string     `json:"umask"`          // Passed to containers as UMASK, e.g. 002
+	UsedBytes     int64      `json:"used_bytes"`
+	MeasuredAt    *time.Time `json:"measured_at,omitempty"`
+	OverQuota     bool       `json:"over_quota"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CreatedBy     *int64     `json:"created_by,omitempty"`
+}
+
+// DataPoolRequest creates or replaces a data pool. Template fills in any
+// ownership fields left unset.
+type DataPoolRequest struct {
+	Name          string `json:"name"` // Fixed once created
+	Purpose       string `json:"purpose"`
+	Path          string `json:"path,omitempty"` // Defaults to pools/<name> under the app data root
+	ContainerPath string `json:"container_path,omitempty"`
+	QuotaBytes    int64  `json:"quota_bytes"`
+	Template      string `json:"template,omitempty"`
+	UID           *int   `json:"uid,omitempty"`
+	GID           *int   `json:"gid,omitempty"`
+	Mode          string `json:"mode,omitempty"`
+	Umask         string `json:"umask,omitempty"`
+}
+
+// DataPoolPermissionTemplate is a preset ownership and mode for a pool
+type DataPoolPermissionTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	UID         int    `json:"uid"`
+	GID         int    `json:"gid"`
+	Mode        string `json:"mode"`
+	Umask       string `json:"umask"`
+}
+
+// DataPoolPermissionResult reports a permission fix on a pool
+type DataPoolPermissionResult struct {
+	Changed int      `json:"changed"` // Files and directories whose owner or mode changed
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// DataPoolWiring records which pools were mounted into which services of a stack
+type DataPoolWiring struct {
+	Service string   `json:"service"`
+	Pools   []string `json:"pools"`
+}
+
+// Audit actions for data pools
+const (
+	ActionDataPoolCreate      = "data_pool.create"
+	ActionDataPoolUpdate      = "data_pool.update"
+	ActionDataPoolDelete      = "data_pool.delete"
+	ActionDataPoolPermissions = "data_pool.permissions"
+)
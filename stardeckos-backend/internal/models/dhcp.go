@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// DHCPScope is an address range handed out on one interface
+type DHCPScope struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Interface  string    `json:"interface"`
+	RangeStart string    `json:"range_start"`
+	RangeEnd   string    `json:"range_end"`
+	Netmask    string    `json:"netmask"`
+	LeaseTime  int       `json:"lease_time"`  // Seconds
+	Gateway    string    `json:"gateway"`     // Empty to advertise the host itself
+	DNSServers []string  `json:"dns_servers"` // Empty to pass on the host's resolvers
+	DomainName string    `json:"domain_name"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// DHCPReservation pins an address to a client's MAC address
+type DHCPReservation struct {
+	ID          int64     `json:"id"`
+	ScopeID     int64     `json:"scope_id"`
+	MAC         string    `json:"mac"`
+	IPAddress   string    `json:"ip_address"`
+	Hostname    string    `json:"hostname"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DHCPLease is an address the server has handed out
+type DHCPLease struct {
+	MAC       string     `json:"mac"`
+	IPAddress string     `json:"ip_address"`
+	Hostname  string     `json:"hostname"`
+	ClientID  string     `json:"client_id"`
+	Expires   *time.Time `json:"expires,omitempty"` // Nil for leases that never expire
+	ScopeID   int64      `json:"scope_id,omitempty"`
+	Reserved  bool       `json:"reserved"`
+}
+
+// DHCPInterface reports whether the server answers on an interface
+type DHCPInterface struct {
+	Name    string   `json:"name"`
+	State   string   `json:"state"`
+	IPv4    []string `json:"ipv4"`
+	Enabled bool     `json:"enabled"`
+	Scopes  int      `json:"scopes"`
+}
+
+// DHCPStatus is the state of the DHCP server
+type DHCPStatus struct {
+	Installed  bool            `json:"installed"`
+	Running    bool            `json:"running"`
+	Version    string          `json:"version,omitempty"`
+	ConfigPath string          `json:"config_path"`
+	Interfaces []DHCPInterface `json:"interfaces"`
+}
+
+// SetDHCPInterfaceRequest turns the DHCP server on or off for an interface
+type SetDHCPInterfaceRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Audit actions for the DHCP server
+const (
+	ActionDHCPInstall           = "network.dhcp.install"
+	ActionDHCPInterface         = "network.dhcp.interface"
+	ActionDHCPScopeCreate       = "network.dhcp.scope.create"
+	ActionDHCPScopeUpdate       = "network.dhcp.scope.update"
+	ActionDHCPScopeDelete       = "network.dhcp.scope.delete"
+	ActionDHCPReservationCreate = "network.dhcp.reservation.create"
+	ActionDHCPReservationDelete = "network.dhcp.reservation.delete"
+)
@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// How often a digest is sent
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Sections a digest can hold
+const (
+	DigestSectionUpdates  = "updates"
+	DigestSectionBackups  = "backups"
+	DigestSectionDisk     = "disk"
+	DigestSectionRestarts = "restarts"
+	DigestSectionSecurity = "security" // Admins only
+)
+
+// DigestSections lists every section in the order a digest shows them
+var DigestSections = []string{
+	DigestSectionUpdates,
+	DigestSectionBackups,
+	DigestSectionDisk,
+	DigestSectionRestarts,
+	DigestSectionSecurity,
+}
+
+// DigestSubscription is a user's choice of digest
+type DigestSubscription struct {
+	UserID       int64      `json:"user_id"`
+	Frequency    string     `json:"frequency"`
+	Hour         int        `json:"hour"`    // Local hour the digest goes out, 0-23
+	Weekday      int        `json:"weekday"` // Day of a weekly digest, 0 is Sunday
+	Sections     []string   `json:"sections"`
+	Email        bool       `json:"email"`        // Send to the account's email address
+	Notification bool       `json:"notification"` // Post to the desktop notifications
+	LastSentAt   *time.Time `json:"last_sent_at,omitempty"`
+	DiskBaseline string     `json:"-"` // JSON of bytes used per mount when the last digest went out
+}
+
+// DigestUpdates summarises pending package updates
+type DigestUpdates struct {
+	Available int      `json:"available"`
+	Security  int      `json:"security"`
+	Packages  []string `json:"packages"` // Security updates first, capped
+	Error     string   `json:"error,omitempty"`
+}
+
+// DigestBackups summarises the backups and snapshots taken in the period
+type DigestBackups struct {
+	ContainerBackups []string `json:"container_backups"` // Containers backed up before an update
+	Snapshots        []string `json:"snapshots"`         // Host filesystem snapshots, as mount: reason
+}
+
+// DigestDiskUsage is one filesystem's usage and how it changed in the period
+type DigestDiskUsage struct {
+	MountPoint string  `json:"mount_point"`
+	Total      uint64  `json:"total"`
+	Used       uint64  `json:"used"`
+	UsePercent float64 `json:"use_percent"`
+	Change     int64   `json:"change"`     // Bytes since the previous digest
+	HasChange  bool    `json:"has_change"` // False for the first digest or a new mount
+}
+
+// DigestRestart counts a container's exits in the period
+type DigestRestart struct {
+	Container string `json:"container"`
+	Crashes   int    `json:"crashes"`
+	OOMKills  int    `json:"oom_kills"`
+	Stops     int    `json:"stops"`
+}
+
+// DigestSecurity summarises security events in the period
+type DigestSecurity struct {
+	Logins         int      `json:"logins"`
+	FailedLogins   int      `json:"failed_logins"`
+	SessionRevokes int      `json:"session_revokes"`
+	UserChanges    int      `json:"user_changes"`
+	FirewallEvents int      `json:"firewall_events"`
+	FailedFrom     []string `json:"failed_from"` // Addresses with failed sign-ins, most first
+}
+
+// Digest is one digest report
+type Digest struct {
+	Frequency string            `json:"frequency"`
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Updates   *DigestUpdates    `json:"updates,omitempty"`
+	Backups   *DigestBackups    `json:"backups,omitempty"`
+	Disk      []DigestDiskUsage `json:"disk,omitempty"`
+	Restarts  []DigestRestart   `json:"restarts,omitempty"`
+	Security  *DigestSecurity   `json:"security,omitempty"`
+}
+
+// NotificationDigest delivers a digest to the desktop
+const NotificationDigest = "digest"
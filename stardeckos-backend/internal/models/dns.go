@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// DNSRecordType is the kind of a local DNS record
+type DNSRecordType string
+
+const (
+	DNSRecordA     DNSRecordType = "A"
+	DNSRecordAAAA  DNSRecordType = "AAAA"
+	DNSRecordCNAME DNSRecordType = "CNAME"
+)
+
+// DNSRecord is a record the local resolver answers for the LAN
+type DNSRecord struct {
+	ID        int64         `json:"id"`
+	Name      string        `json:"name"`
+	Type      DNSRecordType `json:"type"`
+	Value     string        `json:"value"` // An address, or the target name of a CNAME
+	Comment   string        `json:"comment"`
+	Container string        `json:"container,omitempty"` // Set on records generated for containers
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// DNSSettings configures the local resolver. It also answers on every
+// interface DHCP is enabled on.
+type DNSSettings struct {
+	Enabled     bool     `json:"enabled"`
+	Interfaces  []string `json:"interfaces"` // Interfaces to answer on besides loopback
+	Upstreams   []string `json:"upstreams"`  // Forwarders; empty to use the host's resolv.conf
+	Domain      string   `json:"domain"`     // Local domain that is never forwarded, e.g. "lab"
+	AutoRecords bool     `json:"auto_records"`
+	HostAddress string   `json:"host_address"` // Address container records point at; empty to detect
+	CacheSize   int      `json:"cache_size"`
+	Blocklists  []string `json:"blocklists"` // URLs of hosts-format ad-block lists
+}
+
+// DNSBlocklist is the state of a downloaded ad-block list
+type DNSBlocklist struct {
+	URL       string     `json:"url"`
+	Domains   int        `json:"domains"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// DNSStatus is the state of the local resolver
+type DNSStatus struct {
+	Installed   bool           `json:"installed"`
+	Running     bool           `json:"running"`
+	Version     string         `json:"version,omitempty"`
+	ConfigPath  string         `json:"config_path"`
+	Settings    DNSSettings    `json:"settings"`
+	Blocklists  []DNSBlocklist `json:"blocklists"`
+	AutoRecords []DNSRecord    `json:"auto_records"`
+}
+
+// Audit actions for the local resolver
+const (
+	ActionDNSInstall         = "network.dns.install"
+	ActionDNSConfigure       = "network.dns.configure"
+	ActionDNSRecordCreate    = "network.dns.record.create"
+	ActionDNSRecordUpdate    = "network.dns.record.update"
+	ActionDNSRecordDelete    = "network.dns.record.delete"
+	ActionDNSBlocklistUpdate = "network.dns.blocklist.update"
+)
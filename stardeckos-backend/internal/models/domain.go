@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// DomainInfo describes an AD or FreeIPA domain as reported by realmd
+type DomainInfo struct {
+	Domain           string   `json:"domain"`
+	Realm            string   `json:"realm,omitempty"`           // Kerberos realm, e.g. EXAMPLE.COM
+	Type             string   `json:"type,omitempty"`            // kerberos
+	ServerSoftware   string   `json:"server_software,omitempty"` // active-directory or ipa
+	ClientSoftware   string   `json:"client_software,omitempty"` // sssd
+	Configured       bool     `json:"configured"`                // True once this host is joined
+	LoginFormats     []string `json:"login_formats,omitempty"`
+	LoginPolicy      string   `json:"login_policy,omitempty"`
+	PermittedLogins  []string `json:"permitted_logins,omitempty"`
+	PermittedGroups  []string `json:"permitted_groups,omitempty"`
+	RequiredPackages []string `json:"required_packages,omitempty"`
+}
+
+// DomainStatus is the host's domain membership
+type DomainStatus struct {
+	Available  bool         `json:"available"` // realmd is installed
+	Joined     bool         `json:"joined"`
+	Domains    []DomainInfo `json:"domains"`
+	SSSDActive bool         `json:"sssd_active"`
+	PAMLogins  bool         `json:"pam_logins"` // Domain users sign in through the PAM login method
+}
+
+// JoinDomainRequest joins the host to a domain with an account allowed to enroll computers
+type JoinDomainRequest struct {
+	Domain       string `json:"domain"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ComputerOU   string `json:"computer_ou,omitempty"` // AD only, e.g. OU=Servers,DC=example,DC=com
+	ComputerName string `json:"computer_name,omitempty"`
+}
+
+// LeaveDomainRequest removes the host from a domain. With credentials the computer
+// account is deleted from the directory as well.
+type LeaveDomainRequest struct {
+	Domain   string `json:"domain"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// DomainRoleMapping grants a Stardeck role to members of a domain (or local) group
+// when they sign in with their system account
+type DomainRoleMapping struct {
+	ID        int64     `json:"id"`
+	GroupName string    `json:"group_name"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateDomainRoleMappingRequest adds a group to role mapping
+type CreateDomainRoleMappingRequest struct {
+	GroupName string `json:"group_name"`
+	Role      Role   `json:"role"`
+}
+
+// Domain audit actions
+const (
+	ActionDomainJoin          = "domain.join"
+	ActionDomainLeave         = "domain.leave"
+	ActionDomainMappingCreate = "domain.mapping_create"
+	ActionDomainMappingDelete = "domain.mapping_delete"
+)
+
+// KerberosSettings configures Kerberos/SPNEGO single sign-on for the web UI
+type KerberosSettings struct {
+	Enabled     bool     `json:"enabled"`
+	Keytab      string   `json:"keytab"`
+	Realms      []string `json:"realms"`                 // Trusted client realms; empty trusts the keytab's realms
+	Principals  []string `json:"principals,omitempty"`   // Service principals found in the keytab
+	KeytabError string   `json:"keytab_error,omitempty"` // Why the keytab could not be read
+}
+
+// ActionKerberosSettings records a change to Kerberos sign-on settings
+const ActionKerberosSettings = "auth.kerberos_settings"
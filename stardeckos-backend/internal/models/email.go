@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+// SMTP connection security modes
+const (
+	SMTPSecurityNone     = "none"     // Plain SMTP, e.g. a local relay
+	SMTPSecurityStartTLS = "starttls" // Upgrade with STARTTLS, usually port 587
+	SMTPSecurityTLS      = "tls"      // Implicit TLS, usually port 465
+)
+
+// EmailSettings configures outgoing mail. The SMTP password is kept in the
+// Podman secret store, never in the database.
+type EmailSettings struct {
+	Enabled         bool   `json:"enabled"`
+	SMTPHost        string `json:"smtp_host"`
+	SMTPPort        int    `json:"smtp_port"`
+	SMTPSecurity    string `json:"smtp_security"`
+	SMTPUsername    string `json:"smtp_username"`
+	PasswordSet     bool   `json:"password_set"`
+	From            string `json:"from"`       // e.g. "Stardeck <stardeck@example.com>"
+	PublicURL       string `json:"public_url"` // Base URL of the web UI used in email links
+	InviteTTLHours  int    `json:"invite_ttl_hours"`
+	ResetTTLMinutes int    `json:"reset_ttl_minutes"`
+}
+
+// UpdateEmailSettingsRequest replaces the email settings. Password is left
+// unchanged when omitted and removed when empty.
+type UpdateEmailSettingsRequest struct {
+	EmailSettings
+	Password *string `json:"password,omitempty"`
+}
+
+// EmailTemplate is the subject and body of one kind of email, written with
+// text/template placeholders such as {{.Username}} and {{.Link}}
+type EmailTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Subject     string `json:"subject"`
+	Body        string `json:"body"`
+	Customized  bool   `json:"customized"`
+}
+
+// UpdateEmailTemplateRequest overrides a template; empty fields restore the default
+type UpdateEmailTemplateRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// TestEmailRequest sends a test message to check the SMTP settings
+type TestEmailRequest struct {
+	To string `json:"to"`
+}
+
+// UserInvitation is an emailed link that lets someone create their own local account
+type UserInvitation struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	DisplayName    string     `json:"display_name,omitempty"`
+	Role           Role       `json:"role"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	AcceptedUserID *int64     `json:"accepted_user_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CreatedBy      *int64     `json:"created_by,omitempty"`
+}
+
+// CreateInvitationRequest invites someone by email
+type CreateInvitationRequest struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name"`
+	Role        Role   `json:"role"`
+}
+
+// InvitationTokenRequest identifies an invitation by the token from its link
+type InvitationTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// AcceptInvitationRequest creates the invited account
+type AcceptInvitationRequest struct {
+	Token       string `json:"token"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Password    string `json:"password"`
+}
+
+// PasswordReset is a single-use token for setting a new password
+type PasswordReset struct {
+	UserID    int64
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// RequestPasswordResetRequest asks for a reset link by username or email
+type RequestPasswordResetRequest struct {
+	Login string `json:"login"`
+}
+
+// ConfirmPasswordResetRequest sets a new password with the token from a reset link
+type ConfirmPasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Audit actions for email, invitations and password resets
+const (
+	ActionEmailSettings       = "email.settings"
+	ActionEmailTemplate       = "email.template"
+	ActionInvitationCreate    = "invitation.create"
+	ActionInvitationResend    = "invitation.resend"
+	ActionInvitationRevoke    = "invitation.revoke"
+	ActionInvitationAccept    = "invitation.accept"
+	ActionPasswordResetSend   = "password_reset.send"
+	ActionPasswordResetFinish = "password_reset.complete"
+)
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// ExecTaskStatus represents the outcome of an exec task run
+type ExecTaskStatus string
+
+const (
+	ExecTaskStatusSuccess ExecTaskStatus = "success"
+	ExecTaskStatusFailed  ExecTaskStatus = "failed"
+	ExecTaskStatusTimeout ExecTaskStatus = "timeout"
+	ExecTaskStatusRunning ExecTaskStatus = "running"
+)
+
+// ExecTask is a recurring command run inside a container on a cron schedule
+type ExecTask struct {
+	ID              string         `json:"id"`
+	ContainerID     string         `json:"container_id"` // Stardeck container ID
+	Name            string         `json:"name"`
+	Command         string         `json:"command"`  // Run via /bin/sh -c inside the container
+	Schedule        string         `json:"schedule"` // Cron expression (5 fields or @macro)
+	Enabled         bool           `json:"enabled"`
+	TimeoutSeconds  int            `json:"timeout_seconds"`
+	NotifyOnFailure bool           `json:"notify_on_failure"`
+	LastRunAt       *time.Time     `json:"last_run_at,omitempty"`
+	LastStatus      ExecTaskStatus `json:"last_status,omitempty"`
+	LastExitCode    *int           `json:"last_exit_code,omitempty"`
+	NextRunAt       *time.Time     `json:"next_run_at,omitempty"` // Computed from schedule, not stored
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	CreatedBy       *int64         `json:"created_by,omitempty"`
+}
+
+// ExecTaskRun records a single execution of an exec task
+type ExecTaskRun struct {
+	ID         int64          `json:"id"`
+	TaskID     string         `json:"task_id"`
+	Trigger    string         `json:"trigger"` // schedule or manual
+	Status     ExecTaskStatus `json:"status"`
+	ExitCode   int            `json:"exit_code"`
+	Output     string         `json:"output"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+}
+
+// CreateExecTaskRequest represents a request to create an exec task
+type CreateExecTaskRequest struct {
+	Name            string `json:"name"`
+	Command         string `json:"command"`
+	Schedule        string `json:"schedule"`
+	Enabled         *bool  `json:"enabled,omitempty"`
+	TimeoutSeconds  int    `json:"timeout_seconds,omitempty"`
+	NotifyOnFailure bool   `json:"notify_on_failure"`
+}
+
+// UpdateExecTaskRequest represents a request to update an exec task
+type UpdateExecTaskRequest struct {
+	Name            *string `json:"name,omitempty"`
+	Command         *string `json:"command,omitempty"`
+	Schedule        *string `json:"schedule,omitempty"`
+	Enabled         *bool   `json:"enabled,omitempty"`
+	TimeoutSeconds  *int    `json:"timeout_seconds,omitempty"`
+	NotifyOnFailure *bool   `json:"notify_on_failure,omitempty"`
+}
+
+// Audit action constants for exec tasks
+const (
+	ActionExecTaskCreate  = "exec_task.create"
+	ActionExecTaskUpdate  = "exec_task.update"
+	ActionExecTaskDelete  = "exec_task.delete"
+	ActionExecTaskRun     = "exec_task.run"
+	ActionExecTaskFailure = "exec_task.failure"
+)
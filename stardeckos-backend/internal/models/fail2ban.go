@@ -0,0 +1,48 @@
+package models
+
+// Fail2banSettings configures the jails Stardeck manages. The stardeck jail
+// watches the backend's own sign-in failure log, so repeated failed web logins
+// are banned at the firewall just like failed SSH logins.
+type Fail2banSettings struct {
+	SSHDEnabled     bool     `json:"sshd_enabled"`
+	StardeckEnabled bool     `json:"stardeck_enabled"`
+	MaxRetry        int      `json:"max_retry"`
+	FindTime        int      `json:"find_time"` // Seconds in which MaxRetry failures trigger a ban
+	BanTime         int      `json:"ban_time"`  // Seconds, or -1 to ban permanently
+	IgnoreIP        []string `json:"ignore_ip"` // Addresses and CIDRs that are never banned
+}
+
+// Fail2banJail is the live state of one jail
+type Fail2banJail struct {
+	Name            string   `json:"name"`
+	CurrentlyFailed int      `json:"currently_failed"`
+	TotalFailed     int      `json:"total_failed"`
+	CurrentlyBanned int      `json:"currently_banned"`
+	TotalBanned     int      `json:"total_banned"`
+	BannedIPs       []string `json:"banned_ips"`
+}
+
+// Fail2banStatus is what the security dashboard shows about intrusion detection
+type Fail2banStatus struct {
+	Installed          bool             `json:"installed"`
+	Running            bool             `json:"running"`
+	Version            string           `json:"version,omitempty"`
+	Settings           Fail2banSettings `json:"settings"`
+	Jails              []Fail2banJail   `json:"jails"`
+	CurrentlyBanned    int              `json:"currently_banned"`
+	FailedLogins24h    int              `json:"failed_logins_24h"` // Stardeck sign-in failures in the audit log
+	AuthFailureLogPath string           `json:"auth_failure_log_path"`
+}
+
+// Fail2banUnbanRequest lifts a ban. An empty Jail unbans the IP from every jail.
+type Fail2banUnbanRequest struct {
+	IP   string `json:"ip"`
+	Jail string `json:"jail"`
+}
+
+// Audit actions for intrusion detection
+const (
+	ActionFail2banInstall   = "security.fail2ban.install"
+	ActionFail2banConfigure = "security.fail2ban.configure"
+	ActionFail2banUnban     = "security.fail2ban.unban"
+)
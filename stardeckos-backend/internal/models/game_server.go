@@ -0,0 +1,122 @@
+package models
+
+import "time"
+
+// Game server player-count probes
+const (
+	GameProbeNone = "none"
+	GameProbeRCON = "rcon" // Run PlayerCommand over RCON and match PlayerPattern
+	GameProbeA2S  = "a2s"  // Steam A2S_INFO query over UDP
+)
+
+// GameServerPreset holds the defaults for a known game server image
+type GameServerPreset struct {
+	ID              string        `json:"id"`
+	Name            string        `json:"name"`
+	Description     string        `json:"description"`
+	Image           string        `json:"image"`
+	Ports           []PortMapping `json:"ports"`
+	RCONPort        int           `json:"rcon_port,omitempty"`         // Container port
+	RCONPasswordEnv string        `json:"rcon_password_env,omitempty"` // Container env var holding the RCON password
+	Probe           string        `json:"probe"`
+	QueryPort       int           `json:"query_port,omitempty"` // Container UDP port answering A2S queries
+	PlayerCommand   string        `json:"player_command,omitempty"`
+	PlayerPattern   string        `json:"player_pattern,omitempty"`
+	WarningCommand  string        `json:"warning_command,omitempty"`
+	SaveCommand     string        `json:"save_command,omitempty"`
+}
+
+// GameServerProfile adds game server runtime behaviour to a container:
+// cron-scheduled restarts announced over RCON beforehand, a player-count
+// probe, and stopping the server once it has been empty for a while.
+// WarningCommand may contain {minutes}, replaced with the time left.
+type GameServerProfile struct {
+	ContainerID         string     `json:"container_id"` // Stardeck container ID
+	ContainerName       string     `json:"container_name,omitempty"`
+	Preset              string     `json:"preset,omitempty"`
+	Enabled             bool       `json:"enabled"`
+	RCONPort            int        `json:"rcon_port,omitempty"` // Container port, 0 when the game has no RCON
+	RCONPassword        string     `json:"-"`
+	RCONPasswordEnv     string     `json:"rcon_password_env,omitempty"` // Read from the container when no password is stored
+	RCONPasswordSet     bool       `json:"rcon_password_set"`
+	Probe               string     `json:"probe"`
+	QueryPort           int        `json:"query_port,omitempty"`
+	PlayerCommand       string     `json:"player_command,omitempty"`
+	PlayerPattern       string     `json:"player_pattern,omitempty"`   // First group is the player count, optional second the maximum
+	RestartSchedule     string     `json:"restart_schedule,omitempty"` // Cron expression, empty to never restart
+	Timezone            string     `json:"timezone"`
+	RestartWarnings     []int      `json:"restart_warnings"` // Minutes before a restart to warn players
+	WarningCommand      string     `json:"warning_command,omitempty"`
+	SaveCommand         string     `json:"save_command,omitempty"` // Sent over RCON before a restart or idle shutdown
+	IdleShutdownMinutes int        `json:"idle_shutdown_minutes"`  // 0 never stops an empty server
+	Players             *int       `json:"players,omitempty"`
+	MaxPlayers          int        `json:"max_players,omitempty"`
+	LastProbeAt         *time.Time `json:"last_probe_at,omitempty"`
+	ProbeError          string     `json:"probe_error,omitempty"`
+	IdleSince           *time.Time `json:"idle_since,omitempty"`
+	LastAction          string     `json:"last_action,omitempty"`
+	LastActionAt        *time.Time `json:"last_action_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	NextRestartAt       *time.Time `json:"next_restart_at,omitempty"` // Computed from schedule, not stored
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	CreatedBy           *int64     `json:"created_by,omitempty"`
+}
+
+// GameServerProfileRequest creates or replaces a container's game server profile.
+// Fields left empty take the preset's value. The RCON password is left
+// unchanged when omitted and removed when empty.
+type GameServerProfileRequest struct {
+	Preset              string  `json:"preset"`
+	Enabled             *bool   `json:"enabled,omitempty"`
+	RCONPort            int     `json:"rcon_port"`
+	RCONPassword        *string `json:"rcon_password,omitempty"`
+	RCONPasswordEnv     string  `json:"rcon_password_env"`
+	Probe               string  `json:"probe"`
+	QueryPort           int     `json:"query_port"`
+	PlayerCommand       string  `json:"player_command"`
+	PlayerPattern       string  `json:"player_pattern"`
+	RestartSchedule     string  `json:"restart_schedule"`
+	Timezone            string  `json:"timezone"`
+	RestartWarnings     []int   `json:"restart_warnings"`
+	WarningCommand      string  `json:"warning_command"`
+	SaveCommand         string  `json:"save_command"`
+	IdleShutdownMinutes int     `json:"idle_shutdown_minutes"`
+}
+
+// GameServerPlayers is the result of a player-count probe
+type GameServerPlayers struct {
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"max_players,omitempty"`
+	Name       string `json:"name,omitempty"` // Server name, from A2S
+	Map        string `json:"map,omitempty"`
+}
+
+// RCONCommandRequest runs one command on a game server's console
+type RCONCommandRequest struct {
+	Command string `json:"command"`
+}
+
+// HostPortStatus reports whether a host port is free to publish
+type HostPortStatus struct {
+	Port      int    `json:"port"`
+	Protocol  string `json:"protocol"`
+	Available bool   `json:"available"`
+	UsedBy    string `json:"used_by,omitempty"` // Container publishing the port, if any
+}
+
+// Game server actions, as recorded in LastAction
+const (
+	GameServerActionWarn     = "warn"
+	GameServerActionRestart  = "restart"
+	GameServerActionIdleStop = "idle_stop"
+)
+
+// Audit actions for game servers
+const (
+	ActionGameServerUpdate   = "game_server.update"
+	ActionGameServerDelete   = "game_server.delete"
+	ActionGameServerRestart  = "game_server.restart"
+	ActionGameServerIdleStop = "game_server.idle_stop"
+	ActionGameServerRCON     = "game_server.rcon"
+)
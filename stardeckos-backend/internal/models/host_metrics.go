@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// HostMetrics is one sample of the host's health. CPU and disk figures are
+// rates since the previous sample.
+type HostMetrics struct {
+	ID              int64            `json:"id,omitempty"`
+	Timestamp       time.Time        `json:"timestamp"`
+	CPUPercent      float64          `json:"cpu_percent"`
+	IOWaitPercent   float64          `json:"iowait_percent"`
+	MemoryTotal     int64            `json:"memory_total"`
+	MemoryUsed      int64            `json:"memory_used"`
+	MemoryAvailable int64            `json:"memory_available"`
+	SwapTotal       int64            `json:"swap_total"`
+	SwapUsed        int64            `json:"swap_used"`
+	Load1           float64          `json:"load_1"`
+	Load5           float64          `json:"load_5"`
+	Load15          float64          `json:"load_15"`
+	DiskReadRate    int64            `json:"disk_read_rate"`         // Bytes per second over all disks
+	DiskWriteRate   int64            `json:"disk_write_rate"`        // Bytes per second over all disks
+	DiskBusyPercent float64          `json:"disk_busy_percent"`      // Utilization of the busiest disk
+	TemperatureMax  *float64         `json:"temperature_max"`        // Hottest thermal zone in °C; null without sensors
+	Temperatures    []ThermalReading `json:"temperatures,omitempty"` // Current readings only; not stored
+}
+
+// ThermalReading is the temperature of one kernel thermal zone
+type ThermalReading struct {
+	Zone    string  `json:"zone"` // e.g. thermal_zone0
+	Type    string  `json:"type"` // e.g. x86_pkg_temp, acpitz
+	Celsius float64 `json:"celsius"`
+}
+
+// HostMetricsPoint summarizes the host's samples over one step of its history
+type HostMetricsPoint struct {
+	Timestamp      time.Time `json:"timestamp"` // Start of the step
+	Samples        int       `json:"samples"`
+	CPUAvg         float64   `json:"cpu_avg"`
+	CPUMax         float64   `json:"cpu_max"`
+	IOWaitAvg      float64   `json:"iowait_avg"`
+	MemoryAvg      int64     `json:"memory_avg"`
+	MemoryMax      int64     `json:"memory_max"`
+	MemoryTotal    int64     `json:"memory_total"`
+	SwapAvg        int64     `json:"swap_avg"`
+	Load1Avg       float64   `json:"load_1_avg"`
+	DiskReadRate   int64     `json:"disk_read_rate"` // Averages over the step
+	DiskWriteRate  int64     `json:"disk_write_rate"`
+	DiskBusyAvg    float64   `json:"disk_busy_avg"`
+	TemperatureMax *float64  `json:"temperature_max"`
+}
+
+// HostMetricsHistory is the host's metrics history in fixed steps
+type HostMetricsHistory struct {
+	Hours       int                `json:"hours"`
+	StepSeconds int                `json:"step_seconds"`
+	Points      []HostMetricsPoint `json:"points"`
+}
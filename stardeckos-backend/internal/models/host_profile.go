@@ -0,0 +1,24 @@
+package models
+
+// HostProfile is the operator-assigned identity of this Stardeck host. It
+// tells several Stardeck boxes apart in notifications, emails and MQTT,
+// independently of their OS hostnames.
+type HostProfile struct {
+	Name      string   `json:"name"`     // Friendly name; falls back to the hostname
+	Location  string   `json:"location"` // e.g. "Rack 2, basement"
+	Tags      []string `json:"tags"`
+	Contact   string   `json:"contact"` // Who to call about this host
+	Hostname  string   `json:"hostname"`
+	UpdatedAt string   `json:"updated_at,omitempty"`
+}
+
+// DisplayName returns the friendly name, or the hostname when none is set
+func (p HostProfile) DisplayName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Hostname
+}
+
+// ActionHostProfileUpdate is audited when the host profile changes
+const ActionHostProfileUpdate = "system.host_profile"
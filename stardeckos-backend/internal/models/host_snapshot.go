@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Filesystems a host snapshot can be taken on
+const (
+	SnapshotBackendLVMThin = "lvm-thin"
+	SnapshotBackendBtrfs   = "btrfs"
+	SnapshotBackendZFS     = "zfs"
+)
+
+// Why a host snapshot was taken
+const (
+	SnapshotReasonManual         = "manual"
+	SnapshotReasonOSUpdate       = "os_update"
+	SnapshotReasonStorageMigrate = "storage_migration"
+	SnapshotReasonStackUpdate    = "stack_update"
+)
+
+// Host snapshot states
+const (
+	SnapshotStatusReady      = "ready"
+	SnapshotStatusRolledBack = "rolled_back" // Restored; takes effect on the next boot when RebootRequired
+)
+
+// HostSnapshotTarget is a mounted filesystem and whether it can be snapshotted
+type HostSnapshotTarget struct {
+	Path       string `json:"path"`        // Path the target was asked for, such as /
+	MountPoint string `json:"mount_point"` // Mount holding Path
+	Device     string `json:"device"`
+	FSType     string `json:"fstype"`
+	Backend    string `json:"backend,omitempty"` // Empty when the filesystem can't be snapshotted
+	Source     string `json:"source,omitempty"`  // vg/lv, btrfs subvolume or ZFS dataset
+	Reason     string `json:"reason,omitempty"`  // Why Backend is empty
+}
+
+// HostSnapshot is a filesystem snapshot Stardeck took
+type HostSnapshot struct {
+	ID             string     `json:"id"`
+	Backend        string     `json:"backend"`
+	Path           string     `json:"path"`
+	MountPoint     string     `json:"mount_point"`
+	Device         string     `json:"device"`
+	Source         string     `json:"source"`
+	Name           string     `json:"name"` // Snapshot LV, btrfs subvolume or ZFS snapshot name
+	Reason         string     `json:"reason"`
+	Description    string     `json:"description"`
+	Status         string     `json:"status"`
+	RebootRequired bool       `json:"reboot_required"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CreatedBy      *int64     `json:"created_by,omitempty"`
+	RolledBackAt   *time.Time `json:"rolled_back_at,omitempty"`
+}
+
+// HostSnapshotSettings controls automatic snapshots before risky operations
+type HostSnapshotSettings struct {
+	Enabled bool     `json:"enabled"`
+	Paths   []string `json:"paths"` // Filesystems to snapshot, by a path on them
+	Keep    int      `json:"keep"`  // Automatic snapshots kept per filesystem; older ones are removed
+}
+
+// HostSnapshotStatus is the snapshot capability of the host, its settings and
+// the snapshots taken
+type HostSnapshotStatus struct {
+	Settings  HostSnapshotSettings `json:"settings"`
+	Targets   []HostSnapshotTarget `json:"targets"`
+	Snapshots []HostSnapshot       `json:"snapshots"`
+}
+
+// CreateHostSnapshotRequest takes a snapshot by hand
+type CreateHostSnapshotRequest struct {
+	Paths       []string `json:"paths"` // Defaults to the configured paths
+	Description string   `json:"description"`
+}
+
+// NotificationSnapshotFailed is sent when an automatic snapshot couldn't be taken
+const NotificationSnapshotFailed = "snapshot.failed"
+
+// Audit actions for host snapshots
+const (
+	ActionSnapshotCreate   = "snapshot.create"
+	ActionSnapshotDelete   = "snapshot.delete"
+	ActionSnapshotRollback = "snapshot.rollback"
+	ActionSnapshotSettings = "snapshot.settings"
+)
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// IconSource identifies where an icon came from
+type IconSource string
+
+const (
+	IconSourceUpload  IconSource = "upload"
+	IconSourceLibrary IconSource = "library"
+)
+
+// Icon represents a stored icon that containers, templates, and stacks can reference
+type Icon struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug,omitempty"` // Library slug (e.g. "jellyfin") for library icons
+	Source      IconSource `json:"source"`
+	ContentType string     `json:"content_type"`
+	FileName    string     `json:"-"` // File name inside the icon storage directory
+	SizeBytes   int64      `json:"size_bytes"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CreatedBy   *int64     `json:"created_by,omitempty"`
+	URL         string     `json:"url"` // API URL to use as an icon reference
+}
+
+// FetchLibraryIconRequest represents a request to import an icon from the icon library
+type FetchLibraryIconRequest struct {
+	Slug   string `json:"slug"`
+	Format string `json:"format,omitempty"` // png (default) or svg
+	Name   string `json:"name,omitempty"`
+}
+
+// Audit action constants for icons
+const (
+	ActionIconUpload = "icon.upload"
+	ActionIconFetch  = "icon.fetch"
+	ActionIconDelete = "icon.delete"
+)
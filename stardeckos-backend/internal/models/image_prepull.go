@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Image pre-pull states
+const (
+	PrePullPending   = "pending"
+	PrePullPulling   = "pulling"
+	PrePullPulled    = "pulled"
+	PrePullPresent   = "present" // Already local and skipped with missing_only
+	PrePullFailed    = "failed"
+	PrePullRunning   = "running"
+	PrePullCompleted = "completed"
+)
+
+// PrePullRequest stages images ahead of a deployment
+type PrePullRequest struct {
+	Images      []string `json:"images"`
+	Referenced  bool     `json:"referenced"`   // Add every image the templates and stacks use
+	MissingOnly bool     `json:"missing_only"` // Skip images already present instead of refreshing their tags
+	Concurrency int      `json:"concurrency"`  // Parallel pulls; defaults to 2
+}
+
+// PrePullImage is the progress of one image in a pre-pull job
+type PrePullImage struct {
+	Image      string   `json:"image"`
+	Sources    []string `json:"sources,omitempty"` // Templates and stacks that reference the image
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+	DurationMs int64    `json:"duration_ms,omitempty"`
+}
+
+// PrePullJob pulls a set of images in the background
+type PrePullJob struct {
+	ID         string         `json:"id"`
+	Status     string         `json:"status"` // running or completed
+	Images     []PrePullImage `json:"images"`
+	Pulled     int            `json:"pulled"`
+	Failed     int            `json:"failed"`
+	StartedBy  string         `json:"started_by"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// ActionImagePrePull is the audit action for starting a pre-pull job
+const ActionImagePrePull = "image.prepull"
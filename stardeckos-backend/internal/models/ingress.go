@@ -0,0 +1,54 @@
+package models
+
+// IngressSettings configures name-based routing to container web UIs. With it
+// enabled, a request for <label>.<domain> reaching Stardeck is passed to that
+// container's web UI, and the names are added to the local DNS resolver.
+type IngressSettings struct {
+	Enabled bool   `json:"enabled"`
+	Domain  string `json:"domain"` // e.g. stardeck.lan
+}
+
+// IngressHost is the friendly name of a container's web UI. Every container
+// with a web UI gets one from its name, but it is only served once an admin
+// turns it on. Unless it is marked public, visitors need a Stardeck session
+// (or the container's public app PIN) to get through.
+type IngressHost struct {
+	ContainerID   string `json:"container_id"` // Stardeck container ID
+	ContainerName string `json:"container_name"`
+	Label         string `json:"label"`
+	Hostname      string `json:"hostname"` // <label>.<domain>
+	Custom        bool   `json:"custom"`   // Label was set by hand
+	Enabled       bool   `json:"enabled"`
+	Public        bool   `json:"public"` // Served without a Stardeck session
+	URL           string `json:"url,omitempty"`
+}
+
+// IngressHostOverride is a stored change to a container's generated ingress host
+type IngressHostOverride struct {
+	ContainerID string
+	Label       string // Empty keeps the generated label
+	Enabled     bool
+	Public      bool
+}
+
+// UpdateIngressHostRequest renames a container's ingress host, turns it on or
+// off, or makes it public. An empty label goes back to the one generated from
+// the container name; omitted flags are left unchanged.
+type UpdateIngressHostRequest struct {
+	Label   string `json:"label"`
+	Enabled *bool  `json:"enabled,omitempty"`
+	Public  *bool  `json:"public,omitempty"`
+}
+
+// IngressStatus is the ingress configuration with the hosts it serves
+type IngressStatus struct {
+	Settings IngressSettings `json:"settings"`
+	Hosts    []IngressHost   `json:"hosts"`
+	DNS      bool            `json:"dns"` // Names are registered with the local resolver
+}
+
+// Audit actions for ingress
+const (
+	ActionIngressConfigure  = "network.ingress.configure"
+	ActionIngressHostUpdate = "network.ingress.host.update"
+)
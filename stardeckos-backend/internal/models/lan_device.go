@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Neighbor is an entry in the host's ARP/NDP neighbor table
+type Neighbor struct {
+	IPAddress string `json:"ip_address"`
+	MAC       string `json:"mac"`
+	Interface string `json:"interface"`
+	State     string `json:"state"` // REACHABLE, STALE, DELAY, PROBE or PERMANENT
+	Vendor    string `json:"vendor,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+}
+
+// LANDevice is a device that has been seen on the LAN
+type LANDevice struct {
+	MAC       string    `json:"mac"`
+	IPAddress string    `json:"ip_address"`
+	Interface string    `json:"interface"`
+	Hostname  string    `json:"hostname"`
+	Vendor    string    `json:"vendor"`
+	Name      string    `json:"name"`  // Label given by an administrator
+	Known     bool      `json:"known"` // Acknowledged as belonging on the network
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Online    bool      `json:"online"` // Currently in the neighbor table
+}
+
+// LANScanRequest sweeps a subnet. An empty CIDR scans the interface's own subnet.
+type LANScanRequest struct {
+	Interface string `json:"interface"`
+	CIDR      string `json:"cidr"`
+}
+
+// LANScanResult reports the devices that answered a sweep
+type LANScanResult struct {
+	CIDR       string     `json:"cidr"`
+	Scanned    int        `json:"scanned"`
+	Responded  int        `json:"responded"`
+	NewDevices int        `json:"new_devices"`
+	Devices    []Neighbor `json:"devices"`
+	Duration   float64    `json:"duration_seconds"`
+}
+
+// UpdateLANDeviceRequest labels or acknowledges a device
+type UpdateLANDeviceRequest struct {
+	Name  *string `json:"name"`
+	Known *bool   `json:"known"`
+}
+
+// LANDeviceAlertSettings controls the "new device on network" alert
+type LANDeviceAlertSettings struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Audit actions for LAN device discovery
+const (
+	ActionLANScan         = "network.scan"
+	ActionLANDeviceUpdate = "network.device.update"
+	ActionLANDeviceDelete = "network.device.delete"
+	ActionLANDeviceAlerts = "network.device.alerts"
+)
@@ -0,0 +1,22 @@
+package models
+
+// ListenerSettings configure where Stardeck accepts connections. They are
+// read at startup, so changes apply after a restart.
+type ListenerSettings struct {
+	Addresses  []string `json:"addresses"`   // IPs to bind; empty binds every address
+	Port       int      `json:"port"`        // HTTPS port, or the HTTP port in insecure mode
+	UnixSocket string   `json:"unix_socket"` // Optional socket serving plain HTTP for local automation
+}
+
+// ListenerStatus reports the listener settings and the listeners running now
+type ListenerStatus struct {
+	Settings        ListenerSettings `json:"settings"`
+	Active          []string         `json:"active"` // Addresses currently listening
+	ActiveSocket    string           `json:"active_socket,omitempty"`
+	EnvOverride     string           `json:"env_override,omitempty"` // STARDECK_LISTEN, which replaces the TCP settings
+	RestartRequired bool             `json:"restart_required"`
+	Errors          []string         `json:"errors,omitempty"` // Listeners that failed to open at startup
+}
+
+// Audit action for listener changes
+const ActionListenerSettings = "system.listeners"
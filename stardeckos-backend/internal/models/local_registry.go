@@ -0,0 +1,75 @@
+package models
+
+// LocalRegistrySettings configures the Stardeck-managed registry:2 container
+type LocalRegistrySettings struct {
+	StoragePath  string `json:"storage_path"`
+	Port         int    `json:"port"`
+	AuthEnabled  bool   `json:"auth_enabled"`
+	Username     string `json:"username,omitempty"`
+	TLSEnabled   bool   `json:"tls_enabled"`
+	CredentialID string `json:"credential_id,omitempty"` // Saved credential used for push/pull when auth is on
+	GCEnabled    bool   `json:"gc_enabled"`
+	GCWeekday    int    `json:"gc_weekday"` // 0 = Sunday; -1 runs every day
+	GCHour       int    `json:"gc_hour"`
+	LastGC       string `json:"last_gc,omitempty"`
+}
+
+// DeployLocalRegistryRequest deploys (or redeploys) the local registry
+type DeployLocalRegistryRequest struct {
+	StoragePath string `json:"storage_path"`
+	Port        int    `json:"port"`
+	AuthEnabled bool   `json:"auth_enabled"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"` // Required when enabling auth; blank keeps the current password
+	TLSEnabled  bool   `json:"tls_enabled"`
+}
+
+// UpdateLocalRegistryScheduleRequest changes the garbage-collection schedule
+type UpdateLocalRegistryScheduleRequest struct {
+	GCEnabled bool `json:"gc_enabled"`
+	GCWeekday int  `json:"gc_weekday"`
+	GCHour    int  `json:"gc_hour"`
+}
+
+// LocalRegistryStatus describes the managed registry container
+type LocalRegistryStatus struct {
+	Deployed      bool                  `json:"deployed"`
+	Running       bool                  `json:"running"`
+	State         string                `json:"state,omitempty"`
+	Image         string                `json:"image"`
+	Address       string                `json:"address,omitempty"`        // host:port for use on this machine
+	RemoteAddress string                `json:"remote_address,omitempty"` // hostname:port for other machines
+	Settings      LocalRegistrySettings `json:"settings"`
+}
+
+// LocalRegistryRepository is a repository stored in the local registry
+type LocalRegistryRepository struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// LocalRegistryTransferRequest is the first message on the push/pull WebSocket
+type LocalRegistryTransferRequest struct {
+	Direction string `json:"direction"`        // push (local image -> registry) or pull (registry -> local images)
+	Image     string `json:"image"`            // Local image for push, repository:tag for pull
+	Target    string `json:"target,omitempty"` // Repository:tag in the registry for push; defaults to the image name
+}
+
+// GarbageCollectResult summarises a registry garbage-collection run
+type GarbageCollectResult struct {
+	RanAt       string `json:"ran_at"`
+	Output      string `json:"output"`
+	BytesBefore int64  `json:"bytes_before"`
+	BytesAfter  int64  `json:"bytes_after"`
+}
+
+// Local registry audit actions
+const (
+	ActionLocalRegistryDeploy   = "local_registry.deploy"
+	ActionLocalRegistryRemove   = "local_registry.remove"
+	ActionLocalRegistrySchedule = "local_registry.schedule"
+	ActionLocalRegistryGC       = "local_registry.garbage_collect"
+	ActionLocalRegistryPush     = "local_registry.push"
+	ActionLocalRegistryPull     = "local_registry.pull"
+	ActionLocalRegistryDelete   = "local_registry.delete_tag"
+)
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// LogRuleAction is what happens when a log rule matches
+type LogRuleAction string
+
+const (
+	LogRuleActionNotify  LogRuleAction = "notify"  // Record a log_rule.triggered audit event
+	LogRuleActionRestart LogRuleAction = "restart" // Restart the container
+	LogRuleActionWebhook LogRuleAction = "webhook" // POST the match to a URL
+)
+
+// LogRule watches a container's log stream for a regex and triggers an action on match
+type LogRule struct {
+	ID              string        `json:"id"`
+	ContainerID     string        `json:"container_id"` // Stardeck container ID
+	Name            string        `json:"name"`
+	Pattern         string        `json:"pattern"`          // Go regular expression
+	Stream          string        `json:"stream,omitempty"` // stdout, stderr, or empty for both
+	Action          LogRuleAction `json:"action"`
+	WebhookURL      string        `json:"webhook_url,omitempty"`
+	CooldownSeconds int           `json:"cooldown_seconds"` // Minimum time between triggers
+	Enabled         bool          `json:"enabled"`
+	LastTriggeredAt *time.Time    `json:"last_triggered_at,omitempty"`
+	LastMatch       string        `json:"last_match,omitempty"`
+	TriggerCount    int           `json:"trigger_count"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	CreatedBy       *int64        `json:"created_by,omitempty"`
+}
+
+// CreateLogRuleRequest represents a request to create a log rule
+type CreateLogRuleRequest struct {
+	Name            string        `json:"name"`
+	Pattern         string        `json:"pattern"`
+	Stream          string        `json:"stream,omitempty"`
+	Action          LogRuleAction `json:"action"`
+	WebhookURL      string        `json:"webhook_url,omitempty"`
+	CooldownSeconds int           `json:"cooldown_seconds,omitempty"`
+	Enabled         *bool         `json:"enabled,omitempty"`
+}
+
+// UpdateLogRuleRequest represents a request to update a log rule
+type UpdateLogRuleRequest struct {
+	Name            *string        `json:"name,omitempty"`
+	Pattern         *string        `json:"pattern,omitempty"`
+	Stream          *string        `json:"stream,omitempty"`
+	Action          *LogRuleAction `json:"action,omitempty"`
+	WebhookURL      *string        `json:"webhook_url,omitempty"`
+	CooldownSeconds *int           `json:"cooldown_seconds,omitempty"`
+	Enabled         *bool          `json:"enabled,omitempty"`
+}
+
+// LogRuleWebhookPayload is the JSON body sent to a webhook action
+type LogRuleWebhookPayload struct {
+	Rule      string    `json:"rule"`
+	RuleID    string    `json:"rule_id"`
+	Container string    `json:"container"`
+	Stream    string    `json:"stream"`
+	Line      string    `json:"line"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Audit action constants for log rules
+const (
+	ActionLogRuleCreate    = "log_rule.create"
+	ActionLogRuleUpdate    = "log_rule.update"
+	ActionLogRuleDelete    = "log_rule.delete"
+	ActionLogRuleTriggered = "log_rule.triggered"
+)
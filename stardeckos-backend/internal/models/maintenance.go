@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// DatabaseStats describes the size and layout of the Stardeck database
+type DatabaseStats struct {
+	Path          string                  `json:"path"`
+	SizeBytes     int64                   `json:"size_bytes"`
+	WALSizeBytes  int64                   `json:"wal_size_bytes"`
+	PageSize      int64                   `json:"page_size"`
+	PageCount     int64                   `json:"page_count"`
+	FreelistCount int64                   `json:"freelist_count"` // Unused pages reclaimable by VACUUM
+	Tables        []TableStats            `json:"tables"`
+	Connections   DatabaseConnectionStats `json:"connections"`
+}
+
+// DatabaseConnectionStats describes the connection pool and how often writes
+// had to queue behind one another
+type DatabaseConnectionStats struct {
+	JournalMode    string     `json:"journal_mode"`
+	BusyTimeoutMs  int        `json:"busy_timeout_ms"`
+	MaxOpen        int        `json:"max_open"`
+	Open           int        `json:"open"`
+	InUse          int        `json:"in_use"`
+	Idle           int        `json:"idle"`
+	PoolWaits      int64      `json:"pool_waits"` // Times a caller waited for a free connection
+	PoolWaitMs     int64      `json:"pool_wait_ms"`
+	Writes         int64      `json:"writes"`
+	Transactions   int64      `json:"transactions"`
+	WriteWaits     int64      `json:"write_waits"` // Writes queued behind another write
+	WriteWaitMs    int64      `json:"write_wait_ms"`
+	MaxWriteWaitMs int64      `json:"max_write_wait_ms"`
+	BusyErrors     int64      `json:"busy_errors"` // SQLITE_BUSY or SQLITE_LOCKED errors returned to callers
+	LastBusyError  string     `json:"last_busy_error,omitempty"`
+	LastBusyAt     *time.Time `json:"last_busy_at,omitempty"`
+}
+
+// TableStats describes a single table's row count and on-disk size
+type TableStats struct {
+	Name      string `json:"name"`
+	Rows      int64  `json:"rows"`
+	SizeBytes int64  `json:"size_bytes"` // Includes the table's indexes; 0 if dbstat is unavailable
+}
+
+// IntegrityResult is the outcome of an SQLite integrity check
+type IntegrityResult struct {
+	OK       bool          `json:"ok"`
+	Quick    bool          `json:"quick"`
+	Problems []string      `json:"problems,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// CompactRequest specifies retention windows for pruning metrics and audit history
+type CompactRequest struct {
+	MetricsRetentionHours int  `json:"metrics_retention_hours,omitempty"`
+	AuditRetentionDays    int  `json:"audit_retention_days,omitempty"`
+	Vacuum                bool `json:"vacuum"`
+}
+
+// MaintenanceResult summarises a maintenance run
+type MaintenanceResult struct {
+	MetricsDeleted int64     `json:"metrics_deleted"`
+	AuditDeleted   int64     `json:"audit_deleted"`
+	Vacuumed       bool      `json:"vacuumed"`
+	SizeBefore     int64     `json:"size_before"`
+	SizeAfter      int64     `json:"size_after"`
+	RanAt          time.Time `json:"ran_at"`
+}
+
+// MaintenanceSettings controls automatic nightly database maintenance
+type MaintenanceSettings struct {
+	NightlyEnabled        bool   `json:"nightly_enabled"`
+	Hour                  int    `json:"hour"` // Local hour (0-23) to run nightly maintenance
+	MetricsRetentionHours int    `json:"metrics_retention_hours"`
+	AuditRetentionDays    int    `json:"audit_retention_days"`
+	Vacuum                bool   `json:"vacuum"`
+	LastRun               string `json:"last_run,omitempty"`
+}
+
+// Audit action constants for database maintenance
+const (
+	ActionDBMaintenanceVacuum    = "db_maintenance.vacuum"
+	ActionDBMaintenanceIntegrity = "db_maintenance.integrity_check"
+	ActionDBMaintenanceCompact   = "db_maintenance.compact"
+	ActionDBMaintenanceSettings  = "db_maintenance.settings"
+)
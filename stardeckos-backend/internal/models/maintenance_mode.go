@@ -0,0 +1,21 @@
+package models
+
+// MaintenanceMode is the system-wide maintenance toggle. While enabled, background
+// schedulers are paused and the desktop shows a banner.
+type MaintenanceMode struct {
+	Enabled     bool   `json:"enabled"`
+	Message     string `json:"message,omitempty"` // Banner text shown to users
+	BlockLogins bool   `json:"block_logins"`      // Reject sign-ins from non-admins
+	StartedAt   string `json:"started_at,omitempty"`
+	StartedBy   string `json:"started_by,omitempty"`
+	ExpectedEnd string `json:"expected_end,omitempty"` // RFC 3339, informational only
+}
+
+// NotificationMaintenanceMode is pushed to every user when maintenance mode changes
+const NotificationMaintenanceMode = "maintenance.mode"
+
+// Maintenance mode audit actions
+const (
+	ActionMaintenanceModeOn  = "maintenance_mode.enable"
+	ActionMaintenanceModeOff = "maintenance_mode.disable"
+)
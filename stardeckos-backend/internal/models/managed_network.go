@@ -0,0 +1,41 @@
+package models
+
+// Proxy target modes for container web UIs
+const (
+	ProxyTargetLocalhost = "localhost" // Proxy to the published host port
+	ProxyTargetDNS       = "dns"       // Proxy to the container's address on the managed network
+)
+
+// ManagedNetworkInfo describes the Stardeck-managed DNS network
+type ManagedNetworkInfo struct {
+	Name            string                 `json:"name"`
+	Exists          bool                   `json:"exists"`
+	ProxyTargetMode string                 `json:"proxy_target_mode"`
+	Members         []ManagedNetworkMember `json:"members"`
+}
+
+// ManagedNetworkMember is a container attached to the managed network
+type ManagedNetworkMember struct {
+	ID          string   `json:"id"` // Stardeck container ID
+	ContainerID string   `json:"container_id"`
+	Name        string   `json:"name"`
+	IPAddress   string   `json:"ip_address"`
+	Aliases     []string `json:"aliases"`
+}
+
+// UpdateManagedNetworkRequest changes the managed network name or proxy target mode
+type UpdateManagedNetworkRequest struct {
+	Name            *string `json:"name,omitempty"`
+	ProxyTargetMode *string `json:"proxy_target_mode,omitempty"`
+}
+
+// SetContainerAliasesRequest attaches a container to the managed network with DNS aliases
+type SetContainerAliasesRequest struct {
+	Aliases []string `json:"aliases"`
+}
+
+// Audit action constants for the managed network
+const (
+	ActionNetworkAliases         = "network.aliases"
+	ActionManagedNetworkSettings = "network.managed_settings"
+)
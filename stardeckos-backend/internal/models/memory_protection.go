@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// MemoryProtectionSettings configures zram swap and the userspace OOM guard.
+// The guard acts before the kernel OOM killer: when available memory and free
+// swap both drop below their thresholds it terminates the process with the
+// highest oom_score, and never the Stardeck backend.
+type MemoryProtectionSettings struct {
+	ZRAMEnabled         bool   `json:"zram_enabled"`
+	ZRAMSizePercent     int    `json:"zram_size_percent"` // Of physical RAM
+	ZRAMMaxMB           int    `json:"zram_max_mb"`
+	ZRAMAlgorithm       string `json:"zram_algorithm"`
+	OOMGuardEnabled     bool   `json:"oom_guard_enabled"`
+	OOMGuardMemPercent  int    `json:"oom_guard_mem_percent"`  // SIGTERM below this, SIGKILL below half
+	OOMGuardSwapPercent int    `json:"oom_guard_swap_percent"` // Free swap must also be below this
+	OOMGuardAvoid       string `json:"oom_guard_avoid"`        // Regex of process names never killed
+}
+
+// OOMGuardKill records a process the OOM guard signalled
+type OOMGuardKill struct {
+	PID                 int       `json:"pid"`
+	Name                string    `json:"name"`
+	OOMScore            int       `json:"oom_score"`
+	RSS                 int64     `json:"rss"`
+	Signal              string    `json:"signal"`
+	MemAvailablePercent float64   `json:"mem_available_percent"`
+	SwapFreePercent     float64   `json:"swap_free_percent"`
+	At                  time.Time `json:"at"`
+}
+
+// OOMGuardStatus reports the guard's state and current memory pressure
+type OOMGuardStatus struct {
+	Running             bool           `json:"running"`
+	MemAvailablePercent float64        `json:"mem_available_percent"`
+	SwapFreePercent     float64        `json:"swap_free_percent"`
+	ProtectedPID        int            `json:"protected_pid"`
+	OOMScoreAdj         int            `json:"oom_score_adj"` // Of the Stardeck backend
+	RecentKills         []OOMGuardKill `json:"recent_kills"`
+}
+
+// Audit actions for memory protection
+const (
+	ActionMemoryProtection = "system.memory_protection"
+	ActionOOMGuardKill     = "system.oom_guard_kill"
+)
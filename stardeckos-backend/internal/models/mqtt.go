@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// MQTTSettings configures the MQTT bridge, which announces host and container
+// state to Home Assistant through its discovery topics and can accept start
+// and stop commands for the containers in CommandContainers. The broker
+// password is kept in the Podman secret store, never in the database.
+type MQTTSettings struct {
+	Enabled           bool     `json:"enabled"`
+	BrokerURL         string   `json:"broker_url"` // mqtt://host:1883 or mqtts://host:8883
+	Username          string   `json:"username"`
+	PasswordSet       bool     `json:"password_set"`
+	ClientID          string   `json:"client_id"`
+	TopicPrefix       string   `json:"topic_prefix"`     // State and command topics, e.g. stardeck/myhost
+	DiscoveryPrefix   string   `json:"discovery_prefix"` // Home Assistant's discovery prefix, usually homeassistant
+	PublishInterval   int      `json:"publish_interval"` // Seconds between state updates
+	CommandsEnabled   bool     `json:"commands_enabled"`
+	CommandContainers []string `json:"command_containers"` // Container names controllable over MQTT; "*" for all
+}
+
+// UpdateMQTTSettingsRequest replaces the MQTT settings. Password is left
+// unchanged when omitted and removed when empty.
+type UpdateMQTTSettingsRequest struct {
+	MQTTSettings
+	Password *string `json:"password,omitempty"`
+}
+
+// MQTTStatus reports the bridge's connection to the broker
+type MQTTStatus struct {
+	Enabled       bool       `json:"enabled"`
+	Connected     bool       `json:"connected"`
+	Broker        string     `json:"broker,omitempty"`
+	ConnectedAt   *time.Time `json:"connected_at,omitempty"`
+	LastPublishAt *time.Time `json:"last_publish_at,omitempty"`
+	LastCommandAt *time.Time `json:"last_command_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	Containers    int        `json:"containers"` // Containers announced to Home Assistant
+}
+
+// Audit actions for the MQTT bridge
+const (
+	ActionMQTTSettings = "mqtt.settings"
+	ActionMQTTCommand  = "mqtt.command"
+)
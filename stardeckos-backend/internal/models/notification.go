@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// NotificationLevel controls how the desktop presents a notification
+type NotificationLevel string
+
+const (
+	NotificationInfo    NotificationLevel = "info"
+	NotificationSuccess NotificationLevel = "success"
+	NotificationWarning NotificationLevel = "warning"
+	NotificationError   NotificationLevel = "error"
+)
+
+// Notification types delivered over the /api/events stream
+const (
+	NotificationTaskFinished   = "task.finished"
+	NotificationAlertFired     = "alert.fired"
+	NotificationLoginDetected  = "login.detected"
+	NotificationLoginFailed    = "login.failed"
+	NotificationContainerOOM   = "container.oom"
+	NotificationFirewallRevert = "firewall.reverted"
+	NotificationLANDeviceNew   = "network.device.new"
+	NotificationCertExpiring   = "cert.expiring"
+	NotificationGameServerIdle = "game_server.idle_stopped"
+	NotificationCloudMountDown = "cloud_mount.down"
+	NotificationDataPoolFull   = "data_pool.over_quota"
+
+	NotificationApprovalRequested = "approval.requested"
+	NotificationApprovalDecided   = "approval.decided"
+
+	NotificationContainerUnhealthy = "container.unhealthy"
+)
+
+// Notification is a real-time event pushed to a user's desktop
+type Notification struct {
+	ID        int64                  `json:"id"`
+	Type      string                 `json:"type"`
+	Level     NotificationLevel      `json:"level"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+
+	// Catalog key and placeholder values the title and message were rendered
+	// from, so each client can show them in its own language
+	Key    string            `json:"key,omitempty"`
+	Params map[string]string `json:"params,omitempty"`
+
+	// Audience: delivered to UserID and to every user holding one of Roles
+	UserID int64  `json:"-"`
+	Roles  []Role `json:"-"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// NTPServerSettings lets chrony serve time to the LAN
+type NTPServerSettings struct {
+	Enabled      bool     `json:"enabled"`
+	Allow        []string `json:"allow"`         // Client networks, e.g. "192.168.1.0/24", or "all"
+	LocalStratum int      `json:"local_stratum"` // Keep serving at this stratum when upstream is lost; 0 to stop serving
+}
+
+// NTPTracking is chrony's synchronization state
+type NTPTracking struct {
+	ReferenceID    string    `json:"reference_id"`
+	ReferenceName  string    `json:"reference_name"`
+	Stratum        int       `json:"stratum"`
+	ReferenceTime  time.Time `json:"reference_time"`
+	SystemOffset   float64   `json:"system_offset"` // Seconds the clock is ahead of true time
+	RMSOffset      float64   `json:"rms_offset"`
+	Frequency      float64   `json:"frequency_ppm"`
+	RootDelay      float64   `json:"root_delay"`
+	RootDispersion float64   `json:"root_dispersion"`
+	LeapStatus     string    `json:"leap_status"`
+}
+
+// NTPSource is an upstream server or peer chrony measures
+type NTPSource struct {
+	Mode    string  `json:"mode"`  // server, peer or refclock
+	State   string  `json:"state"` // selected, combined, not_combined, unreachable, falseticker or variable
+	Address string  `json:"address"`
+	Stratum int     `json:"stratum"`
+	Poll    int     `json:"poll"`  // Polling interval as a power of two seconds
+	Reach   string  `json:"reach"` // Octal register of the last eight polls
+	LastRx  int     `json:"last_rx_seconds"`
+	Offset  float64 `json:"offset"` // Seconds
+	Error   float64 `json:"error"`  // Seconds
+}
+
+// NTPClient is a LAN host that has queried the NTP server
+type NTPClient struct {
+	Address string `json:"address"`
+	Packets int    `json:"packets"`
+	Dropped int    `json:"dropped"`
+	LastRx  int    `json:"last_rx_seconds"`
+}
+
+// NTPStatus is the state of chrony as client and server
+type NTPStatus struct {
+	Installed bool              `json:"installed"`
+	Running   bool              `json:"running"`
+	Server    NTPServerSettings `json:"server"`
+	Tracking  *NTPTracking      `json:"tracking,omitempty"`
+	Sources   []NTPSource       `json:"sources"`
+	Clients   []NTPClient       `json:"clients"`
+}
+
+// ActionNTPServer is the audit action for NTP server changes
+const ActionNTPServer = "system.ntp.server"
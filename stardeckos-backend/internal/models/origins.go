@@ -0,0 +1,12 @@
+package models
+
+// OriginSettings lists the browser origins, besides the one Stardeck is
+// served from, that may call the API with credentials and open WebSockets
+type OriginSettings struct {
+	TrustedOrigins []string `json:"trusted_origins"`       // scheme://host[:port]; a *. host prefix matches subdomains
+	DevOrigins     []string `json:"dev_origins,omitempty"` // From STARDECK_DEV_ORIGINS; not editable
+	AllowAll       bool     `json:"allow_all"`             // STARDECK_DEV_ORIGINS=* turns origin checks off
+}
+
+// Audit action for trusted origin changes
+const ActionTrustedOrigins = "security.origins"
@@ -0,0 +1,141 @@
+package models
+
+import "time"
+
+// Plugin states
+const (
+	PluginStatusRunning  = "running"
+	PluginStatusStarting = "starting"
+	PluginStatusStopped  = "stopped" // Disabled
+	PluginStatusError    = "error"
+)
+
+// PluginManifest is the plugin.json at the root of a plugin archive
+type PluginManifest struct {
+	Name        string   `json:"name"` // Lowercase letters, digits and dashes
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	Command     []string `json:"command"` // Run from the plugin directory, e.g. ["./bin/plugin", "--verbose"]
+}
+
+// PluginCapabilities is what a running plugin registers, answering
+// GET /stardeck/capabilities on its socket
+type PluginCapabilities struct {
+	Routes               []PluginRoute               `json:"routes,omitempty"`
+	Widgets              []PluginWidget              `json:"widgets,omitempty"`
+	JobTypes             []PluginJobType             `json:"job_types,omitempty"`
+	NotificationChannels []PluginNotificationChannel `json:"notification_channels,omitempty"`
+}
+
+// PluginRoute is an API route a plugin serves. Requests to
+// /api/plugins/<name>/api<path> are passed to the plugin's socket.
+type PluginRoute struct {
+	Method string `json:"method"`         // GET, POST, ... or * for any
+	Path   string `json:"path"`           // Matches the path and everything below it
+	Role   Role   `json:"role,omitempty"` // Least role allowed, viewer if empty
+}
+
+// PluginWidget is a dashboard widget a plugin provides. The frontend loads its
+// data from the plugin route named by Route.
+type PluginWidget struct {
+	Plugin      string `json:"plugin"` // Filled in by Stardeck
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Route       string `json:"route"`
+	Size        string `json:"size,omitempty"`            // small, medium or large
+	RefreshSecs int    `json:"refresh_seconds,omitempty"` // How often to reload, 0 for never
+	Role        Role   `json:"role,omitempty"`            // Least role that sees it, viewer if empty
+}
+
+// PluginJobType is a kind of scheduled job a plugin runs. Stardeck posts each
+// run to /stardeck/jobs/<type> on the plugin's socket.
+type PluginJobType struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// PluginNotificationChannel delivers Stardeck notifications somewhere else,
+// e.g. a chat service. Stardeck posts each notification at one of Levels
+// (every level if empty) to /stardeck/channels/<type> on the plugin's socket.
+type PluginNotificationChannel struct {
+	Type        string              `json:"type"`
+	Description string              `json:"description,omitempty"`
+	Levels      []NotificationLevel `json:"levels,omitempty"`
+}
+
+// Plugin is an installed plugin
+type Plugin struct {
+	Name         string              `json:"name"`
+	Version      string              `json:"version"`
+	Description  string              `json:"description,omitempty"`
+	Author       string              `json:"author,omitempty"`
+	Command      []string            `json:"command"`
+	Enabled      bool                `json:"enabled"`
+	Status       string              `json:"status"` // Not stored
+	Error        string              `json:"error,omitempty"`
+	Capabilities *PluginCapabilities `json:"capabilities,omitempty"` // While running, not stored
+	InstalledAt  time.Time           `json:"installed_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	InstalledBy  *int64              `json:"installed_by,omitempty"`
+}
+
+// PluginChannelDelivery is the body of a notification posted to a plugin's
+// notification channel
+type PluginChannelDelivery struct {
+	Notification Notification `json:"notification"`
+	UserID       int64        `json:"user_id,omitempty"` // Audience, as on the desktop
+	Roles        []Role       `json:"roles,omitempty"`
+}
+
+// Plugin job run results
+const (
+	PluginJobSuccess = "success"
+	PluginJobFailed  = "failed"
+)
+
+// PluginJob runs one of a plugin's job types on a cron schedule
+type PluginJob struct {
+	ID         int64                  `json:"id"`
+	Plugin     string                 `json:"plugin"`
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Schedule   string                 `json:"schedule"` // Cron expression
+	Params     map[string]interface{} `json:"params,omitempty"`
+	Enabled    bool                   `json:"enabled"`
+	LastRunAt  *time.Time             `json:"last_run_at,omitempty"`
+	LastStatus string                 `json:"last_status,omitempty"`
+	LastOutput string                 `json:"last_output,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	CreatedBy  *int64                 `json:"created_by,omitempty"`
+}
+
+// PluginJobRequest creates or replaces a plugin job
+type PluginJobRequest struct {
+	Plugin   string                 `json:"plugin"`
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	Schedule string                 `json:"schedule"`
+	Params   map[string]interface{} `json:"params"`
+	Enabled  *bool                  `json:"enabled,omitempty"`
+}
+
+// PluginJobRun is the body of a job run posted to a plugin
+type PluginJobRun struct {
+	JobID   int64                  `json:"job_id"`
+	Name    string                 `json:"name"`
+	Trigger string                 `json:"trigger"` // schedule or manual
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// Audit actions for plugins
+const (
+	ActionPluginInstall   = "plugin.install"
+	ActionPluginEnable    = "plugin.enable"
+	ActionPluginDisable   = "plugin.disable"
+	ActionPluginUninstall = "plugin.uninstall"
+	ActionPluginJobUpdate = "plugin.job_update"
+	ActionPluginJobDelete = "plugin.job_delete"
+	ActionPluginJobRun    = "plugin.job_run"
+)
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// PortExposure is a temporary publish of a container port on the host
+type PortExposure struct {
+	ID            string     `json:"id"`
+	ContainerID   string     `json:"container_id"` // Podman container ID
+	ContainerName string     `json:"container_name"`
+	ContainerPort int        `json:"container_port"`
+	HostPort      int        `json:"host_port"`
+	Protocol      string     `json:"protocol"`  // tcp or udp
+	TargetIP      string     `json:"target_ip"` // Container address the forwarder connects to
+	Zone          string     `json:"zone"`      // Firewall zone the port was opened in
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CreatedBy     *int64     `json:"created_by,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	Active        bool       `json:"active"` // Whether the forwarder process is currently running
+}
+
+// CreatePortExposureRequest opens a container port on the host for a limited time
+type CreatePortExposureRequest struct {
+	ContainerPort   int    `json:"container_port"`
+	HostPort        int    `json:"host_port,omitempty"` // Defaults to the container port
+	Protocol        string `json:"protocol,omitempty"`  // Defaults to tcp
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+	Zone            string `json:"zone,omitempty"` // Defaults to the firewall's default zone
+}
+
+// Audit action constants for temporary port exposure
+const (
+	ActionPortExpose       = "container.port_expose"
+	ActionPortExposeRevoke = "container.port_expose_revoke"
+)
@@ -0,0 +1,47 @@
+package models
+
+// Power measurement sources
+const (
+	PowerSourceRAPL      = "rapl"      // Measured from powercap energy counters
+	PowerSourceEstimated = "estimated" // Modeled from CPU load between idle and max watts
+)
+
+// PowerSettings configures energy estimation and cost reporting. The watt
+// figures are only used on hosts without RAPL counters.
+type PowerSettings struct {
+	IdleWatts   float64 `json:"idle_watts"`
+	MaxWatts    float64 `json:"max_watts"`
+	PricePerKWh float64 `json:"price_per_kwh"`
+	Currency    string  `json:"currency"`
+}
+
+// ContainerEnergy is a container's estimated energy use over a day
+type ContainerEnergy struct {
+	Name       string  `json:"name"`
+	KWh        float64 `json:"kwh"`
+	CPUSeconds float64 `json:"cpu_seconds"`
+	Share      float64 `json:"share"` // Fraction of the host's energy
+	Cost       float64 `json:"cost"`
+}
+
+// EnergyDay is the host's energy use for one day, split by container. The
+// remainder not attributed to containers is idle draw and host processes.
+type EnergyDay struct {
+	Day        string            `json:"day"` // YYYY-MM-DD, host local time
+	HostKWh    float64           `json:"host_kwh"`
+	HostCost   float64           `json:"host_cost"`
+	Containers []ContainerEnergy `json:"containers"`
+}
+
+// PowerReport is the current draw and recent daily energy use of the host
+type PowerReport struct {
+	Source       string        `json:"source"`
+	CurrentWatts float64       `json:"current_watts"`
+	Settings     PowerSettings `json:"settings"`
+	Days         []EnergyDay   `json:"days"`
+}
+
+// Audit actions for power settings
+const (
+	ActionPowerSettings = "power.settings"
+)
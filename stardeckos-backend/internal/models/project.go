@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Project groups containers, stacks, volumes and networks for a team. Members
+// see the project's workloads and act on them with their project role; other
+// non-admin users don't see them at all. Unassigned resources stay visible to everyone.
+type Project struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	CreatedAt   time.Time         `json:"created_at"`
+	CreatedBy   *int64            `json:"created_by,omitempty"`
+	Role        Role              `json:"role,omitempty"` // The requesting user's project role
+	Members     []ProjectMember   `json:"members,omitempty"`
+	Resources   []ProjectResource `json:"resources,omitempty"`
+}
+
+// ProjectMember binds a user to a role within a project
+type ProjectMember struct {
+	ProjectID string    `json:"project_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      Role      `json:"role"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// ProjectResourceType is the kind of workload a project can hold
+type ProjectResourceType string
+
+const (
+	ProjectResourceContainer ProjectResourceType = "container" // Stardeck container ID
+	ProjectResourceStack     ProjectResourceType = "stack"     // Stack ID
+	ProjectResourceVolume    ProjectResourceType = "volume"    // Volume name
+	ProjectResourceNetwork   ProjectResourceType = "network"   // Podman network name
+)
+
+// ProjectResource is a workload assigned to a project
+type ProjectResource struct {
+	ProjectID  string              `json:"project_id"`
+	Type       ProjectResourceType `json:"type"`
+	ResourceID string              `json:"resource_id"`
+	Name       string              `json:"name"`
+	AddedAt    time.Time           `json:"added_at"`
+}
+
+// CreateProjectRequest creates a project
+type CreateProjectRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// UpdateProjectRequest changes a project's description
+type UpdateProjectRequest struct {
+	Description string `json:"description"`
+}
+
+// AddProjectMemberRequest adds a user to a project or changes their role
+type AddProjectMemberRequest struct {
+	UserID int64 `json:"user_id"`
+	Role   Role  `json:"role"`
+}
+
+// AddProjectResourceRequest assigns a workload to a project
+type AddProjectResourceRequest struct {
+	Type ProjectResourceType `json:"type"`
+	ID   string              `json:"id"` // Container or stack ID, or volume/network name
+}
+
+// Audit actions for projects
+const (
+	ActionProjectCreate         = "project.create"
+	ActionProjectUpdate         = "project.update"
+	ActionProjectDelete         = "project.delete"
+	ActionProjectMemberAdd      = "project.member_add"
+	ActionProjectMemberRemove   = "project.member_remove"
+	ActionProjectResourceAdd    = "project.resource_add"
+	ActionProjectResourceRemove = "project.resource_remove"
+)
@@ -0,0 +1,35 @@
+package models
+
+// OutboundProxySettings routes the host's outbound HTTP through a proxy: dnf,
+// image pulls and Stardeck's own requests. NoProxy lists hosts, domains and
+// CIDRs reached directly; loopback addresses always are.
+type OutboundProxySettings struct {
+	HTTPProxy  string   `json:"http_proxy"`  // e.g. "http://proxy.example.com:3128"
+	HTTPSProxy string   `json:"https_proxy"` // Empty to use the HTTP proxy
+	NoProxy    []string `json:"no_proxy"`    // e.g. "example.com", ".corp", "10.0.0.0/8"
+}
+
+// ActionOutboundProxySettings is the audit action for proxy changes
+const ActionOutboundProxySettings = "system.proxy"
+
+// ReverseProxySettings describe the proxies in front of Stardeck. Only
+// requests from a trusted proxy may set the client address, scheme and host
+// with X-Forwarded-* headers; everyone else's are dropped.
+type ReverseProxySettings struct {
+	TrustedProxies []string `json:"trusted_proxies"` // CIDRs or addresses, e.g. "10.0.0.5", "172.16.0.0/12"
+	BasePath       string   `json:"base_path"`       // Subdirectory served from, e.g. "/stardeck"; empty for the root
+}
+
+// ReverseProxyStatus reports the settings and how the current request was
+// read, to check a proxy is passing the right headers
+type ReverseProxyStatus struct {
+	Settings   ReverseProxySettings `json:"settings"`
+	RemoteAddr string               `json:"remote_addr"` // The peer the request came from
+	Trusted    bool                 `json:"trusted"`     // Whether that peer is a trusted proxy
+	ClientIP   string               `json:"client_ip"`   // The address audit entries record
+	Scheme     string               `json:"scheme"`
+	Host       string               `json:"host"`
+}
+
+// ActionReverseProxySettings is the audit action for reverse proxy changes
+const ActionReverseProxySettings = "system.reverse_proxy"
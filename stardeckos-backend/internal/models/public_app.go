@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// PublicApp exposes a container's web UI through the proxy without a Stardeck
+// session, e.g. a media server for household members without accounts. A
+// shared PIN can be required; unlocking sets a cookie scoped to the app.
+type PublicApp struct {
+	ContainerID string    `json:"container_id"` // Stardeck container ID
+	Slug        string    `json:"slug"`         // Served at /api/public/apps/<slug>/
+	Name        string    `json:"name"`
+	Icon        string    `json:"icon,omitempty"`
+	PINRequired bool      `json:"pin_required"`
+	PINHash     string    `json:"-"`
+	Listed      bool      `json:"listed"` // Shown on the public app index
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+	CreatedBy   *int64    `json:"created_by,omitempty"`
+}
+
+// UpdatePublicAppRequest publishes a container's web UI or changes its settings.
+// PIN is left unchanged when omitted and removed when empty.
+type UpdatePublicAppRequest struct {
+	Slug   string  `json:"slug"`
+	PIN    *string `json:"pin,omitempty"`
+	Listed bool    `json:"listed"`
+}
+
+// UnlockPublicAppRequest submits the shared PIN for a public app, either as
+// JSON or from the built-in PIN form
+type UnlockPublicAppRequest struct {
+	PIN string `json:"pin" form:"pin"`
+}
+
+// Audit actions for public apps
+const (
+	ActionPublicAppUpdate       = "public_app.update"
+	ActionPublicAppRemove       = "public_app.remove"
+	ActionPublicAppUnlockFailed = "public_app.unlock_failed"
+)
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// UserQuota caps what a user's containers and stacks may use. A zero limit
+// means unlimited; users without a quota are not limited at all.
+type UserQuota struct {
+	UserID         int64     `json:"user_id"`
+	MaxContainers  int       `json:"max_containers"`
+	MaxMemoryBytes int64     `json:"max_memory_bytes"`
+	MaxCPUs        float64   `json:"max_cpus"`
+	MaxDiskBytes   int64     `json:"max_disk_bytes"` // Data under the app data root
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// QuotaUsage is what a user's containers and stacks currently reserve. Memory
+// and CPU are the limits configured on each container, not live usage.
+type QuotaUsage struct {
+	Containers  int     `json:"containers"`
+	MemoryBytes int64   `json:"memory_bytes"`
+	CPUs        float64 `json:"cpus"`
+	DiskBytes   int64   `json:"disk_bytes"`
+}
+
+// UserQuotaStatus is a user's quota alongside their current usage
+type UserQuotaStatus struct {
+	UserID   int64      `json:"user_id"`
+	Username string     `json:"username"`
+	Quota    *UserQuota `json:"quota"` // nil when the user is unlimited
+	Usage    QuotaUsage `json:"usage"`
+}
+
+// QuotaDemand is what a new deployment would add to its owner's usage. The
+// missing lists name containers or services without the memory or CPU limit a
+// quota needs to account for them.
+type QuotaDemand struct {
+	Containers    int      `json:"containers"`
+	MemoryBytes   int64    `json:"memory_bytes"`
+	CPUs          float64  `json:"cpus"`
+	MissingMemory []string `json:"missing_memory,omitempty"`
+	MissingCPU    []string `json:"missing_cpu,omitempty"`
+}
+
+// UpdateUserQuotaRequest sets a user's quota
+type UpdateUserQuotaRequest struct {
+	MaxContainers  int     `json:"max_containers"`
+	MaxMemoryBytes int64   `json:"max_memory_bytes"`
+	MaxCPUs        float64 `json:"max_cpus"`
+	MaxDiskBytes   int64   `json:"max_disk_bytes"`
+}
+
+// SetOwnerRequest transfers a container or stack to another user
+type SetOwnerRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+// Audit actions for quotas and ownership
+const (
+	ActionQuotaUpdate    = "quota.update"
+	ActionQuotaDelete    = "quota.delete"
+	ActionContainerOwner = "container.owner"
+	ActionStackOwner     = "stack.owner"
+)
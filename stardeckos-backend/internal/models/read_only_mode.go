@@ -0,0 +1,20 @@
+package models
+
+// ReadOnlyMode is the global read-only switch for demo instances and change
+// freezes. While enabled every user, admins included, can only do what a
+// viewer can; admins can still turn it off.
+type ReadOnlyMode struct {
+	Enabled   bool   `json:"enabled"`
+	Message   string `json:"message,omitempty"` // Reason given with every rejected request
+	StartedAt string `json:"started_at,omitempty"`
+	StartedBy string `json:"started_by,omitempty"`
+}
+
+// NotificationReadOnlyMode is pushed to every user when read-only mode changes
+const NotificationReadOnlyMode = "read_only.mode"
+
+// Read-only mode audit actions
+const (
+	ActionReadOnlyModeOn  = "read_only_mode.enable"
+	ActionReadOnlyModeOff = "read_only_mode.disable"
+)
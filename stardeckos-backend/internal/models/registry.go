@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// RegistryCredential is a saved login for a container registry. The password is
+// kept in the Podman secret store, never in the database.
+type RegistryCredential struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Registry   string    `json:"registry"` // Host, e.g. docker.io or registry.local:5000
+	Username   string    `json:"username"`
+	SecretName string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  *int64    `json:"created_by,omitempty"`
+}
+
+// CreateRegistryCredentialRequest represents a request to save registry credentials
+type CreateRegistryCredentialRequest struct {
+	Name     string `json:"name"`
+	Registry string `json:"registry"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RegistryAuth is a resolved username and password for one registry
+type RegistryAuth struct {
+	Registry string
+	Username string
+	Password string
+}
+
+// PromoteImageRequest is the first WebSocket message of an image promotion
+type PromoteImageRequest struct {
+	Source             string  `json:"source"`       // e.g. docker.io/library/nginx:1.27
+	Destination        string  `json:"destination"`  // e.g. registry.local:5000/library/nginx:1.27
+	SourceLocal        bool    `json:"source_local"` // Read the source from local Podman storage
+	SourceCredentialID *string `json:"source_credential_id,omitempty"`
+	DestCredentialID   *string `json:"dest_credential_id,omitempty"`
+	AllArchitectures   bool    `json:"all_architectures"`           // Copy every platform of a multi-arch image
+	SourceTLSVerify    *bool   `json:"source_tls_verify,omitempty"` // Defaults to true
+	DestTLSVerify      *bool   `json:"dest_tls_verify,omitempty"`   // Defaults to true
+}
+
+// ImageCopyOptions describes a skopeo copy between registries
+type ImageCopyOptions struct {
+	Source           string // Full skopeo reference including transport
+	Destination      string
+	SourceAuth       *RegistryAuth
+	DestAuth         *RegistryAuth
+	AllArchitectures bool
+	SrcTLSVerify     bool
+	DestTLSVerify    bool
+}
+
+// Registry and promotion audit actions
+const (
+	ActionImagePromote             = "image.promote"
+	ActionRegistryCredentialCreate = "registry.credential_create"
+	ActionRegistryCredentialDelete = "registry.credential_delete"
+)
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ReleaseNote is one published release of an image's source project
+type ReleaseNote struct {
+	Tag         string     `json:"tag"`
+	Name        string     `json:"name"`
+	Body        string     `json:"body"` // Markdown
+	URL         string     `json:"url"`
+	Prerelease  bool       `json:"prerelease"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+// ImageChangelog describes what changed between the image a container runs
+// and the update found for it, from the releases of the image's source repository
+type ImageChangelog struct {
+	Source         string        `json:"source,omitempty"`     // org.opencontainers.image.source
+	Repository     string        `json:"repository,omitempty"` // owner/name on GitHub
+	CurrentVersion string        `json:"current_version,omitempty"`
+	NewVersion     string        `json:"new_version,omitempty"`
+	ReleasesURL    string        `json:"releases_url,omitempty"`
+	Releases       []ReleaseNote `json:"releases"`
+	Matched        bool          `json:"matched"` // Releases run from the current version to the new one; otherwise they are just the latest
+	Error          string        `json:"error,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// Resource recommendation actions
+const (
+	RecommendationSet          = "set"   // No limit yet; add one
+	RecommendationLower        = "lower" // The limit is far above what the container uses
+	RecommendationRaise        = "raise" // The container runs close to its limit
+	RecommendationKeep         = "keep"
+	RecommendationInsufficient = "insufficient_data"
+)
+
+// ResourceRecommendation suggests CPU and memory limits for a container from
+// its recorded usage: the 95th percentile plus headroom, never below the peak
+// for memory
+type ResourceRecommendation struct {
+	ContainerID string `json:"container_id"` // Stardeck container ID
+	Name        string `json:"name"`
+	WindowHours int    `json:"window_hours"`
+	Samples     int    `json:"samples"`
+
+	CPUP95    float64 `json:"cpu_p95"` // Cores
+	CPUMax    float64 `json:"cpu_max"`
+	MemoryP95 int64   `json:"memory_p95"` // Bytes
+	MemoryMax int64   `json:"memory_max"`
+
+	CurrentCPULimit        float64 `json:"current_cpu_limit"`    // 0 = unlimited
+	CurrentMemoryLimit     int64   `json:"current_memory_limit"` // 0 = unlimited
+	RecommendedCPULimit    float64 `json:"recommended_cpu_limit"`
+	RecommendedMemoryLimit int64   `json:"recommended_memory_limit"`
+	CPUAction              string  `json:"cpu_action"`
+	MemoryAction           string  `json:"memory_action"`
+
+	// Stack containers get their limits back from the compose file when recreated
+	Stack string `json:"stack,omitempty"`
+}
+
+// UpdateContainerResourcesRequest changes a running container's limits in
+// place. Omitted or zero values leave that limit as it is.
+type UpdateContainerResourcesRequest struct {
+	CPULimit    float64 `json:"cpu_limit"`    // Cores
+	MemoryLimit int64   `json:"memory_limit"` // Bytes
+}
+
+// ApplyRecommendationRequest picks which recommended limits to apply; both
+// are applied when neither is set
+type ApplyRecommendationRequest struct {
+	CPU    *bool `json:"cpu,omitempty"`
+	Memory *bool `json:"memory,omitempty"`
+}
+
+// Audit action for live resource changes
+const ActionContainerResources = "container.resources"
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Permission codes are area:access. A "*" in either part matches anything,
+// so "*" grants everything and "*:read" every read permission.
+const (
+	PermContainersRead  = "containers:read"
+	PermContainersWrite = "containers:write"
+	PermImagesRead      = "images:read"
+	PermImagesWrite     = "images:write"
+	PermVolumesRead     = "volumes:read"
+	PermVolumesWrite    = "volumes:write"
+	PermDatabasesRead   = "databases:read"
+	PermDatabasesWrite  = "databases:write"
+	PermNetworkRead     = "network:read"
+	PermNetworkWrite    = "network:write"
+	PermNetworkFirewall = "network:firewall"
+	PermStorageRead     = "storage:read"
+	PermStorageWrite    = "storage:write"
+	PermFilesRead       = "files:read"
+	PermFilesWrite      = "files:write"
+	PermSystemRead      = "system:read"
+	PermSystemWrite     = "system:write"
+	PermSystemUpdates   = "system:updates"
+	PermUsersRead       = "users:read"
+	PermUsersWrite      = "users:write"
+	PermSecurityRead    = "security:read"
+	PermSecurityWrite   = "security:write"
+	PermAllianceRead    = "alliance:read"
+	PermAllianceManage  = "alliance:manage"
+	PermPluginsRead     = "plugins:read"
+	PermPluginsWrite    = "plugins:write"
+)
+
+// PermissionInfo describes a permission for the role editor
+type PermissionInfo struct {
+	Code        string `json:"code"`
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalog lists every permission a role can grant
+var PermissionCatalog = []PermissionInfo{
+	{PermContainersRead, "containers", "View containers, stacks, templates and projects"},
+	{PermContainersWrite, "containers", "Deploy, change and remove containers and stacks"},
+	{PermImagesRead, "images", "View images and registries"},
+	{PermImagesWrite, "images", "Pull, promote and remove images; manage registries"},
+	{PermVolumesRead, "volumes", "View volumes and bind mounts"},
+	{PermVolumesWrite, "volumes", "Create and remove volumes and bind mounts"},
+	{PermDatabasesRead, "databases", "View managed databases"},
+	{PermDatabasesWrite, "databases", "Create, change and remove managed databases"},
+	{PermNetworkRead, "network", "View interfaces, routes, DNS, DHCP and the firewall"},
+	{PermNetworkWrite, "network", "Change interfaces, routes, DNS, DHCP, ingress and Podman networks"},
+	{PermNetworkFirewall, "network", "Change firewall zones and rules"},
+	{PermStorageRead, "storage", "View disks, mounts, data pools and cloud storage"},
+	{PermStorageWrite, "storage", "Partition, format and mount disks; manage pools, cloud storage and WebDAV"},
+	{PermFilesRead, "files", "Browse and download files"},
+	{PermFilesWrite, "files", "Upload, edit, move and delete files"},
+	{PermSystemRead, "system", "View system information, metrics, services, processes and updates"},
+	{PermSystemWrite, "system", "Change system settings, control services and processes"},
+	{PermSystemUpdates, "system", "Apply updates, install packages and manage repositories"},
+	{PermUsersRead, "users", "View users, groups, realms and roles"},
+	{PermUsersWrite, "users", "Manage users, groups, realms, roles and domain membership"},
+	{PermSecurityRead, "security", "View security settings, the audit log and approvals"},
+	{PermSecurityWrite, "security", "Change security settings and decide approvals"},
+	{PermAllianceRead, "alliance", "View Alliance providers, clients and users"},
+	{PermAllianceManage, "alliance", "Manage Alliance providers, clients and users"},
+	{PermPluginsRead, "plugins", "View plugins and their widgets"},
+	{PermPluginsWrite, "plugins", "Install, configure and remove plugins"},
+}
+
+// ValidPermission reports whether code is in the catalog or a wildcard
+// pattern matching part of it
+func ValidPermission(code string) bool {
+	if code == "*" {
+		return true
+	}
+	for _, p := range PermissionCatalog {
+		if PermissionMatches(code, p.Code) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionMatches reports whether a granted pattern covers perm
+func PermissionMatches(granted, perm string) bool {
+	if granted == "*" || granted == perm {
+		return true
+	}
+	grantedArea, grantedAccess, ok := strings.Cut(granted, ":")
+	if !ok {
+		return false
+	}
+	area, access, _ := strings.Cut(perm, ":")
+	return (grantedArea == "*" || grantedArea == area) && (grantedAccess == "*" || grantedAccess == access)
+}
+
+// HasPermission reports whether any of the granted patterns covers perm
+func HasPermission(granted []string, perm string) bool {
+	for _, g := range granted {
+		if PermissionMatches(g, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleDefinition is a named set of permissions. The built-in admin,
+// operator and viewer roles always exist; custom roles are assigned on top of
+// a base role, which stands in for them wherever a fixed tier is checked
+// (read-only enforcement, session policy, WebDAV access).
+type RoleDefinition struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	BaseRole    Role      `json:"base_role"`
+	Permissions []string  `json:"permissions"`
+	BuiltIn     bool      `json:"built_in"`
+	UserCount   int       `json:"user_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RoleRequest creates or updates a custom role. Built-in roles only take
+// new permissions.
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	BaseRole    Role     `json:"base_role"`
+	Permissions []string `json:"permissions"`
+}
+
+// IsBuiltInRole reports whether r is one of the fixed roles
+func IsBuiltInRole(r Role) bool {
+	return r == RoleAdmin || r == RoleOperator || r == RoleViewer
+}
+
+// Audit actions for role changes
+const (
+	ActionRoleCreate = "role.create"
+	ActionRoleUpdate = "role.update"
+	ActionRoleDelete = "role.delete"
+)
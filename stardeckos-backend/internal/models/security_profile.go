@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// SecurityProfileType is the confinement mechanism a profile configures
+type SecurityProfileType string
+
+const (
+	SecurityProfileSeccomp  SecurityProfileType = "seccomp"
+	SecurityProfileAppArmor SecurityProfileType = "apparmor"
+)
+
+// SecurityProfile is a seccomp or AppArmor profile in the profile store
+type SecurityProfile struct {
+	ID          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Type        SecurityProfileType `json:"type"`
+	Description string              `json:"description"`
+	Content     string              `json:"content,omitempty"`
+	Curated     string              `json:"curated,omitempty"` // Curated profile it was installed from
+	Path        string              `json:"path"`              // Where the profile is installed on the host
+	CreatedBy   string              `json:"created_by"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	Containers  []string            `json:"containers"` // Containers currently confined by the profile
+}
+
+// CuratedSecurityProfile is a profile Stardeck ships that admins can install
+type CuratedSecurityProfile struct {
+	Name        string              `json:"name"`
+	Type        SecurityProfileType `json:"type"`
+	Description string              `json:"description"`
+}
+
+// CreateSecurityProfileRequest uploads a profile, or installs a curated one
+// when Curated is set
+type CreateSecurityProfileRequest struct {
+	Name        string              `json:"name"`
+	Type        SecurityProfileType `json:"type"`
+	Description string              `json:"description"`
+	Content     string              `json:"content"`
+	Curated     string              `json:"curated"`
+}
+
+// UpdateSecurityProfileRequest replaces a profile's description or content
+type UpdateSecurityProfileRequest struct {
+	Description *string `json:"description"`
+	Content     *string `json:"content"`
+}
+
+// ContainerConfinement is how a container is confined by seccomp, AppArmor and SELinux
+type ContainerConfinement struct {
+	ContainerID     string `json:"container_id"`
+	Name            string `json:"name"`
+	Running         bool   `json:"running"`
+	Privileged      bool   `json:"privileged"`
+	Seccomp         string `json:"seccomp"`                    // default, unconfined, or a profile path
+	SeccompProfile  string `json:"seccomp_profile,omitempty"`  // Store profile name, when the path is one
+	AppArmor        string `json:"apparmor,omitempty"`         // Empty when AppArmor is not in use
+	AppArmorProfile string `json:"apparmor_profile,omitempty"` // Store profile name, when the label is one
+	SELinuxLabel    string `json:"selinux_label,omitempty"`
+	Unconfined      bool   `json:"unconfined"` // Seccomp, AppArmor or SELinux is disabled for the container
+}
+
+// AssignSecurityProfileRequest sets a container's profiles. A zero ID selects
+// the runtime's default profile.
+type AssignSecurityProfileRequest struct {
+	SeccompProfileID  int64 `json:"seccomp_profile_id"`
+	AppArmorProfileID int64 `json:"apparmor_profile_id"`
+}
+
+// Audit actions for the security profile store
+const (
+	ActionSecurityProfileCreate = "security.profile.create"
+	ActionSecurityProfileUpdate = "security.profile.update"
+	ActionSecurityProfileDelete = "security.profile.delete"
+	ActionSecurityProfileAssign = "security.profile.assign"
+)
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// FindingSeverity ranks a security report finding
+type FindingSeverity string
+
+const (
+	FindingPass     FindingSeverity = "pass"
+	FindingInfo     FindingSeverity = "info"
+	FindingWarning  FindingSeverity = "warning"
+	FindingCritical FindingSeverity = "critical"
+)
+
+// SecurityFinding is one check's outcome in the security report
+type SecurityFinding struct {
+	Check    string          `json:"check"`
+	Severity FindingSeverity `json:"severity"`
+	Title    string          `json:"title"`
+	Detail   string          `json:"detail,omitempty"`
+}
+
+// ReportPort compares a port the firewall opens with what listens on it
+type ReportPort struct {
+	Port         int      `json:"port"`
+	Protocol     string   `json:"protocol"`
+	FirewallOpen bool     `json:"firewall_open"`
+	Zones        []string `json:"zones"`     // Active zones that open the port
+	Listening    bool     `json:"listening"` // Something listens on a non-loopback address
+	Processes    []string `json:"processes"`
+}
+
+// ContainerSecurity is the privilege posture of one container
+type ContainerSecurity struct {
+	Name       string   `json:"name"`
+	Image      string   `json:"image"`
+	Running    bool     `json:"running"`
+	Privileged bool     `json:"privileged"`
+	RunsAsRoot bool     `json:"runs_as_root"`
+	User       string   `json:"user"`
+	CapAdd     []string `json:"cap_add"`
+}
+
+// CertificateExpiry reports when a certificate Stardeck relies on expires
+type CertificateExpiry struct {
+	Name     string    `json:"name"`
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"not_after"`
+	DaysLeft int       `json:"days_left"`
+}
+
+// SecurityAdvisory is a pending security update
+type SecurityAdvisory struct {
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+	Package  string `json:"package"`
+}
+
+// SecurityReport is the exported security posture of the host
+type SecurityReport struct {
+	GeneratedAt          time.Time               `json:"generated_at"`
+	GeneratedBy          string                  `json:"generated_by"`
+	Hostname             string                  `json:"hostname"`
+	Summary              map[FindingSeverity]int `json:"summary"`
+	Findings             []SecurityFinding       `json:"findings"`
+	DefaultAdminPassword bool                    `json:"default_admin_password"`
+	FirewallRunning      bool                    `json:"firewall_running"`
+	Ports                []ReportPort            `json:"ports"`
+	Containers           []ContainerSecurity     `json:"containers"`
+	SecurityUpdates      []SecurityAdvisory      `json:"security_updates"`
+	Certificates         []CertificateExpiry     `json:"certificates"`
+}
+
+// Audit action for exporting the security report
+const ActionSecurityReport = "security.report"
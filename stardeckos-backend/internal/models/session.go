@@ -11,6 +11,7 @@ type Session struct {
 	ExpiresAt time.Time `json:"expires_at"`
 	IPAddress string    `json:"ip_address"`
 	UserAgent string    `json:"user_agent"`
+	ReadOnly  bool      `json:"read_only"` // Only what a viewer may do, whatever the user's role
 }
 
 // LoginRequest represents the request body for login
@@ -24,3 +25,21 @@ type LoginResponse struct {
 	User  User   `json:"user"`
 	Token string `json:"token"`
 }
+
+// SessionPolicy bounds how long a session lives and how many a user may hold.
+// The idle timeout slides forward with activity; the absolute lifetime does not.
+type SessionPolicy struct {
+	IdleTimeoutMinutes    int `json:"idle_timeout_minutes"`
+	AbsoluteLifetimeHours int `json:"absolute_lifetime_hours"` // 0 = no cap
+	MaxSessions           int `json:"max_sessions"`            // 0 = unlimited
+}
+
+// SessionPolicySettings holds the default session policy and per-role overrides.
+// Roles without an override use the default.
+type SessionPolicySettings struct {
+	Default SessionPolicy          `json:"default"`
+	Roles   map[Role]SessionPolicy `json:"roles"`
+}
+
+// Audit action for session policy changes
+const ActionSessionPolicy = "session.policy"
@@ -0,0 +1,69 @@
+package models
+
+// StackHealth is the overall state of a stack's services, for dashboard badges
+type StackHealth string
+
+const (
+	StackHealthHealthy  StackHealth = "healthy"  // Every service is running and passing its health check
+	StackHealthStarting StackHealth = "starting" // Services are up but health checks haven't passed yet
+	StackHealthDegraded StackHealth = "degraded" // Some services are down or failing their health check
+	StackHealthDown     StackHealth = "down"     // Nothing is running
+)
+
+// Service states in the stack graph
+const (
+	ServiceStateHealthy   = "healthy"   // Running and passing its health check
+	ServiceStateRunning   = "running"   // Running without a health check
+	ServiceStateStarting  = "starting"  // Running, health check not passed yet
+	ServiceStateUnhealthy = "unhealthy" // Running but failing its health check
+	ServiceStateCompleted = "completed" // A one-off service that exited successfully
+	ServiceStateStopped   = "stopped"
+	ServiceStateMissing   = "missing" // In the compose file but no container exists
+)
+
+// StackServiceStatus is a compose service's state after a deploy, with the
+// Stardeck records of its containers
+type StackServiceStatus struct {
+	Service    string   `json:"service"`
+	State      string   `json:"state"`
+	Containers []string `json:"containers"`            // Container names
+	ManagedIDs []string `json:"managed_ids,omitempty"` // Stardeck IDs of those containers
+	Adopted    int      `json:"adopted"`               // Containers the deploy added to Stardeck
+	Error      string   `json:"error,omitempty"`       // Why a container couldn't be adopted
+}
+
+// Stack graph edge kinds
+const (
+	StackEdgeDependsOn = "depends_on"
+	StackEdgeLink      = "link"
+)
+
+// StackGraphNode is a compose service with its live state
+type StackGraphNode struct {
+	Service    string   `json:"service"`
+	Image      string   `json:"image,omitempty"`
+	State      string   `json:"state"`
+	Containers int      `json:"containers"`
+	Running    int      `json:"running"`
+	Networks   []string `json:"networks"`
+	Profiles   []string `json:"profiles,omitempty"`
+}
+
+// StackGraphEdge points from a service to one it needs
+type StackGraphEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Kind      string `json:"kind"`                // depends_on or link
+	Condition string `json:"condition,omitempty"` // depends_on condition
+	Alias     string `json:"alias,omitempty"`     // Name the link is reachable by
+	Missing   bool   `json:"missing,omitempty"`   // The target isn't a service in the stack
+	Waiting   bool   `json:"waiting,omitempty"`   // The target hasn't met the condition
+}
+
+// StackGraph is a stack's service dependency graph and aggregate health
+type StackGraph struct {
+	StackID string           `json:"stack_id"`
+	Health  StackHealth      `json:"health"`
+	Nodes   []StackGraphNode `json:"nodes"`
+	Edges   []StackGraphEdge `json:"edges"`
+}
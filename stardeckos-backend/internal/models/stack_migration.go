@@ -0,0 +1,49 @@
+package models
+
+// Other container managers stacks can be migrated from
+const (
+	MigrationSourcePortainer = "portainer"
+	MigrationSourceDockge    = "dockge"
+	MigrationSourceYacht     = "yacht"
+)
+
+// MigrationSource says where to read another manager's stacks from. Portainer
+// is read through its API; Dockge and Yacht keep one directory per stack.
+type MigrationSource struct {
+	Source      string `json:"source"`
+	URL         string `json:"url,omitempty"`          // Portainer, e.g. https://portainer.example.com:9443
+	APIKey      string `json:"api_key,omitempty"`      // Portainer access token
+	InsecureTLS bool   `json:"insecure_tls,omitempty"` // Accept Portainer's self-signed certificate
+	Path        string `json:"path,omitempty"`         // Dockge or Yacht stacks directory
+}
+
+// MigrationCandidate is a stack found in another manager
+type MigrationCandidate struct {
+	Name           string   `json:"name"` // Compose project name, used as the Stardeck stack name
+	SourceID       string   `json:"source_id"`
+	Path           string   `json:"path,omitempty"` // Stack directory, for directory-based sources
+	Services       []string `json:"services"`
+	EnvVars        int      `json:"env_vars"`
+	ContainerCount int      `json:"container_count"`
+	RunningCount   int      `json:"running_count"`
+	ExistingStack  string   `json:"existing_stack,omitempty"` // Set when a Stardeck stack already has this name
+	Error          string   `json:"error,omitempty"`          // Why the stack can't be migrated
+}
+
+// MigrateStacksRequest imports stacks from another manager
+type MigrateStacksRequest struct {
+	MigrationSource
+	Stacks          []string `json:"stacks,omitempty"` // Names to import; all importable stacks when empty
+	AdoptContainers bool     `json:"adopt_containers"` // Adopt the stacks' existing containers
+	FetchIcons      bool     `json:"fetch_icons"`      // Look up icons in the icon library by image name
+}
+
+// MigrationResult reports the outcome for one stack
+type MigrationResult struct {
+	Name     string `json:"name"`
+	StackID  string `json:"stack_id,omitempty"`
+	Icon     string `json:"icon,omitempty"`
+	Adopted  int    `json:"adopted"` // Containers adopted into Stardeck
+	Imported bool   `json:"imported"`
+	Error    string `json:"error,omitempty"`
+}
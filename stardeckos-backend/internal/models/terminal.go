@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// TerminalSession records a host shell session opened through the web terminal
+type TerminalSession struct {
+	ID            string     `json:"id"`
+	UserID        int64      `json:"user_id"`
+	Username      string     `json:"username"`
+	IPAddress     string     `json:"ip_address"`
+	StartedAt     time.Time  `json:"started_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	EndReason     string     `json:"end_reason,omitempty"` // closed, exited, idle_timeout
+	RecordingPath string     `json:"-"`
+	RecordingSize int64      `json:"recording_size"`
+	HasRecording  bool       `json:"has_recording"`
+	Active        bool       `json:"active"`   // Computed: session is still attached
+	Watchers      int        `json:"watchers"` // Computed: admins currently viewing the live session
+}
+
+// Terminal session end reasons
+const (
+	TerminalEndClosed      = "closed"
+	TerminalEndExited      = "exited"
+	TerminalEndIdleTimeout = "idle_timeout"
+)
+
+// Audit action constants for host terminal sessions
+const (
+	ActionTerminalSessionStart = "terminal.session_start"
+	ActionTerminalSessionEnd   = "terminal.session_end"
+	ActionTerminalSessionWatch = "terminal.session_watch"
+)
@@ -0,0 +1,31 @@
+package models
+
+// TLSSettings configures the HTTPS listener. Everything applies to new
+// connections without a restart.
+type TLSSettings struct {
+	MinVersion            string   `json:"min_version"`   // "1.2" or "1.3"
+	CipherSuites          []string `json:"cipher_suites"` // TLS 1.2 suites by Go name; empty uses Go's defaults
+	HSTSEnabled           bool     `json:"hsts_enabled"`
+	HSTSMaxAge            int      `json:"hsts_max_age"` // Seconds
+	HSTSIncludeSubdomains bool     `json:"hsts_include_subdomains"`
+	HTTPRedirect          bool     `json:"http_redirect"` // Redirect plain HTTP to HTTPS
+	HTTPRedirectPort      int      `json:"http_redirect_port"`
+}
+
+// TLSCipherSuite is a TLS 1.2 cipher suite that may be enabled
+type TLSCipherSuite struct {
+	Name    string `json:"name"`
+	Default bool   `json:"default"` // Enabled when no suites are configured
+}
+
+// TLSStatus reports the TLS settings and the state of the listeners they control
+type TLSStatus struct {
+	Settings              TLSSettings      `json:"settings"`
+	Enabled               bool             `json:"enabled"` // False when serving plain HTTP
+	AvailableCipherSuites []TLSCipherSuite `json:"available_cipher_suites"`
+	RedirectListening     bool             `json:"redirect_listening"`
+	RedirectError         string           `json:"redirect_error,omitempty"`
+}
+
+// Audit action for TLS settings changes
+const ActionTLSSettings = "system.tls"
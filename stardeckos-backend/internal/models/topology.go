@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// TopologyNodeType is the kind of a node in the network topology
+type TopologyNodeType string
+
+const (
+	TopologyHost      TopologyNodeType = "host"
+	TopologyInterface TopologyNodeType = "interface"
+	TopologyBridge    TopologyNodeType = "bridge"
+	TopologyNetwork   TopologyNodeType = "network"
+	TopologyContainer TopologyNodeType = "container"
+)
+
+// TopologyEdgeType is how two topology nodes are connected
+type TopologyEdgeType string
+
+const (
+	TopologyEdgeLink      TopologyEdgeType = "link"      // Host to its interfaces
+	TopologyEdgeMember    TopologyEdgeType = "member"    // Interface enslaved to a bridge or bond
+	TopologyEdgeBackedBy  TopologyEdgeType = "backed_by" // Podman network to its bridge or parent interface
+	TopologyEdgeAttached  TopologyEdgeType = "attached"  // Container to a podman network
+	TopologyEdgePublish   TopologyEdgeType = "publish"   // Host or interface forwarding ports to a container
+	TopologyEdgeHostShare TopologyEdgeType = "host_network"
+)
+
+// TopologyNode is a host, interface, network or container in the topology
+type TopologyNode struct {
+	ID        string           `json:"id"`
+	Type      TopologyNodeType `json:"type"`
+	Label     string           `json:"label"`
+	State     string           `json:"state,omitempty"` // Interface operstate or container status
+	Addresses []string         `json:"addresses,omitempty"`
+	Driver    string           `json:"driver,omitempty"` // Podman network driver
+	Subnet    string           `json:"subnet,omitempty"`
+	Internal  bool             `json:"internal,omitempty"`
+	Image     string           `json:"image,omitempty"`
+	Stack     string           `json:"stack,omitempty"`
+	Managed   bool             `json:"managed,omitempty"` // Container is managed by Stardeck
+}
+
+// TopologyEdge connects two nodes
+type TopologyEdge struct {
+	From  string           `json:"from"`
+	To    string           `json:"to"`
+	Type  TopologyEdgeType `json:"type"`
+	Label string           `json:"label,omitempty"`
+	Ports []PortMapping    `json:"ports,omitempty"` // Published ports on publish edges
+}
+
+// Topology is a graph of how traffic reaches the host's containers
+type Topology struct {
+	Nodes       []TopologyNode `json:"nodes"`
+	Edges       []TopologyEdge `json:"edges"`
+	Mode        string         `json:"mode"` // Podman mode; rootless networks live outside the host's namespace
+	GeneratedAt time.Time      `json:"generated_at"`
+}
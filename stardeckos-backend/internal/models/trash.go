@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// TrashItemType identifies what kind of resource a trash item holds
+type TrashItemType string
+
+const (
+	TrashItemContainer TrashItemType = "container"
+	TrashItemStack     TrashItemType = "stack"
+	TrashItemVolume    TrashItemType = "volume"
+	TrashItemBindMount TrashItemType = "bind_mount"
+)
+
+// TrashItem represents a soft-deleted container or stack kept for later restore
+type TrashItem struct {
+	ID         string        `json:"id"`
+	ItemType   TrashItemType `json:"item_type"`
+	Name       string        `json:"name"`        // Container or stack name at deletion time
+	OriginalID string        `json:"original_id"` // Stardeck ID (or Podman ID) of the removed resource
+	Payload    string        `json:"-"`           // JSON snapshot needed to restore the resource
+	BackupPath string        `json:"backup_path"` // Directory holding preserved files/data, if any
+	HasBackup  bool          `json:"has_backup"`  // Whether a data backup was taken
+	SizeBytes  int64         `json:"size_bytes"`
+	DeletedAt  time.Time     `json:"deleted_at"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	DeletedBy  *int64        `json:"deleted_by,omitempty"`
+}
+
+// TrashedContainer is the payload stored for a soft-deleted container
+type TrashedContainer struct {
+	Config    ContainerConfig  `json:"config"`
+	Container *Container       `json:"container,omitempty"` // Stardeck metadata, nil if the container was unmanaged
+	Backup    *ContainerBackup `json:"backup,omitempty"`    // Bind mount backup taken before removal
+}
+
+// TrashedStack is the payload stored for a soft-deleted stack
+type TrashedStack struct {
+	Stack          Stack `json:"stack"`
+	VolumesRemoved bool  `json:"volumes_removed"` // Whether named volumes were deleted with the stack
+}
+
+// TrashedData is the payload stored for a volume or bind mount directory archived before deletion
+type TrashedData struct {
+	Volume      *Volume `json:"volume,omitempty"` // Set for podman volumes
+	Path        string  `json:"path,omitempty"`   // Set for bind mount directories
+	ArchivePath string  `json:"archive_path"`     // Tarball in the backup path; kept after the trash entry expires
+}
+
+// RestoreTrashRequest represents options for restoring a trash item
+type RestoreTrashRequest struct {
+	Start bool `json:"start"` // Start the container / deploy the stack after restore
+}
+
+// Audit action constants for the recycle bin
+const (
+	ActionTrashRestore = "trash.restore"
+	ActionTrashPurge   = "trash.purge"
+)
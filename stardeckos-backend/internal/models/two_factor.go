@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// TwoFactor is a local user's TOTP enrollment. Secret is pending until a
+// code from it is verified, which enables it.
+type TwoFactor struct {
+	UserID    int64      `json:"user_id"`
+	Secret    string     `json:"-"`
+	Enabled   bool       `json:"enabled"`
+	LastStep  int64      `json:"-"` // Last time step accepted, so a code can't be replayed
+	CreatedAt time.Time  `json:"created_at"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+}
+
+// TwoFactorStatus reports whether a user has two-factor authentication on
+type TwoFactorStatus struct {
+	Available              bool       `json:"available"` // Only local accounts can enroll
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorSetupResponse is a new secret for the user's authenticator app.
+// OTPAuthURL is what the QR code encodes.
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	Issuer     string `json:"issuer"`
+	Account    string `json:"account"`
+	Digits     int    `json:"digits"`
+	Period     int    `json:"period"`
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorDisableRequest turns two-factor authentication off, which needs
+// the password and a current code or a recovery code
+type TwoFactorDisableRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// TwoFactorRecoveryCodes are shown once, when they are generated
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Audit action constants for two-factor authentication
+const (
+	ActionTwoFactorEnable       = "auth.2fa_enable"
+	ActionTwoFactorDisable      = "auth.2fa_disable"
+	ActionTwoFactorReset        = "auth.2fa_reset"
+	ActionTwoFactorRecoveryUsed = "auth.2fa_recovery_used"
+	ActionTwoFactorCodesRenew   = "auth.2fa_recovery_codes"
+)
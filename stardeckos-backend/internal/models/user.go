@@ -8,7 +8,7 @@ type Role string
 const (
 	RoleAdmin    Role = "admin"
 	RoleOperator Role = "operator"
-	RoleViewer   Role = "viewer"
+	RoleViewer   Role = "viewer" // Read-only, enforced in auth.RequireAuth
 )
 
 // AuthType represents how a user authenticates
@@ -27,6 +27,7 @@ type User struct {
 	Email        string    `json:"email,omitempty"`
 	PasswordHash string    `json:"-"` // Never expose in JSON
 	Role         Role      `json:"role"`
+	RoleID       *int64    `json:"role_id,omitempty"` // Custom role; Role is then its base role
 	AuthType     AuthType  `json:"auth_type"`
 	RealmID      *int64    `json:"realm_id,omitempty"`
 	SystemUID    *string   `json:"system_uid,omitempty"` // Linux UID if synced to system
@@ -35,6 +36,7 @@ type User struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 	LastLogin    time.Time `json:"last_login,omitempty"`
 	IsPAMAdmin   bool      `json:"is_pam_admin,omitempty"` // Calculated: true if in wheel/sudo or is root
+	Permissions  []string  `json:"permissions,omitempty"`  // Calculated: granted by the user's role
 }
 
 // IsAdmin returns true if the user has admin privileges
@@ -44,6 +46,17 @@ func (u *User) IsAdmin() bool {
 	return u.Role == RoleAdmin || u.IsPAMAdmin
 }
 
+// IsReadOnly returns true for viewers, who may see everything but change nothing
+func (u *User) IsReadOnly() bool {
+	return u.Role == RoleViewer && !u.IsPAMAdmin
+}
+
+// HasPermission returns true if the user's role grants perm. Admins hold
+// every permission.
+func (u *User) HasPermission(perm string) bool {
+	return u.IsAdmin() || HasPermission(u.Permissions, perm)
+}
+
 // CanManageUsers returns true if the user can manage other users
 func (u *User) CanManageUsers() bool {
 	// PAM admins (wheel/sudo/root) can always manage users
@@ -61,6 +74,7 @@ type CreateUserRequest struct {
 	Email        string `json:"email,omitempty" validate:"omitempty,email"`
 	Password     string `json:"password" validate:"required,min=8"`
 	Role         Role   `json:"role" validate:"required,oneof=admin operator viewer"`
+	RoleID       *int64 `json:"role_id,omitempty"` // Custom role, replacing Role with its base role
 	RealmID      *int64 `json:"realm_id,omitempty"`
 	CreateSystem bool   `json:"create_system"` // Also create Linux system user
 }
@@ -70,7 +84,8 @@ type UpdateUserRequest struct {
 	DisplayName *string `json:"display_name,omitempty"`
 	Email       *string `json:"email,omitempty"`
 	Password    *string `json:"password,omitempty"`
-	Role        *Role   `json:"role,omitempty"`
+	Role        *Role   `json:"role,omitempty"`    // Built-in role, clearing any custom role
+	RoleID      *int64  `json:"role_id,omitempty"` // Custom role
 	Disabled    *bool   `json:"disabled,omitempty"`
 }
 
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// MountUsage reports the disk a named volume or bind mount uses on the host
+type MountUsage struct {
+	Type              string    `json:"type"`           // volume or bind
+	Name              string    `json:"name,omitempty"` // Volume name when Type is volume
+	HostPath          string    `json:"host_path"`
+	SizeBytes         int64     `json:"size_bytes"`
+	MeasuredAt        time.Time `json:"measured_at"`
+	Containers        []string  `json:"containers"`                // Names of the containers mounting it
+	Shared            bool      `json:"shared"`                    // Also mounted by another app
+	GrowthBytesPerDay float64   `json:"growth_bytes_per_day"`      // 0 until there is a day of history
+	FreeBytes         uint64    `json:"free_bytes"`                // Free on the filesystem holding it
+	DaysUntilFull     *float64  `json:"days_until_full,omitempty"` // At the current growth rate
+	Error             string    `json:"error,omitempty"`           // Why it couldn't be measured
+}
+
+// AppStorageUsage groups the mounts of one app: a compose stack, or a
+// container on its own
+type AppStorageUsage struct {
+	App               string       `json:"app"`
+	Stack             bool         `json:"stack"`
+	Containers        []string     `json:"containers"`
+	Mounts            []MountUsage `json:"mounts"`
+	TotalBytes        int64        `json:"total_bytes"`
+	GrowthBytesPerDay float64      `json:"growth_bytes_per_day"`
+	DaysUntilFull     *float64     `json:"days_until_full,omitempty"` // Soonest of its mounts
+	Warning           bool         `json:"warning"`                   // Full within the warning window
+}
+
+// StorageUsageReport lists storage by app, largest first. TotalBytes counts
+// a mount shared by several apps once.
+type StorageUsageReport struct {
+	Apps       []AppStorageUsage `json:"apps"`
+	TotalBytes int64             `json:"total_bytes"`
+	WarnDays   int               `json:"warn_days"`
+	Warnings   int               `json:"warnings"`
+}
+
+// VolumeUsageSample is a recorded size of a mount, kept to work out growth
+type VolumeUsageSample struct {
+	HostPath  string    `json:"host_path"`
+	SizeBytes int64     `json:"size_bytes"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// WebDAVShare exposes a container bind-mount directory over WebDAV
+type WebDAVShare struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"` // URL segment: /api/dav/<name>/
+	Path        string    `json:"path"` // Host directory being shared
+	Description string    `json:"description,omitempty"`
+	ReadOnly    bool      `json:"read_only"`
+	MinRole     Role      `json:"min_role"` // Lowest role allowed to connect
+	Enabled     bool      `json:"enabled"`
+	URL         string    `json:"url"` // Computed: path clients mount
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   *int64    `json:"created_by,omitempty"`
+}
+
+// CreateWebDAVShareRequest represents a request to share a directory over WebDAV
+type CreateWebDAVShareRequest struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+	ReadOnly    bool   `json:"read_only"`
+	MinRole     Role   `json:"min_role,omitempty"` // Defaults to operator
+	Enabled     *bool  `json:"enabled,omitempty"`
+}
+
+// UpdateWebDAVShareRequest represents a request to update a WebDAV share
+type UpdateWebDAVShareRequest struct {
+	Description *string `json:"description,omitempty"`
+	ReadOnly    *bool   `json:"read_only,omitempty"`
+	MinRole     *Role   `json:"min_role,omitempty"`
+	Enabled     *bool   `json:"enabled,omitempty"`
+}
+
+// Audit action constants for WebDAV shares
+const (
+	ActionWebDAVShareCreate = "webdav.share_create"
+	ActionWebDAVShareUpdate = "webdav.share_update"
+	ActionWebDAVShareDelete = "webdav.share_delete"
+)
@@ -0,0 +1,165 @@
+// Package pdf writes simple text-only PDF documents using the standard
+// Helvetica fonts, enough for exported reports without a third-party library.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth    = 612 // US Letter, in points
+	pageHeight   = 792
+	margin       = 54
+	bodySize     = 10
+	headingSize  = 14
+	titleSize    = 18
+	lineSpacing  = 1.4
+	avgCharWidth = 0.5 // Approximate Helvetica advance as a fraction of the font size
+)
+
+// Document is a PDF being built line by line
+type Document struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// New starts a document with a title line on the first page
+func New(title string) *Document {
+	d := &Document{}
+	d.newPage()
+	d.line(title, "F2", titleSize)
+	d.y -= bodySize
+	return d
+}
+
+// Heading writes a bold section heading
+func (d *Document) Heading(text string) {
+	d.y -= bodySize / 2
+	d.line(text, "F2", headingSize)
+}
+
+// Text writes body text, wrapping it to the page width
+func (d *Document) Text(text string) {
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrap(paragraph, bodySize) {
+			d.line(line, "F1", bodySize)
+		}
+	}
+}
+
+// Blank writes an empty line
+func (d *Document) Blank() {
+	d.line("", "F1", bodySize)
+}
+
+// Bytes renders the finished document
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then adds
+	// a page object followed by its content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// newPage starts a page with the cursor at the top margin
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+// line writes a single line, breaking to a new page when the current one is full
+func (d *Document) line(text, font string, size float64) {
+	height := size * lineSpacing
+	if d.y-height < margin {
+		d.newPage()
+	}
+	d.y -= height
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %g Tf %d %.2f Td (%s) Tj ET\n", font, size, margin, d.y, escape(text))
+}
+
+// wrap splits text into lines that fit the printable width at the given size
+func wrap(text string, size float64) []string {
+	maxChars := int((pageWidth - 2*margin) / (size * avgCharWidth))
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for len(word) > maxChars {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:maxChars])
+			word = word[maxChars:]
+		}
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= maxChars:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// escape makes text safe inside a PDF string literal. Characters outside
+// Latin-1 have no WinAnsi glyph and are replaced.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r > 0xff:
+			b.WriteByte('?')
+		case r >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
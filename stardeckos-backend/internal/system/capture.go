@@ -0,0 +1,201 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	// CaptureDir holds finished packet captures
+	CaptureDir = "/var/lib/stardeck/captures"
+	// maxCaptures is how many captures are kept; older ones are removed
+	maxCaptures = 20
+)
+
+var (
+	captureIDPattern      = regexp.MustCompile(`^[0-9]{8}-[0-9]{6}-[a-zA-Z0-9_.-]+$`)
+	capturedPacketPattern = regexp.MustCompile(`(?m)^([0-9]+) packets? captured`)
+)
+
+// TcpdumpInstalled reports whether tcpdump is available
+func TcpdumpInstalled() bool {
+	_, err := exec.LookPath("tcpdump")
+	return err == nil
+}
+
+// tcpdumpFilterArgs ends option parsing so a filter can't pass flags to tcpdump
+func tcpdumpFilterArgs(filter string) []string {
+	if strings.TrimSpace(filter) == "" {
+		return nil
+	}
+	return []string{"--", filter}
+}
+
+// ValidateCaptureFilter compiles a BPF filter for an interface without capturing
+func ValidateCaptureFilter(iface, filter string) error {
+	args := append([]string{"-d", "-i", iface}, tcpdumpFilterArgs(filter)...)
+	if output, err := exec.Command("tcpdump", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("invalid filter: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// CapturePath returns the pcap file of a capture
+func CapturePath(id string) (string, error) {
+	if !captureIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid capture ID")
+	}
+	return filepath.Join(CaptureDir, id+".pcap"), nil
+}
+
+// RunCapture captures packets into a pcap file until the duration elapses,
+// the packet limit is reached or ctx is cancelled. progress is called about
+// once a second with the bytes written so far.
+func RunCapture(ctx context.Context, req models.PacketCaptureRequest, startedBy string, progress func(size int64, elapsed time.Duration)) (*models.PacketCapture, error) {
+	if err := os.MkdirAll(CaptureDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create capture directory: %w", err)
+	}
+	started := time.Now()
+	capture := &models.PacketCapture{
+		ID:        started.Format("20060102-150405") + "-" + req.Interface,
+		Interface: req.Interface,
+		Filter:    req.Filter,
+		StartedAt: started,
+		StartedBy: startedBy,
+	}
+	path, err := CapturePath(capture.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// -Z root keeps tcpdump from dropping to a user that can't write the capture directory
+	args := []string{"-i", req.Interface, "-w", path, "-Z", "root", "-s", strconv.Itoa(req.SnapLen), "-n"}
+	if req.MaxPackets > 0 {
+		args = append(args, "-c", strconv.Itoa(req.MaxPackets))
+	}
+	args = append(args, tcpdumpFilterArgs(req.Filter)...)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(req.Duration)*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "tcpdump", args...)
+	// An interrupt lets tcpdump flush its buffer and report the packet count
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 5 * time.Second
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start tcpdump: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var waitErr error
+loop:
+	for {
+		select {
+		case waitErr = <-done:
+			break loop
+		case <-ticker.C:
+			if info, err := os.Stat(path); err == nil && progress != nil {
+				progress(info.Size(), time.Since(started))
+			}
+		}
+	}
+
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		return nil, fmt.Errorf("tcpdump failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	// tcpdump exits nonzero when interrupted; the capture is still complete
+	if waitErr != nil && ctx.Err() == nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("tcpdump failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	capture.Size = info.Size()
+	capture.Duration = time.Since(started).Seconds()
+	if m := capturedPacketPattern.FindStringSubmatch(stderr.String()); m != nil {
+		capture.Packets, _ = strconv.Atoi(m[1])
+	}
+	meta, _ := json.Marshal(capture)
+	if err := os.WriteFile(strings.TrimSuffix(path, ".pcap")+".json", meta, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save capture details: %w", err)
+	}
+	pruneCaptures()
+	return capture, nil
+}
+
+// ListCaptures returns the stored captures, newest first
+func ListCaptures() ([]models.PacketCapture, error) {
+	captures := []models.PacketCapture{}
+	files, err := filepath.Glob(filepath.Join(CaptureDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var capture models.PacketCapture
+		if json.Unmarshal(data, &capture) == nil {
+			captures = append(captures, capture)
+		}
+	}
+	sort.Slice(captures, func(i, j int) bool { return captures[i].StartedAt.After(captures[j].StartedAt) })
+	return captures, nil
+}
+
+// GetCapture returns a stored capture
+func GetCapture(id string) (*models.PacketCapture, error) {
+	path, err := CapturePath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(strings.TrimSuffix(path, ".pcap") + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("capture not found")
+	}
+	var capture models.PacketCapture
+	if err := json.Unmarshal(data, &capture); err != nil {
+		return nil, fmt.Errorf("failed to read capture details: %w", err)
+	}
+	return &capture, nil
+}
+
+// RemoveCapture deletes a capture and its details
+func RemoveCapture(id string) error {
+	path, err := CapturePath(id)
+	if err != nil {
+		return err
+	}
+	os.Remove(strings.TrimSuffix(path, ".pcap") + ".json")
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove capture: %w", err)
+	}
+	return nil
+}
+
+// pruneCaptures removes the oldest captures beyond maxCaptures
+func pruneCaptures() {
+	captures, err := ListCaptures()
+	if err != nil {
+		return
+	}
+	for i := maxCaptures; i < len(captures); i++ {
+		RemoveCapture(captures[i].ID)
+	}
+}
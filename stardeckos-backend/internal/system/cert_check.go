@@ -0,0 +1,138 @@
+package system
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// CertDaysLeft is the number of whole days until a certificate expires,
+// negative once it has
+func CertDaysLeft(notAfter time.Time) int {
+	return int(math.Floor(time.Until(notAfter).Hours() / 24))
+}
+
+// ValidateCertMonitor normalizes and checks a monitor's endpoint settings
+func ValidateCertMonitor(req *models.CertMonitorRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Host = strings.ToLower(strings.TrimSpace(req.Host))
+	req.ServerName = strings.ToLower(strings.TrimSpace(req.ServerName))
+	if req.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if net.ParseIP(req.Host) == nil && !domainNamePattern.MatchString(req.Host) {
+		return fmt.Errorf("host must be a hostname or IP address")
+	}
+	if req.ServerName != "" && !domainNamePattern.MatchString(req.ServerName) {
+		return fmt.Errorf("server_name must be a hostname")
+	}
+	if req.Name == "" {
+		req.Name = req.Host
+	}
+	if req.Port == 0 {
+		req.Port = 443
+	}
+	if req.Port < 1 || req.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if req.WarnDays == 0 {
+		req.WarnDays = 21
+	}
+	if req.WarnDays < 1 || req.WarnDays > 365 {
+		return fmt.Errorf("warn_days must be between 1 and 365")
+	}
+	return nil
+}
+
+// describeCertificate fills in a check from the leaf certificate, verifying it
+// against the system roots for serverName
+func describeCertificate(check *models.CertCheck, leaf *x509.Certificate, intermediates []*x509.Certificate, serverName string) {
+	check.Subject = leaf.Subject.String()
+	check.Issuer = leaf.Issuer.String()
+	check.DNSNames = leaf.DNSNames
+	notAfter := leaf.NotAfter
+	check.NotAfter = &notAfter
+	days := CertDaysLeft(notAfter)
+	check.DaysLeft = &days
+
+	pool := x509.NewCertPool()
+	for _, cert := range intermediates {
+		pool.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: serverName, Intermediates: pool}); err != nil {
+		check.VerifyError = err.Error()
+	} else {
+		check.Trusted = true
+	}
+}
+
+// CheckEndpointCertificate fetches the certificate served at host:port.
+// Untrusted certificates are still reported, so self-signed endpoints can be
+// monitored too.
+func CheckEndpointCertificate(ctx context.Context, host string, port int, serverName string) models.CertCheck {
+	now := time.Now()
+	check := models.CertCheck{CheckedAt: &now}
+	if serverName == "" {
+		serverName = host
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 10 * time.Second},
+		// Verification happens afterwards so an untrusted certificate is still read
+		Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		check.Error = "the server sent no certificate"
+		return check
+	}
+	describeCertificate(&check, certs[0], certs[1:], serverName)
+	return check
+}
+
+// CheckCertificateFile reads a PEM certificate from disk, verifying it for serverName
+func CheckCertificateFile(path, serverName string) models.CertCheck {
+	now := time.Now()
+	check := models.CertCheck{CheckedAt: &now}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			check.Error = fmt.Sprintf("failed to parse certificate: %v", err)
+			return check
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		check.Error = "no certificate found in " + path
+		return check
+	}
+	describeCertificate(&check, certs[0], certs[1:], serverName)
+	return check
+}
@@ -0,0 +1,455 @@
+package system
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ParseComposeServices returns the services in a compose file with their images,
+// profiles, depends_on entries, links, networks, resource limits, published
+// ports and mounts. It only understands the subset of YAML needed for those
+// keys.
+func ParseComposeServices(content string) []models.ComposeService {
+	var services []models.ComposeService
+	var svc *models.ComposeService
+	var ports []composePortSpec // Expanded once the file is read, as long syntax spans lines
+	longItem := false           // The current ports or volumes item uses the long syntax
+
+	inServices := false
+	serviceIndent := -1
+	keyIndent := -1 // Indent of the current service's own keys
+	key := ""       // Current service-level key
+	subIndent := -1 // Indent of map entries under depends_on and networks
+	var deployPath []yamlKey
+
+	for _, raw := range strings.Split(content, "\n") {
+		line := stripYAMLComment(strings.TrimRight(raw, " \t\r"))
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		// Top-level keys open and close the services section
+		if indent == 0 {
+			inServices = trimmed == "services:"
+			serviceIndent = -1
+			svc = nil
+			continue
+		}
+		if !inServices {
+			continue
+		}
+
+		if serviceIndent == -1 {
+			serviceIndent = indent
+		}
+		if indent == serviceIndent {
+			services = append(services, models.ComposeService{
+				Name: unquoteYAML(strings.TrimSuffix(trimmed, ":")),
+			})
+			svc = &services[len(services)-1]
+			keyIndent, key, subIndent, deployPath = -1, "", -1, nil
+			continue
+		}
+		if svc == nil || indent < serviceIndent {
+			continue
+		}
+
+		if keyIndent == -1 {
+			keyIndent = indent
+		}
+		item, isItem := strings.CutPrefix(trimmed, "-")
+
+		// A new service-level key, unless it is a list item written at the key's indent
+		if indent == keyIndent && !isItem {
+			name, value, _ := strings.Cut(trimmed, ":")
+			key, subIndent = strings.TrimSpace(name), -1
+			value = strings.TrimSpace(value)
+			if key == "build" {
+				svc.Build = true
+			}
+			if value == "" {
+				continue
+			}
+			switch key {
+			case "image":
+				svc.Image = unquoteYAML(value)
+				continue
+			case "mem_limit":
+				svc.MemoryLimit = parseComposeBytes(unquoteYAML(value))
+				continue
+			case "cpus":
+				svc.CPULimit, _ = strconv.ParseFloat(unquoteYAML(value), 64)
+				continue
+			}
+			for _, v := range parseFlowList(value) {
+				switch key {
+				case "profiles":
+					svc.Profiles = append(svc.Profiles, v)
+				case "depends_on":
+					svc.DependsOn = append(svc.DependsOn, models.ComposeDependency{Service: v, Condition: models.DependsOnStarted})
+				case "links":
+					svc.Links = append(svc.Links, v)
+				case "networks":
+					svc.Networks = append(svc.Networks, v)
+				case "ports":
+					ports = append(ports, parseShortPort(len(services)-1, v))
+				case "volumes":
+					if m, ok := parseShortMount(v); ok {
+						svc.Mounts = append(svc.Mounts, m)
+					}
+				}
+			}
+			continue
+		}
+		if indent < keyIndent {
+			continue
+		}
+
+		switch key {
+		case "deploy":
+			// Track the key path below deploy to find resources.limits
+			name, value, _ := strings.Cut(trimmed, ":")
+			for len(deployPath) > 0 && deployPath[len(deployPath)-1].indent >= indent {
+				deployPath = deployPath[:len(deployPath)-1]
+			}
+			deployPath = append(deployPath, yamlKey{indent: indent, name: strings.TrimSpace(name)})
+			if len(deployPath) == 3 && deployPath[0].name == "resources" && deployPath[1].name == "limits" {
+				switch deployPath[2].name {
+				case "memory":
+					svc.MemoryLimit = parseComposeBytes(unquoteYAML(value))
+				case "cpus":
+					svc.CPULimit, _ = strconv.ParseFloat(unquoteYAML(value), 64)
+				}
+			}
+		case "profiles":
+			if isItem {
+				if v := unquoteYAML(item); v != "" {
+					svc.Profiles = append(svc.Profiles, v)
+				}
+			}
+		case "depends_on":
+			if isItem {
+				if v := unquoteYAML(item); v != "" {
+					svc.DependsOn = append(svc.DependsOn, models.ComposeDependency{Service: v, Condition: models.DependsOnStarted})
+				}
+				continue
+			}
+			// Long syntax: each dependency is a map with an optional condition
+			if subIndent == -1 {
+				subIndent = indent
+			}
+			name, value, _ := strings.Cut(trimmed, ":")
+			if indent == subIndent {
+				svc.DependsOn = append(svc.DependsOn, models.ComposeDependency{
+					Service:   unquoteYAML(name),
+					Condition: models.DependsOnStarted,
+				})
+			} else if strings.TrimSpace(name) == "condition" && len(svc.DependsOn) > 0 {
+				svc.DependsOn[len(svc.DependsOn)-1].Condition = unquoteYAML(value)
+			}
+		case "links":
+			if isItem {
+				if v := unquoteYAML(item); v != "" {
+					svc.Links = append(svc.Links, v)
+				}
+			}
+		case "networks":
+			if isItem {
+				if v := unquoteYAML(item); v != "" {
+					svc.Networks = append(svc.Networks, v)
+				}
+				continue
+			}
+			// Map syntax: each network may carry aliases or addresses below it
+			if subIndent == -1 {
+				subIndent = indent
+			}
+			if indent == subIndent {
+				name, _, _ := strings.Cut(trimmed, ":")
+				svc.Networks = append(svc.Networks, unquoteYAML(name))
+			}
+		case "ports":
+			if isItem {
+				item = strings.TrimSpace(item)
+				_, _, longItem = yamlField(item)
+				if !longItem {
+					ports = append(ports, parseShortPort(len(services)-1, unquoteYAML(item)))
+					continue
+				}
+				ports = append(ports, composePortSpec{service: len(services) - 1})
+			} else {
+				item = trimmed
+			}
+			if name, value, ok := yamlField(item); ok && longItem {
+				ports[len(ports)-1].set(name, unquoteYAML(value))
+			}
+		case "volumes":
+			if isItem {
+				item = strings.TrimSpace(item)
+				_, _, longItem = yamlField(item)
+				if !longItem {
+					if m, ok := parseShortMount(unquoteYAML(item)); ok {
+						svc.Mounts = append(svc.Mounts, m)
+					}
+					continue
+				}
+				svc.Mounts = append(svc.Mounts, models.ComposeMount{Type: "volume"})
+			} else {
+				item = trimmed
+			}
+			if name, value, ok := yamlField(item); ok && longItem {
+				m := &svc.Mounts[len(svc.Mounts)-1]
+				switch value = unquoteYAML(value); name {
+				case "type":
+					m.Type = value
+				case "source":
+					m.Source = value
+				case "target":
+					m.Target = value
+				case "read_only":
+					m.ReadOnly = value == "true"
+				}
+			}
+		}
+	}
+
+	for _, spec := range ports {
+		services[spec.service].Ports = append(services[spec.service].Ports, spec.expand()...)
+	}
+	return services
+}
+
+// composePortSpec is a ports entry before its ranges are expanded
+type composePortSpec struct {
+	service   int // Index of the service it belongs to
+	hostIP    string
+	published string // Port or range; empty when Podman picks the host port
+	target    string
+	protocol  string
+}
+
+func (p *composePortSpec) set(field, value string) {
+	switch field {
+	case "host_ip":
+		p.hostIP = value
+	case "published":
+		p.published = value
+	case "target":
+		p.target = value
+	case "protocol":
+		p.protocol = value
+	}
+}
+
+// parseShortPort reads a short syntax ports entry such as 8080:80,
+// 127.0.0.1:53:53/udp, [::1]:8080:80 or 9000-9002:9000-9002
+func parseShortPort(service int, entry string) composePortSpec {
+	spec := composePortSpec{service: service}
+	entry, spec.protocol, _ = strings.Cut(entry, "/")
+
+	if strings.HasPrefix(entry, "[") {
+		if end := strings.Index(entry, "]:"); end > 0 {
+			spec.hostIP, entry = entry[1:end], entry[end+2:]
+		}
+	} else if strings.Count(entry, ":") == 2 {
+		spec.hostIP, entry, _ = strings.Cut(entry, ":")
+	}
+	if published, target, ok := strings.Cut(entry, ":"); ok {
+		spec.published, spec.target = published, target
+	} else {
+		spec.target = entry
+	}
+	return spec
+}
+
+// expand returns the host ports an entry publishes, one per port of a range
+func (p composePortSpec) expand() []models.ComposePort {
+	hostStart, hostEnd, ok := parsePortRange(p.published)
+	if !ok {
+		return nil
+	}
+	targetStart, targetEnd, ok := parsePortRange(p.target)
+	if !ok {
+		return nil
+	}
+	protocol := strings.ToLower(p.protocol)
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	var ports []models.ComposePort
+	for host := hostStart; host <= hostEnd; host++ {
+		// A single target behind a host range is served on one port of it,
+		// but every port in the range must be free to be sure
+		target := targetStart
+		if targetEnd > targetStart {
+			target += host - hostStart
+		}
+		ports = append(ports, models.ComposePort{HostIP: p.hostIP, Host: host, Target: target, Protocol: protocol})
+	}
+	return ports
+}
+
+// parsePortRange parses a port or a start-end range
+func parsePortRange(s string) (int, int, bool) {
+	startValue, endValue, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	start, err := strconv.Atoi(startValue)
+	if err != nil || start < 1 || start > 65535 {
+		return 0, 0, false
+	}
+	if !isRange {
+		return start, start, true
+	}
+	end, err := strconv.Atoi(endValue)
+	if err != nil || end < start || end > 65535 {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// parseShortMount reads a short syntax volumes entry such as ./data:/data:ro
+// or name:/data. Anonymous volumes, given only a target, have no source.
+func parseShortMount(entry string) (models.ComposeMount, bool) {
+	if entry == "" {
+		return models.ComposeMount{}, false
+	}
+	parts := strings.Split(entry, ":")
+	if len(parts) == 1 {
+		return models.ComposeMount{Type: "volume", Target: parts[0]}, true
+	}
+
+	m := models.ComposeMount{Type: "volume", Source: parts[0], Target: parts[1]}
+	if strings.HasPrefix(m.Source, "/") || strings.HasPrefix(m.Source, ".") || strings.HasPrefix(m.Source, "~") {
+		m.Type = "bind"
+	}
+	if len(parts) > 2 {
+		for _, opt := range strings.Split(parts[2], ",") {
+			if opt == "ro" {
+				m.ReadOnly = true
+			}
+		}
+	}
+	return m, true
+}
+
+// yamlField splits a "key: value" mapping entry, telling it apart from a
+// scalar that merely contains colons, like 8080:80 or data:/data
+func yamlField(s string) (string, string, bool) {
+	name, value, ok := strings.Cut(s, ":")
+	if !ok || name == "" || (value != "" && value[0] != ' ') {
+		return "", "", false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') {
+			return "", "", false
+		}
+	}
+	return name, strings.TrimSpace(value), true
+}
+
+// ParseComposeProfiles returns the profiles declared by services in a compose file
+// and the services that run without any profile
+func ParseComposeProfiles(content string) ([]models.ComposeProfile, []string) {
+	profileServices := make(map[string][]string)
+	var defaultServices []string
+
+	for _, svc := range ParseComposeServices(content) {
+		if len(svc.Profiles) == 0 {
+			defaultServices = append(defaultServices, svc.Name)
+			continue
+		}
+		for _, p := range svc.Profiles {
+			profileServices[p] = append(profileServices[p], svc.Name)
+		}
+	}
+
+	profiles := make([]models.ComposeProfile, 0, len(profileServices))
+	for name, services := range profileServices {
+		profiles = append(profiles, models.ComposeProfile{Name: name, Services: services})
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+
+	return profiles, defaultServices
+}
+
+// parseFlowList splits a flow-style sequence like [a, "b"] or a single scalar
+func parseFlowList(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var items []string
+	for _, v := range strings.Split(value, ",") {
+		if v = unquoteYAML(v); v != "" {
+			items = append(items, v)
+		}
+	}
+	return items
+}
+
+// stripYAMLComment removes a trailing comment that is not inside quotes
+func stripYAMLComment(line string) string {
+	inSingle, inDouble := false, false
+	for i, r := range line {
+		switch r {
+		case '\'':
+			if !inDouble {
+				inSingle = !inSingle
+			}
+		case '"':
+			if !inSingle {
+				inDouble = !inDouble
+			}
+		case '#':
+			if !inSingle && !inDouble && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+				return strings.TrimRight(line[:i], " \t")
+			}
+		}
+	}
+	return line
+}
+
+// unquoteYAML trims whitespace and surrounding quotes from a scalar
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+// yamlKey is a mapping key and the indent it was found at
+type yamlKey struct {
+	indent int
+	name   string
+}
+
+// parseComposeBytes parses a compose byte value such as 512m, 1g, 1.5GB or a
+// plain number of bytes. Compose units are binary.
+func parseComposeBytes(s string) int64 {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimSuffix(s, "b")
+	multiplier := float64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'k':
+			multiplier = 1 << 10
+		case 'm':
+			multiplier = 1 << 20
+		case 'g':
+			multiplier = 1 << 30
+		case 't':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return int64(value * multiplier)
+}
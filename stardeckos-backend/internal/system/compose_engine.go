@@ -0,0 +1,162 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Compose engines Stardeck can run stacks with, in order of preference
+const (
+	ComposeEnginePodman        = "podman compose" // Podman's compose subcommand, which runs whichever provider is installed
+	ComposeEngineDockerCompose = "docker-compose" // Compose v2, against Podman's Docker-compatible socket
+	ComposeEnginePodmanCompose = "podman-compose"
+)
+
+// ErrNoComposeEngine means none of the compose engines is installed
+var ErrNoComposeEngine = errors.New("no compose engine found: install podman-compose or docker-compose")
+
+// composeEngine is a detected compose engine and the command that runs it
+type composeEngine struct {
+	name    string
+	command []string
+	version string
+}
+
+// ComposeEngineInfo describes the compose engine in use
+type ComposeEngineInfo struct {
+	Engine  string `json:"engine"`
+	Version string `json:"version"`
+}
+
+// composeCandidates returns the engines to look for, preferred first.
+// STARDECK_COMPOSE_ENGINE narrows it to one: podman, docker-compose or
+// podman-compose.
+func composeCandidates() []composeEngine {
+	all := []composeEngine{
+		{name: ComposeEnginePodman, command: []string{"podman", "compose"}},
+		{name: ComposeEngineDockerCompose, command: []string{"docker-compose"}},
+		{name: ComposeEnginePodmanCompose, command: []string{"podman-compose"}},
+	}
+	forced := os.Getenv("STARDECK_COMPOSE_ENGINE")
+	if forced == "" {
+		return all
+	}
+	for _, e := range all {
+		if e.name == forced || e.command[0] == forced {
+			return []composeEngine{e}
+		}
+	}
+	return all
+}
+
+// detectComposeEngine finds the first compose engine that answers a version
+// query
+func detectComposeEngine(ctx context.Context) *composeEngine {
+	for _, e := range composeCandidates() {
+		versionCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		args := append(append([]string{}, e.command[1:]...), "version")
+		// podman compose reports which provider it runs on stderr
+		out, err := exec.CommandContext(versionCtx, e.command[0], args...).Output()
+		cancel()
+		if err != nil {
+			continue
+		}
+		if line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n"); line != "" {
+			e.version = strings.TrimSpace(line)
+		}
+		return &e
+	}
+	return nil
+}
+
+// composeEngine returns the compose engine, detecting it on first use. When
+// none was found it looks again, so one installed later is picked up.
+func (p *PodmanService) composeEngine(ctx context.Context) (*composeEngine, error) {
+	p.composeMu.Lock()
+	defer p.composeMu.Unlock()
+	if p.compose == nil {
+		p.compose = detectComposeEngine(ctx)
+	}
+	if p.compose == nil {
+		return nil, ErrNoComposeEngine
+	}
+	return p.compose, nil
+}
+
+// ComposeEngine detects the compose engine afresh and reports it, or nil
+// when none is installed
+func (p *PodmanService) ComposeEngine(ctx context.Context) *ComposeEngineInfo {
+	p.composeMu.Lock()
+	p.compose = detectComposeEngine(ctx)
+	engine := p.compose
+	p.composeMu.Unlock()
+	if engine == nil {
+		return nil
+	}
+	return &ComposeEngineInfo{Engine: engine.name, Version: engine.version}
+}
+
+// composeCmd builds a compose command for a project directory. env adds
+// variables for compose file interpolation. docker-compose is pointed at
+// Podman's socket unless DOCKER_HOST already says where to go; podman
+// compose does that itself.
+func (p *PodmanService) composeCmd(ctx context.Context, projectDir string, env []string, args ...string) (*exec.Cmd, error) {
+	engine, err := p.composeEngine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, engine.command[0], append(append([]string{}, engine.command[1:]...), args...)...)
+	cmd.Dir = projectDir
+	if engine.name == ComposeEngineDockerCompose && os.Getenv("DOCKER_HOST") == "" {
+		if socket := p.podmanSocketPath(); socket != "" {
+			env = append([]string{"DOCKER_HOST=unix://" + socket}, env...)
+		}
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd, nil
+}
+
+// runComposeStreaming runs a compose command, sending its output lines to
+// outputChan when it is set. The readers finish before it returns, so the
+// caller may close outputChan afterwards.
+func runComposeStreaming(cmd *exec.Cmd, action string, outputChan chan<- string) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start compose %s: %w", action, err)
+	}
+
+	done := make(chan struct{}, 2)
+	for _, pipe := range []io.Reader{stdout, stderr} {
+		go func() {
+			scanner := bufio.NewScanner(pipe)
+			for scanner.Scan() {
+				if outputChan != nil {
+					outputChan <- scanner.Text()
+				}
+			}
+			done <- struct{}{}
+		}()
+	}
+	<-done
+	<-done
+
+	return cmd.Wait()
+}
@@ -0,0 +1,306 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ComposeExportService is a container to render as a compose service
+type ComposeExportService struct {
+	Config *models.ContainerConfig
+	Image  *ImageConfig // Settings the image already provides are left out; nil keeps everything
+}
+
+// generatedHostnamePattern matches the hostname podman assigns from the container ID
+var generatedHostnamePattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// exportSkippedLabelPrefixes are labels set by the tools that created the container
+var exportSkippedLabelPrefixes = []string{"com.docker.compose.", "io.podman.compose.", "io.containers.", "PODMAN_SYSTEMD_UNIT"}
+
+// defaultNetworkModes are network modes compose uses without being told
+var defaultNetworkModes = map[string]bool{"": true, "bridge": true, "default": true, "slirp4netns": true, "pasta": true, "podman": true}
+
+// yamlScalar quotes a string for YAML. JSON strings are valid YAML
+// double-quoted scalars.
+func yamlScalar(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// yamlKeyPattern matches mapping keys that need no quoting
+var yamlKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+func yamlMapKey(s string) string {
+	if yamlKeyPattern.MatchString(s) {
+		return s
+	}
+	return yamlScalar(s)
+}
+
+// composeWriter builds indented YAML
+type composeWriter struct {
+	strings.Builder
+}
+
+func (w *composeWriter) line(indent int, format string, args ...interface{}) {
+	w.WriteString(strings.Repeat("  ", indent))
+	fmt.Fprintf(w, format, args...)
+	w.WriteByte('\n')
+}
+
+func (w *composeWriter) list(indent int, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	w.line(indent, "%s:", key)
+	for _, v := range values {
+		w.line(indent+1, "- %s", yamlScalar(v))
+	}
+}
+
+func (w *composeWriter) mapping(indent int, key string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	w.line(indent, "%s:", key)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		w.line(indent+1, "%s: %s", yamlMapKey(k), yamlScalar(values[k]))
+	}
+}
+
+// composePort formats a port mapping in compose's short syntax
+func composePort(p models.PortMapping) string {
+	spec := strconv.Itoa(p.ContainerPort)
+	if p.HostPort > 0 {
+		spec = strconv.Itoa(p.HostPort) + ":" + spec
+		if p.HostIP != "" && p.HostIP != "0.0.0.0" {
+			host := p.HostIP
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			spec = host + ":" + spec
+		}
+	}
+	if p.Protocol != "" && p.Protocol != "tcp" {
+		spec += "/" + p.Protocol
+	}
+	return spec
+}
+
+// exportEnvironment returns the variables the container sets beyond its image
+func exportEnvironment(cfg *models.ContainerConfig, image *ImageConfig) map[string]string {
+	defaults := make(map[string]string)
+	if image != nil {
+		for _, env := range image.Environment {
+			defaults[env.Key] = env.Value
+		}
+	}
+	env := make(map[string]string)
+	for key, value := range cfg.Environment {
+		if v, ok := defaults[key]; ok && v == value {
+			continue
+		}
+		// Podman sets these in every container
+		if key == "HOSTNAME" || (key == "container" && value == "podman") {
+			continue
+		}
+		env[key] = value
+	}
+	return env
+}
+
+// exportLabels returns the container's own labels with its Stardeck metadata
+func exportLabels(cfg *models.ContainerConfig, image *ImageConfig) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range cfg.Labels {
+		if image != nil && image.Labels[key] == value {
+			continue
+		}
+		skipped := false
+		for _, prefix := range exportSkippedLabelPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				skipped = true
+				break
+			}
+		}
+		if !skipped {
+			labels[key] = value
+		}
+	}
+	if cfg.HasWebUI {
+		labels["stardeck.webui"] = "true"
+		if cfg.WebUIPort > 0 {
+			labels["stardeck.webui.port"] = strconv.Itoa(cfg.WebUIPort)
+		}
+		if cfg.WebUIPath != "" && cfg.WebUIPath != "/" {
+			labels["stardeck.webui.path"] = cfg.WebUIPath
+		}
+	}
+	if cfg.Icon != "" {
+		labels["stardeck.icon"] = cfg.Icon
+	}
+	return labels
+}
+
+// RenderCompose renders containers as a compose file. Named volumes and
+// networks keep their names so a rebuild reattaches to existing data.
+func RenderCompose(services []ComposeExportService) string {
+	var w composeWriter
+	w.line(0, "# Exported from Stardeck on %s", time.Now().Format(time.RFC3339))
+	if len(services) == 0 {
+		w.line(0, "services: {}")
+	} else {
+		w.line(0, "services:")
+	}
+
+	volumes := make(map[string]bool)
+	networks := make(map[string]bool)
+	sort.Slice(services, func(i, j int) bool { return services[i].Config.Name < services[j].Config.Name })
+	for _, s := range services {
+		cfg, image := s.Config, s.Image
+		w.line(1, "%s:", yamlMapKey(cfg.Name))
+		w.line(2, "image: %s", yamlScalar(cfg.Image))
+		w.line(2, "container_name: %s", yamlScalar(cfg.Name))
+		if cfg.Hostname != "" && cfg.Hostname != cfg.Name && !generatedHostnamePattern.MatchString(cfg.Hostname) {
+			w.line(2, "hostname: %s", yamlScalar(cfg.Hostname))
+		}
+		if cfg.User != "" && (image == nil || cfg.User != image.User) {
+			w.line(2, "user: %s", yamlScalar(cfg.User))
+		}
+		if cfg.WorkDir != "" && (image == nil || cfg.WorkDir != image.WorkingDir) {
+			w.line(2, "working_dir: %s", yamlScalar(cfg.WorkDir))
+		}
+		if image == nil || !slices.Equal(cfg.Entrypoint, image.Entrypoint) {
+			w.list(2, "entrypoint", cfg.Entrypoint)
+		}
+		if image == nil || !slices.Equal(cfg.Command, image.Cmd) {
+			w.list(2, "command", cfg.Command)
+		}
+		if cfg.RestartPolicy != "" && cfg.RestartPolicy != "no" {
+			w.line(2, "restart: %s", yamlScalar(cfg.RestartPolicy))
+		}
+
+		switch mode := cfg.NetworkMode; {
+		case defaultNetworkModes[mode]:
+		case mode == "host", mode == "none", strings.HasPrefix(mode, "container:"):
+			w.line(2, "network_mode: %s", yamlScalar(mode))
+		default:
+			networks[mode] = true
+			w.line(2, "networks:")
+			if len(cfg.NetworkAliases) == 0 {
+				w.line(3, "%s: {}", yamlMapKey(mode))
+			} else {
+				w.line(3, "%s:", yamlMapKey(mode))
+				w.list(4, "aliases", cfg.NetworkAliases)
+			}
+		}
+
+		ports := slices.Clone(cfg.Ports)
+		sort.Slice(ports, func(i, j int) bool {
+			if ports[i].HostPort != ports[j].HostPort {
+				return ports[i].HostPort < ports[j].HostPort
+			}
+			return ports[i].ContainerPort < ports[j].ContainerPort
+		})
+		portSpecs := make([]string, len(ports))
+		for i, p := range ports {
+			portSpecs[i] = composePort(p)
+		}
+		w.list(2, "ports", portSpecs)
+
+		var mounts, tmpfs []string
+		for _, v := range cfg.Volumes {
+			var spec string
+			switch v.Type {
+			case "bind":
+				spec = v.Source + ":" + v.Target
+			case "volume":
+				name := v.Name
+				if name == "" {
+					name = v.Source
+				}
+				volumes[name] = true
+				spec = name + ":" + v.Target
+			case "tmpfs":
+				tmpfs = append(tmpfs, v.Target)
+				continue
+			default:
+				continue
+			}
+			if v.ReadOnly {
+				spec += ":ro"
+			}
+			mounts = append(mounts, spec)
+		}
+		sort.Strings(mounts)
+		w.list(2, "volumes", mounts)
+		w.list(2, "tmpfs", tmpfs)
+
+		w.mapping(2, "environment", exportEnvironment(cfg, image))
+		w.mapping(2, "labels", exportLabels(cfg, image))
+
+		if cfg.CPULimit > 0 {
+			w.line(2, "cpus: %s", yamlScalar(strconv.FormatFloat(cfg.CPULimit, 'f', -1, 64)))
+		}
+		if cfg.MemoryLimit > 0 {
+			w.line(2, "mem_limit: %d", cfg.MemoryLimit)
+		}
+
+		if cfg.Privileged {
+			w.line(2, "privileged: true")
+		}
+		w.list(2, "cap_add", cfg.CapAdd)
+		w.list(2, "cap_drop", cfg.CapDrop)
+		var securityOpt []string
+		if cfg.NoNewPrivileges {
+			securityOpt = append(securityOpt, "no-new-privileges:true")
+		}
+		if cfg.SeccompProfile != "" {
+			securityOpt = append(securityOpt, "seccomp="+cfg.SeccompProfile)
+		}
+		if cfg.AppArmorProfile != "" {
+			securityOpt = append(securityOpt, "apparmor="+cfg.AppArmorProfile)
+		}
+		w.list(2, "security_opt", securityOpt)
+		if cfg.ReadOnlyRootfs {
+			w.line(2, "read_only: true")
+		}
+		if cfg.UserNS != "" {
+			w.line(2, "userns_mode: %s", yamlScalar(cfg.UserNS))
+		}
+	}
+
+	for _, section := range []struct {
+		key   string
+		names map[string]bool
+	}{{"networks", networks}, {"volumes", volumes}} {
+		if len(section.names) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(section.names))
+		for name := range section.names {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w.WriteByte('\n')
+		w.line(0, "%s:", section.key)
+		for _, name := range names {
+			w.line(1, "%s:", yamlMapKey(name))
+			w.line(2, "name: %s", yamlScalar(name))
+		}
+	}
+	return w.String()
+}
@@ -0,0 +1,213 @@
+package system
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ComposeVariableRef is one variable reference met while interpolating a
+// compose file
+type ComposeVariableRef struct {
+	Name      string // Empty for malformed references
+	Set       bool
+	Defaulted bool   // Replaced by the reference's default or alternative value
+	Error     string // A required variable (${VAR:?message}) is missing
+}
+
+// Unresolved reports whether the reference renders as an empty string only
+// because its variable is unset
+func (r ComposeVariableRef) Unresolved() bool {
+	return r.Name != "" && !r.Set && !r.Defaulted && r.Error == ""
+}
+
+// InterpolateCompose substitutes $VAR and ${VAR} references in compose content
+// the way compose does, including the :-, -, :?, ?, :+ and + forms and $$
+// escapes. Full-line YAML comments are left as they are.
+func InterpolateCompose(content string, lookup func(name string) (string, bool)) (string, []ComposeVariableRef) {
+	var refs []ComposeVariableRef
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines[i] = interpolate(line, lookup, &refs)
+	}
+	return strings.Join(lines, "\n"), refs
+}
+
+func interpolate(s string, lookup func(string) (string, bool), refs *[]ComposeVariableRef) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(s, i+2)
+			if end < 0 {
+				*refs = append(*refs, ComposeVariableRef{Error: fmt.Sprintf("invalid interpolation format for %s: missing }", s[i:])})
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			b.WriteString(expandBraced(s[i+2:end], lookup, refs))
+			i = end
+		case isVarStart(next):
+			j := i + 1
+			for j < len(s) && isVarChar(s[j]) {
+				j++
+			}
+			name := s[i+1 : j]
+			value, ok := lookup(name)
+			*refs = append(*refs, ComposeVariableRef{Name: name, Set: ok})
+			b.WriteString(value)
+			i = j - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String()
+}
+
+// expandBraced evaluates the inside of a ${...} reference
+func expandBraced(expr string, lookup func(string) (string, bool), refs *[]ComposeVariableRef) string {
+	n := 0
+	for n < len(expr) && isVarChar(expr[n]) && (n > 0 || isVarStart(expr[n])) {
+		n++
+	}
+	name, rest := expr[:n], expr[n:]
+	if name == "" {
+		*refs = append(*refs, ComposeVariableRef{Error: fmt.Sprintf("invalid interpolation format for ${%s}", expr)})
+		return "${" + expr + "}"
+	}
+
+	op := ""
+	for _, candidate := range []string{":-", ":?", ":+", "-", "?", "+"} {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" && rest != "" {
+		*refs = append(*refs, ComposeVariableRef{Name: name, Error: fmt.Sprintf("invalid interpolation format for ${%s}", expr)})
+		return "${" + expr + "}"
+	}
+	arg := rest[len(op):]
+
+	value, set := lookup(name)
+	ref := ComposeVariableRef{Name: name, Set: set}
+	// The colon forms treat an empty value like an unset one
+	present := set && (value != "" || !strings.HasPrefix(op, ":"))
+
+	result := value
+	switch strings.TrimPrefix(op, ":") {
+	case "-":
+		if !present {
+			ref.Defaulted = true
+			result = interpolate(arg, lookup, refs)
+		}
+	case "?":
+		if !present {
+			ref.Error = "required variable " + name + " is missing a value"
+			if msg := interpolate(arg, lookup, refs); msg != "" {
+				ref.Error += ": " + msg
+			}
+		}
+	case "+":
+		ref.Defaulted = true
+		result = ""
+		if present {
+			result = interpolate(arg, lookup, refs)
+		}
+	}
+	*refs = append(*refs, ref)
+	return result
+}
+
+// matchingBrace returns the index of the } closing a reference whose
+// contents start at start, allowing nested references in defaults
+func matchingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isVarStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isVarChar(c byte) bool {
+	return isVarStart(c) || (c >= '0' && c <= '9')
+}
+
+// envFileVarPattern matches the references python-dotenv expands in .env
+// values: ${VAR} and ${VAR:-default}
+var envFileVarPattern = regexp.MustCompile(`\$\{([^}:]*)(?::-([^}]*))?\}`)
+
+// ParseEnvFile reads the variables in .env file content as podman-compose
+// does: blank lines and # comments are skipped, "export " prefixes and
+// quotes are removed, and ${VAR} references in unquoted or double-quoted
+// values are expanded from earlier entries or lookup
+func ParseEnvFile(content string, lookup func(name string) (string, bool)) map[string]string {
+	vars := make(map[string]string)
+	expandLookup := func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		if lookup != nil {
+			return lookup(name)
+		}
+		return "", false
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && strings.LastIndexByte(value, '\'') > 0:
+			vars[key] = value[1:strings.LastIndexByte(value, '\'')]
+			continue
+		case len(value) >= 2 && value[0] == '"' && strings.LastIndexByte(value, '"') > 0:
+			value = value[1:strings.LastIndexByte(value, '"')]
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value)
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		vars[key] = envFileVarPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			m := envFileVarPattern.FindStringSubmatch(ref)
+			if v, ok := expandLookup(m[1]); ok && v != "" {
+				return v
+			}
+			return m[2]
+		})
+	}
+	return vars
+}
@@ -0,0 +1,134 @@
+package system
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"stardeckos-backend/internal/models"
+)
+
+// HashContainerConfig returns a stable hash of a configuration, used to skip
+// snapshots that record no change
+func HashContainerConfig(config *models.ContainerConfig) string {
+	// encoding/json sorts map keys, so equal configurations marshal identically
+	data, _ := json.Marshal(config)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// DiffContainerConfigs lists the differences between two configurations.
+// Objects such as the environment and labels are compared key by key; lists
+// such as ports and volumes are compared as sets, reporting added and
+// removed entries, except for the command and entrypoint, whose order matters.
+func DiffContainerConfigs(old, new *models.ContainerConfig) []models.ConfigChange {
+	changes := []models.ConfigChange{}
+	diffConfigValues("", configAsMap(old), configAsMap(new), &changes)
+	return changes
+}
+
+// orderedConfigLists are compared as a whole rather than as sets
+var orderedConfigLists = map[string]bool{"command": true, "entrypoint": true}
+
+func configAsMap(config *models.ContainerConfig) map[string]interface{} {
+	m := map[string]interface{}{}
+	if config == nil {
+		return m
+	}
+	data, _ := json.Marshal(config)
+	json.Unmarshal(data, &m)
+	return m
+}
+
+func diffConfigValues(path string, old, new interface{}, changes *[]models.ConfigChange) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if (oldIsMap || old == nil) && (newIsMap || new == nil) && (oldIsMap || newIsMap) {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for k := range oldMap {
+			keys = append(keys, k)
+		}
+		for k := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field := k
+			if path != "" {
+				field = path + "." + k
+			}
+			diffConfigValues(field, oldMap[k], newMap[k], changes)
+		}
+		return
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if (oldIsList || old == nil) && (newIsList || new == nil) && (oldIsList || newIsList) && !orderedConfigLists[path] {
+		diffConfigLists(path, oldList, newList, changes)
+		return
+	}
+
+	if isEmptyConfigValue(old) && isEmptyConfigValue(new) {
+		return
+	}
+	switch {
+	case reflect.DeepEqual(old, new):
+	case isEmptyConfigValue(old):
+		*changes = append(*changes, models.ConfigChange{Field: path, Change: "added", New: new})
+	case isEmptyConfigValue(new):
+		*changes = append(*changes, models.ConfigChange{Field: path, Change: "removed", Old: old})
+	default:
+		*changes = append(*changes, models.ConfigChange{Field: path, Change: "changed", Old: old, New: new})
+	}
+}
+
+// diffConfigLists reports the entries only in one of two lists
+func diffConfigLists(path string, old, new []interface{}, changes *[]models.ConfigChange) {
+	key := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	oldKeys := map[string]bool{}
+	for _, v := range old {
+		oldKeys[key(v)] = true
+	}
+	newKeys := map[string]bool{}
+	for _, v := range new {
+		newKeys[key(v)] = true
+	}
+	for _, v := range old {
+		if !newKeys[key(v)] {
+			*changes = append(*changes, models.ConfigChange{Field: path, Change: "removed", Old: v})
+		}
+	}
+	for _, v := range new {
+		if !oldKeys[key(v)] {
+			*changes = append(*changes, models.ConfigChange{Field: path, Change: "added", New: v})
+		}
+	}
+}
+
+// isEmptyConfigValue treats a missing field the same as its zero value, so
+// fields added to the configuration later don't show up as changes
+func isEmptyConfigValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// HealthOnFailureLabel carries a container's on-failure policy for the
+// health monitor
+const HealthOnFailureLabel = "stardeck.health.on-failure"
+
+// ValidateHealthCheck checks a container's health check options
+func ValidateHealthCheck(opts *models.ContainerHealthCheck) error {
+	opts.HealthCmd = strings.TrimSpace(opts.HealthCmd)
+	opts.HealthOnFailure = strings.TrimSpace(opts.HealthOnFailure)
+	disabled := opts.HealthCmd == "none"
+
+	durations := []struct {
+		name  string
+		value *string
+		min   time.Duration
+	}{
+		{"interval", &opts.HealthInterval, time.Second},
+		{"timeout", &opts.HealthTimeout, time.Second},
+		{"start period", &opts.HealthStartPeriod, 0},
+	}
+	for _, d := range durations {
+		*d.value = strings.TrimSpace(*d.value)
+		if *d.value == "" {
+			continue
+		}
+		if disabled {
+			return fmt.Errorf("health check %s is set but the health check is turned off", d.name)
+		}
+		parsed, err := time.ParseDuration(*d.value)
+		if err != nil {
+			return fmt.Errorf("invalid health check %s '%s', use a duration such as 30s or 1m", d.name, *d.value)
+		}
+		if parsed < d.min {
+			return fmt.Errorf("health check %s must be at least %s", d.name, d.min)
+		}
+	}
+	if opts.HealthRetries < 0 || opts.HealthRetries > 100 {
+		return fmt.Errorf("health check retries must be between 0 and 100")
+	}
+	if opts.HealthRetries > 0 && disabled {
+		return fmt.Errorf("health check retries are set but the health check is turned off")
+	}
+
+	switch opts.HealthOnFailure {
+	case "", models.HealthOnFailureNone:
+	case models.HealthOnFailureRestart:
+		if disabled {
+			return fmt.Errorf("restarting on failure needs a health check")
+		}
+	default:
+		return fmt.Errorf("health on failure must be %s or %s", models.HealthOnFailureNone, models.HealthOnFailureRestart)
+	}
+	return nil
+}
+
+// containerHealthArgs maps health check options to podman create flags
+func containerHealthArgs(opts *models.ContainerHealthCheck) []string {
+	if opts.HealthCmd == "none" {
+		return []string{"--no-healthcheck"}
+	}
+	var args []string
+	if opts.HealthCmd != "" {
+		args = append(args, "--health-cmd", opts.HealthCmd)
+	}
+	if opts.HealthInterval != "" {
+		args = append(args, "--health-interval", opts.HealthInterval)
+	}
+	if opts.HealthTimeout != "" {
+		args = append(args, "--health-timeout", opts.HealthTimeout)
+	}
+	if opts.HealthRetries > 0 {
+		args = append(args, "--health-retries", fmt.Sprintf("%d", opts.HealthRetries))
+	}
+	if opts.HealthStartPeriod != "" {
+		args = append(args, "--health-start-period", opts.HealthStartPeriod)
+	}
+	if opts.HealthOnFailure == models.HealthOnFailureRestart {
+		args = append(args, "--label", HealthOnFailureLabel+"="+models.HealthOnFailureRestart)
+	}
+	return args
+}
+
+// ContainerHealth reads a container's health check state from its inspect
+// output, or nil when it has no health check
+func ContainerHealth(inspect *podmanInspect) *models.ContainerHealth {
+	state := inspect.State.Health
+	if state.Status == "" {
+		return nil
+	}
+	health := &models.ContainerHealth{
+		Status:        state.Status,
+		FailingStreak: state.FailingStreak,
+		OnFailure:     models.HealthOnFailureNone,
+		Log:           make([]models.HealthCheckResult, 0, len(state.Log)),
+	}
+	if inspect.Config.Labels[HealthOnFailureLabel] == models.HealthOnFailureRestart {
+		health.OnFailure = models.HealthOnFailureRestart
+	}
+	for _, run := range state.Log {
+		health.Log = append(health.Log, models.HealthCheckResult{
+			Start:    run.Start,
+			End:      run.End,
+			ExitCode: run.ExitCode,
+			Output:   strings.TrimSpace(run.Output),
+		})
+	}
+	return health
+}
+
+// ContainerHealthState is a container's health as the health monitor sees it
+type ContainerHealthState struct {
+	ContainerID string
+	Name        string
+	Image       string
+	Stack       string // Compose project, from the container's labels
+	Health      string
+	OnFailure   string
+}
+
+// ListContainerHealth returns the health of every running container that has
+// a health check
+func (p *PodmanService) ListContainerHealth(ctx context.Context) ([]ContainerHealthState, error) {
+	containers, err := p.psContainers(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var result []ContainerHealthState
+	for _, c := range containers {
+		health := psHealth(c.Status)
+		if c.State != "running" || health == "" {
+			continue
+		}
+		state := ContainerHealthState{
+			ContainerID: c.ID,
+			Image:       c.Image,
+			Stack:       c.Labels["com.docker.compose.project"],
+			Health:      health,
+			OnFailure:   models.HealthOnFailureNone,
+		}
+		if len(c.Names) > 0 {
+			state.Name = c.Names[0]
+		}
+		if c.Labels[HealthOnFailureLabel] == models.HealthOnFailureRestart {
+			state.OnFailure = models.HealthOnFailureRestart
+		}
+		result = append(result, state)
+	}
+	return result, nil
+}
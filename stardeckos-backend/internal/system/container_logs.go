@@ -0,0 +1,134 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// LogDrivers are the podman log drivers Stardeck offers
+var LogDrivers = []string{"k8s-file", "journald", "none", "passthrough"}
+
+// logSizePattern matches sizes like 512k, 10m, 1.5GB or 10.49MB (podman's inspect format)
+var logSizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([kmg]?)(?:i?b)?$`)
+
+// ParseLogSize converts a log size such as "10m" to bytes. Units are binary,
+// as they are for podman's max-size option.
+func ParseLogSize(size string) (int64, error) {
+	m := logSizePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(size)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size '%s', use a number with an optional k, m or g suffix", size)
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s'", size)
+	}
+	switch m[2] {
+	case "k":
+		value *= 1 << 10
+	case "m":
+		value *= 1 << 20
+	case "g":
+		value *= 1 << 30
+	}
+	return int64(value), nil
+}
+
+// ValidateLogOptions checks a container's log driver and rotation options
+func ValidateLogOptions(opts *models.ContainerLogOptions) error {
+	opts.LogDriver = strings.TrimSpace(opts.LogDriver)
+	opts.LogMaxSize = strings.TrimSpace(opts.LogMaxSize)
+	if opts.LogDriver != "" {
+		known := false
+		for _, driver := range LogDrivers {
+			if opts.LogDriver == driver {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("log driver must be one of %s", strings.Join(LogDrivers, ", "))
+		}
+	}
+	if opts.LogMaxSize != "" {
+		if opts.LogDriver != "" && opts.LogDriver != "k8s-file" {
+			return fmt.Errorf("a log size cap needs the k8s-file driver; %s manages its own storage", opts.LogDriver)
+		}
+		size, err := ParseLogSize(opts.LogMaxSize)
+		if err != nil {
+			return err
+		}
+		if size < 64<<10 {
+			return fmt.Errorf("log size cap must be at least 64k")
+		}
+	}
+	if opts.LogMaxFiles < 0 || opts.LogMaxFiles > 100 {
+		return fmt.Errorf("log file count must be between 0 and 100")
+	}
+	if opts.LogMaxFiles > 0 && opts.LogMaxSize == "" {
+		return fmt.Errorf("log file count needs a log size cap")
+	}
+	return nil
+}
+
+// containerLogArgs maps log options to podman create flags
+func containerLogArgs(opts *models.ContainerLogOptions) []string {
+	var args []string
+	if opts.LogDriver != "" {
+		args = append(args, "--log-driver", opts.LogDriver)
+	}
+	if opts.LogMaxSize != "" {
+		args = append(args, "--log-opt", "max-size="+opts.LogMaxSize)
+	}
+	if opts.LogMaxFiles > 0 {
+		args = append(args, "--log-opt", fmt.Sprintf("max-file=%d", opts.LogMaxFiles))
+	}
+	return args
+}
+
+// ContainerLogUsage reports the log driver, cap and current log file size of
+// each container, inspecting them in a single podman call
+func (p *PodmanService) ContainerLogUsage(ctx context.Context, containerIDs []string) ([]models.ContainerLogUsage, error) {
+	if len(containerIDs) == 0 {
+		return []models.ContainerLogUsage{}, nil
+	}
+	args := append([]string{"inspect", "--format", "json"}, containerIDs...)
+	output, err := p.podmanCmd(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var containers []podmanInspect
+	if err := json.Unmarshal(output, &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container inspect: %w", err)
+	}
+
+	usage := make([]models.ContainerLogUsage, 0, len(containers))
+	for _, inspect := range containers {
+		logConfig := inspect.HostConfig.LogConfig
+		u := models.ContainerLogUsage{
+			ContainerID: inspect.ID,
+			Name:        strings.TrimPrefix(inspect.Name, "/"),
+			Driver:      logConfig.Type,
+			MaxSize:     logConfig.Size,
+		}
+		if u.MaxSize != "" {
+			u.MaxSizeBytes, _ = ParseLogSize(u.MaxSize)
+		}
+		u.MaxFiles, _ = strconv.Atoi(logConfig.Config["max-file"])
+		if logConfig.Type == "k8s-file" || logConfig.Type == "json-file" {
+			u.Path = logConfig.Path
+			if info, err := os.Stat(logConfig.Path); err == nil {
+				u.SizeBytes = info.Size()
+			}
+			u.Uncapped = u.MaxSizeBytes == 0
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
@@ -0,0 +1,58 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// DataPoolOverrideFile is the compose override holding a stack's data pool
+// mounts. It sits next to docker-compose.yml and is passed to podman-compose
+// whenever it exists.
+const DataPoolOverrideFile = "docker-compose.pools.yml"
+
+// DataPoolOverrideService is a service's share of the pool override file
+type DataPoolOverrideService struct {
+	Name        string
+	Volumes     []string // host:container bind mounts
+	Environment map[string]string
+}
+
+// RenderDataPoolOverride renders the compose override that mounts data pools into services
+func RenderDataPoolOverride(services []DataPoolOverrideService) string {
+	var w composeWriter
+	w.line(0, "# Generated by Stardeck from the shared data pools. Changes are overwritten.")
+	w.line(0, "services:")
+	for _, svc := range services {
+		w.line(1, "%s:", yamlMapKey(svc.Name))
+		w.list(2, "volumes", svc.Volumes)
+		w.mapping(2, "environment", svc.Environment)
+	}
+	return w.String()
+}
+
+// ParseOctalMode parses a mode such as 2775 or 0750. The setgid and sticky
+// bits are kept; setuid is refused since it means nothing on a directory.
+func ParseOctalMode(s string) (os.FileMode, error) {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 03777 {
+		return 0, fmt.Errorf("mode must be octal without the setuid bit, e.g. 2775")
+	}
+	mode := os.FileMode(n & 0777)
+	if n&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if n&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// ParseUmask parses an octal umask such as 002
+func ParseUmask(s string) (int, error) {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 0777 {
+		return 0, fmt.Errorf("umask must be octal, e.g. 002")
+	}
+	return int(n), nil
+}
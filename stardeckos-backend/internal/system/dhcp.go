@@ -0,0 +1,306 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"stardeckos-backend/internal/models"
+)
+
+// dhcpLeaseFile is where dnsmasq records leases by default
+const dhcpLeaseFile = "/var/lib/dnsmasq/dnsmasq.leases"
+
+// hostnameLabelPattern matches a single DNS label
+var hostnameLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// domainNamePattern matches a DNS domain name
+var domainNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// GetDHCPStatus returns whether the DHCP server is installed and running
+func GetDHCPStatus() *models.DHCPStatus {
+	status := &models.DHCPStatus{
+		ConfigPath: DnsmasqConfigPath,
+		Interfaces: []models.DHCPInterface{},
+	}
+	status.Installed, status.Running, status.Version = dnsmasqState()
+	return status
+}
+
+// interfaceNetwork returns the IPv4 network of an interface that contains ip
+func interfaceNetwork(name string, ip net.IP) (*net.IPNet, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s not found", name)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read addresses of %s: %w", name, err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil && ipNet.Contains(ip) {
+			return ipNet, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not on a network of interface %s", ip, name)
+}
+
+// parseIPv4 parses an IPv4 address, naming the field in the error
+func parseIPv4(field, value string) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(value)).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("%s must be an IPv4 address", field)
+	}
+	return ip, nil
+}
+
+// dhcpScopeNetwork returns the network a scope hands out addresses in
+func dhcpScopeNetwork(s *models.DHCPScope) *net.IPNet {
+	start := net.ParseIP(s.RangeStart).To4()
+	mask := net.ParseIP(s.Netmask).To4()
+	if start == nil || mask == nil {
+		return nil
+	}
+	m := net.IPMask(mask)
+	return &net.IPNet{IP: start.Mask(m), Mask: m}
+}
+
+// ValidateDHCPScope checks a scope against the interface it serves and fills
+// in the netmask from the interface's address when it is empty
+func ValidateDHCPScope(s *models.DHCPScope) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	// The name is written into the configuration as a comment
+	if strings.IndexFunc(s.Name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("name must not contain control characters")
+	}
+	start, err := parseIPv4("range_start", s.RangeStart)
+	if err != nil {
+		return err
+	}
+	end, err := parseIPv4("range_end", s.RangeEnd)
+	if err != nil {
+		return err
+	}
+	if binary.BigEndian.Uint32(start) > binary.BigEndian.Uint32(end) {
+		return fmt.Errorf("range_start must not be after range_end")
+	}
+
+	ifaceNet, err := interfaceNetwork(s.Interface, start)
+	if err != nil {
+		return err
+	}
+	if s.Netmask == "" {
+		s.Netmask = net.IP(ifaceNet.Mask).String()
+	}
+	mask, err := parseIPv4("netmask", s.Netmask)
+	if err != nil {
+		return err
+	}
+	if ones, bits := net.IPMask(mask).Size(); bits == 0 || ones > 30 {
+		return fmt.Errorf("netmask must be a contiguous mask of at most /30")
+	}
+	network := &net.IPNet{IP: start.Mask(net.IPMask(mask)), Mask: net.IPMask(mask)}
+	if !network.Contains(end) {
+		return fmt.Errorf("range_start and range_end must be in the same network")
+	}
+	if start.Equal(network.IP) || end.Equal(broadcastAddress(network)) {
+		return fmt.Errorf("the range must not include the network or broadcast address")
+	}
+	s.RangeStart, s.RangeEnd, s.Netmask = start.String(), end.String(), net.IP(mask).String()
+
+	if s.LeaseTime == 0 {
+		s.LeaseTime = 86400
+	}
+	if s.LeaseTime < 120 {
+		return fmt.Errorf("lease_time must be at least 120 seconds")
+	}
+	if s.Gateway != "" {
+		gateway, err := parseIPv4("gateway", s.Gateway)
+		if err != nil {
+			return err
+		}
+		if !network.Contains(gateway) {
+			return fmt.Errorf("gateway must be in the scope's network")
+		}
+		s.Gateway = gateway.String()
+	}
+	servers := make([]string, 0, len(s.DNSServers))
+	for _, server := range s.DNSServers {
+		ip, err := parseIPv4("dns_servers", server)
+		if err != nil {
+			return err
+		}
+		servers = append(servers, ip.String())
+	}
+	s.DNSServers = servers
+	if s.DomainName != "" && !domainNamePattern.MatchString(s.DomainName) {
+		return fmt.Errorf("domain_name is not a valid domain name")
+	}
+	return nil
+}
+
+// broadcastAddress returns the last address of a network
+func broadcastAddress(n *net.IPNet) net.IP {
+	ip := make(net.IP, len(n.IP))
+	for i := range n.IP {
+		ip[i] = n.IP[i] | ^n.Mask[i]
+	}
+	return ip
+}
+
+// DHCPScopeContains reports whether an address is in a scope's network
+func DHCPScopeContains(s *models.DHCPScope, ip string) bool {
+	network := dhcpScopeNetwork(s)
+	return network != nil && network.Contains(net.ParseIP(ip))
+}
+
+// DHCPRangesOverlap reports whether two scopes hand out any of the same addresses
+func DHCPRangesOverlap(a, b *models.DHCPScope) bool {
+	value := func(s string) uint32 {
+		if ip := net.ParseIP(s).To4(); ip != nil {
+			return binary.BigEndian.Uint32(ip)
+		}
+		return 0
+	}
+	return value(a.RangeStart) <= value(b.RangeEnd) && value(b.RangeStart) <= value(a.RangeEnd)
+}
+
+// ValidateDHCPReservation checks a reservation against its scope, normalizing the MAC address
+func ValidateDHCPReservation(res *models.DHCPReservation, scope *models.DHCPScope) error {
+	mac, err := net.ParseMAC(strings.TrimSpace(res.MAC))
+	if err != nil || len(mac) != 6 {
+		return fmt.Errorf("mac must be an Ethernet MAC address such as 52:54:00:12:34:56")
+	}
+	res.MAC = mac.String()
+	ip, err := parseIPv4("ip_address", res.IPAddress)
+	if err != nil {
+		return err
+	}
+	network := dhcpScopeNetwork(scope)
+	if network == nil || !network.Contains(ip) {
+		return fmt.Errorf("ip_address must be in the scope's network")
+	}
+	if ip.Equal(network.IP) || ip.Equal(broadcastAddress(network)) {
+		return fmt.Errorf("ip_address must not be the network or broadcast address")
+	}
+	res.IPAddress = ip.String()
+	if res.Hostname != "" && !hostnameLabelPattern.MatchString(res.Hostname) {
+		return fmt.Errorf("hostname must be a single DNS label")
+	}
+	return nil
+}
+
+// hostResolvers returns the host's non-loopback nameservers, which clients
+// can reach when a scope names no DNS servers and the local resolver is off
+func hostResolvers() []string {
+	config, err := GetDNSConfig()
+	if err != nil {
+		return nil
+	}
+	var servers []string
+	for _, server := range config.Nameservers {
+		if ip := net.ParseIP(server).To4(); ip != nil && !ip.IsLoopback() {
+			servers = append(servers, ip.String())
+		}
+	}
+	return servers
+}
+
+// writeDHCPConfig adds the scopes on the enabled DHCP interfaces and their
+// reservations to a dnsmasq configuration
+func writeDHCPConfig(b *strings.Builder, cfg *DnsmasqConfig) {
+	enabled := make(map[string]bool, len(cfg.DHCPInterfaces))
+	for _, name := range cfg.DHCPInterfaces {
+		enabled[name] = true
+	}
+	served := make(map[int64]bool)
+
+	// With the resolver running, dnsmasq advertises itself as the DNS server
+	var resolvers []string
+	if !cfg.DNS.Enabled {
+		resolvers = hostResolvers()
+	}
+	for _, s := range cfg.Scopes {
+		if !enabled[s.Interface] {
+			continue
+		}
+		served[s.ID] = true
+		tag := fmt.Sprintf("scope%d", s.ID)
+		fmt.Fprintf(b, "\n# DHCP scope %s\n", s.Name)
+		fmt.Fprintf(b, "dhcp-range=set:%s,%s,%s,%s,%d\n", tag, s.RangeStart, s.RangeEnd, s.Netmask, s.LeaseTime)
+		if s.Gateway != "" {
+			fmt.Fprintf(b, "dhcp-option=tag:%s,option:router,%s\n", tag, s.Gateway)
+		}
+		servers := s.DNSServers
+		if len(servers) == 0 {
+			servers = resolvers
+		}
+		if len(servers) > 0 {
+			fmt.Fprintf(b, "dhcp-option=tag:%s,option:dns-server,%s\n", tag, strings.Join(servers, ","))
+		}
+		if s.DomainName != "" {
+			fmt.Fprintf(b, "dhcp-option=tag:%s,option:domain-name,%s\n", tag, s.DomainName)
+		}
+	}
+
+	first := true
+	for _, res := range cfg.Reservations {
+		if !served[res.ScopeID] {
+			continue
+		}
+		if first {
+			b.WriteString("\n# DHCP reservations\n")
+			first = false
+		}
+		line := res.MAC + "," + res.IPAddress
+		if res.Hostname != "" {
+			line += "," + res.Hostname
+		}
+		fmt.Fprintf(b, "dhcp-host=%s\n", line)
+	}
+}
+
+// GetDHCPLeases reads the leases dnsmasq has handed out
+func GetDHCPLeases() ([]models.DHCPLease, error) {
+	leases := []models.DHCPLease{}
+	data, err := os.ReadFile(dhcpLeaseFile)
+	if os.IsNotExist(err) {
+		return leases, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leases: %w", err)
+	}
+
+	// Each line is: expiry mac ip hostname client-id
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] == "duid" || net.ParseIP(fields[2]).To4() == nil {
+			continue
+		}
+		lease := models.DHCPLease{MAC: fields[1], IPAddress: fields[2]}
+		if fields[3] != "*" {
+			lease.Hostname = fields[3]
+		}
+		if len(fields) > 4 && fields[4] != "*" {
+			lease.ClientID = fields[4]
+		}
+		if expiry, err := strconv.ParseInt(fields[0], 10, 64); err == nil && expiry > 0 {
+			t := time.Unix(expiry, 0)
+			lease.Expires = &t
+		}
+		leases = append(leases, lease)
+	}
+	return leases, scanner.Err()
+}
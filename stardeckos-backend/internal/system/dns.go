@@ -0,0 +1,312 @@
+package system
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	// dnsBlocklistDir holds downloaded ad-block lists in hosts format
+	dnsBlocklistDir = "/var/lib/stardeck/dns/blocklists"
+	// dnsBlocklistMaxBytes bounds the size of a downloaded list
+	dnsBlocklistMaxBytes = 64 << 20
+)
+
+// blocklistSkipped are hosts-file entries that must never be blocked
+var blocklistSkipped = map[string]bool{
+	"localhost": true, "localhost.localdomain": true, "local": true, "broadcasthost": true,
+	"ip6-localhost": true, "ip6-loopback": true, "ip6-localnet": true, "ip6-mcastprefix": true,
+	"ip6-allnodes": true, "ip6-allrouters": true, "ip6-allhosts": true, "0.0.0.0": true,
+}
+
+// GetDNSStatus returns whether the local resolver is installed and running
+func GetDNSStatus() *models.DNSStatus {
+	status := &models.DNSStatus{
+		ConfigPath:  DnsmasqConfigPath,
+		Blocklists:  []models.DNSBlocklist{},
+		AutoRecords: []models.DNSRecord{},
+	}
+	status.Installed, status.Running, status.Version = dnsmasqState()
+	return status
+}
+
+// ValidateDNSSettings checks resolver settings, normalizing them in place
+func ValidateDNSSettings(s *models.DNSSettings) error {
+	for _, name := range s.Interfaces {
+		if _, err := net.InterfaceByName(name); err != nil {
+			return fmt.Errorf("interface %s not found", name)
+		}
+	}
+	for i, upstream := range s.Upstreams {
+		upstream = strings.TrimSpace(upstream)
+		host, port, hasPort := strings.Cut(upstream, "#")
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return fmt.Errorf("upstream %s must be an IP address, optionally followed by #port", upstream)
+		}
+		if hasPort {
+			if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+				return fmt.Errorf("upstream %s has an invalid port", upstream)
+			}
+		}
+		s.Upstreams[i] = upstream
+	}
+	s.Domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s.Domain), "."))
+	if s.Domain != "" && !domainNamePattern.MatchString(s.Domain) {
+		return fmt.Errorf("domain is not a valid domain name")
+	}
+	if s.HostAddress != "" && net.ParseIP(s.HostAddress) == nil {
+		return fmt.Errorf("host_address must be an IP address")
+	}
+	if s.CacheSize < 0 || s.CacheSize > 100000 {
+		return fmt.Errorf("cache_size must be between 0 and 100000")
+	}
+	for _, list := range s.Blocklists {
+		u, err := url.Parse(list)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("blocklist %s must be an http or https URL", list)
+		}
+		if strings.ContainsAny(list, " \t\r\n") {
+			return fmt.Errorf("blocklist %s must not contain whitespace", list)
+		}
+	}
+	return nil
+}
+
+// ValidateIngressSettings checks the ingress settings, normalizing the domain
+func ValidateIngressSettings(s *models.IngressSettings) error {
+	s.Domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s.Domain), "."))
+	if s.Domain == "" || !domainNamePattern.MatchString(s.Domain) {
+		return fmt.Errorf("domain must be a valid domain name, e.g. stardeck.lan")
+	}
+	return nil
+}
+
+// ValidateDNSRecord checks a record, normalizing its name and value
+func ValidateDNSRecord(rec *models.DNSRecord) error {
+	rec.Name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(rec.Name), "."))
+	if !domainNamePattern.MatchString(rec.Name) {
+		return fmt.Errorf("name is not a valid host name")
+	}
+	rec.Value = strings.TrimSpace(rec.Value)
+	switch rec.Type {
+	case models.DNSRecordA:
+		ip := net.ParseIP(rec.Value).To4()
+		if ip == nil {
+			return fmt.Errorf("an A record needs an IPv4 address")
+		}
+		rec.Value = ip.String()
+	case models.DNSRecordAAAA:
+		ip := net.ParseIP(rec.Value)
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("an AAAA record needs an IPv6 address")
+		}
+		rec.Value = ip.String()
+	case models.DNSRecordCNAME:
+		rec.Value = strings.ToLower(strings.TrimSuffix(rec.Value, "."))
+		if !domainNamePattern.MatchString(rec.Value) {
+			return fmt.Errorf("a CNAME record needs a target host name")
+		}
+		if rec.Value == rec.Name {
+			return fmt.Errorf("a CNAME record can't point at itself")
+		}
+	default:
+		return fmt.Errorf("type must be A, AAAA or CNAME")
+	}
+	if strings.ContainsAny(rec.Comment, "\r\n") {
+		return fmt.Errorf("comment must be a single line")
+	}
+	return nil
+}
+
+// ContainerRecordName turns a container name into a DNS label
+func ContainerRecordName(name string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, name)
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return strings.Trim(label, "-")
+}
+
+// DefaultHostAddress picks the address container records point at: the first
+// IPv4 address of the given interfaces, or else the one the host routes from
+func DefaultHostAddress(interfaces []string) string {
+	for _, name := range interfaces {
+		iface, err := net.InterfaceByName(name)
+		if err != nil || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				return ipNet.IP.String()
+			}
+		}
+	}
+	// Dialing UDP sends nothing; it only selects the source address
+	conn, err := net.Dial("udp", "192.0.2.1:53")
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String()
+}
+
+// qualifiedNames returns a record name, plus its name in the local domain when it is unqualified
+func qualifiedNames(name, domain string) string {
+	if domain != "" && !strings.Contains(name, ".") {
+		return name + "," + name + "." + domain
+	}
+	return name
+}
+
+// writeDNSConfig adds the resolver settings, records and blocklists to a
+// dnsmasq configuration
+func writeDNSConfig(b *strings.Builder, cfg *DnsmasqConfig) {
+	s := cfg.DNS
+	b.WriteString("\n# Local resolver\ndomain-needed\nbogus-priv\n")
+	fmt.Fprintf(b, "cache-size=%d\n", s.CacheSize)
+	if len(s.Upstreams) > 0 {
+		b.WriteString("no-resolv\n")
+		for _, upstream := range s.Upstreams {
+			fmt.Fprintf(b, "server=%s\n", upstream)
+		}
+	}
+	if s.Domain != "" {
+		fmt.Fprintf(b, "domain=%s\nlocal=/%s/\nexpand-hosts\n", s.Domain, s.Domain)
+	}
+
+	if len(cfg.Records) > 0 {
+		b.WriteString("\n# Local records\n")
+	}
+	for _, rec := range cfg.Records {
+		names := qualifiedNames(rec.Name, s.Domain)
+		if rec.Type == models.DNSRecordCNAME {
+			fmt.Fprintf(b, "cname=%s,%s\n", names, rec.Value)
+		} else {
+			fmt.Fprintf(b, "host-record=%s,%s\n", names, rec.Value)
+		}
+	}
+
+	for _, list := range s.Blocklists {
+		path := DNSBlocklistPath(list)
+		if _, err := os.Stat(path); err == nil {
+			fmt.Fprintf(b, "addn-hosts=%s\n", path)
+		}
+	}
+}
+
+// DNSBlocklistPath is where a blocklist is stored once downloaded
+func DNSBlocklistPath(listURL string) string {
+	sum := sha256.Sum256([]byte(listURL))
+	return filepath.Join(dnsBlocklistDir, hex.EncodeToString(sum[:8])+".hosts")
+}
+
+// GetDNSBlocklist returns the state of a downloaded blocklist
+func GetDNSBlocklist(listURL string) models.DNSBlocklist {
+	list := models.DNSBlocklist{URL: listURL}
+	f, err := os.Open(DNSBlocklistPath(listURL))
+	if err != nil {
+		return list
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil {
+		t := info.ModTime()
+		list.UpdatedAt = &t
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		list.Domains++
+	}
+	return list
+}
+
+// RefreshDNSBlocklist downloads a hosts-format or plain domain list and stores
+// it as a hosts file that answers 0.0.0.0 for each domain
+func RefreshDNSBlocklist(listURL string) (int, error) {
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Get(listURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", listURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %s", listURL, resp.Status)
+	}
+
+	if err := os.MkdirAll(dnsBlocklistDir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create blocklist directory: %w", err)
+	}
+	path := DNSBlocklistPath(listURL)
+	tmp, err := os.CreateTemp(dnsBlocklistDir, ".download-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, dnsBlocklistMaxBytes))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			fields = fields[1:]
+		}
+		for _, domain := range fields {
+			domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+			if seen[domain] || blocklistSkipped[domain] || !strings.Contains(domain, ".") || !domainNamePattern.MatchString(domain) {
+				continue
+			}
+			seen[domain] = true
+			fmt.Fprintf(w, "0.0.0.0 %s\n", domain)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to read %s: %w", listURL, err)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	tmp.Close()
+	if len(seen) == 0 {
+		return 0, fmt.Errorf("%s contains no domains", listURL)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to write blocklist: %w", err)
+	}
+	return len(seen), nil
+}
+
+// RemoveDNSBlocklist deletes a downloaded blocklist
+func RemoveDNSBlocklist(listURL string) {
+	os.Remove(DNSBlocklistPath(listURL))
+}
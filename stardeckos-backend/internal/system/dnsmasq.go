@@ -0,0 +1,157 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// DnsmasqConfigPath is the dnsmasq configuration Stardeck manages. One dnsmasq
+// instance serves both DHCP and the local resolver.
+const DnsmasqConfigPath = "/etc/dnsmasq.d/stardeck.conf"
+
+// DnsmasqConfig is everything Stardeck configures in dnsmasq
+type DnsmasqConfig struct {
+	DHCPInterfaces []string
+	Scopes         []models.DHCPScope
+	Reservations   []models.DHCPReservation
+	DNS            models.DNSSettings
+	Records        []models.DNSRecord // Including records generated for containers
+}
+
+// DnsmasqInstalled reports whether dnsmasq is available
+func DnsmasqInstalled() bool {
+	_, err := exec.LookPath("dnsmasq")
+	return err == nil
+}
+
+// dnsmasqState returns whether dnsmasq is installed and running, and its version
+func dnsmasqState() (installed, running bool, version string) {
+	if !DnsmasqInstalled() {
+		return false, false, ""
+	}
+	if output, err := exec.Command("dnsmasq", "--version").Output(); err == nil {
+		version, _, _ = strings.Cut(strings.TrimSpace(string(output)), "\n")
+	}
+	running = exec.Command("systemctl", "is-active", "--quiet", "dnsmasq").Run() == nil
+	return true, running, version
+}
+
+// dnsmasqInterfaces returns the interfaces dnsmasq listens on. The resolver
+// always answers on loopback so the host itself can use it.
+func dnsmasqInterfaces(cfg *DnsmasqConfig) []string {
+	var interfaces []string
+	seen := make(map[string]bool)
+	add := func(names ...string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				interfaces = append(interfaces, name)
+			}
+		}
+	}
+	if cfg.DNS.Enabled {
+		add("lo")
+		add(cfg.DNS.Interfaces...)
+	}
+	add(cfg.DHCPInterfaces...)
+	return interfaces
+}
+
+// RenderDnsmasqConfig builds the dnsmasq configuration
+func RenderDnsmasqConfig(cfg *DnsmasqConfig) string {
+	var b strings.Builder
+	b.WriteString("# Managed by Stardeck; changes are overwritten\n")
+	if cfg.DNS.Enabled {
+		// Binding each address leaves the host's stub resolver on 127.0.0.53 alone
+		b.WriteString("bind-dynamic\n")
+	} else {
+		b.WriteString("# DNS is left to the host's resolver\nport=0\n")
+	}
+	for _, name := range dnsmasqInterfaces(cfg) {
+		fmt.Fprintf(&b, "interface=%s\n", name)
+	}
+	if cfg.DNS.Enabled {
+		writeDNSConfig(&b, cfg)
+	}
+	writeDHCPConfig(&b, cfg)
+	return b.String()
+}
+
+// ApplyDnsmasqConfig writes the dnsmasq configuration and restarts the
+// server, or stops it when neither DHCP nor DNS is in use. The configuration
+// is checked by dnsmasq before it replaces the running one.
+func ApplyDnsmasqConfig(cfg *DnsmasqConfig) error {
+	if !DnsmasqInstalled() {
+		return fmt.Errorf("dnsmasq is not installed")
+	}
+	config := RenderDnsmasqConfig(cfg)
+
+	tmp, err := os.CreateTemp("", "stardeck-dnsmasq-*.conf")
+	if err != nil {
+		return fmt.Errorf("failed to write dnsmasq configuration: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(config); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write dnsmasq configuration: %w", err)
+	}
+	tmp.Close()
+	if output, err := exec.Command("dnsmasq", "--test", "--conf-file="+tmp.Name()).CombinedOutput(); err != nil {
+		return fmt.Errorf("dnsmasq rejected the configuration: %s", strings.TrimSpace(string(output)))
+	}
+
+	if err := os.MkdirAll("/etc/dnsmasq.d", 0755); err != nil {
+		return fmt.Errorf("failed to create /etc/dnsmasq.d: %w", err)
+	}
+	if err := os.WriteFile(DnsmasqConfigPath, []byte(config), 0644); err != nil {
+		return fmt.Errorf("failed to write dnsmasq configuration: %w", err)
+	}
+
+	if len(cfg.DHCPInterfaces) == 0 && !cfg.DNS.Enabled {
+		// Without interface lines dnsmasq would answer on every interface
+		if output, err := exec.Command("systemctl", "disable", "--now", "dnsmasq").CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to stop dnsmasq: %s", strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+
+	for _, name := range cfg.DHCPInterfaces {
+		openFirewallService(name, "dhcp")
+	}
+	if cfg.DNS.Enabled {
+		for _, name := range dnsmasqInterfaces(cfg) {
+			if name != "lo" {
+				openFirewallService(name, "dns")
+			}
+		}
+	}
+	if output, err := exec.Command("systemctl", "enable", "dnsmasq").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to enable dnsmasq: %s", strings.TrimSpace(string(output)))
+	}
+	if output, err := exec.Command("systemctl", "restart", "dnsmasq").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart dnsmasq: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// openFirewallService allows a firewalld service in the zone of an interface.
+// Hosts without firewalld are left alone.
+func openFirewallService(iface, service string) {
+	if exec.Command("firewall-cmd", "--state").Run() != nil {
+		return
+	}
+	output, err := exec.Command("firewall-cmd", "--get-zone-of-interface="+iface).Output()
+	if err != nil {
+		output, err = exec.Command("firewall-cmd", "--get-default-zone").Output()
+		if err != nil {
+			return
+		}
+	}
+	zone := strings.TrimSpace(string(output))
+	AddFirewallService(zone, service, false)
+	AddFirewallService(zone, service, true)
+}
@@ -0,0 +1,148 @@
+package system
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// egressTable is the nftables table holding container egress rules
+const egressTable = "stardeck_egress"
+
+// Destinations that stay reachable when a policy blocks the internet
+var (
+	egressLocal4 = []string{"10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12", "192.168.0.0/16"}
+	egressLocal6 = []string{"::1/128", "fc00::/7", "fe80::/10"}
+)
+
+var egressChainUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// EgressTarget is a container whose forwarded traffic a policy filters
+type EgressTarget struct {
+	Key       string   // Stable identifier used to name the container's chain
+	Addresses []string // The container's addresses on its networks
+	Policy    models.EgressPolicySpec
+}
+
+// ValidateEgressPolicy checks a policy and normalizes single addresses to
+// host networks, so rules render as CIDRs
+func ValidateEgressPolicy(spec *models.EgressPolicySpec) error {
+	switch spec.DefaultAction {
+	case "":
+		spec.DefaultAction = models.EgressAllow
+	case models.EgressAllow, models.EgressDeny:
+	default:
+		return fmt.Errorf("default_action must be allow or deny")
+	}
+	if spec.Rules == nil {
+		spec.Rules = []models.EgressRule{}
+	}
+	if len(spec.Rules) > 256 {
+		return fmt.Errorf("a policy can have at most 256 rules")
+	}
+	for i := range spec.Rules {
+		rule := &spec.Rules[i]
+		if rule.Action != models.EgressAllow && rule.Action != models.EgressDeny {
+			return fmt.Errorf("rule %d: action must be allow or deny", i+1)
+		}
+		cidr := strings.TrimSpace(rule.CIDR)
+		if ip := net.ParseIP(cidr); ip != nil {
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("rule %d: invalid CIDR %q", i+1, rule.CIDR)
+		}
+		rule.CIDR = network.String()
+		rule.Comment = strings.TrimSpace(rule.Comment)
+	}
+	return nil
+}
+
+// RenderEgressRuleset builds the nftables script that replaces the egress
+// table. Each container's forwarded traffic jumps to its own chain, which
+// mirrors the policy: replies, rules in order, the internet block, then the
+// default action.
+func RenderEgressRuleset(targets []EgressTarget) string {
+	var b strings.Builder
+	// Declaring the table first lets the delete succeed on the first run
+	fmt.Fprintf(&b, "table inet %s\ndelete table inet %s\n", egressTable, egressTable)
+	fmt.Fprintf(&b, "table inet %s {\n", egressTable)
+	fmt.Fprintf(&b, "\tset local4 {\n\t\ttype ipv4_addr\n\t\tflags interval\n\t\telements = { %s }\n\t}\n", strings.Join(egressLocal4, ", "))
+	fmt.Fprintf(&b, "\tset local6 {\n\t\ttype ipv6_addr\n\t\tflags interval\n\t\telements = { %s }\n\t}\n", strings.Join(egressLocal6, ", "))
+
+	b.WriteString("\tchain forward {\n\t\ttype filter hook forward priority -10; policy accept;\n")
+	for _, t := range targets {
+		chain := egressChainName(t.Key)
+		for _, addr := range t.Addresses {
+			ip := net.ParseIP(addr)
+			switch {
+			case ip == nil:
+				continue
+			case ip.To4() != nil:
+				fmt.Fprintf(&b, "\t\tip saddr %s jump %s\n", ip, chain)
+			default:
+				fmt.Fprintf(&b, "\t\tip6 saddr %s jump %s\n", ip, chain)
+			}
+		}
+	}
+	b.WriteString("\t}\n")
+
+	for _, t := range targets {
+		fmt.Fprintf(&b, "\tchain %s {\n", egressChainName(t.Key))
+		b.WriteString("\t\tct state established,related accept\n")
+		for _, rule := range t.Policy.Rules {
+			verdict := "accept"
+			if rule.Action == models.EgressDeny {
+				verdict = "drop"
+			}
+			family := "ip"
+			if _, network, err := net.ParseCIDR(rule.CIDR); err != nil {
+				continue
+			} else if network.IP.To4() == nil {
+				family = "ip6"
+			}
+			fmt.Fprintf(&b, "\t\t%s daddr %s %s\n", family, rule.CIDR, verdict)
+		}
+		if t.Policy.BlockInternet {
+			b.WriteString("\t\tip daddr != @local4 drop\n\t\tip6 daddr != @local6 drop\n")
+		}
+		if t.Policy.DefaultAction == models.EgressDeny {
+			b.WriteString("\t\tdrop\n")
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ApplyEgressRules atomically replaces the egress table. With no targets the
+// table is removed.
+func ApplyEgressRules(targets []EgressTarget) error {
+	if _, err := exec.LookPath("nft"); err != nil {
+		return fmt.Errorf("nftables is not installed")
+	}
+	script := RenderEgressRuleset(targets)
+	if len(targets) == 0 {
+		script = fmt.Sprintf("table inet %s\ndelete table inet %s\n", egressTable, egressTable)
+	}
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("nft rejected the egress rules: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// egressChainName derives a valid nftables chain name from a container key
+func egressChainName(key string) string {
+	return "ctr_" + egressChainUnsafe.ReplaceAllString(key, "_")
+}
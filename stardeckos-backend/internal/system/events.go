@@ -0,0 +1,157 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// ContainerEvent is a container lifecycle event from podman events
+type ContainerEvent struct {
+	ID       string
+	Name     string
+	Image    string
+	Status   string // died, stop, kill, start, ...
+	ExitCode *int   // Set on died events
+	Stack    string // Compose project, from the container's labels
+	Time     time.Time
+}
+
+// podmanEvent is one line of podman events --format json
+type podmanEvent struct {
+	ID                string `json:"ID"`
+	Name              string `json:"Name"`
+	Image             string `json:"Image"`
+	Status            string `json:"Status"`
+	Type              string `json:"Type"`
+	TimeNano          int64  `json:"timeNano"`
+	ContainerExitCode *int   `json:"ContainerExitCode"`
+
+	Attributes map[string]string `json:"Attributes"` // Container labels
+}
+
+// podmanAPIEvent is one message of the API's event stream, in Docker's format
+type podmanAPIEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	From     string `json:"from"`
+	TimeNano int64  `json:"timeNano"`
+}
+
+// StreamContainerEvents follows podman events for the given container event
+// types and sends them on events until the context is cancelled or podman exits
+func (p *PodmanService) StreamContainerEvents(ctx context.Context, events chan<- ContainerEvent, statuses ...string) error {
+	err := p.streamAPIEvents(ctx, events, statuses)
+	if !errors.Is(err, errPodmanAPIUnavailable) {
+		return err
+	}
+
+	args := []string{"events", "--format", "json", "--filter", "type=container"}
+	for _, status := range statuses {
+		args = append(args, "--filter", "event="+status)
+	}
+
+	// Build command with rootless support
+	var cmd *exec.Cmd
+	if os.Getuid() == 0 && p.targetUser != "" {
+		cmd = exec.CommandContext(ctx, "sudo", append([]string{"-u", p.targetUser, "podman"}, args...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "podman", args...)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var e podmanEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Type != "container" {
+			continue
+		}
+		event := ContainerEvent{
+			ID:       e.ID,
+			Name:     e.Name,
+			Image:    e.Image,
+			Status:   e.Status,
+			ExitCode: e.ContainerExitCode,
+			Stack:    e.Attributes["com.docker.compose.project"],
+		}
+		sendContainerEvent(ctx, events, event, e.TimeNano)
+	}
+
+	cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// streamAPIEvents follows the API socket's event stream; it returns
+// errPodmanAPIUnavailable if the socket can't be reached
+func (p *PodmanService) streamAPIEvents(ctx context.Context, events chan<- ContainerEvent, statuses []string) error {
+	filters := map[string][]string{"type": {"container"}}
+	if len(statuses) > 0 {
+		filters["event"] = statuses
+	}
+	query := url.Values{"stream": {"true"}, "filters": {podmanFilters(filters)}}
+	resp, err := p.api().do(ctx, http.MethodGet, "/events", query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var e podmanAPIEvent
+		if err := decoder.Decode(&e); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if e.Type != "container" {
+			continue
+		}
+		event := ContainerEvent{
+			ID:     e.Actor.ID,
+			Name:   e.Actor.Attributes["name"],
+			Image:  e.Actor.Attributes["image"],
+			Status: e.Action,
+			Stack:  e.Actor.Attributes["com.docker.compose.project"],
+		}
+		if event.Image == "" {
+			event.Image = e.From
+		}
+		if code, err := strconv.Atoi(e.Actor.Attributes["containerExitCode"]); err == nil {
+			event.ExitCode = &code
+		}
+		sendContainerEvent(ctx, events, event, e.TimeNano)
+	}
+}
+
+func sendContainerEvent(ctx context.Context, events chan<- ContainerEvent, event ContainerEvent, timeNano int64) {
+	event.Time = time.Now()
+	if timeNano > 0 {
+		event.Time = time.Unix(0, timeNano)
+	}
+	select {
+	case events <- event:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,192 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	fail2banJailConfig   = "/etc/fail2ban/jail.d/stardeck.local"
+	fail2banFilterConfig = "/etc/fail2ban/filter.d/stardeck.conf"
+
+	// AuthFailureLog is where the backend records failed sign-ins for the stardeck jail
+	AuthFailureLog = "/var/log/stardeck/auth.log"
+
+	// Fail2banStardeckJail is the jail fed by AuthFailureLog
+	Fail2banStardeckJail = "stardeck"
+)
+
+// The IP comes before the user-supplied username so a crafted username cannot
+// place another address where the filter looks for the host
+const fail2banFilter = `# Managed by Stardeck; changes are overwritten
+[Definition]
+failregex = authentication failure ip=<HOST> user=
+ignoreregex =
+`
+
+var authFailureLogMu sync.Mutex
+
+// Fail2banInstalled reports whether fail2ban-client is available
+func Fail2banInstalled() bool {
+	_, err := exec.LookPath("fail2ban-client")
+	return err == nil
+}
+
+// GetFail2banStatus returns whether fail2ban is running and the state of each jail
+func GetFail2banStatus() (*models.Fail2banStatus, error) {
+	status := &models.Fail2banStatus{
+		Installed:          Fail2banInstalled(),
+		Jails:              []models.Fail2banJail{},
+		AuthFailureLogPath: AuthFailureLog,
+	}
+	if !status.Installed {
+		return status, nil
+	}
+
+	if output, err := exec.Command("fail2ban-client", "--version").Output(); err == nil {
+		status.Version = strings.TrimSpace(string(output))
+	}
+
+	output, err := exec.Command("fail2ban-client", "status").Output()
+	if err != nil {
+		// The client fails when the server is not running
+		return status, nil
+	}
+	status.Running = true
+
+	jails := fail2banStatusFields(string(output))["Jail list"]
+	for _, name := range strings.Split(jails, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		jail, err := GetFail2banJail(name)
+		if err != nil {
+			return nil, err
+		}
+		status.CurrentlyBanned += jail.CurrentlyBanned
+		status.Jails = append(status.Jails, *jail)
+	}
+
+	return status, nil
+}
+
+// GetFail2banJail returns the failure and ban counters of a jail
+func GetFail2banJail(name string) (*models.Fail2banJail, error) {
+	output, err := exec.Command("fail2ban-client", "status", name).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jail %s: %s", name, strings.TrimSpace(string(output)))
+	}
+
+	fields := fail2banStatusFields(string(output))
+	jail := &models.Fail2banJail{
+		Name:      name,
+		BannedIPs: strings.Fields(fields["Banned IP list"]),
+	}
+	jail.CurrentlyFailed, _ = strconv.Atoi(fields["Currently failed"])
+	jail.TotalFailed, _ = strconv.Atoi(fields["Total failed"])
+	jail.CurrentlyBanned, _ = strconv.Atoi(fields["Currently banned"])
+	jail.TotalBanned, _ = strconv.Atoi(fields["Total banned"])
+	return jail, nil
+}
+
+// fail2banStatusFields parses the tree-drawn "key:\tvalue" lines of fail2ban-client status
+func fail2banStatusFields(output string) map[string]string {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimLeft(key, "|`- ")
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+// ConfigureFail2ban writes the Stardeck jail and filter configuration, then
+// starts fail2ban or reloads it so the jails take effect
+func ConfigureFail2ban(s models.Fail2banSettings) error {
+	if err := os.WriteFile(fail2banFilterConfig, []byte(fail2banFilter), 0644); err != nil {
+		return fmt.Errorf("failed to write fail2ban filter: %w", err)
+	}
+	if err := os.WriteFile(fail2banJailConfig, []byte(fail2banJails(s)), 0644); err != nil {
+		return fmt.Errorf("failed to write fail2ban jails: %w", err)
+	}
+
+	// fail2ban refuses to start a jail whose log file is missing
+	if err := os.MkdirAll(filepath.Dir(AuthFailureLog), 0750); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := os.OpenFile(AuthFailureLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to create auth failure log: %w", err)
+	}
+	f.Close()
+
+	if exec.Command("systemctl", "is-active", "--quiet", "fail2ban").Run() == nil {
+		if output, err := exec.Command("fail2ban-client", "reload").CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to reload fail2ban: %s", strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	if output, err := exec.Command("systemctl", "enable", "--now", "fail2ban").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to start fail2ban: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// fail2banJails renders jail.d/stardeck.local for the settings
+func fail2banJails(s models.Fail2banSettings) string {
+	common := fmt.Sprintf("maxretry = %d\nfindtime = %d\nbantime = %d\n", s.MaxRetry, s.FindTime, s.BanTime)
+	if len(s.IgnoreIP) > 0 {
+		common += "ignoreip = 127.0.0.1/8 ::1 " + strings.Join(s.IgnoreIP, " ") + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("# Managed by Stardeck; changes are overwritten\n\n")
+	fmt.Fprintf(&b, "[sshd]\nenabled = %t\n%s\n", s.SSHDEnabled, common)
+	// A banned client is blocked from every port; the web UI and proxied apps share no single port
+	fmt.Fprintf(&b, "[%s]\nenabled = %t\nfilter = stardeck\nlogpath = %s\nbackend = auto\nbanaction = %%(banaction_allports)s\n%s",
+		Fail2banStardeckJail, s.StardeckEnabled, AuthFailureLog, common)
+	return b.String()
+}
+
+// Fail2banUnban lifts a ban on an IP in one jail, or in every jail when jail is empty
+func Fail2banUnban(jail, ip string) error {
+	args := []string{"unban", ip}
+	if jail != "" {
+		args = []string{"set", jail, "unbanip", ip}
+	}
+	if output, err := exec.Command("fail2ban-client", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unban %s: %s", ip, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RecordAuthFailure appends a failed sign-in to the log the stardeck jail watches
+func RecordAuthFailure(username, ip string) error {
+	authFailureLogMu.Lock()
+	defer authFailureLogMu.Unlock()
+
+	f, err := os.OpenFile(AuthFailureLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Quoting keeps newlines in the username from forging extra lines
+	_, err = fmt.Fprintf(f, "%s stardeck: authentication failure ip=%s user=%s\n",
+		time.Now().Format("2006-01-02 15:04:05"), ip, strconv.Quote(username))
+	return err
+}
@@ -1,15 +1,20 @@
 package system
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
+
+	"stardeckos-backend/internal/models"
 )
 
 // FileInfo represents information about a file or directory
@@ -225,6 +230,98 @@ func DeletePath(path string, recursive bool) error {
 	return os.Remove(cleanPath)
 }
 
+// PreviewDirectory reports the total size and file count of a directory and its
+// largest top-level entries, listing at most maxEntries of them
+func PreviewDirectory(path string, maxEntries int) (*models.DataPreview, error) {
+	cleanPath := filepath.Clean(path)
+
+	info, err := os.Stat(cleanPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", cleanPath)
+	}
+
+	entries, err := os.ReadDir(cleanPath)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.DataPreview{
+		Path:    cleanPath,
+		Entries: make([]models.DataPreviewEntry, 0, len(entries)),
+		UsedBy:  []string{},
+	}
+
+	for _, entry := range entries {
+		item := models.DataPreviewEntry{
+			Name:  entry.Name(),
+			IsDir: entry.IsDir(),
+		}
+		filepath.Walk(filepath.Join(cleanPath, entry.Name()), func(_ string, fi os.FileInfo, err error) error {
+			if err == nil && !fi.IsDir() {
+				item.SizeBytes += fi.Size()
+				preview.FileCount++
+			}
+			return nil
+		})
+		preview.SizeBytes += item.SizeBytes
+		preview.Entries = append(preview.Entries, item)
+	}
+
+	sort.Slice(preview.Entries, func(i, j int) bool {
+		return preview.Entries[i].SizeBytes > preview.Entries[j].SizeBytes
+	})
+	if maxEntries > 0 && len(preview.Entries) > maxEntries {
+		preview.Entries = preview.Entries[:maxEntries]
+		preview.Truncated = true
+	}
+
+	return preview, nil
+}
+
+// ArchiveDirectory writes the contents of a directory to a gzipped tarball,
+// preserving ownership and permissions
+func ArchiveDirectory(ctx context.Context, src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "tar", "--numeric-owner", "-czf", dest, "-C", filepath.Clean(src), ".")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("failed to archive %s: %w - %s", src, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// StreamArchive writes a gzipped tarball of a directory to w, preserving
+// ownership and permissions
+func StreamArchive(ctx context.Context, src string, w io.Writer) error {
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, "tar", "--numeric-owner", "-czf", "-", "-C", filepath.Clean(src), ".")
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to archive %s: %w - %s", src, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ExtractArchive unpacks a tarball written by ArchiveDirectory into dest
+func ExtractArchive(ctx context.Context, archive, dest string) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "tar", "--numeric-owner", "-xzf", archive, "-C", filepath.Clean(dest))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to extract %s: %w - %s", archive, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // RenamePath renames or moves a file/directory
 func RenamePath(oldPath, newPath string) error {
 	cleanOld := filepath.Clean(oldPath)
@@ -471,3 +568,41 @@ func getMimeType(ext string, isDir bool) string {
 
 	return "application/octet-stream"
 }
+
+// DirectorySize returns the total size of regular files under a directory
+func DirectorySize(path string) int64 {
+	var total int64
+	filepath.Walk(path, func(_ string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			total += fi.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// DiskUsage returns the bytes a directory takes up on disk, staying on its
+// filesystem, using du. It falls back to DirectorySize when du isn't
+// available or gives no total. du still prints a total when it can't read
+// some subdirectories, so that counts as a result.
+func DiskUsage(ctx context.Context, path string) (int64, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	out, err := exec.CommandContext(ctx, "du", "-s", "-x", "-B1", filepath.Clean(path)).Output()
+	if ctx.Err() != nil {
+		return 0, fmt.Errorf("measuring %s timed out", path)
+	}
+	if fields := strings.Fields(string(out)); len(fields) > 0 {
+		if size, perr := strconv.ParseInt(fields[0], 10, 64); perr == nil {
+			return size, nil
+		}
+	}
+	if err != nil {
+		if _, lookErr := exec.LookPath("du"); lookErr != nil {
+			return DirectorySize(path), nil
+		}
+		return 0, fmt.Errorf("failed to measure %s: %w", path, err)
+	}
+	return DirectorySize(path), nil
+}
@@ -0,0 +1,288 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// FirewallChangeKind is the part of the firewall configuration a change touches
+type FirewallChangeKind string
+
+const (
+	FirewallChangeService     FirewallChangeKind = "service"
+	FirewallChangePort        FirewallChangeKind = "port"
+	FirewallChangeRichRule    FirewallChangeKind = "rich_rule"
+	FirewallChangeDefaultZone FirewallChangeKind = "default_zone"
+)
+
+// Firewall change actions
+const (
+	FirewallActionAdd    = "add"
+	FirewallActionRemove = "remove"
+	FirewallActionSet    = "set"
+)
+
+// FirewallChange is a single firewall mutation that can be previewed, applied and inverted
+type FirewallChange struct {
+	Kind      FirewallChangeKind `json:"kind"`
+	Action    string             `json:"action"`
+	Zone      string             `json:"zone,omitempty"`
+	Value     string             `json:"value"`              // service name, "port/protocol", rich rule or zone
+	Previous  string             `json:"previous,omitempty"` // default zone before a default_zone change
+	Permanent bool               `json:"permanent"`
+}
+
+// FirewallPreview describes what applying a change would do without applying it
+type FirewallPreview struct {
+	Change         FirewallChange `json:"change"`
+	Commands       []string       `json:"commands"`
+	RevertCommands []string       `json:"revert_commands"`
+	Zone           string         `json:"zone"`
+	Before         *FirewallZone  `json:"before,omitempty"`
+	After          *FirewallZone  `json:"after,omitempty"`
+	WebUIPort      int            `json:"web_ui_port,omitempty"`
+	LocksOutWebUI  bool           `json:"locks_out_web_ui"`
+	Warnings       []string       `json:"warnings"`
+}
+
+// Validate checks that the change is well formed
+func (ch FirewallChange) Validate() error {
+	switch ch.Kind {
+	case FirewallChangeService, FirewallChangePort, FirewallChangeRichRule:
+		if ch.Action != FirewallActionAdd && ch.Action != FirewallActionRemove {
+			return fmt.Errorf("action must be add or remove")
+		}
+		if ch.Zone == "" {
+			return fmt.Errorf("zone is required")
+		}
+	case FirewallChangeDefaultZone:
+		if ch.Action != FirewallActionSet {
+			return fmt.Errorf("action must be set")
+		}
+	default:
+		return fmt.Errorf("unknown change kind %q", ch.Kind)
+	}
+	if ch.Value == "" {
+		return fmt.Errorf("value is required")
+	}
+	if ch.Kind == FirewallChangePort {
+		if _, _, err := parsePortSpec(ch.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inverse returns the change that undoes this one. A default_zone change
+// needs Previous set to be invertible.
+func (ch FirewallChange) Inverse() FirewallChange {
+	inv := ch
+	switch ch.Action {
+	case FirewallActionAdd:
+		inv.Action = FirewallActionRemove
+	case FirewallActionRemove:
+		inv.Action = FirewallActionAdd
+	case FirewallActionSet:
+		inv.Value, inv.Previous = ch.Previous, ch.Value
+	}
+	return inv
+}
+
+// FirewallChangeArgs returns the firewall-cmd arguments that apply a change
+func FirewallChangeArgs(ch FirewallChange, permanent bool) []string {
+	if ch.Kind == FirewallChangeDefaultZone {
+		return []string{"--set-default-zone=" + ch.Value}
+	}
+	flag := map[FirewallChangeKind]string{
+		FirewallChangeService:  "service",
+		FirewallChangePort:     "port",
+		FirewallChangeRichRule: "rich-rule",
+	}[ch.Kind]
+	args := []string{"--zone=" + ch.Zone, "--" + ch.Action + "-" + flag + "=" + ch.Value}
+	if permanent {
+		args = append(args, "--permanent")
+	}
+	return args
+}
+
+// ApplyFirewallChange applies a change to the runtime or permanent configuration.
+// Default zone changes always take effect in both.
+func ApplyFirewallChange(ch FirewallChange, permanent bool) error {
+	switch ch.Kind {
+	case FirewallChangeService:
+		if ch.Action == FirewallActionAdd {
+			return AddFirewallService(ch.Zone, ch.Value, permanent)
+		}
+		return RemoveFirewallService(ch.Zone, ch.Value, permanent)
+	case FirewallChangePort:
+		port, protocol, err := parsePortSpec(ch.Value)
+		if err != nil {
+			return err
+		}
+		if ch.Action == FirewallActionAdd {
+			return AddFirewallPort(ch.Zone, port, protocol, permanent)
+		}
+		return RemoveFirewallPort(ch.Zone, port, protocol, permanent)
+	case FirewallChangeRichRule:
+		if ch.Action == FirewallActionAdd {
+			return AddFirewallRichRule(ch.Zone, ch.Value, permanent)
+		}
+		return RemoveFirewallRichRule(ch.Zone, ch.Value, permanent)
+	case FirewallChangeDefaultZone:
+		return SetDefaultZone(ch.Value)
+	}
+	return fmt.Errorf("unknown change kind %q", ch.Kind)
+}
+
+// PreviewFirewallChange shows the commands a change runs and the affected zone
+// before and after it. When webUIPort is set it also reports whether the change
+// would close that port.
+func PreviewFirewallChange(ch FirewallChange, webUIPort int) (*FirewallPreview, error) {
+	preview := &FirewallPreview{
+		Change:    ch,
+		Zone:      ch.Zone,
+		WebUIPort: webUIPort,
+		Warnings:  []string{},
+	}
+
+	if ch.Kind == FirewallChangeDefaultZone {
+		status, err := GetFirewallStatus()
+		if err != nil {
+			return nil, err
+		}
+		preview.Change.Previous = status.DefaultZone
+		preview.Zone = status.DefaultZone
+	}
+	preview.Commands = []string{firewallCommandLine(FirewallChangeArgs(preview.Change, false))}
+	preview.RevertCommands = []string{firewallCommandLine(FirewallChangeArgs(preview.Change.Inverse(), false))}
+	if ch.Permanent && ch.Kind != FirewallChangeDefaultZone {
+		preview.Commands = append(preview.Commands, firewallCommandLine(FirewallChangeArgs(ch, true)))
+	}
+
+	before, err := GetFirewallZone(preview.Zone)
+	if err != nil {
+		return nil, err
+	}
+	preview.Before = before
+	if ch.Kind == FirewallChangeDefaultZone {
+		// The new default zone takes over interfaces without an explicit zone
+		if preview.After, err = GetFirewallZone(ch.Value); err != nil {
+			return nil, err
+		}
+	} else {
+		preview.After = simulateFirewallChange(before, ch)
+	}
+
+	if ch.Kind == FirewallChangeRichRule {
+		preview.Warnings = append(preview.Warnings, "rich rules are not evaluated; check that the rule does not block the web UI")
+	}
+	if webUIPort > 0 && firewallZoneAllowsPort(preview.Before, webUIPort) && !firewallZoneAllowsPort(preview.After, webUIPort) {
+		preview.LocksOutWebUI = true
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("this change closes port %d/tcp, which serves the web UI", webUIPort))
+	}
+
+	return preview, nil
+}
+
+// simulateFirewallChange returns a copy of the zone with a service or port change applied
+func simulateFirewallChange(zone *FirewallZone, ch FirewallChange) *FirewallZone {
+	after := *zone
+	after.Services = append([]string{}, zone.Services...)
+	after.Ports = append([]string{}, zone.Ports...)
+
+	var list *[]string
+	switch ch.Kind {
+	case FirewallChangeService:
+		list = &after.Services
+	case FirewallChangePort:
+		list = &after.Ports
+	default:
+		return &after
+	}
+
+	kept := (*list)[:0]
+	for _, v := range *list {
+		if v != ch.Value {
+			kept = append(kept, v)
+		}
+	}
+	if ch.Action == FirewallActionAdd {
+		kept = append(kept, ch.Value)
+	}
+	*list = kept
+	return &after
+}
+
+// firewallZoneAllowsPort reports whether a zone accepts TCP traffic on a port,
+// either directly, through one of its services or through an ACCEPT target
+func firewallZoneAllowsPort(zone *FirewallZone, port int) bool {
+	if zone.Target == "ACCEPT" {
+		return true
+	}
+	spec := strconv.Itoa(port) + "/tcp"
+	for _, p := range zone.Ports {
+		if p == spec {
+			return true
+		}
+	}
+	for _, service := range zone.Services {
+		ports, err := FirewallServicePorts(service)
+		if err != nil {
+			continue
+		}
+		for _, p := range ports {
+			if p == spec {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FirewallServicePorts returns the "port/protocol" entries a firewalld service opens
+func FirewallServicePorts(service string) ([]string, error) {
+	output, err := exec.Command("firewall-cmd", "--info-service="+service).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", service, err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "ports:") {
+			return strings.Fields(strings.TrimPrefix(line, "ports:")), nil
+		}
+	}
+	return []string{}, nil
+}
+
+// parsePortSpec splits "port/protocol" into its parts
+func parsePortSpec(spec string) (int, string, error) {
+	portStr, protocol, ok := strings.Cut(spec, "/")
+	port, err := strconv.Atoi(portStr)
+	if !ok || err != nil || port < 1 || port > 65535 {
+		return 0, "", fmt.Errorf("invalid port %q, expected port/protocol (e.g., 8080/tcp)", spec)
+	}
+	switch protocol {
+	case "tcp", "udp", "sctp", "dccp":
+	default:
+		return 0, "", fmt.Errorf("invalid protocol %q", protocol)
+	}
+	return port, protocol, nil
+}
+
+// firewallCommandLine renders firewall-cmd arguments as a shell command line
+func firewallCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \"'") {
+			flag, value, _ := strings.Cut(arg, "=")
+			arg = flag + "='" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return "firewall-cmd " + strings.Join(quoted, " ")
+}
@@ -0,0 +1,61 @@
+package system
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// PortForwarder relays a host port to an address inside a container network using socat
+type PortForwarder struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// StartPortForwarder listens on hostPort and forwards connections to targetIP:targetPort
+func StartPortForwarder(protocol string, hostPort int, targetIP string, targetPort int) (*PortForwarder, error) {
+	if _, err := exec.LookPath("socat"); err != nil {
+		return nil, fmt.Errorf("socat is not installed")
+	}
+
+	proto := strings.ToUpper(protocol)
+	if proto != "TCP" && proto != "UDP" {
+		return nil, fmt.Errorf("unsupported protocol: %s", protocol)
+	}
+
+	listen := fmt.Sprintf("%s-LISTEN:%d,fork,reuseaddr", proto, hostPort)
+	target := fmt.Sprintf("%s:%s:%d", proto, targetIP, targetPort)
+
+	cmd := exec.Command("socat", listen, target)
+	// Own process group so the forwarder and its forked children are stopped together
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start socat: %w", err)
+	}
+
+	f := &PortForwarder{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		close(f.done)
+	}()
+	return f, nil
+}
+
+// Running reports whether the forwarder process is still alive
+func (f *PortForwarder) Running() bool {
+	select {
+	case <-f.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Stop terminates the forwarder and any connections it is serving
+func (f *PortForwarder) Stop() {
+	if f.Running() {
+		syscall.Kill(-f.cmd.Process.Pid, syscall.SIGTERM)
+		<-f.done
+	}
+}
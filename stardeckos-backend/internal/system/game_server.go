@@ -0,0 +1,315 @@
+package system
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// gameServerPresets are the game server images Stardeck knows defaults for
+var gameServerPresets = []models.GameServerPreset{
+	{
+		ID:              "minecraft",
+		Name:            "Minecraft: Java Edition",
+		Description:     "itzg/minecraft-server. RCON is enabled by default; set RCON_PASSWORD on the container.",
+		Image:           "docker.io/itzg/minecraft-server:latest",
+		Ports:           []models.PortMapping{{HostPort: 25565, ContainerPort: 25565, Protocol: "tcp"}},
+		RCONPort:        25575,
+		RCONPasswordEnv: "RCON_PASSWORD",
+		Probe:           models.GameProbeRCON,
+		PlayerCommand:   "list",
+		PlayerPattern:   `There are (\d+) of a max(?: of)? (\d+) players online`,
+		WarningCommand:  "say Server restarting in {minutes} minute(s)",
+		SaveCommand:     "save-all flush",
+	},
+	{
+		ID:          "valheim",
+		Name:        "Valheim",
+		Description: "lloesche/valheim-server. Players are counted with a Steam query; Valheim has no RCON console.",
+		Image:       "ghcr.io/lloesche/valheim-server:latest",
+		Ports: []models.PortMapping{
+			{HostPort: 2456, ContainerPort: 2456, Protocol: "udp"},
+			{HostPort: 2457, ContainerPort: 2457, Protocol: "udp"},
+		},
+		Probe:     models.GameProbeA2S,
+		QueryPort: 2457,
+	},
+	{
+		ID:          "factorio",
+		Name:        "Factorio",
+		Description: "factoriotools/factorio. The RCON password is generated into /factorio/config/rconpw; copy it into the profile.",
+		Image:       "docker.io/factoriotools/factorio:stable",
+		Ports: []models.PortMapping{
+			{HostPort: 34197, ContainerPort: 34197, Protocol: "udp"},
+			{HostPort: 27015, ContainerPort: 27015, Protocol: "tcp"},
+		},
+		RCONPort:       27015,
+		Probe:          models.GameProbeRCON,
+		PlayerCommand:  "/players online",
+		PlayerPattern:  `Online players \((\d+)\)`,
+		WarningCommand: "Server restarting in {minutes} minute(s)",
+		SaveCommand:    "/server-save",
+	},
+	{
+		ID:          "source",
+		Name:        "Source engine (generic)",
+		Description: "Counter-Strike, Team Fortress 2, Garry's Mod and other Source or Steam dedicated servers.",
+		Ports: []models.PortMapping{
+			{HostPort: 27015, ContainerPort: 27015, Protocol: "udp"},
+			{HostPort: 27015, ContainerPort: 27015, Protocol: "tcp"},
+		},
+		RCONPort:       27015,
+		Probe:          models.GameProbeA2S,
+		QueryPort:      27015,
+		WarningCommand: "say Server restarting in {minutes} minute(s)",
+	},
+}
+
+// GameServerPresets lists the known game server presets
+func GameServerPresets() []models.GameServerPreset {
+	return gameServerPresets
+}
+
+// GameServerPreset returns a preset by ID
+func GameServerPreset(id string) *models.GameServerPreset {
+	for i := range gameServerPresets {
+		if gameServerPresets[i].ID == id {
+			return &gameServerPresets[i]
+		}
+	}
+	return nil
+}
+
+// ValidateGameServerProfile checks a profile before it is saved
+func ValidateGameServerProfile(p *models.GameServerProfile) error {
+	for _, port := range []int{p.RCONPort, p.QueryPort} {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+	}
+	switch p.Probe {
+	case models.GameProbeNone:
+	case models.GameProbeRCON:
+		if p.RCONPort == 0 || p.PlayerCommand == "" || p.PlayerPattern == "" {
+			return fmt.Errorf("the rcon probe needs rcon_port, player_command and player_pattern")
+		}
+	case models.GameProbeA2S:
+		if p.QueryPort == 0 {
+			return fmt.Errorf("the a2s probe needs query_port")
+		}
+	default:
+		return fmt.Errorf("probe must be none, rcon or a2s")
+	}
+	if p.PlayerPattern != "" {
+		re, err := regexp.Compile(p.PlayerPattern)
+		if err != nil {
+			return fmt.Errorf("invalid player_pattern: %w", err)
+		}
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("player_pattern must capture the player count in a group")
+		}
+	}
+	if p.RestartSchedule != "" {
+		if _, err := ParseCron(p.RestartSchedule); err != nil {
+			return fmt.Errorf("invalid restart_schedule: %w", err)
+		}
+	}
+	for _, minutes := range p.RestartWarnings {
+		if minutes < 1 || minutes > 24*60 {
+			return fmt.Errorf("restart warnings must be between 1 and 1440 minutes")
+		}
+	}
+	if ((len(p.RestartWarnings) > 0 && p.WarningCommand != "") || p.SaveCommand != "") && p.RCONPort == 0 {
+		return fmt.Errorf("warning and save commands need rcon_port")
+	}
+	if p.IdleShutdownMinutes < 0 {
+		return fmt.Errorf("idle_shutdown_minutes can't be negative")
+	}
+	if p.IdleShutdownMinutes > 0 && p.Probe == models.GameProbeNone {
+		return fmt.Errorf("idle shutdown needs a player-count probe")
+	}
+	return nil
+}
+
+// FormatGameWarning fills in the minutes left in a warning command
+func FormatGameWarning(command string, minutes int) string {
+	return strings.ReplaceAll(command, "{minutes}", strconv.Itoa(minutes))
+}
+
+// ParsePlayerCount extracts the player count from a console command's output
+func ParsePlayerCount(pattern, output string) (*models.GameServerPlayers, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	m := re.FindStringSubmatch(output)
+	if m == nil {
+		return nil, fmt.Errorf("player count not found in %q", strings.TrimSpace(output))
+	}
+	players := &models.GameServerPlayers{}
+	players.Players, _ = strconv.Atoi(m[1])
+	if len(m) > 2 {
+		players.MaxPlayers, _ = strconv.Atoi(m[2])
+	}
+	return players, nil
+}
+
+// QueryA2SInfo asks a Steam game server for its name, map and player count
+func QueryA2SInfo(addr string, timeout time.Duration) (*models.GameServerPlayers, error) {
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request := append([]byte{0xFF, 0xFF, 0xFF, 0xFF, 'T'}, "Source Engine Query\x00"...)
+	buf := make([]byte, 1400)
+	for attempt := 0; attempt < 2; attempt++ {
+		if _, err := conn.Write(request); err != nil {
+			return nil, fmt.Errorf("failed to send query: %w", err)
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("no reply to query: %w", err)
+		}
+		reply := buf[:n]
+		if len(reply) < 5 || !bytes.Equal(reply[:4], []byte{0xFF, 0xFF, 0xFF, 0xFF}) {
+			return nil, fmt.Errorf("unexpected reply to query")
+		}
+		switch reply[4] {
+		case 'A':
+			// Newer servers answer with a challenge the query must repeat
+			if len(reply) < 9 {
+				return nil, fmt.Errorf("truncated challenge")
+			}
+			request = append(request[:25], reply[5:9]...)
+			continue
+		case 'I':
+			return parseA2SInfo(reply[5:])
+		default:
+			return nil, fmt.Errorf("unexpected reply type 0x%02x", reply[4])
+		}
+	}
+	return nil, fmt.Errorf("server kept answering with a challenge")
+}
+
+// parseA2SInfo reads an A2S_INFO reply after its header byte
+func parseA2SInfo(data []byte) (*models.GameServerPlayers, error) {
+	// Protocol version, then name, map, folder and game
+	if len(data) < 1 {
+		return nil, fmt.Errorf("truncated reply")
+	}
+	data = data[1:]
+	var fields [4]string
+	for i := range fields {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			return nil, fmt.Errorf("truncated reply")
+		}
+		fields[i] = string(data[:end])
+		data = data[end+1:]
+	}
+	// Steam app ID (2 bytes), players, max players
+	if len(data) < 4 {
+		return nil, fmt.Errorf("truncated reply")
+	}
+	return &models.GameServerPlayers{
+		Players:    int(data[2]),
+		MaxPlayers: int(data[3]),
+		Name:       fields[0],
+		Map:        fields[1],
+	}, nil
+}
+
+// ReachableAddress returns where Stardeck can reach a container port. Rootful
+// containers are reached on their network address; rootless ones only
+// through a port published on the host.
+func (i *podmanInspect) ReachableAddress(rootless bool, port int, protocol string) (string, error) {
+	if i.HostConfig.NetworkMode == "host" {
+		return net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), nil
+	}
+	if !rootless {
+		for _, n := range i.NetworkSettings.Networks {
+			if n.IPAddress != "" {
+				return net.JoinHostPort(n.IPAddress, strconv.Itoa(port)), nil
+			}
+		}
+	}
+	for _, binding := range i.HostConfig.PortBindings[fmt.Sprintf("%d/%s", port, protocol)] {
+		if binding.HostPort == "" {
+			continue
+		}
+		host := binding.HostIP
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		return net.JoinHostPort(host, binding.HostPort), nil
+	}
+	return "", fmt.Errorf("port %d/%s isn't reachable; publish it on the host", port, protocol)
+}
+
+// ParsePortList parses ports such as "25565", "2456-2458/udp" or
+// "27015/tcp,27015/udp"; ports without a protocol default to tcp
+func ParsePortList(spec string) ([]models.PortMapping, error) {
+	var ports []models.PortMapping
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		protocol := "tcp"
+		if i := strings.Index(item, "/"); i >= 0 {
+			protocol = strings.ToLower(item[i+1:])
+			item = item[:i]
+		}
+		if protocol != "tcp" && protocol != "udp" {
+			return nil, fmt.Errorf("invalid protocol %q", protocol)
+		}
+		first, last := item, item
+		if i := strings.Index(item, "-"); i >= 0 {
+			first, last = item[:i], item[i+1:]
+		}
+		from, err1 := strconv.Atoi(first)
+		to, err2 := strconv.Atoi(last)
+		if err1 != nil || err2 != nil || from < 1 || to > 65535 || from > to {
+			return nil, fmt.Errorf("invalid port %q", item)
+		}
+		if to-from >= 1000 {
+			return nil, fmt.Errorf("port range %q is too large", item)
+		}
+		for port := from; port <= to; port++ {
+			ports = append(ports, models.PortMapping{HostPort: port, ContainerPort: port, Protocol: protocol})
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports given")
+	}
+	return ports, nil
+}
+
+// HostPortFree reports whether nothing on the host is bound to a port. UDP
+// has no listen state, so a successful bind is the only reliable check.
+func HostPortFree(protocol string, port int) bool {
+	addr := ":" + strconv.Itoa(port)
+	if protocol == "udp" {
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
@@ -0,0 +1,224 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+const thermalRoot = "/sys/class/thermal"
+
+// HostCounters is a snapshot of the host's cumulative CPU and disk counters
+// along with its instantaneous memory, load and temperature readings. Rates
+// come from comparing two snapshots with HostMetricsSince.
+type HostCounters struct {
+	Time time.Time
+
+	cpuBusy, cpuIOWait, cpuTotal uint64 // USER_HZ ticks over all cores
+
+	diskRead, diskWritten uint64            // Bytes, summed over whole disks
+	diskIOTicks           map[string]uint64 // Milliseconds each disk spent doing I/O
+
+	memoryTotal, memoryAvailable uint64
+	swapTotal, swapFree          uint64
+	load                         LoadAverage
+	temperatures                 []models.ThermalReading
+}
+
+// ReadHostCounters reads /proc/stat, /proc/diskstats, /proc/meminfo,
+// /proc/loadavg and the thermal zones under /sys/class/thermal
+func ReadHostCounters() (*HostCounters, error) {
+	c := &HostCounters{Time: time.Now()}
+	if err := c.readCPU(); err != nil {
+		return nil, err
+	}
+	if err := c.readMemory(); err != nil {
+		return nil, err
+	}
+	c.readDisks()
+	if load, err := getLoadAverage(); err == nil && load != nil {
+		c.load = *load
+	}
+	c.temperatures = ReadThermalZones()
+	return c, nil
+}
+
+func (c *HostCounters) readCPU() error {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return fmt.Errorf("unexpected /proc/stat format")
+	}
+
+	// user nice system idle iowait irq softirq steal; guest time is already in user
+	for i, field := range fields[1:] {
+		if i >= 8 {
+			break
+		}
+		v, _ := strconv.ParseUint(field, 10, 64)
+		c.cpuTotal += v
+		switch i {
+		case 3: // idle
+		case 4:
+			c.cpuIOWait = v
+		default:
+			c.cpuBusy += v
+		}
+	}
+	return nil
+}
+
+func (c *HostCounters) readMemory() error {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, _ := strconv.ParseUint(fields[1], 10, 64)
+		value *= 1024 // kB
+		switch fields[0] {
+		case "MemTotal:":
+			c.memoryTotal = value
+		case "MemAvailable:":
+			c.memoryAvailable = value
+		case "SwapTotal:":
+			c.swapTotal = value
+		case "SwapFree:":
+			c.swapFree = value
+		}
+	}
+	return scanner.Err()
+}
+
+// readDisks sums the counters of whole physical disks. Partitions, loop
+// devices and device-mapper volumes would count the same I/O twice.
+func (c *HostCounters) readDisks() {
+	data, err := os.ReadFile("/proc/diskstats")
+	if err != nil {
+		return
+	}
+	c.diskIOTicks = make(map[string]uint64)
+	for _, line := range strings.Split(string(data), "\n") {
+		// major minor name reads merged sectors_read ms writes merged sectors_written ms in_flight io_ms ...
+		fields := strings.Fields(line)
+		if len(fields) < 13 {
+			continue
+		}
+		name := strings.ReplaceAll(fields[2], "/", "!")
+		if _, err := os.Stat(filepath.Join("/sys/block", name, "device")); err != nil {
+			continue
+		}
+		sectorsRead, _ := strconv.ParseUint(fields[5], 10, 64)
+		sectorsWritten, _ := strconv.ParseUint(fields[9], 10, 64)
+		ioTicks, _ := strconv.ParseUint(fields[12], 10, 64)
+
+		// diskstats always counts 512-byte sectors
+		c.diskRead += sectorsRead * 512
+		c.diskWritten += sectorsWritten * 512
+		c.diskIOTicks[name] = ioTicks
+	}
+}
+
+// ReadThermalZones returns the temperature of every readable thermal zone.
+// Hosts without any, such as most VMs, return none.
+func ReadThermalZones() []models.ThermalReading {
+	readings := []models.ThermalReading{}
+	zones, _ := filepath.Glob(filepath.Join(thermalRoot, "thermal_zone*"))
+	for _, zone := range zones {
+		milli, err := readIntFile(filepath.Join(zone, "temp"))
+		if err != nil || milli <= 0 {
+			continue
+		}
+		zoneType := filepath.Base(zone)
+		if data, err := os.ReadFile(filepath.Join(zone, "type")); err == nil {
+			zoneType = strings.TrimSpace(string(data))
+		}
+		readings = append(readings, models.ThermalReading{
+			Zone:    filepath.Base(zone),
+			Type:    zoneType,
+			Celsius: float64(milli) / 1000,
+		})
+	}
+	return readings
+}
+
+func readIntFile(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// HostMetricsSince turns two snapshots into a sample: CPU and disk figures
+// are rates over the time between them, the rest are the current readings
+func HostMetricsSince(prev, cur *HostCounters) models.HostMetrics {
+	m := models.HostMetrics{
+		Timestamp:       cur.Time,
+		MemoryTotal:     int64(cur.memoryTotal),
+		MemoryUsed:      int64(cur.memoryTotal - cur.memoryAvailable),
+		MemoryAvailable: int64(cur.memoryAvailable),
+		SwapTotal:       int64(cur.swapTotal),
+		SwapUsed:        int64(cur.swapTotal - cur.swapFree),
+		Load1:           cur.load.Load1,
+		Load5:           cur.load.Load5,
+		Load15:          cur.load.Load15,
+		Temperatures:    cur.temperatures,
+	}
+	for _, t := range cur.temperatures {
+		if m.TemperatureMax == nil || t.Celsius > *m.TemperatureMax {
+			celsius := t.Celsius
+			m.TemperatureMax = &celsius
+		}
+	}
+	if prev == nil {
+		return m
+	}
+
+	if total := counterDelta(prev.cpuTotal, cur.cpuTotal); total > 0 {
+		m.CPUPercent = float64(counterDelta(prev.cpuBusy, cur.cpuBusy)) / float64(total) * 100
+		m.IOWaitPercent = float64(counterDelta(prev.cpuIOWait, cur.cpuIOWait)) / float64(total) * 100
+	}
+	if seconds := cur.Time.Sub(prev.Time).Seconds(); seconds > 0 {
+		m.DiskReadRate = int64(float64(counterDelta(prev.diskRead, cur.diskRead)) / seconds)
+		m.DiskWriteRate = int64(float64(counterDelta(prev.diskWritten, cur.diskWritten)) / seconds)
+
+		// Utilization of the busiest disk, as iostat's %util reports it
+		for name, ticks := range cur.diskIOTicks {
+			before, ok := prev.diskIOTicks[name]
+			if !ok {
+				continue
+			}
+			busy := float64(counterDelta(before, ticks)) / (seconds * 1000) * 100
+			if busy > m.DiskBusyPercent {
+				m.DiskBusyPercent = min(busy, 100)
+			}
+		}
+	}
+	return m
+}
+
+// counterDelta returns how far a counter advanced, or 0 if it was reset
+func counterDelta(before, after uint64) uint64 {
+	if after < before {
+		return 0
+	}
+	return after - before
+}
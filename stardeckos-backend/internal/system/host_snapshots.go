@@ -0,0 +1,231 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// btrfsSnapshotDir is where btrfs snapshots are kept, in the filesystem's
+// top-level subvolume so they survive rolling back the subvolume they came from
+const btrfsSnapshotDir = ".stardeck-snapshots"
+
+// snapshotTimeFormat names snapshots by when they were taken
+const snapshotTimeFormat = "20060102-150405"
+
+// snapshotCmd runs a storage command, returning its trimmed output and the
+// output as the error when it fails
+func snapshotCmd(name string, args ...string) (string, error) {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	out := strings.TrimSpace(string(output))
+	if err != nil {
+		if out == "" {
+			out = err.Error()
+		}
+		return out, fmt.Errorf("%s %s failed: %s", name, args[0], out)
+	}
+	return out, nil
+}
+
+// DetectSnapshotTarget finds the filesystem holding path and whether it can be
+// snapshotted, as an LVM thin volume, a btrfs subvolume or a ZFS dataset
+func DetectSnapshotTarget(path string) models.HostSnapshotTarget {
+	target := models.HostSnapshotTarget{Path: path}
+
+	output, err := exec.Command("findmnt", "-J", "-n", "-T", path, "-o", "TARGET,SOURCE,FSTYPE").Output()
+	if err != nil {
+		target.Reason = "Failed to find the filesystem: " + err.Error()
+		return target
+	}
+	var found struct {
+		Filesystems []struct {
+			Target string `json:"target"`
+			Source string `json:"source"`
+			FSType string `json:"fstype"`
+		} `json:"filesystems"`
+	}
+	if err := json.Unmarshal(output, &found); err != nil || len(found.Filesystems) == 0 {
+		target.Reason = "Failed to find the filesystem"
+		return target
+	}
+	fs := found.Filesystems[0]
+	target.MountPoint, target.Device, target.FSType = fs.Target, fs.Source, fs.FSType
+
+	switch fs.FSType {
+	case "btrfs":
+		// findmnt shows the mounted subvolume as /dev/sda3[/root]
+		device, subvol, _ := strings.Cut(fs.Source, "[")
+		target.Device = device
+		target.Source = "/" + strings.Trim(strings.TrimSuffix(subvol, "]"), "/")
+		target.Backend = models.SnapshotBackendBtrfs
+		if _, err := exec.LookPath("btrfs"); err != nil {
+			target.Backend, target.Reason = "", "btrfs-progs is not installed"
+		}
+	case "zfs":
+		target.Source = fs.Source
+		target.Backend = models.SnapshotBackendZFS
+	default:
+		target.Source, target.Reason = lvmThinVolume(fs.Source)
+		if target.Source != "" {
+			target.Backend = models.SnapshotBackendLVMThin
+		}
+	}
+	return target
+}
+
+// lvmThinVolume returns the vg/lv of a thin-provisioned logical volume, or why
+// the device isn't one
+func lvmThinVolume(device string) (string, string) {
+	if !strings.HasPrefix(device, "/dev/") {
+		return "", "Not on LVM, btrfs or ZFS"
+	}
+	output, err := exec.Command("lvs", "--noheadings", "--separator", "|", "-o", "vg_name,lv_name,pool_lv", device).Output()
+	if err != nil {
+		return "", "Not on LVM, btrfs or ZFS"
+	}
+	fields := strings.Split(strings.TrimSpace(string(output)), "|")
+	if len(fields) < 3 {
+		return "", "Not on LVM, btrfs or ZFS"
+	}
+	if strings.TrimSpace(fields[2]) == "" {
+		return "", "The logical volume isn't thin-provisioned; only thin volumes are snapshotted without reserving space"
+	}
+	return strings.TrimSpace(fields[0]) + "/" + strings.TrimSpace(fields[1]), ""
+}
+
+// withBtrfsTopLevel mounts a btrfs filesystem's top-level subvolume in a
+// temporary directory for the length of fn
+func withBtrfsTopLevel(device string, fn func(top string) error) error {
+	dir, err := os.MkdirTemp("", "stardeck-btrfs-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dir)
+
+	if _, err := snapshotCmd("mount", "-o", "subvolid=5", device, dir); err != nil {
+		return err
+	}
+	defer exec.Command("umount", dir).Run()
+	return fn(dir)
+}
+
+// CreateHostSnapshot snapshots a target, returning the snapshot's name
+func CreateHostSnapshot(target models.HostSnapshotTarget, at time.Time) (string, error) {
+	stamp := at.Format(snapshotTimeFormat)
+	switch target.Backend {
+	case models.SnapshotBackendLVMThin:
+		_, lv, _ := strings.Cut(target.Source, "/")
+		name := lv + "_stardeck_" + stamp
+		_, err := snapshotCmd("lvcreate", "--snapshot", "--name", name, "--addtag", "stardeck", target.Source)
+		return name, err
+	case models.SnapshotBackendBtrfs:
+		subvol := strings.ReplaceAll(strings.Trim(target.Source, "/"), "/", "_")
+		if subvol == "" {
+			subvol = "toplevel"
+		}
+		name := subvol + "-" + stamp
+		err := withBtrfsTopLevel(target.Device, func(top string) error {
+			dir := filepath.Join(top, btrfsSnapshotDir)
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return err
+			}
+			_, err := snapshotCmd("btrfs", "subvolume", "snapshot", "-r", target.MountPoint, filepath.Join(dir, name))
+			return err
+		})
+		return name, err
+	case models.SnapshotBackendZFS:
+		name := "stardeck-" + stamp
+		_, err := snapshotCmd("zfs", "snapshot", target.Source+"@"+name)
+		return name, err
+	}
+	return "", fmt.Errorf("%s can't be snapshotted: %s", target.Path, target.Reason)
+}
+
+// HostSnapshotExists reports whether a snapshot is still on disk
+func HostSnapshotExists(s *models.HostSnapshot) bool {
+	switch s.Backend {
+	case models.SnapshotBackendLVMThin:
+		vg, _, _ := strings.Cut(s.Source, "/")
+		return exec.Command("lvs", vg+"/"+s.Name).Run() == nil
+	case models.SnapshotBackendBtrfs:
+		exists := false
+		withBtrfsTopLevel(s.Device, func(top string) error {
+			_, err := os.Stat(filepath.Join(top, btrfsSnapshotDir, s.Name))
+			exists = err == nil
+			return nil
+		})
+		return exists
+	case models.SnapshotBackendZFS:
+		return exec.Command("zfs", "list", "-t", "snapshot", s.Source+"@"+s.Name).Run() == nil
+	}
+	return false
+}
+
+// DeleteHostSnapshot removes a snapshot from disk
+func DeleteHostSnapshot(s *models.HostSnapshot) error {
+	switch s.Backend {
+	case models.SnapshotBackendLVMThin:
+		vg, _, _ := strings.Cut(s.Source, "/")
+		_, err := snapshotCmd("lvremove", "-y", vg+"/"+s.Name)
+		return err
+	case models.SnapshotBackendBtrfs:
+		return withBtrfsTopLevel(s.Device, func(top string) error {
+			_, err := snapshotCmd("btrfs", "subvolume", "delete", filepath.Join(top, btrfsSnapshotDir, s.Name))
+			return err
+		})
+	case models.SnapshotBackendZFS:
+		_, err := snapshotCmd("zfs", "destroy", s.Source+"@"+s.Name)
+		return err
+	}
+	return fmt.Errorf("unknown snapshot backend %q", s.Backend)
+}
+
+// RollbackHostSnapshot returns a filesystem to a snapshot, reporting whether
+// the rollback only takes effect on the next boot.
+//
+// An LVM merge of a mounted volume is deferred by LVM until the volume is next
+// activated. A btrfs subvolume is renamed aside and replaced by a writable
+// copy of the snapshot, which is mounted in its place at the next boot; the
+// old subvolume is kept. A ZFS dataset rolls back at once, destroying any
+// later snapshots of it.
+func RollbackHostSnapshot(s *models.HostSnapshot) (bool, error) {
+	switch s.Backend {
+	case models.SnapshotBackendLVMThin:
+		vg, _, _ := strings.Cut(s.Source, "/")
+		output, err := snapshotCmd("lvconvert", "--merge", vg+"/"+s.Name)
+		if err != nil {
+			return false, err
+		}
+		return strings.Contains(output, "Delaying merge"), nil
+	case models.SnapshotBackendBtrfs:
+		subvol := strings.Trim(s.Source, "/")
+		if subvol == "" {
+			return false, fmt.Errorf("the top-level btrfs subvolume can't be replaced; copy files back from %s/%s instead", btrfsSnapshotDir, s.Name)
+		}
+		err := withBtrfsTopLevel(s.Device, func(top string) error {
+			current := filepath.Join(top, subvol)
+			aside := current + ".pre-rollback-" + time.Now().Format(snapshotTimeFormat)
+			if err := os.Rename(current, aside); err != nil {
+				return fmt.Errorf("failed to move %s aside: %w", s.Source, err)
+			}
+			_, err := snapshotCmd("btrfs", "subvolume", "snapshot", filepath.Join(top, btrfsSnapshotDir, s.Name), current)
+			if err != nil {
+				os.Rename(aside, current)
+			}
+			return err
+		})
+		return err == nil, err
+	case models.SnapshotBackendZFS:
+		if _, err := snapshotCmd("zfs", "rollback", "-r", s.Source+"@"+s.Name); err != nil {
+			return false, err
+		}
+		return s.MountPoint == "/", nil
+	}
+	return false, fmt.Errorf("unknown snapshot backend %q", s.Backend)
+}
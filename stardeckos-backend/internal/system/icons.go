@@ -0,0 +1,50 @@
+package system
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG decoder for uploaded icons
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// ResizeIcon scales a PNG or JPEG image to fit within a size x size square and writes it as PNG
+func ResizeIcon(srcPath, dstPath string, size int) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(src)
+	src.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode icon: %w", err)
+	}
+
+	b := img.Bounds()
+	width := size
+	if b.Dy() > b.Dx() {
+		width = size * b.Dx() / b.Dy()
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(dst, scaleImage(img, width)); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	dst.Close()
+
+	return os.Rename(tmpPath, dstPath)
+}
@@ -0,0 +1,292 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ImageUpdateCheck compares a local image with its tag in the registry. The
+// check reads registry metadata only; nothing is pulled.
+type ImageUpdateCheck struct {
+	HasUpdate    bool
+	LocalDigest  string
+	RemoteDigest string
+	RemoteLabels map[string]string // Labels of the registry image; empty when only its digest could be read
+	Method       string            // "skopeo", "registry" or "pinned" for images referenced by digest
+}
+
+// manifestAcceptTypes are the manifest formats a registry may return for a tag.
+// Multi-arch images answer with an index, whose digest is what Podman records.
+var manifestAcceptTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// bearerParamPattern matches the key="value" pairs of a WWW-Authenticate challenge
+var bearerParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// imageReference is an image split into the parts the registry API needs
+type imageReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseImageReference splits a normalized image name such as
+// docker.io/library/nginx:1.25 or ghcr.io/org/app@sha256:...
+func parseImageReference(image string) imageReference {
+	var ref imageReference
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		image, ref.digest = name, digest
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, ref.tag = image[:i], image[i+1:]
+	}
+	if ref.tag == "" && ref.digest == "" {
+		ref.tag = "latest"
+	}
+	ref.registry, ref.repository, _ = strings.Cut(image, "/")
+	if ref.registry == "docker.io" {
+		ref.registry = "registry-1.docker.io"
+		if !strings.Contains(ref.repository, "/") {
+			ref.repository = "library/" + ref.repository
+		}
+	}
+	return ref
+}
+
+// localRepoDigests returns every manifest digest a local image is known by.
+// A multi-arch image has both its index digest and its platform manifest
+// digest, and the registry may report either.
+func (p *PodmanService) localRepoDigests(ctx context.Context, image string) ([]string, error) {
+	output, err := p.podmanCmd(ctx, "image", "inspect", image, "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	var images []struct {
+		Digest      string   `json:"Digest"`
+		RepoDigests []string `json:"RepoDigests"`
+	}
+	if err := json.Unmarshal(output, &images); err != nil {
+		return nil, fmt.Errorf("failed to parse image inspect: %w", err)
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("image not found: %s", image)
+	}
+
+	digests := []string{images[0].Digest}
+	for _, rd := range images[0].RepoDigests {
+		if _, digest, ok := strings.Cut(rd, "@"); ok {
+			digests = append(digests, digest)
+		}
+	}
+	return digests, nil
+}
+
+// inspectRemoteImage reads an image's digest and labels from its registry with
+// skopeo, using the Podman user's registry logins
+func (p *PodmanService) inspectRemoteImage(ctx context.Context, image string) (string, map[string]string, error) {
+	cmd := p.skopeoCmd(ctx, "inspect", "--no-tags", "docker://"+image)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", nil, fmt.Errorf("skopeo inspect failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", nil, err
+	}
+	var info struct {
+		Digest string            `json:"Digest"`
+		Labels map[string]string `json:"Labels"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return "", nil, fmt.Errorf("failed to parse skopeo inspect: %w", err)
+	}
+	return info.Digest, info.Labels, nil
+}
+
+// registryManifestDigest asks a registry for a tag's manifest digest with a
+// HEAD request, fetching an anonymous token when the registry asks for one.
+// Private images need skopeo, which can use registry logins.
+func registryManifestDigest(ctx context.Context, ref imageReference) (string, error) {
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.registry, ref.repository, ref.tag)
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	head := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(manifestAcceptTypes, ", "))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach %s: %w", ref.registry, err)
+		}
+		resp.Body.Close()
+		return resp, nil
+	}
+
+	resp, err := head("")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := registryToken(ctx, client, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if resp, err = head(token); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("%s requires a login to check this image; install skopeo so registry logins are used", ref.registry)
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("tag %s not found in %s", ref.tag, ref.repository)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("%s returned status %d", ref.registry, resp.StatusCode)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("%s did not report a manifest digest", ref.registry)
+	}
+	return digest, nil
+}
+
+// registryToken answers a registry's Bearer challenge with an anonymous token
+func registryToken(ctx context.Context, client *http.Client, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported registry authentication %q", scheme)
+	}
+	values := make(map[string]string)
+	for _, m := range bearerParamPattern.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(m[1])] = m[2]
+	}
+	if values["realm"] == "" {
+		return "", fmt.Errorf("registry authentication challenge has no realm")
+	}
+
+	tokenURL, err := url.Parse(values["realm"])
+	if err != nil {
+		return "", fmt.Errorf("invalid registry token realm: %w", err)
+	}
+	q := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			q.Set(key, values[key])
+		}
+	}
+	tokenURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// ResolveImage reports how an image would be obtained for a deploy: "local"
+// when it is already present, otherwise "skopeo" or "registry" once its
+// registry confirms the tag exists. Nothing is pulled.
+func (p *PodmanService) ResolveImage(ctx context.Context, image string) (string, error) {
+	if p.ImageExists(ctx, image) {
+		return "local", nil
+	}
+
+	normalizedImage := normalizeImageName(image)
+	if SkopeoAvailable() {
+		if _, _, err := p.inspectRemoteImage(ctx, normalizedImage); err != nil {
+			return "", err
+		}
+		return "skopeo", nil
+	}
+	ref := parseImageReference(normalizedImage)
+	if ref.digest != "" {
+		ref.tag = ref.digest
+	}
+	if _, err := registryManifestDigest(ctx, ref); err != nil {
+		return "", err
+	}
+	return "registry", nil
+}
+
+// CheckImageUpdate compares a local image with the current manifest of its tag
+// in the registry, without pulling. skopeo is used when installed, since it
+// also returns the new image's labels and honours registry logins; otherwise
+// the registry API is asked directly.
+func (p *PodmanService) CheckImageUpdate(ctx context.Context, image string) (*ImageUpdateCheck, error) {
+	normalizedImage := normalizeImageName(image)
+
+	localDigests, err := p.localRepoDigests(ctx, normalizedImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local image digest: %w", err)
+	}
+	check := &ImageUpdateCheck{LocalDigest: localDigests[0], RemoteLabels: map[string]string{}}
+
+	ref := parseImageReference(normalizedImage)
+	switch {
+	case ref.digest != "":
+		// A digest reference always means the same image
+		check.Method, check.RemoteDigest = "pinned", ref.digest
+		return check, nil
+	case SkopeoAvailable():
+		check.Method = "skopeo"
+		digest, labels, err := p.inspectRemoteImage(ctx, normalizedImage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for updates: %w", err)
+		}
+		check.RemoteDigest = digest
+		if labels != nil {
+			check.RemoteLabels = labels
+		}
+	default:
+		check.Method = "registry"
+		if check.RemoteDigest, err = registryManifestDigest(ctx, ref); err != nil {
+			return nil, fmt.Errorf("failed to check for updates: %w", err)
+		}
+	}
+
+	check.HasUpdate = true
+	for _, digest := range localDigests {
+		if digest == check.RemoteDigest {
+			check.HasUpdate = false
+			break
+		}
+	}
+	return check, nil
+}
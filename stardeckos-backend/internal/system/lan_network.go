@@ -0,0 +1,104 @@
+package system
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"stardeckos-backend/internal/models"
+)
+
+// LANInterfaces lists the interfaces a macvlan or ipvlan network can use as
+// its parent: those with an IPv4 address that aren't part of a bridge or
+// bond. The bridges of podman networks are passed in skip.
+func LANInterfaces(skip map[string]bool) ([]models.LANInterface, error) {
+	interfaces, err := GetNetworkInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	routes, _ := GetRoutes()
+
+	result := []models.LANInterface{}
+	for _, iface := range interfaces {
+		switch iface.Type {
+		case "loopback", "virtual":
+			continue
+		}
+		if skip[iface.Name] || iface.Master != "" || len(iface.IPv4) == 0 {
+			continue
+		}
+		ip, ipnet, err := net.ParseCIDR(iface.IPv4[0])
+		if err != nil {
+			continue
+		}
+
+		lan := models.LANInterface{
+			Name:        iface.Name,
+			Type:        iface.Type,
+			State:       iface.State,
+			HostAddress: iface.IPv4[0],
+			Subnet:      ipnet.String(),
+		}
+		for _, r := range routes {
+			if r.Destination == "default" && r.Interface == iface.Name && r.Gateway != "" {
+				lan.Gateway = r.Gateway
+				break
+			}
+		}
+		lan.SuggestedIPRange = suggestIPRange(ipnet, ip, net.ParseIP(lan.Gateway))
+
+		if lan.Gateway == "" {
+			lan.Warnings = append(lan.Warnings, "No default route on this interface; enter the LAN gateway yourself")
+		}
+		if iface.Type == "wireless" {
+			lan.Warnings = append(lan.Warnings, "Wi-Fi access points usually drop frames from extra MAC addresses; use ipvlan rather than macvlan")
+		}
+		if iface.State != "up" {
+			lan.Warnings = append(lan.Warnings, fmt.Sprintf("Interface is %s", iface.State))
+		}
+		result = append(result, lan)
+	}
+	return result, nil
+}
+
+// LANInterfaceByName returns the detected LAN settings of one interface
+func LANInterfaceByName(name string) (*models.LANInterface, error) {
+	interfaces, err := LANInterfaces(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range interfaces {
+		if interfaces[i].Name == name {
+			return &interfaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not an interface with an IPv4 address on a LAN", name)
+}
+
+// suggestIPRange picks a quarter of an IPv4 subnet, from the top down, that
+// holds neither the host nor the gateway. Containers given addresses from it
+// are less likely to collide with a router's DHCP pool, which usually sits low.
+func suggestIPRange(subnet *net.IPNet, avoid ...net.IP) string {
+	ones, bits := subnet.Mask.Size()
+	base := subnet.IP.To4()
+	if base == nil || bits != 32 || ones > 28 {
+		return ""
+	}
+	size := uint32(1) << (32 - ones - 2)
+	start := binary.BigEndian.Uint32(base)
+	for q := uint32(3); q >= 1; q-- {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, start+q*size)
+		block := &net.IPNet{IP: ip, Mask: net.CIDRMask(ones+2, 32)}
+		clear := true
+		for _, a := range avoid {
+			if a != nil && block.Contains(a) {
+				clear = false
+			}
+		}
+		if clear {
+			return block.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,222 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	zramGeneratorPath   = "/usr/lib/systemd/system-generators/zram-generator"
+	zramGeneratorConfig = "/etc/systemd/zram-generator.conf"
+	zramSetupUnit       = "systemd-zram-setup@zram0.service"
+)
+
+// zramActiveAlgorithm matches the bracketed active entry in comp_algorithm
+var zramActiveAlgorithm = regexp.MustCompile(`\[(\S+)\]`)
+
+// ZRAMDevice is an active compressed swap device
+type ZRAMDevice struct {
+	Name           string `json:"name"`
+	DiskSize       int64  `json:"disk_size"`
+	Algorithm      string `json:"algorithm"`
+	OrigDataSize   int64  `json:"orig_data_size"`  // Uncompressed bytes stored
+	ComprDataSize  int64  `json:"compr_data_size"` // Bytes after compression
+	MemUsedTotal   int64  `json:"mem_used_total"`  // RAM used including overhead
+	SwapUsed       int64  `json:"swap_used"`
+	SwapPriority   int    `json:"swap_priority"`
+	CompressionPct int    `json:"compression_pct,omitempty"`
+}
+
+// ZRAMStatus reports zram-generator availability and active zram swap devices
+type ZRAMStatus struct {
+	Available  bool         `json:"available"` // zram-generator is installed
+	Configured bool         `json:"configured"`
+	Devices    []ZRAMDevice `json:"devices"`
+}
+
+// GetZRAMStatus reads active zram swap devices from /proc/swaps and sysfs
+func GetZRAMStatus() ZRAMStatus {
+	status := ZRAMStatus{Devices: make([]ZRAMDevice, 0)}
+	if _, err := os.Stat(zramGeneratorPath); err == nil {
+		status.Available = true
+	}
+	if data, err := os.ReadFile(zramGeneratorConfig); err == nil && strings.Contains(string(data), "[zram0]") {
+		status.Configured = true
+	}
+
+	data, err := os.ReadFile("/proc/swaps")
+	if err != nil {
+		return status
+	}
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "/dev/zram") {
+			continue
+		}
+		name := filepath.Base(fields[0])
+		dev := ZRAMDevice{Name: name}
+		used, _ := strconv.ParseInt(fields[3], 10, 64)
+		dev.SwapUsed = used * 1024
+		dev.SwapPriority, _ = strconv.Atoi(fields[4])
+
+		sysfs := filepath.Join("/sys/block", name)
+		if v, err := readUintFile(filepath.Join(sysfs, "disksize")); err == nil {
+			dev.DiskSize = int64(v)
+		}
+		// comp_algorithm lists all algorithms with the active one in brackets
+		if algs, err := os.ReadFile(filepath.Join(sysfs, "comp_algorithm")); err == nil {
+			if m := zramActiveAlgorithm.FindStringSubmatch(string(algs)); m != nil {
+				dev.Algorithm = m[1]
+			}
+		}
+		// mm_stat: orig_data_size compr_data_size mem_used_total ...
+		if mm, err := os.ReadFile(filepath.Join(sysfs, "mm_stat")); err == nil {
+			stats := strings.Fields(string(mm))
+			if len(stats) >= 3 {
+				dev.OrigDataSize, _ = strconv.ParseInt(stats[0], 10, 64)
+				dev.ComprDataSize, _ = strconv.ParseInt(stats[1], 10, 64)
+				dev.MemUsedTotal, _ = strconv.ParseInt(stats[2], 10, 64)
+			}
+		}
+		if dev.OrigDataSize > 0 {
+			dev.CompressionPct = int(dev.ComprDataSize * 100 / dev.OrigDataSize)
+		}
+		status.Devices = append(status.Devices, dev)
+	}
+	return status
+}
+
+// ConfigureZRAM writes the zram-generator configuration and applies it. When
+// disabled an empty config masks the distribution default.
+func ConfigureZRAM(enabled bool, sizePercent, maxSizeMB int, algorithm string) error {
+	if _, err := os.Stat(zramGeneratorPath); err != nil {
+		return fmt.Errorf("zram-generator is not installed")
+	}
+
+	content := "# Managed by Stardeck - zram swap disabled\n"
+	if enabled {
+		content = fmt.Sprintf(`# Managed by Stardeck
+[zram0]
+zram-size = min(ram * %d / 100, %d)
+compression-algorithm = %s
+swap-priority = 100
+`, sizePercent, maxSizeMB, algorithm)
+	}
+	if err := os.WriteFile(zramGeneratorConfig, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", zramGeneratorConfig, err)
+	}
+
+	if output, err := exec.Command("systemctl", "daemon-reload").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reload systemd: %s - %s", err, string(output))
+	}
+	action := "stop"
+	if enabled {
+		action = "restart"
+	}
+	if output, err := exec.Command("systemctl", action, zramSetupUnit).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to %s %s: %s - %s", action, zramSetupUnit, err, string(output))
+	}
+	return nil
+}
+
+// MemoryPressure returns available memory and free swap as percentages of
+// their totals. swapFreePct is 0 on hosts without swap.
+func MemoryPressure() (memAvailPct, swapFreePct float64, err error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	values := make(map[string]float64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 {
+			v, _ := strconv.ParseFloat(fields[1], 64)
+			values[strings.TrimSuffix(fields[0], ":")] = v
+		}
+	}
+	if values["MemTotal"] == 0 {
+		return 0, 0, fmt.Errorf("MemTotal missing from /proc/meminfo")
+	}
+	memAvailPct = values["MemAvailable"] * 100 / values["MemTotal"]
+	if values["SwapTotal"] > 0 {
+		swapFreePct = values["SwapFree"] * 100 / values["SwapTotal"]
+	}
+	return memAvailPct, swapFreePct, nil
+}
+
+// OOMCandidate is a process the memory guard may kill
+type OOMCandidate struct {
+	PID      int    `json:"pid"`
+	Name     string `json:"name"`
+	OOMScore int    `json:"oom_score"`
+	RSS      int64  `json:"rss"`
+}
+
+// SelectOOMVictim picks the process with the highest kernel oom_score,
+// skipping protected PIDs, names matching avoid, and processes the kernel
+// itself would never kill
+func SelectOOMVictim(protected map[int]bool, avoid *regexp.Regexp) (*OOMCandidate, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var victim *OOMCandidate
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid <= 1 || protected[pid] {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+		score, err := readUintFile(filepath.Join(dir, "oom_score"))
+		if err != nil || score == 0 {
+			continue
+		}
+		if victim != nil && int(score) <= victim.OOMScore {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(comm))
+		if avoid != nil && avoid.MatchString(name) {
+			continue
+		}
+		candidate := &OOMCandidate{PID: pid, Name: name, OOMScore: int(score)}
+		if statm, err := os.ReadFile(filepath.Join(dir, "statm")); err == nil {
+			if fields := strings.Fields(string(statm)); len(fields) >= 2 {
+				pages, _ := strconv.ParseInt(fields[1], 10, 64)
+				candidate.RSS = pages * int64(os.Getpagesize())
+			}
+		}
+		victim = candidate
+	}
+	if victim == nil {
+		return nil, fmt.Errorf("no process eligible to kill")
+	}
+	return victim, nil
+}
+
+// SetOOMScoreAdj sets a process's oom_score_adj; -1000 exempts it from the kernel OOM killer
+func SetOOMScoreAdj(pid, adj int) error {
+	return os.WriteFile(fmt.Sprintf("/proc/%d/oom_score_adj", pid), []byte(strconv.Itoa(adj)), 0644)
+}
+
+// OOMScoreAdj reads a process's oom_score_adj
+func OOMScoreAdj(pid int) (int, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/oom_score_adj", pid))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,353 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 1
+	mqttConnAck    = 2
+	mqttPublish    = 3
+	mqttPubAck     = 4
+	mqttSubscribe  = 8
+	mqttSubAck     = 9
+	mqttPingReq    = 12
+	mqttPingResp   = 13
+	mqttDisconnect = 14
+)
+
+const (
+	mqttMaxPacket   = 1 << 20
+	mqttDialTimeout = 10 * time.Second
+)
+
+// mqttConnAckErrors are the broker's reasons for refusing a connection
+var mqttConnAckErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client ID rejected",
+	3: "broker unavailable",
+	4: "bad username or password",
+	5: "not authorized",
+}
+
+// MQTTMessage is a message published to or received from a broker
+type MQTTMessage struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// MQTTConfig describes a broker connection
+type MQTTConfig struct {
+	BrokerURL string // mqtt://, tcp://, mqtts:// or ssl://
+	Username  string
+	Password  string
+	ClientID  string
+	KeepAlive time.Duration
+	Will      *MQTTMessage // Published by the broker if the connection drops
+}
+
+// MQTTClient is a minimal MQTT 3.1.1 client. It publishes and subscribes at
+// QoS 0, which suits state that is republished periodically.
+type MQTTClient struct {
+	conn      net.Conn
+	writeMu   sync.Mutex
+	nextID    uint16
+	keepAlive time.Duration
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// ValidateMQTTBrokerURL checks a broker URL and returns its address and whether it uses TLS
+func ValidateMQTTBrokerURL(broker string) (string, bool, error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("broker URL must look like mqtt://host:1883")
+	}
+	var useTLS bool
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTLS = true
+		port = "8883"
+	default:
+		return "", false, fmt.Errorf("broker URL scheme must be mqtt or mqtts")
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+// DialMQTT connects and logs in to a broker. onMessage is called from the
+// client's read loop for every message on a subscribed topic.
+func DialMQTT(ctx context.Context, cfg MQTTConfig, onMessage func(MQTTMessage)) (*MQTTClient, error) {
+	addr, useTLS, err := ValidateMQTTBrokerURL(cfg.BrokerURL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = 60 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: mqttDialTimeout}
+	var conn net.Conn
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to broker: %w", err)
+	}
+
+	c := &MQTTClient{conn: conn, nextID: 1, keepAlive: cfg.KeepAlive, done: make(chan struct{})}
+	conn.SetDeadline(time.Now().Add(mqttDialTimeout))
+	if err := c.writePacket(mqttConnect<<4, connectPacket(cfg)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	header, body, err := readMQTTPacket(reader)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no reply from broker: %w", err)
+	}
+	if header>>4 != mqttConnAck || len(body) < 2 {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected reply from broker")
+	}
+	if body[1] != 0 {
+		conn.Close()
+		reason := mqttConnAckErrors[body[1]]
+		if reason == "" {
+			reason = fmt.Sprintf("code %d", body[1])
+		}
+		return nil, fmt.Errorf("broker refused connection: %s", reason)
+	}
+	conn.SetDeadline(time.Time{})
+
+	go c.readLoop(reader, onMessage)
+	go c.pingLoop()
+	return c, nil
+}
+
+// connectPacket builds a CONNECT packet's variable header and payload
+func connectPacket(cfg MQTTConfig) []byte {
+	flags := byte(0x02) // Clean session
+	if cfg.Will != nil {
+		flags |= 0x04
+		if cfg.Will.Retain {
+			flags |= 0x20
+		}
+	}
+	if cfg.Username != "" {
+		flags |= 0x80
+		if cfg.Password != "" {
+			flags |= 0x40
+		}
+	}
+
+	var b []byte
+	b = appendMQTTString(b, "MQTT")
+	b = append(b, 4, flags)
+	b = binary.BigEndian.AppendUint16(b, uint16(cfg.KeepAlive/time.Second))
+	b = appendMQTTString(b, cfg.ClientID)
+	if cfg.Will != nil {
+		b = appendMQTTString(b, cfg.Will.Topic)
+		b = appendMQTTString(b, string(cfg.Will.Payload))
+	}
+	if cfg.Username != "" {
+		b = appendMQTTString(b, cfg.Username)
+		if cfg.Password != "" {
+			b = appendMQTTString(b, cfg.Password)
+		}
+	}
+	return b
+}
+
+// Publish sends a message at QoS 0
+func (c *MQTTClient) Publish(topic string, payload []byte, retain bool) error {
+	header := byte(mqttPublish << 4)
+	if retain {
+		header |= 0x01
+	}
+	body := appendMQTTString(nil, topic)
+	body = append(body, payload...)
+	return c.writePacket(header, body)
+}
+
+// Subscribe asks the broker for messages on topic filters at QoS 0
+func (c *MQTTClient) Subscribe(filters ...string) error {
+	c.writeMu.Lock()
+	id := c.nextID
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+	c.writeMu.Unlock()
+
+	body := binary.BigEndian.AppendUint16(nil, id)
+	for _, filter := range filters {
+		body = appendMQTTString(body, filter)
+		body = append(body, 0)
+	}
+	return c.writePacket(mqttSubscribe<<4|0x02, body)
+}
+
+// Done is closed when the connection ends
+func (c *MQTTClient) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended
+func (c *MQTTClient) Err() error {
+	<-c.done
+	return c.err
+}
+
+// Close disconnects cleanly; the broker discards the will message
+func (c *MQTTClient) Close() error {
+	c.writePacket(mqttDisconnect<<4, nil)
+	c.fail(errors.New("connection closed"))
+	return nil
+}
+
+func (c *MQTTClient) fail(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+func (c *MQTTClient) writePacket(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = appendMQTTLength(packet, len(body))
+	packet = append(packet, body...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(mqttDialTimeout))
+	if _, err := c.conn.Write(packet); err != nil {
+		c.fail(err)
+		return fmt.Errorf("failed to write to broker: %w", err)
+	}
+	return nil
+}
+
+func (c *MQTTClient) pingLoop() {
+	ticker := time.NewTicker(c.keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.writePacket(mqttPingReq<<4, nil)
+		}
+	}
+}
+
+func (c *MQTTClient) readLoop(reader *bufio.Reader, onMessage func(MQTTMessage)) {
+	for {
+		// The broker answers pings, so silence for longer than the keep-alive means it's gone
+		c.conn.SetReadDeadline(time.Now().Add(c.keepAlive * 3 / 2))
+		header, body, err := readMQTTPacket(reader)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		if header>>4 != mqttPublish {
+			continue
+		}
+
+		qos := (header >> 1) & 0x03
+		if len(body) < 2 {
+			continue
+		}
+		topicLen := int(binary.BigEndian.Uint16(body))
+		if len(body) < 2+topicLen {
+			continue
+		}
+		msg := MQTTMessage{Topic: string(body[2 : 2+topicLen]), Retain: header&0x01 != 0}
+		rest := body[2+topicLen:]
+		if qos > 0 {
+			if len(rest) < 2 {
+				continue
+			}
+			if qos == 1 {
+				c.writePacket(mqttPubAck<<4, rest[:2])
+			}
+			rest = rest[2:]
+		}
+		msg.Payload = rest
+		if onMessage != nil {
+			onMessage(msg)
+		}
+	}
+}
+
+// readMQTTPacket reads one control packet, returning its first header byte and body
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, fmt.Errorf("malformed packet length")
+		}
+		multiplier *= 128
+	}
+	if length > mqttMaxPacket {
+		return 0, nil, fmt.Errorf("packet too large (%d bytes)", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func appendMQTTLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	// maxLANScanHosts bounds a sweep to a /22
+	maxLANScanHosts = 1022
+	// lanScanWorkers is how many pings run at once
+	lanScanWorkers = 64
+)
+
+// ouiDatabases are where distributions install the IEEE vendor registry
+var ouiDatabases = []string{
+	"/usr/share/hwdata/oui.txt",
+	"/usr/share/ieee-data/oui.txt",
+	"/usr/share/misc/oui.txt",
+	"/usr/share/nmap/nmap-mac-prefixes",
+}
+
+var (
+	ouiOnce    sync.Once
+	ouiVendors map[string]string // Upper-case hex prefix without separators -> vendor
+)
+
+// GetNeighbors returns the resolved entries of the host's neighbor table
+func GetNeighbors() ([]models.Neighbor, error) {
+	output, err := exec.Command("ip", "-j", "neigh", "show").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read neighbor table: %w", err)
+	}
+	var entries []struct {
+		Dst    string   `json:"dst"`
+		Dev    string   `json:"dev"`
+		LLAddr string   `json:"lladdr"`
+		State  []string `json:"state"`
+	}
+	if err := json.Unmarshal(output, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse neighbor table: %w", err)
+	}
+
+	neighbors := []models.Neighbor{}
+	for _, e := range entries {
+		if e.LLAddr == "" {
+			continue
+		}
+		state := strings.Join(e.State, ",")
+		if state == "FAILED" || state == "INCOMPLETE" || state == "NOARP" {
+			continue
+		}
+		neighbors = append(neighbors, models.Neighbor{
+			IPAddress: e.Dst,
+			MAC:       strings.ToLower(e.LLAddr),
+			Interface: e.Dev,
+			State:     state,
+			Vendor:    MACVendor(e.LLAddr),
+		})
+	}
+	return neighbors, nil
+}
+
+// loadOUIVendors reads the first vendor registry that is installed. Both the
+// IEEE "XX-XX-XX (hex) Vendor" format and nmap's "XXXXXX Vendor" are read.
+func loadOUIVendors() {
+	ouiVendors = make(map[string]string)
+	for _, path := range ouiDatabases {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if prefix, vendor, ok := strings.Cut(line, "(hex)"); ok {
+				ouiVendors[strings.ReplaceAll(strings.TrimSpace(prefix), "-", "")] = strings.TrimSpace(vendor)
+				continue
+			}
+			fields := strings.SplitN(line, " ", 2)
+			if len(fields) == 2 && len(fields[0]) == 6 && !strings.HasPrefix(line, "#") {
+				ouiVendors[strings.ToUpper(fields[0])] = strings.TrimSpace(fields[1])
+			}
+		}
+		f.Close()
+		if len(ouiVendors) > 0 {
+			return
+		}
+	}
+}
+
+// MACVendor looks up the manufacturer a MAC address is registered to.
+// Locally administered addresses, such as randomized ones, have no vendor.
+func MACVendor(mac string) string {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) < 3 || hw[0]&0x02 != 0 {
+		return ""
+	}
+	ouiOnce.Do(loadOUIVendors)
+	return ouiVendors[fmt.Sprintf("%02X%02X%02X", hw[0], hw[1], hw[2])]
+}
+
+// ReverseLookup returns the first PTR name of an address, or an empty string
+func ReverseLookup(ctx context.Context, ip string) string {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(names[0], ".")
+}
+
+// InterfaceSubnet returns the first IPv4 network of an interface
+func InterfaceSubnet(name string) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", fmt.Errorf("interface %s not found", name)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("failed to read addresses of %s: %w", name, err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return (&net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}).String(), nil
+		}
+	}
+	return "", fmt.Errorf("interface %s has no IPv4 address", name)
+}
+
+// PingSweep pings every host address of an IPv4 subnet once so that the
+// devices on it land in the neighbor table. It returns how many addresses
+// were probed and how many answered.
+func PingSweep(ctx context.Context, cidr string) (int, int, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil || network.IP.To4() == nil {
+		return 0, 0, fmt.Errorf("%s is not an IPv4 subnet", cidr)
+	}
+	ones, bits := network.Mask.Size()
+	hosts := (1 << (bits - ones)) - 2
+	if ones >= 31 {
+		hosts = 1 << (bits - ones)
+	}
+	if hosts > maxLANScanHosts {
+		return 0, 0, fmt.Errorf("subnets larger than /22 can't be scanned")
+	}
+
+	first := binary.BigEndian.Uint32(network.IP.To4())
+	if ones < 31 {
+		first++
+	}
+	addresses := make(chan string)
+	go func() {
+		defer close(addresses)
+		for i := 0; i < hosts; i++ {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, first+uint32(i))
+			select {
+			case addresses <- ip.String():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	responded := 0
+	for w := 0; w < lanScanWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range addresses {
+				if exec.CommandContext(ctx, "ping", "-c", "1", "-W", "1", "-n", "-q", ip).Run() == nil {
+					mu.Lock()
+					responded++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return hosts, responded, ctx.Err()
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // NetworkInterface represents a network interface
@@ -23,6 +24,7 @@ type NetworkInterface struct {
 	IPv6       []string `json:"ipv6"`
 	Driver     string   `json:"driver"`
 	IsPhysical bool     `json:"is_physical"`
+	Master     string   `json:"master,omitempty"` // Bridge or bond the interface is enslaved to
 }
 
 // InterfaceStats represents traffic statistics for an interface
@@ -174,6 +176,11 @@ func GetNetworkInterfaces() ([]NetworkInterface, error) {
 			iface.Driver = filepath.Base(link)
 		}
 
+		// Get the bridge or bond the interface belongs to
+		if link, err := os.Readlink(filepath.Join(basePath, "master")); err == nil {
+			iface.Master = filepath.Base(link)
+		}
+
 		// Get IP addresses using net package
 		if netIface, err := net.InterfaceByName(name); err == nil {
 			if addrs, err := netIface.Addrs(); err == nil {
@@ -790,6 +797,19 @@ func AddFirewallPort(zone string, port int, protocol string, permanent bool) err
 	return nil
 }
 
+// AddFirewallPortTimeout opens a port in a zone's runtime config; firewalld removes it after the timeout
+func AddFirewallPortTimeout(zone string, port int, protocol string, timeout time.Duration) error {
+	portSpec := fmt.Sprintf("%d/%s", port, protocol)
+	args := []string{"--zone=" + zone, "--add-port=" + portSpec, fmt.Sprintf("--timeout=%ds", int(timeout.Seconds()))}
+
+	cmd := exec.Command("firewall-cmd", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to add port: %s", string(output))
+	}
+	return nil
+}
+
 // RemoveFirewallPort removes a port from a zone
 func RemoveFirewallPort(zone string, port int, protocol string, permanent bool) error {
 	portSpec := fmt.Sprintf("%d/%s", port, protocol)
@@ -0,0 +1,242 @@
+package system
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// ChronyServerConfigPath holds the directives Stardeck manages, included
+// from chrony.conf
+const ChronyServerConfigPath = "/etc/chrony.d/stardeck-server.conf"
+
+// chronyConfigPaths are where distributions keep chrony.conf
+var chronyConfigPaths = []string{"/etc/chrony.conf", "/etc/chrony/chrony.conf"}
+
+// chronySourceStates maps chronyc's source state symbols
+var chronySourceStates = map[string]string{
+	"*": "selected", "+": "combined", "-": "not_combined",
+	"?": "unreachable", "x": "falseticker", "~": "variable",
+}
+
+// chronySourceModes maps chronyc's source mode symbols
+var chronySourceModes = map[string]string{"^": "server", "=": "peer", "#": "refclock"}
+
+// chronyService returns chrony's systemd unit, which Debian names differently
+func chronyService() string {
+	output, _ := exec.Command("systemctl", "show", "-p", "LoadState", "--value", "chrony").Output()
+	if strings.TrimSpace(string(output)) == "loaded" {
+		return "chrony"
+	}
+	return "chronyd"
+}
+
+// ChronyInstalled reports whether chrony is available
+func ChronyInstalled() bool {
+	_, err := exec.LookPath("chronyc")
+	return err == nil
+}
+
+// chronyc runs a chronyc report in CSV mode
+func chronyc(args ...string) ([][]string, error) {
+	output, err := exec.Command("chronyc", append([]string{"-c", "-n"}, args...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("chronyc %s failed: %w", args[0], err)
+	}
+	reader := csv.NewReader(strings.NewReader(string(output)))
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
+
+func atoiField(records []string, i int) int {
+	if i >= len(records) {
+		return 0
+	}
+	v, _ := strconv.Atoi(records[i])
+	return v
+}
+
+func floatField(records []string, i int) float64 {
+	if i >= len(records) {
+		return 0
+	}
+	v, _ := strconv.ParseFloat(records[i], 64)
+	return v
+}
+
+// GetNTPStatus reports chrony's synchronization state, its sources and the
+// clients it has served
+func GetNTPStatus() models.NTPStatus {
+	status := models.NTPStatus{
+		Sources: []models.NTPSource{},
+		Clients: []models.NTPClient{},
+	}
+	if !ChronyInstalled() {
+		return status
+	}
+	status.Installed = true
+	status.Running = exec.Command("systemctl", "is-active", "--quiet", chronyService()).Run() == nil
+	if !status.Running {
+		return status
+	}
+
+	if rows, err := chronyc("tracking"); err == nil && len(rows) > 0 && len(rows[0]) >= 14 {
+		r := rows[0]
+		secs, frac := math.Modf(floatField(r, 3))
+		status.Tracking = &models.NTPTracking{
+			ReferenceID:    r[0],
+			ReferenceName:  r[1],
+			Stratum:        atoiField(r, 2),
+			ReferenceTime:  time.Unix(int64(secs), int64(frac*1e9)),
+			SystemOffset:   floatField(r, 4),
+			RMSOffset:      floatField(r, 6),
+			Frequency:      floatField(r, 7),
+			RootDelay:      floatField(r, 10),
+			RootDispersion: floatField(r, 11),
+			LeapStatus:     r[13],
+		}
+	}
+
+	if rows, err := chronyc("sources"); err == nil {
+		for _, r := range rows {
+			if len(r) < 10 {
+				continue
+			}
+			status.Sources = append(status.Sources, models.NTPSource{
+				Mode:    chronySourceModes[r[0]],
+				State:   chronySourceStates[r[1]],
+				Address: r[2],
+				Stratum: atoiField(r, 3),
+				Poll:    atoiField(r, 4),
+				Reach:   r[5],
+				LastRx:  atoiField(r, 6),
+				Offset:  floatField(r, 7),
+				Error:   floatField(r, 9),
+			})
+		}
+	}
+
+	// Columns: address, NTP packets, dropped, interval, interleaved interval,
+	// last received, then the same for command packets
+	if rows, err := chronyc("clients"); err == nil {
+		for _, r := range rows {
+			if len(r) < 6 || atoiField(r, 1) == 0 {
+				continue
+			}
+			status.Clients = append(status.Clients, models.NTPClient{
+				Address: r[0],
+				Packets: atoiField(r, 1),
+				Dropped: atoiField(r, 2),
+				LastRx:  atoiField(r, 5),
+			})
+		}
+	}
+	return status
+}
+
+// ValidateNTPServerSettings normalizes and checks the NTP server settings
+func ValidateNTPServerSettings(s *models.NTPServerSettings) error {
+	allow := make([]string, 0, len(s.Allow))
+	for _, entry := range s.Allow {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			continue
+		case entry == "all":
+		case net.ParseIP(entry) != nil:
+		default:
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return fmt.Errorf("'%s' is not an address, a CIDR or 'all'", entry)
+			}
+			entry = network.String()
+		}
+		allow = append(allow, entry)
+	}
+	s.Allow = allow
+	if s.Enabled && len(s.Allow) == 0 {
+		return fmt.Errorf("allow at least one client network")
+	}
+	if s.LocalStratum < 0 || s.LocalStratum > 15 {
+		return fmt.Errorf("local_stratum must be between 0 and 15")
+	}
+	return nil
+}
+
+// includeChronyServerConfig adds an include for Stardeck's directives to chrony.conf
+func includeChronyServerConfig() error {
+	for _, path := range chronyConfigPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		include := "include " + ChronyServerConfigPath
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) == include {
+				return nil
+			}
+		}
+		content := strings.TrimRight(string(data), "\n") + "\n\n# NTP server settings managed by Stardeck\n" + include + "\n"
+		return os.WriteFile(path, []byte(content), 0644)
+	}
+	return fmt.Errorf("chrony.conf not found")
+}
+
+// ApplyNTPServerSettings writes the allow directives and restarts chrony,
+// opening or closing the firewall's ntp service to match
+func ApplyNTPServerSettings(s models.NTPServerSettings) error {
+	if !ChronyInstalled() {
+		return fmt.Errorf("chrony is not installed")
+	}
+
+	var b strings.Builder
+	b.WriteString("# Managed by Stardeck; changes are overwritten\n")
+	if s.Enabled {
+		for _, entry := range s.Allow {
+			if entry == "all" {
+				b.WriteString("allow all\n")
+			} else {
+				fmt.Fprintf(&b, "allow %s\n", entry)
+			}
+		}
+		if s.LocalStratum > 0 {
+			fmt.Fprintf(&b, "local stratum %d orphan\n", s.LocalStratum)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ChronyServerConfigPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(ChronyServerConfigPath), err)
+	}
+	if err := os.WriteFile(ChronyServerConfigPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ChronyServerConfigPath, err)
+	}
+	if err := includeChronyServerConfig(); err != nil {
+		return err
+	}
+	if output, err := exec.Command("systemctl", "restart", chronyService()).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart chrony: %s", strings.TrimSpace(string(output)))
+	}
+
+	if exec.Command("firewall-cmd", "--state").Run() == nil {
+		if output, err := exec.Command("firewall-cmd", "--get-default-zone").Output(); err == nil {
+			zone := strings.TrimSpace(string(output))
+			for _, permanent := range []bool{false, true} {
+				if s.Enabled {
+					AddFirewallService(zone, "ntp", permanent)
+				} else {
+					RemoveFirewallService(zone, "ntp", permanent)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// maxPermissionErrors caps the errors kept from a recursive permission fix
+const maxPermissionErrors = 20
+
+// PermissionFix describes the ownership to give a directory tree
+type PermissionFix struct {
+	UID       int
+	GID       int
+	Mode      os.FileMode // Directory mode; files get it without the execute and special bits
+	KeepMode  bool        // Leave modes alone apart from giving the owner read and write access
+	Recursive bool
+	DryRun    bool // Count what would change without changing it
+}
+
+// FixPermissions sets the owner and mode of a path, and of everything under it
+// when recursive. Symlinks are re-owned but never followed. It returns how many
+// entries changed, or would change on a dry run, and the first errors met.
+func FixPermissions(root string, fix PermissionFix) (int, []string) {
+	fileMode := fix.Mode.Perm() &^ 0111
+	changed := 0
+	var errs []string
+	fail := func(path string, err error) {
+		if len(errs) < maxPermissionErrors {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+	}
+
+	apply := func(path string, info fs.FileInfo) {
+		current := info.Mode() & (os.ModePerm | os.ModeSetgid | os.ModeSticky)
+		mode := fileMode
+		switch {
+		case fix.KeepMode && info.IsDir():
+			mode = current | 0700
+		case fix.KeepMode:
+			mode = current | 0600
+		case info.IsDir():
+			mode = fix.Mode
+		}
+
+		chown := false
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && (int(st.Uid) != fix.UID || int(st.Gid) != fix.GID) {
+			chown = true
+		}
+		// chown clears setgid on some filesystems, so the mode is reapplied after it
+		chmod := info.Mode()&os.ModeSymlink == 0 && (current != mode || chown)
+		if !chown && !chmod {
+			return
+		}
+		if fix.DryRun {
+			changed++
+			return
+		}
+		if chown {
+			if err := os.Lchown(path, fix.UID, fix.GID); err != nil {
+				fail(path, err)
+				return
+			}
+		}
+		if chmod {
+			if err := os.Chmod(path, mode); err != nil {
+				fail(path, err)
+				return
+			}
+		}
+		changed++
+	}
+
+	info, err := os.Lstat(root)
+	if err != nil {
+		return 0, []string{err.Error()}
+	}
+	if !fix.Recursive || !info.IsDir() {
+		apply(root, info)
+		return changed, errs
+	}
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fail(path, err)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			fail(path, err)
+			return nil
+		}
+		apply(path, info)
+		return nil
+	})
+	return changed, errs
+}
+
+// FileOwner returns the owning user and group of a file
+func FileOwner(info fs.FileInfo) (int, int) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(st.Uid), int(st.Gid)
+	}
+	return -1, -1
+}
+
+// PathAccess reports whether a user can read and write a file or directory
+// according to its owner and mode bits. Directories also need the execute bit.
+// Supplementary groups and ACLs are not considered.
+func PathAccess(info fs.FileInfo, uid, gid int) (read, write bool) {
+	if uid == 0 {
+		return true, true
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true, true
+	}
+	perm := info.Mode().Perm()
+	shift := uint(0) // Other
+	switch {
+	case int(st.Uid) == uid:
+		shift = 6
+	case int(st.Gid) == gid:
+		shift = 3
+	}
+	bits := (perm >> shift) & 07
+	if info.IsDir() && bits&01 == 0 {
+		return false, false
+	}
+	return bits&04 != 0, bits&02 != 0
+}
+
+// MapContainerID translates a user or group ID inside a running container to
+// the host ID it acts as, using the user namespace of the process with pid.
+// It returns false when the ID isn't mapped.
+func MapContainerID(pid, id int, group bool) (int, bool, error) {
+	file := "uid_map"
+	if group {
+		file = "gid_map"
+	}
+	f, err := os.Open(fmt.Sprintf("/proc/%d/%s", pid, file))
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		inside, err1 := strconv.Atoi(fields[0])
+		outside, err2 := strconv.Atoi(fields[1])
+		count, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		if id >= inside && id < inside+count {
+			return outside + id - inside, true, nil
+		}
+	}
+	return 0, false, scanner.Err()
+}
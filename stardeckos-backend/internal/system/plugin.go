@@ -0,0 +1,340 @@
+package system
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// Plugins are sidecar processes. Stardeck runs a plugin's command from its
+// directory with these environment variables:
+//
+//	STARDECK_PLUGIN_NAME    the plugin's name
+//	STARDECK_PLUGIN_SOCKET  the unix socket to serve HTTP on
+//	STARDECK_PLUGIN_DATA    a directory the plugin may keep state in
+//
+// and talks to it over the socket:
+//
+//	GET  /stardeck/capabilities     registers routes, widgets, job types and channels
+//	POST /stardeck/jobs/<type>      runs a scheduled job, body models.PluginJobRun
+//	POST /stardeck/channels/<type>  delivers a notification, body models.PluginChannelDelivery
+//	*    /api/...                   the plugin's own API routes
+//
+// Job runs succeed on any 2xx response; the response body is kept as the
+// run's output.
+
+// PluginManifestFile is the manifest's name at the root of a plugin
+const PluginManifestFile = "plugin.json"
+
+const (
+	// pluginStartTimeout bounds how long a new process has to answer on its socket
+	pluginStartTimeout = 15 * time.Second
+
+	// pluginStopTimeout is how long a plugin gets to exit after SIGTERM
+	pluginStopTimeout = 5 * time.Second
+
+	// maxPluginArchiveSize limits the unpacked size of a plugin archive
+	maxPluginArchiveSize = 512 << 20
+)
+
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidPluginName reports whether a name is safe to use for a plugin's
+// directory and socket
+func ValidPluginName(name string) bool {
+	return pluginNamePattern.MatchString(name)
+}
+
+// ReadPluginManifest loads and checks the manifest in a plugin directory
+func ReadPluginManifest(dir string) (*models.PluginManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, PluginManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", PluginManifestFile, err)
+	}
+	var m models.PluginManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PluginManifestFile, err)
+	}
+	if !ValidPluginName(m.Name) {
+		return nil, fmt.Errorf("invalid plugin name %q: use lowercase letters, digits and dashes", m.Name)
+	}
+	if len(m.Command) == 0 || m.Command[0] == "" {
+		return nil, fmt.Errorf("%s has no command", PluginManifestFile)
+	}
+	return &m, nil
+}
+
+// ExtractPluginArchive unpacks a .tar.gz plugin archive into dir, which must
+// not exist yet, and returns its manifest. The manifest may sit at the root of
+// the archive or inside a single top-level directory.
+func ExtractPluginArchive(r io.Reader, dir string) (*models.PluginManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written int64
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: %w", err)
+		}
+
+		name := filepath.Clean(hdr.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("archive entry %q is outside the plugin directory", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			written += hdr.Size
+			if written > maxPluginArchiveSize {
+				return nil, fmt.Errorf("archive is larger than %d MB", maxPluginArchiveSize>>20)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644|os.FileMode(hdr.Mode)&0111)
+			if err != nil {
+				return nil, err
+			}
+			_, err = io.Copy(f, io.LimitReader(tr, hdr.Size))
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+		default:
+			// Links and devices could reach outside the directory
+			return nil, fmt.Errorf("archive entry %q is not a file or directory", hdr.Name)
+		}
+	}
+
+	// Unwrap a single top-level directory
+	if _, err := os.Stat(filepath.Join(dir, PluginManifestFile)); os.IsNotExist(err) {
+		entries, _ := os.ReadDir(dir)
+		if len(entries) == 1 && entries[0].IsDir() {
+			inner := filepath.Join(dir, entries[0].Name())
+			children, err := os.ReadDir(inner)
+			if err != nil {
+				return nil, err
+			}
+			for _, child := range children {
+				if err := os.Rename(filepath.Join(inner, child.Name()), filepath.Join(dir, child.Name())); err != nil {
+					return nil, err
+				}
+			}
+			os.Remove(inner)
+		}
+	}
+
+	return ReadPluginManifest(dir)
+}
+
+// PluginProcess is a running plugin
+type PluginProcess struct {
+	Socket string
+	cmd    *exec.Cmd
+	done   chan struct{}
+	client *http.Client
+	err    error
+}
+
+// StartPlugin runs a plugin's command from its directory, logging its output
+// to logPath, and waits until it answers on its socket
+func StartPlugin(name, dir string, command []string, socket, logPath string) (*PluginProcess, error) {
+	dataDir := filepath.Join(dir, "data")
+	if err := os.MkdirAll(dataDir, 0750); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(socket), 0750); err != nil {
+		return nil, err
+	}
+	os.Remove(socket)
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close()
+
+	executable := command[0]
+	if !filepath.IsAbs(executable) && strings.Contains(executable, "/") {
+		executable = filepath.Join(dir, executable)
+	}
+	cmd := exec.Command(executable, command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"STARDECK_PLUGIN_NAME="+name,
+		"STARDECK_PLUGIN_SOCKET="+socket,
+		"STARDECK_PLUGIN_DATA="+dataDir,
+	)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Own process group so the plugin and its children are stopped together,
+	// and no plugin outlives Stardeck
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGTERM}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+
+	p := &PluginProcess{
+		Socket: socket,
+		cmd:    cmd,
+		done:   make(chan struct{}),
+		client: &http.Client{Transport: pluginTransport(socket)},
+	}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginStartTimeout)
+	defer cancel()
+	for {
+		if _, err := os.Stat(socket); err == nil {
+			break
+		}
+		select {
+		case <-p.done:
+			return nil, fmt.Errorf("plugin exited during start: %v", p.err)
+		case <-ctx.Done():
+			p.Stop()
+			return nil, fmt.Errorf("plugin did not open %s within %s", socket, pluginStartTimeout)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return p, nil
+}
+
+// pluginTransport sends HTTP requests over a plugin's unix socket
+func pluginTransport(socket string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+		MaxIdleConns:    4,
+		IdleConnTimeout: 90 * time.Second,
+	}
+}
+
+// Transport returns the round tripper for proxying requests to the plugin
+func (p *PluginProcess) Transport() http.RoundTripper {
+	return p.client.Transport
+}
+
+// Done is closed when the process exits
+func (p *PluginProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// ExitError returns why the process exited, once Done is closed
+func (p *PluginProcess) ExitError() error {
+	return p.err
+}
+
+// Running reports whether the plugin process is still alive
+func (p *PluginProcess) Running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Stop asks the plugin to exit, killing it if it doesn't in time
+func (p *PluginProcess) Stop() {
+	if !p.Running() {
+		return
+	}
+	syscall.Kill(-p.cmd.Process.Pid, syscall.SIGTERM)
+	select {
+	case <-p.done:
+	case <-time.After(pluginStopTimeout):
+		syscall.Kill(-p.cmd.Process.Pid, syscall.SIGKILL)
+		<-p.done
+	}
+	os.Remove(p.Socket)
+}
+
+// Capabilities asks the plugin what it provides
+func (p *PluginProcess) Capabilities(ctx context.Context) (*models.PluginCapabilities, error) {
+	body, err := p.call(ctx, http.MethodGet, "/stardeck/capabilities", nil)
+	if err != nil {
+		return nil, err
+	}
+	var caps models.PluginCapabilities
+	if err := json.Unmarshal(body, &caps); err != nil {
+		return nil, fmt.Errorf("invalid capabilities: %w", err)
+	}
+	return &caps, nil
+}
+
+// Post sends a JSON body to one of the plugin's /stardeck endpoints and
+// returns the response body
+func (p *PluginProcess) Post(ctx context.Context, path string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return p.call(ctx, http.MethodPost, path, bytes.NewReader(data))
+}
+
+func (p *PluginProcess) call(ctx context.Context, method, path string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "http://plugin"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := strings.TrimSpace(string(data))
+		if msg == "" {
+			msg = resp.Status
+		}
+		return data, errors.New(msg)
+	}
+	return data, nil
+}
@@ -4,14 +4,18 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"stardeckos-backend/internal/models"
@@ -25,6 +29,13 @@ type PodmanService struct {
 	// targetUser is the user whose Podman we should query (for rootless mode)
 	// If empty and running as root, will use root's Podman
 	targetUser string
+
+	apiOnce   sync.Once
+	apiClient *podmanAPI
+
+	// compose is the compose engine, detected on first use
+	composeMu sync.Mutex
+	compose   *composeEngine
 }
 
 // NewPodmanService creates a new PodmanService
@@ -183,35 +194,68 @@ func (p *PodmanService) CheckPodman(ctx context.Context) (string, error) {
 	return version.Client.Version, nil
 }
 
-// podmanContainer represents the JSON output from podman ps
+// podmanContainer represents the JSON output from podman ps and the API's
+// container list
 type podmanContainer struct {
-	ID      string   `json:"Id"`
-	Names   []string `json:"Names"`
-	Image   string   `json:"Image"`
-	State   string   `json:"State"`
-	Status  string   `json:"Status"`
-	Created int64    `json:"Created"` // Unix timestamp
-	Ports   []struct {
+	ID        string   `json:"Id"`
+	Names     []string `json:"Names"`
+	Image     string   `json:"Image"`
+	State     string   `json:"State"`
+	Status    string   `json:"Status"` // Only the health from the API, see psStatus
+	StartedAt int64    `json:"StartedAt"`
+	ExitedAt  int64    `json:"ExitedAt"`
+	ExitCode  int      `json:"ExitCode"`
+	Ports     []struct {
 		HostIP        string `json:"host_ip"`
 		HostPort      int    `json:"host_port"`
 		ContainerPort int    `json:"container_port"`
 		Protocol      string `json:"protocol"`
 	} `json:"Ports"`
-	Labels map[string]string `json:"Labels"`
-	Mounts json.RawMessage    `json:"Mounts"` // Can be string or array, ignored in list
+	Labels   map[string]string `json:"Labels"`
+	Networks []string          `json:"Networks"`
+	Mounts   json.RawMessage   `json:"Mounts"` // Can be string or array, ignored in list
 }
 
-// ListContainers returns all containers (running and stopped)
-func (p *PodmanService) ListContainers(ctx context.Context) ([]models.ContainerListItem, error) {
-	output, err := p.podmanCmd(ctx, "ps", "-a", "--format", "json")
-	if err != nil {
+// psContainers lists all containers, optionally only those with a label
+// ("key" or "key=value"), from the API socket or podman ps
+func (p *PodmanService) psContainers(ctx context.Context, label string) ([]podmanContainer, error) {
+	var containers []podmanContainer
+
+	query := url.Values{"all": {"true"}}
+	if label != "" {
+		query.Set("filters", podmanFilters(map[string][]string{"label": {label}}))
+	}
+	err := p.api().getJSON(ctx, "/containers/json", query, &containers)
+	if err == nil {
+		for i := range containers {
+			containers[i].Status = psStatus(&containers[i])
+		}
+		return containers, nil
+	}
+	if !errors.Is(err, errPodmanAPIUnavailable) {
 		return nil, err
 	}
 
-	var containers []podmanContainer
+	args := []string{"ps", "-a", "--format", "json"}
+	if label != "" {
+		args = append(args, "--filter", "label="+label)
+	}
+	output, err := p.podmanCmd(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
 	if err := json.Unmarshal(output, &containers); err != nil {
 		return nil, fmt.Errorf("failed to parse container list: %w", err)
 	}
+	return containers, nil
+}
+
+// ListContainers returns all containers (running and stopped)
+func (p *PodmanService) ListContainers(ctx context.Context) ([]models.ContainerListItem, error) {
+	containers, err := p.psContainers(ctx, "")
+	if err != nil {
+		return nil, err
+	}
 
 	result := make([]models.ContainerListItem, 0, len(containers))
 	for _, c := range containers {
@@ -233,7 +277,9 @@ func (p *PodmanService) ListContainers(ctx context.Context) ([]models.ContainerL
 		// Check for Stardeck labels
 		hasWebUI := false
 		icon := ""
+		stack := ""
 		if c.Labels != nil {
+			stack = c.Labels["com.docker.compose.project"]
 			if val, ok := c.Labels["stardeck.webui"]; ok && val == "true" {
 				hasWebUI = true
 			}
@@ -251,6 +297,8 @@ func (p *PodmanService) ListContainers(ctx context.Context) ([]models.ContainerL
 			Icon:        icon,
 			Ports:       ports,
 			Uptime:      c.Status,
+			Stack:       stack,
+			Health:      psHealth(c.Status),
 		})
 	}
 
@@ -313,9 +361,11 @@ func mapPodmanStatus(state string) models.ContainerStatus {
 
 // podmanInspect represents detailed container information
 type podmanInspect struct {
-	ID      string `json:"Id"`
-	Created string `json:"Created"`
-	Name    string `json:"Name"`
+	ID              string `json:"Id"`
+	Created         string `json:"Created"`
+	Name            string `json:"Name"`
+	AppArmorProfile string `json:"AppArmorProfile"`
+	ProcessLabel    string `json:"ProcessLabel"`
 	State   struct {
 		Status     string `json:"Status"`
 		Running    bool   `json:"Running"`
@@ -328,16 +378,29 @@ type podmanInspect struct {
 		Error      string `json:"Error"`
 		StartedAt  string `json:"StartedAt"`
 		FinishedAt string `json:"FinishedAt"`
+		Health     struct {
+			Status        string `json:"Status"`
+			FailingStreak int    `json:"FailingStreak"`
+			Log           []struct {
+				Start    string `json:"Start"`
+				End      string `json:"End"`
+				ExitCode int    `json:"ExitCode"`
+				Output   string `json:"Output"`
+			} `json:"Log"`
+		} `json:"Health"`
 	} `json:"State"`
 	Config struct {
-		Hostname   string            `json:"Hostname"`
-		User       string            `json:"User"`
-		Env        []string          `json:"Env"`
-		Cmd        []string          `json:"Cmd"`
-		Image      string            `json:"Image"`
-		WorkingDir string            `json:"WorkingDir"`
-		Entrypoint []string          `json:"Entrypoint"`
-		Labels     map[string]string `json:"Labels"`
+		Hostname      string            `json:"Hostname"`
+		User          string            `json:"User"`
+		Env           []string          `json:"Env"`
+		Cmd           []string          `json:"Cmd"`
+		Image         string            `json:"Image"`
+		WorkingDir    string            `json:"WorkingDir"`
+		Entrypoint    []string          `json:"Entrypoint"`
+		Labels        map[string]string `json:"Labels"`
+		Tty           bool              `json:"Tty"`
+		OpenStdin     bool              `json:"OpenStdin"`
+		CreateCommand []string          `json:"CreateCommand"`
 	} `json:"Config"`
 	HostConfig struct {
 		RestartPolicy struct {
@@ -352,24 +415,48 @@ type podmanInspect struct {
 		NetworkMode string   `json:"NetworkMode"`
 		Memory      int64    `json:"Memory"`
 		NanoCpus    int64    `json:"NanoCpus"`
+		Privileged     bool     `json:"Privileged"`
+		CapAdd         []string `json:"CapAdd"`
+		CapDrop        []string `json:"CapDrop"`
+		SecurityOpt    []string `json:"SecurityOpt"`
+		ReadonlyRootfs bool     `json:"ReadonlyRootfs"`
+		UsernsMode     string   `json:"UsernsMode"`
+		LogConfig      struct {
+			Type   string            `json:"Type"`
+			Config map[string]string `json:"Config"`
+			Path   string            `json:"Path"`
+			Size   string            `json:"Size"`
+		} `json:"LogConfig"`
 	} `json:"HostConfig"`
 	Mounts []struct {
 		Type        string `json:"Type"`
+		Name        string `json:"Name"`
 		Source      string `json:"Source"`
 		Destination string `json:"Destination"`
 		RW          bool   `json:"RW"`
 	} `json:"Mounts"`
 	NetworkSettings struct {
 		Networks map[string]struct {
-			IPAddress string `json:"IPAddress"`
-			Gateway   string `json:"Gateway"`
-			MacAddr   string `json:"MacAddress"`
+			IPAddress         string   `json:"IPAddress"`
+			GlobalIPv6Address string   `json:"GlobalIPv6Address"`
+			Gateway           string   `json:"Gateway"`
+			MacAddr           string   `json:"MacAddress"`
+			Aliases           []string `json:"Aliases"`
 		} `json:"Networks"`
 	} `json:"NetworkSettings"`
 }
 
 // InspectContainer returns detailed information about a container
 func (p *PodmanService) InspectContainer(ctx context.Context, containerID string) (*podmanInspect, error) {
+	var inspect podmanInspect
+	err := p.api().getJSON(ctx, "/containers/"+url.PathEscape(containerID)+"/json", nil, &inspect)
+	if err == nil {
+		return &inspect, nil
+	}
+	if !errors.Is(err, errPodmanAPIUnavailable) {
+		return nil, err
+	}
+
 	output, err := p.podmanCmd(ctx, "inspect", containerID, "--format", "json")
 	if err != nil {
 		return nil, err
@@ -389,16 +476,27 @@ func (p *PodmanService) InspectContainer(ctx context.Context, containerID string
 
 // GetContainerSize returns the container's disk usage (writable layer size and total size)
 func (p *PodmanService) GetContainerSize(ctx context.Context, containerID string) (sizeRw int64, sizeRootFs int64) {
+	type containerSize struct {
+		SizeRw     int64 `json:"SizeRw"`
+		SizeRootFs int64 `json:"SizeRootFs"`
+	}
+
+	var size containerSize
+	err := p.api().getJSON(ctx, "/containers/"+url.PathEscape(containerID)+"/json", url.Values{"size": {"true"}}, &size)
+	if err == nil {
+		return size.SizeRw, size.SizeRootFs
+	}
+	if !errors.Is(err, errPodmanAPIUnavailable) {
+		return 0, 0
+	}
+
 	// Use podman inspect with size flag to get container size
 	output, err := p.podmanCmd(ctx, "inspect", containerID, "--size", "--format", "json")
 	if err != nil {
 		return 0, 0
 	}
 
-	var containers []struct {
-		SizeRw     int64 `json:"SizeRw"`
-		SizeRootFs int64 `json:"SizeRootFs"`
-	}
+	var containers []containerSize
 	if err := json.Unmarshal(output, &containers); err != nil || len(containers) == 0 {
 		return 0, 0
 	}
@@ -473,6 +571,11 @@ func (p *PodmanService) CreateContainer(ctx context.Context, req *models.CreateC
 		args = append(args, "--network", req.NetworkMode)
 	}
 
+	// DNS aliases on the container's network
+	for _, alias := range req.NetworkAliases {
+		args = append(args, "--network-alias", alias)
+	}
+
 	// Hostname
 	if req.Hostname != "" {
 		args = append(args, "--hostname", req.Hostname)
@@ -488,6 +591,15 @@ func (p *PodmanService) CreateContainer(ctx context.Context, req *models.CreateC
 		args = append(args, "--workdir", req.WorkDir)
 	}
 
+	// Privileges and confinement
+	args = append(args, containerSecurityArgs(&req.ContainerSecurityOptions)...)
+
+	// Log driver and rotation
+	args = append(args, containerLogArgs(&req.ContainerLogOptions)...)
+
+	// Health check
+	args = append(args, containerHealthArgs(&req.ContainerHealthCheck)...)
+
 	// Entrypoint
 	if len(req.Entrypoint) > 0 {
 		args = append(args, "--entrypoint", strings.Join(req.Entrypoint, " "))
@@ -539,6 +651,25 @@ func (p *PodmanService) RestartContainer(ctx context.Context, containerID string
 	return err
 }
 
+// UpdateContainerResources changes a container's CPU and memory limits in
+// place with podman update, without restarting it. A zero value leaves that
+// limit alone.
+func (p *PodmanService) UpdateContainerResources(ctx context.Context, containerID string, cpus float64, memory int64) error {
+	args := []string{"update"}
+	if cpus > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(cpus, 'f', 2, 64))
+	}
+	if memory > 0 {
+		args = append(args, "--memory", strconv.FormatInt(memory, 10))
+	}
+	if len(args) == 1 {
+		return nil
+	}
+	args = append(args, containerID)
+	_, err := p.podmanCmd(ctx, args...)
+	return err
+}
+
 // RemoveContainer removes a container
 func (p *PodmanService) RemoveContainer(ctx context.Context, containerID string, force bool) error {
 	args := []string{"rm"}
@@ -550,6 +681,17 @@ func (p *PodmanService) RemoveContainer(ctx context.Context, containerID string,
 	return err
 }
 
+// RemoveContainerWithVolumes removes a container together with its anonymous volumes
+func (p *PodmanService) RemoveContainerWithVolumes(ctx context.Context, containerID string, force bool) error {
+	args := []string{"rm", "-v"}
+	if force {
+		args = append(args, "-f")
+	}
+	args = append(args, containerID)
+	_, err := p.podmanCmd(ctx, args...)
+	return err
+}
+
 // GetContainerLogs streams container logs
 func (p *PodmanService) GetContainerLogs(ctx context.Context, containerID string, tail int, follow bool) (io.ReadCloser, error) {
 	args := []string{"logs"}
@@ -603,7 +745,72 @@ func (l *logReader) Close() error {
 
 // GetContainerStats gets real-time stats for a container
 func (p *PodmanService) GetContainerStats(ctx context.Context, containerID string) (*models.ContainerStats, error) {
-	output, err := p.podmanCmd(ctx, "stats", containerID, "--no-stream", "--format", "json")
+	stats, err := p.containerStats(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("no stats available for container: %s", containerID)
+	}
+
+	result := &stats[0]
+	result.ContainerID = containerID
+	return result, nil
+}
+
+// GetAllContainerStats samples every running container in one call. Each
+// ContainerID is the full ID over the API socket but podman's 12 character
+// short ID from the CLI.
+func (p *PodmanService) GetAllContainerStats(ctx context.Context) ([]models.ContainerStats, error) {
+	return p.containerStats(ctx)
+}
+
+// containerStats samples the given containers, or all running ones
+func (p *PodmanService) containerStats(ctx context.Context, containerIDs ...string) ([]models.ContainerStats, error) {
+	// The API reports raw numbers where the CLI prints human-readable ones
+	var report struct {
+		Stats []struct {
+			ContainerID string  `json:"ContainerID"`
+			CPU         float64 `json:"CPU"`
+			MemUsage    int64   `json:"MemUsage"`
+			MemLimit    int64   `json:"MemLimit"`
+			MemPerc     float64 `json:"MemPerc"`
+			NetInput    int64   `json:"NetInput"`
+			NetOutput   int64   `json:"NetOutput"`
+			BlockInput  int64   `json:"BlockInput"`
+			BlockOutput int64   `json:"BlockOutput"`
+			PIDs        int     `json:"PIDs"`
+		} `json:"Stats"`
+	}
+	query := url.Values{"stream": {"false"}}
+	for _, id := range containerIDs {
+		query.Add("containers", id)
+	}
+	err := p.api().getJSON(ctx, "/containers/stats", query, &report)
+	if err == nil {
+		result := make([]models.ContainerStats, 0, len(report.Stats))
+		for _, s := range report.Stats {
+			result = append(result, models.ContainerStats{
+				ContainerID: s.ContainerID,
+				CPUPercent:  s.CPU,
+				MemoryUsed:  s.MemUsage,
+				MemoryLimit: s.MemLimit,
+				MemoryPct:   s.MemPerc,
+				NetworkRx:   s.NetInput,
+				NetworkTx:   s.NetOutput,
+				BlockRead:   s.BlockInput,
+				BlockWrite:  s.BlockOutput,
+				PIDs:        s.PIDs,
+			})
+		}
+		return result, nil
+	}
+	if !errors.Is(err, errPodmanAPIUnavailable) {
+		return nil, err
+	}
+
+	args := append([]string{"stats", "--no-stream", "--format", "json"}, containerIDs...)
+	output, err := p.podmanCmd(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -623,30 +830,30 @@ func (p *PodmanService) GetContainerStats(ctx context.Context, containerID strin
 		return nil, fmt.Errorf("failed to parse stats: %w", err)
 	}
 
-	if len(stats) == 0 {
-		return nil, fmt.Errorf("no stats available for container: %s", containerID)
-	}
+	result := make([]models.ContainerStats, 0, len(stats))
+	for _, s := range stats {
+		item := models.ContainerStats{
+			ContainerID: s.ID,
+		}
 
-	s := stats[0]
-	result := &models.ContainerStats{
-		ContainerID: containerID,
-	}
+		// Parse CPU percentage (e.g., "0.83%")
+		item.CPUPercent = parsePercentage(s.CPUPercent)
 
-	// Parse CPU percentage (e.g., "0.83%")
-	result.CPUPercent = parsePercentage(s.CPUPercent)
+		// Parse memory (e.g., "88.65MB / 7.971GB")
+		item.MemoryUsed, item.MemoryLimit = parseMemoryUsage(s.MemUsage)
+		item.MemoryPct = parsePercentage(s.MemPercent)
 
-	// Parse memory (e.g., "88.65MB / 7.971GB")
-	result.MemoryUsed, result.MemoryLimit = parseMemoryUsage(s.MemUsage)
-	result.MemoryPct = parsePercentage(s.MemPercent)
+		// Parse network I/O (e.g., "6.345kB / 6.338kB")
+		item.NetworkRx, item.NetworkTx = parseIOStats(s.NetIO)
 
-	// Parse network I/O (e.g., "6.345kB / 6.338kB")
-	result.NetworkRx, result.NetworkTx = parseIOStats(s.NetIO)
+		// Parse block I/O (e.g., "0B / 0B")
+		item.BlockRead, item.BlockWrite = parseIOStats(s.BlockIO)
 
-	// Parse block I/O (e.g., "0B / 0B")
-	result.BlockRead, result.BlockWrite = parseIOStats(s.BlockIO)
+		// Parse PIDs
+		item.PIDs, _ = strconv.Atoi(s.PIDs)
 
-	// Parse PIDs
-	result.PIDs, _ = strconv.Atoi(s.PIDs)
+		result = append(result, item)
+	}
 
 	return result, nil
 }
@@ -726,14 +933,19 @@ type podmanImage struct {
 
 // ListImages returns all container images
 func (p *PodmanService) ListImages(ctx context.Context) ([]models.Image, error) {
-	output, err := p.podmanCmd(ctx, "images", "--format", "json")
-	if err != nil {
-		return nil, err
-	}
-
 	var images []podmanImage
-	if err := json.Unmarshal(output, &images); err != nil {
-		return nil, fmt.Errorf("failed to parse image list: %w", err)
+	err := p.api().getJSON(ctx, "/images/json", nil, &images)
+	if errors.Is(err, errPodmanAPIUnavailable) {
+		var output []byte
+		output, err = p.podmanCmd(ctx, "images", "--format", "json")
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(output, &images); err != nil {
+			return nil, fmt.Errorf("failed to parse image list: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
 	}
 
 	result := make([]models.Image, 0, len(images))
@@ -863,11 +1075,6 @@ func (p *PodmanService) RemoveImage(ctx context.Context, imageID string, force b
 
 // ListVolumes returns all Podman volumes
 func (p *PodmanService) ListVolumes(ctx context.Context) ([]models.Volume, error) {
-	output, err := p.podmanCmd(ctx, "volume", "ls", "--format", "json")
-	if err != nil {
-		return nil, err
-	}
-
 	var volumes []struct {
 		Name       string            `json:"Name"`
 		Driver     string            `json:"Driver"`
@@ -877,8 +1084,18 @@ func (p *PodmanService) ListVolumes(ctx context.Context) ([]models.Volume, error
 		Scope      string            `json:"Scope"`
 		Options    map[string]string `json:"Options"`
 	}
-	if err := json.Unmarshal(output, &volumes); err != nil {
-		return nil, fmt.Errorf("failed to parse volume list: %w", err)
+	err := p.api().getJSON(ctx, "/volumes/json", nil, &volumes)
+	if errors.Is(err, errPodmanAPIUnavailable) {
+		var output []byte
+		output, err = p.podmanCmd(ctx, "volume", "ls", "--format", "json")
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(output, &volumes); err != nil {
+			return nil, fmt.Errorf("failed to parse volume list: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
 	}
 
 	result := make([]models.Volume, 0, len(volumes))
@@ -930,50 +1147,84 @@ func (p *PodmanService) RemoveVolume(ctx context.Context, name string, force boo
 	return err
 }
 
+// InspectVolume returns a single volume by name
+func (p *PodmanService) InspectVolume(ctx context.Context, name string) (*models.Volume, error) {
+	volumes, err := p.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range volumes {
+		if volumes[i].Name == name {
+			return &volumes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("volume not found: %s", name)
+}
+
+// ExportVolume writes the contents of a volume to a tarball
+func (p *PodmanService) ExportVolume(ctx context.Context, name, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if _, err := p.podmanCmd(ctx, "volume", "export", "--output", dest, name); err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return nil
+}
+
+// ImportVolume loads a tarball written by ExportVolume into an existing volume
+func (p *PodmanService) ImportVolume(ctx context.Context, name, archive string) error {
+	_, err := p.podmanCmd(ctx, "volume", "import", name, archive)
+	return err
+}
+
+// MountVolume mounts a volume whose driver doesn't keep it mounted and
+// returns the host path of its data
+func (p *PodmanService) MountVolume(ctx context.Context, name string) (string, error) {
+	output, err := p.podmanCmd(ctx, "volume", "mount", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// UnmountVolume releases a mount taken by MountVolume
+func (p *PodmanService) UnmountVolume(ctx context.Context, name string) error {
+	_, err := p.podmanCmd(ctx, "volume", "unmount", name)
+	return err
+}
+
 // Network operations
 
-// ListNetworks returns all Podman networks
-func (p *PodmanService) ListNetworks(ctx context.Context) ([]models.Network, error) {
-	output, err := p.podmanCmd(ctx, "network", "ls", "--format", "json")
+// ListSecrets returns the secrets in the Podman secret store without their values
+func (p *PodmanService) ListSecrets(ctx context.Context) ([]models.PodmanSecret, error) {
+	output, err := p.podmanCmd(ctx, "secret", "ls", "--format", "json")
 	if err != nil {
 		return nil, err
 	}
 
-	var networks []struct {
-		ID        string            `json:"Id"`
-		Name      string            `json:"Name"`
-		Driver    string            `json:"Driver"`
-		Labels    map[string]string `json:"Labels"`
-		Internal  bool              `json:"Internal"`
-		IPv6      bool              `json:"IPv6"`
-		CreatedAt string            `json:"Created"`
-		Subnets   []struct {
-			Subnet  string `json:"Subnet"`
-			Gateway string `json:"Gateway"`
-		} `json:"Subnets"`
+	var secrets []struct {
+		ID        string `json:"ID"`
+		CreatedAt string `json:"CreatedAt"`
+		Spec      struct {
+			Name   string `json:"Name"`
+			Driver struct {
+				Name string `json:"Name"`
+			} `json:"Driver"`
+		} `json:"Spec"`
 	}
-	if err := json.Unmarshal(output, &networks); err != nil {
-		return nil, fmt.Errorf("failed to parse network list: %w", err)
+	if err := json.Unmarshal(output, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secret list: %w", err)
 	}
 
-	result := make([]models.Network, 0, len(networks))
-	for _, n := range networks {
-		createdAt, _ := time.Parse(time.RFC3339, n.CreatedAt)
-		subnet := ""
-		gateway := ""
-		if len(n.Subnets) > 0 {
-			subnet = n.Subnets[0].Subnet
-			gateway = n.Subnets[0].Gateway
-		}
-		result = append(result, models.Network{
-			ID:        n.ID,
-			Name:      n.Name,
-			Driver:    n.Driver,
-			Subnet:    subnet,
-			Gateway:   gateway,
-			Internal:  n.Internal,
-			IPv6:      n.IPv6,
-			Labels:    n.Labels,
+	result := make([]models.PodmanSecret, 0, len(secrets))
+	for _, s := range secrets {
+		createdAt, _ := time.Parse(time.RFC3339Nano, s.CreatedAt)
+		result = append(result, models.PodmanSecret{
+			ID:        s.ID,
+			Name:      s.Spec.Name,
+			Driver:    s.Spec.Driver.Name,
 			CreatedAt: createdAt,
 		})
 	}
@@ -981,6 +1232,235 @@ func (p *PodmanService) ListNetworks(ctx context.Context) ([]models.Network, err
 	return result, nil
 }
 
+// SecretValue reads the plaintext value of a Podman secret
+func (p *PodmanService) SecretValue(ctx context.Context, name string) (string, error) {
+	output, err := p.podmanCmd(ctx, "secret", "inspect", "--showsecret", "--format", "{{.SecretData}}", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// CreateSecret stores a value in the Podman secret store, replacing any existing secret of that name
+func (p *PodmanService) CreateSecret(ctx context.Context, name, value string) error {
+	args := []string{"secret", "create", "--replace", name, "-"}
+
+	var cmd *exec.Cmd
+	if os.Getuid() == 0 && p.targetUser != "" {
+		cmd = exec.CommandContext(ctx, "sudo", append([]string{"-u", p.targetUser, "podman"}, args...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "podman", args...)
+	}
+	// Pass the value on stdin so it never appears in the process list
+	cmd.Stdin = strings.NewReader(value)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("podman error: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RemoveSecret deletes a secret from the Podman secret store
+func (p *PodmanService) RemoveSecret(ctx context.Context, name string) error {
+	_, err := p.podmanCmd(ctx, "secret", "rm", name)
+	return err
+}
+
+// podmanNetwork is a network as the libpod API and podman network ls and
+// inspect report it
+type podmanNetwork struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Driver    string `json:"driver"`
+	Interface string `json:"network_interface"`
+	CreatedAt string `json:"created"`
+	Subnets   []struct {
+		Subnet     string `json:"subnet"`
+		Gateway    string `json:"gateway"`
+		LeaseRange *struct {
+			StartIP string `json:"start_ip"`
+			EndIP   string `json:"end_ip"`
+		} `json:"lease_range"`
+	} `json:"subnets"`
+	IPv6       bool              `json:"ipv6_enabled"`
+	Internal   bool              `json:"internal"`
+	DNSEnabled bool              `json:"dns_enabled"`
+	DNSServers []string          `json:"network_dns_servers"`
+	Labels     map[string]string `json:"labels"`
+	Options    map[string]string `json:"options"`
+	IPAM       map[string]string `json:"ipam_options"`
+}
+
+// summary converts the network to the list form
+func (n *podmanNetwork) summary() models.Network {
+	createdAt, _ := time.Parse(time.RFC3339, n.CreatedAt)
+	subnet := ""
+	gateway := ""
+	if len(n.Subnets) > 0 {
+		subnet = n.Subnets[0].Subnet
+		gateway = n.Subnets[0].Gateway
+	}
+	return models.Network{
+		ID:        n.ID,
+		Name:      n.Name,
+		Driver:    n.Driver,
+		Interface: n.Interface,
+		Subnet:    subnet,
+		Gateway:   gateway,
+		Internal:  n.Internal,
+		IPv6:      n.IPv6,
+		Labels:    n.Labels,
+		CreatedAt: createdAt,
+	}
+}
+
+// ListNetworks returns all Podman networks
+func (p *PodmanService) ListNetworks(ctx context.Context) ([]models.Network, error) {
+	var networks []podmanNetwork
+	err := p.api().getJSON(ctx, "/networks/json", nil, &networks)
+	if errors.Is(err, errPodmanAPIUnavailable) {
+		var output []byte
+		output, err = p.podmanCmd(ctx, "network", "ls", "--format", "json")
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(output, &networks); err != nil {
+			return nil, fmt.Errorf("failed to parse network list: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Network, 0, len(networks))
+	for i := range networks {
+		result = append(result, networks[i].summary())
+	}
+
+	return result, nil
+}
+
+// InspectNetwork returns a network's full configuration. Containers is left
+// for NetworkContainers to fill.
+func (p *PodmanService) InspectNetwork(ctx context.Context, name string) (*models.NetworkDetail, error) {
+	var network podmanNetwork
+	err := p.api().getJSON(ctx, "/networks/"+url.PathEscape(name)+"/json", nil, &network)
+	if errors.Is(err, errPodmanAPIUnavailable) {
+		output, err := p.podmanCmd(ctx, "network", "inspect", name, "--format", "json")
+		if err != nil {
+			return nil, err
+		}
+		var networks []podmanNetwork
+		if err := json.Unmarshal(output, &networks); err != nil {
+			return nil, fmt.Errorf("failed to parse network inspect: %w", err)
+		}
+		if len(networks) == 0 {
+			return nil, fmt.Errorf("network not found: %s", name)
+		}
+		network = networks[0]
+	} else if err != nil {
+		return nil, err
+	}
+
+	detail := &models.NetworkDetail{
+		Network:    network.summary(),
+		Subnets:    make([]models.NetworkSubnet, 0, len(network.Subnets)),
+		DNSEnabled: network.DNSEnabled,
+		DNSServers: network.DNSServers,
+		IPAMDriver: network.IPAM["driver"],
+		Options:    network.Options,
+		Containers: []models.NetworkMember{},
+	}
+	for _, s := range network.Subnets {
+		subnet := models.NetworkSubnet{Subnet: s.Subnet, Gateway: s.Gateway}
+		if s.LeaseRange != nil {
+			subnet.LeaseStart = s.LeaseRange.StartIP
+			subnet.LeaseEnd = s.LeaseRange.EndIP
+		}
+		detail.Subnets = append(detail.Subnets, subnet)
+	}
+	if detail.IPAMDriver == "" {
+		detail.IPAMDriver = "host-local"
+	}
+	return detail, nil
+}
+
+// NetworkContainers returns the containers attached to a network, running or
+// not, with their addresses on it
+func (p *PodmanService) NetworkContainers(ctx context.Context, name, ipamDriver string) ([]models.NetworkMember, error) {
+	containers, err := p.psContainers(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	members := []models.NetworkMember{}
+	for _, c := range containers {
+		attached := false
+		for _, n := range c.Networks {
+			attached = attached || n == name
+		}
+		if !attached {
+			continue
+		}
+		inspect, err := p.InspectContainer(ctx, c.ID)
+		if err != nil {
+			continue
+		}
+		n, ok := inspect.NetworkSettings.Networks[name]
+		if !ok {
+			continue
+		}
+
+		addressing := models.NetworkAddressDynamic
+		switch {
+		case ipamDriver == "dhcp":
+			addressing = models.NetworkAddressDHCP
+		case hasStaticIP(inspect.Config.CreateCommand, name, len(inspect.NetworkSettings.Networks) == 1):
+			addressing = models.NetworkAddressStatic
+		}
+		members = append(members, models.NetworkMember{
+			ContainerID: inspect.ID,
+			Name:        strings.TrimPrefix(inspect.Name, "/"),
+			State:       inspect.State.Status,
+			Stack:       c.Labels["com.docker.compose.project"],
+			IPv4:        n.IPAddress,
+			IPv6:        n.GlobalIPv6Address,
+			MACAddress:  n.MacAddr,
+			Aliases:     filterNetworkAliases(n.Aliases, inspect.ID, inspect.Name),
+			Addressing:  addressing,
+		})
+	}
+	return members, nil
+}
+
+// hasStaticIP reports whether a container was created with a fixed address on
+// a network, either as --network name:ip=... or, when it is the container's
+// only network, as --ip or --ip6
+func hasStaticIP(createCommand []string, network string, only bool) bool {
+	for i, arg := range createCommand {
+		flag, value, inline := strings.Cut(arg, "=")
+		if !inline && i+1 < len(createCommand) {
+			value = createCommand[i+1]
+		}
+		switch flag {
+		case "--ip", "--ip6":
+			if only {
+				return true
+			}
+		case "--network", "--net":
+			opts, found := strings.CutPrefix(value, network+":")
+			if !found {
+				continue
+			}
+			for _, opt := range strings.Split(opts, ",") {
+				if strings.HasPrefix(opt, "ip=") || strings.HasPrefix(opt, "ip6=") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // CreateNetwork creates a new network
 func (p *PodmanService) CreateNetwork(ctx context.Context, req *models.CreateNetworkRequest) error {
 	args := []string{"network", "create"}
@@ -997,6 +1477,14 @@ func (p *PodmanService) CreateNetwork(ctx context.Context, req *models.CreateNet
 		args = append(args, "--gateway", req.Gateway)
 	}
 
+	if req.IPRange != "" {
+		args = append(args, "--ip-range", req.IPRange)
+	}
+
+	if req.IPAMDriver != "" {
+		args = append(args, "--ipam-driver", req.IPAMDriver)
+	}
+
 	if req.Internal {
 		args = append(args, "--internal")
 	}
@@ -1005,6 +1493,18 @@ func (p *PodmanService) CreateNetwork(ctx context.Context, req *models.CreateNet
 		args = append(args, "--ipv6")
 	}
 
+	if req.DisableDNS {
+		args = append(args, "--disable-dns")
+	}
+
+	if req.Interface != "" {
+		args = append(args, "--interface-name", req.Interface)
+	}
+
+	for key, value := range req.Options {
+		args = append(args, "--opt", fmt.Sprintf("%s=%s", key, value))
+	}
+
 	for key, value := range req.Labels {
 		args = append(args, "--label", fmt.Sprintf("%s=%s", key, value))
 	}
@@ -1014,6 +1514,194 @@ func (p *PodmanService) CreateNetwork(ctx context.Context, req *models.CreateNet
 	return err
 }
 
+// createNetworkFrom creates a network with all of a detail's settings,
+// including every subnet and its lease range
+func (p *PodmanService) createNetworkFrom(ctx context.Context, n *models.NetworkDetail) error {
+	args := []string{"network", "create", "--driver", n.Driver}
+	for _, s := range n.Subnets {
+		args = append(args, "--subnet", s.Subnet)
+		if s.Gateway != "" {
+			args = append(args, "--gateway", s.Gateway)
+		}
+		if s.LeaseStart != "" && s.LeaseEnd != "" {
+			args = append(args, "--ip-range", s.LeaseStart+"-"+s.LeaseEnd)
+		}
+	}
+	if n.IPAMDriver != "" && n.IPAMDriver != "host-local" {
+		args = append(args, "--ipam-driver", n.IPAMDriver)
+	}
+	if n.Internal {
+		args = append(args, "--internal")
+	}
+	if n.IPv6 {
+		args = append(args, "--ipv6")
+	}
+	if !n.DNSEnabled {
+		args = append(args, "--disable-dns")
+	}
+	for _, server := range n.DNSServers {
+		args = append(args, "--dns", server)
+	}
+	if n.Interface != "" {
+		args = append(args, "--interface-name", n.Interface)
+	}
+	for key, value := range n.Options {
+		args = append(args, "--opt", fmt.Sprintf("%s=%s", key, value))
+	}
+	for key, value := range n.Labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", key, value))
+	}
+	args = append(args, n.Name)
+	_, err := p.podmanCmd(ctx, args...)
+	return err
+}
+
+// RecreateNetwork replaces a network with one built from updated, for the
+// settings podman can't change in place. The containers on it are moved over
+// with their aliases, and keep static addresses that still fit a subnet. If
+// the new network can't be created the old one is put back. The warnings say
+// which containers didn't come back as they were.
+func (p *PodmanService) RecreateNetwork(ctx context.Context, old, updated *models.NetworkDetail) ([]string, error) {
+	var warnings []string
+	reconnect := func(members []models.NetworkMember, n *models.NetworkDetail) {
+		for _, m := range members {
+			ip := ""
+			if m.Addressing == models.NetworkAddressStatic && m.IPv4 != "" {
+				if subnetsContain(n.Subnets, m.IPv4) {
+					ip = m.IPv4
+				} else {
+					warnings = append(warnings, fmt.Sprintf("%s: static address %s is outside the new subnet, podman assigned a new one", m.Name, m.IPv4))
+				}
+			}
+			if err := p.ConnectNetworkWithIP(ctx, n.Name, m.ContainerID, m.Aliases, ip); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: failed to reconnect: %v", m.Name, err))
+			}
+		}
+	}
+
+	for i, m := range old.Containers {
+		if err := p.DisconnectNetwork(ctx, old.Name, m.ContainerID); err != nil {
+			reconnect(old.Containers[:i], old)
+			return warnings, fmt.Errorf("failed to disconnect %s: %w", m.Name, err)
+		}
+	}
+	if err := p.RemoveNetwork(ctx, old.Name, false); err != nil {
+		reconnect(old.Containers, old)
+		return warnings, err
+	}
+	if err := p.createNetworkFrom(ctx, updated); err != nil {
+		if restoreErr := p.createNetworkFrom(ctx, old); restoreErr != nil {
+			return warnings, fmt.Errorf("%w; restoring the old network also failed: %v", err, restoreErr)
+		}
+		reconnect(old.Containers, old)
+		return warnings, err
+	}
+	reconnect(old.Containers, updated)
+	return warnings, nil
+}
+
+// subnetsContain reports whether an address lies in one of the subnets
+func subnetsContain(subnets []models.NetworkSubnet, addr string) bool {
+	ip := net.ParseIP(addr)
+	for _, s := range subnets {
+		if _, ipnet, err := net.ParseCIDR(s.Subnet); err == nil && ip != nil && ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkExists checks whether a network with the given name exists
+func (p *PodmanService) NetworkExists(ctx context.Context, name string) bool {
+	_, err := p.podmanCmd(ctx, "network", "exists", name)
+	return err == nil
+}
+
+// EnsureNetwork creates a DNS-enabled bridge network if it does not already exist
+func (p *PodmanService) EnsureNetwork(ctx context.Context, name string, labels map[string]string) error {
+	if p.NetworkExists(ctx, name) {
+		return nil
+	}
+	return p.CreateNetwork(ctx, &models.CreateNetworkRequest{
+		Name:   name,
+		Driver: "bridge",
+		Labels: labels,
+	})
+}
+
+// ConnectNetwork attaches a container to a network with optional DNS aliases
+func (p *PodmanService) ConnectNetwork(ctx context.Context, network, containerID string, aliases []string) error {
+	return p.ConnectNetworkWithIP(ctx, network, containerID, aliases, "")
+}
+
+// ConnectNetworkWithIP attaches a container to a network at a fixed address,
+// or one podman picks when ip is empty
+func (p *PodmanService) ConnectNetworkWithIP(ctx context.Context, network, containerID string, aliases []string, ip string) error {
+	args := []string{"network", "connect"}
+	for _, alias := range aliases {
+		args = append(args, "--alias", alias)
+	}
+	if ip != "" {
+		args = append(args, "--ip", ip)
+	}
+	args = append(args, network, containerID)
+	_, err := p.podmanCmd(ctx, args...)
+	return err
+}
+
+// DisconnectNetwork detaches a container from a network
+func (p *PodmanService) DisconnectNetwork(ctx context.Context, network, containerID string) error {
+	_, err := p.podmanCmd(ctx, "network", "disconnect", network, containerID)
+	return err
+}
+
+// GetContainerNetwork returns a container's IP address and DNS aliases on a network
+func (p *PodmanService) GetContainerNetwork(ctx context.Context, containerID, network string) (string, []string, error) {
+	inspect, err := p.InspectContainer(ctx, containerID)
+	if err != nil {
+		return "", nil, err
+	}
+	n, ok := inspect.NetworkSettings.Networks[network]
+	if !ok {
+		return "", nil, fmt.Errorf("container is not attached to network %s", network)
+	}
+	return n.IPAddress, filterNetworkAliases(n.Aliases, inspect.ID, inspect.Name), nil
+}
+
+// GetContainerIPAddress returns an address the host can reach the container on, preferring the given network
+func (p *PodmanService) GetContainerIPAddress(ctx context.Context, containerID, preferred string) (string, error) {
+	inspect, err := p.InspectContainer(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	if n, ok := inspect.NetworkSettings.Networks[preferred]; ok && n.IPAddress != "" {
+		return n.IPAddress, nil
+	}
+	for _, n := range inspect.NetworkSettings.Networks {
+		if n.IPAddress != "" {
+			return n.IPAddress, nil
+		}
+	}
+	return "", fmt.Errorf("container has no IP address (host or rootless slirp networking)")
+}
+
+// filterNetworkAliases drops the aliases Podman adds automatically (container name and short ID)
+func filterNetworkAliases(aliases []string, id, name string) []string {
+	shortID := id
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+	name = strings.TrimPrefix(name, "/")
+
+	result := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		if a != shortID && a != id && a != name {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
 // RemoveNetwork removes a network
 func (p *PodmanService) RemoveNetwork(ctx context.Context, name string, force bool) error {
 	args := []string{"network", "rm"}
@@ -1037,6 +1725,31 @@ func (p *PodmanService) Exec(ctx context.Context, containerID string, cmd []stri
 	return p.podmanCmd(ctx, args...)
 }
 
+// ExecCapture runs a command inside a container and returns its combined output and exit code
+// A non-zero exit code is not treated as an error; err is only set if the command could not be run
+func (p *PodmanService) ExecCapture(ctx context.Context, containerID string, cmd []string) (string, int, error) {
+	args := []string{"exec", containerID}
+	args = append(args, cmd...)
+
+	var c *exec.Cmd
+	if os.Getuid() == 0 && p.targetUser != "" {
+		sudoArgs := []string{"-u", p.targetUser, "podman"}
+		sudoArgs = append(sudoArgs, args...)
+		c = exec.CommandContext(ctx, "sudo", sudoArgs...)
+	} else {
+		c = exec.CommandContext(ctx, "podman", args...)
+	}
+
+	output, err := c.CombinedOutput()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return string(output), exitErr.ExitCode(), nil
+		}
+		return string(output), -1, err
+	}
+	return string(output), 0, nil
+}
+
 // GetLogs returns container logs as a slice of strings (for REST API)
 func (p *PodmanService) GetLogs(ctx context.Context, containerID string, tail string, timestamps bool) ([]string, error) {
 	args := []string{"logs"}
@@ -1108,11 +1821,83 @@ func (p *PodmanService) ExecInteractive(ctx context.Context, containerID string)
 	return stdin, io.NopCloser(combinedReader), nil
 }
 
+// DefaultDetachKeys is the key sequence that detaches from an attached container
+const DefaultDetachKeys = "ctrl-p,ctrl-q"
+
+// ParseDetachKeys converts a podman --detach-keys value such as "ctrl-p,ctrl-q"
+// into the bytes the sequence sends. An empty value disables detaching.
+func ParseDetachKeys(keys string) ([]byte, error) {
+	if keys == "" {
+		return nil, nil
+	}
+	var seq []byte
+	for _, key := range strings.Split(keys, ",") {
+		switch {
+		case len(key) == 1:
+			seq = append(seq, key[0])
+		case strings.HasPrefix(key, "ctrl-") && len(key) == 6:
+			c := key[5]
+			switch {
+			case c >= 'a' && c <= 'z':
+				seq = append(seq, c-'a'+1)
+			case c == '@' || c == '[' || c == '\\' || c == ']' || c == '^' || c == '_':
+				seq = append(seq, c-'@')
+			default:
+				return nil, fmt.Errorf("invalid detach key %q", key)
+			}
+		default:
+			return nil, fmt.Errorf("invalid detach key %q: use a single character or ctrl-<key>", key)
+		}
+	}
+	return seq, nil
+}
+
+// AttachInteractive attaches to a running container's main process, unlike
+// ExecInteractive which starts a new one. Stdout and stderr are interleaved on
+// the returned reader, which ends when podman detaches or the container exits.
+// Closing the session without the detach keys leaves the container running.
+func (p *PodmanService) AttachInteractive(ctx context.Context, containerID, detachKeys string) (io.WriteCloser, io.ReadCloser, error) {
+	args := []string{"attach", "--sig-proxy=false", "--detach-keys=" + detachKeys, containerID}
+
+	// Build command with rootless support
+	var cmd *exec.Cmd
+	if os.Getuid() == 0 && p.targetUser != "" {
+		sudoArgs := []string{"-u", p.targetUser, "podman"}
+		sudoArgs = append(sudoArgs, args...)
+		cmd = exec.CommandContext(ctx, "sudo", sudoArgs...)
+	} else {
+		cmd = exec.CommandContext(ctx, "podman", args...)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+
+	output, outputWriter := io.Pipe()
+	cmd.Stdout = outputWriter
+	cmd.Stderr = outputWriter
+
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		return nil, nil, fmt.Errorf("failed to start attach: %w", err)
+	}
+
+	go func() {
+		outputWriter.CloseWithError(cmd.Wait())
+	}()
+
+	return stdin, output, nil
+}
+
 // StreamLogs streams logs to a channel (for WebSocket)
+// A negative tail follows only new lines, skipping existing history
 func (p *PodmanService) StreamLogs(ctx context.Context, containerID string, tail int, logChan chan<- models.ContainerLog) error {
 	args := []string{"logs", "-f", "--timestamps"}
 	if tail > 0 {
 		args = append(args, "--tail", strconv.Itoa(tail))
+	} else if tail < 0 {
+		args = append(args, "--tail", "0")
 	}
 	args = append(args, containerID)
 
@@ -1221,16 +2006,17 @@ func parseLogLine(line string) (time.Time, string) {
 	return time.Now(), line
 }
 
-// CheckPodmanCompose checks if podman-compose is available
+// CheckPodmanCompose checks if a compose engine is available
 func (p *PodmanService) CheckPodmanCompose(ctx context.Context) bool {
-	cmd := exec.CommandContext(ctx, "podman-compose", "version")
-	err := cmd.Run()
+	_, err := p.composeEngine(ctx)
 	return err == nil
 }
 
 // InstallPackage installs a package via dnf and streams output
 func (p *PodmanService) InstallPackage(ctx context.Context, packageName string, outputChan chan<- string) error {
-	cmd := exec.CommandContext(ctx, "sudo", "dnf", "install", "-y", packageName)
+	// sudo resets the environment, so the proxy is passed through env
+	sudoArgs := append(append([]string{"env"}, ProxyEnv()...), "dnf", "install", "-y", packageName)
+	cmd := exec.CommandContext(ctx, "sudo", sudoArgs...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -1279,187 +2065,95 @@ func (p *PodmanService) UnpauseContainer(ctx context.Context, containerID string
 
 // Compose operations
 
-// ComposeUp deploys a compose stack
-func (p *PodmanService) ComposeUp(ctx context.Context, projectDir string, projectName string, outputChan chan<- string) error {
+// composeArgs builds the global compose arguments for a project and its active profiles.
+// The data pool override is layered on top when the stack has one.
+func composeArgs(projectDir string, projectName string, profiles []string) []string {
 	args := []string{"-f", projectDir + "/docker-compose.yml"}
+	override := filepath.Join(projectDir, DataPoolOverrideFile)
+	if _, err := os.Stat(override); err == nil {
+		args = append(args, "-f", override)
+	}
 	if projectName != "" {
 		args = append(args, "-p", projectName)
 	}
-	args = append(args, "up", "-d")
+	for _, profile := range profiles {
+		args = append(args, "--profile", profile)
+	}
+	return args
+}
 
-	cmd := exec.CommandContext(ctx, "podman-compose", args...)
-	cmd.Dir = projectDir
+// ComposeUp deploys a compose stack
+func (p *PodmanService) ComposeUp(ctx context.Context, projectDir string, projectName string, profiles []string, outputChan chan<- string) error {
+	return p.ComposeUpServices(ctx, projectDir, projectName, profiles, nil, nil, outputChan)
+}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
+// ComposeUpServices deploys the named services of a compose stack without their dependencies,
+// or every service when none are named. env adds variables for compose file interpolation.
+func (p *PodmanService) ComposeUpServices(ctx context.Context, projectDir string, projectName string, profiles []string, services []string, env []string, outputChan chan<- string) error {
+	args := composeArgs(projectDir, projectName, profiles)
+	args = append(args, "up", "-d")
+	if len(services) > 0 {
+		args = append(args, "--no-deps")
+		args = append(args, services...)
 	}
 
-	stderr, err := cmd.StderrPipe()
+	cmd, err := p.composeCmd(ctx, projectDir, env, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get stderr pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start compose up: %w", err)
+		return err
 	}
-
-	// Read stdout
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			if outputChan != nil {
-				outputChan <- scanner.Text()
-			}
-		}
-	}()
-
-	// Read stderr
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			if outputChan != nil {
-				outputChan <- scanner.Text()
-			}
-		}
-	}()
-
-	return cmd.Wait()
+	return runComposeStreaming(cmd, "up", outputChan)
 }
 
 // ComposeDown stops and removes a compose stack
-func (p *PodmanService) ComposeDown(ctx context.Context, projectDir string, projectName string, removeVolumes bool, outputChan chan<- string) error {
-	args := []string{"-f", projectDir + "/docker-compose.yml"}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
-	}
+func (p *PodmanService) ComposeDown(ctx context.Context, projectDir string, projectName string, profiles []string, removeVolumes bool, outputChan chan<- string) error {
+	args := composeArgs(projectDir, projectName, profiles)
 	args = append(args, "down")
 	if removeVolumes {
 		args = append(args, "-v")
 	}
 
-	cmd := exec.CommandContext(ctx, "podman-compose", args...)
-	cmd.Dir = projectDir
-
-	stdout, err := cmd.StdoutPipe()
+	cmd, err := p.composeCmd(ctx, projectDir, nil, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stderr pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start compose down: %w", err)
+		return err
 	}
-
-	// Read output
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			if outputChan != nil {
-				outputChan <- scanner.Text()
-			}
-		}
-	}()
-
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			if outputChan != nil {
-				outputChan <- scanner.Text()
-			}
-		}
-	}()
-
-	return cmd.Wait()
+	return runComposeStreaming(cmd, "down", outputChan)
 }
 
 // ComposeStop stops a compose stack (without removing)
-func (p *PodmanService) ComposeStop(ctx context.Context, projectDir string, projectName string) error {
-	args := []string{"-f", projectDir + "/docker-compose.yml"}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
-	}
-	args = append(args, "stop")
-
-	cmd := exec.CommandContext(ctx, "podman-compose", args...)
-	cmd.Dir = projectDir
-	return cmd.Run()
+func (p *PodmanService) ComposeStop(ctx context.Context, projectDir string, projectName string, profiles []string) error {
+	return p.runCompose(ctx, projectDir, projectName, profiles, "stop")
 }
 
 // ComposeStart starts a stopped compose stack
-func (p *PodmanService) ComposeStart(ctx context.Context, projectDir string, projectName string) error {
-	args := []string{"-f", projectDir + "/docker-compose.yml"}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
-	}
-	args = append(args, "start")
-
-	cmd := exec.CommandContext(ctx, "podman-compose", args...)
-	cmd.Dir = projectDir
-	return cmd.Run()
+func (p *PodmanService) ComposeStart(ctx context.Context, projectDir string, projectName string, profiles []string) error {
+	return p.runCompose(ctx, projectDir, projectName, profiles, "start")
 }
 
 // ComposeRestart restarts a compose stack
-func (p *PodmanService) ComposeRestart(ctx context.Context, projectDir string, projectName string) error {
-	args := []string{"-f", projectDir + "/docker-compose.yml"}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
-	}
-	args = append(args, "restart")
+func (p *PodmanService) ComposeRestart(ctx context.Context, projectDir string, projectName string, profiles []string) error {
+	return p.runCompose(ctx, projectDir, projectName, profiles, "restart")
+}
 
-	cmd := exec.CommandContext(ctx, "podman-compose", args...)
-	cmd.Dir = projectDir
+// runCompose runs a compose subcommand that needs no output
+func (p *PodmanService) runCompose(ctx context.Context, projectDir string, projectName string, profiles []string, subcommand string) error {
+	args := append(composeArgs(projectDir, projectName, profiles), subcommand)
+	cmd, err := p.composeCmd(ctx, projectDir, nil, args...)
+	if err != nil {
+		return err
+	}
 	return cmd.Run()
 }
 
 // ComposePull pulls images for a compose stack
-func (p *PodmanService) ComposePull(ctx context.Context, projectDir string, projectName string, outputChan chan<- string) error {
-	args := []string{"-f", projectDir + "/docker-compose.yml"}
-	if projectName != "" {
-		args = append(args, "-p", projectName)
-	}
+func (p *PodmanService) ComposePull(ctx context.Context, projectDir string, projectName string, profiles []string, outputChan chan<- string) error {
+	args := composeArgs(projectDir, projectName, profiles)
 	args = append(args, "pull")
 
-	cmd := exec.CommandContext(ctx, "podman-compose", args...)
-	cmd.Dir = projectDir
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
-
-	stderr, err := cmd.StderrPipe()
+	cmd, err := p.composeCmd(ctx, projectDir, nil, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get stderr pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start compose pull: %w", err)
+		return err
 	}
-
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			if outputChan != nil {
-				outputChan <- scanner.Text()
-			}
-		}
-	}()
-
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			if outputChan != nil {
-				outputChan <- scanner.Text()
-			}
-		}
-	}()
-
-	return cmd.Wait()
+	return runComposeStreaming(cmd, "pull", outputChan)
 }
 
 // ImageConfig represents the configuration hints extracted from an image
@@ -1669,20 +2363,56 @@ func (p *PodmanService) InspectImageWithProgress(ctx context.Context, image stri
 	return config, nil
 }
 
+// ListComposeProjects returns compose projects found on running or stopped containers via compose labels
+func (p *PodmanService) ListComposeProjects(ctx context.Context) ([]models.ComposeProject, error) {
+	containers, err := p.psContainers(ctx, "com.docker.compose.project")
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make(map[string]*models.ComposeProject)
+	var order []string
+	for _, c := range containers {
+		name := c.Labels["com.docker.compose.project"]
+		if name == "" {
+			continue
+		}
+
+		project, ok := projects[name]
+		if !ok {
+			project = &models.ComposeProject{
+				Name:       name,
+				WorkingDir: c.Labels["com.docker.compose.project.working_dir"],
+			}
+			// config_files may be a comma-separated list; the first is the primary file
+			if files := c.Labels["com.docker.compose.project.config_files"]; files != "" {
+				project.ConfigFile = strings.TrimSpace(strings.Split(files, ",")[0])
+			}
+			projects[name] = project
+			order = append(order, name)
+		}
+
+		project.ContainerCount++
+		if mapPodmanStatus(c.State) == models.ContainerStatusRunning {
+			project.RunningCount++
+		}
+	}
+
+	result := make([]models.ComposeProject, 0, len(order))
+	for _, name := range order {
+		result = append(result, *projects[name])
+	}
+	return result, nil
+}
+
 // GetStackContainers returns containers belonging to a compose project
 func (p *PodmanService) GetStackContainers(ctx context.Context, projectName string) ([]models.StackContainer, error) {
 	// List containers with the compose project label
-	output, err := p.podmanCmd(ctx, "ps", "-a", "--format", "json",
-		"--filter", fmt.Sprintf("label=com.docker.compose.project=%s", projectName))
+	containers, err := p.psContainers(ctx, "com.docker.compose.project="+projectName)
 	if err != nil {
 		return nil, err
 	}
 
-	var containers []podmanContainer
-	if err := json.Unmarshal(output, &containers); err != nil {
-		return nil, fmt.Errorf("failed to parse container list: %w", err)
-	}
-
 	result := make([]models.StackContainer, 0, len(containers))
 	for _, c := range containers {
 		name := ""
@@ -1708,17 +2438,30 @@ func (p *PodmanService) GetStackContainers(ctx context.Context, projectName stri
 		}
 
 		result = append(result, models.StackContainer{
-			Name:    name,
-			Service: service,
-			Status:  mapPodmanStatus(c.State),
-			Image:   c.Image,
-			Ports:   ports,
+			Name:     name,
+			Service:  service,
+			Status:   mapPodmanStatus(c.State),
+			Health:   psHealth(c.Status),
+			ExitCode: c.ExitCode,
+			Image:    c.Image,
+			Ports:    ports,
 		})
 	}
 
 	return result, nil
 }
 
+// psHealth reads the health check state from a ps status such as
+// "Up 5 minutes (healthy)"
+func psHealth(status string) string {
+	for _, health := range []string{"unhealthy", "healthy", "starting"} {
+		if strings.HasSuffix(status, "("+health+")") {
+			return health
+		}
+	}
+	return ""
+}
+
 // StorageConfig represents Podman storage configuration
 type StorageConfig struct {
 	GraphRoot  string `json:"graph_root"`
@@ -1910,6 +2653,37 @@ func (p *PodmanService) GetContainerConfig(ctx context.Context, containerID stri
 		config.Icon = val
 	}
 
+	// Privileges and confinement
+	config.Privileged = inspect.HostConfig.Privileged
+	config.CapAdd = inspect.HostConfig.CapAdd
+	config.CapDrop = inspect.HostConfig.CapDrop
+	config.ReadOnlyRootfs = inspect.HostConfig.ReadonlyRootfs
+	for _, opt := range inspect.HostConfig.SecurityOpt {
+		switch {
+		case opt == "no-new-privileges" || opt == "no-new-privileges:true":
+			config.NoNewPrivileges = true
+		case strings.HasPrefix(opt, "seccomp="):
+			config.SeccompProfile = strings.TrimPrefix(opt, "seccomp=")
+		case strings.HasPrefix(opt, "apparmor="):
+			config.AppArmorProfile = strings.TrimPrefix(opt, "apparmor=")
+		}
+	}
+	switch mode := inspect.HostConfig.UsernsMode; {
+	case mode == "auto", mode == "keep-id", mode == "nomap",
+		strings.HasPrefix(mode, "auto:"), strings.HasPrefix(mode, "keep-id:"), strings.HasPrefix(mode, "ns:"):
+		config.UserNS = mode
+	}
+
+	// Log driver and rotation; the size is in podman's own format, which it accepts back
+	config.LogDriver = inspect.HostConfig.LogConfig.Type
+	config.LogMaxSize = inspect.HostConfig.LogConfig.Size
+	config.LogMaxFiles, _ = strconv.Atoi(inspect.HostConfig.LogConfig.Config["max-file"])
+
+	// Preserve user-defined DNS aliases on the container's network
+	if n, ok := inspect.NetworkSettings.Networks[config.NetworkMode]; ok {
+		config.NetworkAliases = filterNetworkAliases(n.Aliases, inspect.ID, config.Name)
+	}
+
 	// Parse port bindings
 	for portSpec, bindings := range inspect.HostConfig.PortBindings {
 		parts := strings.Split(portSpec, "/")
@@ -1937,6 +2711,7 @@ func (p *PodmanService) GetContainerConfig(ctx context.Context, containerID stri
 			Target:   mount.Destination,
 			ReadOnly: !mount.RW,
 			Type:     mount.Type,
+			Name:     mount.Name,
 		})
 	}
 
@@ -2162,32 +2937,32 @@ func (p *PodmanService) GetImageDigest(ctx context.Context, image string) (strin
 	return strings.TrimSpace(string(output)), nil
 }
 
-// CheckImageUpdate checks if a newer version of the image is available
-// Returns true if an update is available, along with local and remote digests
-func (p *PodmanService) CheckImageUpdate(ctx context.Context, image string) (bool, string, string, error) {
-	normalizedImage := normalizeImageName(image)
-
-	// Get local digest
-	localDigest, err := p.GetImageDigest(ctx, normalizedImage)
-	if err != nil {
-		return false, "", "", fmt.Errorf("failed to get local image digest: %w", err)
+// containerSecurityArgs maps security options to podman create flags
+func containerSecurityArgs(opts *models.ContainerSecurityOptions) []string {
+	var args []string
+	if opts.Privileged {
+		args = append(args, "--privileged")
 	}
-
-	// Pull to check for updates (this will update if newer is available)
-	// We use --quiet to suppress output
-	_, pullErr := p.podmanCmd(ctx, "pull", "--quiet", normalizedImage)
-	if pullErr != nil {
-		return false, localDigest, "", fmt.Errorf("failed to check for updates: %w", pullErr)
+	for _, capability := range opts.CapAdd {
+		args = append(args, "--cap-add", capability)
 	}
-
-	// Get the new digest
-	newDigest, err := p.GetImageDigest(ctx, normalizedImage)
-	if err != nil {
-		return false, localDigest, "", fmt.Errorf("failed to get new image digest: %w", err)
+	for _, capability := range opts.CapDrop {
+		args = append(args, "--cap-drop", capability)
 	}
-
-	// Compare digests
-	hasUpdate := localDigest != newDigest
-
-	return hasUpdate, localDigest, newDigest, nil
+	if opts.NoNewPrivileges {
+		args = append(args, "--security-opt", "no-new-privileges")
+	}
+	if opts.SeccompProfile != "" {
+		args = append(args, "--security-opt", "seccomp="+opts.SeccompProfile)
+	}
+	if opts.AppArmorProfile != "" {
+		args = append(args, "--security-opt", "apparmor="+opts.AppArmorProfile)
+	}
+	if opts.ReadOnlyRootfs {
+		args = append(args, "--read-only")
+	}
+	if opts.UserNS != "" {
+		args = append(args, "--userns", opts.UserNS)
+	}
+	return args
 }
@@ -0,0 +1,241 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// podmanAPIVersion is the libpod API version requested; Podman 4 and
+	// later serve it
+	podmanAPIVersion = "v4.0.0"
+
+	// podmanAPIRetry is how long the CLI is used after the socket fails
+	// before the socket is tried again
+	podmanAPIRetry = 30 * time.Second
+)
+
+// errPodmanAPIUnavailable means the API socket can't be reached and the
+// caller should fall back to the podman CLI
+var errPodmanAPIUnavailable = errors.New("podman API socket unavailable")
+
+// podmanAPI is a client for the libpod REST API on Podman's unix socket
+type podmanAPI struct {
+	socket string
+	client *http.Client
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+// podmanSocketPath returns the API socket of the Podman instance in use:
+// STARDECK_PODMAN_SOCKET if set ("none" turns the API off), the target user's
+// rootless socket, or the rootful one
+func (p *PodmanService) podmanSocketPath() string {
+	if env := os.Getenv("STARDECK_PODMAN_SOCKET"); env != "" {
+		if env == "none" {
+			return ""
+		}
+		return strings.TrimPrefix(env, "unix://")
+	}
+	if p.targetUser != "" {
+		u, err := user.Lookup(p.targetUser)
+		if err != nil {
+			return ""
+		}
+		return "/run/user/" + u.Uid + "/podman/podman.sock"
+	}
+	if os.Getuid() == 0 {
+		return "/run/podman/podman.sock"
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir + "/podman/podman.sock"
+	}
+	return fmt.Sprintf("/run/user/%d/podman/podman.sock", os.Getuid())
+}
+
+// api returns the service's API client, set up on first use
+func (p *PodmanService) api() *podmanAPI {
+	p.apiOnce.Do(func() {
+		socket := p.podmanSocketPath()
+		p.apiClient = &podmanAPI{
+			socket: socket,
+			client: &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						var d net.Dialer
+						return d.DialContext(ctx, "unix", socket)
+					},
+					MaxIdleConns:    8,
+					IdleConnTimeout: 90 * time.Second,
+				},
+			},
+		}
+	})
+	return p.apiClient
+}
+
+// APISocket returns the Podman API socket in use, or "" while Stardeck is
+// running the podman CLI instead
+func (p *PodmanService) APISocket() string {
+	a := p.api()
+	if !a.available() {
+		return ""
+	}
+	return a.socket
+}
+
+// available reports whether the socket is worth trying
+func (a *podmanAPI) available() bool {
+	if a.socket == "" {
+		return false
+	}
+	a.mu.Lock()
+	down := time.Now().Before(a.downUntil)
+	a.mu.Unlock()
+	if down {
+		return false
+	}
+	_, err := os.Stat(a.socket)
+	return err == nil
+}
+
+func (a *podmanAPI) markDown(err error) {
+	a.mu.Lock()
+	a.downUntil = time.Now().Add(podmanAPIRetry)
+	a.mu.Unlock()
+	log.Printf("Warning: Podman API socket %s failed, using the podman CLI for %s: %v", a.socket, podmanAPIRetry, err)
+}
+
+// do sends a request to the libpod API. It returns errPodmanAPIUnavailable if
+// the socket can't be reached, and the API's message for error responses.
+func (a *podmanAPI) do(ctx context.Context, method, path string, query url.Values) (*http.Response, error) {
+	if !a.available() {
+		return nil, errPodmanAPIUnavailable
+	}
+
+	u := "http://podman/" + podmanAPIVersion + "/libpod" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if podmanDebug {
+		log.Printf("[PODMAN] API %s %s", method, u)
+	}
+	startTime := time.Now()
+	resp, err := a.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		a.markDown(err)
+		return nil, errPodmanAPIUnavailable
+	}
+	if podmanDebug {
+		log.Printf("[PODMAN] API %s %s answered %d in %v", method, u, resp.StatusCode, time.Since(startTime))
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return nil, fmt.Errorf("podman error: %s", apiErr.Message)
+	}
+	return resp, nil
+}
+
+// getJSON decodes the response to a libpod API GET request
+func (a *podmanAPI) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	resp, err := a.do(ctx, http.MethodGet, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse podman API response: %w", err)
+	}
+	return nil
+}
+
+// podmanFilters encodes libpod list filters for a query string
+func podmanFilters(filters map[string][]string) string {
+	data, _ := json.Marshal(filters)
+	return string(data)
+}
+
+// psStatus rebuilds the status text podman ps prints, such as "Up 5 minutes
+// (healthy)" or "Exited (0) 2 hours ago". The API only reports the health.
+func psStatus(c *podmanContainer) string {
+	health := ""
+	if c.Status != "" {
+		health = " (" + c.Status + ")"
+	}
+	switch c.State {
+	case "running":
+		return "Up " + humanDuration(time.Since(time.Unix(c.StartedAt, 0))) + health
+	case "paused":
+		return "Up " + humanDuration(time.Since(time.Unix(c.StartedAt, 0))) + " (Paused)"
+	case "exited", "stopped":
+		return fmt.Sprintf("Exited (%d) %s ago", c.ExitCode, humanDuration(time.Since(time.Unix(c.ExitedAt, 0))))
+	case "created", "configured", "initialized":
+		return "Created"
+	}
+	if c.State == "" {
+		return ""
+	}
+	return strings.ToUpper(c.State[:1]) + c.State[1:]
+}
+
+// humanDuration describes a duration the way podman ps does
+func humanDuration(d time.Duration) string {
+	switch seconds := int(d.Seconds()); {
+	case seconds < 1:
+		return "Less than a second"
+	case seconds == 1:
+		return "1 second"
+	case seconds < 60:
+		return fmt.Sprintf("%d seconds", seconds)
+	}
+	switch minutes := int(d.Minutes()); {
+	case minutes == 1:
+		return "About a minute"
+	case minutes < 60:
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	switch hours := int(d.Hours() + 0.5); {
+	case hours == 1:
+		return "About an hour"
+	case hours < 48:
+		return fmt.Sprintf("%d hours", hours)
+	case hours < 24*7*2:
+		return fmt.Sprintf("%d days", hours/24)
+	case hours < 24*30*2:
+		return fmt.Sprintf("%d weeks", hours/24/7)
+	case hours < 24*365*2:
+		return fmt.Sprintf("%d months", hours/24/30)
+	default:
+		return fmt.Sprintf("%d years", hours/24/365)
+	}
+}
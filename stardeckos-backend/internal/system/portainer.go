@@ -0,0 +1,154 @@
+package system
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// portainerStackCompose is Portainer's type for standalone compose stacks; swarm
+// (1) and Kubernetes (3) stacks can't be migrated
+const portainerStackCompose = 2
+
+// PortainerStack is a compose stack read from a Portainer instance
+type PortainerStack struct {
+	ID         int
+	Name       string
+	EndpointID int
+	Running    bool
+	Env        []PortainerEnvVar
+}
+
+// PortainerEnvVar is a stack environment variable as Portainer stores it
+type PortainerEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PortainerClient reads stacks from the Portainer API using an access token
+type PortainerClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewPortainerClient checks a Portainer URL and returns a client for it.
+// insecure accepts the self-signed certificate Portainer generates by default.
+func NewPortainerClient(baseURL, apiKey string, insecure bool) (*PortainerClient, error) {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("portainer URL must look like https://portainer.example.com:9443")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("a Portainer access token is required")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &PortainerClient{
+		baseURL: strings.TrimSuffix(u.String(), "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (p *PortainerClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", p.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Portainer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("portainer rejected the access token")
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("portainer returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse Portainer response: %w", err)
+	}
+	return nil
+}
+
+// ListStacks returns the standalone compose stacks. Swarm and Kubernetes
+// stacks are skipped since Podman can't run them.
+func (p *PortainerClient) ListStacks(ctx context.Context) ([]PortainerStack, error) {
+	var raw []struct {
+		ID         int               `json:"Id"`
+		Name       string            `json:"Name"`
+		Type       int               `json:"Type"`
+		EndpointID int               `json:"EndpointId"`
+		Status     int               `json:"Status"` // 1 active, 2 inactive
+		Env        []PortainerEnvVar `json:"Env"`
+	}
+	if err := p.get(ctx, "/api/stacks", &raw); err != nil {
+		return nil, err
+	}
+
+	stacks := make([]PortainerStack, 0, len(raw))
+	for _, s := range raw {
+		if s.Type != portainerStackCompose {
+			continue
+		}
+		stacks = append(stacks, PortainerStack{
+			ID:         s.ID,
+			Name:       s.Name,
+			EndpointID: s.EndpointID,
+			Running:    s.Status == 1,
+			Env:        s.Env,
+		})
+	}
+	return stacks, nil
+}
+
+// StackFile returns a stack's compose file
+func (p *PortainerClient) StackFile(ctx context.Context, id int) (string, error) {
+	var file struct {
+		StackFileContent string `json:"StackFileContent"`
+	}
+	if err := p.get(ctx, fmt.Sprintf("/api/stacks/%d/file", id), &file); err != nil {
+		return "", err
+	}
+	return file.StackFileContent, nil
+}
+
+// envQuoteReplacer escapes a value for a double-quoted .env entry
+var envQuoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`)
+
+// PortainerEnvFile renders Portainer environment variables as a .env file
+func PortainerEnvFile(vars []PortainerEnvVar) string {
+	var b strings.Builder
+	for _, v := range vars {
+		if v.Name == "" {
+			continue
+		}
+		value := v.Value
+		switch {
+		case !strings.ContainsAny(value, " \t#\"'$\\\n"):
+		case !strings.ContainsAny(value, "'\n"):
+			// Single quotes keep the value literal
+			value = "'" + value + "'"
+		default:
+			value = `"` + envQuoteReplacer.Replace(value) + `"`
+		}
+		fmt.Fprintf(&b, "%s=%s\n", v.Name, value)
+	}
+	return b.String()
+}
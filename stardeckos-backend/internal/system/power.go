@@ -0,0 +1,164 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const powercapRoot = "/sys/class/powercap"
+
+// RAPLReading is a snapshot of the package energy counters exposed through
+// powercap (Intel RAPL, and AMD on recent kernels), keyed by zone
+type RAPLReading struct {
+	EnergyUJ map[string]uint64
+	MaxUJ    map[string]uint64
+}
+
+// ReadRAPL reads the top-level package energy counters. ok is false when the
+// host has no readable powercap zones (VMs, ARM boards, or no permission).
+func ReadRAPL() (RAPLReading, bool) {
+	reading := RAPLReading{EnergyUJ: make(map[string]uint64), MaxUJ: make(map[string]uint64)}
+	zones, _ := filepath.Glob(filepath.Join(powercapRoot, "*-rapl:*"))
+	for _, zone := range zones {
+		// Subzones (core, uncore, dram) are already included in their package
+		if strings.Count(filepath.Base(zone), ":") != 1 {
+			continue
+		}
+		energy, err := readUintFile(filepath.Join(zone, "energy_uj"))
+		if err != nil {
+			continue
+		}
+		reading.EnergyUJ[zone] = energy
+		if max, err := readUintFile(filepath.Join(zone, "max_energy_range_uj")); err == nil {
+			reading.MaxUJ[zone] = max
+		}
+	}
+	return reading, len(reading.EnergyUJ) > 0
+}
+
+// JoulesSince returns the energy used since an earlier reading, handling counter wrap-around
+func (r RAPLReading) JoulesSince(prev RAPLReading) float64 {
+	var total uint64
+	for zone, energy := range r.EnergyUJ {
+		before, ok := prev.EnergyUJ[zone]
+		if !ok {
+			continue
+		}
+		if energy >= before {
+			total += energy - before
+		} else if max := r.MaxUJ[zone]; max > 0 {
+			total += max - before + energy
+		}
+	}
+	return float64(total) / 1e6
+}
+
+// HostCPUTime returns the host's busy and total CPU time in seconds, summed over all cores
+func HostCPUTime() (busy, total float64, err error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("unexpected /proc/stat format")
+	}
+
+	// Fields are user nice system idle iowait irq softirq steal ..., in USER_HZ ticks
+	var idle float64
+	for i, field := range fields[1:] {
+		if i >= 8 {
+			break // guest time is already counted in user
+		}
+		v, _ := strconv.ParseFloat(field, 64)
+		total += v
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+	const userHZ = 100
+	return (total - idle) / userHZ, total / userHZ, nil
+}
+
+// ContainerCPUTime returns the cumulative CPU time in seconds of each running
+// container, read from its cgroup, keyed by Podman container ID
+func (p *PodmanService) ContainerCPUTime(ctx context.Context, containerIDs []string) (map[string]float64, error) {
+	usage := make(map[string]float64)
+	if len(containerIDs) == 0 {
+		return usage, nil
+	}
+
+	args := append([]string{"inspect", "--format", "{{.Id}} {{.State.Pid}}"}, containerIDs...)
+	output, err := p.podmanCmd(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		id, pidStr, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil || pid <= 0 {
+			continue
+		}
+		if seconds, err := processCgroupCPUTime(pid); err == nil {
+			usage[id] = seconds
+		}
+	}
+	return usage, nil
+}
+
+// processCgroupCPUTime reads the CPU time of the cgroup a process belongs to
+func processCgroupCPUTime(pid int) (float64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		// cgroup v2 unified hierarchy
+		if parts[0] == "0" && parts[1] == "" {
+			stat, err := os.ReadFile(filepath.Join("/sys/fs/cgroup", parts[2], "cpu.stat"))
+			if err != nil {
+				return 0, err
+			}
+			for _, statLine := range strings.Split(string(stat), "\n") {
+				if v, ok := strings.CutPrefix(statLine, "usage_usec "); ok {
+					usec, err := strconv.ParseFloat(v, 64)
+					return usec / 1e6, err
+				}
+			}
+			return 0, fmt.Errorf("usage_usec missing from cpu.stat")
+		}
+		// cgroup v1 cpuacct controller
+		for _, controller := range strings.Split(parts[1], ",") {
+			if controller == "cpuacct" {
+				ns, err := readUintFile(filepath.Join("/sys/fs/cgroup/cpuacct", parts[2], "cpuacct.usage"))
+				return float64(ns) / 1e9, err
+			}
+		}
+	}
+	return 0, fmt.Errorf("no CPU accounting cgroup for pid %d", pid)
+}
+
+// readUintFile reads a sysfs file holding a single unsigned integer
+func readUintFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
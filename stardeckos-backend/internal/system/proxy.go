@@ -0,0 +1,199 @@
+package system
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"stardeckos-backend/internal/models"
+)
+
+// containersProxyConfPath passes the proxy to Podman and Buildah, including
+// pulls Stardeck doesn't start such as podman-auto-update
+const containersProxyConfPath = "/etc/containers/containers.conf.d/stardeck-proxy.conf"
+
+// outboundProxy is the proxy configuration in effect
+var outboundProxy struct {
+	mu       sync.RWMutex
+	settings models.OutboundProxySettings
+}
+
+func init() {
+	// Stardeck's clients use the default transport; its proxy follows the
+	// settings rather than the environment at startup
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Proxy = OutboundProxy
+	}
+}
+
+// ValidateProxySettings normalizes the proxy settings and checks the proxy URLs
+func ValidateProxySettings(s *models.OutboundProxySettings) error {
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{{"http_proxy", &s.HTTPProxy}, {"https_proxy", &s.HTTPSProxy}} {
+		*field.value = strings.TrimSpace(*field.value)
+		if *field.value == "" {
+			continue
+		}
+		u, err := url.Parse(*field.value)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("%s must be a URL such as http://proxy.example.com:3128", field.name)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("%s must use http, https or socks5", field.name)
+		}
+		if strings.ContainsAny(*field.value, "\" \t\r\n") {
+			return fmt.Errorf("%s can't contain quotes or whitespace", field.name)
+		}
+	}
+
+	noProxy := make([]string, 0, len(s.NoProxy))
+	for _, entry := range s.NoProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.ContainsAny(entry, ",\" \t\r\n") {
+			return fmt.Errorf("invalid no_proxy entry '%s'", entry)
+		}
+		noProxy = append(noProxy, entry)
+	}
+	s.NoProxy = noProxy
+	return nil
+}
+
+// httpsProxy returns the proxy for HTTPS requests
+func httpsProxy(s models.OutboundProxySettings) string {
+	if s.HTTPSProxy != "" {
+		return s.HTTPSProxy
+	}
+	return s.HTTPProxy
+}
+
+// RedactProxySettings hides proxy passwords, e.g. for the audit log
+func RedactProxySettings(s models.OutboundProxySettings) models.OutboundProxySettings {
+	for _, value := range []*string{&s.HTTPProxy, &s.HTTPSProxy} {
+		if u, err := url.Parse(*value); err == nil && *value != "" {
+			*value = u.Redacted()
+		}
+	}
+	return s
+}
+
+// ProxyEnv returns the proxy environment variables in both cases, as tools
+// disagree on which they read
+func ProxyEnv() []string {
+	outboundProxy.mu.RLock()
+	s := outboundProxy.settings
+	outboundProxy.mu.RUnlock()
+
+	var env []string
+	add := func(name, value string) {
+		if value != "" {
+			env = append(env, name+"="+value, strings.ToUpper(name)+"="+value)
+		}
+	}
+	add("http_proxy", s.HTTPProxy)
+	add("https_proxy", httpsProxy(s))
+	if len(env) > 0 {
+		add("no_proxy", strings.Join(append([]string{"localhost", "127.0.0.1", "::1"}, s.NoProxy...), ","))
+	}
+	return env
+}
+
+// ApplyProxySettings puts proxy settings into effect for Stardeck's requests
+// and the tools it runs, which inherit its environment, and writes the
+// containers.conf drop-in for Podman
+func ApplyProxySettings(s models.OutboundProxySettings) error {
+	outboundProxy.mu.Lock()
+	outboundProxy.settings = s
+	outboundProxy.mu.Unlock()
+
+	for _, name := range []string{"http_proxy", "https_proxy", "no_proxy"} {
+		os.Unsetenv(name)
+		os.Unsetenv(strings.ToUpper(name))
+	}
+	env := ProxyEnv()
+	for _, pair := range env {
+		name, value, _ := strings.Cut(pair, "=")
+		os.Setenv(name, value)
+	}
+
+	if len(env) == 0 {
+		if err := os.Remove(containersProxyConfPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", containersProxyConfPath, err)
+		}
+		return nil
+	}
+	quoted := make([]string, len(env))
+	for i, pair := range env {
+		quoted[i] = strconv.Quote(pair)
+	}
+	content := "# Managed by Stardeck; changes are overwritten\n[engine]\nenv = [" + strings.Join(quoted, ", ") + "]\n"
+	if err := os.MkdirAll(filepath.Dir(containersProxyConfPath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(containersProxyConfPath), err)
+	}
+	// The proxy URL may carry credentials
+	if err := os.WriteFile(containersProxyConfPath, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", containersProxyConfPath, err)
+	}
+	return nil
+}
+
+// OutboundProxy picks the proxy for a request from the current settings, for
+// use as an http.Transport's Proxy
+func OutboundProxy(req *http.Request) (*url.URL, error) {
+	outboundProxy.mu.RLock()
+	s := outboundProxy.settings
+	outboundProxy.mu.RUnlock()
+
+	proxy := s.HTTPProxy
+	if req.URL.Scheme == "https" {
+		proxy = httpsProxy(s)
+	}
+	if proxy == "" || bypassProxy(req.URL.Hostname(), s.NoProxy) {
+		return nil, nil
+	}
+	return url.Parse(proxy)
+}
+
+// bypassProxy reports whether a host is reached directly. Entries match a
+// host and its subdomains, an IP address or a CIDR; "*" matches everything.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+	for _, entry := range noProxy {
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		domain := strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	rcloneConfigPath   = "/var/lib/stardeck/rclone/rclone.conf"
+	rcloneCacheDir     = "/var/cache/stardeck/rclone"
+	rcloneUnitDir      = "/etc/systemd/system"
+	cloudMountTimeout  = 10 * time.Second
+	cloudMountUnitName = "stardeck-rclone-%s.service"
+)
+
+// CloudNamePattern restricts remote and mount names, which appear in rclone
+// paths, unit names and volume names
+var CloudNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// CloudCacheModes are the accepted rclone --vfs-cache-mode values
+var CloudCacheModes = []string{"off", "minimal", "writes", "full"}
+
+// cloudRemoteOption is a setting Stardeck passes through to rclone
+type cloudRemoteOption struct {
+	secret   bool
+	required bool
+}
+
+// cloudRemoteOptions lists the options accepted for each remote type
+var cloudRemoteOptions = map[string]map[string]cloudRemoteOption{
+	models.CloudRemoteS3: {
+		"provider":            {required: true}, // AWS, Minio, Wasabi, Cloudflare, Other...
+		"access_key_id":       {},
+		"secret_access_key":   {secret: true},
+		"env_auth":            {},
+		"region":              {},
+		"endpoint":            {},
+		"location_constraint": {},
+		"acl":                 {},
+		"storage_class":       {},
+	},
+	models.CloudRemoteDrive: {
+		"client_id":      {},
+		"client_secret":  {secret: true},
+		"scope":          {},
+		"root_folder_id": {},
+		"team_drive":     {},
+		"token":          {secret: true, required: true},
+	},
+	models.CloudRemoteDropbox: {
+		"client_id":     {},
+		"client_secret": {secret: true},
+		"token":         {secret: true, required: true},
+	},
+}
+
+// RcloneAvailable reports whether rclone is installed
+func RcloneAvailable() bool {
+	_, err := exec.LookPath("rclone")
+	return err == nil
+}
+
+func rcloneCmd(ctx context.Context, args ...string) ([]byte, error) {
+	if !RcloneAvailable() {
+		return nil, fmt.Errorf("rclone is not installed")
+	}
+	if err := os.MkdirAll(filepath.Dir(rcloneConfigPath), 0700); err != nil {
+		return nil, err
+	}
+	args = append(args, "--config", rcloneConfigPath)
+	output, err := exec.CommandContext(ctx, "rclone", args...).CombinedOutput()
+	if err != nil {
+		return output, fmt.Errorf("rclone %s failed: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return output, nil
+}
+
+// ValidateCloudRemote checks a remote's name, type and options. Required
+// options may be missing on update, when the remote already has them.
+func ValidateCloudRemote(req *models.CloudRemoteRequest, update bool) error {
+	if !CloudNamePattern.MatchString(req.Name) {
+		return fmt.Errorf("name must be up to 32 lowercase letters, digits, '-' or '_'")
+	}
+	allowed, ok := cloudRemoteOptions[req.Type]
+	if !ok {
+		return fmt.Errorf("type must be s3, drive or dropbox")
+	}
+	for key, value := range req.Options {
+		if _, ok := allowed[key]; !ok {
+			return fmt.Errorf("option %s is not supported for %s remotes", key, req.Type)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("option %s can't span lines", key)
+		}
+	}
+	if !update {
+		for key, opt := range allowed {
+			if opt.required && req.Options[key] == "" {
+				return fmt.Errorf("option %s is required for %s remotes", key, req.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// SaveCloudRemote creates a remote, or updates the given options of an existing one
+func SaveCloudRemote(ctx context.Context, req *models.CloudRemoteRequest, update bool) error {
+	args := []string{"config", "create", req.Name, req.Type}
+	if update {
+		args = []string{"config", "update", req.Name}
+	}
+	keys := make([]string, 0, len(req.Options))
+	for key := range req.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key, req.Options[key])
+	}
+	// The token is supplied, so rclone must not start its own OAuth flow
+	args = append(args, "--non-interactive", "--obscure")
+	_, err := rcloneCmd(ctx, args...)
+	return err
+}
+
+// ListCloudRemotes returns the configured remotes without their secrets
+func ListCloudRemotes(ctx context.Context) ([]models.CloudRemote, error) {
+	if _, err := os.Stat(rcloneConfigPath); os.IsNotExist(err) {
+		return []models.CloudRemote{}, nil
+	}
+	output, err := rcloneCmd(ctx, "config", "dump")
+	if err != nil {
+		return nil, err
+	}
+	var dump map[string]map[string]string
+	if err := json.Unmarshal(output, &dump); err != nil {
+		return nil, fmt.Errorf("failed to parse rclone config: %w", err)
+	}
+
+	remotes := make([]models.CloudRemote, 0, len(dump))
+	for name, options := range dump {
+		r := models.CloudRemote{Name: name, Type: options["type"], Options: map[string]string{}, SecretsSet: []string{}}
+		for key, value := range options {
+			if key == "type" {
+				continue
+			}
+			if opt, ok := cloudRemoteOptions[r.Type][key]; ok && opt.secret {
+				r.SecretsSet = append(r.SecretsSet, key)
+				continue
+			}
+			r.Options[key] = value
+		}
+		sort.Strings(r.SecretsSet)
+		remotes = append(remotes, r)
+	}
+	sort.Slice(remotes, func(i, j int) bool { return remotes[i].Name < remotes[j].Name })
+	return remotes, nil
+}
+
+// GetCloudRemote returns one remote without its secrets
+func GetCloudRemote(ctx context.Context, name string) (*models.CloudRemote, error) {
+	remotes, err := ListCloudRemotes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range remotes {
+		if remotes[i].Name == name {
+			return &remotes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("remote not found: %s", name)
+}
+
+// DeleteCloudRemote removes a remote from the rclone config
+func DeleteCloudRemote(ctx context.Context, name string) error {
+	_, err := rcloneCmd(ctx, "config", "delete", name)
+	return err
+}
+
+// TestCloudRemote lists the top level of a remote to check its credentials
+func TestCloudRemote(ctx context.Context, name, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	_, err := rcloneCmd(ctx, "lsf", name+":"+path, "--max-depth", "1", "--dirs-only")
+	return err
+}
+
+// cloudMountUnit returns the systemd unit that runs a mount
+func cloudMountUnit(name string) string {
+	return fmt.Sprintf(cloudMountUnitName, name)
+}
+
+// cloudMountUnitContent builds the systemd service running rclone mount
+func cloudMountUnitContent(m *models.CloudMount) string {
+	args := []string{
+		"/usr/bin/env", "rclone", "mount", m.Remote + ":" + m.RemotePath, m.MountPath,
+		"--config", rcloneConfigPath,
+		"--cache-dir", filepath.Join(rcloneCacheDir, m.Name),
+		"--vfs-cache-mode", m.CacheMode,
+		// Containers run as other users and must see the files
+		"--allow-other",
+		"--umask", "002",
+	}
+	if m.ReadOnly {
+		args = append(args, "--read-only")
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = systemdQuote(arg)
+	}
+	return fmt.Sprintf(`# Managed by Stardeck - changes are overwritten
+[Unit]
+Description=Stardeck cloud mount %s
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=notify
+ExecStartPre=/bin/mkdir -p %s
+ExecStart=%s
+ExecStop=/bin/fusermount -uz %s
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=multi-user.target
+`, m.Name, systemdQuote(m.MountPath), strings.Join(quoted, " "), systemdQuote(m.MountPath))
+}
+
+// systemdQuote quotes a command-line argument for a unit file, where % starts a specifier
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// StartCloudMount installs a mount's unit and starts it, enabled at boot
+func StartCloudMount(m *models.CloudMount) error {
+	if !RcloneAvailable() {
+		return fmt.Errorf("rclone is not installed")
+	}
+	if err := os.MkdirAll(m.MountPath, 0755); err != nil {
+		return fmt.Errorf("failed to create mount point: %w", err)
+	}
+	unitPath := filepath.Join(rcloneUnitDir, cloudMountUnit(m.Name))
+	if err := os.WriteFile(unitPath, []byte(cloudMountUnitContent(m)), 0644); err != nil {
+		return fmt.Errorf("failed to write mount unit: %w", err)
+	}
+	if output, err := exec.Command("systemctl", "daemon-reload").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reload systemd: %s - %s", err, string(output))
+	}
+	if output, err := exec.Command("systemctl", "enable", cloudMountUnit(m.Name)).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to enable mount: %s - %s", err, string(output))
+	}
+	// Restart rather than start, so a changed unit takes effect
+	if output, err := exec.Command("systemctl", "restart", cloudMountUnit(m.Name)).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to start mount: %s - %s", err, string(output))
+	}
+	return nil
+}
+
+// StopCloudMount stops a mount and removes its unit
+func StopCloudMount(m *models.CloudMount) error {
+	unit := cloudMountUnit(m.Name)
+	unitPath := filepath.Join(rcloneUnitDir, unit)
+	if _, err := os.Stat(unitPath); os.IsNotExist(err) {
+		return nil
+	}
+	if output, err := exec.Command("systemctl", "disable", "--now", unit).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stop mount: %s - %s", err, string(output))
+	}
+	if err := os.Remove(unitPath); err != nil {
+		return err
+	}
+	exec.Command("systemctl", "daemon-reload").Run()
+	return nil
+}
+
+// RestartCloudMount restarts a mount's unit after a failure
+func RestartCloudMount(m *models.CloudMount) error {
+	if output, err := exec.Command("systemctl", "restart", cloudMountUnit(m.Name)).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart mount: %s - %s", err, string(output))
+	}
+	return nil
+}
+
+// isRcloneMount reports whether path is an active rclone FUSE mount
+func isRcloneMount(path string) bool {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Fields: id parent major:minor root mountpoint options ... - fstype source superoptions
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || fields[4] != path {
+			continue
+		}
+		for i, field := range fields {
+			if field == "-" && i+1 < len(fields) {
+				return strings.HasPrefix(fields[i+1], "fuse.rclone")
+			}
+		}
+	}
+	return false
+}
+
+// CheckCloudMount reports a mount's health. A stalled remote can hang
+// filesystem calls, so the directory read is given a deadline.
+func CheckCloudMount(m *models.CloudMount) (string, error) {
+	if !m.Enabled {
+		return models.CloudMountStopped, nil
+	}
+	if !isRcloneMount(m.MountPath) {
+		state, _ := exec.Command("systemctl", "is-active", cloudMountUnit(m.Name)).Output()
+		return models.CloudMountUnmounted, fmt.Errorf("not mounted (unit %s)", strings.TrimSpace(string(state)))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		f, err := os.Open(m.MountPath)
+		if err == nil {
+			if _, err = f.Readdirnames(1); errors.Is(err, io.EOF) {
+				err = nil // Empty directory
+			}
+			f.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return models.CloudMountUnhealthy, err
+		}
+		return models.CloudMountHealthy, nil
+	case <-time.After(cloudMountTimeout):
+		return models.CloudMountUnhealthy, fmt.Errorf("mount did not respond within %s", cloudMountTimeout)
+	}
+}
@@ -0,0 +1,128 @@
+package system
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Source RCON packet types, also spoken by Minecraft, Factorio, ARK and Rust
+const (
+	rconTypeResponse = 0
+	rconTypeCommand  = 2
+	rconTypeAuth     = 3
+	rconMaxBody      = 4096    // Longest command body servers accept
+	rconMaxPacket    = 1 << 16 // Responses can exceed the command limit
+)
+
+// RCONClient is an authenticated Source RCON connection
+type RCONClient struct {
+	conn   net.Conn
+	nextID int32
+}
+
+// DialRCON connects to an RCON console and logs in
+func DialRCON(addr, password string, timeout time.Duration) (*RCONClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RCON: %w", err)
+	}
+	c := &RCONClient{conn: conn, nextID: 1}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	id, err := c.send(rconTypeAuth, password)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// Some servers send an empty response packet before the auth result
+	for {
+		respID, respType, _, err := c.read()
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if respType != rconTypeCommand {
+			continue
+		}
+		if respID == -1 || respID != id {
+			conn.Close()
+			return nil, fmt.Errorf("RCON authentication failed")
+		}
+		break
+	}
+	return c, nil
+}
+
+// Command runs a console command and returns its output
+func (c *RCONClient) Command(command string, timeout time.Duration) (string, error) {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	id, err := c.send(rconTypeCommand, command)
+	if err != nil {
+		return "", err
+	}
+	for {
+		respID, respType, body, err := c.read()
+		if err != nil {
+			return "", err
+		}
+		if respID == id && respType == rconTypeResponse {
+			return body, nil
+		}
+	}
+}
+
+// Close ends the RCON session
+func (c *RCONClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *RCONClient) send(packetType int32, body string) (int32, error) {
+	if len(body) > rconMaxBody {
+		return 0, fmt.Errorf("RCON command is too long")
+	}
+	id := c.nextID
+	c.nextID++
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)+10))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, packetType)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("failed to send RCON packet: %w", err)
+	}
+	return id, nil
+}
+
+func (c *RCONClient) read() (int32, int32, string, error) {
+	var size int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", fmt.Errorf("failed to read RCON response: %w", err)
+	}
+	if size < 10 || size > rconMaxPacket {
+		return 0, 0, "", fmt.Errorf("invalid RCON packet size %d", size)
+	}
+	packet := make([]byte, size)
+	if _, err := io.ReadFull(c.conn, packet); err != nil {
+		return 0, 0, "", fmt.Errorf("failed to read RCON response: %w", err)
+	}
+	id := int32(binary.LittleEndian.Uint32(packet[0:4]))
+	packetType := int32(binary.LittleEndian.Uint32(packet[4:8]))
+	body := string(bytes.TrimRight(packet[8:], "\x00"))
+	return id, packetType, body, nil
+}
+
+// RunRCONCommand logs in, runs one command and disconnects
+func RunRCONCommand(addr, password, command string) (string, error) {
+	client, err := DialRCON(addr, password, 5*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	return client.Command(command, 10*time.Second)
+}
@@ -0,0 +1,168 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// RealmdAvailable reports whether realmd (the realm command) is installed
+func RealmdAvailable() bool {
+	_, err := exec.LookPath("realm")
+	return err == nil
+}
+
+// SSSDActive reports whether the sssd service is running
+func SSSDActive() bool {
+	return exec.Command("systemctl", "is-active", "--quiet", "sssd").Run() == nil
+}
+
+// DiscoverDomain looks up an AD or FreeIPA domain via DNS. An empty domain
+// discovers whatever the host's DHCP/DNS configuration points at.
+func DiscoverDomain(ctx context.Context, domain string) ([]models.DomainInfo, error) {
+	args := []string{"discover"}
+	if domain != "" {
+		args = append(args, domain)
+	}
+	output, err := exec.CommandContext(ctx, "realm", args...).Output()
+	if err != nil {
+		// realm discover exits 1 when nothing is found
+		if exitErr, ok := err.(*exec.ExitError); ok && len(strings.TrimSpace(string(exitErr.Stderr))) == 0 {
+			return []models.DomainInfo{}, nil
+		}
+		return nil, realmError(err)
+	}
+	return parseRealmOutput(string(output)), nil
+}
+
+// ListDomains returns the domains this host is joined to
+func ListDomains(ctx context.Context) ([]models.DomainInfo, error) {
+	output, err := exec.CommandContext(ctx, "realm", "list").Output()
+	if err != nil {
+		return nil, realmError(err)
+	}
+	return parseRealmOutput(string(output)), nil
+}
+
+// JoinDomain enrolls the host in a domain and configures SSSD. The password is
+// passed on stdin so it never appears in the process list.
+func JoinDomain(ctx context.Context, req *models.JoinDomainRequest) (string, error) {
+	args := []string{"join", "--verbose", "--user=" + req.Username}
+	if req.ComputerOU != "" {
+		args = append(args, "--computer-ou="+req.ComputerOU)
+	}
+	if req.ComputerName != "" {
+		args = append(args, "--computer-name="+req.ComputerName)
+	}
+	args = append(args, req.Domain)
+
+	cmd := exec.CommandContext(ctx, "realm", args...)
+	cmd.Stdin = strings.NewReader(req.Password + "\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("realm join failed: %s", lastLine(string(output)))
+	}
+	return string(output), nil
+}
+
+// LeaveDomain removes the host from a domain. With a username the computer
+// account is also removed from the directory.
+func LeaveDomain(ctx context.Context, req *models.LeaveDomainRequest) (string, error) {
+	args := []string{"leave", "--verbose"}
+	if req.Username != "" {
+		args = append(args, "--remove", "--user="+req.Username)
+	}
+	args = append(args, req.Domain)
+
+	cmd := exec.CommandContext(ctx, "realm", args...)
+	if req.Username != "" {
+		cmd.Stdin = strings.NewReader(req.Password + "\n")
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("realm leave failed: %s", lastLine(string(output)))
+	}
+	return string(output), nil
+}
+
+// parseRealmOutput parses the indented key: value blocks printed by realm list/discover
+func parseRealmOutput(output string) []models.DomainInfo {
+	domains := []models.DomainInfo{}
+	var current *models.DomainInfo
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			domains = append(domains, models.DomainInfo{Domain: strings.TrimSpace(line)})
+			current = &domains[len(domains)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "type":
+			current.Type = value
+		case "realm-name":
+			current.Realm = value
+		case "domain-name":
+			current.Domain = value
+		case "configured":
+			current.Configured = value != "no"
+		case "server-software":
+			current.ServerSoftware = value
+		case "client-software":
+			current.ClientSoftware = value
+		case "login-formats":
+			current.LoginFormats = append(current.LoginFormats, value)
+		case "login-policy":
+			current.LoginPolicy = value
+		case "permitted-logins":
+			current.PermittedLogins = splitRealmList(value)
+		case "permitted-groups":
+			current.PermittedGroups = splitRealmList(value)
+		case "required-package":
+			current.RequiredPackages = append(current.RequiredPackages, value)
+		}
+	}
+	return domains
+}
+
+// splitRealmList splits realm's comma separated value lists
+func splitRealmList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// realmError turns a failed realm invocation into an error carrying its stderr
+func realmError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s", lastLine(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// lastLine returns the last non-empty line of command output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,95 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// registryTrustDir is where Podman, Buildah and skopeo look up per-registry CA certificates
+const registryTrustDir = "/etc/containers/certs.d"
+
+// HtpasswdAvailable reports whether the htpasswd tool (httpd-tools) is installed
+func HtpasswdAvailable() bool {
+	_, err := exec.LookPath("htpasswd")
+	return err == nil
+}
+
+// WriteHtpasswd creates a bcrypt htpasswd file with a single user. The password is
+// passed on stdin so it never appears in the process list.
+func WriteHtpasswd(ctx context.Context, path, username, password string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create auth directory: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "htpasswd", "-i", "-B", "-c", path, username)
+	cmd.Stdin = strings.NewReader(password)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("htpasswd failed: %s", strings.TrimSpace(string(output)))
+	}
+	return os.Chmod(path, 0600)
+}
+
+// ChownToTargetUser hands files to the rootless Podman user so containers running
+// as that user can read them. It is a no-op when Stardeck is not running as root.
+func (p *PodmanService) ChownToTargetUser(paths ...string) error {
+	if os.Getuid() != 0 || p.targetUser == "" {
+		return nil
+	}
+	u, err := user.Lookup(p.targetUser)
+	if err != nil {
+		return err
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	for _, path := range paths {
+		if err := os.Chown(path, uid, gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TrustRegistryCA installs a CA certificate for a registry address so local
+// Podman and skopeo verify its TLS certificate
+func TrustRegistryCA(address, certPath string) error {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(registryTrustDir, address)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "ca.crt"), data, 0644)
+}
+
+// UntrustRegistryCA removes a CA certificate installed by TrustRegistryCA
+func UntrustRegistryCA(address string) error {
+	return os.RemoveAll(filepath.Join(registryTrustDir, address))
+}
+
+// RunRemoved runs a one-off container that is removed when it exits and returns
+// its standard output
+func (p *PodmanService) RunRemoved(ctx context.Context, image string, volumes []models.VolumeMount, cmd ...string) (string, error) {
+	args := []string{"run", "--rm"}
+	for _, vol := range volumes {
+		volArg := fmt.Sprintf("%s:%s", vol.Source, vol.Target)
+		if vol.ReadOnly {
+			volArg += ":ro"
+		}
+		args = append(args, "-v", volArg)
+	}
+	args = append(args, normalizeImageName(image))
+	args = append(args, cmd...)
+
+	output, err := p.podmanCmd(ctx, args...)
+	return string(output), err
+}
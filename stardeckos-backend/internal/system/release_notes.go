@@ -0,0 +1,108 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"stardeckos-backend/internal/models"
+)
+
+// maxReleaseNoteBody caps each release body, since some projects paste full commit logs
+const maxReleaseNoteBody = 16 << 10
+
+// githubRepoPattern matches the owner/name part of a GitHub repository reference
+var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// GitHubRepository returns owner/name for a GitHub source URL such as
+// https://github.com/linuxserver/docker-sonarr, git@github.com:org/app.git or
+// github.com/org/app/tree/main. It returns "" for other hosts.
+func GitHubRepository(source string) string {
+	source = strings.TrimSpace(source)
+	if rest, ok := strings.CutPrefix(source, "git@github.com:"); ok {
+		source = "https://github.com/" + rest
+	}
+	if !strings.Contains(source, "://") {
+		source = "https://" + source
+	}
+	u, err := url.Parse(source)
+	if err != nil || (u.Host != "github.com" && u.Host != "www.github.com") {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	repo := parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+	if !githubRepoPattern.MatchString(repo) {
+		return ""
+	}
+	return repo
+}
+
+// FetchGitHubReleases returns a repository's most recent releases, newest first.
+// Drafts are skipped.
+func FetchGitHubReleases(ctx context.Context, repo string, limit int) ([]models.ReleaseNote, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=%d", repo, limit), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "Stardeck")
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("repository %s not found on GitHub", repo)
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("GitHub rate limit reached, try again later")
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("GitHub returned status %d", resp.StatusCode)
+	}
+
+	var raw []struct {
+		TagName     string     `json:"tag_name"`
+		Name        string     `json:"name"`
+		Body        string     `json:"body"`
+		HTMLURL     string     `json:"html_url"`
+		Draft       bool       `json:"draft"`
+		Prerelease  bool       `json:"prerelease"`
+		PublishedAt *time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub releases: %w", err)
+	}
+
+	notes := make([]models.ReleaseNote, 0, len(raw))
+	for _, r := range raw {
+		if r.Draft {
+			continue
+		}
+		body := r.Body
+		if len(body) > maxReleaseNoteBody {
+			body = strings.ToValidUTF8(body[:maxReleaseNoteBody], "") + "\n\n…"
+		}
+		notes = append(notes, models.ReleaseNote{
+			Tag:         r.TagName,
+			Name:        r.Name,
+			Body:        body,
+			URL:         r.HTMLURL,
+			Prerelease:  r.Prerelease,
+			PublishedAt: r.PublishedAt,
+		})
+	}
+	return notes, nil
+}
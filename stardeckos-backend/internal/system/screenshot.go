@@ -0,0 +1,137 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Screenshot capture dimensions
+const (
+	ScreenshotWidth  = 1280
+	ScreenshotHeight = 800
+	ThumbnailWidth   = 320
+)
+
+// browserCandidates lists headless-capable browsers in order of preference
+var browserCandidates = []string{
+	"chromium-browser",
+	"chromium",
+	"google-chrome",
+	"google-chrome-stable",
+	"headless-shell",
+}
+
+// FindHeadlessBrowser returns the path to an installed Chromium-based browser
+func FindHeadlessBrowser() (string, error) {
+	for _, name := range browserCandidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no headless browser found (install chromium)")
+}
+
+// CaptureThumbnail renders a URL in a headless browser and writes a scaled PNG thumbnail to outPath
+func CaptureThumbnail(ctx context.Context, url, outPath string) error {
+	browser, err := FindHeadlessBrowser()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("failed to create thumbnail directory: %w", err)
+	}
+
+	// Capture into a scratch profile/file next to the target so a failed run never clobbers the last good thumbnail
+	tmpDir, err := os.MkdirTemp(filepath.Dir(outPath), ".capture-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	rawPath := filepath.Join(tmpDir, "screenshot.png")
+	cmd := exec.CommandContext(ctx, browser,
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--hide-scrollbars",
+		"--ignore-certificate-errors",
+		"--user-data-dir="+filepath.Join(tmpDir, "profile"),
+		fmt.Sprintf("--window-size=%d,%d", ScreenshotWidth, ScreenshotHeight),
+		"--screenshot="+rawPath,
+		url,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("screenshot failed: %s", string(output))
+	}
+
+	src, err := os.Open(rawPath)
+	if err != nil {
+		return fmt.Errorf("browser did not produce a screenshot: %w", err)
+	}
+	img, err := png.Decode(src)
+	src.Close()
+	if err != nil {
+		return fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	thumbPath := filepath.Join(tmpDir, "thumbnail.png")
+	dst, err := os.Create(thumbPath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(dst, scaleImage(img, ThumbnailWidth)); err != nil {
+		dst.Close()
+		return err
+	}
+	dst.Close()
+
+	return os.Rename(thumbPath, outPath)
+}
+
+// scaleImage downsamples an image to the given width using box averaging
+func scaleImage(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if b.Dx() <= width {
+		return src
+	}
+	height := b.Dy() * width / b.Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := b.Min.Y + (y+1)*b.Dy()/height
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := b.Min.X + (x+1)*b.Dx()/width
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
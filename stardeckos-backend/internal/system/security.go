@@ -0,0 +1,183 @@
+package system
+
+import (
+	"bufio"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// SecurityUpdates lists pending security advisories from dnf
+func SecurityUpdates() ([]models.SecurityAdvisory, error) {
+	// Exit status is non-zero when there is nothing to list on some dnf versions
+	output, err := exec.Command("dnf", "updateinfo", "list", "--security", "-q").Output()
+	if err != nil && len(output) == 0 {
+		if _, lookErr := exec.LookPath("dnf"); lookErr != nil {
+			return nil, lookErr
+		}
+	}
+
+	advisories := []models.SecurityAdvisory{}
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		// FEDORA-2024-1234abcd  Important/Sec.  openssl-1:3.1.4-2.fc40.x86_64
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || !strings.Contains(fields[0], "-") {
+			continue
+		}
+		advisories = append(advisories, models.SecurityAdvisory{
+			ID:       fields[0],
+			Severity: strings.TrimSuffix(fields[1], "/Sec."),
+			Package:  fields[2],
+		})
+	}
+	return advisories, nil
+}
+
+// GetPortExposure compares the ports active firewall zones open with the
+// sockets listening on non-loopback addresses. With the firewall stopped,
+// every listening port counts as open.
+func GetPortExposure() ([]models.ReportPort, error) {
+	listening, err := listeningSockets()
+	if err != nil {
+		return nil, err
+	}
+
+	status, _ := GetFirewallStatus()
+	open := make(map[string][]string)
+	var acceptZones []string
+	type portRange struct {
+		low, high int
+		protocol  string
+		zone      string
+	}
+	var ranges []portRange
+	if status != nil && status.Running {
+		zones, err := GetFirewallZones()
+		if err != nil {
+			return nil, err
+		}
+		servicePorts := make(map[string][]string)
+		for _, zone := range zones {
+			if !zone.IsActive && !zone.IsDefault {
+				continue
+			}
+			if zone.Target == "ACCEPT" {
+				acceptZones = append(acceptZones, zone.Name)
+			}
+			specs := append([]string{}, zone.Ports...)
+			for _, service := range zone.Services {
+				if _, ok := servicePorts[service]; !ok {
+					servicePorts[service], _ = FirewallServicePorts(service)
+				}
+				specs = append(specs, servicePorts[service]...)
+			}
+			for _, spec := range specs {
+				// Ranges such as 60000-61000/udp only match listeners
+				portStr, protocol, _ := strings.Cut(spec, "/")
+				if lowStr, highStr, isRange := strings.Cut(portStr, "-"); isRange {
+					low, _ := strconv.Atoi(lowStr)
+					high, _ := strconv.Atoi(highStr)
+					ranges = append(ranges, portRange{low, high, protocol, zone.Name})
+					continue
+				}
+				open[spec] = appendUnique(open[spec], zone.Name)
+			}
+		}
+	}
+
+	for spec := range listening {
+		portStr, protocol, _ := strings.Cut(spec, "/")
+		port, _ := strconv.Atoi(portStr)
+		for _, r := range ranges {
+			if r.protocol == protocol && port >= r.low && port <= r.high {
+				open[spec] = appendUnique(open[spec], r.zone)
+			}
+		}
+		for _, zone := range acceptZones {
+			open[spec] = appendUnique(open[spec], zone)
+		}
+	}
+
+	specs := make(map[string]bool)
+	for spec := range open {
+		specs[spec] = true
+	}
+	for spec := range listening {
+		specs[spec] = true
+	}
+
+	exposure := []models.ReportPort{}
+	firewallDown := status == nil || !status.Running
+	for spec := range specs {
+		portStr, protocol, _ := strings.Cut(spec, "/")
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+		entry := models.ReportPort{
+			Port:         port,
+			Protocol:     protocol,
+			FirewallOpen: firewallDown || len(open[spec]) > 0,
+			Zones:        open[spec],
+			Listening:    len(listening[spec]) > 0,
+			Processes:    listening[spec],
+		}
+		if entry.Zones == nil {
+			entry.Zones = []string{}
+		}
+		if entry.Processes == nil {
+			entry.Processes = []string{}
+		}
+		exposure = append(exposure, entry)
+	}
+	sort.Slice(exposure, func(i, j int) bool {
+		if exposure[i].Port != exposure[j].Port {
+			return exposure[i].Port < exposure[j].Port
+		}
+		return exposure[i].Protocol < exposure[j].Protocol
+	})
+	return exposure, nil
+}
+
+// listeningSockets returns sockets listening on non-loopback addresses, keyed
+// by "port/protocol", with the processes holding them
+func listeningSockets() (map[string][]string, error) {
+	conns, err := GetConnections("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	listening := make(map[string][]string)
+	for _, conn := range conns {
+		if conn.State != "LISTEN" && conn.State != "UNCONN" {
+			continue
+		}
+		host, _, _ := strings.Cut(conn.LocalAddr, "%") // Drop interface scope such as 127.0.0.53%lo
+		if ip := net.ParseIP(host); (ip != nil && ip.IsLoopback()) || conn.LocalPort == 0 {
+			continue
+		}
+		protocol := strings.TrimSuffix(conn.Protocol, "6")
+		spec := strconv.Itoa(conn.LocalPort) + "/" + protocol
+		process := conn.Process
+		if process == "" {
+			process = "unknown"
+		}
+		listening[spec] = appendUnique(listening[spec], process)
+	}
+	return listening, nil
+}
+
+// appendUnique appends v unless the slice already holds it
+func appendUnique(list []string, v string) []string {
+	for _, existing := range list {
+		if existing == v {
+			return list
+		}
+	}
+	return append(list, v)
+}
@@ -0,0 +1,222 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+const (
+	// SeccompProfileDir holds seccomp profiles from the profile store
+	SeccompProfileDir = "/var/lib/stardeck/security-profiles/seccomp"
+	// appArmorProfileDir is where AppArmor loads profiles from at boot
+	appArmorProfileDir = "/etc/apparmor.d"
+	// appArmorFilePrefix marks the AppArmor profiles Stardeck manages
+	appArmorFilePrefix = "stardeck-"
+)
+
+// defaultSeccompPaths are where containers-common installs the runtime's default profile
+var defaultSeccompPaths = []string{"/etc/containers/seccomp.json", "/usr/share/containers/seccomp.json"}
+
+// SecurityProfileNamePattern restricts profile names to safe file names
+var SecurityProfileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// curatedSeccomp describes a curated seccomp profile as the runtime default
+// with further system calls removed from its allow list
+type curatedSeccomp struct {
+	description string
+	deny        []string
+}
+
+var curatedSeccompProfiles = map[string]curatedSeccomp{
+	"no-io-uring": {
+		description: "Runtime default without io_uring, a frequent source of kernel privilege escalation bugs.",
+		deny:        []string{"io_uring_setup", "io_uring_enter", "io_uring_register"},
+	},
+	"no-ptrace": {
+		description: "Runtime default without process tracing or cross-process memory access.",
+		deny:        []string{"ptrace", "process_vm_readv", "process_vm_writev", "kcmp", "pidfd_getfd"},
+	},
+	"restricted": {
+		description: "Runtime default without tracing, namespaces, mounts, kernel keyrings, BPF, perf, " +
+			"userfaultfd, io_uring or file handles. Suits most web apps and databases.",
+		deny: []string{
+			"ptrace", "process_vm_readv", "process_vm_writev", "kcmp", "pidfd_getfd",
+			"unshare", "setns", "mount", "umount", "umount2", "pivot_root", "fsopen", "fsmount", "fsconfig", "move_mount", "open_tree",
+			"keyctl", "add_key", "request_key", "bpf", "perf_event_open", "userfaultfd",
+			"io_uring_setup", "io_uring_enter", "io_uring_register",
+			"name_to_handle_at", "open_by_handle_at", "fanotify_init",
+		},
+	},
+}
+
+// CuratedSecurityProfiles lists the profiles Stardeck ships
+func CuratedSecurityProfiles() []models.CuratedSecurityProfile {
+	names := []string{"restricted", "no-ptrace", "no-io-uring"}
+	profiles := make([]models.CuratedSecurityProfile, 0, len(names))
+	for _, name := range names {
+		profiles = append(profiles, models.CuratedSecurityProfile{
+			Name:        name,
+			Type:        models.SecurityProfileSeccomp,
+			Description: curatedSeccompProfiles[name].description,
+		})
+	}
+	return profiles
+}
+
+// CuratedSecurityProfile renders a curated profile against the host's default
+// seccomp profile, so it is never looser than the runtime default
+func CuratedSecurityProfile(name string) (*models.SecurityProfile, error) {
+	curated, ok := curatedSeccompProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown curated profile %q", name)
+	}
+
+	var base map[string]interface{}
+	for _, path := range defaultSeccompPaths {
+		if data, err := os.ReadFile(path); err == nil {
+			if err := json.Unmarshal(data, &base); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			break
+		}
+	}
+	if base == nil {
+		return nil, fmt.Errorf("the runtime's default seccomp profile was not found; install containers-common")
+	}
+
+	denied := make(map[string]bool, len(curated.deny))
+	for _, syscall := range curated.deny {
+		denied[syscall] = true
+	}
+	rules, _ := base["syscalls"].([]interface{})
+	kept := make([]interface{}, 0, len(rules))
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if rule["action"] == "SCMP_ACT_ALLOW" {
+			names, _ := rule["names"].([]interface{})
+			filtered := make([]interface{}, 0, len(names))
+			for _, n := range names {
+				if s, _ := n.(string); !denied[s] {
+					filtered = append(filtered, n)
+				}
+			}
+			if len(filtered) == 0 {
+				continue
+			}
+			rule["names"] = filtered
+		}
+		kept = append(kept, rule)
+	}
+	base["syscalls"] = kept
+
+	content, err := json.MarshalIndent(base, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return &models.SecurityProfile{
+		Name:        name,
+		Type:        models.SecurityProfileSeccomp,
+		Description: curated.description,
+		Content:     string(content),
+		Curated:     name,
+	}, nil
+}
+
+// DefaultSeccompPath returns the runtime's default seccomp profile, if installed
+func DefaultSeccompPath() string {
+	for _, path := range defaultSeccompPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// AppArmorEnabled reports whether the kernel enforces AppArmor
+func AppArmorEnabled() bool {
+	data, err := os.ReadFile("/sys/module/apparmor/parameters/enabled")
+	return err == nil && strings.TrimSpace(string(data)) == "Y"
+}
+
+// ValidateSecurityProfile checks a profile's content before it is installed
+func ValidateSecurityProfile(p *models.SecurityProfile) error {
+	if !SecurityProfileNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("name must be lowercase letters, digits, '.', '_' or '-'")
+	}
+	switch p.Type {
+	case models.SecurityProfileSeccomp:
+		var profile struct {
+			DefaultAction string            `json:"defaultAction"`
+			Syscalls      []json.RawMessage `json:"syscalls"`
+		}
+		if err := json.Unmarshal([]byte(p.Content), &profile); err != nil {
+			return fmt.Errorf("invalid seccomp profile JSON: %w", err)
+		}
+		if !strings.HasPrefix(profile.DefaultAction, "SCMP_ACT_") {
+			return fmt.Errorf("seccomp profile needs a defaultAction such as SCMP_ACT_ERRNO")
+		}
+	case models.SecurityProfileAppArmor:
+		// Podman refers to AppArmor profiles by the name they declare
+		declared := regexp.MustCompile(`(?m)^\s*profile\s+` + regexp.QuoteMeta(p.Name) + `[\s{]`)
+		if !declared.MatchString(p.Content) {
+			return fmt.Errorf("AppArmor profile must declare 'profile %s'", p.Name)
+		}
+	default:
+		return fmt.Errorf("type must be seccomp or apparmor")
+	}
+	return nil
+}
+
+// SecurityProfilePath is where a profile is installed on the host
+func SecurityProfilePath(p *models.SecurityProfile) string {
+	if p.Type == models.SecurityProfileAppArmor {
+		return filepath.Join(appArmorProfileDir, appArmorFilePrefix+p.Name)
+	}
+	return filepath.Join(SeccompProfileDir, p.Name+".json")
+}
+
+// InstallSecurityProfile writes a profile to the host. Seccomp profiles are
+// read when a container is created; AppArmor profiles are loaded into the
+// kernel immediately and again at boot.
+func InstallSecurityProfile(p *models.SecurityProfile) error {
+	path := SecurityProfilePath(p)
+	if p.Type == models.SecurityProfileAppArmor && !AppArmorEnabled() {
+		return fmt.Errorf("AppArmor is not enabled on this host")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(p.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	if p.Type == models.SecurityProfileAppArmor {
+		if output, err := exec.Command("apparmor_parser", "-r", "-W", path).CombinedOutput(); err != nil {
+			os.Remove(path)
+			return fmt.Errorf("apparmor_parser rejected the profile: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	p.Path = path
+	return nil
+}
+
+// RemoveSecurityProfile unloads and deletes an installed profile
+func RemoveSecurityProfile(p *models.SecurityProfile) error {
+	path := SecurityProfilePath(p)
+	if p.Type == models.SecurityProfileAppArmor && AppArmorEnabled() {
+		exec.Command("apparmor_parser", "-R", path).Run()
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"stardeckos-backend/internal/models"
+)
+
+// SkopeoAvailable reports whether skopeo is installed
+func SkopeoAvailable() bool {
+	_, err := exec.LookPath("skopeo")
+	return err == nil
+}
+
+// skopeoCmd builds a skopeo command. It runs as the rootless Podman user, so
+// containers-storage: references resolve to their images and that user's
+// registry logins apply.
+func (p *PodmanService) skopeoCmd(ctx context.Context, args ...string) *exec.Cmd {
+	if os.Getuid() == 0 && p.targetUser != "" {
+		// sudo resets the environment, so the proxy is passed through env
+		sudoArgs := append(append([]string{"-u", p.targetUser, "env"}, ProxyEnv()...), "skopeo")
+		return exec.CommandContext(ctx, "sudo", append(sudoArgs, args...)...)
+	}
+	return exec.CommandContext(ctx, "skopeo", args...)
+}
+
+// writeAuthFile writes a containers-auth.json file for one registry login and
+// returns its path. The file is readable only by the user skopeo runs as.
+func (p *PodmanService) writeAuthFile(auth *models.RegistryAuth) (string, error) {
+	token := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+	data, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			auth.Registry: map[string]string{"auth": token},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "stardeck-auth-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create auth file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write auth file: %w", err)
+	}
+
+	// When skopeo runs via sudo as the rootless Podman user, that user must be able to read it
+	p.ChownToTargetUser(f.Name())
+	return f.Name(), nil
+}
+
+// CopyImage copies an image between registries (or from local storage) with
+// skopeo, streaming its output. The output channel is closed when the copy ends.
+func (p *PodmanService) CopyImage(ctx context.Context, opts models.ImageCopyOptions, output chan<- string) error {
+	defer close(output)
+
+	args := []string{"copy",
+		"--src-tls-verify=" + strconv.FormatBool(opts.SrcTLSVerify),
+		"--dest-tls-verify=" + strconv.FormatBool(opts.DestTLSVerify),
+	}
+	if opts.AllArchitectures {
+		args = append(args, "--all")
+	}
+
+	if opts.SourceAuth != nil {
+		authFile, err := p.writeAuthFile(opts.SourceAuth)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(authFile)
+		args = append(args, "--src-authfile", authFile)
+	}
+	if opts.DestAuth != nil {
+		authFile, err := p.writeAuthFile(opts.DestAuth)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(authFile)
+		args = append(args, "--dest-authfile", authFile)
+	}
+	args = append(args, opts.Source, opts.Destination)
+
+	cmd := p.skopeoCmd(ctx, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start skopeo: %w", err)
+	}
+
+	var lastLine string
+	scanner := bufio.NewScanner(io.MultiReader(stdout, stderr))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		lastLine = line
+		select {
+		case output <- line:
+		case <-ctx.Done():
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if lastLine != "" {
+			return fmt.Errorf("%s", lastLine)
+		}
+		return err
+	}
+	return nil
+}
@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 )
 
 // Disk represents a physical disk device
@@ -731,3 +734,21 @@ func findFreeSpaceStart(output string) int64 {
 	}
 	return -1
 }
+
+// FreeSpace returns the bytes available to unprivileged users on the
+// filesystem holding path. A path that doesn't exist yet is measured at its
+// nearest existing parent, where it would be created.
+func FreeSpace(path string) (uint64, error) {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
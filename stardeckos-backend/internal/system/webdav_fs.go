@@ -0,0 +1,131 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/net/webdav"
+)
+
+// ConfinedDir is a WebDAV filesystem rooted at a directory that refuses to follow
+// symlinks out of it, and gives new files the root directory's owner
+type ConfinedDir struct {
+	root string
+	dir  webdav.Dir
+	uid  int
+	gid  int
+}
+
+// NewConfinedDir creates a confined WebDAV filesystem for root
+func NewConfinedDir(root string) (*ConfinedDir, error) {
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("share path is not a directory")
+	}
+
+	d := &ConfinedDir{root: resolved, dir: webdav.Dir(resolved), uid: -1, gid: -1}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		d.uid, d.gid = int(st.Uid), int(st.Gid)
+	}
+	return d, nil
+}
+
+// check resolves name and rejects it if it, or its nearest existing parent, lies outside the root
+func (d *ConfinedDir) check(name string) (string, error) {
+	full := filepath.Join(d.root, filepath.FromSlash(path.Clean("/"+name)))
+
+	probe := full
+	for {
+		resolved, err := filepath.EvalSymlinks(probe)
+		if err == nil {
+			if resolved != d.root && !strings.HasPrefix(resolved, d.root+string(os.PathSeparator)) {
+				return "", os.ErrPermission
+			}
+			return full, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(probe)
+		if parent == probe {
+			return "", os.ErrPermission
+		}
+		probe = parent
+	}
+}
+
+// chown gives a newly created path the share root's owner
+func (d *ConfinedDir) chown(full string) {
+	if d.uid >= 0 && os.Getuid() == 0 {
+		os.Lchown(full, d.uid, d.gid)
+	}
+}
+
+// Mkdir creates a directory inside the root
+func (d *ConfinedDir) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	full, err := d.check(name)
+	if err != nil {
+		return err
+	}
+	if err := d.dir.Mkdir(ctx, name, perm); err != nil {
+		return err
+	}
+	d.chown(full)
+	return nil
+}
+
+// OpenFile opens a file inside the root
+func (d *ConfinedDir) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	full, err := d.check(name)
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Lstat(full)
+	f, err := d.dir.OpenFile(ctx, name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	if flag&os.O_CREATE != 0 && os.IsNotExist(statErr) {
+		d.chown(full)
+	}
+	return f, nil
+}
+
+// RemoveAll removes a path inside the root
+func (d *ConfinedDir) RemoveAll(ctx context.Context, name string) error {
+	if _, err := d.check(name); err != nil {
+		return err
+	}
+	return d.dir.RemoveAll(ctx, name)
+}
+
+// Rename moves a path within the root
+func (d *ConfinedDir) Rename(ctx context.Context, oldName, newName string) error {
+	if _, err := d.check(oldName); err != nil {
+		return err
+	}
+	if _, err := d.check(newName); err != nil {
+		return err
+	}
+	return d.dir.Rename(ctx, oldName, newName)
+}
+
+// Stat describes a path inside the root
+func (d *ConfinedDir) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	if _, err := d.check(name); err != nil {
+		return nil, err
+	}
+	return d.dir.Stat(ctx, name)
+}
@@ -229,6 +229,7 @@ services:
       - AUTHENTIK_ERROR_REPORTING__ENABLED=${ERROR_REPORTING:-false}
       - AUTHENTIK_BOOTSTRAP_EMAIL=${ADMIN_EMAIL}
       - AUTHENTIK_BOOTSTRAP_PASSWORD=${ADMIN_PASSWORD}
+      - AUTHENTIK_BOOTSTRAP_TOKEN=${API_TOKEN}
     volumes:
       - authentik_media:/media
       - authentik_templates:/templates
@@ -258,6 +259,7 @@ services:
       - AUTHENTIK_POSTGRESQL__PASSWORD=${PG_PASS:-authentik}
       - AUTHENTIK_SECRET_KEY=${AUTHENTIK_SECRET_KEY}
       - AUTHENTIK_ERROR_REPORTING__ENABLED=${ERROR_REPORTING:-false}
+      - AUTHENTIK_BOOTSTRAP_TOKEN=${API_TOKEN}
     volumes:
       - authentik_media:/media
       - authentik_templates:/templates
@@ -279,6 +281,7 @@ volumes:
 		"AUTHENTIK_SECRET_KEY": "",
 		"ADMIN_EMAIL":          "",
 		"ADMIN_PASSWORD":       "",
+		"API_TOKEN":            "",
 		"AUTHENTIK_PORT":       "9000",
 		"AUTHENTIK_HTTPS_PORT": "9443",
 		"ERROR_REPORTING":      "false",
@@ -288,6 +291,7 @@ volumes:
 		"AUTHENTIK_SECRET_KEY": "Secret key for encryption (auto-generated if empty)",
 		"ADMIN_EMAIL":          "Initial admin user email",
 		"ADMIN_PASSWORD":       "Initial admin user password",
+		"API_TOKEN":            "API token Stardeck creates clients with (auto-generated if empty)",
 		"AUTHENTIK_PORT":       "HTTP port for Authentik",
 		"AUTHENTIK_HTTPS_PORT": "HTTPS port for Authentik",
 		"ERROR_REPORTING":      "Enable anonymous error reporting to Authentik",
@@ -104,24 +104,19 @@ func main() {
 
 	e := echo.New()
 	e.HideBanner = true
+	e.IPExtractor = api.ClientIP // Forwarded addresses count only from trusted proxies
+	e.Pre(api.ReverseProxyMiddleware())
 
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOriginFunc: func(origin string) (bool, error) {
-			// Allow all origins in development
-			// TODO: Restrict this in production
-			return true, nil
-		},
-		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-CSRF-Token", "Upgrade", "Connection", "Sec-WebSocket-Key", "Sec-WebSocket-Version"},
-		AllowCredentials: true,
-	}))
+	e.Use(api.IngressMiddleware())
+	e.Use(api.CORSMiddleware()) // Trusted origins come from settings
 
 	// API routes
 	apiGroup := e.Group("/api")
 	api.RegisterRoutes(apiGroup, authSvc)
+	e.Use(api.HSTSMiddleware())
 
 	// Serve embedded frontend in production with proper handling for Next.js static export
 	frontendContent, err := fs.Sub(frontendFS, "frontend_dist")
@@ -130,11 +125,8 @@ func main() {
 		e.GET("/*", echo.WrapHandler(handler))
 	}
 
-	// Get port from environment or default
-	port := os.Getenv("STARDECK_PORT")
-	if port == "" {
-		port = "443"
-	}
+	// Listen addresses come from settings, with STARDECK_PORT as the default port
+	port := api.ListenPort()
 
 	// Get cert directory (next to database or from env)
 	certDir := os.Getenv("STARDECK_CERT_DIR")
@@ -146,8 +138,8 @@ func main() {
 	useHTTP := os.Getenv("STARDECK_USE_HTTP") == "true"
 
 	if useHTTP {
-		log.Printf("Starting Stardeck backend on HTTP port %s (insecure mode)", port)
-		e.Logger.Fatal(e.Start(":" + port))
+		log.Printf("Using insecure HTTP mode")
+		e.Logger.Fatal(api.Serve(e, nil))
 	} else {
 		// Ensure TLS certificates exist
 		certPath, keyPath, err := certs.EnsureCertificates(certDir)
@@ -155,8 +147,13 @@ func main() {
 			log.Fatalf("Failed to setup TLS certificates: %v", err)
 		}
 		log.Printf("Using TLS certificates from %s", certDir)
-		log.Printf("Starting Stardeck backend on HTTPS port %s", port)
-		e.Logger.Fatal(e.StartTLS(":"+port, certPath, keyPath))
+
+		// Min version, cipher suites, HSTS and the HTTP redirect come from settings
+		tlsConfig, err := api.NewTLSConfig(certPath, keyPath, port)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+		e.Logger.Fatal(api.Serve(e, tlsConfig))
 	}
 }
 